@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	activityDays     int
+	activityGroupBy  string
+	activityLimit    int32
+	activityDepth    int32
+	activityTimeZone string
+)
+
+// ActivityOutput is the machine-readable form of an activity group
+type ActivityOutput struct {
+	Key          string `json:"key"`
+	Commits      int64  `json:"commits"`
+	Authors      int64  `json:"authors"`
+	FilesChanged int64  `json:"filesChanged"`
+	Insertions   int64  `json:"insertions"`
+	Deletions    int64  `json:"deletions"`
+	BytesAdded   int64  `json:"bytesAdded"`
+}
+
+func activityOutput(group *pb.ActivityGroup) ActivityOutput {
+	return ActivityOutput{
+		Key:          group.Key,
+		Commits:      group.Commits,
+		Authors:      group.Authors,
+		FilesChanged: group.FilesChanged,
+		Insertions:   group.Insertions,
+		Deletions:    group.Deletions,
+		BytesAdded:   group.BytesAdded,
+	}
+}
+
+var activityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Show repository activity",
+	Long: `Show the versions created over the last days: commits per day, week or
+month, or the most active authors or directories.
+
+Examples:
+  poon activity                         # Commits per day over 30 days
+  poon activity --days 365 --by month
+  poon activity --by directory --depth 2`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if activityDays <= 0 {
+			return fmt.Errorf("--days must be positive")
+		}
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureActivity) {
+			return fmt.Errorf("server %s does not report activity", serverAddr)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		end := time.Now()
+		resp, err := client.GetActivity(ctx, &pb.GetActivityRequest{
+			Start:          end.AddDate(0, 0, -activityDays).Unix(),
+			End:            end.Unix(),
+			GroupBy:        activityGroupBy,
+			TimeZone:       activityTimeZone,
+			Limit:          activityLimit,
+			DirectoryDepth: activityDepth,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get activity: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := struct {
+				Groups []ActivityOutput `json:"groups"`
+				Total  ActivityOutput   `json:"total"`
+			}{Groups: []ActivityOutput{}}
+			for _, group := range resp.Groups {
+				out.Groups = append(out.Groups, activityOutput(group))
+			}
+			if resp.Total != nil {
+				out.Total = activityOutput(resp.Total)
+			}
+			return printJSON(out)
+		}
+
+		width := len("Total")
+		for _, group := range resp.Groups {
+			width = max(width, len(group.Key))
+		}
+		for _, group := range resp.Groups {
+			fmt.Println(formatActivity(group, width))
+		}
+		if resp.Total != nil {
+			resp.Total.Key = "Total"
+			fmt.Println(formatActivity(resp.Total, width))
+		}
+		return nil
+	},
+}
+
+func formatActivity(group *pb.ActivityGroup, width int) string {
+	return fmt.Sprintf("%-*s  %5d %s  %4d %s  %5d %s  +%d -%d",
+		width, group.Key,
+		group.Commits, plural(int(group.Commits), "commit", "commits"),
+		group.Authors, plural(int(group.Authors), "author", "authors"),
+		group.FilesChanged, plural(int(group.FilesChanged), "file", "files"),
+		group.Insertions, group.Deletions)
+}
+
+func init() {
+	activityCmd.Flags().IntVar(&activityDays, "days", 30, "How many days back to look")
+	activityCmd.Flags().StringVar(&activityGroupBy, "by", "day", "Group by day, week, month, author or directory")
+	activityCmd.Flags().Int32Var(&activityLimit, "limit", 10, "Most authors or directories to show")
+	activityCmd.Flags().Int32Var(&activityDepth, "depth", 1, "Path components to group directories at")
+	activityCmd.Flags().StringVar(&activityTimeZone, "tz", "", "IANA time zone days start in (default UTC)")
+	rootCmd.AddCommand(activityCmd)
+}
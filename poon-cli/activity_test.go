@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+func TestFormatActivity(t *testing.T) {
+	group := &pb.ActivityGroup{Key: "2024-06-03", Commits: 1, Authors: 2, FilesChanged: 14, Insertions: 120, Deletions: 7}
+	want := "2024-06-03      1 commit     2 authors     14 files  +120 -7"
+	if got := formatActivity(group, 10); got != want {
+		t.Errorf("formatActivity() = %q, want %q", got, want)
+	}
+
+	// Keys are padded to the widest one
+	want = "Total           0 commits     0 authors      0 files  +0 -0"
+	if got := formatActivity(&pb.ActivityGroup{Key: "Total"}, 10); got != want {
+		t.Errorf("formatActivity() = %q, want %q", got, want)
+	}
+}
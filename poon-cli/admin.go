@@ -0,0 +1,727 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminServerAddr string
+	adminDryRun     bool
+	adminMaxIdle    time.Duration
+	adminGrace      time.Duration
+	adminRepaired   bool
+	adminForce      bool
+
+	// Filters for `poon admin workspaces`
+	adminStale    time.Duration
+	adminDiverged bool
+	adminOwner    string
+
+	// Retention policy for `poon admin prune`
+	adminKeepDays     int32
+	adminKeepTagged   bool
+	adminKeepVersions []int64
+
+	// Limits passed to `poon admin set-quota`
+	adminWorkspaceBytes int64
+	adminUserBytes      int64
+	adminWorkspaces     int64
+	adminFileBytes      int64
+	adminPathBytes      int64
+	adminAllowOverride  bool
+	adminDiskBytes      int64
+
+	// History kept by `poon admin compact`
+	adminDepth int64
+)
+
+// dialAdmin dials the server's admin address using POON_ADMIN_TOKEN.
+// Admin credentials are separate from the user tokens stored by 'poon login'.
+func dialAdmin() (*poonclient.Client, error) {
+	token := os.Getenv("POON_ADMIN_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("POON_ADMIN_TOKEN is not set")
+	}
+
+	opts := poonclient.DefaultOptions()
+	opts.Token = token
+	opts.UserAgent = "poon-cli/" + clientVersion
+	return poonclient.NewWithOptions(adminServerAddr, opts)
+}
+
+// connectAdmin returns an admin API client and a function closing it
+func connectAdmin() (pb.MonorepoAdminServiceClient, func(), error) {
+	c, err := dialAdmin()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.GetAdminClient(), func() { c.Close() }, nil
+}
+
+// runAdmin connects to the admin API and runs fn with a bounded context
+func runAdmin(fn func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error) error {
+	admin, closeConn, err := connectAdmin()
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	return fn(ctx, admin)
+}
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Run server maintenance operations",
+	Long: `Run server maintenance operations against the admin API.
+
+The admin API listens on a separate address (--admin-server) and requires
+an admin token in POON_ADMIN_TOKEN.`,
+}
+
+var adminStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show backend object counts and storage usage",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.GetBackendStats(ctx, &pb.BackendStatsRequest{})
+			if err != nil {
+				return fmt.Errorf("failed to get backend stats: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			fmt.Printf("Current version: %d (%d versions)\n", resp.CurrentVersion, resp.Versions)
+			fmt.Printf("Objects:         %d (%d blobs, %d trees, %d commits)\n", resp.Objects, resp.Blobs, resp.Trees, resp.Commits)
+			fmt.Printf("Object bytes:    %d\n", resp.ObjectBytes)
+			fmt.Printf("Backend keys:    %d (%d bytes)\n", resp.Keys, resp.TotalBytes)
+			fmt.Printf("Workspaces:      %d\n", resp.Workspaces)
+			fmt.Printf("Locks:           %d\n", resp.Locks)
+			if resp.ArchiveCacheMaxBytes > 0 {
+				fmt.Printf("Archive cache:   %d archives, %d of %d bytes (%d hits, %d misses)\n",
+					resp.ArchiveCacheEntries, resp.ArchiveCacheBytes, resp.ArchiveCacheMaxBytes, resp.ArchiveCacheHits, resp.ArchiveCacheMisses)
+			}
+			return nil
+		})
+	},
+}
+
+var adminGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete objects not reachable from any version",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.RunGarbageCollection(ctx, &pb.GarbageCollectionRequest{DryRun: adminDryRun, Async: true})
+			if err != nil {
+				return fmt.Errorf("garbage collection failed: %w", err)
+			}
+			if err := awaitResponse(admin.WaitOperation, resp.OperationId, progressLabel("Scanning objects"), resp); err != nil {
+				return fmt.Errorf("garbage collection failed: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			verb := "Removed"
+			if adminDryRun {
+				verb = "Would remove"
+			}
+			fmt.Printf("✓ Scanned %d objects, %d reachable\n", resp.Scanned, resp.Reachable)
+			fmt.Printf("%s %d objects (%d bytes)\n", verb, resp.Removed, resp.BytesFreed)
+			return nil
+		})
+	},
+}
+
+var adminFsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Verify stored objects and version references",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.Fsck(ctx, &pb.FsckRequest{Async: true})
+			if err != nil {
+				return fmt.Errorf("fsck failed: %w", err)
+			}
+			if err := awaitResponse(admin.WaitOperation, resp.OperationId, progressLabel("Verifying objects"), resp); err != nil {
+				return fmt.Errorf("fsck failed: %w", err)
+			}
+
+			if isJSONOutput() {
+				if err := printJSON(resp); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("Checked %d objects and %d versions\n", resp.ObjectsChecked, resp.VersionsChecked)
+				if resp.HashAlgorithm != "" {
+					fmt.Printf("New objects are hashed with %s\n", resp.HashAlgorithm)
+				}
+				for _, algorithm := range slices.Sorted(maps.Keys(resp.ObjectsByAlgorithm)) {
+					if algorithm != resp.HashAlgorithm {
+						fmt.Printf("%d objects are still hashed with %s; 'poon admin gc' removes those a rehash left behind\n", resp.ObjectsByAlgorithm[algorithm], algorithm)
+					}
+				}
+				if resp.HashFormat != "" {
+					fmt.Printf("New trees and commits are hashed in the %s format\n", resp.HashFormat)
+				}
+				for _, format := range slices.Sorted(maps.Keys(resp.ObjectsByFormat)) {
+					if format != resp.HashFormat {
+						fmt.Printf("%d trees and commits are still hashed in the %s format; 'poon-server rehash' moves them to %s\n", resp.ObjectsByFormat[format], format, resp.HashFormat)
+					}
+				}
+				for _, problem := range resp.Problems {
+					fmt.Printf("✗ %s\n", problem)
+				}
+			}
+
+			if len(resp.Problems) > 0 {
+				return fmt.Errorf("fsck found %d problems", len(resp.Problems))
+			}
+			if !isJSONOutput() {
+				fmt.Println("✓ No problems found")
+			}
+			return nil
+		})
+	},
+}
+
+var adminMigrateCmd = &cobra.Command{
+	Use:   "migrate <destination>",
+	Short: "Copy all objects and version metadata to another backend",
+	Long: `Copy every object and the version metadata to another storage backend, a
+directory on the server host or s3://bucket/prefix, while the server keeps
+running. Running it again after a failure only copies what is still missing.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.MigrateBackend(ctx, &pb.MigrateBackendRequest{Destination: args[0], Async: true})
+			if err != nil {
+				return fmt.Errorf("migration failed: %w", err)
+			}
+			if err := awaitResponse(admin.WaitOperation, resp.OperationId, progressLabel("Copying objects"), resp); err != nil {
+				return fmt.Errorf("migration failed: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+			fmt.Printf("✓ Copied %d of %d objects and %d metadata keys (%d bytes)\n", resp.ObjectsCopied, resp.Objects, resp.Metadata, resp.BytesCopied)
+			if resp.MetadataRemoved > 0 {
+				fmt.Printf("Removed %d stale metadata keys from the destination\n", resp.MetadataRemoved)
+			}
+			return nil
+		})
+	},
+}
+
+var adminOperationCmd = &cobra.Command{
+	Use:   "operation <id>",
+	Short: "Show a long-running server operation of any user",
+	Long: `Show a long-running server operation, including those admin commands such
+as gc, fsck and migrate start. With --wait, follow it until it is done; with
+--cancel, stop it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		admin, closeConn, err := connectAdmin()
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+
+		id := args[0]
+		return showOperation(id,
+			func(ctx context.Context) (*pb.Operation, error) {
+				resp, err := admin.GetOperation(ctx, &pb.GetOperationRequest{OperationId: id})
+				if err != nil {
+					return nil, fmt.Errorf("failed to get operation: %w", err)
+				}
+				return resp.Operation, nil
+			},
+			admin.WaitOperation,
+			func(ctx context.Context) (string, error) {
+				resp, err := admin.CancelOperation(ctx, &pb.CancelOperationRequest{OperationId: id})
+				if err != nil {
+					return "", fmt.Errorf("failed to cancel operation: %w", err)
+				}
+				return resp.Message, nil
+			})
+	},
+}
+
+var adminOperationsCmd = &cobra.Command{
+	Use:   "operations",
+	Short: "List the long-running server operations of every user",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.ListOperations(ctx, &pb.ListOperationsRequest{RunningOnly: operationRunning})
+			if err != nil {
+				return fmt.Errorf("failed to list operations: %w", err)
+			}
+			if isJSONOutput() {
+				return printJSON(resp.Operations)
+			}
+			printOperations(resp.Operations)
+			return nil
+		})
+	},
+}
+
+var adminCorruptCmd = &cobra.Command{
+	Use:   "corrupt",
+	Short: "List objects reads found corrupt",
+	Long: `List the objects the server found not matching their hash when reading
+them. With REPAIR_SOURCE set the server replaces each with a copy from the
+backup or replica that verifies; those are listed only with --repaired.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.ListCorruptObjects(ctx, &pb.ListCorruptObjectsRequest{IncludeRepaired: adminRepaired})
+			if err != nil {
+				return fmt.Errorf("failed to list corrupt objects: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			if len(resp.Objects) == 0 {
+				fmt.Println("✓ No corrupt objects found")
+				return nil
+			}
+			for _, object := range resp.Objects {
+				state := "✗ corrupt"
+				if object.RepairedAt != 0 {
+					state = "✓ repaired " + time.Unix(object.RepairedAt, 0).Format(time.RFC3339)
+				}
+				fmt.Printf("%s  %s  (%d %s, last %s)\n", object.Hash, state,
+					object.Detections, plural(int(object.Detections), "failed read", "failed reads"),
+					time.Unix(object.LastSeenAt, 0).Format(time.RFC3339))
+				fmt.Printf("  %s\n", object.Error)
+				if object.RepairError != "" {
+					fmt.Printf("  not repaired: %s\n", object.RepairError)
+				}
+			}
+			return nil
+		})
+	},
+}
+
+func printReplicas(replicas []*pb.ReplicaStatus) {
+	for _, replica := range replicas {
+		state := "replica"
+		if replica.Active {
+			state = "active"
+		}
+		fmt.Printf("%s  (%s)", replica.Name, state)
+		if !replica.Active {
+			fmt.Printf("  %d pending, %s behind", replica.Pending, time.Duration(replica.LagSeconds*float64(time.Second)).Round(time.Second))
+			if replica.Resync {
+				fmt.Printf(", resyncing")
+			}
+		}
+		fmt.Printf("  %d mirrored, %d failures\n", replica.Replicated, replica.Failures)
+		if replica.LastError != "" {
+			fmt.Printf("  ✗ %s\n", replica.LastError)
+		}
+	}
+}
+
+var adminReplicationCmd = &cobra.Command{
+	Use:   "replication",
+	Short: "Show how far storage replicas are behind",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.GetReplicationStatus(ctx, &pb.GetReplicationStatusRequest{})
+			if err != nil {
+				return fmt.Errorf("failed to get replication status: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+			if len(resp.Replicas) == 0 {
+				fmt.Println("The storage backend has no replicas")
+				return nil
+			}
+			printReplicas(resp.Replicas)
+			return nil
+		})
+	},
+}
+
+var adminFailoverCmd = &cobra.Command{
+	Use:   "failover <replica>",
+	Short: "Make a storage replica the active backend",
+	Long: `Make a replica, named by its location as 'poon admin replication' lists it,
+the backend the server reads and writes; the other backends, the old primary
+included, mirror it from then on. A replica with writes not yet mirrored is
+refused unless --force, which gives those writes up.
+
+The failover is saved in the backends and kept when the server restarts
+with the same STORAGE_BACKEND and REPLICA_BACKENDS.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.FailoverBackend(ctx, &pb.FailoverBackendRequest{Name: args[0], Force: adminForce})
+			if err != nil {
+				return fmt.Errorf("failover failed: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+			fmt.Printf("✓ Storage now reads and writes %s\n", args[0])
+			printReplicas(resp.Replicas)
+			return nil
+		})
+	},
+}
+
+var adminResyncCmd = &cobra.Command{
+	Use:   "resync <replica>",
+	Short: "Compare every key of a storage replica with the active backend",
+	Long: `Compare every key of a replica, named by its location as 'poon admin
+replication' lists it, with the active backend and copy those that differ,
+in the background. The server resyncs on its own after a crash or when a
+replica falls too far behind; use this after a replica was restored or
+changed by hand.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.ResyncReplica(ctx, &pb.ResyncReplicaRequest{Name: args[0]})
+			if err != nil {
+				return fmt.Errorf("resync failed: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+			fmt.Printf("✓ Resyncing %s\n", args[0])
+			printReplicas(resp.Replicas)
+			return nil
+		})
+	},
+}
+
+var adminWorkspacesCmd = &cobra.Command{
+	Use:   "workspaces",
+	Short: "List workspaces with their last reported client status",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.ListWorkspaces(ctx, &pb.ListWorkspacesRequest{
+				StaleSeconds: int64(adminStale.Seconds()),
+				DivergedOnly: adminDiverged,
+				Owner:        adminOwner,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			if len(resp.Workspaces) == 0 {
+				fmt.Println("No workspaces found")
+				return nil
+			}
+			for _, workspace := range resp.Workspaces {
+				state := "clean"
+				if workspace.Diverged {
+					state = fmt.Sprintf("diverged, %d dirty files", workspace.DirtyFiles)
+				}
+				if workspace.LastReport == "" {
+					state = "never reported"
+				}
+				fmt.Printf("%s  owner: %s  last sync: %s  (%s)\n", workspace.Id, workspace.Owner, workspace.LastSync, state)
+				fmt.Printf("  %d bytes on disk, %d %s\n", workspace.DiskBytes, workspace.Commits, plural(int(workspace.Commits), "commit", "commits"))
+				if workspace.ClientVersion != "" {
+					fmt.Printf("  client %s at %s\n", workspace.ClientVersion, workspace.HeadCommit)
+				}
+			}
+			return nil
+		})
+	},
+}
+
+var adminPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old versions under a retention policy",
+	Long: `Remove the versions the retention policy does not keep: those older than
+--keep-days, unless a tag pins them (--keep-tagged) or they are listed with
+--keep-version. The current version is always kept, as are the versions
+workspaces are synced to and the ones the trash restores deleted files from.
+
+Retained versions keep their numbers and content. Their commits are rewritten
+so the oldest retained version becomes a checkpoint without history and each
+gap is squashed into the version after it. Run 'poon admin gc' afterwards to
+free the objects only pruned versions used.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.PruneHistory(ctx, &pb.PruneHistoryRequest{
+				KeepDays:     adminKeepDays,
+				KeepTagged:   adminKeepTagged,
+				KeepVersions: adminKeepVersions,
+				DryRun:       adminDryRun,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to prune history: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			verb := "Pruned"
+			if adminDryRun {
+				verb = "Would prune"
+			}
+			fmt.Printf("%s %d versions, keeping %d from version %d on (%d commits rewritten)\n",
+				verb, resp.Pruned, resp.Kept, resp.OldestKept, resp.Rewritten)
+			if !adminDryRun && resp.Pruned > 0 {
+				fmt.Println("  Run 'poon admin gc' to remove the objects they used")
+			}
+			return nil
+		})
+	},
+}
+
+var adminReapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "Delete workspaces that have been idle for too long",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.ReapWorkspaces(ctx, &pb.ReapWorkspacesRequest{
+				MaxIdleSeconds: int64(adminMaxIdle.Seconds()),
+				DryRun:         adminDryRun,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to reap workspaces: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			verb := "Reaped"
+			if adminDryRun {
+				verb = "Would reap"
+			}
+			fmt.Printf("%s %d workspaces\n", verb, len(resp.WorkspaceIds))
+			for _, id := range resp.WorkspaceIds {
+				fmt.Printf("  %s\n", id)
+			}
+			return nil
+		})
+	},
+}
+
+var adminWorkspaceGCCmd = &cobra.Command{
+	Use:   "workspace-gc",
+	Short: "Delete workspace directories on the server that no workspace owns",
+	Long: `Delete directories in the server's workspace root that no workspace owns,
+such as those left by deleted workspaces, once they have not changed for the
+grace period, then the objects workspaces share that none of them uses any
+more. The server also does this on startup and every WORKSPACE_GC_INTERVAL;
+use --dry-run to see what it would remove.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.CollectWorkspaceDirectories(ctx, &pb.CollectWorkspaceDirectoriesRequest{
+				DryRun:       adminDryRun,
+				GraceSeconds: int64(adminGrace.Seconds()),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to collect workspace directories: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			verb := "Removed"
+			if adminDryRun {
+				verb = "Would remove"
+			}
+			fmt.Printf("%s %d workspace directories (%d bytes)\n", verb, len(resp.Directories), resp.BytesFreed)
+			for _, dir := range resp.Directories {
+				fmt.Printf("  %s  last changed %s  (%d bytes)\n", dir.Name, dir.ModifiedAt, dir.Bytes)
+			}
+			if resp.SharedObjects > 0 {
+				fmt.Printf("%s %d shared objects no workspace uses (%d bytes)\n", verb, resp.SharedObjects, resp.SharedBytesFreed)
+			}
+			return nil
+		})
+	},
+}
+
+var adminCompactCmd = &cobra.Command{
+	Use:   "compact [workspace]",
+	Short: "Cut workspace repository history down to the last commits",
+	Long: `Cut the history of a workspace repository on the server, or of every one,
+down to its last --depth commits and delete the objects only older commits
+used. Commit hashes stay the same, so clients keep pulling and pushing as
+before. Without --depth the server's WORKSPACE_HISTORY_DEPTH applies, and
+workspaces over their disk limit keep one commit.
+
+The server also does this every WORKSPACE_GC_INTERVAL when
+WORKSPACE_HISTORY_DEPTH is set.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceID := ""
+		if len(args) == 1 {
+			workspaceID = args[0]
+		}
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{
+				WorkspaceId: workspaceID,
+				Depth:       adminDepth,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to compact workspaces: %w", err)
+			}
+
+			if isJSONOutput() {
+				return printJSON(resp)
+			}
+
+			compacted := 0
+			for _, workspace := range resp.Workspaces {
+				if workspace.Depth > 0 {
+					compacted++
+				}
+			}
+			fmt.Printf("Compacted %d of %d workspaces (%d bytes freed)\n", compacted, len(resp.Workspaces), resp.BytesFreed)
+			for _, workspace := range resp.Workspaces {
+				if workspace.Depth == 0 {
+					fmt.Printf("  %s  %d %s, %d bytes\n", workspace.WorkspaceId, workspace.CommitsAfter, plural(int(workspace.CommitsAfter), "commit", "commits"), workspace.BytesAfter)
+					continue
+				}
+				fmt.Printf("  %s  %d → %d commits, %d → %d bytes\n", workspace.WorkspaceId,
+					workspace.CommitsBefore, workspace.CommitsAfter, workspace.BytesBefore, workspace.BytesAfter)
+			}
+			return nil
+		})
+	},
+}
+
+var adminUnlockCmd = &cobra.Command{
+	Use:   "unlock <path>",
+	Short: "Remove a path lock regardless of its owner",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.ForceUnlockPath(ctx, &pb.ForceUnlockPathRequest{Path: args[0]})
+			if err != nil {
+				return fmt.Errorf("failed to unlock path: %w", err)
+			}
+			if !resp.Success {
+				return fmt.Errorf("%s", resp.Message)
+			}
+
+			fmt.Printf("✓ %s\n", resp.Message)
+			return nil
+		})
+	},
+}
+
+var adminSetQuotaCmd = &cobra.Command{
+	Use:   "set-quota <user>",
+	Short: "Set quota limits for a user (0 means unlimited)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdmin(func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.SetUserQuota(ctx, &pb.SetUserQuotaRequest{
+				User:                args[0],
+				MaxWorkspaceBytes:   adminWorkspaceBytes,
+				MaxUserBytes:        adminUserBytes,
+				MaxUserWorkspaces:   adminWorkspaces,
+				MaxFileBytes:        adminFileBytes,
+				MaxTrackedPathBytes: adminPathBytes,
+				AllowSizeOverride:   adminAllowOverride,
+
+				MaxWorkspaceDiskBytes: adminDiskBytes,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to set quota: %w", err)
+			}
+			if !resp.Success {
+				return fmt.Errorf("%s", resp.Message)
+			}
+
+			fmt.Printf("✓ %s\n", resp.Message)
+			return nil
+		})
+	},
+}
+
+func init() {
+	adminCmd.PersistentFlags().StringVar(&adminServerAddr, "admin-server", "localhost:50052", "Admin API address")
+
+	adminGCCmd.Flags().BoolVar(&adminDryRun, "dry-run", false, "Report what would be removed without deleting")
+	adminWorkspacesCmd.Flags().DurationVar(&adminStale, "stale", 0, "Only list workspaces not synced for this long")
+	adminWorkspacesCmd.Flags().BoolVar(&adminDiverged, "diverged", false, "Only list workspaces with local changes or commits")
+	adminWorkspacesCmd.Flags().StringVar(&adminOwner, "owner", "", "Only list workspaces owned by this user")
+	adminPruneCmd.Flags().BoolVar(&adminDryRun, "dry-run", false, "Report what would be pruned without changing anything")
+	adminPruneCmd.Flags().Int32Var(&adminKeepDays, "keep-days", 90, "Keep versions created in the last N days (0 keeps none for their age)")
+	adminPruneCmd.Flags().BoolVar(&adminKeepTagged, "keep-tagged", true, "Keep every version a tag pins")
+	adminPruneCmd.Flags().Int64SliceVar(&adminKeepVersions, "keep-version", nil, "Also keep this version (repeatable)")
+	adminReapCmd.Flags().BoolVar(&adminDryRun, "dry-run", false, "Report what would be reaped without deleting")
+	adminReapCmd.Flags().DurationVar(&adminMaxIdle, "max-idle", 30*24*time.Hour, "Reap workspaces not synced for this long")
+	adminWorkspaceGCCmd.Flags().BoolVar(&adminDryRun, "dry-run", false, "Report what would be removed without deleting")
+	adminOperationCmd.Flags().BoolVar(&operationWait, "wait", false, "Wait until the operation is done")
+	adminOperationCmd.Flags().BoolVar(&operationCancel, "cancel", false, "Cancel the operation")
+	adminOperationsCmd.Flags().BoolVar(&operationRunning, "running", false, "Only list operations still running")
+	adminFailoverCmd.Flags().BoolVar(&adminForce, "force", false, "Fail over even if the replica has not mirrored every write")
+	adminCorruptCmd.Flags().BoolVar(&adminRepaired, "repaired", false, "Also list objects that were repaired")
+	adminWorkspaceGCCmd.Flags().DurationVar(&adminGrace, "grace", 0, "Keep directories changed this recently (default: the server's WORKSPACE_GC_GRACE)")
+	adminCompactCmd.Flags().Int64Var(&adminDepth, "depth", 0, "Commits to keep (default: the server's WORKSPACE_HISTORY_DEPTH)")
+	adminSetQuotaCmd.Flags().Int64Var(&adminWorkspaceBytes, "workspace-bytes", 0, "Maximum bytes per workspace")
+	adminSetQuotaCmd.Flags().Int64Var(&adminUserBytes, "user-bytes", 0, "Maximum bytes across the user's workspaces")
+	adminSetQuotaCmd.Flags().Int64Var(&adminWorkspaces, "workspaces", 0, "Maximum number of workspaces")
+	adminSetQuotaCmd.Flags().Int64Var(&adminFileBytes, "file-bytes", 0, "Maximum size of any file in a tracked path")
+	adminSetQuotaCmd.Flags().Int64Var(&adminPathBytes, "tracked-path-bytes", 0, "Maximum bytes under a single tracked path")
+	adminSetQuotaCmd.Flags().Int64Var(&adminDiskBytes, "workspace-disk-bytes", 0, "Maximum disk per workspace repository, history included")
+	adminSetQuotaCmd.Flags().BoolVar(&adminAllowOverride, "allow-size-override", false, "Let the user skip the file and tracked path limits with --override-size-limits")
+
+	adminCmd.AddCommand(adminStatsCmd)
+	adminCmd.AddCommand(adminGCCmd)
+	adminCmd.AddCommand(adminFsckCmd)
+	adminCmd.AddCommand(adminMigrateCmd)
+	adminCmd.AddCommand(adminOperationCmd)
+	adminCmd.AddCommand(adminOperationsCmd)
+	adminCmd.AddCommand(adminCorruptCmd)
+	adminCmd.AddCommand(adminReplicationCmd)
+	adminCmd.AddCommand(adminFailoverCmd)
+	adminCmd.AddCommand(adminResyncCmd)
+	adminCmd.AddCommand(adminWorkspacesCmd)
+	adminCmd.AddCommand(adminReapCmd)
+	adminCmd.AddCommand(adminPruneCmd)
+	adminCmd.AddCommand(adminWorkspaceGCCmd)
+	adminCmd.AddCommand(adminCompactCmd)
+	adminCmd.AddCommand(adminUnlockCmd)
+	adminCmd.AddCommand(adminSetQuotaCmd)
+	rootCmd.AddCommand(adminCmd)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	affectedFrom  int64
+	affectedTo    int64
+	affectedDepth int32
+)
+
+// AffectedPathOutput is a path prefix in the result of `poon affected`
+type AffectedPathOutput struct {
+	Path         string `json:"path"`
+	FilesChanged int32  `json:"filesChanged"`
+}
+
+// AffectedOutput is the machine-readable result of `poon affected`
+type AffectedOutput struct {
+	FromVersion  int64                `json:"fromVersion"`
+	ToVersion    int64                `json:"toVersion"`
+	FilesChanged int32                `json:"filesChanged"`
+	Paths        []AffectedPathOutput `json:"paths"`
+}
+
+var affectedCmd = &cobra.Command{
+	Use:   "affected --from <version> [--to <version>]",
+	Short: "List the paths changed between two versions",
+	Long: `List the path prefixes containing files changed after --from, up to and
+including --to (default: the current version). Prefixes are top-level
+directories unless --depth asks for more components; files shallower than
+the depth are listed by their full path.
+
+Meant for CI systems deciding which pipelines to run after a merge, for
+example with -o json.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("from") {
+			return fmt.Errorf("--from is required")
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		resp, err := client.GetAffectedPaths(ctx, &pb.GetAffectedPathsRequest{
+			FromVersion: affectedFrom,
+			ToVersion:   affectedTo,
+			Depth:       affectedDepth,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get affected paths: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := AffectedOutput{
+				FromVersion:  resp.FromVersion,
+				ToVersion:    resp.ToVersion,
+				FilesChanged: resp.FilesChanged,
+				Paths:        []AffectedPathOutput{},
+			}
+			for _, p := range resp.Paths {
+				out.Paths = append(out.Paths, AffectedPathOutput{Path: p.Path, FilesChanged: p.FilesChanged})
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Paths) == 0 {
+			fmt.Printf("✓ No changes between versions %d and %d\n", resp.FromVersion, resp.ToVersion)
+			return nil
+		}
+
+		fmt.Printf("✓ %d files changed between versions %d and %d:\n", resp.FilesChanged, resp.FromVersion, resp.ToVersion)
+		for _, p := range resp.Paths {
+			fmt.Printf("  %s (%d)\n", p.Path, p.FilesChanged)
+		}
+		return nil
+	},
+}
+
+func init() {
+	affectedCmd.Flags().Int64Var(&affectedFrom, "from", 0, "Version to compare from; changes after it are listed (0 for the empty tree)")
+	affectedCmd.Flags().Int64Var(&affectedTo, "to", 0, "Last version to include (default: current)")
+	affectedCmd.Flags().Int32Var(&affectedDepth, "depth", 1, "Path components to keep from each changed file")
+	rootCmd.AddCommand(affectedCmd)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// maxDownloadedPatchBytes bounds a patch fetched from a URL
+const maxDownloadedPatchBytes = 64 << 20
+
+// readPatchSource reads a patch from a file, from standard input for "-",
+// or from an http(s) URL
+func readPatchSource(source string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case source == "-":
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read patch from stdin: %w", err)
+		}
+		return content, nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return downloadPatch(source)
+	default:
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read patch file: %w", err)
+		}
+		return content, nil
+	}
+}
+
+// downloadPatch fetches a patch over HTTP
+func downloadPatch(url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download patch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download patch: %s returned %s", url, resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadedPatchBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download patch: %w", err)
+	}
+	if len(content) > maxDownloadedPatchBytes {
+		return nil, fmt.Errorf("patch at %s is larger than %d bytes", url, maxDownloadedPatchBytes)
+	}
+	return content, nil
+}
+
+// previewApply runs every file of a patch through PreviewPatch before any is
+// merged. The server merges a file at a time, so this is what keeps a file
+// that would not apply from leaving the others landed without it.
+func previewApply(requests []*pb.MergePatchRequest) error {
+	for _, req := range requests {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := client.PreviewPatch(ctx, &pb.PreviewPatchRequest{
+			Path:    req.Path,
+			Patch:   req.Patch,
+			Message: req.Message,
+			Author:  req.Author,
+			Branch:  req.Branch,
+
+			IgnoreWhitespace:        req.IgnoreWhitespace,
+			NormalizeLineEndings:    req.NormalizeLineEndings,
+			PreserveTrailingNewline: req.PreserveTrailingNewline,
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to preview patch to %s: %w", req.Path, err)
+		}
+		if !resp.Success {
+			fmt.Printf("✗ Patch to %s would not apply: %s\n", req.Path, resp.Message)
+			printFailureHints("  ", resp.Failure)
+			return fmt.Errorf("patch not applied: %s would not apply, so no file was changed", req.Path)
+		}
+	}
+	return nil
+}
+
+// partialApplyError reports a patch that stopped at the file failed: the
+// files in landed were merged before it, the ones after it were not
+func partialApplyError(landed []string, failed string, total int, cause error) error {
+	if len(landed) == 0 {
+		return fmt.Errorf("failed to apply patch to %s: %w", failed, cause)
+	}
+	return fmt.Errorf("patch partly applied: %d of %d files landed (%s), then %s failed and the rest were not applied: %w",
+		len(landed), total, strings.Join(landed, ", "), failed, cause)
+}
+
+// splitPatch splits a unified diff into one patch per file, since the
+// server applies patches a file at a time, and names each file from the
+// patch's headers. Text before the first file, such as the mail headers of
+// git format-patch output, is dropped.
+func splitPatch(content []byte) []FilePatch {
+	var patches []FilePatch
+	current := -1            // Index of the file being read
+	var gitHeader bool       // The current file started with "diff --git"
+	var oldLeft, newLeft int // Lines the current hunk still has
+
+	lines := strings.SplitAfter(string(content), "\n")
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+
+		// Hunk lines are counted so content such as "--- x" is not taken
+		// for a header
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, "+"):
+				newLeft--
+			case strings.HasPrefix(text, "\\"):
+			default:
+				oldLeft--
+				newLeft--
+			}
+			patches[current].Patch = append(patches[current].Patch, line...)
+			continue
+		}
+
+		startsFile := strings.HasPrefix(text, "diff --git ")
+		// Without git headers, a file starts at its "---" line
+		if !startsFile && !gitHeader && strings.HasPrefix(text, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			startsFile = true
+		}
+		if startsFile {
+			patches = append(patches, FilePatch{})
+			current = len(patches) - 1
+			gitHeader = strings.HasPrefix(text, "diff --git ")
+			if gitHeader {
+				patches[current].Path = gitDiffTarget(text)
+			}
+		}
+		if current < 0 {
+			continue
+		}
+		patches[current].Patch = append(patches[current].Patch, line...)
+
+		switch {
+		case strings.HasPrefix(text, "--- "):
+			if name := patchFileName(text[4:]); name != "" && patches[current].Path == "" {
+				patches[current].Path = name
+			}
+		case strings.HasPrefix(text, "+++ "):
+			// The new name wins unless the patch deletes the file
+			if name := patchFileName(text[4:]); name != "" {
+				patches[current].Path = name
+			}
+		case strings.HasPrefix(text, "@@ "):
+			oldLeft, newLeft = hunkLengths(text)
+		}
+	}
+	return patches
+}
+
+// gitDiffTarget returns the new name from a "diff --git a/x b/y" line
+func gitDiffTarget(line string) string {
+	names := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.LastIndex(names, " b/"); i >= 0 {
+		return names[i+3:]
+	}
+	return ""
+}
+
+// patchFileName returns the repository path a "---" or "+++" line names,
+// without the a/ or b/ prefix and any timestamp, or "" for /dev/null
+func patchFileName(name string) string {
+	if i := strings.Index(name, "\t"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSpace(name)
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		name = name[2:]
+	}
+	return name
+}
+
+// hunkLengths returns the old and new line counts of a hunk header such as
+// "@@ -1,4 +1,5 @@"; a missing count is 1
+func hunkLengths(header string) (int, int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	length := func(r string) int {
+		_, count, found := strings.Cut(r[1:], ",")
+		if !found {
+			return 1
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+	return length(fields[1]), length(fields[2])
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPatch(t *testing.T) {
+	patch := `From 1234 Mon Sep 17 00:00:00 2001
+Subject: [PATCH] Update docs
+
+diff --git a/docs/README.md b/docs/README.md
+index 1111111..2222222 100644
+--- a/docs/README.md
++++ b/docs/README.md
+@@ -1,3 +1,3 @@
+ # Title
+--- a list item that looks like a header
++++ another one
+ end
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/src/new.go b/src/new.go
+new file mode 100644
+--- /dev/null
++++ b/src/new.go
+@@ -0,0 +1 @@
++package src
+`
+	patches := splitPatch([]byte(patch))
+	var paths []string
+	for _, p := range patches {
+		paths = append(paths, p.Path)
+	}
+	if got, want := strings.Join(paths, ","), "docs/README.md,old.txt,src/new.go"; got != want {
+		t.Fatalf("splitPatch paths = %s, want %s", got, want)
+	}
+	if !strings.HasPrefix(string(patches[0].Patch), "diff --git a/docs/README.md") || !strings.HasSuffix(string(patches[0].Patch), " end\n") {
+		t.Errorf("first patch = %q", patches[0].Patch)
+	}
+	if strings.Contains(string(patches[0].Patch), "Subject") {
+		t.Errorf("mail headers kept in %q", patches[0].Patch)
+	}
+
+	// Plain unified diffs start a file at each ---/+++ pair
+	plain := splitPatch([]byte("--- a/one.txt\t2024-01-01\n+++ b/one.txt\t2024-01-02\n@@ -1 +1 @@\n-a\n+b\n--- two.txt\n+++ two.txt\n@@ -1 +1 @@\n-c\n+d\n"))
+	if len(plain) != 2 || plain[0].Path != "one.txt" || plain[1].Path != "two.txt" {
+		t.Errorf("splitPatch of a plain diff = %+v", plain)
+	}
+
+	if patches := splitPatch([]byte("not a patch\n")); len(patches) != 0 {
+		t.Errorf("splitPatch of text = %+v, want none", patches)
+	}
+}
+
+func TestReadPatchSource(t *testing.T) {
+	content, err := readPatchSource("-", strings.NewReader("from stdin"))
+	if err != nil || string(content) != "from stdin" {
+		t.Errorf("readPatchSource(-) = %q, %v", content, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "fix.patch")
+	if err := os.WriteFile(file, []byte("from a file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content, err = readPatchSource(file, nil)
+	if err != nil || string(content) != "from a file" {
+		t.Errorf("readPatchSource(%s) = %q, %v", file, content, err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fix.patch" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("from a URL"))
+	}))
+	defer server.Close()
+
+	content, err = readPatchSource(server.URL+"/fix.patch", nil)
+	if err != nil || string(content) != "from a URL" {
+		t.Errorf("readPatchSource(URL) = %q, %v", content, err)
+	}
+	if _, err := readPatchSource(server.URL+"/missing.patch", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("readPatchSource of a missing URL: %v", err)
+	}
+}
+
+func TestPartialApplyError(t *testing.T) {
+	cause := errors.New("patch does not apply")
+	err := partialApplyError(nil, "a.txt", 3, cause)
+	if got, want := err.Error(), "failed to apply patch to a.txt: patch does not apply"; got != want {
+		t.Errorf("nothing landed: %q, want %q", got, want)
+	}
+
+	err = partialApplyError([]string{"a.txt", "b.txt"}, "c.txt", 4, cause)
+	if !errors.Is(err, cause) {
+		t.Errorf("%v does not wrap the cause", err)
+	}
+	if got := err.Error(); !strings.Contains(got, "2 of 4 files landed (a.txt, b.txt), then c.txt failed") {
+		t.Errorf("partly landed: %q", got)
+	}
+}
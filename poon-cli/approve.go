@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// signaturePrefix starts every signed payload; the server builds the same
+// payload in signedPatchPayload
+const signaturePrefix = "poon-patch-signature-v1"
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <patch-file>",
+	Short: "Approve a patch for a protected path",
+	Long: `Record your approval of a patch.
+
+Branch protection rules can require approvals from users other than the
+author before a patch touching a protected path is accepted. Approvals are
+tied to the exact patch bytes, so the author must apply the same file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		patchContent, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read patch file: %w", err)
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.ApprovePatch(ctx, &pb.ApprovePatchRequest{
+			Patch:    patchContent,
+			Approver: localUser(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to approve patch: %w", err)
+		}
+
+		if !resp.Success {
+			fmt.Printf("✗ %s\n", resp.Message)
+			return nil
+		}
+		fmt.Printf("✓ %s\n", resp.Message)
+		fmt.Printf("  Approved by: %s\n", strings.Join(resp.Approvers, ", "))
+		return nil
+	},
+}
+
+// signPatch signs a patch and its commit message with the Ed25519 private
+// key in keyFile, a PKCS#8 PEM file such as one written by
+// "openssl genpkey -algorithm ed25519"
+func signPatch(keyFile, message string, patch []byte) ([]byte, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", keyFile)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", keyFile)
+	}
+
+	payload := make([]byte, 0, len(signaturePrefix)+len(message)+len(patch)+2)
+	payload = append(payload, signaturePrefix...)
+	payload = append(payload, 0)
+	payload = append(payload, message...)
+	payload = append(payload, 0)
+	payload = append(payload, patch...)
+	return ed25519.Sign(edKey, payload), nil
+}
+
+func init() {
+	rootCmd.AddCommand(approveCmd)
+}
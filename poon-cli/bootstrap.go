@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nic/poon/poon-cli/pkg/util"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// bootstrapFromArchive sets up a workspace repository in dir from an archive
+// of the server's checked-out commit instead of a clone. The files come from
+// the archive; git then fetches only the commits and trees (a blobless
+// partial clone), so the workspace's content crosses the network once and the
+// server never builds a pack of it. Blobs of the original files are fetched
+// on demand, e.g. by 'git diff' on a changed file.
+func bootstrapFromArchive(workspaceID, remoteURL, dir string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.StreamWorkspaceArchive(ctx, &pb.StreamWorkspaceArchiveRequest{WorkspaceId: workspaceID})
+	if err != nil {
+		return fmt.Errorf("failed to stream workspace archive: %w", err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to stream workspace archive: %w", err)
+	}
+	if first.Commit == "" || first.Branch == "" {
+		return fmt.Errorf("server did not say which commit the archive holds")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	files, err := util.ExtractArchive(&archiveStreamReader{stream: stream, data: first.Data}, dir)
+	if err != nil {
+		return fmt.Errorf("failed to extract workspace archive: %w", err)
+	}
+	fmt.Printf("✓ Extracted %d file(s) at commit %s\n", files, first.Commit[:min(12, len(first.Commit))])
+
+	// Point the branch at the archived commit and build the index from its
+	// tree; refreshing the index hashes the extracted files, so none of
+	// their blobs are fetched
+	branch := first.Branch
+	steps := [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", remoteURL},
+		{"fetch", "-q", "--filter=blob:none", "origin", branch},
+		{"update-ref", "refs/heads/" + branch, first.Commit},
+		{"symbolic-ref", "HEAD", "refs/heads/" + branch},
+		{"branch", "-q", "--set-upstream-to=origin/" + branch},
+		{"reset", "-q"},
+		{"update-index", "-q", "--refresh"},
+	}
+	for _, args := range steps {
+		if err := runCommand("git", append([]string{"-C", dir}, args...)...); err != nil {
+			return fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+// archiveStreamReader reads the data of StreamWorkspaceArchive chunks
+type archiveStreamReader struct {
+	stream pb.MonorepoService_StreamWorkspaceArchiveClient
+	data   []byte
+}
+
+func (r *archiveStreamReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.data = chunk.Data
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const cacheDir = ".poon/cache"
+
+// CacheIndex maps monorepo paths to cached content. File contents are stored
+// once per blob hash under .poon/cache/blobs so identical files share storage.
+type CacheIndex struct {
+	Files       map[string]*CachedFile      `json:"files"`
+	Directories map[string]*CachedDirectory `json:"directories"`
+}
+
+// CachedFile records the blob hash last seen for a file path
+type CachedFile struct {
+	Hash     string    `json:"hash"`
+	CachedAt time.Time `json:"cachedAt"`
+}
+
+// CachedDirectory records the last directory listing seen for a path
+type CachedDirectory struct {
+	Hash     string    `json:"hash,omitempty"` // Tree hash, empty for listings cached by older versions
+	Items    []LsEntry `json:"items"`
+	CachedAt time.Time `json:"cachedAt"`
+}
+
+// cacheEnabled reports whether the current directory is a poon workspace.
+// Outside a workspace there is nowhere sensible to keep cached data.
+func cacheEnabled() bool {
+	_, err := findWorkspaceRoot()
+	return err == nil
+}
+
+// cachePath returns a path inside the cache of the enclosing workspace
+func cachePath(elem ...string) string {
+	root, err := findWorkspaceRoot()
+	if err != nil {
+		root = "."
+	}
+	return filepath.Join(append([]string{root, cacheDir}, elem...)...)
+}
+
+func loadCacheIndex() *CacheIndex {
+	index := &CacheIndex{
+		Files:       make(map[string]*CachedFile),
+		Directories: make(map[string]*CachedDirectory),
+	}
+
+	data, err := os.ReadFile(cachePath("index.json"))
+	if err != nil {
+		return index
+	}
+
+	// A corrupt index is treated as empty; it will be rebuilt on the next read
+	if err := json.Unmarshal(data, index); err != nil {
+		return &CacheIndex{
+			Files:       make(map[string]*CachedFile),
+			Directories: make(map[string]*CachedDirectory),
+		}
+	}
+	if index.Files == nil {
+		index.Files = make(map[string]*CachedFile)
+	}
+	if index.Directories == nil {
+		index.Directories = make(map[string]*CachedDirectory)
+	}
+
+	return index
+}
+
+func saveCacheIndex(index *CacheIndex) error {
+	if err := os.MkdirAll(cachePath(), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache index: %w", err)
+	}
+
+	return os.WriteFile(cachePath("index.json"), data, 0644)
+}
+
+// blobHashLength is the length of a blob hash: 32 bytes in hex, whether the
+// server hashes with SHA-256 or BLAKE3
+const blobHashLength = 64
+
+// blobCachePath returns where a blob is cached. The hash comes from the
+// server or the cache index and names a file, so anything but a hash, such
+// as "../../x", is refused rather than joined into a path.
+func blobCachePath(hash string) (string, error) {
+	if len(hash) != blobHashLength {
+		return "", fmt.Errorf("invalid blob hash %q", hash)
+	}
+	for _, c := range hash {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return "", fmt.Errorf("invalid blob hash %q", hash)
+		}
+	}
+	return cachePath("blobs", hash), nil
+}
+
+// blobCached reports whether a blob is in the cache
+func blobCached(hash string) bool {
+	path, err := blobCachePath(hash)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func readCachedBlob(hash string) ([]byte, bool) {
+	path, err := blobCachePath(hash)
+	if err != nil {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+func writeCachedBlob(hash string, content []byte) error {
+	path, err := blobCachePath(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil // Content-addressed: already cached
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create blob cache directory: %w", err)
+	}
+	// Write to a temporary file first so an interrupted write never leaves a
+	// truncated blob under its final name
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write cached blob: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// cacheFile stores a ReadFile response in the workspace cache
+func cacheFile(path string, resp *pb.ReadFileResponse) {
+	if !cacheEnabled() || resp.Hash == "" {
+		return
+	}
+
+	if err := writeCachedBlob(resp.Hash, resp.Content); err != nil {
+		return
+	}
+
+	index := loadCacheIndex()
+	index.Files[path] = &CachedFile{Hash: resp.Hash, CachedAt: time.Now()}
+	saveCacheIndex(index)
+}
+
+// cachedFile returns cached content for path, if any
+func cachedFile(path string) ([]byte, time.Time, bool) {
+	if !cacheEnabled() {
+		return nil, time.Time{}, false
+	}
+
+	entry, exists := loadCacheIndex().Files[path]
+	if !exists {
+		return nil, time.Time{}, false
+	}
+
+	content, ok := readCachedBlob(entry.Hash)
+	return content, entry.CachedAt, ok
+}
+
+// cachedFileHash returns the hash of the cached content for path, to send as
+// if_not_hash, or "" when there is no usable cached content
+func cachedFileHash(path string) string {
+	if !cacheEnabled() {
+		return ""
+	}
+
+	entry, exists := loadCacheIndex().Files[path]
+	if !exists {
+		return ""
+	}
+	if !blobCached(entry.Hash) {
+		return ""
+	}
+	return entry.Hash
+}
+
+// cachedFileHashes returns cachedFileHash for each of paths that has usable
+// cached content, to send as ReadFiles' if_not_hash
+func cachedFileHashes(paths []string) map[string]string {
+	if !cacheEnabled() {
+		return nil
+	}
+
+	index := loadCacheIndex()
+	hashes := make(map[string]string)
+	for _, path := range paths {
+		if entry, exists := index.Files[path]; exists && blobCached(entry.Hash) {
+			hashes[path] = entry.Hash
+		}
+	}
+	return hashes
+}
+
+// cacheDirectory stores a directory listing and its tree hash in the
+// workspace cache
+func cacheDirectory(path, hash string, items []LsEntry) {
+	if !cacheEnabled() {
+		return
+	}
+
+	index := loadCacheIndex()
+	index.Directories[path] = &CachedDirectory{Hash: hash, Items: items, CachedAt: time.Now()}
+	saveCacheIndex(index)
+}
+
+// cachedDirectory returns the cached listing for path, if any
+func cachedDirectory(path string) ([]LsEntry, time.Time, bool) {
+	if !cacheEnabled() {
+		return nil, time.Time{}, false
+	}
+
+	entry, exists := loadCacheIndex().Directories[path]
+	if !exists {
+		return nil, time.Time{}, false
+	}
+	return entry.Items, entry.CachedAt, true
+}
+
+// cachedDirectoryHash returns the tree hash of the cached listing for path,
+// to send as if_not_hash, or "" when there is none
+func cachedDirectoryHash(path string) string {
+	if !cacheEnabled() {
+		return ""
+	}
+
+	if entry, exists := loadCacheIndex().Directories[path]; exists {
+		return entry.Hash
+	}
+	return ""
+}
+
+// isServerUnavailable reports whether err means the server could not be reached
+func isServerUnavailable(err error) bool {
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
+
+// warnStale tells the user that output is coming from the local cache
+func warnStale(cachedAt time.Time) {
+	fmt.Fprintf(os.Stderr, "warning: server unreachable, showing cached data from %s (%s ago)\n",
+		cachedAt.Format(time.RFC3339), time.Since(cachedAt).Round(time.Second))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlobCachePath(t *testing.T) {
+	hash := strings.Repeat("0123456789abcdef", 4)
+	path, err := blobCachePath(hash)
+	if err != nil {
+		t.Fatalf("blobCachePath(%s): %v", hash, err)
+	}
+	if want := filepath.Join(cacheDir, "blobs", hash); !strings.HasSuffix(path, want) {
+		t.Errorf("blobCachePath(%s) = %s, want it to end in %s", hash, path, want)
+	}
+
+	for _, bad := range []string{
+		"",
+		"../../../etc/passwd",
+		"../" + hash[3:],
+		strings.ToUpper(hash),
+		hash[:63],
+		hash + "0",
+	} {
+		if _, err := blobCachePath(bad); err == nil {
+			t.Errorf("blobCachePath(%q) accepted", bad)
+		}
+		if err := writeCachedBlob(bad, []byte("x")); err == nil {
+			t.Errorf("writeCachedBlob(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// parseLineRange parses the "from:to" of `poon cat --lines`, either end of
+// which may be left out: "100:200", "100:" or ":200". A single number is one
+// line.
+func parseLineRange(spec string) (int32, int32, error) {
+	fromText, toText, isRange := strings.Cut(spec, ":")
+	if !isRange {
+		toText = fromText
+	}
+
+	parse := func(text string) (int32, error) {
+		if text == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseInt(text, 10, 32)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid line range %q: lines are numbered from 1", spec)
+		}
+		return int32(n), nil
+	}
+	from, err := parse(fromText)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := parse(toText)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from == 0 && to == 0 {
+		return 0, 0, fmt.Errorf("invalid line range %q: give from:to", spec)
+	}
+	if to != 0 && to < max(from, 1) {
+		return 0, 0, fmt.Errorf("invalid line range %q: %d is before %d", spec, to, from)
+	}
+	return from, to, nil
+}
+
+// catLines prints numbered lines of a file, highlighted by the server when
+// writing to a terminal
+func catLines(ctx context.Context, path, spec string) error {
+	from, to, err := parseLineRange(spec)
+	if err != nil {
+		return err
+	}
+	if !serverInfo.Supports(poonclient.FeatureFilePreview) {
+		return fmt.Errorf("server does not support file previews; upgrade poon-server or read the whole file")
+	}
+
+	req := &pb.PreviewFileRequest{Path: path, FromLine: from, ToLine: to}
+	if colorOutput() {
+		req.Highlight = "ansi"
+	}
+	resp, err := client.PreviewFile(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if resp.Binary {
+		return fmt.Errorf("%s is a binary file", path)
+	}
+
+	width := len(strconv.Itoa(int(resp.TotalLines)))
+	for _, line := range resp.Lines {
+		text := line.Text
+		if line.Truncated {
+			text += " [...]"
+		}
+		fmt.Printf("%*d  %s\n", width, line.Number, text)
+	}
+	if resp.Truncated {
+		last := int32(0)
+		if n := len(resp.Lines); n > 0 {
+			last = resp.Lines[n-1].Number
+		}
+		fmt.Fprintf(os.Stderr, "[stopped at line %d of %d; ask for --lines %d: to read on]\n", last, resp.TotalLines, last+1)
+	}
+	return nil
+}
+
+// colorOutput reports whether standard output is a terminal that should get
+// colors; NO_COLOR turns them off
+func colorOutput() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
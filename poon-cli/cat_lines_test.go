@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		from, to int32
+	}{
+		{"100:200", 100, 200},
+		{"100:", 100, 0},
+		{":20", 0, 20},
+		{"7", 7, 7},
+		{"5:5", 5, 5},
+	}
+	for _, test := range tests {
+		from, to, err := parseLineRange(test.spec)
+		if err != nil {
+			t.Errorf("parseLineRange(%q): %v", test.spec, err)
+			continue
+		}
+		if from != test.from || to != test.to {
+			t.Errorf("parseLineRange(%q) = %d, %d, want %d, %d", test.spec, from, to, test.from, test.to)
+		}
+	}
+
+	for _, spec := range []string{"", ":", "0:10", "20:10", "a:b", "-1", "1:2:3"} {
+		if _, _, err := parseLineRange(spec); err == nil {
+			t.Errorf("parseLineRange(%q) should fail", spec)
+		}
+	}
+}
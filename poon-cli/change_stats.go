@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// statGraphWidth is the widest +/- bar formatChangeStats draws; files with
+// more changed lines are scaled down to fit
+const statGraphWidth = 40
+
+// formatChangeStats renders change stats the way git diff --stat does: one
+// line per file with its changed line count and a +/- bar, then a total
+func formatChangeStats(stats *pb.ChangeStats) string {
+	if stats == nil {
+		return ""
+	}
+
+	names := make([]string, len(stats.Files))
+	nameWidth, countWidth, largest := 0, 1, int32(0)
+	for i, file := range stats.Files {
+		names[i] = file.Path
+		if file.OldPath != "" {
+			names[i] = file.OldPath + " => " + file.Path
+		}
+		nameWidth = max(nameWidth, len(names[i]))
+		countWidth = max(countWidth, len(fmt.Sprint(file.Insertions+file.Deletions)))
+		largest = max(largest, file.Insertions+file.Deletions)
+	}
+
+	var b strings.Builder
+	for i, file := range stats.Files {
+		if file.Binary {
+			fmt.Fprintf(&b, " %-*s | Bin\n", nameWidth, names[i])
+			continue
+		}
+		plus, minus := int(file.Insertions), int(file.Deletions)
+		if largest > statGraphWidth {
+			plus, minus = scaleStat(plus, largest), scaleStat(minus, largest)
+		}
+		graph := strings.Repeat("+", plus) + strings.Repeat("-", minus)
+		fmt.Fprintf(&b, " %-*s | %*d %s\n", nameWidth, names[i], countWidth, file.Insertions+file.Deletions, graph)
+	}
+
+	fmt.Fprintf(&b, " %d %s changed", stats.FilesChanged, plural(int(stats.FilesChanged), "file", "files"))
+	if stats.Insertions > 0 || stats.Deletions == 0 {
+		fmt.Fprintf(&b, ", %d %s(+)", stats.Insertions, plural(int(stats.Insertions), "insertion", "insertions"))
+	}
+	if stats.Deletions > 0 || stats.Insertions == 0 {
+		fmt.Fprintf(&b, ", %d %s(-)", stats.Deletions, plural(int(stats.Deletions), "deletion", "deletions"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// scaleStat scales a line count to the graph width, keeping at least one
+// mark for any change
+func scaleStat(n int, largest int32) int {
+	if n == 0 {
+		return 0
+	}
+	return max(1, n*statGraphWidth/int(largest))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+func TestFormatChangeStats(t *testing.T) {
+	stats := &pb.ChangeStats{
+		FilesChanged: 3,
+		Insertions:   3,
+		Deletions:    2,
+		Files: []*pb.FileStat{
+			{Path: "src/main.go", Status: "modified", Insertions: 3, Deletions: 1},
+			{Path: "docs/new.md", OldPath: "docs/old.md", Status: "renamed", Deletions: 1},
+			{Path: "logo.png", Status: "added", Binary: true},
+		},
+	}
+	want := "" +
+		" src/main.go                | 4 +++-\n" +
+		" docs/old.md => docs/new.md | 1 -\n" +
+		" logo.png                   | Bin\n" +
+		" 3 files changed, 3 insertions(+), 2 deletions(-)\n"
+	if got := formatChangeStats(stats); got != want {
+		t.Errorf("formatChangeStats:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Large changes are scaled to the graph width, and a total with no
+	// deletions leaves them out like git does
+	large := &pb.ChangeStats{
+		FilesChanged: 1,
+		Insertions:   400,
+		Files:        []*pb.FileStat{{Path: "big.txt", Status: "added", Insertions: 400}},
+	}
+	want = " big.txt | 400 ++++++++++++++++++++++++++++++++++++++++\n 1 file changed, 400 insertions(+)\n"
+	if got := formatChangeStats(large); got != want {
+		t.Errorf("formatChangeStats:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := formatChangeStats(nil); got != "" {
+		t.Errorf("formatChangeStats(nil) = %q, want empty", got)
+	}
+}
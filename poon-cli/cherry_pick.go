@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// CherryPickOutput is the machine-readable result of `poon cherry-pick`
+type CherryPickOutput struct {
+	Success    bool     `json:"success"`
+	Message    string   `json:"message"`
+	CommitHash string   `json:"commitHash,omitempty"`
+	Version    int64    `json:"version,omitempty"`
+	Empty      bool     `json:"empty"`
+	Queued     bool     `json:"queued"`
+	QueueEntry string   `json:"queueEntryId,omitempty"`
+	Conflicts  []string `json:"conflicts"`
+	Violations []string `json:"violations"`
+}
+
+var (
+	cherryPickTarget       string
+	cherryPickFailIfLocked bool
+)
+
+var cherryPickCmd = &cobra.Command{
+	Use:   "cherry-pick <commit|version>",
+	Short: "Apply the changes of one commit to another branch",
+	Long: `Apply the changes a commit made to its parent on top of another branch,
+main by default, as a new commit. Give a commit hash from any branch, or a
+version number for a commit on main.
+
+Files the target branch also changed differently are reported as conflicts
+and nothing is committed. Picking onto main creates a version. To move the
+fix made in version 42 to a release branch:
+
+  poon cherry-pick 42 --to release/1.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := &pb.CherryPickRequest{
+			TargetBranch: cherryPickTarget,
+			Author:       localUser(),
+			FailIfLocked: cherryPickFailIfLocked,
+		}
+		// Commit hashes are far longer than any version number
+		if version, err := strconv.ParseInt(args[0], 10, 64); err == nil && len(args[0]) < 16 {
+			req.Version = version
+		} else {
+			req.CommitHash = args[0]
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureBranches) {
+			return fmt.Errorf("server %s has no branches", serverAddr)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		resp, err := client.CherryPick(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to cherry-pick %s: %w", args[0], err)
+		}
+
+		if isJSONOutput() {
+			out := CherryPickOutput{
+				Success:    resp.Success,
+				Message:    resp.Message,
+				CommitHash: resp.CommitHash,
+				Version:    resp.Version,
+				Empty:      resp.Empty,
+				Queued:     resp.Queued,
+				QueueEntry: resp.QueueEntryId,
+				Conflicts:  append([]string{}, resp.Conflicts...),
+				Violations: []string{},
+			}
+			for _, violation := range resp.Violations {
+				out.Violations = append(out.Violations, violation.Description)
+			}
+			return printJSON(out)
+		}
+
+		if !resp.Success {
+			fmt.Printf("✗ %s\n", resp.Message)
+			for _, path := range resp.Conflicts {
+				fmt.Printf("  conflict: %s\n", path)
+			}
+			for _, violation := range resp.Violations {
+				fmt.Printf("  %s\n", violation.Description)
+			}
+			return nil
+		}
+
+		fmt.Printf("✓ %s\n", resp.Message)
+		if !resp.Empty && !resp.Queued {
+			fmt.Printf("Commit: %s\n", resp.CommitHash)
+		}
+		return nil
+	},
+}
+
+func init() {
+	cherryPickCmd.Flags().StringVar(&cherryPickTarget, "to", "main", "Branch to apply the commit to")
+	cherryPickCmd.Flags().BoolVar(&cherryPickFailIfLocked, "fail-if-locked", false, "Refuse to pick onto main if a changed path is locked by someone else")
+	rootCmd.AddCommand(cherryPickCmd)
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keychainService names poon's entries in the system keychain (macOS
+// Keychain, Secret Service, Windows Credential Manager); each entry is keyed
+// by gRPC server address
+const keychainService = "poon"
+
+// Credential stores selected with POON_CREDENTIALS_STORE
+const (
+	credentialStoreKeychain = "keychain"
+	credentialStoreFile     = "file"
+)
+
+// Credentials holds access tokens keyed by gRPC server address, as kept in
+// the plaintext credentials file
+type Credentials struct {
+	Servers map[string]string `json:"servers"`
+}
+
+// credentialsPath returns the per-user credentials file location. The file is
+// only used when POON_CREDENTIALS_STORE=file asks for it, or to move tokens
+// stored by older releases into the keychain. It is kept at 0600 permissions
+// and lives outside any workspace so tokens are never committed by accident.
+func credentialsPath() (string, error) {
+	if path := os.Getenv("POON_CREDENTIALS_FILE"); path != "" {
+		return path, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user config directory: %w", err)
+	}
+
+	return filepath.Join(configDir, "poon", "credentials.json"), nil
+}
+
+func loadCredentials() (*Credentials, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &Credentials{Servers: make(map[string]string)}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	// A file others can read, say one copied in or made before the file was
+	// kept private, is made private before its tokens are used. Windows
+	// keeps the file private through the profile directory's ACL instead.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(path, 0600); err != nil {
+			return nil, fmt.Errorf("credentials file %s can be read by other users (mode %04o) and could not be made private: %w", path, info.Mode().Perm(), err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: credentials file %s could be read by other users (mode %04o); changed it to 0600\n", path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	if creds.Servers == nil {
+		creds.Servers = make(map[string]string)
+	}
+
+	return creds, nil
+}
+
+func saveCredentials(creds *Credentials) error {
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	// Written to a new private file and renamed over the old one, since
+	// WriteFile keeps the mode of a file that exists, and a crash must not
+	// leave half the tokens
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	return nil
+}
+
+// usePlaintextCredentials reports whether tokens are kept in the plaintext
+// credentials file rather than the system keychain. The file is an opt-in
+// fallback for machines without a keychain, and every use of it is warned
+// about.
+func usePlaintextCredentials() (bool, error) {
+	switch store := os.Getenv("POON_CREDENTIALS_STORE"); store {
+	case "", credentialStoreKeychain:
+		return false, nil
+	case credentialStoreFile:
+		path, err := credentialsPath()
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: access tokens are stored unencrypted in %s (POON_CREDENTIALS_STORE=file); anyone who can read the file can use them\n", path)
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown POON_CREDENTIALS_STORE %q (use %s or %s)", store, credentialStoreKeychain, credentialStoreFile)
+	}
+}
+
+// keychainError explains a keychain failure and how to do without one
+func keychainError(err error) error {
+	return fmt.Errorf("system keychain unavailable (set POON_CREDENTIALS_STORE=file to store tokens unencrypted in a file instead): %w", err)
+}
+
+// migrateCredentialsFile moves tokens from a credentials file written by an
+// older release into the keychain and removes the file. The file is left in
+// place when the keychain cannot take them.
+func migrateCredentialsFile() error {
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	creds, err := loadCredentials()
+	if err != nil {
+		return err
+	}
+	for addr, token := range creds.Servers {
+		if err := keyring.Set(keychainService, addr, token); err != nil {
+			return keychainError(err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove credentials file after moving its tokens to the keychain: %w", err)
+	}
+	if len(creds.Servers) > 0 {
+		fmt.Fprintf(os.Stderr, "Moved %d access token(s) from %s to the system keychain\n", len(creds.Servers), path)
+	}
+	return nil
+}
+
+// storedToken returns the token stored for addr, or "" when there is none
+func storedToken(addr string) (string, error) {
+	plaintext, err := usePlaintextCredentials()
+	if err != nil {
+		return "", err
+	}
+	if plaintext {
+		creds, err := loadCredentials()
+		if err != nil {
+			return "", err
+		}
+		return creds.Servers[addr], nil
+	}
+
+	if err := migrateCredentialsFile(); err != nil {
+		return "", err
+	}
+	// A machine without a keychain, such as a CI runner using POON_TOKEN,
+	// has no token stored in one either: poon login fails there instead, so
+	// commands are not warned about it each time they connect
+	token, err := keyring.Get(keychainService, addr)
+	if err != nil {
+		return "", nil
+	}
+	return token, nil
+}
+
+// storeToken stores token for addr, replacing any stored before
+func storeToken(addr, token string) error {
+	plaintext, err := usePlaintextCredentials()
+	if err != nil {
+		return err
+	}
+	if plaintext {
+		creds, err := loadCredentials()
+		if err != nil {
+			return err
+		}
+		creds.Servers[addr] = token
+		return saveCredentials(creds)
+	}
+
+	if err := migrateCredentialsFile(); err != nil {
+		return err
+	}
+	if err := keyring.Set(keychainService, addr, token); err != nil {
+		return keychainError(err)
+	}
+	return nil
+}
+
+// deleteToken removes the token stored for addr and reports whether there
+// was one
+func deleteToken(addr string) (bool, error) {
+	plaintext, err := usePlaintextCredentials()
+	if err != nil {
+		return false, err
+	}
+	if plaintext {
+		creds, err := loadCredentials()
+		if err != nil {
+			return false, err
+		}
+		if _, exists := creds.Servers[addr]; !exists {
+			return false, nil
+		}
+		delete(creds.Servers, addr)
+		return true, saveCredentials(creds)
+	}
+
+	if err := migrateCredentialsFile(); err != nil {
+		return false, err
+	}
+	err = keyring.Delete(keychainService, addr)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, keychainError(err)
+	}
+	return true, nil
+}
+
+// tokenForServer returns the token to use for addr. POON_TOKEN overrides any
+// stored credential, which is convenient for CI.
+func tokenForServer(addr string) string {
+	if token := os.Getenv("POON_TOKEN"); token != "" {
+		return token
+	}
+
+	token, err := storedToken(addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: connecting without a stored token: %v\n", err)
+		return ""
+	}
+
+	return token
+}
+
+// WhoAmIOutput is the machine-readable result of `poon whoami`
+type WhoAmIOutput struct {
+	Server        string `json:"server"`
+	User          string `json:"user"`
+	Authenticated bool   `json:"authenticated"`
+	AuthRequired  bool   `json:"authRequired"`
+}
+
+var loginToken string
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authenticate with the poon server",
+	Long: `Authenticate with the poon server using a personal access token.
+
+The token is read from --token, or from standard input when not given, and is
+verified against the server before being stored in the system keychain
+(macOS Keychain, Secret Service on Linux, Windows Credential Manager). On
+machines without a keychain, POON_CREDENTIALS_STORE=file stores tokens
+unencrypted in a private file instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token := strings.TrimSpace(loginToken)
+		if token == "" {
+			fmt.Fprintf(os.Stderr, "Paste your access token for %s: ", serverAddr)
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("failed to read token: %w", err)
+			}
+			token = strings.TrimSpace(line)
+		}
+		if token == "" {
+			return fmt.Errorf("no token provided")
+		}
+
+		// Verify the token before storing it
+		if err := connectWithToken(token); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.WhoAmI(ctx, &pb.WhoAmIRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to verify token: %w", err)
+		}
+
+		if err := storeToken(serverAddr, token); err != nil {
+			return err
+		}
+
+		if resp.Authenticated {
+			fmt.Printf("✓ Logged in to %s as %s\n", serverAddr, resp.User)
+		} else {
+			fmt.Printf("✓ Token stored for %s (server does not require authentication)\n", serverAddr)
+		}
+		return nil
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove stored credentials for the poon server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deleted, err := deleteToken(serverAddr)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			fmt.Printf("Not logged in to %s\n", serverAddr)
+			return nil
+		}
+
+		fmt.Printf("✓ Logged out of %s\n", serverAddr)
+		return nil
+	},
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the identity used for the poon server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.WhoAmI(ctx, &pb.WhoAmIRequest{})
+		// A server that requires authentication refuses the call itself
+		// without a valid token
+		if status.Code(err) == codes.Unauthenticated {
+			resp, err = &pb.WhoAmIResponse{AuthRequired: true}, nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get identity: %w", err)
+		}
+
+		if isJSONOutput() {
+			return printJSON(WhoAmIOutput{
+				Server:        serverAddr,
+				User:          resp.User,
+				Authenticated: resp.Authenticated,
+				AuthRequired:  resp.AuthRequired,
+			})
+		}
+
+		if resp.Authenticated {
+			fmt.Printf("Logged in to %s as %s\n", serverAddr, resp.User)
+		} else if resp.AuthRequired && connToken != "" {
+			fmt.Printf("Not logged in to %s: the server refused the stored token (run 'poon login')\n", serverAddr)
+		} else if resp.AuthRequired {
+			fmt.Printf("Not logged in to %s (run 'poon login')\n", serverAddr)
+		} else {
+			fmt.Printf("Anonymous access to %s (server does not require authentication)\n", serverAddr)
+		}
+		return nil
+	},
+}
+
+func init() {
+	loginCmd.Flags().StringVar(&loginToken, "token", "", "Access token (read from stdin when omitted)")
+
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(whoamiCmd)
+}
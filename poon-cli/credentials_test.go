@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestKeychainCredentials(t *testing.T) {
+	keyring.MockInit()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	t.Setenv("POON_CREDENTIALS_FILE", path)
+	t.Setenv("POON_CREDENTIALS_STORE", "")
+
+	// Tokens in a file written by an older release move to the keychain
+	if err := os.WriteFile(path, []byte(`{"servers":{"old:50051":"legacy"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := storedToken("old:50051"); err != nil || token != "legacy" {
+		t.Fatalf("storedToken(old) = %q, %v; want the migrated token", token, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("credentials file left after migration: %v", err)
+	}
+
+	if err := storeToken("localhost:50051", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := keyring.Get(keychainService, "localhost:50051"); err != nil || token != "secret" {
+		t.Errorf("keychain holds %q, %v; want the stored token", token, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("storing a token wrote the plaintext file: %v", err)
+	}
+	if token, err := storedToken("localhost:50051"); err != nil || token != "secret" {
+		t.Errorf("storedToken = %q, %v; want secret", token, err)
+	}
+
+	if deleted, err := deleteToken("localhost:50051"); err != nil || !deleted {
+		t.Errorf("deleteToken = %v, %v; want deleted", deleted, err)
+	}
+	if deleted, err := deleteToken("localhost:50051"); err != nil || deleted {
+		t.Errorf("second deleteToken = %v, %v; want nothing to delete", deleted, err)
+	}
+	if token, err := storedToken("localhost:50051"); err != nil || token != "" {
+		t.Errorf("storedToken after logout = %q, %v", token, err)
+	}
+}
+
+func TestUnknownCredentialStore(t *testing.T) {
+	t.Setenv("POON_CREDENTIALS_STORE", "vault")
+	if _, err := storedToken("localhost:50051"); err == nil {
+		t.Error("unknown POON_CREDENTIALS_STORE accepted")
+	}
+}
+
+func TestCredentialsArePrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not used for privacy on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	t.Setenv("POON_CREDENTIALS_FILE", path)
+	t.Setenv("POON_CREDENTIALS_STORE", credentialStoreFile)
+
+	// Saving over a file others can read leaves a private one
+	if err := os.WriteFile(path, []byte(`{"servers":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeToken("localhost:50051", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("saved credentials have mode %04o, want 0600", mode)
+	}
+
+	// A file made readable by others is made private when it is read
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	token, err := storedToken("localhost:50051")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret" {
+		t.Errorf("loaded token %q, want secret", token)
+	}
+	if info, err = os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("loaded credentials left at mode %04o, want 0600", mode)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+var debugTimeout time.Duration
+
+var debugCmd = &cobra.Command{
+	Use:    "debug",
+	Short:  "Troubleshooting tools",
+	Hidden: true,
+}
+
+var debugRPCCmd = &cobra.Command{
+	Use:   "rpc <method> [json]",
+	Short: "Call an RPC with a JSON request",
+	Long: `Call any RPC of the server by name with a request written in protobuf JSON,
+and print the response (each message, for streaming RPCs) and its headers
+and trailers. The request defaults to {}; "-" reads it from stdin.
+
+Methods are named as ReadFile, MonorepoService/ReadFile or
+monorepo.MonorepoService.ReadFile. Admin methods go to --admin-server with
+POON_ADMIN_TOKEN; the others to --server with your stored token.
+
+Examples:
+  poon debug rpc GetServerInfo
+  poon debug rpc ReadFile '{"path": "docs/README.md", "version": "3"}'
+  poon debug rpc GetBackendStats`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, err := findMethod(args[0])
+		if err != nil {
+			return err
+		}
+		if method.IsStreamingClient() {
+			return fmt.Errorf("%s takes a stream of requests, which debug rpc cannot send", method.FullName())
+		}
+
+		request := "{}"
+		if len(args) > 1 {
+			request = args[1]
+		}
+		if request == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read request: %w", err)
+			}
+			request = string(data)
+		}
+		req, err := newMessage(method.Input())
+		if err != nil {
+			return err
+		}
+		if err := protojson.Unmarshal([]byte(request), req); err != nil {
+			return fmt.Errorf("invalid %s: %w", method.Input().FullName(), err)
+		}
+
+		var c *poonclient.Client
+		if method.Parent().Name() == "MonorepoAdminService" {
+			if c, err = dialAdmin(); err != nil {
+				return err
+			}
+			defer c.Close()
+		} else {
+			if err := connectToServer(); err != nil {
+				return err
+			}
+			c = conn
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), debugTimeout)
+		defer cancel()
+		fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+		var header, trailer metadata.MD
+		err = callMethod(ctx, c.Conn(), fullMethod, method, req, printMessage, grpc.Header(&header), grpc.Trailer(&trailer))
+		printMetadata("header", header)
+		printMetadata("trailer", trailer)
+		if err != nil {
+			if st, ok := status.FromError(err); ok {
+				if data, merr := (protojson.MarshalOptions{Multiline: true}).Marshal(st.Proto()); merr == nil {
+					fmt.Fprintf(os.Stderr, "%s\n", data)
+				}
+			}
+			return fmt.Errorf("%s failed: %w", method.Name(), err)
+		}
+		return nil
+	},
+}
+
+// findMethod looks a method up in the poon services by its name alone, by
+// service and name, or by full name
+func findMethod(name string) (protoreflect.MethodDescriptor, error) {
+	name = strings.ReplaceAll(strings.TrimPrefix(name, "/"), "/", ".")
+
+	var matches []protoreflect.MethodDescriptor
+	services := pb.File_monorepo_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		service := services.Get(i)
+		methods := service.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			if name == string(method.FullName()) || name == string(method.Name()) ||
+				name == string(service.Name())+"."+string(method.Name()) {
+				matches = append(matches, method)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown method %q", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("method %q is ambiguous; name its service, as in %s", name, matches[0].FullName())
+	}
+}
+
+func newMessage(desc protoreflect.MessageDescriptor) (proto.Message, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		return nil, fmt.Errorf("no type for %s: %w", desc.FullName(), err)
+	}
+	return mt.New().Interface(), nil
+}
+
+// callMethod sends req to a unary or server-streaming method and hands each
+// response to handle
+func callMethod(ctx context.Context, cc *grpc.ClientConn, fullMethod string, method protoreflect.MethodDescriptor, req proto.Message, handle func(proto.Message) error, opts ...grpc.CallOption) error {
+	if !method.IsStreamingServer() {
+		resp, err := newMessage(method.Output())
+		if err != nil {
+			return err
+		}
+		if err := cc.Invoke(ctx, fullMethod, req, resp, opts...); err != nil {
+			return err
+		}
+		return handle(resp)
+	}
+
+	stream, err := cc.NewStream(ctx, &grpc.StreamDesc{StreamName: string(method.Name()), ServerStreams: true}, fullMethod, opts...)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		resp, err := newMessage(method.Output())
+		if err != nil {
+			return err
+		}
+		if err := stream.RecvMsg(resp); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := handle(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func printMessage(m proto.Message) error {
+	data, err := (protojson.MarshalOptions{Multiline: true, Indent: "  "}).Marshal(m)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printMetadata shows response metadata on stderr, so that stdout holds
+// only the responses
+func printMetadata(kind string, md metadata.MD) {
+	keys := make([]string, 0, len(md))
+	for key := range md {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range md[key] {
+			if strings.HasSuffix(key, "-bin") {
+				value = fmt.Sprintf("(%d bytes)", len(value)) // Binary, such as status details shown below
+			}
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", kind, key, value)
+		}
+	}
+}
+
+func init() {
+	debugRPCCmd.Flags().DurationVar(&debugTimeout, "timeout", 30*time.Second, "How long to wait for the call")
+	debugRPCCmd.Flags().StringVar(&adminServerAddr, "admin-server", "localhost:50052", "Admin API address, for admin methods")
+	debugCmd.AddCommand(debugRPCCmd)
+	rootCmd.AddCommand(debugCmd)
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestFindMethod(t *testing.T) {
+	for _, name := range []string{
+		"ReadFile",
+		"MonorepoService/ReadFile",
+		"MonorepoService.ReadFile",
+		"monorepo.MonorepoService.ReadFile",
+		"/monorepo.MonorepoService/ReadFile",
+	} {
+		method, err := findMethod(name)
+		if err != nil {
+			t.Errorf("findMethod(%q): %v", name, err)
+			continue
+		}
+		if method.FullName() != "monorepo.MonorepoService.ReadFile" {
+			t.Errorf("findMethod(%q) = %s", name, method.FullName())
+		}
+	}
+
+	method, err := findMethod("GetBackendStats")
+	if err != nil || method.Parent().Name() != "MonorepoAdminService" {
+		t.Errorf("findMethod(GetBackendStats) = %v, %v", method, err)
+	}
+	method, err = findMethod("StreamFile")
+	if err != nil || !method.IsStreamingServer() {
+		t.Errorf("findMethod(StreamFile) = %v, %v", method, err)
+	}
+
+	if _, err := findMethod("NoSuchMethod"); err == nil {
+		t.Error("findMethod(NoSuchMethod) succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <file> [file...]",
+	Short: "Show how workspace files differ from the monorepo",
+	Long: `Show a unified diff from each file's current content in the monorepo to its
+copy in the workspace. The monorepo side is read as 'poon cat' reads it:
+from the local cache when the server reports the file unchanged, and from
+the cache with a warning when the server cannot be reached. A file missing
+on one side is diffed against /dev/null.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, path := range args {
+			diff, err := diffFile(ctx, path)
+			if err != nil {
+				return err
+			}
+			fmt.Print(diff)
+		}
+		return nil
+	},
+}
+
+// diffFile returns the unified diff from a file's content in the monorepo to
+// its copy in the workspace, or "" when they are the same
+func diffFile(ctx context.Context, path string) (string, error) {
+	repoPath := toRepoPath(path)
+
+	fromName := "a/" + repoPath
+	from, err := catFile(ctx, repoPath)
+	if status.Code(err) == codes.NotFound {
+		from, fromName, err = nil, "/dev/null", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the monorepo: %w", repoPath, err)
+	}
+
+	toName := "b/" + repoPath
+	to, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		to, toName, err = nil, "/dev/null", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if fromName == "/dev/null" && toName == "/dev/null" {
+		return "", fmt.Errorf("%s is neither in the monorepo nor in the workspace", repoPath)
+	}
+	if bytes.Equal(from, to) {
+		return "", nil
+	}
+	if bytes.IndexByte(from, 0) >= 0 || bytes.IndexByte(to, 0) >= 0 {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName), nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(from),
+		B:        diffLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+// diffLines splits content into lines that each end in a newline, marking a
+// last line without one the way diff does
+func diffLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// diffServer answers ReadFile from files, honouring if_not_hash, and fails
+// every read while down
+type diffServer struct {
+	pb.MonorepoServiceClient
+	files     map[string]string
+	down      bool
+	ifNotHash []string // if_not_hash of each read
+}
+
+func (d *diffServer) ReadFile(ctx context.Context, req *pb.ReadFileRequest, opts ...grpc.CallOption) (*pb.ReadFileResponse, error) {
+	d.ifNotHash = append(d.ifNotHash, req.IfNotHash)
+	if d.down {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	content, ok := d.files[req.Path]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "file not found")
+	}
+	hash := blobHash([]byte(content))
+	if req.IfNotHash == hash {
+		return &pb.ReadFileResponse{Hash: hash, Size: int64(len(content)), NotModified: true}, nil
+	}
+	return &pb.ReadFileResponse{Content: []byte(content), Hash: hash, Size: int64(len(content))}, nil
+}
+
+func TestDiffFile(t *testing.T) {
+	server := &diffServer{files: map[string]string{
+		"src/a.txt":    "one\ntwo\n",
+		"src/same.txt": "same\n",
+		"src/gone.txt": "x\n",
+	}}
+	useFetchServer(t, nil)
+	client = server
+	if err := os.WriteFile(configPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("src", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		"src/a.txt":    "one\nthree\n",
+		"src/same.txt": "same\n",
+		"src/new.txt":  "new",
+	} {
+		if err := os.WriteFile(filepath.FromSlash(path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	diff := func(path string) string {
+		t.Helper()
+		out, err := diffFile(ctx, path)
+		if err != nil {
+			t.Fatalf("diffFile(%s): %v", path, err)
+		}
+		return out
+	}
+	wantLines := func(out string, lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			if !strings.Contains(out, line+"\n") {
+				t.Errorf("diff %q lacks line %q", out, line)
+			}
+		}
+	}
+
+	changed := diff("src/a.txt")
+	wantLines(changed, "--- a/src/a.txt", "+++ b/src/a.txt", " one", "-two", "+three")
+	wantLines(diff("src/gone.txt"), "--- a/src/gone.txt", "+++ /dev/null", "-x")
+	wantLines(diff("src/new.txt"), "--- /dev/null", "+++ b/src/new.txt", "+new", `\ No newline at end of file`)
+	if out := diff("src/same.txt"); out != "" {
+		t.Errorf("unchanged file diff = %q", out)
+	}
+	if _, err := diffFile(ctx, "src/missing.txt"); err == nil {
+		t.Error("diff of a file on neither side succeeded")
+	}
+
+	// The second read sends the cached hash and is answered from the cache
+	server.ifNotHash = nil
+	if out := diff("src/a.txt"); out != changed {
+		t.Errorf("diff from the cache = %q, want %q", out, changed)
+	}
+	if want := blobHash([]byte(server.files["src/a.txt"])); len(server.ifNotHash) != 1 || server.ifNotHash[0] != want {
+		t.Errorf("reads sent if_not_hash %q, want %q", server.ifNotHash, want)
+	}
+
+	// Offline, the cached content is diffed
+	server.down = true
+	if out := diff("src/a.txt"); out != changed {
+		t.Errorf("offline diff = %q, want %q", out, changed)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// streamDownload saves the archive of a directory to the current directory
+// as the server streams it, so archives larger than a message can be
+// downloaded. The file only appears once the whole archive has arrived.
+func streamDownload(req *pb.DownloadPathRequest) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.StreamDownloadPath(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to download path: %w", err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to download path: %w", err)
+	}
+	filename := filepath.Base(first.Filename)
+	if filename == "." || filename == string(filepath.Separator) {
+		return fmt.Errorf("server did not name the archive")
+	}
+
+	tmp, err := os.CreateTemp(".", "."+filename+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write download file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	var size int64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to download path: %w", err)
+		}
+		if _, err := tmp.Write(chunk.Data); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write download file: %w", err)
+		}
+		size += int64(len(chunk.Data))
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write download file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write download file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to write download file: %w", err)
+	}
+
+	at := "commit " + first.CommitHash
+	if first.Version > 0 {
+		at = fmt.Sprintf("version %d", first.Version)
+	}
+	fmt.Printf("✓ Downloaded %s at %s\n", req.Path, at)
+	fmt.Printf("Content size: %d bytes\n", size)
+	fmt.Printf("Saved to: %s\n", filename)
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// remediationHints turns the details of a failure into what to do about it.
+// Failures without details, or with reasons this client does not know, get
+// no hints and are shown by their message alone.
+func remediationHints(details *poonclient.ErrorDetails) []string {
+	if details == nil {
+		return nil
+	}
+	md := details.Metadata
+
+	var hints []string
+	switch details.Reason {
+	case poonclient.ReasonUnauthenticated:
+		hints = append(hints, fmt.Sprintf("Run 'poon login' to store a token for %s", serverAddr))
+	case poonclient.ReasonInvalidPath:
+		hints = append(hints, "Paths are relative to the repository root and must not contain '..'")
+	case poonclient.ReasonPathNotFound:
+		hints = append(hints, fmt.Sprintf("%s does not exist at version %s; check the spelling with 'poon ls'", md["path"], md["version"]))
+	case poonclient.ReasonVersionNotFound:
+		hints = append(hints, fmt.Sprintf("The newest version is %s; pass a version up to that, or 0 for the current one", md["current_version"]))
+	case poonclient.ReasonPatchTooLarge:
+		hints = append(hints, fmt.Sprintf("The patch exceeds the server's %s limit (%s, at most %s); split the change into smaller pushes", md["limit"], md["actual"], md["max"]))
+	case poonclient.ReasonCommitMessageRejected:
+		for _, violation := range details.Violations {
+			hints = append(hints, fmt.Sprintf("Commit message %s: %s", violation.Field, violation.Description))
+		}
+	case poonclient.ReasonTimeLimitExceeded:
+		hints = append(hints, fmt.Sprintf("%s is limited to %s on this server; narrow the request (a smaller path or fewer files) or retry later", md["method"], md["limit"]))
+	case poonclient.ReasonEmptyPatch:
+		hints = append(hints, "There is nothing to merge; check that the patch file has content")
+	case poonclient.ReasonPatchConflict:
+		if md["file_line"] != "" {
+			hints = append(hints, fmt.Sprintf("%s line %s is %q on the server but the patch expects %q", md["path"], md["file_line"], md["actual"], md["expected"]))
+		}
+		hints = append(hints, fmt.Sprintf("%s changed since your workspace was synced (server is at version %s); run 'poon sync' and retry", md["path"], md["base_version"]))
+	case poonclient.ReasonCaseCollision:
+		hints = append(hints, "A path differing only in case already exists; rename the file and retry (see 'poon collisions')")
+	case poonclient.ReasonPathLocked:
+		hints = append(hints, fmt.Sprintf("%s is locked by %s until %s; ask them to release it or retry after it expires (see 'poon locks')", md["path"], md["owner"], md["expires_at"]))
+	case poonclient.ReasonPolicyViolation:
+		hints = append(hints, fmt.Sprintf("Fix the %s violations listed above and retry", strings.ReplaceAll(md["policy"], "_", " ")))
+	case poonclient.ReasonTooManyPaths:
+		hints = append(hints, fmt.Sprintf("Ask for at most %s files at a time", md["max"]))
+	case poonclient.ReasonNotAFile:
+		hints = append(hints, fmt.Sprintf("%s is a directory; list it with 'poon ls %s'", md["path"], md["path"]))
+	case poonclient.ReasonPathChanged:
+		hints = append(hints, fmt.Sprintf("%s changed after version %s (server is at version %s); rebase the patch onto the current version, or drop --expected-version to apply it anyway", md["path"], md["expected_version"], md["current_version"]))
+	case poonclient.ReasonFileTooLarge:
+		hints = append(hints, fmt.Sprintf("%s is %s bytes, too large to read with other files; read it on its own with 'poon cat %s'", md["path"], md["size"], md["path"]))
+	}
+	return hints
+}
+
+// printHints writes hints one per line below the failure they belong to
+func printHints(w io.Writer, indent string, hints []string) {
+	for _, hint := range hints {
+		fmt.Fprintf(w, "%shint: %s\n", indent, hint)
+	}
+}
+
+// printFailureHints shows what to do about a failure a response reported
+// in-band
+func printFailureHints(indent string, failure *pb.FailureInfo) {
+	printHints(os.Stdout, indent, remediationHints(poonclient.FailureDetails(failure)))
+}
+
+// exitWithError reports the error a command returned, with hints from any
+// details the server attached, and exits
+func exitWithError(err error) {
+	log.Print(err)
+	printHints(os.Stderr, "", remediationHints(poonclient.Details(err)))
+	os.Exit(1)
+}
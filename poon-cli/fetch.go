@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"lukechampine.com/blake3"
+)
+
+const fetchJournalPath = ".poon/fetch-journal.json"
+
+// defaultFetchJobs is how many files sync and track download at once
+const defaultFetchJobs = 8
+
+// FetchJournal records a fetch of the tracked paths into the workspace cache.
+// It is written before the first download and removed once every file is
+// cached, so a fetch that was interrupted resumes without listing the tree
+// again, as long as the tracked paths have not changed since (see current).
+type FetchJournal struct {
+	Version   int64             `json:"version"`         // Version the files were listed at; 0 from servers without versioned reads
+	Paths     []string          `json:"paths"`           // Tracked paths being fetched
+	Trees     map[string]string `json:"trees,omitempty"` // Hash of each tracked path at Version
+	Files     []FetchFile       `json:"files"`
+	Done      map[string]bool   `json:"done"` // By file path
+	StartedAt time.Time         `json:"startedAt"`
+}
+
+// FetchFile is a file to fetch and the blob hash the server listed for it
+type FetchFile struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+func loadFetchJournal() (*FetchJournal, error) {
+	data, err := os.ReadFile(fetchJournalPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fetch journal: %w", err)
+	}
+
+	var journal FetchJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		// A torn journal only costs a fresh listing
+		return nil, nil
+	}
+	if journal.Done == nil {
+		journal.Done = make(map[string]bool)
+	}
+	return &journal, nil
+}
+
+func saveFetchJournal(journal *FetchJournal) error {
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fetch journal: %w", err)
+	}
+
+	tmp := fetchJournalPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fetch journal: %w", err)
+	}
+	return os.Rename(tmp, fetchJournalPath)
+}
+
+// blobHash computes the hash the server gives a file's content: SHA-256, or
+// BLAKE3 on servers that say so, over "blob <size>\0" and the content
+func blobHash(content []byte) string {
+	var h hash.Hash = sha256.New()
+	if serverInfo != nil && serverInfo.HashAlgorithm == "blake3" {
+		h = blake3.New(32, nil)
+	}
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fetchTrackedPaths downloads every file below the tracked paths into the
+// workspace cache, jobs at a time, so 'poon cat' and 'poon ls' can answer
+// from it. Files whose blob is already cached are skipped, and each download
+// is checked against the hash the server listed before it is stored.
+func fetchTrackedPaths(config *PoonConfig, jobs int) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	journal, err := loadFetchJournal()
+	if err != nil {
+		return err
+	}
+	if journal != nil && journal.current(config.TrackedPaths) {
+		fmt.Printf("Resuming fetch of %s (%d of %d files done)\n", describeVersion(journal.Version), len(journal.Done), len(journal.Files))
+	} else {
+		journal, err = listTrackedFiles(config.TrackedPaths)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Files with the same content are fetched once
+	var pending []FetchFile
+	sameContent := make(map[string][]string) // Paths by hash
+	for _, file := range journal.Files {
+		if journal.Done[file.Path] {
+			continue
+		}
+		if blobCached(file.Hash) {
+			journal.Done[file.Path] = true
+			continue
+		}
+		if _, ok := sameContent[file.Hash]; !ok {
+			pending = append(pending, file)
+		}
+		sameContent[file.Hash] = append(sameContent[file.Hash], file.Path)
+	}
+	if err := saveFetchJournal(journal); err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		fmt.Printf("Fetching %d file(s) at %s (%d at a time)\n", len(pending), describeVersion(journal.Version), jobs)
+	}
+
+	queue := make(chan FetchFile)
+	results := make(chan fetchResult)
+	var workers sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for file := range queue {
+				size, err := fetchFile(file, journal.Version)
+				results <- fetchResult{file: file, size: size, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, file := range pending {
+			queue <- file
+		}
+		close(queue)
+		workers.Wait()
+		close(results)
+	}()
+
+	// Progress is saved every so often rather than per file; a file fetched
+	// but not yet journaled is found in the cache on resume anyway
+	var failed []error
+	var fetchedBytes int64
+	lastSave := time.Now()
+	for result := range results {
+		if result.err != nil {
+			failed = append(failed, result.err)
+			continue
+		}
+		for _, path := range sameContent[result.file.Hash] {
+			journal.Done[path] = true
+		}
+		fetchedBytes += result.size
+		if time.Since(lastSave) > time.Second {
+			if err := saveFetchJournal(journal); err != nil {
+				return err
+			}
+			lastSave = time.Now()
+		}
+	}
+
+	if len(failed) > 0 {
+		if err := saveFetchJournal(journal); err != nil {
+			return err
+		}
+		for _, err := range failed {
+			fmt.Printf("  ✗ %v\n", err)
+		}
+		return fmt.Errorf("failed to fetch %d of %d file(s); run the command again to resume", len(failed), len(pending))
+	}
+
+	index := loadCacheIndex()
+	now := time.Now()
+	for _, file := range journal.Files {
+		index.Files[file.Path] = &CachedFile{Hash: file.Hash, CachedAt: now}
+	}
+	if err := saveCacheIndex(index); err != nil {
+		return err
+	}
+	if err := os.Remove(fetchJournalPath); err != nil {
+		return fmt.Errorf("failed to remove fetch journal: %w", err)
+	}
+	if err := recordSyncedTrees(journal.Paths, journal.Version); err != nil {
+		fmt.Printf("Warning: failed to record synced tree hashes: %v\n", err)
+	}
+
+	fmt.Printf("✓ Cached %d file(s) at %s (%d fetched, %d bytes)\n",
+		len(journal.Files), describeVersion(journal.Version), len(pending), fetchedBytes)
+	return nil
+}
+
+// current reports whether an interrupted fetch can resume: it is of the same
+// paths, and their hashes at the current version are the ones it listed, so
+// the files it lists are still the ones to fetch. The journal then moves to
+// the current version. A journal that cannot be checked this way is listed
+// again; the files it fetched are still in the blob cache.
+func (j *FetchJournal) current(paths []string) bool {
+	if !slices.Equal(j.Paths, paths) || j.Version == 0 || len(j.Trees) == 0 {
+		return false
+	}
+	trees, version, err := trackedTreeHashes(paths, 0)
+	if err != nil || !maps.Equal(trees, j.Trees) {
+		return false
+	}
+	j.Version = version
+	return true
+}
+
+// trackedTreeHashes returns the hash of each path at version, or at the
+// current version if version is 0, and the version they were read at. It
+// returns no hashes from servers without GetTreeHash.
+func trackedTreeHashes(paths []string, version int64) (map[string]string, int64, error) {
+	if !serverInfo.Supports(poonclient.FeatureTreeHashes) {
+		return nil, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetTreeHash(ctx, &pb.GetTreeHashRequest{Paths: paths, Version: version})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tree hashes: %w", err)
+	}
+	trees := make(map[string]string, len(resp.Hashes))
+	for _, hash := range resp.Hashes {
+		trees[hash.Path] = hash.Hash
+	}
+	return trees, resp.Version, nil
+}
+
+// describeVersion names the version a fetch reads; servers without streaming
+// reads only serve the current one
+func describeVersion(version int64) string {
+	if version == 0 {
+		return "the current version"
+	}
+	return fmt.Sprintf("version %d", version)
+}
+
+type fetchResult struct {
+	file FetchFile
+	size int64
+	err  error
+}
+
+// listTrackedFiles walks the tracked paths at one version and returns a new
+// journal of the files below them
+func listTrackedFiles(paths []string) (*FetchJournal, error) {
+	journal := &FetchJournal{
+		Paths:     slices.Clone(paths),
+		Done:      make(map[string]bool),
+		StartedAt: time.Now(),
+	}
+
+	dirs := slices.Clone(paths)
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		items, version, err := listDirectory(dir, journal.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", dir, err)
+		}
+		journal.Version = version
+
+		for _, item := range items {
+			path := item.Name
+			if dir != "" && dir != "." {
+				path = dir + "/" + item.Name
+			}
+			if item.IsDir {
+				dirs = append(dirs, path)
+				continue
+			}
+			journal.Files = append(journal.Files, FetchFile{Path: path, Hash: item.Hash, Size: item.Size})
+		}
+	}
+
+	// Without the hashes the journal is not resumed, only listed again
+	if journal.Version > 0 {
+		if trees, _, err := trackedTreeHashes(paths, journal.Version); err == nil {
+			journal.Trees = trees
+		}
+	}
+	return journal, nil
+}
+
+// listDirectory lists a directory at version, or at the current version if
+// version is 0, and returns the version it was read from
+func listDirectory(path string, version int64) ([]*pb.DirectoryItem, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if !streamingReads() {
+		// Without versioned reads the listing is of the current tree; a file
+		// that changes before it is fetched fails its hash check
+		resp, err := client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: path})
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Items, 0, nil
+	}
+
+	stream, err := client.StreamDirectory(ctx, &pb.StreamDirectoryRequest{Path: path, Version: version})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var items []*pb.DirectoryItem
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return items, version, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		version = resp.Version
+		items = append(items, resp.Items...)
+	}
+}
+
+// fetchFile downloads a file at version and caches it if its content matches
+// the listed hash. It returns the number of bytes downloaded.
+func fetchFile(file FetchFile, version int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	content, err := readFileAt(ctx, file.Path, version)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", file.Path, err)
+	}
+
+	if hash := blobHash(content); hash != file.Hash {
+		return 0, fmt.Errorf("%s: content hash %s does not match %s", file.Path, hash, file.Hash)
+	}
+	if err := writeCachedBlob(file.Hash, content); err != nil {
+		return 0, fmt.Errorf("%s: %v", file.Path, err)
+	}
+	return int64(len(content)), nil
+}
+
+// readFileAt reads a file at version, or at the current version if version
+// is 0. Unversioned reads send the hash of the path's cached content, which
+// is served from the cache when the server reports it unchanged; versioned
+// reads are only made for content fetchTrackedPaths found missing from the
+// cache.
+func readFileAt(ctx context.Context, path string, version int64) ([]byte, error) {
+	if !streamingReads() {
+		req := &pb.ReadFileRequest{Path: path, IfNotHash: cachedFileHash(path)}
+		resp, err := client.ReadFile(ctx, req)
+		if err == nil && resp.NotModified {
+			if content, ok := readCachedBlob(resp.Hash); ok {
+				return content, nil
+			}
+			req.IfNotHash = ""
+			resp, err = client.ReadFile(ctx, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp.Content, nil
+	}
+
+	stream, err := client.StreamFile(ctx, &pb.StreamFileRequest{Path: path, Version: version})
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return content, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, chunk.Data...)
+	}
+}
+
+// streamingReads reports whether the server has StreamDirectory and
+// StreamFile. Servers that predate GetServerInfo only get the calls every
+// server has; one that could not be asked is assumed to be current.
+func streamingReads() bool {
+	return serverInfo == nil || serverInfo.Supports(poonclient.FeatureStreamingReads)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+)
+
+// fetchServer serves the reads a fetch makes from versions of a tree held
+// in memory. Reads of a path in failing fail.
+type fetchServer struct {
+	pb.MonorepoServiceClient
+	versions []map[string]string // Files by path, for versions 1 on
+	failing  map[string]bool
+	reads    []string // "path@version" of each file read
+}
+
+func (f *fetchServer) files(version int64) (map[string]string, int64) {
+	if version == 0 {
+		version = int64(len(f.versions))
+	}
+	return f.versions[version-1], version
+}
+
+func (f *fetchServer) StreamDirectory(ctx context.Context, req *pb.StreamDirectoryRequest, opts ...grpc.CallOption) (pb.MonorepoService_StreamDirectoryClient, error) {
+	files, version := f.files(req.Version)
+	dirs := make(map[string]bool)
+	var items []*pb.DirectoryItem
+	for name, content := range files {
+		rest, ok := strings.CutPrefix(name, req.Path+"/")
+		if !ok {
+			continue
+		}
+		if dir, _, nested := strings.Cut(rest, "/"); nested {
+			if !dirs[dir] {
+				dirs[dir] = true
+				items = append(items, &pb.DirectoryItem{Name: dir, IsDir: true})
+			}
+			continue
+		}
+		items = append(items, &pb.DirectoryItem{Name: rest, Hash: blobHash([]byte(content)), Size: int64(len(content))})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return &fakeStream[pb.StreamDirectoryResponse]{msgs: []*pb.StreamDirectoryResponse{{Version: version, Items: items}}}, nil
+}
+
+func (f *fetchServer) StreamFile(ctx context.Context, req *pb.StreamFileRequest, opts ...grpc.CallOption) (pb.MonorepoService_StreamFileClient, error) {
+	files, version := f.files(req.Version)
+	f.reads = append(f.reads, fmt.Sprintf("%s@%d", req.Path, version))
+	if f.failing[req.Path] {
+		return nil, errors.New("connection reset")
+	}
+	return &fakeStream[pb.FileChunk]{msgs: []*pb.FileChunk{{Data: []byte(files[req.Path])}}}, nil
+}
+
+// GetTreeHash hashes the paths and contents below each directory, which is
+// enough to tell versions apart
+func (f *fetchServer) GetTreeHash(ctx context.Context, req *pb.GetTreeHashRequest, opts ...grpc.CallOption) (*pb.GetTreeHashResponse, error) {
+	files, version := f.files(req.Version)
+	resp := &pb.GetTreeHashResponse{Version: version}
+	for _, dir := range req.Paths {
+		var names []string
+		for name := range files {
+			if strings.HasPrefix(name, dir+"/") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		h := sha256.New()
+		for _, name := range names {
+			io.WriteString(h, name+"\x00"+files[name]+"\x00")
+		}
+		resp.Hashes = append(resp.Hashes, &pb.TreeHash{Path: dir, Hash: hex.EncodeToString(h.Sum(nil)), IsDir: true, Exists: true})
+	}
+	return resp, nil
+}
+
+// fakeStream returns msgs from Recv, then io.EOF
+type fakeStream[T any] struct {
+	grpc.ClientStream
+	msgs []*T
+}
+
+func (s *fakeStream[T]) Recv() (*T, error) {
+	if len(s.msgs) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+// useFetchServer points the client at f from a new workspace directory
+func useFetchServer(t *testing.T, f *fetchServer) {
+	t.Helper()
+	savedClient, savedInfo := client, serverInfo
+	client = f
+	serverInfo = &poonclient.ServerInfo{Features: []string{poonclient.FeatureStreamingReads, poonclient.FeatureTreeHashes}}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".poon"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client, serverInfo = savedClient, savedInfo
+		os.Chdir(wd)
+	})
+}
+
+// cachedHash returns the hash the cache index holds for a path
+func cachedHash(t *testing.T, path string) string {
+	t.Helper()
+	file, ok := loadCacheIndex().Files[path]
+	if !ok {
+		t.Fatalf("%s is not in the cache index", path)
+	}
+	return file.Hash
+}
+
+func TestFetchResumes(t *testing.T) {
+	f := &fetchServer{
+		versions: []map[string]string{{"src/a.txt": "a", "src/lib/b.txt": "b"}},
+		failing:  map[string]bool{"src/lib/b.txt": true},
+	}
+	useFetchServer(t, f)
+	config := &PoonConfig{WorkspaceConfig: WorkspaceConfig{TrackedPaths: []string{"src"}}}
+
+	if err := fetchTrackedPaths(config, 1); err == nil {
+		t.Fatal("fetch with a failing read succeeded")
+	}
+	journal, err := loadFetchJournal()
+	if err != nil || journal == nil {
+		t.Fatalf("no journal after a failed fetch: %v", err)
+	}
+	if journal.Version != 1 || len(journal.Trees) != 1 || !journal.Done["src/a.txt"] {
+		t.Errorf("journal %+v", journal)
+	}
+
+	// Nothing changed, so only the file that failed is read again
+	f.failing = nil
+	f.reads = nil
+	if err := fetchTrackedPaths(config, 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"src/lib/b.txt@1"}; !slices.Equal(f.reads, want) {
+		t.Errorf("resumed fetch read %v, want %v", f.reads, want)
+	}
+	if journal, _ := loadFetchJournal(); journal != nil {
+		t.Error("journal left after the fetch finished")
+	}
+	if got := cachedHash(t, "src/lib/b.txt"); got != blobHash([]byte("b")) {
+		t.Errorf("cached hash %s", got)
+	}
+}
+
+func TestFetchDoesNotResumeStaleJournal(t *testing.T) {
+	f := &fetchServer{
+		versions: []map[string]string{{"src/a.txt": "a", "src/lib/b.txt": "b"}},
+		failing:  map[string]bool{"src/lib/b.txt": true},
+	}
+	useFetchServer(t, f)
+	config := &PoonConfig{WorkspaceConfig: WorkspaceConfig{TrackedPaths: []string{"src"}}}
+
+	if err := fetchTrackedPaths(config, 1); err == nil {
+		t.Fatal("fetch with a failing read succeeded")
+	}
+
+	// b changes before the fetch is run again, so the journal's listing is
+	// stale and the fetch starts over at the new version
+	f.versions = append(f.versions, map[string]string{"src/a.txt": "a", "src/lib/b.txt": "b2"})
+	f.failing = nil
+	f.reads = nil
+	if err := fetchTrackedPaths(config, 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"src/lib/b.txt@2"}; !slices.Equal(f.reads, want) {
+		t.Errorf("fetch read %v, want %v", f.reads, want)
+	}
+	if got := cachedHash(t, "src/lib/b.txt"); got != blobHash([]byte("b2")) {
+		t.Errorf("cached hash %s is not that of version 2", got)
+	}
+
+	// A journal from a server that could not pin the version is not resumed
+	if err := saveFetchJournal(&FetchJournal{Paths: config.TrackedPaths, Done: map[string]bool{}}); err != nil {
+		t.Fatal(err)
+	}
+	journal, _ := loadFetchJournal()
+	if journal.current(config.TrackedPaths) {
+		t.Error("journal without a version resumed")
+	}
+}
+
+func TestUnversionedFetchSendsCachedHash(t *testing.T) {
+	server := &diffServer{files: map[string]string{"src/a.txt": "one\n"}}
+	useFetchServer(t, nil)
+	client = server
+	serverInfo = &poonclient.ServerInfo{}
+	if err := os.WriteFile(configPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	hash := blobHash([]byte("one\n"))
+	cacheFile("src/a.txt", &pb.ReadFileResponse{Content: []byte("one\n"), Hash: hash})
+	content, err := readFileAt(ctx, "src/a.txt", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "one\n" {
+		t.Errorf("read %q from the cache, want %q", content, "one\n")
+	}
+	if len(server.ifNotHash) != 1 || server.ifNotHash[0] != hash {
+		t.Errorf("reads sent if_not_hash %q, want %q", server.ifNotHash, hash)
+	}
+}
@@ -5,21 +5,31 @@ go 1.23.0
 toolchain go1.23.3
 
 require (
+	github.com/nic/poon/poon-go v0.0.0-00010101000000-000000000000
 	github.com/nic/poon/poon-proto/gen/go v0.0.0-00010101000000-000000000000
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/cobra v1.7.0
+	github.com/zalando/go-keyring v0.2.8
+	golang.org/x/sys v0.33.0
 	google.golang.org/grpc v1.74.2
+	google.golang.org/protobuf v1.36.6
+	lukechampine.com/blake3 v1.4.1
 )
 
 replace github.com/nic/poon => ../
 
 require (
+	github.com/danieljoos/wincred v1.2.3 // indirect
+	github.com/godbus/dbus/v5 v5.2.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/klauspost/cpuid/v2 v2.2.10 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/net v0.40.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250528174236-200df99c418a // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
 
 replace github.com/nic/poon/poon-proto/gen/go => ../poon-proto/gen/go
+
+replace github.com/nic/poon/poon-go => ../poon-go
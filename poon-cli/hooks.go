@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const hooksDir = ".poon/hooks"
+
+// runHook runs the workspace hook with the given name, if one is installed.
+// Hooks receive the tracked paths as arguments and in POON_TRACKED_PATHS
+// (newline separated); a non-zero exit aborts the calling command.
+func runHook(name string, config *PoonConfig) error {
+	hookPath := filepath.Join(hooksDir, name)
+	info, err := os.Stat(hookPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s hook: %v", name, err)
+	}
+
+	// Match git: hooks that are not executable are ignored with a hint.
+	// Windows has no executable bit, so every hook there is run.
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		fmt.Fprintf(os.Stderr, "hint: the %s hook was ignored because it is not executable (chmod +x %s)\n", name, hookPath)
+		return nil
+	}
+
+	absPath, err := filepath.Abs(hookPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s hook: %v", name, err)
+	}
+
+	program, args := hookCommand(runtime.GOOS, absPath, config.TrackedPaths)
+	cmd := exec.Command(program, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"POON_HOOK="+name,
+		"POON_WORKSPACE="+config.WorkspaceName,
+		"POON_TRACKED_PATHS="+strings.Join(config.TrackedPaths, "\n"),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %v", name, err)
+	}
+
+	return nil
+}
+
+// hookCommand returns the program and arguments that run the hook at path.
+// Windows cannot run scripts directly, so there, like git for Windows, hooks
+// other than programs and batch files are run by sh.
+func hookCommand(goos, path string, args []string) (string, []string) {
+	if goos != "windows" {
+		return path, args
+	}
+	// Spelled out rather than filepath.ToSlash so this can be tested anywhere
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	switch strings.ToLower(filepath.Ext(slashed)) {
+	case ".exe", ".bat", ".cmd":
+		return path, args
+	}
+	return "sh", append([]string{slashed}, args...)
+}
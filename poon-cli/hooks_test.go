@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHookCommand(t *testing.T) {
+	args := []string{"src/app", "docs"}
+	tests := []struct {
+		goos, path  string
+		wantProgram string
+		wantArgs    []string
+	}{
+		{"linux", "/repo/.poon/hooks/pre-push", "/repo/.poon/hooks/pre-push", args},
+		{"darwin", "/repo/.poon/hooks/pre-push", "/repo/.poon/hooks/pre-push", args},
+		{"windows", `C:\repo\.poon\hooks\pre-push`, "sh", append([]string{"C:/repo/.poon/hooks/pre-push"}, args...)},
+		{"windows", `C:\repo\.poon\hooks\pre-push.CMD`, `C:\repo\.poon\hooks\pre-push.CMD`, args},
+		{"windows", `C:\repo\.poon\hooks\pre-push.exe`, `C:\repo\.poon\hooks\pre-push.exe`, args},
+	}
+	for _, test := range tests {
+		program, gotArgs := hookCommand(test.goos, test.path, args)
+		if program != test.wantProgram || !reflect.DeepEqual(gotArgs, test.wantArgs) {
+			t.Errorf("hookCommand(%s, %s) = %s %v, want %s %v", test.goos, test.path, program, gotArgs, test.wantProgram, test.wantArgs)
+		}
+	}
+}
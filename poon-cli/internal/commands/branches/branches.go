@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/nic/poon/poon-cli/pkg/client"
+	"github.com/nic/poon/poon-go"
 	pb "github.com/nic/poon/poon-proto/gen/go"
 	"github.com/spf13/cobra"
 )
@@ -16,7 +16,7 @@ func NewCommand() *cobra.Command {
 		Short: "List available branches",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			serverAddr, _ := cmd.Flags().GetString("server")
-			c, err := client.New(serverAddr)
+			c, err := poon.New(serverAddr)
 			if err != nil {
 				return err
 			}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/nic/poon/poon-cli/pkg/client"
+	"github.com/nic/poon/poon-go"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +21,7 @@ func NewCommand() *cobra.Command {
 			}
 
 			serverAddr, _ := cmd.Flags().GetString("server")
-			c, err := client.New(serverAddr)
+			c, err := poon.New(serverAddr)
 			if err != nil {
 				return err
 			}
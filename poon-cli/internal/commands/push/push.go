@@ -3,8 +3,8 @@ package push
 import (
 	"fmt"
 
-	"github.com/nic/poon/poon-cli/pkg/client"
 	"github.com/nic/poon/poon-cli/pkg/config"
+	"github.com/nic/poon/poon-go"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +19,7 @@ func NewCommand() *cobra.Command {
 			}
 
 			serverAddr, _ := cmd.Flags().GetString("server")
-			c, err := client.New(serverAddr)
+			c, err := poon.New(serverAddr)
 			if err != nil {
 				return err
 			}
@@ -7,9 +7,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/nic/poon/poon-cli/pkg/client"
 	"github.com/nic/poon/poon-cli/pkg/config"
 	"github.com/nic/poon/poon-cli/pkg/util"
+	"github.com/nic/poon/poon-go"
 	pb "github.com/nic/poon/poon-proto/gen/go"
 	"github.com/spf13/cobra"
 )
@@ -39,7 +39,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 	gitServerAddr, _ := cmd.Flags().GetString("git-server")
 
 	// Connect to server
-	c, err := client.New(serverAddr)
+	c, err := poon.New(serverAddr)
 	if err != nil {
 		return fmt.Errorf("failed to connect to server: %v", err)
 	}
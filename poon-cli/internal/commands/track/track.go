@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/nic/poon/poon-cli/pkg/client"
 	"github.com/nic/poon/poon-cli/pkg/config"
 	"github.com/nic/poon/poon-cli/pkg/util"
+	"github.com/nic/poon/poon-go"
 	"github.com/spf13/cobra"
 )
 
@@ -30,7 +30,7 @@ func runTrack(cmd *cobra.Command, args []string) error {
 	}
 
 	serverAddr, _ := cmd.Flags().GetString("server")
-	c, err := client.New(serverAddr)
+	c, err := poon.New(serverAddr)
 	if err != nil {
 		return err
 	}
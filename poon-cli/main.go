@@ -3,32 +3,79 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	poonclient "github.com/nic/poon/poon-go"
 	pb "github.com/nic/poon/poon-proto/gen/go"
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
+// clientVersion is reported to the server when creating workspaces and
+// after syncs and pushes
+const clientVersion = "1.0.0"
+
 var (
-	serverAddr    string
-	gitServerAddr string
-	client        pb.MonorepoServiceClient
+	serverAddr           string
+	gitServerAddr        string
+	maxAttempts          int
+	compression          string
+	keepaliveInterval    time.Duration
+	pushNoVerify         bool
+	pushDryRun           bool
+	lsLong               bool
+	catOffset            int64
+	catLength            int64
+	catLineRange         string
+	applyFailIfLocked    bool
+	applyIgnoreSpace     bool
+	applyNormalizeEOL    bool
+	applyKeepEOF         bool
+	applySignKey         string
+	applyBranch          string
+	downloadBranch       string
+	applyAmend           bool
+	applyAuthor          string
+	applyMessage         string
+	applyExpectedVersion int64
+	overrideSizes        bool
+	startFromArchive     bool
+	startName            string
+	startTemplate        string
+	fetchJobs            int
+	client               pb.MonorepoServiceClient
+	conn                 *poonclient.Client
+	connToken            string
+	serverInfo           *poonclient.ServerInfo // nil when the server could not be asked
 )
 
-type PoonConfig struct {
+// WorkspaceConfig describes one server-side workspace backing the checkout
+type WorkspaceConfig struct {
 	WorkspaceName string   `json:"workspaceName"`
 	GitServerURL  string   `json:"gitServerUrl"`
 	GrpcServerURL string   `json:"grpcServerUrl"`
 	TrackedPaths  []string `json:"trackedPaths"`
-	CreatedAt     string   `json:"createdAt"`
+	// Glob patterns the server re-expands on sync; matches are in TrackedPaths
+	TrackedPatterns []string `json:"trackedPatterns,omitempty"`
+	CreatedAt       string   `json:"createdAt"`
+}
+
+// PoonConfig is the contents of .poon/config.json. The embedded workspace is
+// the one created by 'poon start'; Remotes holds further named workspaces
+// added with 'poon remote add'. When --workspace selects a remote, the
+// embedded fields hold that remote's settings until the config is saved.
+type PoonConfig struct {
+	WorkspaceConfig
+	Remotes map[string]*WorkspaceConfig `json:"remotes,omitempty"`
+
+	selected         string          // Remote loaded into WorkspaceConfig, "" for the default
+	defaultWorkspace WorkspaceConfig // Default workspace while a remote is selected
 }
 
 type TrackedPath struct {
@@ -38,29 +85,79 @@ type TrackedPath struct {
 }
 
 func connectToServer() error {
-	conn, err := grpc.Dial(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return connectWithToken(tokenForServer(serverAddr))
+}
+
+// connectWithToken dials the server once and reuses the connection for the
+// rest of the command
+func connectWithToken(token string) error {
+	if conn != nil && connToken == token {
+		return nil
+	}
+	if conn != nil {
+		conn.Close()
+	}
+
+	opts := poonclient.DefaultOptions()
+	opts.Token = token
+	opts.Retry.MaxAttempts = maxAttempts
+	opts.Compression = compression
+	opts.Keepalive = keepaliveInterval
+	opts.UserAgent = "poon-cli/" + clientVersion
+
+	c, err := poonclient.NewWithOptions(serverAddr, opts)
 	if err != nil {
-		return fmt.Errorf("failed to connect to server: %v", err)
+		return err
 	}
 
-	client = pb.NewMonorepoServiceClient(conn)
+	conn = c
+	connToken = token
+	client = c.GetClient()
+	checkServerInfo()
 	return nil
 }
 
+// checkServerInfo learns what the server supports and warns when this client
+// is older than the server accepts. An unreachable server is left for the
+// command's own calls to report.
+func checkServerInfo() {
+	info, err := conn.ServerInfo(context.Background(), clientVersion)
+	if err != nil {
+		serverInfo = nil
+		return
+	}
+
+	serverInfo = info
+	if info.ClientTooOld(clientVersion) {
+		fmt.Fprintf(os.Stderr, "warning: poon %s is older than the oldest client %s supports (%s); some commands may fail until poon is upgraded\n",
+			clientVersion, serverAddr, info.MinClientVersion)
+	}
+}
+
 func loadPoonConfig() (*PoonConfig, error) {
-	configPath := ".poon/config.json"
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("no poon workspace found (run 'poon start' first)")
+	root, err := findWorkspaceRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	// Tracked paths and everything under .poon are relative to the workspace
+	// root, so commands working on the checkout run from there
+	if err := os.Chdir(root); err != nil {
+		return nil, fmt.Errorf("failed to enter workspace root: %w", err)
 	}
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config: %v", err)
+		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
 	var config PoonConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %v", err)
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if err := config.selectWorkspace(workspaceSelector); err != nil {
+		return nil, err
 	}
 
 	return &config, nil
@@ -68,17 +165,16 @@ func loadPoonConfig() (*PoonConfig, error) {
 
 func savePoonConfig(config *PoonConfig) error {
 	if err := os.MkdirAll(".poon", 0755); err != nil {
-		return fmt.Errorf("failed to create .poon directory: %v", err)
+		return fmt.Errorf("failed to create .poon directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := json.MarshalIndent(config.fileContents(), "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %v", err)
+		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	configPath := ".poon/config.json"
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %v", err)
+		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return nil
@@ -95,7 +191,7 @@ func moveDirectoryContents(src, dst string) error {
 	// Read all entries from source directory
 	entries, err := os.ReadDir(src)
 	if err != nil {
-		return fmt.Errorf("failed to read source directory: %v", err)
+		return fmt.Errorf("failed to read source directory: %w", err)
 	}
 
 	// Move each entry
@@ -115,14 +211,34 @@ var rootCmd = &cobra.Command{
 	Use:   "poon",
 	Short: "Poon CLI - Internet-scale monorepo client",
 	Long:  `Poon CLI - A CLI tool for interacting with the Poon monorepo system via gRPC.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat()
+	},
 }
 
 var startCmd = &cobra.Command{
-	Use:   "start <initial-path>",
+	Use:   "start [initial-path]",
 	Short: "Initialize a new poon workspace with initial tracking path",
-	Args:  cobra.ExactArgs(1),
+	Long: `Initialize a new poon workspace tracking initial-path.
+
+initial-path may be a glob pattern, a view, "view:<name>", naming a set of
+paths defined in the repository (see 'poon views'), or another workspace,
+"workspace:<id-or-name>", to track everything that workspace tracks.
+
+With --template the workspace starts from one of the server's templates (see
+'poon workspace templates'), tracking its paths plus initial-path if given.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// A template supplies the paths, so the initial path is optional
+		if startTemplate != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		initialPath := args[0]
+		var initialPath string
+		if len(args) == 1 {
+			initialPath = toRepoPath(args[0])
+		}
 
 		// Check if already initialized
 		if _, err := os.Stat(".poon"); err == nil {
@@ -131,35 +247,58 @@ var startCmd = &cobra.Command{
 
 		// Connect to server
 		if err := connectToServer(); err != nil {
-			return fmt.Errorf("failed to connect to server: %v", err)
+			return fmt.Errorf("failed to connect to server: %w", err)
 		}
 
 		// Test server connectivity and validate path exists
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		_, err := client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{
-			Path: initialPath,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to access initial path '%s': %v", initialPath, err)
+		if startTemplate != "" && serverInfo != nil && !serverInfo.Supports(poonclient.FeatureTemplates) {
+			return fmt.Errorf("server %s has no workspace templates; run 'poon start' with a path instead", serverAddr)
+		}
+
+		if err := checkTrackedPatternSupport(initialPath); err != nil {
+			return err
+		}
+
+		if initialPath != "" && !isTrackedPattern(initialPath) {
+			_, err := client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{
+				Path: initialPath,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to access initial path '%s': %v", initialPath, err)
+			}
 		}
 
 		// Create workspace on server
-		fmt.Printf("Creating workspace with initial path: %s\n", initialPath)
+		var requestedPaths []string
+		if initialPath != "" {
+			requestedPaths = []string{initialPath}
+		}
+		if startTemplate != "" {
+			fmt.Printf("Creating workspace from template: %s\n", startTemplate)
+		} else {
+			fmt.Printf("Creating workspace with initial path: %s\n", initialPath)
+		}
 		createReq := &pb.CreateWorkspaceRequest{
-			Name:         "", // Server will generate UUID
-			TrackedPaths: []string{initialPath},
+			Name:         startName, // Optional alias; the server generates the ID
+			Template:     startTemplate,
+			TrackedPaths: requestedPaths,
 			BaseBranch:   "main",
 			Metadata: map[string]string{
-				"client_version": "1.0.0",
+				"client_version": clientVersion,
 				"created_by":     "poon-cli",
 			},
+			OverrideSizeLimits: overrideSizes,
+			// The server copies the tracked paths in the background and
+			// reports its progress, so large paths do not time out
+			Lazy: serverInfo != nil && serverInfo.Supports(poonclient.FeatureOperations),
 		}
 
 		createResp, err := client.CreateWorkspace(ctx, createReq)
 		if err != nil {
-			return fmt.Errorf("failed to create workspace on server: %v", err)
+			return fmt.Errorf("failed to create workspace on server: %w", err)
 		}
 
 		if !createResp.Success {
@@ -168,21 +307,54 @@ var startCmd = &cobra.Command{
 
 		fmt.Printf("✓ Server created workspace: %s\n", createResp.WorkspaceId)
 
+		// A pattern, view or template is expanded by the server; ask it what
+		// it tracks
+		trackedPaths := requestedPaths
+		var trackedPatterns []string
+		if isTrackedPattern(initialPath) || startTemplate != "" {
+			getResp, err := client.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+			if err != nil {
+				return fmt.Errorf("failed to get workspace: %w", err)
+			}
+			if !getResp.Success {
+				return fmt.Errorf("server failed to get workspace: %s", getResp.Message)
+			}
+			trackedPaths = getResp.Workspace.TrackedPaths
+			trackedPatterns = getResp.Workspace.TrackedPatterns
+		}
+
+		if createResp.OperationId != "" {
+			if _, err := waitForOperation(client.WaitOperation, createResp.OperationId, "Copying tracked paths on the server"); err != nil {
+				return err
+			}
+		}
+
 		// Clone the server-created git repository via poon-git
 		gitRemoteURL := createResp.RemoteUrl
-		fmt.Printf("Cloning workspace repository from server...\n")
 
 		// Create a temporary directory for the clone
 		tempDir := ".poon-temp-clone"
 
-		// Clone the repository
-		if err := runCommand("git", "clone", gitRemoteURL, tempDir); err != nil {
-			return fmt.Errorf("failed to clone workspace repository: %v", err)
+		if startFromArchive {
+			if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureWorkspaceArchive) {
+				return fmt.Errorf("server %s cannot send workspace archives; run 'poon start' without --archive", serverAddr)
+			}
+			fmt.Printf("Bootstrapping workspace repository from an archive...\n")
+			if err := bootstrapFromArchive(createResp.WorkspaceId, gitRemoteURL, tempDir); err != nil {
+				os.RemoveAll(tempDir)
+				return err
+			}
+		} else {
+			// Clone the repository
+			fmt.Printf("Cloning workspace repository from server...\n")
+			if err := runCommand("git", "clone", gitRemoteURL, tempDir); err != nil {
+				return fmt.Errorf("failed to clone workspace repository: %w", err)
+			}
 		}
 
 		// Move contents from temp directory to current directory
 		if err := moveDirectoryContents(tempDir, "."); err != nil {
-			return fmt.Errorf("failed to move cloned repository: %v", err)
+			return fmt.Errorf("failed to move cloned repository: %w", err)
 		}
 
 		// Clean up temp directory
@@ -192,21 +364,28 @@ var startCmd = &cobra.Command{
 
 		// Configure git identity
 		if err := runCommand("git", "config", "user.email", "poon@example.com"); err != nil {
-			return fmt.Errorf("failed to configure git user email: %v", err)
+			return fmt.Errorf("failed to configure git user email: %w", err)
 		}
 		if err := runCommand("git", "config", "user.name", "Poon CLI"); err != nil {
-			return fmt.Errorf("failed to configure git user name: %v", err)
+			return fmt.Errorf("failed to configure git user name: %w", err)
 		}
 
-		fmt.Printf("✓ Successfully cloned workspace repository\n")
+		if startFromArchive {
+			fmt.Printf("✓ Successfully bootstrapped workspace repository\n")
+		} else {
+			fmt.Printf("✓ Successfully cloned workspace repository\n")
+		}
 
 		// Create poon config
 		config := &PoonConfig{
-			WorkspaceName: createResp.WorkspaceId,
-			GitServerURL:  gitServerAddr,
-			GrpcServerURL: serverAddr,
-			TrackedPaths:  []string{initialPath},
-			CreatedAt:     time.Now().Format(time.RFC3339),
+			WorkspaceConfig: WorkspaceConfig{
+				WorkspaceName:   createResp.WorkspaceId,
+				GitServerURL:    gitServerAddr,
+				GrpcServerURL:   serverAddr,
+				TrackedPaths:    trackedPaths,
+				TrackedPatterns: trackedPatterns,
+				CreatedAt:       time.Now().Format(time.RFC3339),
+			},
 		}
 
 		if err := savePoonConfig(config); err != nil {
@@ -241,7 +420,17 @@ var startCmd = &cobra.Command{
 
 		fmt.Printf("✓ Workspace initialized successfully\n")
 		fmt.Printf("   Workspace ID: %s\n", createResp.WorkspaceId)
-		fmt.Printf("   Tracking: %s\n", initialPath)
+		if startName != "" {
+			fmt.Printf("   Name: %s\n", startName)
+		}
+		if startTemplate != "" {
+			fmt.Printf("   Template: %s\n", startTemplate)
+			fmt.Printf("   Tracking: %s\n", strings.Join(trackedPaths, ", "))
+		} else if isViewReference(initialPath) || isWorkspaceReference(initialPath) {
+			fmt.Printf("   Tracking: %s (%s)\n", initialPath, strings.Join(trackedPaths, ", "))
+		} else {
+			fmt.Printf("   Tracking: %s\n", initialPath)
+		}
 		fmt.Printf("   Remote URL: %s\n", gitRemoteURL)
 		fmt.Printf("\nNext steps:\n")
 		fmt.Printf("  poon track <path>     # Track additional directories\n")
@@ -255,7 +444,28 @@ var startCmd = &cobra.Command{
 var trackCmd = &cobra.Command{
 	Use:   "track <path> [path...]",
 	Short: "Track directories from the monorepo",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Track directories from the monorepo.
+
+A path may be a glob pattern: * and ? match within one path component, [...]
+matches a character class and ** matches any number of directories. The server
+tracks what the pattern matches now, and 'poon sync' adds paths that match it
+later. Quote patterns so the shell does not expand them:
+
+  poon track 'src/*/api' '**/*.proto'
+
+A path may also be a view, "view:<name>", naming a set of paths defined in the
+repository's .poon/views.json (see 'poon views'). 'poon sync' adds paths the
+view gains later:
+
+  poon track view:payments
+
+A path may also be another workspace, "workspace:<id-or-name>": this
+workspace then tracks everything that one tracks, including the workspaces it
+builds on, and 'poon sync' adds what it gains later. References that would
+form a cycle are rejected:
+
+  poon track workspace:platform-base`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, err := loadPoonConfig()
 		if err != nil {
@@ -277,26 +487,33 @@ var trackCmd = &cobra.Command{
 		defer cancel()
 		_, err = client.GetBranches(ctx, &pb.BranchesRequest{})
 		if err != nil {
-			return fmt.Errorf("failed to connect to server: %v", err)
+			return fmt.Errorf("failed to connect to server: %w", err)
 		}
 
 		for _, path := range args {
+			path = toRepoPath(path)
+			if err := checkTrackedPatternSupport(path); err != nil {
+				return err
+			}
 			fmt.Printf("Tracking %s...\n", path)
 
-			// Check if path exists in monorepo
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			_, err := client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{
-				Path: path,
-			})
-			cancel()
-
-			if err != nil {
-				return fmt.Errorf("failed to access path %s: %v", path, err)
+			// Check if path exists in monorepo; patterns and views are
+			// expanded by the server
+			if !isTrackedPattern(path) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				_, err := client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{
+					Path: path,
+				})
+				cancel()
+
+				if err != nil {
+					return fmt.Errorf("failed to access path %s: %v", path, err)
+				}
 			}
 
 			// Check if already tracked
 			alreadyTracked := false
-			for _, tracked := range config.TrackedPaths {
+			for _, tracked := range append(append([]string{}, config.TrackedPaths...), config.TrackedPatterns...) {
 				if tracked == path {
 					fmt.Printf("Path %s is already tracked\n", path)
 					alreadyTracked = true
@@ -309,11 +526,12 @@ var trackCmd = &cobra.Command{
 
 			// Use gRPC to add the tracked path to workspace
 			fmt.Printf("  Adding %s to workspace via gRPC...\n", path)
-			ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			addResp, err := client.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{
-				WorkspaceId: config.WorkspaceName,
-				Path:        path,
-				Branch:      "main",
+				WorkspaceId:        config.WorkspaceName,
+				Path:               path,
+				Branch:             "main",
+				OverrideSizeLimits: overrideSizes,
 			})
 			cancel()
 
@@ -326,14 +544,22 @@ var trackCmd = &cobra.Command{
 			}
 
 			// Add to tracked paths in local config
-			config.TrackedPaths = append(config.TrackedPaths, path)
+			if isTrackedPattern(path) {
+				config.TrackedPatterns = append(config.TrackedPatterns, path)
+				config.TrackedPaths = append(config.TrackedPaths, addResp.AddedPaths...)
+				for _, added := range addResp.AddedPaths {
+					fmt.Printf("  + %s\n", added)
+				}
+			} else {
+				config.TrackedPaths = append(config.TrackedPaths, path)
+			}
 			fmt.Printf("  ✓ Successfully added %s to workspace (commit: %s)\n", path, addResp.CommitHash)
 
 			// Pull the updated main branch from remote
 			fmt.Printf("  Pulling latest changes from remote...\n")
-			if err := runCommand("git", "pull", "origin", "main"); err != nil {
+			if err := runCommand("git", "pull", config.gitRemote(), "main"); err != nil {
 				fmt.Printf("  Warning: failed to pull from remote: %v\n", err)
-				fmt.Printf("  You can pull later with: git pull origin main\n")
+				fmt.Printf("  You can pull later with: git pull %s main\n", config.gitRemote())
 			}
 		}
 
@@ -341,6 +567,10 @@ var trackCmd = &cobra.Command{
 			return err
 		}
 
+		if err := fetchTrackedPaths(config, fetchJobs); err != nil {
+			fmt.Printf("Warning: failed to cache tracked files: %v\n", err)
+		}
+
 		fmt.Printf("✓ Successfully tracked %d path(s)\n", len(args))
 		fmt.Printf("  Tracked paths: %v\n", config.TrackedPaths)
 		fmt.Printf("  Remote is synced with main branch\n")
@@ -351,23 +581,41 @@ var trackCmd = &cobra.Command{
 var pushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Push local changes back to the monorepo",
+	Long: `Push local changes back to the monorepo.
+
+If an executable .poon/hooks/pre-push script exists it is run first, with the
+tracked paths as arguments; a non-zero exit aborts the push. Use --no-verify
+to skip it.
+
+With --dry-run each changed file is applied to the current monorepo version
+in memory and the result is reported; nothing is pushed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := loadPoonConfig()
+		config, err := loadPoonConfig()
 		if err != nil {
 			return err
 		}
 
+		if !pushNoVerify {
+			if err := runHook("pre-push", config); err != nil {
+				return err
+			}
+		}
+
 		if err := connectToServer(); err != nil {
 			return err
 		}
 
+		if pushDryRun {
+			return previewPush(config)
+		}
+
 		// Test server connectivity by attempting to get branches
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		_, err = client.GetBranches(ctx, &pb.BranchesRequest{})
 		if err != nil {
-			return fmt.Errorf("failed to connect to server: %v", err)
+			return fmt.Errorf("failed to connect to server: %w", err)
 		}
 
 		// TODO: Calculate diffs for each tracked path
@@ -375,6 +623,7 @@ var pushCmd = &cobra.Command{
 		// TODO: Send patches to poon-server for merging
 
 		fmt.Println("✓ Changes pushed to monorepo")
+		reportWorkspaceStatus(config, "push")
 		return nil
 	},
 }
@@ -383,7 +632,7 @@ var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync with latest monorepo state",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := loadPoonConfig()
+		config, err := loadPoonConfig()
 		if err != nil {
 			return err
 		}
@@ -398,13 +647,21 @@ var syncCmd = &cobra.Command{
 
 		_, err = client.GetBranches(ctx, &pb.BranchesRequest{})
 		if err != nil {
-			return fmt.Errorf("failed to connect to server: %v", err)
+			return fmt.Errorf("failed to connect to server: %w", err)
+		}
+
+		if err := refreshTrackedPatterns(config); err != nil {
+			fmt.Printf("Warning: failed to refresh tracked patterns: %v\n", err)
+		}
+
+		if err := fetchTrackedPaths(config, fetchJobs); err != nil {
+			fmt.Printf("Warning: failed to cache tracked files: %v\n", err)
 		}
 
-		// TODO: Fetch latest state for tracked paths
 		// TODO: Merge/rebase with local changes
 
 		fmt.Println("✓ Synced with monorepo")
+		reportWorkspaceStatus(config, "sync")
 		return nil
 	},
 }
@@ -418,13 +675,58 @@ var statusCmd = &cobra.Command{
 			return err
 		}
 
-		fmt.Printf("Workspace: %s\n", config.WorkspaceName)
+		output := StatusOutput{
+			Workspace:     config.WorkspaceName,
+			Name:          config.workspaceName(),
+			GitServerURL:  config.GitServerURL,
+			GrpcServerURL: config.GrpcServerURL,
+			CreatedAt:     config.CreatedAt,
+			TrackedPaths:  config.TrackedPaths,
+		}
+		if len(config.TrackedPaths) > 0 {
+			output.Paths, output.ServerVersion, err = trackedPathStatus(config.TrackedPaths)
+			if err != nil {
+				output.ServerError = err.Error()
+			}
+		}
+
+		if isJSONOutput() {
+			return printJSON(output)
+		}
+
+		fmt.Printf("Workspace: %s (%s)\n", config.WorkspaceName, config.workspaceName())
 		fmt.Printf("Git Server: %s\n", config.GitServerURL)
 		fmt.Printf("gRPC Server: %s\n", config.GrpcServerURL)
 		fmt.Printf("Created: %s\n", config.CreatedAt)
 		fmt.Printf("\nTracked Paths (%d):\n", len(config.TrackedPaths))
-		for _, path := range config.TrackedPaths {
-			fmt.Printf("  %s\n", path)
+		if output.Paths == nil {
+			for _, path := range config.TrackedPaths {
+				fmt.Printf("  %s\n", path)
+			}
+			if output.ServerError != "" {
+				fmt.Printf("\nCould not check for server changes: %s\n", output.ServerError)
+			}
+			return nil
+		}
+
+		behind := 0
+		for _, path := range output.Paths {
+			switch path.State {
+			case pathUpToDate:
+				fmt.Printf("  %s  ✓ up to date\n", path.Path)
+			case pathChanged:
+				behind++
+				fmt.Printf("  %s  ✗ changed on the server since version %d\n", path.Path, path.SyncedVersion)
+			case pathDeleted:
+				behind++
+				fmt.Printf("  %s  ✗ deleted on the server\n", path.Path)
+			default:
+				behind++
+				fmt.Printf("  %s  ? not synced yet\n", path.Path)
+			}
+		}
+		if behind > 0 {
+			fmt.Printf("\n%d path(s) behind version %d; run 'poon sync' to update\n", behind, output.ServerVersion)
 		}
 
 		return nil
@@ -438,7 +740,7 @@ var lsCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := "."
 		if len(args) > 0 {
-			path = args[0]
+			path = toRepoPath(args[0])
 		}
 
 		if err := connectToServer(); err != nil {
@@ -448,18 +750,68 @@ var lsCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		resp, err := client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{
-			Path: path,
-		})
+		// Listings with history are always fetched: the last changes can move
+		// while the tree stays the same
+		req := &pb.ReadDirectoryRequest{
+			Path:        path,
+			WithHistory: lsLong,
+		}
+		if !lsLong {
+			req.IfNotHash = cachedDirectoryHash(path)
+		}
+
+		var entries []LsEntry
+		resp, err := client.ReadDirectory(ctx, req)
+		if err == nil && resp.NotModified {
+			if cached, _, ok := cachedDirectory(path); ok {
+				entries = cached
+			} else {
+				// The cached listing went away after its hash was read
+				req.IfNotHash = ""
+				resp, err = client.ReadDirectory(ctx, req)
+			}
+		}
 		if err != nil {
-			return fmt.Errorf("failed to list directory: %v", err)
+			cached, cachedAt, ok := cachedDirectory(path)
+			if !isServerUnavailable(err) || !ok {
+				return fmt.Errorf("failed to list directory: %w", err)
+			}
+			warnStale(cachedAt)
+			entries = cached
+		} else if entries == nil {
+			entries = []LsEntry{}
+			for _, item := range resp.Items {
+				entryType := "file"
+				if item.IsDir {
+					entryType = "dir"
+				}
+				entries = append(entries, LsEntry{
+					Name:          item.Name,
+					Type:          entryType,
+					Size:          item.Size,
+					ModTime:       item.ModTime,
+					Mode:          item.Mode,
+					LastVersion:   item.LastVersion,
+					LastCommit:    item.LastCommit,
+					LastAuthor:    item.LastAuthor,
+					LastMessage:   item.LastMessage,
+					LastTimestamp: item.LastTimestamp,
+				})
+			}
+			cacheDirectory(path, resp.Hash, entries)
 		}
 
-		for _, item := range resp.Items {
-			if item.IsDir {
-				fmt.Printf("d %s/\n", item.Name)
+		if isJSONOutput() {
+			return printJSON(LsOutput{Path: path, Entries: entries})
+		}
+
+		for _, entry := range entries {
+			if lsLong {
+				printLongEntry(entry)
+			} else if entry.Type == "dir" {
+				fmt.Printf("d %s/\n", entry.Name)
 			} else {
-				fmt.Printf("f %s (%d bytes)\n", item.Name, item.Size)
+				fmt.Printf("f %s (%d bytes)\n", entry.Name, entry.Size)
 			}
 		}
 
@@ -467,10 +819,38 @@ var lsCmd = &cobra.Command{
 	},
 }
 
+// printLongEntry prints one line of `poon ls -l`: mode, size, the version,
+// author and date of the last change, the name and the change's message
+func printLongEntry(entry LsEntry) {
+	mode := os.FileMode(entry.Mode).Perm()
+	name, size := entry.Name, fmt.Sprintf("%d", entry.Size)
+	if entry.Type == "dir" {
+		if mode == 0 {
+			mode = 0755 // Directories imported from disk carry no permissions
+		}
+		mode |= os.ModeDir
+		name, size = name+"/", "-"
+	}
+
+	version, author, date := "-", "-", "-"
+	if entry.LastVersion > 0 {
+		version = fmt.Sprintf("v%d", entry.LastVersion)
+		author = entry.LastAuthor
+		date = time.Unix(entry.LastTimestamp, 0).Format("2006-01-02")
+	}
+
+	message, _, _ := strings.Cut(entry.LastMessage, "\n")
+	if runes := []rune(message); len(runes) > 50 {
+		message = string(runes[:47]) + "..."
+	}
+
+	fmt.Printf("%s %10s %6s %-16s %s  %-24s %s\n", mode, size, version, author, date, name, message)
+}
+
 var catCmd = &cobra.Command{
-	Use:   "cat <file>",
+	Use:   "cat <file> [file...]",
 	Short: "Display file contents",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := connectToServer(); err != nil {
 			return err
@@ -479,48 +859,229 @@ var catCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		resp, err := client.ReadFile(ctx, &pb.ReadFileRequest{
-			Path: args[0],
-		})
-		if err != nil {
-			return fmt.Errorf("failed to read file: %v", err)
+		if catLineRange != "" {
+			if len(args) != 1 || catOffset != 0 || catLength != 0 {
+				return fmt.Errorf("--lines reads one file, without --offset or --length")
+			}
+			return catLines(ctx, toRepoPath(args[0]), catLineRange)
 		}
 
-		fmt.Print(string(resp.Content))
-		return nil
+		if catOffset != 0 || catLength != 0 {
+			if len(args) != 1 {
+				return fmt.Errorf("--offset and --length read one file")
+			}
+			return catRange(ctx, toRepoPath(args[0]), catOffset, catLength)
+		}
+
+		if len(args) == 1 {
+			content, err := catFile(ctx, toRepoPath(args[0]))
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			fmt.Print(string(content))
+			return nil
+		}
+
+		return catFiles(ctx, args)
 	},
 }
 
+// catFile reads one file, from the local cache when the server says it is
+// unchanged or cannot be reached
+func catFile(ctx context.Context, path string) ([]byte, error) {
+	req := &pb.ReadFileRequest{
+		Path:      path,
+		IfNotHash: cachedFileHash(path),
+	}
+	resp, err := client.ReadFile(ctx, req)
+	if err == nil && resp.NotModified {
+		if content, _, ok := cachedFile(path); ok {
+			return content, nil
+		}
+		// The cached content went away after its hash was read
+		req.IfNotHash = ""
+		resp, err = client.ReadFile(ctx, req)
+	}
+	if err != nil {
+		content, cachedAt, ok := cachedFile(path)
+		if !isServerUnavailable(err) || !ok {
+			return nil, err
+		}
+		warnStale(cachedAt)
+		return content, nil
+	}
+
+	cacheFile(path, resp)
+	return resp.Content, nil
+}
+
+// catRange prints part of a file, reading only that part from the server
+func catRange(ctx context.Context, path string, offset, length int64) error {
+	if offset < 0 || length < 0 {
+		return fmt.Errorf("--offset and --length must not be negative")
+	}
+	if !serverInfo.Supports(poonclient.FeatureRangeReads) {
+		return fmt.Errorf("server does not support range reads; upgrade poon-server or read the whole file")
+	}
+
+	resp, err := client.ReadFile(ctx, &pb.ReadFileRequest{Path: path, Offset: offset, Length: length})
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	_, err = os.Stdout.Write(resp.Content)
+	return err
+}
+
+// catFiles prints several files in order, all read at one version when the
+// server has ReadFiles. Files that cannot be read are reported and skipped.
+func catFiles(ctx context.Context, paths []string) error {
+	failed := 0
+	report := func(path, message string, details *poonclient.ErrorDetails) {
+		failed++
+		fmt.Fprintf(os.Stderr, "✗ %s: %s\n", path, message)
+		printHints(os.Stderr, "  ", remediationHints(details))
+	}
+
+	if !serverInfo.Supports(poonclient.FeatureBatchReads) {
+		for _, path := range paths {
+			content, err := catFile(ctx, path)
+			if err != nil {
+				report(path, err.Error(), poonclient.Details(err))
+				continue
+			}
+			fmt.Print(string(content))
+		}
+	} else {
+		results, _, err := conn.ReadChangedFiles(ctx, paths, cachedFileHashes(paths), 0)
+		if err != nil {
+			return fmt.Errorf("failed to read files: %w", err)
+		}
+		for _, result := range results {
+			if result.Error != "" {
+				report(result.Path, result.Error, poonclient.FailureDetails(result.Failure))
+				continue
+			}
+			if result.NotModified {
+				content, ok := readCachedBlob(result.Hash)
+				if !ok {
+					// The cached content went away after its hash was sent
+					if content, err = catFile(ctx, result.Path); err != nil {
+						report(result.Path, err.Error(), poonclient.Details(err))
+						continue
+					}
+				}
+				fmt.Print(string(content))
+				continue
+			}
+			cacheFile(result.Path, &pb.ReadFileResponse{Content: result.Content, Hash: result.Hash, Size: result.Size})
+			fmt.Print(string(result.Content))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to read %d of %d files", failed, len(paths))
+	}
+	return nil
+}
+
 var applyCmd = &cobra.Command{
-	Use:   "apply <patch-file>",
+	Use:   "apply <patch-file|-|url>",
 	Short: "Apply a patch to the monorepo",
-	Args:  cobra.ExactArgs(1),
+	Long: `Apply a unified diff to the monorepo. The patch is read from a file, from
+standard input for "-", or from an http(s) URL. The files it changes are
+named by its own headers. A patch changing several files is previewed file
+by file first, so one that would not apply changes nothing; it is then
+applied a file at a time, and if a file still fails the files that landed
+before it are listed and the command fails.
+
+  git diff | poon apply -
+  poon apply https://example.com/fix.patch --expected-version 120`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		patchContent, err := os.ReadFile(args[0])
+		source := args[0]
+		patchContent, err := readPatchSource(source, os.Stdin)
 		if err != nil {
-			return fmt.Errorf("failed to read patch file: %v", err)
+			return err
+		}
+		patches := splitPatch(patchContent)
+		if len(patches) == 0 {
+			return fmt.Errorf("no file changes found in the patch from %s", source)
+		}
+		for _, filePatch := range patches {
+			if filePatch.Path == "" {
+				return fmt.Errorf("could not tell which file a patch section changes from its headers")
+			}
 		}
 
 		if err := connectToServer(); err != nil {
 			return err
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		message := applyMessage
+		if message == "" {
+			if source == "-" {
+				source = "stdin"
+			}
+			message = fmt.Sprintf("Applied patch from %s", source)
+		}
+		author := applyAuthor
+		if author == "" {
+			author = localUser()
+		}
 
-		resp, err := client.MergePatch(ctx, &pb.MergePatchRequest{
-			Path:    ".",
-			Patch:   patchContent,
-			Message: fmt.Sprintf("Applied patch from %s", args[0]),
-		})
-		if err != nil {
-			return fmt.Errorf("failed to apply patch: %v", err)
+		var requests []*pb.MergePatchRequest
+		for _, filePatch := range patches {
+			var signature []byte
+			if applySignKey != "" {
+				if signature, err = signPatch(applySignKey, message, filePatch.Patch); err != nil {
+					return err
+				}
+			}
+			requests = append(requests, &pb.MergePatchRequest{
+				Path:            filePatch.Path,
+				Patch:           filePatch.Patch,
+				Message:         message,
+				Author:          author,
+				FailIfLocked:    applyFailIfLocked,
+				Signature:       signature,
+				Branch:          applyBranch,
+				Amend:           applyAmend,
+				ExpectedVersion: applyExpectedVersion,
+
+				IgnoreWhitespace:        applyIgnoreSpace,
+				NormalizeLineEndings:    applyNormalizeEOL,
+				PreserveTrailingNewline: applyKeepEOF,
+			})
 		}
 
-		if resp.Success {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ Failed to apply patch: %s\n", resp.Message)
+		// An amend may carry an empty patch, which has nothing to preview
+		if len(requests) > 1 && !applyAmend {
+			if err := previewApply(requests); err != nil {
+				return err
+			}
+		}
+
+		var landed []string
+		for _, req := range requests {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			resp, err := client.MergePatch(ctx, req)
+			cancel()
+			if err != nil {
+				return partialApplyError(landed, req.Path, len(requests), err)
+			}
+
+			if !resp.Success {
+				fmt.Printf("✗ Failed to apply patch to %s: %s\n", req.Path, resp.Message)
+				printFailureHints("  ", resp.Failure)
+				return partialApplyError(landed, req.Path, len(requests), errors.New(resp.Message))
+			}
+			landed = append(landed, req.Path)
+			if len(requests) > 1 {
+				fmt.Printf("✓ %s: %s\n", req.Path, resp.Message)
+			} else {
+				fmt.Printf("✓ %s\n", resp.Message)
+			}
+			fmt.Print(formatChangeStats(resp.Stats))
 		}
 
 		return nil
@@ -540,7 +1101,14 @@ var branchesCmd = &cobra.Command{
 
 		resp, err := client.GetBranches(ctx, &pb.BranchesRequest{})
 		if err != nil {
-			return fmt.Errorf("failed to get branches: %v", err)
+			return fmt.Errorf("failed to get branches: %w", err)
+		}
+
+		if isJSONOutput() {
+			return printJSON(BranchesOutput{
+				Branches:      resp.Branches,
+				DefaultBranch: resp.DefaultBranch,
+			})
 		}
 
 		fmt.Printf("Available branches:\n")
@@ -556,6 +1124,57 @@ var branchesCmd = &cobra.Command{
 	},
 }
 
+var viewsCmd = &cobra.Command{
+	Use:   "views",
+	Short: "List the path views defined in the repository",
+	Long: `List the path views defined in the repository's .poon/views.json.
+
+A view names a set of paths or glob patterns. Track one with
+'poon track view:<name>' or 'poon start view:<name>'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureViews) {
+			return fmt.Errorf("server %s has no path views", serverAddr)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.ListViews(ctx, &pb.ListViewsRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to list views: %w", err)
+		}
+		if !resp.Success {
+			return fmt.Errorf("server failed to list views: %s", resp.Message)
+		}
+
+		if isJSONOutput() {
+			out := make([]ViewOutput, 0, len(resp.Views))
+			for _, view := range resp.Views {
+				out = append(out, ViewOutput{
+					Name:        view.Name,
+					Description: view.Description,
+					Paths:       view.Paths,
+				})
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Views) == 0 {
+			fmt.Println("No path views")
+			return nil
+		}
+		for _, view := range resp.Views {
+			fmt.Printf("view:%s\t%s\n", view.Name, view.Description)
+			fmt.Printf("  Paths: %s\n", strings.Join(view.Paths, ", "))
+		}
+		return nil
+	},
+}
+
 var createBranchCmd = &cobra.Command{
 	Use:   "create-branch <name> [from-branch]",
 	Short: "Create a new branch",
@@ -579,7 +1198,7 @@ var createBranchCmd = &cobra.Command{
 			FromBranch: fromBranch,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create branch: %v", err)
+			return fmt.Errorf("failed to create branch: %w", err)
 		}
 
 		if resp.Success {
@@ -607,11 +1226,27 @@ var historyCmd = &cobra.Command{
 		defer cancel()
 
 		resp, err := client.GetFileHistory(ctx, &pb.FileHistoryRequest{
-			Path:  args[0],
+			Path:  toRepoPath(args[0]),
 			Limit: 10,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to get file history: %v", err)
+			return fmt.Errorf("failed to get file history: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := HistoryOutput{Path: args[0], Commits: []CommitOutput{}}
+			for _, commit := range resp.Commits {
+				out.Commits = append(out.Commits, CommitOutput{
+					Hash:         commit.Hash,
+					Author:       commit.Author,
+					Message:      commit.Message,
+					Timestamp:    commit.Timestamp,
+					ChangedFiles: commit.ChangedFiles,
+					Path:         commit.Path,
+					OldPath:      commit.OldPath,
+				})
+			}
+			return printJSON(out)
 		}
 
 		fmt.Printf("History for %s:\n", args[0])
@@ -620,12 +1255,177 @@ var historyCmd = &cobra.Command{
 			fmt.Printf("Author: %s\n", commit.Author)
 			fmt.Printf("Date: %s\n", time.Unix(commit.Timestamp, 0).Format(time.RFC3339))
 			fmt.Printf("Message: %s\n", commit.Message)
+			if commit.OldPath != "" {
+				fmt.Printf("Path: %s (from %s)\n", commit.Path, commit.OldPath)
+			}
 		}
 
 		return nil
 	},
 }
 
+var infoCmd = &cobra.Command{
+	Use:   "info [path]",
+	Short: "Summarize a file or directory",
+	Long: `Summarize a file or directory: entry counts, total size, the last change,
+its README and the owners from the nearest OWNERS file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = toRepoPath(args[0])
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: path})
+		if err != nil {
+			return fmt.Errorf("failed to get path info: %w", err)
+		}
+
+		out := InfoOutput{
+			Path:              resp.Path,
+			Type:              "file",
+			Version:           resp.Version,
+			Files:             resp.Files,
+			Directories:       resp.Directories,
+			TotalFiles:        resp.TotalFiles,
+			TotalSize:         resp.TotalSize,
+			LastChangeVersion: resp.LastChangeVersion,
+			ReadmePath:        resp.ReadmePath,
+			Readme:            string(resp.ReadmeContent),
+			ReadmeTruncated:   resp.ReadmeTruncated,
+			Owners:            resp.Owners,
+			OwnersPath:        resp.OwnersPath,
+			Attributes:        resp.Attributes,
+		}
+		if resp.IsDir {
+			out.Type = "dir"
+		}
+		if resp.LastChange != nil {
+			out.LastChange = &CommitOutput{
+				Hash:      resp.LastChange.Hash,
+				Author:    resp.LastChange.Author,
+				Message:   resp.LastChange.Message,
+				Timestamp: resp.LastChange.Timestamp,
+			}
+		}
+
+		if isJSONOutput() {
+			return printJSON(out)
+		}
+
+		if out.Path == "" {
+			out.Path = "/"
+		}
+		fmt.Printf("Path: %s (%s)\n", out.Path, out.Type)
+		fmt.Printf("Version: %d\n", out.Version)
+		if resp.IsDir {
+			fmt.Printf("Entries: %d files, %d directories\n", out.Files, out.Directories)
+			fmt.Printf("Total: %d files, %d bytes\n", out.TotalFiles, out.TotalSize)
+		} else {
+			fmt.Printf("Size: %d bytes\n", out.TotalSize)
+		}
+		if out.LastChange != nil {
+			message, _, _ := strings.Cut(out.LastChange.Message, "\n")
+			fmt.Printf("Last change: v%d by %s on %s\n", out.LastChangeVersion, out.LastChange.Author,
+				time.Unix(out.LastChange.Timestamp, 0).Format(time.RFC3339))
+			fmt.Printf("   %s\n", message)
+		}
+		if out.OwnersPath != "" {
+			fmt.Printf("Owners: %s (from %s)\n", strings.Join(out.Owners, ", "), out.OwnersPath)
+		}
+		if len(out.Attributes) > 0 {
+			fmt.Printf("Attributes: %s\n", formatAttributes(out.Attributes))
+		}
+		if out.ReadmePath != "" {
+			fmt.Printf("\n%s:\n\n%s", out.ReadmePath, out.Readme)
+			if !strings.HasSuffix(out.Readme, "\n") {
+				fmt.Println()
+			}
+			if out.ReadmeTruncated {
+				fmt.Println("[README truncated]")
+			}
+		}
+
+		return nil
+	},
+}
+
+// formatAttributes formats attributes as .poonattributes would set them:
+// "text", "-diff" or "eol=crlf", sorted by name
+func formatAttributes(attributes map[string]string) string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, len(names))
+	for i, name := range names {
+		switch value := attributes[name]; value {
+		case "set":
+			fields[i] = name
+		case "unset":
+			fields[i] = "-" + name
+		default:
+			fields[i] = name + "=" + value
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+var collisionsCmd = &cobra.Command{
+	Use:   "collisions [path]",
+	Short: "List paths that differ only in case",
+	Long: `List paths that differ only in case. Case-insensitive file systems, the
+default on macOS and Windows, cannot hold both, so workspaces tracking them
+cannot be created.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = toRepoPath(args[0])
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		resp, err := client.ListCaseCollisions(ctx, &pb.ListCaseCollisionsRequest{Path: path})
+		if err != nil {
+			return fmt.Errorf("failed to list case collisions: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := CollisionsOutput{Path: path, Version: resp.Version, Collisions: [][]string{}}
+			for _, collision := range resp.Collisions {
+				out.Collisions = append(out.Collisions, collision.Paths)
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Collisions) == 0 {
+			fmt.Printf("✓ No case collisions under %s\n", path)
+			return nil
+		}
+
+		fmt.Printf("✗ %d case collisions under %s:\n", len(resp.Collisions), path)
+		for _, collision := range resp.Collisions {
+			fmt.Printf("  %s\n", strings.Join(collision.Paths, ", "))
+		}
+		return nil
+	},
+}
+
 var workspaceCmd = &cobra.Command{
 	Use:   "workspace",
 	Short: "Workspace management commands",
@@ -634,7 +1434,10 @@ var workspaceCmd = &cobra.Command{
 var createWorkspaceCmd = &cobra.Command{
 	Use:   "create <name>",
 	Short: "Create a new workspace",
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new workspace. The name is an alias accepted wherever the
+workspace's ID is, including its git URL: lowercase letters, digits, '.', '_'
+and '-', unique on the server.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := connectToServer(); err != nil {
 			return err
@@ -647,7 +1450,16 @@ var createWorkspaceCmd = &cobra.Command{
 			Name: args[0],
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create workspace: %v", err)
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+
+		if isJSONOutput() {
+			return printJSON(WorkspaceOutput{
+				Success:   resp.Success,
+				Message:   resp.Message,
+				ID:        resp.WorkspaceId,
+				RemoteURL: resp.RemoteUrl,
+			})
 		}
 
 		if resp.Success {
@@ -663,7 +1475,7 @@ var createWorkspaceCmd = &cobra.Command{
 }
 
 var getWorkspaceCmd = &cobra.Command{
-	Use:   "get <workspace-id>",
+	Use:   "get <workspace-id-or-name>",
 	Short: "Get workspace information",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -678,7 +1490,22 @@ var getWorkspaceCmd = &cobra.Command{
 			WorkspaceId: args[0],
 		})
 		if err != nil {
-			return fmt.Errorf("failed to get workspace: %v", err)
+			return fmt.Errorf("failed to get workspace: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := WorkspaceOutput{Success: resp.Success, Message: resp.Message}
+			if ws := resp.Workspace; ws != nil {
+				out.ID = ws.Id
+				out.Name = ws.Name
+				out.Status = ws.Status.String()
+				out.CreatedAt = ws.CreatedAt
+				out.LastSync = ws.LastSync
+				out.TrackedPaths = ws.TrackedPaths
+				out.TrackedPatterns = ws.TrackedPatterns
+				out.Metadata = ws.Metadata
+			}
+			return printJSON(out)
 		}
 
 		if resp.Success {
@@ -687,12 +1514,24 @@ var getWorkspaceCmd = &cobra.Command{
 			fmt.Printf("ID: %s\n", ws.Id)
 			fmt.Printf("Name: %s\n", ws.Name)
 			fmt.Printf("Status: %s\n", ws.Status)
+			if ws.Status == pb.WorkspaceStatus_SYNCING && ws.FilesTotal > 0 {
+				fmt.Printf("Copying: %d of %d files\n", ws.FilesCopied, ws.FilesTotal)
+			}
+			if ws.StatusMessage != "" {
+				fmt.Printf("Status Message: %s\n", ws.StatusMessage)
+			}
 			fmt.Printf("Created: %s\n", ws.CreatedAt)
 			fmt.Printf("Last Sync: %s\n", ws.LastSync)
 			fmt.Printf("Tracked Paths (%d):\n", len(ws.TrackedPaths))
 			for _, path := range ws.TrackedPaths {
 				fmt.Printf("  %s\n", path)
 			}
+			if len(ws.TrackedPatterns) > 0 {
+				fmt.Printf("Tracked Patterns (%d):\n", len(ws.TrackedPatterns))
+				for _, pattern := range ws.TrackedPatterns {
+					fmt.Printf("  %s\n", pattern)
+				}
+			}
 		} else {
 			fmt.Printf("✗ %s\n", resp.Message)
 		}
@@ -701,6 +1540,51 @@ var getWorkspaceCmd = &cobra.Command{
 	},
 }
 
+var listTemplatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List the workspace templates 'poon start --template' accepts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureTemplates) {
+			return fmt.Errorf("server %s has no workspace templates", serverAddr)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.ListTemplates(ctx, &pb.ListTemplatesRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to list templates: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := make([]TemplateOutput, 0, len(resp.Templates))
+			for _, template := range resp.Templates {
+				out = append(out, TemplateOutput{
+					Name:         template.Name,
+					Description:  template.Description,
+					TrackedPaths: template.TrackedPaths,
+					Metadata:     template.Metadata,
+				})
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Templates) == 0 {
+			fmt.Println("No workspace templates")
+			return nil
+		}
+		for _, template := range resp.Templates {
+			fmt.Printf("%s\t%s\n", template.Name, template.Description)
+			fmt.Printf("  Tracks: %s\n", strings.Join(template.TrackedPaths, ", "))
+		}
+		return nil
+	},
+}
+
 var sparseCheckoutCmd = &cobra.Command{
 	Use:   "sparse-checkout <path1> [path2...]",
 	Short: "Configure sparse checkout",
@@ -717,7 +1601,7 @@ var sparseCheckoutCmd = &cobra.Command{
 			Paths: args,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to configure sparse checkout: %v", err)
+			return fmt.Errorf("failed to configure sparse checkout: %w", err)
 		}
 
 		if resp.Success {
@@ -743,15 +1627,29 @@ var downloadCmd = &cobra.Command{
 			return err
 		}
 
+		req := &pb.DownloadPathRequest{
+			Path:   toRepoPath(args[0]),
+			Branch: downloadBranch,
+			Format: "tar.gz",
+		}
+		if serverInfo != nil && serverInfo.Supports(poonclient.FeatureStreamingDownloads) {
+			return streamDownload(req)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		resp, err := client.DownloadPath(ctx, &pb.DownloadPathRequest{
-			Path:   args[0],
-			Format: "tar.gz",
-		})
+		// The server archives large paths in the background, so the call
+		// returns at once and the archive arrives when the operation is done
+		req.Async = serverInfo != nil && serverInfo.Supports(poonclient.FeatureOperations)
+		resp, err := client.DownloadPath(ctx, req)
 		if err != nil {
-			return fmt.Errorf("failed to download path: %v", err)
+			return fmt.Errorf("failed to download path: %w", err)
+		}
+		if resp.Success {
+			if err := awaitResponse(client.WaitOperation, resp.OperationId, "Archiving", resp); err != nil {
+				return fmt.Errorf("failed to download path: %w", err)
+			}
 		}
 
 		if resp.Success {
@@ -761,7 +1659,7 @@ var downloadCmd = &cobra.Command{
 
 			// Write content to file
 			if err := os.WriteFile(resp.Filename, resp.Content, 0644); err != nil {
-				return fmt.Errorf("failed to write download file: %v", err)
+				return fmt.Errorf("failed to write download file: %w", err)
 			}
 			fmt.Printf("Saved to: %s\n", resp.Filename)
 		} else {
@@ -775,6 +1673,36 @@ var downloadCmd = &cobra.Command{
 func init() {
 	rootCmd.PersistentFlags().StringVar(&serverAddr, "server", "localhost:50051", "gRPC server address")
 	rootCmd.PersistentFlags().StringVar(&gitServerAddr, "git-server", "localhost:3000", "Git server address")
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", poonclient.DefaultRetryPolicy().MaxAttempts, "Maximum attempts for idempotent RPCs on transient failures")
+	rootCmd.PersistentFlags().StringVar(&compression, "compression", os.Getenv("POON_COMPRESSION"), "Compress requests and responses: gzip, zstd or none (default $POON_COMPRESSION)")
+	rootCmd.PersistentFlags().DurationVar(&keepaliveInterval, "keepalive", poonclient.DefaultOptions().Keepalive, "Interval between keepalive pings on an idle connection (0 disables)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text or json")
+	rootCmd.PersistentFlags().StringVarP(&workspaceSelector, "workspace", "w", "", "Named workspace to use when the checkout has several (see 'poon remote')")
+
+	lsCmd.Flags().BoolVarP(&lsLong, "long", "l", false, "Show mode, size and the last change to each entry")
+	catCmd.Flags().Int64Var(&catOffset, "offset", 0, "Print the file from this byte on")
+	catCmd.Flags().Int64Var(&catLength, "length", 0, "Print at most this many bytes (0 for the rest of the file)")
+	catCmd.Flags().StringVar(&catLineRange, "lines", "", "Print numbered lines from:to, such as 100:200 or 100:")
+	pushCmd.Flags().BoolVar(&pushNoVerify, "no-verify", false, "Skip the pre-push hook")
+	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "Show whether local changes would apply without pushing them")
+	applyCmd.Flags().BoolVar(&applyFailIfLocked, "fail-if-locked", false, "Reject the patch if the target path is locked by someone else")
+	applyCmd.Flags().BoolVar(&applyIgnoreSpace, "ignore-whitespace", false, "Match context lines ignoring whitespace differences")
+	applyCmd.Flags().BoolVar(&applyNormalizeEOL, "normalize-eol", false, "Match CRLF and LF lines alike and keep the file's line endings")
+	startCmd.Flags().BoolVar(&overrideSizes, "override-size-limits", false, "Track the path even if it exceeds the server's size limits (requires permission)")
+	startCmd.Flags().StringVar(&startTemplate, "template", "", "Start from a server-side workspace template (see 'poon workspace templates')")
+	startCmd.Flags().StringVar(&startName, "name", "", "Name the workspace so it can be referred to by name instead of its ID")
+	startCmd.Flags().BoolVar(&startFromArchive, "archive", false, "Extract an archive of the workspace instead of cloning it, then fetch git history without file contents; faster for large workspaces")
+	trackCmd.Flags().BoolVar(&overrideSizes, "override-size-limits", false, "Track the paths even if they exceed the server's size limits (requires permission)")
+	applyCmd.Flags().BoolVar(&applyKeepEOF, "keep-trailing-newline", false, "Keep a missing newline at end of file instead of adding one")
+	syncCmd.Flags().IntVarP(&fetchJobs, "jobs", "j", defaultFetchJobs, "Number of files to download at once")
+	trackCmd.Flags().IntVarP(&fetchJobs, "jobs", "j", defaultFetchJobs, "Number of files to download at once")
+	applyCmd.Flags().StringVar(&applyBranch, "branch", "", "Commit the patch to this branch instead of main")
+	downloadCmd.Flags().StringVar(&downloadBranch, "branch", "", "Download the path at the head of this branch instead of main")
+	applyCmd.Flags().BoolVar(&applyAmend, "amend", false, "Fold the patch into the branch's latest commit, if it is not merged yet (requires --branch)")
+	applyCmd.Flags().StringVar(&applyAuthor, "author", "", "Author to record (default: the local user)")
+	applyCmd.Flags().StringVarP(&applyMessage, "message", "m", "", "Commit message (default: \"Applied patch from <source>\")")
+	applyCmd.Flags().Int64Var(&applyExpectedVersion, "expected-version", 0, "Version the patch was made against; reject it if a file it changes has changed since")
+	applyCmd.Flags().StringVar(&applySignKey, "sign-key", "", "Sign the patch with this Ed25519 private key (PKCS#8 PEM) for paths that require signed commits")
 
 	// Workspace workflow commands
 	rootCmd.AddCommand(startCmd)
@@ -787,14 +1715,20 @@ func init() {
 	rootCmd.AddCommand(lsCmd)
 	rootCmd.AddCommand(catCmd)
 	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(collisionsCmd)
 
 	// Branch operations
 	rootCmd.AddCommand(branchesCmd)
 	rootCmd.AddCommand(createBranchCmd)
+	rootCmd.AddCommand(viewsCmd)
 
 	// Workspace management
 	workspaceCmd.AddCommand(createWorkspaceCmd)
 	workspaceCmd.AddCommand(getWorkspaceCmd)
+	workspaceCmd.AddCommand(listTemplatesCmd)
+	workspaceCmd.AddCommand(workspaceExportCmd)
+	workspaceCmd.AddCommand(workspaceImportCmd)
 	rootCmd.AddCommand(workspaceCmd)
 
 	// Advanced operations
@@ -803,39 +1737,13 @@ func init() {
 	rootCmd.AddCommand(downloadCmd)
 }
 
-// extractTarContent extracts tar content to the specified destination
-func extractTarContent(tarContent []byte, destDir string) error {
-	// Create a temporary file for the tar content
-	tempFile, err := os.CreateTemp("", "poon-download-*.tar")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %v", err)
-	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	if _, err := tempFile.Write(tarContent); err != nil {
-		return fmt.Errorf("failed to write tar content: %v", err)
-	}
-
-	// Extract the tar file
-	cmd := exec.Command("tar", "-xf", tempFile.Name(), "-C", destDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to extract tar: %v", err)
-	}
-
-	return nil
-}
-
 // syncFromRemote pulls the latest changes from the remote git repository
 func syncFromRemote() error {
 	fmt.Printf("Syncing with remote repository...\n")
 
 	// Fetch latest changes from remote
 	if err := runCommand("git", "fetch", "origin"); err != nil {
-		return fmt.Errorf("failed to fetch from remote: %v", err)
+		return fmt.Errorf("failed to fetch from remote: %w", err)
 	}
 
 	// Merge or rebase with origin/main
@@ -843,18 +1751,105 @@ func syncFromRemote() error {
 		// If merge fails, try rebase
 		fmt.Printf("Merge failed, attempting rebase...\n")
 		if err := runCommand("git", "reset", "--hard", "HEAD"); err != nil {
-			return fmt.Errorf("failed to reset: %v", err)
+			return fmt.Errorf("failed to reset: %w", err)
 		}
 		if err := runCommand("git", "rebase", "origin/main"); err != nil {
-			return fmt.Errorf("failed to rebase: %v", err)
+			return fmt.Errorf("failed to rebase: %w", err)
 		}
 	}
 
 	return nil
 }
 
+// refreshTrackedPatterns asks the server to track paths that newly match the
+// workspace's glob patterns, then pulls the commit that adds them
+func refreshTrackedPatterns(config *PoonConfig) error {
+	if len(config.TrackedPatterns) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := client.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{
+		WorkspaceId: config.WorkspaceName,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Message)
+	}
+	if len(resp.AddedPaths) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Tracking %d path(s) newly matching %s\n", len(resp.AddedPaths), strings.Join(config.TrackedPatterns, ", "))
+	for _, path := range resp.AddedPaths {
+		fmt.Printf("  + %s\n", path)
+	}
+	config.TrackedPaths = append(config.TrackedPaths, resp.AddedPaths...)
+	if err := savePoonConfig(config); err != nil {
+		return err
+	}
+
+	if err := runCommand("git", "pull", config.gitRemote(), "main"); err != nil {
+		return fmt.Errorf("failed to pull new paths: %v (pull later with: git pull %s main)", err, config.gitRemote())
+	}
+	return nil
+}
+
+// isGlobPattern reports whether path is a glob pattern for the server to
+// expand rather than a literal path
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// toRepoPath returns a repository path given on the command line with
+// forward slashes, the form the server uses, so src\app works on Windows
+func toRepoPath(path string) string {
+	return filepath.ToSlash(path)
+}
+
+// isViewReference reports whether path names a view, "view:<name>"
+func isViewReference(path string) bool {
+	return strings.HasPrefix(path, "view:")
+}
+
+// isWorkspaceReference reports whether path names another workspace,
+// "workspace:<id-or-name>"
+func isWorkspaceReference(path string) bool {
+	return strings.HasPrefix(path, "workspace:")
+}
+
+// isTrackedPattern reports whether path is expanded by the server, as a glob
+// pattern, a view or another workspace, and kept in TrackedPatterns to be
+// re-expanded on sync
+func isTrackedPattern(path string) bool {
+	return isGlobPattern(path) || isViewReference(path) || isWorkspaceReference(path)
+}
+
+// checkTrackedPatternSupport reports an error if path is a view or workspace
+// reference the connected server cannot expand
+func checkTrackedPatternSupport(path string) error {
+	if serverInfo == nil {
+		return nil
+	}
+	if isViewReference(path) && !serverInfo.Supports(poonclient.FeatureViews) {
+		return fmt.Errorf("server %s has no path views; track %s's paths instead", serverAddr, path)
+	}
+	if isWorkspaceReference(path) && !serverInfo.Supports(poonclient.FeatureComposition) {
+		return fmt.Errorf("server %s cannot compose workspaces; track %s's paths instead", serverAddr, path)
+	}
+	return nil
+}
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+	err := rootCmd.Execute()
+	if conn != nil {
+		conn.Close()
+	}
+	if err != nil {
+		exitWithError(err)
 	}
 }
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nic/poon/poon-cli/pkg/fuse"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+var mountVersion int64
+
+var mountCmd = &cobra.Command{
+	Use:   "mount <mountpoint>",
+	Short: "Mount the monorepo as a read-only file system",
+	Long: `Mount the monorepo read-only at mountpoint.
+
+Directories and files are fetched from the server the first time they are
+used, so tools such as grep or an IDE indexer can work on the whole tree
+without downloading it. The mount shows a single version throughout: the
+current one, or the one given with --version. Press Ctrl-C to unmount.
+
+Requires Linux with FUSE. Users other than root need fusermount3 or
+fusermount installed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mountpoint := args[0]
+		info, err := os.Stat(mountpoint)
+		if err != nil {
+			return fmt.Errorf("invalid mountpoint: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("mountpoint %s is not a directory", mountpoint)
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if !streamingReads() {
+			return fmt.Errorf("%s does not support streaming reads, which poon mount needs; upgrade poon-server", serverAddr)
+		}
+
+		// Resolve the version up front so every directory comes from it
+		root, version, err := fetchDirectory("", mountVersion)
+		if err != nil {
+			return fmt.Errorf("failed to read monorepo root: %w", err)
+		}
+		fs := newRemoteFS(version, maxCachedDirs)
+		fs.keep("", root)
+
+		server, err := fuse.Mount(mountpoint, fs, fuse.Options{FSName: "poon@" + serverAddr})
+		if err != nil {
+			return fmt.Errorf("failed to mount %s: %v", mountpoint, err)
+		}
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			for range signals {
+				if err := fuse.Unmount(mountpoint); err != nil {
+					fmt.Printf("✗ Failed to unmount %s: %v\n", mountpoint, err)
+					fmt.Printf("  Close any files under it and press Ctrl-C again\n")
+				}
+			}
+		}()
+
+		fmt.Printf("✓ Mounted version %d at %s (read-only)\n", version, mountpoint)
+		fmt.Printf("  Press Ctrl-C to unmount\n")
+
+		if err := server.Serve(); err != nil {
+			return fmt.Errorf("mount failed: %w", err)
+		}
+		fmt.Printf("✓ Unmounted %s\n", mountpoint)
+		return nil
+	},
+}
+
+// maxCachedDirs bounds the directory listings a mount keeps, so walking
+// the whole tree does not keep every one of them in memory
+const maxCachedDirs = 4096
+
+// remoteFS serves one monorepo version over FUSE. Directory listings are
+// fetched when first used and the most recently used kept, since a version
+// never changes; file contents are read in ranges as the kernel asks for
+// them and cached by the kernel.
+type remoteFS struct {
+	version int64
+	maxDirs int
+
+	mu   sync.Mutex
+	dirs map[string]*list.Element // Of *dirListing in lru
+	lru  *list.List               // Most recently used first
+}
+
+type dirListing struct {
+	path    string
+	entries []fuse.Dirent
+}
+
+func newRemoteFS(version int64, maxDirs int) *remoteFS {
+	return &remoteFS{
+		version: version,
+		maxDirs: maxDirs,
+		dirs:    make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (r *remoteFS) ReadDir(path string) ([]fuse.Dirent, error) {
+	if entries, ok := r.cached(path); ok {
+		return entries, nil
+	}
+
+	entries, _, err := fetchDirectory(path, r.version)
+	if err != nil {
+		return nil, err
+	}
+	r.keep(path, entries)
+	return entries, nil
+}
+
+// cached returns the kept listing of path, if there is one
+func (r *remoteFS) cached(path string) ([]fuse.Dirent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	elem, ok := r.dirs[path]
+	if !ok {
+		return nil, false
+	}
+	r.lru.MoveToFront(elem)
+	return elem.Value.(*dirListing).entries, true
+}
+
+// keep caches the listing of path, dropping the least recently used beyond
+// maxDirs
+func (r *remoteFS) keep(path string, entries []fuse.Dirent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if elem, ok := r.dirs[path]; ok {
+		r.lru.MoveToFront(elem)
+		return
+	}
+	r.dirs[path] = r.lru.PushFront(&dirListing{path: path, entries: entries})
+	for r.lru.Len() > r.maxDirs {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.dirs, oldest.Value.(*dirListing).path)
+	}
+}
+
+func (r *remoteFS) ReadFile(path string, offset int64, size int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamFile(ctx, &pb.StreamFileRequest{
+		Path:    path,
+		Version: r.version,
+		Offset:  offset,
+		Length:  int64(size),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, size)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk.Data...)
+	}
+}
+
+// fetchDirectory lists a directory at version, or at the current version if
+// version is 0, and returns the version it was read from
+func fetchDirectory(path string, version int64) ([]fuse.Dirent, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamDirectory(ctx, &pb.StreamDirectoryRequest{Path: path, Version: version})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []fuse.Dirent
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return entries, version, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		version = resp.Version
+		for _, item := range resp.Items {
+			entry := fuse.Dirent{
+				Name: item.Name,
+				Attr: fuse.Attr{
+					Dir:  item.IsDir,
+					Size: uint64(item.Size),
+					Mode: os.FileMode(item.Mode).Perm(),
+				},
+			}
+			if item.ModTime > 0 {
+				entry.Attr.Mtime = time.Unix(item.ModTime, 0)
+			}
+			entries = append(entries, entry)
+		}
+	}
+}
+
+func init() {
+	mountCmd.Flags().Int64Var(&mountVersion, "version", 0, "Monorepo version to mount (default: current)")
+	rootCmd.AddCommand(mountCmd)
+}
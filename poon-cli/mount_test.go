@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nic/poon/poon-cli/pkg/fuse"
+)
+
+func TestRemoteFSKeepsRecentDirectories(t *testing.T) {
+	fs := newRemoteFS(1, 2)
+	listing := func(name string) []fuse.Dirent { return []fuse.Dirent{{Name: name}} }
+
+	fs.keep("a", listing("a.txt"))
+	fs.keep("b", listing("b.txt"))
+	if _, ok := fs.cached("a"); !ok { // a is now the most recently used
+		t.Fatal("a was not kept")
+	}
+	fs.keep("c", listing("c.txt"))
+
+	if _, ok := fs.cached("b"); ok {
+		t.Error("b, the least recently used, was kept beyond the limit")
+	}
+	for _, path := range []string{"a", "c"} {
+		entries, ok := fs.cached(path)
+		if !ok || entries[0].Name != path+".txt" {
+			t.Errorf("%s: got %v, %v", path, entries, ok)
+		}
+	}
+	if len(fs.dirs) != 2 || fs.lru.Len() != 2 {
+		t.Errorf("%d listings in the map and %d in the list, want 2", len(fs.dirs), fs.lru.Len())
+	}
+}
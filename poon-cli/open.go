@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var openNoBrowser bool
+
+var openCmd = &cobra.Command{
+	Use:   "open [path]",
+	Short: "Open a path in the web UI",
+	Long: `Open a file or directory of the workspace in the web UI, at the version it
+was last synced to. The path defaults to the current directory.
+
+The web UI's address comes from $POON_WEB_URL, or else from the server (its
+WEB_URL setting). With --no-browser the URL is printed instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := "."
+		if len(args) == 1 {
+			target = args[0]
+		}
+		abs, err := filepath.Abs(target)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", target, err)
+		}
+		info, statErr := os.Stat(abs)
+
+		// loadPoonConfig moves to the workspace root, so the path is
+		// resolved first
+		if _, err := loadPoonConfig(); err != nil {
+			return err
+		}
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get workspace root: %w", err)
+		}
+		repoPath, err := workspaceRelativePath(root, abs)
+		if err != nil {
+			return err
+		}
+
+		base, err := webURL()
+		if err != nil {
+			return err
+		}
+
+		state, err := loadWorkspaceState()
+		if err != nil {
+			return err
+		}
+		isFile := statErr == nil && !info.IsDir()
+		link, err := browseURL(base, repoPath, isFile, syncedVersion(state, repoPath))
+		if err != nil {
+			return err
+		}
+
+		if openNoBrowser {
+			fmt.Println(link)
+			return nil
+		}
+		fmt.Printf("Opening %s in your browser.\n", link)
+		return openBrowser(link)
+	},
+}
+
+// workspaceRelativePath returns target as a repository path, relative to the
+// workspace root with forward slashes; "" is the root itself
+func workspaceRelativePath(root, target string) (string, error) {
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace %s", target, root)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// webURL returns the base URL of the web UI
+func webURL() (string, error) {
+	if base := os.Getenv("POON_WEB_URL"); base != "" {
+		return base, nil
+	}
+	if err := connectToServer(); err != nil {
+		return "", err
+	}
+	if serverInfo == nil || serverInfo.WebURL == "" {
+		return "", fmt.Errorf("server %s does not know its web UI; set POON_WEB_URL", serverAddr)
+	}
+	return serverInfo.WebURL, nil
+}
+
+// syncedVersion returns the version the tracked path holding repoPath was
+// last synced to, or 0 if it is not known
+func syncedVersion(state *WorkspaceState, repoPath string) int64 {
+	var version int64
+	longest := -1
+	for path, pathState := range state.TrackedPaths {
+		path = strings.Trim(path, "/")
+		if repoPath != path && !strings.HasPrefix(repoPath, path+"/") {
+			continue
+		}
+		// The innermost tracked path wins
+		if len(path) > longest {
+			longest = len(path)
+			version = pathState.LastSyncVersion
+		}
+	}
+	return version
+}
+
+// browseURL builds the web UI address of a directory, or of a file, at
+// version (0 for the current one)
+func browseURL(base, repoPath string, isFile bool, version int64) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid web UI URL %q: %w", base, err)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	query := u.Query()
+	if isFile {
+		query.Set("file", "/"+repoPath)
+	} else {
+		query.Set("path", "/"+repoPath)
+	}
+	if version > 0 {
+		query.Set("version", strconv.FormatInt(version, 10))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// openBrowser opens link in $BROWSER or the platform's default browser
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch browser := os.Getenv("BROWSER"); {
+	case browser != "":
+		cmd = exec.Command(browser, link)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", link)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open a browser (use --no-browser to print the URL): %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+func init() {
+	openCmd.Flags().BoolVarP(&openNoBrowser, "no-browser", "n", false, "Print the URL instead of opening it")
+	rootCmd.AddCommand(openCmd)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceRelativePath(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "workspace")
+	tests := []struct {
+		target, want string
+	}{
+		{root, ""},
+		{filepath.Join(root, "src"), "src"},
+		{filepath.Join(root, "src", "frontend", "app.js"), "src/frontend/app.js"},
+	}
+	for _, test := range tests {
+		got, err := workspaceRelativePath(root, test.target)
+		if err != nil {
+			t.Errorf("workspaceRelativePath(%s): %v", test.target, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("workspaceRelativePath(%s) = %q, want %q", test.target, got, test.want)
+		}
+	}
+
+	for _, outside := range []string{filepath.Dir(root), filepath.Join(filepath.Dir(root), "other")} {
+		if _, err := workspaceRelativePath(root, outside); err == nil {
+			t.Errorf("workspaceRelativePath(%s) should be outside the workspace", outside)
+		}
+	}
+}
+
+func TestBrowseURL(t *testing.T) {
+	tests := []struct {
+		base, repoPath string
+		isFile         bool
+		version        int64
+		want           string
+	}{
+		{"http://poon.test", "", false, 0, "http://poon.test/?path=%2F"},
+		{"http://poon.test/", "src/app", false, 3, "http://poon.test/?path=%2Fsrc%2Fapp&version=3"},
+		{"https://poon.test/ui", "src/app/main.go", true, 7, "https://poon.test/ui?file=%2Fsrc%2Fapp%2Fmain.go&version=7"},
+	}
+	for _, test := range tests {
+		got, err := browseURL(test.base, test.repoPath, test.isFile, test.version)
+		if err != nil {
+			t.Errorf("browseURL(%s, %s): %v", test.base, test.repoPath, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("browseURL(%s, %s) = %s, want %s", test.base, test.repoPath, got, test.want)
+		}
+	}
+}
+
+func TestSyncedVersion(t *testing.T) {
+	state := &WorkspaceState{TrackedPaths: map[string]*TrackedPathState{
+		"src":          {LastSyncVersion: 4},
+		"src/frontend": {LastSyncVersion: 9},
+	}}
+	tests := map[string]int64{
+		"src/backend/main.go":  4,
+		"src/frontend":         9,
+		"src/frontend/app.js":  9,
+		"src/frontendish/a.js": 4,
+		"docs":                 0,
+	}
+	for repoPath, want := range tests {
+		if got := syncedVersion(state, repoPath); got != want {
+			t.Errorf("syncedVersion(%s) = %d, want %d", repoPath, got, want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// operationPollSeconds is how long each WaitOperation call waits before
+// the progress shown is brought up to date
+const operationPollSeconds = 1
+
+var (
+	operationWait    bool
+	operationCancel  bool
+	operationRunning bool
+)
+
+// operationWaiter is WaitOperation of either the user or the admin API
+type operationWaiter func(ctx context.Context, req *pb.WaitOperationRequest, opts ...grpc.CallOption) (*pb.WaitOperationResponse, error)
+
+// waitForOperation follows a server operation until it is done, showing
+// its progress after label unless label is empty. It returns the finished
+// operation, and an error too if the operation did not succeed.
+func waitForOperation(wait operationWaiter, operationID, label string) (*pb.Operation, error) {
+	shown := false
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), operationPollSeconds*time.Second+10*time.Second)
+		resp, err := wait(ctx, &pb.WaitOperationRequest{
+			OperationId:    operationID,
+			TimeoutSeconds: operationPollSeconds,
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for operation: %w", err)
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("server failed to wait for operation: %s", resp.Message)
+		}
+
+		op := resp.Operation
+		if label != "" && op.ProgressTotal > 0 {
+			fmt.Printf("\r%s: %d of %d", label, op.ProgressDone, op.ProgressTotal)
+			shown = true
+		}
+		if !op.Done {
+			continue
+		}
+		if shown {
+			fmt.Println()
+		}
+		if op.Error != "" {
+			return op, fmt.Errorf("operation %s failed: %s", op.Id, op.Error)
+		}
+		return op, nil
+	}
+}
+
+// progressLabel returns label, or "" when the output is JSON, which
+// progress lines would corrupt
+func progressLabel(label string) string {
+	if isJSONOutput() {
+		return ""
+	}
+	return label
+}
+
+// awaitResponse follows the operation a call started with async set, if
+// it started one, and fills resp with the call's response once it is done.
+// Servers that predate operations ignore async and answer at once, leaving
+// operationID empty.
+func awaitResponse(wait operationWaiter, operationID, label string, resp proto.Message) error {
+	if operationID == "" {
+		return nil
+	}
+	op, err := waitForOperation(wait, operationID, label)
+	if err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(op.Response, resp); err != nil {
+		return fmt.Errorf("failed to decode the result of operation %s: %w", operationID, err)
+	}
+	return nil
+}
+
+// printOperation shows an operation for 'poon operation' and 'poon admin
+// operation'
+func printOperation(op *pb.Operation) {
+	fmt.Printf("Operation: %s\n", op.Id)
+	fmt.Printf("Kind: %s\n", op.Kind)
+	if op.Owner != "" {
+		fmt.Printf("Owner: %s\n", op.Owner)
+	}
+	fmt.Printf("Started: %s\n", op.CreatedAt)
+	switch {
+	case !op.Done:
+		fmt.Printf("Status: running\n")
+	case op.Error != "":
+		fmt.Printf("Status: %s at %s\n", op.Status, op.FinishedAt)
+		fmt.Printf("Error: %s\n", op.Error)
+	default:
+		fmt.Printf("Status: done at %s\n", op.FinishedAt)
+	}
+	if op.ProgressTotal > 0 {
+		fmt.Printf("Progress: %d of %d\n", op.ProgressDone, op.ProgressTotal)
+	}
+	keys := make([]string, 0, len(op.Result))
+	for key := range op.Result {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("Result %s: %s\n", key, op.Result[key])
+	}
+}
+
+// printOperations lists operations for 'poon operations' and 'poon admin
+// operations'
+func printOperations(ops []*pb.Operation) {
+	if len(ops) == 0 {
+		fmt.Println("No operations")
+		return
+	}
+	for _, op := range ops {
+		status := op.Status
+		if status == "" || !op.Done {
+			status = "running"
+		}
+		progress := ""
+		if op.ProgressTotal > 0 {
+			progress = fmt.Sprintf(" (%d of %d)", op.ProgressDone, op.ProgressTotal)
+		}
+		fmt.Printf("%s  %-16s %-10s %s%s\n", op.Id, op.Kind, status, op.CreatedAt, progress)
+	}
+}
+
+// showOperation carries out 'poon operation' and 'poon admin operation'
+// with the calls of either API
+func showOperation(id string, get func(ctx context.Context) (*pb.Operation, error), wait operationWaiter, cancelOp func(ctx context.Context) (string, error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if operationCancel {
+		message, err := cancelOp(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s\n", message)
+		if !operationWait {
+			return nil
+		}
+	}
+
+	var op *pb.Operation
+	var err error
+	if operationWait {
+		if op, err = waitForOperation(wait, id, progressLabel("Progress")); op == nil {
+			return err
+		}
+	} else if op, err = get(ctx); err != nil {
+		return err
+	}
+
+	if isJSONOutput() {
+		return printJSON(op)
+	}
+	printOperation(op)
+	return nil
+}
+
+var operationCmd = &cobra.Command{
+	Use:   "operation <id>",
+	Short: "Show a long-running server operation",
+	Long: `Show a long-running server operation, such as the copying of tracked paths
+into a workspace 'poon start' created or an archive 'poon download' asked for.
+With --wait, follow it until it is done; with --cancel, stop it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		id := args[0]
+		return showOperation(id,
+			func(ctx context.Context) (*pb.Operation, error) {
+				resp, err := client.GetOperation(ctx, &pb.GetOperationRequest{OperationId: id})
+				if err != nil {
+					return nil, fmt.Errorf("failed to get operation: %w", err)
+				}
+				if !resp.Success {
+					return nil, fmt.Errorf("failed to get operation: %s", resp.Message)
+				}
+				return resp.Operation, nil
+			},
+			client.WaitOperation,
+			func(ctx context.Context) (string, error) {
+				resp, err := client.CancelOperation(ctx, &pb.CancelOperationRequest{OperationId: id})
+				if err != nil {
+					return "", fmt.Errorf("failed to cancel operation: %w", err)
+				}
+				if !resp.Success {
+					return "", fmt.Errorf("failed to cancel operation: %s", resp.Message)
+				}
+				return resp.Message, nil
+			})
+	},
+}
+
+var operationsCmd = &cobra.Command{
+	Use:   "operations",
+	Short: "List your long-running server operations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.ListOperations(ctx, &pb.ListOperationsRequest{RunningOnly: operationRunning})
+		if err != nil {
+			return fmt.Errorf("failed to list operations: %w", err)
+		}
+		if isJSONOutput() {
+			return printJSON(resp.Operations)
+		}
+		printOperations(resp.Operations)
+		return nil
+	},
+}
+
+func init() {
+	operationCmd.Flags().BoolVar(&operationWait, "wait", false, "Wait until the operation is done")
+	operationCmd.Flags().BoolVar(&operationCancel, "cancel", false, "Cancel the operation")
+	operationsCmd.Flags().BoolVar(&operationRunning, "running", false, "Only list operations still running")
+	rootCmd.AddCommand(operationCmd)
+	rootCmd.AddCommand(operationsCmd)
+}
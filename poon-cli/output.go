@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var outputFormat string
+
+// LsEntry is the machine-readable form of a single directory item
+type LsEntry struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // "dir" or "file"
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"`
+	Mode    int32  `json:"mode,omitempty"`
+
+	// Last change to the entry, filled in by `poon ls -l`
+	LastVersion   int64  `json:"lastVersion,omitempty"`
+	LastCommit    string `json:"lastCommit,omitempty"`
+	LastAuthor    string `json:"lastAuthor,omitempty"`
+	LastMessage   string `json:"lastMessage,omitempty"`
+	LastTimestamp int64  `json:"lastTimestamp,omitempty"`
+}
+
+// LsOutput is the machine-readable result of `poon ls`
+type LsOutput struct {
+	Path    string    `json:"path"`
+	Entries []LsEntry `json:"entries"`
+}
+
+// StatusOutput is the machine-readable result of `poon status`
+type StatusOutput struct {
+	Workspace     string   `json:"workspace"`
+	Name          string   `json:"name"` // "default" or the remote selected with --workspace
+	GitServerURL  string   `json:"gitServerUrl"`
+	GrpcServerURL string   `json:"grpcServerUrl"`
+	CreatedAt     string   `json:"createdAt"`
+	TrackedPaths  []string `json:"trackedPaths"`
+	// Whether each tracked path changed on the server since the last sync;
+	// absent when the server could not be asked
+	Paths         []PathStatus `json:"paths,omitempty"`
+	ServerVersion int64        `json:"serverVersion,omitempty"`
+	ServerError   string       `json:"serverError,omitempty"`
+}
+
+// CommitOutput is the machine-readable form of a single commit
+type CommitOutput struct {
+	Hash         string   `json:"hash"`
+	Author       string   `json:"author"`
+	Message      string   `json:"message"`
+	Timestamp    int64    `json:"timestamp"`
+	ChangedFiles []string `json:"changedFiles,omitempty"`
+	Path         string   `json:"path,omitempty"`    // Path of the file in this commit
+	OldPath      string   `json:"oldPath,omitempty"` // Set when the file was renamed or copied from here
+}
+
+// HistoryOutput is the machine-readable result of `poon history`
+type HistoryOutput struct {
+	Path    string         `json:"path"`
+	Commits []CommitOutput `json:"commits"`
+}
+
+// InfoOutput is the machine-readable result of `poon info`
+type InfoOutput struct {
+	Path              string            `json:"path"`
+	Type              string            `json:"type"` // "dir" or "file"
+	Version           int64             `json:"version"`
+	Files             int32             `json:"files"`
+	Directories       int32             `json:"directories"`
+	TotalFiles        int64             `json:"totalFiles"`
+	TotalSize         int64             `json:"totalSize"`
+	LastChange        *CommitOutput     `json:"lastChange,omitempty"`
+	LastChangeVersion int64             `json:"lastChangeVersion,omitempty"`
+	ReadmePath        string            `json:"readmePath,omitempty"`
+	Readme            string            `json:"readme,omitempty"`
+	ReadmeTruncated   bool              `json:"readmeTruncated,omitempty"`
+	Owners            []string          `json:"owners,omitempty"`
+	OwnersPath        string            `json:"ownersPath,omitempty"`
+	Attributes        map[string]string `json:"attributes,omitempty"`
+}
+
+// CollisionsOutput is the machine-readable result of `poon collisions`
+type CollisionsOutput struct {
+	Path       string     `json:"path"`
+	Version    int64      `json:"version"`
+	Collisions [][]string `json:"collisions"`
+}
+
+// BranchesOutput is the machine-readable result of `poon branches`
+type BranchesOutput struct {
+	Branches      []string `json:"branches"`
+	DefaultBranch string   `json:"defaultBranch"`
+}
+
+// TemplateOutput is the machine-readable form of one workspace template
+type TemplateOutput struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description,omitempty"`
+	TrackedPaths []string          `json:"trackedPaths"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// ViewOutput is the machine-readable form of one path view
+type ViewOutput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Paths       []string `json:"paths"`
+}
+
+// WorkspaceOutput is the machine-readable result of the workspace commands
+type WorkspaceOutput struct {
+	Success         bool              `json:"success"`
+	Message         string            `json:"message"`
+	ID              string            `json:"id,omitempty"`
+	Name            string            `json:"name,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	RemoteURL       string            `json:"remoteUrl,omitempty"`
+	CreatedAt       string            `json:"createdAt,omitempty"`
+	LastSync        string            `json:"lastSync,omitempty"`
+	TrackedPaths    []string          `json:"trackedPaths,omitempty"`
+	TrackedPatterns []string          `json:"trackedPatterns,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputText, outputJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q (expected %q or %q)", outputFormat, outputText, outputJSON)
+	}
+}
+
+func isJSONOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode output: %v", err)
+	}
+	return nil
+}
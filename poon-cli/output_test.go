@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/zalando/go-keyring"
+	"google.golang.org/grpc"
+)
+
+// outputServer answers the reads behind the commands with JSON output from
+// fixed data
+type outputServer struct {
+	pb.UnimplementedMonorepoServiceServer
+}
+
+func (outputServer) ReadDirectory(ctx context.Context, req *pb.ReadDirectoryRequest) (*pb.ReadDirectoryResponse, error) {
+	return &pb.ReadDirectoryResponse{Hash: "tree", Items: []*pb.DirectoryItem{
+		{Name: "api", IsDir: true},
+		{Name: "README.md", Size: 12, ModTime: 1700000000, Mode: 0644},
+	}}, nil
+}
+
+func (outputServer) GetFileHistory(ctx context.Context, req *pb.FileHistoryRequest) (*pb.FileHistoryResponse, error) {
+	return &pb.FileHistoryResponse{Commits: []*pb.Commit{
+		{Hash: "abc123", Author: "dev@example.com", Message: "Move the handler", Timestamp: 1700000000, Path: req.Path, OldPath: "old/handler.go"},
+	}}, nil
+}
+
+func (outputServer) GetBranches(ctx context.Context, req *pb.BranchesRequest) (*pb.BranchesResponse, error) {
+	return &pb.BranchesResponse{Branches: []string{"main", "release"}, DefaultBranch: "main"}, nil
+}
+
+func (outputServer) GetWorkspace(ctx context.Context, req *pb.GetWorkspaceRequest) (*pb.GetWorkspaceResponse, error) {
+	return &pb.GetWorkspaceResponse{Success: true, Message: "found", Workspace: &pb.WorkspaceInfo{
+		Id:           req.WorkspaceId,
+		Name:         "feature",
+		Status:       pb.WorkspaceStatus_SYNCING,
+		TrackedPaths: []string{"services/api"},
+	}}, nil
+}
+
+func (outputServer) GetServerInfo(ctx context.Context, req *pb.GetServerInfoRequest) (*pb.GetServerInfoResponse, error) {
+	return &pb.GetServerInfoResponse{ApiVersion: "v1", Features: []string{poonclient.FeatureTreeHashes}}, nil
+}
+
+func (outputServer) GetTreeHash(ctx context.Context, req *pb.GetTreeHashRequest) (*pb.GetTreeHashResponse, error) {
+	resp := &pb.GetTreeHashResponse{Version: 3}
+	for _, path := range req.Paths {
+		resp.Hashes = append(resp.Hashes, &pb.TreeHash{Path: path, Hash: "tree", IsDir: true, Exists: true})
+	}
+	return resp, nil
+}
+
+// useOutputServer serves outputServer on a local port and runs the test in
+// a new workspace directory
+func useOutputServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterMonorepoServiceServer(server, outputServer{})
+	go server.Serve(listener)
+	keyring.MockInit()
+	t.Setenv("POON_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials.json"))
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".poon"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	savedAddr := serverAddr
+	t.Cleanup(func() {
+		if conn != nil {
+			conn.Close()
+		}
+		conn, client, serverInfo = nil, nil, nil
+		serverAddr, outputFormat = savedAddr, outputText
+		server.Stop()
+		os.Chdir(wd)
+	})
+	return listener.Addr().String()
+}
+
+// runJSON runs a command with --output json and decodes what it printed
+// into out
+func runJSON(t *testing.T, addr string, out interface{}, args ...string) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	printed := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		printed <- data
+	}()
+
+	rootCmd.SetArgs(append(args, "--server", addr, "--output", "json"))
+	err = rootCmd.Execute()
+	os.Stdout = stdout
+	writer.Close()
+	data := <-printed
+	if err != nil {
+		t.Fatalf("poon %v: %v", args, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		t.Fatalf("poon %v printed %q: %v", args, data, err)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	t.Run("ls", func(t *testing.T) {
+		addr := useOutputServer(t)
+		var out LsOutput
+		runJSON(t, addr, &out, "ls", "services")
+		if out.Path != "services" || len(out.Entries) != 2 {
+			t.Fatalf("ls = %+v", out)
+		}
+		if dir := out.Entries[0]; dir.Name != "api" || dir.Type != "dir" {
+			t.Errorf("first entry = %+v, want the api directory", dir)
+		}
+		if file := out.Entries[1]; file.Name != "README.md" || file.Type != "file" || file.Size != 12 || file.Mode != 0644 {
+			t.Errorf("second entry = %+v, want README.md", file)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		addr := useOutputServer(t)
+		config := PoonConfig{WorkspaceConfig: WorkspaceConfig{
+			WorkspaceName: "ws-1",
+			GitServerURL:  "http://localhost:3000/ws-1.git",
+			GrpcServerURL: addr,
+			TrackedPaths:  []string{"services/api"},
+			CreatedAt:     "2024-06-03T10:00:00Z",
+		}}
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(configPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out StatusOutput
+		runJSON(t, addr, &out, "status")
+		if out.Workspace != "ws-1" || out.Name != "default" || out.GrpcServerURL != addr || out.CreatedAt != config.CreatedAt {
+			t.Errorf("status = %+v", out)
+		}
+		if out.ServerError != "" || out.ServerVersion != 3 {
+			t.Errorf("server version = %d, error %q; want version 3", out.ServerVersion, out.ServerError)
+		}
+		if len(out.Paths) != 1 || out.Paths[0].Path != "services/api" || out.Paths[0].State != pathNotSynced {
+			t.Errorf("paths = %+v, want services/api not synced", out.Paths)
+		}
+	})
+
+	t.Run("history", func(t *testing.T) {
+		addr := useOutputServer(t)
+		var out HistoryOutput
+		runJSON(t, addr, &out, "history", "services/api/handler.go")
+		want := CommitOutput{Hash: "abc123", Author: "dev@example.com", Message: "Move the handler", Timestamp: 1700000000, Path: "services/api/handler.go", OldPath: "old/handler.go"}
+		if out.Path != "services/api/handler.go" || len(out.Commits) != 1 {
+			t.Fatalf("history = %+v", out)
+		}
+		if got := out.Commits[0]; got.Hash != want.Hash || got.Author != want.Author || got.Message != want.Message ||
+			got.Timestamp != want.Timestamp || got.Path != want.Path || got.OldPath != want.OldPath {
+			t.Errorf("commit = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("branches", func(t *testing.T) {
+		addr := useOutputServer(t)
+		var out BranchesOutput
+		runJSON(t, addr, &out, "branches")
+		if len(out.Branches) != 2 || out.Branches[1] != "release" || out.DefaultBranch != "main" {
+			t.Errorf("branches = %+v", out)
+		}
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		addr := useOutputServer(t)
+		var out WorkspaceOutput
+		runJSON(t, addr, &out, "workspace", "get", "ws-1")
+		if !out.Success || out.ID != "ws-1" || out.Name != "feature" || out.Status != "SYNCING" {
+			t.Errorf("workspace = %+v", out)
+		}
+		if len(out.TrackedPaths) != 1 || out.TrackedPaths[0] != "services/api" {
+			t.Errorf("tracked paths = %v", out.TrackedPaths)
+		}
+	})
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	t.Cleanup(func() { outputFormat = outputText })
+	for format, valid := range map[string]bool{
+		outputText: true,
+		outputJSON: true,
+		"yaml":     false,
+		"JSON":     false,
+		"":         false,
+	} {
+		outputFormat = format
+		if err := validateOutputFormat(); (err == nil) != valid {
+			t.Errorf("validateOutputFormat() with %q = %v, want valid %v", format, err, valid)
+		}
+	}
+}
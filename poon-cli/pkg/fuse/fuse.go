@@ -0,0 +1,47 @@
+// Package fuse serves a read-only file tree to the kernel over FUSE. It
+// implements just the part of the protocol a read-only file system needs:
+// lookups, attributes, directory listings and reads.
+package fuse
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrNotSupported is returned by Mount on platforms without FUSE support
+var ErrNotSupported = errors.New("FUSE mounts are only supported on Linux")
+
+// Attr describes a file or directory
+type Attr struct {
+	Dir   bool
+	Size  uint64
+	Mode  os.FileMode // Permission bits; write bits are ignored
+	Mtime time.Time
+}
+
+// Dirent is a directory entry returned by FileSystem.ReadDir
+type Dirent struct {
+	Name string
+	Attr Attr
+}
+
+// FileSystem is the tree served by a mount. Paths are slash separated and
+// relative to the root, which is "". Returning a syscall.Errno reports that
+// error to the caller; any other error is reported as EIO.
+//
+// The tree must not change while it is mounted: the kernel is told to cache
+// attributes and file contents indefinitely.
+type FileSystem interface {
+	// ReadDir lists the directory at path
+	ReadDir(path string) ([]Dirent, error)
+
+	// ReadFile returns up to size bytes of the file at path, starting at
+	// offset. Fewer bytes are only returned at the end of the file.
+	ReadFile(path string, offset int64, size int) ([]byte, error)
+}
+
+// Options configures a mount
+type Options struct {
+	FSName string // Source shown by mount(8), for example the server address
+}
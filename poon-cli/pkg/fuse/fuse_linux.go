@@ -0,0 +1,776 @@
+//go:build linux
+
+package fuse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Kernel protocol version spoken by this package
+const (
+	kernelMajor = 7
+	kernelMinor = 31
+)
+
+// Request opcodes handled by Server
+const (
+	opLookup      = 1
+	opForget      = 2
+	opGetattr     = 3
+	opOpen        = 14
+	opRead        = 15
+	opStatfs      = 17
+	opRelease     = 18
+	opFlush       = 25
+	opInit        = 26
+	opOpendir     = 27
+	opReaddir     = 28
+	opReleasedir  = 29
+	opAccess      = 34
+	opInterrupt   = 36
+	opDestroy     = 38
+	opBatchForget = 42
+)
+
+const (
+	initAsyncRead      = 1 << 0
+	initParallelDirops = 1 << 18
+
+	openKeepCache = 1 << 1
+
+	maxWrite = 128 * 1024
+
+	// bufferSize fits the largest request the kernel sends, a write of
+	// maxWrite bytes plus its headers
+	bufferSize = maxWrite + 4096
+
+	// cacheTimeout is how long the kernel may cache entries and attributes.
+	// The tree never changes while mounted.
+	cacheTimeout = 24 * time.Hour
+
+	// initOutCompatSize is the size of the INIT reply before protocol 7.23
+	initOutCompatSize = 24
+
+	rootID = 1
+)
+
+type inHeader struct {
+	Len     uint32
+	Opcode  uint32
+	Unique  uint64
+	Nodeid  uint64
+	Uid     uint32
+	Gid     uint32
+	Pid     uint32
+	Padding uint32
+}
+
+const inHeaderSize = 40
+
+type outHeader struct {
+	Len    uint32
+	Error  int32
+	Unique uint64
+}
+
+const outHeaderSize = 16
+
+type initIn struct {
+	Major        uint32
+	Minor        uint32
+	MaxReadahead uint32
+	Flags        uint32
+}
+
+type initOut struct {
+	Major               uint32
+	Minor               uint32
+	MaxReadahead        uint32
+	Flags               uint32
+	MaxBackground       uint16
+	CongestionThreshold uint16
+	MaxWrite            uint32
+	TimeGran            uint32
+	MaxPages            uint16
+	MapAlignment        uint16
+	Flags2              uint32
+	Unused              [7]uint32
+}
+
+type kernelAttr struct {
+	Ino       uint64
+	Size      uint64
+	Blocks    uint64
+	Atime     uint64
+	Mtime     uint64
+	Ctime     uint64
+	Atimensec uint32
+	Mtimensec uint32
+	Ctimensec uint32
+	Mode      uint32
+	Nlink     uint32
+	Uid       uint32
+	Gid       uint32
+	Rdev      uint32
+	Blksize   uint32
+	Flags     uint32
+}
+
+type entryOut struct {
+	Nodeid         uint64
+	Generation     uint64
+	EntryValid     uint64
+	AttrValid      uint64
+	EntryValidNsec uint32
+	AttrValidNsec  uint32
+	Attr           kernelAttr
+}
+
+type attrOut struct {
+	AttrValid     uint64
+	AttrValidNsec uint32
+	Dummy         uint32
+	Attr          kernelAttr
+}
+
+type openIn struct {
+	Flags     uint32
+	OpenFlags uint32
+}
+
+type openOut struct {
+	Fh        uint64
+	OpenFlags uint32
+	Padding   uint32
+}
+
+type readIn struct {
+	Fh        uint64
+	Offset    uint64
+	Size      uint32
+	ReadFlags uint32
+	LockOwner uint64
+	Flags     uint32
+	Padding   uint32
+}
+
+type accessIn struct {
+	Mask    uint32
+	Padding uint32
+}
+
+type forgetIn struct {
+	Nlookup uint64
+}
+
+type batchForgetIn struct {
+	Count uint32
+	Dummy uint32
+}
+
+type forgetOne struct {
+	Nodeid  uint64
+	Nlookup uint64
+}
+
+type statfsOut struct {
+	Blocks  uint64
+	Bfree   uint64
+	Bavail  uint64
+	Files   uint64
+	Ffree   uint64
+	Bsize   uint32
+	Namelen uint32
+	Frsize  uint32
+	Padding uint32
+	Spare   [6]uint32
+}
+
+type direntHeader struct {
+	Ino     uint64
+	Off     uint64
+	Namelen uint32
+	Type    uint32
+}
+
+const direntHeaderSize = 24
+
+// Server answers kernel requests for one mount
+type Server struct {
+	fs       FileSystem
+	fd       int
+	uid, gid uint32
+
+	mu     sync.Mutex
+	nodes  map[uint64]*node
+	ids    map[string]uint64 // path -> node ID
+	nextID uint64
+}
+
+// node is a path the kernel holds a reference to
+type node struct {
+	path    string
+	attr    Attr
+	lookups uint64
+}
+
+// Mount mounts fs read-only at mountpoint. Requests are answered once Serve
+// is called. Root mounts directly; other users need fusermount3 or
+// fusermount installed.
+func Mount(mountpoint string, fs FileSystem, opts Options) (*Server, error) {
+	fsname := opts.FSName
+	if fsname == "" {
+		fsname = "poon"
+	}
+
+	var fd int
+	var err error
+	if os.Geteuid() == 0 {
+		fd, err = mountDirect(mountpoint, fsname)
+	} else {
+		fd, err = mountFusermount(mountpoint, fsname)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newServer(fs, fd), nil
+}
+
+// newServer creates a server answering requests read from fd
+func newServer(fs FileSystem, fd int) *Server {
+	return &Server{
+		fs:  fs,
+		fd:  fd,
+		uid: uint32(os.Getuid()),
+		gid: uint32(os.Getgid()),
+		nodes: map[uint64]*node{
+			rootID: {attr: Attr{Dir: true, Mode: 0555}, lookups: 1},
+		},
+		ids:    map[string]uint64{"": rootID},
+		nextID: rootID + 1,
+	}
+}
+
+func mountDirect(mountpoint, fsname string) (int, error) {
+	fd, err := unix.Open("/dev/fuse", unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, fmt.Errorf("failed to open /dev/fuse: %w", err)
+	}
+
+	data := fmt.Sprintf("fd=%d,rootmode=%o,user_id=%d,group_id=%d,default_permissions",
+		fd, unix.S_IFDIR, os.Getuid(), os.Getgid())
+	if err := unix.Mount(fsname, mountpoint, "fuse.poon", unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, data); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to mount %s: %w", mountpoint, err)
+	}
+	return fd, nil
+}
+
+// mountFusermount has the setuid fusermount helper mount the file system and
+// pass back the /dev/fuse descriptor over a socket
+func mountFusermount(mountpoint, fsname string) (int, error) {
+	bin, err := fusermountPath()
+	if err != nil {
+		return -1, err
+	}
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create socket pair: %w", err)
+	}
+	defer unix.Close(fds[0])
+	remote := os.NewFile(uintptr(fds[1]), "fusermount")
+	defer remote.Close()
+
+	cmd := exec.Command(bin, "-o", "ro,nosuid,nodev,default_permissions,subtype=poon,fsname="+fsname, "--", mountpoint)
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return -1, fmt.Errorf("failed to run %s: %w", bin, err)
+	}
+	remote.Close()
+
+	fd, recvErr := receiveFD(fds[0])
+	if err := cmd.Wait(); err != nil {
+		if recvErr == nil {
+			unix.Close(fd)
+		}
+		return -1, fmt.Errorf("%s failed: %w", bin, err)
+	}
+	if recvErr != nil {
+		return -1, fmt.Errorf("failed to receive /dev/fuse descriptor: %w", recvErr)
+	}
+	return fd, nil
+}
+
+func receiveFD(socket int) (int, error) {
+	buf := make([]byte, 1)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_, oobn, _, _, err := unix.Recvmsg(socket, buf, oob, 0)
+	if err != nil {
+		return -1, err
+	}
+
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return -1, err
+	}
+	if len(msgs) != 1 {
+		return -1, fmt.Errorf("expected 1 control message, got %d", len(msgs))
+	}
+	fds, err := unix.ParseUnixRights(&msgs[0])
+	if err != nil {
+		return -1, err
+	}
+	if len(fds) != 1 {
+		return -1, fmt.Errorf("expected 1 descriptor, got %d", len(fds))
+	}
+	return fds[0], nil
+}
+
+func fusermountPath() (string, error) {
+	for _, name := range []string{"fusermount3", "fusermount"} {
+		if bin, err := exec.LookPath(name); err == nil {
+			return bin, nil
+		}
+	}
+	return "", fmt.Errorf("fusermount not found: install fuse3 or mount as root")
+}
+
+// Unmount detaches the file system at mountpoint, which makes Serve return.
+// It fails while files under the mountpoint are open.
+func Unmount(mountpoint string) error {
+	if os.Geteuid() == 0 {
+		return unix.Unmount(mountpoint, 0)
+	}
+
+	bin, err := fusermountPath()
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(bin, "-u", mountpoint).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Serve answers kernel requests until the file system is unmounted
+func (s *Server) Serve() error {
+	defer unix.Close(s.fd)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		buf := make([]byte, bufferSize)
+		n, err := unix.Read(s.fd, buf)
+		switch {
+		case err == unix.EINTR || err == unix.EAGAIN || err == unix.ENOENT:
+			// ENOENT means the request was interrupted before it was read
+			continue
+		case err == unix.ENODEV:
+			return nil // Unmounted
+		case err != nil:
+			return fmt.Errorf("failed to read request: %w", err)
+		case n < inHeaderSize:
+			return fmt.Errorf("short request of %d bytes", n)
+		}
+
+		var hdr inHeader
+		if err := decode(buf[:inHeaderSize], &hdr); err != nil {
+			return err
+		}
+		body := buf[inHeaderSize:n]
+
+		// INIT must be answered before anything else, and forgets have to
+		// be applied in order with the lookups that preceded them
+		switch hdr.Opcode {
+		case opInit, opForget, opBatchForget, opInterrupt:
+			s.handle(hdr, body)
+		default:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.handle(hdr, body)
+			}()
+		}
+	}
+}
+
+func (s *Server) handle(hdr inHeader, body []byte) {
+	var reply any
+	var err error
+
+	switch hdr.Opcode {
+	case opInit:
+		reply, err = s.init(body)
+	case opLookup:
+		reply, err = s.lookup(hdr.Nodeid, cString(body))
+	case opForget:
+		var in forgetIn
+		if decode(body, &in) == nil {
+			s.forget(hdr.Nodeid, in.Nlookup)
+		}
+		return // Forgets get no reply
+	case opBatchForget:
+		s.batchForget(body)
+		return
+	case opInterrupt:
+		return // Requests finish quickly; let them complete
+	case opGetattr:
+		reply, err = s.getattr(hdr.Nodeid)
+	case opOpen, opOpendir:
+		reply, err = s.open(hdr.Nodeid, hdr.Opcode == opOpendir, body)
+	case opRead:
+		reply, err = s.read(hdr.Nodeid, body)
+	case opReaddir:
+		reply, err = s.readdir(hdr.Nodeid, body)
+	case opRelease, opReleasedir, opFlush, opDestroy:
+		// Nothing is held open
+	case opStatfs:
+		reply = statfsOut{Bsize: 4096, Frsize: 4096, Namelen: 255}
+	case opAccess:
+		var in accessIn
+		if err = decode(body, &in); err == nil && in.Mask&unix.W_OK != 0 {
+			err = unix.EROFS
+		}
+	default:
+		err = unix.ENOSYS
+	}
+
+	s.reply(hdr.Unique, reply, err)
+}
+
+func (s *Server) reply(unique uint64, payload any, err error) {
+	var data []byte
+	switch p := payload.(type) {
+	case nil:
+	case []byte:
+		data = p
+	default:
+		data = encode(p)
+	}
+
+	var errno int32
+	if err != nil {
+		data = nil
+		errno = -int32(toErrno(err))
+	}
+
+	msg := encode(outHeader{Len: uint32(outHeaderSize + len(data)), Error: errno, Unique: unique})
+	// The kernel gives up on requests whose reply fails; there is no one
+	// else to report to
+	unix.Write(s.fd, append(msg, data...))
+}
+
+func (s *Server) init(body []byte) (any, error) {
+	var in initIn
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	if in.Major < kernelMajor {
+		return nil, unix.EPROTO
+	}
+
+	out := encode(initOut{
+		Major:               kernelMajor,
+		Minor:               min(in.Minor, kernelMinor),
+		MaxReadahead:        in.MaxReadahead,
+		Flags:               in.Flags & (initAsyncRead | initParallelDirops),
+		MaxBackground:       16,
+		CongestionThreshold: 12,
+		MaxWrite:            maxWrite,
+		TimeGran:            1,
+	})
+	if in.Minor < 23 {
+		out = out[:initOutCompatSize]
+	}
+	return out, nil
+}
+
+func (s *Server) lookup(parentID uint64, name string) (any, error) {
+	parent, err := s.node(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if !parent.attr.Dir {
+		return nil, unix.ENOTDIR
+	}
+
+	entries, err := s.fs.ReadDir(parent.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Name != name {
+			continue
+		}
+		childPath := joinPath(parent.path, name)
+		valid, validNsec := splitDuration(cacheTimeout)
+		return entryOut{
+			Nodeid:         s.ref(childPath, entry.Attr),
+			EntryValid:     valid,
+			EntryValidNsec: validNsec,
+			AttrValid:      valid,
+			AttrValidNsec:  validNsec,
+			Attr:           s.kernelAttr(childPath, entry.Attr),
+		}, nil
+	}
+	return nil, unix.ENOENT
+}
+
+func (s *Server) getattr(id uint64) (any, error) {
+	n, err := s.node(id)
+	if err != nil {
+		return nil, err
+	}
+	valid, validNsec := splitDuration(cacheTimeout)
+	return attrOut{AttrValid: valid, AttrValidNsec: validNsec, Attr: s.kernelAttr(n.path, n.attr)}, nil
+}
+
+func (s *Server) open(id uint64, dir bool, body []byte) (any, error) {
+	n, err := s.node(id)
+	if err != nil {
+		return nil, err
+	}
+	var in openIn
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case in.Flags&unix.O_ACCMODE != unix.O_RDONLY:
+		return nil, unix.EROFS
+	case dir && !n.attr.Dir:
+		return nil, unix.ENOTDIR
+	case !dir && n.attr.Dir:
+		return nil, unix.EISDIR
+	}
+	return openOut{OpenFlags: openKeepCache}, nil
+}
+
+func (s *Server) read(id uint64, body []byte) (any, error) {
+	n, err := s.node(id)
+	if err != nil {
+		return nil, err
+	}
+	var in readIn
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	return s.fs.ReadFile(n.path, int64(in.Offset), int(in.Size))
+}
+
+func (s *Server) readdir(id uint64, body []byte) (any, error) {
+	n, err := s.node(id)
+	if err != nil {
+		return nil, err
+	}
+	var in readIn
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.fs.ReadDir(n.path)
+	if err != nil {
+		return nil, err
+	}
+
+	type dirent struct {
+		name string
+		ino  uint64
+		dir  bool
+	}
+	all := []dirent{
+		{name: ".", ino: inode(n.path), dir: true},
+		{name: "..", ino: inode(parentPath(n.path)), dir: true},
+	}
+	for _, entry := range entries {
+		all = append(all, dirent{name: entry.Name, ino: inode(joinPath(n.path, entry.Name)), dir: entry.Attr.Dir})
+	}
+
+	// The offset of each entry is the index of the one after it
+	var buf bytes.Buffer
+	for i := int(in.Offset); i < len(all); i++ {
+		entry := all[i]
+		size := (direntHeaderSize + len(entry.name) + 7) &^ 7
+		if buf.Len()+size > int(in.Size) {
+			break
+		}
+
+		typ := uint32(unix.DT_REG)
+		if entry.dir {
+			typ = unix.DT_DIR
+		}
+		buf.Write(encode(direntHeader{Ino: entry.ino, Off: uint64(i + 1), Namelen: uint32(len(entry.name)), Type: typ}))
+		buf.WriteString(entry.name)
+		buf.Write(make([]byte, size-direntHeaderSize-len(entry.name)))
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Server) node(id uint64) (node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.nodes[id]
+	if !ok {
+		return node{}, unix.ESTALE
+	}
+	return *n, nil
+}
+
+// ref returns the node ID for path, counting one more kernel reference
+func (s *Server) ref(p string, attr Attr) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.ids[p]; ok {
+		s.nodes[id].lookups++
+		return id
+	}
+
+	id := s.nextID
+	s.nextID++
+	s.nodes[id] = &node{path: p, attr: attr, lookups: 1}
+	s.ids[p] = id
+	return id
+}
+
+func (s *Server) forget(id, lookups uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.nodes[id]
+	if !ok || id == rootID {
+		return
+	}
+	if n.lookups > lookups {
+		n.lookups -= lookups
+		return
+	}
+	delete(s.nodes, id)
+	delete(s.ids, n.path)
+}
+
+func (s *Server) batchForget(body []byte) {
+	var in batchForgetIn
+	if decode(body, &in) != nil {
+		return
+	}
+	body = body[8:]
+	for i := uint32(0); i < in.Count && len(body) >= 16; i++ {
+		var one forgetOne
+		if decode(body[:16], &one) != nil {
+			return
+		}
+		s.forget(one.Nodeid, one.Nlookup)
+		body = body[16:]
+	}
+}
+
+// kernelAttr converts attributes to their wire form. Everything is owned by
+// the user who mounted the tree and nothing is writable.
+func (s *Server) kernelAttr(p string, attr Attr) kernelAttr {
+	mode := uint32(unix.S_IFREG | 0444)
+	nlink := uint32(1)
+	if attr.Dir {
+		mode = unix.S_IFDIR | 0555
+		nlink = 2
+	} else if attr.Mode&0111 != 0 {
+		mode |= 0111
+	}
+
+	sec, nsec := uint64(attr.Mtime.Unix()), uint32(attr.Mtime.Nanosecond())
+	if attr.Mtime.IsZero() {
+		sec, nsec = 0, 0
+	}
+	return kernelAttr{
+		Ino:       inode(p),
+		Size:      attr.Size,
+		Blocks:    (attr.Size + 511) / 512,
+		Atime:     sec,
+		Mtime:     sec,
+		Ctime:     sec,
+		Atimensec: nsec,
+		Mtimensec: nsec,
+		Ctimensec: nsec,
+		Mode:      mode,
+		Nlink:     nlink,
+		Uid:       s.uid,
+		Gid:       s.gid,
+		Blksize:   4096,
+	}
+}
+
+// inode derives a stable inode number from a path, so a file keeps its
+// number after the kernel forgets it
+func inode(p string) uint64 {
+	if p == "" {
+		return rootID
+	}
+	h := fnv.New64a()
+	h.Write([]byte(p))
+	return max(h.Sum64(), rootID+1)
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+func parentPath(p string) string {
+	if dir := path.Dir(p); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+func splitDuration(d time.Duration) (uint64, uint32) {
+	return uint64(d / time.Second), uint32(d % time.Second)
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func encode(v any) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.NativeEndian, v)
+	return buf.Bytes()
+}
+
+func decode(b []byte, v any) error {
+	if err := binary.Read(bytes.NewReader(b), binary.NativeEndian, v); err != nil {
+		return fmt.Errorf("malformed request: %w", err)
+	}
+	return nil
+}
+
+func toErrno(err error) syscall.Errno {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		return errno
+	}
+	return syscall.EIO
+}
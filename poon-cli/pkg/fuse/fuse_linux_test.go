@@ -0,0 +1,263 @@
+//go:build linux
+
+package fuse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// memFS is a FileSystem held in memory
+type memFS map[string][]Dirent
+
+func (m memFS) ReadDir(path string) ([]Dirent, error) {
+	entries, ok := m[path]
+	if !ok {
+		return nil, unix.ENOENT
+	}
+	return entries, nil
+}
+
+func (m memFS) ReadFile(path string, offset int64, size int) ([]byte, error) {
+	content := []byte("hello, world\n")
+	if path != "docs/README.md" {
+		return nil, unix.ENOENT
+	}
+	if offset >= int64(len(content)) {
+		return nil, nil
+	}
+	return content[offset:min(offset+int64(size), int64(len(content)))], nil
+}
+
+// testServer answers requests written with call over a socket pair, as it
+// would the /dev/fuse descriptor
+type testServer struct {
+	t      *testing.T
+	server *Server
+	kernel int
+	unique uint64
+}
+
+func newTestServer(t *testing.T) *testServer {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		unix.Close(fds[0])
+		unix.Close(fds[1])
+	})
+	fs := memFS{
+		"": {
+			{Name: "docs", Attr: Attr{Dir: true, Mode: 0755}},
+			{Name: "run.sh", Attr: Attr{Size: 10, Mode: 0755}},
+		},
+		"docs": {
+			{Name: "README.md", Attr: Attr{Size: 13, Mode: 0644, Mtime: time.Unix(1700000000, 5)}},
+		},
+	}
+	return &testServer{t: t, server: newServer(fs, fds[0]), kernel: fds[1]}
+}
+
+// call sends a request for node and returns the reply's errno and payload
+func (ts *testServer) call(opcode uint32, nodeid uint64, body any) (int32, []byte) {
+	ts.t.Helper()
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		payload = b
+	default:
+		payload = encode(b)
+	}
+	ts.unique++
+	hdr := inHeader{Len: uint32(inHeaderSize + len(payload)), Opcode: opcode, Unique: ts.unique, Nodeid: nodeid}
+	ts.server.handle(hdr, payload)
+	if opcode == opForget || opcode == opBatchForget {
+		return 0, nil // No reply
+	}
+
+	buf := make([]byte, bufferSize)
+	n, err := unix.Read(ts.kernel, buf)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	var out outHeader
+	if err := decode(buf[:outHeaderSize], &out); err != nil {
+		ts.t.Fatal(err)
+	}
+	if out.Unique != ts.unique || int(out.Len) != n {
+		ts.t.Fatalf("reply header %+v for request %d of %d bytes", out, ts.unique, n)
+	}
+	return out.Error, buf[outHeaderSize:n]
+}
+
+func (ts *testServer) lookup(parent uint64, name string) entryOut {
+	ts.t.Helper()
+	errno, data := ts.call(opLookup, parent, append([]byte(name), 0))
+	if errno != 0 {
+		ts.t.Fatalf("lookup %s: errno %d", name, -errno)
+	}
+	var entry entryOut
+	if err := decode(data, &entry); err != nil {
+		ts.t.Fatal(err)
+	}
+	return entry
+}
+
+func TestInit(t *testing.T) {
+	ts := newTestServer(t)
+	errno, data := ts.call(opInit, 0, initIn{Major: 7, Minor: 38, MaxReadahead: 65536, Flags: initAsyncRead | 1<<5})
+	if errno != 0 {
+		t.Fatalf("errno %d", -errno)
+	}
+	var out initOut
+	if err := decode(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Major != kernelMajor || out.Minor != kernelMinor || out.Flags != initAsyncRead || out.MaxWrite != maxWrite {
+		t.Errorf("init reply %+v", out)
+	}
+
+	// Kernels before 7.23 expect the short reply
+	_, data = ts.call(opInit, 0, initIn{Major: 7, Minor: 19})
+	if len(data) != initOutCompatSize {
+		t.Errorf("reply to 7.19 is %d bytes, want %d", len(data), initOutCompatSize)
+	}
+	if errno, _ := ts.call(opInit, 0, initIn{Major: 6}); errno != -int32(unix.EPROTO) {
+		t.Errorf("init with major 6 got errno %d", -errno)
+	}
+}
+
+func TestLookupAndGetattr(t *testing.T) {
+	ts := newTestServer(t)
+	docs := ts.lookup(rootID, "docs")
+	if docs.Attr.Mode != unix.S_IFDIR|0555 || docs.Attr.Ino != inode("docs") {
+		t.Errorf("docs attributes %+v", docs.Attr)
+	}
+	readme := ts.lookup(docs.Nodeid, "README.md")
+	if readme.Attr.Mode != unix.S_IFREG|0444 || readme.Attr.Size != 13 || readme.Attr.Mtime != 1700000000 || readme.Attr.Mtimensec != 5 {
+		t.Errorf("README.md attributes %+v", readme.Attr)
+	}
+	if script := ts.lookup(rootID, "run.sh"); script.Attr.Mode != unix.S_IFREG|0555 {
+		t.Errorf("run.sh mode %o", script.Attr.Mode)
+	}
+
+	// A second lookup returns the same node
+	if again := ts.lookup(docs.Nodeid, "README.md"); again.Nodeid != readme.Nodeid {
+		t.Errorf("second lookup gave node %d, want %d", again.Nodeid, readme.Nodeid)
+	}
+	errno, data := ts.call(opGetattr, readme.Nodeid, nil)
+	var attr attrOut
+	if errno != 0 || decode(data, &attr) != nil || attr.Attr.Size != 13 {
+		t.Errorf("getattr errno %d, attributes %+v", -errno, attr.Attr)
+	}
+
+	if errno, _ := ts.call(opLookup, rootID, []byte("missing\x00")); errno != -int32(unix.ENOENT) {
+		t.Errorf("lookup of a missing name got errno %d", -errno)
+	}
+	if errno, _ := ts.call(opLookup, readme.Nodeid, []byte("x\x00")); errno != -int32(unix.ENOTDIR) {
+		t.Errorf("lookup under a file got errno %d", -errno)
+	}
+
+	// Each lookup is a reference; the node goes once both are forgotten
+	ts.call(opForget, readme.Nodeid, forgetIn{Nlookup: 1})
+	if errno, _ := ts.call(opGetattr, readme.Nodeid, nil); errno != 0 {
+		t.Errorf("getattr after one forget got errno %d", -errno)
+	}
+	var batch bytes.Buffer
+	batch.Write(encode(batchForgetIn{Count: 1}))
+	batch.Write(encode(forgetOne{Nodeid: readme.Nodeid, Nlookup: 1}))
+	ts.call(opBatchForget, 0, batch.Bytes())
+	if errno, _ := ts.call(opGetattr, readme.Nodeid, nil); errno != -int32(unix.ESTALE) {
+		t.Errorf("getattr of a forgotten node got errno %d", -errno)
+	}
+	if len(ts.server.nodes) != 3 || len(ts.server.ids) != 3 {
+		t.Errorf("%d nodes and %d ids left, want root, docs and run.sh", len(ts.server.nodes), len(ts.server.ids))
+	}
+}
+
+func TestOpenAndRead(t *testing.T) {
+	ts := newTestServer(t)
+	docs := ts.lookup(rootID, "docs")
+	readme := ts.lookup(docs.Nodeid, "README.md")
+
+	if errno, _ := ts.call(opOpen, readme.Nodeid, openIn{Flags: unix.O_WRONLY}); errno != -int32(unix.EROFS) {
+		t.Errorf("open for writing got errno %d", -errno)
+	}
+	if errno, _ := ts.call(opOpen, docs.Nodeid, openIn{}); errno != -int32(unix.EISDIR) {
+		t.Errorf("open of a directory got errno %d", -errno)
+	}
+	if errno, _ := ts.call(opOpendir, readme.Nodeid, openIn{}); errno != -int32(unix.ENOTDIR) {
+		t.Errorf("opendir of a file got errno %d", -errno)
+	}
+	errno, data := ts.call(opOpen, readme.Nodeid, openIn{})
+	var open openOut
+	if errno != 0 || decode(data, &open) != nil || open.OpenFlags != openKeepCache {
+		t.Errorf("open errno %d, reply %+v", -errno, open)
+	}
+
+	errno, data = ts.call(opRead, readme.Nodeid, readIn{Offset: 7, Size: 100})
+	if errno != 0 || string(data) != "world\n" {
+		t.Errorf("read errno %d, data %q", -errno, data)
+	}
+	if errno, _ := ts.call(opAccess, readme.Nodeid, accessIn{Mask: unix.W_OK}); errno != -int32(unix.EROFS) {
+		t.Errorf("access for writing got errno %d", -errno)
+	}
+	if errno, _ := ts.call(opGetattr, 999, nil); errno != -int32(unix.ESTALE) {
+		t.Errorf("getattr of an unknown node got errno %d", -errno)
+	}
+	if errno, _ := ts.call(99, rootID, nil); errno != -int32(unix.ENOSYS) {
+		t.Errorf("unknown opcode got errno %d", -errno)
+	}
+}
+
+func TestReaddir(t *testing.T) {
+	ts := newTestServer(t)
+
+	// readdir returns the names from offset on that fit in size
+	readdir := func(offset uint64, size uint32) ([]string, uint64) {
+		t.Helper()
+		errno, data := ts.call(opReaddir, rootID, readIn{Offset: offset, Size: size})
+		if errno != 0 {
+			t.Fatalf("readdir errno %d", -errno)
+		}
+		var names []string
+		var next uint64
+		for len(data) > 0 {
+			var hdr direntHeader
+			if err := binary.Read(bytes.NewReader(data), binary.NativeEndian, &hdr); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, string(data[direntHeaderSize:direntHeaderSize+hdr.Namelen]))
+			next = hdr.Off
+			data = data[(direntHeaderSize+int(hdr.Namelen)+7)&^7:]
+		}
+		return names, next
+	}
+
+	names, _ := readdir(0, 4096)
+	if want := []string{".", "..", "docs", "run.sh"}; !slices.Equal(names, want) {
+		t.Errorf("entries %v, want %v", names, want)
+	}
+
+	// A small buffer takes the listing in pieces, resumed at each offset
+	var all []string
+	var offset uint64
+	for {
+		names, next := readdir(offset, 64)
+		if len(names) == 0 {
+			break
+		}
+		all = append(all, names...)
+		offset = next
+	}
+	if want := []string{".", "..", "docs", "run.sh"}; !slices.Equal(all, want) {
+		t.Errorf("entries read in pieces %v, want %v", all, want)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package fuse
+
+// Server serves a mounted file system
+type Server struct{}
+
+// Mount is not supported on this platform
+func Mount(mountpoint string, fs FileSystem, opts Options) (*Server, error) {
+	return nil, ErrNotSupported
+}
+
+// Serve is not supported on this platform
+func (s *Server) Serve() error {
+	return ErrNotSupported
+}
+
+// Unmount is not supported on this platform
+func Unmount(mountpoint string) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractArchive writes the files, directories and symlinks of a tar, plain
+// or gzipped, below dir and returns how many files it wrote. Entries that
+// would land outside dir, directly or through a symlink, are rejected.
+//
+// Where symlinks cannot be created, as on Windows without developer mode, a
+// symlink is written as a file holding its target, as git does with
+// core.symlinks=false.
+func ExtractArchive(r io.Reader, dir string) (int, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return 0, err
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return 0, err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return 0, err
+	}
+
+	tr := tar.NewReader(r)
+	files := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return files, err
+		}
+
+		name := filepath.FromSlash(strings.TrimSuffix(header.Name, "/"))
+		if !filepath.IsLocal(name) {
+			return files, fmt.Errorf("archive entry %q is outside the destination", header.Name)
+		}
+		target := filepath.Join(root, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := makeDirWithin(root, target); err != nil {
+				return files, err
+			}
+		case tar.TypeReg:
+			if err := makeDirWithin(root, filepath.Dir(target)); err != nil {
+				return files, err
+			}
+			mode := os.FileMode(0644)
+			if header.Mode&0111 != 0 {
+				mode = 0755
+			}
+			if err := writeFile(target, mode, tr); err != nil {
+				return files, err
+			}
+			files++
+		case tar.TypeSymlink:
+			if err := makeDirWithin(root, filepath.Dir(target)); err != nil {
+				return files, err
+			}
+			parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+			if err != nil {
+				return files, err
+			}
+			if _, err := resolveWithin(root, parent, filepath.FromSlash(header.Linkname), 0); err != nil {
+				return files, fmt.Errorf("archive symlink %q points outside the destination", header.Name)
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				if os.IsExist(err) {
+					return files, err
+				}
+				if err := writeFile(target, 0644, strings.NewReader(header.Linkname)); err != nil {
+					return files, err
+				}
+			}
+			files++
+		case tar.TypeXGlobalHeader:
+			// git archive records the commit in a global header
+		default:
+			return files, fmt.Errorf("archive entry %q has unsupported type %q", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// ExtractTarContent extracts tar content to the specified destination
+func ExtractTarContent(tarContent []byte, destDir string) error {
+	if _, err := ExtractArchive(bytes.NewReader(tarContent), destDir); err != nil {
+		return fmt.Errorf("failed to extract tar: %v", err)
+	}
+	return nil
+}
+
+// decompress unwraps gzip, recognised by its magic number
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
+
+// maxSymlinkDepth bounds how many symlinks resolveWithin follows in a chain
+const maxSymlinkDepth = 40
+
+// makeDirWithin creates dir, failing before anything is created if it
+// resolves outside root through a symlink extracted earlier
+func makeDirWithin(root, dir string) error {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return err
+	}
+	if _, err := resolveWithin(root, root, rel, 0); err != nil {
+		return fmt.Errorf("archive entry %s resolves outside the destination", dir)
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// resolveWithin follows the relative path from the real directory dir one
+// element at a time, resolving each symlink it meets the way the system
+// would, and fails as soon as it leaves root. Absolute paths and paths
+// naming a volume are refused outright. Elements that do not exist yet are
+// taken as written.
+func resolveWithin(root, dir, path string, depth int) (string, error) {
+	if depth > maxSymlinkDepth {
+		return "", fmt.Errorf("too many levels of symlinks")
+	}
+	if filepath.IsAbs(path) || filepath.VolumeName(path) != "" || strings.HasPrefix(path, string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not a relative path", path)
+	}
+
+	current := dir
+	for _, part := range strings.Split(path, string(filepath.Separator)) {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			next := filepath.Join(current, part)
+			if info, err := os.Lstat(next); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				link, err := os.Readlink(next)
+				if err != nil {
+					return "", err
+				}
+				if next, err = resolveWithin(root, current, filepath.FromSlash(link), depth+1); err != nil {
+					return "", err
+				}
+			}
+			current = next
+		}
+		if rel, err := filepath.Rel(root, current); err != nil || !(rel == "." || filepath.IsLocal(rel)) {
+			return "", fmt.Errorf("%s resolves outside %s", path, root)
+		}
+	}
+	return current, nil
+}
+
+// writeFile creates path, or replaces it if it is a file. An existing
+// symlink is replaced rather than written through.
+func writeFile(path string, mode os.FileMode, r io.Reader) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,207 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type archiveEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+func buildTar(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     entry.mode,
+			Size:     int64(len(entry.content)),
+			Linkname: entry.linkname,
+		}
+		if entry.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if entry.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExtractArchive(t *testing.T) {
+	archive := buildTar(t, []archiveEntry{
+		{name: "src/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "src/app/main.go", typeflag: tar.TypeReg, mode: 0644, content: "package main\n"},
+		{name: "README.md", typeflag: tar.TypeReg, mode: 0644, content: "# Readme\n"},
+	})
+
+	for name, data := range map[string][]byte{
+		"plain":   archive,
+		"gzipped": gzipBytes(t, archive),
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			files, err := ExtractArchive(bytes.NewReader(data), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if files != 2 {
+				t.Errorf("extracted %d files, want 2", files)
+			}
+			if got := readFile(t, filepath.Join(dir, "src", "app", "main.go")); got != "package main\n" {
+				t.Errorf("main.go = %q", got)
+			}
+			if got := readFile(t, filepath.Join(dir, "README.md")); got != "# Readme\n" {
+				t.Errorf("README.md = %q", got)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("an older and longer version\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := buildTar(t, []archiveEntry{
+		{name: "notes.txt", typeflag: tar.TypeReg, mode: 0644, content: "new\n"},
+	})
+	if err := ExtractTarContent(archive, dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(dir, "notes.txt")); got != "new\n" {
+		t.Errorf("notes.txt = %q, want the archived content", got)
+	}
+}
+
+func TestExtractArchiveRejectsEscapes(t *testing.T) {
+	for name, entries := range map[string][]archiveEntry{
+		"parent": {
+			{name: "../escape.txt", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+		},
+		"absolute": {
+			{name: "/tmp/escape.txt", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+		},
+		"symlink target": {
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "../outside"},
+		},
+		"through symlinks": {
+			{name: "a/", typeflag: tar.TypeDir, mode: 0755},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "a/c", typeflag: tar.TypeSymlink, linkname: "b/.."},
+			{name: "a/c/escape.txt", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+		},
+		"absolute symlink target": {
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+		},
+		"chained symlinks": {
+			{name: "a/", typeflag: tar.TypeDir, mode: 0755},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "a/c", typeflag: tar.TypeSymlink, linkname: "b/.."},
+		},
+		"directory through symlinks": {
+			{name: "p", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "p/q", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "p/q/escape/", typeflag: tar.TypeDir, mode: 0755},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "dest")
+			if _, err := ExtractArchive(bytes.NewReader(buildTar(t, entries)), dir); err == nil {
+				t.Fatal("expected the archive to be rejected")
+			}
+			outside, err := os.ReadDir(parent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(outside) != 1 {
+				t.Fatalf("%d entries beside the destination, want none", len(outside)-1)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveSymlink(t *testing.T) {
+	dir := t.TempDir()
+	archive := buildTar(t, []archiveEntry{
+		{name: "docs/guide.md", typeflag: tar.TypeReg, mode: 0644, content: "# Guide\n"},
+		{name: "GUIDE.md", typeflag: tar.TypeSymlink, linkname: "docs/guide.md"},
+	})
+	if _, err := ExtractArchive(bytes.NewReader(archive), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without symlink support the link is a file holding its target
+	link := filepath.Join(dir, "GUIDE.md")
+	if target, err := os.Readlink(link); err == nil {
+		if target != "docs/guide.md" {
+			t.Errorf("GUIDE.md links to %q", target)
+		}
+	} else if got := readFile(t, link); got != "docs/guide.md" {
+		t.Errorf("GUIDE.md = %q, want the link target", got)
+	}
+}
+
+func TestMakeDirWithin(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "dest")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(parent, filepath.Join(root, "up")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if err := makeDirWithin(root, filepath.Join(root, "up", "escape", "deeper")); err == nil {
+		t.Fatal("expected a directory through an escaping symlink to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escape")); err == nil {
+		t.Fatal("a directory was created outside the destination")
+	}
+	if err := makeDirWithin(root, filepath.Join(root, "inside", "deeper")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -42,30 +42,6 @@ func MoveDirectoryContents(src, dst string) error {
 	return nil
 }
 
-// ExtractTarContent extracts tar content to the specified destination
-func ExtractTarContent(tarContent []byte, destDir string) error {
-	tempFile, err := os.CreateTemp("", "poon-download-*.tar")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %v", err)
-	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	if _, err := tempFile.Write(tarContent); err != nil {
-		return fmt.Errorf("failed to write tar content: %v", err)
-	}
-
-	cmd := exec.Command("tar", "-xf", tempFile.Name(), "-C", destDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to extract tar: %v", err)
-	}
-
-	return nil
-}
-
 // SyncFromRemote pulls the latest changes from the remote git repository
 func SyncFromRemote() error {
 	fmt.Printf("Syncing with remote repository...\n")
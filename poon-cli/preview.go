@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// FilePatch is the patch for a single changed file in the workspace
+type FilePatch struct {
+	Path  string
+	Patch []byte
+}
+
+// PreviewOutput is the machine-readable result of `poon push --dry-run`
+type PreviewOutput struct {
+	Path        string   `json:"path"`
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+	BaseVersion int64    `json:"baseVersion,omitempty"`
+	NewFile     bool     `json:"newFile,omitempty"`
+	DeletedFile bool     `json:"deletedFile,omitempty"`
+	OldSize     int      `json:"oldSize"`
+	NewSize     int      `json:"newSize"`
+	Conflicts   []string `json:"conflicts,omitempty"`
+	Reason      string   `json:"reason,omitempty"` // Why the patch would not apply, from the server's FailureInfo
+	Hints       []string `json:"hints,omitempty"`
+}
+
+// workspacePatches returns one patch per changed file under the tracked
+// paths, including untracked files, without touching the git index
+func workspacePatches(config *PoonConfig) ([]FilePatch, error) {
+	paths, err := dirtyTrackedPaths(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	changed, err := gitOutput(append([]string{"diff", "--name-only", "-z", "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	untracked, err := gitOutput(append([]string{"ls-files", "--others", "--exclude-standard", "-z", "--"}, paths...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var patches []FilePatch
+	for _, file := range splitNUL(changed) {
+		patch, err := gitOutput("diff", "--binary", "HEAD", "--", file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate patch for %s: %v", file, err)
+		}
+		patches = append(patches, FilePatch{Path: file, Patch: patch})
+	}
+	for _, file := range splitNUL(untracked) {
+		// git diff --no-index exits with status 1 when the files differ
+		patch, err := gitOutput("diff", "--binary", "--no-index", "--", "/dev/null", file)
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			return nil, fmt.Errorf("failed to generate patch for %s: %v", file, err)
+		}
+		patches = append(patches, FilePatch{Path: file, Patch: patch})
+	}
+
+	return patches, nil
+}
+
+// splitNUL splits NUL-terminated git output
+func splitNUL(out []byte) []string {
+	return strings.FieldsFunc(string(out), func(r rune) bool { return r == 0 })
+}
+
+// previewPush shows what pushing the workspace changes would do, without
+// creating a version
+func previewPush(config *PoonConfig) error {
+	patches, err := workspacePatches(config)
+	if err != nil {
+		return err
+	}
+
+	results := []PreviewOutput{}
+	for _, filePatch := range patches {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := client.PreviewPatch(ctx, &pb.PreviewPatchRequest{
+			Path:   filePatch.Path,
+			Patch:  filePatch.Patch,
+			Author: localUser(),
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to preview patch for %s: %v", filePatch.Path, err)
+		}
+
+		results = append(results, PreviewOutput{
+			Path:        filePatch.Path,
+			Success:     resp.Success,
+			Message:     resp.Message,
+			BaseVersion: resp.BaseVersion,
+			NewFile:     resp.NewFile,
+			DeletedFile: resp.DeletedFile,
+			OldSize:     len(resp.OriginalContent),
+			NewSize:     len(resp.Content),
+			Conflicts:   resp.Conflicts,
+			Reason:      resp.GetFailure().GetReason(),
+			Hints:       remediationHints(poonclient.FailureDetails(resp.Failure)),
+		})
+	}
+
+	if isJSONOutput() {
+		return printJSON(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No local changes to push")
+		return nil
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+			fmt.Printf("✗ %s: %s\n", result.Path, result.Message)
+			for _, conflict := range result.Conflicts {
+				fmt.Printf("   %s\n", conflict)
+			}
+			printHints(os.Stdout, "   ", result.Hints)
+			continue
+		}
+
+		if result.NewFile {
+			fmt.Printf("✓ %s: new file, %d bytes\n", result.Path, result.NewSize)
+		} else if result.DeletedFile {
+			fmt.Printf("✓ %s: deleted, %d bytes (restorable with 'poon restore')\n", result.Path, result.OldSize)
+		} else {
+			fmt.Printf("✓ %s: %d -> %d bytes\n", result.Path, result.OldSize, result.NewSize)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\nDry run: %d of %d files would not apply\n", failed, len(results))
+	} else {
+		fmt.Printf("\nDry run: %d files would be pushed, nothing was changed\n", len(results))
+	}
+	return nil
+}
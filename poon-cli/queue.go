@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// QueueEntryOutput is the machine-readable form of a merge queue entry
+type QueueEntryOutput struct {
+	ID            string `json:"id"`
+	State         string `json:"state"`
+	StateMessage  string `json:"stateMessage"`
+	Position      int32  `json:"position,omitempty"`
+	Author        string `json:"author"`
+	Message       string `json:"message"`
+	Path          string `json:"path"`
+	BaseVersion   int64  `json:"baseVersion,omitempty"`
+	LandedVersion int64  `json:"landedVersion,omitempty"`
+	CommitHash    string `json:"commitHash,omitempty"`
+	DetailsURL    string `json:"detailsUrl,omitempty"`
+	SubmittedAt   string `json:"submittedAt"`
+	UpdatedAt     string `json:"updatedAt"`
+}
+
+// QueueOutput is the machine-readable result of `poon queue status`
+type QueueOutput struct {
+	Enabled bool               `json:"enabled"`
+	Entries []QueueEntryOutput `json:"entries"`
+}
+
+func queueEntryOutput(entry *pb.QueueEntry) QueueEntryOutput {
+	return QueueEntryOutput{
+		ID:            entry.Id,
+		State:         queueStateName(entry.State),
+		StateMessage:  entry.StateMessage,
+		Position:      entry.Position,
+		Author:        entry.Author,
+		Message:       entry.Message,
+		Path:          entry.Path,
+		BaseVersion:   entry.BaseVersion,
+		LandedVersion: entry.LandedVersion,
+		CommitHash:    entry.CommitHash,
+		DetailsURL:    entry.DetailsUrl,
+		SubmittedAt:   time.Unix(entry.SubmittedAt, 0).Format(time.RFC3339),
+		UpdatedAt:     time.Unix(entry.UpdatedAt, 0).Format(time.RFC3339),
+	}
+}
+
+// queueStateName turns QUEUE_LANDED into "landed"
+func queueStateName(state pb.QueueEntryState) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "QUEUE_"))
+}
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Inspect the merge queue",
+	Long: `Inspect the merge queue. When the server runs a merge queue, 'poon apply'
+adds the patch to it instead of landing it right away; the queue rebases each
+change onto the current version, waits for external validation and lands
+changes one at a time.`,
+}
+
+var queueStatusCmd = &cobra.Command{
+	Use:   "status [entry-id]",
+	Short: "Show queued and recently finished changes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryID := ""
+		if len(args) > 0 {
+			entryID = args[0]
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.GetMergeQueue(ctx, &pb.GetMergeQueueRequest{EntryId: entryID})
+		if err != nil {
+			return fmt.Errorf("failed to get merge queue: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := QueueOutput{Enabled: resp.Enabled, Entries: []QueueEntryOutput{}}
+			for _, entry := range resp.Entries {
+				out.Entries = append(out.Entries, queueEntryOutput(entry))
+			}
+			return printJSON(out)
+		}
+
+		if !resp.Enabled {
+			fmt.Println("Merge queue is not enabled; patches land as soon as they are applied")
+			return nil
+		}
+
+		if entryID != "" {
+			printQueueEntry(resp.Entries[0])
+			return nil
+		}
+
+		if len(resp.Entries) == 0 {
+			fmt.Println("✓ Merge queue is empty")
+			return nil
+		}
+
+		var pending, finished []*pb.QueueEntry
+		for _, entry := range resp.Entries {
+			if entry.Position > 0 {
+				pending = append(pending, entry)
+			} else {
+				finished = append(finished, entry)
+			}
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("✓ No changes waiting")
+		} else {
+			fmt.Printf("Queued changes (%d):\n", len(pending))
+		}
+		for _, entry := range pending {
+			fmt.Printf("  %d. %s  %s  %q\n", entry.Position, entry.Id, entry.Author, firstLine(entry.Message))
+			fmt.Printf("     %s\n", entry.StateMessage)
+		}
+		if len(finished) > 0 {
+			fmt.Printf("\nRecently finished:\n")
+			for _, entry := range finished {
+				mark := "✓"
+				if entry.State != pb.QueueEntryState_QUEUE_LANDED {
+					mark = "✗"
+				}
+				fmt.Printf("  %s %s  %s  %q\n", mark, entry.Id, entry.Author, firstLine(entry.Message))
+				fmt.Printf("     %s\n", entry.StateMessage)
+			}
+		}
+		return nil
+	},
+}
+
+func printQueueEntry(entry *pb.QueueEntry) {
+	switch entry.State {
+	case pb.QueueEntryState_QUEUE_LANDED:
+		fmt.Printf("✓ %s\n", entry.StateMessage)
+	case pb.QueueEntryState_QUEUE_FAILED:
+		fmt.Printf("✗ %s\n", entry.StateMessage)
+	default:
+		fmt.Printf("Position %d: %s\n", entry.Position, entry.StateMessage)
+	}
+
+	fmt.Printf("Entry:     %s\n", entry.Id)
+	fmt.Printf("State:     %s\n", queueStateName(entry.State))
+	fmt.Printf("Author:    %s\n", entry.Author)
+	fmt.Printf("Message:   %s\n", firstLine(entry.Message))
+	fmt.Printf("Submitted: %s\n", time.Unix(entry.SubmittedAt, 0).Format(time.RFC3339))
+	if entry.BaseVersion > 0 {
+		fmt.Printf("Rebased:   onto version %d\n", entry.BaseVersion)
+	}
+	if entry.CommitHash != "" {
+		fmt.Printf("Commit:    %s\n", entry.CommitHash)
+	}
+	if entry.DetailsUrl != "" {
+		fmt.Printf("Details:   %s\n", entry.DetailsUrl)
+	}
+}
+
+// firstLine returns the subject line of a commit message
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
+
+func init() {
+	queueCmd.AddCommand(queueStatusCmd)
+	rootCmd.AddCommand(queueCmd)
+}
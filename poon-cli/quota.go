@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// QuotaOutput is the machine-readable result of `poon quota`
+type QuotaOutput struct {
+	User      string         `json:"user"`
+	UserUsage *pb.QuotaUsage `json:"userUsage"`
+	Workspace string         `json:"workspace,omitempty"`
+	Usage     *pb.QuotaUsage `json:"workspaceUsage,omitempty"`
+}
+
+// formatLimit renders a usage figure against its limit (0 means unlimited)
+func formatLimit(used, limit int64) string {
+	if limit == 0 {
+		return fmt.Sprintf("%d (unlimited)", used)
+	}
+	return fmt.Sprintf("%d of %d", used, limit)
+}
+
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Show storage and request usage against quota limits",
+	Long: `Show storage and request usage against quota limits for the current user,
+and for the current workspace when run inside one.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceID := ""
+		if config, err := loadPoonConfig(); err == nil {
+			workspaceID = config.WorkspaceName
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.GetQuota(ctx, &pb.GetQuotaRequest{WorkspaceId: workspaceID})
+		if err != nil {
+			return fmt.Errorf("failed to get quota: %w", err)
+		}
+		if !resp.Success {
+			return fmt.Errorf("failed to get quota: %s", resp.Message)
+		}
+
+		if isJSONOutput() {
+			return printJSON(QuotaOutput{
+				User:      resp.User,
+				UserUsage: resp.UserUsage,
+				Workspace: workspaceID,
+				Usage:     resp.WorkspaceUsage,
+			})
+		}
+
+		fmt.Printf("User: %s\n", resp.User)
+		fmt.Printf("  Storage (bytes): %s\n", formatLimit(resp.UserUsage.BytesUsed, resp.UserUsage.BytesLimit))
+		fmt.Printf("  Workspaces:      %s\n", formatLimit(resp.UserUsage.WorkspacesUsed, resp.UserUsage.WorkspacesLimit))
+		fmt.Printf("  Requests:        %d\n", resp.UserUsage.RequestCount)
+
+		if resp.WorkspaceUsage != nil {
+			fmt.Printf("\nWorkspace: %s\n", workspaceID)
+			fmt.Printf("  Storage (bytes): %s\n", formatLimit(resp.WorkspaceUsage.BytesUsed, resp.WorkspaceUsage.BytesLimit))
+			fmt.Printf("  Disk (bytes):    %s\n", formatLimit(resp.WorkspaceUsage.DiskBytesUsed, resp.WorkspaceUsage.DiskBytesLimit))
+			fmt.Printf("  Requests:        %d\n", resp.WorkspaceUsage.RequestCount)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quotaCmd)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configPath = ".poon/config.json"
+
+	// defaultWorkspaceName refers to the workspace created by 'poon start'
+	defaultWorkspaceName = "default"
+)
+
+var (
+	workspaceSelector string
+	remoteWorkspaceID string
+)
+
+// findWorkspaceRoot returns the closest directory at or above the current one
+// that holds a poon config, the way git looks for .git
+func findWorkspaceRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, configPath)); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no poon workspace found (run 'poon start' first)")
+		}
+		dir = parent
+	}
+}
+
+// selectWorkspace makes the named remote the active workspace. An empty name
+// or "default" keeps the workspace created by 'poon start'.
+func (c *PoonConfig) selectWorkspace(name string) error {
+	if name == "" || name == defaultWorkspaceName {
+		return nil
+	}
+
+	remote, ok := c.Remotes[name]
+	if !ok {
+		return fmt.Errorf("unknown workspace %q (see 'poon remote')", name)
+	}
+
+	c.defaultWorkspace = c.WorkspaceConfig
+	c.WorkspaceConfig = *remote
+	c.selected = name
+	return nil
+}
+
+// fileContents returns the config as stored on disk, with any changes to a
+// selected remote written back under its name
+func (c *PoonConfig) fileContents() *PoonConfig {
+	if c.selected == "" {
+		return c
+	}
+
+	remotes := make(map[string]*WorkspaceConfig, len(c.Remotes))
+	for name, remote := range c.Remotes {
+		remotes[name] = remote
+	}
+	selected := c.WorkspaceConfig
+	remotes[c.selected] = &selected
+
+	return &PoonConfig{WorkspaceConfig: c.defaultWorkspace, Remotes: remotes}
+}
+
+// workspaceName returns the name of the active workspace
+func (c *PoonConfig) workspaceName() string {
+	if c.selected == "" {
+		return defaultWorkspaceName
+	}
+	return c.selected
+}
+
+// gitRemote returns the git remote the active workspace is fetched from
+func (c *PoonConfig) gitRemote() string {
+	if c.selected == "" {
+		return "origin"
+	}
+	return c.selected
+}
+
+// RemoteOutput is the machine-readable form of one configured workspace
+type RemoteOutput struct {
+	Name         string   `json:"name"`
+	Workspace    string   `json:"workspace"`
+	Active       bool     `json:"active"`
+	TrackedPaths []string `json:"trackedPaths"`
+}
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "List the workspaces this checkout can push to and sync from",
+	Long: `List the workspaces this checkout can push to and sync from.
+
+A checkout starts with the workspace created by 'poon start', named "default".
+Further workspaces tracking other slices of the monorepo can be added with
+'poon remote add' and selected for any command with --workspace.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadPoonConfig()
+		if err != nil {
+			return err
+		}
+
+		file := config.fileContents()
+		remotes := []RemoteOutput{{
+			Name:         defaultWorkspaceName,
+			Workspace:    file.WorkspaceName,
+			Active:       config.selected == "",
+			TrackedPaths: file.TrackedPaths,
+		}}
+		names := make([]string, 0, len(file.Remotes))
+		for name := range file.Remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			remotes = append(remotes, RemoteOutput{
+				Name:         name,
+				Workspace:    file.Remotes[name].WorkspaceName,
+				Active:       config.selected == name,
+				TrackedPaths: file.Remotes[name].TrackedPaths,
+			})
+		}
+
+		if isJSONOutput() {
+			return printJSON(remotes)
+		}
+
+		for _, remote := range remotes {
+			marker := " "
+			if remote.Active {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\t%s\t%v\n", marker, remote.Name, remote.Workspace, remote.TrackedPaths)
+		}
+		return nil
+	},
+}
+
+var remoteAddCmd = &cobra.Command{
+	Use:   "add <name> [initial-path]",
+	Short: "Add a named workspace to this checkout",
+	Long: `Add a named workspace to this checkout.
+
+A new workspace tracking initial-path is created on the server, or an existing
+one is attached with --id. Its repository is added as a git remote of the
+same name.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if name == defaultWorkspaceName || name == "origin" {
+			return fmt.Errorf("%q is reserved for the workspace created by 'poon start'", name)
+		}
+		if (len(args) == 2) == (remoteWorkspaceID != "") {
+			return fmt.Errorf("give either an initial path or --id")
+		}
+
+		config, err := loadPoonConfig()
+		if err != nil {
+			return err
+		}
+		if _, exists := config.Remotes[name]; exists {
+			return fmt.Errorf("workspace %q already exists", name)
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		remoteURL := fmt.Sprintf("http://%s/%s.git", gitServerAddr, remoteWorkspaceID)
+		remote := &WorkspaceConfig{
+			WorkspaceName: remoteWorkspaceID,
+			GitServerURL:  gitServerAddr,
+			GrpcServerURL: serverAddr,
+			CreatedAt:     time.Now().Format(time.RFC3339),
+		}
+
+		if remoteWorkspaceID != "" {
+			resp, err := client.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: remoteWorkspaceID})
+			if err != nil {
+				return fmt.Errorf("failed to get workspace: %w", err)
+			}
+			if !resp.Success {
+				return fmt.Errorf("server failed to get workspace: %s", resp.Message)
+			}
+			// --id may be a workspace name; the config keeps the ID
+			remote.WorkspaceName = resp.Workspace.Id
+			remoteURL = fmt.Sprintf("http://%s/%s.git", gitServerAddr, resp.Workspace.Id)
+			remote.TrackedPaths = resp.Workspace.TrackedPaths
+			remote.TrackedPatterns = resp.Workspace.TrackedPatterns
+		} else {
+			resp, err := client.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{
+				TrackedPaths: []string{args[1]},
+				BaseBranch:   "main",
+				Metadata: map[string]string{
+					"client_version": clientVersion,
+					"created_by":     "poon-cli",
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create workspace on server: %w", err)
+			}
+			if !resp.Success {
+				return fmt.Errorf("server failed to create workspace: %s", resp.Message)
+			}
+			remote.WorkspaceName = resp.WorkspaceId
+			remote.TrackedPaths = []string{args[1]}
+			remoteURL = resp.RemoteUrl
+		}
+
+		if config.Remotes == nil {
+			config.Remotes = make(map[string]*WorkspaceConfig)
+		}
+		config.Remotes[name] = remote
+		if err := savePoonConfig(config); err != nil {
+			return err
+		}
+
+		if err := runCommand("git", "remote", "add", name, remoteURL); err != nil {
+			fmt.Printf("Warning: failed to add git remote %s: %v\n", name, err)
+		}
+
+		fmt.Printf("✓ Added workspace %s (%s)\n", name, remote.WorkspaceName)
+		fmt.Printf("   Tracking: %v\n", remote.TrackedPaths)
+		fmt.Printf("   Use it with: poon --workspace %s <command>\n", name)
+		return nil
+	},
+}
+
+var remoteRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a named workspace from this checkout",
+	Long: `Remove a named workspace from this checkout. The workspace itself is kept on
+the server.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		config, err := loadPoonConfig()
+		if err != nil {
+			return err
+		}
+		if _, exists := config.Remotes[name]; !exists {
+			return fmt.Errorf("unknown workspace %q", name)
+		}
+		if name == config.selected {
+			return fmt.Errorf("cannot remove the selected workspace %q", name)
+		}
+
+		delete(config.Remotes, name)
+		if err := savePoonConfig(config); err != nil {
+			return err
+		}
+
+		if err := runCommand("git", "remote", "remove", name); err != nil {
+			fmt.Printf("Warning: failed to remove git remote %s: %v\n", name, err)
+		}
+
+		fmt.Printf("✓ Removed workspace %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	remoteAddCmd.Flags().StringVar(&remoteWorkspaceID, "id", "", "Attach an existing workspace, by ID or name, instead of creating one")
+
+	remoteCmd.AddCommand(remoteAddCmd)
+	remoteCmd.AddCommand(remoteRemoveCmd)
+	rootCmd.AddCommand(remoteCmd)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// DeletedPathOutput is the machine-readable form of a file in the trash
+type DeletedPathOutput struct {
+	Path           string `json:"path"`
+	Hash           string `json:"hash"`
+	Size           int64  `json:"size"`
+	DeletedVersion int64  `json:"deletedVersion"`
+	DeletedBy      string `json:"deletedBy"`
+	DeletedAt      string `json:"deletedAt"`
+}
+
+// RestoreOutput is the machine-readable result of `poon restore`
+type RestoreOutput struct {
+	Success  bool                `json:"success"`
+	Message  string              `json:"message"`
+	Version  int64               `json:"version,omitempty"`
+	Restored []DeletedPathOutput `json:"restored"`
+}
+
+func deletedPathOutput(path *pb.DeletedPath) DeletedPathOutput {
+	return DeletedPathOutput{
+		Path:           path.Path,
+		Hash:           path.Hash,
+		Size:           path.Size,
+		DeletedVersion: path.DeletedVersion,
+		DeletedBy:      path.DeletedBy,
+		DeletedAt:      time.Unix(path.DeletedAt, 0).Format(time.RFC3339),
+	}
+}
+
+var restoreMessage string
+
+var trashCmd = &cobra.Command{
+	Use:   "trash [path]",
+	Short: "List deleted files that can be restored",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := ""
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.ListDeletedPaths(ctx, &pb.ListDeletedPathsRequest{Path: path})
+		if err != nil {
+			return fmt.Errorf("failed to list deleted paths: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := []DeletedPathOutput{}
+			for _, deleted := range resp.Paths {
+				out = append(out, deletedPathOutput(deleted))
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Paths) == 0 {
+			fmt.Println("✓ Trash is empty")
+			return nil
+		}
+
+		fmt.Printf("Deleted files (%d):\n", len(resp.Paths))
+		for _, deleted := range resp.Paths {
+			fmt.Printf("  %-40s %8d bytes  deleted in version %d by %s, %s\n",
+				deleted.Path, deleted.Size, deleted.DeletedVersion, deleted.DeletedBy,
+				time.Unix(deleted.DeletedAt, 0).Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("\nRestore with 'poon restore <path>'\n")
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Restore files deleted by a patch",
+	Long: `Restore a deleted file, or every deleted file below a directory, with the
+content it had when it was deleted. The restore lands as a new version.
+Files that exist again are not overwritten. See 'poon trash' for what can be
+restored.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		resp, err := client.RestoreDeletedPath(ctx, &pb.RestoreDeletedPathRequest{
+			Path:    args[0],
+			Author:  localUser(),
+			Message: restoreMessage,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %v", args[0], err)
+		}
+
+		if isJSONOutput() {
+			out := RestoreOutput{
+				Success:  resp.Success,
+				Message:  resp.Message,
+				Version:  resp.Version,
+				Restored: []DeletedPathOutput{},
+			}
+			for _, restored := range resp.Restored {
+				out.Restored = append(out.Restored, deletedPathOutput(restored))
+			}
+			return printJSON(out)
+		}
+
+		if !resp.Success {
+			fmt.Printf("✗ %s\n", resp.Message)
+			return nil
+		}
+
+		fmt.Printf("✓ %s\n", resp.Message)
+		for _, restored := range resp.Restored {
+			fmt.Printf("  %s (deleted in version %d)\n", restored.Path, restored.DeletedVersion)
+		}
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().StringVarP(&restoreMessage, "message", "m", "", "Commit message (default: \"Restore <path>\")")
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const stashDir = ".poon/stash"
+
+// StashEntry describes one set of stashed tracked-path changes. The changes
+// themselves are kept as a git patch next to the index.
+type StashEntry struct {
+	ID        int       `json:"id"`
+	Message   string    `json:"message"`
+	Paths     []string  `json:"paths"`
+	PatchFile string    `json:"patchFile"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// StashIndex is the list of stash entries, most recent first
+type StashIndex struct {
+	NextID  int           `json:"nextId"`
+	Entries []*StashEntry `json:"entries"`
+}
+
+func loadStashIndex() (*StashIndex, error) {
+	data, err := os.ReadFile(filepath.Join(stashDir, "index.json"))
+	if os.IsNotExist(err) {
+		return &StashIndex{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stash index: %w", err)
+	}
+
+	var index StashIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse stash index: %w", err)
+	}
+
+	return &index, nil
+}
+
+func saveStashIndex(index *StashIndex) error {
+	if err := os.MkdirAll(stashDir, 0755); err != nil {
+		return fmt.Errorf("failed to create stash directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stash index: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(stashDir, "index.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write stash index: %w", err)
+	}
+
+	return nil
+}
+
+// gitOutput runs git and returns its standard output
+func gitOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// dirtyTrackedPaths returns the tracked paths that have uncommitted changes,
+// including untracked files
+func dirtyTrackedPaths(config *PoonConfig) ([]string, error) {
+	var dirty []string
+	for _, path := range config.TrackedPaths {
+		out, err := gitOutput("status", "--porcelain", "--", path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status for %s: %v", path, err)
+		}
+		if len(strings.TrimSpace(string(out))) > 0 {
+			dirty = append(dirty, path)
+		}
+	}
+	return dirty, nil
+}
+
+// findStashEntry resolves an optional stash id argument; the most recent
+// entry is used when no id is given
+func findStashEntry(index *StashIndex, args []string) (int, error) {
+	if len(index.Entries) == 0 {
+		return -1, fmt.Errorf("no stash entries")
+	}
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "stash@"))
+	if err != nil {
+		return -1, fmt.Errorf("invalid stash id: %s", args[0])
+	}
+
+	for i, entry := range index.Entries {
+		if entry.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("stash entry %d not found", id)
+}
+
+// applyStashEntry reapplies a stashed patch to the working tree
+func applyStashEntry(entry *StashEntry) error {
+	patchPath := filepath.Join(stashDir, entry.PatchFile)
+	if err := runCommand("git", "apply", "--3way", patchPath); err != nil {
+		return fmt.Errorf("failed to apply stash %d (the stash was kept): %v", entry.ID, err)
+	}
+
+	// --3way stages the result; leave changes unstaged like they were stashed
+	if err := runCommand("git", append([]string{"reset", "-q", "--"}, entry.Paths...)...); err != nil {
+		return fmt.Errorf("failed to unstage applied changes: %w", err)
+	}
+
+	return nil
+}
+
+func dropStashEntry(index *StashIndex, i int) error {
+	entry := index.Entries[i]
+	index.Entries = append(index.Entries[:i], index.Entries[i+1:]...)
+	if err := saveStashIndex(index); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(stashDir, entry.PatchFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stash patch: %w", err)
+	}
+
+	return nil
+}
+
+var stashMessage string
+
+var stashCmd = &cobra.Command{
+	Use:   "stash",
+	Short: "Stash local changes in tracked paths",
+	Long: `Save uncommitted changes in tracked paths as a patch under .poon/stash and
+restore those paths to their last committed state, so the workspace can be
+synced cleanly. Use 'poon stash pop' to reapply the changes afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stashPush()
+	},
+}
+
+var stashPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Stash local changes in tracked paths",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stashPush()
+	},
+}
+
+func stashPush() error {
+	config, err := loadPoonConfig()
+	if err != nil {
+		return err
+	}
+
+	paths, err := dirtyTrackedPaths(config)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fmt.Println("No local changes to stash")
+		return nil
+	}
+
+	// Mark untracked files as intent-to-add so they are included in the diff
+	if err := runCommand("git", append([]string{"add", "--intent-to-add", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to prepare untracked files: %w", err)
+	}
+
+	patch, err := gitOutput(append([]string{"diff", "--binary", "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return fmt.Errorf("failed to generate stash patch: %w", err)
+	}
+
+	index, err := loadStashIndex()
+	if err != nil {
+		return err
+	}
+
+	entry := &StashEntry{
+		ID:        index.NextID,
+		Message:   stashMessage,
+		Paths:     paths,
+		PatchFile: fmt.Sprintf("%d.patch", index.NextID),
+		CreatedAt: time.Now(),
+	}
+	if entry.Message == "" {
+		entry.Message = fmt.Sprintf("WIP on %s", strings.Join(paths, ", "))
+	}
+
+	if err := os.MkdirAll(stashDir, 0755); err != nil {
+		return fmt.Errorf("failed to create stash directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(stashDir, entry.PatchFile), patch, 0644); err != nil {
+		return fmt.Errorf("failed to write stash patch: %w", err)
+	}
+
+	index.NextID++
+	index.Entries = append([]*StashEntry{entry}, index.Entries...)
+	if err := saveStashIndex(index); err != nil {
+		return err
+	}
+
+	// Only revert once the patch is safely on disk
+	if err := runCommand("git", append([]string{"reset", "-q", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to unstage stashed changes: %w", err)
+	}
+	if err := runCommand("git", append([]string{"checkout", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to revert stashed changes: %w", err)
+	}
+	if err := runCommand("git", append([]string{"clean", "-fdq", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to remove stashed untracked files: %w", err)
+	}
+
+	fmt.Printf("✓ Saved stash@%d: %s\n", entry.ID, entry.Message)
+	return nil
+}
+
+var stashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stashed changes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadPoonConfig(); err != nil {
+			return err
+		}
+
+		index, err := loadStashIndex()
+		if err != nil {
+			return err
+		}
+
+		if isJSONOutput() {
+			entries := index.Entries
+			if entries == nil {
+				entries = []*StashEntry{}
+			}
+			return printJSON(entries)
+		}
+
+		for _, entry := range index.Entries {
+			fmt.Printf("stash@%d: %s (%s)\n", entry.ID, entry.Message, entry.CreatedAt.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+var stashApplyCmd = &cobra.Command{
+	Use:   "apply [stash-id]",
+	Short: "Reapply stashed changes without removing them from the stash",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadPoonConfig(); err != nil {
+			return err
+		}
+
+		index, err := loadStashIndex()
+		if err != nil {
+			return err
+		}
+
+		i, err := findStashEntry(index, args)
+		if err != nil {
+			return err
+		}
+
+		if err := applyStashEntry(index.Entries[i]); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Applied stash@%d\n", index.Entries[i].ID)
+		return nil
+	},
+}
+
+var stashPopCmd = &cobra.Command{
+	Use:   "pop [stash-id]",
+	Short: "Reapply stashed changes and remove them from the stash",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadPoonConfig(); err != nil {
+			return err
+		}
+
+		index, err := loadStashIndex()
+		if err != nil {
+			return err
+		}
+
+		i, err := findStashEntry(index, args)
+		if err != nil {
+			return err
+		}
+
+		entry := index.Entries[i]
+		if err := applyStashEntry(entry); err != nil {
+			return err
+		}
+		if err := dropStashEntry(index, i); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Applied and dropped stash@%d\n", entry.ID)
+		return nil
+	},
+}
+
+var stashDropCmd = &cobra.Command{
+	Use:   "drop [stash-id]",
+	Short: "Discard stashed changes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadPoonConfig(); err != nil {
+			return err
+		}
+
+		index, err := loadStashIndex()
+		if err != nil {
+			return err
+		}
+
+		i, err := findStashEntry(index, args)
+		if err != nil {
+			return err
+		}
+
+		id := index.Entries[i].ID
+		if err := dropStashEntry(index, i); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Dropped stash@%d\n", id)
+		return nil
+	},
+}
+
+func init() {
+	stashCmd.Flags().StringVarP(&stashMessage, "message", "m", "", "Description of the stashed changes")
+	stashPushCmd.Flags().StringVarP(&stashMessage, "message", "m", "", "Description of the stashed changes")
+
+	stashCmd.AddCommand(stashPushCmd)
+	stashCmd.AddCommand(stashListCmd)
+	stashCmd.AddCommand(stashApplyCmd)
+	stashCmd.AddCommand(stashPopCmd)
+	stashCmd.AddCommand(stashDropCmd)
+	rootCmd.AddCommand(stashCmd)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,9 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
 )
 
 type WorkspaceState struct {
@@ -17,11 +21,12 @@ type WorkspaceState struct {
 }
 
 type TrackedPathState struct {
-	Path         string            `json:"path"`
-	Files        map[string]string `json:"files"` // filename -> hash
-	LastSyncHash string            `json:"lastSyncHash"`
-	AddedAt      time.Time         `json:"addedAt"`
-	LastSyncAt   time.Time         `json:"lastSyncAt"`
+	Path            string            `json:"path"`
+	Files           map[string]string `json:"files"`        // filename -> hash
+	LastSyncHash    string            `json:"lastSyncHash"` // Server tree hash of the path at LastSyncVersion
+	LastSyncVersion int64             `json:"lastSyncVersion,omitempty"`
+	AddedAt         time.Time         `json:"addedAt"`
+	LastSyncAt      time.Time         `json:"lastSyncAt"`
 }
 
 func loadWorkspaceState() (*WorkspaceState, error) {
@@ -35,12 +40,12 @@ func loadWorkspaceState() (*WorkspaceState, error) {
 
 	data, err := os.ReadFile(statePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state file: %v", err)
+		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
 	var state WorkspaceState
 	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("failed to parse state file: %v", err)
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
 
 	if state.TrackedPaths == nil {
@@ -52,22 +57,119 @@ func loadWorkspaceState() (*WorkspaceState, error) {
 
 func saveWorkspaceState(state *WorkspaceState) error {
 	if err := os.MkdirAll(".poon", 0755); err != nil {
-		return fmt.Errorf("failed to create .poon directory: %v", err)
+		return fmt.Errorf("failed to create .poon directory: %w", err)
 	}
 
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal state: %v", err)
+		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
 	statePath := ".poon/state.json"
 	if err := os.WriteFile(statePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write state file: %v", err)
+		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
 }
 
+// recordSyncedTrees pins the server's tree hash of each synced path at the
+// version it was fetched from, so 'poon status' can tell whether the path
+// changed since. Servers without GetTreeHash leave nothing to compare.
+func recordSyncedTrees(paths []string, version int64) error {
+	if !serverInfo.Supports(poonclient.FeatureTreeHashes) || len(paths) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetTreeHash(ctx, &pb.GetTreeHashRequest{Paths: paths, Version: version})
+	if err != nil {
+		return fmt.Errorf("failed to get tree hashes: %w", err)
+	}
+
+	state, err := loadWorkspaceState()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, hash := range resp.Hashes {
+		pathState, ok := state.TrackedPaths[hash.Path]
+		if !ok {
+			pathState = &TrackedPathState{Path: hash.Path, AddedAt: now}
+			state.TrackedPaths[hash.Path] = pathState
+		}
+		pathState.LastSyncHash = hash.Hash
+		pathState.LastSyncVersion = resp.Version
+		pathState.LastSyncAt = now
+	}
+	state.LastSync = now
+	return saveWorkspaceState(state)
+}
+
+// PathStatus is whether a tracked path changed on the server since it was
+// last synced
+type PathStatus struct {
+	Path          string `json:"path"`
+	State         string `json:"state"` // "up-to-date", "changed", "deleted" or "not-synced"
+	SyncedVersion int64  `json:"syncedVersion,omitempty"`
+}
+
+// Tracked path states reported by 'poon status'
+const (
+	pathUpToDate  = "up-to-date"
+	pathChanged   = "changed"
+	pathDeleted   = "deleted"
+	pathNotSynced = "not-synced"
+)
+
+// trackedPathStatus compares the tree hashes recorded at the last sync with
+// the server's current ones in a single GetTreeHash call. It returns the
+// server's current version.
+func trackedPathStatus(paths []string) ([]PathStatus, int64, error) {
+	if err := connectToServer(); err != nil {
+		return nil, 0, err
+	}
+	// A server that could not be asked is assumed to be current
+	if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureTreeHashes) {
+		return nil, 0, fmt.Errorf("the server does not report tree hashes")
+	}
+	state, err := loadWorkspaceState()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.GetTreeHash(ctx, &pb.GetTreeHashRequest{Paths: paths})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var statuses []PathStatus
+	for _, hash := range resp.Hashes {
+		status := PathStatus{Path: hash.Path}
+		pathState, synced := state.TrackedPaths[hash.Path]
+		switch {
+		case !synced || pathState.LastSyncHash == "":
+			status.State = pathNotSynced
+		case !hash.Exists:
+			status.State = pathDeleted
+		case hash.Hash == pathState.LastSyncHash:
+			status.State = pathUpToDate
+		default:
+			status.State = pathChanged
+		}
+		if synced {
+			status.SyncedVersion = pathState.LastSyncVersion
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, resp.Version, nil
+}
+
 func calculateDirectoryHash(dirPath string) (map[string]string, error) {
 	files := make(map[string]string)
 
@@ -80,8 +182,15 @@ func calculateDirectoryHash(dirPath string) (map[string]string, error) {
 			return nil
 		}
 
+		// Keys use forward slashes on every platform, like repository paths
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		relPath = filepath.ToSlash(relPath)
+
 		// Skip .git and other hidden directories
-		if strings.Contains(path, "/.") {
+		if strings.HasPrefix(relPath, ".") || strings.Contains(relPath, "/.") {
 			return nil
 		}
 
@@ -91,11 +200,6 @@ func calculateDirectoryHash(dirPath string) (map[string]string, error) {
 		}
 
 		hash := sha256.Sum256(content)
-		relPath, err := filepath.Rel(dirPath, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path: %v", err)
-		}
-
 		files[relPath] = fmt.Sprintf("%x", hash)
 		return nil
 	})
@@ -107,7 +211,7 @@ func generatePatchForPath(pathState *TrackedPathState, currentPath string) (stri
 	// Calculate current file hashes
 	currentFiles, err := calculateDirectoryHash(currentPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to calculate current directory hash: %v", err)
+		return "", fmt.Errorf("failed to calculate current directory hash: %w", err)
 	}
 
 	// TODO: Generate unified diff patch by comparing:
@@ -130,7 +234,7 @@ func updateTrackedPathState(state *WorkspaceState, path string) error {
 	// Update file hashes to current state
 	currentFiles, err := calculateDirectoryHash(path)
 	if err != nil {
-		return fmt.Errorf("failed to calculate directory hash: %v", err)
+		return fmt.Errorf("failed to calculate directory hash: %w", err)
 	}
 
 	pathState.Files = currentFiles
@@ -138,3 +242,46 @@ func updateTrackedPathState(state *WorkspaceState, path string) error {
 
 	return nil
 }
+
+// reportWorkspaceStatus tells the server the local HEAD and number of dirty
+// files after a sync or push. Failures only produce a warning since the
+// operation itself already succeeded.
+func reportWorkspaceStatus(config *PoonConfig, operation string) {
+	if err := sendWorkspaceStatus(config, operation); err != nil {
+		fmt.Printf("Warning: failed to report workspace status: %v\n", err)
+	}
+}
+
+func sendWorkspaceStatus(config *PoonConfig, operation string) error {
+	head, err := gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	dirty := 0
+	if len(config.TrackedPaths) > 0 {
+		out, err := gitOutput(append([]string{"status", "--porcelain", "-z", "--"}, config.TrackedPaths...)...)
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+		dirty = len(splitNUL(out))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{
+		WorkspaceId:   config.WorkspaceName,
+		ClientVersion: clientVersion,
+		HeadCommit:    strings.TrimSpace(string(head)),
+		DirtyFiles:    int32(dirty),
+		Operation:     operation,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Message)
+	}
+	return nil
+}
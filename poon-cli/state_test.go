@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalculateDirectoryHash(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		filepath.Join("app", "main.go"),
+		filepath.Join("app", "lib", "util.go"),
+		filepath.Join("app", ".cache", "state"),
+		filepath.Join(".git", "HEAD"),
+		"README.md",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := calculateDirectoryHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys use forward slashes on every platform and hidden entries are
+	// skipped
+	want := []string{"README.md", "app/lib/util.go", "app/main.go"}
+	if len(files) != len(want) {
+		t.Fatalf("hashed %v, want %v", files, want)
+	}
+	for _, name := range want {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s in %v", name, files)
+		}
+	}
+}
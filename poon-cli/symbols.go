@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// SymbolOutput is one symbol in the machine-readable result of `poon symbols`
+type SymbolOutput struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Path      string `json:"path"`
+	Line      int32  `json:"line"`
+	Column    int32  `json:"column"`
+	Container string `json:"container,omitempty"`
+	Language  string `json:"language"`
+}
+
+// SymbolsOutput is the machine-readable result of `poon symbols`
+type SymbolsOutput struct {
+	Version   int64          `json:"version"`
+	Symbols   []SymbolOutput `json:"symbols"`
+	Truncated bool           `json:"truncated"`
+}
+
+var (
+	symbolsKinds []string
+	symbolsPath  string
+	symbolsExact bool
+	symbolsLimit int32
+)
+
+var symbolsCmd = &cobra.Command{
+	Use:   "symbols <query>",
+	Short: "Find where functions, types and other symbols are defined",
+	Long: `Find the definitions of functions, types, classes and other symbols whose
+names contain the query, ignoring case. Whole-name matches come first, then
+names starting with the query. The server must index symbols.
+
+  poon symbols NewServer
+  poon symbols handler --kind struct --kind class --path src/backend`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureSymbols) {
+			return fmt.Errorf("server %s does not index symbols", serverAddr)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		resp, err := client.SearchSymbols(ctx, &pb.SearchSymbolsRequest{
+			Query: args[0],
+			Path:  symbolsPath,
+			Kinds: symbolsKinds,
+			Exact: symbolsExact,
+			Limit: symbolsLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to search symbols: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := SymbolsOutput{Version: resp.Version, Symbols: []SymbolOutput{}, Truncated: resp.Truncated}
+			for _, symbol := range resp.Symbols {
+				out.Symbols = append(out.Symbols, SymbolOutput{
+					Name:      symbol.Name,
+					Kind:      symbol.Kind,
+					Path:      symbol.Path,
+					Line:      symbol.Line,
+					Column:    symbol.Column,
+					Container: symbol.Container,
+					Language:  symbol.Language,
+				})
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Symbols) == 0 {
+			fmt.Printf("No symbols match %q\n", args[0])
+			return nil
+		}
+		for _, symbol := range resp.Symbols {
+			name := symbol.Name
+			if symbol.Container != "" {
+				name = symbol.Container + "." + name
+			}
+			fmt.Printf("%s:%d:%d\t%s %s\n", symbol.Path, symbol.Line, symbol.Column, symbol.Kind, name)
+		}
+		if resp.Truncated {
+			fmt.Printf("(more symbols match; narrow the query or raise --limit)\n")
+		}
+		return nil
+	},
+}
+
+func init() {
+	symbolsCmd.Flags().StringArrayVar(&symbolsKinds, "kind", nil, "Only symbols of this kind, such as function, method, type or class (repeatable)")
+	symbolsCmd.Flags().StringVar(&symbolsPath, "path", "", "Only symbols under this path")
+	symbolsCmd.Flags().BoolVar(&symbolsExact, "exact", false, "Only symbols named exactly the query, ignoring case")
+	symbolsCmd.Flags().Int32Var(&symbolsLimit, "limit", 0, "Most symbols to list (0 for the server's default)")
+	rootCmd.AddCommand(symbolsCmd)
+}
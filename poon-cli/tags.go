@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	poonclient "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+// TagOutput is the machine-readable form of a tag
+type TagOutput struct {
+	Name       string `json:"name"`
+	Version    int64  `json:"version"`
+	CommitHash string `json:"commitHash"`
+	CreatedAt  string `json:"createdAt"`
+	CreatedBy  string `json:"createdBy"`
+}
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags [prefix]",
+	Short: "List tags pinning versions",
+	Long: `List the tags that pin versions of the repository, such as the snapshots
+the server takes on a schedule (nightly-2024-06-01). Pass a tag's version to
+--version, as in 'poon mount --version N', to work from the pinned point.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		if serverInfo != nil && !serverInfo.Supports(poonclient.FeatureTags) {
+			return fmt.Errorf("server %s has no tags", serverAddr)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := client.ListTags(ctx, &pb.ListTagsRequest{Prefix: prefix})
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		if isJSONOutput() {
+			out := []TagOutput{}
+			for _, tag := range resp.Tags {
+				out = append(out, TagOutput{
+					Name:       tag.Name,
+					Version:    tag.Version,
+					CommitHash: tag.CommitHash,
+					CreatedAt:  time.Unix(tag.CreatedAt, 0).Format(time.RFC3339),
+					CreatedBy:  tag.CreatedBy,
+				})
+			}
+			return printJSON(out)
+		}
+
+		if len(resp.Tags) == 0 {
+			fmt.Println("No tags")
+			return nil
+		}
+
+		for _, tag := range resp.Tags {
+			fmt.Printf("%s  version %d  (%s by %s)\n", tag.Name, tag.Version, time.Unix(tag.CreatedAt, 0).Format(time.RFC3339), tag.CreatedBy)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// VersionOutput is the machine-readable result of `poon version`
+type VersionOutput struct {
+	ClientVersion string            `json:"clientVersion"`
+	Server        string            `json:"server"`
+	ServerInfo    *ServerInfoOutput `json:"serverInfo,omitempty"` // Absent when the server could not be asked
+	Error         string            `json:"error,omitempty"`
+}
+
+// ServerInfoOutput is what the server reported in GetServerInfo
+type ServerInfoOutput struct {
+	Version          string   `json:"version,omitempty"`
+	APIVersion       string   `json:"apiVersion,omitempty"`
+	MinClientVersion string   `json:"minClientVersion,omitempty"`
+	Features         []string `json:"features"`
+	AuthModes        []string `json:"authModes"`
+	HashAlgorithm    string   `json:"hashAlgorithm,omitempty"`
+	WebURL           string   `json:"webUrl,omitempty"`
+	Legacy           bool     `json:"legacy"` // The server predates GetServerInfo
+	ClientTooOld     bool     `json:"clientTooOld"`
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the client version and what the server supports",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output := VersionOutput{ClientVersion: clientVersion, Server: serverAddr}
+
+		err := connectToServer()
+		if err == nil && serverInfo == nil {
+			// Ask again for the reason the check on connect failed
+			serverInfo, err = conn.ServerInfo(context.Background(), clientVersion)
+		}
+		if err != nil {
+			output.Error = err.Error()
+		} else {
+			output.ServerInfo = &ServerInfoOutput{
+				Version:          serverInfo.Version,
+				APIVersion:       serverInfo.APIVersion,
+				MinClientVersion: serverInfo.MinClientVersion,
+				Features:         serverInfo.Features,
+				AuthModes:        serverInfo.AuthModes,
+				HashAlgorithm:    serverInfo.HashAlgorithm,
+				WebURL:           serverInfo.WebURL,
+				Legacy:           serverInfo.Legacy,
+				ClientTooOld:     serverInfo.ClientTooOld(clientVersion),
+			}
+		}
+
+		if isJSONOutput() {
+			return printJSON(output)
+		}
+
+		fmt.Printf("poon %s\n", clientVersion)
+		info := output.ServerInfo
+		switch {
+		case info == nil:
+			fmt.Printf("Server %s: unavailable (%s)\n", serverAddr, output.Error)
+		case info.Legacy:
+			fmt.Printf("Server %s: version unknown (older than GetServerInfo); streaming reads are not used\n", serverAddr)
+		default:
+			fmt.Printf("Server %s: poon-server %s (API %s)\n", serverAddr, info.Version, info.APIVersion)
+			fmt.Printf("  Features: %s\n", strings.Join(info.Features, ", "))
+			fmt.Printf("  Authentication: %s\n", strings.Join(info.AuthModes, ", "))
+			if info.HashAlgorithm != "" {
+				fmt.Printf("  Hash algorithm: %s\n", info.HashAlgorithm)
+			}
+			if info.WebURL != "" {
+				fmt.Printf("  Web UI: %s\n", info.WebURL)
+			}
+			if info.ClientTooOld {
+				fmt.Printf("  ✗ Requires poon %s or newer\n", info.MinClientVersion)
+			} else {
+				fmt.Printf("  ✓ Supports poon %s and newer\n", info.MinClientVersion)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-cli/pkg/util"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// workspaceBundleVersion is the format of bundles written by 'poon
+	// workspace export'; import refuses newer ones
+	workspaceBundleVersion = 1
+
+	bundleManifestName = "manifest.json"
+	bundleCommitsName  = "commits.bundle"
+)
+
+// bundledPoonPaths are the parts of .poon a bundle carries. The blob cache and
+// fetch journal are left behind: 'poon sync' rebuilds them on the new machine.
+var bundledPoonPaths = []string{configPath, ".poon/state.json", stashDir, hooksDir}
+
+// WorkspaceBundleManifest describes the checkout a workspace bundle was
+// exported from
+type WorkspaceBundleManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	ClientVersion string    `json:"clientVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	Branch        string    `json:"branch"`             // Checked-out branch
+	Upstream      string    `json:"upstream,omitempty"` // e.g. origin/main
+	LocalCommits  int       `json:"localCommits"`       // Commits on branches not yet pushed
+}
+
+var workspaceExportCmd = &cobra.Command{
+	Use:   "export [bundle]",
+	Short: "Export this checkout's workspace state to a portable bundle",
+	Long: `Export this checkout's workspace state to a portable bundle, by default
+poon-workspace-<id>.tar.gz in the current directory.
+
+The bundle holds the poon config, including named workspaces, the workspace
+state, stashes, hooks and every local commit not yet pushed to a workspace.
+Uncommitted changes are not exported; commit them or 'poon stash push' them
+first. Use 'poon workspace import' to recreate the checkout on another
+machine, connected to the same server-side workspaces.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The output path is relative to where the command runs, not to the
+		// workspace root loadPoonConfig moves to
+		var output string
+		if len(args) == 1 {
+			abs, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			output = abs
+		}
+
+		config, err := loadPoonConfig()
+		if err != nil {
+			return err
+		}
+		file := config.fileContents()
+		if output == "" {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			output = filepath.Join(cwd, fmt.Sprintf("poon-workspace-%s.tar.gz", file.WorkspaceName))
+		}
+
+		branch, err := gitOutput("symbolic-ref", "--short", "HEAD")
+		if err != nil {
+			return fmt.Errorf("the checkout is not on a branch; check one out before exporting")
+		}
+		manifest := WorkspaceBundleManifest{
+			FormatVersion: workspaceBundleVersion,
+			ClientVersion: clientVersion,
+			ExportedAt:    time.Now(),
+			Branch:        strings.TrimSpace(string(branch)),
+		}
+		if upstream, err := quietGitOutput("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+			manifest.Upstream = strings.TrimSpace(string(upstream))
+		}
+
+		count, err := gitOutput("rev-list", "--count", "--branches", "--not", "--remotes")
+		if err != nil {
+			return fmt.Errorf("failed to count local commits: %w", err)
+		}
+		manifest.LocalCommits, _ = strconv.Atoi(strings.TrimSpace(string(count)))
+
+		if dirty, err := gitOutput("status", "--porcelain"); err == nil && len(dirty) > 0 {
+			changes := strings.Count(strings.TrimSpace(string(dirty)), "\n") + 1
+			fmt.Printf("Warning: %d uncommitted change(s) are not exported; commit them or run 'poon stash push' first\n", changes)
+		}
+
+		if err := writeWorkspaceBundle(output, &manifest); err != nil {
+			os.Remove(output)
+			return err
+		}
+
+		fmt.Printf("✓ Exported workspace %s to %s\n", file.WorkspaceName, output)
+		fmt.Printf("   Branch: %s (%d local commit(s))\n", manifest.Branch, manifest.LocalCommits)
+		if len(file.Remotes) > 0 {
+			fmt.Printf("   Named workspaces: %d\n", len(file.Remotes))
+		}
+		fmt.Printf("Import it on another machine with: poon workspace import %s\n", filepath.Base(output))
+		return nil
+	},
+}
+
+// quietGitOutput runs git and returns its standard output, discarding errors
+// the caller expects and handles
+func quietGitOutput(args ...string) ([]byte, error) {
+	return exec.Command("git", args...).Output()
+}
+
+// writeWorkspaceBundle writes the manifest, the bundled .poon files and a git
+// bundle of the local commits to a gzipped tar at path. It runs from the
+// workspace root.
+func writeWorkspaceBundle(path string, manifest *WorkspaceBundleManifest) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeTarFile(tw, bundleManifestName, 0644, int64(len(data)), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	for _, root := range bundledPoonPaths {
+		err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return writeTarFile(tw, filepath.ToSlash(name), info.Mode().Perm(), info.Size(), f)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", root, err)
+		}
+	}
+
+	if manifest.LocalCommits > 0 {
+		if err := addCommitsBundle(tw); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return out.Close()
+}
+
+// addCommitsBundle adds a git bundle of every branch's commits that no
+// remote has. Importing it needs the remotes' commits, which the import
+// fetches from the server first.
+func addCommitsBundle(tw *tar.Writer) error {
+	tmp, err := os.CreateTemp("", "poon-commits-*.bundle")
+	if err != nil {
+		return fmt.Errorf("failed to bundle local commits: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := runCommand("git", "bundle", "create", "-q", tmp.Name(), "--branches", "--not", "--remotes"); err != nil {
+		return fmt.Errorf("failed to bundle local commits: %w", err)
+	}
+
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("failed to bundle local commits: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to bundle local commits: %w", err)
+	}
+	return writeTarFile(tw, bundleCommitsName, 0644, info.Size(), f)
+}
+
+func writeTarFile(tw *tar.Writer, name string, mode os.FileMode, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     int64(mode),
+		Size:     size,
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+var workspaceImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Recreate a checkout from a bundle made by 'poon workspace export'",
+	Long: `Recreate a checkout in the current directory from a bundle made by 'poon
+workspace export'.
+
+The workspaces named in the bundle must still exist on the server given by
+--server; their git remotes are set up against --git-server. The poon config,
+state, stashes and hooks are restored, the local commits are reapplied and
+the exported branch is checked out. Run 'poon sync' afterwards to refresh the
+cache.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundlePath, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		for _, existing := range []string{".poon", ".git"} {
+			if _, err := os.Stat(existing); err == nil {
+				return fmt.Errorf("the current directory already has %s; import into an empty directory", existing)
+			}
+		}
+
+		staging, err := os.MkdirTemp(".", ".poon-import-")
+		if err != nil {
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+		defer os.RemoveAll(staging)
+
+		manifest, config, err := readWorkspaceBundle(bundlePath, staging)
+		if err != nil {
+			return err
+		}
+
+		// Every workspace the checkout refers to must still be on the server
+		if err := connectToServer(); err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		remotes := map[string]*WorkspaceConfig{"origin": &config.WorkspaceConfig}
+		for name, remote := range config.Remotes {
+			remotes[name] = remote
+		}
+		names := make([]string, 0, len(remotes))
+		for name, remote := range remotes {
+			resp, err := client.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: remote.WorkspaceName})
+			if err != nil {
+				return fmt.Errorf("failed to get workspace %s: %w", remote.WorkspaceName, err)
+			}
+			if !resp.Success {
+				return fmt.Errorf("workspace %s is not available on %s: %s", remote.WorkspaceName, serverAddr, resp.Message)
+			}
+			remote.GitServerURL = gitServerAddr
+			remote.GrpcServerURL = serverAddr
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if err := runCommand("git", "init", "-q"); err != nil {
+			return fmt.Errorf("failed to initialize git repository: %w", err)
+		}
+		for _, name := range names {
+			remoteURL := fmt.Sprintf("http://%s/%s.git", gitServerAddr, remotes[name].WorkspaceName)
+			if err := runCommand("git", "remote", "add", name, remoteURL); err != nil {
+				return fmt.Errorf("failed to add git remote %s: %w", name, err)
+			}
+			if err := runCommand("git", "fetch", "-q", name); err != nil {
+				return fmt.Errorf("failed to fetch workspace %s: %w", remotes[name].WorkspaceName, err)
+			}
+		}
+
+		if manifest.LocalCommits > 0 {
+			commits := filepath.Join(staging, bundleCommitsName)
+			if err := runCommand("git", "fetch", "-q", "--update-head-ok", commits, "refs/heads/*:refs/heads/*"); err != nil {
+				return fmt.Errorf("failed to restore local commits: %w", err)
+			}
+		}
+
+		// Branches without local commits start from their upstream
+		start := "refs/heads/" + manifest.Branch
+		if _, err := quietGitOutput("rev-parse", "--verify", "-q", start); err != nil {
+			if manifest.Upstream == "" {
+				return fmt.Errorf("branch %s has no commits to check out", manifest.Branch)
+			}
+			start = manifest.Upstream
+		}
+		if err := runCommand("git", "checkout", "-q", "-f", "-B", manifest.Branch, start); err != nil {
+			return fmt.Errorf("failed to check out %s: %w", manifest.Branch, err)
+		}
+		if manifest.Upstream != "" {
+			if err := runCommand("git", "branch", "-q", "--set-upstream-to="+manifest.Upstream); err != nil {
+				fmt.Printf("Warning: failed to set upstream of %s: %v\n", manifest.Branch, err)
+			}
+		}
+
+		if err := runCommand("git", "config", "user.email", "poon@example.com"); err != nil {
+			return fmt.Errorf("failed to configure git user email: %w", err)
+		}
+		if err := runCommand("git", "config", "user.name", "Poon CLI"); err != nil {
+			return fmt.Errorf("failed to configure git user name: %w", err)
+		}
+
+		if err := os.Rename(filepath.Join(staging, ".poon"), ".poon"); err != nil {
+			return fmt.Errorf("failed to restore .poon: %w", err)
+		}
+		if err := savePoonConfig(config); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Imported workspace %s\n", config.WorkspaceName)
+		fmt.Printf("   Branch: %s (%d local commit(s))\n", manifest.Branch, manifest.LocalCommits)
+		if len(config.Remotes) > 0 {
+			fmt.Printf("   Named workspaces: %d\n", len(config.Remotes))
+		}
+		fmt.Printf("Run 'poon sync' to refresh the cache\n")
+		return nil
+	},
+}
+
+// readWorkspaceBundle extracts a workspace bundle into dir and returns its
+// manifest and poon config
+func readWorkspaceBundle(path, dir string) (*WorkspaceBundleManifest, *PoonConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a workspace bundle: %w", path, err)
+	}
+	if _, err := util.ExtractArchive(gz, dir); err != nil {
+		return nil, nil, fmt.Errorf("failed to extract bundle: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, bundleManifestName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a workspace bundle: missing %s", path, bundleManifestName)
+	}
+	var manifest WorkspaceBundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+	if manifest.FormatVersion > workspaceBundleVersion {
+		return nil, nil, fmt.Errorf("bundle format %d is newer than this poon supports (%d); upgrade poon", manifest.FormatVersion, workspaceBundleVersion)
+	}
+	if manifest.Branch == "" {
+		return nil, nil, fmt.Errorf("bundle manifest does not name a branch")
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, configPath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("bundle has no poon config: %w", err)
+	}
+	var config PoonConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse bundled config: %w", err)
+	}
+	if config.WorkspaceName == "" {
+		return nil, nil, fmt.Errorf("bundled config does not name a workspace")
+	}
+	return &manifest, &config, nil
+}
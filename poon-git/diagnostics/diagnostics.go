@@ -0,0 +1,66 @@
+// Package diagnostics serves the ops port of poon-server and poon-git:
+// net/http/pprof, and expvar with values each server adds.
+package diagnostics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+)
+
+// Vars are the values /debug/vars reports besides expvar's own (memstats
+// and cmdline); each is computed when the page is fetched
+type Vars map[string]func() interface{}
+
+// NewHandler serves net/http/pprof under /debug/pprof/ and expvar with vars
+// under /debug/vars; servers may add their own pages to it. Nothing on it is
+// authenticated and profiles expose memory and command lines, so it must
+// only listen where operators can reach it.
+func NewHandler(vars Vars) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/vars", func(w http.ResponseWriter, r *http.Request) {
+		ServeVars(w, vars)
+	})
+	return mux
+}
+
+// ServeVars writes the same JSON object as expvar.Handler with vars added.
+// They are not published with expvar.Publish, which is process-wide and
+// refuses a name twice, so every server in a process reports its own.
+func ServeVars(w http.ResponseWriter, vars Vars) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(w, "{\n")
+	first := true
+	write := func(name, value string) {
+		if !first {
+			fmt.Fprint(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", name, value)
+	}
+
+	expvar.Do(func(kv expvar.KeyValue) {
+		write(kv.Key, kv.Value.String())
+	})
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := json.Marshal(vars[name]())
+		if err != nil {
+			data, _ = json.Marshal(err.Error())
+		}
+		write(name, string(data))
+	}
+	fmt.Fprint(w, "\n}\n")
+}
@@ -0,0 +1,35 @@
+package diagnostics
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// ChildProcesses counts the processes this server started that have not
+// been waited for, such as git commands still running or left behind
+func ChildProcesses() int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return -1
+	}
+
+	self := []byte(strconv.Itoa(os.Getpid()))
+	count := 0
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		stat, err := os.ReadFile("/proc/" + entry.Name() + "/stat")
+		if err != nil {
+			continue // Exited since the directory was read
+		}
+		// "pid (command) state ppid ...": the command may contain spaces
+		// and parentheses, so the fields are counted from the last ")"
+		fields := bytes.Fields(stat[bytes.LastIndexByte(stat, ')')+1:])
+		if len(fields) > 1 && bytes.Equal(fields[1], self) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package diagnostics
+
+// ChildProcesses reports -1: without /proc a server cannot count its
+// subprocesses
+func ChildProcesses() int {
+	return -1
+}
@@ -0,0 +1,109 @@
+package gitserver
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultSlowRequest is how long a git request may take before it is
+// logged as slow, when no threshold is configured
+const DefaultSlowRequest = time.Minute
+
+// SlowRequestFromEnv reads GIT_SLOW_REQUEST, the threshold for
+// SetSlowRequest; unset is 0, for DefaultSlowRequest
+func SlowRequestFromEnv() (time.Duration, error) {
+	value := os.Getenv("GIT_SLOW_REQUEST")
+	if value == "" {
+		return 0, nil
+	}
+	threshold, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid GIT_SLOW_REQUEST %q: %v", value, err)
+	}
+	return threshold, nil
+}
+
+// statusRecorder remembers the status code and counts the bytes of a
+// response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// gitService names the git endpoint a path is for, as Handler routes it,
+// or "" for paths that are not git requests
+func gitService(path string) string {
+	switch {
+	case strings.Contains(path, ".git/info/refs"):
+		return "info-refs"
+	case strings.Contains(path, ".git/git-upload-pack"):
+		return "upload-pack"
+	default:
+		return ""
+	}
+}
+
+// logRequests writes an access log line for every git request next
+// answers, warns about slow ones and counts them in the metrics. Health
+// checks and unknown paths are left out.
+func (gs *GitServer) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service := gitService(r.URL.Path)
+		if service == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		gs.metrics.inFlight.Add(1)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		gs.metrics.inFlight.Add(-1)
+		elapsed := time.Since(start)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		workspaceID := gs.extractWorkspaceID(r.URL.Path)
+		if workspaceID == "" {
+			workspaceID = "-"
+		}
+		protocol := "v0"
+		if gitProtocol(r) != "" {
+			protocol = "v2"
+		}
+		log.Printf("git access workspace=%s service=%s protocol=%s status=%d bytes=%d duration=%s remote=%s agent=%q",
+			workspaceID, service, protocol, rec.status, rec.bytes, elapsed.Round(time.Millisecond), r.RemoteAddr, r.UserAgent())
+		slow := gs.slowRequest > 0 && elapsed > gs.slowRequest
+		if slow {
+			log.Printf("Warning: slow git request: %s for %s took %s and sent %d bytes", service, workspaceID, elapsed.Round(time.Millisecond), rec.bytes)
+		}
+		gs.metrics.observe(service, rec.status, rec.bytes, elapsed, slow)
+	})
+}
@@ -0,0 +1,27 @@
+package gitserver
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/nic/poon/poon-git/diagnostics"
+)
+
+// opsHandler serves the ops port of the shared diagnostics package, with
+// the goroutine and git subprocess counts, the git requests running, queued
+// and turned away under limits and the pack cache's size and hit rate added
+// to /debug/vars, and the request metrics of gs for Prometheus under
+// /metrics
+func opsHandler(gs *GitServer) http.Handler {
+	mux := diagnostics.NewHandler(diagnostics.Vars{
+		"goroutines":   func() interface{} { return runtime.NumGoroutine() },
+		"subprocesses": func() interface{} { return diagnostics.ChildProcesses() },
+		"gitRequests":  func() interface{} { return gs.limiter.stats() },
+		"packCache":    func() interface{} { return gs.packCacheStats() },
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		gs.metrics.write(w, gs.limiter.stats(), gs.packCacheStats())
+	})
+	return mux
+}
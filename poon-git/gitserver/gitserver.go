@@ -0,0 +1,423 @@
+// Package gitserver serves workspace repositories over git's smart HTTP
+// protocol. poon-git runs it on its own; poon-server can embed it and answer
+// from its in-memory workspace registry.
+package gitserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-git/subprocess"
+)
+
+// Workspaces finds the git repository of a workspace. poon-server's
+// workspace registry implements it when the git server is embedded.
+type Workspaces interface {
+	WorkspaceRepoPath(workspaceID string) (string, bool)
+}
+
+// awaiter is implemented by Workspaces whose repositories may be filled in
+// after they are created, as poon-server does for lazy workspaces. Requests
+// wait for the repository rather than see it empty.
+type awaiter interface {
+	AwaitWorkspace(ctx context.Context, workspaceID string) error
+}
+
+type GitServer struct {
+	workspaceRoot string
+	workspaces    Workspaces // nil looks workspaces up under workspaceRoot
+	limiter       *limiter
+	metrics       *metrics
+	slowRequest   time.Duration // Requests taking longer are logged as slow; 0 never
+	packCache     *packCache    // nil runs upload-pack for every fetch
+}
+
+// New returns a git server for the repositories poon-server writes below
+// workspaceRoot. With workspaces, only workspaces it knows are served. It
+// runs git under DefaultLimits, logs requests slower than
+// DefaultSlowRequest and caches up to DefaultPackCacheBytes of packs until
+// SetLimits, SetSlowRequest and SetPackCache.
+func New(workspaceRoot string, workspaces Workspaces) *GitServer {
+	return &GitServer{
+		workspaceRoot: workspaceRoot,
+		workspaces:    workspaces,
+		limiter:       newLimiter(Limits{}),
+		metrics:       newMetrics(),
+		slowRequest:   DefaultSlowRequest,
+		packCache:     newPackCache(DefaultPackCacheBytes),
+	}
+}
+
+// SetLimits replaces the limits on git subprocesses. Call it before serving.
+func (gs *GitServer) SetLimits(limits Limits) {
+	gs.limiter = newLimiter(limits)
+}
+
+// SetSlowRequest sets how long a request may take before it is logged as
+// slow: 0 for DefaultSlowRequest, negative to never. Call it before serving.
+func (gs *GitServer) SetSlowRequest(threshold time.Duration) {
+	switch {
+	case threshold == 0:
+		gs.slowRequest = DefaultSlowRequest
+	case threshold < 0:
+		gs.slowRequest = 0
+	default:
+		gs.slowRequest = threshold
+	}
+}
+
+// SetPackCache sets how many bytes of packs are kept to answer repeated
+// fetches: 0 for DefaultPackCacheBytes, negative for none. Call it before
+// serving.
+func (gs *GitServer) SetPackCache(maxBytes int64) {
+	switch {
+	case maxBytes == 0:
+		gs.packCache = newPackCache(DefaultPackCacheBytes)
+	case maxBytes < 0:
+		gs.packCache = nil
+	default:
+		gs.packCache = newPackCache(maxBytes)
+	}
+}
+
+// acquireGit waits for a slot to run git for workspaceID. When there is
+// none it answers the request itself, 503 with Retry-After if the server is
+// saturated, and returns false.
+func (gs *GitServer) acquireGit(w http.ResponseWriter, r *http.Request, workspaceID string) (func(), bool) {
+	release, err := gs.limiter.acquire(r.Context(), workspaceID)
+	if err == nil {
+		return release, true
+	}
+	if errors.Is(err, errSaturated) {
+		log.Printf("Warning: turning away git request for %s: %v", workspaceID, err)
+		w.Header().Set("Retry-After", strconv.Itoa(int(gs.limiter.limits.RetryAfter.Round(time.Second)/time.Second)))
+		http.Error(w, "Too many git requests, retry later", http.StatusServiceUnavailable)
+	}
+	return nil, false
+}
+
+// Extract the workspace ID or name from URL path like /workspace-uuid.git/info/refs
+func (gs *GitServer) extractWorkspaceID(path string) string {
+	// Match patterns like /workspace-uuid.git/info/refs or /my-workspace.git/git-upload-pack
+	re := regexp.MustCompile(`^/([a-z0-9][a-z0-9._-]*)\.git/`)
+	matches := re.FindStringSubmatch(path)
+	if len(matches) >= 2 {
+		return matches[1]
+	}
+	return ""
+}
+
+// Get the git repository path for a workspace, if it exists
+func (gs *GitServer) getWorkspaceRepoPath(workspaceID string) (string, bool) {
+	if gs.workspaces != nil {
+		return gs.workspaces.WorkspaceRepoPath(workspaceID)
+	}
+	repoPath := filepath.Join(gs.workspaceRoot, workspaceID, "repo")
+	if _, err := os.Stat(repoPath); err != nil {
+		return "", false
+	}
+	return repoPath, true
+}
+
+// awaitWorkspace waits until the workspace's repository can be served
+func (gs *GitServer) awaitWorkspace(ctx context.Context, workspaceID string) error {
+	if a, ok := gs.workspaces.(awaiter); ok {
+		return a.AwaitWorkspace(ctx, workspaceID)
+	}
+	return nil
+}
+
+// gitCommand runs git with the same isolation as the server
+// (subprocess.GitSettings and GitEnv), so nothing on the host changes what
+// upload-pack sends. Filters and wants by object ID are allowed so clients
+// can fetch without blobs and get each blob when they need it, as
+// workspaces bootstrapped from an archive do.
+func gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := subprocess.CommandContext(ctx, "git", subprocess.GitArgs(append([]string{
+		"-c", "uploadpack.allowFilter=true",
+		"-c", "uploadpack.allowReachableSHA1InWant=true",
+	}, args...)...)...)
+	cmd.Env = subprocess.GitEnv()
+	return cmd
+}
+
+// gitProtocol returns the GIT_PROTOCOL upload-pack should run with for a
+// request: "version=2" when the client asked for wire protocol version 2 in
+// its Git-Protocol header, otherwise "" for the original protocol. Nothing
+// else from the header is passed on.
+func gitProtocol(r *http.Request) string {
+	for _, param := range strings.Split(r.Header.Get("Git-Protocol"), ":") {
+		if param == "version=2" {
+			return param
+		}
+	}
+	return ""
+}
+
+// withProtocol has cmd speak the protocol gitProtocol chose
+func withProtocol(cmd *exec.Cmd, protocol string) {
+	if protocol != "" {
+		cmd.Env = append(cmd.Env, "GIT_PROTOCOL="+protocol)
+	}
+}
+
+// Git HTTP protocol handlers
+func (gs *GitServer) handleInfoRefs(w http.ResponseWriter, r *http.Request) {
+	workspaceID := gs.extractWorkspaceID(r.URL.Path)
+	if workspaceID == "" {
+		http.Error(w, "Invalid workspace URL", http.StatusNotFound)
+		return
+	}
+
+	repoPath, ok := gs.getWorkspaceRepoPath(workspaceID)
+	if !ok {
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+		return
+	}
+	if err := gs.awaitWorkspace(r.Context(), workspaceID); err != nil {
+		log.Printf("Workspace %s is not ready: %v", workspaceID, err)
+		http.Error(w, fmt.Sprintf("Workspace is not ready: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	service := r.URL.Query().Get("service")
+
+	if service == "git-upload-pack" {
+		release, ok := gs.acquireGit(w, r, workspaceID)
+		if !ok {
+			return
+		}
+		defer release()
+
+		w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-advertisement", service))
+		w.Header().Set("Cache-Control", "no-cache")
+
+		// Git protocol pkt-line format for service advertisement. Version 2
+		// answers with its capabilities alone, as git http-backend does.
+		protocol := gitProtocol(r)
+		if protocol == "" {
+			fmt.Fprintf(w, "001e# service=%s\n", service)
+			fmt.Fprint(w, "0000")
+		}
+
+		// Use git command to get actual refs
+		cmd := gitCommand(r.Context(), "upload-pack", "--stateless-rpc", "--advertise-refs", repoPath)
+		withProtocol(cmd, protocol)
+		cmd.Stdout = w
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			log.Printf("Error running git upload-pack: %v", err)
+			http.Error(w, "Git command failed", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, "Service not supported", http.StatusForbidden)
+	}
+}
+
+func (gs *GitServer) handleUploadPack(w http.ResponseWriter, r *http.Request) {
+	workspaceID := gs.extractWorkspaceID(r.URL.Path)
+	if workspaceID == "" {
+		http.Error(w, "Invalid workspace URL", http.StatusNotFound)
+		return
+	}
+
+	repoPath, ok := gs.getWorkspaceRepoPath(workspaceID)
+	if !ok {
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+		return
+	}
+	if err := gs.awaitWorkspace(r.Context(), workspaceID); err != nil {
+		log.Printf("Workspace %s is not ready: %v", workspaceID, err)
+		http.Error(w, fmt.Sprintf("Workspace is not ready: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	// A fetch answered before, for the same refs, is answered from the
+	// cache without taking a slot
+	protocol := gitProtocol(r)
+	body, key := gs.cacheKey(r, workspaceID, repoPath, protocol)
+	if key != "" {
+		if pack, ok := gs.packCache.get(key); ok {
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Write(pack)
+			return
+		}
+	}
+
+	release, ok := gs.acquireGit(w, r, workspaceID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// Use git command to handle actual pack generation. The request's context
+	// ends when the client disconnects, which stops the pack.
+	cmd := gitCommand(r.Context(), "upload-pack", "--stateless-rpc", repoPath)
+	withProtocol(cmd, protocol)
+	cmd.Stdin = body
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	var recorder *packRecorder
+	if key != "" {
+		recorder = gs.packCache.recorder()
+		cmd.Stdout = io.MultiWriter(w, recorder)
+	}
+
+	if err := cmd.Run(); err != nil {
+		log.Printf("Error running git upload-pack: %v", err)
+		// Don't send HTTP error here as we might have already started writing response
+		return
+	}
+	if recorder != nil && !recorder.overflow {
+		gs.packCache.put(key, recorder.buf.Bytes())
+	}
+}
+
+// Handler returns the git HTTP endpoints and /health. Git requests are
+// written to the access log and counted in the metrics of the ops port.
+func (gs *GitServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// Git HTTP protocol endpoints for workspace repositories
+	// URLs like /workspace-uuid.git/info/refs
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+
+		// Handle workspace git endpoints
+		if strings.Contains(path, ".git/info/refs") {
+			gs.handleInfoRefs(w, r)
+		} else if strings.Contains(path, ".git/git-upload-pack") {
+			gs.handleUploadPack(w, r)
+		} else if path == "/health" {
+			// Health check
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "OK")
+		} else {
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+	})
+
+	return gs.logRequests(mux)
+}
+
+// Config is what a git server needs to run
+type Config struct {
+	Addr           string // Listen address, e.g. ":3000"; port 0 picks a free port
+	WorkspaceRoot  string
+	Workspaces     Workspaces    // Optional; see New
+	OpsAddr        string        // pprof and expvar listen address, for operators only; "" disables it
+	Limits         Limits        // Bounds on concurrent git subprocesses; zero fields take DefaultLimits
+	SlowRequest    time.Duration // See SetSlowRequest
+	PackCacheBytes int64         // See SetPackCache
+}
+
+// Instance is a git server started with Start
+type Instance struct {
+	listener  net.Listener
+	server    *http.Server
+	opsLis    net.Listener
+	opsServer *http.Server
+	done      chan error
+}
+
+// Start listens on cfg.Addr and serves in the background until Close
+func Start(cfg Config) (*Instance, error) {
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", cfg.Addr, err)
+	}
+
+	gs := New(cfg.WorkspaceRoot, cfg.Workspaces)
+	gs.SetLimits(cfg.Limits)
+	gs.SetSlowRequest(cfg.SlowRequest)
+	gs.SetPackCache(cfg.PackCacheBytes)
+
+	inst := &Instance{
+		listener: lis,
+		server: &http.Server{
+			Handler:           gs.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		done: make(chan error, 1),
+	}
+
+	if cfg.OpsAddr != "" {
+		if inst.opsLis, err = net.Listen("tcp", cfg.OpsAddr); err != nil {
+			lis.Close()
+			return nil, fmt.Errorf("failed to listen on ops address %s: %v", cfg.OpsAddr, err)
+		}
+		inst.opsServer = &http.Server{Handler: opsHandler(gs), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := inst.opsServer.Serve(inst.opsLis); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Ops port stopped: %v", err)
+			}
+		}()
+	}
+	go func() {
+		err := inst.server.Serve(lis)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		inst.done <- err
+	}()
+	return inst, nil
+}
+
+// Serve runs a git server until it fails
+func Serve(cfg Config) error {
+	inst, err := Start(cfg)
+	if err != nil {
+		return err
+	}
+	return inst.Wait()
+}
+
+// Addr returns the address the server listens on
+func (inst *Instance) Addr() net.Addr {
+	return inst.listener.Addr()
+}
+
+// OpsAddr returns the address of the pprof and expvar port, nil without one
+func (inst *Instance) OpsAddr() net.Addr {
+	if inst.opsLis == nil {
+		return nil
+	}
+	return inst.opsLis.Addr()
+}
+
+// Wait blocks until the server stops and returns why it stopped, nil after
+// Close
+func (inst *Instance) Wait() error {
+	err := <-inst.done
+	inst.done <- err
+	return err
+}
+
+// Close stops the server. Clones in progress are cut off.
+func (inst *Instance) Close() error {
+	if inst.opsServer != nil {
+		inst.opsServer.Close()
+	}
+	if err := inst.server.Close(); err != nil {
+		return err
+	}
+	return inst.Wait()
+}
@@ -0,0 +1,255 @@
+package gitserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errSaturated is returned to requests turned away because every git
+// subprocess slot is busy and the queue is full or took too long
+var errSaturated = errors.New("too many git requests")
+
+// Limits bounds the git subprocesses a server runs at once, so a clone
+// storm cannot exhaust memory. A request that finds no free slot waits in a
+// queue; one that finds the queue full, or waits longer than QueueTimeout,
+// is answered 503 with Retry-After. Zero fields take the defaults; a
+// negative count removes that limit.
+type Limits struct {
+	MaxConcurrent   int           // Git subprocesses across all workspaces
+	MaxPerWorkspace int           // Git subprocesses for any one workspace
+	MaxQueued       int           // Requests waiting for a slot
+	QueueTimeout    time.Duration // How long a request waits for a slot
+	RetryAfter      time.Duration // When clients turned away are told to retry
+}
+
+// DefaultLimits returns the limits a server uses when none are configured
+func DefaultLimits() Limits {
+	return Limits{
+		MaxConcurrent:   2 * runtime.NumCPU(),
+		MaxPerWorkspace: 8,
+		MaxQueued:       64,
+		QueueTimeout:    30 * time.Second,
+		RetryAfter:      10 * time.Second,
+	}
+}
+
+// withDefaults fills the zero fields of l from DefaultLimits
+func (l Limits) withDefaults() Limits {
+	defaults := DefaultLimits()
+	if l.MaxConcurrent == 0 {
+		l.MaxConcurrent = defaults.MaxConcurrent
+	}
+	if l.MaxPerWorkspace == 0 {
+		l.MaxPerWorkspace = defaults.MaxPerWorkspace
+	}
+	if l.MaxQueued == 0 {
+		l.MaxQueued = defaults.MaxQueued
+	}
+	if l.QueueTimeout <= 0 {
+		l.QueueTimeout = defaults.QueueTimeout
+	}
+	if l.RetryAfter <= 0 {
+		l.RetryAfter = defaults.RetryAfter
+	}
+	return l
+}
+
+// LimitsFromEnv reads GIT_MAX_CONCURRENT, GIT_MAX_PER_WORKSPACE,
+// GIT_MAX_QUEUED, GIT_QUEUE_TIMEOUT and GIT_RETRY_AFTER. Unset variables
+// are left zero, for the defaults.
+func LimitsFromEnv() (Limits, error) {
+	var limits Limits
+	for name, field := range map[string]*int{
+		"GIT_MAX_CONCURRENT":    &limits.MaxConcurrent,
+		"GIT_MAX_PER_WORKSPACE": &limits.MaxPerWorkspace,
+		"GIT_MAX_QUEUED":        &limits.MaxQueued,
+	} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return limits, fmt.Errorf("invalid %s %q: %v", name, value, err)
+			}
+			*field = n
+		}
+	}
+	for name, field := range map[string]*time.Duration{
+		"GIT_QUEUE_TIMEOUT": &limits.QueueTimeout,
+		"GIT_RETRY_AFTER":   &limits.RetryAfter,
+	} {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return limits, fmt.Errorf("invalid %s %q: %v", name, value, err)
+			}
+			*field = d
+		}
+	}
+	return limits, nil
+}
+
+// workspaceSlots are the slots of one workspace and the requests holding
+// or waiting for them
+type workspaceSlots struct {
+	sem   chan struct{} // nil without a per-workspace limit
+	users int
+}
+
+// limiter hands out slots for git subprocesses under Limits
+type limiter struct {
+	limits Limits
+	slots  chan struct{} // nil without a global limit
+
+	mu         sync.Mutex
+	workspaces map[string]*workspaceSlots
+
+	running  atomic.Int64
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{
+		limits:     limits.withDefaults(),
+		workspaces: make(map[string]*workspaceSlots),
+	}
+	if l.limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, l.limits.MaxConcurrent)
+	}
+	return l
+}
+
+// join returns the slots of workspaceID, counting the caller as a user
+func (l *limiter) join(workspaceID string) *workspaceSlots {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ws, ok := l.workspaces[workspaceID]
+	if !ok {
+		ws = &workspaceSlots{}
+		if l.limits.MaxPerWorkspace > 0 {
+			ws.sem = make(chan struct{}, l.limits.MaxPerWorkspace)
+		}
+		l.workspaces[workspaceID] = ws
+	}
+	ws.users++
+	return ws
+}
+
+// leave undoes join, forgetting workspaces nobody uses
+func (l *limiter) leave(workspaceID string, ws *workspaceSlots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ws.users--; ws.users == 0 {
+		delete(l.workspaces, workspaceID)
+	}
+}
+
+// tryTake takes a slot of sem if one is free; a nil sem always has one
+func tryTake(sem chan struct{}) bool {
+	if sem == nil {
+		return true
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// take waits for a slot of sem until ctx ends or timeout fires
+func take(ctx context.Context, sem chan struct{}, timeout <-chan time.Time) error {
+	if sem == nil {
+		return nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return errSaturated
+	}
+}
+
+// give returns a slot of sem
+func give(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+}
+
+// acquire waits for a slot to run git for workspaceID and returns the
+// function that frees it. It fails with errSaturated when the request is
+// turned away, or with ctx's error when the client gave up first.
+func (l *limiter) acquire(ctx context.Context, workspaceID string) (func(), error) {
+	ws := l.join(workspaceID)
+
+	// Taken in the same order everywhere, so waiters cannot deadlock
+	if tryTake(ws.sem) {
+		if tryTake(l.slots) {
+			return l.release(workspaceID, ws), nil
+		}
+		give(ws.sem)
+	}
+
+	queued := l.queued.Add(1)
+	defer l.queued.Add(-1)
+	if l.limits.MaxQueued >= 0 && queued > int64(l.limits.MaxQueued) {
+		l.leave(workspaceID, ws)
+		l.rejected.Add(1)
+		return nil, errSaturated
+	}
+
+	timer := time.NewTimer(l.limits.QueueTimeout)
+	defer timer.Stop()
+	err := take(ctx, ws.sem, timer.C)
+	if err == nil {
+		if err = take(ctx, l.slots, timer.C); err != nil {
+			give(ws.sem)
+		}
+	}
+	if err != nil {
+		l.leave(workspaceID, ws)
+		if errors.Is(err, errSaturated) {
+			l.rejected.Add(1)
+		}
+		return nil, err
+	}
+	return l.release(workspaceID, ws), nil
+}
+
+// release counts a slot as running and returns the function that frees it
+func (l *limiter) release(workspaceID string, ws *workspaceSlots) func() {
+	l.running.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.running.Add(-1)
+			give(l.slots)
+			give(ws.sem)
+			l.leave(workspaceID, ws)
+		})
+	}
+}
+
+// limiterStats reports the git requests of a limiter for /debug/vars
+type limiterStats struct {
+	Running  int64 `json:"running"`
+	Queued   int64 `json:"queued"`
+	Rejected int64 `json:"rejected"` // Since startup
+}
+
+func (l *limiter) stats() limiterStats {
+	return limiterStats{
+		Running:  l.running.Load(),
+		Queued:   l.queued.Load(),
+		Rejected: l.rejected.Load(),
+	}
+}
@@ -0,0 +1,143 @@
+package gitserver
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration
+// histogram: from ref advertisements to clones of the whole repository
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+type requestKey struct {
+	service string
+	code    int
+}
+
+// serviceMetrics are the totals of one service, such as upload-pack
+type serviceMetrics struct {
+	buckets []int64 // Requests no longer than each of durationBuckets
+	count   int64
+	seconds float64
+	bytes   int64
+	slow    int64
+}
+
+// metrics counts the git requests of a server for /metrics. Labels are
+// kept to the service and status code; workspaces only appear in the access
+// log, as there may be any number of them.
+type metrics struct {
+	inFlight atomic.Int64
+
+	mu       sync.Mutex
+	requests map[requestKey]int64
+	services map[string]*serviceMetrics
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests: make(map[requestKey]int64),
+		services: make(map[string]*serviceMetrics),
+	}
+}
+
+// observe counts a finished request
+func (m *metrics) observe(service string, code int, bytes int64, elapsed time.Duration, slow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{service, code}]++
+	sm, ok := m.services[service]
+	if !ok {
+		sm = &serviceMetrics{buckets: make([]int64, len(durationBuckets))}
+		m.services[service] = sm
+	}
+	seconds := elapsed.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			sm.buckets[i]++
+		}
+	}
+	sm.count++
+	sm.seconds += seconds
+	sm.bytes += bytes
+	if slow {
+		sm.slow++
+	}
+}
+
+// write writes the metrics, with the limiter's and the pack cache's, in the
+// Prometheus text exposition format
+func (m *metrics) write(w io.Writer, limits limiterStats, cache packCacheStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].service != keys[j].service {
+			return keys[i].service < keys[j].service
+		}
+		return keys[i].code < keys[j].code
+	})
+	services := make([]string, 0, len(m.services))
+	for service := range m.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	header(w, "poon_git_requests_total", "counter", "Git HTTP requests by service and status code.")
+	for _, key := range keys {
+		fmt.Fprintf(w, "poon_git_requests_total{service=%q,code=\"%d\"} %d\n", key.service, key.code, m.requests[key])
+	}
+
+	header(w, "poon_git_request_duration_seconds", "histogram", "Time taken to answer git HTTP requests.")
+	for _, service := range services {
+		sm := m.services[service]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "poon_git_request_duration_seconds_bucket{service=%q,le=%q} %d\n", service, strconv.FormatFloat(bound, 'g', -1, 64), sm.buckets[i])
+		}
+		fmt.Fprintf(w, "poon_git_request_duration_seconds_bucket{service=%q,le=\"+Inf\"} %d\n", service, sm.count)
+		fmt.Fprintf(w, "poon_git_request_duration_seconds_sum{service=%q} %g\n", service, sm.seconds)
+		fmt.Fprintf(w, "poon_git_request_duration_seconds_count{service=%q} %d\n", service, sm.count)
+	}
+
+	header(w, "poon_git_response_bytes_total", "counter", "Bytes sent in answer to git HTTP requests.")
+	for _, service := range services {
+		fmt.Fprintf(w, "poon_git_response_bytes_total{service=%q} %d\n", service, m.services[service].bytes)
+	}
+
+	header(w, "poon_git_slow_requests_total", "counter", "Git HTTP requests that took longer than the slow request threshold.")
+	for _, service := range services {
+		fmt.Fprintf(w, "poon_git_slow_requests_total{service=%q} %d\n", service, m.services[service].slow)
+	}
+
+	header(w, "poon_git_requests_in_flight", "gauge", "Git HTTP requests being answered.")
+	fmt.Fprintf(w, "poon_git_requests_in_flight %d\n", m.inFlight.Load())
+	header(w, "poon_git_subprocesses_running", "gauge", "Git subprocesses running under the concurrency limits.")
+	fmt.Fprintf(w, "poon_git_subprocesses_running %d\n", limits.Running)
+	header(w, "poon_git_requests_queued", "gauge", "Git HTTP requests waiting for a subprocess slot.")
+	fmt.Fprintf(w, "poon_git_requests_queued %d\n", limits.Queued)
+	header(w, "poon_git_requests_rejected_total", "counter", "Git HTTP requests turned away with 503 because the server was saturated.")
+	fmt.Fprintf(w, "poon_git_requests_rejected_total %d\n", limits.Rejected)
+
+	header(w, "poon_git_pack_cache_hits_total", "counter", "Fetches answered from the pack cache.")
+	fmt.Fprintf(w, "poon_git_pack_cache_hits_total %d\n", cache.Hits)
+	header(w, "poon_git_pack_cache_misses_total", "counter", "Cacheable fetches upload-pack had to answer.")
+	fmt.Fprintf(w, "poon_git_pack_cache_misses_total %d\n", cache.Misses)
+	header(w, "poon_git_pack_cache_entries", "gauge", "Packs in the pack cache.")
+	fmt.Fprintf(w, "poon_git_pack_cache_entries %d\n", cache.Entries)
+	header(w, "poon_git_pack_cache_bytes", "gauge", "Bytes of packs in the pack cache.")
+	fmt.Fprintf(w, "poon_git_pack_cache_bytes %d\n", cache.Bytes)
+}
+
+func header(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
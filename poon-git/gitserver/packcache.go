@@ -0,0 +1,272 @@
+package gitserver
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPackCacheBytes bounds the pack cache when no size is configured
+const DefaultPackCacheBytes = 256 << 20
+
+// PackCacheBytesFromEnv reads GIT_PACK_CACHE_MAX_BYTES, the size for
+// SetPackCache: unset is 0, for DefaultPackCacheBytes, and "0", which
+// disables the cache, is -1
+func PackCacheBytesFromEnv() (int64, error) {
+	value := os.Getenv("GIT_PACK_CACHE_MAX_BYTES")
+	if value == "" {
+		return 0, nil
+	}
+	maxBytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid GIT_PACK_CACHE_MAX_BYTES %q: %v", value, err)
+	}
+	if maxBytes == 0 {
+		return -1, nil
+	}
+	return maxBytes, nil
+}
+
+// maxCachedRequestBytes is the largest upload-pack request whose answer is
+// cached. Requests are wants and haves, so only fetches into repositories
+// with long histories of their own are larger, and those rarely repeat.
+const maxCachedRequestBytes = 1 << 20
+
+// packCacheStats reports the contents and effectiveness of the pack cache
+type packCacheStats struct {
+	Entries  int   `json:"entries"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"maxBytes"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+// packCache keeps the answers upload-pack gave to final fetch requests in
+// memory, keyed by workspace, protocol, the workspace's refs and the
+// request, so CI jobs cloning the same workspace state again are answered
+// without running git. The least recently used answers are evicted once
+// they take more than maxBytes.
+type packCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List               // Of *packCacheEntry, most recently used first
+	entries map[string]*list.Element // By key
+	bytes   int64
+	hits    int64
+	misses  int64
+}
+
+type packCacheEntry struct {
+	key  string
+	pack []byte
+}
+
+func newPackCache(maxBytes int64) *packCache {
+	return &packCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached answer to the request with key, if there is one
+func (c *packCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.hits++
+	return elem.Value.(*packCacheEntry).pack, true
+}
+
+// put caches the answer to the request with key, evicting the least
+// recently used answers to stay within the size limit. Answers larger than
+// the whole cache are not stored.
+func (c *packCache) put(key string, pack []byte) {
+	if int64(len(pack)) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&packCacheEntry{key: key, pack: pack})
+	c.bytes += int64(len(pack))
+
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*packCacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= int64(len(entry.pack))
+	}
+}
+
+// stats reports the cache's size and hit rate
+func (c *packCache) stats() packCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return packCacheStats{
+		Entries:  c.lru.Len(),
+		Bytes:    c.bytes,
+		MaxBytes: c.maxBytes,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+// packCacheStats reports the pack cache of gs, zero without one
+func (gs *GitServer) packCacheStats() packCacheStats {
+	if gs.packCache == nil {
+		return packCacheStats{}
+	}
+	return gs.packCache.stats()
+}
+
+// finalFetch reports whether an upload-pack request body is a fetch that
+// ends negotiation with "done", so git answers it with a pack that only
+// depends on the request and the repository. Ref listings and negotiation
+// rounds, which clients do not repeat, are not.
+func finalFetch(body []byte) bool {
+	done := false
+	for len(body) > 0 {
+		if len(body) < 4 {
+			return false
+		}
+		n, err := strconv.ParseUint(string(body[:4]), 16, 16)
+		if err != nil {
+			return false
+		}
+		if n < 4 {
+			// Flush, delimiter or response end
+			body = body[4:]
+			continue
+		}
+		if int(n) > len(body) {
+			return false
+		}
+		line := strings.TrimSuffix(string(body[4:n]), "\n")
+		switch {
+		case line == "done":
+			done = true
+		case strings.HasPrefix(line, "command=") && line != "command=fetch":
+			return false
+		}
+		body = body[n:]
+	}
+	return done
+}
+
+// refState returns a digest of the refs, HEAD and shallow boundary of the
+// repository at repoPath, which are everything besides the request that
+// decides what upload-pack sends
+func refState(repoPath string) (string, error) {
+	gitDir := filepath.Join(repoPath, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		gitDir = repoPath
+	}
+
+	h := sha256.New()
+	add := func(name string, data []byte) {
+		fmt.Fprintf(h, "%s %d\n", name, len(data))
+		h.Write(data)
+	}
+	for _, name := range []string{"HEAD", "packed-refs", "shallow"} {
+		data, err := os.ReadFile(filepath.Join(gitDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		add(name, data)
+	}
+	err := filepath.WalkDir(filepath.Join(gitDir, "refs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(gitDir, path)
+		add(filepath.ToSlash(rel), data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheKey reads an upload-pack request far enough to tell whether its
+// answer can be cached, and returns the body to pass to git together with
+// the cache key, or "" when the answer is not to be cached
+func (gs *GitServer) cacheKey(r *http.Request, workspaceID, repoPath, protocol string) (io.Reader, string) {
+	if gs.packCache == nil || r.Header.Get("Content-Encoding") != "" {
+		return r.Body, ""
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCachedRequestBytes+1))
+	rest := io.MultiReader(bytes.NewReader(body), r.Body)
+	if err != nil || len(body) > maxCachedRequestBytes || !finalFetch(body) {
+		return rest, ""
+	}
+	state, err := refState(repoPath)
+	if err != nil {
+		return rest, ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", workspaceID, protocol, state)
+	h.Write(body)
+	return rest, hex.EncodeToString(h.Sum(nil))
+}
+
+// packRecorderShare is how many recordings of the largest size the cache
+// holds. Each fetch in flight records its answer, so the cap keeps them
+// together from taking many times the cache's size in memory.
+const packRecorderShare = 8
+
+// recorder returns a recorder for one answer to be cached, which abandons
+// answers larger than a packRecorderShare of the cache
+func (c *packCache) recorder() *packRecorder {
+	return &packRecorder{max: max(c.maxBytes/packRecorderShare, 1)}
+}
+
+// packRecorder keeps a copy of what upload-pack sends. Past max bytes it
+// drops the copy and records nothing more.
+type packRecorder struct {
+	max      int64
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (p *packRecorder) Write(data []byte) (int, error) {
+	if !p.overflow {
+		if int64(p.buf.Len()+len(data)) > p.max {
+			p.overflow = true
+			p.buf = bytes.Buffer{}
+		} else {
+			p.buf.Write(data)
+		}
+	}
+	return len(data), nil
+}
@@ -0,0 +1,706 @@
+package gitserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestGitServerImplementation(t *testing.T) {
+	// Test the GitServer struct and basic functionality
+	repoRoot := createTestRepo(t)
+	workspaceRoot := t.TempDir()
+
+	gitServer := New(workspaceRoot, nil)
+
+	t.Run("Server Initialization", func(t *testing.T) {
+		assert.Equal(t, workspaceRoot, gitServer.workspaceRoot)
+	})
+
+	t.Run("Repository Structure", func(t *testing.T) {
+		// Verify test repository was created correctly
+		entries, err := os.ReadDir(repoRoot)
+		require.NoError(t, err)
+
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+
+		assert.Contains(t, names, "src")
+		assert.Contains(t, names, "docs")
+		assert.Contains(t, names, "config")
+	})
+
+	t.Run("Git Server Routes", func(t *testing.T) {
+		// Test that GitServer can set up routes
+		gitServer := New(workspaceRoot, nil)
+		mux := gitServer.Handler()
+		assert.NotNil(t, mux)
+	})
+}
+
+func TestHttpServerBasics(t *testing.T) {
+	// Test basic HTTP server functionality without full startup
+
+	t.Run("Health Check Handler", func(t *testing.T) {
+		// Create a simple HTTP server just for health check
+		mux := http.NewServeMux()
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "OK")
+		})
+
+		// Create test server
+		server := &http.Server{
+			Addr:    ":0", // Use any available port
+			Handler: mux,
+		}
+
+		// Test the handler function directly
+		req, err := http.NewRequest("GET", "/health", nil)
+		require.NoError(t, err)
+
+		rr := &testResponseWriter{
+			header: make(http.Header),
+			body:   &bytes.Buffer{},
+		}
+
+		server.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.code)
+		assert.Equal(t, "OK", rr.body.String())
+	})
+
+	t.Run("Git Info Refs Response Format", func(t *testing.T) {
+		// Test git protocol response format
+		service := "git-upload-pack"
+		expectedContentType := fmt.Sprintf("application/x-%s-advertisement", service)
+
+		assert.Equal(t, "application/x-git-upload-pack-advertisement", expectedContentType)
+
+		// Test packet line format
+		expectedHeader := fmt.Sprintf("001e# service=%s\n", service)
+		assert.Contains(t, expectedHeader, "service=")
+		assert.Contains(t, expectedHeader, "git-upload-pack")
+	})
+}
+
+// registry is a Workspaces backed by a map, as poon-server's is
+type registry map[string]string
+
+func (r registry) WorkspaceRepoPath(workspaceID string) (string, bool) {
+	repoPath, ok := r[workspaceID]
+	return repoPath, ok
+}
+
+func TestWorkspaceRegistry(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	// Both repositories exist on disk, but only the registered one is served
+	workspaceRoot := t.TempDir()
+	for _, id := range []string{"aaaa-1111", "bbbb-2222"} {
+		repoPath := filepath.Join(workspaceRoot, id, "repo")
+		require.NoError(t, os.MkdirAll(repoPath, 0755))
+		require.NoError(t, gitCommand(context.Background(), "init", repoPath).Run())
+	}
+	handler := New(workspaceRoot, registry{"aaaa-1111": filepath.Join(workspaceRoot, "aaaa-1111", "repo")}).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/aaaa-1111.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "# service=git-upload-pack")
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/bbbb-2222.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Without a registry the workspace root is searched
+	rr = httptest.NewRecorder()
+	New(workspaceRoot, nil).Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/bbbb-2222.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// poon-server links a workspace's name to its directory
+	require.NoError(t, os.Symlink("bbbb-2222", filepath.Join(workspaceRoot, "my-workspace")))
+	rr = httptest.NewRecorder()
+	New(workspaceRoot, nil).Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/my-workspace.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// fillingRegistry is a registry whose repositories may not be filled yet
+type fillingRegistry struct {
+	registry
+	await func(workspaceID string) error
+}
+
+func (r fillingRegistry) AwaitWorkspace(ctx context.Context, workspaceID string) error {
+	return r.await(workspaceID)
+}
+
+func TestAwaitWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, gitCommand(context.Background(), "init", repoPath).Run())
+	var awaited []string
+	workspaces := fillingRegistry{
+		registry: registry{"ready": repoPath, "broken": repoPath},
+		await: func(workspaceID string) error {
+			awaited = append(awaited, workspaceID)
+			if workspaceID == "broken" {
+				return fmt.Errorf("failed to copy tracked paths")
+			}
+			return nil
+		},
+	}
+	handler := New("", workspaces).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/ready.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/broken.git/git-upload-pack", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "failed to copy tracked paths")
+	assert.Equal(t, []string{"ready", "broken"}, awaited)
+}
+
+func TestGitLimits(t *testing.T) {
+	l := newLimiter(Limits{MaxConcurrent: 2, MaxPerWorkspace: 1, MaxQueued: 1, QueueTimeout: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	releaseA, err := l.acquire(ctx, "a")
+	require.NoError(t, err)
+	releaseB, err := l.acquire(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, limiterStats{Running: 2}, l.stats())
+
+	// A second request for a waits for the first, and gets its slot
+	acquired := make(chan func())
+	go func() {
+		release, err := l.acquire(ctx, "a")
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	require.Eventually(t, func() bool { return l.stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	// The queue is full
+	_, err = l.acquire(ctx, "c")
+	assert.ErrorIs(t, err, errSaturated)
+
+	releaseA()
+	releaseA() // Freeing twice frees once
+	release := <-acquired
+	assert.Equal(t, limiterStats{Running: 2, Rejected: 1}, l.stats())
+
+	// Nothing frees a slot in time
+	_, err = l.acquire(ctx, "c")
+	assert.ErrorIs(t, err, errSaturated)
+
+	// A client that gives up is not counted as turned away
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = l.acquire(cancelled, "c")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	release()
+	releaseB()
+	assert.Equal(t, limiterStats{Rejected: 2}, l.stats())
+	assert.Empty(t, l.workspaces)
+
+	// Negative counts remove the limits
+	l = newLimiter(Limits{MaxConcurrent: -1, MaxPerWorkspace: -1})
+	for i := 0; i < 100; i++ {
+		_, err := l.acquire(ctx, "a")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(100), l.stats().Running)
+}
+
+func TestGitLimitsRetryAfter(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, gitCommand(context.Background(), "init", repoPath).Run())
+	gs := New("", registry{"busy": repoPath})
+	gs.SetLimits(Limits{MaxPerWorkspace: 1, MaxQueued: -1, QueueTimeout: 10 * time.Millisecond, RetryAfter: 7 * time.Second})
+	handler := gs.Handler()
+
+	release, err := gs.limiter.acquire(context.Background(), "busy")
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/busy.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "7", rr.Header().Get("Retry-After"))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/busy.git/git-upload-pack", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	release()
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/busy.git/info/refs?service=git-upload-pack", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestAccessLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, gitCommand(context.Background(), "init", repoPath).Run())
+	gs := New("", registry{"logged": repoPath})
+	gs.SetSlowRequest(time.Nanosecond)
+	handler := gs.Handler()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/logged.git/info/refs?service=git-upload-pack", nil)
+	req.Header.Set("User-Agent", "git/2.45.0")
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	sent := rr.Body.Len()
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/missing.git/info/refs?service=git-upload-pack", nil))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	lines := logs.String()
+	assert.Contains(t, lines, fmt.Sprintf("git access workspace=logged service=info-refs protocol=v0 status=200 bytes=%d duration=", sent))
+	assert.Contains(t, lines, `agent="git/2.45.0"`)
+	assert.Contains(t, lines, "git access workspace=missing service=info-refs protocol=v0 status=404")
+	assert.Contains(t, lines, "Warning: slow git request: info-refs for logged")
+	assert.NotContains(t, lines, "health")
+
+	var metrics bytes.Buffer
+	gs.metrics.write(&metrics, gs.limiter.stats(), gs.packCacheStats())
+	text := metrics.String()
+	assert.Contains(t, text, `poon_git_requests_total{service="info-refs",code="200"} 1`)
+	assert.Contains(t, text, `poon_git_requests_total{service="info-refs",code="404"} 1`)
+	assert.Contains(t, text, `poon_git_request_duration_seconds_count{service="info-refs"} 2`)
+	assert.Contains(t, text, `poon_git_slow_requests_total{service="info-refs"} 2`)
+	assert.Contains(t, text, "poon_git_requests_in_flight 0")
+	assert.Contains(t, text, "# TYPE poon_git_request_duration_seconds histogram")
+}
+
+func TestProtocolV2(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	ctx := context.Background()
+	require.NoError(t, gitCommand(ctx, "init", "-b", "main", repoPath).Run())
+	require.NoError(t, gitCommand(ctx, "-C", repoPath, "-c", "user.name=poon", "-c", "user.email=poon@example.com", "commit", "--allow-empty", "-m", "first").Run())
+	require.NoError(t, gitCommand(ctx, "-C", repoPath, "tag", "v1").Run())
+	handler := New("", registry{"v2": repoPath}).Handler()
+
+	// Version 2 advertises capabilities, without the service line or refs
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/v2.git/info/refs?service=git-upload-pack", nil)
+	req.Header.Set("Git-Protocol", "version=2")
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "000eversion 2\n"), rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "ls-refs")
+	assert.NotContains(t, rr.Body.String(), "# service=")
+
+	// ls-refs filters refs on the server
+	body := "0014command=ls-refs\n0001001bref-prefix refs/heads/\n0000"
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/v2.git/git-upload-pack", strings.NewReader(body))
+	req.Header.Set("Git-Protocol", "version=2")
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "refs/heads/main")
+	assert.NotContains(t, rr.Body.String(), "refs/tags/v1")
+
+	// Without the header, or with anything else in it, clients get version 0
+	for _, header := range []string{"", "version=1", "version=2x"} {
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/v2.git/info/refs?service=git-upload-pack", nil)
+		req.Header.Set("Git-Protocol", header)
+		handler.ServeHTTP(rr, req)
+		assert.True(t, strings.HasPrefix(rr.Body.String(), "001e# service=git-upload-pack\n0000"), header)
+		assert.Contains(t, rr.Body.String(), "refs/tags/v1", header)
+	}
+
+	// And git clones over it
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	clone := filepath.Join(t.TempDir(), "clone")
+	out, err := gitCommand(ctx, "-c", "protocol.version=2", "clone", srv.URL+"/v2.git", clone).CombinedOutput()
+	require.NoError(t, err, string(out))
+	head, err := gitCommand(ctx, "-C", clone, "log", "--format=%s").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(head))
+}
+
+func TestPackCache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	ctx := context.Background()
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	commit := func(message string) {
+		require.NoError(t, gitCommand(ctx, "-C", repoPath, "-c", "user.name=poon", "-c", "user.email=poon@example.com", "commit", "--allow-empty", "-m", message).Run())
+	}
+	require.NoError(t, gitCommand(ctx, "init", "-b", "main", repoPath).Run())
+	commit("first")
+	gs := New("", registry{"ci": repoPath})
+	srv := httptest.NewServer(gs.Handler())
+	defer srv.Close()
+
+	clone := func(protocol string) string {
+		dir := filepath.Join(t.TempDir(), "clone")
+		out, err := gitCommand(ctx, "-c", "protocol.version="+protocol, "clone", srv.URL+"/ci.git", dir).CombinedOutput()
+		require.NoError(t, err, string(out))
+		log, err := gitCommand(ctx, "-C", dir, "log", "--format=%s").Output()
+		require.NoError(t, err)
+		return string(log)
+	}
+
+	// The second clone of the same state is answered from the cache, for
+	// each protocol
+	for _, protocol := range []string{"0", "2"} {
+		before := gs.packCacheStats()
+		assert.Equal(t, "first\n", clone(protocol))
+		assert.Equal(t, "first\n", clone(protocol))
+		after := gs.packCacheStats()
+		assert.Equal(t, before.Hits+1, after.Hits, protocol)
+		assert.Equal(t, before.Entries+1, after.Entries, protocol)
+	}
+
+	// New commits change the refs, and so the key
+	commit("second")
+	hits := gs.packCacheStats().Hits
+	assert.Equal(t, "second\nfirst\n", clone("2"))
+	assert.Equal(t, hits, gs.packCacheStats().Hits)
+
+	// Without a cache every fetch runs upload-pack
+	gs.SetPackCache(-1)
+	assert.Equal(t, "second\nfirst\n", clone("2"))
+	assert.Equal(t, packCacheStats{}, gs.packCacheStats())
+}
+
+func TestPackCacheEviction(t *testing.T) {
+	c := newPackCache(10)
+	c.put("a", []byte("aaaa"))
+	c.put("b", []byte("bbbb"))
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	c.put("c", []byte("cccc")) // Evicts b, used least recently
+	c.put("huge", []byte("too large to cache"))
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("huge")
+	assert.False(t, ok)
+	pack, ok := c.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, "cccc", string(pack))
+	assert.Equal(t, packCacheStats{Entries: 2, Bytes: 8, MaxBytes: 10, Hits: 2, Misses: 2}, c.stats())
+
+	assert.True(t, finalFetch([]byte("0032want 0123456789012345678901234567890123456789\n00000009done\n")))
+	assert.True(t, finalFetch([]byte("0011command=fetch0001000dthin-pack0009done\n0000")))
+	assert.False(t, finalFetch([]byte("0032want 0123456789012345678901234567890123456789\n0000")))
+	assert.False(t, finalFetch([]byte("0014command=ls-refs\n00010009peel\n0000")))
+	assert.False(t, finalFetch([]byte("00ffdone")))
+}
+
+func TestPackRecorder(t *testing.T) {
+	c := newPackCache(80)
+	recorder := c.recorder()
+	assert.Equal(t, int64(10), recorder.max)
+
+	recorder.Write([]byte("0123456789"))
+	assert.False(t, recorder.overflow)
+	assert.Equal(t, "0123456789", recorder.buf.String())
+
+	// Past the cap the recording is abandoned, though writes still succeed
+	n, err := recorder.Write([]byte("x"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, recorder.overflow)
+	assert.Zero(t, recorder.buf.Cap())
+	recorder.Write([]byte("y"))
+	assert.Zero(t, recorder.buf.Len())
+}
+
+// Simple test response writer
+type testResponseWriter struct {
+	header http.Header
+	body   *bytes.Buffer
+	code   int
+}
+
+func (w *testResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *testResponseWriter) Write(data []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+func (w *testResponseWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+func TestHttpServerErrorHandling(t *testing.T) {
+	httpPort := getFreePort(t)
+
+	// Start HTTP server without gRPC server (should handle connection errors)
+	httpServer := startHttpServer(t, httpPort, 0) // Invalid gRPC port
+	defer httpServer.stop()
+
+	baseURL := fmt.Sprintf("http://localhost:%d", httpPort)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	t.Run("Health Check Still Works", func(t *testing.T) {
+		resp, err := client.Get(baseURL + "/health")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Non-existent endpoints return 404", func(t *testing.T) {
+		resp, err := client.Get(baseURL + "/api/nonexistent")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// Should return 404 since we removed all API endpoints except health
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+// Test helpers
+
+type testHttpServer struct {
+	port int
+	stop func()
+}
+
+type testGrpcServer struct {
+	port int
+	stop func()
+}
+
+func startHttpServer(t *testing.T, httpPort, grpcPort int) *testHttpServer {
+	// Set environment variables
+	os.Setenv("PORT", fmt.Sprintf("%d", httpPort))
+	if grpcPort > 0 {
+		os.Setenv("GRPC_SERVER", fmt.Sprintf("localhost:%d", grpcPort))
+	}
+
+	// Start server in goroutine
+	stopCh := make(chan struct{})
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Logf("HTTP server panicked: %v", r)
+			}
+		}()
+
+		// This would start the main HTTP server
+		// For now, we'll simulate with a basic HTTP server
+		mux := http.NewServeMux()
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "OK")
+		})
+
+		server := &http.Server{
+			Addr:    fmt.Sprintf(":%d", httpPort),
+			Handler: mux,
+		}
+
+		go server.ListenAndServe()
+
+		<-stopCh
+		server.Shutdown(context.Background())
+	}()
+
+	// Wait for server to be ready
+	time.Sleep(500 * time.Millisecond)
+
+	return &testHttpServer{
+		port: httpPort,
+		stop: func() {
+			close(stopCh)
+		},
+	}
+}
+
+func startMockGrpcServer(t *testing.T, port int, repoRoot string) *testGrpcServer {
+	// Start a mock gRPC server for HTTP server to connect to
+	stopCh := make(chan struct{})
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Logf("Mock gRPC server panicked: %v", r)
+			}
+		}()
+
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			t.Logf("Failed to start mock gRPC server: %v", err)
+			return
+		}
+
+		s := grpc.NewServer()
+		// Register a mock service here if needed
+
+		go func() {
+			<-stopCh
+			s.GracefulStop()
+		}()
+
+		s.Serve(lis)
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	return &testGrpcServer{
+		port: port,
+		stop: func() {
+			close(stopCh)
+		},
+	}
+}
+
+func getFreePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func createTestRepo(t *testing.T) string {
+	repoRoot := t.TempDir()
+
+	// Create directory structure
+	dirs := []string{
+		"src/frontend",
+		"src/backend",
+		"docs",
+		"config",
+	}
+
+	for _, dir := range dirs {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, dir), 0755))
+	}
+
+	// Create sample files
+	files := map[string]string{
+		"src/frontend/app.js": `// Sample frontend application
+console.log("Hello from frontend");`,
+
+		"src/backend/server.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello from backend")
+}`,
+
+		"docs/README.md": `# Poon Monorepo Documentation
+
+This is a sample monorepo for testing.
+
+## Structure
+
+- src/frontend/ - Frontend application
+- src/backend/ - Backend service  
+- docs/ - Documentation
+- config/ - Configuration files`,
+
+		"config/app.yaml": `environment: test
+services:
+  frontend:
+    port: 3000
+  backend:
+    port: 8080`,
+	}
+
+	for path, content := range files {
+		fullPath := filepath.Join(repoRoot, path)
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	return repoRoot
+}
+
+func TestOpsPort(t *testing.T) {
+	inst, err := Start(Config{Addr: "localhost:0", WorkspaceRoot: t.TempDir(), OpsAddr: "localhost:0"})
+	require.NoError(t, err)
+	defer inst.Close()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/debug/vars", inst.OpsAddr()))
+	require.NoError(t, err)
+	var vars struct {
+		Memstats     map[string]interface{} `json:"memstats"`
+		Goroutines   int                    `json:"goroutines"`
+		Subprocesses int                    `json:"subprocesses"`
+		GitRequests  *limiterStats          `json:"gitRequests"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&vars))
+	resp.Body.Close()
+	assert.NotEmpty(t, vars.Memstats)
+	assert.Greater(t, vars.Goroutines, 0)
+	assert.GreaterOrEqual(t, vars.Subprocesses, 0)
+	assert.Equal(t, &limiterStats{}, vars.GitRequests)
+
+	resp, err = http.Get(fmt.Sprintf("http://%s/metrics", inst.OpsAddr()))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "poon_git_requests_rejected_total 0")
+
+	resp, err = http.Get(fmt.Sprintf("http://%s/debug/pprof/heap?debug=1", inst.OpsAddr()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Profiles are not served on the git port
+	resp, err = http.Get(fmt.Sprintf("http://%s/debug/pprof/", inst.Addr()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,41 @@
+// Package subprocess starts the git commands poon-server and poon-git run,
+// so both servers isolate git from the host in the same way.
+package subprocess
+
+import "os"
+
+// GitSettings are passed to every git command the servers run. The
+// repositories belong to the servers, so nothing from the host may run in
+// them: no hooks, credential helpers, password prompts or file system
+// monitors, and no automatic gc in the middle of a request.
+var GitSettings = []string{
+	"-c", "core.hooksPath=" + os.DevNull,
+	"-c", "credential.helper=",
+	"-c", "core.fsmonitor=false",
+	"-c", "core.askPass=",
+	"-c", "gc.auto=0",
+}
+
+// GitArgs returns GitSettings followed by args
+func GitArgs(args ...string) []string {
+	return append(append([]string{}, GitSettings...), args...)
+}
+
+// GitEnv is the whole environment git gets: enough to find programs, with
+// untranslated messages since the servers match some of git's output, and
+// no system or user gitconfig
+func GitEnv() []string {
+	env := []string{
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL=" + os.DevNull,
+		"GIT_TERMINAL_PROMPT=0",
+		"LANG=C",
+		"LC_ALL=C",
+	}
+	for _, name := range []string{"PATH", "TMPDIR", "SYSTEMROOT"} {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
@@ -0,0 +1,24 @@
+package subprocess
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// WaitDelay bounds how long Wait keeps copying output after a cancelled
+// subprocess is killed
+const WaitDelay = 5 * time.Second
+
+// CommandContext is exec.CommandContext for the subprocesses the servers
+// start. Cancelling ctx kills the process and everything it started, not
+// just the process itself, and Wait stops waiting for output shortly after,
+// so a cancelled or timed-out request leaves no git or lint process behind:
+// upload-pack runs pack-objects, which must not outlive a client that hung
+// up.
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	killProcessGroup(cmd)
+	cmd.WaitDelay = WaitDelay
+	return cmd
+}
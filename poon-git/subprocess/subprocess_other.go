@@ -0,0 +1,9 @@
+//go:build !unix
+
+package subprocess
+
+import "os/exec"
+
+// killProcessGroup leaves cmd alone; without process groups cancellation
+// kills only the process itself
+func killProcessGroup(cmd *exec.Cmd) {}
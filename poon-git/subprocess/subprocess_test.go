@@ -0,0 +1,47 @@
+package subprocess
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandContextKillsChildren(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("needs /proc to inspect processes")
+	}
+
+	// The shell starts a child holding its stdout open, as git does with
+	// its helpers; cancelling must take both down and let Wait return
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := CommandContext(ctx, "sh", "-c", "sleep 30 & echo $! > "+pidFile+"; wait")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	require.NoError(t, cmd.Start())
+
+	var pid string
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(pidFile)
+		pid = strings.TrimSpace(string(data))
+		return err == nil && pid != ""
+	}, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	cancel()
+	assert.Error(t, cmd.Wait())
+	assert.Less(t, time.Since(start), WaitDelay)
+
+	// Gone, or a zombie waiting for a reaper
+	assert.Eventually(t, func() bool {
+		stat, err := os.ReadFile("/proc/" + pid + "/stat")
+		return err != nil || strings.Contains(string(stat), ") Z ")
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,187 @@
+package poon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// API is what Client offers besides the generated client, so code using it
+// can be tested against a fake
+type API interface {
+	ReadDirectory(ctx context.Context, path string) (*pb.ReadDirectoryResponse, error)
+	ReadFiles(ctx context.Context, paths []string, version int64) ([]*pb.FileResult, int64, error)
+	ReadChangedFiles(ctx context.Context, paths []string, ifNotHash map[string]string, version int64) ([]*pb.FileResult, int64, error)
+	CopyFile(ctx context.Context, w io.Writer, path string, version int64) (int64, error)
+	WalkDirectory(ctx context.Context, path string, version int64, fn func(item *pb.DirectoryItem) error) (int64, error)
+	CreateWorkspace(ctx context.Context, req *pb.CreateWorkspaceRequest) (*pb.CreateWorkspaceResponse, error)
+	AddTrackedPath(ctx context.Context, workspaceID, path, branch string) (*pb.AddTrackedPathResponse, error)
+	ServerInfo(ctx context.Context, clientVersion string) (*ServerInfo, error)
+	GetClient() pb.MonorepoServiceClient
+	Close() error
+}
+
+var _ API = (*Client)(nil)
+
+// Client represents a gRPC client connection
+type Client struct {
+	conn   *grpc.ClientConn
+	client pb.MonorepoServiceClient
+}
+
+// Options configures a client connection
+type Options struct {
+	Token            string        // Bearer token attached to every call (optional)
+	Retry            RetryPolicy   // Retry policy for idempotent RPCs
+	Keepalive        time.Duration // Interval between keepalive pings on idle connections
+	KeepaliveTimeout time.Duration // How long to wait for a ping's answer before closing the connection
+	Compression      string        // Request compression: "gzip", "zstd", or "" for none
+	UserAgent        string        // Names the tool making the calls, such as "ci-bot/2.1" (optional)
+}
+
+// DefaultOptions returns the options used by New
+func DefaultOptions() Options {
+	return Options{
+		Retry:            DefaultRetryPolicy(),
+		Keepalive:        30 * time.Second,
+		KeepaliveTimeout: 10 * time.Second,
+	}
+}
+
+// New creates a new gRPC client connection with default options
+func New(serverAddr string) (*Client, error) {
+	return NewWithOptions(serverAddr, DefaultOptions())
+}
+
+// NewWithOptions creates a new gRPC client connection
+func NewWithOptions(serverAddr string, opts Options) (*Client, error) {
+	if err := ValidateCompression(opts.Compression); err != nil {
+		return nil, err
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{
+		retryInterceptor(serverAddr, opts.Retry, newCircuitBreaker(3, 30*time.Second)),
+	}
+	var streamInterceptors []grpc.StreamClientInterceptor
+	if opts.Token != "" {
+		interceptors = append(interceptors, authInterceptor(opts.Token))
+		streamInterceptors = append(streamInterceptors, authStreamInterceptor(opts.Token))
+	}
+	if c := newCompression(opts.Compression); c != nil {
+		interceptors = append(interceptors, c.unaryInterceptor())
+		streamInterceptors = append(streamInterceptors, c.streamInterceptor())
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+		grpc.WithUserAgent(userAgent(opts)),
+	}
+	if opts.Keepalive > 0 {
+		timeout := opts.KeepaliveTimeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.Keepalive,
+			Timeout:             timeout,
+			PermitWithoutStream: false,
+		}))
+	}
+
+	conn, err := grpc.Dial(serverAddr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	return &Client{
+		conn:   conn,
+		client: pb.NewMonorepoServiceClient(conn),
+	}, nil
+}
+
+// authInterceptor attaches the bearer token to every outgoing unary call
+func authInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// authStreamInterceptor attaches the bearer token to every outgoing stream
+func authStreamInterceptor(token string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// Close closes the gRPC connection
+func (c *Client) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// GetClient returns the underlying gRPC client
+func (c *Client) GetClient() pb.MonorepoServiceClient {
+	return c.client
+}
+
+// GetAdminClient returns an admin service client on the same connection.
+// The connection must point at the server's admin address.
+func (c *Client) GetAdminClient() pb.MonorepoAdminServiceClient {
+	return pb.NewMonorepoAdminServiceClient(c.conn)
+}
+
+// Conn returns the underlying connection, for calls the typed clients do
+// not make, such as ad-hoc RPCs by name
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// TestConnection tests the gRPC connection by calling GetBranches
+func (c *Client) TestConnection(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := c.client.GetBranches(ctx, &pb.BranchesRequest{})
+	return err
+}
+
+// ReadDirectory lists the contents of a directory
+func (c *Client) ReadDirectory(ctx context.Context, path string) (*pb.ReadDirectoryResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return c.client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: path})
+}
+
+// CreateWorkspace creates a new workspace on the server
+func (c *Client) CreateWorkspace(ctx context.Context, req *pb.CreateWorkspaceRequest) (*pb.CreateWorkspaceResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return c.client.CreateWorkspace(ctx, req)
+}
+
+// AddTrackedPath adds a tracked path to an existing workspace
+func (c *Client) AddTrackedPath(ctx context.Context, workspaceID, path, branch string) (*pb.AddTrackedPathResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return c.client.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{
+		WorkspaceId: workspaceID,
+		Path:        path,
+		Branch:      branch,
+	})
+}
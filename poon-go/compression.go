@@ -0,0 +1,72 @@
+package poon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	_ "github.com/nic/poon/poon-go/zstdcodec" // Registers the zstd compressor
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // Registers the gzip compressor
+	"google.golang.org/grpc/status"
+)
+
+// ValidateCompression checks a compression name accepted by Options:
+// "gzip", "zstd", or "" or "none" for no compression
+func ValidateCompression(name string) error {
+	switch name {
+	case "", "none", "gzip", "zstd":
+		return nil
+	default:
+		return fmt.Errorf("unknown compression %q (use gzip, zstd or none)", name)
+	}
+}
+
+// compression compresses requests with one compressor until the server turns
+// it down. Servers without the compressor reject a call before running it,
+// so the call is repeated uncompressed and later calls skip compression.
+type compression struct {
+	name        string
+	unsupported atomic.Bool
+}
+
+func newCompression(name string) *compression {
+	if name == "" || name == "none" {
+		return nil
+	}
+	return &compression{name: name}
+}
+
+// rejected reports whether err is the server refusing the request's encoding
+func (c *compression) rejected(err error) bool {
+	return status.Code(err) == codes.Unimplemented &&
+		strings.Contains(status.Convert(err).Message(), "Decompressor is not installed")
+}
+
+func (c *compression) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if c.unsupported.Load() {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.UseCompressor(c.name))...)
+		if c.rejected(err) {
+			c.unsupported.Store(true)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+// streamInterceptor compresses streams only while no unary call has been
+// turned down; a stream's rejection arrives after the caller has it
+func (c *compression) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !c.unsupported.Load() {
+			opts = append(opts, grpc.UseCompressor(c.name))
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,39 @@
+// Package poon is the Go client for poon-server's MonorepoService, for tools
+// that read the repository or manage workspaces without running the poon
+// CLI. The CLI uses it too.
+//
+// Connect with New, or NewWithOptions for a bearer token, compression or
+// another retry policy, and close the client when done:
+//
+//	c, err := poon.NewWithOptions("poon.example.com:50051", poon.Options{
+//		Token: os.Getenv("POON_TOKEN"),
+//		Retry: poon.DefaultRetryPolicy(),
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+// Client covers the common calls and the helpers around them: ReadFiles
+// batches reads under the server's size cap (ReadChangedFiles skips files
+// whose cached content is current), CopyFile and WalkDirectory stream large
+// files and directories, and ServerInfo reports which optional features a
+// server has (see the Feature constants). Every other RPC is on GetClient,
+// the generated MonorepoServiceClient, which shares the connection's
+// retries, auth and compression. API is the interface Client implements,
+// for code that wants to substitute a fake.
+//
+// Idempotent calls are retried on UNAVAILABLE, RESOURCE_EXHAUSTED and
+// ABORTED with jittered backoff, and a circuit breaker fails calls fast
+// while the server is down. Errors carry the server's reason and metadata,
+// which Details extracts.
+//
+// # Versioning
+//
+// The module is versioned separately from the server, with tags named
+// poon-go/vMAJOR.MINOR.PATCH; Version is the release this source belongs to.
+// Minor releases only add to the API. The client works with servers older
+// than itself: calls that need a newer server are guarded by
+// ServerInfo.Supports, and servers that predate GetServerInfo are reported as
+// Legacy.
+package poon
@@ -0,0 +1,75 @@
+package poon
+
+import (
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details poon-server attaches
+const ErrorDomain = "poon-server"
+
+// Reasons a server reports in ErrorInfo details and in FailureInfo
+const (
+	ReasonUnauthenticated       = "UNAUTHENTICATED"         // metadata: credentials
+	ReasonInvalidPath           = "INVALID_PATH"            // metadata: path
+	ReasonPathNotFound          = "PATH_NOT_FOUND"          // metadata: path, version
+	ReasonVersionNotFound       = "VERSION_NOT_FOUND"       // metadata: version, current_version
+	ReasonPatchTooLarge         = "PATCH_TOO_LARGE"         // metadata: limit, actual, max
+	ReasonCommitMessageRejected = "COMMIT_MESSAGE_REJECTED" // Violations name the rules
+	ReasonTimeLimitExceeded     = "TIME_LIMIT_EXCEEDED"     // metadata: method, limit
+	ReasonEmptyPatch            = "EMPTY_PATCH"
+	ReasonPatchConflict         = "PATCH_CONFLICT"   // metadata: path, base_version, hunk_line, file_line, expected, actual
+	ReasonCaseCollision         = "CASE_COLLISION"   // metadata: path, base_version
+	ReasonPathLocked            = "PATH_LOCKED"      // metadata: path, owner, expires_at
+	ReasonPolicyViolation       = "POLICY_VIOLATION" // metadata: policy
+	ReasonTooManyPaths          = "TOO_MANY_PATHS"   // metadata: requested, max
+	ReasonNotAFile              = "NOT_A_FILE"       // metadata: path
+	ReasonFileTooLarge          = "FILE_TOO_LARGE"   // metadata: path, size, max
+	ReasonBackendUnavailable    = "BACKEND_UNAVAILABLE"
+	ReasonBranchNotFound        = "BRANCH_NOT_FOUND" // metadata: branch
+	ReasonMergeConflict         = "MERGE_CONFLICT"   // metadata: source, target
+	ReasonPathChanged           = "PATH_CHANGED"     // metadata: path, expected_version, current_version
+)
+
+// ErrorDetails is the machine-readable part of a failed call
+type ErrorDetails struct {
+	Reason     string
+	Metadata   map[string]string
+	Violations []*errdetails.BadRequest_FieldViolation // Request fields that were rejected
+}
+
+// Details returns the details poon-server attached to err, or nil when err
+// carries none (for example errors from older servers)
+func Details(err error) *ErrorDetails {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+
+	var details *ErrorDetails
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.Domain == ErrorDomain {
+				details = &ErrorDetails{Reason: d.Reason, Metadata: d.Metadata}
+			}
+		case *errdetails.BadRequest:
+			violations = append(violations, d.FieldViolations...)
+		}
+	}
+	if details != nil {
+		details.Violations = violations
+	}
+	return details
+}
+
+// FailureDetails returns the details of a failure a response reported
+// in-band, or nil when there are none
+func FailureDetails(failure *pb.FailureInfo) *ErrorDetails {
+	if failure == nil || failure.Reason == "" {
+		return nil
+	}
+	return &ErrorDetails{Reason: failure.Reason, Metadata: failure.Metadata}
+}
@@ -0,0 +1,117 @@
+package poon_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	poon "github.com/nic/poon/poon-go"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+func ExampleNewWithOptions() {
+	opts := poon.DefaultOptions()
+	opts.Token = os.Getenv("POON_TOKEN")
+	opts.Compression = "zstd"
+	opts.UserAgent = "release-notes/1.4"
+	opts.Retry.MaxAttempts = 5
+
+	c, err := poon.NewWithOptions("poon.example.com:50051", opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	dir, err := c.ReadDirectory(context.Background(), "services")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, item := range dir.Items {
+		fmt.Println(item.Name)
+	}
+}
+
+func ExampleClient_ServerInfo() {
+	c, err := poon.New("localhost:50051")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	info, err := c.ServerInfo(context.Background(), poon.Version)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !info.Supports(poon.FeatureStreamingReads) {
+		log.Printf("%s cannot stream; large files are read range by range", info.Version)
+	}
+}
+
+func ExampleClient_CopyFile() {
+	c, err := poon.New("localhost:50051")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	version, err := c.CopyFile(context.Background(), os.Stdout, "README.md", 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("read README.md at version %d", version)
+}
+
+func ExampleClient_WalkDirectory() {
+	c, err := poon.New("localhost:50051")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	var total int64
+	_, err = c.WalkDirectory(context.Background(), "assets", 0, func(item *pb.DirectoryItem) error {
+		total += item.Size
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(total, "bytes")
+}
+
+func ExampleClient_ReadFiles() {
+	c, err := poon.New("localhost:50051")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	files, version, err := c.ReadFiles(context.Background(), []string{"go.mod", "go.sum"}, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Error != "" {
+			log.Printf("%s: %s", f.Path, f.Error)
+			continue
+		}
+		fmt.Printf("%s@%d: %d bytes\n", f.Path, version, f.Size)
+	}
+}
+
+func ExampleDetails() {
+	c, err := poon.New("localhost:50051")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = c.GetClient().MergePatch(ctx, &pb.MergePatchRequest{Path: "services/api"})
+	if details := poon.Details(err); details != nil && details.Reason == poon.ReasonPolicyViolation {
+		log.Printf("rejected by policy %s", details.Metadata["policy"])
+	}
+}
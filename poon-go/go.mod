@@ -0,0 +1,21 @@
+module github.com/nic/poon/poon-go
+
+go 1.23.0
+
+toolchain go1.23.3
+
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/nic/poon/poon-proto/gen/go v0.0.0-00010101000000-000000000000
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250528174236-200df99c418a
+	google.golang.org/grpc v1.74.2
+)
+
+require (
+	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+)
+
+replace github.com/nic/poon/poon-proto/gen/go => ../poon-proto/gen/go
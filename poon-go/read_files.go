@@ -0,0 +1,69 @@
+package poon
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// ReadFiles reads paths at version (0 for the current one) with as few
+// ReadFiles calls as the server's size cap allows. Files a call omits are
+// asked for again at the version the first call read, so every result
+// comes from the same version. Results are in the order of paths; files
+// that could not be read have Error set.
+func (c *Client) ReadFiles(ctx context.Context, paths []string, version int64) ([]*pb.FileResult, int64, error) {
+	return c.ReadChangedFiles(ctx, paths, nil, version)
+}
+
+// ReadChangedFiles is ReadFiles for a caller that caches content: ifNotHash
+// maps paths to the blob hash of their cached content, and a file that still
+// has that hash comes back with NotModified set and no content. Servers
+// without conditional batch reads send the content anyway.
+func (c *Client) ReadChangedFiles(ctx context.Context, paths []string, ifNotHash map[string]string, version int64) ([]*pb.FileResult, int64, error) {
+	results := make([]*pb.FileResult, len(paths))
+	pending := make([]int, len(paths)) // Indexes into paths still to read
+	for i := range paths {
+		pending[i] = i
+	}
+
+	for len(pending) > 0 {
+		batch := make([]string, len(pending))
+		for i, index := range pending {
+			batch[i] = paths[index]
+		}
+
+		req := &pb.ReadFilesRequest{Paths: batch, Version: version}
+		for _, path := range batch {
+			if hash := ifNotHash[path]; hash != "" {
+				if req.IfNotHash == nil {
+					req.IfNotHash = make(map[string]string)
+				}
+				req.IfNotHash[path] = hash
+			}
+		}
+		resp, err := c.client.ReadFiles(ctx, req)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(resp.Files) != len(batch) {
+			return nil, 0, fmt.Errorf("server returned %d results for %d paths", len(resp.Files), len(batch))
+		}
+		version = resp.Version
+
+		var omitted []int
+		for i, file := range resp.Files {
+			if file.Omitted {
+				omitted = append(omitted, pending[i])
+			} else {
+				results[pending[i]] = file
+			}
+		}
+		if len(omitted) == len(pending) {
+			return nil, 0, fmt.Errorf("server omitted every file in the batch")
+		}
+		pending = omitted
+	}
+
+	return results, version, nil
+}
@@ -0,0 +1,189 @@
+package poon
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy controls how idempotent RPCs are retried on transient failures
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first call (1 disables retries)
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for the delay between retries
+	Multiplier     float64       // Backoff growth factor between retries
+}
+
+// DefaultRetryPolicy returns the retry policy used by New
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2.0,
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= p.Multiplier
+	}
+	if max := float64(p.MaxBackoff); delay > max {
+		delay = max
+	}
+	// Full jitter: anywhere from none to all of the computed delay, so
+	// clients that failed together do not retry together
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// downloadPathMethod is retried only for synchronous downloads; see
+// isIdempotent
+const downloadPathMethod = "/monorepo.MonorepoService/DownloadPath"
+
+// idempotentMethods lists the RPCs that are safe to retry
+var idempotentMethods = map[string]bool{
+	"/monorepo.MonorepoService/PreviewPatch":               true,
+	"/monorepo.MonorepoService/ReadDirectory":              true,
+	"/monorepo.MonorepoService/ReadFile":                   true,
+	"/monorepo.MonorepoService/ReadFiles":                  true,
+	"/monorepo.MonorepoService/PreviewFile":                true,
+	"/monorepo.MonorepoService/GetRenderedDoc":             true,
+	"/monorepo.MonorepoService/SearchSymbols":              true,
+	"/monorepo.MonorepoService/GoToDefinition":             true,
+	"/monorepo.MonorepoService/GetPathInfo":                true,
+	"/monorepo.MonorepoService/GetTreeHash":                true,
+	"/monorepo.MonorepoService/ListCaseCollisions":         true,
+	"/monorepo.MonorepoService/GetAffectedPaths":           true,
+	"/monorepo.MonorepoService/GetFileHistory":             true,
+	"/monorepo.MonorepoService/GetBranches":                true,
+	"/monorepo.MonorepoService/GetWorkspace":               true,
+	"/monorepo.MonorepoService/GetOperation":               true,
+	"/monorepo.MonorepoService/WaitOperation":              true,
+	"/monorepo.MonorepoService/ListOperations":             true,
+	"/monorepo.MonorepoService/ListTemplates":              true,
+	"/monorepo.MonorepoService/ListViews":                  true,
+	"/monorepo.MonorepoService/OpenWorkspaceFile":          true,
+	"/monorepo.MonorepoService/ListWorkspaceSiblings":      true,
+	"/monorepo.MonorepoService/FetchWorkspaceDependencies": true,
+	"/monorepo.MonorepoService/WhoAmI":                     true,
+	"/monorepo.MonorepoService/GetServerInfo":              true,
+	"/monorepo.MonorepoService/ListLocks":                  true,
+	"/monorepo.MonorepoService/ListTags":                   true,
+	"/monorepo.MonorepoService/GetActivity":                true,
+	"/monorepo.MonorepoService/GetQuota":                   true,
+	"/monorepo.MonorepoService/GetMergeQueue":              true,
+	"/monorepo.MonorepoService/ListDeletedPaths":           true,
+}
+
+// isRetryable reports whether err is a transient failure worth retrying
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// circuitBreaker fails fast after repeated unavailability so commands that
+// issue many RPCs don't wait out the full retry budget for each one
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu          sync.Mutex
+	failures    int
+	openUntil   time.Time
+	lastFailure string // Message of the failure that opened the breaker
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.now().After(cb.openUntil)
+}
+
+// openError is the error calls fail with while the breaker is open, naming
+// the failure that opened it so the cause is not lost
+func (cb *circuitBreaker) openError(serverAddr string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return status.Errorf(codes.Unavailable, "server unavailable at %s (is poon-server running?): too many recent connection failures, last: %s", serverAddr, cb.lastFailure)
+}
+
+func (cb *circuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if status.Code(err) != codes.Unavailable {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.openUntil = cb.now().Add(cb.cooldown)
+		cb.failures = 0
+		cb.lastFailure = status.Convert(err).Message()
+	}
+}
+
+// retryInterceptor retries idempotent calls with exponential backoff and
+// turns connection failures into a clear "server unavailable" error
+// isIdempotent reports whether a call of method with req is safe to retry. An
+// async DownloadPath starts a server-side operation, so retrying one whose
+// response was lost would start a second.
+func isIdempotent(method string, req interface{}) bool {
+	if method == downloadPathMethod {
+		download, ok := req.(*pb.DownloadPathRequest)
+		return ok && !download.GetAsync()
+	}
+	return idempotentMethods[method]
+}
+
+func retryInterceptor(serverAddr string, policy RetryPolicy, breaker *circuitBreaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !breaker.allow() {
+			return breaker.openError(serverAddr)
+		}
+
+		attempts := 1
+		if isIdempotent(method, req) && policy.MaxAttempts > 1 {
+			attempts = policy.MaxAttempts
+		}
+
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			breaker.record(err)
+			if err == nil || !isRetryable(err) || attempt == attempts || !breaker.allow() {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(policy.backoff(attempt)):
+			}
+		}
+
+		if status.Code(err) == codes.Unavailable {
+			return status.Error(codes.Unavailable, fmt.Sprintf("server unavailable at %s (is poon-server running?): %s", serverAddr, status.Convert(err).Message()))
+		}
+		return err
+	}
+}
@@ -0,0 +1,215 @@
+package poon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	readMethod  = "/monorepo.MonorepoService/ReadFile"
+	writeMethod = "/monorepo.MonorepoService/MergePatch"
+)
+
+// fakeInvoker answers each call with the next of its errors, and with the
+// last one once they run out
+type fakeInvoker struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeInvoker) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	return f.errs[min(f.calls, len(f.errs))-1]
+}
+
+// changingMethods are the unary RPCs that change something and so must not
+// be retried; every other unary RPC only reads
+var changingMethods = map[string]bool{
+	"MergePatch":              true,
+	"CreateBranch":            true,
+	"MergeBranches":           true,
+	"CherryPick":              true,
+	"CreateWorkspace":         true,
+	"UpdateWorkspace":         true,
+	"DeleteWorkspace":         true,
+	"CancelOperation":         true,
+	"ReportWorkspaceStatus":   true,
+	"ConfigureSparseCheckout": true,
+	"AddTrackedPath":          true,
+	"RefreshTrackedPaths":     true,
+	"LockPath":                true,
+	"UnlockPath":              true,
+	"ApprovePatch":            true,
+	"ReportQueueValidation":   true,
+	"RestoreDeletedPath":      true,
+}
+
+func TestIdempotentMethods(t *testing.T) {
+	service := pb.MonorepoService_ServiceDesc
+	for _, method := range service.Methods {
+		fullName := "/" + service.ServiceName + "/" + method.MethodName
+		if fullName == downloadPathMethod {
+			continue
+		}
+		if changingMethods[method.MethodName] {
+			if idempotentMethods[fullName] {
+				t.Errorf("%s changes state but is retried", method.MethodName)
+			}
+		} else if !idempotentMethods[fullName] {
+			t.Errorf("read-only %s is not retried; add it to idempotentMethods, or to changingMethods if it changes state", method.MethodName)
+		}
+	}
+}
+
+func TestDownloadPathIdempotence(t *testing.T) {
+	if !isIdempotent(downloadPathMethod, &pb.DownloadPathRequest{Path: "src"}) {
+		t.Error("synchronous DownloadPath is not retried")
+	}
+	if isIdempotent(downloadPathMethod, &pb.DownloadPathRequest{Path: "src", Async: true}) {
+		t.Error("async DownloadPath, which starts an operation, is retried")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{status.Error(codes.Unavailable, "down"), true},
+		{status.Error(codes.ResourceExhausted, "slow down"), true},
+		{status.Error(codes.Aborted, "conflict"), true},
+		{status.Error(codes.NotFound, "missing"), false},
+		{status.Error(codes.InvalidArgument, "bad"), false},
+		{status.Error(codes.DeadlineExceeded, "late"), false},
+		{errors.New("plain"), false},
+		{nil, false},
+	} {
+		if got := isRetryable(tc.err); got != tc.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	for _, tc := range []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{20, time.Second},
+	} {
+		for i := 0; i < 100; i++ {
+			if delay := policy.backoff(tc.attempt); delay < 0 || delay > tc.max {
+				t.Fatalf("backoff(%d) = %v, want between 0 and %v", tc.attempt, delay, tc.max)
+			}
+		}
+	}
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	notFound := status.Error(codes.NotFound, "missing")
+	fast := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 2}
+
+	for _, tc := range []struct {
+		name      string
+		method    string
+		policy    RetryPolicy
+		cancelled bool
+		errs      []error
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{"retried until success", readMethod, fast, false, []error{unavailable, unavailable, nil}, 3, codes.OK},
+		{"attempt limit", readMethod, fast, false, []error{unavailable}, 3, codes.Unavailable},
+		{"single attempt policy", readMethod, RetryPolicy{MaxAttempts: 1}, false, []error{unavailable}, 1, codes.Unavailable},
+		{"not retryable", readMethod, fast, false, []error{notFound}, 1, codes.NotFound},
+		{"not idempotent", writeMethod, fast, false, []error{unavailable}, 1, codes.Unavailable},
+		{"not idempotent conflict", writeMethod, fast, false, []error{status.Error(codes.Aborted, "conflict")}, 1, codes.Aborted},
+		{"cancelled", readMethod, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour, Multiplier: 2}, true, []error{unavailable}, 1, codes.Unavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelled {
+				cancel()
+			}
+
+			invoker := &fakeInvoker{errs: tc.errs}
+			interceptor := retryInterceptor("localhost:50051", tc.policy, newCircuitBreaker(100, time.Minute))
+			err := interceptor(ctx, tc.method, nil, nil, nil, invoker.invoke)
+			if invoker.calls != tc.wantCalls {
+				t.Errorf("invoked %d times, want %d", invoker.calls, tc.wantCalls)
+			}
+			if code := status.Code(err); code != tc.wantCode {
+				t.Errorf("code = %v, want %v (%v)", code, tc.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(1000, 0)
+	breaker := newCircuitBreaker(3, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+	interceptor := retryInterceptor("localhost:50051", RetryPolicy{MaxAttempts: 1}, breaker)
+	invoker := &fakeInvoker{errs: []error{status.Error(codes.Unavailable, "connection refused")}}
+	call := func() error {
+		return interceptor(context.Background(), writeMethod, nil, nil, nil, invoker.invoke)
+	}
+
+	// Other failures and successes reset the count
+	breaker.record(status.Error(codes.Unavailable, "down"))
+	breaker.record(status.Error(codes.Unavailable, "down"))
+	breaker.record(nil)
+	if !breaker.allow() {
+		t.Fatal("breaker opened before consecutive failures reached the threshold")
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := call(); status.Code(err) != codes.Unavailable {
+			t.Fatalf("call %d: %v, want unavailable", i, err)
+		}
+	}
+	if invoker.calls != 3 {
+		t.Fatalf("invoked %d times, want 3", invoker.calls)
+	}
+
+	// Open: calls fail without reaching the server
+	now = now.Add(29 * time.Second)
+	err := call()
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("open breaker: %v, want unavailable", err)
+	}
+	if !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("open breaker error %q does not name the failure that opened it", err)
+	}
+	if invoker.calls != 3 {
+		t.Fatalf("open breaker invoked the server (%d calls)", invoker.calls)
+	}
+
+	// Closed again after the cooldown
+	now = now.Add(2 * time.Second)
+	invoker.errs = nil
+	if err := call(); err != nil {
+		t.Fatalf("after cooldown: %v", err)
+	}
+	if invoker.calls != 4 {
+		t.Fatalf("invoked %d times after cooldown, want 4", invoker.calls)
+	}
+}
@@ -0,0 +1,116 @@
+package poon
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Optional features a server can advertise in GetServerInfo
+const (
+	FeatureStreamingReads     = "streaming-reads"     // StreamDirectory and StreamFile
+	FeatureConditionalReads   = "conditional-reads"   // if_not_hash on ReadDirectory and ReadFile
+	FeaturePatchPreview       = "patch-preview"       // PreviewPatch
+	FeatureZstdCompression    = "zstd-compression"    // Requests and responses compressed with zstd
+	FeatureMergeQueue         = "merge-queue"         // MergePatch queues patches
+	FeatureBatchReads         = "batch-reads"         // ReadFiles
+	FeatureTreeHashes         = "tree-hashes"         // GetTreeHash
+	FeatureWorkspaceArchive   = "workspace-archive"   // StreamWorkspaceArchive
+	FeatureTemplates          = "workspace-templates" // ListTemplates and CreateWorkspace templates
+	FeatureViews              = "path-views"          // ListViews and "view:<name>" tracked paths
+	FeatureComposition        = "composed-workspaces" // "workspace:<id-or-name>" tracked paths
+	FeatureAttributes         = "path-attributes"     // .poonattributes for patches, workspaces and archives
+	FeatureRangeReads         = "range-reads"         // offset and length on ReadFile
+	FeatureFilePreview        = "file-preview"        // PreviewFile
+	FeatureTags               = "tags"                // ListTags
+	FeatureActivity           = "activity"            // GetActivity
+	FeatureOperations         = "operations"          // GetOperation, WaitOperation and operation_id from lazy CreateWorkspace
+	FeatureBranches           = "branches"            // CreateBranch, MergeBranches, CherryPick and branch on MergePatch
+	FeatureRenderedDocs       = "rendered-docs"       // GetRenderedDoc
+	FeatureSymbols            = "symbols"             // SearchSymbols and GoToDefinition
+	FeatureEditor             = "workspace-editor"    // OpenWorkspaceFile, ListWorkspaceSiblings and FetchWorkspaceDependencies
+	FeatureStreamingDownloads = "streaming-downloads" // StreamDownloadPath
+)
+
+// ServerInfo is what a server reports about itself. Servers that predate
+// GetServerInfo are Legacy: their version is unknown and they advertise no
+// optional features, so callers use the calls every server has.
+type ServerInfo struct {
+	Version          string
+	APIVersion       string
+	MinClientVersion string
+	Features         []string
+	AuthModes        []string
+	HashAlgorithm    string // "sha256" or "blake3"; empty from servers that only use SHA-256
+	WebURL           string // Base URL of the web UI; empty when the server knows of none
+	Legacy           bool
+}
+
+// ServerInfo asks the server for its version and capabilities
+func (c *Client) ServerInfo(ctx context.Context, clientVersion string) (*ServerInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.GetServerInfo(ctx, &pb.GetServerInfoRequest{ClientVersion: clientVersion})
+	if status.Code(err) == codes.Unimplemented {
+		return &ServerInfo{Legacy: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServerInfo{
+		Version:          resp.ServerVersion,
+		APIVersion:       resp.ApiVersion,
+		MinClientVersion: resp.MinClientVersion,
+		Features:         resp.Features,
+		AuthModes:        resp.AuthModes,
+		HashAlgorithm:    resp.HashAlgorithm,
+		WebURL:           resp.WebUrl,
+	}, nil
+}
+
+// Supports reports whether the server advertises feature
+func (i *ServerInfo) Supports(feature string) bool {
+	return i != nil && slices.Contains(i.Features, feature)
+}
+
+// ClientTooOld reports whether clientVersion is older than the server's
+// minimum supported client
+func (i *ServerInfo) ClientTooOld(clientVersion string) bool {
+	return i != nil && i.MinClientVersion != "" && CompareVersions(clientVersion, i.MinClientVersion) < 0
+}
+
+// CompareVersions orders dotted release numbers such as "1.4.2", returning
+// -1, 0 or 1. A leading "v" and anything after "-" or "+" are ignored, and
+// missing or non-numeric parts count as 0.
+func CompareVersions(a, b string) int {
+	partsA, partsB := versionParts(a), versionParts(b)
+	for len(partsA) < len(partsB) {
+		partsA = append(partsA, 0)
+	}
+	for len(partsB) < len(partsA) {
+		partsB = append(partsB, 0)
+	}
+	return slices.Compare(partsA, partsB)
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, _ := strconv.Atoi(field)
+		parts = append(parts, n)
+	}
+	return parts
+}
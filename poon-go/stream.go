@@ -0,0 +1,108 @@
+package poon
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CopyFile writes the file at path, at version (0 for the current one), to
+// w and returns the version it was read from. It streams the file with
+// StreamFile, so files of any size take little memory. Servers without
+// streaming reads are asked with ReadFile, range by range, and as they do
+// not report the version read, version itself is returned.
+func (c *Client) CopyFile(ctx context.Context, w io.Writer, path string, version int64) (int64, error) {
+	stream, err := c.client.StreamFile(ctx, &pb.StreamFileRequest{Path: path, Version: version})
+	if err != nil {
+		return 0, err
+	}
+
+	first := true
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return version, nil
+		}
+		if first && status.Code(err) == codes.Unimplemented {
+			return c.copyFileUnstreamed(ctx, w, path, version)
+		}
+		if err != nil {
+			return 0, err
+		}
+		first = false
+		version = chunk.Version
+		if _, err := w.Write(chunk.Data); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (c *Client) copyFileUnstreamed(ctx context.Context, w io.Writer, path string, version int64) (int64, error) {
+	var offset int64
+	for {
+		resp, err := c.client.ReadFile(ctx, &pb.ReadFileRequest{Path: path, Version: version, Offset: offset})
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(resp.Content); err != nil {
+			return 0, err
+		}
+		offset += int64(len(resp.Content))
+		if len(resp.Content) == 0 || offset >= resp.Size {
+			return version, nil
+		}
+	}
+}
+
+// WalkDirectory calls fn for each entry of the directory at path, at
+// version (0 for the current one), as the server streams them, and returns
+// the version it was read from, as CopyFile does. An error from fn stops
+// the walk and is returned. Servers without streaming reads list the
+// directory with one ReadDirectory call instead.
+func (c *Client) WalkDirectory(ctx context.Context, path string, version int64, fn func(item *pb.DirectoryItem) error) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.StreamDirectory(ctx, &pb.StreamDirectoryRequest{Path: path, Version: version})
+	if err != nil {
+		return 0, err
+	}
+
+	first := true
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return version, nil
+		}
+		if first && status.Code(err) == codes.Unimplemented {
+			return c.walkDirectoryUnstreamed(ctx, path, version, fn)
+		}
+		if err != nil {
+			return 0, err
+		}
+		first = false
+		version = batch.Version
+		for _, item := range batch.Items {
+			if err := fn(item); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+func (c *Client) walkDirectoryUnstreamed(ctx context.Context, path string, version int64, fn func(item *pb.DirectoryItem) error) (int64, error) {
+	resp, err := c.client.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: path, Version: version})
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range resp.Items {
+		if err := fn(item); err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
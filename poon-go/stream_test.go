@@ -0,0 +1,114 @@
+package poon
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// streamingServer serves a file and a directory with the streaming RPCs
+type streamingServer struct {
+	pb.UnimplementedMonorepoServiceServer
+}
+
+func (streamingServer) StreamFile(req *pb.StreamFileRequest, stream pb.MonorepoService_StreamFileServer) error {
+	for i, part := range []string{"hello ", "world"} {
+		if err := stream.Send(&pb.FileChunk{Version: 7, Offset: int64(i * 6), Data: []byte(part), Size: 11}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (streamingServer) StreamDirectory(req *pb.StreamDirectoryRequest, stream pb.MonorepoService_StreamDirectoryServer) error {
+	for _, name := range []string{"a", "b"} {
+		if err := stream.Send(&pb.StreamDirectoryResponse{Version: 7, Items: []*pb.DirectoryItem{{Name: name}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyServer predates the streaming RPCs and returns files in 4 byte ranges
+type legacyServer struct {
+	pb.UnimplementedMonorepoServiceServer
+}
+
+func (legacyServer) ReadFile(ctx context.Context, req *pb.ReadFileRequest) (*pb.ReadFileResponse, error) {
+	content := []byte("hello world")
+	end := min(req.Offset+4, int64(len(content)))
+	return &pb.ReadFileResponse{Content: content[req.Offset:end], Size: int64(len(content)), Offset: req.Offset}, nil
+}
+
+func (legacyServer) ReadDirectory(ctx context.Context, req *pb.ReadDirectoryRequest) (*pb.ReadDirectoryResponse, error) {
+	return &pb.ReadDirectoryResponse{Items: []*pb.DirectoryItem{{Name: "a"}, {Name: "b"}}}, nil
+}
+
+func dialFake(t *testing.T, srv pb.MonorepoServiceServer) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterMonorepoServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{conn: conn, client: pb.NewMonorepoServiceClient(conn)}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestCopyFile(t *testing.T) {
+	for name, tc := range map[string]struct {
+		srv     pb.MonorepoServiceServer
+		version int64
+	}{
+		"streaming": {streamingServer{}, 7},
+		"legacy":    {legacyServer{}, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := dialFake(t, tc.srv)
+			var buf bytes.Buffer
+			version, err := c.CopyFile(context.Background(), &buf, "greeting.txt", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != "hello world" || version != tc.version {
+				t.Errorf("got %q at version %d, want %q at version %d", buf.String(), version, "hello world", tc.version)
+			}
+		})
+	}
+}
+
+func TestWalkDirectory(t *testing.T) {
+	for name, srv := range map[string]pb.MonorepoServiceServer{
+		"streaming": streamingServer{},
+		"legacy":    legacyServer{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := dialFake(t, srv)
+			var names []string
+			_, err := c.WalkDirectory(context.Background(), "dir", 0, func(item *pb.DirectoryItem) error {
+				names = append(names, item.Name)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+				t.Errorf("walked %v, want [a b]", names)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package poon
+
+// Version is the release of this client library. It is sent in the user
+// agent of every connection, after Options.UserAgent.
+const Version = "0.1.0"
+
+// userAgent is the user agent of a connection made with opts
+func userAgent(opts Options) string {
+	if opts.UserAgent == "" {
+		return "poon-go/" + Version
+	}
+	return opts.UserAgent + " poon-go/" + Version
+}
@@ -0,0 +1,102 @@
+// Package zstdcodec registers the gRPC compressor for the "zstd" encoding,
+// which poon clients and poon-server both use. Import it for its side
+// effect:
+//
+//	import _ "github.com/nic/poon/poon-go/zstdcodec"
+package zstdcodec
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the encoding the compressor is registered under
+const Name = "zstd"
+
+// Decoding limits. A peer sending a frame that asks for a larger window, or
+// that decodes to more than MaxDecodedBytes, gets an error rather than the
+// memory; gRPC's own message size limit applies on top. Encoders use the
+// same window, so every message they write can be read.
+const (
+	MaxWindowBytes  = 8 << 20
+	MaxDecodedBytes = 256 << 20
+)
+
+func init() {
+	encoding.RegisterCompressor(&compressor{})
+}
+
+// compressor pools encoders and decoders, since creating them allocates
+// heavily
+type compressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+func (c *compressor) Name() string {
+	return Name
+}
+
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc, ok := c.encoders.Get().(*zstd.Encoder)
+	if !ok {
+		var err error
+		if enc, err = zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(MaxWindowBytes)); err != nil {
+			return nil, err
+		}
+	} else {
+		enc.Reset(w)
+	}
+	return &writer{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	dec, ok := c.decoders.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		dec, err = zstd.NewReader(r,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxWindow(MaxWindowBytes),
+			zstd.WithDecoderMaxMemory(MaxDecodedBytes),
+		)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := dec.Reset(r); err != nil {
+		c.decoders.Put(dec)
+		return nil, err
+	}
+	return &reader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+type writer struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *writer) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+// reader returns its decoder to the pool once the message is read
+type reader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Decoder)
+		r.Decoder = nil
+	}
+	return n, err
+}
@@ -0,0 +1,70 @@
+package zstdcodec
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestRoundTrip(t *testing.T) {
+	compressor := encoding.GetCompressor(Name)
+	if compressor == nil {
+		t.Fatal("zstd compressor is not registered")
+	}
+	message := bytes.Repeat([]byte("package main\n\nfunc main() {}\n"), 1000)
+
+	// Twice, so the second round reuses pooled encoders and decoders
+	for i := 0; i < 2; i++ {
+		var compressed bytes.Buffer
+		w, err := compressor.Compress(&compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := compressor.Decompress(&compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decompressed, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decompressed, message) {
+			t.Errorf("round %d decompressed %d bytes, want the %d written", i, len(decompressed), len(message))
+		}
+	}
+}
+
+func TestLargeWindowRefused(t *testing.T) {
+	// A frame whose window is larger than the decoder allows, as a peer
+	// could send to make it allocate
+	data := make([]byte, 2*MaxWindowBytes)
+	rand.New(rand.NewSource(1)).Read(data)
+	var compressed bytes.Buffer
+	enc, err := zstd.NewWriter(&compressed, zstd.WithWindowSize(4*MaxWindowBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.Write(data)
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := encoding.GetCompressor(Name).Decompress(&compressed)
+	if err == nil {
+		_, err = io.ReadAll(r)
+	}
+	if err == nil {
+		t.Error("frame with a 32 MiB window was decoded")
+	}
+}
@@ -0,0 +1,6 @@
+"""Messages and gRPC stubs for the Poon MonorepoService.
+
+monorepo_pb2 holds the messages and monorepo_pb2_grpc the
+MonorepoServiceStub and MonorepoAdminServiceStub; both are generated from
+poon-proto/monorepo.proto by `make proto-clients`.
+"""
@@ -0,0 +1,3 @@
+// Messages and the MonorepoService and MonorepoAdminService descriptors,
+// generated into gen/ by `make proto-clients`
+export * from "./gen/monorepo_pb.js";
@@ -73,16 +73,84 @@ func (WorkspaceStatus) EnumDescriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{0}
 }
 
+// Progress of a change through the merge queue
+type QueueEntryState int32
+
+const (
+	QueueEntryState_QUEUE_PENDING    QueueEntryState = 0 // Waiting for the changes ahead of it, or being rebased
+	QueueEntryState_QUEUE_VALIDATING QueueEntryState = 1 // Rebased and waiting for the external validator
+	QueueEntryState_QUEUE_LANDED     QueueEntryState = 2 // Applied as a new version
+	QueueEntryState_QUEUE_FAILED     QueueEntryState = 3 // Did not apply, failed validation or timed out
+)
+
+// Enum value maps for QueueEntryState.
+var (
+	QueueEntryState_name = map[int32]string{
+		0: "QUEUE_PENDING",
+		1: "QUEUE_VALIDATING",
+		2: "QUEUE_LANDED",
+		3: "QUEUE_FAILED",
+	}
+	QueueEntryState_value = map[string]int32{
+		"QUEUE_PENDING":    0,
+		"QUEUE_VALIDATING": 1,
+		"QUEUE_LANDED":     2,
+		"QUEUE_FAILED":     3,
+	}
+)
+
+func (x QueueEntryState) Enum() *QueueEntryState {
+	p := new(QueueEntryState)
+	*p = x
+	return p
+}
+
+func (x QueueEntryState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueueEntryState) Descriptor() protoreflect.EnumDescriptor {
+	return file_monorepo_proto_enumTypes[1].Descriptor()
+}
+
+func (QueueEntryState) Type() protoreflect.EnumType {
+	return &file_monorepo_proto_enumTypes[1]
+}
+
+func (x QueueEntryState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueueEntryState.Descriptor instead.
+func (QueueEntryState) EnumDescriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{1}
+}
+
 // Request to merge a patch
 type MergePatchRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`       // Target path in the monorepo
-	Patch         []byte                 `protobuf:"bytes,2,opt,name=patch,proto3" json:"patch,omitempty"`     // The patch content (unified diff format)
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Commit message
-	Author        string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`   // Author information
-	Branch        string                 `protobuf:"bytes,5,opt,name=branch,proto3" json:"branch,omitempty"`   // Target branch (default: main)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Path                    string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`                                                                         // Target path in the monorepo
+	Patch                   []byte                 `protobuf:"bytes,2,opt,name=patch,proto3" json:"patch,omitempty"`                                                                       // The patch content (unified diff format)
+	Message                 string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                                                                   // Commit message
+	Author                  string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`                                                                     // Author information
+	Branch                  string                 `protobuf:"bytes,5,opt,name=branch,proto3" json:"branch,omitempty"`                                                                     // Target branch (default: main)
+	FailIfLocked            bool                   `protobuf:"varint,6,opt,name=fail_if_locked,json=failIfLocked,proto3" json:"fail_if_locked,omitempty"`                                  // Reject the patch if the target is locked by someone else
+	IgnoreWhitespace        bool                   `protobuf:"varint,7,opt,name=ignore_whitespace,json=ignoreWhitespace,proto3" json:"ignore_whitespace,omitempty"`                        // Match context and removed lines ignoring whitespace differences
+	NormalizeLineEndings    bool                   `protobuf:"varint,8,opt,name=normalize_line_endings,json=normalizeLineEndings,proto3" json:"normalize_line_endings,omitempty"`          // Match CRLF and LF lines alike; added lines use the file's line ending
+	PreserveTrailingNewline bool                   `protobuf:"varint,9,opt,name=preserve_trailing_newline,json=preserveTrailingNewline,proto3" json:"preserve_trailing_newline,omitempty"` // Keep a missing final newline instead of always adding one
+	// Ed25519 signature by the author, required on paths protected with
+	// requireSignedCommits. It signs "poon-patch-signature-v1", a NUL byte, the
+	// commit message, a NUL byte and the patch.
+	Signature []byte `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	// Fold the patch and message into the branch's newest commit instead of
+	// adding one; branches other than main only, while the commit is unmerged.
+	// The patch may then be empty to change only the message.
+	Amend bool `protobuf:"varint,11,opt,name=amend,proto3" json:"amend,omitempty"`
+	// Version of main the patch was made against. The patch is rejected if
+	// a file it changes has changed since; 0 skips the check. Main only.
+	ExpectedVersion int64 `protobuf:"varint,12,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *MergePatchRequest) Reset() {
@@ -150,6 +218,55 @@ func (x *MergePatchRequest) GetBranch() string {
 	return ""
 }
 
+func (x *MergePatchRequest) GetFailIfLocked() bool {
+	if x != nil {
+		return x.FailIfLocked
+	}
+	return false
+}
+
+func (x *MergePatchRequest) GetIgnoreWhitespace() bool {
+	if x != nil {
+		return x.IgnoreWhitespace
+	}
+	return false
+}
+
+func (x *MergePatchRequest) GetNormalizeLineEndings() bool {
+	if x != nil {
+		return x.NormalizeLineEndings
+	}
+	return false
+}
+
+func (x *MergePatchRequest) GetPreserveTrailingNewline() bool {
+	if x != nil {
+		return x.PreserveTrailingNewline
+	}
+	return false
+}
+
+func (x *MergePatchRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *MergePatchRequest) GetAmend() bool {
+	if x != nil {
+		return x.Amend
+	}
+	return false
+}
+
+func (x *MergePatchRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 // Response from merging a patch
 type MergePatchResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -157,6 +274,11 @@ type MergePatchResponse struct {
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	CommitHash    string                 `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
 	Conflicts     []string               `protobuf:"bytes,4,rep,name=conflicts,proto3" json:"conflicts,omitempty"`
+	Violations    []*PolicyViolation     `protobuf:"bytes,5,rep,name=violations,proto3" json:"violations,omitempty"`                           // Validation rules that rejected the patch
+	Queued        bool                   `protobuf:"varint,6,opt,name=queued,proto3" json:"queued,omitempty"`                                  // The patch entered the merge queue instead of landing
+	QueueEntryId  string                 `protobuf:"bytes,7,opt,name=queue_entry_id,json=queueEntryId,proto3" json:"queue_entry_id,omitempty"` // Merge queue entry to follow with GetMergeQueue
+	Failure       *FailureInfo           `protobuf:"bytes,8,opt,name=failure,proto3" json:"failure,omitempty"`                                 // Why the patch was not merged; unset on success
+	Stats         *ChangeStats           `protobuf:"bytes,9,opt,name=stats,proto3" json:"stats,omitempty"`                                     // What the new version changed; unset when queued or failed
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -219,30 +341,68 @@ func (x *MergePatchResponse) GetConflicts() []string {
 	return nil
 }
 
-// Request to read a directory
-type ReadDirectoryRequest struct {
+func (x *MergePatchResponse) GetViolations() []*PolicyViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *MergePatchResponse) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *MergePatchResponse) GetQueueEntryId() string {
+	if x != nil {
+		return x.QueueEntryId
+	}
+	return ""
+}
+
+func (x *MergePatchResponse) GetFailure() *FailureInfo {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+func (x *MergePatchResponse) GetStats() *ChangeStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// How a change affected one file
+type FileStat struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`            // Directory path
-	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`        // Branch name (default: main)
-	Recursive     bool                   `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"` // Whether to list recursively
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`                      // Path in the new version, or the deleted path
+	OldPath       string                 `protobuf:"bytes,2,opt,name=old_path,json=oldPath,proto3" json:"old_path,omitempty"` // Source of a rename
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                  // "added", "deleted", "modified" or "renamed"
+	Insertions    int32                  `protobuf:"varint,4,opt,name=insertions,proto3" json:"insertions,omitempty"`
+	Deletions     int32                  `protobuf:"varint,5,opt,name=deletions,proto3" json:"deletions,omitempty"`
+	Binary        bool                   `protobuf:"varint,6,opt,name=binary,proto3" json:"binary,omitempty"` // Lines are not counted for binary files
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReadDirectoryRequest) Reset() {
-	*x = ReadDirectoryRequest{}
+func (x *FileStat) Reset() {
+	*x = FileStat{}
 	mi := &file_monorepo_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReadDirectoryRequest) String() string {
+func (x *FileStat) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReadDirectoryRequest) ProtoMessage() {}
+func (*FileStat) ProtoMessage() {}
 
-func (x *ReadDirectoryRequest) ProtoReflect() protoreflect.Message {
+func (x *FileStat) ProtoReflect() protoreflect.Message {
 	mi := &file_monorepo_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -254,54 +414,78 @@ func (x *ReadDirectoryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadDirectoryRequest.ProtoReflect.Descriptor instead.
-func (*ReadDirectoryRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use FileStat.ProtoReflect.Descriptor instead.
+func (*FileStat) Descriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *ReadDirectoryRequest) GetPath() string {
+func (x *FileStat) GetPath() string {
 	if x != nil {
 		return x.Path
 	}
 	return ""
 }
 
-func (x *ReadDirectoryRequest) GetBranch() string {
+func (x *FileStat) GetOldPath() string {
 	if x != nil {
-		return x.Branch
+		return x.OldPath
 	}
 	return ""
 }
 
-func (x *ReadDirectoryRequest) GetRecursive() bool {
+func (x *FileStat) GetStatus() string {
 	if x != nil {
-		return x.Recursive
+		return x.Status
+	}
+	return ""
+}
+
+func (x *FileStat) GetInsertions() int32 {
+	if x != nil {
+		return x.Insertions
+	}
+	return 0
+}
+
+func (x *FileStat) GetDeletions() int32 {
+	if x != nil {
+		return x.Deletions
+	}
+	return 0
+}
+
+func (x *FileStat) GetBinary() bool {
+	if x != nil {
+		return x.Binary
 	}
 	return false
 }
 
-// Response containing directory contents
-type ReadDirectoryResponse struct {
+// Files and lines a change touched, like git diff --stat
+type ChangeStats struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*DirectoryItem       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	FilesChanged  int32                  `protobuf:"varint,1,opt,name=files_changed,json=filesChanged,proto3" json:"files_changed,omitempty"`
+	Insertions    int32                  `protobuf:"varint,2,opt,name=insertions,proto3" json:"insertions,omitempty"`
+	Deletions     int32                  `protobuf:"varint,3,opt,name=deletions,proto3" json:"deletions,omitempty"`
+	Files         []*FileStat            `protobuf:"bytes,4,rep,name=files,proto3" json:"files,omitempty"` // Sorted by path
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReadDirectoryResponse) Reset() {
-	*x = ReadDirectoryResponse{}
+func (x *ChangeStats) Reset() {
+	*x = ChangeStats{}
 	mi := &file_monorepo_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReadDirectoryResponse) String() string {
+func (x *ChangeStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReadDirectoryResponse) ProtoMessage() {}
+func (*ChangeStats) ProtoMessage() {}
 
-func (x *ReadDirectoryResponse) ProtoReflect() protoreflect.Message {
+func (x *ChangeStats) ProtoReflect() protoreflect.Message {
 	mi := &file_monorepo_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -313,44 +497,68 @@ func (x *ReadDirectoryResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadDirectoryResponse.ProtoReflect.Descriptor instead.
-func (*ReadDirectoryResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ChangeStats.ProtoReflect.Descriptor instead.
+func (*ChangeStats) Descriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ReadDirectoryResponse) GetItems() []*DirectoryItem {
+func (x *ChangeStats) GetFilesChanged() int32 {
 	if x != nil {
-		return x.Items
+		return x.FilesChanged
+	}
+	return 0
+}
+
+func (x *ChangeStats) GetInsertions() int32 {
+	if x != nil {
+		return x.Insertions
+	}
+	return 0
+}
+
+func (x *ChangeStats) GetDeletions() int32 {
+	if x != nil {
+		return x.Deletions
+	}
+	return 0
+}
+
+func (x *ChangeStats) GetFiles() []*FileStat {
+	if x != nil {
+		return x.Files
 	}
 	return nil
 }
 
-// A single directory item
-type DirectoryItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	IsDir         bool                   `protobuf:"varint,2,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
-	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
-	ModTime       int64                  `protobuf:"varint,4,opt,name=mod_time,json=modTime,proto3" json:"mod_time,omitempty"` // Unix timestamp
-	Hash          string                 `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`                       // Git object hash
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Request to preview a patch; the same checks as MergePatch apply
+type PreviewPatchRequest struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Path                    string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`       // Target path in the monorepo
+	Patch                   []byte                 `protobuf:"bytes,2,opt,name=patch,proto3" json:"patch,omitempty"`     // The patch content (unified diff format)
+	Message                 string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Commit message the patch would be merged with
+	Author                  string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`   // Author information
+	Branch                  string                 `protobuf:"bytes,5,opt,name=branch,proto3" json:"branch,omitempty"`   // Target branch (default: main)
+	IgnoreWhitespace        bool                   `protobuf:"varint,6,opt,name=ignore_whitespace,json=ignoreWhitespace,proto3" json:"ignore_whitespace,omitempty"`
+	NormalizeLineEndings    bool                   `protobuf:"varint,7,opt,name=normalize_line_endings,json=normalizeLineEndings,proto3" json:"normalize_line_endings,omitempty"`
+	PreserveTrailingNewline bool                   `protobuf:"varint,8,opt,name=preserve_trailing_newline,json=preserveTrailingNewline,proto3" json:"preserve_trailing_newline,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
 }
 
-func (x *DirectoryItem) Reset() {
-	*x = DirectoryItem{}
+func (x *PreviewPatchRequest) Reset() {
+	*x = PreviewPatchRequest{}
 	mi := &file_monorepo_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DirectoryItem) String() string {
+func (x *PreviewPatchRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DirectoryItem) ProtoMessage() {}
+func (*PreviewPatchRequest) ProtoMessage() {}
 
-func (x *DirectoryItem) ProtoReflect() protoreflect.Message {
+func (x *PreviewPatchRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_monorepo_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -362,70 +570,99 @@ func (x *DirectoryItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DirectoryItem.ProtoReflect.Descriptor instead.
-func (*DirectoryItem) Descriptor() ([]byte, []int) {
+// Deprecated: Use PreviewPatchRequest.ProtoReflect.Descriptor instead.
+func (*PreviewPatchRequest) Descriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *DirectoryItem) GetName() string {
+func (x *PreviewPatchRequest) GetPath() string {
 	if x != nil {
-		return x.Name
+		return x.Path
 	}
 	return ""
 }
 
-func (x *DirectoryItem) GetIsDir() bool {
+func (x *PreviewPatchRequest) GetPatch() []byte {
 	if x != nil {
-		return x.IsDir
+		return x.Patch
 	}
-	return false
+	return nil
 }
 
-func (x *DirectoryItem) GetSize() int64 {
+func (x *PreviewPatchRequest) GetMessage() string {
 	if x != nil {
-		return x.Size
+		return x.Message
 	}
-	return 0
+	return ""
 }
 
-func (x *DirectoryItem) GetModTime() int64 {
+func (x *PreviewPatchRequest) GetAuthor() string {
 	if x != nil {
-		return x.ModTime
+		return x.Author
 	}
-	return 0
+	return ""
 }
 
-func (x *DirectoryItem) GetHash() string {
+func (x *PreviewPatchRequest) GetBranch() string {
 	if x != nil {
-		return x.Hash
+		return x.Branch
 	}
 	return ""
 }
 
-// Request to read a file
-type ReadFileRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`         // File path
-	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`     // Branch name (default: main)
-	Revision      string                 `protobuf:"bytes,3,opt,name=revision,proto3" json:"revision,omitempty"` // Specific revision/commit hash
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *PreviewPatchRequest) GetIgnoreWhitespace() bool {
+	if x != nil {
+		return x.IgnoreWhitespace
+	}
+	return false
 }
 
-func (x *ReadFileRequest) Reset() {
-	*x = ReadFileRequest{}
+func (x *PreviewPatchRequest) GetNormalizeLineEndings() bool {
+	if x != nil {
+		return x.NormalizeLineEndings
+	}
+	return false
+}
+
+func (x *PreviewPatchRequest) GetPreserveTrailingNewline() bool {
+	if x != nil {
+		return x.PreserveTrailingNewline
+	}
+	return false
+}
+
+// Result of applying a patch in memory
+type PreviewPatchResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message         string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	FilePath        string                 `protobuf:"bytes,3,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`                      // File the patch applies to
+	BaseVersion     int64                  `protobuf:"varint,4,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`            // Version the patch was applied to
+	NewFile         bool                   `protobuf:"varint,5,opt,name=new_file,json=newFile,proto3" json:"new_file,omitempty"`                        // Whether the patch creates the file
+	OriginalContent []byte                 `protobuf:"bytes,6,opt,name=original_content,json=originalContent,proto3" json:"original_content,omitempty"` // File content before the patch
+	Content         []byte                 `protobuf:"bytes,7,opt,name=content,proto3" json:"content,omitempty"`                                        // File content after the patch
+	Conflicts       []string               `protobuf:"bytes,8,rep,name=conflicts,proto3" json:"conflicts,omitempty"`
+	Violations      []*PolicyViolation     `protobuf:"bytes,9,rep,name=violations,proto3" json:"violations,omitempty"`
+	DeletedFile     bool                   `protobuf:"varint,10,opt,name=deleted_file,json=deletedFile,proto3" json:"deleted_file,omitempty"` // Whether the patch deletes the file
+	Failure         *FailureInfo           `protobuf:"bytes,11,opt,name=failure,proto3" json:"failure,omitempty"`                             // Why the patch would not be merged; unset on success
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PreviewPatchResponse) Reset() {
+	*x = PreviewPatchResponse{}
 	mi := &file_monorepo_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReadFileRequest) String() string {
+func (x *PreviewPatchResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReadFileRequest) ProtoMessage() {}
+func (*PreviewPatchResponse) ProtoMessage() {}
 
-func (x *ReadFileRequest) ProtoReflect() protoreflect.Message {
+func (x *PreviewPatchResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_monorepo_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -437,59 +674,115 @@ func (x *ReadFileRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadFileRequest.ProtoReflect.Descriptor instead.
-func (*ReadFileRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use PreviewPatchResponse.ProtoReflect.Descriptor instead.
+func (*PreviewPatchResponse) Descriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *ReadFileRequest) GetPath() string {
+func (x *PreviewPatchResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Path
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *ReadFileRequest) GetBranch() string {
+func (x *PreviewPatchResponse) GetMessage() string {
 	if x != nil {
-		return x.Branch
+		return x.Message
 	}
 	return ""
 }
 
-func (x *ReadFileRequest) GetRevision() string {
+func (x *PreviewPatchResponse) GetFilePath() string {
 	if x != nil {
-		return x.Revision
+		return x.FilePath
 	}
 	return ""
 }
 
-// Response containing file contents
-type ReadFileResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
-	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // Git object hash
-	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *PreviewPatchResponse) GetBaseVersion() int64 {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return 0
 }
 
-func (x *ReadFileResponse) Reset() {
-	*x = ReadFileResponse{}
-	mi := &file_monorepo_proto_msgTypes[6]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *PreviewPatchResponse) GetNewFile() bool {
+	if x != nil {
+		return x.NewFile
+	}
+	return false
 }
 
-func (x *ReadFileResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *PreviewPatchResponse) GetOriginalContent() []byte {
+	if x != nil {
+		return x.OriginalContent
+	}
+	return nil
 }
 
-func (*ReadFileResponse) ProtoMessage() {}
+func (x *PreviewPatchResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
 
-func (x *ReadFileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[6]
+func (x *PreviewPatchResponse) GetConflicts() []string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		return x.Conflicts
+	}
+	return nil
+}
+
+func (x *PreviewPatchResponse) GetViolations() []*PolicyViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *PreviewPatchResponse) GetDeletedFile() bool {
+	if x != nil {
+		return x.DeletedFile
+	}
+	return false
+}
+
+func (x *PreviewPatchResponse) GetFailure() *FailureInfo {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+// A server-side validation rule that rejected a change
+type PolicyViolation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`               // Rule that failed (e.g. "max_file_size")
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`               // File the violation applies to, if any
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"` // Human readable explanation
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PolicyViolation) Reset() {
+	*x = PolicyViolation{}
+	mi := &file_monorepo_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicyViolation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyViolation) ProtoMessage() {}
+
+func (x *PolicyViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
 		}
@@ -498,56 +791,57 @@ func (x *ReadFileResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadFileResponse.ProtoReflect.Descriptor instead.
-func (*ReadFileResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use PolicyViolation.ProtoReflect.Descriptor instead.
+func (*PolicyViolation) Descriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ReadFileResponse) GetContent() []byte {
+func (x *PolicyViolation) GetRule() string {
 	if x != nil {
-		return x.Content
+		return x.Rule
 	}
-	return nil
+	return ""
 }
 
-func (x *ReadFileResponse) GetHash() string {
+func (x *PolicyViolation) GetPath() string {
 	if x != nil {
-		return x.Hash
+		return x.Path
 	}
 	return ""
 }
 
-func (x *ReadFileResponse) GetSize() int64 {
+func (x *PolicyViolation) GetDescription() string {
 	if x != nil {
-		return x.Size
+		return x.Description
 	}
-	return 0
+	return ""
 }
 
-// Request for file history
-type FileHistoryRequest struct {
+// Why a request that reports failure in its response failed. Errors returned
+// as gRPC statuses carry the same reason and metadata in a
+// google.rpc.ErrorInfo detail; clients act on the reason, never the message.
+type FailureInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`     // File path
-	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"` // Branch name (default: main)
-	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`  // Maximum number of commits to return
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`                                                                               // e.g. "PATCH_CONFLICT", "PATH_LOCKED", "POLICY_VIOLATION"
+	Metadata      map[string]string      `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // e.g. path, file_line, expected, actual, base_version
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FileHistoryRequest) Reset() {
-	*x = FileHistoryRequest{}
+func (x *FailureInfo) Reset() {
+	*x = FailureInfo{}
 	mi := &file_monorepo_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FileHistoryRequest) String() string {
+func (x *FailureInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FileHistoryRequest) ProtoMessage() {}
+func (*FailureInfo) ProtoMessage() {}
 
-func (x *FileHistoryRequest) ProtoReflect() protoreflect.Message {
+func (x *FailureInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_monorepo_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -559,55 +853,138 @@ func (x *FileHistoryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FileHistoryRequest.ProtoReflect.Descriptor instead.
-func (*FileHistoryRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use FailureInfo.ProtoReflect.Descriptor instead.
+func (*FailureInfo) Descriptor() ([]byte, []int) {
 	return file_monorepo_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *FileHistoryRequest) GetPath() string {
+func (x *FailureInfo) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *FailureInfo) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Request to read a directory
+type ReadDirectoryRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Path        string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`                                   // Directory path
+	Branch      string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`                               // Branch name (default: main)
+	Recursive   bool                   `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"`                        // Whether to list recursively
+	WithHistory bool                   `protobuf:"varint,4,opt,name=with_history,json=withHistory,proto3" json:"with_history,omitempty"` // Fill in the last change of each entry
+	// Tree hash from an earlier response; when the directory still has it, the
+	// response is not_modified and carries no items. Ignored with with_history,
+	// since the last changes can move while the tree stays the same.
+	IfNotHash     string `protobuf:"bytes,5,opt,name=if_not_hash,json=ifNotHash,proto3" json:"if_not_hash,omitempty"`
+	Version       int64  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadDirectoryRequest) Reset() {
+	*x = ReadDirectoryRequest{}
+	mi := &file_monorepo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadDirectoryRequest) ProtoMessage() {}
+
+func (x *ReadDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*ReadDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReadDirectoryRequest) GetPath() string {
 	if x != nil {
 		return x.Path
 	}
 	return ""
 }
 
-func (x *FileHistoryRequest) GetBranch() string {
+func (x *ReadDirectoryRequest) GetBranch() string {
 	if x != nil {
 		return x.Branch
 	}
 	return ""
 }
 
-func (x *FileHistoryRequest) GetLimit() int32 {
+func (x *ReadDirectoryRequest) GetRecursive() bool {
 	if x != nil {
-		return x.Limit
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *ReadDirectoryRequest) GetWithHistory() bool {
+	if x != nil {
+		return x.WithHistory
+	}
+	return false
+}
+
+func (x *ReadDirectoryRequest) GetIfNotHash() string {
+	if x != nil {
+		return x.IfNotHash
+	}
+	return ""
+}
+
+func (x *ReadDirectoryRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
 	}
 	return 0
 }
 
-// Response containing file history
-type FileHistoryResponse struct {
+// Response containing directory contents
+type ReadDirectoryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Commits       []*Commit              `protobuf:"bytes,1,rep,name=commits,proto3" json:"commits,omitempty"`
+	Items         []*DirectoryItem       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`                                   // Tree hash of the directory
+	NotModified   bool                   `protobuf:"varint,3,opt,name=not_modified,json=notModified,proto3" json:"not_modified,omitempty"` // The directory still has if_not_hash; items is empty
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FileHistoryResponse) Reset() {
-	*x = FileHistoryResponse{}
-	mi := &file_monorepo_proto_msgTypes[8]
+func (x *ReadDirectoryResponse) Reset() {
+	*x = ReadDirectoryResponse{}
+	mi := &file_monorepo_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FileHistoryResponse) String() string {
+func (x *ReadDirectoryResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FileHistoryResponse) ProtoMessage() {}
+func (*ReadDirectoryResponse) ProtoMessage() {}
 
-func (x *FileHistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[8]
+func (x *ReadDirectoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -618,45 +995,66 @@ func (x *FileHistoryResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FileHistoryResponse.ProtoReflect.Descriptor instead.
-func (*FileHistoryResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use ReadDirectoryResponse.ProtoReflect.Descriptor instead.
+func (*ReadDirectoryResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *FileHistoryResponse) GetCommits() []*Commit {
+func (x *ReadDirectoryResponse) GetItems() []*DirectoryItem {
 	if x != nil {
-		return x.Commits
+		return x.Items
 	}
 	return nil
 }
 
-// A git commit
-type Commit struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
-	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	ChangedFiles  []string               `protobuf:"bytes,5,rep,name=changed_files,json=changedFiles,proto3" json:"changed_files,omitempty"`
+func (x *ReadDirectoryResponse) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *ReadDirectoryResponse) GetNotModified() bool {
+	if x != nil {
+		return x.NotModified
+	}
+	return false
+}
+
+// A single directory item
+type DirectoryItem struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Name    string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	IsDir   bool                   `protobuf:"varint,2,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Size    int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	ModTime int64                  `protobuf:"varint,4,opt,name=mod_time,json=modTime,proto3" json:"mod_time,omitempty"` // Unix timestamp
+	Hash    string                 `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`                       // Git object hash
+	Mode    int32                  `protobuf:"varint,6,opt,name=mode,proto3" json:"mode,omitempty"`                      // File permissions
+	// Newest commit that changed the entry, set when with_history is requested
+	LastVersion   int64  `protobuf:"varint,7,opt,name=last_version,json=lastVersion,proto3" json:"last_version,omitempty"`
+	LastCommit    string `protobuf:"bytes,8,opt,name=last_commit,json=lastCommit,proto3" json:"last_commit,omitempty"`
+	LastAuthor    string `protobuf:"bytes,9,opt,name=last_author,json=lastAuthor,proto3" json:"last_author,omitempty"`
+	LastMessage   string `protobuf:"bytes,10,opt,name=last_message,json=lastMessage,proto3" json:"last_message,omitempty"`
+	LastTimestamp int64  `protobuf:"varint,11,opt,name=last_timestamp,json=lastTimestamp,proto3" json:"last_timestamp,omitempty"` // Unix timestamp
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Commit) Reset() {
-	*x = Commit{}
-	mi := &file_monorepo_proto_msgTypes[9]
+func (x *DirectoryItem) Reset() {
+	*x = DirectoryItem{}
+	mi := &file_monorepo_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Commit) String() string {
+func (x *DirectoryItem) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Commit) ProtoMessage() {}
+func (*DirectoryItem) ProtoMessage() {}
 
-func (x *Commit) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[9]
+func (x *DirectoryItem) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -667,68 +1065,9443 @@ func (x *Commit) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Commit.ProtoReflect.Descriptor instead.
-func (*Commit) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use DirectoryItem.ProtoReflect.Descriptor instead.
+func (*DirectoryItem) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *Commit) GetHash() string {
+func (x *DirectoryItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DirectoryItem) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *DirectoryItem) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DirectoryItem) GetModTime() int64 {
+	if x != nil {
+		return x.ModTime
+	}
+	return 0
+}
+
+func (x *DirectoryItem) GetHash() string {
 	if x != nil {
 		return x.Hash
 	}
 	return ""
 }
 
-func (x *Commit) GetAuthor() string {
+func (x *DirectoryItem) GetMode() int32 {
 	if x != nil {
-		return x.Author
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *DirectoryItem) GetLastVersion() int64 {
+	if x != nil {
+		return x.LastVersion
+	}
+	return 0
+}
+
+func (x *DirectoryItem) GetLastCommit() string {
+	if x != nil {
+		return x.LastCommit
 	}
 	return ""
 }
 
-func (x *Commit) GetMessage() string {
+func (x *DirectoryItem) GetLastAuthor() string {
 	if x != nil {
-		return x.Message
+		return x.LastAuthor
 	}
 	return ""
 }
 
-func (x *Commit) GetTimestamp() int64 {
+func (x *DirectoryItem) GetLastMessage() string {
+	if x != nil {
+		return x.LastMessage
+	}
+	return ""
+}
+
+func (x *DirectoryItem) GetLastTimestamp() int64 {
+	if x != nil {
+		return x.LastTimestamp
+	}
+	return 0
+}
+
+// Request for the content hashes of paths at one version
+type GetTreeHashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`      // "" or "." for the repository root
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTreeHashRequest) Reset() {
+	*x = GetTreeHashRequest{}
+	mi := &file_monorepo_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTreeHashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTreeHashRequest) ProtoMessage() {}
+
+func (x *GetTreeHashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTreeHashRequest.ProtoReflect.Descriptor instead.
+func (*GetTreeHashRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetTreeHashRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *GetTreeHashRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Content hashes of the requested paths, in request order
+type GetTreeHashResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Hashes        []*TreeHash            `protobuf:"bytes,2,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTreeHashResponse) Reset() {
+	*x = GetTreeHashResponse{}
+	mi := &file_monorepo_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTreeHashResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTreeHashResponse) ProtoMessage() {}
+
+func (x *GetTreeHashResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTreeHashResponse.ProtoReflect.Descriptor instead.
+func (*GetTreeHashResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetTreeHashResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetTreeHashResponse) GetHashes() []*TreeHash {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+// The content hash of one path
+type TreeHash struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // Tree hash for a directory, blob hash for a file; empty when missing
+	IsDir         bool                   `protobuf:"varint,3,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Exists        bool                   `protobuf:"varint,4,opt,name=exists,proto3" json:"exists,omitempty"` // False when the path does not exist at the version
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeHash) Reset() {
+	*x = TreeHash{}
+	mi := &file_monorepo_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeHash) ProtoMessage() {}
+
+func (x *TreeHash) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeHash.ProtoReflect.Descriptor instead.
+func (*TreeHash) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TreeHash) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TreeHash) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *TreeHash) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *TreeHash) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+// Request for a summary of a path
+type GetPathInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`     // File or directory path
+	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"` // Branch name (default: main)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPathInfoRequest) Reset() {
+	*x = GetPathInfoRequest{}
+	mi := &file_monorepo_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPathInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPathInfoRequest) ProtoMessage() {}
+
+func (x *GetPathInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPathInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetPathInfoRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetPathInfoRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetPathInfoRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+// Summary of a file or directory
+type GetPathInfoResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Path              string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	IsDir             bool                   `protobuf:"varint,2,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Version           int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`                         // Version the summary was computed at
+	Files             int32                  `protobuf:"varint,4,opt,name=files,proto3" json:"files,omitempty"`                             // Files directly in the directory
+	Directories       int32                  `protobuf:"varint,5,opt,name=directories,proto3" json:"directories,omitempty"`                 // Directories directly in the directory
+	TotalFiles        int64                  `protobuf:"varint,6,opt,name=total_files,json=totalFiles,proto3" json:"total_files,omitempty"` // Files in the whole subtree
+	TotalSize         int64                  `protobuf:"varint,7,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`    // Bytes in the whole subtree, or the file size
+	LastChange        *Commit                `protobuf:"bytes,8,opt,name=last_change,json=lastChange,proto3" json:"last_change,omitempty"`  // Newest commit that changed the path
+	LastChangeVersion int64                  `protobuf:"varint,9,opt,name=last_change_version,json=lastChangeVersion,proto3" json:"last_change_version,omitempty"`
+	ReadmePath        string                 `protobuf:"bytes,10,opt,name=readme_path,json=readmePath,proto3" json:"readme_path,omitempty"` // README found in the directory, if any
+	ReadmeContent     []byte                 `protobuf:"bytes,11,opt,name=readme_content,json=readmeContent,proto3" json:"readme_content,omitempty"`
+	ReadmeTruncated   bool                   `protobuf:"varint,12,opt,name=readme_truncated,json=readmeTruncated,proto3" json:"readme_truncated,omitempty"`                                         // README is longer than the returned content
+	Owners            []string               `protobuf:"bytes,13,rep,name=owners,proto3" json:"owners,omitempty"`                                                                                   // Owners from the nearest OWNERS file
+	OwnersPath        string                 `protobuf:"bytes,14,opt,name=owners_path,json=ownersPath,proto3" json:"owners_path,omitempty"`                                                         // OWNERS file the owners come from
+	Attributes        map[string]string      `protobuf:"bytes,15,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // File attributes from .poonattributes
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetPathInfoResponse) Reset() {
+	*x = GetPathInfoResponse{}
+	mi := &file_monorepo_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPathInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPathInfoResponse) ProtoMessage() {}
+
+func (x *GetPathInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPathInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetPathInfoResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetPathInfoResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetPathInfoResponse) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *GetPathInfoResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetPathInfoResponse) GetFiles() int32 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *GetPathInfoResponse) GetDirectories() int32 {
+	if x != nil {
+		return x.Directories
+	}
+	return 0
+}
+
+func (x *GetPathInfoResponse) GetTotalFiles() int64 {
+	if x != nil {
+		return x.TotalFiles
+	}
+	return 0
+}
+
+func (x *GetPathInfoResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *GetPathInfoResponse) GetLastChange() *Commit {
+	if x != nil {
+		return x.LastChange
+	}
+	return nil
+}
+
+func (x *GetPathInfoResponse) GetLastChangeVersion() int64 {
+	if x != nil {
+		return x.LastChangeVersion
+	}
+	return 0
+}
+
+func (x *GetPathInfoResponse) GetReadmePath() string {
+	if x != nil {
+		return x.ReadmePath
+	}
+	return ""
+}
+
+func (x *GetPathInfoResponse) GetReadmeContent() []byte {
+	if x != nil {
+		return x.ReadmeContent
+	}
+	return nil
+}
+
+func (x *GetPathInfoResponse) GetReadmeTruncated() bool {
+	if x != nil {
+		return x.ReadmeTruncated
+	}
+	return false
+}
+
+func (x *GetPathInfoResponse) GetOwners() []string {
+	if x != nil {
+		return x.Owners
+	}
+	return nil
+}
+
+func (x *GetPathInfoResponse) GetOwnersPath() string {
+	if x != nil {
+		return x.OwnersPath
+	}
+	return ""
+}
+
+func (x *GetPathInfoResponse) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+// Request to list paths that differ only in case
+type ListCaseCollisionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`     // Directory to check, including subdirectories (default: root)
+	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"` // Branch name (default: main)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCaseCollisionsRequest) Reset() {
+	*x = ListCaseCollisionsRequest{}
+	mi := &file_monorepo_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCaseCollisionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCaseCollisionsRequest) ProtoMessage() {}
+
+func (x *ListCaseCollisionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCaseCollisionsRequest.ProtoReflect.Descriptor instead.
+func (*ListCaseCollisionsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListCaseCollisionsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ListCaseCollisionsRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+// Paths that a case-insensitive file system would treat as one
+type CaseCollision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CaseCollision) Reset() {
+	*x = CaseCollision{}
+	mi := &file_monorepo_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CaseCollision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaseCollision) ProtoMessage() {}
+
+func (x *CaseCollision) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaseCollision.ProtoReflect.Descriptor instead.
+func (*CaseCollision) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CaseCollision) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type ListCaseCollisionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Collisions    []*CaseCollision       `protobuf:"bytes,1,rep,name=collisions,proto3" json:"collisions,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version that was checked
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCaseCollisionsResponse) Reset() {
+	*x = ListCaseCollisionsResponse{}
+	mi := &file_monorepo_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCaseCollisionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCaseCollisionsResponse) ProtoMessage() {}
+
+func (x *ListCaseCollisionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCaseCollisionsResponse.ProtoReflect.Descriptor instead.
+func (*ListCaseCollisionsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListCaseCollisionsResponse) GetCollisions() []*CaseCollision {
+	if x != nil {
+		return x.Collisions
+	}
+	return nil
+}
+
+func (x *ListCaseCollisionsResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetAffectedPathsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromVersion   int64                  `protobuf:"varint,1,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"` // Changes after this version; 0 compares against an empty tree
+	ToVersion     int64                  `protobuf:"varint,2,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`       // Last version to include (default: current)
+	Depth         int32                  `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`                                // Path components kept from each changed file (default: 1, top-level directories)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAffectedPathsRequest) Reset() {
+	*x = GetAffectedPathsRequest{}
+	mi := &file_monorepo_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAffectedPathsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAffectedPathsRequest) ProtoMessage() {}
+
+func (x *GetAffectedPathsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAffectedPathsRequest.ProtoReflect.Descriptor instead.
+func (*GetAffectedPathsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetAffectedPathsRequest) GetFromVersion() int64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+func (x *GetAffectedPathsRequest) GetToVersion() int64 {
+	if x != nil {
+		return x.ToVersion
+	}
+	return 0
+}
+
+func (x *GetAffectedPathsRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+// A path prefix containing changed files. Files shallower than the requested
+// depth are reported by their full path.
+type AffectedPath struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	FilesChanged  int32                  `protobuf:"varint,2,opt,name=files_changed,json=filesChanged,proto3" json:"files_changed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AffectedPath) Reset() {
+	*x = AffectedPath{}
+	mi := &file_monorepo_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AffectedPath) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AffectedPath) ProtoMessage() {}
+
+func (x *AffectedPath) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AffectedPath.ProtoReflect.Descriptor instead.
+func (*AffectedPath) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AffectedPath) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *AffectedPath) GetFilesChanged() int32 {
+	if x != nil {
+		return x.FilesChanged
+	}
+	return 0
+}
+
+type GetAffectedPathsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []*AffectedPath        `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"` // Sorted by path
+	FromVersion   int64                  `protobuf:"varint,2,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	ToVersion     int64                  `protobuf:"varint,3,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`          // Resolved version, when the request left it unset
+	FilesChanged  int32                  `protobuf:"varint,4,opt,name=files_changed,json=filesChanged,proto3" json:"files_changed,omitempty"` // Changed files across all prefixes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAffectedPathsResponse) Reset() {
+	*x = GetAffectedPathsResponse{}
+	mi := &file_monorepo_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAffectedPathsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAffectedPathsResponse) ProtoMessage() {}
+
+func (x *GetAffectedPathsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAffectedPathsResponse.ProtoReflect.Descriptor instead.
+func (*GetAffectedPathsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetAffectedPathsResponse) GetPaths() []*AffectedPath {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *GetAffectedPathsResponse) GetFromVersion() int64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+func (x *GetAffectedPathsResponse) GetToVersion() int64 {
+	if x != nil {
+		return x.ToVersion
+	}
+	return 0
+}
+
+func (x *GetAffectedPathsResponse) GetFilesChanged() int32 {
+	if x != nil {
+		return x.FilesChanged
+	}
+	return 0
+}
+
+// Request to read a file
+type ReadFileRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Path     string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`         // File path
+	Branch   string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`     // Branch name (default: main)
+	Revision string                 `protobuf:"bytes,3,opt,name=revision,proto3" json:"revision,omitempty"` // Specific revision/commit hash
+	// Blob hash from an earlier response; when the file still has it, the
+	// response is not_modified and carries no content
+	IfNotHash string `protobuf:"bytes,4,opt,name=if_not_hash,json=ifNotHash,proto3" json:"if_not_hash,omitempty"`
+	Version   int64  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	// Range to read: length bytes from offset, or the rest of the file for
+	// length 0. A range past the end of the file is empty.
+	Offset        int64 `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        int64 `protobuf:"varint,7,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadFileRequest) Reset() {
+	*x = ReadFileRequest{}
+	mi := &file_monorepo_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFileRequest) ProtoMessage() {}
+
+func (x *ReadFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFileRequest.ProtoReflect.Descriptor instead.
+func (*ReadFileRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ReadFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ReadFileRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *ReadFileRequest) GetRevision() string {
+	if x != nil {
+		return x.Revision
+	}
+	return ""
+}
+
+func (x *ReadFileRequest) GetIfNotHash() string {
+	if x != nil {
+		return x.IfNotHash
+	}
+	return ""
+}
+
+func (x *ReadFileRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReadFileRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReadFileRequest) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// Response containing file contents
+type ReadFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`                                   // Git object hash
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`                                  // Size of the whole file
+	NotModified   bool                   `protobuf:"varint,4,opt,name=not_modified,json=notModified,proto3" json:"not_modified,omitempty"` // The file still has if_not_hash; content is empty
+	Offset        int64                  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`                              // Offset of content within the file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadFileResponse) Reset() {
+	*x = ReadFileResponse{}
+	mi := &file_monorepo_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFileResponse) ProtoMessage() {}
+
+func (x *ReadFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFileResponse.ProtoReflect.Descriptor instead.
+func (*ReadFileResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ReadFileResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *ReadFileResponse) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *ReadFileResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ReadFileResponse) GetNotModified() bool {
+	if x != nil {
+		return x.NotModified
+	}
+	return false
+}
+
+func (x *ReadFileResponse) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// Request to read a batch of files at a fixed version
+type ReadFilesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`                                    // Version to read, 0 for the current one
+	MaxTotalBytes int64                  `protobuf:"varint,3,opt,name=max_total_bytes,json=maxTotalBytes,proto3" json:"max_total_bytes,omitempty"` // Cap on returned content, 0 or above the server's cap for the server's
+	// Blob hashes from earlier responses by path; a file that still has its
+	// hash is not_modified and carries no content
+	IfNotHash     map[string]string `protobuf:"bytes,4,rep,name=if_not_hash,json=ifNotHash,proto3" json:"if_not_hash,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadFilesRequest) Reset() {
+	*x = ReadFilesRequest{}
+	mi := &file_monorepo_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadFilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFilesRequest) ProtoMessage() {}
+
+func (x *ReadFilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFilesRequest.ProtoReflect.Descriptor instead.
+func (*ReadFilesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ReadFilesRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *ReadFilesRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReadFilesRequest) GetMaxTotalBytes() int64 {
+	if x != nil {
+		return x.MaxTotalBytes
+	}
+	return 0
+}
+
+func (x *ReadFilesRequest) GetIfNotHash() map[string]string {
+	if x != nil {
+		return x.IfNotHash
+	}
+	return nil
+}
+
+// Files read by ReadFiles, one result per requested path in request order
+type ReadFilesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Files         []*FileResult          `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty"`
+	Truncated     bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"` // Some files were omitted to stay under the size cap
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadFilesResponse) Reset() {
+	*x = ReadFilesResponse{}
+	mi := &file_monorepo_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadFilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFilesResponse) ProtoMessage() {}
+
+func (x *ReadFilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFilesResponse.ProtoReflect.Descriptor instead.
+func (*ReadFilesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ReadFilesResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReadFilesResponse) GetFiles() []*FileResult {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *ReadFilesResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// The outcome of reading one path in a batch
+type FileResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`                                 // Why the file could not be read; empty on success
+	Failure       *FailureInfo           `protobuf:"bytes,6,opt,name=failure,proto3" json:"failure,omitempty"`                             // Reason and metadata for error
+	Omitted       bool                   `protobuf:"varint,7,opt,name=omitted,proto3" json:"omitted,omitempty"`                            // Not read because the batch reached its size cap; request it again
+	NotModified   bool                   `protobuf:"varint,8,opt,name=not_modified,json=notModified,proto3" json:"not_modified,omitempty"` // The file still has its if_not_hash; content is empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileResult) Reset() {
+	*x = FileResult{}
+	mi := &file_monorepo_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileResult) ProtoMessage() {}
+
+func (x *FileResult) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileResult.ProtoReflect.Descriptor instead.
+func (*FileResult) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *FileResult) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileResult) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *FileResult) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *FileResult) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *FileResult) GetFailure() *FailureInfo {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+func (x *FileResult) GetOmitted() bool {
+	if x != nil {
+		return x.Omitted
+	}
+	return false
+}
+
+func (x *FileResult) GetNotModified() bool {
+	if x != nil {
+		return x.NotModified
+	}
+	return false
+}
+
+// Request to list a directory at a fixed version
+type StreamDirectoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamDirectoryRequest) Reset() {
+	*x = StreamDirectoryRequest{}
+	mi := &file_monorepo_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDirectoryRequest) ProtoMessage() {}
+
+func (x *StreamDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*StreamDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *StreamDirectoryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StreamDirectoryRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// One batch of directory entries; every batch names the version it was read from
+type StreamDirectoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Items         []*DirectoryItem       `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamDirectoryResponse) Reset() {
+	*x = StreamDirectoryResponse{}
+	mi := &file_monorepo_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamDirectoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDirectoryResponse) ProtoMessage() {}
+
+func (x *StreamDirectoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDirectoryResponse.ProtoReflect.Descriptor instead.
+func (*StreamDirectoryResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *StreamDirectoryResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *StreamDirectoryResponse) GetItems() []*DirectoryItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// Request to read part of a file at a fixed version
+type StreamFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        int64                  `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"` // Bytes to read, 0 for the rest of the file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamFileRequest) Reset() {
+	*x = StreamFileRequest{}
+	mi := &file_monorepo_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamFileRequest) ProtoMessage() {}
+
+func (x *StreamFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamFileRequest.ProtoReflect.Descriptor instead.
+func (*StreamFileRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *StreamFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StreamFileRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *StreamFileRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *StreamFileRequest) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// A piece of a file returned by StreamFile
+type FileChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // Offset of data within the file
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"` // Size of the whole file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_monorepo_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *FileChunk) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FileChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *FileChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *FileChunk) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// Request to preview lines of a file
+type PreviewFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`                   // Version to read, 0 for the current one
+	FromLine      int32                  `protobuf:"varint,3,opt,name=from_line,json=fromLine,proto3" json:"from_line,omitempty"` // First line to return, from 1; 0 for the first
+	ToLine        int32                  `protobuf:"varint,4,opt,name=to_line,json=toLine,proto3" json:"to_line,omitempty"`       // Last line to return; 0 for as many as max_lines allows
+	MaxLines      int32                  `protobuf:"varint,5,opt,name=max_lines,json=maxLines,proto3" json:"max_lines,omitempty"` // Cap on lines returned, 0 or above the server's cap for the server's
+	Highlight     string                 `protobuf:"bytes,6,opt,name=highlight,proto3" json:"highlight,omitempty"`                // "none" (or ""), "html" or "ansi"
+	Language      string                 `protobuf:"bytes,7,opt,name=language,proto3" json:"language,omitempty"`                  // Language to highlight as; "" to detect it from the file name
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewFileRequest) Reset() {
+	*x = PreviewFileRequest{}
+	mi := &file_monorepo_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewFileRequest) ProtoMessage() {}
+
+func (x *PreviewFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewFileRequest.ProtoReflect.Descriptor instead.
+func (*PreviewFileRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *PreviewFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PreviewFileRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *PreviewFileRequest) GetFromLine() int32 {
+	if x != nil {
+		return x.FromLine
+	}
+	return 0
+}
+
+func (x *PreviewFileRequest) GetToLine() int32 {
+	if x != nil {
+		return x.ToLine
+	}
+	return 0
+}
+
+func (x *PreviewFileRequest) GetMaxLines() int32 {
+	if x != nil {
+		return x.MaxLines
+	}
+	return 0
+}
+
+func (x *PreviewFileRequest) GetHighlight() string {
+	if x != nil {
+		return x.Highlight
+	}
+	return ""
+}
+
+func (x *PreviewFileRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+// One line of a file preview
+type PreviewLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`            // Line without its line ending, rendered as asked
+	Truncated     bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"` // The line is longer than the server returns
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewLine) Reset() {
+	*x = PreviewLine{}
+	mi := &file_monorepo_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewLine) ProtoMessage() {}
+
+func (x *PreviewLine) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewLine.ProtoReflect.Descriptor instead.
+func (*PreviewLine) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *PreviewLine) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *PreviewLine) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *PreviewLine) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Lines of a file
+type PreviewFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	TotalLines    int32                  `protobuf:"varint,5,opt,name=total_lines,json=totalLines,proto3" json:"total_lines,omitempty"` // Lines in the whole file
+	Lines         []*PreviewLine         `protobuf:"bytes,6,rep,name=lines,proto3" json:"lines,omitempty"`
+	Truncated     bool                   `protobuf:"varint,7,opt,name=truncated,proto3" json:"truncated,omitempty"` // Lines in the requested range were left out at the cap
+	Binary        bool                   `protobuf:"varint,8,opt,name=binary,proto3" json:"binary,omitempty"`       // The file is binary and no lines are returned
+	Language      string                 `protobuf:"bytes,9,opt,name=language,proto3" json:"language,omitempty"`    // Language highlighted as, "" for plain text
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewFileResponse) Reset() {
+	*x = PreviewFileResponse{}
+	mi := &file_monorepo_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewFileResponse) ProtoMessage() {}
+
+func (x *PreviewFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewFileResponse.ProtoReflect.Descriptor instead.
+func (*PreviewFileResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *PreviewFileResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PreviewFileResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *PreviewFileResponse) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *PreviewFileResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *PreviewFileResponse) GetTotalLines() int32 {
+	if x != nil {
+		return x.TotalLines
+	}
+	return 0
+}
+
+func (x *PreviewFileResponse) GetLines() []*PreviewLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *PreviewFileResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *PreviewFileResponse) GetBinary() bool {
+	if x != nil {
+		return x.Binary
+	}
+	return false
+}
+
+func (x *PreviewFileResponse) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+// Request for a rendered document
+type GetRenderedDocRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`        // Directory whose README to render, or a document; "" for the root
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRenderedDocRequest) Reset() {
+	*x = GetRenderedDocRequest{}
+	mi := &file_monorepo_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRenderedDocRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRenderedDocRequest) ProtoMessage() {}
+
+func (x *GetRenderedDocRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRenderedDocRequest.ProtoReflect.Descriptor instead.
+func (*GetRenderedDocRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GetRenderedDocRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetRenderedDocRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// A document rendered as HTML. Raw HTML in the source is escaped and only
+// relative, http, https and mailto URLs are linked, so the HTML can be put
+// in a page as it is.
+type GetRenderedDocResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Document rendered; "" when the directory has no README
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"` // Hash of the document's content
+	Html          string                 `protobuf:"bytes,4,opt,name=html,proto3" json:"html,omitempty"`
+	Title         string                 `protobuf:"bytes,5,opt,name=title,proto3" json:"title,omitempty"`          // Text of the document's first heading
+	Truncated     bool                   `protobuf:"varint,6,opt,name=truncated,proto3" json:"truncated,omitempty"` // Only the start of a long document was rendered
+	Cached        bool                   `protobuf:"varint,7,opt,name=cached,proto3" json:"cached,omitempty"`       // The HTML came from the server's render cache
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRenderedDocResponse) Reset() {
+	*x = GetRenderedDocResponse{}
+	mi := &file_monorepo_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRenderedDocResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRenderedDocResponse) ProtoMessage() {}
+
+func (x *GetRenderedDocResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRenderedDocResponse.ProtoReflect.Descriptor instead.
+func (*GetRenderedDocResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *GetRenderedDocResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetRenderedDocResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetRenderedDocResponse) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *GetRenderedDocResponse) GetHtml() string {
+	if x != nil {
+		return x.Html
+	}
+	return ""
+}
+
+func (x *GetRenderedDocResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *GetRenderedDocResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *GetRenderedDocResponse) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+// A definition found by the symbol index
+type Symbol struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"` // function, method, type, struct, interface, class, enum, const, var, field, module, macro, message or service
+	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Line          int32                  `protobuf:"varint,4,opt,name=line,proto3" json:"line,omitempty"`          // From 1
+	Column        int32                  `protobuf:"varint,5,opt,name=column,proto3" json:"column,omitempty"`      // From 1, in bytes
+	Container     string                 `protobuf:"bytes,6,opt,name=container,proto3" json:"container,omitempty"` // Type or class the symbol belongs to, such as a method's receiver
+	Language      string                 `protobuf:"bytes,7,opt,name=language,proto3" json:"language,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Symbol) Reset() {
+	*x = Symbol{}
+	mi := &file_monorepo_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Symbol) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Symbol) ProtoMessage() {}
+
+func (x *Symbol) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Symbol.ProtoReflect.Descriptor instead.
+func (*Symbol) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Symbol) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Symbol) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Symbol) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Symbol) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Symbol) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+func (x *Symbol) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *Symbol) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+// Request to find symbols by name
+type SearchSymbolsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`      // Name, or part of one; matched ignoring case
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version to search, 0 for the current one
+	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`        // Only symbols under this path; "" for all
+	Kinds         []string               `protobuf:"bytes,4,rep,name=kinds,proto3" json:"kinds,omitempty"`      // Only symbols of these kinds; empty for all
+	Exact         bool                   `protobuf:"varint,5,opt,name=exact,proto3" json:"exact,omitempty"`     // Only symbols named query, ignoring case
+	Limit         int32                  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`     // 0 for the server's default of 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchSymbolsRequest) Reset() {
+	*x = SearchSymbolsRequest{}
+	mi := &file_monorepo_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSymbolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSymbolsRequest) ProtoMessage() {}
+
+func (x *SearchSymbolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSymbolsRequest.ProtoReflect.Descriptor instead.
+func (*SearchSymbolsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SearchSymbolsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchSymbolsRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SearchSymbolsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SearchSymbolsRequest) GetKinds() []string {
+	if x != nil {
+		return x.Kinds
+	}
+	return nil
+}
+
+func (x *SearchSymbolsRequest) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+func (x *SearchSymbolsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Symbols matching a search: whole-name matches, then prefix matches, then
+// the rest, shorter names first
+type SearchSymbolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []*Symbol              `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Truncated     bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"` // More symbols matched than the limit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchSymbolsResponse) Reset() {
+	*x = SearchSymbolsResponse{}
+	mi := &file_monorepo_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSymbolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSymbolsResponse) ProtoMessage() {}
+
+func (x *SearchSymbolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSymbolsResponse.ProtoReflect.Descriptor instead.
+func (*SearchSymbolsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *SearchSymbolsResponse) GetSymbols() []*Symbol {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *SearchSymbolsResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SearchSymbolsResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Request to find the definitions of an identifier in a file
+type GoToDefinitionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Version to read, 0 for the current one
+	Line          int32                  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`       // From 1
+	Column        int32                  `protobuf:"varint,4,opt,name=column,proto3" json:"column,omitempty"`   // From 1, in bytes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GoToDefinitionRequest) Reset() {
+	*x = GoToDefinitionRequest{}
+	mi := &file_monorepo_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GoToDefinitionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoToDefinitionRequest) ProtoMessage() {}
+
+func (x *GoToDefinitionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GoToDefinitionRequest.ProtoReflect.Descriptor instead.
+func (*GoToDefinitionRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *GoToDefinitionRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GoToDefinitionRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GoToDefinitionRequest) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *GoToDefinitionRequest) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+// Definitions of an identifier, those nearest the file first: in the file,
+// in its directory, then in files of its language
+type GoToDefinitionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"` // Identifier at the position; "" when there is none
+	Definitions   []*Symbol              `protobuf:"bytes,2,rep,name=definitions,proto3" json:"definitions,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GoToDefinitionResponse) Reset() {
+	*x = GoToDefinitionResponse{}
+	mi := &file_monorepo_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GoToDefinitionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoToDefinitionResponse) ProtoMessage() {}
+
+func (x *GoToDefinitionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GoToDefinitionResponse.ProtoReflect.Descriptor instead.
+func (*GoToDefinitionResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *GoToDefinitionResponse) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *GoToDefinitionResponse) GetDefinitions() []*Symbol {
+	if x != nil {
+		return x.Definitions
+	}
+	return nil
+}
+
+func (x *GoToDefinitionResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Request for file history
+type FileHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`     // File path
+	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"` // Branch name (default: main)
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`  // Maximum number of commits to return
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileHistoryRequest) Reset() {
+	*x = FileHistoryRequest{}
+	mi := &file_monorepo_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileHistoryRequest) ProtoMessage() {}
+
+func (x *FileHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileHistoryRequest.ProtoReflect.Descriptor instead.
+func (*FileHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *FileHistoryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileHistoryRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *FileHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Response containing file history
+type FileHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commits       []*Commit              `protobuf:"bytes,1,rep,name=commits,proto3" json:"commits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileHistoryResponse) Reset() {
+	*x = FileHistoryResponse{}
+	mi := &file_monorepo_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileHistoryResponse) ProtoMessage() {}
+
+func (x *FileHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileHistoryResponse.ProtoReflect.Descriptor instead.
+func (*FileHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *FileHistoryResponse) GetCommits() []*Commit {
+	if x != nil {
+		return x.Commits
+	}
+	return nil
+}
+
+// A git commit
+type Commit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ChangedFiles  []string               `protobuf:"bytes,5,rep,name=changed_files,json=changedFiles,proto3" json:"changed_files,omitempty"`
+	Path          string                 `protobuf:"bytes,6,opt,name=path,proto3" json:"path,omitempty"`                      // Path of the file in this commit (file history only; differs from the request before a rename)
+	OldPath       string                 `protobuf:"bytes,7,opt,name=old_path,json=oldPath,proto3" json:"old_path,omitempty"` // Set when the file was renamed or copied from old_path in this commit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Commit) Reset() {
+	*x = Commit{}
+	mi := &file_monorepo_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Commit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Commit) ProtoMessage() {}
+
+func (x *Commit) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Commit.ProtoReflect.Descriptor instead.
+func (*Commit) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *Commit) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Commit) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Commit) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Commit) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Commit) GetChangedFiles() []string {
+	if x != nil {
+		return x.ChangedFiles
+	}
+	return nil
+}
+
+func (x *Commit) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Commit) GetOldPath() string {
+	if x != nil {
+		return x.OldPath
+	}
+	return ""
+}
+
+// Request for available branches
+type BranchesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BranchesRequest) Reset() {
+	*x = BranchesRequest{}
+	mi := &file_monorepo_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BranchesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BranchesRequest) ProtoMessage() {}
+
+func (x *BranchesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BranchesRequest.ProtoReflect.Descriptor instead.
+func (*BranchesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{44}
+}
+
+// Response containing branches
+type BranchesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Branches      []string               `protobuf:"bytes,1,rep,name=branches,proto3" json:"branches,omitempty"`
+	DefaultBranch string                 `protobuf:"bytes,2,opt,name=default_branch,json=defaultBranch,proto3" json:"default_branch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BranchesResponse) Reset() {
+	*x = BranchesResponse{}
+	mi := &file_monorepo_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BranchesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BranchesResponse) ProtoMessage() {}
+
+func (x *BranchesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BranchesResponse.ProtoReflect.Descriptor instead.
+func (*BranchesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *BranchesResponse) GetBranches() []string {
+	if x != nil {
+		return x.Branches
+	}
+	return nil
+}
+
+func (x *BranchesResponse) GetDefaultBranch() string {
+	if x != nil {
+		return x.DefaultBranch
+	}
+	return ""
+}
+
+// Request to create a new branch
+type CreateBranchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                               // New branch name
+	FromBranch    string                 `protobuf:"bytes,2,opt,name=from_branch,json=fromBranch,proto3" json:"from_branch,omitempty"` // Source branch (default: main)
+	FromCommit    string                 `protobuf:"bytes,3,opt,name=from_commit,json=fromCommit,proto3" json:"from_commit,omitempty"` // Specific commit to branch from
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBranchRequest) Reset() {
+	*x = CreateBranchRequest{}
+	mi := &file_monorepo_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBranchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBranchRequest) ProtoMessage() {}
+
+func (x *CreateBranchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBranchRequest.ProtoReflect.Descriptor instead.
+func (*CreateBranchRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *CreateBranchRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateBranchRequest) GetFromBranch() string {
+	if x != nil {
+		return x.FromBranch
+	}
+	return ""
+}
+
+func (x *CreateBranchRequest) GetFromCommit() string {
+	if x != nil {
+		return x.FromCommit
+	}
+	return ""
+}
+
+// Response from creating a branch
+type CreateBranchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	BranchName    string                 `protobuf:"bytes,3,opt,name=branch_name,json=branchName,proto3" json:"branch_name,omitempty"`
+	CommitHash    string                 `protobuf:"bytes,4,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBranchResponse) Reset() {
+	*x = CreateBranchResponse{}
+	mi := &file_monorepo_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBranchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBranchResponse) ProtoMessage() {}
+
+func (x *CreateBranchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBranchResponse.ProtoReflect.Descriptor instead.
+func (*CreateBranchResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *CreateBranchResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CreateBranchResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CreateBranchResponse) GetBranchName() string {
+	if x != nil {
+		return x.BranchName
+	}
+	return ""
+}
+
+func (x *CreateBranchResponse) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+// Request to merge one branch into another
+type MergeBranchesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceBranch  string                 `protobuf:"bytes,1,opt,name=source_branch,json=sourceBranch,proto3" json:"source_branch,omitempty"` // Branch whose commits are merged in
+	TargetBranch  string                 `protobuf:"bytes,2,opt,name=target_branch,json=targetBranch,proto3" json:"target_branch,omitempty"` // Branch merged into (default: main)
+	Author        string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`                                  // Merge commit message (default: "Merge branch '<source>' into <target>")
+	Squash        bool                   `protobuf:"varint,5,opt,name=squash,proto3" json:"squash,omitempty"`                                   // Land the branch as one ordinary commit instead of a merge commit
+	FailIfLocked  bool                   `protobuf:"varint,6,opt,name=fail_if_locked,json=failIfLocked,proto3" json:"fail_if_locked,omitempty"` // Reject a merge into main that changes a path locked by someone else
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MergeBranchesRequest) Reset() {
+	*x = MergeBranchesRequest{}
+	mi := &file_monorepo_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeBranchesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeBranchesRequest) ProtoMessage() {}
+
+func (x *MergeBranchesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeBranchesRequest.ProtoReflect.Descriptor instead.
+func (*MergeBranchesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *MergeBranchesRequest) GetSourceBranch() string {
+	if x != nil {
+		return x.SourceBranch
+	}
+	return ""
+}
+
+func (x *MergeBranchesRequest) GetTargetBranch() string {
+	if x != nil {
+		return x.TargetBranch
+	}
+	return ""
+}
+
+func (x *MergeBranchesRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *MergeBranchesRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *MergeBranchesRequest) GetSquash() bool {
+	if x != nil {
+		return x.Squash
+	}
+	return false
+}
+
+func (x *MergeBranchesRequest) GetFailIfLocked() bool {
+	if x != nil {
+		return x.FailIfLocked
+	}
+	return false
+}
+
+// Response from merging branches
+type MergeBranchesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	CommitHash    string                 `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"` // The merge commit, or the target's head when up to date
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                        // Version created by a merge into main
+	MergeBase     string                 `protobuf:"bytes,5,opt,name=merge_base,json=mergeBase,proto3" json:"merge_base,omitempty"`    // Newest common ancestor of the two branches
+	UpToDate      bool                   `protobuf:"varint,6,opt,name=up_to_date,json=upToDate,proto3" json:"up_to_date,omitempty"`    // The target already had every commit of the source
+	Conflicts     []string               `protobuf:"bytes,7,rep,name=conflicts,proto3" json:"conflicts,omitempty"`                     // Paths both branches changed differently
+	Failure       *FailureInfo           `protobuf:"bytes,8,opt,name=failure,proto3" json:"failure,omitempty"`
+	Violations    []*PolicyViolation     `protobuf:"bytes,9,rep,name=violations,proto3" json:"violations,omitempty"`                            // Branch protection rules or validators of the target the merge breaks
+	Queued        bool                   `protobuf:"varint,10,opt,name=queued,proto3" json:"queued,omitempty"`                                  // The merge into main entered the merge queue instead of landing
+	QueueEntryId  string                 `protobuf:"bytes,11,opt,name=queue_entry_id,json=queueEntryId,proto3" json:"queue_entry_id,omitempty"` // Merge queue entry to follow with GetMergeQueue
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MergeBranchesResponse) Reset() {
+	*x = MergeBranchesResponse{}
+	mi := &file_monorepo_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeBranchesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeBranchesResponse) ProtoMessage() {}
+
+func (x *MergeBranchesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeBranchesResponse.ProtoReflect.Descriptor instead.
+func (*MergeBranchesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *MergeBranchesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *MergeBranchesResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *MergeBranchesResponse) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *MergeBranchesResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MergeBranchesResponse) GetMergeBase() string {
+	if x != nil {
+		return x.MergeBase
+	}
+	return ""
+}
+
+func (x *MergeBranchesResponse) GetUpToDate() bool {
+	if x != nil {
+		return x.UpToDate
+	}
+	return false
+}
+
+func (x *MergeBranchesResponse) GetConflicts() []string {
+	if x != nil {
+		return x.Conflicts
+	}
+	return nil
+}
+
+func (x *MergeBranchesResponse) GetFailure() *FailureInfo {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+func (x *MergeBranchesResponse) GetViolations() []*PolicyViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *MergeBranchesResponse) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *MergeBranchesResponse) GetQueueEntryId() string {
+	if x != nil {
+		return x.QueueEntryId
+	}
+	return ""
+}
+
+// Request to apply the changes a commit made against its first parent to a
+// branch
+type CherryPickRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CommitHash    string                 `protobuf:"bytes,1,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`       // Commit to pick, from any branch
+	TargetBranch  string                 `protobuf:"bytes,2,opt,name=target_branch,json=targetBranch,proto3" json:"target_branch,omitempty"` // Branch to apply it to (default: main)
+	Author        string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                                 // Pick this version's commit instead of commit_hash
+	FailIfLocked  bool                   `protobuf:"varint,5,opt,name=fail_if_locked,json=failIfLocked,proto3" json:"fail_if_locked,omitempty"` // Reject a pick onto main that changes a path locked by someone else
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CherryPickRequest) Reset() {
+	*x = CherryPickRequest{}
+	mi := &file_monorepo_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CherryPickRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CherryPickRequest) ProtoMessage() {}
+
+func (x *CherryPickRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CherryPickRequest.ProtoReflect.Descriptor instead.
+func (*CherryPickRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *CherryPickRequest) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *CherryPickRequest) GetTargetBranch() string {
+	if x != nil {
+		return x.TargetBranch
+	}
+	return ""
+}
+
+func (x *CherryPickRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *CherryPickRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *CherryPickRequest) GetFailIfLocked() bool {
+	if x != nil {
+		return x.FailIfLocked
+	}
+	return false
+}
+
+// Response from a cherry-pick
+type CherryPickResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	CommitHash    string                 `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"` // The new commit, or the target's head when empty
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                        // Version created by a pick onto main
+	Empty         bool                   `protobuf:"varint,5,opt,name=empty,proto3" json:"empty,omitempty"`                            // The target already had the commit's changes
+	Conflicts     []string               `protobuf:"bytes,6,rep,name=conflicts,proto3" json:"conflicts,omitempty"`                     // Paths the target changed differently
+	Failure       *FailureInfo           `protobuf:"bytes,7,opt,name=failure,proto3" json:"failure,omitempty"`
+	Violations    []*PolicyViolation     `protobuf:"bytes,8,rep,name=violations,proto3" json:"violations,omitempty"`                            // Branch protection rules or validators of the target the pick breaks
+	Queued        bool                   `protobuf:"varint,9,opt,name=queued,proto3" json:"queued,omitempty"`                                   // The pick onto main entered the merge queue instead of landing
+	QueueEntryId  string                 `protobuf:"bytes,10,opt,name=queue_entry_id,json=queueEntryId,proto3" json:"queue_entry_id,omitempty"` // Merge queue entry to follow with GetMergeQueue
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CherryPickResponse) Reset() {
+	*x = CherryPickResponse{}
+	mi := &file_monorepo_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CherryPickResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CherryPickResponse) ProtoMessage() {}
+
+func (x *CherryPickResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CherryPickResponse.ProtoReflect.Descriptor instead.
+func (*CherryPickResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *CherryPickResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CherryPickResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CherryPickResponse) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *CherryPickResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *CherryPickResponse) GetEmpty() bool {
+	if x != nil {
+		return x.Empty
+	}
+	return false
+}
+
+func (x *CherryPickResponse) GetConflicts() []string {
+	if x != nil {
+		return x.Conflicts
+	}
+	return nil
+}
+
+func (x *CherryPickResponse) GetFailure() *FailureInfo {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+func (x *CherryPickResponse) GetViolations() []*PolicyViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *CherryPickResponse) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *CherryPickResponse) GetQueueEntryId() string {
+	if x != nil {
+		return x.QueueEntryId
+	}
+	return ""
+}
+
+// Workspace management messages
+type CreateWorkspaceRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	TrackedPaths       []string               `protobuf:"bytes,2,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"` // Paths or glob patterns (*, ?, [...], **)
+	BaseBranch         string                 `protobuf:"bytes,3,opt,name=base_branch,json=baseBranch,proto3" json:"base_branch,omitempty"`
+	Metadata           map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	OverrideSizeLimits bool                   `protobuf:"varint,5,opt,name=override_size_limits,json=overrideSizeLimits,proto3" json:"override_size_limits,omitempty"` // Skip file and tracked path size limits (requires permission)
+	Template           string                 `protobuf:"bytes,6,opt,name=template,proto3" json:"template,omitempty"`                                                  // Template whose tracked paths and metadata to start from (see ListTemplates)
+	Lazy               bool                   `protobuf:"varint,7,opt,name=lazy,proto3" json:"lazy,omitempty"`                                                         // Return once the empty repository exists instead of once the tracked paths are copied into it; fetches wait for the copy
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *CreateWorkspaceRequest) Reset() {
+	*x = CreateWorkspaceRequest{}
+	mi := &file_monorepo_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWorkspaceRequest) ProtoMessage() {}
+
+func (x *CreateWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*CreateWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *CreateWorkspaceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateWorkspaceRequest) GetTrackedPaths() []string {
+	if x != nil {
+		return x.TrackedPaths
+	}
+	return nil
+}
+
+func (x *CreateWorkspaceRequest) GetBaseBranch() string {
+	if x != nil {
+		return x.BaseBranch
+	}
+	return ""
+}
+
+func (x *CreateWorkspaceRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CreateWorkspaceRequest) GetOverrideSizeLimits() bool {
+	if x != nil {
+		return x.OverrideSizeLimits
+	}
+	return false
+}
+
+func (x *CreateWorkspaceRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *CreateWorkspaceRequest) GetLazy() bool {
+	if x != nil {
+		return x.Lazy
+	}
+	return false
+}
+
+type CreateWorkspaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	WorkspaceId   string                 `protobuf:"bytes,3,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	RemoteUrl     string                 `protobuf:"bytes,4,opt,name=remote_url,json=remoteUrl,proto3" json:"remote_url,omitempty"`
+	OperationId   string                 `protobuf:"bytes,5,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"` // The operation copying the tracked paths (see WaitOperation); done already without lazy
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWorkspaceResponse) Reset() {
+	*x = CreateWorkspaceResponse{}
+	mi := &file_monorepo_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWorkspaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWorkspaceResponse) ProtoMessage() {}
+
+func (x *CreateWorkspaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWorkspaceResponse.ProtoReflect.Descriptor instead.
+func (*CreateWorkspaceResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *CreateWorkspaceResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CreateWorkspaceResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CreateWorkspaceResponse) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *CreateWorkspaceResponse) GetRemoteUrl() string {
+	if x != nil {
+		return x.RemoteUrl
+	}
+	return ""
+}
+
+func (x *CreateWorkspaceResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+// Operation is work a call returned from before it was done
+type Operation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`   // What the operation does, such as "create_workspace"
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"` // User who started it
+	Done          bool                   `protobuf:"varint,4,opt,name=done,proto3" json:"done,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`                                       // Why it failed, once done; empty if it succeeded
+	ProgressDone  int64                  `protobuf:"varint,6,opt,name=progress_done,json=progressDone,proto3" json:"progress_done,omitempty"`    // Units of work done, such as files copied
+	ProgressTotal int64                  `protobuf:"varint,7,opt,name=progress_total,json=progressTotal,proto3" json:"progress_total,omitempty"` // Units of work to do; 0 if unknown
+	CreatedAt     string                 `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	FinishedAt    string                 `protobuf:"bytes,9,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`                                                  // Empty while running
+	Result        map[string]string      `protobuf:"bytes,10,rep,name=result,proto3" json:"result,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Such as "workspace_id" for create_workspace
+	Status        string                 `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`                                                                           // "running", "succeeded", "failed" or "cancelled"
+	Response      []byte                 `protobuf:"bytes,12,opt,name=response,proto3" json:"response,omitempty"`                                                                       // Once succeeded: the serialized response of the call that started it, such as a FsckResponse for "fsck"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Operation) Reset() {
+	*x = Operation{}
+	mi := &file_monorepo_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Operation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Operation) ProtoMessage() {}
+
+func (x *Operation) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Operation.ProtoReflect.Descriptor instead.
+func (*Operation) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *Operation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Operation) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Operation) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Operation) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *Operation) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Operation) GetProgressDone() int64 {
+	if x != nil {
+		return x.ProgressDone
+	}
+	return 0
+}
+
+func (x *Operation) GetProgressTotal() int64 {
+	if x != nil {
+		return x.ProgressTotal
+	}
+	return 0
+}
+
+func (x *Operation) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Operation) GetFinishedAt() string {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return ""
+}
+
+func (x *Operation) GetResult() map[string]string {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Operation) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Operation) GetResponse() []byte {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type GetOperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationId   string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOperationRequest) Reset() {
+	*x = GetOperationRequest{}
+	mi := &file_monorepo_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOperationRequest) ProtoMessage() {}
+
+func (x *GetOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOperationRequest.ProtoReflect.Descriptor instead.
+func (*GetOperationRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *GetOperationRequest) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+type GetOperationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Operation     *Operation             `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOperationResponse) Reset() {
+	*x = GetOperationResponse{}
+	mi := &file_monorepo_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOperationResponse) ProtoMessage() {}
+
+func (x *GetOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOperationResponse.ProtoReflect.Descriptor instead.
+func (*GetOperationResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *GetOperationResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *GetOperationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GetOperationResponse) GetOperation() *Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+type WaitOperationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OperationId    string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	TimeoutSeconds int32                  `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // 0 or over a minute waits a minute
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WaitOperationRequest) Reset() {
+	*x = WaitOperationRequest{}
+	mi := &file_monorepo_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WaitOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitOperationRequest) ProtoMessage() {}
+
+func (x *WaitOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitOperationRequest.ProtoReflect.Descriptor instead.
+func (*WaitOperationRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *WaitOperationRequest) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *WaitOperationRequest) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+type WaitOperationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Operation     *Operation             `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WaitOperationResponse) Reset() {
+	*x = WaitOperationResponse{}
+	mi := &file_monorepo_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WaitOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitOperationResponse) ProtoMessage() {}
+
+func (x *WaitOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitOperationResponse.ProtoReflect.Descriptor instead.
+func (*WaitOperationResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *WaitOperationResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WaitOperationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *WaitOperationResponse) GetOperation() *Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+type CancelOperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationId   string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOperationRequest) Reset() {
+	*x = CancelOperationRequest{}
+	mi := &file_monorepo_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOperationRequest) ProtoMessage() {}
+
+func (x *CancelOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOperationRequest.ProtoReflect.Descriptor instead.
+func (*CancelOperationRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *CancelOperationRequest) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+type CancelOperationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOperationResponse) Reset() {
+	*x = CancelOperationResponse{}
+	mi := &file_monorepo_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOperationResponse) ProtoMessage() {}
+
+func (x *CancelOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOperationResponse.ProtoReflect.Descriptor instead.
+func (*CancelOperationResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *CancelOperationResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CancelOperationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ListOperationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunningOnly   bool                   `protobuf:"varint,1,opt,name=running_only,json=runningOnly,proto3" json:"running_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOperationsRequest) Reset() {
+	*x = ListOperationsRequest{}
+	mi := &file_monorepo_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOperationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOperationsRequest) ProtoMessage() {}
+
+func (x *ListOperationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOperationsRequest.ProtoReflect.Descriptor instead.
+func (*ListOperationsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ListOperationsRequest) GetRunningOnly() bool {
+	if x != nil {
+		return x.RunningOnly
+	}
+	return false
+}
+
+type ListOperationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*Operation           `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"` // Without their responses; see GetOperation
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOperationsResponse) Reset() {
+	*x = ListOperationsResponse{}
+	mi := &file_monorepo_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOperationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOperationsResponse) ProtoMessage() {}
+
+func (x *ListOperationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOperationsResponse.ProtoReflect.Descriptor instead.
+func (*ListOperationsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *ListOperationsResponse) GetOperations() []*Operation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+type GetWorkspaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorkspaceRequest) Reset() {
+	*x = GetWorkspaceRequest{}
+	mi := &file_monorepo_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorkspaceRequest) ProtoMessage() {}
+
+func (x *GetWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*GetWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *GetWorkspaceRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+type GetWorkspaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Workspace     *WorkspaceInfo         `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorkspaceResponse) Reset() {
+	*x = GetWorkspaceResponse{}
+	mi := &file_monorepo_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorkspaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorkspaceResponse) ProtoMessage() {}
+
+func (x *GetWorkspaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorkspaceResponse.ProtoReflect.Descriptor instead.
+func (*GetWorkspaceResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *GetWorkspaceResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *GetWorkspaceResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GetWorkspaceResponse) GetWorkspace() *WorkspaceInfo {
+	if x != nil {
+		return x.Workspace
+	}
+	return nil
+}
+
+type UpdateWorkspaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	TrackedPaths  []string               `protobuf:"bytes,2,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateWorkspaceRequest) Reset() {
+	*x = UpdateWorkspaceRequest{}
+	mi := &file_monorepo_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateWorkspaceRequest) ProtoMessage() {}
+
+func (x *UpdateWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*UpdateWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *UpdateWorkspaceRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *UpdateWorkspaceRequest) GetTrackedPaths() []string {
+	if x != nil {
+		return x.TrackedPaths
+	}
+	return nil
+}
+
+func (x *UpdateWorkspaceRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type UpdateWorkspaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Workspace     *WorkspaceInfo         `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateWorkspaceResponse) Reset() {
+	*x = UpdateWorkspaceResponse{}
+	mi := &file_monorepo_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateWorkspaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateWorkspaceResponse) ProtoMessage() {}
+
+func (x *UpdateWorkspaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateWorkspaceResponse.ProtoReflect.Descriptor instead.
+func (*UpdateWorkspaceResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *UpdateWorkspaceResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *UpdateWorkspaceResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *UpdateWorkspaceResponse) GetWorkspace() *WorkspaceInfo {
+	if x != nil {
+		return x.Workspace
+	}
+	return nil
+}
+
+type DeleteWorkspaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWorkspaceRequest) Reset() {
+	*x = DeleteWorkspaceRequest{}
+	mi := &file_monorepo_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWorkspaceRequest) ProtoMessage() {}
+
+func (x *DeleteWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *DeleteWorkspaceRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+type DeleteWorkspaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWorkspaceResponse) Reset() {
+	*x = DeleteWorkspaceResponse{}
+	mi := &file_monorepo_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWorkspaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWorkspaceResponse) ProtoMessage() {}
+
+func (x *DeleteWorkspaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWorkspaceResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWorkspaceResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *DeleteWorkspaceResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DeleteWorkspaceResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type WorkspaceInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	TrackedPaths    []string               `protobuf:"bytes,3,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"`
+	CreatedAt       string                 `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastSync        string                 `protobuf:"bytes,5,opt,name=last_sync,json=lastSync,proto3" json:"last_sync,omitempty"`
+	Status          WorkspaceStatus        `protobuf:"varint,6,opt,name=status,proto3,enum=monorepo.WorkspaceStatus" json:"status,omitempty"`
+	Metadata        map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ClientVersion   string                 `protobuf:"bytes,8,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`        // Reported by the client, empty if it never reported
+	HeadCommit      string                 `protobuf:"bytes,9,opt,name=head_commit,json=headCommit,proto3" json:"head_commit,omitempty"`                 // HEAD of the client's local repository
+	DirtyFiles      int32                  `protobuf:"varint,10,opt,name=dirty_files,json=dirtyFiles,proto3" json:"dirty_files,omitempty"`               // Uncommitted files in tracked paths
+	LastReport      string                 `protobuf:"bytes,11,opt,name=last_report,json=lastReport,proto3" json:"last_report,omitempty"`                // When the client last reported its status
+	Diverged        bool                   `protobuf:"varint,12,opt,name=diverged,proto3" json:"diverged,omitempty"`                                     // Client has local changes or commits not on the server
+	Owner           string                 `protobuf:"bytes,13,opt,name=owner,proto3" json:"owner,omitempty"`                                            // User the workspace belongs to
+	TrackedPatterns []string               `protobuf:"bytes,14,rep,name=tracked_patterns,json=trackedPatterns,proto3" json:"tracked_patterns,omitempty"` // Glob patterns re-expanded on sync
+	DiskBytes       int64                  `protobuf:"varint,15,opt,name=disk_bytes,json=diskBytes,proto3" json:"disk_bytes,omitempty"`                  // Disk used by the workspace repository, history included
+	Commits         int64                  `protobuf:"varint,16,opt,name=commits,proto3" json:"commits,omitempty"`                                       // Commits in the workspace repository's history
+	FilesCopied     int64                  `protobuf:"varint,17,opt,name=files_copied,json=filesCopied,proto3" json:"files_copied,omitempty"`            // While SYNCING after a create: files copied into the repository so far
+	FilesTotal      int64                  `protobuf:"varint,18,opt,name=files_total,json=filesTotal,proto3" json:"files_total,omitempty"`               // While SYNCING after a create: files to copy
+	StatusMessage   string                 `protobuf:"bytes,19,opt,name=status_message,json=statusMessage,proto3" json:"status_message,omitempty"`       // Why the workspace is SYNCING or in ERROR
+	SyncedVersion   int64                  `protobuf:"varint,20,opt,name=synced_version,json=syncedVersion,proto3" json:"synced_version,omitempty"`      // Version the workspace repository's files were last copied from
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WorkspaceInfo) Reset() {
+	*x = WorkspaceInfo{}
+	mi := &file_monorepo_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceInfo) ProtoMessage() {}
+
+func (x *WorkspaceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceInfo.ProtoReflect.Descriptor instead.
+func (*WorkspaceInfo) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *WorkspaceInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetTrackedPaths() []string {
+	if x != nil {
+		return x.TrackedPaths
+	}
+	return nil
+}
+
+func (x *WorkspaceInfo) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetLastSync() string {
+	if x != nil {
+		return x.LastSync
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetStatus() WorkspaceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return WorkspaceStatus_ACTIVE
+}
+
+func (x *WorkspaceInfo) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *WorkspaceInfo) GetClientVersion() string {
+	if x != nil {
+		return x.ClientVersion
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetHeadCommit() string {
+	if x != nil {
+		return x.HeadCommit
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetDirtyFiles() int32 {
+	if x != nil {
+		return x.DirtyFiles
+	}
+	return 0
+}
+
+func (x *WorkspaceInfo) GetLastReport() string {
+	if x != nil {
+		return x.LastReport
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetDiverged() bool {
+	if x != nil {
+		return x.Diverged
+	}
+	return false
+}
+
+func (x *WorkspaceInfo) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetTrackedPatterns() []string {
+	if x != nil {
+		return x.TrackedPatterns
+	}
+	return nil
+}
+
+func (x *WorkspaceInfo) GetDiskBytes() int64 {
+	if x != nil {
+		return x.DiskBytes
+	}
+	return 0
+}
+
+func (x *WorkspaceInfo) GetCommits() int64 {
+	if x != nil {
+		return x.Commits
+	}
+	return 0
+}
+
+func (x *WorkspaceInfo) GetFilesCopied() int64 {
+	if x != nil {
+		return x.FilesCopied
+	}
+	return 0
+}
+
+func (x *WorkspaceInfo) GetFilesTotal() int64 {
+	if x != nil {
+		return x.FilesTotal
+	}
+	return 0
+}
+
+func (x *WorkspaceInfo) GetStatusMessage() string {
+	if x != nil {
+		return x.StatusMessage
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetSyncedVersion() int64 {
+	if x != nil {
+		return x.SyncedVersion
+	}
+	return 0
+}
+
+type ReportWorkspaceStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	ClientVersion string                 `protobuf:"bytes,2,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	HeadCommit    string                 `protobuf:"bytes,3,opt,name=head_commit,json=headCommit,proto3" json:"head_commit,omitempty"`
+	DirtyFiles    int32                  `protobuf:"varint,4,opt,name=dirty_files,json=dirtyFiles,proto3" json:"dirty_files,omitempty"`
+	Operation     string                 `protobuf:"bytes,5,opt,name=operation,proto3" json:"operation,omitempty"` // Command that triggered the report: "sync" or "push"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportWorkspaceStatusRequest) Reset() {
+	*x = ReportWorkspaceStatusRequest{}
+	mi := &file_monorepo_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportWorkspaceStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportWorkspaceStatusRequest) ProtoMessage() {}
+
+func (x *ReportWorkspaceStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportWorkspaceStatusRequest.ProtoReflect.Descriptor instead.
+func (*ReportWorkspaceStatusRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *ReportWorkspaceStatusRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *ReportWorkspaceStatusRequest) GetClientVersion() string {
+	if x != nil {
+		return x.ClientVersion
+	}
+	return ""
+}
+
+func (x *ReportWorkspaceStatusRequest) GetHeadCommit() string {
+	if x != nil {
+		return x.HeadCommit
+	}
+	return ""
+}
+
+func (x *ReportWorkspaceStatusRequest) GetDirtyFiles() int32 {
+	if x != nil {
+		return x.DirtyFiles
+	}
+	return 0
+}
+
+func (x *ReportWorkspaceStatusRequest) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+type ReportWorkspaceStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportWorkspaceStatusResponse) Reset() {
+	*x = ReportWorkspaceStatusResponse{}
+	mi := &file_monorepo_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportWorkspaceStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportWorkspaceStatusResponse) ProtoMessage() {}
+
+func (x *ReportWorkspaceStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportWorkspaceStatusResponse.ProtoReflect.Descriptor instead.
+func (*ReportWorkspaceStatusResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *ReportWorkspaceStatusResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReportWorkspaceStatusResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Sparse checkout messages
+type SparseCheckoutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	TargetDir     string                 `protobuf:"bytes,2,opt,name=target_dir,json=targetDir,proto3" json:"target_dir,omitempty"`
+	WorkspaceId   string                 `protobuf:"bytes,3,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SparseCheckoutRequest) Reset() {
+	*x = SparseCheckoutRequest{}
+	mi := &file_monorepo_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseCheckoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseCheckoutRequest) ProtoMessage() {}
+
+func (x *SparseCheckoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseCheckoutRequest.ProtoReflect.Descriptor instead.
+func (*SparseCheckoutRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *SparseCheckoutRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *SparseCheckoutRequest) GetTargetDir() string {
+	if x != nil {
+		return x.TargetDir
+	}
+	return ""
+}
+
+func (x *SparseCheckoutRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+type SparseCheckoutResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message         string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ConfiguredPaths []string               `protobuf:"bytes,3,rep,name=configured_paths,json=configuredPaths,proto3" json:"configured_paths,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SparseCheckoutResponse) Reset() {
+	*x = SparseCheckoutResponse{}
+	mi := &file_monorepo_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseCheckoutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseCheckoutResponse) ProtoMessage() {}
+
+func (x *SparseCheckoutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseCheckoutResponse.ProtoReflect.Descriptor instead.
+func (*SparseCheckoutResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *SparseCheckoutResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SparseCheckoutResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SparseCheckoutResponse) GetConfiguredPaths() []string {
+	if x != nil {
+		return x.ConfiguredPaths
+	}
+	return nil
+}
+
+// Download messages
+type DownloadPathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`     // Directory to archive
+	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"` // Branch to archive the head of (default main)
+	Format        string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"` // "tar.gz" (default) or "tar"
+	Async         bool                   `protobuf:"varint,4,opt,name=async,proto3" json:"async,omitempty"`  // Return at once with an operation whose response is the DownloadPathResponse
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadPathRequest) Reset() {
+	*x = DownloadPathRequest{}
+	mi := &file_monorepo_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadPathRequest) ProtoMessage() {}
+
+func (x *DownloadPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadPathRequest.ProtoReflect.Descriptor instead.
+func (*DownloadPathRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *DownloadPathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DownloadPathRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *DownloadPathRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *DownloadPathRequest) GetAsync() bool {
+	if x != nil {
+		return x.Async
+	}
+	return false
+}
+
+type DownloadPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Filename      string                 `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`                           // Version the archive was taken from (0 for a branch commit)
+	TreeHash      string                 `protobuf:"bytes,6,opt,name=tree_hash,json=treeHash,proto3" json:"tree_hash,omitempty"`          // Hash of the archived directory
+	OperationId   string                 `protobuf:"bytes,7,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"` // With async: the operation building the archive
+	Path          string                 `protobuf:"bytes,8,opt,name=path,proto3" json:"path,omitempty"`                                  // Directory archived. An async operation keeps this, commit_hash and filename rather than the content, which is built again when the operation is fetched.
+	CommitHash    string                 `protobuf:"bytes,9,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`    // Commit the archive was taken from
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadPathResponse) Reset() {
+	*x = DownloadPathResponse{}
+	mi := &file_monorepo_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadPathResponse) ProtoMessage() {}
+
+func (x *DownloadPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadPathResponse.ProtoReflect.Descriptor instead.
+func (*DownloadPathResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *DownloadPathResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DownloadPathResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DownloadPathResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *DownloadPathResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *DownloadPathResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *DownloadPathResponse) GetTreeHash() string {
+	if x != nil {
+		return x.TreeHash
+	}
+	return ""
+}
+
+func (x *DownloadPathResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *DownloadPathResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DownloadPathResponse) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+// A piece of a StreamDownloadPath archive. The first chunk names the archive
+// and the commit it was taken from, and carries no data.
+type DownloadChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`                  // Version the archive was taken from (0 for a branch commit)
+	TreeHash      string                 `protobuf:"bytes,4,opt,name=tree_hash,json=treeHash,proto3" json:"tree_hash,omitempty"` // Hash of the archived directory
+	CommitHash    string                 `protobuf:"bytes,5,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadChunk) Reset() {
+	*x = DownloadChunk{}
+	mi := &file_monorepo_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadChunk) ProtoMessage() {}
+
+func (x *DownloadChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadChunk.ProtoReflect.Descriptor instead.
+func (*DownloadChunk) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *DownloadChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DownloadChunk) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *DownloadChunk) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *DownloadChunk) GetTreeHash() string {
+	if x != nil {
+		return x.TreeHash
+	}
+	return ""
+}
+
+func (x *DownloadChunk) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+// A predefined workspace: tracked paths and metadata kept by the server
+type WorkspaceTemplate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	TrackedPaths  []string               `protobuf:"bytes,3,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"` // Paths or glob patterns
+	Metadata      map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceTemplate) Reset() {
+	*x = WorkspaceTemplate{}
+	mi := &file_monorepo_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceTemplate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceTemplate) ProtoMessage() {}
+
+func (x *WorkspaceTemplate) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceTemplate.ProtoReflect.Descriptor instead.
+func (*WorkspaceTemplate) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *WorkspaceTemplate) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WorkspaceTemplate) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *WorkspaceTemplate) GetTrackedPaths() []string {
+	if x != nil {
+		return x.TrackedPaths
+	}
+	return nil
+}
+
+func (x *WorkspaceTemplate) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type ListTemplatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesRequest) Reset() {
+	*x = ListTemplatesRequest{}
+	mi := &file_monorepo_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesRequest) ProtoMessage() {}
+
+func (x *ListTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*ListTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{78}
+}
+
+type ListTemplatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Templates     []*WorkspaceTemplate   `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"` // By name
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesResponse) Reset() {
+	*x = ListTemplatesResponse{}
+	mi := &file_monorepo_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesResponse) ProtoMessage() {}
+
+func (x *ListTemplatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesResponse.ProtoReflect.Descriptor instead.
+func (*ListTemplatesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *ListTemplatesResponse) GetTemplates() []*WorkspaceTemplate {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+// A named set of paths, such as everything one team owns
+type PathView struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Paths         []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"` // Paths or glob patterns
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathView) Reset() {
+	*x = PathView{}
+	mi := &file_monorepo_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathView) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathView) ProtoMessage() {}
+
+func (x *PathView) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathView.ProtoReflect.Descriptor instead.
+func (*PathView) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *PathView) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PathView) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PathView) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type ListViewsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"` // Version to read the views at (0 = current)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListViewsRequest) Reset() {
+	*x = ListViewsRequest{}
+	mi := &file_monorepo_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListViewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListViewsRequest) ProtoMessage() {}
+
+func (x *ListViewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListViewsRequest.ProtoReflect.Descriptor instead.
+func (*ListViewsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *ListViewsRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListViewsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Views         []*PathView            `protobuf:"bytes,3,rep,name=views,proto3" json:"views,omitempty"` // By name
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListViewsResponse) Reset() {
+	*x = ListViewsResponse{}
+	mi := &file_monorepo_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListViewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListViewsResponse) ProtoMessage() {}
+
+func (x *ListViewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListViewsResponse.ProtoReflect.Descriptor instead.
+func (*ListViewsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *ListViewsResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ListViewsResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ListViewsResponse) GetViews() []*PathView {
+	if x != nil {
+		return x.Views
+	}
+	return nil
+}
+
+func (x *ListViewsResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type StreamWorkspaceArchiveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamWorkspaceArchiveRequest) Reset() {
+	*x = StreamWorkspaceArchiveRequest{}
+	mi := &file_monorepo_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamWorkspaceArchiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamWorkspaceArchiveRequest) ProtoMessage() {}
+
+func (x *StreamWorkspaceArchiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamWorkspaceArchiveRequest.ProtoReflect.Descriptor instead.
+func (*StreamWorkspaceArchiveRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *StreamWorkspaceArchiveRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+// A piece of a workspace archive. The first chunk names the commit the
+// archive holds and the branch it is on, and carries no data.
+type WorkspaceArchiveChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	Branch        string                 `protobuf:"bytes,3,opt,name=branch,proto3" json:"branch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceArchiveChunk) Reset() {
+	*x = WorkspaceArchiveChunk{}
+	mi := &file_monorepo_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceArchiveChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceArchiveChunk) ProtoMessage() {}
+
+func (x *WorkspaceArchiveChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceArchiveChunk.ProtoReflect.Descriptor instead.
+func (*WorkspaceArchiveChunk) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *WorkspaceArchiveChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *WorkspaceArchiveChunk) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *WorkspaceArchiveChunk) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+// Request to add a tracked path to workspace
+type AddTrackedPathRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId        string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Path               string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`                                                          // Path or glob pattern (*, ?, [...], **)
+	Branch             string                 `protobuf:"bytes,3,opt,name=branch,proto3" json:"branch,omitempty"`                                                      // Branch to track from (default: main)
+	OverrideSizeLimits bool                   `protobuf:"varint,4,opt,name=override_size_limits,json=overrideSizeLimits,proto3" json:"override_size_limits,omitempty"` // Skip file and tracked path size limits (requires permission)
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AddTrackedPathRequest) Reset() {
+	*x = AddTrackedPathRequest{}
+	mi := &file_monorepo_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddTrackedPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddTrackedPathRequest) ProtoMessage() {}
+
+func (x *AddTrackedPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddTrackedPathRequest.ProtoReflect.Descriptor instead.
+func (*AddTrackedPathRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *AddTrackedPathRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *AddTrackedPathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *AddTrackedPathRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *AddTrackedPathRequest) GetOverrideSizeLimits() bool {
+	if x != nil {
+		return x.OverrideSizeLimits
+	}
+	return false
+}
+
+// Response from adding a tracked path
+type AddTrackedPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	CommitHash    string                 `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+	NewVersion    int64                  `protobuf:"varint,4,opt,name=new_version,json=newVersion,proto3" json:"new_version,omitempty"`
+	AddedPaths    []string               `protobuf:"bytes,5,rep,name=added_paths,json=addedPaths,proto3" json:"added_paths,omitempty"` // Paths added to the workspace
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddTrackedPathResponse) Reset() {
+	*x = AddTrackedPathResponse{}
+	mi := &file_monorepo_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddTrackedPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddTrackedPathResponse) ProtoMessage() {}
+
+func (x *AddTrackedPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddTrackedPathResponse.ProtoReflect.Descriptor instead.
+func (*AddTrackedPathResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *AddTrackedPathResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AddTrackedPathResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AddTrackedPathResponse) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *AddTrackedPathResponse) GetNewVersion() int64 {
+	if x != nil {
+		return x.NewVersion
+	}
+	return 0
+}
+
+func (x *AddTrackedPathResponse) GetAddedPaths() []string {
+	if x != nil {
+		return x.AddedPaths
+	}
+	return nil
+}
+
+// Request to pick up paths newly matching a workspace's tracked patterns
+type RefreshTrackedPathsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTrackedPathsRequest) Reset() {
+	*x = RefreshTrackedPathsRequest{}
+	mi := &file_monorepo_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTrackedPathsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTrackedPathsRequest) ProtoMessage() {}
+
+func (x *RefreshTrackedPathsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTrackedPathsRequest.ProtoReflect.Descriptor instead.
+func (*RefreshTrackedPathsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *RefreshTrackedPathsRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+type RefreshTrackedPathsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	AddedPaths    []string               `protobuf:"bytes,3,rep,name=added_paths,json=addedPaths,proto3" json:"added_paths,omitempty"`
+	CommitHash    string                 `protobuf:"bytes,4,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"` // Empty if nothing was added
+	NewVersion    int64                  `protobuf:"varint,5,opt,name=new_version,json=newVersion,proto3" json:"new_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTrackedPathsResponse) Reset() {
+	*x = RefreshTrackedPathsResponse{}
+	mi := &file_monorepo_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTrackedPathsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTrackedPathsResponse) ProtoMessage() {}
+
+func (x *RefreshTrackedPathsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTrackedPathsResponse.ProtoReflect.Descriptor instead.
+func (*RefreshTrackedPathsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *RefreshTrackedPathsResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *RefreshTrackedPathsResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RefreshTrackedPathsResponse) GetAddedPaths() []string {
+	if x != nil {
+		return x.AddedPaths
+	}
+	return nil
+}
+
+func (x *RefreshTrackedPathsResponse) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *RefreshTrackedPathsResponse) GetNewVersion() int64 {
+	if x != nil {
+		return x.NewVersion
+	}
+	return 0
+}
+
+// Request to read a file at a workspace's synced version
+type OpenWorkspaceFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"` // ID or name
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // Version to read instead of the workspace's, such as the client's own synced version
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenWorkspaceFileRequest) Reset() {
+	*x = OpenWorkspaceFileRequest{}
+	mi := &file_monorepo_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenWorkspaceFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenWorkspaceFileRequest) ProtoMessage() {}
+
+func (x *OpenWorkspaceFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenWorkspaceFileRequest.ProtoReflect.Descriptor instead.
+func (*OpenWorkspaceFileRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{89}
+}
+
+func (x *OpenWorkspaceFileRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *OpenWorkspaceFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *OpenWorkspaceFileRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type OpenWorkspaceFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Hash          string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	Size          int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	Tracked       bool                   `protobuf:"varint,6,opt,name=tracked,proto3" json:"tracked,omitempty"` // The workspace tracks the file, so the client has it checked out
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenWorkspaceFileResponse) Reset() {
+	*x = OpenWorkspaceFileResponse{}
+	mi := &file_monorepo_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenWorkspaceFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenWorkspaceFileResponse) ProtoMessage() {}
+
+func (x *OpenWorkspaceFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenWorkspaceFileResponse.ProtoReflect.Descriptor instead.
+func (*OpenWorkspaceFileResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *OpenWorkspaceFileResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *OpenWorkspaceFileResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *OpenWorkspaceFileResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *OpenWorkspaceFileResponse) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *OpenWorkspaceFileResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *OpenWorkspaceFileResponse) GetTracked() bool {
+	if x != nil {
+		return x.Tracked
+	}
+	return false
+}
+
+// Request to list the directory holding a path at a workspace's synced version
+type ListWorkspaceSiblingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"` // ID or name
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`                                  // File or directory whose siblings to list; "" lists the root
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`                           // Version to read instead of the workspace's
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkspaceSiblingsRequest) Reset() {
+	*x = ListWorkspaceSiblingsRequest{}
+	mi := &file_monorepo_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkspaceSiblingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkspaceSiblingsRequest) ProtoMessage() {}
+
+func (x *ListWorkspaceSiblingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[91]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkspaceSiblingsRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkspaceSiblingsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *ListWorkspaceSiblingsRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *ListWorkspaceSiblingsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ListWorkspaceSiblingsRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListWorkspaceSiblingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Directory     string                 `protobuf:"bytes,1,opt,name=directory,proto3" json:"directory,omitempty"` // Directory listed, "" for the root
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Entries       []*WorkspaceEntry      `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"` // By name
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkspaceSiblingsResponse) Reset() {
+	*x = ListWorkspaceSiblingsResponse{}
+	mi := &file_monorepo_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkspaceSiblingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkspaceSiblingsResponse) ProtoMessage() {}
+
+func (x *ListWorkspaceSiblingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkspaceSiblingsResponse.ProtoReflect.Descriptor instead.
+func (*ListWorkspaceSiblingsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{92}
+}
+
+func (x *ListWorkspaceSiblingsResponse) GetDirectory() string {
+	if x != nil {
+		return x.Directory
+	}
+	return ""
+}
+
+func (x *ListWorkspaceSiblingsResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ListWorkspaceSiblingsResponse) GetEntries() []*WorkspaceEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// An entry of a directory as a workspace sees it
+type WorkspaceEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	IsDir         bool                   `protobuf:"varint,3,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Hash          string                 `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	Tracked       bool                   `protobuf:"varint,6,opt,name=tracked,proto3" json:"tracked,omitempty"` // The workspace tracks the entry, or, for a directory, something below it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceEntry) Reset() {
+	*x = WorkspaceEntry{}
+	mi := &file_monorepo_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceEntry) ProtoMessage() {}
+
+func (x *WorkspaceEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceEntry.ProtoReflect.Descriptor instead.
+func (*WorkspaceEntry) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *WorkspaceEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WorkspaceEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *WorkspaceEntry) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *WorkspaceEntry) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *WorkspaceEntry) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *WorkspaceEntry) GetTracked() bool {
+	if x != nil {
+		return x.Tracked
+	}
+	return false
+}
+
+// Request for the files behind an editor's imports
+type FetchWorkspaceDependenciesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`          // ID or name
+	Paths         []string               `protobuf:"bytes,2,rep,name=paths,proto3" json:"paths,omitempty"`                                         // Files, or directories standing for the files directly in them
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`                                    // Version to read instead of the workspace's
+	MaxTotalBytes int64                  `protobuf:"varint,4,opt,name=max_total_bytes,json=maxTotalBytes,proto3" json:"max_total_bytes,omitempty"` // Cap on returned content, 0 or above the server's cap for the server's
+	SkipTracked   bool                   `protobuf:"varint,5,opt,name=skip_tracked,json=skipTracked,proto3" json:"skip_tracked,omitempty"`         // Leave out files the workspace tracks, which the client already has
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchWorkspaceDependenciesRequest) Reset() {
+	*x = FetchWorkspaceDependenciesRequest{}
+	mi := &file_monorepo_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchWorkspaceDependenciesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchWorkspaceDependenciesRequest) ProtoMessage() {}
+
+func (x *FetchWorkspaceDependenciesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchWorkspaceDependenciesRequest.ProtoReflect.Descriptor instead.
+func (*FetchWorkspaceDependenciesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *FetchWorkspaceDependenciesRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *FetchWorkspaceDependenciesRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *FetchWorkspaceDependenciesRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FetchWorkspaceDependenciesRequest) GetMaxTotalBytes() int64 {
+	if x != nil {
+		return x.MaxTotalBytes
+	}
+	return 0
+}
+
+func (x *FetchWorkspaceDependenciesRequest) GetSkipTracked() bool {
+	if x != nil {
+		return x.SkipTracked
+	}
+	return false
+}
+
+// Files read by FetchWorkspaceDependencies, in request order with each
+// directory's files in place of it
+type FetchWorkspaceDependenciesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Files         []*FileResult          `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty"`
+	Truncated     bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"` // Some files were omitted to stay under the size cap
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchWorkspaceDependenciesResponse) Reset() {
+	*x = FetchWorkspaceDependenciesResponse{}
+	mi := &file_monorepo_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchWorkspaceDependenciesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchWorkspaceDependenciesResponse) ProtoMessage() {}
+
+func (x *FetchWorkspaceDependenciesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchWorkspaceDependenciesResponse.ProtoReflect.Descriptor instead.
+func (*FetchWorkspaceDependenciesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *FetchWorkspaceDependenciesResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FetchWorkspaceDependenciesResponse) GetFiles() []*FileResult {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *FetchWorkspaceDependenciesResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Request for the caller's identity
+type WhoAmIRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhoAmIRequest) Reset() {
+	*x = WhoAmIRequest{}
+	mi := &file_monorepo_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhoAmIRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhoAmIRequest) ProtoMessage() {}
+
+func (x *WhoAmIRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhoAmIRequest.ProtoReflect.Descriptor instead.
+func (*WhoAmIRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{96}
+}
+
+// Response describing the caller's identity
+type WhoAmIResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`                                      // Authenticated user name (empty when anonymous)
+	Authenticated bool                   `protobuf:"varint,2,opt,name=authenticated,proto3" json:"authenticated,omitempty"`                   // Whether the request carried valid credentials
+	AuthRequired  bool                   `protobuf:"varint,3,opt,name=auth_required,json=authRequired,proto3" json:"auth_required,omitempty"` // Whether the server requires authentication
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhoAmIResponse) Reset() {
+	*x = WhoAmIResponse{}
+	mi := &file_monorepo_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhoAmIResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhoAmIResponse) ProtoMessage() {}
+
+func (x *WhoAmIResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhoAmIResponse.ProtoReflect.Descriptor instead.
+func (*WhoAmIResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *WhoAmIResponse) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *WhoAmIResponse) GetAuthenticated() bool {
+	if x != nil {
+		return x.Authenticated
+	}
+	return false
+}
+
+func (x *WhoAmIResponse) GetAuthRequired() bool {
+	if x != nil {
+		return x.AuthRequired
+	}
+	return false
+}
+
+// Request for the server's version and capabilities
+type GetServerInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientVersion string                 `protobuf:"bytes,1,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"` // Version of the calling client, for the server log
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServerInfoRequest) Reset() {
+	*x = GetServerInfoRequest{}
+	mi := &file_monorepo_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerInfoRequest) ProtoMessage() {}
+
+func (x *GetServerInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetServerInfoRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *GetServerInfoRequest) GetClientVersion() string {
+	if x != nil {
+		return x.ClientVersion
+	}
+	return ""
+}
+
+// The server's version and capabilities. Clients ignore features they do
+// not know, so a new feature never needs a new API version.
+type GetServerInfoResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServerVersion    string                 `protobuf:"bytes,1,opt,name=server_version,json=serverVersion,proto3" json:"server_version,omitempty"`
+	ApiVersion       string                 `protobuf:"bytes,2,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`                     // Proto package version: "v1" for package monorepo
+	MinClientVersion string                 `protobuf:"bytes,3,opt,name=min_client_version,json=minClientVersion,proto3" json:"min_client_version,omitempty"` // Older clients should be upgraded
+	Features         []string               `protobuf:"bytes,4,rep,name=features,proto3" json:"features,omitempty"`                                           // e.g. "streaming-reads", "conditional-reads", "merge-queue"
+	AuthModes        []string               `protobuf:"bytes,5,rep,name=auth_modes,json=authModes,proto3" json:"auth_modes,omitempty"`                        // "none" when anonymous calls are accepted, "bearer" for tokens
+	HashAlgorithm    string                 `protobuf:"bytes,6,opt,name=hash_algorithm,json=hashAlgorithm,proto3" json:"hash_algorithm,omitempty"`            // How blob and tree hashes are computed: "sha256" or "blake3"
+	WebUrl           string                 `protobuf:"bytes,7,opt,name=web_url,json=webUrl,proto3" json:"web_url,omitempty"`                                 // Base URL of the web UI; "" when the server knows of none
+	HashFormat       string                 `protobuf:"bytes,8,opt,name=hash_format,json=hashFormat,proto3" json:"hash_format,omitempty"`                     // What trees and commits are hashed over: "json" or "canonical/1"
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetServerInfoResponse) Reset() {
+	*x = GetServerInfoResponse{}
+	mi := &file_monorepo_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerInfoResponse) ProtoMessage() {}
+
+func (x *GetServerInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetServerInfoResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *GetServerInfoResponse) GetServerVersion() string {
+	if x != nil {
+		return x.ServerVersion
+	}
+	return ""
+}
+
+func (x *GetServerInfoResponse) GetApiVersion() string {
+	if x != nil {
+		return x.ApiVersion
+	}
+	return ""
+}
+
+func (x *GetServerInfoResponse) GetMinClientVersion() string {
+	if x != nil {
+		return x.MinClientVersion
+	}
+	return ""
+}
+
+func (x *GetServerInfoResponse) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *GetServerInfoResponse) GetAuthModes() []string {
+	if x != nil {
+		return x.AuthModes
+	}
+	return nil
+}
+
+func (x *GetServerInfoResponse) GetHashAlgorithm() string {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return ""
+}
+
+func (x *GetServerInfoResponse) GetWebUrl() string {
+	if x != nil {
+		return x.WebUrl
+	}
+	return ""
+}
+
+func (x *GetServerInfoResponse) GetHashFormat() string {
+	if x != nil {
+		return x.HashFormat
+	}
+	return ""
+}
+
+// An advisory lock on a file or directory
+type PathLock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix timestamp
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Unix timestamp
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathLock) Reset() {
+	*x = PathLock{}
+	mi := &file_monorepo_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathLock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathLock) ProtoMessage() {}
+
+func (x *PathLock) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathLock.ProtoReflect.Descriptor instead.
+func (*PathLock) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *PathLock) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PathLock) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *PathLock) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *PathLock) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+// Request to lock a path
+type LockPathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // Lock lifetime (default: 1 hour)
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`                              // Lock owner when the server does not require authentication
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockPathRequest) Reset() {
+	*x = LockPathRequest{}
+	mi := &file_monorepo_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockPathRequest) ProtoMessage() {}
+
+func (x *LockPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockPathRequest.ProtoReflect.Descriptor instead.
+func (*LockPathRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *LockPathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *LockPathRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *LockPathRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+// Response from locking a path
+type LockPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Lock          *PathLock              `protobuf:"bytes,3,opt,name=lock,proto3" json:"lock,omitempty"` // The acquired lock, or the conflicting lock on failure
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockPathResponse) Reset() {
+	*x = LockPathResponse{}
+	mi := &file_monorepo_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockPathResponse) ProtoMessage() {}
+
+func (x *LockPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockPathResponse.ProtoReflect.Descriptor instead.
+func (*LockPathResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *LockPathResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *LockPathResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LockPathResponse) GetLock() *PathLock {
+	if x != nil {
+		return x.Lock
+	}
+	return nil
+}
+
+// Request to release a path lock
+type UnlockPathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"` // Lock owner when the server does not require authentication
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockPathRequest) Reset() {
+	*x = UnlockPathRequest{}
+	mi := &file_monorepo_proto_msgTypes[103]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockPathRequest) ProtoMessage() {}
+
+func (x *UnlockPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[103]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockPathRequest.ProtoReflect.Descriptor instead.
+func (*UnlockPathRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *UnlockPathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *UnlockPathRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+// Response from releasing a path lock
+type UnlockPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockPathResponse) Reset() {
+	*x = UnlockPathResponse{}
+	mi := &file_monorepo_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockPathResponse) ProtoMessage() {}
+
+func (x *UnlockPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[104]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockPathResponse.ProtoReflect.Descriptor instead.
+func (*UnlockPathResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *UnlockPathResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *UnlockPathResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Request to list path locks
+type ListLocksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PathPrefix    string                 `protobuf:"bytes,1,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"` // Only return locks under this prefix
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLocksRequest) Reset() {
+	*x = ListLocksRequest{}
+	mi := &file_monorepo_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLocksRequest) ProtoMessage() {}
+
+func (x *ListLocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLocksRequest.ProtoReflect.Descriptor instead.
+func (*ListLocksRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *ListLocksRequest) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+// Response listing path locks
+type ListLocksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Locks         []*PathLock            `protobuf:"bytes,1,rep,name=locks,proto3" json:"locks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLocksResponse) Reset() {
+	*x = ListLocksResponse{}
+	mi := &file_monorepo_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLocksResponse) ProtoMessage() {}
+
+func (x *ListLocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLocksResponse.ProtoReflect.Descriptor instead.
+func (*ListLocksResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *ListLocksResponse) GetLocks() []*PathLock {
+	if x != nil {
+		return x.Locks
+	}
+	return nil
+}
+
+// A name pinned to a version
+type Tag struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	CommitHash    string                 `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix timestamp
+	CreatedBy     string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`  // User, or "schedule:NAME" for scheduled snapshots
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tag) Reset() {
+	*x = Tag{}
+	mi := &file_monorepo_proto_msgTypes[107]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tag) ProtoMessage() {}
+
+func (x *Tag) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[107]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tag.ProtoReflect.Descriptor instead.
+func (*Tag) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *Tag) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tag) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Tag) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *Tag) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Tag) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+// Request to list tags
+type ListTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"` // Only return tags whose name starts with this
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsRequest) Reset() {
+	*x = ListTagsRequest{}
+	mi := &file_monorepo_proto_msgTypes[108]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsRequest) ProtoMessage() {}
+
+func (x *ListTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[108]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsRequest.ProtoReflect.Descriptor instead.
+func (*ListTagsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *ListTagsRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+// Response listing tags, sorted by name
+type ListTagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []*Tag                 `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsResponse) Reset() {
+	*x = ListTagsResponse{}
+	mi := &file_monorepo_proto_msgTypes[109]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsResponse) ProtoMessage() {}
+
+func (x *ListTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[109]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsResponse.ProtoReflect.Descriptor instead.
+func (*ListTagsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{109}
+}
+
+func (x *ListTagsResponse) GetTags() []*Tag {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// Request for repository activity
+type GetActivityRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Start          int64                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`                                         // Unix timestamp; default 30 days before end
+	End            int64                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`                                             // Unix timestamp, exclusive; default now
+	GroupBy        string                 `protobuf:"bytes,3,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`                       // "day" (default), "week", "month", "author" or "directory"
+	TimeZone       string                 `protobuf:"bytes,4,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`                    // IANA zone days start in; default UTC
+	Limit          int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`                                         // Most groups for author and directory; default 10
+	DirectoryDepth int32                  `protobuf:"varint,6,opt,name=directory_depth,json=directoryDepth,proto3" json:"directory_depth,omitempty"` // Path components directories are grouped at; default 1
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetActivityRequest) Reset() {
+	*x = GetActivityRequest{}
+	mi := &file_monorepo_proto_msgTypes[110]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetActivityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetActivityRequest) ProtoMessage() {}
+
+func (x *GetActivityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[110]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetActivityRequest.ProtoReflect.Descriptor instead.
+func (*GetActivityRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{110}
+}
+
+func (x *GetActivityRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *GetActivityRequest) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *GetActivityRequest) GetGroupBy() string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return ""
+}
+
+func (x *GetActivityRequest) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *GetActivityRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetActivityRequest) GetDirectoryDepth() int32 {
+	if x != nil {
+		return x.DirectoryDepth
+	}
+	return 0
+}
+
+// Activity of a group of versions
+type ActivityGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`      // "2024-06-01", "2024-06" (months), the author or the directory ("/" for the root)
+	Start         int64                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"` // Unix timestamp the period starts at, for day, week and month
+	Commits       int64                  `protobuf:"varint,3,opt,name=commits,proto3" json:"commits,omitempty"`
+	Authors       int64                  `protobuf:"varint,4,opt,name=authors,proto3" json:"authors,omitempty"` // Distinct authors
+	FilesChanged  int64                  `protobuf:"varint,5,opt,name=files_changed,json=filesChanged,proto3" json:"files_changed,omitempty"`
+	Insertions    int64                  `protobuf:"varint,6,opt,name=insertions,proto3" json:"insertions,omitempty"`
+	Deletions     int64                  `protobuf:"varint,7,opt,name=deletions,proto3" json:"deletions,omitempty"`
+	BytesAdded    int64                  `protobuf:"varint,8,opt,name=bytes_added,json=bytesAdded,proto3" json:"bytes_added,omitempty"` // Size of the files whose content was added or changed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActivityGroup) Reset() {
+	*x = ActivityGroup{}
+	mi := &file_monorepo_proto_msgTypes[111]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActivityGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActivityGroup) ProtoMessage() {}
+
+func (x *ActivityGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[111]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActivityGroup.ProtoReflect.Descriptor instead.
+func (*ActivityGroup) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{111}
+}
+
+func (x *ActivityGroup) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ActivityGroup) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ActivityGroup) GetCommits() int64 {
+	if x != nil {
+		return x.Commits
+	}
+	return 0
+}
+
+func (x *ActivityGroup) GetAuthors() int64 {
+	if x != nil {
+		return x.Authors
+	}
+	return 0
+}
+
+func (x *ActivityGroup) GetFilesChanged() int64 {
+	if x != nil {
+		return x.FilesChanged
+	}
+	return 0
+}
+
+func (x *ActivityGroup) GetInsertions() int64 {
+	if x != nil {
+		return x.Insertions
+	}
+	return 0
+}
+
+func (x *ActivityGroup) GetDeletions() int64 {
+	if x != nil {
+		return x.Deletions
+	}
+	return 0
+}
+
+func (x *ActivityGroup) GetBytesAdded() int64 {
+	if x != nil {
+		return x.BytesAdded
+	}
+	return 0
+}
+
+// Response with activity groups: periods in order, including empty ones, or
+// the most active authors or directories first
+type GetActivityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*ActivityGroup       `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
+	Total         *ActivityGroup         `protobuf:"bytes,2,opt,name=total,proto3" json:"total,omitempty"` // The whole range
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetActivityResponse) Reset() {
+	*x = GetActivityResponse{}
+	mi := &file_monorepo_proto_msgTypes[112]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetActivityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetActivityResponse) ProtoMessage() {}
+
+func (x *GetActivityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[112]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetActivityResponse.ProtoReflect.Descriptor instead.
+func (*GetActivityResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{112}
+}
+
+func (x *GetActivityResponse) GetGroups() []*ActivityGroup {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *GetActivityResponse) GetTotal() *ActivityGroup {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+// Request for quota usage
+type GetQuotaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"` // Also report usage for this workspace (optional)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQuotaRequest) Reset() {
+	*x = GetQuotaRequest{}
+	mi := &file_monorepo_proto_msgTypes[113]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuotaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuotaRequest) ProtoMessage() {}
+
+func (x *GetQuotaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[113]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuotaRequest.ProtoReflect.Descriptor instead.
+func (*GetQuotaRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{113}
+}
+
+func (x *GetQuotaRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+// Usage against quota limits; a limit of 0 means unlimited
+type QuotaUsage struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BytesUsed       int64                  `protobuf:"varint,1,opt,name=bytes_used,json=bytesUsed,proto3" json:"bytes_used,omitempty"`
+	BytesLimit      int64                  `protobuf:"varint,2,opt,name=bytes_limit,json=bytesLimit,proto3" json:"bytes_limit,omitempty"`
+	WorkspacesUsed  int64                  `protobuf:"varint,3,opt,name=workspaces_used,json=workspacesUsed,proto3" json:"workspaces_used,omitempty"`
+	WorkspacesLimit int64                  `protobuf:"varint,4,opt,name=workspaces_limit,json=workspacesLimit,proto3" json:"workspaces_limit,omitempty"`
+	RequestCount    int64                  `protobuf:"varint,5,opt,name=request_count,json=requestCount,proto3" json:"request_count,omitempty"`      // Requests in the current day-long window
+	DiskBytesUsed   int64                  `protobuf:"varint,6,opt,name=disk_bytes_used,json=diskBytesUsed,proto3" json:"disk_bytes_used,omitempty"` // Workspace repository on disk, history included; workspace usage only
+	DiskBytesLimit  int64                  `protobuf:"varint,7,opt,name=disk_bytes_limit,json=diskBytesLimit,proto3" json:"disk_bytes_limit,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *QuotaUsage) Reset() {
+	*x = QuotaUsage{}
+	mi := &file_monorepo_proto_msgTypes[114]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuotaUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuotaUsage) ProtoMessage() {}
+
+func (x *QuotaUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[114]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuotaUsage.ProtoReflect.Descriptor instead.
+func (*QuotaUsage) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{114}
+}
+
+func (x *QuotaUsage) GetBytesUsed() int64 {
+	if x != nil {
+		return x.BytesUsed
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetBytesLimit() int64 {
+	if x != nil {
+		return x.BytesLimit
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetWorkspacesUsed() int64 {
+	if x != nil {
+		return x.WorkspacesUsed
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetWorkspacesLimit() int64 {
+	if x != nil {
+		return x.WorkspacesLimit
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetRequestCount() int64 {
+	if x != nil {
+		return x.RequestCount
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetDiskBytesUsed() int64 {
+	if x != nil {
+		return x.DiskBytesUsed
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetDiskBytesLimit() int64 {
+	if x != nil {
+		return x.DiskBytesLimit
+	}
+	return 0
+}
+
+// Response describing quota usage
+type GetQuotaResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message        string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	User           string                 `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	UserUsage      *QuotaUsage            `protobuf:"bytes,4,opt,name=user_usage,json=userUsage,proto3" json:"user_usage,omitempty"`
+	WorkspaceUsage *QuotaUsage            `protobuf:"bytes,5,opt,name=workspace_usage,json=workspaceUsage,proto3" json:"workspace_usage,omitempty"` // Set when workspace_id was given
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetQuotaResponse) Reset() {
+	*x = GetQuotaResponse{}
+	mi := &file_monorepo_proto_msgTypes[115]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuotaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuotaResponse) ProtoMessage() {}
+
+func (x *GetQuotaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[115]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuotaResponse.ProtoReflect.Descriptor instead.
+func (*GetQuotaResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{115}
+}
+
+func (x *GetQuotaResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *GetQuotaResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GetQuotaResponse) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *GetQuotaResponse) GetUserUsage() *QuotaUsage {
+	if x != nil {
+		return x.UserUsage
+	}
+	return nil
+}
+
+func (x *GetQuotaResponse) GetWorkspaceUsage() *QuotaUsage {
+	if x != nil {
+		return x.WorkspaceUsage
+	}
+	return nil
+}
+
+// Request to approve a patch; approvals are tied to the exact patch bytes
+type ApprovePatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Patch         []byte                 `protobuf:"bytes,1,opt,name=patch,proto3" json:"patch,omitempty"`
+	Approver      string                 `protobuf:"bytes,2,opt,name=approver,proto3" json:"approver,omitempty"` // Used when the server does not require authentication
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApprovePatchRequest) Reset() {
+	*x = ApprovePatchRequest{}
+	mi := &file_monorepo_proto_msgTypes[116]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApprovePatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApprovePatchRequest) ProtoMessage() {}
+
+func (x *ApprovePatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[116]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApprovePatchRequest.ProtoReflect.Descriptor instead.
+func (*ApprovePatchRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{116}
+}
+
+func (x *ApprovePatchRequest) GetPatch() []byte {
+	if x != nil {
+		return x.Patch
+	}
+	return nil
+}
+
+func (x *ApprovePatchRequest) GetApprover() string {
+	if x != nil {
+		return x.Approver
+	}
+	return ""
+}
+
+type ApprovePatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	PatchDigest   string                 `protobuf:"bytes,3,opt,name=patch_digest,json=patchDigest,proto3" json:"patch_digest,omitempty"` // SHA-256 of the patch the approval applies to
+	Approvers     []string               `protobuf:"bytes,4,rep,name=approvers,proto3" json:"approvers,omitempty"`                        // Everyone who has approved the patch so far
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApprovePatchResponse) Reset() {
+	*x = ApprovePatchResponse{}
+	mi := &file_monorepo_proto_msgTypes[117]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApprovePatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApprovePatchResponse) ProtoMessage() {}
+
+func (x *ApprovePatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[117]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApprovePatchResponse.ProtoReflect.Descriptor instead.
+func (*ApprovePatchResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{117}
+}
+
+func (x *ApprovePatchResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ApprovePatchResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ApprovePatchResponse) GetPatchDigest() string {
+	if x != nil {
+		return x.PatchDigest
+	}
+	return ""
+}
+
+func (x *ApprovePatchResponse) GetApprovers() []string {
+	if x != nil {
+		return x.Approvers
+	}
+	return nil
+}
+
+// A change submitted to the merge queue
+type QueueEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Commit message
+	Path          string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	State         QueueEntryState        `protobuf:"varint,5,opt,name=state,proto3,enum=monorepo.QueueEntryState" json:"state,omitempty"`
+	StateMessage  string                 `protobuf:"bytes,6,opt,name=state_message,json=stateMessage,proto3" json:"state_message,omitempty"`     // Why the change failed, or what it is waiting for
+	Position      int32                  `protobuf:"varint,7,opt,name=position,proto3" json:"position,omitempty"`                                // Place in the queue, 1 being the change in progress; 0 once finished
+	BaseVersion   int64                  `protobuf:"varint,8,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`       // Version the change was last rebased onto
+	LandedVersion int64                  `protobuf:"varint,9,opt,name=landed_version,json=landedVersion,proto3" json:"landed_version,omitempty"` // Version created when the change landed
+	CommitHash    string                 `protobuf:"bytes,10,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+	SubmittedAt   int64                  `protobuf:"varint,11,opt,name=submitted_at,json=submittedAt,proto3" json:"submitted_at,omitempty"` // Unix timestamp
+	UpdatedAt     int64                  `protobuf:"varint,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`       // Unix timestamp of the last state change
+	DetailsUrl    string                 `protobuf:"bytes,13,opt,name=details_url,json=detailsUrl,proto3" json:"details_url,omitempty"`     // Link reported by the validator, such as a CI run
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueEntry) Reset() {
+	*x = QueueEntry{}
+	mi := &file_monorepo_proto_msgTypes[118]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueEntry) ProtoMessage() {}
+
+func (x *QueueEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[118]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueEntry.ProtoReflect.Descriptor instead.
+func (*QueueEntry) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{118}
+}
+
+func (x *QueueEntry) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetState() QueueEntryState {
+	if x != nil {
+		return x.State
+	}
+	return QueueEntryState_QUEUE_PENDING
+}
+
+func (x *QueueEntry) GetStateMessage() string {
+	if x != nil {
+		return x.StateMessage
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *QueueEntry) GetBaseVersion() int64 {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return 0
+}
+
+func (x *QueueEntry) GetLandedVersion() int64 {
+	if x != nil {
+		return x.LandedVersion
+	}
+	return 0
+}
+
+func (x *QueueEntry) GetCommitHash() string {
+	if x != nil {
+		return x.CommitHash
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetSubmittedAt() int64 {
+	if x != nil {
+		return x.SubmittedAt
+	}
+	return 0
+}
+
+func (x *QueueEntry) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *QueueEntry) GetDetailsUrl() string {
+	if x != nil {
+		return x.DetailsUrl
+	}
+	return ""
+}
+
+type GetMergeQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntryId       string                 `protobuf:"bytes,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"` // Only report this entry (default: all pending and recently finished)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMergeQueueRequest) Reset() {
+	*x = GetMergeQueueRequest{}
+	mi := &file_monorepo_proto_msgTypes[119]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMergeQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMergeQueueRequest) ProtoMessage() {}
+
+func (x *GetMergeQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[119]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMergeQueueRequest.ProtoReflect.Descriptor instead.
+func (*GetMergeQueueRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{119}
+}
+
+func (x *GetMergeQueueRequest) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+type GetMergeQueueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"` // Whether MergePatch goes through the queue
+	Entries       []*QueueEntry          `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`  // Pending entries in queue order, then finished ones, newest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMergeQueueResponse) Reset() {
+	*x = GetMergeQueueResponse{}
+	mi := &file_monorepo_proto_msgTypes[120]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMergeQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMergeQueueResponse) ProtoMessage() {}
+
+func (x *GetMergeQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[120]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMergeQueueResponse.ProtoReflect.Descriptor instead.
+func (*GetMergeQueueResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{120}
+}
+
+func (x *GetMergeQueueResponse) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *GetMergeQueueResponse) GetEntries() []*QueueEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ReportQueueValidationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntryId       string                 `protobuf:"bytes,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"` // Callback token from the webhook that requested this validation
+	Passed        bool                   `protobuf:"varint,3,opt,name=passed,proto3" json:"passed,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`                         // Shown to the author when validation fails
+	DetailsUrl    string                 `protobuf:"bytes,5,opt,name=details_url,json=detailsUrl,proto3" json:"details_url,omitempty"` // Link to the validation run
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportQueueValidationRequest) Reset() {
+	*x = ReportQueueValidationRequest{}
+	mi := &file_monorepo_proto_msgTypes[121]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportQueueValidationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportQueueValidationRequest) ProtoMessage() {}
+
+func (x *ReportQueueValidationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[121]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportQueueValidationRequest.ProtoReflect.Descriptor instead.
+func (*ReportQueueValidationRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{121}
+}
+
+func (x *ReportQueueValidationRequest) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *ReportQueueValidationRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ReportQueueValidationRequest) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+func (x *ReportQueueValidationRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReportQueueValidationRequest) GetDetailsUrl() string {
+	if x != nil {
+		return x.DetailsUrl
+	}
+	return ""
+}
+
+type ReportQueueValidationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportQueueValidationResponse) Reset() {
+	*x = ReportQueueValidationResponse{}
+	mi := &file_monorepo_proto_msgTypes[122]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportQueueValidationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportQueueValidationResponse) ProtoMessage() {}
+
+func (x *ReportQueueValidationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[122]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportQueueValidationResponse.ProtoReflect.Descriptor instead.
+func (*ReportQueueValidationResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{122}
+}
+
+func (x *ReportQueueValidationResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReportQueueValidationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// A file deleted by a patch. Its content is still in the versions before
+// deleted_version.
+type DeletedPath struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Hash           string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // Blob holding the file's last content
+	Mode           int32                  `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	Size           int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	DeletedVersion int64                  `protobuf:"varint,5,opt,name=deleted_version,json=deletedVersion,proto3" json:"deleted_version,omitempty"` // Version that deleted the file
+	DeletedBy      string                 `protobuf:"bytes,6,opt,name=deleted_by,json=deletedBy,proto3" json:"deleted_by,omitempty"`
+	DeletedAt      int64                  `protobuf:"varint,7,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"` // Unix timestamp
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeletedPath) Reset() {
+	*x = DeletedPath{}
+	mi := &file_monorepo_proto_msgTypes[123]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletedPath) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletedPath) ProtoMessage() {}
+
+func (x *DeletedPath) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[123]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletedPath.ProtoReflect.Descriptor instead.
+func (*DeletedPath) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{123}
+}
+
+func (x *DeletedPath) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DeletedPath) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *DeletedPath) GetMode() int32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *DeletedPath) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DeletedPath) GetDeletedVersion() int64 {
+	if x != nil {
+		return x.DeletedVersion
+	}
+	return 0
+}
+
+func (x *DeletedPath) GetDeletedBy() string {
+	if x != nil {
+		return x.DeletedBy
+	}
+	return ""
+}
+
+func (x *DeletedPath) GetDeletedAt() int64 {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return 0
+}
+
+type ListDeletedPathsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Only list deleted files at or below this path (default: all)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeletedPathsRequest) Reset() {
+	*x = ListDeletedPathsRequest{}
+	mi := &file_monorepo_proto_msgTypes[124]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeletedPathsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeletedPathsRequest) ProtoMessage() {}
+
+func (x *ListDeletedPathsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[124]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeletedPathsRequest.ProtoReflect.Descriptor instead.
+func (*ListDeletedPathsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{124}
+}
+
+func (x *ListDeletedPathsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ListDeletedPathsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []*DeletedPath         `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"` // Sorted by path
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeletedPathsResponse) Reset() {
+	*x = ListDeletedPathsResponse{}
+	mi := &file_monorepo_proto_msgTypes[125]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeletedPathsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeletedPathsResponse) ProtoMessage() {}
+
+func (x *ListDeletedPathsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[125]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeletedPathsResponse.ProtoReflect.Descriptor instead.
+func (*ListDeletedPathsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{125}
+}
+
+func (x *ListDeletedPathsResponse) GetPaths() []*DeletedPath {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type RestoreDeletedPathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Deleted file, or a directory to restore every deleted file below
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Commit message (default: "Restore <path>")
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreDeletedPathRequest) Reset() {
+	*x = RestoreDeletedPathRequest{}
+	mi := &file_monorepo_proto_msgTypes[126]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreDeletedPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreDeletedPathRequest) ProtoMessage() {}
+
+func (x *RestoreDeletedPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[126]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreDeletedPathRequest.ProtoReflect.Descriptor instead.
+func (*RestoreDeletedPathRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{126}
+}
+
+func (x *RestoreDeletedPathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RestoreDeletedPathRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *RestoreDeletedPathRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RestoreDeletedPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // Version that restored the files
+	Restored      []*DeletedPath         `protobuf:"bytes,4,rep,name=restored,proto3" json:"restored,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreDeletedPathResponse) Reset() {
+	*x = RestoreDeletedPathResponse{}
+	mi := &file_monorepo_proto_msgTypes[127]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreDeletedPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreDeletedPathResponse) ProtoMessage() {}
+
+func (x *RestoreDeletedPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[127]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreDeletedPathResponse.ProtoReflect.Descriptor instead.
+func (*RestoreDeletedPathResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{127}
+}
+
+func (x *RestoreDeletedPathResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *RestoreDeletedPathResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RestoreDeletedPathResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RestoreDeletedPathResponse) GetRestored() []*DeletedPath {
+	if x != nil {
+		return x.Restored
+	}
+	return nil
+}
+
+type GarbageCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DryRun        bool                   `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"` // Report what would be removed without deleting
+	Async         bool                   `protobuf:"varint,2,opt,name=async,proto3" json:"async,omitempty"`                 // Return at once with an operation whose response is the GarbageCollectionResponse
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GarbageCollectionRequest) Reset() {
+	*x = GarbageCollectionRequest{}
+	mi := &file_monorepo_proto_msgTypes[128]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GarbageCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GarbageCollectionRequest) ProtoMessage() {}
+
+func (x *GarbageCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[128]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GarbageCollectionRequest.ProtoReflect.Descriptor instead.
+func (*GarbageCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{128}
+}
+
+func (x *GarbageCollectionRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *GarbageCollectionRequest) GetAsync() bool {
+	if x != nil {
+		return x.Async
+	}
+	return false
+}
+
+type GarbageCollectionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scanned       int64                  `protobuf:"varint,1,opt,name=scanned,proto3" json:"scanned,omitempty"`
+	Reachable     int64                  `protobuf:"varint,2,opt,name=reachable,proto3" json:"reachable,omitempty"`
+	Removed       int64                  `protobuf:"varint,3,opt,name=removed,proto3" json:"removed,omitempty"`
+	BytesFreed    int64                  `protobuf:"varint,4,opt,name=bytes_freed,json=bytesFreed,proto3" json:"bytes_freed,omitempty"`
+	OperationId   string                 `protobuf:"bytes,5,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"` // With async: the operation collecting garbage
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GarbageCollectionResponse) Reset() {
+	*x = GarbageCollectionResponse{}
+	mi := &file_monorepo_proto_msgTypes[129]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GarbageCollectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GarbageCollectionResponse) ProtoMessage() {}
+
+func (x *GarbageCollectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[129]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GarbageCollectionResponse.ProtoReflect.Descriptor instead.
+func (*GarbageCollectionResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{129}
+}
+
+func (x *GarbageCollectionResponse) GetScanned() int64 {
+	if x != nil {
+		return x.Scanned
+	}
+	return 0
+}
+
+func (x *GarbageCollectionResponse) GetReachable() int64 {
+	if x != nil {
+		return x.Reachable
+	}
+	return 0
+}
+
+func (x *GarbageCollectionResponse) GetRemoved() int64 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+func (x *GarbageCollectionResponse) GetBytesFreed() int64 {
+	if x != nil {
+		return x.BytesFreed
+	}
+	return 0
+}
+
+func (x *GarbageCollectionResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+type FsckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Async         bool                   `protobuf:"varint,1,opt,name=async,proto3" json:"async,omitempty"` // Return at once with an operation whose response is the FsckResponse
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FsckRequest) Reset() {
+	*x = FsckRequest{}
+	mi := &file_monorepo_proto_msgTypes[130]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FsckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FsckRequest) ProtoMessage() {}
+
+func (x *FsckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[130]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FsckRequest.ProtoReflect.Descriptor instead.
+func (*FsckRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{130}
+}
+
+func (x *FsckRequest) GetAsync() bool {
+	if x != nil {
+		return x.Async
+	}
+	return false
+}
+
+type FsckResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ObjectsChecked     int64                  `protobuf:"varint,1,opt,name=objects_checked,json=objectsChecked,proto3" json:"objects_checked,omitempty"`
+	VersionsChecked    int64                  `protobuf:"varint,2,opt,name=versions_checked,json=versionsChecked,proto3" json:"versions_checked,omitempty"`
+	Problems           []string               `protobuf:"bytes,3,rep,name=problems,proto3" json:"problems,omitempty"`
+	HashAlgorithm      string                 `protobuf:"bytes,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3" json:"hash_algorithm,omitempty"`                                                                                             // What new objects are hashed with
+	ObjectsByAlgorithm map[string]int64       `protobuf:"bytes,5,rep,name=objects_by_algorithm,json=objectsByAlgorithm,proto3" json:"objects_by_algorithm,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // Stored objects per hash algorithm
+	OperationId        string                 `protobuf:"bytes,6,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`                                                                                                   // With async: the operation checking the repository
+	HashFormat         string                 `protobuf:"bytes,7,opt,name=hash_format,json=hashFormat,proto3" json:"hash_format,omitempty"`                                                                                                      // What new trees and commits are hashed over
+	ObjectsByFormat    map[string]int64       `protobuf:"bytes,8,rep,name=objects_by_format,json=objectsByFormat,proto3" json:"objects_by_format,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`          // Stored trees and commits per hash format
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *FsckResponse) Reset() {
+	*x = FsckResponse{}
+	mi := &file_monorepo_proto_msgTypes[131]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FsckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FsckResponse) ProtoMessage() {}
+
+func (x *FsckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[131]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FsckResponse.ProtoReflect.Descriptor instead.
+func (*FsckResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{131}
+}
+
+func (x *FsckResponse) GetObjectsChecked() int64 {
+	if x != nil {
+		return x.ObjectsChecked
+	}
+	return 0
+}
+
+func (x *FsckResponse) GetVersionsChecked() int64 {
+	if x != nil {
+		return x.VersionsChecked
+	}
+	return 0
+}
+
+func (x *FsckResponse) GetProblems() []string {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+func (x *FsckResponse) GetHashAlgorithm() string {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return ""
+}
+
+func (x *FsckResponse) GetObjectsByAlgorithm() map[string]int64 {
+	if x != nil {
+		return x.ObjectsByAlgorithm
+	}
+	return nil
+}
+
+func (x *FsckResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *FsckResponse) GetHashFormat() string {
+	if x != nil {
+		return x.HashFormat
+	}
+	return ""
+}
+
+func (x *FsckResponse) GetObjectsByFormat() map[string]int64 {
+	if x != nil {
+		return x.ObjectsByFormat
+	}
+	return nil
+}
+
+type BackendStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackendStatsRequest) Reset() {
+	*x = BackendStatsRequest{}
+	mi := &file_monorepo_proto_msgTypes[132]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackendStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackendStatsRequest) ProtoMessage() {}
+
+func (x *BackendStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[132]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackendStatsRequest.ProtoReflect.Descriptor instead.
+func (*BackendStatsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{132}
+}
+
+type BackendStatsResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Objects              int64                  `protobuf:"varint,1,opt,name=objects,proto3" json:"objects,omitempty"`
+	Blobs                int64                  `protobuf:"varint,2,opt,name=blobs,proto3" json:"blobs,omitempty"`
+	Trees                int64                  `protobuf:"varint,3,opt,name=trees,proto3" json:"trees,omitempty"`
+	Commits              int64                  `protobuf:"varint,4,opt,name=commits,proto3" json:"commits,omitempty"`
+	ObjectBytes          int64                  `protobuf:"varint,5,opt,name=object_bytes,json=objectBytes,proto3" json:"object_bytes,omitempty"`
+	Versions             int64                  `protobuf:"varint,6,opt,name=versions,proto3" json:"versions,omitempty"`
+	CurrentVersion       int64                  `protobuf:"varint,7,opt,name=current_version,json=currentVersion,proto3" json:"current_version,omitempty"`
+	Keys                 int64                  `protobuf:"varint,8,opt,name=keys,proto3" json:"keys,omitempty"`
+	TotalBytes           int64                  `protobuf:"varint,9,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	Workspaces           int64                  `protobuf:"varint,10,opt,name=workspaces,proto3" json:"workspaces,omitempty"`
+	Locks                int64                  `protobuf:"varint,11,opt,name=locks,proto3" json:"locks,omitempty"`
+	ArchiveCacheEntries  int64                  `protobuf:"varint,12,opt,name=archive_cache_entries,json=archiveCacheEntries,proto3" json:"archive_cache_entries,omitempty"` // Cached DownloadPath and CAS archives
+	ArchiveCacheBytes    int64                  `protobuf:"varint,13,opt,name=archive_cache_bytes,json=archiveCacheBytes,proto3" json:"archive_cache_bytes,omitempty"`
+	ArchiveCacheMaxBytes int64                  `protobuf:"varint,14,opt,name=archive_cache_max_bytes,json=archiveCacheMaxBytes,proto3" json:"archive_cache_max_bytes,omitempty"` // 0 when the cache is disabled
+	ArchiveCacheHits     int64                  `protobuf:"varint,15,opt,name=archive_cache_hits,json=archiveCacheHits,proto3" json:"archive_cache_hits,omitempty"`               // Since the server started
+	ArchiveCacheMisses   int64                  `protobuf:"varint,16,opt,name=archive_cache_misses,json=archiveCacheMisses,proto3" json:"archive_cache_misses,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *BackendStatsResponse) Reset() {
+	*x = BackendStatsResponse{}
+	mi := &file_monorepo_proto_msgTypes[133]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackendStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackendStatsResponse) ProtoMessage() {}
+
+func (x *BackendStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[133]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackendStatsResponse.ProtoReflect.Descriptor instead.
+func (*BackendStatsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{133}
+}
+
+func (x *BackendStatsResponse) GetObjects() int64 {
+	if x != nil {
+		return x.Objects
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetBlobs() int64 {
+	if x != nil {
+		return x.Blobs
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetTrees() int64 {
+	if x != nil {
+		return x.Trees
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetCommits() int64 {
+	if x != nil {
+		return x.Commits
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetObjectBytes() int64 {
+	if x != nil {
+		return x.ObjectBytes
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetVersions() int64 {
+	if x != nil {
+		return x.Versions
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetCurrentVersion() int64 {
+	if x != nil {
+		return x.CurrentVersion
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetKeys() int64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetWorkspaces() int64 {
+	if x != nil {
+		return x.Workspaces
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetLocks() int64 {
+	if x != nil {
+		return x.Locks
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetArchiveCacheEntries() int64 {
+	if x != nil {
+		return x.ArchiveCacheEntries
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetArchiveCacheBytes() int64 {
+	if x != nil {
+		return x.ArchiveCacheBytes
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetArchiveCacheMaxBytes() int64 {
+	if x != nil {
+		return x.ArchiveCacheMaxBytes
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetArchiveCacheHits() int64 {
+	if x != nil {
+		return x.ArchiveCacheHits
+	}
+	return 0
+}
+
+func (x *BackendStatsResponse) GetArchiveCacheMisses() int64 {
+	if x != nil {
+		return x.ArchiveCacheMisses
+	}
+	return 0
+}
+
+type SetUserQuotaRequest struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	User                  string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	MaxWorkspaceBytes     int64                  `protobuf:"varint,2,opt,name=max_workspace_bytes,json=maxWorkspaceBytes,proto3" json:"max_workspace_bytes,omitempty"`               // 0 means unlimited
+	MaxUserBytes          int64                  `protobuf:"varint,3,opt,name=max_user_bytes,json=maxUserBytes,proto3" json:"max_user_bytes,omitempty"`                              // 0 means unlimited
+	MaxUserWorkspaces     int64                  `protobuf:"varint,4,opt,name=max_user_workspaces,json=maxUserWorkspaces,proto3" json:"max_user_workspaces,omitempty"`               // 0 means unlimited
+	MaxFileBytes          int64                  `protobuf:"varint,5,opt,name=max_file_bytes,json=maxFileBytes,proto3" json:"max_file_bytes,omitempty"`                              // Largest file a tracked path may contain; 0 means unlimited
+	MaxTrackedPathBytes   int64                  `protobuf:"varint,6,opt,name=max_tracked_path_bytes,json=maxTrackedPathBytes,proto3" json:"max_tracked_path_bytes,omitempty"`       // Bytes under a single tracked path; 0 means unlimited
+	AllowSizeOverride     bool                   `protobuf:"varint,7,opt,name=allow_size_override,json=allowSizeOverride,proto3" json:"allow_size_override,omitempty"`               // May skip the two limits above with override_size_limits
+	MaxWorkspaceDiskBytes int64                  `protobuf:"varint,8,opt,name=max_workspace_disk_bytes,json=maxWorkspaceDiskBytes,proto3" json:"max_workspace_disk_bytes,omitempty"` // Disk per workspace repository, history included; 0 means unlimited
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *SetUserQuotaRequest) Reset() {
+	*x = SetUserQuotaRequest{}
+	mi := &file_monorepo_proto_msgTypes[134]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserQuotaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserQuotaRequest) ProtoMessage() {}
+
+func (x *SetUserQuotaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[134]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserQuotaRequest.ProtoReflect.Descriptor instead.
+func (*SetUserQuotaRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{134}
+}
+
+func (x *SetUserQuotaRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *SetUserQuotaRequest) GetMaxWorkspaceBytes() int64 {
+	if x != nil {
+		return x.MaxWorkspaceBytes
+	}
+	return 0
+}
+
+func (x *SetUserQuotaRequest) GetMaxUserBytes() int64 {
+	if x != nil {
+		return x.MaxUserBytes
+	}
+	return 0
+}
+
+func (x *SetUserQuotaRequest) GetMaxUserWorkspaces() int64 {
+	if x != nil {
+		return x.MaxUserWorkspaces
+	}
+	return 0
+}
+
+func (x *SetUserQuotaRequest) GetMaxFileBytes() int64 {
+	if x != nil {
+		return x.MaxFileBytes
+	}
+	return 0
+}
+
+func (x *SetUserQuotaRequest) GetMaxTrackedPathBytes() int64 {
+	if x != nil {
+		return x.MaxTrackedPathBytes
+	}
+	return 0
+}
+
+func (x *SetUserQuotaRequest) GetAllowSizeOverride() bool {
+	if x != nil {
+		return x.AllowSizeOverride
+	}
+	return false
+}
+
+func (x *SetUserQuotaRequest) GetMaxWorkspaceDiskBytes() int64 {
+	if x != nil {
+		return x.MaxWorkspaceDiskBytes
+	}
+	return 0
+}
+
+type SetUserQuotaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetUserQuotaResponse) Reset() {
+	*x = SetUserQuotaResponse{}
+	mi := &file_monorepo_proto_msgTypes[135]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserQuotaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserQuotaResponse) ProtoMessage() {}
+
+func (x *SetUserQuotaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[135]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserQuotaResponse.ProtoReflect.Descriptor instead.
+func (*SetUserQuotaResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{135}
+}
+
+func (x *SetUserQuotaResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SetUserQuotaResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SetWorkspaceQuotaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	MaxBytes      int64                  `protobuf:"varint,2,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"` // 0 removes the override
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetWorkspaceQuotaRequest) Reset() {
+	*x = SetWorkspaceQuotaRequest{}
+	mi := &file_monorepo_proto_msgTypes[136]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetWorkspaceQuotaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetWorkspaceQuotaRequest) ProtoMessage() {}
+
+func (x *SetWorkspaceQuotaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[136]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetWorkspaceQuotaRequest.ProtoReflect.Descriptor instead.
+func (*SetWorkspaceQuotaRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{136}
+}
+
+func (x *SetWorkspaceQuotaRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *SetWorkspaceQuotaRequest) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+type SetWorkspaceQuotaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetWorkspaceQuotaResponse) Reset() {
+	*x = SetWorkspaceQuotaResponse{}
+	mi := &file_monorepo_proto_msgTypes[137]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetWorkspaceQuotaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetWorkspaceQuotaResponse) ProtoMessage() {}
+
+func (x *SetWorkspaceQuotaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[137]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetWorkspaceQuotaResponse.ProtoReflect.Descriptor instead.
+func (*SetWorkspaceQuotaResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{137}
+}
+
+func (x *SetWorkspaceQuotaResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SetWorkspaceQuotaResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ForceUnlockPathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceUnlockPathRequest) Reset() {
+	*x = ForceUnlockPathRequest{}
+	mi := &file_monorepo_proto_msgTypes[138]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceUnlockPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceUnlockPathRequest) ProtoMessage() {}
+
+func (x *ForceUnlockPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[138]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceUnlockPathRequest.ProtoReflect.Descriptor instead.
+func (*ForceUnlockPathRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{138}
+}
+
+func (x *ForceUnlockPathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ForceUnlockPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Lock          *PathLock              `protobuf:"bytes,3,opt,name=lock,proto3" json:"lock,omitempty"` // The lock that was removed, if any
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceUnlockPathResponse) Reset() {
+	*x = ForceUnlockPathResponse{}
+	mi := &file_monorepo_proto_msgTypes[139]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceUnlockPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceUnlockPathResponse) ProtoMessage() {}
+
+func (x *ForceUnlockPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[139]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceUnlockPathResponse.ProtoReflect.Descriptor instead.
+func (*ForceUnlockPathResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{139}
+}
+
+func (x *ForceUnlockPathResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ForceUnlockPathResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ForceUnlockPathResponse) GetLock() *PathLock {
+	if x != nil {
+		return x.Lock
+	}
+	return nil
+}
+
+type ListWorkspacesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StaleSeconds  int64                  `protobuf:"varint,1,opt,name=stale_seconds,json=staleSeconds,proto3" json:"stale_seconds,omitempty"` // Only list workspaces not synced for this long
+	DivergedOnly  bool                   `protobuf:"varint,2,opt,name=diverged_only,json=divergedOnly,proto3" json:"diverged_only,omitempty"` // Only list workspaces that have diverged
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`                                    // Only list workspaces owned by this user
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkspacesRequest) Reset() {
+	*x = ListWorkspacesRequest{}
+	mi := &file_monorepo_proto_msgTypes[140]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkspacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkspacesRequest) ProtoMessage() {}
+
+func (x *ListWorkspacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[140]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkspacesRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkspacesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{140}
+}
+
+func (x *ListWorkspacesRequest) GetStaleSeconds() int64 {
+	if x != nil {
+		return x.StaleSeconds
+	}
+	return 0
+}
+
+func (x *ListWorkspacesRequest) GetDivergedOnly() bool {
+	if x != nil {
+		return x.DivergedOnly
+	}
+	return false
+}
+
+func (x *ListWorkspacesRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type ListWorkspacesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workspaces    []*WorkspaceInfo       `protobuf:"bytes,1,rep,name=workspaces,proto3" json:"workspaces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkspacesResponse) Reset() {
+	*x = ListWorkspacesResponse{}
+	mi := &file_monorepo_proto_msgTypes[141]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkspacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkspacesResponse) ProtoMessage() {}
+
+func (x *ListWorkspacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[141]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkspacesResponse.ProtoReflect.Descriptor instead.
+func (*ListWorkspacesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{141}
+}
+
+func (x *ListWorkspacesResponse) GetWorkspaces() []*WorkspaceInfo {
+	if x != nil {
+		return x.Workspaces
+	}
+	return nil
+}
+
+type ReapWorkspacesRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MaxIdleSeconds int64                  `protobuf:"varint,1,opt,name=max_idle_seconds,json=maxIdleSeconds,proto3" json:"max_idle_seconds,omitempty"` // Reap workspaces not synced for this long
+	DryRun         bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReapWorkspacesRequest) Reset() {
+	*x = ReapWorkspacesRequest{}
+	mi := &file_monorepo_proto_msgTypes[142]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReapWorkspacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReapWorkspacesRequest) ProtoMessage() {}
+
+func (x *ReapWorkspacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[142]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReapWorkspacesRequest.ProtoReflect.Descriptor instead.
+func (*ReapWorkspacesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{142}
+}
+
+func (x *ReapWorkspacesRequest) GetMaxIdleSeconds() int64 {
+	if x != nil {
+		return x.MaxIdleSeconds
+	}
+	return 0
+}
+
+func (x *ReapWorkspacesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ReapWorkspacesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceIds  []string               `protobuf:"bytes,1,rep,name=workspace_ids,json=workspaceIds,proto3" json:"workspace_ids,omitempty"` // Workspaces reaped (or that would be)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReapWorkspacesResponse) Reset() {
+	*x = ReapWorkspacesResponse{}
+	mi := &file_monorepo_proto_msgTypes[143]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReapWorkspacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReapWorkspacesResponse) ProtoMessage() {}
+
+func (x *ReapWorkspacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[143]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReapWorkspacesResponse.ProtoReflect.Descriptor instead.
+func (*ReapWorkspacesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{143}
+}
+
+func (x *ReapWorkspacesResponse) GetWorkspaceIds() []string {
+	if x != nil {
+		return x.WorkspaceIds
+	}
+	return nil
+}
+
+type CollectWorkspaceDirectoriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DryRun        bool                   `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	GraceSeconds  int64                  `protobuf:"varint,2,opt,name=grace_seconds,json=graceSeconds,proto3" json:"grace_seconds,omitempty"` // Keep directories changed this recently; 0 uses WORKSPACE_GC_GRACE
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectWorkspaceDirectoriesRequest) Reset() {
+	*x = CollectWorkspaceDirectoriesRequest{}
+	mi := &file_monorepo_proto_msgTypes[144]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectWorkspaceDirectoriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectWorkspaceDirectoriesRequest) ProtoMessage() {}
+
+func (x *CollectWorkspaceDirectoriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[144]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectWorkspaceDirectoriesRequest.ProtoReflect.Descriptor instead.
+func (*CollectWorkspaceDirectoriesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{144}
+}
+
+func (x *CollectWorkspaceDirectoriesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *CollectWorkspaceDirectoriesRequest) GetGraceSeconds() int64 {
+	if x != nil {
+		return x.GraceSeconds
+	}
+	return 0
+}
+
+type CollectWorkspaceDirectoriesResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Directories      []*OrphanedDirectory   `protobuf:"bytes,1,rep,name=directories,proto3" json:"directories,omitempty"` // Directories removed (or that would be)
+	BytesFreed       int64                  `protobuf:"varint,2,opt,name=bytes_freed,json=bytesFreed,proto3" json:"bytes_freed,omitempty"`
+	SharedObjects    int64                  `protobuf:"varint,3,opt,name=shared_objects,json=sharedObjects,proto3" json:"shared_objects,omitempty"` // Objects in the shared object directory no workspace uses any more
+	SharedBytesFreed int64                  `protobuf:"varint,4,opt,name=shared_bytes_freed,json=sharedBytesFreed,proto3" json:"shared_bytes_freed,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CollectWorkspaceDirectoriesResponse) Reset() {
+	*x = CollectWorkspaceDirectoriesResponse{}
+	mi := &file_monorepo_proto_msgTypes[145]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectWorkspaceDirectoriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectWorkspaceDirectoriesResponse) ProtoMessage() {}
+
+func (x *CollectWorkspaceDirectoriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[145]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectWorkspaceDirectoriesResponse.ProtoReflect.Descriptor instead.
+func (*CollectWorkspaceDirectoriesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{145}
+}
+
+func (x *CollectWorkspaceDirectoriesResponse) GetDirectories() []*OrphanedDirectory {
+	if x != nil {
+		return x.Directories
+	}
+	return nil
+}
+
+func (x *CollectWorkspaceDirectoriesResponse) GetBytesFreed() int64 {
+	if x != nil {
+		return x.BytesFreed
+	}
+	return 0
+}
+
+func (x *CollectWorkspaceDirectoriesResponse) GetSharedObjects() int64 {
+	if x != nil {
+		return x.SharedObjects
+	}
+	return 0
+}
+
+func (x *CollectWorkspaceDirectoriesResponse) GetSharedBytesFreed() int64 {
+	if x != nil {
+		return x.SharedBytesFreed
+	}
+	return 0
+}
+
+type CompactWorkspacesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"` // ID or name; empty compacts every workspace
+	Depth         int64                  `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`                               // Commits to keep; 0 uses WORKSPACE_HISTORY_DEPTH
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactWorkspacesRequest) Reset() {
+	*x = CompactWorkspacesRequest{}
+	mi := &file_monorepo_proto_msgTypes[146]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactWorkspacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactWorkspacesRequest) ProtoMessage() {}
+
+func (x *CompactWorkspacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[146]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactWorkspacesRequest.ProtoReflect.Descriptor instead.
+func (*CompactWorkspacesRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{146}
+}
+
+func (x *CompactWorkspacesRequest) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *CompactWorkspacesRequest) GetDepth() int64 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type CompactWorkspacesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workspaces    []*WorkspaceCompaction `protobuf:"bytes,1,rep,name=workspaces,proto3" json:"workspaces,omitempty"`
+	BytesFreed    int64                  `protobuf:"varint,2,opt,name=bytes_freed,json=bytesFreed,proto3" json:"bytes_freed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactWorkspacesResponse) Reset() {
+	*x = CompactWorkspacesResponse{}
+	mi := &file_monorepo_proto_msgTypes[147]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactWorkspacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactWorkspacesResponse) ProtoMessage() {}
+
+func (x *CompactWorkspacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[147]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactWorkspacesResponse.ProtoReflect.Descriptor instead.
+func (*CompactWorkspacesResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{147}
+}
+
+func (x *CompactWorkspacesResponse) GetWorkspaces() []*WorkspaceCompaction {
+	if x != nil {
+		return x.Workspaces
+	}
+	return nil
+}
+
+func (x *CompactWorkspacesResponse) GetBytesFreed() int64 {
+	if x != nil {
+		return x.BytesFreed
+	}
+	return 0
+}
+
+// A workspace repository before and after compaction
+type WorkspaceCompaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Depth         int64                  `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"` // Commits kept; 0 when the history was left alone
+	CommitsBefore int64                  `protobuf:"varint,3,opt,name=commits_before,json=commitsBefore,proto3" json:"commits_before,omitempty"`
+	CommitsAfter  int64                  `protobuf:"varint,4,opt,name=commits_after,json=commitsAfter,proto3" json:"commits_after,omitempty"`
+	BytesBefore   int64                  `protobuf:"varint,5,opt,name=bytes_before,json=bytesBefore,proto3" json:"bytes_before,omitempty"`
+	BytesAfter    int64                  `protobuf:"varint,6,opt,name=bytes_after,json=bytesAfter,proto3" json:"bytes_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceCompaction) Reset() {
+	*x = WorkspaceCompaction{}
+	mi := &file_monorepo_proto_msgTypes[148]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceCompaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceCompaction) ProtoMessage() {}
+
+func (x *WorkspaceCompaction) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[148]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceCompaction.ProtoReflect.Descriptor instead.
+func (*WorkspaceCompaction) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{148}
+}
+
+func (x *WorkspaceCompaction) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *WorkspaceCompaction) GetDepth() int64 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *WorkspaceCompaction) GetCommitsBefore() int64 {
+	if x != nil {
+		return x.CommitsBefore
+	}
+	return 0
+}
+
+func (x *WorkspaceCompaction) GetCommitsAfter() int64 {
+	if x != nil {
+		return x.CommitsAfter
+	}
+	return 0
+}
+
+func (x *WorkspaceCompaction) GetBytesBefore() int64 {
+	if x != nil {
+		return x.BytesBefore
+	}
+	return 0
+}
+
+func (x *WorkspaceCompaction) GetBytesAfter() int64 {
+	if x != nil {
+		return x.BytesAfter
+	}
+	return 0
+}
+
+// A directory in the workspace root without a workspace
+type OrphanedDirectory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                               // Directory name, the workspace ID for directories the server made
+	ModifiedAt    string                 `protobuf:"bytes,2,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"` // RFC 3339
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrphanedDirectory) Reset() {
+	*x = OrphanedDirectory{}
+	mi := &file_monorepo_proto_msgTypes[149]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrphanedDirectory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrphanedDirectory) ProtoMessage() {}
+
+func (x *OrphanedDirectory) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[149]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrphanedDirectory.ProtoReflect.Descriptor instead.
+func (*OrphanedDirectory) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{149}
+}
+
+func (x *OrphanedDirectory) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OrphanedDirectory) GetModifiedAt() string {
 	if x != nil {
-		return x.Timestamp
+		return x.ModifiedAt
 	}
-	return 0
+	return ""
 }
 
-func (x *Commit) GetChangedFiles() []string {
+func (x *OrphanedDirectory) GetBytes() int64 {
 	if x != nil {
-		return x.ChangedFiles
+		return x.Bytes
 	}
-	return nil
+	return 0
 }
 
-// Request for available branches
-type BranchesRequest struct {
+type BackupRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Destination   string                 `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"` // Directory on the server host, or s3://bucket/prefix
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BranchesRequest) Reset() {
-	*x = BranchesRequest{}
-	mi := &file_monorepo_proto_msgTypes[10]
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_monorepo_proto_msgTypes[150]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BranchesRequest) String() string {
+func (x *BackupRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BranchesRequest) ProtoMessage() {}
+func (*BackupRequest) ProtoMessage() {}
 
-func (x *BranchesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[10]
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[150]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -739,35 +10512,43 @@ func (x *BranchesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BranchesRequest.ProtoReflect.Descriptor instead.
-func (*BranchesRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{150}
 }
 
-// Response containing branches
-type BranchesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Branches      []string               `protobuf:"bytes,1,rep,name=branches,proto3" json:"branches,omitempty"`
-	DefaultBranch string                 `protobuf:"bytes,2,opt,name=default_branch,json=defaultBranch,proto3" json:"default_branch,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *BackupRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
 }
 
-func (x *BranchesResponse) Reset() {
-	*x = BranchesResponse{}
-	mi := &file_monorepo_proto_msgTypes[11]
+type BackupResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot       string                 `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // Snapshot ID, usable with Restore
+	Objects        int64                  `protobuf:"varint,2,opt,name=objects,proto3" json:"objects,omitempty"`
+	ObjectsWritten int64                  `protobuf:"varint,3,opt,name=objects_written,json=objectsWritten,proto3" json:"objects_written,omitempty"` // Objects not already present in the destination
+	BytesWritten   int64                  `protobuf:"varint,4,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BackupResponse) Reset() {
+	*x = BackupResponse{}
+	mi := &file_monorepo_proto_msgTypes[151]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BranchesResponse) String() string {
+func (x *BackupResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BranchesResponse) ProtoMessage() {}
+func (*BackupResponse) ProtoMessage() {}
 
-func (x *BranchesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[11]
+func (x *BackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[151]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -778,50 +10559,62 @@ func (x *BranchesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BranchesResponse.ProtoReflect.Descriptor instead.
-func (*BranchesResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use BackupResponse.ProtoReflect.Descriptor instead.
+func (*BackupResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{151}
 }
 
-func (x *BranchesResponse) GetBranches() []string {
+func (x *BackupResponse) GetSnapshot() string {
 	if x != nil {
-		return x.Branches
+		return x.Snapshot
 	}
-	return nil
+	return ""
 }
 
-func (x *BranchesResponse) GetDefaultBranch() string {
+func (x *BackupResponse) GetObjects() int64 {
 	if x != nil {
-		return x.DefaultBranch
+		return x.Objects
 	}
-	return ""
+	return 0
 }
 
-// Request to create a new branch
-type CreateBranchRequest struct {
+func (x *BackupResponse) GetObjectsWritten() int64 {
+	if x != nil {
+		return x.ObjectsWritten
+	}
+	return 0
+}
+
+func (x *BackupResponse) GetBytesWritten() int64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+type RestoreRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                               // New branch name
-	FromBranch    string                 `protobuf:"bytes,2,opt,name=from_branch,json=fromBranch,proto3" json:"from_branch,omitempty"` // Source branch (default: main)
-	FromCommit    string                 `protobuf:"bytes,3,opt,name=from_commit,json=fromCommit,proto3" json:"from_commit,omitempty"` // Specific commit to branch from
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`     // Directory on the server host, or s3://bucket/prefix
+	Snapshot      string                 `protobuf:"bytes,2,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // Snapshot ID; empty selects the latest
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateBranchRequest) Reset() {
-	*x = CreateBranchRequest{}
-	mi := &file_monorepo_proto_msgTypes[12]
+func (x *RestoreRequest) Reset() {
+	*x = RestoreRequest{}
+	mi := &file_monorepo_proto_msgTypes[152]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateBranchRequest) String() string {
+func (x *RestoreRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateBranchRequest) ProtoMessage() {}
+func (*RestoreRequest) ProtoMessage() {}
 
-func (x *CreateBranchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[12]
+func (x *RestoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[152]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -832,58 +10625,50 @@ func (x *CreateBranchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateBranchRequest.ProtoReflect.Descriptor instead.
-func (*CreateBranchRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{12}
-}
-
-func (x *CreateBranchRequest) GetName() string {
-	if x != nil {
-		return x.Name
-	}
-	return ""
+// Deprecated: Use RestoreRequest.ProtoReflect.Descriptor instead.
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{152}
 }
 
-func (x *CreateBranchRequest) GetFromBranch() string {
+func (x *RestoreRequest) GetSource() string {
 	if x != nil {
-		return x.FromBranch
+		return x.Source
 	}
 	return ""
 }
 
-func (x *CreateBranchRequest) GetFromCommit() string {
+func (x *RestoreRequest) GetSnapshot() string {
 	if x != nil {
-		return x.FromCommit
+		return x.Snapshot
 	}
 	return ""
 }
 
-// Response from creating a branch
-type CreateBranchResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	BranchName    string                 `protobuf:"bytes,3,opt,name=branch_name,json=branchName,proto3" json:"branch_name,omitempty"`
-	CommitHash    string                 `protobuf:"bytes,4,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type RestoreResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot       string                 `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	CurrentVersion int64                  `protobuf:"varint,2,opt,name=current_version,json=currentVersion,proto3" json:"current_version,omitempty"`
+	Objects        int64                  `protobuf:"varint,3,opt,name=objects,proto3" json:"objects,omitempty"`
+	Workspaces     int64                  `protobuf:"varint,4,opt,name=workspaces,proto3" json:"workspaces,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *CreateBranchResponse) Reset() {
-	*x = CreateBranchResponse{}
-	mi := &file_monorepo_proto_msgTypes[13]
+func (x *RestoreResponse) Reset() {
+	*x = RestoreResponse{}
+	mi := &file_monorepo_proto_msgTypes[153]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateBranchResponse) String() string {
+func (x *RestoreResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateBranchResponse) ProtoMessage() {}
+func (*RestoreResponse) ProtoMessage() {}
 
-func (x *CreateBranchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[13]
+func (x *RestoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[153]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -894,65 +10679,62 @@ func (x *CreateBranchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateBranchResponse.ProtoReflect.Descriptor instead.
-func (*CreateBranchResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use RestoreResponse.ProtoReflect.Descriptor instead.
+func (*RestoreResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{153}
 }
 
-func (x *CreateBranchResponse) GetSuccess() bool {
+func (x *RestoreResponse) GetSnapshot() string {
 	if x != nil {
-		return x.Success
+		return x.Snapshot
 	}
-	return false
+	return ""
 }
 
-func (x *CreateBranchResponse) GetMessage() string {
+func (x *RestoreResponse) GetCurrentVersion() int64 {
 	if x != nil {
-		return x.Message
+		return x.CurrentVersion
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateBranchResponse) GetBranchName() string {
+func (x *RestoreResponse) GetObjects() int64 {
 	if x != nil {
-		return x.BranchName
+		return x.Objects
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateBranchResponse) GetCommitHash() string {
+func (x *RestoreResponse) GetWorkspaces() int64 {
 	if x != nil {
-		return x.CommitHash
+		return x.Workspaces
 	}
-	return ""
+	return 0
 }
 
-// Workspace management messages
-type CreateWorkspaceRequest struct {
+type MigrateBackendRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	TrackedPaths  []string               `protobuf:"bytes,2,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"`
-	BaseBranch    string                 `protobuf:"bytes,3,opt,name=base_branch,json=baseBranch,proto3" json:"base_branch,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Destination   string                 `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"` // Directory on the server host, or s3://bucket/prefix
+	Async         bool                   `protobuf:"varint,2,opt,name=async,proto3" json:"async,omitempty"`            // Return at once with an operation whose response is the MigrateBackendResponse
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateWorkspaceRequest) Reset() {
-	*x = CreateWorkspaceRequest{}
-	mi := &file_monorepo_proto_msgTypes[14]
+func (x *MigrateBackendRequest) Reset() {
+	*x = MigrateBackendRequest{}
+	mi := &file_monorepo_proto_msgTypes[154]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateWorkspaceRequest) String() string {
+func (x *MigrateBackendRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateWorkspaceRequest) ProtoMessage() {}
+func (*MigrateBackendRequest) ProtoMessage() {}
 
-func (x *CreateWorkspaceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[14]
+func (x *MigrateBackendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[154]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -963,64 +10745,52 @@ func (x *CreateWorkspaceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateWorkspaceRequest.ProtoReflect.Descriptor instead.
-func (*CreateWorkspaceRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{14}
-}
-
-func (x *CreateWorkspaceRequest) GetName() string {
-	if x != nil {
-		return x.Name
-	}
-	return ""
-}
-
-func (x *CreateWorkspaceRequest) GetTrackedPaths() []string {
-	if x != nil {
-		return x.TrackedPaths
-	}
-	return nil
+// Deprecated: Use MigrateBackendRequest.ProtoReflect.Descriptor instead.
+func (*MigrateBackendRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{154}
 }
 
-func (x *CreateWorkspaceRequest) GetBaseBranch() string {
+func (x *MigrateBackendRequest) GetDestination() string {
 	if x != nil {
-		return x.BaseBranch
+		return x.Destination
 	}
 	return ""
 }
 
-func (x *CreateWorkspaceRequest) GetMetadata() map[string]string {
+func (x *MigrateBackendRequest) GetAsync() bool {
 	if x != nil {
-		return x.Metadata
+		return x.Async
 	}
-	return nil
+	return false
 }
 
-type CreateWorkspaceResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	WorkspaceId   string                 `protobuf:"bytes,3,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
-	RemoteUrl     string                 `protobuf:"bytes,4,opt,name=remote_url,json=remoteUrl,proto3" json:"remote_url,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type MigrateBackendResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Objects         int64                  `protobuf:"varint,1,opt,name=objects,proto3" json:"objects,omitempty"`
+	ObjectsCopied   int64                  `protobuf:"varint,2,opt,name=objects_copied,json=objectsCopied,proto3" json:"objects_copied,omitempty"`       // Objects the destination did not have yet
+	Metadata        int64                  `protobuf:"varint,3,opt,name=metadata,proto3" json:"metadata,omitempty"`                                      // Version index and lock keys copied
+	MetadataRemoved int64                  `protobuf:"varint,4,opt,name=metadata_removed,json=metadataRemoved,proto3" json:"metadata_removed,omitempty"` // Stale keys removed from the destination
+	BytesCopied     int64                  `protobuf:"varint,5,opt,name=bytes_copied,json=bytesCopied,proto3" json:"bytes_copied,omitempty"`
+	OperationId     string                 `protobuf:"bytes,6,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"` // With async: the operation migrating the backend
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *CreateWorkspaceResponse) Reset() {
-	*x = CreateWorkspaceResponse{}
-	mi := &file_monorepo_proto_msgTypes[15]
+func (x *MigrateBackendResponse) Reset() {
+	*x = MigrateBackendResponse{}
+	mi := &file_monorepo_proto_msgTypes[155]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateWorkspaceResponse) String() string {
+func (x *MigrateBackendResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateWorkspaceResponse) ProtoMessage() {}
+func (*MigrateBackendResponse) ProtoMessage() {}
 
-func (x *CreateWorkspaceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[15]
+func (x *MigrateBackendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[155]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1031,61 +10801,75 @@ func (x *CreateWorkspaceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateWorkspaceResponse.ProtoReflect.Descriptor instead.
-func (*CreateWorkspaceResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use MigrateBackendResponse.ProtoReflect.Descriptor instead.
+func (*MigrateBackendResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{155}
 }
 
-func (x *CreateWorkspaceResponse) GetSuccess() bool {
+func (x *MigrateBackendResponse) GetObjects() int64 {
 	if x != nil {
-		return x.Success
+		return x.Objects
 	}
-	return false
+	return 0
 }
 
-func (x *CreateWorkspaceResponse) GetMessage() string {
+func (x *MigrateBackendResponse) GetObjectsCopied() int64 {
 	if x != nil {
-		return x.Message
+		return x.ObjectsCopied
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateWorkspaceResponse) GetWorkspaceId() string {
+func (x *MigrateBackendResponse) GetMetadata() int64 {
 	if x != nil {
-		return x.WorkspaceId
+		return x.Metadata
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateWorkspaceResponse) GetRemoteUrl() string {
+func (x *MigrateBackendResponse) GetMetadataRemoved() int64 {
 	if x != nil {
-		return x.RemoteUrl
+		return x.MetadataRemoved
+	}
+	return 0
+}
+
+func (x *MigrateBackendResponse) GetBytesCopied() int64 {
+	if x != nil {
+		return x.BytesCopied
+	}
+	return 0
+}
+
+func (x *MigrateBackendResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
 	}
 	return ""
 }
 
-type GetWorkspaceRequest struct {
+type RehashObjectsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Algorithm     string                 `protobuf:"bytes,1,opt,name=algorithm,proto3" json:"algorithm,omitempty"` // "sha256" or "blake3"; empty keeps the repository's
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetWorkspaceRequest) Reset() {
-	*x = GetWorkspaceRequest{}
-	mi := &file_monorepo_proto_msgTypes[16]
+func (x *RehashObjectsRequest) Reset() {
+	*x = RehashObjectsRequest{}
+	mi := &file_monorepo_proto_msgTypes[156]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetWorkspaceRequest) String() string {
+func (x *RehashObjectsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetWorkspaceRequest) ProtoMessage() {}
+func (*RehashObjectsRequest) ProtoMessage() {}
 
-func (x *GetWorkspaceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[16]
+func (x *RehashObjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[156]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1096,42 +10880,45 @@ func (x *GetWorkspaceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetWorkspaceRequest.ProtoReflect.Descriptor instead.
-func (*GetWorkspaceRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use RehashObjectsRequest.ProtoReflect.Descriptor instead.
+func (*RehashObjectsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{156}
 }
 
-func (x *GetWorkspaceRequest) GetWorkspaceId() string {
+func (x *RehashObjectsRequest) GetAlgorithm() string {
 	if x != nil {
-		return x.WorkspaceId
+		return x.Algorithm
 	}
 	return ""
 }
 
-type GetWorkspaceResponse struct {
+type RehashObjectsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Workspace     *WorkspaceInfo         `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	Algorithm     string                 `protobuf:"bytes,1,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	Objects       int64                  `protobuf:"varint,2,opt,name=objects,proto3" json:"objects,omitempty"`                               // Objects written with the new algorithm or format
+	Unchanged     int64                  `protobuf:"varint,3,opt,name=unchanged,proto3" json:"unchanged,omitempty"`                           // Objects that already used both
+	Versions      int64                  `protobuf:"varint,4,opt,name=versions,proto3" json:"versions,omitempty"`                             // Versions pointed at new commit hashes
+	TrashEntries  int64                  `protobuf:"varint,5,opt,name=trash_entries,json=trashEntries,proto3" json:"trash_entries,omitempty"` // Trash entries pointed at new blob hashes
+	HashFormat    string                 `protobuf:"bytes,6,opt,name=hash_format,json=hashFormat,proto3" json:"hash_format,omitempty"`        // Format trees and commits are now hashed in, e.g. "canonical/1"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetWorkspaceResponse) Reset() {
-	*x = GetWorkspaceResponse{}
-	mi := &file_monorepo_proto_msgTypes[17]
+func (x *RehashObjectsResponse) Reset() {
+	*x = RehashObjectsResponse{}
+	mi := &file_monorepo_proto_msgTypes[157]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetWorkspaceResponse) String() string {
+func (x *RehashObjectsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetWorkspaceResponse) ProtoMessage() {}
+func (*RehashObjectsResponse) ProtoMessage() {}
 
-func (x *GetWorkspaceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[17]
+func (x *RehashObjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[157]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1142,56 +10929,78 @@ func (x *GetWorkspaceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetWorkspaceResponse.ProtoReflect.Descriptor instead.
-func (*GetWorkspaceResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use RehashObjectsResponse.ProtoReflect.Descriptor instead.
+func (*RehashObjectsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{157}
 }
 
-func (x *GetWorkspaceResponse) GetSuccess() bool {
+func (x *RehashObjectsResponse) GetAlgorithm() string {
 	if x != nil {
-		return x.Success
+		return x.Algorithm
 	}
-	return false
+	return ""
 }
 
-func (x *GetWorkspaceResponse) GetMessage() string {
+func (x *RehashObjectsResponse) GetObjects() int64 {
 	if x != nil {
-		return x.Message
+		return x.Objects
 	}
-	return ""
+	return 0
 }
 
-func (x *GetWorkspaceResponse) GetWorkspace() *WorkspaceInfo {
+func (x *RehashObjectsResponse) GetUnchanged() int64 {
 	if x != nil {
-		return x.Workspace
+		return x.Unchanged
 	}
-	return nil
+	return 0
+}
+
+func (x *RehashObjectsResponse) GetVersions() int64 {
+	if x != nil {
+		return x.Versions
+	}
+	return 0
+}
+
+func (x *RehashObjectsResponse) GetTrashEntries() int64 {
+	if x != nil {
+		return x.TrashEntries
+	}
+	return 0
+}
+
+func (x *RehashObjectsResponse) GetHashFormat() string {
+	if x != nil {
+		return x.HashFormat
+	}
+	return ""
 }
 
-type UpdateWorkspaceRequest struct {
+type PruneHistoryRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
-	TrackedPaths  []string               `protobuf:"bytes,2,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	KeepDays      int32                  `protobuf:"varint,1,opt,name=keep_days,json=keepDays,proto3" json:"keep_days,omitempty"`                    // Keep versions created in the last N days; 0 keeps none for their age
+	KeepTagged    bool                   `protobuf:"varint,2,opt,name=keep_tagged,json=keepTagged,proto3" json:"keep_tagged,omitempty"`              // Keep every version a tag pins
+	KeepVersions  []int64                `protobuf:"varint,3,rep,packed,name=keep_versions,json=keepVersions,proto3" json:"keep_versions,omitempty"` // Also keep these versions
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                          // Report what would be pruned without changing anything
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateWorkspaceRequest) Reset() {
-	*x = UpdateWorkspaceRequest{}
-	mi := &file_monorepo_proto_msgTypes[18]
+func (x *PruneHistoryRequest) Reset() {
+	*x = PruneHistoryRequest{}
+	mi := &file_monorepo_proto_msgTypes[158]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateWorkspaceRequest) String() string {
+func (x *PruneHistoryRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateWorkspaceRequest) ProtoMessage() {}
+func (*PruneHistoryRequest) ProtoMessage() {}
 
-func (x *UpdateWorkspaceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[18]
+func (x *PruneHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[158]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1202,56 +11011,64 @@ func (x *UpdateWorkspaceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateWorkspaceRequest.ProtoReflect.Descriptor instead.
-func (*UpdateWorkspaceRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use PruneHistoryRequest.ProtoReflect.Descriptor instead.
+func (*PruneHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{158}
 }
 
-func (x *UpdateWorkspaceRequest) GetWorkspaceId() string {
+func (x *PruneHistoryRequest) GetKeepDays() int32 {
 	if x != nil {
-		return x.WorkspaceId
+		return x.KeepDays
 	}
-	return ""
+	return 0
 }
 
-func (x *UpdateWorkspaceRequest) GetTrackedPaths() []string {
+func (x *PruneHistoryRequest) GetKeepTagged() bool {
 	if x != nil {
-		return x.TrackedPaths
+		return x.KeepTagged
 	}
-	return nil
+	return false
 }
 
-func (x *UpdateWorkspaceRequest) GetMetadata() map[string]string {
+func (x *PruneHistoryRequest) GetKeepVersions() []int64 {
 	if x != nil {
-		return x.Metadata
+		return x.KeepVersions
 	}
 	return nil
 }
 
-type UpdateWorkspaceResponse struct {
+func (x *PruneHistoryRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type PruneHistoryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Workspace     *WorkspaceInfo         `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	Kept          int64                  `protobuf:"varint,1,opt,name=kept,proto3" json:"kept,omitempty"`                               // Versions retained, always including the current one
+	Pruned        int64                  `protobuf:"varint,2,opt,name=pruned,proto3" json:"pruned,omitempty"`                           // Versions removed (or that would be)
+	Rewritten     int64                  `protobuf:"varint,3,opt,name=rewritten,proto3" json:"rewritten,omitempty"`                     // Retained versions whose commits were rewritten
+	OldestKept    int64                  `protobuf:"varint,4,opt,name=oldest_kept,json=oldestKept,proto3" json:"oldest_kept,omitempty"` // The checkpoint version history now starts at
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateWorkspaceResponse) Reset() {
-	*x = UpdateWorkspaceResponse{}
-	mi := &file_monorepo_proto_msgTypes[19]
+func (x *PruneHistoryResponse) Reset() {
+	*x = PruneHistoryResponse{}
+	mi := &file_monorepo_proto_msgTypes[159]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateWorkspaceResponse) String() string {
+func (x *PruneHistoryResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateWorkspaceResponse) ProtoMessage() {}
+func (*PruneHistoryResponse) ProtoMessage() {}
 
-func (x *UpdateWorkspaceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[19]
+func (x *PruneHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[159]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1262,54 +11079,61 @@ func (x *UpdateWorkspaceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateWorkspaceResponse.ProtoReflect.Descriptor instead.
-func (*UpdateWorkspaceResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use PruneHistoryResponse.ProtoReflect.Descriptor instead.
+func (*PruneHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{159}
 }
 
-func (x *UpdateWorkspaceResponse) GetSuccess() bool {
+func (x *PruneHistoryResponse) GetKept() int64 {
 	if x != nil {
-		return x.Success
+		return x.Kept
 	}
-	return false
+	return 0
 }
 
-func (x *UpdateWorkspaceResponse) GetMessage() string {
+func (x *PruneHistoryResponse) GetPruned() int64 {
 	if x != nil {
-		return x.Message
+		return x.Pruned
 	}
-	return ""
+	return 0
 }
 
-func (x *UpdateWorkspaceResponse) GetWorkspace() *WorkspaceInfo {
+func (x *PruneHistoryResponse) GetRewritten() int64 {
 	if x != nil {
-		return x.Workspace
+		return x.Rewritten
 	}
-	return nil
+	return 0
 }
 
-type DeleteWorkspaceRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *PruneHistoryResponse) GetOldestKept() int64 {
+	if x != nil {
+		return x.OldestKept
+	}
+	return 0
 }
 
-func (x *DeleteWorkspaceRequest) Reset() {
-	*x = DeleteWorkspaceRequest{}
-	mi := &file_monorepo_proto_msgTypes[20]
+type ListCorruptObjectsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IncludeRepaired bool                   `protobuf:"varint,1,opt,name=include_repaired,json=includeRepaired,proto3" json:"include_repaired,omitempty"` // Also list objects replaced by a good copy
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListCorruptObjectsRequest) Reset() {
+	*x = ListCorruptObjectsRequest{}
+	mi := &file_monorepo_proto_msgTypes[160]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteWorkspaceRequest) String() string {
+func (x *ListCorruptObjectsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteWorkspaceRequest) ProtoMessage() {}
+func (*ListCorruptObjectsRequest) ProtoMessage() {}
 
-func (x *DeleteWorkspaceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[20]
+func (x *ListCorruptObjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[160]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1320,41 +11144,46 @@ func (x *DeleteWorkspaceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteWorkspaceRequest.ProtoReflect.Descriptor instead.
-func (*DeleteWorkspaceRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use ListCorruptObjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListCorruptObjectsRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{160}
 }
 
-func (x *DeleteWorkspaceRequest) GetWorkspaceId() string {
+func (x *ListCorruptObjectsRequest) GetIncludeRepaired() bool {
 	if x != nil {
-		return x.WorkspaceId
+		return x.IncludeRepaired
 	}
-	return ""
+	return false
 }
 
-type DeleteWorkspaceResponse struct {
+type CorruptObject struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	DetectedAt    int64                  `protobuf:"varint,2,opt,name=detected_at,json=detectedAt,proto3" json:"detected_at,omitempty"`   // Unix seconds of the first failed read
+	LastSeenAt    int64                  `protobuf:"varint,3,opt,name=last_seen_at,json=lastSeenAt,proto3" json:"last_seen_at,omitempty"` // Unix seconds of the latest failed read
+	Detections    int32                  `protobuf:"varint,4,opt,name=detections,proto3" json:"detections,omitempty"`                     // Failed reads recorded
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`                                // The latest failure
+	RepairedAt    int64                  `protobuf:"varint,6,opt,name=repaired_at,json=repairedAt,proto3" json:"repaired_at,omitempty"`   // Unix seconds of the repair; 0 while still corrupt
+	RepairError   string                 `protobuf:"bytes,7,opt,name=repair_error,json=repairError,proto3" json:"repair_error,omitempty"` // Why the latest repair failed
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteWorkspaceResponse) Reset() {
-	*x = DeleteWorkspaceResponse{}
-	mi := &file_monorepo_proto_msgTypes[21]
+func (x *CorruptObject) Reset() {
+	*x = CorruptObject{}
+	mi := &file_monorepo_proto_msgTypes[161]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteWorkspaceResponse) String() string {
+func (x *CorruptObject) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteWorkspaceResponse) ProtoMessage() {}
+func (*CorruptObject) ProtoMessage() {}
 
-func (x *DeleteWorkspaceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[21]
+func (x *CorruptObject) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[161]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1365,53 +11194,82 @@ func (x *DeleteWorkspaceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteWorkspaceResponse.ProtoReflect.Descriptor instead.
-func (*DeleteWorkspaceResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{21}
+// Deprecated: Use CorruptObject.ProtoReflect.Descriptor instead.
+func (*CorruptObject) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{161}
 }
 
-func (x *DeleteWorkspaceResponse) GetSuccess() bool {
+func (x *CorruptObject) GetHash() string {
 	if x != nil {
-		return x.Success
+		return x.Hash
 	}
-	return false
+	return ""
 }
 
-func (x *DeleteWorkspaceResponse) GetMessage() string {
+func (x *CorruptObject) GetDetectedAt() int64 {
 	if x != nil {
-		return x.Message
+		return x.DetectedAt
+	}
+	return 0
+}
+
+func (x *CorruptObject) GetLastSeenAt() int64 {
+	if x != nil {
+		return x.LastSeenAt
+	}
+	return 0
+}
+
+func (x *CorruptObject) GetDetections() int32 {
+	if x != nil {
+		return x.Detections
+	}
+	return 0
+}
+
+func (x *CorruptObject) GetError() string {
+	if x != nil {
+		return x.Error
 	}
 	return ""
 }
 
-type WorkspaceInfo struct {
+func (x *CorruptObject) GetRepairedAt() int64 {
+	if x != nil {
+		return x.RepairedAt
+	}
+	return 0
+}
+
+func (x *CorruptObject) GetRepairError() string {
+	if x != nil {
+		return x.RepairError
+	}
+	return ""
+}
+
+type ListCorruptObjectsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	TrackedPaths  []string               `protobuf:"bytes,3,rep,name=tracked_paths,json=trackedPaths,proto3" json:"tracked_paths,omitempty"`
-	CreatedAt     string                 `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	LastSync      string                 `protobuf:"bytes,5,opt,name=last_sync,json=lastSync,proto3" json:"last_sync,omitempty"`
-	Status        WorkspaceStatus        `protobuf:"varint,6,opt,name=status,proto3,enum=monorepo.WorkspaceStatus" json:"status,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Objects       []*CorruptObject       `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WorkspaceInfo) Reset() {
-	*x = WorkspaceInfo{}
-	mi := &file_monorepo_proto_msgTypes[22]
+func (x *ListCorruptObjectsResponse) Reset() {
+	*x = ListCorruptObjectsResponse{}
+	mi := &file_monorepo_proto_msgTypes[162]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WorkspaceInfo) String() string {
+func (x *ListCorruptObjectsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WorkspaceInfo) ProtoMessage() {}
+func (*ListCorruptObjectsResponse) ProtoMessage() {}
 
-func (x *WorkspaceInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[22]
+func (x *ListCorruptObjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[162]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1422,85 +11280,84 @@ func (x *WorkspaceInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WorkspaceInfo.ProtoReflect.Descriptor instead.
-func (*WorkspaceInfo) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use ListCorruptObjectsResponse.ProtoReflect.Descriptor instead.
+func (*ListCorruptObjectsResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{162}
 }
 
-func (x *WorkspaceInfo) GetId() string {
+func (x *ListCorruptObjectsResponse) GetObjects() []*CorruptObject {
 	if x != nil {
-		return x.Id
+		return x.Objects
 	}
-	return ""
+	return nil
 }
 
-func (x *WorkspaceInfo) GetName() string {
-	if x != nil {
-		return x.Name
-	}
-	return ""
+type GetReplicationStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WorkspaceInfo) GetTrackedPaths() []string {
-	if x != nil {
-		return x.TrackedPaths
-	}
-	return nil
+func (x *GetReplicationStatusRequest) Reset() {
+	*x = GetReplicationStatusRequest{}
+	mi := &file_monorepo_proto_msgTypes[163]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *WorkspaceInfo) GetCreatedAt() string {
-	if x != nil {
-		return x.CreatedAt
-	}
-	return ""
+func (x *GetReplicationStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *WorkspaceInfo) GetLastSync() string {
-	if x != nil {
-		return x.LastSync
-	}
-	return ""
-}
+func (*GetReplicationStatusRequest) ProtoMessage() {}
 
-func (x *WorkspaceInfo) GetStatus() WorkspaceStatus {
+func (x *GetReplicationStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[163]
 	if x != nil {
-		return x.Status
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return WorkspaceStatus_ACTIVE
+	return mi.MessageOf(x)
 }
 
-func (x *WorkspaceInfo) GetMetadata() map[string]string {
-	if x != nil {
-		return x.Metadata
-	}
-	return nil
+// Deprecated: Use GetReplicationStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetReplicationStatusRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{163}
 }
 
-// Sparse checkout messages
-type SparseCheckoutRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
-	TargetDir     string                 `protobuf:"bytes,2,opt,name=target_dir,json=targetDir,proto3" json:"target_dir,omitempty"`
-	WorkspaceId   string                 `protobuf:"bytes,3,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ReplicaStatus struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                    // Backend location
+	Active           bool                   `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`                                               // Whether reads and writes go to it
+	Pending          int64                  `protobuf:"varint,3,opt,name=pending,proto3" json:"pending,omitempty"`                                             // Keys written but not yet mirrored to it
+	LagSeconds       float64                `protobuf:"fixed64,4,opt,name=lag_seconds,json=lagSeconds,proto3" json:"lag_seconds,omitempty"`                    // Age of the oldest pending write
+	Replicated       int64                  `protobuf:"varint,5,opt,name=replicated,proto3" json:"replicated,omitempty"`                                       // Keys mirrored since the server started
+	Failures         int64                  `protobuf:"varint,6,opt,name=failures,proto3" json:"failures,omitempty"`                                           // Failed mirroring attempts since the server started
+	LastError        string                 `protobuf:"bytes,7,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`                         // Of the latest failed attempt, cleared by a success
+	LastReplicatedAt int64                  `protobuf:"varint,8,opt,name=last_replicated_at,json=lastReplicatedAt,proto3" json:"last_replicated_at,omitempty"` // Unix seconds; 0 before the first
+	Resync           bool                   `protobuf:"varint,9,opt,name=resync,proto3" json:"resync,omitempty"`                                               // A full comparison with the active backend is due or running
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *SparseCheckoutRequest) Reset() {
-	*x = SparseCheckoutRequest{}
-	mi := &file_monorepo_proto_msgTypes[23]
+func (x *ReplicaStatus) Reset() {
+	*x = ReplicaStatus{}
+	mi := &file_monorepo_proto_msgTypes[164]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SparseCheckoutRequest) String() string {
+func (x *ReplicaStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SparseCheckoutRequest) ProtoMessage() {}
+func (*ReplicaStatus) ProtoMessage() {}
 
-func (x *SparseCheckoutRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[23]
+func (x *ReplicaStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[164]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1511,56 +11368,96 @@ func (x *SparseCheckoutRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SparseCheckoutRequest.ProtoReflect.Descriptor instead.
-func (*SparseCheckoutRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{23}
+// Deprecated: Use ReplicaStatus.ProtoReflect.Descriptor instead.
+func (*ReplicaStatus) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{164}
 }
 
-func (x *SparseCheckoutRequest) GetPaths() []string {
+func (x *ReplicaStatus) GetName() string {
 	if x != nil {
-		return x.Paths
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-func (x *SparseCheckoutRequest) GetTargetDir() string {
+func (x *ReplicaStatus) GetActive() bool {
 	if x != nil {
-		return x.TargetDir
+		return x.Active
 	}
-	return ""
+	return false
 }
 
-func (x *SparseCheckoutRequest) GetWorkspaceId() string {
+func (x *ReplicaStatus) GetPending() int64 {
 	if x != nil {
-		return x.WorkspaceId
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *ReplicaStatus) GetLagSeconds() float64 {
+	if x != nil {
+		return x.LagSeconds
+	}
+	return 0
+}
+
+func (x *ReplicaStatus) GetReplicated() int64 {
+	if x != nil {
+		return x.Replicated
+	}
+	return 0
+}
+
+func (x *ReplicaStatus) GetFailures() int64 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *ReplicaStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
 	}
 	return ""
 }
 
-type SparseCheckoutResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message         string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	ConfiguredPaths []string               `protobuf:"bytes,3,rep,name=configured_paths,json=configuredPaths,proto3" json:"configured_paths,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *ReplicaStatus) GetLastReplicatedAt() int64 {
+	if x != nil {
+		return x.LastReplicatedAt
+	}
+	return 0
 }
 
-func (x *SparseCheckoutResponse) Reset() {
-	*x = SparseCheckoutResponse{}
-	mi := &file_monorepo_proto_msgTypes[24]
+func (x *ReplicaStatus) GetResync() bool {
+	if x != nil {
+		return x.Resync
+	}
+	return false
+}
+
+type GetReplicationStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Replicas      []*ReplicaStatus       `protobuf:"bytes,1,rep,name=replicas,proto3" json:"replicas,omitempty"` // Primary first; empty without replicas
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReplicationStatusResponse) Reset() {
+	*x = GetReplicationStatusResponse{}
+	mi := &file_monorepo_proto_msgTypes[165]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SparseCheckoutResponse) String() string {
+func (x *GetReplicationStatusResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SparseCheckoutResponse) ProtoMessage() {}
+func (*GetReplicationStatusResponse) ProtoMessage() {}
 
-func (x *SparseCheckoutResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[24]
+func (x *GetReplicationStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[165]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1571,57 +11468,41 @@ func (x *SparseCheckoutResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SparseCheckoutResponse.ProtoReflect.Descriptor instead.
-func (*SparseCheckoutResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{24}
-}
-
-func (x *SparseCheckoutResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *SparseCheckoutResponse) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
+// Deprecated: Use GetReplicationStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetReplicationStatusResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{165}
 }
 
-func (x *SparseCheckoutResponse) GetConfiguredPaths() []string {
+func (x *GetReplicationStatusResponse) GetReplicas() []*ReplicaStatus {
 	if x != nil {
-		return x.ConfiguredPaths
+		return x.Replicas
 	}
 	return nil
 }
 
-// Download messages
-type DownloadPathRequest struct {
+type FailoverBackendRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`
-	Format        string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"` // "tar", "zip", etc.
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`    // Location of the replica to make active
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"` // Fail over even though it lacks pending writes, losing them
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DownloadPathRequest) Reset() {
-	*x = DownloadPathRequest{}
-	mi := &file_monorepo_proto_msgTypes[25]
+func (x *FailoverBackendRequest) Reset() {
+	*x = FailoverBackendRequest{}
+	mi := &file_monorepo_proto_msgTypes[166]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadPathRequest) String() string {
+func (x *FailoverBackendRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownloadPathRequest) ProtoMessage() {}
+func (*FailoverBackendRequest) ProtoMessage() {}
 
-func (x *DownloadPathRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[25]
+func (x *FailoverBackendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[166]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1632,57 +11513,47 @@ func (x *DownloadPathRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DownloadPathRequest.ProtoReflect.Descriptor instead.
-func (*DownloadPathRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{25}
-}
-
-func (x *DownloadPathRequest) GetPath() string {
-	if x != nil {
-		return x.Path
-	}
-	return ""
+// Deprecated: Use FailoverBackendRequest.ProtoReflect.Descriptor instead.
+func (*FailoverBackendRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{166}
 }
 
-func (x *DownloadPathRequest) GetBranch() string {
+func (x *FailoverBackendRequest) GetName() string {
 	if x != nil {
-		return x.Branch
+		return x.Name
 	}
 	return ""
 }
 
-func (x *DownloadPathRequest) GetFormat() string {
+func (x *FailoverBackendRequest) GetForce() bool {
 	if x != nil {
-		return x.Format
+		return x.Force
 	}
-	return ""
+	return false
 }
 
-type DownloadPathResponse struct {
+type FailoverBackendResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	Filename      string                 `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
+	Replicas      []*ReplicaStatus       `protobuf:"bytes,1,rep,name=replicas,proto3" json:"replicas,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DownloadPathResponse) Reset() {
-	*x = DownloadPathResponse{}
-	mi := &file_monorepo_proto_msgTypes[26]
+func (x *FailoverBackendResponse) Reset() {
+	*x = FailoverBackendResponse{}
+	mi := &file_monorepo_proto_msgTypes[167]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadPathResponse) String() string {
+func (x *FailoverBackendResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownloadPathResponse) ProtoMessage() {}
+func (*FailoverBackendResponse) ProtoMessage() {}
 
-func (x *DownloadPathResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[26]
+func (x *FailoverBackendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[167]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1693,64 +11564,40 @@ func (x *DownloadPathResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DownloadPathResponse.ProtoReflect.Descriptor instead.
-func (*DownloadPathResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{26}
-}
-
-func (x *DownloadPathResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *DownloadPathResponse) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
+// Deprecated: Use FailoverBackendResponse.ProtoReflect.Descriptor instead.
+func (*FailoverBackendResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{167}
 }
 
-func (x *DownloadPathResponse) GetContent() []byte {
+func (x *FailoverBackendResponse) GetReplicas() []*ReplicaStatus {
 	if x != nil {
-		return x.Content
+		return x.Replicas
 	}
 	return nil
 }
 
-func (x *DownloadPathResponse) GetFilename() string {
-	if x != nil {
-		return x.Filename
-	}
-	return ""
-}
-
-// Request to add a tracked path to workspace
-type AddTrackedPathRequest struct {
+type ResyncReplicaRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	WorkspaceId   string                 `protobuf:"bytes,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
-	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
-	Branch        string                 `protobuf:"bytes,3,opt,name=branch,proto3" json:"branch,omitempty"` // Branch to track from (default: main)
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Location of the replica to resync
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AddTrackedPathRequest) Reset() {
-	*x = AddTrackedPathRequest{}
-	mi := &file_monorepo_proto_msgTypes[27]
+func (x *ResyncReplicaRequest) Reset() {
+	*x = ResyncReplicaRequest{}
+	mi := &file_monorepo_proto_msgTypes[168]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddTrackedPathRequest) String() string {
+func (x *ResyncReplicaRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddTrackedPathRequest) ProtoMessage() {}
+func (*ResyncReplicaRequest) ProtoMessage() {}
 
-func (x *AddTrackedPathRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[27]
+func (x *ResyncReplicaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[168]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1761,58 +11608,40 @@ func (x *AddTrackedPathRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddTrackedPathRequest.ProtoReflect.Descriptor instead.
-func (*AddTrackedPathRequest) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{27}
-}
-
-func (x *AddTrackedPathRequest) GetWorkspaceId() string {
-	if x != nil {
-		return x.WorkspaceId
-	}
-	return ""
-}
-
-func (x *AddTrackedPathRequest) GetPath() string {
-	if x != nil {
-		return x.Path
-	}
-	return ""
+// Deprecated: Use ResyncReplicaRequest.ProtoReflect.Descriptor instead.
+func (*ResyncReplicaRequest) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{168}
 }
 
-func (x *AddTrackedPathRequest) GetBranch() string {
+func (x *ResyncReplicaRequest) GetName() string {
 	if x != nil {
-		return x.Branch
+		return x.Name
 	}
 	return ""
 }
 
-// Response from adding a tracked path
-type AddTrackedPathResponse struct {
+type ResyncReplicaResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	CommitHash    string                 `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
-	NewVersion    int64                  `protobuf:"varint,4,opt,name=new_version,json=newVersion,proto3" json:"new_version,omitempty"`
+	Replicas      []*ReplicaStatus       `protobuf:"bytes,1,rep,name=replicas,proto3" json:"replicas,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AddTrackedPathResponse) Reset() {
-	*x = AddTrackedPathResponse{}
-	mi := &file_monorepo_proto_msgTypes[28]
+func (x *ResyncReplicaResponse) Reset() {
+	*x = ResyncReplicaResponse{}
+	mi := &file_monorepo_proto_msgTypes[169]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddTrackedPathResponse) String() string {
+func (x *ResyncReplicaResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddTrackedPathResponse) ProtoMessage() {}
+func (*ResyncReplicaResponse) ProtoMessage() {}
 
-func (x *AddTrackedPathResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monorepo_proto_msgTypes[28]
+func (x *ResyncReplicaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monorepo_proto_msgTypes[169]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1823,88 +11652,321 @@ func (x *AddTrackedPathResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddTrackedPathResponse.ProtoReflect.Descriptor instead.
-func (*AddTrackedPathResponse) Descriptor() ([]byte, []int) {
-	return file_monorepo_proto_rawDescGZIP(), []int{28}
-}
-
-func (x *AddTrackedPathResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *AddTrackedPathResponse) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
-}
-
-func (x *AddTrackedPathResponse) GetCommitHash() string {
-	if x != nil {
-		return x.CommitHash
-	}
-	return ""
+// Deprecated: Use ResyncReplicaResponse.ProtoReflect.Descriptor instead.
+func (*ResyncReplicaResponse) Descriptor() ([]byte, []int) {
+	return file_monorepo_proto_rawDescGZIP(), []int{169}
 }
 
-func (x *AddTrackedPathResponse) GetNewVersion() int64 {
+func (x *ResyncReplicaResponse) GetReplicas() []*ReplicaStatus {
 	if x != nil {
-		return x.NewVersion
+		return x.Replicas
 	}
-	return 0
+	return nil
 }
 
 var File_monorepo_proto protoreflect.FileDescriptor
 
 const file_monorepo_proto_rawDesc = "" +
 	"\n" +
-	"\x0emonorepo.proto\x12\bmonorepo\"\x87\x01\n" +
+	"\x0emonorepo.proto\x12\bmonorepo\"\xab\x03\n" +
 	"\x11MergePatchRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
 	"\x05patch\x18\x02 \x01(\fR\x05patch\x12\x18\n" +
 	"\amessage\x18\x03 \x01(\tR\amessage\x12\x16\n" +
 	"\x06author\x18\x04 \x01(\tR\x06author\x12\x16\n" +
-	"\x06branch\x18\x05 \x01(\tR\x06branch\"\x87\x01\n" +
+	"\x06branch\x18\x05 \x01(\tR\x06branch\x12$\n" +
+	"\x0efail_if_locked\x18\x06 \x01(\bR\ffailIfLocked\x12+\n" +
+	"\x11ignore_whitespace\x18\a \x01(\bR\x10ignoreWhitespace\x124\n" +
+	"\x16normalize_line_endings\x18\b \x01(\bR\x14normalizeLineEndings\x12:\n" +
+	"\x19preserve_trailing_newline\x18\t \x01(\bR\x17preserveTrailingNewline\x12\x1c\n" +
+	"\tsignature\x18\n" +
+	" \x01(\fR\tsignature\x12\x14\n" +
+	"\x05amend\x18\v \x01(\bR\x05amend\x12)\n" +
+	"\x10expected_version\x18\f \x01(\x03R\x0fexpectedVersion\"\xde\x02\n" +
 	"\x12MergePatchResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
 	"\vcommit_hash\x18\x03 \x01(\tR\n" +
 	"commitHash\x12\x1c\n" +
-	"\tconflicts\x18\x04 \x03(\tR\tconflicts\"`\n" +
+	"\tconflicts\x18\x04 \x03(\tR\tconflicts\x129\n" +
+	"\n" +
+	"violations\x18\x05 \x03(\v2\x19.monorepo.PolicyViolationR\n" +
+	"violations\x12\x16\n" +
+	"\x06queued\x18\x06 \x01(\bR\x06queued\x12$\n" +
+	"\x0equeue_entry_id\x18\a \x01(\tR\fqueueEntryId\x12/\n" +
+	"\afailure\x18\b \x01(\v2\x15.monorepo.FailureInfoR\afailure\x12+\n" +
+	"\x05stats\x18\t \x01(\v2\x15.monorepo.ChangeStatsR\x05stats\"\xa7\x01\n" +
+	"\bFileStat\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x19\n" +
+	"\bold_path\x18\x02 \x01(\tR\aoldPath\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1e\n" +
+	"\n" +
+	"insertions\x18\x04 \x01(\x05R\n" +
+	"insertions\x12\x1c\n" +
+	"\tdeletions\x18\x05 \x01(\x05R\tdeletions\x12\x16\n" +
+	"\x06binary\x18\x06 \x01(\bR\x06binary\"\x9a\x01\n" +
+	"\vChangeStats\x12#\n" +
+	"\rfiles_changed\x18\x01 \x01(\x05R\ffilesChanged\x12\x1e\n" +
+	"\n" +
+	"insertions\x18\x02 \x01(\x05R\n" +
+	"insertions\x12\x1c\n" +
+	"\tdeletions\x18\x03 \x01(\x05R\tdeletions\x12(\n" +
+	"\x05files\x18\x04 \x03(\v2\x12.monorepo.FileStatR\x05files\"\xa8\x02\n" +
+	"\x13PreviewPatchRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05patch\x18\x02 \x01(\fR\x05patch\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x12\x16\n" +
+	"\x06branch\x18\x05 \x01(\tR\x06branch\x12+\n" +
+	"\x11ignore_whitespace\x18\x06 \x01(\bR\x10ignoreWhitespace\x124\n" +
+	"\x16normalize_line_endings\x18\a \x01(\bR\x14normalizeLineEndings\x12:\n" +
+	"\x19preserve_trailing_newline\x18\b \x01(\bR\x17preserveTrailingNewline\"\x97\x03\n" +
+	"\x14PreviewPatchResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1b\n" +
+	"\tfile_path\x18\x03 \x01(\tR\bfilePath\x12!\n" +
+	"\fbase_version\x18\x04 \x01(\x03R\vbaseVersion\x12\x19\n" +
+	"\bnew_file\x18\x05 \x01(\bR\anewFile\x12)\n" +
+	"\x10original_content\x18\x06 \x01(\fR\x0foriginalContent\x12\x18\n" +
+	"\acontent\x18\a \x01(\fR\acontent\x12\x1c\n" +
+	"\tconflicts\x18\b \x03(\tR\tconflicts\x129\n" +
+	"\n" +
+	"violations\x18\t \x03(\v2\x19.monorepo.PolicyViolationR\n" +
+	"violations\x12!\n" +
+	"\fdeleted_file\x18\n" +
+	" \x01(\bR\vdeletedFile\x12/\n" +
+	"\afailure\x18\v \x01(\v2\x15.monorepo.FailureInfoR\afailure\"[\n" +
+	"\x0fPolicyViolation\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\xa3\x01\n" +
+	"\vFailureInfo\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12?\n" +
+	"\bmetadata\x18\x02 \x03(\v2#.monorepo.FailureInfo.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbd\x01\n" +
 	"\x14ReadDirectoryRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
 	"\x06branch\x18\x02 \x01(\tR\x06branch\x12\x1c\n" +
-	"\trecursive\x18\x03 \x01(\bR\trecursive\"F\n" +
+	"\trecursive\x18\x03 \x01(\bR\trecursive\x12!\n" +
+	"\fwith_history\x18\x04 \x01(\bR\vwithHistory\x12\x1e\n" +
+	"\vif_not_hash\x18\x05 \x01(\tR\tifNotHash\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x03R\aversion\"}\n" +
 	"\x15ReadDirectoryResponse\x12-\n" +
-	"\x05items\x18\x01 \x03(\v2\x17.monorepo.DirectoryItemR\x05items\"}\n" +
+	"\x05items\x18\x01 \x03(\v2\x17.monorepo.DirectoryItemR\x05items\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12!\n" +
+	"\fnot_modified\x18\x03 \x01(\bR\vnotModified\"\xc0\x02\n" +
 	"\rDirectoryItem\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x15\n" +
 	"\x06is_dir\x18\x02 \x01(\bR\x05isDir\x12\x12\n" +
 	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x19\n" +
 	"\bmod_time\x18\x04 \x01(\x03R\amodTime\x12\x12\n" +
-	"\x04hash\x18\x05 \x01(\tR\x04hash\"Y\n" +
+	"\x04hash\x18\x05 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04mode\x18\x06 \x01(\x05R\x04mode\x12!\n" +
+	"\flast_version\x18\a \x01(\x03R\vlastVersion\x12\x1f\n" +
+	"\vlast_commit\x18\b \x01(\tR\n" +
+	"lastCommit\x12\x1f\n" +
+	"\vlast_author\x18\t \x01(\tR\n" +
+	"lastAuthor\x12!\n" +
+	"\flast_message\x18\n" +
+	" \x01(\tR\vlastMessage\x12%\n" +
+	"\x0elast_timestamp\x18\v \x01(\x03R\rlastTimestamp\"D\n" +
+	"\x12GetTreeHashRequest\x12\x14\n" +
+	"\x05paths\x18\x01 \x03(\tR\x05paths\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"[\n" +
+	"\x13GetTreeHashResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12*\n" +
+	"\x06hashes\x18\x02 \x03(\v2\x12.monorepo.TreeHashR\x06hashes\"a\n" +
+	"\bTreeHash\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x15\n" +
+	"\x06is_dir\x18\x03 \x01(\bR\x05isDir\x12\x16\n" +
+	"\x06exists\x18\x04 \x01(\bR\x06exists\"@\n" +
+	"\x12GetPathInfoRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06branch\x18\x02 \x01(\tR\x06branch\"\xef\x04\n" +
+	"\x13GetPathInfoResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x15\n" +
+	"\x06is_dir\x18\x02 \x01(\bR\x05isDir\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x12\x14\n" +
+	"\x05files\x18\x04 \x01(\x05R\x05files\x12 \n" +
+	"\vdirectories\x18\x05 \x01(\x05R\vdirectories\x12\x1f\n" +
+	"\vtotal_files\x18\x06 \x01(\x03R\n" +
+	"totalFiles\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\a \x01(\x03R\ttotalSize\x121\n" +
+	"\vlast_change\x18\b \x01(\v2\x10.monorepo.CommitR\n" +
+	"lastChange\x12.\n" +
+	"\x13last_change_version\x18\t \x01(\x03R\x11lastChangeVersion\x12\x1f\n" +
+	"\vreadme_path\x18\n" +
+	" \x01(\tR\n" +
+	"readmePath\x12%\n" +
+	"\x0ereadme_content\x18\v \x01(\fR\rreadmeContent\x12)\n" +
+	"\x10readme_truncated\x18\f \x01(\bR\x0freadmeTruncated\x12\x16\n" +
+	"\x06owners\x18\r \x03(\tR\x06owners\x12\x1f\n" +
+	"\vowners_path\x18\x0e \x01(\tR\n" +
+	"ownersPath\x12M\n" +
+	"\n" +
+	"attributes\x18\x0f \x03(\v2-.monorepo.GetPathInfoResponse.AttributesEntryR\n" +
+	"attributes\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"G\n" +
+	"\x19ListCaseCollisionsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06branch\x18\x02 \x01(\tR\x06branch\"%\n" +
+	"\rCaseCollision\x12\x14\n" +
+	"\x05paths\x18\x01 \x03(\tR\x05paths\"o\n" +
+	"\x1aListCaseCollisionsResponse\x127\n" +
+	"\n" +
+	"collisions\x18\x01 \x03(\v2\x17.monorepo.CaseCollisionR\n" +
+	"collisions\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"q\n" +
+	"\x17GetAffectedPathsRequest\x12!\n" +
+	"\ffrom_version\x18\x01 \x01(\x03R\vfromVersion\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x02 \x01(\x03R\ttoVersion\x12\x14\n" +
+	"\x05depth\x18\x03 \x01(\x05R\x05depth\"G\n" +
+	"\fAffectedPath\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12#\n" +
+	"\rfiles_changed\x18\x02 \x01(\x05R\ffilesChanged\"\xaf\x01\n" +
+	"\x18GetAffectedPathsResponse\x12,\n" +
+	"\x05paths\x18\x01 \x03(\v2\x16.monorepo.AffectedPathR\x05paths\x12!\n" +
+	"\ffrom_version\x18\x02 \x01(\x03R\vfromVersion\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x03 \x01(\x03R\ttoVersion\x12#\n" +
+	"\rfiles_changed\x18\x04 \x01(\x05R\ffilesChanged\"\xc3\x01\n" +
 	"\x0fReadFileRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
 	"\x06branch\x18\x02 \x01(\tR\x06branch\x12\x1a\n" +
-	"\brevision\x18\x03 \x01(\tR\brevision\"T\n" +
+	"\brevision\x18\x03 \x01(\tR\brevision\x12\x1e\n" +
+	"\vif_not_hash\x18\x04 \x01(\tR\tifNotHash\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\x12\x16\n" +
+	"\x06offset\x18\x06 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\a \x01(\x03R\x06length\"\x8f\x01\n" +
 	"\x10ReadFileResponse\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\fR\acontent\x12\x12\n" +
 	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x12\n" +
-	"\x04size\x18\x03 \x01(\x03R\x04size\"V\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12!\n" +
+	"\fnot_modified\x18\x04 \x01(\bR\vnotModified\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x03R\x06offset\"\xf3\x01\n" +
+	"\x10ReadFilesRequest\x12\x14\n" +
+	"\x05paths\x18\x01 \x03(\tR\x05paths\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12&\n" +
+	"\x0fmax_total_bytes\x18\x03 \x01(\x03R\rmaxTotalBytes\x12I\n" +
+	"\vif_not_hash\x18\x04 \x03(\v2).monorepo.ReadFilesRequest.IfNotHashEntryR\tifNotHash\x1a<\n" +
+	"\x0eIfNotHashEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"w\n" +
+	"\x11ReadFilesResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12*\n" +
+	"\x05files\x18\x02 \x03(\v2\x14.monorepo.FileResultR\x05files\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\"\xe6\x01\n" +
+	"\n" +
+	"FileResult\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12/\n" +
+	"\afailure\x18\x06 \x01(\v2\x15.monorepo.FailureInfoR\afailure\x12\x18\n" +
+	"\aomitted\x18\a \x01(\bR\aomitted\x12!\n" +
+	"\fnot_modified\x18\b \x01(\bR\vnotModified\"F\n" +
+	"\x16StreamDirectoryRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"b\n" +
+	"\x17StreamDirectoryResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12-\n" +
+	"\x05items\x18\x02 \x03(\v2\x17.monorepo.DirectoryItemR\x05items\"q\n" +
+	"\x11StreamFileRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\x04 \x01(\x03R\x06length\"e\n" +
+	"\tFileChunk\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\"\xcf\x01\n" +
+	"\x12PreviewFileRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x1b\n" +
+	"\tfrom_line\x18\x03 \x01(\x05R\bfromLine\x12\x17\n" +
+	"\ato_line\x18\x04 \x01(\x05R\x06toLine\x12\x1b\n" +
+	"\tmax_lines\x18\x05 \x01(\x05R\bmaxLines\x12\x1c\n" +
+	"\thighlight\x18\x06 \x01(\tR\thighlight\x12\x1a\n" +
+	"\blanguage\x18\a \x01(\tR\blanguage\"W\n" +
+	"\vPreviewLine\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\"\x8b\x02\n" +
+	"\x13PreviewFileResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x1f\n" +
+	"\vtotal_lines\x18\x05 \x01(\x05R\n" +
+	"totalLines\x12+\n" +
+	"\x05lines\x18\x06 \x03(\v2\x15.monorepo.PreviewLineR\x05lines\x12\x1c\n" +
+	"\ttruncated\x18\a \x01(\bR\ttruncated\x12\x16\n" +
+	"\x06binary\x18\b \x01(\bR\x06binary\x12\x1a\n" +
+	"\blanguage\x18\t \x01(\tR\blanguage\"E\n" +
+	"\x15GetRenderedDocRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\xba\x01\n" +
+	"\x16GetRenderedDocResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04html\x18\x04 \x01(\tR\x04html\x12\x14\n" +
+	"\x05title\x18\x05 \x01(\tR\x05title\x12\x1c\n" +
+	"\ttruncated\x18\x06 \x01(\bR\ttruncated\x12\x16\n" +
+	"\x06cached\x18\a \x01(\bR\x06cached\"\xaa\x01\n" +
+	"\x06Symbol\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x12\n" +
+	"\x04line\x18\x04 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x05 \x01(\x05R\x06column\x12\x1c\n" +
+	"\tcontainer\x18\x06 \x01(\tR\tcontainer\x12\x1a\n" +
+	"\blanguage\x18\a \x01(\tR\blanguage\"\x9c\x01\n" +
+	"\x14SearchSymbolsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x14\n" +
+	"\x05kinds\x18\x04 \x03(\tR\x05kinds\x12\x14\n" +
+	"\x05exact\x18\x05 \x01(\bR\x05exact\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\"{\n" +
+	"\x15SearchSymbolsResponse\x12*\n" +
+	"\asymbols\x18\x01 \x03(\v2\x10.monorepo.SymbolR\asymbols\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\"q\n" +
+	"\x15GoToDefinitionRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x04 \x01(\x05R\x06column\"\x86\x01\n" +
+	"\x16GoToDefinitionResponse\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x01 \x01(\tR\n" +
+	"identifier\x122\n" +
+	"\vdefinitions\x18\x02 \x03(\v2\x10.monorepo.SymbolR\vdefinitions\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"V\n" +
 	"\x12FileHistoryRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
 	"\x06branch\x18\x02 \x01(\tR\x06branch\x12\x14\n" +
 	"\x05limit\x18\x03 \x01(\x05R\x05limit\"A\n" +
 	"\x13FileHistoryResponse\x12*\n" +
-	"\acommits\x18\x01 \x03(\v2\x10.monorepo.CommitR\acommits\"\x91\x01\n" +
+	"\acommits\x18\x01 \x03(\v2\x10.monorepo.CommitR\acommits\"\xc0\x01\n" +
 	"\x06Commit\x12\x12\n" +
 	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x16\n" +
 	"\x06author\x18\x02 \x01(\tR\x06author\x12\x18\n" +
 	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
 	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12#\n" +
-	"\rchanged_files\x18\x05 \x03(\tR\fchangedFiles\"\x11\n" +
+	"\rchanged_files\x18\x05 \x03(\tR\fchangedFiles\x12\x12\n" +
+	"\x04path\x18\x06 \x01(\tR\x04path\x12\x19\n" +
+	"\bold_path\x18\a \x01(\tR\aoldPath\"\x11\n" +
 	"\x0fBranchesRequest\"U\n" +
 	"\x10BranchesResponse\x12\x1a\n" +
 	"\bbranches\x18\x01 \x03(\tR\bbranches\x12%\n" +
@@ -1921,22 +11983,116 @@ const file_monorepo_proto_rawDesc = "" +
 	"\vbranch_name\x18\x03 \x01(\tR\n" +
 	"branchName\x12\x1f\n" +
 	"\vcommit_hash\x18\x04 \x01(\tR\n" +
-	"commitHash\"\xfb\x01\n" +
+	"commitHash\"\xd0\x01\n" +
+	"\x14MergeBranchesRequest\x12#\n" +
+	"\rsource_branch\x18\x01 \x01(\tR\fsourceBranch\x12#\n" +
+	"\rtarget_branch\x18\x02 \x01(\tR\ftargetBranch\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x16\n" +
+	"\x06squash\x18\x05 \x01(\bR\x06squash\x12$\n" +
+	"\x0efail_if_locked\x18\x06 \x01(\bR\ffailIfLocked\"\x8b\x03\n" +
+	"\x15MergeBranchesResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vcommit_hash\x18\x03 \x01(\tR\n" +
+	"commitHash\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x12\x1d\n" +
+	"\n" +
+	"merge_base\x18\x05 \x01(\tR\tmergeBase\x12\x1c\n" +
+	"\n" +
+	"up_to_date\x18\x06 \x01(\bR\bupToDate\x12\x1c\n" +
+	"\tconflicts\x18\a \x03(\tR\tconflicts\x12/\n" +
+	"\afailure\x18\b \x01(\v2\x15.monorepo.FailureInfoR\afailure\x129\n" +
+	"\n" +
+	"violations\x18\t \x03(\v2\x19.monorepo.PolicyViolationR\n" +
+	"violations\x12\x16\n" +
+	"\x06queued\x18\n" +
+	" \x01(\bR\x06queued\x12$\n" +
+	"\x0equeue_entry_id\x18\v \x01(\tR\fqueueEntryId\"\xb1\x01\n" +
+	"\x11CherryPickRequest\x12\x1f\n" +
+	"\vcommit_hash\x18\x01 \x01(\tR\n" +
+	"commitHash\x12#\n" +
+	"\rtarget_branch\x18\x02 \x01(\tR\ftargetBranch\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x12$\n" +
+	"\x0efail_if_locked\x18\x05 \x01(\bR\ffailIfLocked\"\xe1\x02\n" +
+	"\x12CherryPickResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vcommit_hash\x18\x03 \x01(\tR\n" +
+	"commitHash\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x12\x14\n" +
+	"\x05empty\x18\x05 \x01(\bR\x05empty\x12\x1c\n" +
+	"\tconflicts\x18\x06 \x03(\tR\tconflicts\x12/\n" +
+	"\afailure\x18\a \x01(\v2\x15.monorepo.FailureInfoR\afailure\x129\n" +
+	"\n" +
+	"violations\x18\b \x03(\v2\x19.monorepo.PolicyViolationR\n" +
+	"violations\x12\x16\n" +
+	"\x06queued\x18\t \x01(\bR\x06queued\x12$\n" +
+	"\x0equeue_entry_id\x18\n" +
+	" \x01(\tR\fqueueEntryId\"\xdd\x02\n" +
 	"\x16CreateWorkspaceRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
 	"\rtracked_paths\x18\x02 \x03(\tR\ftrackedPaths\x12\x1f\n" +
 	"\vbase_branch\x18\x03 \x01(\tR\n" +
 	"baseBranch\x12J\n" +
-	"\bmetadata\x18\x04 \x03(\v2..monorepo.CreateWorkspaceRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\bmetadata\x18\x04 \x03(\v2..monorepo.CreateWorkspaceRequest.MetadataEntryR\bmetadata\x120\n" +
+	"\x14override_size_limits\x18\x05 \x01(\bR\x12overrideSizeLimits\x12\x1a\n" +
+	"\btemplate\x18\x06 \x01(\tR\btemplate\x12\x12\n" +
+	"\x04lazy\x18\a \x01(\bR\x04lazy\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8f\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x01\n" +
 	"\x17CreateWorkspaceResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12!\n" +
 	"\fworkspace_id\x18\x03 \x01(\tR\vworkspaceId\x12\x1d\n" +
 	"\n" +
-	"remote_url\x18\x04 \x01(\tR\tremoteUrl\"8\n" +
+	"remote_url\x18\x04 \x01(\tR\tremoteUrl\x12!\n" +
+	"\foperation_id\x18\x05 \x01(\tR\voperationId\"\xa3\x03\n" +
+	"\tOperation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x12\n" +
+	"\x04done\x18\x04 \x01(\bR\x04done\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12#\n" +
+	"\rprogress_done\x18\x06 \x01(\x03R\fprogressDone\x12%\n" +
+	"\x0eprogress_total\x18\a \x01(\x03R\rprogressTotal\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12\x1f\n" +
+	"\vfinished_at\x18\t \x01(\tR\n" +
+	"finishedAt\x127\n" +
+	"\x06result\x18\n" +
+	" \x03(\v2\x1f.monorepo.Operation.ResultEntryR\x06result\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\x12\x1a\n" +
+	"\bresponse\x18\f \x01(\fR\bresponse\x1a9\n" +
+	"\vResultEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"8\n" +
+	"\x13GetOperationRequest\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\"}\n" +
+	"\x14GetOperationResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x121\n" +
+	"\toperation\x18\x03 \x01(\v2\x13.monorepo.OperationR\toperation\"b\n" +
+	"\x14WaitOperationRequest\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\x12'\n" +
+	"\x0ftimeout_seconds\x18\x02 \x01(\x05R\x0etimeoutSeconds\"~\n" +
+	"\x15WaitOperationResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x121\n" +
+	"\toperation\x18\x03 \x01(\v2\x13.monorepo.OperationR\toperation\";\n" +
+	"\x16CancelOperationRequest\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\"M\n" +
+	"\x17CancelOperationResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\":\n" +
+	"\x15ListOperationsRequest\x12!\n" +
+	"\frunning_only\x18\x01 \x01(\bR\vrunningOnly\"M\n" +
+	"\x16ListOperationsResponse\x123\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x13.monorepo.OperationR\n" +
+	"operations\"8\n" +
 	"\x13GetWorkspaceRequest\x12!\n" +
 	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\"\x81\x01\n" +
 	"\x14GetWorkspaceResponse\x12\x18\n" +
@@ -1958,7 +12114,7 @@ const file_monorepo_proto_rawDesc = "" +
 	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\"M\n" +
 	"\x17DeleteWorkspaceResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\xc7\x02\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xf9\x05\n" +
 	"\rWorkspaceInfo\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12#\n" +
@@ -1967,10 +12123,40 @@ const file_monorepo_proto_rawDesc = "" +
 	"created_at\x18\x04 \x01(\tR\tcreatedAt\x12\x1b\n" +
 	"\tlast_sync\x18\x05 \x01(\tR\blastSync\x121\n" +
 	"\x06status\x18\x06 \x01(\x0e2\x19.monorepo.WorkspaceStatusR\x06status\x12A\n" +
-	"\bmetadata\x18\a \x03(\v2%.monorepo.WorkspaceInfo.MetadataEntryR\bmetadata\x1a;\n" +
+	"\bmetadata\x18\a \x03(\v2%.monorepo.WorkspaceInfo.MetadataEntryR\bmetadata\x12%\n" +
+	"\x0eclient_version\x18\b \x01(\tR\rclientVersion\x12\x1f\n" +
+	"\vhead_commit\x18\t \x01(\tR\n" +
+	"headCommit\x12\x1f\n" +
+	"\vdirty_files\x18\n" +
+	" \x01(\x05R\n" +
+	"dirtyFiles\x12\x1f\n" +
+	"\vlast_report\x18\v \x01(\tR\n" +
+	"lastReport\x12\x1a\n" +
+	"\bdiverged\x18\f \x01(\bR\bdiverged\x12\x14\n" +
+	"\x05owner\x18\r \x01(\tR\x05owner\x12)\n" +
+	"\x10tracked_patterns\x18\x0e \x03(\tR\x0ftrackedPatterns\x12\x1d\n" +
+	"\n" +
+	"disk_bytes\x18\x0f \x01(\x03R\tdiskBytes\x12\x18\n" +
+	"\acommits\x18\x10 \x01(\x03R\acommits\x12!\n" +
+	"\ffiles_copied\x18\x11 \x01(\x03R\vfilesCopied\x12\x1f\n" +
+	"\vfiles_total\x18\x12 \x01(\x03R\n" +
+	"filesTotal\x12%\n" +
+	"\x0estatus_message\x18\x13 \x01(\tR\rstatusMessage\x12%\n" +
+	"\x0esynced_version\x18\x14 \x01(\x03R\rsyncedVersion\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"o\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc8\x01\n" +
+	"\x1cReportWorkspaceStatusRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12%\n" +
+	"\x0eclient_version\x18\x02 \x01(\tR\rclientVersion\x12\x1f\n" +
+	"\vhead_commit\x18\x03 \x01(\tR\n" +
+	"headCommit\x12\x1f\n" +
+	"\vdirty_files\x18\x04 \x01(\x05R\n" +
+	"dirtyFiles\x12\x1c\n" +
+	"\toperation\x18\x05 \x01(\tR\toperation\"S\n" +
+	"\x1dReportWorkspaceStatusResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"o\n" +
 	"\x15SparseCheckoutRequest\x12\x14\n" +
 	"\x05paths\x18\x01 \x03(\tR\x05paths\x12\x1d\n" +
 	"\n" +
@@ -1979,48 +12165,588 @@ const file_monorepo_proto_rawDesc = "" +
 	"\x16SparseCheckoutResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12)\n" +
-	"\x10configured_paths\x18\x03 \x03(\tR\x0fconfiguredPaths\"Y\n" +
+	"\x10configured_paths\x18\x03 \x03(\tR\x0fconfiguredPaths\"o\n" +
 	"\x13DownloadPathRequest\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
 	"\x06branch\x18\x02 \x01(\tR\x06branch\x12\x16\n" +
-	"\x06format\x18\x03 \x01(\tR\x06format\"\x80\x01\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12\x14\n" +
+	"\x05async\x18\x04 \x01(\bR\x05async\"\x8f\x02\n" +
 	"\x14DownloadPathResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
 	"\acontent\x18\x03 \x01(\fR\acontent\x12\x1a\n" +
-	"\bfilename\x18\x04 \x01(\tR\bfilename\"f\n" +
+	"\bfilename\x18\x04 \x01(\tR\bfilename\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\x12\x1b\n" +
+	"\ttree_hash\x18\x06 \x01(\tR\btreeHash\x12!\n" +
+	"\foperation_id\x18\a \x01(\tR\voperationId\x12\x12\n" +
+	"\x04path\x18\b \x01(\tR\x04path\x12\x1f\n" +
+	"\vcommit_hash\x18\t \x01(\tR\n" +
+	"commitHash\"\x97\x01\n" +
+	"\rDownloadChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x12\x1b\n" +
+	"\ttree_hash\x18\x04 \x01(\tR\btreeHash\x12\x1f\n" +
+	"\vcommit_hash\x18\x05 \x01(\tR\n" +
+	"commitHash\"\xf2\x01\n" +
+	"\x11WorkspaceTemplate\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12#\n" +
+	"\rtracked_paths\x18\x03 \x03(\tR\ftrackedPaths\x12E\n" +
+	"\bmetadata\x18\x04 \x03(\v2).monorepo.WorkspaceTemplate.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x16\n" +
+	"\x14ListTemplatesRequest\"R\n" +
+	"\x15ListTemplatesResponse\x129\n" +
+	"\ttemplates\x18\x01 \x03(\v2\x1b.monorepo.WorkspaceTemplateR\ttemplates\"V\n" +
+	"\bPathView\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05paths\x18\x03 \x03(\tR\x05paths\",\n" +
+	"\x10ListViewsRequest\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\"\x8b\x01\n" +
+	"\x11ListViewsResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12(\n" +
+	"\x05views\x18\x03 \x03(\v2\x12.monorepo.PathViewR\x05views\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\"B\n" +
+	"\x1dStreamWorkspaceArchiveRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\"[\n" +
+	"\x15WorkspaceArchiveChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x12\x16\n" +
+	"\x06branch\x18\x03 \x01(\tR\x06branch\"\x98\x01\n" +
 	"\x15AddTrackedPathRequest\x12!\n" +
 	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12\x16\n" +
-	"\x06branch\x18\x03 \x01(\tR\x06branch\"\x8e\x01\n" +
+	"\x06branch\x18\x03 \x01(\tR\x06branch\x120\n" +
+	"\x14override_size_limits\x18\x04 \x01(\bR\x12overrideSizeLimits\"\xaf\x01\n" +
 	"\x16AddTrackedPathResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
 	"\vcommit_hash\x18\x03 \x01(\tR\n" +
 	"commitHash\x12\x1f\n" +
 	"\vnew_version\x18\x04 \x01(\x03R\n" +
-	"newVersion*D\n" +
+	"newVersion\x12\x1f\n" +
+	"\vadded_paths\x18\x05 \x03(\tR\n" +
+	"addedPaths\"?\n" +
+	"\x1aRefreshTrackedPathsRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\"\xb4\x01\n" +
+	"\x1bRefreshTrackedPathsResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vadded_paths\x18\x03 \x03(\tR\n" +
+	"addedPaths\x12\x1f\n" +
+	"\vcommit_hash\x18\x04 \x01(\tR\n" +
+	"commitHash\x12\x1f\n" +
+	"\vnew_version\x18\x05 \x01(\x03R\n" +
+	"newVersion\"k\n" +
+	"\x18OpenWorkspaceFileRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"\xa5\x01\n" +
+	"\x19OpenWorkspaceFileResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12\x18\n" +
+	"\atracked\x18\x06 \x01(\bR\atracked\"o\n" +
+	"\x1cListWorkspaceSiblingsRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"\x8b\x01\n" +
+	"\x1dListWorkspaceSiblingsResponse\x12\x1c\n" +
+	"\tdirectory\x18\x01 \x01(\tR\tdirectory\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x122\n" +
+	"\aentries\x18\x03 \x03(\v2\x18.monorepo.WorkspaceEntryR\aentries\"\x91\x01\n" +
+	"\x0eWorkspaceEntry\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x15\n" +
+	"\x06is_dir\x18\x03 \x01(\bR\x05isDir\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04hash\x18\x05 \x01(\tR\x04hash\x12\x18\n" +
+	"\atracked\x18\x06 \x01(\bR\atracked\"\xc1\x01\n" +
+	"!FetchWorkspaceDependenciesRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x14\n" +
+	"\x05paths\x18\x02 \x03(\tR\x05paths\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x12&\n" +
+	"\x0fmax_total_bytes\x18\x04 \x01(\x03R\rmaxTotalBytes\x12!\n" +
+	"\fskip_tracked\x18\x05 \x01(\bR\vskipTracked\"\x88\x01\n" +
+	"\"FetchWorkspaceDependenciesResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12*\n" +
+	"\x05files\x18\x02 \x03(\v2\x14.monorepo.FileResultR\x05files\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\"\x0f\n" +
+	"\rWhoAmIRequest\"o\n" +
+	"\x0eWhoAmIResponse\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12$\n" +
+	"\rauthenticated\x18\x02 \x01(\bR\rauthenticated\x12#\n" +
+	"\rauth_required\x18\x03 \x01(\bR\fauthRequired\"=\n" +
+	"\x14GetServerInfoRequest\x12%\n" +
+	"\x0eclient_version\x18\x01 \x01(\tR\rclientVersion\"\xa9\x02\n" +
+	"\x15GetServerInfoResponse\x12%\n" +
+	"\x0eserver_version\x18\x01 \x01(\tR\rserverVersion\x12\x1f\n" +
+	"\vapi_version\x18\x02 \x01(\tR\n" +
+	"apiVersion\x12,\n" +
+	"\x12min_client_version\x18\x03 \x01(\tR\x10minClientVersion\x12\x1a\n" +
+	"\bfeatures\x18\x04 \x03(\tR\bfeatures\x12\x1d\n" +
+	"\n" +
+	"auth_modes\x18\x05 \x03(\tR\tauthModes\x12%\n" +
+	"\x0ehash_algorithm\x18\x06 \x01(\tR\rhashAlgorithm\x12\x17\n" +
+	"\aweb_url\x18\a \x01(\tR\x06webUrl\x12\x1f\n" +
+	"\vhash_format\x18\b \x01(\tR\n" +
+	"hashFormat\"r\n" +
+	"\bPathLock\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"\\\n" +
+	"\x0fLockPathRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x03R\n" +
+	"ttlSeconds\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\"n\n" +
+	"\x10LockPathResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12&\n" +
+	"\x04lock\x18\x03 \x01(\v2\x12.monorepo.PathLockR\x04lock\"=\n" +
+	"\x11UnlockPathRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\"H\n" +
+	"\x12UnlockPathResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"3\n" +
+	"\x10ListLocksRequest\x12\x1f\n" +
+	"\vpath_prefix\x18\x01 \x01(\tR\n" +
+	"pathPrefix\"=\n" +
+	"\x11ListLocksResponse\x12(\n" +
+	"\x05locks\x18\x01 \x03(\v2\x12.monorepo.PathLockR\x05locks\"\x92\x01\n" +
+	"\x03Tag\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x1f\n" +
+	"\vcommit_hash\x18\x03 \x01(\tR\n" +
+	"commitHash\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\")\n" +
+	"\x0fListTagsRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\"5\n" +
+	"\x10ListTagsResponse\x12!\n" +
+	"\x04tags\x18\x01 \x03(\v2\r.monorepo.TagR\x04tags\"\xb3\x01\n" +
+	"\x12GetActivityRequest\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x03R\x03end\x12\x19\n" +
+	"\bgroup_by\x18\x03 \x01(\tR\agroupBy\x12\x1b\n" +
+	"\ttime_zone\x18\x04 \x01(\tR\btimeZone\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12'\n" +
+	"\x0fdirectory_depth\x18\x06 \x01(\x05R\x0edirectoryDepth\"\xef\x01\n" +
+	"\rActivityGroup\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x18\n" +
+	"\acommits\x18\x03 \x01(\x03R\acommits\x12\x18\n" +
+	"\aauthors\x18\x04 \x01(\x03R\aauthors\x12#\n" +
+	"\rfiles_changed\x18\x05 \x01(\x03R\ffilesChanged\x12\x1e\n" +
+	"\n" +
+	"insertions\x18\x06 \x01(\x03R\n" +
+	"insertions\x12\x1c\n" +
+	"\tdeletions\x18\a \x01(\x03R\tdeletions\x12\x1f\n" +
+	"\vbytes_added\x18\b \x01(\x03R\n" +
+	"bytesAdded\"u\n" +
+	"\x13GetActivityResponse\x12/\n" +
+	"\x06groups\x18\x01 \x03(\v2\x17.monorepo.ActivityGroupR\x06groups\x12-\n" +
+	"\x05total\x18\x02 \x01(\v2\x17.monorepo.ActivityGroupR\x05total\"4\n" +
+	"\x0fGetQuotaRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\"\x97\x02\n" +
+	"\n" +
+	"QuotaUsage\x12\x1d\n" +
+	"\n" +
+	"bytes_used\x18\x01 \x01(\x03R\tbytesUsed\x12\x1f\n" +
+	"\vbytes_limit\x18\x02 \x01(\x03R\n" +
+	"bytesLimit\x12'\n" +
+	"\x0fworkspaces_used\x18\x03 \x01(\x03R\x0eworkspacesUsed\x12)\n" +
+	"\x10workspaces_limit\x18\x04 \x01(\x03R\x0fworkspacesLimit\x12#\n" +
+	"\rrequest_count\x18\x05 \x01(\x03R\frequestCount\x12&\n" +
+	"\x0fdisk_bytes_used\x18\x06 \x01(\x03R\rdiskBytesUsed\x12(\n" +
+	"\x10disk_bytes_limit\x18\a \x01(\x03R\x0ediskBytesLimit\"\xce\x01\n" +
+	"\x10GetQuotaResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\x123\n" +
+	"\n" +
+	"user_usage\x18\x04 \x01(\v2\x14.monorepo.QuotaUsageR\tuserUsage\x12=\n" +
+	"\x0fworkspace_usage\x18\x05 \x01(\v2\x14.monorepo.QuotaUsageR\x0eworkspaceUsage\"G\n" +
+	"\x13ApprovePatchRequest\x12\x14\n" +
+	"\x05patch\x18\x01 \x01(\fR\x05patch\x12\x1a\n" +
+	"\bapprover\x18\x02 \x01(\tR\bapprover\"\x8b\x01\n" +
+	"\x14ApprovePatchResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12!\n" +
+	"\fpatch_digest\x18\x03 \x01(\tR\vpatchDigest\x12\x1c\n" +
+	"\tapprovers\x18\x04 \x03(\tR\tapprovers\"\xa2\x03\n" +
+	"\n" +
+	"QueueEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\x12/\n" +
+	"\x05state\x18\x05 \x01(\x0e2\x19.monorepo.QueueEntryStateR\x05state\x12#\n" +
+	"\rstate_message\x18\x06 \x01(\tR\fstateMessage\x12\x1a\n" +
+	"\bposition\x18\a \x01(\x05R\bposition\x12!\n" +
+	"\fbase_version\x18\b \x01(\x03R\vbaseVersion\x12%\n" +
+	"\x0elanded_version\x18\t \x01(\x03R\rlandedVersion\x12\x1f\n" +
+	"\vcommit_hash\x18\n" +
+	" \x01(\tR\n" +
+	"commitHash\x12!\n" +
+	"\fsubmitted_at\x18\v \x01(\x03R\vsubmittedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\x03R\tupdatedAt\x12\x1f\n" +
+	"\vdetails_url\x18\r \x01(\tR\n" +
+	"detailsUrl\"1\n" +
+	"\x14GetMergeQueueRequest\x12\x19\n" +
+	"\bentry_id\x18\x01 \x01(\tR\aentryId\"a\n" +
+	"\x15GetMergeQueueResponse\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12.\n" +
+	"\aentries\x18\x02 \x03(\v2\x14.monorepo.QueueEntryR\aentries\"\xa2\x01\n" +
+	"\x1cReportQueueValidationRequest\x12\x19\n" +
+	"\bentry_id\x18\x01 \x01(\tR\aentryId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x16\n" +
+	"\x06passed\x18\x03 \x01(\bR\x06passed\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1f\n" +
+	"\vdetails_url\x18\x05 \x01(\tR\n" +
+	"detailsUrl\"S\n" +
+	"\x1dReportQueueValidationResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xc4\x01\n" +
+	"\vDeletedPath\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\x05R\x04mode\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12'\n" +
+	"\x0fdeleted_version\x18\x05 \x01(\x03R\x0edeletedVersion\x12\x1d\n" +
+	"\n" +
+	"deleted_by\x18\x06 \x01(\tR\tdeletedBy\x12\x1d\n" +
+	"\n" +
+	"deleted_at\x18\a \x01(\x03R\tdeletedAt\"-\n" +
+	"\x17ListDeletedPathsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"G\n" +
+	"\x18ListDeletedPathsResponse\x12+\n" +
+	"\x05paths\x18\x01 \x03(\v2\x15.monorepo.DeletedPathR\x05paths\"a\n" +
+	"\x19RestoreDeletedPathRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x9d\x01\n" +
+	"\x1aRestoreDeletedPathResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x121\n" +
+	"\brestored\x18\x04 \x03(\v2\x15.monorepo.DeletedPathR\brestored\"I\n" +
+	"\x18GarbageCollectionRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x12\x14\n" +
+	"\x05async\x18\x02 \x01(\bR\x05async\"\xb1\x01\n" +
+	"\x19GarbageCollectionResponse\x12\x18\n" +
+	"\ascanned\x18\x01 \x01(\x03R\ascanned\x12\x1c\n" +
+	"\treachable\x18\x02 \x01(\x03R\treachable\x12\x18\n" +
+	"\aremoved\x18\x03 \x01(\x03R\aremoved\x12\x1f\n" +
+	"\vbytes_freed\x18\x04 \x01(\x03R\n" +
+	"bytesFreed\x12!\n" +
+	"\foperation_id\x18\x05 \x01(\tR\voperationId\"#\n" +
+	"\vFsckRequest\x12\x14\n" +
+	"\x05async\x18\x01 \x01(\bR\x05async\"\xaf\x04\n" +
+	"\fFsckResponse\x12'\n" +
+	"\x0fobjects_checked\x18\x01 \x01(\x03R\x0eobjectsChecked\x12)\n" +
+	"\x10versions_checked\x18\x02 \x01(\x03R\x0fversionsChecked\x12\x1a\n" +
+	"\bproblems\x18\x03 \x03(\tR\bproblems\x12%\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\tR\rhashAlgorithm\x12`\n" +
+	"\x14objects_by_algorithm\x18\x05 \x03(\v2..monorepo.FsckResponse.ObjectsByAlgorithmEntryR\x12objectsByAlgorithm\x12!\n" +
+	"\foperation_id\x18\x06 \x01(\tR\voperationId\x12\x1f\n" +
+	"\vhash_format\x18\a \x01(\tR\n" +
+	"hashFormat\x12W\n" +
+	"\x11objects_by_format\x18\b \x03(\v2+.monorepo.FsckResponse.ObjectsByFormatEntryR\x0fobjectsByFormat\x1aE\n" +
+	"\x17ObjectsByAlgorithmEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1aB\n" +
+	"\x14ObjectsByFormatEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x15\n" +
+	"\x13BackendStatsRequest\"\xc4\x04\n" +
+	"\x14BackendStatsResponse\x12\x18\n" +
+	"\aobjects\x18\x01 \x01(\x03R\aobjects\x12\x14\n" +
+	"\x05blobs\x18\x02 \x01(\x03R\x05blobs\x12\x14\n" +
+	"\x05trees\x18\x03 \x01(\x03R\x05trees\x12\x18\n" +
+	"\acommits\x18\x04 \x01(\x03R\acommits\x12!\n" +
+	"\fobject_bytes\x18\x05 \x01(\x03R\vobjectBytes\x12\x1a\n" +
+	"\bversions\x18\x06 \x01(\x03R\bversions\x12'\n" +
+	"\x0fcurrent_version\x18\a \x01(\x03R\x0ecurrentVersion\x12\x12\n" +
+	"\x04keys\x18\b \x01(\x03R\x04keys\x12\x1f\n" +
+	"\vtotal_bytes\x18\t \x01(\x03R\n" +
+	"totalBytes\x12\x1e\n" +
+	"\n" +
+	"workspaces\x18\n" +
+	" \x01(\x03R\n" +
+	"workspaces\x12\x14\n" +
+	"\x05locks\x18\v \x01(\x03R\x05locks\x122\n" +
+	"\x15archive_cache_entries\x18\f \x01(\x03R\x13archiveCacheEntries\x12.\n" +
+	"\x13archive_cache_bytes\x18\r \x01(\x03R\x11archiveCacheBytes\x125\n" +
+	"\x17archive_cache_max_bytes\x18\x0e \x01(\x03R\x14archiveCacheMaxBytes\x12,\n" +
+	"\x12archive_cache_hits\x18\x0f \x01(\x03R\x10archiveCacheHits\x120\n" +
+	"\x14archive_cache_misses\x18\x10 \x01(\x03R\x12archiveCacheMisses\"\xf3\x02\n" +
+	"\x13SetUserQuotaRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12.\n" +
+	"\x13max_workspace_bytes\x18\x02 \x01(\x03R\x11maxWorkspaceBytes\x12$\n" +
+	"\x0emax_user_bytes\x18\x03 \x01(\x03R\fmaxUserBytes\x12.\n" +
+	"\x13max_user_workspaces\x18\x04 \x01(\x03R\x11maxUserWorkspaces\x12$\n" +
+	"\x0emax_file_bytes\x18\x05 \x01(\x03R\fmaxFileBytes\x123\n" +
+	"\x16max_tracked_path_bytes\x18\x06 \x01(\x03R\x13maxTrackedPathBytes\x12.\n" +
+	"\x13allow_size_override\x18\a \x01(\bR\x11allowSizeOverride\x127\n" +
+	"\x18max_workspace_disk_bytes\x18\b \x01(\x03R\x15maxWorkspaceDiskBytes\"J\n" +
+	"\x14SetUserQuotaResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"Z\n" +
+	"\x18SetWorkspaceQuotaRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x1b\n" +
+	"\tmax_bytes\x18\x02 \x01(\x03R\bmaxBytes\"O\n" +
+	"\x19SetWorkspaceQuotaResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\",\n" +
+	"\x16ForceUnlockPathRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"u\n" +
+	"\x17ForceUnlockPathResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12&\n" +
+	"\x04lock\x18\x03 \x01(\v2\x12.monorepo.PathLockR\x04lock\"w\n" +
+	"\x15ListWorkspacesRequest\x12#\n" +
+	"\rstale_seconds\x18\x01 \x01(\x03R\fstaleSeconds\x12#\n" +
+	"\rdiverged_only\x18\x02 \x01(\bR\fdivergedOnly\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\"Q\n" +
+	"\x16ListWorkspacesResponse\x127\n" +
+	"\n" +
+	"workspaces\x18\x01 \x03(\v2\x17.monorepo.WorkspaceInfoR\n" +
+	"workspaces\"Z\n" +
+	"\x15ReapWorkspacesRequest\x12(\n" +
+	"\x10max_idle_seconds\x18\x01 \x01(\x03R\x0emaxIdleSeconds\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"=\n" +
+	"\x16ReapWorkspacesResponse\x12#\n" +
+	"\rworkspace_ids\x18\x01 \x03(\tR\fworkspaceIds\"b\n" +
+	"\"CollectWorkspaceDirectoriesRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x12#\n" +
+	"\rgrace_seconds\x18\x02 \x01(\x03R\fgraceSeconds\"\xda\x01\n" +
+	"#CollectWorkspaceDirectoriesResponse\x12=\n" +
+	"\vdirectories\x18\x01 \x03(\v2\x1b.monorepo.OrphanedDirectoryR\vdirectories\x12\x1f\n" +
+	"\vbytes_freed\x18\x02 \x01(\x03R\n" +
+	"bytesFreed\x12%\n" +
+	"\x0eshared_objects\x18\x03 \x01(\x03R\rsharedObjects\x12,\n" +
+	"\x12shared_bytes_freed\x18\x04 \x01(\x03R\x10sharedBytesFreed\"S\n" +
+	"\x18CompactWorkspacesRequest\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x03R\x05depth\"{\n" +
+	"\x19CompactWorkspacesResponse\x12=\n" +
+	"\n" +
+	"workspaces\x18\x01 \x03(\v2\x1d.monorepo.WorkspaceCompactionR\n" +
+	"workspaces\x12\x1f\n" +
+	"\vbytes_freed\x18\x02 \x01(\x03R\n" +
+	"bytesFreed\"\xde\x01\n" +
+	"\x13WorkspaceCompaction\x12!\n" +
+	"\fworkspace_id\x18\x01 \x01(\tR\vworkspaceId\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x03R\x05depth\x12%\n" +
+	"\x0ecommits_before\x18\x03 \x01(\x03R\rcommitsBefore\x12#\n" +
+	"\rcommits_after\x18\x04 \x01(\x03R\fcommitsAfter\x12!\n" +
+	"\fbytes_before\x18\x05 \x01(\x03R\vbytesBefore\x12\x1f\n" +
+	"\vbytes_after\x18\x06 \x01(\x03R\n" +
+	"bytesAfter\"^\n" +
+	"\x11OrphanedDirectory\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vmodified_at\x18\x02 \x01(\tR\n" +
+	"modifiedAt\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\"1\n" +
+	"\rBackupRequest\x12 \n" +
+	"\vdestination\x18\x01 \x01(\tR\vdestination\"\x94\x01\n" +
+	"\x0eBackupResponse\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\tR\bsnapshot\x12\x18\n" +
+	"\aobjects\x18\x02 \x01(\x03R\aobjects\x12'\n" +
+	"\x0fobjects_written\x18\x03 \x01(\x03R\x0eobjectsWritten\x12#\n" +
+	"\rbytes_written\x18\x04 \x01(\x03R\fbytesWritten\"D\n" +
+	"\x0eRestoreRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x1a\n" +
+	"\bsnapshot\x18\x02 \x01(\tR\bsnapshot\"\x90\x01\n" +
+	"\x0fRestoreResponse\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\tR\bsnapshot\x12'\n" +
+	"\x0fcurrent_version\x18\x02 \x01(\x03R\x0ecurrentVersion\x12\x18\n" +
+	"\aobjects\x18\x03 \x01(\x03R\aobjects\x12\x1e\n" +
+	"\n" +
+	"workspaces\x18\x04 \x01(\x03R\n" +
+	"workspaces\"O\n" +
+	"\x15MigrateBackendRequest\x12 \n" +
+	"\vdestination\x18\x01 \x01(\tR\vdestination\x12\x14\n" +
+	"\x05async\x18\x02 \x01(\bR\x05async\"\xe6\x01\n" +
+	"\x16MigrateBackendResponse\x12\x18\n" +
+	"\aobjects\x18\x01 \x01(\x03R\aobjects\x12%\n" +
+	"\x0eobjects_copied\x18\x02 \x01(\x03R\robjectsCopied\x12\x1a\n" +
+	"\bmetadata\x18\x03 \x01(\x03R\bmetadata\x12)\n" +
+	"\x10metadata_removed\x18\x04 \x01(\x03R\x0fmetadataRemoved\x12!\n" +
+	"\fbytes_copied\x18\x05 \x01(\x03R\vbytesCopied\x12!\n" +
+	"\foperation_id\x18\x06 \x01(\tR\voperationId\"4\n" +
+	"\x14RehashObjectsRequest\x12\x1c\n" +
+	"\talgorithm\x18\x01 \x01(\tR\talgorithm\"\xcf\x01\n" +
+	"\x15RehashObjectsResponse\x12\x1c\n" +
+	"\talgorithm\x18\x01 \x01(\tR\talgorithm\x12\x18\n" +
+	"\aobjects\x18\x02 \x01(\x03R\aobjects\x12\x1c\n" +
+	"\tunchanged\x18\x03 \x01(\x03R\tunchanged\x12\x1a\n" +
+	"\bversions\x18\x04 \x01(\x03R\bversions\x12#\n" +
+	"\rtrash_entries\x18\x05 \x01(\x03R\ftrashEntries\x12\x1f\n" +
+	"\vhash_format\x18\x06 \x01(\tR\n" +
+	"hashFormat\"\x91\x01\n" +
+	"\x13PruneHistoryRequest\x12\x1b\n" +
+	"\tkeep_days\x18\x01 \x01(\x05R\bkeepDays\x12\x1f\n" +
+	"\vkeep_tagged\x18\x02 \x01(\bR\n" +
+	"keepTagged\x12#\n" +
+	"\rkeep_versions\x18\x03 \x03(\x03R\fkeepVersions\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"\x81\x01\n" +
+	"\x14PruneHistoryResponse\x12\x12\n" +
+	"\x04kept\x18\x01 \x01(\x03R\x04kept\x12\x16\n" +
+	"\x06pruned\x18\x02 \x01(\x03R\x06pruned\x12\x1c\n" +
+	"\trewritten\x18\x03 \x01(\x03R\trewritten\x12\x1f\n" +
+	"\voldest_kept\x18\x04 \x01(\x03R\n" +
+	"oldestKept\"F\n" +
+	"\x19ListCorruptObjectsRequest\x12)\n" +
+	"\x10include_repaired\x18\x01 \x01(\bR\x0fincludeRepaired\"\xe0\x01\n" +
+	"\rCorruptObject\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x1f\n" +
+	"\vdetected_at\x18\x02 \x01(\x03R\n" +
+	"detectedAt\x12 \n" +
+	"\flast_seen_at\x18\x03 \x01(\x03R\n" +
+	"lastSeenAt\x12\x1e\n" +
+	"\n" +
+	"detections\x18\x04 \x01(\x05R\n" +
+	"detections\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1f\n" +
+	"\vrepaired_at\x18\x06 \x01(\x03R\n" +
+	"repairedAt\x12!\n" +
+	"\frepair_error\x18\a \x01(\tR\vrepairError\"O\n" +
+	"\x1aListCorruptObjectsResponse\x121\n" +
+	"\aobjects\x18\x01 \x03(\v2\x17.monorepo.CorruptObjectR\aobjects\"\x1d\n" +
+	"\x1bGetReplicationStatusRequest\"\x97\x02\n" +
+	"\rReplicaStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06active\x18\x02 \x01(\bR\x06active\x12\x18\n" +
+	"\apending\x18\x03 \x01(\x03R\apending\x12\x1f\n" +
+	"\vlag_seconds\x18\x04 \x01(\x01R\n" +
+	"lagSeconds\x12\x1e\n" +
+	"\n" +
+	"replicated\x18\x05 \x01(\x03R\n" +
+	"replicated\x12\x1a\n" +
+	"\bfailures\x18\x06 \x01(\x03R\bfailures\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\a \x01(\tR\tlastError\x12,\n" +
+	"\x12last_replicated_at\x18\b \x01(\x03R\x10lastReplicatedAt\x12\x16\n" +
+	"\x06resync\x18\t \x01(\bR\x06resync\"S\n" +
+	"\x1cGetReplicationStatusResponse\x123\n" +
+	"\breplicas\x18\x01 \x03(\v2\x17.monorepo.ReplicaStatusR\breplicas\"B\n" +
+	"\x16FailoverBackendRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"N\n" +
+	"\x17FailoverBackendResponse\x123\n" +
+	"\breplicas\x18\x01 \x03(\v2\x17.monorepo.ReplicaStatusR\breplicas\"*\n" +
+	"\x14ResyncReplicaRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"L\n" +
+	"\x15ResyncReplicaResponse\x123\n" +
+	"\breplicas\x18\x01 \x03(\v2\x17.monorepo.ReplicaStatusR\breplicas*D\n" +
 	"\x0fWorkspaceStatus\x12\n" +
 	"\n" +
 	"\x06ACTIVE\x10\x00\x12\v\n" +
 	"\aSYNCING\x10\x01\x12\t\n" +
 	"\x05ERROR\x10\x02\x12\r\n" +
-	"\tSUSPENDED\x10\x032\xac\b\n" +
+	"\tSUSPENDED\x10\x03*^\n" +
+	"\x0fQueueEntryState\x12\x11\n" +
+	"\rQUEUE_PENDING\x10\x00\x12\x14\n" +
+	"\x10QUEUE_VALIDATING\x10\x01\x12\x10\n" +
+	"\fQUEUE_LANDED\x10\x02\x12\x10\n" +
+	"\fQUEUE_FAILED\x10\x032\xb9\"\n" +
 	"\x0fMonorepoService\x12G\n" +
 	"\n" +
-	"MergePatch\x12\x1b.monorepo.MergePatchRequest\x1a\x1c.monorepo.MergePatchResponse\x12P\n" +
+	"MergePatch\x12\x1b.monorepo.MergePatchRequest\x1a\x1c.monorepo.MergePatchResponse\x12M\n" +
+	"\fPreviewPatch\x12\x1d.monorepo.PreviewPatchRequest\x1a\x1e.monorepo.PreviewPatchResponse\x12P\n" +
 	"\rReadDirectory\x12\x1e.monorepo.ReadDirectoryRequest\x1a\x1f.monorepo.ReadDirectoryResponse\x12A\n" +
-	"\bReadFile\x12\x19.monorepo.ReadFileRequest\x1a\x1a.monorepo.ReadFileResponse\x12M\n" +
+	"\bReadFile\x12\x19.monorepo.ReadFileRequest\x1a\x1a.monorepo.ReadFileResponse\x12D\n" +
+	"\tReadFiles\x12\x1a.monorepo.ReadFilesRequest\x1a\x1b.monorepo.ReadFilesResponse\x12X\n" +
+	"\x0fStreamDirectory\x12 .monorepo.StreamDirectoryRequest\x1a!.monorepo.StreamDirectoryResponse0\x01\x12@\n" +
+	"\n" +
+	"StreamFile\x12\x1b.monorepo.StreamFileRequest\x1a\x13.monorepo.FileChunk0\x01\x12J\n" +
+	"\vPreviewFile\x12\x1c.monorepo.PreviewFileRequest\x1a\x1d.monorepo.PreviewFileResponse\x12S\n" +
+	"\x0eGetRenderedDoc\x12\x1f.monorepo.GetRenderedDocRequest\x1a .monorepo.GetRenderedDocResponse\x12P\n" +
+	"\rSearchSymbols\x12\x1e.monorepo.SearchSymbolsRequest\x1a\x1f.monorepo.SearchSymbolsResponse\x12S\n" +
+	"\x0eGoToDefinition\x12\x1f.monorepo.GoToDefinitionRequest\x1a .monorepo.GoToDefinitionResponse\x12J\n" +
+	"\vGetPathInfo\x12\x1c.monorepo.GetPathInfoRequest\x1a\x1d.monorepo.GetPathInfoResponse\x12J\n" +
+	"\vGetTreeHash\x12\x1c.monorepo.GetTreeHashRequest\x1a\x1d.monorepo.GetTreeHashResponse\x12_\n" +
+	"\x12ListCaseCollisions\x12#.monorepo.ListCaseCollisionsRequest\x1a$.monorepo.ListCaseCollisionsResponse\x12Y\n" +
+	"\x10GetAffectedPaths\x12!.monorepo.GetAffectedPathsRequest\x1a\".monorepo.GetAffectedPathsResponse\x12M\n" +
 	"\x0eGetFileHistory\x12\x1c.monorepo.FileHistoryRequest\x1a\x1d.monorepo.FileHistoryResponse\x12D\n" +
 	"\vGetBranches\x12\x19.monorepo.BranchesRequest\x1a\x1a.monorepo.BranchesResponse\x12M\n" +
-	"\fCreateBranch\x12\x1d.monorepo.CreateBranchRequest\x1a\x1e.monorepo.CreateBranchResponse\x12V\n" +
+	"\fCreateBranch\x12\x1d.monorepo.CreateBranchRequest\x1a\x1e.monorepo.CreateBranchResponse\x12P\n" +
+	"\rMergeBranches\x12\x1e.monorepo.MergeBranchesRequest\x1a\x1f.monorepo.MergeBranchesResponse\x12G\n" +
+	"\n" +
+	"CherryPick\x12\x1b.monorepo.CherryPickRequest\x1a\x1c.monorepo.CherryPickResponse\x12V\n" +
 	"\x0fCreateWorkspace\x12 .monorepo.CreateWorkspaceRequest\x1a!.monorepo.CreateWorkspaceResponse\x12M\n" +
 	"\fGetWorkspace\x12\x1d.monorepo.GetWorkspaceRequest\x1a\x1e.monorepo.GetWorkspaceResponse\x12V\n" +
 	"\x0fUpdateWorkspace\x12 .monorepo.UpdateWorkspaceRequest\x1a!.monorepo.UpdateWorkspaceResponse\x12V\n" +
-	"\x0fDeleteWorkspace\x12 .monorepo.DeleteWorkspaceRequest\x1a!.monorepo.DeleteWorkspaceResponse\x12\\\n" +
+	"\x0fDeleteWorkspace\x12 .monorepo.DeleteWorkspaceRequest\x1a!.monorepo.DeleteWorkspaceResponse\x12M\n" +
+	"\fGetOperation\x12\x1d.monorepo.GetOperationRequest\x1a\x1e.monorepo.GetOperationResponse\x12P\n" +
+	"\rWaitOperation\x12\x1e.monorepo.WaitOperationRequest\x1a\x1f.monorepo.WaitOperationResponse\x12V\n" +
+	"\x0fCancelOperation\x12 .monorepo.CancelOperationRequest\x1a!.monorepo.CancelOperationResponse\x12S\n" +
+	"\x0eListOperations\x12\x1f.monorepo.ListOperationsRequest\x1a .monorepo.ListOperationsResponse\x12P\n" +
+	"\rListTemplates\x12\x1e.monorepo.ListTemplatesRequest\x1a\x1f.monorepo.ListTemplatesResponse\x12D\n" +
+	"\tListViews\x12\x1a.monorepo.ListViewsRequest\x1a\x1b.monorepo.ListViewsResponse\x12h\n" +
+	"\x15ReportWorkspaceStatus\x12&.monorepo.ReportWorkspaceStatusRequest\x1a'.monorepo.ReportWorkspaceStatusResponse\x12\\\n" +
 	"\x17ConfigureSparseCheckout\x12\x1f.monorepo.SparseCheckoutRequest\x1a .monorepo.SparseCheckoutResponse\x12M\n" +
-	"\fDownloadPath\x12\x1d.monorepo.DownloadPathRequest\x1a\x1e.monorepo.DownloadPathResponse\x12S\n" +
-	"\x0eAddTrackedPath\x12\x1f.monorepo.AddTrackedPathRequest\x1a .monorepo.AddTrackedPathResponseB'Z%github.com/nic/poon/poon-proto/gen/gob\x06proto3"
+	"\fDownloadPath\x12\x1d.monorepo.DownloadPathRequest\x1a\x1e.monorepo.DownloadPathResponse\x12N\n" +
+	"\x12StreamDownloadPath\x12\x1d.monorepo.DownloadPathRequest\x1a\x17.monorepo.DownloadChunk0\x01\x12d\n" +
+	"\x16StreamWorkspaceArchive\x12'.monorepo.StreamWorkspaceArchiveRequest\x1a\x1f.monorepo.WorkspaceArchiveChunk0\x01\x12S\n" +
+	"\x0eAddTrackedPath\x12\x1f.monorepo.AddTrackedPathRequest\x1a .monorepo.AddTrackedPathResponse\x12b\n" +
+	"\x13RefreshTrackedPaths\x12$.monorepo.RefreshTrackedPathsRequest\x1a%.monorepo.RefreshTrackedPathsResponse\x12\\\n" +
+	"\x11OpenWorkspaceFile\x12\".monorepo.OpenWorkspaceFileRequest\x1a#.monorepo.OpenWorkspaceFileResponse\x12h\n" +
+	"\x15ListWorkspaceSiblings\x12&.monorepo.ListWorkspaceSiblingsRequest\x1a'.monorepo.ListWorkspaceSiblingsResponse\x12w\n" +
+	"\x1aFetchWorkspaceDependencies\x12+.monorepo.FetchWorkspaceDependenciesRequest\x1a,.monorepo.FetchWorkspaceDependenciesResponse\x12;\n" +
+	"\x06WhoAmI\x12\x17.monorepo.WhoAmIRequest\x1a\x18.monorepo.WhoAmIResponse\x12P\n" +
+	"\rGetServerInfo\x12\x1e.monorepo.GetServerInfoRequest\x1a\x1f.monorepo.GetServerInfoResponse\x12A\n" +
+	"\bLockPath\x12\x19.monorepo.LockPathRequest\x1a\x1a.monorepo.LockPathResponse\x12G\n" +
+	"\n" +
+	"UnlockPath\x12\x1b.monorepo.UnlockPathRequest\x1a\x1c.monorepo.UnlockPathResponse\x12D\n" +
+	"\tListLocks\x12\x1a.monorepo.ListLocksRequest\x1a\x1b.monorepo.ListLocksResponse\x12A\n" +
+	"\bListTags\x12\x19.monorepo.ListTagsRequest\x1a\x1a.monorepo.ListTagsResponse\x12J\n" +
+	"\vGetActivity\x12\x1c.monorepo.GetActivityRequest\x1a\x1d.monorepo.GetActivityResponse\x12A\n" +
+	"\bGetQuota\x12\x19.monorepo.GetQuotaRequest\x1a\x1a.monorepo.GetQuotaResponse\x12M\n" +
+	"\fApprovePatch\x12\x1d.monorepo.ApprovePatchRequest\x1a\x1e.monorepo.ApprovePatchResponse\x12P\n" +
+	"\rGetMergeQueue\x12\x1e.monorepo.GetMergeQueueRequest\x1a\x1f.monorepo.GetMergeQueueResponse\x12h\n" +
+	"\x15ReportQueueValidation\x12&.monorepo.ReportQueueValidationRequest\x1a'.monorepo.ReportQueueValidationResponse\x12Y\n" +
+	"\x10ListDeletedPaths\x12!.monorepo.ListDeletedPathsRequest\x1a\".monorepo.ListDeletedPathsResponse\x12_\n" +
+	"\x12RestoreDeletedPath\x12#.monorepo.RestoreDeletedPathRequest\x1a$.monorepo.RestoreDeletedPathResponse2\xbc\x0f\n" +
+	"\x14MonorepoAdminService\x12_\n" +
+	"\x14RunGarbageCollection\x12\".monorepo.GarbageCollectionRequest\x1a#.monorepo.GarbageCollectionResponse\x125\n" +
+	"\x04Fsck\x12\x15.monorepo.FsckRequest\x1a\x16.monorepo.FsckResponse\x12P\n" +
+	"\x0fGetBackendStats\x12\x1d.monorepo.BackendStatsRequest\x1a\x1e.monorepo.BackendStatsResponse\x12M\n" +
+	"\fSetUserQuota\x12\x1d.monorepo.SetUserQuotaRequest\x1a\x1e.monorepo.SetUserQuotaResponse\x12\\\n" +
+	"\x11SetWorkspaceQuota\x12\".monorepo.SetWorkspaceQuotaRequest\x1a#.monorepo.SetWorkspaceQuotaResponse\x12V\n" +
+	"\x0fForceUnlockPath\x12 .monorepo.ForceUnlockPathRequest\x1a!.monorepo.ForceUnlockPathResponse\x12S\n" +
+	"\x0eListWorkspaces\x12\x1f.monorepo.ListWorkspacesRequest\x1a .monorepo.ListWorkspacesResponse\x12S\n" +
+	"\x0eReapWorkspaces\x12\x1f.monorepo.ReapWorkspacesRequest\x1a .monorepo.ReapWorkspacesResponse\x12z\n" +
+	"\x1bCollectWorkspaceDirectories\x12,.monorepo.CollectWorkspaceDirectoriesRequest\x1a-.monorepo.CollectWorkspaceDirectoriesResponse\x12\\\n" +
+	"\x11CompactWorkspaces\x12\".monorepo.CompactWorkspacesRequest\x1a#.monorepo.CompactWorkspacesResponse\x12;\n" +
+	"\x06Backup\x12\x17.monorepo.BackupRequest\x1a\x18.monorepo.BackupResponse\x12>\n" +
+	"\aRestore\x12\x18.monorepo.RestoreRequest\x1a\x19.monorepo.RestoreResponse\x12S\n" +
+	"\x0eMigrateBackend\x12\x1f.monorepo.MigrateBackendRequest\x1a .monorepo.MigrateBackendResponse\x12P\n" +
+	"\rRehashObjects\x12\x1e.monorepo.RehashObjectsRequest\x1a\x1f.monorepo.RehashObjectsResponse\x12M\n" +
+	"\fPruneHistory\x12\x1d.monorepo.PruneHistoryRequest\x1a\x1e.monorepo.PruneHistoryResponse\x12_\n" +
+	"\x12ListCorruptObjects\x12#.monorepo.ListCorruptObjectsRequest\x1a$.monorepo.ListCorruptObjectsResponse\x12e\n" +
+	"\x14GetReplicationStatus\x12%.monorepo.GetReplicationStatusRequest\x1a&.monorepo.GetReplicationStatusResponse\x12V\n" +
+	"\x0fFailoverBackend\x12 .monorepo.FailoverBackendRequest\x1a!.monorepo.FailoverBackendResponse\x12P\n" +
+	"\rResyncReplica\x12\x1e.monorepo.ResyncReplicaRequest\x1a\x1f.monorepo.ResyncReplicaResponse\x12M\n" +
+	"\fGetOperation\x12\x1d.monorepo.GetOperationRequest\x1a\x1e.monorepo.GetOperationResponse\x12P\n" +
+	"\rWaitOperation\x12\x1e.monorepo.WaitOperationRequest\x1a\x1f.monorepo.WaitOperationResponse\x12V\n" +
+	"\x0fCancelOperation\x12 .monorepo.CancelOperationRequest\x1a!.monorepo.CancelOperationResponse\x12S\n" +
+	"\x0eListOperations\x12\x1f.monorepo.ListOperationsRequest\x1a .monorepo.ListOperationsResponseB'Z%github.com/nic/poon/poon-proto/gen/gob\x06proto3"
 
 var (
 	file_monorepo_proto_rawDescOnce sync.Once
@@ -2034,83 +12760,411 @@ func file_monorepo_proto_rawDescGZIP() []byte {
 	return file_monorepo_proto_rawDescData
 }
 
-var file_monorepo_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_monorepo_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_monorepo_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_monorepo_proto_msgTypes = make([]protoimpl.MessageInfo, 180)
 var file_monorepo_proto_goTypes = []any{
-	(WorkspaceStatus)(0),            // 0: monorepo.WorkspaceStatus
-	(*MergePatchRequest)(nil),       // 1: monorepo.MergePatchRequest
-	(*MergePatchResponse)(nil),      // 2: monorepo.MergePatchResponse
-	(*ReadDirectoryRequest)(nil),    // 3: monorepo.ReadDirectoryRequest
-	(*ReadDirectoryResponse)(nil),   // 4: monorepo.ReadDirectoryResponse
-	(*DirectoryItem)(nil),           // 5: monorepo.DirectoryItem
-	(*ReadFileRequest)(nil),         // 6: monorepo.ReadFileRequest
-	(*ReadFileResponse)(nil),        // 7: monorepo.ReadFileResponse
-	(*FileHistoryRequest)(nil),      // 8: monorepo.FileHistoryRequest
-	(*FileHistoryResponse)(nil),     // 9: monorepo.FileHistoryResponse
-	(*Commit)(nil),                  // 10: monorepo.Commit
-	(*BranchesRequest)(nil),         // 11: monorepo.BranchesRequest
-	(*BranchesResponse)(nil),        // 12: monorepo.BranchesResponse
-	(*CreateBranchRequest)(nil),     // 13: monorepo.CreateBranchRequest
-	(*CreateBranchResponse)(nil),    // 14: monorepo.CreateBranchResponse
-	(*CreateWorkspaceRequest)(nil),  // 15: monorepo.CreateWorkspaceRequest
-	(*CreateWorkspaceResponse)(nil), // 16: monorepo.CreateWorkspaceResponse
-	(*GetWorkspaceRequest)(nil),     // 17: monorepo.GetWorkspaceRequest
-	(*GetWorkspaceResponse)(nil),    // 18: monorepo.GetWorkspaceResponse
-	(*UpdateWorkspaceRequest)(nil),  // 19: monorepo.UpdateWorkspaceRequest
-	(*UpdateWorkspaceResponse)(nil), // 20: monorepo.UpdateWorkspaceResponse
-	(*DeleteWorkspaceRequest)(nil),  // 21: monorepo.DeleteWorkspaceRequest
-	(*DeleteWorkspaceResponse)(nil), // 22: monorepo.DeleteWorkspaceResponse
-	(*WorkspaceInfo)(nil),           // 23: monorepo.WorkspaceInfo
-	(*SparseCheckoutRequest)(nil),   // 24: monorepo.SparseCheckoutRequest
-	(*SparseCheckoutResponse)(nil),  // 25: monorepo.SparseCheckoutResponse
-	(*DownloadPathRequest)(nil),     // 26: monorepo.DownloadPathRequest
-	(*DownloadPathResponse)(nil),    // 27: monorepo.DownloadPathResponse
-	(*AddTrackedPathRequest)(nil),   // 28: monorepo.AddTrackedPathRequest
-	(*AddTrackedPathResponse)(nil),  // 29: monorepo.AddTrackedPathResponse
-	nil,                             // 30: monorepo.CreateWorkspaceRequest.MetadataEntry
-	nil,                             // 31: monorepo.UpdateWorkspaceRequest.MetadataEntry
-	nil,                             // 32: monorepo.WorkspaceInfo.MetadataEntry
+	(WorkspaceStatus)(0),                        // 0: monorepo.WorkspaceStatus
+	(QueueEntryState)(0),                        // 1: monorepo.QueueEntryState
+	(*MergePatchRequest)(nil),                   // 2: monorepo.MergePatchRequest
+	(*MergePatchResponse)(nil),                  // 3: monorepo.MergePatchResponse
+	(*FileStat)(nil),                            // 4: monorepo.FileStat
+	(*ChangeStats)(nil),                         // 5: monorepo.ChangeStats
+	(*PreviewPatchRequest)(nil),                 // 6: monorepo.PreviewPatchRequest
+	(*PreviewPatchResponse)(nil),                // 7: monorepo.PreviewPatchResponse
+	(*PolicyViolation)(nil),                     // 8: monorepo.PolicyViolation
+	(*FailureInfo)(nil),                         // 9: monorepo.FailureInfo
+	(*ReadDirectoryRequest)(nil),                // 10: monorepo.ReadDirectoryRequest
+	(*ReadDirectoryResponse)(nil),               // 11: monorepo.ReadDirectoryResponse
+	(*DirectoryItem)(nil),                       // 12: monorepo.DirectoryItem
+	(*GetTreeHashRequest)(nil),                  // 13: monorepo.GetTreeHashRequest
+	(*GetTreeHashResponse)(nil),                 // 14: monorepo.GetTreeHashResponse
+	(*TreeHash)(nil),                            // 15: monorepo.TreeHash
+	(*GetPathInfoRequest)(nil),                  // 16: monorepo.GetPathInfoRequest
+	(*GetPathInfoResponse)(nil),                 // 17: monorepo.GetPathInfoResponse
+	(*ListCaseCollisionsRequest)(nil),           // 18: monorepo.ListCaseCollisionsRequest
+	(*CaseCollision)(nil),                       // 19: monorepo.CaseCollision
+	(*ListCaseCollisionsResponse)(nil),          // 20: monorepo.ListCaseCollisionsResponse
+	(*GetAffectedPathsRequest)(nil),             // 21: monorepo.GetAffectedPathsRequest
+	(*AffectedPath)(nil),                        // 22: monorepo.AffectedPath
+	(*GetAffectedPathsResponse)(nil),            // 23: monorepo.GetAffectedPathsResponse
+	(*ReadFileRequest)(nil),                     // 24: monorepo.ReadFileRequest
+	(*ReadFileResponse)(nil),                    // 25: monorepo.ReadFileResponse
+	(*ReadFilesRequest)(nil),                    // 26: monorepo.ReadFilesRequest
+	(*ReadFilesResponse)(nil),                   // 27: monorepo.ReadFilesResponse
+	(*FileResult)(nil),                          // 28: monorepo.FileResult
+	(*StreamDirectoryRequest)(nil),              // 29: monorepo.StreamDirectoryRequest
+	(*StreamDirectoryResponse)(nil),             // 30: monorepo.StreamDirectoryResponse
+	(*StreamFileRequest)(nil),                   // 31: monorepo.StreamFileRequest
+	(*FileChunk)(nil),                           // 32: monorepo.FileChunk
+	(*PreviewFileRequest)(nil),                  // 33: monorepo.PreviewFileRequest
+	(*PreviewLine)(nil),                         // 34: monorepo.PreviewLine
+	(*PreviewFileResponse)(nil),                 // 35: monorepo.PreviewFileResponse
+	(*GetRenderedDocRequest)(nil),               // 36: monorepo.GetRenderedDocRequest
+	(*GetRenderedDocResponse)(nil),              // 37: monorepo.GetRenderedDocResponse
+	(*Symbol)(nil),                              // 38: monorepo.Symbol
+	(*SearchSymbolsRequest)(nil),                // 39: monorepo.SearchSymbolsRequest
+	(*SearchSymbolsResponse)(nil),               // 40: monorepo.SearchSymbolsResponse
+	(*GoToDefinitionRequest)(nil),               // 41: monorepo.GoToDefinitionRequest
+	(*GoToDefinitionResponse)(nil),              // 42: monorepo.GoToDefinitionResponse
+	(*FileHistoryRequest)(nil),                  // 43: monorepo.FileHistoryRequest
+	(*FileHistoryResponse)(nil),                 // 44: monorepo.FileHistoryResponse
+	(*Commit)(nil),                              // 45: monorepo.Commit
+	(*BranchesRequest)(nil),                     // 46: monorepo.BranchesRequest
+	(*BranchesResponse)(nil),                    // 47: monorepo.BranchesResponse
+	(*CreateBranchRequest)(nil),                 // 48: monorepo.CreateBranchRequest
+	(*CreateBranchResponse)(nil),                // 49: monorepo.CreateBranchResponse
+	(*MergeBranchesRequest)(nil),                // 50: monorepo.MergeBranchesRequest
+	(*MergeBranchesResponse)(nil),               // 51: monorepo.MergeBranchesResponse
+	(*CherryPickRequest)(nil),                   // 52: monorepo.CherryPickRequest
+	(*CherryPickResponse)(nil),                  // 53: monorepo.CherryPickResponse
+	(*CreateWorkspaceRequest)(nil),              // 54: monorepo.CreateWorkspaceRequest
+	(*CreateWorkspaceResponse)(nil),             // 55: monorepo.CreateWorkspaceResponse
+	(*Operation)(nil),                           // 56: monorepo.Operation
+	(*GetOperationRequest)(nil),                 // 57: monorepo.GetOperationRequest
+	(*GetOperationResponse)(nil),                // 58: monorepo.GetOperationResponse
+	(*WaitOperationRequest)(nil),                // 59: monorepo.WaitOperationRequest
+	(*WaitOperationResponse)(nil),               // 60: monorepo.WaitOperationResponse
+	(*CancelOperationRequest)(nil),              // 61: monorepo.CancelOperationRequest
+	(*CancelOperationResponse)(nil),             // 62: monorepo.CancelOperationResponse
+	(*ListOperationsRequest)(nil),               // 63: monorepo.ListOperationsRequest
+	(*ListOperationsResponse)(nil),              // 64: monorepo.ListOperationsResponse
+	(*GetWorkspaceRequest)(nil),                 // 65: monorepo.GetWorkspaceRequest
+	(*GetWorkspaceResponse)(nil),                // 66: monorepo.GetWorkspaceResponse
+	(*UpdateWorkspaceRequest)(nil),              // 67: monorepo.UpdateWorkspaceRequest
+	(*UpdateWorkspaceResponse)(nil),             // 68: monorepo.UpdateWorkspaceResponse
+	(*DeleteWorkspaceRequest)(nil),              // 69: monorepo.DeleteWorkspaceRequest
+	(*DeleteWorkspaceResponse)(nil),             // 70: monorepo.DeleteWorkspaceResponse
+	(*WorkspaceInfo)(nil),                       // 71: monorepo.WorkspaceInfo
+	(*ReportWorkspaceStatusRequest)(nil),        // 72: monorepo.ReportWorkspaceStatusRequest
+	(*ReportWorkspaceStatusResponse)(nil),       // 73: monorepo.ReportWorkspaceStatusResponse
+	(*SparseCheckoutRequest)(nil),               // 74: monorepo.SparseCheckoutRequest
+	(*SparseCheckoutResponse)(nil),              // 75: monorepo.SparseCheckoutResponse
+	(*DownloadPathRequest)(nil),                 // 76: monorepo.DownloadPathRequest
+	(*DownloadPathResponse)(nil),                // 77: monorepo.DownloadPathResponse
+	(*DownloadChunk)(nil),                       // 78: monorepo.DownloadChunk
+	(*WorkspaceTemplate)(nil),                   // 79: monorepo.WorkspaceTemplate
+	(*ListTemplatesRequest)(nil),                // 80: monorepo.ListTemplatesRequest
+	(*ListTemplatesResponse)(nil),               // 81: monorepo.ListTemplatesResponse
+	(*PathView)(nil),                            // 82: monorepo.PathView
+	(*ListViewsRequest)(nil),                    // 83: monorepo.ListViewsRequest
+	(*ListViewsResponse)(nil),                   // 84: monorepo.ListViewsResponse
+	(*StreamWorkspaceArchiveRequest)(nil),       // 85: monorepo.StreamWorkspaceArchiveRequest
+	(*WorkspaceArchiveChunk)(nil),               // 86: monorepo.WorkspaceArchiveChunk
+	(*AddTrackedPathRequest)(nil),               // 87: monorepo.AddTrackedPathRequest
+	(*AddTrackedPathResponse)(nil),              // 88: monorepo.AddTrackedPathResponse
+	(*RefreshTrackedPathsRequest)(nil),          // 89: monorepo.RefreshTrackedPathsRequest
+	(*RefreshTrackedPathsResponse)(nil),         // 90: monorepo.RefreshTrackedPathsResponse
+	(*OpenWorkspaceFileRequest)(nil),            // 91: monorepo.OpenWorkspaceFileRequest
+	(*OpenWorkspaceFileResponse)(nil),           // 92: monorepo.OpenWorkspaceFileResponse
+	(*ListWorkspaceSiblingsRequest)(nil),        // 93: monorepo.ListWorkspaceSiblingsRequest
+	(*ListWorkspaceSiblingsResponse)(nil),       // 94: monorepo.ListWorkspaceSiblingsResponse
+	(*WorkspaceEntry)(nil),                      // 95: monorepo.WorkspaceEntry
+	(*FetchWorkspaceDependenciesRequest)(nil),   // 96: monorepo.FetchWorkspaceDependenciesRequest
+	(*FetchWorkspaceDependenciesResponse)(nil),  // 97: monorepo.FetchWorkspaceDependenciesResponse
+	(*WhoAmIRequest)(nil),                       // 98: monorepo.WhoAmIRequest
+	(*WhoAmIResponse)(nil),                      // 99: monorepo.WhoAmIResponse
+	(*GetServerInfoRequest)(nil),                // 100: monorepo.GetServerInfoRequest
+	(*GetServerInfoResponse)(nil),               // 101: monorepo.GetServerInfoResponse
+	(*PathLock)(nil),                            // 102: monorepo.PathLock
+	(*LockPathRequest)(nil),                     // 103: monorepo.LockPathRequest
+	(*LockPathResponse)(nil),                    // 104: monorepo.LockPathResponse
+	(*UnlockPathRequest)(nil),                   // 105: monorepo.UnlockPathRequest
+	(*UnlockPathResponse)(nil),                  // 106: monorepo.UnlockPathResponse
+	(*ListLocksRequest)(nil),                    // 107: monorepo.ListLocksRequest
+	(*ListLocksResponse)(nil),                   // 108: monorepo.ListLocksResponse
+	(*Tag)(nil),                                 // 109: monorepo.Tag
+	(*ListTagsRequest)(nil),                     // 110: monorepo.ListTagsRequest
+	(*ListTagsResponse)(nil),                    // 111: monorepo.ListTagsResponse
+	(*GetActivityRequest)(nil),                  // 112: monorepo.GetActivityRequest
+	(*ActivityGroup)(nil),                       // 113: monorepo.ActivityGroup
+	(*GetActivityResponse)(nil),                 // 114: monorepo.GetActivityResponse
+	(*GetQuotaRequest)(nil),                     // 115: monorepo.GetQuotaRequest
+	(*QuotaUsage)(nil),                          // 116: monorepo.QuotaUsage
+	(*GetQuotaResponse)(nil),                    // 117: monorepo.GetQuotaResponse
+	(*ApprovePatchRequest)(nil),                 // 118: monorepo.ApprovePatchRequest
+	(*ApprovePatchResponse)(nil),                // 119: monorepo.ApprovePatchResponse
+	(*QueueEntry)(nil),                          // 120: monorepo.QueueEntry
+	(*GetMergeQueueRequest)(nil),                // 121: monorepo.GetMergeQueueRequest
+	(*GetMergeQueueResponse)(nil),               // 122: monorepo.GetMergeQueueResponse
+	(*ReportQueueValidationRequest)(nil),        // 123: monorepo.ReportQueueValidationRequest
+	(*ReportQueueValidationResponse)(nil),       // 124: monorepo.ReportQueueValidationResponse
+	(*DeletedPath)(nil),                         // 125: monorepo.DeletedPath
+	(*ListDeletedPathsRequest)(nil),             // 126: monorepo.ListDeletedPathsRequest
+	(*ListDeletedPathsResponse)(nil),            // 127: monorepo.ListDeletedPathsResponse
+	(*RestoreDeletedPathRequest)(nil),           // 128: monorepo.RestoreDeletedPathRequest
+	(*RestoreDeletedPathResponse)(nil),          // 129: monorepo.RestoreDeletedPathResponse
+	(*GarbageCollectionRequest)(nil),            // 130: monorepo.GarbageCollectionRequest
+	(*GarbageCollectionResponse)(nil),           // 131: monorepo.GarbageCollectionResponse
+	(*FsckRequest)(nil),                         // 132: monorepo.FsckRequest
+	(*FsckResponse)(nil),                        // 133: monorepo.FsckResponse
+	(*BackendStatsRequest)(nil),                 // 134: monorepo.BackendStatsRequest
+	(*BackendStatsResponse)(nil),                // 135: monorepo.BackendStatsResponse
+	(*SetUserQuotaRequest)(nil),                 // 136: monorepo.SetUserQuotaRequest
+	(*SetUserQuotaResponse)(nil),                // 137: monorepo.SetUserQuotaResponse
+	(*SetWorkspaceQuotaRequest)(nil),            // 138: monorepo.SetWorkspaceQuotaRequest
+	(*SetWorkspaceQuotaResponse)(nil),           // 139: monorepo.SetWorkspaceQuotaResponse
+	(*ForceUnlockPathRequest)(nil),              // 140: monorepo.ForceUnlockPathRequest
+	(*ForceUnlockPathResponse)(nil),             // 141: monorepo.ForceUnlockPathResponse
+	(*ListWorkspacesRequest)(nil),               // 142: monorepo.ListWorkspacesRequest
+	(*ListWorkspacesResponse)(nil),              // 143: monorepo.ListWorkspacesResponse
+	(*ReapWorkspacesRequest)(nil),               // 144: monorepo.ReapWorkspacesRequest
+	(*ReapWorkspacesResponse)(nil),              // 145: monorepo.ReapWorkspacesResponse
+	(*CollectWorkspaceDirectoriesRequest)(nil),  // 146: monorepo.CollectWorkspaceDirectoriesRequest
+	(*CollectWorkspaceDirectoriesResponse)(nil), // 147: monorepo.CollectWorkspaceDirectoriesResponse
+	(*CompactWorkspacesRequest)(nil),            // 148: monorepo.CompactWorkspacesRequest
+	(*CompactWorkspacesResponse)(nil),           // 149: monorepo.CompactWorkspacesResponse
+	(*WorkspaceCompaction)(nil),                 // 150: monorepo.WorkspaceCompaction
+	(*OrphanedDirectory)(nil),                   // 151: monorepo.OrphanedDirectory
+	(*BackupRequest)(nil),                       // 152: monorepo.BackupRequest
+	(*BackupResponse)(nil),                      // 153: monorepo.BackupResponse
+	(*RestoreRequest)(nil),                      // 154: monorepo.RestoreRequest
+	(*RestoreResponse)(nil),                     // 155: monorepo.RestoreResponse
+	(*MigrateBackendRequest)(nil),               // 156: monorepo.MigrateBackendRequest
+	(*MigrateBackendResponse)(nil),              // 157: monorepo.MigrateBackendResponse
+	(*RehashObjectsRequest)(nil),                // 158: monorepo.RehashObjectsRequest
+	(*RehashObjectsResponse)(nil),               // 159: monorepo.RehashObjectsResponse
+	(*PruneHistoryRequest)(nil),                 // 160: monorepo.PruneHistoryRequest
+	(*PruneHistoryResponse)(nil),                // 161: monorepo.PruneHistoryResponse
+	(*ListCorruptObjectsRequest)(nil),           // 162: monorepo.ListCorruptObjectsRequest
+	(*CorruptObject)(nil),                       // 163: monorepo.CorruptObject
+	(*ListCorruptObjectsResponse)(nil),          // 164: monorepo.ListCorruptObjectsResponse
+	(*GetReplicationStatusRequest)(nil),         // 165: monorepo.GetReplicationStatusRequest
+	(*ReplicaStatus)(nil),                       // 166: monorepo.ReplicaStatus
+	(*GetReplicationStatusResponse)(nil),        // 167: monorepo.GetReplicationStatusResponse
+	(*FailoverBackendRequest)(nil),              // 168: monorepo.FailoverBackendRequest
+	(*FailoverBackendResponse)(nil),             // 169: monorepo.FailoverBackendResponse
+	(*ResyncReplicaRequest)(nil),                // 170: monorepo.ResyncReplicaRequest
+	(*ResyncReplicaResponse)(nil),               // 171: monorepo.ResyncReplicaResponse
+	nil,                                         // 172: monorepo.FailureInfo.MetadataEntry
+	nil,                                         // 173: monorepo.GetPathInfoResponse.AttributesEntry
+	nil,                                         // 174: monorepo.ReadFilesRequest.IfNotHashEntry
+	nil,                                         // 175: monorepo.CreateWorkspaceRequest.MetadataEntry
+	nil,                                         // 176: monorepo.Operation.ResultEntry
+	nil,                                         // 177: monorepo.UpdateWorkspaceRequest.MetadataEntry
+	nil,                                         // 178: monorepo.WorkspaceInfo.MetadataEntry
+	nil,                                         // 179: monorepo.WorkspaceTemplate.MetadataEntry
+	nil,                                         // 180: monorepo.FsckResponse.ObjectsByAlgorithmEntry
+	nil,                                         // 181: monorepo.FsckResponse.ObjectsByFormatEntry
 }
 var file_monorepo_proto_depIdxs = []int32{
-	5,  // 0: monorepo.ReadDirectoryResponse.items:type_name -> monorepo.DirectoryItem
-	10, // 1: monorepo.FileHistoryResponse.commits:type_name -> monorepo.Commit
-	30, // 2: monorepo.CreateWorkspaceRequest.metadata:type_name -> monorepo.CreateWorkspaceRequest.MetadataEntry
-	23, // 3: monorepo.GetWorkspaceResponse.workspace:type_name -> monorepo.WorkspaceInfo
-	31, // 4: monorepo.UpdateWorkspaceRequest.metadata:type_name -> monorepo.UpdateWorkspaceRequest.MetadataEntry
-	23, // 5: monorepo.UpdateWorkspaceResponse.workspace:type_name -> monorepo.WorkspaceInfo
-	0,  // 6: monorepo.WorkspaceInfo.status:type_name -> monorepo.WorkspaceStatus
-	32, // 7: monorepo.WorkspaceInfo.metadata:type_name -> monorepo.WorkspaceInfo.MetadataEntry
-	1,  // 8: monorepo.MonorepoService.MergePatch:input_type -> monorepo.MergePatchRequest
-	3,  // 9: monorepo.MonorepoService.ReadDirectory:input_type -> monorepo.ReadDirectoryRequest
-	6,  // 10: monorepo.MonorepoService.ReadFile:input_type -> monorepo.ReadFileRequest
-	8,  // 11: monorepo.MonorepoService.GetFileHistory:input_type -> monorepo.FileHistoryRequest
-	11, // 12: monorepo.MonorepoService.GetBranches:input_type -> monorepo.BranchesRequest
-	13, // 13: monorepo.MonorepoService.CreateBranch:input_type -> monorepo.CreateBranchRequest
-	15, // 14: monorepo.MonorepoService.CreateWorkspace:input_type -> monorepo.CreateWorkspaceRequest
-	17, // 15: monorepo.MonorepoService.GetWorkspace:input_type -> monorepo.GetWorkspaceRequest
-	19, // 16: monorepo.MonorepoService.UpdateWorkspace:input_type -> monorepo.UpdateWorkspaceRequest
-	21, // 17: monorepo.MonorepoService.DeleteWorkspace:input_type -> monorepo.DeleteWorkspaceRequest
-	24, // 18: monorepo.MonorepoService.ConfigureSparseCheckout:input_type -> monorepo.SparseCheckoutRequest
-	26, // 19: monorepo.MonorepoService.DownloadPath:input_type -> monorepo.DownloadPathRequest
-	28, // 20: monorepo.MonorepoService.AddTrackedPath:input_type -> monorepo.AddTrackedPathRequest
-	2,  // 21: monorepo.MonorepoService.MergePatch:output_type -> monorepo.MergePatchResponse
-	4,  // 22: monorepo.MonorepoService.ReadDirectory:output_type -> monorepo.ReadDirectoryResponse
-	7,  // 23: monorepo.MonorepoService.ReadFile:output_type -> monorepo.ReadFileResponse
-	9,  // 24: monorepo.MonorepoService.GetFileHistory:output_type -> monorepo.FileHistoryResponse
-	12, // 25: monorepo.MonorepoService.GetBranches:output_type -> monorepo.BranchesResponse
-	14, // 26: monorepo.MonorepoService.CreateBranch:output_type -> monorepo.CreateBranchResponse
-	16, // 27: monorepo.MonorepoService.CreateWorkspace:output_type -> monorepo.CreateWorkspaceResponse
-	18, // 28: monorepo.MonorepoService.GetWorkspace:output_type -> monorepo.GetWorkspaceResponse
-	20, // 29: monorepo.MonorepoService.UpdateWorkspace:output_type -> monorepo.UpdateWorkspaceResponse
-	22, // 30: monorepo.MonorepoService.DeleteWorkspace:output_type -> monorepo.DeleteWorkspaceResponse
-	25, // 31: monorepo.MonorepoService.ConfigureSparseCheckout:output_type -> monorepo.SparseCheckoutResponse
-	27, // 32: monorepo.MonorepoService.DownloadPath:output_type -> monorepo.DownloadPathResponse
-	29, // 33: monorepo.MonorepoService.AddTrackedPath:output_type -> monorepo.AddTrackedPathResponse
-	21, // [21:34] is the sub-list for method output_type
-	8,  // [8:21] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	8,   // 0: monorepo.MergePatchResponse.violations:type_name -> monorepo.PolicyViolation
+	9,   // 1: monorepo.MergePatchResponse.failure:type_name -> monorepo.FailureInfo
+	5,   // 2: monorepo.MergePatchResponse.stats:type_name -> monorepo.ChangeStats
+	4,   // 3: monorepo.ChangeStats.files:type_name -> monorepo.FileStat
+	8,   // 4: monorepo.PreviewPatchResponse.violations:type_name -> monorepo.PolicyViolation
+	9,   // 5: monorepo.PreviewPatchResponse.failure:type_name -> monorepo.FailureInfo
+	172, // 6: monorepo.FailureInfo.metadata:type_name -> monorepo.FailureInfo.MetadataEntry
+	12,  // 7: monorepo.ReadDirectoryResponse.items:type_name -> monorepo.DirectoryItem
+	15,  // 8: monorepo.GetTreeHashResponse.hashes:type_name -> monorepo.TreeHash
+	45,  // 9: monorepo.GetPathInfoResponse.last_change:type_name -> monorepo.Commit
+	173, // 10: monorepo.GetPathInfoResponse.attributes:type_name -> monorepo.GetPathInfoResponse.AttributesEntry
+	19,  // 11: monorepo.ListCaseCollisionsResponse.collisions:type_name -> monorepo.CaseCollision
+	22,  // 12: monorepo.GetAffectedPathsResponse.paths:type_name -> monorepo.AffectedPath
+	174, // 13: monorepo.ReadFilesRequest.if_not_hash:type_name -> monorepo.ReadFilesRequest.IfNotHashEntry
+	28,  // 14: monorepo.ReadFilesResponse.files:type_name -> monorepo.FileResult
+	9,   // 15: monorepo.FileResult.failure:type_name -> monorepo.FailureInfo
+	12,  // 16: monorepo.StreamDirectoryResponse.items:type_name -> monorepo.DirectoryItem
+	34,  // 17: monorepo.PreviewFileResponse.lines:type_name -> monorepo.PreviewLine
+	38,  // 18: monorepo.SearchSymbolsResponse.symbols:type_name -> monorepo.Symbol
+	38,  // 19: monorepo.GoToDefinitionResponse.definitions:type_name -> monorepo.Symbol
+	45,  // 20: monorepo.FileHistoryResponse.commits:type_name -> monorepo.Commit
+	9,   // 21: monorepo.MergeBranchesResponse.failure:type_name -> monorepo.FailureInfo
+	8,   // 22: monorepo.MergeBranchesResponse.violations:type_name -> monorepo.PolicyViolation
+	9,   // 23: monorepo.CherryPickResponse.failure:type_name -> monorepo.FailureInfo
+	8,   // 24: monorepo.CherryPickResponse.violations:type_name -> monorepo.PolicyViolation
+	175, // 25: monorepo.CreateWorkspaceRequest.metadata:type_name -> monorepo.CreateWorkspaceRequest.MetadataEntry
+	176, // 26: monorepo.Operation.result:type_name -> monorepo.Operation.ResultEntry
+	56,  // 27: monorepo.GetOperationResponse.operation:type_name -> monorepo.Operation
+	56,  // 28: monorepo.WaitOperationResponse.operation:type_name -> monorepo.Operation
+	56,  // 29: monorepo.ListOperationsResponse.operations:type_name -> monorepo.Operation
+	71,  // 30: monorepo.GetWorkspaceResponse.workspace:type_name -> monorepo.WorkspaceInfo
+	177, // 31: monorepo.UpdateWorkspaceRequest.metadata:type_name -> monorepo.UpdateWorkspaceRequest.MetadataEntry
+	71,  // 32: monorepo.UpdateWorkspaceResponse.workspace:type_name -> monorepo.WorkspaceInfo
+	0,   // 33: monorepo.WorkspaceInfo.status:type_name -> monorepo.WorkspaceStatus
+	178, // 34: monorepo.WorkspaceInfo.metadata:type_name -> monorepo.WorkspaceInfo.MetadataEntry
+	179, // 35: monorepo.WorkspaceTemplate.metadata:type_name -> monorepo.WorkspaceTemplate.MetadataEntry
+	79,  // 36: monorepo.ListTemplatesResponse.templates:type_name -> monorepo.WorkspaceTemplate
+	82,  // 37: monorepo.ListViewsResponse.views:type_name -> monorepo.PathView
+	95,  // 38: monorepo.ListWorkspaceSiblingsResponse.entries:type_name -> monorepo.WorkspaceEntry
+	28,  // 39: monorepo.FetchWorkspaceDependenciesResponse.files:type_name -> monorepo.FileResult
+	102, // 40: monorepo.LockPathResponse.lock:type_name -> monorepo.PathLock
+	102, // 41: monorepo.ListLocksResponse.locks:type_name -> monorepo.PathLock
+	109, // 42: monorepo.ListTagsResponse.tags:type_name -> monorepo.Tag
+	113, // 43: monorepo.GetActivityResponse.groups:type_name -> monorepo.ActivityGroup
+	113, // 44: monorepo.GetActivityResponse.total:type_name -> monorepo.ActivityGroup
+	116, // 45: monorepo.GetQuotaResponse.user_usage:type_name -> monorepo.QuotaUsage
+	116, // 46: monorepo.GetQuotaResponse.workspace_usage:type_name -> monorepo.QuotaUsage
+	1,   // 47: monorepo.QueueEntry.state:type_name -> monorepo.QueueEntryState
+	120, // 48: monorepo.GetMergeQueueResponse.entries:type_name -> monorepo.QueueEntry
+	125, // 49: monorepo.ListDeletedPathsResponse.paths:type_name -> monorepo.DeletedPath
+	125, // 50: monorepo.RestoreDeletedPathResponse.restored:type_name -> monorepo.DeletedPath
+	180, // 51: monorepo.FsckResponse.objects_by_algorithm:type_name -> monorepo.FsckResponse.ObjectsByAlgorithmEntry
+	181, // 52: monorepo.FsckResponse.objects_by_format:type_name -> monorepo.FsckResponse.ObjectsByFormatEntry
+	102, // 53: monorepo.ForceUnlockPathResponse.lock:type_name -> monorepo.PathLock
+	71,  // 54: monorepo.ListWorkspacesResponse.workspaces:type_name -> monorepo.WorkspaceInfo
+	151, // 55: monorepo.CollectWorkspaceDirectoriesResponse.directories:type_name -> monorepo.OrphanedDirectory
+	150, // 56: monorepo.CompactWorkspacesResponse.workspaces:type_name -> monorepo.WorkspaceCompaction
+	163, // 57: monorepo.ListCorruptObjectsResponse.objects:type_name -> monorepo.CorruptObject
+	166, // 58: monorepo.GetReplicationStatusResponse.replicas:type_name -> monorepo.ReplicaStatus
+	166, // 59: monorepo.FailoverBackendResponse.replicas:type_name -> monorepo.ReplicaStatus
+	166, // 60: monorepo.ResyncReplicaResponse.replicas:type_name -> monorepo.ReplicaStatus
+	2,   // 61: monorepo.MonorepoService.MergePatch:input_type -> monorepo.MergePatchRequest
+	6,   // 62: monorepo.MonorepoService.PreviewPatch:input_type -> monorepo.PreviewPatchRequest
+	10,  // 63: monorepo.MonorepoService.ReadDirectory:input_type -> monorepo.ReadDirectoryRequest
+	24,  // 64: monorepo.MonorepoService.ReadFile:input_type -> monorepo.ReadFileRequest
+	26,  // 65: monorepo.MonorepoService.ReadFiles:input_type -> monorepo.ReadFilesRequest
+	29,  // 66: monorepo.MonorepoService.StreamDirectory:input_type -> monorepo.StreamDirectoryRequest
+	31,  // 67: monorepo.MonorepoService.StreamFile:input_type -> monorepo.StreamFileRequest
+	33,  // 68: monorepo.MonorepoService.PreviewFile:input_type -> monorepo.PreviewFileRequest
+	36,  // 69: monorepo.MonorepoService.GetRenderedDoc:input_type -> monorepo.GetRenderedDocRequest
+	39,  // 70: monorepo.MonorepoService.SearchSymbols:input_type -> monorepo.SearchSymbolsRequest
+	41,  // 71: monorepo.MonorepoService.GoToDefinition:input_type -> monorepo.GoToDefinitionRequest
+	16,  // 72: monorepo.MonorepoService.GetPathInfo:input_type -> monorepo.GetPathInfoRequest
+	13,  // 73: monorepo.MonorepoService.GetTreeHash:input_type -> monorepo.GetTreeHashRequest
+	18,  // 74: monorepo.MonorepoService.ListCaseCollisions:input_type -> monorepo.ListCaseCollisionsRequest
+	21,  // 75: monorepo.MonorepoService.GetAffectedPaths:input_type -> monorepo.GetAffectedPathsRequest
+	43,  // 76: monorepo.MonorepoService.GetFileHistory:input_type -> monorepo.FileHistoryRequest
+	46,  // 77: monorepo.MonorepoService.GetBranches:input_type -> monorepo.BranchesRequest
+	48,  // 78: monorepo.MonorepoService.CreateBranch:input_type -> monorepo.CreateBranchRequest
+	50,  // 79: monorepo.MonorepoService.MergeBranches:input_type -> monorepo.MergeBranchesRequest
+	52,  // 80: monorepo.MonorepoService.CherryPick:input_type -> monorepo.CherryPickRequest
+	54,  // 81: monorepo.MonorepoService.CreateWorkspace:input_type -> monorepo.CreateWorkspaceRequest
+	65,  // 82: monorepo.MonorepoService.GetWorkspace:input_type -> monorepo.GetWorkspaceRequest
+	67,  // 83: monorepo.MonorepoService.UpdateWorkspace:input_type -> monorepo.UpdateWorkspaceRequest
+	69,  // 84: monorepo.MonorepoService.DeleteWorkspace:input_type -> monorepo.DeleteWorkspaceRequest
+	57,  // 85: monorepo.MonorepoService.GetOperation:input_type -> monorepo.GetOperationRequest
+	59,  // 86: monorepo.MonorepoService.WaitOperation:input_type -> monorepo.WaitOperationRequest
+	61,  // 87: monorepo.MonorepoService.CancelOperation:input_type -> monorepo.CancelOperationRequest
+	63,  // 88: monorepo.MonorepoService.ListOperations:input_type -> monorepo.ListOperationsRequest
+	80,  // 89: monorepo.MonorepoService.ListTemplates:input_type -> monorepo.ListTemplatesRequest
+	83,  // 90: monorepo.MonorepoService.ListViews:input_type -> monorepo.ListViewsRequest
+	72,  // 91: monorepo.MonorepoService.ReportWorkspaceStatus:input_type -> monorepo.ReportWorkspaceStatusRequest
+	74,  // 92: monorepo.MonorepoService.ConfigureSparseCheckout:input_type -> monorepo.SparseCheckoutRequest
+	76,  // 93: monorepo.MonorepoService.DownloadPath:input_type -> monorepo.DownloadPathRequest
+	76,  // 94: monorepo.MonorepoService.StreamDownloadPath:input_type -> monorepo.DownloadPathRequest
+	85,  // 95: monorepo.MonorepoService.StreamWorkspaceArchive:input_type -> monorepo.StreamWorkspaceArchiveRequest
+	87,  // 96: monorepo.MonorepoService.AddTrackedPath:input_type -> monorepo.AddTrackedPathRequest
+	89,  // 97: monorepo.MonorepoService.RefreshTrackedPaths:input_type -> monorepo.RefreshTrackedPathsRequest
+	91,  // 98: monorepo.MonorepoService.OpenWorkspaceFile:input_type -> monorepo.OpenWorkspaceFileRequest
+	93,  // 99: monorepo.MonorepoService.ListWorkspaceSiblings:input_type -> monorepo.ListWorkspaceSiblingsRequest
+	96,  // 100: monorepo.MonorepoService.FetchWorkspaceDependencies:input_type -> monorepo.FetchWorkspaceDependenciesRequest
+	98,  // 101: monorepo.MonorepoService.WhoAmI:input_type -> monorepo.WhoAmIRequest
+	100, // 102: monorepo.MonorepoService.GetServerInfo:input_type -> monorepo.GetServerInfoRequest
+	103, // 103: monorepo.MonorepoService.LockPath:input_type -> monorepo.LockPathRequest
+	105, // 104: monorepo.MonorepoService.UnlockPath:input_type -> monorepo.UnlockPathRequest
+	107, // 105: monorepo.MonorepoService.ListLocks:input_type -> monorepo.ListLocksRequest
+	110, // 106: monorepo.MonorepoService.ListTags:input_type -> monorepo.ListTagsRequest
+	112, // 107: monorepo.MonorepoService.GetActivity:input_type -> monorepo.GetActivityRequest
+	115, // 108: monorepo.MonorepoService.GetQuota:input_type -> monorepo.GetQuotaRequest
+	118, // 109: monorepo.MonorepoService.ApprovePatch:input_type -> monorepo.ApprovePatchRequest
+	121, // 110: monorepo.MonorepoService.GetMergeQueue:input_type -> monorepo.GetMergeQueueRequest
+	123, // 111: monorepo.MonorepoService.ReportQueueValidation:input_type -> monorepo.ReportQueueValidationRequest
+	126, // 112: monorepo.MonorepoService.ListDeletedPaths:input_type -> monorepo.ListDeletedPathsRequest
+	128, // 113: monorepo.MonorepoService.RestoreDeletedPath:input_type -> monorepo.RestoreDeletedPathRequest
+	130, // 114: monorepo.MonorepoAdminService.RunGarbageCollection:input_type -> monorepo.GarbageCollectionRequest
+	132, // 115: monorepo.MonorepoAdminService.Fsck:input_type -> monorepo.FsckRequest
+	134, // 116: monorepo.MonorepoAdminService.GetBackendStats:input_type -> monorepo.BackendStatsRequest
+	136, // 117: monorepo.MonorepoAdminService.SetUserQuota:input_type -> monorepo.SetUserQuotaRequest
+	138, // 118: monorepo.MonorepoAdminService.SetWorkspaceQuota:input_type -> monorepo.SetWorkspaceQuotaRequest
+	140, // 119: monorepo.MonorepoAdminService.ForceUnlockPath:input_type -> monorepo.ForceUnlockPathRequest
+	142, // 120: monorepo.MonorepoAdminService.ListWorkspaces:input_type -> monorepo.ListWorkspacesRequest
+	144, // 121: monorepo.MonorepoAdminService.ReapWorkspaces:input_type -> monorepo.ReapWorkspacesRequest
+	146, // 122: monorepo.MonorepoAdminService.CollectWorkspaceDirectories:input_type -> monorepo.CollectWorkspaceDirectoriesRequest
+	148, // 123: monorepo.MonorepoAdminService.CompactWorkspaces:input_type -> monorepo.CompactWorkspacesRequest
+	152, // 124: monorepo.MonorepoAdminService.Backup:input_type -> monorepo.BackupRequest
+	154, // 125: monorepo.MonorepoAdminService.Restore:input_type -> monorepo.RestoreRequest
+	156, // 126: monorepo.MonorepoAdminService.MigrateBackend:input_type -> monorepo.MigrateBackendRequest
+	158, // 127: monorepo.MonorepoAdminService.RehashObjects:input_type -> monorepo.RehashObjectsRequest
+	160, // 128: monorepo.MonorepoAdminService.PruneHistory:input_type -> monorepo.PruneHistoryRequest
+	162, // 129: monorepo.MonorepoAdminService.ListCorruptObjects:input_type -> monorepo.ListCorruptObjectsRequest
+	165, // 130: monorepo.MonorepoAdminService.GetReplicationStatus:input_type -> monorepo.GetReplicationStatusRequest
+	168, // 131: monorepo.MonorepoAdminService.FailoverBackend:input_type -> monorepo.FailoverBackendRequest
+	170, // 132: monorepo.MonorepoAdminService.ResyncReplica:input_type -> monorepo.ResyncReplicaRequest
+	57,  // 133: monorepo.MonorepoAdminService.GetOperation:input_type -> monorepo.GetOperationRequest
+	59,  // 134: monorepo.MonorepoAdminService.WaitOperation:input_type -> monorepo.WaitOperationRequest
+	61,  // 135: monorepo.MonorepoAdminService.CancelOperation:input_type -> monorepo.CancelOperationRequest
+	63,  // 136: monorepo.MonorepoAdminService.ListOperations:input_type -> monorepo.ListOperationsRequest
+	3,   // 137: monorepo.MonorepoService.MergePatch:output_type -> monorepo.MergePatchResponse
+	7,   // 138: monorepo.MonorepoService.PreviewPatch:output_type -> monorepo.PreviewPatchResponse
+	11,  // 139: monorepo.MonorepoService.ReadDirectory:output_type -> monorepo.ReadDirectoryResponse
+	25,  // 140: monorepo.MonorepoService.ReadFile:output_type -> monorepo.ReadFileResponse
+	27,  // 141: monorepo.MonorepoService.ReadFiles:output_type -> monorepo.ReadFilesResponse
+	30,  // 142: monorepo.MonorepoService.StreamDirectory:output_type -> monorepo.StreamDirectoryResponse
+	32,  // 143: monorepo.MonorepoService.StreamFile:output_type -> monorepo.FileChunk
+	35,  // 144: monorepo.MonorepoService.PreviewFile:output_type -> monorepo.PreviewFileResponse
+	37,  // 145: monorepo.MonorepoService.GetRenderedDoc:output_type -> monorepo.GetRenderedDocResponse
+	40,  // 146: monorepo.MonorepoService.SearchSymbols:output_type -> monorepo.SearchSymbolsResponse
+	42,  // 147: monorepo.MonorepoService.GoToDefinition:output_type -> monorepo.GoToDefinitionResponse
+	17,  // 148: monorepo.MonorepoService.GetPathInfo:output_type -> monorepo.GetPathInfoResponse
+	14,  // 149: monorepo.MonorepoService.GetTreeHash:output_type -> monorepo.GetTreeHashResponse
+	20,  // 150: monorepo.MonorepoService.ListCaseCollisions:output_type -> monorepo.ListCaseCollisionsResponse
+	23,  // 151: monorepo.MonorepoService.GetAffectedPaths:output_type -> monorepo.GetAffectedPathsResponse
+	44,  // 152: monorepo.MonorepoService.GetFileHistory:output_type -> monorepo.FileHistoryResponse
+	47,  // 153: monorepo.MonorepoService.GetBranches:output_type -> monorepo.BranchesResponse
+	49,  // 154: monorepo.MonorepoService.CreateBranch:output_type -> monorepo.CreateBranchResponse
+	51,  // 155: monorepo.MonorepoService.MergeBranches:output_type -> monorepo.MergeBranchesResponse
+	53,  // 156: monorepo.MonorepoService.CherryPick:output_type -> monorepo.CherryPickResponse
+	55,  // 157: monorepo.MonorepoService.CreateWorkspace:output_type -> monorepo.CreateWorkspaceResponse
+	66,  // 158: monorepo.MonorepoService.GetWorkspace:output_type -> monorepo.GetWorkspaceResponse
+	68,  // 159: monorepo.MonorepoService.UpdateWorkspace:output_type -> monorepo.UpdateWorkspaceResponse
+	70,  // 160: monorepo.MonorepoService.DeleteWorkspace:output_type -> monorepo.DeleteWorkspaceResponse
+	58,  // 161: monorepo.MonorepoService.GetOperation:output_type -> monorepo.GetOperationResponse
+	60,  // 162: monorepo.MonorepoService.WaitOperation:output_type -> monorepo.WaitOperationResponse
+	62,  // 163: monorepo.MonorepoService.CancelOperation:output_type -> monorepo.CancelOperationResponse
+	64,  // 164: monorepo.MonorepoService.ListOperations:output_type -> monorepo.ListOperationsResponse
+	81,  // 165: monorepo.MonorepoService.ListTemplates:output_type -> monorepo.ListTemplatesResponse
+	84,  // 166: monorepo.MonorepoService.ListViews:output_type -> monorepo.ListViewsResponse
+	73,  // 167: monorepo.MonorepoService.ReportWorkspaceStatus:output_type -> monorepo.ReportWorkspaceStatusResponse
+	75,  // 168: monorepo.MonorepoService.ConfigureSparseCheckout:output_type -> monorepo.SparseCheckoutResponse
+	77,  // 169: monorepo.MonorepoService.DownloadPath:output_type -> monorepo.DownloadPathResponse
+	78,  // 170: monorepo.MonorepoService.StreamDownloadPath:output_type -> monorepo.DownloadChunk
+	86,  // 171: monorepo.MonorepoService.StreamWorkspaceArchive:output_type -> monorepo.WorkspaceArchiveChunk
+	88,  // 172: monorepo.MonorepoService.AddTrackedPath:output_type -> monorepo.AddTrackedPathResponse
+	90,  // 173: monorepo.MonorepoService.RefreshTrackedPaths:output_type -> monorepo.RefreshTrackedPathsResponse
+	92,  // 174: monorepo.MonorepoService.OpenWorkspaceFile:output_type -> monorepo.OpenWorkspaceFileResponse
+	94,  // 175: monorepo.MonorepoService.ListWorkspaceSiblings:output_type -> monorepo.ListWorkspaceSiblingsResponse
+	97,  // 176: monorepo.MonorepoService.FetchWorkspaceDependencies:output_type -> monorepo.FetchWorkspaceDependenciesResponse
+	99,  // 177: monorepo.MonorepoService.WhoAmI:output_type -> monorepo.WhoAmIResponse
+	101, // 178: monorepo.MonorepoService.GetServerInfo:output_type -> monorepo.GetServerInfoResponse
+	104, // 179: monorepo.MonorepoService.LockPath:output_type -> monorepo.LockPathResponse
+	106, // 180: monorepo.MonorepoService.UnlockPath:output_type -> monorepo.UnlockPathResponse
+	108, // 181: monorepo.MonorepoService.ListLocks:output_type -> monorepo.ListLocksResponse
+	111, // 182: monorepo.MonorepoService.ListTags:output_type -> monorepo.ListTagsResponse
+	114, // 183: monorepo.MonorepoService.GetActivity:output_type -> monorepo.GetActivityResponse
+	117, // 184: monorepo.MonorepoService.GetQuota:output_type -> monorepo.GetQuotaResponse
+	119, // 185: monorepo.MonorepoService.ApprovePatch:output_type -> monorepo.ApprovePatchResponse
+	122, // 186: monorepo.MonorepoService.GetMergeQueue:output_type -> monorepo.GetMergeQueueResponse
+	124, // 187: monorepo.MonorepoService.ReportQueueValidation:output_type -> monorepo.ReportQueueValidationResponse
+	127, // 188: monorepo.MonorepoService.ListDeletedPaths:output_type -> monorepo.ListDeletedPathsResponse
+	129, // 189: monorepo.MonorepoService.RestoreDeletedPath:output_type -> monorepo.RestoreDeletedPathResponse
+	131, // 190: monorepo.MonorepoAdminService.RunGarbageCollection:output_type -> monorepo.GarbageCollectionResponse
+	133, // 191: monorepo.MonorepoAdminService.Fsck:output_type -> monorepo.FsckResponse
+	135, // 192: monorepo.MonorepoAdminService.GetBackendStats:output_type -> monorepo.BackendStatsResponse
+	137, // 193: monorepo.MonorepoAdminService.SetUserQuota:output_type -> monorepo.SetUserQuotaResponse
+	139, // 194: monorepo.MonorepoAdminService.SetWorkspaceQuota:output_type -> monorepo.SetWorkspaceQuotaResponse
+	141, // 195: monorepo.MonorepoAdminService.ForceUnlockPath:output_type -> monorepo.ForceUnlockPathResponse
+	143, // 196: monorepo.MonorepoAdminService.ListWorkspaces:output_type -> monorepo.ListWorkspacesResponse
+	145, // 197: monorepo.MonorepoAdminService.ReapWorkspaces:output_type -> monorepo.ReapWorkspacesResponse
+	147, // 198: monorepo.MonorepoAdminService.CollectWorkspaceDirectories:output_type -> monorepo.CollectWorkspaceDirectoriesResponse
+	149, // 199: monorepo.MonorepoAdminService.CompactWorkspaces:output_type -> monorepo.CompactWorkspacesResponse
+	153, // 200: monorepo.MonorepoAdminService.Backup:output_type -> monorepo.BackupResponse
+	155, // 201: monorepo.MonorepoAdminService.Restore:output_type -> monorepo.RestoreResponse
+	157, // 202: monorepo.MonorepoAdminService.MigrateBackend:output_type -> monorepo.MigrateBackendResponse
+	159, // 203: monorepo.MonorepoAdminService.RehashObjects:output_type -> monorepo.RehashObjectsResponse
+	161, // 204: monorepo.MonorepoAdminService.PruneHistory:output_type -> monorepo.PruneHistoryResponse
+	164, // 205: monorepo.MonorepoAdminService.ListCorruptObjects:output_type -> monorepo.ListCorruptObjectsResponse
+	167, // 206: monorepo.MonorepoAdminService.GetReplicationStatus:output_type -> monorepo.GetReplicationStatusResponse
+	169, // 207: monorepo.MonorepoAdminService.FailoverBackend:output_type -> monorepo.FailoverBackendResponse
+	171, // 208: monorepo.MonorepoAdminService.ResyncReplica:output_type -> monorepo.ResyncReplicaResponse
+	58,  // 209: monorepo.MonorepoAdminService.GetOperation:output_type -> monorepo.GetOperationResponse
+	60,  // 210: monorepo.MonorepoAdminService.WaitOperation:output_type -> monorepo.WaitOperationResponse
+	62,  // 211: monorepo.MonorepoAdminService.CancelOperation:output_type -> monorepo.CancelOperationResponse
+	64,  // 212: monorepo.MonorepoAdminService.ListOperations:output_type -> monorepo.ListOperationsResponse
+	137, // [137:213] is the sub-list for method output_type
+	61,  // [61:137] is the sub-list for method input_type
+	61,  // [61:61] is the sub-list for extension type_name
+	61,  // [61:61] is the sub-list for extension extendee
+	0,   // [0:61] is the sub-list for field type_name
 }
 
 func init() { file_monorepo_proto_init() }
@@ -2123,10 +13177,10 @@ func file_monorepo_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_monorepo_proto_rawDesc), len(file_monorepo_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   32,
+			NumEnums:      2,
+			NumMessages:   180,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_monorepo_proto_goTypes,
 		DependencyIndexes: file_monorepo_proto_depIdxs,
@@ -19,19 +19,59 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MonorepoService_MergePatch_FullMethodName              = "/monorepo.MonorepoService/MergePatch"
-	MonorepoService_ReadDirectory_FullMethodName           = "/monorepo.MonorepoService/ReadDirectory"
-	MonorepoService_ReadFile_FullMethodName                = "/monorepo.MonorepoService/ReadFile"
-	MonorepoService_GetFileHistory_FullMethodName          = "/monorepo.MonorepoService/GetFileHistory"
-	MonorepoService_GetBranches_FullMethodName             = "/monorepo.MonorepoService/GetBranches"
-	MonorepoService_CreateBranch_FullMethodName            = "/monorepo.MonorepoService/CreateBranch"
-	MonorepoService_CreateWorkspace_FullMethodName         = "/monorepo.MonorepoService/CreateWorkspace"
-	MonorepoService_GetWorkspace_FullMethodName            = "/monorepo.MonorepoService/GetWorkspace"
-	MonorepoService_UpdateWorkspace_FullMethodName         = "/monorepo.MonorepoService/UpdateWorkspace"
-	MonorepoService_DeleteWorkspace_FullMethodName         = "/monorepo.MonorepoService/DeleteWorkspace"
-	MonorepoService_ConfigureSparseCheckout_FullMethodName = "/monorepo.MonorepoService/ConfigureSparseCheckout"
-	MonorepoService_DownloadPath_FullMethodName            = "/monorepo.MonorepoService/DownloadPath"
-	MonorepoService_AddTrackedPath_FullMethodName          = "/monorepo.MonorepoService/AddTrackedPath"
+	MonorepoService_MergePatch_FullMethodName                 = "/monorepo.MonorepoService/MergePatch"
+	MonorepoService_PreviewPatch_FullMethodName               = "/monorepo.MonorepoService/PreviewPatch"
+	MonorepoService_ReadDirectory_FullMethodName              = "/monorepo.MonorepoService/ReadDirectory"
+	MonorepoService_ReadFile_FullMethodName                   = "/monorepo.MonorepoService/ReadFile"
+	MonorepoService_ReadFiles_FullMethodName                  = "/monorepo.MonorepoService/ReadFiles"
+	MonorepoService_StreamDirectory_FullMethodName            = "/monorepo.MonorepoService/StreamDirectory"
+	MonorepoService_StreamFile_FullMethodName                 = "/monorepo.MonorepoService/StreamFile"
+	MonorepoService_PreviewFile_FullMethodName                = "/monorepo.MonorepoService/PreviewFile"
+	MonorepoService_GetRenderedDoc_FullMethodName             = "/monorepo.MonorepoService/GetRenderedDoc"
+	MonorepoService_SearchSymbols_FullMethodName              = "/monorepo.MonorepoService/SearchSymbols"
+	MonorepoService_GoToDefinition_FullMethodName             = "/monorepo.MonorepoService/GoToDefinition"
+	MonorepoService_GetPathInfo_FullMethodName                = "/monorepo.MonorepoService/GetPathInfo"
+	MonorepoService_GetTreeHash_FullMethodName                = "/monorepo.MonorepoService/GetTreeHash"
+	MonorepoService_ListCaseCollisions_FullMethodName         = "/monorepo.MonorepoService/ListCaseCollisions"
+	MonorepoService_GetAffectedPaths_FullMethodName           = "/monorepo.MonorepoService/GetAffectedPaths"
+	MonorepoService_GetFileHistory_FullMethodName             = "/monorepo.MonorepoService/GetFileHistory"
+	MonorepoService_GetBranches_FullMethodName                = "/monorepo.MonorepoService/GetBranches"
+	MonorepoService_CreateBranch_FullMethodName               = "/monorepo.MonorepoService/CreateBranch"
+	MonorepoService_MergeBranches_FullMethodName              = "/monorepo.MonorepoService/MergeBranches"
+	MonorepoService_CherryPick_FullMethodName                 = "/monorepo.MonorepoService/CherryPick"
+	MonorepoService_CreateWorkspace_FullMethodName            = "/monorepo.MonorepoService/CreateWorkspace"
+	MonorepoService_GetWorkspace_FullMethodName               = "/monorepo.MonorepoService/GetWorkspace"
+	MonorepoService_UpdateWorkspace_FullMethodName            = "/monorepo.MonorepoService/UpdateWorkspace"
+	MonorepoService_DeleteWorkspace_FullMethodName            = "/monorepo.MonorepoService/DeleteWorkspace"
+	MonorepoService_GetOperation_FullMethodName               = "/monorepo.MonorepoService/GetOperation"
+	MonorepoService_WaitOperation_FullMethodName              = "/monorepo.MonorepoService/WaitOperation"
+	MonorepoService_CancelOperation_FullMethodName            = "/monorepo.MonorepoService/CancelOperation"
+	MonorepoService_ListOperations_FullMethodName             = "/monorepo.MonorepoService/ListOperations"
+	MonorepoService_ListTemplates_FullMethodName              = "/monorepo.MonorepoService/ListTemplates"
+	MonorepoService_ListViews_FullMethodName                  = "/monorepo.MonorepoService/ListViews"
+	MonorepoService_ReportWorkspaceStatus_FullMethodName      = "/monorepo.MonorepoService/ReportWorkspaceStatus"
+	MonorepoService_ConfigureSparseCheckout_FullMethodName    = "/monorepo.MonorepoService/ConfigureSparseCheckout"
+	MonorepoService_DownloadPath_FullMethodName               = "/monorepo.MonorepoService/DownloadPath"
+	MonorepoService_StreamDownloadPath_FullMethodName         = "/monorepo.MonorepoService/StreamDownloadPath"
+	MonorepoService_StreamWorkspaceArchive_FullMethodName     = "/monorepo.MonorepoService/StreamWorkspaceArchive"
+	MonorepoService_AddTrackedPath_FullMethodName             = "/monorepo.MonorepoService/AddTrackedPath"
+	MonorepoService_RefreshTrackedPaths_FullMethodName        = "/monorepo.MonorepoService/RefreshTrackedPaths"
+	MonorepoService_OpenWorkspaceFile_FullMethodName          = "/monorepo.MonorepoService/OpenWorkspaceFile"
+	MonorepoService_ListWorkspaceSiblings_FullMethodName      = "/monorepo.MonorepoService/ListWorkspaceSiblings"
+	MonorepoService_FetchWorkspaceDependencies_FullMethodName = "/monorepo.MonorepoService/FetchWorkspaceDependencies"
+	MonorepoService_WhoAmI_FullMethodName                     = "/monorepo.MonorepoService/WhoAmI"
+	MonorepoService_GetServerInfo_FullMethodName              = "/monorepo.MonorepoService/GetServerInfo"
+	MonorepoService_LockPath_FullMethodName                   = "/monorepo.MonorepoService/LockPath"
+	MonorepoService_UnlockPath_FullMethodName                 = "/monorepo.MonorepoService/UnlockPath"
+	MonorepoService_ListLocks_FullMethodName                  = "/monorepo.MonorepoService/ListLocks"
+	MonorepoService_ListTags_FullMethodName                   = "/monorepo.MonorepoService/ListTags"
+	MonorepoService_GetActivity_FullMethodName                = "/monorepo.MonorepoService/GetActivity"
+	MonorepoService_GetQuota_FullMethodName                   = "/monorepo.MonorepoService/GetQuota"
+	MonorepoService_ApprovePatch_FullMethodName               = "/monorepo.MonorepoService/ApprovePatch"
+	MonorepoService_GetMergeQueue_FullMethodName              = "/monorepo.MonorepoService/GetMergeQueue"
+	MonorepoService_ReportQueueValidation_FullMethodName      = "/monorepo.MonorepoService/ReportQueueValidation"
+	MonorepoService_ListDeletedPaths_FullMethodName           = "/monorepo.MonorepoService/ListDeletedPaths"
+	MonorepoService_RestoreDeletedPath_FullMethodName         = "/monorepo.MonorepoService/RestoreDeletedPath"
 )
 
 // MonorepoServiceClient is the client API for MonorepoService service.
@@ -42,27 +82,137 @@ const (
 type MonorepoServiceClient interface {
 	// MergePatch applies a patch to the monorepo
 	MergePatch(ctx context.Context, in *MergePatchRequest, opts ...grpc.CallOption) (*MergePatchResponse, error)
+	// PreviewPatch applies a patch in memory and returns the result without
+	// creating a version
+	PreviewPatch(ctx context.Context, in *PreviewPatchRequest, opts ...grpc.CallOption) (*PreviewPatchResponse, error)
 	// ReadDirectory lists the contents of a directory
 	ReadDirectory(ctx context.Context, in *ReadDirectoryRequest, opts ...grpc.CallOption) (*ReadDirectoryResponse, error)
 	// ReadFile returns the contents of a file
 	ReadFile(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (*ReadFileResponse, error)
+	// ReadFiles returns several files at one version in a single round trip.
+	// Each path gets its own result, so one missing file does not fail the
+	// rest; files past the response size cap come back omitted
+	ReadFiles(ctx context.Context, in *ReadFilesRequest, opts ...grpc.CallOption) (*ReadFilesResponse, error)
+	// StreamDirectory lists a directory at a fixed version in batches, for
+	// clients that load the tree lazily such as `poon mount`
+	StreamDirectory(ctx context.Context, in *StreamDirectoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamDirectoryResponse], error)
+	// StreamFile returns a byte range of a file at a fixed version in chunks
+	StreamFile(ctx context.Context, in *StreamFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error)
+	// PreviewFile returns numbered lines of a text file, optionally
+	// syntax-highlighted, for viewers that show part of a file
+	PreviewFile(ctx context.Context, in *PreviewFileRequest, opts ...grpc.CallOption) (*PreviewFileResponse, error)
+	// GetRenderedDoc renders a directory's README, or a Markdown file, as
+	// sanitized HTML for the directory browser
+	GetRenderedDoc(ctx context.Context, in *GetRenderedDocRequest, opts ...grpc.CallOption) (*GetRenderedDocResponse, error)
+	// SearchSymbols finds the definitions of functions, types and other
+	// symbols by name, on servers that index symbols
+	SearchSymbols(ctx context.Context, in *SearchSymbolsRequest, opts ...grpc.CallOption) (*SearchSymbolsResponse, error)
+	// GoToDefinition finds the definitions of the identifier at a position
+	// in a file, on servers that index symbols
+	GoToDefinition(ctx context.Context, in *GoToDefinitionRequest, opts ...grpc.CallOption) (*GoToDefinitionResponse, error)
+	// GetPathInfo summarizes a file or directory: sizes, last change, README
+	// and owners
+	GetPathInfo(ctx context.Context, in *GetPathInfoRequest, opts ...grpc.CallOption) (*GetPathInfoResponse, error)
+	// GetTreeHash returns the content hash of directories (or files) at a
+	// version. A hash only changes when something below the path does, so
+	// comparing it with one recorded earlier tells whether a subtree changed
+	GetTreeHash(ctx context.Context, in *GetTreeHashRequest, opts ...grpc.CallOption) (*GetTreeHashResponse, error)
+	// ListCaseCollisions lists paths that differ only in case, which cannot
+	// both exist on case-insensitive file systems
+	ListCaseCollisions(ctx context.Context, in *ListCaseCollisionsRequest, opts ...grpc.CallOption) (*ListCaseCollisionsResponse, error)
+	// GetAffectedPaths lists the path prefixes changed between two versions, so
+	// CI systems can decide which pipelines to run after a merge
+	GetAffectedPaths(ctx context.Context, in *GetAffectedPathsRequest, opts ...grpc.CallOption) (*GetAffectedPathsResponse, error)
 	// GetFileHistory returns the commit history for a file
 	GetFileHistory(ctx context.Context, in *FileHistoryRequest, opts ...grpc.CallOption) (*FileHistoryResponse, error)
 	// GetBranches returns available branches
 	GetBranches(ctx context.Context, in *BranchesRequest, opts ...grpc.CallOption) (*BranchesResponse, error)
 	// CreateBranch creates a new branch
 	CreateBranch(ctx context.Context, in *CreateBranchRequest, opts ...grpc.CallOption) (*CreateBranchResponse, error)
+	// MergeBranches merges one branch into another with a merge commit
+	MergeBranches(ctx context.Context, in *MergeBranchesRequest, opts ...grpc.CallOption) (*MergeBranchesResponse, error)
+	// CherryPick applies the changes one commit made to another branch
+	CherryPick(ctx context.Context, in *CherryPickRequest, opts ...grpc.CallOption) (*CherryPickResponse, error)
 	// Workspace operations
 	CreateWorkspace(ctx context.Context, in *CreateWorkspaceRequest, opts ...grpc.CallOption) (*CreateWorkspaceResponse, error)
 	GetWorkspace(ctx context.Context, in *GetWorkspaceRequest, opts ...grpc.CallOption) (*GetWorkspaceResponse, error)
 	UpdateWorkspace(ctx context.Context, in *UpdateWorkspaceRequest, opts ...grpc.CallOption) (*UpdateWorkspaceResponse, error)
 	DeleteWorkspace(ctx context.Context, in *DeleteWorkspaceRequest, opts ...grpc.CallOption) (*DeleteWorkspaceResponse, error)
+	// GetOperation reports a long-running operation, such as a
+	// CreateWorkspace filling the workspace repository
+	GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*GetOperationResponse, error)
+	// WaitOperation blocks until an operation is done or the timeout elapses,
+	// then reports it like GetOperation
+	WaitOperation(ctx context.Context, in *WaitOperationRequest, opts ...grpc.CallOption) (*WaitOperationResponse, error)
+	// CancelOperation stops an operation the caller started
+	CancelOperation(ctx context.Context, in *CancelOperationRequest, opts ...grpc.CallOption) (*CancelOperationResponse, error)
+	// ListOperations lists the caller's operations, newest first
+	ListOperations(ctx context.Context, in *ListOperationsRequest, opts ...grpc.CallOption) (*ListOperationsResponse, error)
+	// ListTemplates lists the workspace templates CreateWorkspace accepts
+	ListTemplates(ctx context.Context, in *ListTemplatesRequest, opts ...grpc.CallOption) (*ListTemplatesResponse, error)
+	// ListViews lists the path views defined in the repository's
+	// .poon/views.json; a workspace tracks one as the path "view:<name>"
+	ListViews(ctx context.Context, in *ListViewsRequest, opts ...grpc.CallOption) (*ListViewsResponse, error)
+	// ReportWorkspaceStatus records the client-side state of a workspace after
+	// a sync or push
+	ReportWorkspaceStatus(ctx context.Context, in *ReportWorkspaceStatusRequest, opts ...grpc.CallOption) (*ReportWorkspaceStatusResponse, error)
 	// Sparse checkout operations
 	ConfigureSparseCheckout(ctx context.Context, in *SparseCheckoutRequest, opts ...grpc.CallOption) (*SparseCheckoutResponse, error)
 	// Download operations
 	DownloadPath(ctx context.Context, in *DownloadPathRequest, opts ...grpc.CallOption) (*DownloadPathResponse, error)
+	// StreamDownloadPath is DownloadPath with the archive sent in chunks as it
+	// is built, so it is not limited to one message's size. async is ignored.
+	StreamDownloadPath(ctx context.Context, in *DownloadPathRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadChunk], error)
+	// StreamWorkspaceArchive streams a tar of the commit a workspace repository
+	// has checked out, so a client can extract it instead of cloning a large
+	// workspace and then fetch only the commits and trees
+	StreamWorkspaceArchive(ctx context.Context, in *StreamWorkspaceArchiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WorkspaceArchiveChunk], error)
 	// Track additional paths in workspace
 	AddTrackedPath(ctx context.Context, in *AddTrackedPathRequest, opts ...grpc.CallOption) (*AddTrackedPathResponse, error)
+	// RefreshTrackedPaths re-expands a workspace's tracked glob patterns and
+	// adds any newly matching paths
+	RefreshTrackedPaths(ctx context.Context, in *RefreshTrackedPathsRequest, opts ...grpc.CallOption) (*RefreshTrackedPathsResponse, error)
+	// OpenWorkspaceFile reads any file in the repository at the version a
+	// workspace was synced to, tracked or not, so an editor can open files
+	// outside the workspace without checking them out
+	OpenWorkspaceFile(ctx context.Context, in *OpenWorkspaceFileRequest, opts ...grpc.CallOption) (*OpenWorkspaceFileResponse, error)
+	// ListWorkspaceSiblings lists the directory holding a path at a
+	// workspace's synced version, marking which entries the workspace tracks
+	ListWorkspaceSiblings(ctx context.Context, in *ListWorkspaceSiblingsRequest, opts ...grpc.CallOption) (*ListWorkspaceSiblingsResponse, error)
+	// FetchWorkspaceDependencies reads the files an editor needs to resolve
+	// imports, at a workspace's synced version. A directory stands for the
+	// files directly in it, such as a Go package
+	FetchWorkspaceDependencies(ctx context.Context, in *FetchWorkspaceDependenciesRequest, opts ...grpc.CallOption) (*FetchWorkspaceDependenciesResponse, error)
+	// WhoAmI returns the identity associated with the caller's credentials
+	WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIResponse, error)
+	// GetServerInfo returns the server's version, the oldest client it
+	// supports and the optional features it offers. It needs no credentials.
+	GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*GetServerInfoResponse, error)
+	// Advisory path locks
+	LockPath(ctx context.Context, in *LockPathRequest, opts ...grpc.CallOption) (*LockPathResponse, error)
+	UnlockPath(ctx context.Context, in *UnlockPathRequest, opts ...grpc.CallOption) (*UnlockPathResponse, error)
+	ListLocks(ctx context.Context, in *ListLocksRequest, opts ...grpc.CallOption) (*ListLocksResponse, error)
+	// ListTags lists the tags pinning versions, such as scheduled snapshots
+	ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error)
+	// GetActivity summarizes the versions created in a time range by day,
+	// week, month, author or directory, for activity dashboards
+	GetActivity(ctx context.Context, in *GetActivityRequest, opts ...grpc.CallOption) (*GetActivityResponse, error)
+	// GetQuota reports storage and request usage against quota limits
+	GetQuota(ctx context.Context, in *GetQuotaRequest, opts ...grpc.CallOption) (*GetQuotaResponse, error)
+	// ApprovePatch records the caller's approval of a patch, for paths whose
+	// branch protection requires review
+	ApprovePatch(ctx context.Context, in *ApprovePatchRequest, opts ...grpc.CallOption) (*ApprovePatchResponse, error)
+	// GetMergeQueue lists the changes waiting in the merge queue and those
+	// that recently left it
+	GetMergeQueue(ctx context.Context, in *GetMergeQueueRequest, opts ...grpc.CallOption) (*GetMergeQueueResponse, error)
+	// ReportQueueValidation delivers an external validator's verdict on the
+	// change at the head of the merge queue
+	ReportQueueValidation(ctx context.Context, in *ReportQueueValidationRequest, opts ...grpc.CallOption) (*ReportQueueValidationResponse, error)
+	// ListDeletedPaths lists files deleted by patches that can still be
+	// restored
+	ListDeletedPaths(ctx context.Context, in *ListDeletedPathsRequest, opts ...grpc.CallOption) (*ListDeletedPathsResponse, error)
+	// RestoreDeletedPath puts deleted files back with their last content
+	RestoreDeletedPath(ctx context.Context, in *RestoreDeletedPathRequest, opts ...grpc.CallOption) (*RestoreDeletedPathResponse, error)
 }
 
 type monorepoServiceClient struct {
@@ -83,6 +233,16 @@ func (c *monorepoServiceClient) MergePatch(ctx context.Context, in *MergePatchRe
 	return out, nil
 }
 
+func (c *monorepoServiceClient) PreviewPatch(ctx context.Context, in *PreviewPatchRequest, opts ...grpc.CallOption) (*PreviewPatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PreviewPatchResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_PreviewPatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *monorepoServiceClient) ReadDirectory(ctx context.Context, in *ReadDirectoryRequest, opts ...grpc.CallOption) (*ReadDirectoryResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ReadDirectoryResponse)
@@ -103,6 +263,134 @@ func (c *monorepoServiceClient) ReadFile(ctx context.Context, in *ReadFileReques
 	return out, nil
 }
 
+func (c *monorepoServiceClient) ReadFiles(ctx context.Context, in *ReadFilesRequest, opts ...grpc.CallOption) (*ReadFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadFilesResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ReadFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) StreamDirectory(ctx context.Context, in *StreamDirectoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamDirectoryResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonorepoService_ServiceDesc.Streams[0], MonorepoService_StreamDirectory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamDirectoryRequest, StreamDirectoryResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamDirectoryClient = grpc.ServerStreamingClient[StreamDirectoryResponse]
+
+func (c *monorepoServiceClient) StreamFile(ctx context.Context, in *StreamFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonorepoService_ServiceDesc.Streams[1], MonorepoService_StreamFile_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamFileRequest, FileChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamFileClient = grpc.ServerStreamingClient[FileChunk]
+
+func (c *monorepoServiceClient) PreviewFile(ctx context.Context, in *PreviewFileRequest, opts ...grpc.CallOption) (*PreviewFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PreviewFileResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_PreviewFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetRenderedDoc(ctx context.Context, in *GetRenderedDocRequest, opts ...grpc.CallOption) (*GetRenderedDocResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRenderedDocResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetRenderedDoc_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) SearchSymbols(ctx context.Context, in *SearchSymbolsRequest, opts ...grpc.CallOption) (*SearchSymbolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchSymbolsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_SearchSymbols_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GoToDefinition(ctx context.Context, in *GoToDefinitionRequest, opts ...grpc.CallOption) (*GoToDefinitionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GoToDefinitionResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GoToDefinition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetPathInfo(ctx context.Context, in *GetPathInfoRequest, opts ...grpc.CallOption) (*GetPathInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPathInfoResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetPathInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetTreeHash(ctx context.Context, in *GetTreeHashRequest, opts ...grpc.CallOption) (*GetTreeHashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTreeHashResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetTreeHash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListCaseCollisions(ctx context.Context, in *ListCaseCollisionsRequest, opts ...grpc.CallOption) (*ListCaseCollisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCaseCollisionsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListCaseCollisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetAffectedPaths(ctx context.Context, in *GetAffectedPathsRequest, opts ...grpc.CallOption) (*GetAffectedPathsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAffectedPathsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetAffectedPaths_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *monorepoServiceClient) GetFileHistory(ctx context.Context, in *FileHistoryRequest, opts ...grpc.CallOption) (*FileHistoryResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(FileHistoryResponse)
@@ -133,6 +421,26 @@ func (c *monorepoServiceClient) CreateBranch(ctx context.Context, in *CreateBran
 	return out, nil
 }
 
+func (c *monorepoServiceClient) MergeBranches(ctx context.Context, in *MergeBranchesRequest, opts ...grpc.CallOption) (*MergeBranchesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MergeBranchesResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_MergeBranches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) CherryPick(ctx context.Context, in *CherryPickRequest, opts ...grpc.CallOption) (*CherryPickResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CherryPickResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_CherryPick_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *monorepoServiceClient) CreateWorkspace(ctx context.Context, in *CreateWorkspaceRequest, opts ...grpc.CallOption) (*CreateWorkspaceResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CreateWorkspaceResponse)
@@ -173,6 +481,76 @@ func (c *monorepoServiceClient) DeleteWorkspace(ctx context.Context, in *DeleteW
 	return out, nil
 }
 
+func (c *monorepoServiceClient) GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*GetOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOperationResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) WaitOperation(ctx context.Context, in *WaitOperationRequest, opts ...grpc.CallOption) (*WaitOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WaitOperationResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_WaitOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) CancelOperation(ctx context.Context, in *CancelOperationRequest, opts ...grpc.CallOption) (*CancelOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelOperationResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_CancelOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListOperations(ctx context.Context, in *ListOperationsRequest, opts ...grpc.CallOption) (*ListOperationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOperationsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListOperations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListTemplates(ctx context.Context, in *ListTemplatesRequest, opts ...grpc.CallOption) (*ListTemplatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTemplatesResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListTemplates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListViews(ctx context.Context, in *ListViewsRequest, opts ...grpc.CallOption) (*ListViewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListViewsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListViews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ReportWorkspaceStatus(ctx context.Context, in *ReportWorkspaceStatusRequest, opts ...grpc.CallOption) (*ReportWorkspaceStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportWorkspaceStatusResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ReportWorkspaceStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *monorepoServiceClient) ConfigureSparseCheckout(ctx context.Context, in *SparseCheckoutRequest, opts ...grpc.CallOption) (*SparseCheckoutResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SparseCheckoutResponse)
@@ -193,6 +571,44 @@ func (c *monorepoServiceClient) DownloadPath(ctx context.Context, in *DownloadPa
 	return out, nil
 }
 
+func (c *monorepoServiceClient) StreamDownloadPath(ctx context.Context, in *DownloadPathRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonorepoService_ServiceDesc.Streams[2], MonorepoService_StreamDownloadPath_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadPathRequest, DownloadChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamDownloadPathClient = grpc.ServerStreamingClient[DownloadChunk]
+
+func (c *monorepoServiceClient) StreamWorkspaceArchive(ctx context.Context, in *StreamWorkspaceArchiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WorkspaceArchiveChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonorepoService_ServiceDesc.Streams[3], MonorepoService_StreamWorkspaceArchive_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamWorkspaceArchiveRequest, WorkspaceArchiveChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamWorkspaceArchiveClient = grpc.ServerStreamingClient[WorkspaceArchiveChunk]
+
 func (c *monorepoServiceClient) AddTrackedPath(ctx context.Context, in *AddTrackedPathRequest, opts ...grpc.CallOption) (*AddTrackedPathResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AddTrackedPathResponse)
@@ -203,63 +619,385 @@ func (c *monorepoServiceClient) AddTrackedPath(ctx context.Context, in *AddTrack
 	return out, nil
 }
 
-// MonorepoServiceServer is the server API for MonorepoService service.
-// All implementations must embed UnimplementedMonorepoServiceServer
-// for forward compatibility.
-//
-// MonorepoService provides operations for the internet-scale monorepo
-type MonorepoServiceServer interface {
-	// MergePatch applies a patch to the monorepo
-	MergePatch(context.Context, *MergePatchRequest) (*MergePatchResponse, error)
-	// ReadDirectory lists the contents of a directory
-	ReadDirectory(context.Context, *ReadDirectoryRequest) (*ReadDirectoryResponse, error)
-	// ReadFile returns the contents of a file
-	ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error)
-	// GetFileHistory returns the commit history for a file
-	GetFileHistory(context.Context, *FileHistoryRequest) (*FileHistoryResponse, error)
-	// GetBranches returns available branches
-	GetBranches(context.Context, *BranchesRequest) (*BranchesResponse, error)
-	// CreateBranch creates a new branch
-	CreateBranch(context.Context, *CreateBranchRequest) (*CreateBranchResponse, error)
-	// Workspace operations
-	CreateWorkspace(context.Context, *CreateWorkspaceRequest) (*CreateWorkspaceResponse, error)
-	GetWorkspace(context.Context, *GetWorkspaceRequest) (*GetWorkspaceResponse, error)
-	UpdateWorkspace(context.Context, *UpdateWorkspaceRequest) (*UpdateWorkspaceResponse, error)
-	DeleteWorkspace(context.Context, *DeleteWorkspaceRequest) (*DeleteWorkspaceResponse, error)
-	// Sparse checkout operations
-	ConfigureSparseCheckout(context.Context, *SparseCheckoutRequest) (*SparseCheckoutResponse, error)
-	// Download operations
-	DownloadPath(context.Context, *DownloadPathRequest) (*DownloadPathResponse, error)
-	// Track additional paths in workspace
-	AddTrackedPath(context.Context, *AddTrackedPathRequest) (*AddTrackedPathResponse, error)
-	mustEmbedUnimplementedMonorepoServiceServer()
+func (c *monorepoServiceClient) RefreshTrackedPaths(ctx context.Context, in *RefreshTrackedPathsRequest, opts ...grpc.CallOption) (*RefreshTrackedPathsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefreshTrackedPathsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_RefreshTrackedPaths_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// UnimplementedMonorepoServiceServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedMonorepoServiceServer struct{}
+func (c *monorepoServiceClient) OpenWorkspaceFile(ctx context.Context, in *OpenWorkspaceFileRequest, opts ...grpc.CallOption) (*OpenWorkspaceFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OpenWorkspaceFileResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_OpenWorkspaceFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-func (UnimplementedMonorepoServiceServer) MergePatch(context.Context, *MergePatchRequest) (*MergePatchResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method MergePatch not implemented")
+func (c *monorepoServiceClient) ListWorkspaceSiblings(ctx context.Context, in *ListWorkspaceSiblingsRequest, opts ...grpc.CallOption) (*ListWorkspaceSiblingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorkspaceSiblingsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListWorkspaceSiblings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedMonorepoServiceServer) ReadDirectory(context.Context, *ReadDirectoryRequest) (*ReadDirectoryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ReadDirectory not implemented")
+
+func (c *monorepoServiceClient) FetchWorkspaceDependencies(ctx context.Context, in *FetchWorkspaceDependenciesRequest, opts ...grpc.CallOption) (*FetchWorkspaceDependenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchWorkspaceDependenciesResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_FetchWorkspaceDependencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedMonorepoServiceServer) ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ReadFile not implemented")
+
+func (c *monorepoServiceClient) WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WhoAmIResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_WhoAmI_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedMonorepoServiceServer) GetFileHistory(context.Context, *FileHistoryRequest) (*FileHistoryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetFileHistory not implemented")
+
+func (c *monorepoServiceClient) GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*GetServerInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetServerInfoResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetServerInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedMonorepoServiceServer) GetBranches(context.Context, *BranchesRequest) (*BranchesResponse, error) {
+
+func (c *monorepoServiceClient) LockPath(ctx context.Context, in *LockPathRequest, opts ...grpc.CallOption) (*LockPathResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockPathResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_LockPath_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) UnlockPath(ctx context.Context, in *UnlockPathRequest, opts ...grpc.CallOption) (*UnlockPathResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnlockPathResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_UnlockPath_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListLocks(ctx context.Context, in *ListLocksRequest, opts ...grpc.CallOption) (*ListLocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLocksResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListLocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTagsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetActivity(ctx context.Context, in *GetActivityRequest, opts ...grpc.CallOption) (*GetActivityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetActivityResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetActivity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetQuota(ctx context.Context, in *GetQuotaRequest, opts ...grpc.CallOption) (*GetQuotaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetQuotaResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ApprovePatch(ctx context.Context, in *ApprovePatchRequest, opts ...grpc.CallOption) (*ApprovePatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApprovePatchResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ApprovePatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) GetMergeQueue(ctx context.Context, in *GetMergeQueueRequest, opts ...grpc.CallOption) (*GetMergeQueueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMergeQueueResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_GetMergeQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ReportQueueValidation(ctx context.Context, in *ReportQueueValidationRequest, opts ...grpc.CallOption) (*ReportQueueValidationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportQueueValidationResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ReportQueueValidation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) ListDeletedPaths(ctx context.Context, in *ListDeletedPathsRequest, opts ...grpc.CallOption) (*ListDeletedPathsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeletedPathsResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_ListDeletedPaths_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoServiceClient) RestoreDeletedPath(ctx context.Context, in *RestoreDeletedPathRequest, opts ...grpc.CallOption) (*RestoreDeletedPathResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreDeletedPathResponse)
+	err := c.cc.Invoke(ctx, MonorepoService_RestoreDeletedPath_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MonorepoServiceServer is the server API for MonorepoService service.
+// All implementations must embed UnimplementedMonorepoServiceServer
+// for forward compatibility.
+//
+// MonorepoService provides operations for the internet-scale monorepo
+type MonorepoServiceServer interface {
+	// MergePatch applies a patch to the monorepo
+	MergePatch(context.Context, *MergePatchRequest) (*MergePatchResponse, error)
+	// PreviewPatch applies a patch in memory and returns the result without
+	// creating a version
+	PreviewPatch(context.Context, *PreviewPatchRequest) (*PreviewPatchResponse, error)
+	// ReadDirectory lists the contents of a directory
+	ReadDirectory(context.Context, *ReadDirectoryRequest) (*ReadDirectoryResponse, error)
+	// ReadFile returns the contents of a file
+	ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error)
+	// ReadFiles returns several files at one version in a single round trip.
+	// Each path gets its own result, so one missing file does not fail the
+	// rest; files past the response size cap come back omitted
+	ReadFiles(context.Context, *ReadFilesRequest) (*ReadFilesResponse, error)
+	// StreamDirectory lists a directory at a fixed version in batches, for
+	// clients that load the tree lazily such as `poon mount`
+	StreamDirectory(*StreamDirectoryRequest, grpc.ServerStreamingServer[StreamDirectoryResponse]) error
+	// StreamFile returns a byte range of a file at a fixed version in chunks
+	StreamFile(*StreamFileRequest, grpc.ServerStreamingServer[FileChunk]) error
+	// PreviewFile returns numbered lines of a text file, optionally
+	// syntax-highlighted, for viewers that show part of a file
+	PreviewFile(context.Context, *PreviewFileRequest) (*PreviewFileResponse, error)
+	// GetRenderedDoc renders a directory's README, or a Markdown file, as
+	// sanitized HTML for the directory browser
+	GetRenderedDoc(context.Context, *GetRenderedDocRequest) (*GetRenderedDocResponse, error)
+	// SearchSymbols finds the definitions of functions, types and other
+	// symbols by name, on servers that index symbols
+	SearchSymbols(context.Context, *SearchSymbolsRequest) (*SearchSymbolsResponse, error)
+	// GoToDefinition finds the definitions of the identifier at a position
+	// in a file, on servers that index symbols
+	GoToDefinition(context.Context, *GoToDefinitionRequest) (*GoToDefinitionResponse, error)
+	// GetPathInfo summarizes a file or directory: sizes, last change, README
+	// and owners
+	GetPathInfo(context.Context, *GetPathInfoRequest) (*GetPathInfoResponse, error)
+	// GetTreeHash returns the content hash of directories (or files) at a
+	// version. A hash only changes when something below the path does, so
+	// comparing it with one recorded earlier tells whether a subtree changed
+	GetTreeHash(context.Context, *GetTreeHashRequest) (*GetTreeHashResponse, error)
+	// ListCaseCollisions lists paths that differ only in case, which cannot
+	// both exist on case-insensitive file systems
+	ListCaseCollisions(context.Context, *ListCaseCollisionsRequest) (*ListCaseCollisionsResponse, error)
+	// GetAffectedPaths lists the path prefixes changed between two versions, so
+	// CI systems can decide which pipelines to run after a merge
+	GetAffectedPaths(context.Context, *GetAffectedPathsRequest) (*GetAffectedPathsResponse, error)
+	// GetFileHistory returns the commit history for a file
+	GetFileHistory(context.Context, *FileHistoryRequest) (*FileHistoryResponse, error)
+	// GetBranches returns available branches
+	GetBranches(context.Context, *BranchesRequest) (*BranchesResponse, error)
+	// CreateBranch creates a new branch
+	CreateBranch(context.Context, *CreateBranchRequest) (*CreateBranchResponse, error)
+	// MergeBranches merges one branch into another with a merge commit
+	MergeBranches(context.Context, *MergeBranchesRequest) (*MergeBranchesResponse, error)
+	// CherryPick applies the changes one commit made to another branch
+	CherryPick(context.Context, *CherryPickRequest) (*CherryPickResponse, error)
+	// Workspace operations
+	CreateWorkspace(context.Context, *CreateWorkspaceRequest) (*CreateWorkspaceResponse, error)
+	GetWorkspace(context.Context, *GetWorkspaceRequest) (*GetWorkspaceResponse, error)
+	UpdateWorkspace(context.Context, *UpdateWorkspaceRequest) (*UpdateWorkspaceResponse, error)
+	DeleteWorkspace(context.Context, *DeleteWorkspaceRequest) (*DeleteWorkspaceResponse, error)
+	// GetOperation reports a long-running operation, such as a
+	// CreateWorkspace filling the workspace repository
+	GetOperation(context.Context, *GetOperationRequest) (*GetOperationResponse, error)
+	// WaitOperation blocks until an operation is done or the timeout elapses,
+	// then reports it like GetOperation
+	WaitOperation(context.Context, *WaitOperationRequest) (*WaitOperationResponse, error)
+	// CancelOperation stops an operation the caller started
+	CancelOperation(context.Context, *CancelOperationRequest) (*CancelOperationResponse, error)
+	// ListOperations lists the caller's operations, newest first
+	ListOperations(context.Context, *ListOperationsRequest) (*ListOperationsResponse, error)
+	// ListTemplates lists the workspace templates CreateWorkspace accepts
+	ListTemplates(context.Context, *ListTemplatesRequest) (*ListTemplatesResponse, error)
+	// ListViews lists the path views defined in the repository's
+	// .poon/views.json; a workspace tracks one as the path "view:<name>"
+	ListViews(context.Context, *ListViewsRequest) (*ListViewsResponse, error)
+	// ReportWorkspaceStatus records the client-side state of a workspace after
+	// a sync or push
+	ReportWorkspaceStatus(context.Context, *ReportWorkspaceStatusRequest) (*ReportWorkspaceStatusResponse, error)
+	// Sparse checkout operations
+	ConfigureSparseCheckout(context.Context, *SparseCheckoutRequest) (*SparseCheckoutResponse, error)
+	// Download operations
+	DownloadPath(context.Context, *DownloadPathRequest) (*DownloadPathResponse, error)
+	// StreamDownloadPath is DownloadPath with the archive sent in chunks as it
+	// is built, so it is not limited to one message's size. async is ignored.
+	StreamDownloadPath(*DownloadPathRequest, grpc.ServerStreamingServer[DownloadChunk]) error
+	// StreamWorkspaceArchive streams a tar of the commit a workspace repository
+	// has checked out, so a client can extract it instead of cloning a large
+	// workspace and then fetch only the commits and trees
+	StreamWorkspaceArchive(*StreamWorkspaceArchiveRequest, grpc.ServerStreamingServer[WorkspaceArchiveChunk]) error
+	// Track additional paths in workspace
+	AddTrackedPath(context.Context, *AddTrackedPathRequest) (*AddTrackedPathResponse, error)
+	// RefreshTrackedPaths re-expands a workspace's tracked glob patterns and
+	// adds any newly matching paths
+	RefreshTrackedPaths(context.Context, *RefreshTrackedPathsRequest) (*RefreshTrackedPathsResponse, error)
+	// OpenWorkspaceFile reads any file in the repository at the version a
+	// workspace was synced to, tracked or not, so an editor can open files
+	// outside the workspace without checking them out
+	OpenWorkspaceFile(context.Context, *OpenWorkspaceFileRequest) (*OpenWorkspaceFileResponse, error)
+	// ListWorkspaceSiblings lists the directory holding a path at a
+	// workspace's synced version, marking which entries the workspace tracks
+	ListWorkspaceSiblings(context.Context, *ListWorkspaceSiblingsRequest) (*ListWorkspaceSiblingsResponse, error)
+	// FetchWorkspaceDependencies reads the files an editor needs to resolve
+	// imports, at a workspace's synced version. A directory stands for the
+	// files directly in it, such as a Go package
+	FetchWorkspaceDependencies(context.Context, *FetchWorkspaceDependenciesRequest) (*FetchWorkspaceDependenciesResponse, error)
+	// WhoAmI returns the identity associated with the caller's credentials
+	WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIResponse, error)
+	// GetServerInfo returns the server's version, the oldest client it
+	// supports and the optional features it offers. It needs no credentials.
+	GetServerInfo(context.Context, *GetServerInfoRequest) (*GetServerInfoResponse, error)
+	// Advisory path locks
+	LockPath(context.Context, *LockPathRequest) (*LockPathResponse, error)
+	UnlockPath(context.Context, *UnlockPathRequest) (*UnlockPathResponse, error)
+	ListLocks(context.Context, *ListLocksRequest) (*ListLocksResponse, error)
+	// ListTags lists the tags pinning versions, such as scheduled snapshots
+	ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error)
+	// GetActivity summarizes the versions created in a time range by day,
+	// week, month, author or directory, for activity dashboards
+	GetActivity(context.Context, *GetActivityRequest) (*GetActivityResponse, error)
+	// GetQuota reports storage and request usage against quota limits
+	GetQuota(context.Context, *GetQuotaRequest) (*GetQuotaResponse, error)
+	// ApprovePatch records the caller's approval of a patch, for paths whose
+	// branch protection requires review
+	ApprovePatch(context.Context, *ApprovePatchRequest) (*ApprovePatchResponse, error)
+	// GetMergeQueue lists the changes waiting in the merge queue and those
+	// that recently left it
+	GetMergeQueue(context.Context, *GetMergeQueueRequest) (*GetMergeQueueResponse, error)
+	// ReportQueueValidation delivers an external validator's verdict on the
+	// change at the head of the merge queue
+	ReportQueueValidation(context.Context, *ReportQueueValidationRequest) (*ReportQueueValidationResponse, error)
+	// ListDeletedPaths lists files deleted by patches that can still be
+	// restored
+	ListDeletedPaths(context.Context, *ListDeletedPathsRequest) (*ListDeletedPathsResponse, error)
+	// RestoreDeletedPath puts deleted files back with their last content
+	RestoreDeletedPath(context.Context, *RestoreDeletedPathRequest) (*RestoreDeletedPathResponse, error)
+	mustEmbedUnimplementedMonorepoServiceServer()
+}
+
+// UnimplementedMonorepoServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMonorepoServiceServer struct{}
+
+func (UnimplementedMonorepoServiceServer) MergePatch(context.Context, *MergePatchRequest) (*MergePatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergePatch not implemented")
+}
+func (UnimplementedMonorepoServiceServer) PreviewPatch(context.Context, *PreviewPatchRequest) (*PreviewPatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewPatch not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ReadDirectory(context.Context, *ReadDirectoryRequest) (*ReadDirectoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadDirectory not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadFile not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ReadFiles(context.Context, *ReadFilesRequest) (*ReadFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadFiles not implemented")
+}
+func (UnimplementedMonorepoServiceServer) StreamDirectory(*StreamDirectoryRequest, grpc.ServerStreamingServer[StreamDirectoryResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDirectory not implemented")
+}
+func (UnimplementedMonorepoServiceServer) StreamFile(*StreamFileRequest, grpc.ServerStreamingServer[FileChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFile not implemented")
+}
+func (UnimplementedMonorepoServiceServer) PreviewFile(context.Context, *PreviewFileRequest) (*PreviewFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewFile not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetRenderedDoc(context.Context, *GetRenderedDocRequest) (*GetRenderedDocResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRenderedDoc not implemented")
+}
+func (UnimplementedMonorepoServiceServer) SearchSymbols(context.Context, *SearchSymbolsRequest) (*SearchSymbolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchSymbols not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GoToDefinition(context.Context, *GoToDefinitionRequest) (*GoToDefinitionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GoToDefinition not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetPathInfo(context.Context, *GetPathInfoRequest) (*GetPathInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPathInfo not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetTreeHash(context.Context, *GetTreeHashRequest) (*GetTreeHashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTreeHash not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListCaseCollisions(context.Context, *ListCaseCollisionsRequest) (*ListCaseCollisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCaseCollisions not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetAffectedPaths(context.Context, *GetAffectedPathsRequest) (*GetAffectedPathsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAffectedPaths not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetFileHistory(context.Context, *FileHistoryRequest) (*FileHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileHistory not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetBranches(context.Context, *BranchesRequest) (*BranchesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBranches not implemented")
 }
 func (UnimplementedMonorepoServiceServer) CreateBranch(context.Context, *CreateBranchRequest) (*CreateBranchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateBranch not implemented")
 }
+func (UnimplementedMonorepoServiceServer) MergeBranches(context.Context, *MergeBranchesRequest) (*MergeBranchesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeBranches not implemented")
+}
+func (UnimplementedMonorepoServiceServer) CherryPick(context.Context, *CherryPickRequest) (*CherryPickResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CherryPick not implemented")
+}
 func (UnimplementedMonorepoServiceServer) CreateWorkspace(context.Context, *CreateWorkspaceRequest) (*CreateWorkspaceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateWorkspace not implemented")
 }
@@ -272,328 +1010,2295 @@ func (UnimplementedMonorepoServiceServer) UpdateWorkspace(context.Context, *Upda
 func (UnimplementedMonorepoServiceServer) DeleteWorkspace(context.Context, *DeleteWorkspaceRequest) (*DeleteWorkspaceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteWorkspace not implemented")
 }
+func (UnimplementedMonorepoServiceServer) GetOperation(context.Context, *GetOperationRequest) (*GetOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOperation not implemented")
+}
+func (UnimplementedMonorepoServiceServer) WaitOperation(context.Context, *WaitOperationRequest) (*WaitOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitOperation not implemented")
+}
+func (UnimplementedMonorepoServiceServer) CancelOperation(context.Context, *CancelOperationRequest) (*CancelOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOperation not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListOperations(context.Context, *ListOperationsRequest) (*ListOperationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOperations not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListTemplates(context.Context, *ListTemplatesRequest) (*ListTemplatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTemplates not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListViews(context.Context, *ListViewsRequest) (*ListViewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListViews not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ReportWorkspaceStatus(context.Context, *ReportWorkspaceStatusRequest) (*ReportWorkspaceStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportWorkspaceStatus not implemented")
+}
 func (UnimplementedMonorepoServiceServer) ConfigureSparseCheckout(context.Context, *SparseCheckoutRequest) (*SparseCheckoutResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ConfigureSparseCheckout not implemented")
 }
 func (UnimplementedMonorepoServiceServer) DownloadPath(context.Context, *DownloadPathRequest) (*DownloadPathResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DownloadPath not implemented")
 }
-func (UnimplementedMonorepoServiceServer) AddTrackedPath(context.Context, *AddTrackedPathRequest) (*AddTrackedPathResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddTrackedPath not implemented")
+func (UnimplementedMonorepoServiceServer) StreamDownloadPath(*DownloadPathRequest, grpc.ServerStreamingServer[DownloadChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDownloadPath not implemented")
+}
+func (UnimplementedMonorepoServiceServer) StreamWorkspaceArchive(*StreamWorkspaceArchiveRequest, grpc.ServerStreamingServer[WorkspaceArchiveChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamWorkspaceArchive not implemented")
+}
+func (UnimplementedMonorepoServiceServer) AddTrackedPath(context.Context, *AddTrackedPathRequest) (*AddTrackedPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTrackedPath not implemented")
+}
+func (UnimplementedMonorepoServiceServer) RefreshTrackedPaths(context.Context, *RefreshTrackedPathsRequest) (*RefreshTrackedPathsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshTrackedPaths not implemented")
+}
+func (UnimplementedMonorepoServiceServer) OpenWorkspaceFile(context.Context, *OpenWorkspaceFileRequest) (*OpenWorkspaceFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenWorkspaceFile not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListWorkspaceSiblings(context.Context, *ListWorkspaceSiblingsRequest) (*ListWorkspaceSiblingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkspaceSiblings not implemented")
+}
+func (UnimplementedMonorepoServiceServer) FetchWorkspaceDependencies(context.Context, *FetchWorkspaceDependenciesRequest) (*FetchWorkspaceDependenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchWorkspaceDependencies not implemented")
+}
+func (UnimplementedMonorepoServiceServer) WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WhoAmI not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetServerInfo(context.Context, *GetServerInfoRequest) (*GetServerInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerInfo not implemented")
+}
+func (UnimplementedMonorepoServiceServer) LockPath(context.Context, *LockPathRequest) (*LockPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockPath not implemented")
+}
+func (UnimplementedMonorepoServiceServer) UnlockPath(context.Context, *UnlockPathRequest) (*UnlockPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockPath not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListLocks(context.Context, *ListLocksRequest) (*ListLocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLocks not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTags not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetActivity(context.Context, *GetActivityRequest) (*GetActivityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetActivity not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetQuota(context.Context, *GetQuotaRequest) (*GetQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuota not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ApprovePatch(context.Context, *ApprovePatchRequest) (*ApprovePatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApprovePatch not implemented")
+}
+func (UnimplementedMonorepoServiceServer) GetMergeQueue(context.Context, *GetMergeQueueRequest) (*GetMergeQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMergeQueue not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ReportQueueValidation(context.Context, *ReportQueueValidationRequest) (*ReportQueueValidationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportQueueValidation not implemented")
+}
+func (UnimplementedMonorepoServiceServer) ListDeletedPaths(context.Context, *ListDeletedPathsRequest) (*ListDeletedPathsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeletedPaths not implemented")
+}
+func (UnimplementedMonorepoServiceServer) RestoreDeletedPath(context.Context, *RestoreDeletedPathRequest) (*RestoreDeletedPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreDeletedPath not implemented")
+}
+func (UnimplementedMonorepoServiceServer) mustEmbedUnimplementedMonorepoServiceServer() {}
+func (UnimplementedMonorepoServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeMonorepoServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MonorepoServiceServer will
+// result in compilation errors.
+type UnsafeMonorepoServiceServer interface {
+	mustEmbedUnimplementedMonorepoServiceServer()
+}
+
+func RegisterMonorepoServiceServer(s grpc.ServiceRegistrar, srv MonorepoServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMonorepoServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MonorepoService_ServiceDesc, srv)
+}
+
+func _MonorepoService_MergePatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergePatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).MergePatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_MergePatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).MergePatch(ctx, req.(*MergePatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_PreviewPatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewPatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).PreviewPatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_PreviewPatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).PreviewPatch(ctx, req.(*PreviewPatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ReadDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ReadDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ReadDirectory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ReadDirectory(ctx, req.(*ReadDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ReadFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ReadFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ReadFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ReadFile(ctx, req.(*ReadFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ReadFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ReadFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ReadFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ReadFiles(ctx, req.(*ReadFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_StreamDirectory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDirectoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonorepoServiceServer).StreamDirectory(m, &grpc.GenericServerStream[StreamDirectoryRequest, StreamDirectoryResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamDirectoryServer = grpc.ServerStreamingServer[StreamDirectoryResponse]
+
+func _MonorepoService_StreamFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonorepoServiceServer).StreamFile(m, &grpc.GenericServerStream[StreamFileRequest, FileChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamFileServer = grpc.ServerStreamingServer[FileChunk]
+
+func _MonorepoService_PreviewFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).PreviewFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_PreviewFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).PreviewFile(ctx, req.(*PreviewFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetRenderedDoc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRenderedDocRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetRenderedDoc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetRenderedDoc_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetRenderedDoc(ctx, req.(*GetRenderedDocRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_SearchSymbols_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchSymbolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).SearchSymbols(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_SearchSymbols_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).SearchSymbols(ctx, req.(*SearchSymbolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GoToDefinition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GoToDefinitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GoToDefinition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GoToDefinition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GoToDefinition(ctx, req.(*GoToDefinitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetPathInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPathInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetPathInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetPathInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetPathInfo(ctx, req.(*GetPathInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetTreeHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTreeHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetTreeHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetTreeHash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetTreeHash(ctx, req.(*GetTreeHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListCaseCollisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCaseCollisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListCaseCollisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListCaseCollisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListCaseCollisions(ctx, req.(*ListCaseCollisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetAffectedPaths_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAffectedPathsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetAffectedPaths(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetAffectedPaths_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetAffectedPaths(ctx, req.(*GetAffectedPathsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetFileHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetFileHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetFileHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetFileHistory(ctx, req.(*FileHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetBranches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BranchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetBranches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetBranches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetBranches(ctx, req.(*BranchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_CreateBranch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBranchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).CreateBranch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_CreateBranch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).CreateBranch(ctx, req.(*CreateBranchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_MergeBranches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeBranchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).MergeBranches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_MergeBranches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).MergeBranches(ctx, req.(*MergeBranchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_CherryPick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CherryPickRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).CherryPick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_CherryPick_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).CherryPick(ctx, req.(*CherryPickRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_CreateWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).CreateWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_CreateWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).CreateWorkspace(ctx, req.(*CreateWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetWorkspace(ctx, req.(*GetWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_UpdateWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).UpdateWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_UpdateWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).UpdateWorkspace(ctx, req.(*UpdateWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_DeleteWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).DeleteWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_DeleteWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).DeleteWorkspace(ctx, req.(*DeleteWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetOperation(ctx, req.(*GetOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_WaitOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WaitOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).WaitOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_WaitOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).WaitOperation(ctx, req.(*WaitOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_CancelOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).CancelOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_CancelOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).CancelOperation(ctx, req.(*CancelOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListOperations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOperationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListOperations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListOperations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListOperations(ctx, req.(*ListOperationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListTemplates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTemplatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListTemplates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListTemplates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListTemplates(ctx, req.(*ListTemplatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListViews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListViewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListViews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListViews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListViews(ctx, req.(*ListViewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ReportWorkspaceStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportWorkspaceStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ReportWorkspaceStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ReportWorkspaceStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ReportWorkspaceStatus(ctx, req.(*ReportWorkspaceStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ConfigureSparseCheckout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SparseCheckoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ConfigureSparseCheckout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ConfigureSparseCheckout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ConfigureSparseCheckout(ctx, req.(*SparseCheckoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_DownloadPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DownloadPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).DownloadPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_DownloadPath_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).DownloadPath(ctx, req.(*DownloadPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_StreamDownloadPath_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadPathRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonorepoServiceServer).StreamDownloadPath(m, &grpc.GenericServerStream[DownloadPathRequest, DownloadChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamDownloadPathServer = grpc.ServerStreamingServer[DownloadChunk]
+
+func _MonorepoService_StreamWorkspaceArchive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamWorkspaceArchiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonorepoServiceServer).StreamWorkspaceArchive(m, &grpc.GenericServerStream[StreamWorkspaceArchiveRequest, WorkspaceArchiveChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonorepoService_StreamWorkspaceArchiveServer = grpc.ServerStreamingServer[WorkspaceArchiveChunk]
+
+func _MonorepoService_AddTrackedPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTrackedPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).AddTrackedPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_AddTrackedPath_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).AddTrackedPath(ctx, req.(*AddTrackedPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_RefreshTrackedPaths_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshTrackedPathsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).RefreshTrackedPaths(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_RefreshTrackedPaths_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).RefreshTrackedPaths(ctx, req.(*RefreshTrackedPathsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_OpenWorkspaceFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenWorkspaceFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).OpenWorkspaceFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_OpenWorkspaceFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).OpenWorkspaceFile(ctx, req.(*OpenWorkspaceFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListWorkspaceSiblings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkspaceSiblingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListWorkspaceSiblings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListWorkspaceSiblings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListWorkspaceSiblings(ctx, req.(*ListWorkspaceSiblingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_FetchWorkspaceDependencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchWorkspaceDependenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).FetchWorkspaceDependencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_FetchWorkspaceDependencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).FetchWorkspaceDependencies(ctx, req.(*FetchWorkspaceDependenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_WhoAmI_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhoAmIRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).WhoAmI(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_WhoAmI_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).WhoAmI(ctx, req.(*WhoAmIRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetServerInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetServerInfo(ctx, req.(*GetServerInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_LockPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).LockPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_LockPath_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).LockPath(ctx, req.(*LockPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_UnlockPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).UnlockPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_UnlockPath_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).UnlockPath(ctx, req.(*UnlockPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListLocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListLocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListLocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListLocks(ctx, req.(*ListLocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListTags(ctx, req.(*ListTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetActivity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetActivity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetActivity(ctx, req.(*GetActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetQuota_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetQuota(ctx, req.(*GetQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ApprovePatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApprovePatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ApprovePatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ApprovePatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ApprovePatch(ctx, req.(*ApprovePatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_GetMergeQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMergeQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).GetMergeQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_GetMergeQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).GetMergeQueue(ctx, req.(*GetMergeQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ReportQueueValidation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportQueueValidationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ReportQueueValidation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ReportQueueValidation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ReportQueueValidation(ctx, req.(*ReportQueueValidationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_ListDeletedPaths_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeletedPathsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).ListDeletedPaths(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_ListDeletedPaths_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).ListDeletedPaths(ctx, req.(*ListDeletedPathsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoService_RestoreDeletedPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreDeletedPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoServiceServer).RestoreDeletedPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoService_RestoreDeletedPath_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoServiceServer).RestoreDeletedPath(ctx, req.(*RestoreDeletedPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MonorepoService_ServiceDesc is the grpc.ServiceDesc for MonorepoService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MonorepoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "monorepo.MonorepoService",
+	HandlerType: (*MonorepoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MergePatch",
+			Handler:    _MonorepoService_MergePatch_Handler,
+		},
+		{
+			MethodName: "PreviewPatch",
+			Handler:    _MonorepoService_PreviewPatch_Handler,
+		},
+		{
+			MethodName: "ReadDirectory",
+			Handler:    _MonorepoService_ReadDirectory_Handler,
+		},
+		{
+			MethodName: "ReadFile",
+			Handler:    _MonorepoService_ReadFile_Handler,
+		},
+		{
+			MethodName: "ReadFiles",
+			Handler:    _MonorepoService_ReadFiles_Handler,
+		},
+		{
+			MethodName: "PreviewFile",
+			Handler:    _MonorepoService_PreviewFile_Handler,
+		},
+		{
+			MethodName: "GetRenderedDoc",
+			Handler:    _MonorepoService_GetRenderedDoc_Handler,
+		},
+		{
+			MethodName: "SearchSymbols",
+			Handler:    _MonorepoService_SearchSymbols_Handler,
+		},
+		{
+			MethodName: "GoToDefinition",
+			Handler:    _MonorepoService_GoToDefinition_Handler,
+		},
+		{
+			MethodName: "GetPathInfo",
+			Handler:    _MonorepoService_GetPathInfo_Handler,
+		},
+		{
+			MethodName: "GetTreeHash",
+			Handler:    _MonorepoService_GetTreeHash_Handler,
+		},
+		{
+			MethodName: "ListCaseCollisions",
+			Handler:    _MonorepoService_ListCaseCollisions_Handler,
+		},
+		{
+			MethodName: "GetAffectedPaths",
+			Handler:    _MonorepoService_GetAffectedPaths_Handler,
+		},
+		{
+			MethodName: "GetFileHistory",
+			Handler:    _MonorepoService_GetFileHistory_Handler,
+		},
+		{
+			MethodName: "GetBranches",
+			Handler:    _MonorepoService_GetBranches_Handler,
+		},
+		{
+			MethodName: "CreateBranch",
+			Handler:    _MonorepoService_CreateBranch_Handler,
+		},
+		{
+			MethodName: "MergeBranches",
+			Handler:    _MonorepoService_MergeBranches_Handler,
+		},
+		{
+			MethodName: "CherryPick",
+			Handler:    _MonorepoService_CherryPick_Handler,
+		},
+		{
+			MethodName: "CreateWorkspace",
+			Handler:    _MonorepoService_CreateWorkspace_Handler,
+		},
+		{
+			MethodName: "GetWorkspace",
+			Handler:    _MonorepoService_GetWorkspace_Handler,
+		},
+		{
+			MethodName: "UpdateWorkspace",
+			Handler:    _MonorepoService_UpdateWorkspace_Handler,
+		},
+		{
+			MethodName: "DeleteWorkspace",
+			Handler:    _MonorepoService_DeleteWorkspace_Handler,
+		},
+		{
+			MethodName: "GetOperation",
+			Handler:    _MonorepoService_GetOperation_Handler,
+		},
+		{
+			MethodName: "WaitOperation",
+			Handler:    _MonorepoService_WaitOperation_Handler,
+		},
+		{
+			MethodName: "CancelOperation",
+			Handler:    _MonorepoService_CancelOperation_Handler,
+		},
+		{
+			MethodName: "ListOperations",
+			Handler:    _MonorepoService_ListOperations_Handler,
+		},
+		{
+			MethodName: "ListTemplates",
+			Handler:    _MonorepoService_ListTemplates_Handler,
+		},
+		{
+			MethodName: "ListViews",
+			Handler:    _MonorepoService_ListViews_Handler,
+		},
+		{
+			MethodName: "ReportWorkspaceStatus",
+			Handler:    _MonorepoService_ReportWorkspaceStatus_Handler,
+		},
+		{
+			MethodName: "ConfigureSparseCheckout",
+			Handler:    _MonorepoService_ConfigureSparseCheckout_Handler,
+		},
+		{
+			MethodName: "DownloadPath",
+			Handler:    _MonorepoService_DownloadPath_Handler,
+		},
+		{
+			MethodName: "AddTrackedPath",
+			Handler:    _MonorepoService_AddTrackedPath_Handler,
+		},
+		{
+			MethodName: "RefreshTrackedPaths",
+			Handler:    _MonorepoService_RefreshTrackedPaths_Handler,
+		},
+		{
+			MethodName: "OpenWorkspaceFile",
+			Handler:    _MonorepoService_OpenWorkspaceFile_Handler,
+		},
+		{
+			MethodName: "ListWorkspaceSiblings",
+			Handler:    _MonorepoService_ListWorkspaceSiblings_Handler,
+		},
+		{
+			MethodName: "FetchWorkspaceDependencies",
+			Handler:    _MonorepoService_FetchWorkspaceDependencies_Handler,
+		},
+		{
+			MethodName: "WhoAmI",
+			Handler:    _MonorepoService_WhoAmI_Handler,
+		},
+		{
+			MethodName: "GetServerInfo",
+			Handler:    _MonorepoService_GetServerInfo_Handler,
+		},
+		{
+			MethodName: "LockPath",
+			Handler:    _MonorepoService_LockPath_Handler,
+		},
+		{
+			MethodName: "UnlockPath",
+			Handler:    _MonorepoService_UnlockPath_Handler,
+		},
+		{
+			MethodName: "ListLocks",
+			Handler:    _MonorepoService_ListLocks_Handler,
+		},
+		{
+			MethodName: "ListTags",
+			Handler:    _MonorepoService_ListTags_Handler,
+		},
+		{
+			MethodName: "GetActivity",
+			Handler:    _MonorepoService_GetActivity_Handler,
+		},
+		{
+			MethodName: "GetQuota",
+			Handler:    _MonorepoService_GetQuota_Handler,
+		},
+		{
+			MethodName: "ApprovePatch",
+			Handler:    _MonorepoService_ApprovePatch_Handler,
+		},
+		{
+			MethodName: "GetMergeQueue",
+			Handler:    _MonorepoService_GetMergeQueue_Handler,
+		},
+		{
+			MethodName: "ReportQueueValidation",
+			Handler:    _MonorepoService_ReportQueueValidation_Handler,
+		},
+		{
+			MethodName: "ListDeletedPaths",
+			Handler:    _MonorepoService_ListDeletedPaths_Handler,
+		},
+		{
+			MethodName: "RestoreDeletedPath",
+			Handler:    _MonorepoService_RestoreDeletedPath_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDirectory",
+			Handler:       _MonorepoService_StreamDirectory_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamFile",
+			Handler:       _MonorepoService_StreamFile_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDownloadPath",
+			Handler:       _MonorepoService_StreamDownloadPath_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamWorkspaceArchive",
+			Handler:       _MonorepoService_StreamWorkspaceArchive_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "monorepo.proto",
+}
+
+const (
+	MonorepoAdminService_RunGarbageCollection_FullMethodName        = "/monorepo.MonorepoAdminService/RunGarbageCollection"
+	MonorepoAdminService_Fsck_FullMethodName                        = "/monorepo.MonorepoAdminService/Fsck"
+	MonorepoAdminService_GetBackendStats_FullMethodName             = "/monorepo.MonorepoAdminService/GetBackendStats"
+	MonorepoAdminService_SetUserQuota_FullMethodName                = "/monorepo.MonorepoAdminService/SetUserQuota"
+	MonorepoAdminService_SetWorkspaceQuota_FullMethodName           = "/monorepo.MonorepoAdminService/SetWorkspaceQuota"
+	MonorepoAdminService_ForceUnlockPath_FullMethodName             = "/monorepo.MonorepoAdminService/ForceUnlockPath"
+	MonorepoAdminService_ListWorkspaces_FullMethodName              = "/monorepo.MonorepoAdminService/ListWorkspaces"
+	MonorepoAdminService_ReapWorkspaces_FullMethodName              = "/monorepo.MonorepoAdminService/ReapWorkspaces"
+	MonorepoAdminService_CollectWorkspaceDirectories_FullMethodName = "/monorepo.MonorepoAdminService/CollectWorkspaceDirectories"
+	MonorepoAdminService_CompactWorkspaces_FullMethodName           = "/monorepo.MonorepoAdminService/CompactWorkspaces"
+	MonorepoAdminService_Backup_FullMethodName                      = "/monorepo.MonorepoAdminService/Backup"
+	MonorepoAdminService_Restore_FullMethodName                     = "/monorepo.MonorepoAdminService/Restore"
+	MonorepoAdminService_MigrateBackend_FullMethodName              = "/monorepo.MonorepoAdminService/MigrateBackend"
+	MonorepoAdminService_RehashObjects_FullMethodName               = "/monorepo.MonorepoAdminService/RehashObjects"
+	MonorepoAdminService_PruneHistory_FullMethodName                = "/monorepo.MonorepoAdminService/PruneHistory"
+	MonorepoAdminService_ListCorruptObjects_FullMethodName          = "/monorepo.MonorepoAdminService/ListCorruptObjects"
+	MonorepoAdminService_GetReplicationStatus_FullMethodName        = "/monorepo.MonorepoAdminService/GetReplicationStatus"
+	MonorepoAdminService_FailoverBackend_FullMethodName             = "/monorepo.MonorepoAdminService/FailoverBackend"
+	MonorepoAdminService_ResyncReplica_FullMethodName               = "/monorepo.MonorepoAdminService/ResyncReplica"
+	MonorepoAdminService_GetOperation_FullMethodName                = "/monorepo.MonorepoAdminService/GetOperation"
+	MonorepoAdminService_WaitOperation_FullMethodName               = "/monorepo.MonorepoAdminService/WaitOperation"
+	MonorepoAdminService_CancelOperation_FullMethodName             = "/monorepo.MonorepoAdminService/CancelOperation"
+	MonorepoAdminService_ListOperations_FullMethodName              = "/monorepo.MonorepoAdminService/ListOperations"
+)
+
+// MonorepoAdminServiceClient is the client API for MonorepoAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MonorepoAdminService exposes operational endpoints. It is served on a
+// separate port with its own credentials and is not meant for regular clients.
+type MonorepoAdminServiceClient interface {
+	// RunGarbageCollection deletes objects not reachable from any version
+	RunGarbageCollection(ctx context.Context, in *GarbageCollectionRequest, opts ...grpc.CallOption) (*GarbageCollectionResponse, error)
+	// Fsck verifies stored objects and version references
+	Fsck(ctx context.Context, in *FsckRequest, opts ...grpc.CallOption) (*FsckResponse, error)
+	// GetBackendStats reports object counts and storage usage
+	GetBackendStats(ctx context.Context, in *BackendStatsRequest, opts ...grpc.CallOption) (*BackendStatsResponse, error)
+	// Quota management
+	SetUserQuota(ctx context.Context, in *SetUserQuotaRequest, opts ...grpc.CallOption) (*SetUserQuotaResponse, error)
+	SetWorkspaceQuota(ctx context.Context, in *SetWorkspaceQuotaRequest, opts ...grpc.CallOption) (*SetWorkspaceQuotaResponse, error)
+	// ForceUnlockPath removes a path lock regardless of its owner
+	ForceUnlockPath(ctx context.Context, in *ForceUnlockPathRequest, opts ...grpc.CallOption) (*ForceUnlockPathResponse, error)
+	// ListWorkspaces lists workspaces with their reported client status
+	ListWorkspaces(ctx context.Context, in *ListWorkspacesRequest, opts ...grpc.CallOption) (*ListWorkspacesResponse, error)
+	// ReapWorkspaces deletes workspaces that have been idle for too long
+	ReapWorkspaces(ctx context.Context, in *ReapWorkspacesRequest, opts ...grpc.CallOption) (*ReapWorkspacesResponse, error)
+	// CollectWorkspaceDirectories deletes directories in the workspace root
+	// that no workspace owns, such as those of deleted workspaces, once they
+	// have not changed for a grace period, and then the objects workspaces
+	// share that none of them uses any more. The server also runs it on
+	// startup and periodically.
+	CollectWorkspaceDirectories(ctx context.Context, in *CollectWorkspaceDirectoriesRequest, opts ...grpc.CallOption) (*CollectWorkspaceDirectoriesResponse, error)
+	// CompactWorkspaces cuts the history of workspace repositories down to
+	// their last commits, keeping commit hashes so clients are unaffected,
+	// and measures their disk usage. The server also runs it periodically
+	// when WORKSPACE_HISTORY_DEPTH is set.
+	CompactWorkspaces(ctx context.Context, in *CompactWorkspacesRequest, opts ...grpc.CallOption) (*CompactWorkspacesResponse, error)
+	// Backup writes a consistent snapshot of objects, the version index and
+	// workspace metadata. Objects already in the destination are skipped.
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (*BackupResponse, error)
+	// Restore replaces repository contents and workspace metadata with a snapshot
+	Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error)
+	// MigrateBackend copies all objects and version metadata to another
+	// backend while the server keeps running. Re-running resumes a partial copy.
+	MigrateBackend(ctx context.Context, in *MigrateBackendRequest, opts ...grpc.CallOption) (*MigrateBackendResponse, error)
+	// RehashObjects rewrites every reachable object with another hash
+	// algorithm, and trees and commits in the current hash format, and makes
+	// new objects use them. Writes are blocked while it runs; the old objects
+	// are removed by the next garbage collection.
+	RehashObjects(ctx context.Context, in *RehashObjectsRequest, opts ...grpc.CallOption) (*RehashObjectsResponse, error)
+	// PruneHistory removes old versions under a retention policy. The current
+	// version, the versions workspaces are synced to and the versions holding
+	// the content of files in the trash are always retained. Retained
+	// versions keep their numbers and content; their commits are rewritten so
+	// the oldest one is a checkpoint without history. Writes are blocked while
+	// it runs; the objects only pruned versions used are removed by the next
+	// garbage collection.
+	PruneHistory(ctx context.Context, in *PruneHistoryRequest, opts ...grpc.CallOption) (*PruneHistoryResponse, error)
+	// ListCorruptObjects lists the objects reads found not matching their
+	// hash. The server records each one and, with a repair source
+	// configured, replaces it with a copy that verifies.
+	ListCorruptObjects(ctx context.Context, in *ListCorruptObjectsRequest, opts ...grpc.CallOption) (*ListCorruptObjectsResponse, error)
+	// GetReplicationStatus reports how far each replica of the storage
+	// backend (REPLICA_BACKENDS) is behind the active backend
+	GetReplicationStatus(ctx context.Context, in *GetReplicationStatusRequest, opts ...grpc.CallOption) (*GetReplicationStatusResponse, error)
+	// FailoverBackend makes a replica the active backend: reads and writes
+	// go to it and the others mirror it. The choice is saved in the backends
+	// and kept across restarts.
+	FailoverBackend(ctx context.Context, in *FailoverBackendRequest, opts ...grpc.CallOption) (*FailoverBackendResponse, error)
+	// ResyncReplica compares every key of a replica with the active backend
+	// and copies those that differ, in the background
+	ResyncReplica(ctx context.Context, in *ResyncReplicaRequest, opts ...grpc.CallOption) (*ResyncReplicaResponse, error)
+	// Operations started with async through either API, including those of
+	// other users
+	GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*GetOperationResponse, error)
+	WaitOperation(ctx context.Context, in *WaitOperationRequest, opts ...grpc.CallOption) (*WaitOperationResponse, error)
+	CancelOperation(ctx context.Context, in *CancelOperationRequest, opts ...grpc.CallOption) (*CancelOperationResponse, error)
+	ListOperations(ctx context.Context, in *ListOperationsRequest, opts ...grpc.CallOption) (*ListOperationsResponse, error)
+}
+
+type monorepoAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMonorepoAdminServiceClient(cc grpc.ClientConnInterface) MonorepoAdminServiceClient {
+	return &monorepoAdminServiceClient{cc}
+}
+
+func (c *monorepoAdminServiceClient) RunGarbageCollection(ctx context.Context, in *GarbageCollectionRequest, opts ...grpc.CallOption) (*GarbageCollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GarbageCollectionResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_RunGarbageCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) Fsck(ctx context.Context, in *FsckRequest, opts ...grpc.CallOption) (*FsckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FsckResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_Fsck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) GetBackendStats(ctx context.Context, in *BackendStatsRequest, opts ...grpc.CallOption) (*BackendStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BackendStatsResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_GetBackendStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) SetUserQuota(ctx context.Context, in *SetUserQuotaRequest, opts ...grpc.CallOption) (*SetUserQuotaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetUserQuotaResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_SetUserQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) SetWorkspaceQuota(ctx context.Context, in *SetWorkspaceQuotaRequest, opts ...grpc.CallOption) (*SetWorkspaceQuotaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetWorkspaceQuotaResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_SetWorkspaceQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) ForceUnlockPath(ctx context.Context, in *ForceUnlockPathRequest, opts ...grpc.CallOption) (*ForceUnlockPathResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForceUnlockPathResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_ForceUnlockPath_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) ListWorkspaces(ctx context.Context, in *ListWorkspacesRequest, opts ...grpc.CallOption) (*ListWorkspacesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorkspacesResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_ListWorkspaces_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) ReapWorkspaces(ctx context.Context, in *ReapWorkspacesRequest, opts ...grpc.CallOption) (*ReapWorkspacesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReapWorkspacesResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_ReapWorkspaces_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) CollectWorkspaceDirectories(ctx context.Context, in *CollectWorkspaceDirectoriesRequest, opts ...grpc.CallOption) (*CollectWorkspaceDirectoriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectWorkspaceDirectoriesResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_CollectWorkspaceDirectories_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) CompactWorkspaces(ctx context.Context, in *CompactWorkspacesRequest, opts ...grpc.CallOption) (*CompactWorkspacesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompactWorkspacesResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_CompactWorkspaces_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (*BackupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BackupResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_Backup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) MigrateBackend(ctx context.Context, in *MigrateBackendRequest, opts ...grpc.CallOption) (*MigrateBackendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MigrateBackendResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_MigrateBackend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) RehashObjects(ctx context.Context, in *RehashObjectsRequest, opts ...grpc.CallOption) (*RehashObjectsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RehashObjectsResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_RehashObjects_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) PruneHistory(ctx context.Context, in *PruneHistoryRequest, opts ...grpc.CallOption) (*PruneHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PruneHistoryResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_PruneHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) ListCorruptObjects(ctx context.Context, in *ListCorruptObjectsRequest, opts ...grpc.CallOption) (*ListCorruptObjectsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCorruptObjectsResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_ListCorruptObjects_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) GetReplicationStatus(ctx context.Context, in *GetReplicationStatusRequest, opts ...grpc.CallOption) (*GetReplicationStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReplicationStatusResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_GetReplicationStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) FailoverBackend(ctx context.Context, in *FailoverBackendRequest, opts ...grpc.CallOption) (*FailoverBackendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FailoverBackendResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_FailoverBackend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) ResyncReplica(ctx context.Context, in *ResyncReplicaRequest, opts ...grpc.CallOption) (*ResyncReplicaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResyncReplicaResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_ResyncReplica_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*GetOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOperationResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_GetOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) WaitOperation(ctx context.Context, in *WaitOperationRequest, opts ...grpc.CallOption) (*WaitOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WaitOperationResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_WaitOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) CancelOperation(ctx context.Context, in *CancelOperationRequest, opts ...grpc.CallOption) (*CancelOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelOperationResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_CancelOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monorepoAdminServiceClient) ListOperations(ctx context.Context, in *ListOperationsRequest, opts ...grpc.CallOption) (*ListOperationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOperationsResponse)
+	err := c.cc.Invoke(ctx, MonorepoAdminService_ListOperations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MonorepoAdminServiceServer is the server API for MonorepoAdminService service.
+// All implementations must embed UnimplementedMonorepoAdminServiceServer
+// for forward compatibility.
+//
+// MonorepoAdminService exposes operational endpoints. It is served on a
+// separate port with its own credentials and is not meant for regular clients.
+type MonorepoAdminServiceServer interface {
+	// RunGarbageCollection deletes objects not reachable from any version
+	RunGarbageCollection(context.Context, *GarbageCollectionRequest) (*GarbageCollectionResponse, error)
+	// Fsck verifies stored objects and version references
+	Fsck(context.Context, *FsckRequest) (*FsckResponse, error)
+	// GetBackendStats reports object counts and storage usage
+	GetBackendStats(context.Context, *BackendStatsRequest) (*BackendStatsResponse, error)
+	// Quota management
+	SetUserQuota(context.Context, *SetUserQuotaRequest) (*SetUserQuotaResponse, error)
+	SetWorkspaceQuota(context.Context, *SetWorkspaceQuotaRequest) (*SetWorkspaceQuotaResponse, error)
+	// ForceUnlockPath removes a path lock regardless of its owner
+	ForceUnlockPath(context.Context, *ForceUnlockPathRequest) (*ForceUnlockPathResponse, error)
+	// ListWorkspaces lists workspaces with their reported client status
+	ListWorkspaces(context.Context, *ListWorkspacesRequest) (*ListWorkspacesResponse, error)
+	// ReapWorkspaces deletes workspaces that have been idle for too long
+	ReapWorkspaces(context.Context, *ReapWorkspacesRequest) (*ReapWorkspacesResponse, error)
+	// CollectWorkspaceDirectories deletes directories in the workspace root
+	// that no workspace owns, such as those of deleted workspaces, once they
+	// have not changed for a grace period, and then the objects workspaces
+	// share that none of them uses any more. The server also runs it on
+	// startup and periodically.
+	CollectWorkspaceDirectories(context.Context, *CollectWorkspaceDirectoriesRequest) (*CollectWorkspaceDirectoriesResponse, error)
+	// CompactWorkspaces cuts the history of workspace repositories down to
+	// their last commits, keeping commit hashes so clients are unaffected,
+	// and measures their disk usage. The server also runs it periodically
+	// when WORKSPACE_HISTORY_DEPTH is set.
+	CompactWorkspaces(context.Context, *CompactWorkspacesRequest) (*CompactWorkspacesResponse, error)
+	// Backup writes a consistent snapshot of objects, the version index and
+	// workspace metadata. Objects already in the destination are skipped.
+	Backup(context.Context, *BackupRequest) (*BackupResponse, error)
+	// Restore replaces repository contents and workspace metadata with a snapshot
+	Restore(context.Context, *RestoreRequest) (*RestoreResponse, error)
+	// MigrateBackend copies all objects and version metadata to another
+	// backend while the server keeps running. Re-running resumes a partial copy.
+	MigrateBackend(context.Context, *MigrateBackendRequest) (*MigrateBackendResponse, error)
+	// RehashObjects rewrites every reachable object with another hash
+	// algorithm, and trees and commits in the current hash format, and makes
+	// new objects use them. Writes are blocked while it runs; the old objects
+	// are removed by the next garbage collection.
+	RehashObjects(context.Context, *RehashObjectsRequest) (*RehashObjectsResponse, error)
+	// PruneHistory removes old versions under a retention policy. The current
+	// version, the versions workspaces are synced to and the versions holding
+	// the content of files in the trash are always retained. Retained
+	// versions keep their numbers and content; their commits are rewritten so
+	// the oldest one is a checkpoint without history. Writes are blocked while
+	// it runs; the objects only pruned versions used are removed by the next
+	// garbage collection.
+	PruneHistory(context.Context, *PruneHistoryRequest) (*PruneHistoryResponse, error)
+	// ListCorruptObjects lists the objects reads found not matching their
+	// hash. The server records each one and, with a repair source
+	// configured, replaces it with a copy that verifies.
+	ListCorruptObjects(context.Context, *ListCorruptObjectsRequest) (*ListCorruptObjectsResponse, error)
+	// GetReplicationStatus reports how far each replica of the storage
+	// backend (REPLICA_BACKENDS) is behind the active backend
+	GetReplicationStatus(context.Context, *GetReplicationStatusRequest) (*GetReplicationStatusResponse, error)
+	// FailoverBackend makes a replica the active backend: reads and writes
+	// go to it and the others mirror it. The choice is saved in the backends
+	// and kept across restarts.
+	FailoverBackend(context.Context, *FailoverBackendRequest) (*FailoverBackendResponse, error)
+	// ResyncReplica compares every key of a replica with the active backend
+	// and copies those that differ, in the background
+	ResyncReplica(context.Context, *ResyncReplicaRequest) (*ResyncReplicaResponse, error)
+	// Operations started with async through either API, including those of
+	// other users
+	GetOperation(context.Context, *GetOperationRequest) (*GetOperationResponse, error)
+	WaitOperation(context.Context, *WaitOperationRequest) (*WaitOperationResponse, error)
+	CancelOperation(context.Context, *CancelOperationRequest) (*CancelOperationResponse, error)
+	ListOperations(context.Context, *ListOperationsRequest) (*ListOperationsResponse, error)
+	mustEmbedUnimplementedMonorepoAdminServiceServer()
+}
+
+// UnimplementedMonorepoAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMonorepoAdminServiceServer struct{}
+
+func (UnimplementedMonorepoAdminServiceServer) RunGarbageCollection(context.Context, *GarbageCollectionRequest) (*GarbageCollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunGarbageCollection not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) Fsck(context.Context, *FsckRequest) (*FsckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fsck not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) GetBackendStats(context.Context, *BackendStatsRequest) (*BackendStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBackendStats not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) SetUserQuota(context.Context, *SetUserQuotaRequest) (*SetUserQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserQuota not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) SetWorkspaceQuota(context.Context, *SetWorkspaceQuotaRequest) (*SetWorkspaceQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWorkspaceQuota not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) ForceUnlockPath(context.Context, *ForceUnlockPathRequest) (*ForceUnlockPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceUnlockPath not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) ListWorkspaces(context.Context, *ListWorkspacesRequest) (*ListWorkspacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkspaces not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) ReapWorkspaces(context.Context, *ReapWorkspacesRequest) (*ReapWorkspacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReapWorkspaces not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) CollectWorkspaceDirectories(context.Context, *CollectWorkspaceDirectoriesRequest) (*CollectWorkspaceDirectoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CollectWorkspaceDirectories not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) CompactWorkspaces(context.Context, *CompactWorkspacesRequest) (*CompactWorkspacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompactWorkspaces not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) Backup(context.Context, *BackupRequest) (*BackupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) Restore(context.Context, *RestoreRequest) (*RestoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) MigrateBackend(context.Context, *MigrateBackendRequest) (*MigrateBackendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MigrateBackend not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) RehashObjects(context.Context, *RehashObjectsRequest) (*RehashObjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RehashObjects not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) PruneHistory(context.Context, *PruneHistoryRequest) (*PruneHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PruneHistory not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) ListCorruptObjects(context.Context, *ListCorruptObjectsRequest) (*ListCorruptObjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCorruptObjects not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) GetReplicationStatus(context.Context, *GetReplicationStatusRequest) (*GetReplicationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReplicationStatus not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) FailoverBackend(context.Context, *FailoverBackendRequest) (*FailoverBackendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FailoverBackend not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) ResyncReplica(context.Context, *ResyncReplicaRequest) (*ResyncReplicaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResyncReplica not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) GetOperation(context.Context, *GetOperationRequest) (*GetOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOperation not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) WaitOperation(context.Context, *WaitOperationRequest) (*WaitOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitOperation not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) CancelOperation(context.Context, *CancelOperationRequest) (*CancelOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOperation not implemented")
+}
+func (UnimplementedMonorepoAdminServiceServer) ListOperations(context.Context, *ListOperationsRequest) (*ListOperationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOperations not implemented")
 }
-func (UnimplementedMonorepoServiceServer) mustEmbedUnimplementedMonorepoServiceServer() {}
-func (UnimplementedMonorepoServiceServer) testEmbeddedByValue()                         {}
+func (UnimplementedMonorepoAdminServiceServer) mustEmbedUnimplementedMonorepoAdminServiceServer() {}
+func (UnimplementedMonorepoAdminServiceServer) testEmbeddedByValue()                              {}
 
-// UnsafeMonorepoServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to MonorepoServiceServer will
+// UnsafeMonorepoAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MonorepoAdminServiceServer will
 // result in compilation errors.
-type UnsafeMonorepoServiceServer interface {
-	mustEmbedUnimplementedMonorepoServiceServer()
+type UnsafeMonorepoAdminServiceServer interface {
+	mustEmbedUnimplementedMonorepoAdminServiceServer()
 }
 
-func RegisterMonorepoServiceServer(s grpc.ServiceRegistrar, srv MonorepoServiceServer) {
-	// If the following call pancis, it indicates UnimplementedMonorepoServiceServer was
+func RegisterMonorepoAdminServiceServer(s grpc.ServiceRegistrar, srv MonorepoAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMonorepoAdminServiceServer was
 	// embedded by pointer and is nil.  This will cause panics if an
 	// unimplemented method is ever invoked, so we test this at initialization
 	// time to prevent it from happening at runtime later due to I/O.
 	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
 		t.testEmbeddedByValue()
 	}
-	s.RegisterService(&MonorepoService_ServiceDesc, srv)
+	s.RegisterService(&MonorepoAdminService_ServiceDesc, srv)
 }
 
-func _MonorepoService_MergePatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MergePatchRequest)
+func _MonorepoAdminService_RunGarbageCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GarbageCollectionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).MergePatch(ctx, in)
+		return srv.(MonorepoAdminServiceServer).RunGarbageCollection(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_MergePatch_FullMethodName,
+		FullMethod: MonorepoAdminService_RunGarbageCollection_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).MergePatch(ctx, req.(*MergePatchRequest))
+		return srv.(MonorepoAdminServiceServer).RunGarbageCollection(ctx, req.(*GarbageCollectionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_ReadDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ReadDirectoryRequest)
+func _MonorepoAdminService_Fsck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FsckRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).ReadDirectory(ctx, in)
+		return srv.(MonorepoAdminServiceServer).Fsck(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_ReadDirectory_FullMethodName,
+		FullMethod: MonorepoAdminService_Fsck_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).ReadDirectory(ctx, req.(*ReadDirectoryRequest))
+		return srv.(MonorepoAdminServiceServer).Fsck(ctx, req.(*FsckRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_ReadFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ReadFileRequest)
+func _MonorepoAdminService_GetBackendStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BackendStatsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).ReadFile(ctx, in)
+		return srv.(MonorepoAdminServiceServer).GetBackendStats(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_ReadFile_FullMethodName,
+		FullMethod: MonorepoAdminService_GetBackendStats_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).ReadFile(ctx, req.(*ReadFileRequest))
+		return srv.(MonorepoAdminServiceServer).GetBackendStats(ctx, req.(*BackendStatsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_GetFileHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(FileHistoryRequest)
+func _MonorepoAdminService_SetUserQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserQuotaRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).GetFileHistory(ctx, in)
+		return srv.(MonorepoAdminServiceServer).SetUserQuota(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_GetFileHistory_FullMethodName,
+		FullMethod: MonorepoAdminService_SetUserQuota_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).GetFileHistory(ctx, req.(*FileHistoryRequest))
+		return srv.(MonorepoAdminServiceServer).SetUserQuota(ctx, req.(*SetUserQuotaRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_GetBranches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(BranchesRequest)
+func _MonorepoAdminService_SetWorkspaceQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetWorkspaceQuotaRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).GetBranches(ctx, in)
+		return srv.(MonorepoAdminServiceServer).SetWorkspaceQuota(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_GetBranches_FullMethodName,
+		FullMethod: MonorepoAdminService_SetWorkspaceQuota_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).GetBranches(ctx, req.(*BranchesRequest))
+		return srv.(MonorepoAdminServiceServer).SetWorkspaceQuota(ctx, req.(*SetWorkspaceQuotaRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_CreateBranch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreateBranchRequest)
+func _MonorepoAdminService_ForceUnlockPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceUnlockPathRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).CreateBranch(ctx, in)
+		return srv.(MonorepoAdminServiceServer).ForceUnlockPath(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_CreateBranch_FullMethodName,
+		FullMethod: MonorepoAdminService_ForceUnlockPath_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).CreateBranch(ctx, req.(*CreateBranchRequest))
+		return srv.(MonorepoAdminServiceServer).ForceUnlockPath(ctx, req.(*ForceUnlockPathRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_CreateWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreateWorkspaceRequest)
+func _MonorepoAdminService_ListWorkspaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkspacesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).CreateWorkspace(ctx, in)
+		return srv.(MonorepoAdminServiceServer).ListWorkspaces(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_CreateWorkspace_FullMethodName,
+		FullMethod: MonorepoAdminService_ListWorkspaces_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).CreateWorkspace(ctx, req.(*CreateWorkspaceRequest))
+		return srv.(MonorepoAdminServiceServer).ListWorkspaces(ctx, req.(*ListWorkspacesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_GetWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetWorkspaceRequest)
+func _MonorepoAdminService_ReapWorkspaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReapWorkspacesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).GetWorkspace(ctx, in)
+		return srv.(MonorepoAdminServiceServer).ReapWorkspaces(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_GetWorkspace_FullMethodName,
+		FullMethod: MonorepoAdminService_ReapWorkspaces_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).GetWorkspace(ctx, req.(*GetWorkspaceRequest))
+		return srv.(MonorepoAdminServiceServer).ReapWorkspaces(ctx, req.(*ReapWorkspacesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_UpdateWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UpdateWorkspaceRequest)
+func _MonorepoAdminService_CollectWorkspaceDirectories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectWorkspaceDirectoriesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).UpdateWorkspace(ctx, in)
+		return srv.(MonorepoAdminServiceServer).CollectWorkspaceDirectories(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_UpdateWorkspace_FullMethodName,
+		FullMethod: MonorepoAdminService_CollectWorkspaceDirectories_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).UpdateWorkspace(ctx, req.(*UpdateWorkspaceRequest))
+		return srv.(MonorepoAdminServiceServer).CollectWorkspaceDirectories(ctx, req.(*CollectWorkspaceDirectoriesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_DeleteWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteWorkspaceRequest)
+func _MonorepoAdminService_CompactWorkspaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactWorkspacesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).DeleteWorkspace(ctx, in)
+		return srv.(MonorepoAdminServiceServer).CompactWorkspaces(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_DeleteWorkspace_FullMethodName,
+		FullMethod: MonorepoAdminService_CompactWorkspaces_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).DeleteWorkspace(ctx, req.(*DeleteWorkspaceRequest))
+		return srv.(MonorepoAdminServiceServer).CompactWorkspaces(ctx, req.(*CompactWorkspacesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_ConfigureSparseCheckout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SparseCheckoutRequest)
+func _MonorepoAdminService_Backup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BackupRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).ConfigureSparseCheckout(ctx, in)
+		return srv.(MonorepoAdminServiceServer).Backup(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_ConfigureSparseCheckout_FullMethodName,
+		FullMethod: MonorepoAdminService_Backup_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).ConfigureSparseCheckout(ctx, req.(*SparseCheckoutRequest))
+		return srv.(MonorepoAdminServiceServer).Backup(ctx, req.(*BackupRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_DownloadPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DownloadPathRequest)
+func _MonorepoAdminService_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).DownloadPath(ctx, in)
+		return srv.(MonorepoAdminServiceServer).Restore(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_DownloadPath_FullMethodName,
+		FullMethod: MonorepoAdminService_Restore_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).DownloadPath(ctx, req.(*DownloadPathRequest))
+		return srv.(MonorepoAdminServiceServer).Restore(ctx, req.(*RestoreRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _MonorepoService_AddTrackedPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddTrackedPathRequest)
+func _MonorepoAdminService_MigrateBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MigrateBackendRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MonorepoServiceServer).AddTrackedPath(ctx, in)
+		return srv.(MonorepoAdminServiceServer).MigrateBackend(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: MonorepoService_AddTrackedPath_FullMethodName,
+		FullMethod: MonorepoAdminService_MigrateBackend_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MonorepoServiceServer).AddTrackedPath(ctx, req.(*AddTrackedPathRequest))
+		return srv.(MonorepoAdminServiceServer).MigrateBackend(ctx, req.(*MigrateBackendRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// MonorepoService_ServiceDesc is the grpc.ServiceDesc for MonorepoService service.
+func _MonorepoAdminService_RehashObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RehashObjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).RehashObjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_RehashObjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).RehashObjects(ctx, req.(*RehashObjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_PruneHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PruneHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).PruneHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_PruneHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).PruneHistory(ctx, req.(*PruneHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_ListCorruptObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCorruptObjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).ListCorruptObjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_ListCorruptObjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).ListCorruptObjects(ctx, req.(*ListCorruptObjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_GetReplicationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReplicationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).GetReplicationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_GetReplicationStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).GetReplicationStatus(ctx, req.(*GetReplicationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_FailoverBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FailoverBackendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).FailoverBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_FailoverBackend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).FailoverBackend(ctx, req.(*FailoverBackendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_ResyncReplica_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResyncReplicaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).ResyncReplica(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_ResyncReplica_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).ResyncReplica(ctx, req.(*ResyncReplicaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_GetOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).GetOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_GetOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).GetOperation(ctx, req.(*GetOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_WaitOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WaitOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).WaitOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_WaitOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).WaitOperation(ctx, req.(*WaitOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_CancelOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).CancelOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_CancelOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).CancelOperation(ctx, req.(*CancelOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonorepoAdminService_ListOperations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOperationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonorepoAdminServiceServer).ListOperations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonorepoAdminService_ListOperations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonorepoAdminServiceServer).ListOperations(ctx, req.(*ListOperationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MonorepoAdminService_ServiceDesc is the grpc.ServiceDesc for MonorepoAdminService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
-var MonorepoService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "monorepo.MonorepoService",
-	HandlerType: (*MonorepoServiceServer)(nil),
+var MonorepoAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "monorepo.MonorepoAdminService",
+	HandlerType: (*MonorepoAdminServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "MergePatch",
-			Handler:    _MonorepoService_MergePatch_Handler,
+			MethodName: "RunGarbageCollection",
+			Handler:    _MonorepoAdminService_RunGarbageCollection_Handler,
 		},
 		{
-			MethodName: "ReadDirectory",
-			Handler:    _MonorepoService_ReadDirectory_Handler,
+			MethodName: "Fsck",
+			Handler:    _MonorepoAdminService_Fsck_Handler,
 		},
 		{
-			MethodName: "ReadFile",
-			Handler:    _MonorepoService_ReadFile_Handler,
+			MethodName: "GetBackendStats",
+			Handler:    _MonorepoAdminService_GetBackendStats_Handler,
 		},
 		{
-			MethodName: "GetFileHistory",
-			Handler:    _MonorepoService_GetFileHistory_Handler,
+			MethodName: "SetUserQuota",
+			Handler:    _MonorepoAdminService_SetUserQuota_Handler,
 		},
 		{
-			MethodName: "GetBranches",
-			Handler:    _MonorepoService_GetBranches_Handler,
+			MethodName: "SetWorkspaceQuota",
+			Handler:    _MonorepoAdminService_SetWorkspaceQuota_Handler,
 		},
 		{
-			MethodName: "CreateBranch",
-			Handler:    _MonorepoService_CreateBranch_Handler,
+			MethodName: "ForceUnlockPath",
+			Handler:    _MonorepoAdminService_ForceUnlockPath_Handler,
 		},
 		{
-			MethodName: "CreateWorkspace",
-			Handler:    _MonorepoService_CreateWorkspace_Handler,
+			MethodName: "ListWorkspaces",
+			Handler:    _MonorepoAdminService_ListWorkspaces_Handler,
 		},
 		{
-			MethodName: "GetWorkspace",
-			Handler:    _MonorepoService_GetWorkspace_Handler,
+			MethodName: "ReapWorkspaces",
+			Handler:    _MonorepoAdminService_ReapWorkspaces_Handler,
 		},
 		{
-			MethodName: "UpdateWorkspace",
-			Handler:    _MonorepoService_UpdateWorkspace_Handler,
+			MethodName: "CollectWorkspaceDirectories",
+			Handler:    _MonorepoAdminService_CollectWorkspaceDirectories_Handler,
 		},
 		{
-			MethodName: "DeleteWorkspace",
-			Handler:    _MonorepoService_DeleteWorkspace_Handler,
+			MethodName: "CompactWorkspaces",
+			Handler:    _MonorepoAdminService_CompactWorkspaces_Handler,
 		},
 		{
-			MethodName: "ConfigureSparseCheckout",
-			Handler:    _MonorepoService_ConfigureSparseCheckout_Handler,
+			MethodName: "Backup",
+			Handler:    _MonorepoAdminService_Backup_Handler,
 		},
 		{
-			MethodName: "DownloadPath",
-			Handler:    _MonorepoService_DownloadPath_Handler,
+			MethodName: "Restore",
+			Handler:    _MonorepoAdminService_Restore_Handler,
 		},
 		{
-			MethodName: "AddTrackedPath",
-			Handler:    _MonorepoService_AddTrackedPath_Handler,
+			MethodName: "MigrateBackend",
+			Handler:    _MonorepoAdminService_MigrateBackend_Handler,
+		},
+		{
+			MethodName: "RehashObjects",
+			Handler:    _MonorepoAdminService_RehashObjects_Handler,
+		},
+		{
+			MethodName: "PruneHistory",
+			Handler:    _MonorepoAdminService_PruneHistory_Handler,
+		},
+		{
+			MethodName: "ListCorruptObjects",
+			Handler:    _MonorepoAdminService_ListCorruptObjects_Handler,
+		},
+		{
+			MethodName: "GetReplicationStatus",
+			Handler:    _MonorepoAdminService_GetReplicationStatus_Handler,
+		},
+		{
+			MethodName: "FailoverBackend",
+			Handler:    _MonorepoAdminService_FailoverBackend_Handler,
+		},
+		{
+			MethodName: "ResyncReplica",
+			Handler:    _MonorepoAdminService_ResyncReplica_Handler,
+		},
+		{
+			MethodName: "GetOperation",
+			Handler:    _MonorepoAdminService_GetOperation_Handler,
+		},
+		{
+			MethodName: "WaitOperation",
+			Handler:    _MonorepoAdminService_WaitOperation_Handler,
+		},
+		{
+			MethodName: "CancelOperation",
+			Handler:    _MonorepoAdminService_CancelOperation_Handler,
+		},
+		{
+			MethodName: "ListOperations",
+			Handler:    _MonorepoAdminService_ListOperations_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// runCommand handles the maintenance subcommands of the poon-server binary.
+// They talk to a running server's admin API (ADMIN_ADDR) with the token in
+// POON_ADMIN_TOKEN; paths are resolved on the server host.
+func runCommand(name string, args []string) error {
+	flags := flag.NewFlagSet("poon-server "+name, flag.ContinueOnError)
+	adminAddr := flags.String("admin", defaultAdminAddr(), "Admin API address of the running server")
+
+	switch name {
+	case "backup":
+		to := flags.String("to", "", "Backup destination: a directory or s3://bucket/prefix")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *to == "" {
+			return fmt.Errorf("--to is required")
+		}
+
+		return withAdminClient(*adminAddr, func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.Backup(ctx, &pb.BackupRequest{Destination: *to})
+			if err != nil {
+				return fmt.Errorf("backup failed: %v", err)
+			}
+			fmt.Printf("✓ Snapshot %s written to %s\n", resp.Snapshot, *to)
+			fmt.Printf("  %d objects, %d new (%d bytes written)\n", resp.Objects, resp.ObjectsWritten, resp.BytesWritten)
+			return nil
+		})
+
+	case "restore":
+		from := flags.String("from", "", "Backup source: a directory or s3://bucket/prefix")
+		snapshot := flags.String("snapshot", "", "Snapshot ID to restore (default: latest)")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *from == "" {
+			return fmt.Errorf("--from is required")
+		}
+
+		return withAdminClient(*adminAddr, func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.Restore(ctx, &pb.RestoreRequest{Source: *from, Snapshot: *snapshot})
+			if err != nil {
+				return fmt.Errorf("restore failed: %v", err)
+			}
+			fmt.Printf("✓ Restored snapshot %s from %s\n", resp.Snapshot, *from)
+			fmt.Printf("  Version %d, %d objects, %d workspaces\n", resp.CurrentVersion, resp.Objects, resp.Workspaces)
+			return nil
+		})
+
+	case "migrate":
+		to := flags.String("to", "", "Destination backend: a directory or s3://bucket/prefix")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *to == "" {
+			return fmt.Errorf("--to is required")
+		}
+
+		return withAdminClient(*adminAddr, func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.MigrateBackend(ctx, &pb.MigrateBackendRequest{Destination: *to})
+			if err != nil {
+				return fmt.Errorf("migration failed (re-run to resume): %v", err)
+			}
+			fmt.Printf("✓ Copied repository to %s\n", *to)
+			fmt.Printf("  %d objects (%d new), %d metadata keys, %d stale keys removed, %d bytes copied\n",
+				resp.Objects, resp.ObjectsCopied, resp.Metadata, resp.MetadataRemoved, resp.BytesCopied)
+			fmt.Printf("  Restart the server with STORAGE_BACKEND=%s to switch over\n", *to)
+			return nil
+		})
+
+	case "rehash":
+		algorithm := flags.String("algorithm", "", "Hash algorithm to rewrite objects with: sha256 or blake3 (default: keep the repository's)")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+
+		return withAdminClient(*adminAddr, func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error {
+			resp, err := admin.RehashObjects(ctx, &pb.RehashObjectsRequest{Algorithm: *algorithm})
+			if err != nil {
+				return fmt.Errorf("rehash failed (re-run to resume): %v", err)
+			}
+			fmt.Printf("✓ Rehashed repository with %s in the %s format\n", resp.Algorithm, resp.HashFormat)
+			fmt.Printf("  %d objects rewritten (%d already used both), %d versions and %d trash entries updated\n",
+				resp.Objects, resp.Unchanged, resp.Versions, resp.TrashEntries)
+			if resp.Objects > 0 {
+				fmt.Println("  Run 'poon admin gc' to remove the old objects")
+			}
+			return nil
+		})
+
+	default:
+		return fmt.Errorf("unknown command %q (available: backup, restore, migrate, rehash)", name)
+	}
+}
+
+func defaultAdminAddr() string {
+	if addr := os.Getenv("ADMIN_ADDR"); addr != "" {
+		return addr
+	}
+	return "localhost:50052"
+}
+
+// withAdminClient dials the admin API and runs fn with an authenticated context
+func withAdminClient(addr string, fn func(ctx context.Context, admin pb.MonorepoAdminServiceClient) error) error {
+	token := os.Getenv("POON_ADMIN_TOKEN")
+	if token == "" {
+		return fmt.Errorf("POON_ADMIN_TOKEN is not set")
+	}
+
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to admin API: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	return fn(ctx, pb.NewMonorepoAdminServiceClient(conn))
+}
@@ -0,0 +1,259 @@
+// Package highlight splits source lines into keyword, string, comment and
+// number tokens and renders them as HTML or ANSI-colored text. It is a small
+// lexer, not a parser: it knows each language's keywords, comments and
+// quotes, which is enough for file previews.
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Class is the kind of a token
+type Class string
+
+const (
+	Text    Class = ""
+	Keyword Class = "keyword"
+	String  Class = "string"
+	Comment Class = "comment"
+	Number  Class = "number"
+)
+
+// Token is a run of text of one class
+type Token struct {
+	Class Class
+	Text  string
+}
+
+// Lexer tokenizes the lines of one file in order, carrying comments and
+// strings that span lines from one line to the next
+type Lexer struct {
+	lang      *Language
+	keywords  map[string]bool
+	inComment bool   // Inside a block comment
+	inString  string // Closing quote of a string that spans lines
+}
+
+// NewLexer returns a lexer for a language. A nil language yields plain text.
+func NewLexer(lang *Language) *Lexer {
+	l := &Lexer{lang: lang, keywords: make(map[string]bool)}
+	if lang != nil {
+		for _, keyword := range lang.Keywords {
+			l.keywords[keyword] = true
+		}
+	}
+	return l
+}
+
+// Line tokenizes the next line of the file, without its line ending
+func (l *Lexer) Line(line string) []Token {
+	var tokens tokenList
+	if l.lang == nil {
+		tokens.add(Text, line)
+		return tokens
+	}
+
+	i := 0
+	if l.inComment {
+		end := strings.Index(line, l.lang.BlockComment[1])
+		if end < 0 {
+			tokens.add(Comment, line)
+			return tokens
+		}
+		i = end + len(l.lang.BlockComment[1])
+		tokens.add(Comment, line[:i])
+		l.inComment = false
+	}
+	if l.inString != "" {
+		end, closed := l.closeString(line, 0, l.inString)
+		tokens.add(String, line[:end])
+		if !closed {
+			return tokens
+		}
+		i = end
+		l.inString = ""
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+		if l.lineComment(line, i) {
+			tokens.add(Comment, rest)
+			break
+		}
+		if open := l.lang.BlockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			end := strings.Index(rest[len(open):], l.lang.BlockComment[1])
+			if end < 0 {
+				tokens.add(Comment, rest)
+				l.inComment = true
+				break
+			}
+			n := len(open) + end + len(l.lang.BlockComment[1])
+			tokens.add(Comment, rest[:n])
+			i += n
+			continue
+		}
+		if quote := l.openQuote(rest); quote != "" {
+			end, closed := l.closeString(line, i+len(quote), quote)
+			tokens.add(String, line[i:end])
+			if !closed {
+				if l.multiline(quote) {
+					l.inString = quote
+				}
+				break
+			}
+			i = end
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case unicode.IsDigit(r) && (i == 0 || !isIdent(line[i-1])):
+			n := 0
+			for n < len(rest) && (isIdent(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			tokens.add(Number, rest[:n])
+			i += n
+		case r == '_' || unicode.IsLetter(r):
+			n := 0
+			for n < len(rest) {
+				r, size := utf8.DecodeRuneInString(rest[n:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				n += size
+			}
+			word := rest[:n]
+			if l.keywords[word] {
+				tokens.add(Keyword, word)
+			} else {
+				tokens.add(Text, word)
+			}
+			i += n
+		default:
+			tokens.add(Text, rest[:size])
+			i += size
+		}
+	}
+	return tokens
+}
+
+// lineComment reports whether a line comment starts at i. "#" only starts
+// one at the start of a word, so "a#b" in a URL is not a comment.
+func (l *Lexer) lineComment(line string, i int) bool {
+	for _, prefix := range l.lang.LineComments {
+		if !strings.HasPrefix(line[i:], prefix) {
+			continue
+		}
+		if prefix == "#" && i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// openQuote returns the quote a string starts with at the start of s
+func (l *Lexer) openQuote(s string) string {
+	if l.lang.TripleQuotes {
+		for _, quote := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(s, quote) {
+				return quote
+			}
+		}
+	}
+	if s != "" && strings.IndexByte(l.lang.Quotes, s[0]) >= 0 {
+		return s[:1]
+	}
+	return ""
+}
+
+// closeString returns the end of the string closed by quote at or after
+// start, or the end of the line if it is not closed there
+func (l *Lexer) closeString(line string, start int, quote string) (int, bool) {
+	raw := len(quote) == 1 && strings.Contains(l.lang.RawQuotes, quote)
+	for i := start; i < len(line); i++ {
+		if line[i] == '\\' && !raw {
+			i++
+			continue
+		}
+		if strings.HasPrefix(line[i:], quote) {
+			return i + len(quote), true
+		}
+	}
+	return len(line), false
+}
+
+func (l *Lexer) multiline(quote string) bool {
+	return len(quote) == 3 || strings.Contains(l.lang.MultilineQuotes, quote)
+}
+
+func isIdent(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// tokenList merges adjacent tokens of the same class
+type tokenList []Token
+
+func (t *tokenList) add(class Class, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(*t); n > 0 && (*t)[n-1].Class == class {
+		(*t)[n-1].Text += text
+		return
+	}
+	*t = append(*t, Token{Class: class, Text: text})
+}
+
+// Format is how highlighted lines are rendered
+type Format string
+
+const (
+	FormatNone Format = ""     // Plain text
+	FormatHTML Format = "html" // Escaped HTML, tokens in <span class="tok-CLASS">
+	FormatANSI Format = "ansi" // Terminal colors
+)
+
+// ParseFormat parses a format name; "none" and "" are plain text
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatNone, "none":
+		return FormatNone, nil
+	case FormatHTML:
+		return FormatHTML, nil
+	case FormatANSI:
+		return FormatANSI, nil
+	}
+	return FormatNone, fmt.Errorf("unknown highlight format %q (use none, html or ansi)", name)
+}
+
+// ansiColors are the escape sequences each class is written in
+var ansiColors = map[Class]string{
+	Keyword: "\x1b[34m",
+	String:  "\x1b[32m",
+	Comment: "\x1b[90m",
+	Number:  "\x1b[35m",
+}
+
+// Render writes tokens in the format
+func (f Format) Render(tokens []Token) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		switch {
+		case f == FormatHTML && token.Class != Text:
+			fmt.Fprintf(&b, `<span class="tok-%s">%s</span>`, token.Class, html.EscapeString(token.Text))
+		case f == FormatHTML:
+			b.WriteString(html.EscapeString(token.Text))
+		case f == FormatANSI && token.Class != Text:
+			b.WriteString(ansiColors[token.Class] + token.Text + "\x1b[0m")
+		default:
+			b.WriteString(token.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package highlight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexer(t *testing.T) {
+	lexer := NewLexer(Lookup("go"))
+
+	assert.Equal(t, []Token{
+		{Keyword, "func"},
+		{Text, " main() { x := "},
+		{Number, "42"},
+		{Text, " "},
+		{Comment, "// answer"},
+	}, lexer.Line("func main() { x := 42 // answer"))
+
+	assert.Equal(t, []Token{
+		{Text, "s := "},
+		{String, `"a \"quoted\" word"`},
+		{Text, " + v2"},
+	}, lexer.Line(`s := "a \"quoted\" word" + v2`))
+
+	// Block comments and raw strings carry over to the next lines
+	assert.Equal(t, []Token{{Text, "x "}, {Comment, "/* one"}}, lexer.Line("x /* one"))
+	assert.Equal(t, []Token{{Comment, "two */"}, {Text, " "}, {Keyword, "return"}}, lexer.Line("two */ return"))
+	assert.Equal(t, []Token{{Text, "q := "}, {String, "`raw \\"}}, lexer.Line("q := `raw \\"))
+	assert.Equal(t, []Token{{String, "end`"}, {Text, " "}, {Keyword, "nil"}}, lexer.Line("end` nil"))
+}
+
+func TestLexerLanguages(t *testing.T) {
+	python := NewLexer(Detect("tools/build.py"))
+	assert.Equal(t, []Token{{Text, "doc = "}, {String, `"""Start`}}, python.Line(`doc = """Start`))
+	assert.Equal(t, []Token{{String, `end"""`}, {Text, " "}, {Comment, "# done"}}, python.Line(`end""" # done`))
+
+	yaml := NewLexer(Detect("config/app.yml"))
+	assert.Equal(t, []Token{{Text, "url: http://host/a#b "}, {Comment, "# comment"}}, yaml.Line("url: http://host/a#b # comment"))
+
+	shell := NewLexer(Detect("docker/Dockerfile"))
+	assert.Equal(t, []Token{{Comment, "# base image"}}, shell.Line("# base image"))
+
+	plain := NewLexer(Detect("notes.txt"))
+	assert.Equal(t, []Token{{Text, "if 1 // x"}}, plain.Line("if 1 // x"))
+	assert.Nil(t, Lookup("cobol"))
+}
+
+func TestRender(t *testing.T) {
+	tokens := []Token{{Keyword, "if"}, {Text, " a < b "}, {String, `"<b>"`}}
+
+	assert.Equal(t, `if a < b "<b>"`, FormatNone.Render(tokens))
+	assert.Equal(t, `<span class="tok-keyword">if</span> a &lt; b <span class="tok-string">&#34;&lt;b&gt;&#34;</span>`, FormatHTML.Render(tokens))
+	assert.Equal(t, "\x1b[34mif\x1b[0m a < b \x1b[32m\"<b>\"\x1b[0m", FormatANSI.Render(tokens))
+
+	for name, want := range map[string]Format{"": FormatNone, "none": FormatNone, "HTML": FormatHTML, "ansi": FormatANSI} {
+		format, err := ParseFormat(name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, format)
+	}
+	_, err := ParseFormat("latex")
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package highlight
+
+import (
+	"path"
+	"strings"
+)
+
+// Language describes what the lexer needs to know about a language
+type Language struct {
+	Name            string
+	Extensions      []string  // File extensions, with the dot
+	Filenames       []string  // Whole file names, such as "Dockerfile"
+	Keywords        []string  // Keywords and literals such as true and nil
+	LineComments    []string  // Prefixes of comments that run to the end of the line
+	BlockComment    [2]string // Delimiters of block comments, if any
+	Quotes          string    // Characters that open and close strings
+	RawQuotes       string    // Quotes whose strings have no backslash escapes
+	MultilineQuotes string    // Quotes whose strings may span lines
+	TripleQuotes    bool      // """ and ''' open strings that may span lines
+}
+
+var cKeywords = []string{
+	"auto", "break", "case", "char", "const", "continue", "default", "do", "double", "else", "enum",
+	"extern", "float", "for", "goto", "if", "inline", "int", "long", "register", "return", "short",
+	"signed", "sizeof", "static", "struct", "switch", "typedef", "union", "unsigned", "void",
+	"volatile", "while", "NULL", "true", "false",
+}
+
+var jsKeywords = []string{
+	"async", "await", "break", "case", "catch", "class", "const", "continue", "debugger", "default",
+	"delete", "do", "else", "export", "extends", "finally", "for", "from", "function", "if",
+	"import", "in", "instanceof", "let", "new", "of", "return", "static", "super", "switch", "this",
+	"throw", "try", "typeof", "var", "void", "while", "yield", "true", "false", "null", "undefined",
+}
+
+// Languages are the languages the lexer knows, by name
+var Languages = []*Language{
+	{
+		Name:       "go",
+		Extensions: []string{".go"},
+		Keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+			"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+			"return", "select", "struct", "switch", "type", "var", "true", "false", "nil", "iota",
+		},
+		LineComments:    []string{"//"},
+		BlockComment:    [2]string{"/*", "*/"},
+		Quotes:          "\"'`",
+		RawQuotes:       "`",
+		MultilineQuotes: "`",
+	},
+	{
+		Name:            "javascript",
+		Extensions:      []string{".js", ".jsx", ".mjs", ".cjs"},
+		Keywords:        jsKeywords,
+		LineComments:    []string{"//"},
+		BlockComment:    [2]string{"/*", "*/"},
+		Quotes:          "\"'`",
+		MultilineQuotes: "`",
+	},
+	{
+		Name:       "typescript",
+		Extensions: []string{".ts", ".tsx", ".mts", ".cts"},
+		Keywords: append([]string{
+			"abstract", "as", "declare", "enum", "implements", "interface", "keyof", "namespace",
+			"private", "protected", "public", "readonly", "type",
+		}, jsKeywords...),
+		LineComments:    []string{"//"},
+		BlockComment:    [2]string{"/*", "*/"},
+		Quotes:          "\"'`",
+		MultilineQuotes: "`",
+	},
+	{
+		Name:       "python",
+		Extensions: []string{".py", ".pyi"},
+		Filenames:  []string{"BUILD", "WORKSPACE", "BUILD.bazel"},
+		Keywords: []string{
+			"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del",
+			"elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is",
+			"lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with",
+			"yield", "True", "False", "None",
+		},
+		LineComments: []string{"#"},
+		Quotes:       "\"'",
+		TripleQuotes: true,
+	},
+	{
+		Name:       "shell",
+		Extensions: []string{".sh", ".bash", ".zsh"},
+		Filenames:  []string{"Dockerfile", "Makefile", ".bashrc", ".profile"},
+		Keywords: []string{
+			"case", "do", "done", "elif", "else", "esac", "export", "fi", "for", "function", "if",
+			"in", "local", "return", "then", "until", "while",
+		},
+		LineComments:    []string{"#"},
+		Quotes:          "\"'",
+		RawQuotes:       "'",
+		MultilineQuotes: "\"'",
+	},
+	{
+		Name:         "c",
+		Extensions:   []string{".c", ".h"},
+		Keywords:     cKeywords,
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'",
+	},
+	{
+		Name:       "cpp",
+		Extensions: []string{".cc", ".cpp", ".cxx", ".hh", ".hpp"},
+		Keywords: append([]string{
+			"auto", "bool", "catch", "class", "constexpr", "delete", "explicit", "friend", "mutable",
+			"namespace", "new", "noexcept", "nullptr", "operator", "override", "private", "protected",
+			"public", "template", "this", "throw", "try", "typename", "using", "virtual",
+		}, cKeywords...),
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'",
+	},
+	{
+		Name:       "java",
+		Extensions: []string{".java", ".kt"},
+		Keywords: []string{
+			"abstract", "boolean", "break", "byte", "case", "catch", "char", "class", "continue",
+			"default", "do", "double", "else", "enum", "extends", "final", "finally", "float", "for",
+			"if", "implements", "import", "instanceof", "int", "interface", "long", "new", "package",
+			"private", "protected", "public", "return", "short", "static", "super", "switch", "this",
+			"throw", "throws", "try", "void", "while", "true", "false", "null",
+		},
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'",
+	},
+	{
+		Name:       "rust",
+		Extensions: []string{".rs"},
+		Keywords: []string{
+			"as", "async", "await", "break", "const", "continue", "crate", "else", "enum", "extern",
+			"fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub",
+			"ref", "return", "self", "Self", "static", "struct", "super", "trait", "type", "unsafe",
+			"use", "where", "while", "true", "false",
+		},
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		// Not "'", which also starts lifetimes
+		Quotes:          "\"",
+		MultilineQuotes: "\"",
+	},
+	{
+		Name:       "proto",
+		Extensions: []string{".proto"},
+		Keywords: []string{
+			"enum", "import", "map", "message", "oneof", "option", "optional", "package", "repeated",
+			"reserved", "returns", "rpc", "service", "stream", "syntax", "true", "false",
+		},
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'",
+	},
+	{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Keywords:   []string{"true", "false", "null"},
+		Quotes:     "\"",
+	},
+	{
+		Name:         "yaml",
+		Extensions:   []string{".yaml", ".yml"},
+		Keywords:     []string{"true", "false", "null", "yes", "no", "on", "off"},
+		LineComments: []string{"#"},
+		Quotes:       "\"'",
+		RawQuotes:    "'",
+	},
+}
+
+// Lookup returns the language with a name, ignoring case, or nil
+func Lookup(name string) *Language {
+	for _, lang := range Languages {
+		if strings.EqualFold(lang.Name, name) {
+			return lang
+		}
+	}
+	return nil
+}
+
+// Detect returns the language of a file from its name, or nil
+func Detect(filePath string) *Language {
+	base := path.Base(filePath)
+	ext := strings.ToLower(path.Ext(base))
+	for _, lang := range Languages {
+		for _, name := range lang.Filenames {
+			if base == name {
+				return lang
+			}
+		}
+		for _, e := range lang.Extensions {
+			if ext == e {
+				return lang
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// maxInlineSpan bounds how far code spans, emphasis and link text may run,
+// so each delimiter looks only that far for its closer and rendering stays
+// linear in the size of a document however many delimiters go unclosed
+const maxInlineSpan = 1024
+
+var (
+	autolinkRe = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+	emailRe    = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+	bareURLRe  = regexp.MustCompile(`^https?://[^\s<]+`)
+	entityRe   = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+)
+
+// safeURL reports whether a link or image URL may be put in the page:
+// relative URLs and http, https and mailto ones
+func safeURL(url string) bool {
+	// Browsers skip whitespace and control characters in a scheme, so
+	// "java\tscript:" is looked at as "javascript:"
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 {
+		return true
+	}
+	// A colon after a slash, ? or # is in a relative URL's path or query
+	if i := strings.IndexAny(cleaned, "/?#"); i >= 0 && i < colon {
+		return true
+	}
+	switch strings.ToLower(cleaned[:colon]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// inline renders the inline text of a paragraph, heading or table cell
+func (r *renderer) inline(s string) string {
+	var b strings.Builder
+	r.writeInline(&b, strings.TrimRight(s, " "))
+	return b.String()
+}
+
+func (r *renderer) writeInline(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br />\n")
+			i += 2
+
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+
+		case c == ' ':
+			// Two or more spaces at the end of a line break it
+			j := i
+			for j < len(s) && s[j] == ' ' {
+				j++
+			}
+			switch {
+			case j < len(s) && s[j] == '\n' && j-i >= 2:
+				b.WriteString("<br />\n")
+				j++
+			case j < len(s) && s[j] == '\n':
+				b.WriteString("\n")
+				j++
+			default:
+				b.WriteString(s[i:j])
+			}
+			i = j
+
+		case c == '`':
+			n := runLength(s, i)
+			end := closingCode(s, i+n, n)
+			if end < 0 {
+				b.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			fmt.Fprintf(b, "<code>%s</code>", html.EscapeString(code))
+			i = end + n
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			text, url, title, end, ok := r.parseLink(s, i+1)
+			if !ok {
+				b.WriteByte('!')
+				i++
+				continue
+			}
+			r.writeImage(b, text, url, title)
+			i = end
+
+		case c == '[' && !r.inLink:
+			text, url, title, end, ok := r.parseLink(s, i)
+			if !ok {
+				b.WriteByte('[')
+				i++
+				continue
+			}
+			r.writeLink(b, text, url, title)
+			i = end
+
+		case c == '<':
+			if m := autolinkRe.FindStringSubmatch(s[i:]); m != nil && !r.inLink && safeURL(m[1]) {
+				r.writeAutolink(b, m[1], m[1])
+				i += len(m[0])
+			} else if m := emailRe.FindStringSubmatch(s[i:]); m != nil && !r.inLink {
+				r.writeAutolink(b, m[1], "mailto:"+m[1])
+				i += len(m[0])
+			} else {
+				b.WriteString("&lt;")
+				i++
+			}
+
+		case c == '&':
+			if m := entityRe.FindString(s[i:]); m != "" {
+				b.WriteString(html.EscapeString(html.UnescapeString(m)))
+				i += len(m)
+			} else {
+				b.WriteString("&amp;")
+				i++
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if end, ok := r.emphasis(b, s, i); ok {
+				i = end
+			} else {
+				n := runLength(s, i)
+				b.WriteString(s[i : i+n])
+				i += n
+			}
+
+		case c == 'h' && !r.inLink && (i == 0 || !isWordByte(s[i-1])) && bareURLRe.MatchString(s[i:]):
+			url := trimURL(bareURLRe.FindString(s[i:]))
+			r.writeAutolink(b, url, url)
+			i += len(url)
+
+		default:
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			i++
+		}
+	}
+}
+
+// emphasis renders the emphasis, strong emphasis or strikethrough opened by
+// the delimiter run at s[i], returning where it ends, or false when nothing
+// closes it
+func (r *renderer) emphasis(b *strings.Builder, s string, i int) (int, bool) {
+	c := s[i]
+	n := runLength(s, i)
+	if n > 3 || c == '~' && n != 2 {
+		return i, false
+	}
+	// Openers come before text, and underscores in words are not emphasis
+	if i+n >= len(s) || isSpace(s[i+n]) || c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return i, false
+	}
+
+	for j := i + n; j < len(s) && j-i <= maxInlineSpan; {
+		switch {
+		case s[j] == '\\':
+			j += 2
+		case s[j] == '`':
+			m := runLength(s, j)
+			if end := closingCode(s, j+m, m); end >= 0 {
+				j = end + m
+			} else {
+				j += m
+			}
+		case s[j] == c:
+			m := runLength(s, j)
+			if m == n && !isSpace(s[j-1]) && (c != '_' || j+m >= len(s) || !isWordByte(s[j+m])) {
+				open, close := "<em>", "</em>"
+				switch {
+				case c == '~':
+					open, close = "<del>", "</del>"
+				case n == 2:
+					open, close = "<strong>", "</strong>"
+				case n == 3:
+					open, close = "<em><strong>", "</strong></em>"
+				}
+				b.WriteString(open)
+				r.writeInline(b, s[i+n:j])
+				b.WriteString(close)
+				return j + m, true
+			}
+			j += m
+		default:
+			j++
+		}
+	}
+	return i, false
+}
+
+// parseLink parses the link whose text opens at s[i]: an inline link, a
+// full, collapsed or shortcut reference, returning where it ends
+func (r *renderer) parseLink(s string, i int) (text, url, title string, end int, ok bool) {
+	depth := 0
+	j := i
+loop:
+	for ; j < len(s) && j-i <= maxInlineSpan; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			m := runLength(s, j)
+			if close := closingCode(s, j+m, m); close >= 0 {
+				j = close + m - 1
+			} else {
+				j += m - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				break loop
+			}
+		}
+	}
+	if j >= len(s) || s[j] != ']' {
+		return "", "", "", 0, false
+	}
+	text = s[i+1 : j]
+	j++
+
+	if j < len(s) && s[j] == '(' {
+		if url, title, end, ok := parseDestination(s, j+1); ok {
+			return text, url, title, end, true
+		}
+	}
+
+	label := text
+	if j < len(s) && s[j] == '[' {
+		k := strings.IndexByte(s[j+1:], ']')
+		if k < 0 {
+			return "", "", "", 0, false
+		}
+		if k > 0 {
+			label = s[j+1 : j+1+k]
+		}
+		j += k + 2
+	}
+	if ref, found := r.refs[normalizeLabel(label)]; found {
+		return text, ref.url, ref.title, j, true
+	}
+	return "", "", "", 0, false
+}
+
+// parseDestination parses the URL and optional title of an inline link,
+// from just after its opening parenthesis to just after its closing one
+func parseDestination(s string, i int) (url, title string, end int, ok bool) {
+	skipSpace := func() {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+	}
+
+	skipSpace()
+	if i < len(s) && s[i] == '<' {
+		k := strings.IndexAny(s[i+1:], ">\n")
+		if k < 0 || s[i+1+k] != '>' {
+			return "", "", 0, false
+		}
+		url = s[i+1 : i+1+k]
+		i += k + 2
+	} else {
+		start, depth := i, 0
+		for ; i < len(s) && !isSpace(s[i]); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		url = s[start:min(i, len(s))]
+	}
+
+	skipSpace()
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		k := strings.IndexByte(s[i+1:], closer)
+		if k < 0 {
+			return "", "", 0, false
+		}
+		title = s[i+1 : i+1+k]
+		i += k + 2
+		skipSpace()
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0, false
+	}
+	return unescape(url), unescape(title), i + 1, true
+}
+
+// writeLink writes a link, or only its text when its URL is not safe
+func (r *renderer) writeLink(b *strings.Builder, text, url, title string) {
+	if !safeURL(url) {
+		r.writeInline(b, text)
+		return
+	}
+	openLink(b, url, title)
+	r.inLink = true
+	r.writeInline(b, text)
+	r.inLink = false
+	b.WriteString("</a>")
+}
+
+// writeAutolink writes a link whose text is shown as it is
+func (r *renderer) writeAutolink(b *strings.Builder, text, url string) {
+	openLink(b, url, "")
+	b.WriteString(html.EscapeString(text))
+	b.WriteString("</a>")
+}
+
+// openLink writes the start tag of a link. Links off the site are marked
+// nofollow, as documents anyone can commit to are not endorsements.
+func openLink(b *strings.Builder, url, title string) {
+	fmt.Fprintf(b, `<a href="%s"`, html.EscapeString(url))
+	if title != "" {
+		fmt.Fprintf(b, ` title="%s"`, html.EscapeString(title))
+	}
+	if lower := strings.ToLower(url); strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		b.WriteString(` rel="nofollow"`)
+	}
+	b.WriteString(">")
+}
+
+// writeImage writes an image, or only its description when its URL is not
+// safe
+func (r *renderer) writeImage(b *strings.Builder, text, url, title string) {
+	var alt strings.Builder
+	r.writeInline(&alt, text)
+	description := plainText(alt.String())
+	if !safeURL(url) {
+		b.WriteString(html.EscapeString(description))
+		return
+	}
+	fmt.Fprintf(b, `<img src="%s" alt="%s"`, html.EscapeString(url), html.EscapeString(description))
+	if title != "" {
+		fmt.Fprintf(b, ` title="%s"`, html.EscapeString(title))
+	}
+	b.WriteString(" />")
+}
+
+// closingCode returns where the backtick run of length n that closes a code
+// span starts, searching from i, or -1
+func closingCode(s string, i, n int) int {
+	for start := i; i < len(s) && i-start <= maxInlineSpan; {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		m := runLength(s, i)
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+// runLength returns how many times s[i] repeats from i
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// trimURL drops the punctuation that ends a sentence rather than a bare URL,
+// and closing parentheses the URL did not open
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		if strings.IndexByte(`.,:;!?"'*_~`, last) >= 0 ||
+			last == ')' && strings.Count(url, "(") < strings.Count(url, ")") {
+			url = url[:len(url)-1]
+			continue
+		}
+		break
+	}
+	return url
+}
+
+// unescape removes the backslashes escaping punctuation
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isPunct(c byte) bool {
+	return c < 0x80 && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// isWordByte reports whether c is part of a word; bytes of non-ASCII
+// characters count as letters
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
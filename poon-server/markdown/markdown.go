@@ -0,0 +1,553 @@
+// Package markdown renders the Markdown of READMEs and other documents as
+// HTML that is safe to put in a page. It covers what READMEs use: headings,
+// paragraphs, lists, block quotes, code blocks, tables, links, images and
+// emphasis, roughly as CommonMark and GitHub render them.
+//
+// The output is sanitized by construction rather than by filtering: raw HTML
+// in the source is escaped like any other text, every attribute value is
+// escaped, and links and images are only kept when their URL is relative or
+// http, https or mailto, so a document cannot run script in the page.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nic/poon/poon-server/highlight"
+)
+
+// Result is a rendered document
+type Result struct {
+	HTML  string
+	Title string // Text of the first top-level heading, or of the first heading
+}
+
+// Render renders a Markdown document
+func Render(source []byte) Result {
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(strings.ReplaceAll(line, "\x00", "�"))
+	}
+
+	p := &parser{refs: make(map[string]linkRef)}
+	blocks := p.parse(lines)
+
+	r := &renderer{refs: p.refs, ids: make(map[string]int)}
+	var b strings.Builder
+	r.blocks(&b, blocks, false)
+	return Result{HTML: b.String(), Title: r.title}
+}
+
+// RenderText renders a plain text document as preformatted text
+func RenderText(source []byte) Result {
+	return Result{HTML: "<pre>" + html.EscapeString(string(source)) + "</pre>\n"}
+}
+
+// IsMarkdown reports whether a file name has a Markdown extension
+func IsMarkdown(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".md", ".markdown", ".mdown", ".mkd"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	headingBlock
+	codeBlock
+	quoteBlock
+	listBlock
+	ruleBlock
+	tableBlock
+)
+
+type block struct {
+	kind     blockKind
+	text     string   // Inline text of a paragraph or heading
+	level    int      // Heading level
+	info     string   // Language of a fenced code block
+	lines    []string // Code lines, or table rows of cells joined by "\x00"
+	children []*block // Blocks of a quote
+	items    [][]*block
+	ordered  bool
+	start    int
+	tight    bool
+	align    []string // Table column alignments
+}
+
+type linkRef struct {
+	url, title string
+}
+
+type parser struct {
+	refs map[string]linkRef
+}
+
+var (
+	atxHeadingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	fenceRe        = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*)$")
+	ruleRe         = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextRe       = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	quoteRe        = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemRe     = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])( +|$)`)
+	tableDelimRe   = regexp.MustCompile(`^ *\|? *:?-+:? *(?:\| *:?-+:? *)*\|? *$`)
+	linkRefDefRe   = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?([^\s>]+)>?(?:[ \t]+(?:"([^"]*)"|'([^']*)'|\(([^)]*)\)))?[ \t]*$`)
+	blankRe        = regexp.MustCompile(`^[ \t]*$`)
+	slugStripRe    = regexp.MustCompile(`[^\p{L}\p{N}\- _]`)
+	htmlTagRe      = regexp.MustCompile(`<[^>]*>`)
+	codeLanguageRe = regexp.MustCompile(`[^A-Za-z0-9_+#-]`)
+)
+
+func isBlank(line string) bool {
+	return blankRe.MatchString(line)
+}
+
+// expandTabs turns the tabs in a line's indentation into spaces, to tab
+// stops of four, so indentation can be measured in columns
+func expandTabs(line string) string {
+	col := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			col++
+		case '\t':
+			col += 4 - col%4
+		default:
+			if strings.IndexByte(line[:i], '\t') < 0 {
+				return line
+			}
+			return strings.Repeat(" ", col) + line[i:]
+		}
+	}
+	return line
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// listMarker describes the list item a line starts, if any
+type listMarker struct {
+	ordered bool
+	delim   byte // Bullet character, or '.' or ')' after a number
+	start   int
+	indent  int // Columns before the item's content
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	m := listItemRe.FindStringSubmatch(line)
+	if m == nil {
+		return listMarker{}, false
+	}
+	marker := listMarker{delim: m[2][len(m[2])-1], indent: len(m[0])}
+	if len(m[2]) > 1 || m[2][0] >= '0' && m[2][0] <= '9' {
+		marker.ordered = true
+		marker.start, _ = strconv.Atoi(m[2][:len(m[2])-1])
+	}
+	// Content indented five or more columns past the marker is indented
+	// code inside the item, so the item itself starts one column after it
+	if len(m[3]) > 4 {
+		marker.indent = len(m[1]) + len(m[2]) + 1
+	}
+	if m[3] == "" {
+		marker.indent = len(m[1]) + len(m[2]) + 1
+	}
+	return marker, true
+}
+
+// interruptsParagraph reports whether a line starts a block that ends a
+// paragraph, rather than continuing it
+func interruptsParagraph(line string) bool {
+	if isBlank(line) || atxHeadingRe.MatchString(line) || fenceRe.MatchString(line) ||
+		ruleRe.MatchString(line) || quoteRe.MatchString(line) {
+		return true
+	}
+	// Only lists that say something and, when numbered, start at one
+	if marker, ok := parseListMarker(line); ok {
+		content := strings.TrimSpace(line[min(marker.indent, len(line)):])
+		return content != "" && (!marker.ordered || marker.start == 1)
+	}
+	return false
+}
+
+// parse splits lines into blocks, collecting link reference definitions
+func (p *parser) parse(lines []string) []*block {
+	var blocks []*block
+	var para []string
+
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, &block{kind: paragraphBlock, text: strings.Join(para, "\n")})
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if isBlank(line) {
+			flush()
+			continue
+		}
+
+		// A line under a paragraph made of = or - turns it into a heading
+		if len(para) > 0 {
+			if m := setextRe.FindStringSubmatch(line); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				blocks = append(blocks, &block{kind: headingBlock, level: level, text: strings.TrimSpace(strings.Join(para, "\n"))})
+				para = nil
+				continue
+			}
+			if !interruptsParagraph(line) && !isTableStart(lines, i) {
+				para = append(para, strings.TrimLeft(line, " "))
+				continue
+			}
+			flush()
+		}
+
+		switch {
+		case indentation(line) >= 4:
+			var code []string
+			for ; i < len(lines) && (isBlank(lines[i]) || indentation(lines[i]) >= 4); i++ {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+			}
+			i--
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, &block{kind: codeBlock, lines: code})
+
+		case fenceRe.MatchString(line):
+			m := fenceRe.FindStringSubmatch(line)
+			indent, fence := len(m[1]), m[2]
+			b := &block{kind: codeBlock}
+			if info := strings.Fields(m[3]); len(info) > 0 {
+				b.info = info[0]
+			}
+			for i++; i < len(lines); i++ {
+				trimmed := strings.TrimSpace(lines[i])
+				if indentation(lines[i]) < 4 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+					break
+				}
+				b.lines = append(b.lines, strings.TrimPrefix(lines[i], strings.Repeat(" ", min(indent, indentation(lines[i])))))
+			}
+			blocks = append(blocks, b)
+
+		case atxHeadingRe.MatchString(line):
+			m := atxHeadingRe.FindStringSubmatch(line)
+			blocks = append(blocks, &block{kind: headingBlock, level: len(m[1]), text: strings.TrimSpace(m[2])})
+
+		case ruleRe.MatchString(line):
+			blocks = append(blocks, &block{kind: ruleBlock})
+
+		case quoteRe.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				if loc := quoteRe.FindStringIndex(lines[i]); loc != nil {
+					quoted = append(quoted, lines[i][loc[1]:])
+				} else if !interruptsParagraph(lines[i]) {
+					quoted = append(quoted, lines[i])
+				} else {
+					break
+				}
+			}
+			i--
+			blocks = append(blocks, &block{kind: quoteBlock, children: p.parse(quoted)})
+
+		case isTableStart(lines, i):
+			b := &block{kind: tableBlock}
+			header := splitTableRow(line)
+			for _, cell := range splitTableRow(lines[i+1]) {
+				switch {
+				case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+					b.align = append(b.align, "center")
+				case strings.HasSuffix(cell, ":"):
+					b.align = append(b.align, "right")
+				case strings.HasPrefix(cell, ":"):
+					b.align = append(b.align, "left")
+				default:
+					b.align = append(b.align, "")
+				}
+			}
+			b.lines = append(b.lines, strings.Join(header, "\x00"))
+			for i += 2; i < len(lines) && !interruptsParagraph(lines[i]); i++ {
+				b.lines = append(b.lines, strings.Join(splitTableRow(lines[i]), "\x00"))
+			}
+			i--
+			blocks = append(blocks, b)
+
+		default:
+			if marker, ok := parseListMarker(line); ok {
+				var b *block
+				b, i = p.parseList(lines, i, marker)
+				blocks = append(blocks, b)
+				continue
+			}
+			if m := linkRefDefRe.FindStringSubmatch(line); m != nil {
+				label := normalizeLabel(m[1])
+				if _, ok := p.refs[label]; !ok {
+					p.refs[label] = linkRef{url: m[2], title: m[3] + m[4] + m[5]}
+				}
+				continue
+			}
+			para = append(para, strings.TrimLeft(line, " "))
+		}
+	}
+	flush()
+	return blocks
+}
+
+// parseList parses the list starting at lines[i], returning it and the
+// index of its last line
+func (p *parser) parseList(lines []string, i int, first listMarker) (*block, int) {
+	list := &block{kind: listBlock, ordered: first.ordered, start: first.start, tight: true}
+	marker := first
+	blankBetween := false
+
+	for i < len(lines) {
+		// The item's first line, without its marker
+		line := lines[i]
+		item := []string{line[min(marker.indent, len(line)):]}
+		lastBlank := false
+		i++
+		for ; i < len(lines); i++ {
+			line := lines[i]
+			if isBlank(line) {
+				item = append(item, "")
+				lastBlank = true
+				continue
+			}
+			if indentation(line) >= marker.indent {
+				// Blank lines between the blocks of an item loosen the list
+				if lastBlank {
+					list.tight = false
+				}
+				item = append(item, line[marker.indent:])
+				lastBlank = false
+				continue
+			}
+			if _, ok := parseListMarker(line); ok || lastBlank || interruptsParagraph(line) {
+				break
+			}
+			// A lazy continuation of the item's paragraph
+			item = append(item, line)
+		}
+
+		for len(item) > 0 && item[len(item)-1] == "" {
+			item = item[:len(item)-1]
+		}
+		list.items = append(list.items, p.parse(item))
+		if blankBetween {
+			list.tight = false
+		}
+
+		if i >= len(lines) {
+			break
+		}
+		next, ok := parseListMarker(lines[i])
+		if !ok || next.ordered != first.ordered || next.delim != first.delim {
+			break
+		}
+		blankBetween = lastBlank
+		marker = next
+	}
+	return list, i - 1
+}
+
+// isTableStart reports whether lines[i] is the header row of a table: a row
+// with pipes followed by a delimiter row with as many columns
+func isTableStart(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !tableDelimRe.MatchString(lines[i+1]) {
+		return false
+	}
+	return len(splitTableRow(lines[i])) == len(splitTableRow(lines[i+1]))
+}
+
+// splitTableRow splits a table row into its trimmed cells. Pipes escaped
+// with a backslash or inside code spans do not separate cells.
+func splitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(row); i++ {
+		switch c := row[i]; {
+		case c == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// normalizeLabel folds a link label so references match case-insensitively
+// and whatever their spacing
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+type renderer struct {
+	refs   map[string]linkRef
+	ids    map[string]int // Heading IDs used, to number repeats
+	title  string
+	titled bool // title is from a top-level heading
+	inLink bool // Rendering link text, where links would nest
+}
+
+func (r *renderer) blocks(b *strings.Builder, blocks []*block, tight bool) {
+	for _, blk := range blocks {
+		switch blk.kind {
+		case paragraphBlock:
+			if tight {
+				b.WriteString(r.inline(blk.text))
+				b.WriteString("\n")
+			} else {
+				fmt.Fprintf(b, "<p>%s</p>\n", r.inline(blk.text))
+			}
+
+		case headingBlock:
+			content := r.inline(blk.text)
+			text := plainText(content)
+			if !r.titled && (r.title == "" || blk.level == 1) {
+				r.title, r.titled = text, blk.level == 1
+			}
+			if id := r.headingID(text); id != "" {
+				fmt.Fprintf(b, "<h%d id=\"%s\">%s</h%d>\n", blk.level, html.EscapeString(id), content, blk.level)
+			} else {
+				fmt.Fprintf(b, "<h%d>%s</h%d>\n", blk.level, content, blk.level)
+			}
+
+		case codeBlock:
+			r.code(b, blk)
+
+		case quoteBlock:
+			b.WriteString("<blockquote>\n")
+			r.blocks(b, blk.children, false)
+			b.WriteString("</blockquote>\n")
+
+		case listBlock:
+			tag := "ul"
+			if blk.ordered {
+				tag = "ol"
+			}
+			if blk.ordered && blk.start != 1 {
+				fmt.Fprintf(b, "<%s start=\"%d\">\n", tag, blk.start)
+			} else {
+				fmt.Fprintf(b, "<%s>\n", tag)
+			}
+			for _, item := range blk.items {
+				b.WriteString("<li>")
+				if !blk.tight {
+					b.WriteString("\n")
+				}
+				var content strings.Builder
+				r.blocks(&content, item, blk.tight)
+				b.WriteString(strings.TrimSuffix(content.String(), "\n"))
+				if !blk.tight {
+					b.WriteString("\n")
+				}
+				b.WriteString("</li>\n")
+			}
+			fmt.Fprintf(b, "</%s>\n", tag)
+
+		case ruleBlock:
+			b.WriteString("<hr />\n")
+
+		case tableBlock:
+			r.table(b, blk)
+		}
+	}
+}
+
+// code renders a code block, highlighting it when its language is known
+func (r *renderer) code(b *strings.Builder, blk *block) {
+	language := codeLanguageRe.ReplaceAllString(blk.info, "")
+	if language != "" {
+		fmt.Fprintf(b, "<pre><code class=\"language-%s\">", language)
+	} else {
+		b.WriteString("<pre><code>")
+	}
+	lexer := highlight.NewLexer(highlight.Lookup(language))
+	for _, line := range blk.lines {
+		b.WriteString(highlight.FormatHTML.Render(lexer.Line(line)))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+func (r *renderer) table(b *strings.Builder, blk *block) {
+	b.WriteString("<table>\n")
+	for i, row := range blk.lines {
+		tag := "td"
+		if i == 0 {
+			tag = "th"
+			b.WriteString("<thead>\n")
+		} else if i == 1 {
+			b.WriteString("<tbody>\n")
+		}
+		b.WriteString("<tr>\n")
+		// Rows have as many cells as the header, padded or cut to fit
+		cells := strings.Split(row, "\x00")
+		for col, align := range blk.align {
+			cell := ""
+			if col < len(cells) {
+				cell = cells[col]
+			}
+			if align != "" {
+				fmt.Fprintf(b, "<%s align=\"%s\">%s</%s>\n", tag, align, r.inline(cell), tag)
+			} else {
+				fmt.Fprintf(b, "<%s>%s</%s>\n", tag, r.inline(cell), tag)
+			}
+		}
+		b.WriteString("</tr>\n")
+		if i == 0 {
+			b.WriteString("</thead>\n")
+		}
+	}
+	if len(blk.lines) > 1 {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// headingID returns the anchor of a heading as GitHub makes it: lower case,
+// punctuation dropped and spaces turned into dashes, numbered from -1 when
+// the document repeats it
+func (r *renderer) headingID(text string) string {
+	id := strings.ReplaceAll(slugStripRe.ReplaceAllString(strings.ToLower(text), ""), " ", "-")
+	n := r.ids[id]
+	r.ids[id]++
+	if n > 0 {
+		return fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// plainText returns the text of rendered inline HTML
+func plainText(rendered string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(rendered, "")))
+}
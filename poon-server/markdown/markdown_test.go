@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderBlocks(t *testing.T) {
+	result := Render([]byte(`# Poon *Monorepo*
+
+A monorepo with
+sparse checkouts.
+
+Setup
+-----
+
+- one
+- two
+  - nested
+- three
+
+3. third
+4. fourth
+
+> quoted **text**
+
+    indented code
+
+` + "```go\nfunc main() {}\n```" + `
+
+| Name | Size |
+|:-----|-----:|
+| a \| b | ` + "`1|2`" + ` |
+
+***
+`))
+
+	assert.Equal(t, "Poon Monorepo", result.Title)
+	assert.Equal(t, `<h1 id="poon-monorepo">Poon <em>Monorepo</em></h1>
+<p>A monorepo with
+sparse checkouts.</p>
+<h2 id="setup">Setup</h2>
+<ul>
+<li>one</li>
+<li>two
+<ul>
+<li>nested</li>
+</ul></li>
+<li>three</li>
+</ul>
+<ol start="3">
+<li>third</li>
+<li>fourth</li>
+</ol>
+<blockquote>
+<p>quoted <strong>text</strong></p>
+</blockquote>
+<pre><code>indented code
+</code></pre>
+<pre><code class="language-go"><span class="tok-keyword">func</span> main() {}
+</code></pre>
+<table>
+<thead>
+<tr>
+<th align="left">Name</th>
+<th align="right">Size</th>
+</tr>
+</thead>
+<tbody>
+<tr>
+<td align="left">a | b</td>
+<td align="right"><code>1|2</code></td>
+</tr>
+</tbody>
+</table>
+<hr />
+`, result.HTML)
+}
+
+func TestRenderLooseList(t *testing.T) {
+	assert.Equal(t, "<ul>\n<li>\n<p>one</p>\n</li>\n<li>\n<p>two</p>\n</li>\n</ul>\n", Render([]byte("- one\n\n- two\n")).HTML)
+}
+
+func TestRenderInline(t *testing.T) {
+	for source, want := range map[string]string{
+		"*em* **strong** ***both*** ~~gone~~":    "<em>em</em> <strong>strong</strong> <em><strong>both</strong></em> <del>gone</del>",
+		"snake_case_name and _em_":               "snake_case_name and <em>em</em>",
+		"2 * 3 * 4":                              "2 * 3 * 4",
+		"`a <b>` and ``x ` y``":                  "<code>a &lt;b&gt;</code> and <code>x ` y</code>",
+		`\*not em\* and a\` + "\nbreak":          "*not em* and a<br />\nbreak",
+		"two spaces  \nbreak":                    "two spaces<br />\nbreak",
+		"[docs](docs/README.md \"The docs\")":    `<a href="docs/README.md" title="The docs">docs</a>`,
+		"[**site**](https://example.com)":        `<a href="https://example.com" rel="nofollow"><strong>site</strong></a>`,
+		"![logo](img/logo.png)":                  `<img src="img/logo.png" alt="logo" />`,
+		"see https://example.com/a_(b).":         `see <a href="https://example.com/a_(b)" rel="nofollow">https://example.com/a_(b)</a>.`,
+		"<https://example.com> <me@example.com>": `<a href="https://example.com" rel="nofollow">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a>`,
+		"AT&T &copy; &#60;":                      "AT&amp;T © &lt;",
+		"[unclosed and [no](":                    "[unclosed and [no](",
+	} {
+		assert.Equal(t, "<p>"+want+"</p>\n", Render([]byte(source)).HTML, source)
+	}
+}
+
+func TestRenderReferenceLinks(t *testing.T) {
+	result := Render([]byte(`See [the guide][guide], [Guide][] and [guide].
+
+[guide]: https://example.com/guide "Guide"
+`))
+	link := `<a href="https://example.com/guide" title="Guide" rel="nofollow">`
+	assert.Equal(t, "<p>See "+link+"the guide</a>, "+link+"Guide</a> and "+link+"guide</a>.</p>\n", result.HTML)
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	for _, source := range []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror="alert(1)">`,
+		`[click](javascript:alert(1))`,
+		`[click](JaVa	Script:alert(1))`,
+		`![x](data:text/html;base64,PHNjcmlwdD4=)`,
+		`<javascript:alert(1)>`,
+		"[ref]\n\n[ref]: vbscript:msgbox",
+		`[x](https://example.com "a\" onmouseover=\"alert(1)")`,
+		"```\"><script>\nx\n```",
+	} {
+		html := strings.ToLower(Render([]byte(source)).HTML)
+		assert.NotContains(t, html, "<script", source)
+		assert.NotContains(t, html, "<img src=x", source)
+		assert.NotContains(t, html, `href="java`, source)
+		assert.NotContains(t, html, `href="vbscript`, source)
+		assert.NotContains(t, html, `src="data:`, source)
+		assert.NotContains(t, html, `" onmouseover`, source)
+	}
+
+	// Unsafe links keep their text
+	assert.Equal(t, "<p>click</p>\n", Render([]byte(`[click](javascript:alert(1))`)).HTML)
+	assert.Equal(t, "<p>&lt;b onclick=&#34;x&#34;&gt;bold&lt;/b&gt;</p>\n", Render([]byte(`<b onclick="x">bold</b>`)).HTML)
+}
+
+func TestRenderHeadingIDs(t *testing.T) {
+	result := Render([]byte("## Install\n# Usage: `poon` & more!\n## Install\n"))
+	assert.Equal(t, "Usage: poon & more!", result.Title)
+	assert.Equal(t, `<h2 id="install">Install</h2>
+<h1 id="usage-poon--more">Usage: <code>poon</code> &amp; more!</h1>
+<h2 id="install-1">Install</h2>
+`, result.HTML)
+}
+
+func TestRenderUnclosedDelimiters(t *testing.T) {
+	// Unclosed delimiters look only so far for a closer
+	source := strings.Repeat("[a *b _c ~~d ", 10000)
+	assert.Equal(t, "<p>"+strings.TrimSpace(source)+"</p>\n", Render([]byte(source)).HTML)
+}
+
+func TestRenderText(t *testing.T) {
+	assert.Equal(t, "<pre>a &lt;b&gt;\n</pre>\n", RenderText([]byte("a <b>\n")).HTML)
+	assert.True(t, IsMarkdown("docs/README.MD"))
+	assert.False(t, IsMarkdown("README.rst"))
+}
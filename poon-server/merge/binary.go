@@ -0,0 +1,276 @@
+package merge
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const binaryPatchMarker = "GIT binary patch"
+
+// BinaryHunk is one block of a "GIT binary patch" section: either the
+// complete new content (literal) or a git delta against the old content
+type BinaryHunk struct {
+	Kind string // "literal" or "delta"
+	Size int    // Inflated size announced by the block header
+	Data []byte // Inflated block data
+}
+
+// BinaryPatch holds the blocks git writes for a binary file. Forward turns
+// the old content into the new one; Reverse, when present, undoes it.
+type BinaryPatch struct {
+	Forward *BinaryHunk
+	Reverse *BinaryHunk
+}
+
+// NewSize returns the size of the content produced by the forward block
+func (b *BinaryPatch) NewSize() int64 {
+	if b.Forward.Kind == "literal" {
+		return int64(len(b.Forward.Data))
+	}
+	_, dstSize, _, err := deltaSizes(b.Forward.Data)
+	if err != nil {
+		return 0
+	}
+	return int64(dstSize)
+}
+
+// ApplyBinaryPatch produces the new content of a binary file from its old
+// content. Deltas must have been made against exactly that content.
+func ApplyBinaryPatch(original []byte, patch *BinaryPatch) ([]byte, error) {
+	if patch.Forward.Kind == "literal" {
+		return patch.Forward.Data, nil
+	}
+	return applyDelta(original, patch.Forward.Data)
+}
+
+// parseBinaryPatch reads the blocks following a "GIT binary patch" line.
+// Each block is a "literal <size>" or "delta <size>" line, base85 data lines
+// and a blank line.
+func parseBinaryPatch(scanner *bufio.Scanner) (*BinaryPatch, error) {
+	patch := &BinaryPatch{}
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue // Blank line after the last block
+		}
+
+		kind, sizeText, ok := strings.Cut(line, " ")
+		if !ok || (kind != "literal" && kind != "delta") {
+			return nil, fmt.Errorf("invalid binary patch block header %q", line)
+		}
+		size, err := strconv.Atoi(sizeText)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid binary patch block size %q", sizeText)
+		}
+
+		hunk, err := readBinaryHunk(scanner, kind, size)
+		if err != nil {
+			return nil, err
+		}
+
+		if patch.Forward == nil {
+			patch.Forward = hunk
+		} else {
+			patch.Reverse = hunk
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read patch: %v", err)
+	}
+	if patch.Forward == nil {
+		return nil, fmt.Errorf("binary patch has no data")
+	}
+
+	return patch, nil
+}
+
+// readBinaryHunk decodes the data lines of one block up to the blank line
+// that ends it and inflates them
+func readBinaryHunk(scanner *bufio.Scanner, kind string, size int) (*BinaryHunk, error) {
+	var compressed []byte
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+
+		data, err := decodeBase85Line(line)
+		if err != nil {
+			return nil, err
+		}
+		compressed = append(compressed, data...)
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate binary patch: %v", err)
+	}
+	defer reader.Close()
+
+	// Read at most one byte more than announced so a bad header cannot make
+	// us inflate an arbitrarily large stream
+	data, err := io.ReadAll(io.LimitReader(reader, int64(size)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate binary patch: %v", err)
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("binary patch block inflates to %d bytes, header says %d", len(data), size)
+	}
+
+	return &BinaryHunk{Kind: kind, Size: size, Data: data}, nil
+}
+
+const base85Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+var base85Values = func() [256]int {
+	var values [256]int
+	for i := range values {
+		values[i] = -1
+	}
+	for i := 0; i < len(base85Alphabet); i++ {
+		values[base85Alphabet[i]] = i
+	}
+	return values
+}()
+
+// decodeBase85Line decodes one data line of a binary patch. The first
+// character gives the decoded length (A-Z for 1-26, a-z for 27-52) and the
+// rest is git's base85 encoding of that many bytes.
+func decodeBase85Line(line string) ([]byte, error) {
+	var length int
+	switch c := line[0]; {
+	case c >= 'A' && c <= 'Z':
+		length = int(c-'A') + 1
+	case c >= 'a' && c <= 'z':
+		length = int(c-'a') + 27
+	default:
+		return nil, fmt.Errorf("invalid binary patch line length %q", c)
+	}
+
+	encoded := line[1:]
+	if len(encoded) != (length+3)/4*5 {
+		return nil, fmt.Errorf("binary patch line has %d characters for %d bytes", len(encoded), length)
+	}
+
+	decoded := make([]byte, 0, len(encoded)/5*4)
+	for i := 0; i < len(encoded); i += 5 {
+		var acc uint64
+		for _, c := range []byte(encoded[i : i+5]) {
+			value := base85Values[c]
+			if value < 0 {
+				return nil, fmt.Errorf("invalid base85 character %q in binary patch", c)
+			}
+			acc = acc*85 + uint64(value)
+		}
+		if acc > 0xffffffff {
+			return nil, fmt.Errorf("invalid base85 group %q in binary patch", encoded[i:i+5])
+		}
+		decoded = append(decoded, byte(acc>>24), byte(acc>>16), byte(acc>>8), byte(acc))
+	}
+
+	return decoded[:length], nil
+}
+
+// readDeltaVarint reads one of the little-endian size fields at the start of
+// a git delta
+func readDeltaVarint(delta []byte, pos int) (value uint64, next int, err error) {
+	for shift := uint(0); ; shift += 7 {
+		if pos >= len(delta) || shift > 63 {
+			return 0, 0, fmt.Errorf("truncated binary delta header")
+		}
+		b := delta[pos]
+		pos++
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, pos, nil
+		}
+	}
+}
+
+// deltaSizes returns the source and result sizes of a git delta and the
+// offset of its first instruction
+func deltaSizes(delta []byte) (srcSize, dstSize uint64, pos int, err error) {
+	if srcSize, pos, err = readDeltaVarint(delta, 0); err != nil {
+		return 0, 0, 0, err
+	}
+	if dstSize, pos, err = readDeltaVarint(delta, pos); err != nil {
+		return 0, 0, 0, err
+	}
+	return srcSize, dstSize, pos, nil
+}
+
+// applyDelta applies a git delta: a sequence of instructions that either
+// copy a range of base or insert literal bytes
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, dstSize, pos, err := deltaSizes(delta)
+	if err != nil {
+		return nil, err
+	}
+	if srcSize != uint64(len(base)) {
+		return nil, fmt.Errorf("binary delta expects a %d-byte file, file is %d bytes", srcSize, len(base))
+	}
+
+	var result []byte
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+
+		switch {
+		case op&0x80 != 0:
+			// Copy: bits 0-3 select offset bytes, bits 4-6 size bytes
+			var offset, size uint64
+			for i := uint(0); i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if pos >= len(delta) {
+					return nil, fmt.Errorf("truncated binary delta")
+				}
+				if i < 4 {
+					offset |= uint64(delta[pos]) << (8 * i)
+				} else {
+					size |= uint64(delta[pos]) << (8 * (i - 4))
+				}
+				pos++
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, fmt.Errorf("binary delta copies past the end of the file")
+			}
+			result = append(result, base[offset:offset+size]...)
+
+		case op != 0:
+			// Insert the next op bytes
+			if pos+int(op) > len(delta) {
+				return nil, fmt.Errorf("truncated binary delta")
+			}
+			result = append(result, delta[pos:pos+int(op)]...)
+			pos += int(op)
+
+		default:
+			return nil, fmt.Errorf("invalid binary delta instruction")
+		}
+
+		if uint64(len(result)) > dstSize {
+			return nil, fmt.Errorf("binary delta produces more than the announced %d bytes", dstSize)
+		}
+	}
+
+	if uint64(len(result)) != dstSize {
+		return nil, fmt.Errorf("binary delta produced %d bytes, header says %d", len(result), dstSize)
+	}
+	if result == nil {
+		result = []byte{}
+	}
+	return result, nil
+}
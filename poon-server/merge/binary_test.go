@@ -0,0 +1,169 @@
+package merge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Produced by git diff --binary
+const (
+	binaryDeltaPatch = "diff --git a/blob.bin b/blob.bin\n" +
+		"index c8b49c8cd518e58491924bfc364ff26e01a85009..9f15bb7869509e807bb8d1f186afbcd8444ebd8f 100644\n" +
+		"GIT binary patch\n" +
+		"delta 23\n" +
+		"ecmZqRXyKTU!pN{OHJpi&apPWRMwXJq%p3qvLI&Fa\n" +
+		"\n" +
+		"delta 14\n" +
+		"VcmZqSXyDkyxQB7!9;S(VTmU801$6)b\n" +
+		"\n"
+
+	binaryLiteralPatch = "diff --git a/new.bin b/new.bin\n" +
+		"new file mode 100644\n" +
+		"index 0000000000000000000000000000000000000000..b43761b27df02a0c6c305120d37445368d1ac5e1\n" +
+		"GIT binary patch\n" +
+		"literal 10\n" +
+		"RcmZQzWJ=1+ODwAV4*(1}1Bd_s\n" +
+		"\n" +
+		"literal 0\n" +
+		"HcmV?d00001\n" +
+		"\n"
+)
+
+// binaryDeltaBase is the content binaryDeltaPatch was made against, and
+// binaryDeltaResult what it turns it into
+func binaryDeltaBase() []byte {
+	base := make([]byte, 0, 1024)
+	for i := 0; i < 4; i++ {
+		for b := 0; b < 256; b++ {
+			base = append(base, byte(b))
+		}
+	}
+	return base
+}
+
+func binaryDeltaResult() []byte {
+	result := append([]byte{}, binaryDeltaBase()...)
+	result[100], result[700] = 0, 1
+	return append(result, "tail"...)
+}
+
+func TestBinaryPatch(t *testing.T) {
+	t.Run("Literal", func(t *testing.T) {
+		patch, err := ParsePatch([]byte(binaryLiteralPatch))
+		require.NoError(t, err)
+		assert.Equal(t, "/dev/null", patch.Header.OldFile)
+		assert.Equal(t, "new.bin", patch.Header.NewFile)
+		assert.Equal(t, "100644", patch.Header.NewMode)
+		assert.Empty(t, patch.Hunks)
+		require.NotNil(t, patch.Binary)
+		assert.Equal(t, "literal", patch.Binary.Forward.Kind)
+		assert.Equal(t, int64(10), patch.Binary.NewSize())
+
+		content, err := ApplyBinaryPatch(nil, patch.Binary)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x00\x01\x02binary\xff"), content)
+	})
+
+	t.Run("Delta", func(t *testing.T) {
+		patch, err := ParsePatch([]byte(binaryDeltaPatch))
+		require.NoError(t, err)
+		assert.Equal(t, "blob.bin", patch.Header.OldFile)
+		assert.Equal(t, "blob.bin", patch.Header.NewFile)
+		require.NotNil(t, patch.Binary)
+		assert.Equal(t, "delta", patch.Binary.Forward.Kind)
+		assert.Equal(t, "delta", patch.Binary.Reverse.Kind)
+		assert.Equal(t, int64(1028), patch.Binary.NewSize())
+
+		content, err := ApplyBinaryPatch(binaryDeltaBase(), patch.Binary)
+		require.NoError(t, err)
+		assert.Equal(t, binaryDeltaResult(), content)
+
+		// The reverse block undoes the change
+		reverted, err := applyDelta(content, patch.Binary.Reverse.Data)
+		require.NoError(t, err)
+		assert.Equal(t, binaryDeltaBase(), reverted)
+	})
+
+	t.Run("Delta Against Different Content", func(t *testing.T) {
+		patch, err := ParsePatch([]byte(binaryDeltaPatch))
+		require.NoError(t, err)
+
+		_, err = ApplyBinaryPatch([]byte("something else"), patch.Binary)
+		assert.Error(t, err)
+	})
+
+	t.Run("Apply To File", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "blob.bin")
+		require.NoError(t, os.WriteFile(filePath, binaryDeltaBase(), 0644))
+
+		patch, err := ParsePatch([]byte(binaryDeltaPatch))
+		require.NoError(t, err)
+		require.NoError(t, ApplyPatch(filePath, patch))
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, binaryDeltaResult(), content)
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		counts := CountPatch([]byte(binaryDeltaPatch + binaryLiteralPatch))
+		assert.Equal(t, 2, counts.Files)
+		assert.Equal(t, 0, counts.Hunks)
+		assert.Equal(t, int64(23), counts.BinaryBytes)
+	})
+
+	t.Run("Corrupt Data", func(t *testing.T) {
+		corrupt := map[string]string{
+			"Bad Length":     "literal 10\nZcmZQzWJ=1+ODwAV4*(1}1Bd_s\n\n",
+			"Bad Character":  "literal 10\nRcmZQzWJ=1+ODwAV4*(1}1Bd_\"\n\n",
+			"Wrong Size":     "literal 11\nRcmZQzWJ=1+ODwAV4*(1}1Bd_s\n\n",
+			"Unknown Block":  "patch 10\nRcmZQzWJ=1+ODwAV4*(1}1Bd_s\n\n",
+			"Missing Blocks": "",
+		}
+		for name, body := range corrupt {
+			t.Run(name, func(t *testing.T) {
+				_, err := ParsePatch([]byte("diff --git a/x.bin b/x.bin\nGIT binary patch\n" + body))
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("Without Binary Data", func(t *testing.T) {
+		_, err := ParsePatch([]byte("diff --git a/x.bin b/x.bin\nindex 1234567..89abcde 100644\nBinary files a/x.bin and b/x.bin differ\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "git diff --binary")
+	})
+
+	t.Run("Names With Spaces", func(t *testing.T) {
+		oldFile, newFile := parseGitDiffNames("diff --git a/dir b/x b/dir b/x")
+		assert.Equal(t, "dir b/x", oldFile)
+		assert.Equal(t, "dir b/x", newFile)
+
+		oldFile, newFile = parseGitDiffNames("diff --git a/old.bin b/new.bin")
+		assert.Equal(t, "old.bin", oldFile)
+		assert.Equal(t, "new.bin", newFile)
+	})
+}
+
+func FuzzApplyDelta(f *testing.F) {
+	patch, err := ParsePatch([]byte(binaryDeltaPatch))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(binaryDeltaBase(), patch.Binary.Forward.Data)
+	f.Add([]byte("abc"), []byte{3, 5, 0x90, 2, 2, 'x', 'y'})
+
+	f.Fuzz(func(t *testing.T, base, delta []byte) {
+		result, err := applyDelta(base, delta)
+		if err != nil {
+			return
+		}
+		if _, dstSize, _, _ := deltaSizes(delta); uint64(len(result)) != dstSize {
+			t.Fatalf("result is %d bytes, delta announced %d", len(result), dstSize)
+		}
+	})
+}
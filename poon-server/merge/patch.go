@@ -11,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type PatchHeader struct {
@@ -29,13 +31,33 @@ type PatchHunk struct {
 }
 
 type PatchLine struct {
-	Type    string // "+", "-", " " (context)
-	Content string
+	Type      string // "+", "-", " " (context)
+	Content   string // Line without its newline; a CR before the newline is kept
+	NoNewline bool   // Followed by "\ No newline at end of file"
 }
 
 type ParsedPatch struct {
 	Header PatchHeader
 	Hunks  []PatchHunk
+	Binary *BinaryPatch // Set for "GIT binary patch" sections, which have no Hunks
+}
+
+// DevNull stands for the missing side of a patch that creates or deletes a
+// file
+const DevNull = "/dev/null"
+
+// Target returns the file the patch applies to: its new name, or its old
+// name when the patch deletes the file or names only one side
+func (p *ParsedPatch) Target() string {
+	if p.Header.NewFile != "" && p.Header.NewFile != DevNull {
+		return p.Header.NewFile
+	}
+	return p.Header.OldFile
+}
+
+// Deletes reports whether the patch deletes its file
+func (p *ParsedPatch) Deletes() bool {
+	return p.Header.NewFile == DevNull && p.Header.OldFile != "" && p.Header.OldFile != DevNull
 }
 
 func ValidatePatch(patchData []byte) error {
@@ -43,12 +65,12 @@ func ValidatePatch(patchData []byte) error {
 		return fmt.Errorf("patch data is empty")
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(patchData))
+	scanner := newLineScanner(patchData)
 	hasValidHeader := false
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+		if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "diff --git ") {
 			hasValidHeader = true
 		}
 		if strings.HasPrefix(line, "@@") {
@@ -58,6 +80,10 @@ func ValidatePatch(patchData []byte) error {
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read patch: %v", err)
+	}
+
 	if !hasValidHeader {
 		return fmt.Errorf("patch does not contain valid unified diff headers")
 	}
@@ -65,65 +91,321 @@ func ValidatePatch(patchData []byte) error {
 	return nil
 }
 
+// newLineScanner returns a scanner over patch lines that accepts lines as
+// long as the patch itself, so long lines are never silently dropped. Unlike
+// bufio.ScanLines it keeps carriage returns, which are part of the content
+// of files with CRLF line endings.
+func newLineScanner(patchData []byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(bytes.NewReader(patchData))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(patchData)+1)
+	scanner.Split(scanLinesKeepCR)
+	return scanner
+}
+
+func scanLinesKeepCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+var hunkRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseHunkHeader parses the ranges of an "@@ -a,b +c,d @@" line
+func parseHunkHeader(matches []string) (*PatchHunk, error) {
+	numbers := make([]int, 4)
+	for i, match := range matches[1:] {
+		if match == "" {
+			numbers[i] = 1 // Omitted counts default to one line
+			continue
+		}
+		n, err := strconv.Atoi(match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hunk header %q: %v", matches[0], err)
+		}
+		numbers[i] = n
+	}
+
+	return &PatchHunk{
+		OldStart: numbers[0],
+		OldCount: numbers[1],
+		NewStart: numbers[2],
+		NewCount: numbers[3],
+	}, nil
+}
+
+// finishHunk checks that a hunk contains as many lines as its header
+// announced and does not overlap the hunk before it
+func finishHunk(patch *ParsedPatch, hunk *PatchHunk, oldLeft, newLeft int) error {
+	if oldLeft != 0 || newLeft != 0 {
+		return fmt.Errorf("hunk at line %d is truncated", hunk.OldStart)
+	}
+
+	if n := len(patch.Hunks); n > 0 {
+		prev := patch.Hunks[n-1]
+		if hunk.OldStart < prev.OldStart+prev.OldCount {
+			return fmt.Errorf("hunk at line %d overlaps or precedes the previous hunk", hunk.OldStart)
+		}
+	}
+
+	patch.Hunks = append(patch.Hunks, *hunk)
+	return nil
+}
+
+// PatchCounts describes the size of a patch as found by CountPatch
+type PatchCounts struct {
+	Files       int
+	Hunks       int
+	BinaryBytes int64 // Largest inflated size announced by a binary block
+}
+
+// CountPatch counts the files and hunks in a patch without building the
+// parsed form, so oversized patches can be rejected cheaply. Hunk bodies are
+// skipped by their header counts like ParsePatch does; malformed input is
+// counted as far as possible and left for ParsePatch to reject.
+func CountPatch(patchData []byte) PatchCounts {
+	var counts PatchCounts
+	scanner := newLineScanner(patchData)
+	var oldLeft, newLeft int
+	previousWasOldHeader := false
+	gitHeaderPending := false // A "diff --git" line already counted the file
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case len(line) == 0 || line[0] == ' ' || string(line) == "\r":
+				oldLeft--
+				newLeft--
+			case line[0] == '-':
+				oldLeft--
+			case line[0] == '+':
+				newLeft--
+			case line[0] == '\\':
+			default:
+				oldLeft, newLeft = 0, 0 // Malformed hunk; resume header scanning
+			}
+			oldLeft, newLeft = max(oldLeft, 0), max(newLeft, 0)
+			continue
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+
+		switch {
+		case bytes.HasPrefix(line, []byte("diff --git ")):
+			counts.Files++
+			gitHeaderPending = true
+		case bytes.HasPrefix(line, []byte("+++ ")) && previousWasOldHeader:
+			if !gitHeaderPending {
+				counts.Files++
+			}
+			gitHeaderPending = false
+		case bytes.HasPrefix(line, []byte("@@")):
+			if matches := hunkRegex.FindStringSubmatch(string(line)); matches != nil {
+				counts.Hunks++
+				if hunk, err := parseHunkHeader(matches); err == nil {
+					oldLeft, newLeft = hunk.OldCount, hunk.NewCount
+				}
+			}
+		case bytes.HasPrefix(line, []byte("literal ")) || bytes.HasPrefix(line, []byte("delta ")):
+			// Base85 data lines never contain spaces, so this is a block header
+			_, sizeText, _ := bytes.Cut(line, []byte(" "))
+			if size, err := strconv.ParseInt(string(sizeText), 10, 64); err == nil {
+				counts.BinaryBytes = max(counts.BinaryBytes, size)
+			}
+		}
+		previousWasOldHeader = bytes.HasPrefix(line, []byte("--- "))
+	}
+
+	return counts
+}
+
+// parseGitDiffNames extracts the file names from a "diff --git a/x b/y"
+// line. Binary patches have no ---/+++ lines, so this is their only header.
+func parseGitDiffNames(line string) (oldFile, newFile string) {
+	names := strings.TrimPrefix(line, "diff --git ")
+
+	// Git quotes names with special characters, so a quote can only start
+	// or end a name
+	if strings.HasPrefix(names, `"`) {
+		if end := closingQuote(names); end > 0 && end+1 < len(names) && names[end+1] == ' ' {
+			return gitPath(names[:end+1], "a/"), gitPath(names[end+2:], "b/")
+		}
+		return "", ""
+	}
+	if strings.HasSuffix(names, `"`) {
+		if i := strings.LastIndex(names, ` "b/`); i >= 0 {
+			return gitPath(names[:i], "a/"), gitPath(names[i+1:], "b/")
+		}
+		return "", ""
+	}
+
+	// Unless the file was renamed both names are equal, which also resolves
+	// names containing " b/"
+	if n := len(names); n%2 == 1 && strings.HasPrefix(names, "a/") {
+		half := (n - 1) / 2
+		if names[half] == ' ' && names[2:half] == names[half+3:] {
+			return gitPath(names[:half], "a/"), gitPath(names[half+1:], "b/")
+		}
+	}
+
+	if i := strings.LastIndex(names, " b/"); i >= 0 {
+		return gitPath(names[:i], "a/"), gitPath(names[i+1:], "b/")
+	}
+	return "", ""
+}
+
+// closingQuote returns the index of the quote ending the quoted string at
+// the start of s, or -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// gitPath decodes a file name from a patch header. Names with special or
+// non-ASCII characters are C-quoted by git, names containing spaces are
+// followed by a tab, and traditional diffs put a timestamp after the tab.
+// The a/ or b/ prefix is removed and the name is returned in NFC form, as
+// macOS may produce decomposed names.
+func gitPath(name, prefix string) string {
+	if end := closingQuote(name); strings.HasPrefix(name, `"`) && end > 0 {
+		if unquoted, err := strconv.Unquote(name[:end+1]); err == nil {
+			name = unquoted
+		}
+	} else if i := strings.IndexByte(name, '\t'); i >= 0 {
+		name = name[:i]
+	}
+
+	if name == "/dev/null" {
+		return name
+	}
+	return norm.NFC.String(strings.TrimPrefix(name, prefix))
+}
+
+// ApplyOptions relax how a text patch is matched against and applied to a
+// file. The zero value matches exactly and always ends the result with a
+// newline.
+type ApplyOptions struct {
+	IgnoreWhitespace        bool // Match context and removed lines ignoring differences in whitespace
+	NormalizeLineEndings    bool // Match lines regardless of CRLF/LF and write added lines with the file's line ending
+	PreserveTrailingNewline bool // Keep a missing final newline and honour "\ No newline at end of file"
+	Union                   bool // Where a hunk does not match, keep the file's lines and add the hunk's
+}
+
+// markNoNewline records a "\ No newline at end of file" marker, which
+// applies to the line before it
+func markNoNewline(hunk *PatchHunk) {
+	if n := len(hunk.Lines); n > 0 {
+		hunk.Lines[n-1].NoNewline = true
+	}
+}
+
 func ParsePatch(patchData []byte) (*ParsedPatch, error) {
 	if err := ValidatePatch(patchData); err != nil {
 		return nil, err
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(patchData))
+	scanner := newLineScanner(patchData)
 	patch := &ParsedPatch{}
 	var currentHunk *PatchHunk
-
-	hunkRegex := regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	var oldLeft, newLeft int // Lines the current hunk header still expects
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		if strings.HasPrefix(line, "--- ") {
-			oldFile := strings.TrimPrefix(line, "--- ")
-			if strings.HasPrefix(oldFile, "a/") {
-				oldFile = oldFile[2:]
-			}
-			patch.Header.OldFile = oldFile
-		} else if strings.HasPrefix(line, "+++ ") {
-			newFile := strings.TrimPrefix(line, "+++ ")
-			if strings.HasPrefix(newFile, "b/") {
-				newFile = newFile[2:]
+		// Inside a hunk, lines are consumed according to the header counts so
+		// content such as "--- x" or "+++ y" is never mistaken for a header.
+		// Some editors strip the space from blank context lines.
+		if currentHunk != nil && (oldLeft > 0 || newLeft > 0) {
+			lineType, content := " ", line
+			if line != "" && line != "\r" {
+				lineType, content = line[:1], line[1:]
 			}
-			patch.Header.NewFile = newFile
-		} else if matches := hunkRegex.FindStringSubmatch(line); matches != nil {
-			if currentHunk != nil {
-				patch.Hunks = append(patch.Hunks, *currentHunk)
+
+			switch {
+			case lineType == " " && oldLeft > 0 && newLeft > 0:
+				oldLeft--
+				newLeft--
+			case lineType == "-" && oldLeft > 0:
+				oldLeft--
+			case lineType == "+" && newLeft > 0:
+				newLeft--
+			case lineType == "\\":
+				markNoNewline(currentHunk)
+				continue
+			default:
+				return nil, fmt.Errorf("unexpected line in hunk at line %d: %q", currentHunk.OldStart, line)
 			}
 
-			oldStart, _ := strconv.Atoi(matches[1])
-			oldCount := 1
-			if matches[2] != "" {
-				oldCount, _ = strconv.Atoi(matches[2])
+			currentHunk.Lines = append(currentHunk.Lines, PatchLine{Type: lineType, Content: content})
+			continue
+		}
+
+		// Header lines of a patch saved with CRLF line endings end in a CR
+		line = strings.TrimSuffix(line, "\r")
+
+		if strings.HasPrefix(line, "\\") && currentHunk != nil {
+			markNoNewline(currentHunk) // Marker after the last line of a hunk
+		} else if strings.HasPrefix(line, "diff --git ") {
+			patch.Header.OldFile, patch.Header.NewFile = parseGitDiffNames(line)
+		} else if mode, ok := strings.CutPrefix(line, "new file mode "); ok {
+			patch.Header.OldFile, patch.Header.NewMode = "/dev/null", mode
+		} else if mode, ok := strings.CutPrefix(line, "deleted file mode "); ok {
+			patch.Header.NewFile, patch.Header.OldMode = "/dev/null", mode
+		} else if mode, ok := strings.CutPrefix(line, "old mode "); ok {
+			patch.Header.OldMode = mode
+		} else if mode, ok := strings.CutPrefix(line, "new mode "); ok {
+			patch.Header.NewMode = mode
+		} else if line == binaryPatchMarker {
+			binary, err := parseBinaryPatch(scanner)
+			if err != nil {
+				return nil, err
 			}
-			newStart, _ := strconv.Atoi(matches[3])
-			newCount := 1
-			if matches[4] != "" {
-				newCount, _ = strconv.Atoi(matches[4])
+			patch.Binary = binary
+		} else if strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ") {
+			return nil, fmt.Errorf("patch has no binary data (create it with git diff --binary)")
+		} else if strings.HasPrefix(line, "--- ") {
+			patch.Header.OldFile = gitPath(strings.TrimPrefix(line, "--- "), "a/")
+		} else if strings.HasPrefix(line, "+++ ") {
+			patch.Header.NewFile = gitPath(strings.TrimPrefix(line, "+++ "), "b/")
+		} else if matches := hunkRegex.FindStringSubmatch(line); matches != nil {
+			if currentHunk != nil {
+				if err := finishHunk(patch, currentHunk, oldLeft, newLeft); err != nil {
+					return nil, err
+				}
 			}
 
-			currentHunk = &PatchHunk{
-				OldStart: oldStart,
-				OldCount: oldCount,
-				NewStart: newStart,
-				NewCount: newCount,
+			hunk, err := parseHunkHeader(matches)
+			if err != nil {
+				return nil, err
 			}
-		} else if currentHunk != nil && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, " ")) {
-			patchLine := PatchLine{
-				Type:    string(line[0]),
-				Content: line[1:],
-			}
-			currentHunk.Lines = append(currentHunk.Lines, patchLine)
+			currentHunk = hunk
+			oldLeft, newLeft = hunk.OldCount, hunk.NewCount
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read patch: %v", err)
+	}
+
 	if currentHunk != nil {
-		patch.Hunks = append(patch.Hunks, *currentHunk)
+		if err := finishHunk(patch, currentHunk, oldLeft, newLeft); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Binary != nil && len(patch.Hunks) > 0 {
+		return nil, fmt.Errorf("patch mixes text hunks and binary data")
 	}
 
 	return patch, nil
@@ -158,12 +440,14 @@ func BackupFile(filePath string) (string, error) {
 
 func ApplyPatch(filePath string, patch *ParsedPatch) error {
 	var originalLines []string
+	var original []byte
 
 	if _, err := os.Stat(filePath); err == nil {
 		content, err := os.ReadFile(filePath)
 		if err != nil {
 			return fmt.Errorf("failed to read existing file: %v", err)
 		}
+		original = content
 		originalContent := string(content)
 		if originalContent != "" {
 			originalLines = strings.Split(originalContent, "\n")
@@ -173,6 +457,14 @@ func ApplyPatch(filePath string, patch *ParsedPatch) error {
 		}
 	}
 
+	if patch.Binary != nil {
+		content, err := ApplyBinaryPatch(original, patch.Binary)
+		if err != nil {
+			return fmt.Errorf("failed to apply binary patch: %v", err)
+		}
+		return writePatchedFile(filePath, content)
+	}
+
 	result := make([]string, 0, len(originalLines)+100)
 	originalIndex := 0
 
@@ -209,11 +501,15 @@ func ApplyPatch(filePath string, patch *ParsedPatch) error {
 		newContent += "\n"
 	}
 
+	return writePatchedFile(filePath, []byte(newContent))
+}
+
+func writePatchedFile(filePath string, content []byte) error {
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
 		return fmt.Errorf("failed to write patched file: %v", err)
 	}
 
@@ -3,6 +3,7 @@ package merge
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -67,6 +68,191 @@ func TestPatchParsing(t *testing.T) {
 		assert.Equal(t, 1, patch.Hunks[0].OldStart)
 		assert.Equal(t, 10, patch.Hunks[1].OldStart)
 	})
+	t.Run("Malformed Hunks", func(t *testing.T) {
+		header := "--- a/test.txt\n+++ b/test.txt\n"
+		cases := map[string]string{
+			"truncated":     "@@ -1,3 +1,3 @@\n line 1\n",
+			"out of order":  "@@ -5,1 +5,1 @@\n-a\n+b\n@@ -1,1 +1,1 @@\n-c\n+d\n",
+			"overlapping":   "@@ -1,3 +1,3 @@\n a\n-b\n+c\n d\n@@ -2,1 +2,1 @@\n-e\n+f\n",
+			"huge number":   "@@ -99999999999999999999 +1 @@\n-a\n+b\n",
+			"unexpected +":  "@@ -1,1 +1,0 @@\n+a\n",
+			"garbage lines": "@@ -1,2 +1,2 @@\n a\n?b\n",
+		}
+		for name, hunks := range cases {
+			_, err := ParsePatch([]byte(header + hunks))
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("Hunk Content Resembling Headers", func(t *testing.T) {
+		patchData := "--- a/test.txt\n+++ b/test.txt\n@@ -1,1 +1,1 @@\n--- old\n+++ new\n"
+
+		patch, err := ParsePatch([]byte(patchData))
+		require.NoError(t, err)
+		assert.Equal(t, "test.txt", patch.Header.NewFile)
+		require.Len(t, patch.Hunks, 1)
+		assert.Equal(t, []PatchLine{{Type: "-", Content: "-- old"}, {Type: "+", Content: "++ new"}}, patch.Hunks[0].Lines)
+	})
+
+	t.Run("Long Lines", func(t *testing.T) {
+		long := strings.Repeat("x", 200*1024)
+		patchData := "--- a/test.txt\n+++ b/test.txt\n@@ -1,1 +1,1 @@\n-a\n+" + long + "\n"
+
+		patch, err := ParsePatch([]byte(patchData))
+		require.NoError(t, err)
+		assert.Equal(t, long, patch.Hunks[0].Lines[1].Content)
+	})
+
+	t.Run("Line Endings", func(t *testing.T) {
+		patchData := "--- a/test.txt\r\n+++ b/test.txt\r\n@@ -1,2 +1,2 @@\r\n a\r\n-b\r\n\\ No newline at end of file\r\n+c\r\n"
+
+		patch, err := ParsePatch([]byte(patchData))
+		require.NoError(t, err)
+		assert.Equal(t, "test.txt", patch.Header.NewFile)
+		require.Len(t, patch.Hunks, 1)
+		assert.Equal(t, []PatchLine{
+			{Type: " ", Content: "a\r"},
+			{Type: "-", Content: "b\r", NoNewline: true},
+			{Type: "+", Content: "c\r"},
+		}, patch.Hunks[0].Lines)
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		patchData := "--- a/one.txt\n+++ b/one.txt\n@@ -1,1 +1,1 @@\n--- old\n+++ new\n@@ -5 +5 @@\n-a\n+b\n" +
+			"--- a/two.txt\n+++ b/two.txt\n@@ -0,0 +1 @@\n+c\n"
+
+		counts := CountPatch([]byte(patchData))
+		assert.Equal(t, 2, counts.Files)
+		assert.Equal(t, 3, counts.Hunks)
+	})
+}
+
+func FuzzParsePatch(f *testing.F) {
+	f.Add([]byte("--- a/test.txt\n+++ b/test.txt\n@@ -1,3 +1,3 @@\n line 1\n-line 2\n+modified line 2\n line 3\n"))
+	f.Add([]byte("--- a/test.txt\n+++ b/test.txt\n@@ -1,2 +1,3 @@\n line 1\n+new line\n line 2\n@@ -10 +11,2 @@\n line 10\n+another\n"))
+	f.Add([]byte("--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n\\ No newline at end of file\n"))
+	f.Add([]byte(binaryDeltaPatch))
+	f.Add([]byte(binaryLiteralPatch))
+	f.Add([]byte("@@ -1 +1 @@\n"))
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// ValidatePatch runs as part of ParsePatch, but is also exported
+		_ = ValidatePatch(data)
+
+		counts := CountPatch(data)
+
+		patch, err := ParsePatch(data)
+		if err != nil {
+			return
+		}
+		if counts.Hunks != len(patch.Hunks) {
+			t.Fatalf("CountPatch found %d hunks, ParsePatch %d", counts.Hunks, len(patch.Hunks))
+		}
+
+		end := 0
+		for _, hunk := range patch.Hunks {
+			if hunk.OldStart < end {
+				t.Fatalf("hunk at %d overlaps previous hunk ending at %d", hunk.OldStart, end)
+			}
+			end = hunk.OldStart + hunk.OldCount
+
+			oldLines, newLines := 0, 0
+			for _, line := range hunk.Lines {
+				switch line.Type {
+				case " ":
+					oldLines++
+					newLines++
+				case "-":
+					oldLines++
+				case "+":
+					newLines++
+				default:
+					t.Fatalf("unexpected line type %q", line.Type)
+				}
+			}
+			if oldLines != hunk.OldCount || newLines != hunk.NewCount {
+				t.Fatalf("hunk has %d/%d lines, header says %d/%d", oldLines, newLines, hunk.OldCount, hunk.NewCount)
+			}
+		}
+	})
+}
+
+func TestPatchFileNames(t *testing.T) {
+	// Headers as written by git diff for each kind of name
+	tests := []struct {
+		name    string
+		headers string
+		oldFile string
+		newFile string
+	}{
+		{
+			name:    "Spaces",
+			headers: "diff --git a/my file.txt b/my file.txt\n--- a/my file.txt\t\n+++ b/my file.txt\t\n",
+			oldFile: "my file.txt",
+			newFile: "my file.txt",
+		},
+		{
+			name:    "Non-ASCII Quoted",
+			headers: "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\n--- \"a/caf\\303\\251.txt\"\n+++ \"b/caf\\303\\251.txt\"\n",
+			oldFile: "caf\u00e9.txt",
+			newFile: "caf\u00e9.txt",
+		},
+		{
+			name:    "Decomposed To NFC",
+			headers: "--- a/cafe\u0301.txt\n+++ b/cafe\u0301.txt\n",
+			oldFile: "caf\u00e9.txt",
+			newFile: "caf\u00e9.txt",
+		},
+		{
+			name:    "Leading Dash",
+			headers: "diff --git a/-rf b/-rf\n--- a/-rf\n+++ b/-rf\n",
+			oldFile: "-rf",
+			newFile: "-rf",
+		},
+		{
+			name:    "Escaped Quote And Tab",
+			headers: "--- \"a/say \\\"hi\\\"\\t.txt\"\t\n+++ \"b/say \\\"hi\\\"\\t.txt\"\t\n",
+			oldFile: "say \"hi\"\t.txt",
+			newFile: "say \"hi\"\t.txt",
+		},
+		{
+			name:    "Traditional Diff Timestamps",
+			headers: "--- a/notes.txt\t2024-01-01 10:00:00.000000000 +0000\n+++ b/notes.txt\t2024-01-02 10:00:00.000000000 +0000\n",
+			oldFile: "notes.txt",
+			newFile: "notes.txt",
+		},
+		{
+			name:    "New File",
+			headers: "--- /dev/null\n+++ b/new file.txt\t\n",
+			oldFile: "/dev/null",
+			newFile: "new file.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := "@@ -1 +1 @@\n-old\n+new\n"
+			if tt.oldFile == "/dev/null" {
+				body = "@@ -0,0 +1 @@\n+new\n"
+			}
+
+			patch, err := ParsePatch([]byte(tt.headers + body))
+			require.NoError(t, err)
+			assert.Equal(t, tt.oldFile, patch.Header.OldFile)
+			assert.Equal(t, tt.newFile, patch.Header.NewFile)
+		})
+	}
+
+	t.Run("Quoted Rename", func(t *testing.T) {
+		oldFile, newFile := parseGitDiffNames(`diff --git a/cafe.txt "b/caf\303\251.txt"`)
+		assert.Equal(t, "cafe.txt", oldFile)
+		assert.Equal(t, "caf\u00e9.txt", newFile)
+
+		oldFile, newFile = parseGitDiffNames(`diff --git "a/caf\303\251.txt" b/cafe.txt`)
+		assert.Equal(t, "caf\u00e9.txt", oldFile)
+		assert.Equal(t, "cafe.txt", newFile)
+	})
 }
 
 func TestPatchValidation(t *testing.T) {
@@ -0,0 +1,218 @@
+// Package poonfake is an in-memory poon-server for the tests of tools that
+// call MonorepoService. It serves the real service over a memory backend, so
+// reads, patches, workspaces and errors behave as they do in production,
+// without a storage directory or a server process to manage.
+//
+//	fake := poonfake.NewFixture(t, "basic")
+//	c, err := poon.New(fake.Start(t)) // or fake.Client(t) without a port
+//
+// A Server starts from files given in the test or from one of the fixture
+// repositories (see Fixtures), and more versions can be added with Commit.
+// Creating workspaces needs git on PATH, as it does in poon-server.
+package poonfake
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/server"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// author is the author of the versions a Server creates
+const author = "poonfake@example.com"
+
+// modTime is the modification time of every file and directory a Server
+// commits. Trees record it, so a fixed one gives the same tree hashes on
+// every run.
+var modTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fixtures holds the fixture repositories: testdata/fixtures/<name>/<n>/ is
+// the whole tree of version n. Under testdata, the Go files in them are not
+// built.
+//
+//go:embed all:testdata/fixtures
+var fixtures embed.FS
+
+const fixtureRoot = "testdata/fixtures"
+
+// Files are the contents of a repository by path, such as
+// "services/api/main.go"
+type Files map[string]string
+
+// Server is an in-memory MonorepoService. Its Repository is the one the
+// service reads and writes.
+type Server struct {
+	pb.MonorepoServiceServer
+	Repository storage.Repository
+}
+
+// New returns a Server whose repository has one version holding files
+func New(t testing.TB, files Files) *Server {
+	t.Helper()
+	s := newServer(t)
+	s.Commit(t, files, "Initial repository commit")
+	return s
+}
+
+// NewFixture returns a Server whose repository has the versions of the
+// named fixture, one after another
+func NewFixture(t testing.TB, name string) *Server {
+	t.Helper()
+	versions, err := fixtureVersions(name)
+	if err != nil {
+		t.Fatalf("poonfake: %v", err)
+	}
+	if len(versions) == 0 {
+		t.Fatalf("poonfake: no fixture %q (have %v)", name, Fixtures())
+	}
+
+	s := newServer(t)
+	for _, version := range versions {
+		tree, _ := fs.Sub(fixtures, path.Join(fixtureRoot, name, version))
+		s.commitTree(t, tree, name+" fixture, version "+version)
+	}
+	return s
+}
+
+// Fixtures lists the fixture repositories NewFixture knows:
+//   - "basic": two services, a shared library, docs and a "backend" view
+//     in .poon/views.json, in one version
+//   - "history": a service over three versions that edit, delete, add and
+//     move files
+//   - "attributes": .poonattributes with text, eol=crlf, binary and
+//     export-ignore rules, and files each one applies to
+func Fixtures() []string {
+	entries, _ := fixtures.ReadDir(fixtureRoot)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// fixtureVersions returns the version directories of a fixture in order
+func fixtureVersions(name string) ([]string, error) {
+	entries, err := fixtures.ReadDir(path.Join(fixtureRoot, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, entry.Name())
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if len(versions[i]) != len(versions[j]) {
+			return len(versions[i]) < len(versions[j])
+		}
+		return versions[i] < versions[j]
+	})
+	return versions, nil
+}
+
+func newServer(t testing.TB) *Server {
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	return &Server{
+		MonorepoServiceServer: server.NewService(backend, repository, t.TempDir()),
+		Repository:            repository,
+	}
+}
+
+// Commit adds a version whose whole tree is files and returns its number.
+// Paths left out of files are deleted.
+func (s *Server) Commit(t testing.TB, files Files, message string) int64 {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("poonfake: %v", err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("poonfake: %v", err)
+		}
+	}
+	return s.commitDir(t, dir, message)
+}
+
+func (s *Server) commitTree(t testing.TB, tree fs.FS, message string) int64 {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, tree); err != nil {
+		t.Fatalf("poonfake: %v", err)
+	}
+	return s.commitDir(t, dir, message)
+}
+
+func (s *Server) commitDir(t testing.TB, dir, message string) int64 {
+	t.Helper()
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(name, modTime, modTime)
+	})
+	if err != nil {
+		t.Fatalf("poonfake: %v", err)
+	}
+	info, err := s.Repository.CreateCommitFromFileSystem(context.Background(), dir, author, message)
+	if err != nil {
+		t.Fatalf("poonfake: failed to commit: %v", err)
+	}
+	return info.Version
+}
+
+// Start serves s on a loopback port until the test ends and returns its
+// address, for poon-go's New, the CLI's --server or any other gRPC client
+func (s *Server) Start(t testing.TB) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("poonfake: failed to listen: %v", err)
+	}
+	s.serve(t, lis)
+	return lis.Addr().String()
+}
+
+// Client returns a client of s connected in memory, without a port, that is
+// closed when the test ends
+func (s *Server) Client(t testing.TB) pb.MonorepoServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s.serve(t, lis)
+
+	conn, err := grpc.NewClient("passthrough:///poonfake",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("poonfake: failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewMonorepoServiceClient(conn)
+}
+
+func (s *Server) serve(t testing.TB, lis net.Listener) {
+	grpcServer := grpc.NewServer()
+	pb.RegisterMonorepoServiceServer(grpcServer, s)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+}
@@ -0,0 +1,124 @@
+package poonfake
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/golden from the fixtures")
+
+// listTree writes a line for every entry below dir at version: its path,
+// whether it is a directory, size and hash
+func listTree(t *testing.T, client pb.MonorepoServiceClient, version int64, dir string, out *strings.Builder) {
+	resp, err := client.ReadDirectory(context.Background(), &pb.ReadDirectoryRequest{Path: dir, Version: version})
+	require.NoError(t, err)
+	for _, item := range resp.Items {
+		name := path.Join(dir, item.Name)
+		if item.IsDir {
+			fmt.Fprintf(out, "%s/ %s\n", name, item.Hash)
+			listTree(t, client, version, name, out)
+		} else {
+			fmt.Fprintf(out, "%s %d %s\n", name, item.Size, item.Hash)
+		}
+	}
+}
+
+// TestFixturesGolden pins the trees of every fixture version, so tests
+// downstream can rely on the paths, sizes and hashes they see
+func TestFixturesGolden(t *testing.T) {
+	for _, name := range Fixtures() {
+		t.Run(name, func(t *testing.T) {
+			fake := NewFixture(t, name)
+			client := fake.Client(t)
+
+			current, err := fake.Repository.GetCurrentVersion(context.Background())
+			require.NoError(t, err)
+			var out strings.Builder
+			for version := int64(1); version <= current; version++ {
+				fmt.Fprintf(&out, "version %d\n", version)
+				listTree(t, client, version, "", &out)
+			}
+
+			golden := filepath.Join("testdata", "golden", name+".golden")
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				require.NoError(t, os.WriteFile(golden, []byte(out.String()), 0644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "run go test -update to create it")
+			assert.Equal(t, string(want), out.String())
+		})
+	}
+}
+
+func TestNewAndCommit(t *testing.T) {
+	fake := New(t, Files{"README.md": "hello\n", "src/main.go": "package main\n"})
+	client := fake.Client(t)
+	ctx := context.Background()
+
+	resp, err := client.ReadFile(ctx, &pb.ReadFileRequest{Path: "src/main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(resp.Content))
+
+	version := fake.Commit(t, Files{"README.md": "hello again\n"}, "Drop src")
+	assert.Equal(t, int64(2), version)
+	resp, err = client.ReadFile(ctx, &pb.ReadFileRequest{Path: "README.md"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello again\n", string(resp.Content))
+	_, err = client.ReadFile(ctx, &pb.ReadFileRequest{Path: "src/main.go"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Earlier versions stay readable
+	resp, err = client.ReadFile(ctx, &pb.ReadFileRequest{Path: "src/main.go", Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(resp.Content))
+}
+
+func TestStart(t *testing.T) {
+	fake := NewFixture(t, "basic")
+	conn, err := grpc.NewClient(fake.Start(t), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewMonorepoServiceClient(conn)
+
+	views, err := client.ListViews(context.Background(), &pb.ListViewsRequest{})
+	require.NoError(t, err)
+	require.Len(t, views.Views, 1)
+	assert.Equal(t, "backend", views.Views[0].Name)
+
+	_, err = client.MergePatch(context.Background(), &pb.MergePatchRequest{
+		Path:    "docs",
+		Patch:   []byte("--- a/docs/guide.md\n+++ b/docs/guide.md\n@@ -1 +1 @@\n-# Guide\n+# User guide\n"),
+		Message: "Rename the guide",
+		Author:  "dev@example.com",
+	})
+	require.NoError(t, err)
+	resp, err := client.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/guide.md"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(resp.Content), "# User guide\n"))
+}
+
+func TestFixtures(t *testing.T) {
+	assert.Equal(t, []string{"attributes", "basic", "history"}, Fixtures())
+
+	versions, err := fixtureVersions("history")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, versions)
+	versions, err = fixtureVersions("nope")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
@@ -0,0 +1,2 @@
+@echo off
+echo Checked out with CRLF line endings
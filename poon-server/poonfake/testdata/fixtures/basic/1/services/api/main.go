@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/monorepo/libs/strings"
+)
+
+func main() {
+	fmt.Println(strings.Title("api"))
+}
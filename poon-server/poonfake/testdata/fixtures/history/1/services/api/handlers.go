@@ -0,0 +1,10 @@
+package main
+
+import "net/http"
+
+func serve() {
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	http.ListenAndServe(":8080", nil)
+}
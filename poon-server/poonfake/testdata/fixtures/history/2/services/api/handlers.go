@@ -0,0 +1,13 @@
+package main
+
+import "net/http"
+
+func serve() {
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	http.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("2"))
+	})
+	http.ListenAndServe(":8080", nil)
+}
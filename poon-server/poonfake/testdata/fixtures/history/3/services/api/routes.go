@@ -0,0 +1,14 @@
+package main
+
+import "net/http"
+
+func serve() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("3"))
+	})
+	http.ListenAndServe(":8080", mux)
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultActivityRange is how far back GetActivity looks without a start
+	defaultActivityRange = 30 * 24 * time.Hour
+
+	// defaultActivityLimit is how many authors or directories GetActivity
+	// returns without a limit
+	defaultActivityLimit = 10
+
+	// maxActivityPeriods bounds the days, weeks or months of one request
+	maxActivityPeriods = 1000
+)
+
+// recordActivity returns an event bus subscriber recording the metrics of
+// each created version from the diff the event carries
+func recordActivity(activity *storage.ActivityStore, repository storage.Repository) func(Event) {
+	return func(event Event) {
+		if event.Type != EventVersionCreated {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		files, err := event.Files(ctx, repository)
+		var metrics *storage.VersionMetrics
+		if err == nil {
+			metrics, err = storage.NewVersionMetrics(ctx, repository, event.Version, files)
+		}
+		if err == nil {
+			err = activity.Record(ctx, metrics)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to record activity of version %d: %v", event.Version, err)
+		}
+	}
+}
+
+// activityGroup adds up the versions of one group
+type activityGroup struct {
+	group   *pb.ActivityGroup
+	authors map[string]bool
+}
+
+func newActivityGroup(key string, start int64) *activityGroup {
+	return &activityGroup{
+		group:   &pb.ActivityGroup{Key: key, Start: start},
+		authors: make(map[string]bool),
+	}
+}
+
+func (g *activityGroup) add(author string, counts storage.ChangeCounts) {
+	g.group.Commits++
+	if !g.authors[author] {
+		g.authors[author] = true
+		g.group.Authors++
+	}
+	g.group.FilesChanged += int64(counts.Files)
+	g.group.Insertions += int64(counts.Insertions)
+	g.group.Deletions += int64(counts.Deletions)
+	g.group.BytesAdded += counts.BytesAdded
+}
+
+// periodStart returns the start of the day, week (from Monday) or month
+// holding t, in t's location
+func periodStart(t time.Time, groupBy string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch groupBy {
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+func nextPeriod(start time.Time, groupBy string) time.Time {
+	switch groupBy {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func periodKey(start time.Time, groupBy string) string {
+	if groupBy == "month" {
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+// activityDirectory returns the directory a changed file's parent directory
+// is grouped under: its first depth components, or "/" for the root
+func activityDirectory(dir string, depth int) string {
+	if dir == "" {
+		return "/"
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// GetActivity summarizes the versions created in a time range, for activity
+// dashboards: commits per period, top authors or the hottest directories
+func (s *server) GetActivity(ctx context.Context, req *pb.GetActivityRequest) (*pb.GetActivityResponse, error) {
+	log.Printf("Getting activity by %s (%d to %d)", req.GroupBy, req.Start, req.End)
+
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = "day"
+	}
+	switch groupBy {
+	case "day", "week", "month", "author", "directory":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown group_by %q (want day, week, month, author or directory)", req.GroupBy)
+	}
+	if req.Limit < 0 || req.DirectoryDepth < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and directory_depth must not be negative")
+	}
+	location := time.UTC
+	if req.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(req.TimeZone); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown time zone %q", req.TimeZone)
+		}
+	}
+
+	end := time.Now()
+	if req.End != 0 {
+		end = time.Unix(req.End, 0)
+	}
+	start := end.Add(-defaultActivityRange)
+	if req.Start != 0 {
+		start = time.Unix(req.Start, 0)
+	}
+	if !start.Before(end) {
+		return nil, status.Error(codes.InvalidArgument, "start must be before end")
+	}
+
+	var metrics []*storage.VersionMetrics
+	if s.activity != nil {
+		var err error
+		if metrics, err = s.activity.Query(ctx, start, end); err != nil {
+			return nil, internalError(err, "failed to query activity: %v", err)
+		}
+	}
+
+	total := newActivityGroup("", start.Unix())
+	for _, m := range metrics {
+		total.add(m.Author, m.ChangeCounts)
+	}
+	resp := &pb.GetActivityResponse{Total: total.group}
+
+	switch groupBy {
+	case "day", "week", "month":
+		var periods []*activityGroup
+		index := make(map[int64]*activityGroup)
+		for at := periodStart(start.In(location), groupBy); at.Before(end); at = nextPeriod(at, groupBy) {
+			if len(periods) == maxActivityPeriods {
+				return nil, status.Errorf(codes.InvalidArgument, "range spans more than %d periods; group by a longer period", maxActivityPeriods)
+			}
+			period := newActivityGroup(periodKey(at, groupBy), at.Unix())
+			periods = append(periods, period)
+			index[at.Unix()] = period
+		}
+		for _, m := range metrics {
+			if period := index[periodStart(m.Time.In(location), groupBy).Unix()]; period != nil {
+				period.add(m.Author, m.ChangeCounts)
+			}
+		}
+		for _, period := range periods {
+			resp.Groups = append(resp.Groups, period.group)
+		}
+
+	case "author", "directory":
+		depth := int(req.DirectoryDepth)
+		if depth == 0 {
+			depth = 1
+		}
+		groups := make(map[string]*activityGroup)
+		group := func(key string) *activityGroup {
+			if groups[key] == nil {
+				groups[key] = newActivityGroup(key, 0)
+			}
+			return groups[key]
+		}
+		for _, m := range metrics {
+			if groupBy == "author" {
+				group(m.Author).add(m.Author, m.ChangeCounts)
+				continue
+			}
+			// A version counts once for each directory, however many of
+			// its subdirectories it touched
+			touched := make(map[string]storage.ChangeCounts)
+			for dir, counts := range m.Directories {
+				key := activityDirectory(dir, depth)
+				sum := touched[key]
+				sum.Files += counts.Files
+				sum.Insertions += counts.Insertions
+				sum.Deletions += counts.Deletions
+				sum.BytesAdded += counts.BytesAdded
+				touched[key] = sum
+			}
+			for key, counts := range touched {
+				group(key).add(m.Author, counts)
+			}
+		}
+
+		for _, g := range groups {
+			resp.Groups = append(resp.Groups, g.group)
+		}
+		sort.Slice(resp.Groups, func(i, j int) bool {
+			a, b := resp.Groups[i], resp.Groups[j]
+			if a.Commits != b.Commits {
+				return a.Commits > b.Commits
+			}
+			if a.FilesChanged != b.FilesChanged {
+				return a.FilesChanged > b.FilesChanged
+			}
+			return a.Key < b.Key
+		})
+		limit := int(req.Limit)
+		if limit == 0 {
+			limit = defaultActivityLimit
+		}
+		if len(resp.Groups) > limit {
+			resp.Groups = resp.Groups[:limit]
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,691 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/protobuf/proto"
+)
+
+// adminServer implements MonorepoAdminService on top of the user-facing
+// server's state. It is registered on its own listener (ADMIN_ADDR) with its
+// own credentials so operational endpoints are never reachable with a
+// regular user token.
+type adminServer struct {
+	pb.UnimplementedMonorepoAdminServiceServer
+	srv *server
+}
+
+func (a *adminServer) RunGarbageCollection(ctx context.Context, req *pb.GarbageCollectionRequest) (*pb.GarbageCollectionResponse, error) {
+	log.Printf("Admin %s: running garbage collection (dry run: %t, async: %t)", userFromContext(ctx), req.DryRun, req.Async)
+
+	if req.Async {
+		id := a.srv.runOperation(ctx, operationGC, true, func(ctx context.Context) (proto.Message, error) {
+			return a.garbageCollect(ctx, req.DryRun)
+		})
+		return &pb.GarbageCollectionResponse{OperationId: id}, nil
+	}
+	return a.garbageCollect(ctx, req.DryRun)
+}
+
+func (a *adminServer) garbageCollect(ctx context.Context, dryRun bool) (*pb.GarbageCollectionResponse, error) {
+	result, err := a.srv.repository.GarbageCollect(ctx, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("garbage collection failed: %w", err)
+	}
+
+	return &pb.GarbageCollectionResponse{
+		Scanned:    int64(result.Scanned),
+		Reachable:  int64(result.Reachable),
+		Removed:    int64(result.Removed),
+		BytesFreed: result.BytesFreed,
+	}, nil
+}
+
+func (a *adminServer) Fsck(ctx context.Context, req *pb.FsckRequest) (*pb.FsckResponse, error) {
+	log.Printf("Admin %s: running fsck (async: %t)", userFromContext(ctx), req.Async)
+
+	if req.Async {
+		id := a.srv.runOperation(ctx, operationFsck, true, func(ctx context.Context) (proto.Message, error) {
+			return a.fsck(ctx)
+		})
+		return &pb.FsckResponse{OperationId: id}, nil
+	}
+	return a.fsck(ctx)
+}
+
+func (a *adminServer) fsck(ctx context.Context) (*pb.FsckResponse, error) {
+	result, err := a.srv.repository.Fsck(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fsck failed: %w", err)
+	}
+
+	objectsByAlgorithm := make(map[string]int64, len(result.ObjectsByAlgorithm))
+	for algorithm, count := range result.ObjectsByAlgorithm {
+		objectsByAlgorithm[string(algorithm)] = int64(count)
+	}
+	objectsByFormat := make(map[string]int64, len(result.ObjectsByFormat))
+	for format, count := range result.ObjectsByFormat {
+		objectsByFormat[format.String()] = int64(count)
+	}
+
+	return &pb.FsckResponse{
+		ObjectsChecked:     int64(result.ObjectsChecked),
+		VersionsChecked:    int64(result.VersionsChecked),
+		Problems:           result.Problems,
+		HashAlgorithm:      string(result.HashAlgorithm),
+		ObjectsByAlgorithm: objectsByAlgorithm,
+		HashFormat:         result.HashFormat.String(),
+		ObjectsByFormat:    objectsByFormat,
+	}, nil
+}
+
+func (a *adminServer) GetBackendStats(ctx context.Context, req *pb.BackendStatsRequest) (*pb.BackendStatsResponse, error) {
+	log.Printf("Admin %s: getting backend stats", userFromContext(ctx))
+
+	stats, err := a.srv.repository.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backend stats: %w", err)
+	}
+
+	locks, err := a.srv.locks.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locks: %w", err)
+	}
+
+	a.srv.mu.RLock()
+	workspaces := len(a.srv.workspaces)
+	a.srv.mu.RUnlock()
+
+	resp := &pb.BackendStatsResponse{
+		Objects:        int64(stats.Objects),
+		Blobs:          int64(stats.Blobs),
+		Trees:          int64(stats.Trees),
+		Commits:        int64(stats.Commits),
+		ObjectBytes:    stats.ObjectBytes,
+		Versions:       int64(stats.Versions),
+		CurrentVersion: stats.CurrentVersion,
+		Keys:           int64(stats.Keys),
+		TotalBytes:     stats.TotalBytes,
+		Workspaces:     int64(workspaces),
+		Locks:          int64(len(locks)),
+	}
+	if a.srv.archiveCache != nil {
+		cache := a.srv.archiveCache.Stats()
+		resp.ArchiveCacheEntries = int64(cache.Entries)
+		resp.ArchiveCacheBytes = cache.Bytes
+		resp.ArchiveCacheMaxBytes = cache.MaxBytes
+		resp.ArchiveCacheHits = cache.Hits
+		resp.ArchiveCacheMisses = cache.Misses
+	}
+	return resp, nil
+}
+
+func (a *adminServer) SetUserQuota(ctx context.Context, req *pb.SetUserQuotaRequest) (*pb.SetUserQuotaResponse, error) {
+	log.Printf("Admin %s: setting quota for user %s", userFromContext(ctx), req.User)
+
+	if req.User == "" {
+		return &pb.SetUserQuotaResponse{
+			Success: false,
+			Message: "User is required",
+		}, nil
+	}
+	if a.srv.quotas == nil {
+		return &pb.SetUserQuotaResponse{
+			Success: false,
+			Message: "Quotas are not enabled on this server",
+		}, nil
+	}
+
+	a.srv.quotas.SetUserLimits(req.User, QuotaLimits{
+		MaxWorkspaceBytes:   req.MaxWorkspaceBytes,
+		MaxUserBytes:        req.MaxUserBytes,
+		MaxUserWorkspaces:   req.MaxUserWorkspaces,
+		MaxFileBytes:        req.MaxFileBytes,
+		MaxTrackedPathBytes: req.MaxTrackedPathBytes,
+		AllowSizeOverride:   req.AllowSizeOverride,
+
+		MaxWorkspaceDiskBytes: req.MaxWorkspaceDiskBytes,
+	})
+
+	return &pb.SetUserQuotaResponse{
+		Success: true,
+		Message: fmt.Sprintf("Quota updated for %s", req.User),
+	}, nil
+}
+
+func (a *adminServer) SetWorkspaceQuota(ctx context.Context, req *pb.SetWorkspaceQuotaRequest) (*pb.SetWorkspaceQuotaResponse, error) {
+	log.Printf("Admin %s: setting quota for workspace %s", userFromContext(ctx), req.WorkspaceId)
+
+	a.srv.mu.Lock()
+	defer a.srv.mu.Unlock()
+
+	workspace, exists := a.srv.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.SetWorkspaceQuotaResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	if req.MaxBytes > 0 {
+		if workspace.Metadata == nil {
+			workspace.Metadata = make(map[string]string)
+		}
+		workspace.Metadata[quotaMaxBytesMetadataKey] = strconv.FormatInt(req.MaxBytes, 10)
+	} else {
+		delete(workspace.Metadata, quotaMaxBytesMetadataKey)
+	}
+
+	return &pb.SetWorkspaceQuotaResponse{
+		Success: true,
+		Message: fmt.Sprintf("Quota updated for workspace %s", req.WorkspaceId),
+	}, nil
+}
+
+func (a *adminServer) ForceUnlockPath(ctx context.Context, req *pb.ForceUnlockPathRequest) (*pb.ForceUnlockPathResponse, error) {
+	log.Printf("Admin %s: force unlocking path %s", userFromContext(ctx), req.Path)
+
+	path := strings.Trim(req.Path, "/")
+	locks, err := a.srv.locks.List(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locks: %w", err)
+	}
+
+	var removed *pb.PathLock
+	for _, lock := range locks {
+		if lock.Path == path {
+			removed = lockToProto(lock)
+		}
+	}
+	if removed == nil {
+		return &pb.ForceUnlockPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("%s is not locked", req.Path),
+		}, nil
+	}
+
+	if err := a.srv.locks.Release(ctx, path, "", true); err != nil {
+		return nil, fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	return &pb.ForceUnlockPathResponse{
+		Success: true,
+		Message: fmt.Sprintf("Removed lock on %s held by %s", removed.Path, removed.Owner),
+		Lock:    removed,
+	}, nil
+}
+
+func (a *adminServer) ListWorkspaces(ctx context.Context, req *pb.ListWorkspacesRequest) (*pb.ListWorkspacesResponse, error) {
+	log.Printf("Admin %s: listing workspaces (stale: %ds, diverged only: %t)", userFromContext(ctx), req.StaleSeconds, req.DivergedOnly)
+
+	if req.StaleSeconds < 0 {
+		return nil, fmt.Errorf("stale seconds must not be negative")
+	}
+	cutoff := time.Now().Add(-time.Duration(req.StaleSeconds) * time.Second)
+
+	a.srv.mu.RLock()
+	defer a.srv.mu.RUnlock()
+
+	workspaces := []*pb.WorkspaceInfo{}
+	for _, workspace := range a.srv.workspaces {
+		if req.StaleSeconds > 0 && !workspace.LastSync.Before(cutoff) {
+			continue
+		}
+		if req.DivergedOnly && !workspace.Diverged {
+			continue
+		}
+		if req.Owner != "" && workspace.Owner != req.Owner {
+			continue
+		}
+		workspaces = append(workspaces, workspaceToProto(workspace))
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].Id < workspaces[j].Id })
+	return &pb.ListWorkspacesResponse{Workspaces: workspaces}, nil
+}
+
+func (a *adminServer) ReapWorkspaces(ctx context.Context, req *pb.ReapWorkspacesRequest) (*pb.ReapWorkspacesResponse, error) {
+	log.Printf("Admin %s: reaping workspaces idle for %ds (dry run: %t)", userFromContext(ctx), req.MaxIdleSeconds, req.DryRun)
+
+	if req.MaxIdleSeconds <= 0 {
+		return nil, fmt.Errorf("max idle seconds must be positive")
+	}
+
+	cutoff := time.Now().Add(-time.Duration(req.MaxIdleSeconds) * time.Second)
+
+	a.srv.mu.Lock()
+	defer a.srv.mu.Unlock()
+
+	reaped := []string{}
+	for id, workspace := range a.srv.workspaces {
+		lastActive := workspace.LastSync
+		if lastActive.IsZero() {
+			lastActive = workspace.CreatedAt
+		}
+		if !lastActive.Before(cutoff) {
+			continue
+		}
+
+		reaped = append(reaped, id)
+		if req.DryRun {
+			continue
+		}
+
+		workspace.stopMaterializing()
+		delete(a.srv.workspaces, id)
+		a.srv.unlinkWorkspaceName(workspace)
+		if workspace.GitRepoPath != "" {
+			if err := os.RemoveAll(workspace.GitRepoPath); err != nil {
+				log.Printf("Warning: failed to remove workspace directory %s: %v", workspace.GitRepoPath, err)
+			}
+		}
+	}
+
+	sort.Strings(reaped)
+	return &pb.ReapWorkspacesResponse{WorkspaceIds: reaped}, nil
+}
+
+func (a *adminServer) CollectWorkspaceDirectories(ctx context.Context, req *pb.CollectWorkspaceDirectoriesRequest) (*pb.CollectWorkspaceDirectoriesResponse, error) {
+	log.Printf("Admin %s: collecting workspace directories (grace: %ds, dry run: %t)", userFromContext(ctx), req.GraceSeconds, req.DryRun)
+
+	if req.GraceSeconds < 0 {
+		return nil, fmt.Errorf("grace seconds must not be negative")
+	}
+	grace := time.Duration(req.GraceSeconds) * time.Second
+	if grace == 0 {
+		grace = a.srv.workspaceGCGrace
+	}
+
+	orphans, err := a.srv.collectWorkspaceDirectories(grace, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.CollectWorkspaceDirectoriesResponse{Directories: []*pb.OrphanedDirectory{}}
+	for _, orphan := range orphans {
+		resp.Directories = append(resp.Directories, &pb.OrphanedDirectory{
+			Name:       orphan.Name,
+			ModifiedAt: orphan.ModifiedAt.Format(time.RFC3339),
+			Bytes:      orphan.Bytes,
+		})
+		resp.BytesFreed += orphan.Bytes
+	}
+
+	shared, err := a.srv.collectSharedObjects(ctx, grace, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	resp.SharedObjects = int64(shared.Objects)
+	resp.SharedBytesFreed = shared.Bytes
+	return resp, nil
+}
+
+func (a *adminServer) CompactWorkspaces(ctx context.Context, req *pb.CompactWorkspacesRequest) (*pb.CompactWorkspacesResponse, error) {
+	log.Printf("Admin %s: compacting workspaces (workspace: %q, depth: %d)", userFromContext(ctx), req.WorkspaceId, req.Depth)
+
+	if req.Depth < 0 {
+		return nil, fmt.Errorf("depth must not be negative")
+	}
+
+	compactions, err := a.srv.compactWorkspaces(ctx, req.WorkspaceId, int(req.Depth))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.CompactWorkspacesResponse{Workspaces: []*pb.WorkspaceCompaction{}}
+	for _, compaction := range compactions {
+		resp.Workspaces = append(resp.Workspaces, &pb.WorkspaceCompaction{
+			WorkspaceId:   compaction.ID,
+			Depth:         int64(compaction.Depth),
+			CommitsBefore: int64(compaction.CommitsBefore),
+			CommitsAfter:  int64(compaction.CommitsAfter),
+			BytesBefore:   compaction.BytesBefore,
+			BytesAfter:    compaction.BytesAfter,
+		})
+		resp.BytesFreed += compaction.BytesBefore - compaction.BytesAfter
+	}
+	return resp, nil
+}
+
+func (a *adminServer) Backup(ctx context.Context, req *pb.BackupRequest) (*pb.BackupResponse, error) {
+	log.Printf("Admin %s: backing up to %s", userFromContext(ctx), req.Destination)
+
+	if req.Destination == "" {
+		return nil, fmt.Errorf("destination is required")
+	}
+	target, err := storage.OpenBackend(req.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup destination: %w", err)
+	}
+	defer target.Close()
+
+	a.srv.mu.RLock()
+	workspaces, err := json.Marshal(a.srv.workspaces)
+	a.srv.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workspaces: %w", err)
+	}
+
+	result, err := a.srv.repository.Backup(ctx, target, workspaces)
+	if err != nil {
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+
+	return &pb.BackupResponse{
+		Snapshot:       result.Snapshot,
+		Objects:        int64(result.Objects),
+		ObjectsWritten: int64(result.ObjectsWritten),
+		BytesWritten:   result.BytesWritten,
+	}, nil
+}
+
+func (a *adminServer) Restore(ctx context.Context, req *pb.RestoreRequest) (*pb.RestoreResponse, error) {
+	log.Printf("Admin %s: restoring snapshot %q from %s", userFromContext(ctx), req.Snapshot, req.Source)
+
+	if req.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	source, err := storage.OpenBackend(req.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup source: %w", err)
+	}
+	defer source.Close()
+
+	a.srv.mu.Lock()
+	defer a.srv.mu.Unlock()
+
+	manifest, err := a.srv.repository.Restore(ctx, source, req.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("restore failed: %w", err)
+	}
+
+	// Only workspace metadata is part of a snapshot; workspace directories
+	// are recreated by clients on their next sync
+	workspaces := make(map[string]*Workspace)
+	if len(manifest.Workspaces) > 0 {
+		if err := json.Unmarshal(manifest.Workspaces, &workspaces); err != nil {
+			return nil, fmt.Errorf("failed to restore workspaces: %w", err)
+		}
+	}
+	a.srv.workspaces = workspaces
+
+	return &pb.RestoreResponse{
+		Snapshot:       manifest.ID,
+		CurrentVersion: manifest.CurrentVersion,
+		Objects:        int64(len(manifest.Objects)),
+		Workspaces:     int64(len(workspaces)),
+	}, nil
+}
+
+func (a *adminServer) MigrateBackend(ctx context.Context, req *pb.MigrateBackendRequest) (*pb.MigrateBackendResponse, error) {
+	log.Printf("Admin %s: migrating backend to %s (async: %t)", userFromContext(ctx), req.Destination, req.Async)
+
+	if req.Destination == "" {
+		return nil, fmt.Errorf("destination is required")
+	}
+	dst, err := storage.OpenBackend(req.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open destination: %w", err)
+	}
+
+	if req.Async {
+		id := a.srv.runOperation(ctx, operationMigrateBackend, true, func(ctx context.Context) (proto.Message, error) {
+			defer dst.Close()
+			return a.migrateBackend(ctx, dst)
+		})
+		return &pb.MigrateBackendResponse{OperationId: id}, nil
+	}
+	defer dst.Close()
+	return a.migrateBackend(ctx, dst)
+}
+
+func (a *adminServer) migrateBackend(ctx context.Context, dst storage.StorageBackend) (*pb.MigrateBackendResponse, error) {
+	result, err := a.srv.repository.MigrateTo(ctx, dst)
+	if err != nil {
+		return nil, fmt.Errorf("migration failed after copying %d objects: %w", result.ObjectsCopied, err)
+	}
+
+	return &pb.MigrateBackendResponse{
+		Objects:         int64(result.Objects),
+		ObjectsCopied:   int64(result.ObjectsCopied),
+		Metadata:        int64(result.Metadata),
+		MetadataRemoved: int64(result.MetadataRemoved),
+		BytesCopied:     result.BytesCopied,
+	}, nil
+}
+
+func (a *adminServer) RehashObjects(ctx context.Context, req *pb.RehashObjectsRequest) (*pb.RehashObjectsResponse, error) {
+	log.Printf("Admin %s: rehashing objects with %q", userFromContext(ctx), req.Algorithm)
+
+	// An empty algorithm keeps the repository's and migrates only the format
+	var algorithm storage.HashAlgorithm
+	if req.Algorithm != "" {
+		var err error
+		if algorithm, err = storage.ParseHashAlgorithm(req.Algorithm); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := a.srv.repository.Rehash(ctx, algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("rehash failed after writing %d objects: %w", result.Objects, err)
+	}
+	if err := a.srv.refreshTagCommits(ctx); err != nil {
+		return nil, err
+	}
+
+	return &pb.RehashObjectsResponse{
+		Algorithm:    string(result.Algorithm),
+		Objects:      int64(result.Objects),
+		Unchanged:    int64(result.Unchanged),
+		Versions:     int64(result.Versions),
+		TrashEntries: int64(result.TrashEntries),
+		HashFormat:   result.Format.String(),
+	}, nil
+}
+
+func (a *adminServer) PruneHistory(ctx context.Context, req *pb.PruneHistoryRequest) (*pb.PruneHistoryResponse, error) {
+	log.Printf("Admin %s: pruning history (keep %d days, tagged: %t, versions: %v, dry run: %t)",
+		userFromContext(ctx), req.KeepDays, req.KeepTagged, req.KeepVersions, req.DryRun)
+
+	if req.KeepDays < 0 {
+		return nil, fmt.Errorf("keep_days must not be negative")
+	}
+
+	policy := storage.RetentionPolicy{KeepVersions: make(map[int64]bool)}
+	if req.KeepDays > 0 {
+		policy.KeepSince = time.Now().AddDate(0, 0, -int(req.KeepDays))
+	}
+	for _, version := range req.KeepVersions {
+		policy.KeepVersions[version] = true
+	}
+	// Workspaces read their synced version until they sync again
+	a.srv.mu.RLock()
+	for _, workspace := range a.srv.workspaces {
+		if workspace.SyncedVersion > 0 {
+			policy.KeepVersions[workspace.SyncedVersion] = true
+		}
+	}
+	a.srv.mu.RUnlock()
+	if req.KeepTagged && a.srv.tags != nil {
+		tags, err := a.srv.tags.List(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			policy.KeepVersions[tag.Version] = true
+		}
+	}
+
+	result, err := a.srv.repository.PruneHistory(ctx, policy, req.DryRun)
+	if err != nil {
+		return nil, fmt.Errorf("history pruning failed (re-run to resume): %w", err)
+	}
+	if !req.DryRun {
+		if err := a.srv.refreshTagCommits(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pb.PruneHistoryResponse{
+		Kept:       int64(result.Kept),
+		Pruned:     int64(result.Pruned),
+		Rewritten:  int64(result.Rewritten),
+		OldestKept: result.OldestKept,
+	}, nil
+}
+
+func (a *adminServer) ListCorruptObjects(ctx context.Context, req *pb.ListCorruptObjectsRequest) (*pb.ListCorruptObjectsResponse, error) {
+	log.Printf("Admin %s: listing corrupt objects", userFromContext(ctx))
+
+	records, err := a.srv.repository.CorruptObjects(ctx, req.IncludeRepaired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list corrupt objects: %w", err)
+	}
+
+	resp := &pb.ListCorruptObjectsResponse{}
+	for _, record := range records {
+		object := &pb.CorruptObject{
+			Hash:        string(record.Hash),
+			DetectedAt:  record.DetectedAt.Unix(),
+			LastSeenAt:  record.LastSeenAt.Unix(),
+			Detections:  int32(record.Detections),
+			Error:       record.Error,
+			RepairError: record.RepairError,
+		}
+		if record.RepairedAt != nil {
+			object.RepairedAt = record.RepairedAt.Unix()
+		}
+		resp.Objects = append(resp.Objects, object)
+	}
+	return resp, nil
+}
+
+func replicaStatuses(statuses []storage.ReplicaStatus) []*pb.ReplicaStatus {
+	var replicas []*pb.ReplicaStatus
+	for _, status := range statuses {
+		replica := &pb.ReplicaStatus{
+			Name:       status.Name,
+			Active:     status.Active,
+			Pending:    int64(status.Pending),
+			LagSeconds: status.Lag.Seconds(),
+			Replicated: status.Replicated,
+			Failures:   status.Failures,
+			LastError:  status.LastError,
+			Resync:     status.Resync,
+		}
+		if !status.LastReplicatedAt.IsZero() {
+			replica.LastReplicatedAt = status.LastReplicatedAt.Unix()
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+func (a *adminServer) GetReplicationStatus(ctx context.Context, req *pb.GetReplicationStatusRequest) (*pb.GetReplicationStatusResponse, error) {
+	log.Printf("Admin %s: getting replication status", userFromContext(ctx))
+
+	if a.srv.replication == nil {
+		return &pb.GetReplicationStatusResponse{}, nil
+	}
+	return &pb.GetReplicationStatusResponse{Replicas: replicaStatuses(a.srv.replication.Status())}, nil
+}
+
+func (a *adminServer) FailoverBackend(ctx context.Context, req *pb.FailoverBackendRequest) (*pb.FailoverBackendResponse, error) {
+	log.Printf("Admin %s: failing storage over to %s (force: %t)", userFromContext(ctx), req.Name, req.Force)
+
+	if a.srv.replication == nil {
+		return nil, fmt.Errorf("the storage backend has no replicas (REPLICA_BACKENDS)")
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := a.srv.replication.Failover(req.Name, req.Force); err != nil {
+		return nil, err
+	}
+	// Version metadata cached from the old backend may be ahead of the new one
+	if invalidator, ok := a.srv.repository.(interface{ Invalidate() }); ok {
+		invalidator.Invalidate()
+	}
+	return &pb.FailoverBackendResponse{Replicas: replicaStatuses(a.srv.replication.Status())}, nil
+}
+
+func (a *adminServer) ResyncReplica(ctx context.Context, req *pb.ResyncReplicaRequest) (*pb.ResyncReplicaResponse, error) {
+	log.Printf("Admin %s: resyncing replica %s", userFromContext(ctx), req.Name)
+
+	if a.srv.replication == nil {
+		return nil, fmt.Errorf("the storage backend has no replicas (REPLICA_BACKENDS)")
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := a.srv.replication.Resync(req.Name); err != nil {
+		return nil, err
+	}
+	return &pb.ResyncReplicaResponse{Replicas: replicaStatuses(a.srv.replication.Status())}, nil
+}
+
+func (a *adminServer) GetOperation(ctx context.Context, req *pb.GetOperationRequest) (*pb.GetOperationResponse, error) {
+	log.Printf("Admin %s: getting operation %s", userFromContext(ctx), req.OperationId)
+
+	op, err := a.srv.operationRPC(ctx, req.OperationId, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s", operationMessage(req.OperationId, err))
+	}
+	return &pb.GetOperationResponse{Success: true, Operation: op}, nil
+}
+
+func (a *adminServer) WaitOperation(ctx context.Context, req *pb.WaitOperationRequest) (*pb.WaitOperationResponse, error) {
+	log.Printf("Admin %s: waiting for operation %s (timeout %ds)", userFromContext(ctx), req.OperationId, req.TimeoutSeconds)
+
+	op, err := a.srv.operationRPC(ctx, req.OperationId, operationWait(req.TimeoutSeconds), true)
+	if err != nil {
+		return nil, fmt.Errorf("%s", operationMessage(req.OperationId, err))
+	}
+	return &pb.WaitOperationResponse{Success: true, Operation: op}, nil
+}
+
+func (a *adminServer) CancelOperation(ctx context.Context, req *pb.CancelOperationRequest) (*pb.CancelOperationResponse, error) {
+	log.Printf("Admin %s: cancelling operation %s", userFromContext(ctx), req.OperationId)
+
+	op, err := a.srv.operations.get(ctx, req.OperationId, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s", operationMessage(req.OperationId, err))
+	}
+	if err := a.srv.operations.cancelOperation(op); err != nil {
+		return nil, err
+	}
+	return &pb.CancelOperationResponse{
+		Success: true,
+		Message: fmt.Sprintf("Cancelling operation %s", req.OperationId),
+	}, nil
+}
+
+func (a *adminServer) ListOperations(ctx context.Context, req *pb.ListOperationsRequest) (*pb.ListOperationsResponse, error) {
+	log.Printf("Admin %s: listing operations (running only: %t)", userFromContext(ctx), req.RunningOnly)
+
+	records, err := a.srv.operations.list(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations: %w", err)
+	}
+	resp := &pb.ListOperationsResponse{}
+	for _, record := range records {
+		if req.RunningOnly && record.Done() {
+			continue
+		}
+		info := operationToProto(record)
+		info.Response = nil // Fetched with GetOperation
+		resp.Operations = append(resp.Operations, info)
+	}
+	return resp, nil
+}
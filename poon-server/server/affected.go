@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+func (s *server) GetAffectedPaths(ctx context.Context, req *pb.GetAffectedPathsRequest) (*pb.GetAffectedPathsResponse, error) {
+	log.Printf("Getting affected paths from version %d to %d (depth %d)", req.FromVersion, req.ToVersion, req.Depth)
+
+	if req.FromVersion < 0 {
+		return nil, invalidArgumentError("from_version", "must not be negative")
+	}
+	if req.ToVersion < 0 {
+		return nil, invalidArgumentError("to_version", "must not be negative")
+	}
+	if req.Depth < 0 {
+		return nil, invalidArgumentError("depth", "must not be negative")
+	}
+	depth := int(req.Depth)
+	if depth == 0 {
+		depth = 1
+	}
+
+	toVersion, err := s.resolveVersion(ctx, req.ToVersion)
+	if err != nil {
+		return nil, err
+	}
+	if req.FromVersion > toVersion {
+		return nil, invalidArgumentError("from_version", fmt.Sprintf("%d is after to_version %d", req.FromVersion, toVersion))
+	}
+
+	// Renames are left as a deletion and an addition so both the old and
+	// the new location count as affected
+	changes, err := s.repository.DiffVersions(ctx, req.FromVersion, toVersion, storage.DiffOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff versions: %w", err)
+	}
+
+	counts := make(map[string]int32)
+	for _, change := range changes {
+		counts[pathPrefix(change.Path, depth)]++
+	}
+
+	resp := &pb.GetAffectedPathsResponse{
+		FromVersion:  req.FromVersion,
+		ToVersion:    toVersion,
+		FilesChanged: int32(len(changes)),
+	}
+	for prefix, count := range counts {
+		resp.Paths = append(resp.Paths, &pb.AffectedPath{Path: prefix, FilesChanged: count})
+	}
+	sort.Slice(resp.Paths, func(i, j int) bool { return resp.Paths[i].Path < resp.Paths[j].Path })
+	return resp, nil
+}
+
+// pathPrefix returns the first depth components of a slash-separated path
+func pathPrefix(path string, depth int) string {
+	parts := strings.SplitN(path, "/", depth+1)
+	if len(parts) <= depth {
+		return path
+	}
+	return strings.Join(parts[:depth], "/")
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Line endings in the monorepo follow .poonattributes (see
+// storage.AttributesFile): text files are stored with LF and checked out
+// with the platform's line ending. Workspaces are git repositories, so their
+// checkouts get the same rules as a .gitattributes file; git then converts
+// line endings on checkout and normalizes them again on commit, and a
+// Windows user's changes do not rewrite every line. The diff and merge
+// attributes are copied too, so git diffs and merges files as the monorepo
+// patches them.
+//
+// "store" is not copied, since it only says how the monorepo keeps a file
+// and git has no such attribute, and neither is "export-ignore", since
+// bootstrap archives of workspaces must hold every tracked file.
+
+// workspaceAttributes are the attributes copied into workspace .gitattributes
+var workspaceAttributes = []string{"text", "eol", "diff", "merge"}
+
+// writeGitAttributes writes the attribute rules at version to the
+// workspace repository's .gitattributes, or removes it if there are none
+func (s *server) writeGitAttributes(ctx context.Context, version int64, gitRepoPath string) error {
+	rules, err := s.repository.Attributes(ctx, version)
+	if err != nil {
+		return fmt.Errorf("failed to read attributes: %w", err)
+	}
+
+	attributesPath := filepath.Join(gitRepoPath, ".gitattributes")
+	lines := rules.GitAttributes(workspaceAttributes...)
+	if lines == "" {
+		if err := os.Remove(attributesPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove .gitattributes: %w", err)
+		}
+		return nil
+	}
+
+	content := "# Generated by poon from the monorepo's .poonattributes\n" + lines
+	if err := os.WriteFile(attributesPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to create .gitattributes: %w", err)
+	}
+	return nil
+}
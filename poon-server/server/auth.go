@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "poon-user"
+
+// Authenticator validates bearer tokens attached to incoming requests.
+// A nil or empty Authenticator accepts all requests anonymously.
+type Authenticator struct {
+	tokens map[string]string // token -> user
+}
+
+// NewAuthenticator creates an authenticator from a token -> user mapping
+func NewAuthenticator(tokens map[string]string) *Authenticator {
+	return &Authenticator{tokens: tokens}
+}
+
+// LoadAuthenticator reads a JSON file mapping tokens to user names
+func LoadAuthenticator(path string) (*Authenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth tokens file: %w", err)
+	}
+
+	var tokens map[string]string
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse auth tokens file: %w", err)
+	}
+
+	return NewAuthenticator(tokens), nil
+}
+
+// Enabled reports whether the server requires authentication
+func (a *Authenticator) Enabled() bool {
+	return a != nil && len(a.tokens) > 0
+}
+
+// authenticate resolves the user for the bearer token in ctx
+func (a *Authenticator) authenticate(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", credentialsError("missing")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", credentialsError("missing")
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer "))
+	user, exists := a.tokens[token]
+	if !exists {
+		return "", credentialsError("invalid")
+	}
+
+	return user, nil
+}
+
+// publicMethods are served without credentials, so a client can learn how to
+// authenticate before it has a token
+var publicMethods = map[string]bool{
+	pb.MonorepoService_GetServerInfo_FullMethodName: true,
+}
+
+// credentialsError rejects a request whose credentials are missing or invalid
+func credentialsError(problem string) error {
+	return detailedError(codes.Unauthenticated, problem+" credentials (run 'poon login')", ReasonUnauthenticated,
+		map[string]string{"credentials": problem})
+}
+
+// UnaryInterceptor rejects unauthenticated requests when auth is enabled and
+// records the authenticated user in the request context
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !a.Enabled() || publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		user, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(context.WithValue(ctx, userContextKey, user), req)
+	}
+}
+
+// StreamInterceptor is the streaming counterpart of UnaryInterceptor
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !a.Enabled() {
+			return handler(srv, ss)
+		}
+
+		user, err := a.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), userContextKey, user)})
+	}
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// userFromContext returns the authenticated user, or "" for anonymous requests
+func userFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userContextKey).(string)
+	return user
+}
+
+func (s *server) WhoAmI(ctx context.Context, req *pb.WhoAmIRequest) (*pb.WhoAmIResponse, error) {
+	user := userFromContext(ctx)
+	return &pb.WhoAmIResponse{
+		User:          user,
+		Authenticated: user != "",
+		AuthRequired:  s.auth.Enabled(),
+	}, nil
+}
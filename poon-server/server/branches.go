@@ -0,0 +1,384 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+func (s *server) GetBranches(ctx context.Context, req *pb.BranchesRequest) (*pb.BranchesResponse, error) {
+	log.Printf("Getting branches")
+
+	branches, err := s.repository.ListBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	resp := &pb.BranchesResponse{
+		Branches:      []string{storage.MainBranch},
+		DefaultBranch: storage.MainBranch,
+	}
+	for _, branch := range branches {
+		resp.Branches = append(resp.Branches, branch.Name)
+	}
+	return resp, nil
+}
+
+// CreateBranch starts a branch at from_commit, or else at the head of
+// from_branch, main by default
+func (s *server) CreateBranch(ctx context.Context, req *pb.CreateBranchRequest) (*pb.CreateBranchResponse, error) {
+	log.Printf("Creating branch: %s", req.Name)
+
+	from := storage.Hash(req.FromCommit)
+	if from == "" {
+		fromBranch := req.FromBranch
+		if fromBranch == "" {
+			fromBranch = storage.MainBranch
+		}
+		head, err := s.repository.BranchHead(ctx, fromBranch)
+		if err != nil {
+			return &pb.CreateBranchResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to read branch %s: %v", fromBranch, err),
+			}, nil
+		}
+		from = head
+	}
+
+	branch, err := s.repository.CreateBranch(ctx, req.Name, from, userFromContext(ctx))
+	if err != nil {
+		return &pb.CreateBranchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create branch: %v", err),
+		}, nil
+	}
+
+	return &pb.CreateBranchResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Branch '%s' created successfully", branch.Name),
+		BranchName: branch.Name,
+		CommitHash: string(branch.CommitHash),
+	}, nil
+}
+
+// mergePatchToBranch commits a patch that passed MergePatch's lock and
+// protection checks to a branch other than main, or amends the branch's
+// newest commit with it
+func (s *server) mergePatchToBranch(ctx context.Context, req *pb.MergePatchRequest) (*pb.MergePatchResponse, error) {
+	opts := merge.ApplyOptions{
+		IgnoreWhitespace:        req.IgnoreWhitespace,
+		NormalizeLineEndings:    req.NormalizeLineEndings,
+		PreserveTrailingNewline: req.PreserveTrailingNewline,
+	}
+
+	var branch *storage.Branch
+	var err error
+	if req.Amend {
+		branch, err = s.repository.AmendBranch(ctx, req.Branch, req.Patch, req.Author, req.Message, opts)
+	} else {
+		branch, err = s.repository.ApplyPatchToBranch(ctx, req.Branch, req.Patch, req.Author, req.Message, opts)
+	}
+	if errors.Is(err, storage.ErrBranchNotFound) {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to apply patch: %v", err),
+			Failure: branchNotFoundFailure(req.Branch),
+		}, nil
+	}
+	if err != nil {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to apply patch: %v", err),
+		}, nil
+	}
+
+	if req.Amend {
+		log.Printf("Amended the newest commit of branch %s as %s", branch.Name, branch.CommitHash)
+		return &pb.MergePatchResponse{
+			Success:    true,
+			Message:    fmt.Sprintf("Amended the newest commit of branch %s", branch.Name),
+			CommitHash: string(branch.CommitHash),
+		}, nil
+	}
+
+	log.Printf("Successfully applied patch to branch %s as commit %s", branch.Name, branch.CommitHash)
+	return &pb.MergePatchResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Patch applied successfully to branch %s", branch.Name),
+		CommitHash: string(branch.CommitHash),
+	}, nil
+}
+
+// MergeBranches merges source_branch into target_branch, main by default.
+// The target's branch protection rules are checked against every file the
+// merge changes; a merge carries no patch to approve or sign, so paths whose
+// rules need approvals or signatures cannot be merged into. A merge into main
+// also goes through the validators, patch limits, locks and merge queue that
+// guard patches to main.
+func (s *server) MergeBranches(ctx context.Context, req *pb.MergeBranchesRequest) (*pb.MergeBranchesResponse, error) {
+	target := req.TargetBranch
+	if target == "" {
+		target = storage.MainBranch
+	}
+	log.Printf("Merging branch %s into %s", req.SourceBranch, target)
+
+	if req.Message != "" {
+		if violations := s.commitPolicy.Check(req.Message); len(violations) > 0 {
+			log.Printf("Rejected merge message for %s into %s", req.SourceBranch, target)
+			return nil, commitMessageError(violations)
+		}
+	}
+
+	preview, err := s.repository.PreviewMerge(ctx, req.SourceBranch, target)
+	if err != nil {
+		return s.mergeFailure(ctx, req.SourceBranch, target, err), nil
+	}
+	if !preview.UpToDate {
+		targets := make([]string, 0, len(preview.Changes))
+		for _, change := range preview.Changes {
+			targets = append(targets, change.Path)
+		}
+		change := &pb.MergePatchRequest{Author: req.Author, Message: req.Message, Branch: target}
+		if violations := s.protectionViolations(ctx, targets, change); len(violations) > 0 {
+			log.Printf("Rejected merge of %s into %s: %s", req.SourceBranch, target, formatViolations(violations))
+			return &pb.MergeBranchesResponse{
+				Success:    false,
+				Message:    fmt.Sprintf("Merge rejected by branch protection: %s", formatViolations(violations)),
+				Violations: violations,
+				Failure:    policyFailure("branch_protection"),
+			}, nil
+		}
+	}
+
+	// Validation, the patch limits and the merge queue guard main, for
+	// merged changes as for patches
+	if target == storage.MainBranch && !preview.UpToDate {
+		rejection, err := s.checkMainline(ctx, "Merge", preview.Changes, req.Author, req.Message, req.FailIfLocked)
+		if err != nil {
+			log.Printf("Rejected merge of %s into %s: %v", req.SourceBranch, target, err)
+			return nil, err
+		}
+		if rejection != nil {
+			log.Printf("Rejected merge of %s into %s: %s", req.SourceBranch, target, rejection.message)
+			return &pb.MergeBranchesResponse{
+				Success:    false,
+				Message:    rejection.message,
+				Violations: rejection.violations,
+				Failure:    rejection.failure,
+			}, nil
+		}
+
+		if s.mergeQueue != nil {
+			entry, err := s.mergeQueue.SubmitMerge(ctx, req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to queue merge: %w", err)
+			}
+			log.Printf("Queued merge of %s into %s as merge queue entry %s at position %d", req.SourceBranch, target, entry.Id, entry.Position)
+			return &pb.MergeBranchesResponse{
+				Success:      true,
+				Message:      fmt.Sprintf("Merge queued at position %d; follow it with 'poon queue status %s'", entry.Position, entry.Id),
+				MergeBase:    string(preview.Base),
+				Queued:       true,
+				QueueEntryId: entry.Id,
+			}, nil
+		}
+	}
+
+	result, err := s.repository.MergeBranches(ctx, req.SourceBranch, target, req.Author, req.Message, storage.MergeOptions{Squash: req.Squash})
+	if err != nil {
+		return s.mergeFailure(ctx, req.SourceBranch, target, err), nil
+	}
+
+	resp := &pb.MergeBranchesResponse{
+		Success:    true,
+		CommitHash: string(result.Commit),
+		MergeBase:  string(result.Base),
+		UpToDate:   result.UpToDate,
+	}
+	merged := "Merged"
+	if req.Squash {
+		merged = "Squashed"
+	}
+	switch {
+	case result.UpToDate:
+		resp.Message = fmt.Sprintf("%s is already up to date with %s", target, req.SourceBranch)
+	case result.Version != nil:
+		resp.Version = result.Version.Version
+		resp.Message = fmt.Sprintf("%s %s into %s, created version %d", merged, req.SourceBranch, target, result.Version.Version)
+		log.Printf("%s branch %s into %s, created version %d with commit %s", merged, req.SourceBranch, target, result.Version.Version, result.Commit)
+		s.events.Publish(versionCreated(result.Version, req.Author))
+	default:
+		resp.Message = fmt.Sprintf("%s %s into %s", merged, req.SourceBranch, target)
+		log.Printf("%s branch %s into %s with commit %s", merged, req.SourceBranch, target, result.Commit)
+	}
+	return resp, nil
+}
+
+// mergeFailure reports a merge that could not be made
+func (s *server) mergeFailure(ctx context.Context, source, target string, err error) *pb.MergeBranchesResponse {
+	resp := &pb.MergeBranchesResponse{
+		Success: false,
+		Message: fmt.Sprintf("Merge failed: %v", err),
+	}
+
+	var conflict *storage.MergeConflictError
+	switch {
+	case errors.As(err, &conflict):
+		resp.Conflicts = conflict.Paths
+		resp.Failure = conflictFailure(conflict)
+	case errors.Is(err, storage.ErrBranchNotFound):
+		missing := target
+		if _, err := s.repository.BranchHead(ctx, source); err != nil {
+			missing = source
+		}
+		resp.Failure = branchNotFoundFailure(missing)
+	}
+	return resp
+}
+
+// CherryPick applies the changes a commit made to its first parent to
+// target_branch, main by default, checking the commit message policy and the
+// target's branch protection rules against every file the pick changes, and
+// onto main the checks of patches to main, as MergeBranches does
+func (s *server) CherryPick(ctx context.Context, req *pb.CherryPickRequest) (*pb.CherryPickResponse, error) {
+	target := req.TargetBranch
+	if target == "" {
+		target = storage.MainBranch
+	}
+
+	commit := storage.Hash(req.CommitHash)
+	if req.Version > 0 {
+		info, err := s.repository.GetVersionInfo(ctx, req.Version)
+		if err != nil {
+			currentVersion, _ := s.repository.GetCurrentVersion(ctx)
+			return &pb.CherryPickResponse{
+				Success: false,
+				Message: fmt.Sprintf("Version %d does not exist", req.Version),
+				Failure: &pb.FailureInfo{Reason: ReasonVersionNotFound, Metadata: map[string]string{
+					"version":         strconv.FormatInt(req.Version, 10),
+					"current_version": strconv.FormatInt(currentVersion, 10),
+				}},
+			}, nil
+		}
+		commit = info.CommitHash
+	}
+	log.Printf("Cherry-picking commit %s onto %s", commit, target)
+
+	failed := func(err error) *pb.CherryPickResponse {
+		resp := &pb.CherryPickResponse{
+			Success: false,
+			Message: fmt.Sprintf("Cherry-pick failed: %v", err),
+		}
+		var conflict *storage.MergeConflictError
+		switch {
+		case errors.As(err, &conflict):
+			resp.Conflicts = conflict.Paths
+			resp.Failure = conflictFailure(conflict)
+		case errors.Is(err, storage.ErrBranchNotFound):
+			resp.Failure = branchNotFoundFailure(target)
+		}
+		return resp
+	}
+
+	preview, err := s.repository.PreviewCherryPick(ctx, commit, target)
+	if err != nil {
+		return failed(err), nil
+	}
+	picked, err := s.repository.GetCommit(ctx, commit)
+	if err != nil {
+		return failed(err), nil
+	}
+	message := storage.CherryPickMessage(picked.Message, commit)
+	if violations := s.commitPolicy.Check(message); len(violations) > 0 {
+		log.Printf("Rejected cherry-pick message of %s onto %s", commit, target)
+		return nil, commitMessageError(violations)
+	}
+	targets := make([]string, 0, len(preview.Changes))
+	for _, change := range preview.Changes {
+		targets = append(targets, change.Path)
+	}
+	change := &pb.MergePatchRequest{Author: req.Author, Message: message, Branch: target}
+	if violations := s.protectionViolations(ctx, targets, change); len(violations) > 0 {
+		log.Printf("Rejected cherry-pick of %s onto %s: %s", commit, target, formatViolations(violations))
+		return &pb.CherryPickResponse{
+			Success:    false,
+			Message:    fmt.Sprintf("Cherry-pick rejected by branch protection: %s", formatViolations(violations)),
+			Violations: violations,
+			Failure:    policyFailure("branch_protection"),
+		}, nil
+	}
+
+	if target == storage.MainBranch && len(preview.Changes) > 0 {
+		rejection, err := s.checkMainline(ctx, "Cherry-pick", preview.Changes, req.Author, message, req.FailIfLocked)
+		if err != nil {
+			log.Printf("Rejected cherry-pick of %s onto %s: %v", commit, target, err)
+			return nil, err
+		}
+		if rejection != nil {
+			log.Printf("Rejected cherry-pick of %s onto %s: %s", commit, target, rejection.message)
+			return &pb.CherryPickResponse{
+				Success:    false,
+				Message:    rejection.message,
+				Violations: rejection.violations,
+				Failure:    rejection.failure,
+			}, nil
+		}
+
+		if s.mergeQueue != nil {
+			entry, err := s.mergeQueue.SubmitPick(ctx, commit, req.Author, message)
+			if err != nil {
+				return nil, fmt.Errorf("failed to queue cherry-pick: %w", err)
+			}
+			log.Printf("Queued cherry-pick of %s onto %s as merge queue entry %s at position %d", commit, target, entry.Id, entry.Position)
+			return &pb.CherryPickResponse{
+				Success:      true,
+				Message:      fmt.Sprintf("Cherry-pick queued at position %d; follow it with 'poon queue status %s'", entry.Position, entry.Id),
+				Queued:       true,
+				QueueEntryId: entry.Id,
+			}, nil
+		}
+	}
+
+	result, err := s.repository.CherryPick(ctx, commit, target, req.Author)
+	if err != nil {
+		return failed(err), nil
+	}
+
+	resp := &pb.CherryPickResponse{
+		Success:    true,
+		CommitHash: string(result.Commit),
+		Empty:      result.Empty,
+	}
+	switch {
+	case result.Empty:
+		resp.Message = fmt.Sprintf("%s already has the changes of %s", target, commit)
+	case result.Version != nil:
+		resp.Version = result.Version.Version
+		resp.Message = fmt.Sprintf("Applied %s to %s, created version %d", commit, target, result.Version.Version)
+		log.Printf("Cherry-picked %s onto %s, created version %d with commit %s", commit, target, result.Version.Version, result.Commit)
+		s.events.Publish(versionCreated(result.Version, req.Author))
+	default:
+		resp.Message = fmt.Sprintf("Applied %s to %s", commit, target)
+		log.Printf("Cherry-picked %s onto %s as commit %s", commit, target, result.Commit)
+	}
+	return resp, nil
+}
+
+// conflictFailure describes a merge or cherry-pick the target's changes
+// conflict with
+func conflictFailure(conflict *storage.MergeConflictError) *pb.FailureInfo {
+	return &pb.FailureInfo{Reason: ReasonMergeConflict, Metadata: map[string]string{
+		"source": conflict.Source,
+		"target": conflict.Target,
+	}}
+}
+
+func branchNotFoundFailure(branch string) *pb.FailureInfo {
+	return &pb.FailureInfo{Reason: ReasonBranchNotFound, Metadata: map[string]string{"branch": branch}}
+}
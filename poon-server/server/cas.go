@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc/metadata"
+)
+
+// casCacheControl marks content-addressed responses as cacheable forever:
+// the bytes behind a hash never change
+const casCacheControl = "public, max-age=31536000, immutable"
+
+// casHandler serves stored objects over HTTP by hash, so build systems such
+// as Bazel or Buck can declare monorepo paths as remote inputs and fetch them
+// without a workspace:
+//
+//	GET /cas/blobs/<hash>            file contents
+//	GET /cas/trees/<hash>            directory listing as JSON
+//	GET /cas/trees/<hash>.tar[.gz]   reproducible archive of a directory
+//	GET /cas/resolve?path=&version=  hash, URL and SHA-256 for a path
+//
+// Blob and tree URLs never change, so they can be pinned in build files.
+type casHandler struct {
+	srv     *server
+	hasher  *storage.Hasher
+	baseURL string // Prefix of resolved URLs; taken from the request when empty
+}
+
+// casEntry is a directory entry in a tree listing
+type casEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "file" or "directory"
+	Hash string `json:"hash"`
+	Mode int32  `json:"mode"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// casResolution is the reply to /cas/resolve
+type casResolution struct {
+	Path    string `json:"path"`
+	Version int64  `json:"version"`
+	Type    string `json:"type"` // "file" or "directory"
+	Hash    string `json:"hash"`
+	URL     string `json:"url"`    // Files resolve to the blob, directories to the .tar.gz archive
+	SHA256  string `json:"sha256"` // Checksum of the bytes served at URL
+	Size    int64  `json:"size"`   // Length of the bytes served at URL
+}
+
+func newCASHandler(srv *server, baseURL string) http.Handler {
+	h := &casHandler{srv: srv, hasher: storage.NewHasher(), baseURL: strings.TrimSuffix(baseURL, "/")}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cas/blobs/{hash}", h.serveBlob)
+	mux.HandleFunc("GET /cas/trees/{name}", h.serveTree)
+	mux.HandleFunc("GET /cas/resolve", h.resolve)
+	return h.authenticate(mux)
+}
+
+// authenticate applies the gRPC bearer tokens to HTTP requests and counts
+// them against the user's request quota
+func (h *casHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if h.srv.auth.Enabled() {
+			md := metadata.Pairs("authorization", r.Header.Get("Authorization"))
+			user, err := h.srv.auth.authenticate(metadata.NewIncomingContext(ctx, md))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="poon"`)
+				http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+				return
+			}
+			ctx = context.WithValue(ctx, userContextKey, user)
+		}
+		h.srv.quotas.recordRequest(quotaUser(ctx), "")
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *casHandler) serveBlob(w http.ResponseWriter, r *http.Request) {
+	hash := storage.Hash(r.PathValue("hash"))
+	log.Printf("CAS fetch of blob %s", hash)
+
+	if err := h.hasher.ValidateHash(hash); err != nil {
+		http.Error(w, fmt.Sprintf("invalid hash: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if h.notModified(w, r, string(hash)) {
+		return
+	}
+
+	content, size, err := h.srv.repository.OpenBlob(r.Context(), hash)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("blob %s not found", hash), http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	if r.Method != http.MethodHead {
+		if _, err := io.Copy(w, content); err != nil {
+			log.Printf("CAS fetch of blob %s failed: %v", hash, err)
+		}
+	}
+}
+
+func (h *casHandler) serveTree(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	log.Printf("CAS fetch of tree %s", name)
+
+	hash, format, _ := strings.Cut(name, ".")
+	if err := h.hasher.ValidateHash(storage.Hash(hash)); err != nil {
+		http.Error(w, fmt.Sprintf("invalid hash: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if format != "" && format != "tar" && format != "tar.gz" {
+		http.Error(w, fmt.Sprintf("unsupported archive format %q (use .tar or .tar.gz)", format), http.StatusNotFound)
+		return
+	}
+	if h.notModified(w, r, name) {
+		return
+	}
+
+	tree, err := h.srv.repository.GetTree(r.Context(), storage.Hash(hash))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("tree %s not found", hash), http.StatusNotFound)
+		return
+	}
+
+	switch format {
+	case "":
+		entries := make([]casEntry, 0, len(tree.Entries))
+		for _, entry := range tree.Entries {
+			entries = append(entries, casEntry{
+				Name: entry.Name,
+				Type: casType(entry.Type),
+				Hash: string(entry.Hash),
+				Mode: entry.Mode,
+				Size: entry.Size,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"hash": hash, "entries": entries})
+
+	default:
+		// Headers cannot be changed once the archive starts streaming, so a
+		// failure part way through can only be reported by cutting it short
+		if format == "tar" {
+			w.Header().Set("Content-Type", "application/x-tar")
+		} else {
+			w.Header().Set("Content-Type", "application/gzip")
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		if err := h.srv.writeTreeArchive(r.Context(), w, storage.Hash(hash), format); err != nil {
+			log.Printf("CAS archive of tree %s failed: %v", hash, err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func (h *casHandler) resolve(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Query().Get("path"), "/")
+	log.Printf("CAS resolve of %s at version %s", path, r.URL.Query().Get("version"))
+
+	if err := validatePath(path); err != nil {
+		http.Error(w, fmt.Sprintf("invalid path: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var version int64
+	if v := r.URL.Query().Get("version"); v != "" {
+		var err error
+		if version, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("invalid version %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	version, err := h.srv.resolveVersion(ctx, version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	entry, err := h.srv.repository.GetEntry(ctx, version, path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("path %s not found at version %d", path, version), http.StatusNotFound)
+		return
+	}
+
+	res := casResolution{
+		Path:    path,
+		Version: version,
+		Type:    casType(entry.Type),
+		Hash:    string(entry.Hash),
+	}
+
+	sum := sha256.New()
+	counter := &countingWriter{w: sum}
+	if entry.Type == storage.ObjectTypeTree {
+		res.URL = h.urlFor(r, "/cas/trees/"+string(entry.Hash)+".tar.gz")
+		err = h.srv.writeTreeArchive(ctx, counter, entry.Hash, "tar.gz")
+	} else {
+		res.URL = h.urlFor(r, "/cas/blobs/"+string(entry.Hash))
+		var content io.ReadCloser
+		if content, _, err = h.srv.repository.OpenBlob(ctx, entry.Hash); err == nil {
+			_, err = io.Copy(counter, content)
+			content.Close()
+		}
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read %s: %v", path, err), http.StatusInternalServerError)
+		return
+	}
+	res.SHA256 = hex.EncodeToString(sum.Sum(nil))
+	res.Size = counter.n
+
+	// Paths move between versions, so only pinned versions are cacheable
+	if r.URL.Query().Get("version") != "" {
+		w.Header().Set("Cache-Control", casCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// notModified sets the caching headers for an immutable response and answers
+// 304 Not Modified if the client already holds it
+func (h *casHandler) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	etag = strconv.Quote(etag)
+	w.Header().Set("Cache-Control", casCacheControl)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+func (h *casHandler) urlFor(r *http.Request, path string) string {
+	if h.baseURL != "" {
+		return h.baseURL + path
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+func casType(t storage.ObjectType) string {
+	if t == storage.ObjectTypeTree {
+		return "directory"
+	}
+	return "file"
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// changeStats summarizes what an event's version changed relative to the one
+// before it, sharing the diff with the event's subscribers. The version
+// already exists by the time this is asked, so a failure is logged and
+// leaves the stats out rather than failing the request.
+func (s *server) changeStats(ctx context.Context, event Event) *pb.ChangeStats {
+	files, err := event.Files(ctx, s.repository)
+	if err != nil {
+		log.Printf("Warning: failed to compute change stats for version %d: %v", event.Version, err)
+		return nil
+	}
+
+	stats := &pb.ChangeStats{FilesChanged: int32(len(files))}
+	for _, file := range files {
+		stats.Insertions += int32(file.Insertions)
+		stats.Deletions += int32(file.Deletions)
+		stats.Files = append(stats.Files, &pb.FileStat{
+			Path:       file.Path,
+			OldPath:    file.OldPath,
+			Status:     string(file.Type),
+			Insertions: int32(file.Insertions),
+			Deletions:  int32(file.Deletions),
+			Binary:     file.Binary,
+		})
+	}
+	return stats
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+func (s *server) ListCaseCollisions(ctx context.Context, req *pb.ListCaseCollisionsRequest) (*pb.ListCaseCollisionsResponse, error) {
+	log.Printf("Listing case collisions under: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+
+	currentVersion, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	if currentVersion == 0 {
+		return nil, fmt.Errorf("no repository versions exist - create an initial commit first")
+	}
+
+	groups, err := s.repository.CaseCollisions(ctx, currentVersion, req.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check case collisions: %w", err)
+	}
+
+	resp := &pb.ListCaseCollisionsResponse{Version: currentVersion}
+	for _, paths := range groups {
+		resp.Collisions = append(resp.Collisions, &pb.CaseCollision{Paths: paths})
+	}
+	return resp, nil
+}
+
+// checkCaseCollisions reports the first group of paths that would overwrite
+// each other if trackedPaths were materialized on a case-insensitive file
+// system. Paths that do not exist are left for the copy to report.
+func (s *server) checkCaseCollisions(ctx context.Context, version int64, trackedPaths []string) error {
+	// Tracked paths, and the directories above them, may collide with each other
+	seen := make(map[string]string)
+	for _, trackedPath := range trackedPaths {
+		var prefix string
+		for _, part := range strings.Split(cleanRepoPath(trackedPath), "/") {
+			if prefix != "" {
+				prefix += "/"
+			}
+			prefix += part
+
+			folded := storage.FoldPath(prefix)
+			if other, ok := seen[folded]; ok && other != prefix {
+				return caseCollisionError([]string{other, prefix})
+			}
+			seen[folded] = prefix
+		}
+	}
+
+	for _, trackedPath := range trackedPaths {
+		groups, err := s.repository.CaseCollisions(ctx, version, trackedPath)
+		if err != nil {
+			continue
+		}
+		if len(groups) > 0 {
+			return caseCollisionError(groups[0])
+		}
+	}
+	return nil
+}
+
+func caseCollisionError(paths []string) error {
+	return fmt.Errorf("paths differ only in case and would overwrite each other on case-insensitive file systems: %s",
+		strings.Join(paths, ", "))
+}
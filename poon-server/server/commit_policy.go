@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// CommitMessageRules configures the commit message policy. Empty fields are
+// not enforced.
+type CommitMessageRules struct {
+	Pattern          string `json:"pattern"`          // Regex the whole message must match
+	TicketPattern    string `json:"ticketPattern"`    // Regex for a ticket ID the message must contain, e.g. "[A-Z]+-[0-9]+"
+	MaxSubjectLength int    `json:"maxSubjectLength"` // Longest allowed first line
+}
+
+// CommitMessagePolicy checks commit messages against organization rules.
+// A nil policy accepts every message.
+type CommitMessagePolicy struct {
+	pattern          *regexp.Regexp
+	ticketPattern    *regexp.Regexp
+	maxSubjectLength int
+}
+
+// NewCommitMessagePolicy compiles rules into a policy. It returns nil when
+// rules is nil.
+func NewCommitMessagePolicy(rules *CommitMessageRules) (*CommitMessagePolicy, error) {
+	if rules == nil {
+		return nil, nil
+	}
+
+	policy := &CommitMessagePolicy{maxSubjectLength: rules.MaxSubjectLength}
+
+	if rules.Pattern != "" {
+		pattern, err := regexp.Compile(rules.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit message pattern: %w", err)
+		}
+		policy.pattern = pattern
+	}
+
+	if rules.TicketPattern != "" {
+		ticketPattern, err := regexp.Compile(rules.TicketPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern: %w", err)
+		}
+		policy.ticketPattern = ticketPattern
+	}
+
+	return policy, nil
+}
+
+// Check returns one field violation per rule the message breaks
+func (p *CommitMessagePolicy) Check(message string) []*errdetails.BadRequest_FieldViolation {
+	if p == nil {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	violation := func(description string) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "message",
+			Description: description,
+		})
+	}
+
+	if strings.TrimSpace(message) == "" {
+		violation("commit message must not be empty")
+		return violations
+	}
+
+	subject := strings.SplitN(message, "\n", 2)[0]
+	if p.maxSubjectLength > 0 && len(subject) > p.maxSubjectLength {
+		violation(fmt.Sprintf("subject line is %d characters, limit is %d", len(subject), p.maxSubjectLength))
+	}
+
+	if p.ticketPattern != nil && !p.ticketPattern.MatchString(message) {
+		violation(fmt.Sprintf("commit message must reference a ticket matching %q", p.ticketPattern.String()))
+	}
+
+	if p.pattern != nil && !p.pattern.MatchString(message) {
+		violation(fmt.Sprintf("commit message must match %q", p.pattern.String()))
+	}
+
+	return violations
+}
+
+// commitMessageError builds an INVALID_ARGUMENT status carrying the
+// violations as BadRequest details
+func commitMessageError(violations []*errdetails.BadRequest_FieldViolation) error {
+	descriptions := make([]string, 0, len(violations))
+	for _, v := range violations {
+		descriptions = append(descriptions, v.Description)
+	}
+
+	return detailedError(codes.InvalidArgument, "commit message rejected: "+strings.Join(descriptions, "; "), ReasonCommitMessageRejected, nil,
+		&errdetails.BadRequest{FieldViolations: violations})
+}
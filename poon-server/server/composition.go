@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A workspace can build on another by tracking "workspace:<id-or-name>": it
+// tracks whatever the referenced workspace tracks, including that
+// workspace's own patterns, views and references, expanded through the
+// whole graph. A platform team publishes a base workspace and product teams
+// extend it; paths the base gains reach them on their next sync.
+
+// workspacePrefix marks a tracked path as a reference to another workspace
+const workspacePrefix = "workspace:"
+
+// isWorkspaceReference reports whether path names another workspace
+func isWorkspaceReference(path string) bool {
+	return strings.HasPrefix(path, workspacePrefix)
+}
+
+// expandWorkspace returns the paths the referenced workspace tracks at the
+// expansion's version. Referencing a workspace already on the stack is a
+// cycle and an error.
+func (e *patternExpansion) expandWorkspace(ctx context.Context, reference string, stack []string) ([]string, error) {
+	idOrName := strings.TrimPrefix(reference, workspacePrefix)
+	base, ok := e.s.lookupWorkspace(idOrName)
+	if !ok {
+		return nil, fmt.Errorf("unknown workspace %q", idOrName)
+	}
+	if slices.Contains(stack, base.ID) {
+		return nil, fmt.Errorf("workspace composition cycle: %s", e.describeCycle(append(stack, base.ID)))
+	}
+	stack = append(slices.Clip(stack), base.ID)
+
+	var found []string
+	for _, path := range base.TrackedPaths {
+		// Paths deleted from the repository since the base tracked them
+		// are left out
+		if _, err := e.s.repository.GetEntry(ctx, e.version, path); err == nil {
+			found = append(found, cleanRepoPath(path))
+		}
+	}
+
+	patterns, err := e.defined(ctx, base.TrackedPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("workspace %q: %w", idOrName, err)
+	}
+	for _, pattern := range patterns {
+		matches, err := e.expand(ctx, pattern, stack)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, matches...)
+	}
+	return found, nil
+}
+
+// describeCycle names the workspaces in a reference cycle, for errors
+func (e *patternExpansion) describeCycle(ids []string) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = id
+		if workspace, ok := e.s.workspaces[id]; ok && workspace.Name != "" {
+			names[i] = workspace.Name
+		}
+	}
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-git/gitserver"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// Config is everything Start needs to run a server. The poon-server binary
+// fills it from the environment with ConfigFromEnv; tests and embedders
+// build one from DefaultConfig.
+type Config struct {
+	Addr           string // gRPC listen address; port 0 picks a free port
+	RepoRoot       string // Directory imported as the first version of an empty repository
+	WorkspaceRoot  string // Where workspace git repositories live; "" uses a temporary directory
+	StorageBackend string // "memory", a directory or s3://bucket/prefix
+	RepairSource   string // Backup destination or replica that corrupt objects are fetched again from; "" for none
+
+	// ReplicaBackends are mirrored every write to StorageBackend in the
+	// background, and can take over from it with a failover
+	ReplicaBackends []string
+
+	// StorageResilience bounds the operations on a StorageBackend other
+	// than memory: timeouts, retries and the circuit breaker that fails
+	// requests with UNAVAILABLE while the backend is down
+	StorageResilience storage.ResilienceConfig
+
+	// HashAlgorithm is what a new repository hashes objects with; an
+	// existing one keeps the algorithm it records until it is rehashed
+	HashAlgorithm storage.HashAlgorithm
+
+	// GitAddr runs the git HTTP server in this process when set.
+	// GitServerPort is the port in workspace remote URLs; "" uses the
+	// embedded git server's port, or 3000.
+	GitAddr           string
+	GitServerPort     string
+	GitLimits         gitserver.Limits // Bounds on the embedded git server's subprocesses
+	GitSlowRequest    time.Duration    // When the embedded git server logs a request as slow; see gitserver.SetSlowRequest
+	GitPackCacheBytes int64            // Size of the embedded git server's pack cache; see gitserver.SetPackCache
+
+	AdminAddr       string // Admin API listen address; "" disables it
+	AdminTokensFile string // Required with AdminAddr
+	CASAddr         string // Content-addressed fetch API listen address; "" disables it
+	CASBaseURL      string
+	GRPCWebAddr     string // gRPC-Web listen address for browser clients; "" disables it
+	WebURL          string // Base URL of the web UI, reported to clients for 'poon open'
+	OpsAddr         string // pprof and expvar listen address, for operators only; "" disables it
+
+	// CORSAllowedOrigins may call the gRPC-Web and CAS APIs from a
+	// browser; "*" allows any origin
+	CORSAllowedOrigins []string
+
+	// JSON config files; "" leaves each feature at its default
+	AuthTokensFile         string
+	ValidationConfig       string
+	QuotaConfig            string
+	BranchProtectionConfig string
+	MergeQueueConfig       string
+	RPCTimeoutConfig       string
+	TemplatesConfig        string
+	NotifyConfig           string
+	SnapshotConfig         string
+
+	MinClientVersion string // Oldest poon-cli release the server supports; clients warn when older
+
+	// Reflection registers gRPC server reflection on the gRPC and admin
+	// servers so that tools such as grpcurl and evans can list and call
+	// their methods. Callers still need credentials where auth is on.
+	Reflection bool
+
+	PatchLimits       PatchLimits
+	MaxMessageBytes   int64 // Largest gRPC message; 0 for no limit
+	ArchiveCacheBytes int64 // 0 disables the archive cache
+	DocCacheBytes     int64 // 0 disables the rendered document cache
+	ReadFilesMaxBytes int64 // Content per ReadFiles call; kept to half of MaxMessageBytes
+
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	KeepaliveMinTime time.Duration
+
+	// Directories in the workspace root without a workspace are removed
+	// every WorkspaceGCInterval, and on startup, once they have not changed
+	// for WorkspaceGCGrace. An interval of 0 disables the collection.
+	WorkspaceGCInterval time.Duration
+	WorkspaceGCGrace    time.Duration
+
+	// Workspace repositories are cut down to their last
+	// WorkspaceHistoryDepth commits by the same periodic pass, which also
+	// measures their disk usage. 0 keeps their whole history.
+	WorkspaceHistoryDepth int64
+
+	// Workspace repositories keep their objects in one directory in the
+	// workspace root, so files tracked by many workspaces are stored once
+	WorkspaceSharedObjects bool
+
+	// SymbolIndex indexes the functions, types and other symbols of each
+	// version for SearchSymbols and GoToDefinition. Each new version is
+	// indexed as it is created.
+	SymbolIndex bool
+}
+
+// DefaultConfig returns the settings poon-server runs with when no
+// environment variables are set
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":50051",
+		RepoRoot:          ".",
+		StorageBackend:    "memory",
+		StorageResilience: storage.DefaultResilienceConfig(),
+		HashAlgorithm:     storage.HashSHA256,
+		MinClientVersion:  DefaultMinClientVersion,
+		PatchLimits:       DefaultPatchLimits,
+		MaxMessageBytes:   defaultMaxMessageBytes,
+		ArchiveCacheBytes: defaultArchiveCacheBytes,
+		DocCacheBytes:     defaultDocCacheBytes,
+		ReadFilesMaxBytes: defaultReadFilesMaxBytes,
+		KeepaliveTime:     defaultKeepaliveTime,
+		KeepaliveTimeout:  defaultKeepaliveTimeout,
+		KeepaliveMinTime:  defaultKeepaliveMinTime,
+
+		WorkspaceGCInterval: defaultWorkspaceGCInterval,
+		WorkspaceGCGrace:    defaultWorkspaceGCGrace,
+
+		WorkspaceSharedObjects: true,
+	}
+}
+
+// ConfigFromEnv reads the environment variables documented for poon-server
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Addr = ":" + port
+	}
+	if repoRoot := os.Getenv("REPO_ROOT"); repoRoot != "" {
+		cfg.RepoRoot = repoRoot
+	}
+	cfg.WorkspaceRoot = os.Getenv("WORKSPACE_ROOT")
+	if storageLocation := os.Getenv("STORAGE_BACKEND"); storageLocation != "" {
+		cfg.StorageBackend = storageLocation
+	}
+	cfg.RepairSource = os.Getenv("REPAIR_SOURCE")
+	for _, location := range strings.Split(os.Getenv("REPLICA_BACKENDS"), ",") {
+		if location = strings.TrimSpace(location); location != "" {
+			cfg.ReplicaBackends = append(cfg.ReplicaBackends, location)
+		}
+	}
+	cfg.GitServerPort = os.Getenv("GIT_SERVER_PORT")
+
+	cfg.AdminAddr = os.Getenv("ADMIN_ADDR")
+	cfg.AdminTokensFile = os.Getenv("ADMIN_TOKENS_FILE")
+	cfg.CASAddr = os.Getenv("CAS_ADDR")
+	cfg.CASBaseURL = os.Getenv("CAS_BASE_URL")
+	cfg.GRPCWebAddr = os.Getenv("GRPC_WEB_ADDR")
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.CORSAllowedOrigins = append(cfg.CORSAllowedOrigins, origin)
+		}
+	}
+	cfg.WebURL = os.Getenv("WEB_URL")
+	cfg.OpsAddr = os.Getenv("OPS_ADDR")
+
+	cfg.AuthTokensFile = os.Getenv("AUTH_TOKENS_FILE")
+	cfg.ValidationConfig = os.Getenv("VALIDATION_CONFIG")
+	cfg.QuotaConfig = os.Getenv("QUOTA_CONFIG")
+	cfg.BranchProtectionConfig = os.Getenv("BRANCH_PROTECTION_CONFIG")
+	cfg.MergeQueueConfig = os.Getenv("MERGE_QUEUE_CONFIG")
+	cfg.RPCTimeoutConfig = os.Getenv("RPC_TIMEOUT_CONFIG")
+	cfg.TemplatesConfig = os.Getenv("WORKSPACE_TEMPLATES_CONFIG")
+	cfg.NotifyConfig = os.Getenv("NOTIFY_CONFIG")
+	cfg.SnapshotConfig = os.Getenv("SNAPSHOT_CONFIG")
+	if minClientVersion := os.Getenv("MIN_CLIENT_VERSION"); minClientVersion != "" {
+		cfg.MinClientVersion = minClientVersion
+	}
+
+	var err error
+	if cfg.Reflection, err = envBool("GRPC_REFLECTION", false); err != nil {
+		return cfg, fmt.Errorf("failed to load reflection setting: %w", err)
+	}
+	if cfg.SymbolIndex, err = envBool("SYMBOL_INDEX", false); err != nil {
+		return cfg, fmt.Errorf("failed to load symbol index setting: %w", err)
+	}
+	if cfg.StorageResilience, err = loadStorageResilience(); err != nil {
+		return cfg, fmt.Errorf("failed to load storage backend limits: %w", err)
+	}
+	if cfg.GitLimits, err = gitserver.LimitsFromEnv(); err != nil {
+		return cfg, fmt.Errorf("failed to load git limits: %w", err)
+	}
+	if cfg.GitSlowRequest, err = gitserver.SlowRequestFromEnv(); err != nil {
+		return cfg, fmt.Errorf("failed to load git slow request threshold: %w", err)
+	}
+	if cfg.GitPackCacheBytes, err = gitserver.PackCacheBytesFromEnv(); err != nil {
+		return cfg, fmt.Errorf("failed to load git pack cache size: %w", err)
+	}
+	if cfg.HashAlgorithm, err = storage.ParseHashAlgorithm(os.Getenv("HASH_ALGORITHM")); err != nil {
+		return cfg, fmt.Errorf("failed to load hash algorithm: %w", err)
+	}
+	if cfg.PatchLimits, err = LoadPatchLimits(); err != nil {
+		return cfg, fmt.Errorf("failed to load patch limits: %w", err)
+	}
+	if cfg.MaxMessageBytes, err = envInt64("GRPC_MAX_MESSAGE_BYTES", defaultMaxMessageBytes); err != nil {
+		return cfg, fmt.Errorf("failed to load gRPC message limit: %w", err)
+	}
+	if cfg.ArchiveCacheBytes, err = envInt64("ARCHIVE_CACHE_MAX_BYTES", defaultArchiveCacheBytes); err != nil {
+		return cfg, fmt.Errorf("failed to load archive cache size: %w", err)
+	}
+	if cfg.DocCacheBytes, err = envInt64("DOC_CACHE_MAX_BYTES", defaultDocCacheBytes); err != nil {
+		return cfg, fmt.Errorf("failed to load document cache size: %w", err)
+	}
+	if cfg.ReadFilesMaxBytes, err = envInt64("READ_FILES_MAX_BYTES", defaultReadFilesMaxBytes); err != nil {
+		return cfg, fmt.Errorf("failed to load ReadFiles size cap: %w", err)
+	}
+	if cfg.KeepaliveTime, err = envDuration("GRPC_KEEPALIVE_TIME", defaultKeepaliveTime); err != nil {
+		return cfg, fmt.Errorf("failed to load keepalive settings: %w", err)
+	}
+	if cfg.KeepaliveTimeout, err = envDuration("GRPC_KEEPALIVE_TIMEOUT", defaultKeepaliveTimeout); err != nil {
+		return cfg, fmt.Errorf("failed to load keepalive settings: %w", err)
+	}
+	if cfg.KeepaliveMinTime, err = envDuration("GRPC_KEEPALIVE_MIN_TIME", defaultKeepaliveMinTime); err != nil {
+		return cfg, fmt.Errorf("failed to load keepalive settings: %w", err)
+	}
+	if os.Getenv("WORKSPACE_GC_INTERVAL") == "0" {
+		cfg.WorkspaceGCInterval = 0
+	} else if cfg.WorkspaceGCInterval, err = envDuration("WORKSPACE_GC_INTERVAL", defaultWorkspaceGCInterval); err != nil {
+		return cfg, fmt.Errorf("failed to load workspace collection settings: %w", err)
+	}
+	if cfg.WorkspaceGCGrace, err = envDuration("WORKSPACE_GC_GRACE", defaultWorkspaceGCGrace); err != nil {
+		return cfg, fmt.Errorf("failed to load workspace collection settings: %w", err)
+	}
+	if cfg.WorkspaceHistoryDepth, err = envInt64("WORKSPACE_HISTORY_DEPTH", 0); err != nil {
+		return cfg, fmt.Errorf("failed to load workspace collection settings: %w", err)
+	}
+	if cfg.WorkspaceSharedObjects, err = envBool("WORKSPACE_SHARED_OBJECTS", true); err != nil {
+		return cfg, fmt.Errorf("failed to load workspace settings: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadStorageResilience reads STORAGE_TIMEOUT ("0" for none),
+// STORAGE_ATTEMPTS, STORAGE_BREAKER_THRESHOLD (0 disables the breaker) and
+// STORAGE_BREAKER_COOLDOWN over the defaults
+func loadStorageResilience() (storage.ResilienceConfig, error) {
+	config := storage.DefaultResilienceConfig()
+	var err error
+	if os.Getenv("STORAGE_TIMEOUT") == "0" {
+		config.Timeout = 0
+	} else if config.Timeout, err = envDuration("STORAGE_TIMEOUT", config.Timeout); err != nil {
+		return config, err
+	}
+	attempts, err := envInt64("STORAGE_ATTEMPTS", int64(config.MaxAttempts))
+	if err != nil {
+		return config, err
+	}
+	if attempts == 0 {
+		return config, fmt.Errorf("invalid STORAGE_ATTEMPTS \"0\": must be at least 1")
+	}
+	config.MaxAttempts = int(attempts)
+	threshold, err := envInt64("STORAGE_BREAKER_THRESHOLD", int64(config.BreakerThreshold))
+	if err != nil {
+		return config, err
+	}
+	config.BreakerThreshold = int(threshold)
+	if config.BreakerCooldown, err = envDuration("STORAGE_BREAKER_COOLDOWN", config.BreakerCooldown); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// envBool reads a boolean such as "true" or "1" from the environment
+func envBool(name string, fallback bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", name, value)
+	}
+	return b, nil
+}
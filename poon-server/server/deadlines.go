@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Time limits applied without RPC_TIMEOUT_CONFIG. Most RPCs read a few
+// objects; those that materialize workspaces, run validators or stream large
+// files get longer.
+const (
+	defaultRPCTimeout  = time.Minute
+	defaultSlowRequest = 5 * time.Second
+)
+
+var defaultMethodTimeouts = map[string]time.Duration{
+	"CreateWorkspace":        10 * time.Minute,
+	"AddTrackedPath":         10 * time.Minute,
+	"RefreshTrackedPaths":    10 * time.Minute,
+	"MergePatch":             5 * time.Minute,
+	"DownloadPath":           5 * time.Minute,
+	"StreamDownloadPath":     30 * time.Minute,
+	"StreamDirectory":        5 * time.Minute,
+	"StreamFile":             30 * time.Minute,
+	"StreamWorkspaceArchive": 30 * time.Minute,
+}
+
+// DeadlineConfig is the JSON file named by RPC_TIMEOUT_CONFIG. Durations are
+// Go duration strings such as "90s"; "0" removes the limit.
+type DeadlineConfig struct {
+	Default     string            `json:"default"`     // Limit for methods not listed
+	SlowRequest string            `json:"slowRequest"` // Requests taking longer are logged
+	Methods     map[string]string `json:"methods"`     // By method name, e.g. "MergePatch"
+}
+
+// DeadlinePolicy caps how long each RPC may run. The handler's context is
+// cancelled at the limit, which stops storage reads and kills git
+// subprocesses started with it, and a handler that fails for it gets the
+// client DEADLINE_EXCEEDED. A client's own, earlier deadline still applies.
+type DeadlinePolicy struct {
+	defaultTimeout time.Duration
+	slowRequest    time.Duration
+	methods        map[string]time.Duration
+}
+
+// NewDeadlinePolicy returns the built-in limits
+func NewDeadlinePolicy() *DeadlinePolicy {
+	methods := make(map[string]time.Duration, len(defaultMethodTimeouts))
+	for method, timeout := range defaultMethodTimeouts {
+		methods[method] = timeout
+	}
+	return &DeadlinePolicy{
+		defaultTimeout: defaultRPCTimeout,
+		slowRequest:    defaultSlowRequest,
+		methods:        methods,
+	}
+}
+
+// LoadDeadlinePolicy reads a deadline config file. Settings it leaves out
+// keep their built-in values.
+func LoadDeadlinePolicy(configPath string) (*DeadlinePolicy, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RPC timeout config: %w", err)
+	}
+
+	var config DeadlineConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse RPC timeout config: %w", err)
+	}
+
+	policy := NewDeadlinePolicy()
+	if config.Default != "" {
+		if policy.defaultTimeout, err = parseLimit("default", config.Default); err != nil {
+			return nil, err
+		}
+	}
+	if config.SlowRequest != "" {
+		if policy.slowRequest, err = parseLimit("slowRequest", config.SlowRequest); err != nil {
+			return nil, err
+		}
+	}
+	for method, value := range config.Methods {
+		if policy.methods[method], err = parseLimit(method, value); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+func parseLimit(name, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid time limit for %s %q: must be a duration such as 30s", name, value)
+	}
+	return d, nil
+}
+
+// Timeout returns the limit for a full gRPC method name, 0 for none
+func (p *DeadlinePolicy) Timeout(fullMethod string) time.Duration {
+	if timeout, ok := p.methods[path.Base(fullMethod)]; ok {
+		return timeout
+	}
+	return p.defaultTimeout
+}
+
+// UnaryInterceptor runs each call with its time limit. The handler's
+// context ends at the limit and the call waits for the handler to return,
+// so a change that was already being committed, such as a MergePatch, is
+// reported as it ended rather than as timed out while it still lands.
+func (p *DeadlinePolicy) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		defer p.logSlow(ctx, info.FullMethod, start)
+
+		limit := p.Timeout(info.FullMethod)
+		if limit <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel, ours := withLimit(ctx, limit)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if err != nil && ctx.Err() != nil {
+			return nil, deadlineError(ctx, info.FullMethod, limit, ours)
+		}
+		return resp, err
+	}
+}
+
+// StreamInterceptor runs each stream with its time limit. Sends fail once
+// the limit passes, so handlers stop at their next message.
+func (p *DeadlinePolicy) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		defer p.logSlow(ss.Context(), info.FullMethod, start)
+
+		limit := p.Timeout(info.FullMethod)
+		if limit <= 0 {
+			return handler(srv, ss)
+		}
+		ctx, cancel, ours := withLimit(ss.Context(), limit)
+		defer cancel()
+
+		err := handler(srv, &deadlineStream{ServerStream: ss, ctx: ctx})
+		if err != nil && ctx.Err() != nil {
+			return deadlineError(ctx, info.FullMethod, limit, ours)
+		}
+		return err
+	}
+}
+
+// withLimit derives a context ending after limit and reports whether the
+// limit, rather than an earlier deadline of the caller, ends it
+func withLimit(ctx context.Context, limit time.Duration) (context.Context, context.CancelFunc, bool) {
+	deadline, hasDeadline := ctx.Deadline()
+	ours := !hasDeadline || time.Until(deadline) > limit
+	ctx, cancel := context.WithTimeout(ctx, limit)
+	return ctx, cancel, ours
+}
+
+// deadlineError explains why a call's context ended
+func deadlineError(ctx context.Context, fullMethod string, limit time.Duration, ours bool) error {
+	if ours && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("Request %s stopped after its %s time limit", fullMethod, limit)
+		method := path.Base(fullMethod)
+		return detailedError(codes.DeadlineExceeded, fmt.Sprintf("%s exceeded the server's %s time limit", method, limit), ReasonTimeLimitExceeded,
+			map[string]string{"method": method, "limit": limit.String()})
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+func (p *DeadlinePolicy) logSlow(ctx context.Context, fullMethod string, start time.Time) {
+	elapsed := time.Since(start)
+	if p.slowRequest > 0 && elapsed > p.slowRequest {
+		log.Printf("Slow request: %s by %s took %s", fullMethod, quotaUser(ctx), elapsed.Round(time.Millisecond))
+	}
+}
+
+// deadlineStream gives a stream's handler the limited context
+type deadlineStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *deadlineStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *deadlineStream) SendMsg(m interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return s.ServerStream.SendMsg(m)
+}
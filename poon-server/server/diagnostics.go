@@ -0,0 +1,46 @@
+package server
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/nic/poon/poon-git/diagnostics"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// diagnosticVars returns what the ops port reports about a running server
+func (s *server) diagnosticVars(backend storage.StorageBackend) diagnostics.Vars {
+	return diagnostics.Vars{
+		"goroutines":   func() interface{} { return runtime.NumGoroutine() },
+		"subprocesses": func() interface{} { return diagnostics.ChildProcesses() },
+		"workspaces": func() interface{} {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			return len(s.workspaces)
+		},
+		"backend": func() interface{} {
+			usage := map[string]interface{}{}
+			active := backend
+			if replicated, ok := active.(*storage.ReplicatedBackend); ok {
+				usage["replicas"] = replicated.Status()
+				active = replicated.Active()
+			}
+			if resilient, ok := active.(*storage.ResilientBackend); ok {
+				usage["breaker"] = resilient.State()
+				active = resilient.Unwrap()
+			}
+			usage["type"] = fmt.Sprintf("%T", active)
+			if memory, ok := active.(*storage.MemoryBackend); ok {
+				usage["keys"], usage["bytes"] = memory.Usage()
+			}
+			return usage
+		},
+		"queues": func() interface{} {
+			queues := map[string]int{"events": s.events.Pending()}
+			if s.mergeQueue != nil {
+				queues["mergeQueue"] = s.mergeQueue.Len()
+			}
+			return queues
+		},
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/markdown"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+const (
+	// maxRenderedDocBytes caps the part of a document GetRenderedDoc renders
+	maxRenderedDocBytes = 512 * 1024
+
+	// defaultDocCacheBytes bounds the rendered document cache unless
+	// DOC_CACHE_MAX_BYTES says otherwise
+	defaultDocCacheBytes = 32 << 20
+)
+
+func (s *server) GetRenderedDoc(ctx context.Context, req *pb.GetRenderedDocRequest) (*pb.GetRenderedDocResponse, error) {
+	log.Printf("Rendering doc: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	path := cleanRepoPath(req.Path)
+	entry, err := s.repository.GetEntry(ctx, version, path)
+	if err != nil {
+		return nil, readError("path not found", path, version, err)
+	}
+
+	// Directories are cached by tree hash, so a hit needs neither the
+	// listing nor the README; documents by content hash and how they render
+	key := docCacheKey{hash: entry.Hash, dir: entry.Type == storage.ObjectTypeTree}
+	if !key.dir {
+		key.markdown = markdown.IsMarkdown(path)
+	}
+	doc, cached := s.docCache.get(key)
+	if !cached {
+		if doc, err = s.renderDoc(ctx, version, path, key); err != nil {
+			return nil, err
+		}
+		s.docCache.put(key, doc)
+	}
+
+	resp := &pb.GetRenderedDocResponse{
+		Version:   version,
+		Hash:      string(doc.hash),
+		Html:      doc.html,
+		Title:     doc.title,
+		Truncated: doc.truncated,
+		Cached:    cached,
+	}
+	switch {
+	case !key.dir:
+		resp.Path = path
+	case doc.name != "":
+		resp.Path = repoJoin(path, doc.name)
+	}
+	return resp, nil
+}
+
+// renderDoc renders the document at path, or the README of the directory
+// there, which is left empty when it has none
+func (s *server) renderDoc(ctx context.Context, version int64, path string, key docCacheKey) (*renderedDoc, error) {
+	doc := &renderedDoc{hash: key.hash}
+	isMarkdown := key.markdown
+	if key.dir {
+		entries, err := s.repository.ReadDirectory(ctx, version, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+		readme := findReadme(entries)
+		if readme == nil {
+			return &renderedDoc{}, nil
+		}
+		doc.name, doc.hash = readme.Name, readme.Hash
+		path = repoJoin(path, readme.Name)
+		isMarkdown = markdown.IsMarkdown(readme.Name)
+	}
+
+	content, size, err := s.repository.OpenFile(ctx, version, path)
+	if err != nil {
+		return nil, readError("failed to read document", path, version, err)
+	}
+	defer content.Close()
+	source, err := io.ReadAll(io.LimitReader(content, maxRenderedDocBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	doc.truncated = size > maxRenderedDocBytes
+
+	result := markdown.RenderText(source)
+	if isMarkdown {
+		result = markdown.Render(source)
+	}
+	doc.html, doc.title = result.HTML, result.Title
+	return doc, nil
+}
+
+// docCacheKey identifies a rendering: of the README of a directory with a
+// tree hash, or of a document with a content hash as Markdown or as text
+type docCacheKey struct {
+	hash     storage.Hash
+	dir      bool
+	markdown bool
+}
+
+type renderedDoc struct {
+	name      string // README's name in its directory; "" for a document, or a directory without one
+	hash      storage.Hash
+	html      string
+	title     string
+	truncated bool
+}
+
+func (d *renderedDoc) size() int64 {
+	return int64(len(d.name) + len(d.hash) + len(d.html) + len(d.title))
+}
+
+// docCache keeps rendered documents in memory and drops the least recently
+// used ones once they take more than maxBytes. Keys are content hashes, so
+// entries never go stale, and there is nothing to lose in a restart that a
+// render does not bring back. A nil cache renders every request.
+type docCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List // Of *docCacheEntry, most recently used first
+	entries map[docCacheKey]*list.Element
+	bytes   int64
+}
+
+type docCacheEntry struct {
+	key docCacheKey
+	doc *renderedDoc
+}
+
+func newDocCache(maxBytes int64) *docCache {
+	return &docCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[docCacheKey]*list.Element),
+	}
+}
+
+func (c *docCache) get(key docCacheKey) (*renderedDoc, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*docCacheEntry).doc, true
+}
+
+// put caches a rendered document. One larger than the whole cache is not
+// kept.
+func (c *docCache) put(key docCacheKey, doc *renderedDoc) {
+	if c == nil || doc.size() > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&docCacheEntry{key: key, doc: doc})
+	c.bytes += doc.size()
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Remove(c.lru.Back()).(*docCacheEntry)
+		delete(c.entries, oldest.key)
+		c.bytes -= oldest.doc.size()
+	}
+}
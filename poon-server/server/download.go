@@ -0,0 +1,305 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultArchiveCacheBytes bounds the archive cache unless
+// ARCHIVE_CACHE_MAX_BYTES says otherwise
+const defaultArchiveCacheBytes = 256 << 20
+
+func (s *server) DownloadPath(ctx context.Context, req *pb.DownloadPathRequest) (*pb.DownloadPathResponse, error) {
+	log.Printf("Downloading path: %s", req.Path)
+
+	dirPath, format, err := downloadRequest(req)
+	if err != nil {
+		return &pb.DownloadPathResponse{
+			Success: false,
+			Message: status.Convert(err).Message(),
+		}, nil
+	}
+
+	if req.Async {
+		id := s.runOperation(ctx, operationDownloadPath, false, func(ctx context.Context) (proto.Message, error) {
+			resp := s.archivePath(ctx, dirPath, req.Branch, format)
+			if !resp.Success {
+				return nil, errors.New(resp.Message)
+			}
+			// Archives can be large; operationArchive builds it again
+			resp.Content = nil
+			return resp, nil
+		})
+		return &pb.DownloadPathResponse{
+			Success:     true,
+			Message:     fmt.Sprintf("Archiving %s in operation %s", dirPath, id),
+			OperationId: id,
+		}, nil
+	}
+	return s.archivePath(ctx, dirPath, req.Branch, format), nil
+}
+
+// StreamDownloadPath sends the archive DownloadPath would return in chunks
+// as it is written, from the archive cache when it holds it
+func (s *server) StreamDownloadPath(req *pb.DownloadPathRequest, stream pb.MonorepoService_StreamDownloadPathServer) error {
+	log.Printf("Streaming download of path: %s", req.Path)
+
+	dirPath, format, err := downloadRequest(req)
+	if err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	commit, err := s.branchCommit(ctx, req.Branch)
+	if err != nil {
+		return err
+	}
+	archive, err := s.openArchive(ctx, commit, dirPath, format)
+	if err != nil {
+		return err
+	}
+
+	if err := stream.Send(&pb.DownloadChunk{
+		Filename:   archive.filename(),
+		Version:    archive.version,
+		TreeHash:   string(archive.tree),
+		CommitHash: string(archive.commit),
+	}); err != nil {
+		return err
+	}
+	out := &archiveChunkWriter{send: func(data []byte) error {
+		return stream.Send(&pb.DownloadChunk{Data: data})
+	}}
+	if err := s.writePathArchive(ctx, archive, out); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to archive %s: %w", dirPath, err)
+	}
+	return out.flush()
+}
+
+// downloadRequest returns the directory and archive format a download asks
+// for, "tar.gz" unless it says otherwise
+func downloadRequest(req *pb.DownloadPathRequest) (string, string, error) {
+	dirPath := strings.Trim(req.Path, "/")
+	if err := validatePath(dirPath); err != nil {
+		return "", "", status.Errorf(codes.InvalidArgument, "Invalid path: %v", err)
+	}
+
+	switch req.Format {
+	case "", "tgz", "tar.gz":
+		return dirPath, "tar.gz", nil
+	case "tar":
+		return dirPath, "tar", nil
+	}
+	return "", "", status.Errorf(codes.InvalidArgument, "Unsupported archive format %q (use tar or tar.gz)", req.Format)
+}
+
+// branchCommit returns the head of branch, the current version's commit
+// for main or ""
+func (s *server) branchCommit(ctx context.Context, branch string) (storage.Hash, error) {
+	if branch != "" && branch != storage.MainBranch {
+		head, err := s.repository.BranchHead(ctx, branch)
+		if errors.Is(err, storage.ErrBranchNotFound) {
+			return "", status.Errorf(codes.NotFound, "Branch %s not found", branch)
+		}
+		if err != nil {
+			return "", internalError(err, "Failed to read branch %s: %v", branch, err)
+		}
+		return head, nil
+	}
+
+	version, err := s.resolveVersion(ctx, 0)
+	if err != nil {
+		return "", status.Error(codes.FailedPrecondition, err.Error())
+	}
+	info, err := s.repository.GetVersionInfo(ctx, version)
+	if err != nil {
+		return "", internalError(err, "Failed to read version %d: %v", version, err)
+	}
+	return info.CommitHash, nil
+}
+
+// pathArchive is a directory of a commit to archive
+type pathArchive struct {
+	dirPath string
+	format  string
+	commit  storage.Hash
+	version int64 // 0 for a branch commit
+	tree    storage.Hash
+
+	// exportRoot is the commit's root tree when some files are export-ignore
+	exportRoot storage.Hash
+}
+
+// at names the version or commit an archive is taken from
+func (a *pathArchive) at() string {
+	if a.version > 0 {
+		return fmt.Sprintf("version %d", a.version)
+	}
+	return fmt.Sprintf("commit %s", a.commit)
+}
+
+func (a *pathArchive) filename() string {
+	if a.dirPath == "" {
+		return "monorepo." + a.format
+	}
+	return path.Base(a.dirPath) + "." + a.format
+}
+
+// openArchive finds the directory dirPath in a commit to archive in format
+func (s *server) openArchive(ctx context.Context, commitHash storage.Hash, dirPath, format string) (*pathArchive, error) {
+	commit, err := s.repository.GetCommit(ctx, commitHash)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Failed to read commit %s: %v", commitHash, err)
+	}
+	archive := &pathArchive{dirPath: dirPath, format: format, commit: commitHash, version: commit.Version}
+
+	entry, err := s.repository.GetEntryInTree(ctx, commit.RootTree, dirPath)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Path %s not found at %s", dirPath, archive.at())
+	}
+	if entry.Type != storage.ObjectTypeTree {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is a file; read it with ReadFile", dirPath)
+	}
+	archive.tree = entry.Hash
+
+	// Archives leaving out export-ignore files are not the tree's archive,
+	// so they are built each time rather than cached by tree hash
+	rules, err := s.repository.AttributesInTree(ctx, commit.RootTree)
+	if err != nil {
+		return nil, internalError(err, "Failed to read attributes: %v", err)
+	}
+	if rules.Uses("export-ignore") {
+		archive.exportRoot = commit.RootTree
+	}
+	return archive, nil
+}
+
+// writePathArchive writes an archive opened by openArchive to w
+func (s *server) writePathArchive(ctx context.Context, archive *pathArchive, w io.Writer) error {
+	if archive.exportRoot == "" {
+		return s.writeTreeArchive(ctx, w, archive.tree, archive.format)
+	}
+	return writeArchive(w, archive.format, func(w io.Writer) error {
+		return s.repository.WriteExportArchiveInTree(ctx, archive.exportRoot, archive.dirPath, w)
+	})
+}
+
+// archivePath archives the directory dirPath at the head of branch, the
+// current version for main or "", in format for DownloadPath
+func (s *server) archivePath(ctx context.Context, dirPath, branch, format string) *pb.DownloadPathResponse {
+	commit, err := s.branchCommit(ctx, branch)
+	if err != nil {
+		return &pb.DownloadPathResponse{
+			Success: false,
+			Message: status.Convert(err).Message(),
+		}
+	}
+	return s.archiveCommit(ctx, commit, dirPath, format)
+}
+
+// archiveCommit archives the directory dirPath in a commit in format
+func (s *server) archiveCommit(ctx context.Context, commit storage.Hash, dirPath, format string) *pb.DownloadPathResponse {
+	archive, err := s.openArchive(ctx, commit, dirPath, format)
+	if err != nil {
+		return &pb.DownloadPathResponse{
+			Success: false,
+			Message: status.Convert(err).Message(),
+		}
+	}
+
+	var content bytes.Buffer
+	if err := s.writePathArchive(ctx, archive, &content); err != nil {
+		return &pb.DownloadPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to archive %s: %v", dirPath, err),
+		}
+	}
+
+	name := strings.TrimSuffix(archive.filename(), "."+format)
+	return &pb.DownloadPathResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Archived %s at %s (%d bytes)", name, archive.at(), content.Len()),
+		Content:    content.Bytes(),
+		Filename:   archive.filename(),
+		Version:    archive.version,
+		TreeHash:   string(archive.tree),
+		Path:       dirPath,
+		CommitHash: string(commit),
+	}
+}
+
+// operationArchive fills in the archive of a download_path operation's
+// response. The operation only keeps the path, commit and format, so
+// stored operations stay small; the archive is built again from them, or
+// read from the archive cache.
+func (s *server) operationArchive(ctx context.Context, record *storage.Operation) error {
+	var resp pb.DownloadPathResponse
+	if err := proto.Unmarshal(record.Response, &resp); err != nil {
+		return fmt.Errorf("failed to decode archive of operation %s: %w", record.ID, err)
+	}
+	format := "tar"
+	if strings.HasSuffix(resp.Filename, ".tar.gz") {
+		format = "tar.gz"
+	}
+	archive := s.archiveCommit(ctx, storage.Hash(resp.CommitHash), resp.Path, format)
+	if !archive.Success {
+		return fmt.Errorf("archive of operation %s is no longer available: %s", record.ID, archive.Message)
+	}
+	resp.Content = archive.Content
+	data, err := proto.Marshal(&resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive of operation %s: %w", record.ID, err)
+	}
+	record.Response = data
+	return nil
+}
+
+// writeTreeArchive writes a tree as a "tar" or "tar.gz" archive. With an
+// archive cache, a cached archive is streamed from it and a missing one is
+// cached as it is written, so the next request for the same tree, from
+// DownloadPath or the CAS endpoints, is a single read.
+func (s *server) writeTreeArchive(ctx context.Context, w io.Writer, hash storage.Hash, format string) error {
+	if s.archiveCache == nil {
+		return buildTreeArchive(ctx, s.repository, w, hash, format)
+	}
+	return s.archiveCache.WriteArchive(ctx, hash, format, w, func(w io.Writer) error {
+		return buildTreeArchive(ctx, s.repository, w, hash, format)
+	})
+}
+
+// buildTreeArchive writes a tree as a tar archive, gzipped for "tar.gz"
+func buildTreeArchive(ctx context.Context, repository storage.Repository, w io.Writer, hash storage.Hash, format string) error {
+	return writeArchive(w, format, func(w io.Writer) error {
+		return repository.WriteTreeArchive(ctx, hash, w)
+	})
+}
+
+// writeArchive writes the tar archive from write, gzipped for "tar.gz". The
+// gzip header carries no name or timestamp, so the compressed archive is as
+// reproducible as the tar inside it.
+func writeArchive(w io.Writer, format string, write func(io.Writer) error) error {
+	if format == "tar" {
+		return write(w)
+	}
+
+	gz := gzip.NewWriter(w)
+	if err := write(gz); err != nil {
+		return err
+	}
+	return gz.Close()
+}
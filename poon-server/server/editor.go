@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// workspaceView is what editor calls need of a workspace: the version its
+// files come from and the paths it has checked out
+type workspaceView struct {
+	version int64
+	tracked []string
+}
+
+// viewWorkspace looks up a workspace for an editor call. version, when not
+// 0, is read instead of the workspace's synced version, for clients that
+// keep track of their own.
+func (s *server) viewWorkspace(ctx context.Context, idOrName string, version int64) (*workspaceView, error) {
+	s.mu.RLock()
+	workspace, exists := s.lookupWorkspace(idOrName)
+	var view workspaceView
+	if exists {
+		view = workspaceView{version: workspace.SyncedVersion, tracked: append([]string{}, workspace.TrackedPaths...)}
+	}
+	s.mu.RUnlock()
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "workspace %s not found", idOrName)
+	}
+
+	if version != 0 {
+		view.version = version
+	}
+	// A workspace created before the first version reads the current one
+	resolved, err := s.resolveVersion(ctx, view.version)
+	if err != nil {
+		return nil, err
+	}
+	view.version = resolved
+	return &view, nil
+}
+
+// tracks reports whether the workspace has a path checked out
+func (v *workspaceView) tracks(p string) bool {
+	return coveredBy(p, v.tracked)
+}
+
+// tracksBelow reports whether the workspace has a directory, or something
+// below it, checked out
+func (v *workspaceView) tracksBelow(dir string) bool {
+	if v.tracks(dir) {
+		return true
+	}
+	dir = cleanRepoPath(dir)
+	for _, tracked := range v.tracked {
+		if dir == "" || strings.HasPrefix(cleanRepoPath(tracked), dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenWorkspaceFile reads a file at the version a workspace was synced to,
+// so that code an editor navigates to matches the code the user has checked
+// out, whether or not the workspace tracks the file
+func (s *server) OpenWorkspaceFile(ctx context.Context, req *pb.OpenWorkspaceFileRequest) (*pb.OpenWorkspaceFileResponse, error) {
+	log.Printf("Opening %s for workspace %s", req.Path, req.WorkspaceId)
+
+	view, err := s.viewWorkspace(ctx, req.WorkspaceId, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.batchEntry(ctx, view.version, req.Path, "", s.batchCap(0))
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.repository.ReadFile(ctx, view.version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read file", req.Path, view.version, err)
+	}
+
+	return &pb.OpenWorkspaceFileResponse{
+		Path:    req.Path,
+		Version: view.version,
+		Content: content,
+		Hash:    string(entry.Hash),
+		Size:    int64(len(content)),
+		Tracked: view.tracks(req.Path),
+	}, nil
+}
+
+// ListWorkspaceSiblings lists the directory holding a path at a workspace's
+// synced version. The path itself need not exist, so an editor can list
+// where a new file would go.
+func (s *server) ListWorkspaceSiblings(ctx context.Context, req *pb.ListWorkspaceSiblingsRequest) (*pb.ListWorkspaceSiblingsResponse, error) {
+	log.Printf("Listing siblings of %s for workspace %s", req.Path, req.WorkspaceId)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+	view, err := s.viewWorkspace(ctx, req.WorkspaceId, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := ""
+	if p := cleanRepoPath(req.Path); p != "" {
+		if dir = repoDir(p); dir == "." {
+			dir = ""
+		}
+	}
+	entries, err := s.repository.ReadDirectory(ctx, view.version, dir)
+	if err != nil {
+		return nil, readError("failed to read directory", dir, view.version, err)
+	}
+
+	resp := &pb.ListWorkspaceSiblingsResponse{Directory: dir, Version: view.version}
+	for _, entry := range entries {
+		entryPath := repoJoin(dir, entry.Name)
+		isDir := entry.Type == storage.ObjectTypeTree
+		tracked := view.tracks(entryPath)
+		if isDir {
+			tracked = view.tracksBelow(entryPath)
+		}
+		resp.Entries = append(resp.Entries, &pb.WorkspaceEntry{
+			Name:    entry.Name,
+			Path:    entryPath,
+			IsDir:   isDir,
+			Size:    entry.Size,
+			Hash:    string(entry.Hash),
+			Tracked: tracked,
+		})
+	}
+	return resp, nil
+}
+
+// FetchWorkspaceDependencies reads the files behind an editor's imports at
+// a workspace's synced version. Each directory requested stands for the
+// files directly in it, which is what an import of a Go package or a Python
+// module directory needs. Paths that cannot be read get their own error, as
+// in ReadFiles.
+func (s *server) FetchWorkspaceDependencies(ctx context.Context, req *pb.FetchWorkspaceDependenciesRequest) (*pb.FetchWorkspaceDependenciesResponse, error) {
+	log.Printf("Fetching %d dependency paths for workspace %s", len(req.Paths), req.WorkspaceId)
+
+	if len(req.Paths) > maxReadFilesPaths {
+		return nil, tooManyPathsError(len(req.Paths), maxReadFilesPaths)
+	}
+	view, err := s.viewWorkspace(ctx, req.WorkspaceId, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, p := range req.Paths {
+		files, err := s.dependencyFiles(ctx, view.version, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if req.SkipTracked && view.tracks(file) {
+				continue
+			}
+			paths = append(paths, file)
+		}
+	}
+	if len(paths) > maxReadFilesPaths {
+		return nil, tooManyPathsError(len(paths), maxReadFilesPaths)
+	}
+
+	resp := &pb.FetchWorkspaceDependenciesResponse{Version: view.version}
+	resp.Files, resp.Truncated = s.readBatch(ctx, view.version, paths, nil, s.batchCap(req.MaxTotalBytes))
+	return resp, nil
+}
+
+// dependencyFiles returns the files a requested dependency path stands
+// for: a directory's files, or else the path itself, which is read (or
+// reported missing) as it is
+func (s *server) dependencyFiles(ctx context.Context, version int64, p string) ([]string, error) {
+	if err := validatePath(p); err != nil {
+		return []string{p}, nil
+	}
+	entry, err := s.repository.GetEntry(ctx, version, p)
+	if err != nil || entry.Type != storage.ObjectTypeTree {
+		return []string{p}, nil
+	}
+
+	entries, err := s.repository.ReadDirectory(ctx, version, p)
+	if err != nil {
+		return nil, readError("failed to read directory", p, version, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type == storage.ObjectTypeBlob {
+			files = append(files, repoJoin(p, entry.Name))
+		}
+	}
+	return files, nil
+}
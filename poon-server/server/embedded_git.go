@@ -0,0 +1,19 @@
+package server
+
+// DefaultGitServerPort is the git server port in workspace remote URLs, and
+// where poon-server --with-git-server listens, unless GIT_SERVER_PORT says
+// otherwise
+const DefaultGitServerPort = "3000"
+
+// WorkspaceRepoPath finds a workspace's git repository for the embedded git
+// server, which serves only workspaces in the registry
+func (s *server) WorkspaceRepoPath(workspaceID string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workspace, ok := s.lookupWorkspace(workspaceID)
+	if !ok || workspace.GitRepoPath == "" {
+		return "", false
+	}
+	return workspace.GitRepoPath, true
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details the server
+// attaches to errors
+const ErrorDomain = "poon-server"
+
+// Reasons in ErrorInfo details and in the FailureInfo of responses that
+// report failure in-band. Clients branch on these, never on messages, so a
+// reason keeps its meaning once released.
+const (
+	ReasonUnauthenticated       = "UNAUTHENTICATED"         // metadata: credentials ("missing" or "invalid")
+	ReasonInvalidPath           = "INVALID_PATH"            // metadata: path
+	ReasonPathNotFound          = "PATH_NOT_FOUND"          // metadata: path, version
+	ReasonVersionNotFound       = "VERSION_NOT_FOUND"       // metadata: version, current_version
+	ReasonPatchTooLarge         = "PATCH_TOO_LARGE"         // metadata: limit, actual, max
+	ReasonCommitMessageRejected = "COMMIT_MESSAGE_REJECTED" // BadRequest details name the rules
+	ReasonTimeLimitExceeded     = "TIME_LIMIT_EXCEEDED"     // metadata: method, limit
+	ReasonEmptyPatch            = "EMPTY_PATCH"
+	ReasonPatchConflict         = "PATCH_CONFLICT"   // metadata: path, base_version, hunk_line, file_line, expected, actual
+	ReasonCaseCollision         = "CASE_COLLISION"   // metadata: path, base_version
+	ReasonPathLocked            = "PATH_LOCKED"      // metadata: path, owner, expires_at
+	ReasonPolicyViolation       = "POLICY_VIOLATION" // metadata: policy ("validation" or "branch_protection"); violations are in the response
+	ReasonTooManyPaths          = "TOO_MANY_PATHS"   // metadata: requested, max
+	ReasonNotAFile              = "NOT_A_FILE"       // metadata: path
+	ReasonFileTooLarge          = "FILE_TOO_LARGE"   // metadata: path, size, max
+	ReasonBackendUnavailable    = "BACKEND_UNAVAILABLE"
+	ReasonBranchNotFound        = "BRANCH_NOT_FOUND" // metadata: branch
+	ReasonMergeConflict         = "MERGE_CONFLICT"   // metadata: source (branch or picked commit), target; paths are in the response
+	ReasonPathChanged           = "PATH_CHANGED"     // metadata: path, expected_version, current_version
+	ReasonInvalidArgument       = "INVALID_ARGUMENT" // metadata: field; BadRequest details name it
+)
+
+// detailedError builds a status error carrying an ErrorInfo with reason and
+// metadata, followed by any further details
+func detailedError(code codes.Code, message, reason string, metadata map[string]string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, message)
+	all := append([]protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	}}, details...)
+	if detailed, err := st.WithDetails(all...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// invalidPathError rejects a request whose path field is not a relative
+// path inside the repository
+func invalidPathError(path string, err error) error {
+	return detailedError(codes.InvalidArgument, fmt.Sprintf("invalid path: %v", err), ReasonInvalidPath,
+		map[string]string{"path": path},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "path", Description: err.Error()},
+		}})
+}
+
+// invalidArgumentError rejects a request whose field holds a value the RPC
+// does not accept, such as a negative offset
+func invalidArgumentError(field, description string) error {
+	return detailedError(codes.InvalidArgument, fmt.Sprintf("invalid %s: %s", field, description), ReasonInvalidArgument,
+		map[string]string{"field": field},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: description},
+		}})
+}
+
+// tooManyPathsError rejects a batch request for more paths than one call
+// may ask for
+func tooManyPathsError(requested, max int) error {
+	return detailedError(codes.InvalidArgument, fmt.Sprintf("too many paths: %d requested, at most %d per call", requested, max), ReasonTooManyPaths,
+		map[string]string{"requested": strconv.Itoa(requested), "max": strconv.Itoa(max)},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "paths", Description: fmt.Sprintf("at most %d paths per call", max)},
+		}})
+}
+
+// readError reports a failed read of path at version: NotFound when the
+// path does not exist there, the plain error otherwise
+func readError(action, path string, version int64, err error) error {
+	if !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	message := fmt.Sprintf("%s: %v", action, err)
+	return detailedError(codes.NotFound, message, ReasonPathNotFound,
+		map[string]string{"path": path, "version": strconv.FormatInt(version, 10)},
+		&errdetails.ResourceInfo{ResourceType: "path", ResourceName: path, Description: fmt.Sprintf("not found at version %d", version)})
+}
+
+// patchFailure describes why patch does not apply to the current version,
+// or returns nil when err is not a conflict
+func (s *server) patchFailure(ctx context.Context, patch []byte, err error) *pb.FailureInfo {
+	var changed *storage.PathChangedError
+	if errors.As(err, &changed) {
+		return pathChangedFailure(changed)
+	}
+
+	var mismatch *storage.HunkMismatchError
+	reason := ReasonPatchConflict
+	switch {
+	case errors.Is(err, storage.ErrCaseCollision):
+		reason = ReasonCaseCollision
+	case errors.As(err, &mismatch), errors.Is(err, storage.ErrPatchConflict):
+	default:
+		return nil
+	}
+
+	metadata := map[string]string{}
+	if parsed, err := merge.ParsePatch(patch); err == nil {
+		metadata["path"] = parsed.Target()
+	}
+	if version, err := s.repository.GetCurrentVersion(ctx); err == nil {
+		metadata["base_version"] = strconv.FormatInt(version, 10)
+	}
+	if mismatch != nil {
+		metadata["hunk_line"] = strconv.Itoa(mismatch.HunkLine)
+		metadata["file_line"] = strconv.Itoa(mismatch.FileLine)
+		metadata["expected"] = mismatch.Expected
+		metadata["actual"] = mismatch.Actual
+	}
+	return &pb.FailureInfo{Reason: reason, Metadata: metadata}
+}
+
+// lockFailure describes a lock held by someone else on a patched path
+func lockFailure(lock *storage.PathLock) *pb.FailureInfo {
+	return &pb.FailureInfo{Reason: ReasonPathLocked, Metadata: map[string]string{
+		"path":       lock.Path,
+		"owner":      lock.Owner,
+		"expires_at": lock.ExpiresAt.Format(time.RFC3339),
+	}}
+}
+
+// policyFailure describes a patch rejected by branch protection or
+// validation; the violations themselves travel in the response
+func policyFailure(policy string) *pb.FailureInfo {
+	return &pb.FailureInfo{Reason: ReasonPolicyViolation, Metadata: map[string]string{"policy": policy}}
+}
+
+// versionNotFoundError rejects a request for a version the repository does
+// not have yet
+func versionNotFoundError(version, currentVersion int64) error {
+	return detailedError(codes.NotFound, fmt.Sprintf("version %d does not exist (current version is %d)", version, currentVersion), ReasonVersionNotFound,
+		map[string]string{"version": strconv.FormatInt(version, 10), "current_version": strconv.FormatInt(currentVersion, 10)},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "version", Description: fmt.Sprintf("must be between 0 and %d", currentVersion)},
+		}})
+}
+
+// failureInfo carries the ErrorInfo of a detailed error in-band, for
+// responses that report failures per item
+func failureInfo(err error) *pb.FailureInfo {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return &pb.FailureInfo{Reason: info.Reason, Metadata: info.Metadata}
+		}
+	}
+	return nil
+}
+
+// backendUnavailable reports whether err failed because the storage backend
+// timed out or its circuit breaker is open. Handlers wrap storage errors
+// with %w, or report them with internalError, so the chain says so.
+func backendUnavailable(err error) bool {
+	return errors.Is(err, storage.ErrBackendUnavailable)
+}
+
+// internalError is an INTERNAL error with the formatted message for a
+// failure caused by err, or UNAVAILABLE when the storage backend was
+// unavailable, since a status error does not keep err to be checked later
+func internalError(err error, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	if backendUnavailable(err) {
+		return detailedError(codes.Unavailable, message, ReasonBackendUnavailable, nil)
+	}
+	return status.Error(codes.Internal, message)
+}
+
+// unavailableError turns an error caused by an unavailable storage backend
+// into UNAVAILABLE, which clients retry, rather than INTERNAL or UNKNOWN
+func unavailableError(err error) error {
+	if err == nil || status.Code(err) == codes.Unavailable || !backendUnavailable(err) {
+		return err
+	}
+	return detailedError(codes.Unavailable, status.Convert(err).Message(), ReasonBackendUnavailable, nil)
+}
+
+func unavailableUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, unavailableError(err)
+	}
+}
+
+func unavailableStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return unavailableError(handler(srv, ss))
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// eventBuffer is how many events a subscriber may fall behind by before
+// newer ones are dropped for it
+const eventBuffer = 256
+
+// EventType names what an Event reports
+type EventType string
+
+// EventVersionCreated is published for every version MergePatch, the merge
+// queue or RestoreDeleted creates
+const EventVersionCreated EventType = "version.created"
+
+// Event is something that happened to the repository
+type Event struct {
+	Type       EventType
+	Version    int64
+	CommitHash string
+	Author     string
+	Message    string
+	Time       time.Time
+
+	changes *versionChanges // Shared by every copy of a version.created event
+}
+
+// versionChanges holds the files a version changed once someone has diffed
+// it, so that the RPC creating the version and each subscriber do not diff
+// it again
+type versionChanges struct {
+	mu    sync.Mutex
+	done  bool
+	files []storage.FileStat
+}
+
+// Files returns the files the event's version changed relative to the
+// version before it. The first caller diffs the versions and later callers
+// share the result; a failure is not kept, so a later caller with more
+// time left tries again. The returned files must not be modified.
+func (e Event) Files(ctx context.Context, repository storage.Repository) ([]storage.FileStat, error) {
+	if e.changes == nil {
+		return repository.ChangeStats(ctx, e.Version-1, e.Version)
+	}
+
+	e.changes.mu.Lock()
+	defer e.changes.mu.Unlock()
+	if !e.changes.done {
+		files, err := repository.ChangeStats(ctx, e.Version-1, e.Version)
+		if err != nil {
+			return nil, err
+		}
+		e.changes.files = files
+		e.changes.done = true
+	}
+	return e.changes.files, nil
+}
+
+// versionCreated returns the event for a version someone just created
+func versionCreated(info *storage.VersionInfo, author string) Event {
+	return Event{
+		Type:       EventVersionCreated,
+		Version:    info.Version,
+		CommitHash: string(info.CommitHash),
+		Author:     author,
+		Message:    info.Message,
+		Time:       info.Timestamp,
+		changes:    &versionChanges{},
+	}
+}
+
+// EventBus hands events to subscribers in the background so that slow
+// subscribers, such as notifiers calling out to other services, never hold
+// up the RPC that published the event. Each subscriber sees events in the
+// order they were published. A nil EventBus drops everything.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers []chan Event
+	closed      bool
+	wg          sync.WaitGroup
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe calls handle for every event published from now on, one at a
+// time, on a goroutine of its own
+func (b *EventBus) Subscribe(handle func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	events := make(chan Event, eventBuffer)
+	b.subscribers = append(b.subscribers, events)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for event := range events {
+			handle(event)
+		}
+	}()
+}
+
+// Publish queues an event for every subscriber without waiting for them
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	for _, events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			log.Printf("Warning: event subscriber is %d events behind; dropping %s event for version %d", eventBuffer, event.Type, event.Version)
+		}
+	}
+}
+
+// Pending returns how many published events subscribers have yet to
+// handle, over all subscribers
+func (b *EventBus) Pending() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pending := 0
+	for _, events := range b.subscribers {
+		pending += len(events)
+	}
+	return pending
+}
+
+// Close stops accepting events and waits for subscribers to handle the ones
+// already published
+func (b *EventBus) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, events := range b.subscribers {
+		close(events)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// changedSinceExpected checks a patch made against req.ExpectedVersion of
+// main before anything else is done with it, so a stale patch is refused
+// without being validated or queued: it describes the first file the patch
+// touches that is different now, or returns nil when none is. This is only
+// the early answer; the check is made again under the lock that creates the
+// version, directly or when the merge queue lands the patch.
+func (s *server) changedSinceExpected(ctx context.Context, req *pb.MergePatchRequest) (*pb.FailureInfo, error) {
+	if _, err := s.resolveVersion(ctx, req.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	// A patch that does not parse is reported by the checks after this one
+	if _, err := merge.ParsePatch(req.Patch); err != nil {
+		return nil, nil
+	}
+
+	err := s.repository.CheckPatchBase(ctx, req.Patch, req.ExpectedVersion)
+	var changed *storage.PathChangedError
+	if errors.As(err, &changed) {
+		return pathChangedFailure(changed), nil
+	}
+	return nil, err
+}
+
+// pathChangedFailure describes a file that changed after the version a
+// patch was made against
+func pathChangedFailure(changed *storage.PathChangedError) *pb.FailureInfo {
+	return &pb.FailureInfo{Reason: ReasonPathChanged, Metadata: map[string]string{
+		"path":             changed.Path,
+		"expected_version": strconv.FormatInt(changed.ExpectedVersion, 10),
+		"current_version":  strconv.FormatInt(changed.CurrentVersion, 10),
+	}}
+}
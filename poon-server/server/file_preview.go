@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/highlight"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxPreviewLines caps the lines one PreviewFile returns
+	maxPreviewLines = 2000
+
+	// maxPreviewLineBytes caps each previewed line; longer ones are cut
+	maxPreviewLineBytes = 4096
+)
+
+func (s *server) PreviewFile(ctx context.Context, req *pb.PreviewFileRequest) (*pb.PreviewFileResponse, error) {
+	log.Printf("Previewing file %s (lines %d-%d)", req.Path, req.FromLine, req.ToLine)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+	if req.FromLine < 0 || req.ToLine < 0 || req.MaxLines < 0 {
+		return nil, status.Error(codes.InvalidArgument, "line numbers and max_lines must not be negative")
+	}
+	from := max(req.FromLine, 1)
+	if req.ToLine != 0 && req.ToLine < from {
+		return nil, status.Errorf(codes.InvalidArgument, "to_line %d is before from_line %d", req.ToLine, from)
+	}
+	format, err := highlight.ParseFormat(req.Highlight)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	lang := highlight.Detect(req.Path)
+	if req.Language != "" {
+		if lang = highlight.Lookup(req.Language); lang == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown language %q", req.Language)
+		}
+	}
+
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.repository.GetEntry(ctx, version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read file entry", req.Path, version, err)
+	}
+	if entry.Type != storage.ObjectTypeBlob {
+		return nil, detailedError(codes.InvalidArgument, fmt.Sprintf("%s is a directory", req.Path), ReasonNotAFile,
+			map[string]string{"path": req.Path})
+	}
+
+	content, size, err := s.repository.OpenFile(ctx, version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read file", req.Path, version, err)
+	}
+	defer content.Close()
+
+	resp := &pb.PreviewFileResponse{
+		Path:    req.Path,
+		Version: version,
+		Hash:    string(entry.Hash),
+		Size:    size,
+	}
+
+	// Binary files are judged as patches judge them, by their attributes or
+	// their first 8000 bytes
+	reader := bufio.NewReaderSize(content, 64*1024)
+	head, err := reader.Peek(8000)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	rules, err := s.repository.Attributes(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes: %w", err)
+	}
+	if rules.Lookup(req.Path).Binary(head) {
+		resp.Binary = true
+		return resp, nil
+	}
+	if lang != nil && format != highlight.FormatNone {
+		resp.Language = lang.Name
+	}
+
+	// Lines before the range are still lexed, so comments and strings that
+	// start above it are highlighted within it
+	limit := int32(maxPreviewLines)
+	if req.MaxLines > 0 && req.MaxLines < limit {
+		limit = req.MaxLines
+	}
+	to := from + limit - 1
+	capped := req.ToLine == 0 || req.ToLine > to
+	if !capped {
+		to = req.ToLine
+	}
+	lexer := highlight.NewLexer(lang)
+	for number := int32(1); ; number++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		resp.TotalLines = number
+
+		if number > to {
+			continue
+		}
+		text, truncated := previewText(line)
+		tokens := lexer.Line(text)
+		if number >= from {
+			resp.Lines = append(resp.Lines, &pb.PreviewLine{
+				Number:    number,
+				Text:      format.Render(tokens),
+				Truncated: truncated,
+			})
+		}
+	}
+	resp.Truncated = capped && resp.TotalLines > to
+
+	return resp, nil
+}
+
+// previewText strips a line's ending and cuts it at maxPreviewLineBytes,
+// on a character boundary
+func previewText(line string) (string, bool) {
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if len(line) <= maxPreviewLineBytes {
+		return line, false
+	}
+	cut := maxPreviewLineBytes
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut], true
+}
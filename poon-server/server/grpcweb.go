@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// grpcWebTrailerFlag marks the frame carrying the trailers at the end of a
+// gRPC-Web response body
+const grpcWebTrailerFlag = 0x80
+
+// newGRPCWebHandler serves the gRPC-Web protocol to browsers over HTTP/1.1.
+// Each call is handed to grpcServer as if it had arrived over HTTP/2, so it
+// goes through the same interceptors (auth, deadlines, quotas) as any other;
+// only the framing differs. Both application/grpc-web and the base64
+// application/grpc-web-text are understood. Client streaming is not, as
+// browsers cannot stream request bodies.
+func newGRPCWebHandler(grpcServer *grpc.Server, cors *corsPolicy) http.Handler {
+	return cors.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		if r.Method != http.MethodPost || !strings.HasPrefix(contentType, "application/grpc-web") {
+			http.Error(w, "Only gRPC-Web requests are served here", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		text := strings.HasPrefix(contentType, "application/grpc-web-text")
+		subtype := strings.TrimPrefix(strings.TrimPrefix(contentType, "application/grpc-web-text"), "application/grpc-web")
+
+		req := r.Clone(r.Context())
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2", 2, 0
+		req.Header.Set("Content-Type", "application/grpc"+subtype)
+		req.Header.Del("Content-Length")
+		if text {
+			req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+		}
+
+		resp := &grpcWebResponse{w: w, header: make(http.Header), contentType: contentType, text: text}
+		grpcServer.ServeHTTP(resp, req)
+		resp.finish()
+	}))
+}
+
+// grpcWebResponse turns what grpc.Server writes for HTTP/2 into a gRPC-Web
+// response: the same headers and message frames, and the trailers as a
+// last frame of the body, where browsers can read them
+type grpcWebResponse struct {
+	w           http.ResponseWriter
+	header      http.Header // What grpc.Server sets, trailers included
+	contentType string
+	text        bool // Base64 body
+	wroteHeader bool
+}
+
+func (g *grpcWebResponse) Header() http.Header {
+	return g.header
+}
+
+func (g *grpcWebResponse) WriteHeader(code int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+
+	trailers := g.trailerNames()
+	h := g.w.Header()
+	for name, values := range g.header {
+		if name == "Trailer" || trailers[name] || strings.HasPrefix(name, http.TrailerPrefix) {
+			continue
+		}
+		h[name] = values
+	}
+	h.Set("Content-Type", g.contentType)
+	h.Del("Content-Length")
+	g.w.WriteHeader(code)
+}
+
+func (g *grpcWebResponse) Write(data []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.text {
+		// Each write is padded on its own; gRPC-Web clients decode the
+		// body chunk by chunk
+		if _, err := io.WriteString(g.w, base64.StdEncoding.EncodeToString(data)); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	return g.w.Write(data)
+}
+
+func (g *grpcWebResponse) Flush() {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if f, ok := g.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// trailerNames returns the canonical names the Trailer header declared
+func (g *grpcWebResponse) trailerNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, value := range g.header.Values("Trailer") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+	return names
+}
+
+// finish writes the trailers grpc.Server set as the trailer frame. Nothing
+// is written for requests grpc.Server refused before starting a call.
+func (g *grpcWebResponse) finish() {
+	trailers := make(map[string][]string)
+	for name := range g.trailerNames() {
+		if values := g.header.Values(name); len(values) > 0 {
+			trailers[strings.ToLower(name)] = values
+		}
+	}
+	for name, values := range g.header {
+		if strings.HasPrefix(name, http.TrailerPrefix) {
+			trailers[strings.ToLower(strings.TrimPrefix(name, http.TrailerPrefix))] = values
+		}
+	}
+	if _, ok := trailers["grpc-status"]; !ok {
+		return
+	}
+
+	names := make([]string, 0, len(trailers))
+	for name := range trailers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var block strings.Builder
+	for _, name := range names {
+		for _, value := range trailers[name] {
+			fmt.Fprintf(&block, "%s: %s\r\n", name, value)
+		}
+	}
+
+	frame := make([]byte, 5, 5+block.Len())
+	frame[0] = grpcWebTrailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(block.Len()))
+	frame = append(frame, block.String()...)
+	g.Write(frame)
+	g.Flush()
+}
+
+// corsPolicy answers browsers' cross-origin checks for the origins allowed
+// to call the gRPC-Web and CAS APIs
+type corsPolicy struct {
+	origins map[string]bool
+	any     bool // "*" was allowed
+}
+
+// newCORSPolicy allows the given origins, such as https://poon.example.com;
+// "*" allows every origin. Without any, no CORS headers are sent and
+// browsers only allow same-origin calls.
+func newCORSPolicy(origins []string) *corsPolicy {
+	p := &corsPolicy{origins: make(map[string]bool)}
+	for _, origin := range origins {
+		if origin == "*" {
+			p.any = true
+		} else {
+			p.origins[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+	return p
+}
+
+func (p *corsPolicy) allows(origin string) bool {
+	return origin != "" && (p.any || p.origins[origin])
+}
+
+// wrap adds the CORS headers for allowed origins to next's responses and
+// answers their preflight requests
+func (p *corsPolicy) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		if !p.allows(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin, Content-Length, ETag")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+const defaultLockTTL = time.Hour
+
+// lockOwner returns the identity locks are recorded under. Authenticated
+// users always act as themselves; the request-supplied owner is only used
+// when the server runs without authentication.
+func lockOwner(ctx context.Context, requested string) string {
+	if user := userFromContext(ctx); user != "" {
+		return user
+	}
+	return requested
+}
+
+func lockToProto(lock *storage.PathLock) *pb.PathLock {
+	if lock == nil {
+		return nil
+	}
+	return &pb.PathLock{
+		Path:      lock.Path,
+		Owner:     lock.Owner,
+		CreatedAt: lock.CreatedAt.Unix(),
+		ExpiresAt: lock.ExpiresAt.Unix(),
+	}
+}
+
+func (s *server) LockPath(ctx context.Context, req *pb.LockPathRequest) (*pb.LockPathResponse, error) {
+	log.Printf("Locking path: %s", req.Path)
+
+	if req.Path == "" {
+		return &pb.LockPathResponse{Success: false, Message: "Path is required"}, nil
+	}
+	if err := validatePath(req.Path); err != nil {
+		return &pb.LockPathResponse{Success: false, Message: fmt.Sprintf("Invalid path: %v", err)}, nil
+	}
+
+	owner := lockOwner(ctx, req.Owner)
+	if owner == "" {
+		return &pb.LockPathResponse{Success: false, Message: "Lock owner is required"}, nil
+	}
+
+	ttl := defaultLockTTL
+	if req.TtlSeconds > 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+
+	lock, err := s.locks.Acquire(ctx, req.Path, owner, ttl)
+	if err != nil {
+		return &pb.LockPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to lock %s: %v", req.Path, err),
+			Lock:    lockToProto(lock),
+		}, nil
+	}
+
+	return &pb.LockPathResponse{
+		Success: true,
+		Message: fmt.Sprintf("Locked %s until %s", lock.Path, lock.ExpiresAt.Format(time.RFC3339)),
+		Lock:    lockToProto(lock),
+	}, nil
+}
+
+func (s *server) UnlockPath(ctx context.Context, req *pb.UnlockPathRequest) (*pb.UnlockPathResponse, error) {
+	log.Printf("Unlocking path: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return &pb.UnlockPathResponse{Success: false, Message: fmt.Sprintf("Invalid path: %v", err)}, nil
+	}
+
+	if err := s.locks.Release(ctx, req.Path, lockOwner(ctx, req.Owner), false); err != nil {
+		return &pb.UnlockPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to unlock %s: %v", req.Path, err),
+		}, nil
+	}
+
+	return &pb.UnlockPathResponse{
+		Success: true,
+		Message: fmt.Sprintf("Unlocked %s", req.Path),
+	}, nil
+}
+
+func (s *server) ListLocks(ctx context.Context, req *pb.ListLocksRequest) (*pb.ListLocksResponse, error) {
+	log.Printf("Listing locks under: %s", req.PathPrefix)
+
+	locks, err := s.locks.List(ctx, req.PathPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locks: %w", err)
+	}
+
+	resp := &pb.ListLocksResponse{}
+	for _, lock := range locks {
+		resp.Locks = append(resp.Locks, lockToProto(lock))
+	}
+	return resp, nil
+}
+
+// checkPatchLocks reports a conflicting lock on the file targeted by a
+// MergePatch request, if the request asked for lock enforcement
+func (s *server) checkPatchLocks(ctx context.Context, req *pb.MergePatchRequest) (*storage.PathLock, error) {
+	if !req.FailIfLocked {
+		return nil, nil
+	}
+
+	// Locks apply to the file the patch modifies; fall back to the request
+	// path when the patch cannot be parsed
+	target := req.Path
+	if parsed, err := merge.ParsePatch(req.Patch); err == nil {
+		if file := parsed.Target(); file != "" {
+			target = file
+		}
+	}
+
+	return s.locks.FindConflict(ctx, target, lockOwner(ctx, req.Author))
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// mainlineRejection is why a merge or cherry-pick into main was refused by
+// the checks MergePatch makes of patches to main
+type mainlineRejection struct {
+	message    string
+	failure    *pb.FailureInfo
+	violations []*pb.PolicyViolation
+}
+
+// checkMainline runs the checks a patch to main goes through over every
+// file a merge or cherry-pick into main would change: the patch limits, the
+// path locks when failIfLocked is set, and the validators. Limit errors are
+// returned as errors, as MergePatch returns them; locks and validators that
+// refuse the change are returned as a rejection, whose message starts with
+// kind, such as "Merge".
+func (s *server) checkMainline(ctx context.Context, kind string, changes []storage.FileChange, author, message string, failIfLocked bool) (*mainlineRejection, error) {
+	if limit := s.patchLimits.MaxFiles; limit > 0 && len(changes) > limit {
+		return nil, patchTooLarge("max_files", int64(len(changes)), int64(limit),
+			fmt.Sprintf("change touches %d files, limit is %d", len(changes), limit))
+	}
+
+	if failIfLocked {
+		owner := lockOwner(ctx, author)
+		for _, change := range changes {
+			lock, err := s.locks.FindConflict(ctx, change.Path, owner)
+			if err != nil {
+				return &mainlineRejection{message: fmt.Sprintf("Failed to check locks: %v", err)}, nil
+			}
+			if lock != nil {
+				return &mainlineRejection{
+					message: fmt.Sprintf("Path %s is locked by %s until %s", lock.Path, lock.Owner, lock.ExpiresAt.Format(time.RFC3339)),
+					failure: lockFailure(lock),
+				}, nil
+			}
+		}
+	}
+
+	var violations []*pb.PolicyViolation
+	for _, fileChange := range changes {
+		change, err := s.mainlineChange(ctx, fileChange, author, message)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.patchLimits.CheckFileSize(change); err != nil {
+			return nil, err
+		}
+		violations = append(violations, s.validatePatch(ctx, change)...)
+	}
+	if len(violations) > 0 {
+		return &mainlineRejection{
+			message:    fmt.Sprintf("%s rejected by validation: %s", kind, formatViolations(violations)),
+			failure:    policyFailure("validation"),
+			violations: violations,
+		}, nil
+	}
+	return nil, nil
+}
+
+// mainlineChange describes one file a merge or cherry-pick changes as the
+// patch validators see: a patch replacing the file's old content with its
+// new content, or a literal binary patch for binary files
+func (s *server) mainlineChange(ctx context.Context, fileChange storage.FileChange, author, message string) (*Change, error) {
+	oldPath := fileChange.Path
+	if fileChange.OldPath != "" {
+		oldPath = fileChange.OldPath
+	}
+	header := merge.PatchHeader{OldFile: oldPath, NewFile: fileChange.Path}
+	if fileChange.Type == storage.ChangeAdded {
+		header.OldFile = merge.DevNull
+	}
+	if fileChange.Type == storage.ChangeDeleted {
+		header.NewFile = merge.DevNull
+	}
+
+	change := &Change{
+		Path:        fileChange.Path,
+		TargetFile:  fileChange.Path,
+		Author:      author,
+		Message:     message,
+		CurrentSize: -1,
+	}
+
+	// Sizes are checked before content is read, so an oversized file in a
+	// branch costs no more than its blob header
+	var newSize int64
+	if fileChange.NewHash != "" {
+		r, size, err := s.repository.OpenBlob(ctx, fileChange.NewHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", fileChange.Path, err)
+		}
+		r.Close()
+		newSize = size
+	}
+	if limit := s.patchLimits.MaxFileBytes; limit > 0 && newSize > limit {
+		return nil, patchTooLarge("max_file_bytes", newSize, limit,
+			fmt.Sprintf("%s would be %d bytes, limit is %d bytes", fileChange.Path, newSize, limit))
+	}
+
+	oldContent, err := s.blobContent(ctx, fileChange.OldHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", oldPath, err)
+	}
+	newContent, err := s.blobContent(ctx, fileChange.NewHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fileChange.Path, err)
+	}
+	if fileChange.OldHash != "" {
+		change.CurrentSize = int64(len(oldContent))
+	}
+
+	if bytes.IndexByte(oldContent, 0) >= 0 || bytes.IndexByte(newContent, 0) >= 0 {
+		change.Parsed = &merge.ParsedPatch{Header: header, Binary: &merge.BinaryPatch{
+			Forward: &merge.BinaryHunk{Kind: "literal", Size: len(newContent), Data: newContent},
+		}}
+		change.Patch = []byte(fmt.Sprintf("diff --git a/%s b/%s\nBinary files differ\n", oldPath, fileChange.Path))
+		return change, nil
+	}
+
+	change.Parsed = &merge.ParsedPatch{Header: header}
+	change.Patch = replacementPatch(change.Parsed, oldContent, newContent)
+	return change, nil
+}
+
+// blobContent reads a blob, or nothing for an empty hash
+func (s *server) blobContent(ctx context.Context, hash storage.Hash) ([]byte, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	blob, err := s.repository.GetBlob(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return blob.Content, nil
+}
+
+// replacementPatch fills parsed with one hunk replacing all of oldContent
+// with newContent and returns it as a unified diff
+func replacementPatch(parsed *merge.ParsedPatch, oldContent, newContent []byte) []byte {
+	hunk := merge.PatchHunk{}
+	addLines := func(lineType string, content []byte) int {
+		if len(content) == 0 {
+			return 0
+		}
+		text := string(content)
+		noNewline := !strings.HasSuffix(text, "\n")
+		lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		for i, line := range lines {
+			hunk.Lines = append(hunk.Lines, merge.PatchLine{
+				Type:      lineType,
+				Content:   line,
+				NoNewline: noNewline && i == len(lines)-1,
+			})
+		}
+		return len(lines)
+	}
+	hunk.OldCount = addLines("-", oldContent)
+	hunk.NewCount = addLines("+", newContent)
+	if hunk.OldCount > 0 {
+		hunk.OldStart = 1
+	}
+	if hunk.NewCount > 0 {
+		hunk.NewStart = 1
+	}
+
+	var b strings.Builder
+	name := func(prefix, file string) string {
+		if file == merge.DevNull {
+			return file
+		}
+		return prefix + file
+	}
+	oldPath, newPath := parsed.Header.OldFile, parsed.Header.NewFile
+	if oldPath == merge.DevNull {
+		oldPath = newPath
+	}
+	if newPath == merge.DevNull {
+		newPath = oldPath
+	}
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", oldPath, newPath)
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", name("a/", parsed.Header.OldFile), name("b/", parsed.Header.NewFile))
+	if len(hunk.Lines) > 0 {
+		parsed.Hunks = []merge.PatchHunk{hunk}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldCount, hunk.NewStart, hunk.NewCount)
+		for _, line := range hunk.Lines {
+			b.WriteString(line.Type + line.Content + "\n")
+			if line.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return []byte(b.String())
+}
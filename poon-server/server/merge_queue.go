@@ -0,0 +1,770 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+const (
+	defaultValidationTimeout = time.Hour
+	defaultKeepFinished      = 100
+
+	// maxQueueRebases bounds how often a change is rebased and validated
+	// again because a version was created outside the queue meanwhile
+	maxQueueRebases = 3
+
+	// webhookTimeout bounds the delivery of a validation request; the
+	// verdict itself arrives later through ReportQueueValidation
+	webhookTimeout = 30 * time.Second
+)
+
+// MergeQueueConfig configures the merge queue. It is loaded from the JSON
+// file named by MERGE_QUEUE_CONFIG; without one MergePatch lands patches
+// directly.
+type MergeQueueConfig struct {
+	// WebhookURL receives each change once it is rebased onto the current
+	// version. Without it changes land as soon as they rebase cleanly.
+	WebhookURL string `json:"webhookURL"`
+	// WebhookSecret, when set, signs webhook bodies with HMAC-SHA256 in the
+	// X-Poon-Signature header
+	WebhookSecret            string `json:"webhookSecret"`
+	ValidationTimeoutSeconds int64  `json:"validationTimeoutSeconds"` // How long to wait for a verdict (default 3600)
+	KeepFinished             int    `json:"keepFinished"`             // Finished entries reported by GetMergeQueue (default 100)
+}
+
+// MergeQueue lands patches one at a time in submission order. Each change is
+// rebased onto the current version and, if a webhook is configured, handed to
+// an external validator; it lands only when the validator reports success
+// for exactly that version, so every version on the line has passed.
+type MergeQueue struct {
+	config     MergeQueueConfig
+	repository storage.Repository
+	client     *http.Client
+	events     *EventBus           // Told about landed versions
+	store      *storage.QueueStore // Keeps entries across restarts; nil keeps them in memory only
+
+	mu       sync.Mutex
+	pending  []*queueEntry // In queue order; the first is in progress
+	finished []*queueEntry // Newest first
+	wake     chan struct{}
+}
+
+type queueEntry struct {
+	id      string
+	author  string
+	message string
+	path    string
+	patch   []byte
+	opts    merge.ApplyOptions
+	// Version the patch was made against, checked again when it lands; 0
+	// for none
+	expectedVersion int64
+
+	// Set instead of patch for an entry that merges a branch into main, or
+	// that cherry-picks a commit onto it
+	source string
+	squash bool
+	pick   storage.Hash
+
+	state         pb.QueueEntryState
+	stateMessage  string
+	baseVersion   int64
+	landedVersion int64
+	commitHash    string
+	detailsURL    string
+	submittedAt   time.Time
+	updatedAt     time.Time
+	landing       bool // Being committed to main
+
+	// Set while waiting for a validator; a new token is issued for every
+	// validation so verdicts for an earlier rebase are refused
+	token   string
+	verdict chan queueVerdict
+}
+
+type queueVerdict struct {
+	passed     bool
+	message    string
+	detailsURL string
+}
+
+// queueWebhook is the body POSTed to MergeQueueConfig.WebhookURL. The
+// validator applies Patch to BaseVersion, merges SourceBranch into it or
+// cherry-picks PickCommit onto it, tests the result and calls
+// ReportQueueValidation with EntryID and CallbackToken.
+type queueWebhook struct {
+	EntryID       string `json:"entryId"`
+	CallbackToken string `json:"callbackToken"`
+	Author        string `json:"author"`
+	Message       string `json:"message"`
+	Path          string `json:"path"`
+	BaseVersion   int64  `json:"baseVersion"`
+	Patch         string `json:"patch"`
+	SourceBranch  string `json:"sourceBranch,omitempty"`
+	Squash        bool   `json:"squash,omitempty"`
+	PickCommit    string `json:"pickCommit,omitempty"`
+}
+
+// NewMergeQueue creates a merge queue; Run must be called to process it
+func NewMergeQueue(config MergeQueueConfig, repository storage.Repository) *MergeQueue {
+	if config.ValidationTimeoutSeconds <= 0 {
+		config.ValidationTimeoutSeconds = int64(defaultValidationTimeout / time.Second)
+	}
+	if config.KeepFinished <= 0 {
+		config.KeepFinished = defaultKeepFinished
+	}
+	return &MergeQueue{
+		config:     config,
+		repository: repository,
+		client:     &http.Client{Timeout: webhookTimeout},
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// LoadMergeQueue reads a merge queue config file
+func LoadMergeQueue(path string, repository storage.Repository) (*MergeQueue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merge queue config: %w", err)
+	}
+
+	var config MergeQueueConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse merge queue config: %w", err)
+	}
+
+	return NewMergeQueue(config, repository), nil
+}
+
+// Len returns how many changes are waiting in the queue, including the one
+// in progress
+func (q *MergeQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Submit adds a patch to the end of the queue
+func (q *MergeQueue) Submit(ctx context.Context, req *pb.MergePatchRequest) (*pb.QueueEntry, error) {
+	now := time.Now()
+	entry := &queueEntry{
+		id:      uuid.New().String(),
+		author:  req.Author,
+		message: req.Message,
+		path:    req.Path,
+		patch:   req.Patch,
+		opts: merge.ApplyOptions{
+			IgnoreWhitespace:        req.IgnoreWhitespace,
+			NormalizeLineEndings:    req.NormalizeLineEndings,
+			PreserveTrailingNewline: req.PreserveTrailingNewline,
+		},
+		expectedVersion: req.ExpectedVersion,
+		state:           pb.QueueEntryState_QUEUE_PENDING,
+		stateMessage:    "Waiting in queue",
+		submittedAt:     now,
+		updatedAt:       now,
+	}
+
+	return q.enqueue(ctx, entry)
+}
+
+// SubmitMerge adds a merge of a branch into main to the end of the queue
+func (q *MergeQueue) SubmitMerge(ctx context.Context, req *pb.MergeBranchesRequest) (*pb.QueueEntry, error) {
+	now := time.Now()
+	return q.enqueue(ctx, &queueEntry{
+		id:           uuid.New().String(),
+		author:       req.Author,
+		message:      req.Message,
+		source:       req.SourceBranch,
+		squash:       req.Squash,
+		state:        pb.QueueEntryState_QUEUE_PENDING,
+		stateMessage: "Waiting in queue",
+		submittedAt:  now,
+		updatedAt:    now,
+	})
+}
+
+// SubmitPick adds a cherry-pick of a commit onto main to the end of the
+// queue; message is the one the pick will have
+func (q *MergeQueue) SubmitPick(ctx context.Context, commit storage.Hash, author, message string) (*pb.QueueEntry, error) {
+	now := time.Now()
+	return q.enqueue(ctx, &queueEntry{
+		id:           uuid.New().String(),
+		author:       author,
+		message:      message,
+		pick:         commit,
+		state:        pb.QueueEntryState_QUEUE_PENDING,
+		stateMessage: "Waiting in queue",
+		submittedAt:  now,
+		updatedAt:    now,
+	})
+}
+
+// enqueue stores entry, so it is not lost to a restart once accepted, and
+// adds it to the end of the queue
+func (q *MergeQueue) enqueue(ctx context.Context, entry *queueEntry) (*pb.QueueEntry, error) {
+	if q.store != nil {
+		if err := q.store.Put(ctx, entry.record()); err != nil {
+			return nil, err
+		}
+	}
+
+	q.mu.Lock()
+	q.pending = append(q.pending, entry)
+	info := q.entryInfo(entry)
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return info, nil
+}
+
+// Recover loads the entries an earlier run stored; it is called before Run.
+// Entries that were waiting for a validator are validated again, as their
+// verdict can no longer be delivered. An entry that was being committed to
+// main when the server stopped is marked landed if the version it would
+// have created exists, and otherwise goes through the queue again.
+func (q *MergeQueue) Recover(ctx context.Context) error {
+	records, err := q.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending, finished []*queueEntry
+	for _, record := range records {
+		entry := entryFromRecord(record)
+		if entry.state == pb.QueueEntryState_QUEUE_LANDED || entry.state == pb.QueueEntryState_QUEUE_FAILED {
+			finished = append(finished, entry)
+			continue
+		}
+
+		if entry.landing {
+			landed, err := q.landedAs(ctx, entry)
+			if err != nil {
+				return err
+			}
+			if landed != nil {
+				entry.landing = false
+				entry.state = pb.QueueEntryState_QUEUE_LANDED
+				entry.stateMessage = fmt.Sprintf("Landed as version %d", landed.Version)
+				entry.landedVersion = landed.Version
+				entry.commitHash = string(landed.CommitHash)
+				entry.updatedAt = time.Now()
+				if err := q.store.Put(ctx, entry.record()); err != nil {
+					return err
+				}
+				finished = append(finished, entry)
+				continue
+			}
+		}
+		entry.landing = false
+		entry.state = pb.QueueEntryState_QUEUE_PENDING
+		entry.stateMessage = "Waiting in queue (recovered after a restart)"
+		pending = append(pending, entry)
+	}
+
+	sort.SliceStable(finished, func(i, j int) bool { return finished[i].updatedAt.After(finished[j].updatedAt) })
+	if len(finished) > q.config.KeepFinished {
+		for _, dropped := range finished[q.config.KeepFinished:] {
+			if err := q.store.Delete(ctx, dropped.id); err != nil {
+				return err
+			}
+		}
+		finished = finished[:q.config.KeepFinished]
+	}
+
+	q.mu.Lock()
+	q.pending = append(pending, q.pending...)
+	q.finished = append(q.finished, finished...)
+	q.mu.Unlock()
+	if len(pending) > 0 {
+		log.Printf("Merge queue recovered %d pending entries", len(pending))
+	}
+	return nil
+}
+
+// landedAs returns the version an entry that was landing created, or nil if
+// the version after its base is not its commit
+func (q *MergeQueue) landedAs(ctx context.Context, entry *queueEntry) (*storage.VersionInfo, error) {
+	current, err := q.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+	if entry.baseVersion >= current {
+		return nil, nil
+	}
+	info, err := q.repository.GetVersionInfo(ctx, entry.baseVersion+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version %d: %w", entry.baseVersion+1, err)
+	}
+	commit, err := q.repository.GetCommit(ctx, info.CommitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit of version %d: %w", info.Version, err)
+	}
+	if commit.Author != entry.author || commit.Message != entry.message {
+		return nil, nil
+	}
+	return info, nil
+}
+
+// Entries returns the entry with the given ID, or every pending and finished
+// entry when id is empty
+func (q *MergeQueue) Entries(id string) ([]*pb.QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var entries []*pb.QueueEntry
+	for _, list := range [][]*queueEntry{q.pending, q.finished} {
+		for _, entry := range list {
+			if id == "" || entry.id == id {
+				entries = append(entries, q.entryInfo(entry))
+			}
+		}
+	}
+	if id != "" && len(entries) == 0 {
+		return nil, fmt.Errorf("merge queue entry %s not found", id)
+	}
+	return entries, nil
+}
+
+// Report delivers a validator's verdict for the validation identified by token
+func (q *MergeQueue) Report(id, token string, verdict queueVerdict) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, entry := range q.pending {
+		if entry.id != id {
+			continue
+		}
+		if entry.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(entry.token)) != 1 {
+			return fmt.Errorf("entry %s is not waiting for this validation", id)
+		}
+		entry.token = ""
+		entry.verdict <- verdict // Buffered, and the token is cleared so only one verdict is sent
+		return nil
+	}
+	return fmt.Errorf("entry %s is not in the queue", id)
+}
+
+// Run processes the queue until ctx is cancelled. Entries in progress when
+// it stops stay at the head of the queue.
+func (q *MergeQueue) Run(ctx context.Context) {
+	for {
+		q.mu.Lock()
+		var head *queueEntry
+		if len(q.pending) > 0 {
+			head = q.pending[0]
+		}
+		q.mu.Unlock()
+
+		if head == nil {
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		q.process(ctx, head)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (q *MergeQueue) process(ctx context.Context, entry *queueEntry) {
+	for rebase := 1; ; rebase++ {
+		baseVersion, failure := q.preview(ctx, entry)
+		if failure != "" {
+			q.finish(entry, pb.QueueEntryState_QUEUE_FAILED, failure)
+			return
+		}
+		q.update(entry, func() { entry.baseVersion = baseVersion })
+
+		if q.config.WebhookURL != "" {
+			if err := q.validate(ctx, entry); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				q.finish(entry, pb.QueueEntryState_QUEUE_FAILED, fmt.Sprintf("Validation failed: %v", err))
+				return
+			}
+		}
+
+		// Only the queue creates versions while it is enabled, but an
+		// administrator restoring a backup can still move the line
+		currentVersion, err := q.repository.GetCurrentVersion(ctx)
+		if err != nil {
+			q.finish(entry, pb.QueueEntryState_QUEUE_FAILED, fmt.Sprintf("Failed to get current version: %v", err))
+			return
+		}
+		if currentVersion != baseVersion {
+			if rebase == maxQueueRebases {
+				q.finish(entry, pb.QueueEntryState_QUEUE_FAILED, fmt.Sprintf("Version %d was created during validation %d times; resubmit the change", currentVersion, rebase))
+				return
+			}
+			log.Printf("Merge queue entry %s was validated at version %d but the current version is %d; rebasing", entry.id, baseVersion, currentVersion)
+			continue
+		}
+
+		q.update(entry, func() { entry.landing = true })
+		versionInfo, failure := q.land(ctx, entry)
+		if failure != "" {
+			q.finish(entry, pb.QueueEntryState_QUEUE_FAILED, failure)
+			return
+		}
+		if versionInfo == nil {
+			q.finish(entry, pb.QueueEntryState_QUEUE_LANDED, fmt.Sprintf("%s already has the changes", storage.MainBranch))
+			return
+		}
+
+		q.update(entry, func() {
+			entry.landedVersion = versionInfo.Version
+			entry.commitHash = string(versionInfo.CommitHash)
+		})
+		q.events.Publish(versionCreated(versionInfo, entry.author))
+		q.finish(entry, pb.QueueEntryState_QUEUE_LANDED, fmt.Sprintf("Landed as version %d", versionInfo.Version))
+		return
+	}
+}
+
+// preview checks that an entry still applies to main and returns the
+// version it would be made on, or why the entry failed
+func (q *MergeQueue) preview(ctx context.Context, entry *queueEntry) (int64, string) {
+	if entry.source == "" && entry.pick == "" {
+		if entry.expectedVersion != 0 {
+			if err := q.repository.CheckPatchBase(ctx, entry.patch, entry.expectedVersion); err != nil {
+				return 0, fmt.Sprintf("Patch is out of date: %v", err)
+			}
+		}
+		preview, err := q.repository.PreviewPatch(ctx, entry.patch, entry.opts)
+		if err != nil {
+			return 0, fmt.Sprintf("Patch no longer applies: %v", err)
+		}
+		return preview.BaseVersion, ""
+	}
+
+	currentVersion, err := q.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return 0, fmt.Sprintf("Failed to get current version: %v", err)
+	}
+	if entry.pick != "" {
+		if _, err := q.repository.PreviewCherryPick(ctx, entry.pick, storage.MainBranch); err != nil {
+			return 0, fmt.Sprintf("Commit %s no longer applies: %v", entry.pick, err)
+		}
+		return currentVersion, ""
+	}
+	if _, err := q.repository.PreviewMerge(ctx, entry.source, storage.MainBranch); err != nil {
+		return 0, fmt.Sprintf("Branch %s no longer merges: %v", entry.source, err)
+	}
+	return currentVersion, ""
+}
+
+// land commits an entry to main and returns the version created, which is
+// nil when main already had a merge's or pick's changes, or why the entry
+// failed
+func (q *MergeQueue) land(ctx context.Context, entry *queueEntry) (*storage.VersionInfo, string) {
+	if entry.pick != "" {
+		result, err := q.repository.CherryPick(ctx, entry.pick, storage.MainBranch, entry.author)
+		if err != nil {
+			return nil, fmt.Sprintf("Failed to cherry-pick %s: %v", entry.pick, err)
+		}
+		return result.Version, ""
+	}
+	if entry.source == "" {
+		versionInfo, err := q.repository.ApplyPatchAgainst(ctx, entry.patch, entry.author, entry.message, entry.opts, entry.expectedVersion)
+		if err != nil {
+			return nil, fmt.Sprintf("Failed to apply patch: %v", err)
+		}
+		return versionInfo, ""
+	}
+
+	result, err := q.repository.MergeBranches(ctx, entry.source, storage.MainBranch, entry.author, entry.message, storage.MergeOptions{Squash: entry.squash})
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to merge branch %s: %v", entry.source, err)
+	}
+	return result.Version, ""
+}
+
+// validate asks the webhook to validate entry at its base version and waits
+// for the verdict
+func (q *MergeQueue) validate(ctx context.Context, entry *queueEntry) error {
+	token := uuid.New().String()
+	verdict := make(chan queueVerdict, 1)
+	q.update(entry, func() {
+		entry.state = pb.QueueEntryState_QUEUE_VALIDATING
+		entry.stateMessage = fmt.Sprintf("Waiting for validation at version %d", entry.baseVersion)
+		entry.token = token
+		entry.verdict = verdict
+		entry.detailsURL = ""
+	})
+	defer q.update(entry, func() { entry.token = "" })
+
+	if err := q.notify(ctx, entry, token); err != nil {
+		return fmt.Errorf("could not reach validator: %w", err)
+	}
+
+	timeout := time.Duration(q.config.ValidationTimeoutSeconds) * time.Second
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case v := <-verdict:
+		q.update(entry, func() { entry.detailsURL = v.detailsURL })
+		if v.passed {
+			return nil
+		}
+		if v.message == "" {
+			return errors.New("rejected by validator")
+		}
+		return errors.New(v.message)
+	case <-timer.C:
+		return fmt.Errorf("no verdict within %s", timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *MergeQueue) notify(ctx context.Context, entry *queueEntry, token string) error {
+	q.mu.Lock()
+	body, err := json.Marshal(queueWebhook{
+		EntryID:       entry.id,
+		CallbackToken: token,
+		Author:        entry.author,
+		Message:       entry.message,
+		Path:          entry.path,
+		BaseVersion:   entry.baseVersion,
+		Patch:         string(entry.patch),
+		SourceBranch:  entry.source,
+		Squash:        entry.squash,
+		PickCommit:    string(entry.pick),
+	})
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.config.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(q.config.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Poon-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// update changes entry under the queue lock and stores it
+func (q *MergeQueue) update(entry *queueEntry, change func()) {
+	q.mu.Lock()
+	change()
+	entry.updatedAt = time.Now()
+	record := entry.record()
+	q.mu.Unlock()
+
+	q.save(record, nil)
+}
+
+// save stores an entry and deletes the records of finished entries no
+// longer kept. The entry carries on in memory if it cannot be stored.
+func (q *MergeQueue) save(record *storage.QueueRecord, dropped []*queueEntry) {
+	if q.store == nil {
+		return
+	}
+	ctx := context.Background()
+	if err := q.store.Put(ctx, record); err != nil {
+		log.Printf("Warning: merge queue entry %s: %v", record.ID, err)
+	}
+	for _, entry := range dropped {
+		if err := q.store.Delete(ctx, entry.id); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// finish moves entry from the pending list to the finished list
+func (q *MergeQueue) finish(entry *queueEntry, state pb.QueueEntryState, message string) {
+	log.Printf("Merge queue entry %s: %s", entry.id, message)
+
+	q.mu.Lock()
+	entry.state = state
+	entry.stateMessage = message
+	entry.updatedAt = time.Now()
+	entry.landing = false
+
+	for i, pending := range q.pending {
+		if pending == entry {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	q.finished = append([]*queueEntry{entry}, q.finished...)
+	var dropped []*queueEntry
+	if len(q.finished) > q.config.KeepFinished {
+		dropped = q.finished[q.config.KeepFinished:]
+		q.finished = q.finished[:q.config.KeepFinished]
+	}
+	record := entry.record()
+	q.mu.Unlock()
+
+	q.save(record, dropped)
+}
+
+// record converts an entry for the queue store; q.mu must be held once the
+// entry is queued
+func (entry *queueEntry) record() *storage.QueueRecord {
+	return &storage.QueueRecord{
+		ID:                      entry.id,
+		Author:                  entry.author,
+		Message:                 entry.message,
+		Path:                    entry.path,
+		Patch:                   entry.patch,
+		IgnoreWhitespace:        entry.opts.IgnoreWhitespace,
+		NormalizeLineEndings:    entry.opts.NormalizeLineEndings,
+		PreserveTrailingNewline: entry.opts.PreserveTrailingNewline,
+		ExpectedVersion:         entry.expectedVersion,
+		Source:                  entry.source,
+		Squash:                  entry.squash,
+		Pick:                    entry.pick,
+		State:                   entry.state.String(),
+		StateMessage:            entry.stateMessage,
+		BaseVersion:             entry.baseVersion,
+		LandedVersion:           entry.landedVersion,
+		CommitHash:              entry.commitHash,
+		DetailsURL:              entry.detailsURL,
+		SubmittedAt:             entry.submittedAt,
+		UpdatedAt:               entry.updatedAt,
+		Landing:                 entry.landing,
+	}
+}
+
+// entryFromRecord restores a stored entry
+func entryFromRecord(record *storage.QueueRecord) *queueEntry {
+	return &queueEntry{
+		id:      record.ID,
+		author:  record.Author,
+		message: record.Message,
+		path:    record.Path,
+		patch:   record.Patch,
+		opts: merge.ApplyOptions{
+			IgnoreWhitespace:        record.IgnoreWhitespace,
+			NormalizeLineEndings:    record.NormalizeLineEndings,
+			PreserveTrailingNewline: record.PreserveTrailingNewline,
+		},
+		expectedVersion: record.ExpectedVersion,
+		source:          record.Source,
+		squash:          record.Squash,
+		pick:            record.Pick,
+		state:           pb.QueueEntryState(pb.QueueEntryState_value[record.State]),
+		stateMessage:    record.StateMessage,
+		baseVersion:     record.BaseVersion,
+		landedVersion:   record.LandedVersion,
+		commitHash:      record.CommitHash,
+		detailsURL:      record.DetailsURL,
+		submittedAt:     record.SubmittedAt,
+		updatedAt:       record.UpdatedAt,
+		landing:         record.Landing,
+	}
+}
+
+// entryInfo converts an entry for the API; q.mu must be held
+func (q *MergeQueue) entryInfo(entry *queueEntry) *pb.QueueEntry {
+	info := &pb.QueueEntry{
+		Id:            entry.id,
+		Author:        entry.author,
+		Message:       entry.message,
+		Path:          entry.path,
+		State:         entry.state,
+		StateMessage:  entry.stateMessage,
+		BaseVersion:   entry.baseVersion,
+		LandedVersion: entry.landedVersion,
+		CommitHash:    entry.commitHash,
+		SubmittedAt:   entry.submittedAt.Unix(),
+		UpdatedAt:     entry.updatedAt.Unix(),
+		DetailsUrl:    entry.detailsURL,
+	}
+	for i, pending := range q.pending {
+		if pending == entry {
+			info.Position = int32(i + 1)
+			break
+		}
+	}
+	return info
+}
+
+func (s *server) GetMergeQueue(ctx context.Context, req *pb.GetMergeQueueRequest) (*pb.GetMergeQueueResponse, error) {
+	log.Printf("Getting merge queue status (entry %q)", req.EntryId)
+
+	if s.mergeQueue == nil {
+		if req.EntryId != "" {
+			return nil, fmt.Errorf("merge queue is not enabled")
+		}
+		return &pb.GetMergeQueueResponse{}, nil
+	}
+
+	entries, err := s.mergeQueue.Entries(req.EntryId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetMergeQueueResponse{Enabled: true, Entries: entries}, nil
+}
+
+func (s *server) ReportQueueValidation(ctx context.Context, req *pb.ReportQueueValidationRequest) (*pb.ReportQueueValidationResponse, error) {
+	log.Printf("Validation of merge queue entry %s reported (passed: %t)", req.EntryId, req.Passed)
+
+	if s.mergeQueue == nil {
+		return &pb.ReportQueueValidationResponse{
+			Success: false,
+			Message: "Merge queue is not enabled",
+		}, nil
+	}
+
+	if err := s.mergeQueue.Report(req.EntryId, req.Token, queueVerdict{
+		passed:     req.Passed,
+		message:    req.Message,
+		detailsURL: req.DetailsUrl,
+	}); err != nil {
+		return &pb.ReportQueueValidationResponse{
+			Success: false,
+			Message: fmt.Sprintf("Verdict not accepted: %v", err),
+		}, nil
+	}
+
+	return &pb.ReportQueueValidationResponse{
+		Success: true,
+		Message: fmt.Sprintf("Verdict recorded for entry %s", req.EntryId),
+	}, nil
+}
@@ -0,0 +1,375 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nic/poon/poon-server/storage"
+)
+
+const (
+	// notifyTimeout bounds one delivery to one notifier
+	notifyTimeout = 30 * time.Second
+
+	// maxNotifiedFiles is how many changed files an announcement lists
+	maxNotifiedFiles = 20
+)
+
+// NotifyConfig is loaded from the JSON file named by NOTIFY_CONFIG
+type NotifyConfig struct {
+	// DiffURL links announcements to the change: {from}, {to} and {commit}
+	// are replaced by the previous version, the new one and its commit hash.
+	// Without it announcements carry no link.
+	DiffURL   string           `json:"diffURL"`
+	Notifiers []NotifierConfig `json:"notifiers"`
+}
+
+// NotifierConfig announces the versions that touch Paths through Slack or
+// email; exactly one of them must be set
+type NotifierConfig struct {
+	Name  string       `json:"name"`  // Used in logs; defaults to the notifier's position
+	Paths []string     `json:"paths"` // Patterns as for forbiddenPaths; empty announces every version
+	Slack *SlackConfig `json:"slack"`
+	Email *EmailConfig `json:"email"`
+}
+
+// SlackConfig posts announcements to a Slack incoming webhook
+type SlackConfig struct {
+	WebhookURL string `json:"webhookURL"`
+	Channel    string `json:"channel"` // Overrides the webhook's default channel
+}
+
+// EmailConfig mails announcements through an SMTP server
+type EmailConfig struct {
+	Addr     string   `json:"addr"` // host:port
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username"` // PLAIN authentication when set
+	Password string   `json:"password"`
+}
+
+// Notifier delivers announcements of new versions. Slack and email are
+// built in; embedders add others with Notifications.Add.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// Notification announces a version to one notifier. Files are the changed
+// files that matched the notifier's paths.
+type Notification struct {
+	Event
+	Files   []storage.FileStat
+	DiffURL string // "" when NotifyConfig has no DiffURL
+}
+
+// Notifications subscribes to the event bus and announces each new version
+// to the notifiers whose paths it touches
+type Notifications struct {
+	repository storage.Repository
+	diffURL    string
+	routes     []notifyRoute
+}
+
+type notifyRoute struct {
+	name     string
+	paths    []string
+	notifier Notifier
+}
+
+// NewNotifications builds the notifiers in config
+func NewNotifications(config NotifyConfig, repository storage.Repository) (*Notifications, error) {
+	n := &Notifications{repository: repository, diffURL: config.DiffURL}
+	for i, nc := range config.Notifiers {
+		name := nc.Name
+		if name == "" {
+			name = "notifier " + strconv.Itoa(i+1)
+		}
+
+		var notifier Notifier
+		switch {
+		case nc.Slack != nil && nc.Email != nil:
+			return nil, fmt.Errorf("%s: set slack or email, not both", name)
+		case nc.Slack != nil:
+			if nc.Slack.WebhookURL == "" {
+				return nil, fmt.Errorf("%s: slack webhookURL is required", name)
+			}
+			notifier = NewSlackNotifier(*nc.Slack)
+		case nc.Email != nil:
+			if nc.Email.Addr == "" || nc.Email.From == "" || len(nc.Email.To) == 0 {
+				return nil, fmt.Errorf("%s: email addr, from and to are required", name)
+			}
+			notifier = NewEmailNotifier(*nc.Email)
+		default:
+			return nil, fmt.Errorf("%s: set slack or email", name)
+		}
+
+		if err := n.Add(name, nc.Paths, notifier); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// LoadNotifications reads a notification config file
+func LoadNotifications(configPath string, repository storage.Repository) (*Notifications, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification config: %w", err)
+	}
+
+	var config NotifyConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse notification config: %w", err)
+	}
+
+	return NewNotifications(config, repository)
+}
+
+// Add announces versions touching paths to notifier
+func (n *Notifications) Add(name string, paths []string, notifier Notifier) error {
+	for _, pattern := range paths {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return fmt.Errorf("%s: invalid path pattern %q: %w", name, pattern, err)
+		}
+	}
+	n.routes = append(n.routes, notifyRoute{name: name, paths: paths, notifier: notifier})
+	return nil
+}
+
+// Handle announces a created version; it is the event bus subscriber
+func (n *Notifications) Handle(event Event) {
+	if event.Type != EventVersionCreated || len(n.routes) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	files, err := event.Files(ctx, n.repository)
+	if err != nil {
+		log.Printf("Warning: not announcing version %d: %v", event.Version, err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, route := range n.routes {
+		var matched []storage.FileStat
+		for _, file := range files {
+			if len(route.paths) == 0 || matchesAnyPattern(route.paths, file.Path) ||
+				(file.OldPath != "" && matchesAnyPattern(route.paths, file.OldPath)) {
+				matched = append(matched, file)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		// Each notifier gets its own goroutine and deadline, so a slow one
+		// does not hold up the others
+		notification := &Notification{Event: event, Files: matched, DiffURL: n.link(event)}
+		wg.Add(1)
+		go func(route notifyRoute) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := route.notifier.Notify(ctx, notification); err != nil {
+				log.Printf("Warning: %s failed to announce version %d: %v", route.name, event.Version, err)
+			}
+		}(route)
+	}
+	wg.Wait()
+}
+
+func (n *Notifications) link(event Event) string {
+	if n.diffURL == "" {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{from}", strconv.FormatInt(event.Version-1, 10),
+		"{to}", strconv.FormatInt(event.Version, 10),
+		"{commit}", event.CommitHash,
+	).Replace(n.diffURL)
+}
+
+// Subject is a one-line summary of the notification
+func (n *Notification) Subject() string {
+	title, _, _ := strings.Cut(n.Message, "\n")
+	return fmt.Sprintf("[poon] Version %d: %s", n.Version, strings.TrimSpace(title))
+}
+
+// Text is the plain text body of the notification: author, message, the
+// changed files and the diff link
+func (n *Notification) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Version %d by %s\n\n", n.Version, n.Author)
+	fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(n.Message))
+
+	insertions, deletions := 0, 0
+	for _, file := range n.Files {
+		insertions += file.Insertions
+		deletions += file.Deletions
+	}
+	fmt.Fprintf(&b, "%d file(s) changed, %d insertion(s), %d deletion(s)\n", len(n.Files), insertions, deletions)
+	for i, file := range n.Files {
+		if i == maxNotifiedFiles {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(n.Files)-i)
+			break
+		}
+		name := file.Path
+		if file.OldPath != "" {
+			name = file.OldPath + " => " + file.Path
+		}
+		if file.Binary {
+			fmt.Fprintf(&b, "  %-8s %s (binary)\n", file.Type, name)
+		} else {
+			fmt.Fprintf(&b, "  %-8s %s (+%d -%d)\n", file.Type, name, file.Insertions, file.Deletions)
+		}
+	}
+
+	if n.DiffURL != "" {
+		fmt.Fprintf(&b, "\n%s\n", n.DiffURL)
+	}
+	return b.String()
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	config SlackConfig
+	client *http.Client
+}
+
+// NewSlackNotifier creates a notifier for a Slack webhook
+func NewSlackNotifier(config SlackConfig) *SlackNotifier {
+	return &SlackNotifier{config: config, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Notify posts the notification as a message
+func (s *SlackNotifier) Notify(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(struct {
+		Channel string `json:"channel,omitempty"`
+		Text    string `json:"text"`
+	}{s.config.Channel, "*" + n.Subject() + "*\n" + n.Text()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier mails notifications through an SMTP server
+type EmailNotifier struct {
+	config EmailConfig
+	send   func(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates a notifier for an SMTP server
+func NewEmailNotifier(config EmailConfig) *EmailNotifier {
+	return &EmailNotifier{config: config, send: sendMail}
+}
+
+// Notify mails the notification, giving up when ctx is done
+func (e *EmailNotifier) Notify(ctx context.Context, n *Notification) error {
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		host, _, _ := strings.Cut(e.config.Addr, ":")
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, host)
+	}
+	return e.send(ctx, e.config.Addr, auth, e.config.From, e.config.To, e.message(n))
+}
+
+// sendMail is smtp.SendMail bounded by ctx: the dial, and every exchange
+// after it, fail once ctx is done, where smtp.SendMail could wait on a
+// server that stopped answering for as long as the connection stays open
+func sendMail(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *EmailNotifier) message(n *Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(n.Text(), "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,461 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/protobuf/proto"
+)
+
+// Kinds of long-running operations
+const (
+	operationCreateWorkspace = "create_workspace"
+	operationDownloadPath    = "download_path"
+	operationGC              = "gc"
+	operationFsck            = "fsck"
+	operationMigrateBackend  = "migrate_backend"
+)
+
+// operationRetention is how long a finished operation can still be looked
+// up, for clients that come back for its result late
+const operationRetention = 24 * time.Hour
+
+// maxOperationWait caps how long one WaitOperation call blocks, so clients
+// poll again instead of holding a call open past proxy timeouts
+const maxOperationWait = time.Minute
+
+// errOperationNotFound is returned for operations that do not exist, have
+// expired or are not visible to the caller
+var errOperationNotFound = errors.New("operation not found")
+
+// operation is the live side of a stored operation: what this process
+// needs to report its progress, cancel it and wake its waiters
+type operation struct {
+	progress func() (int64, int64) // Overrides the progress in record while running; nil if reported with setProgress
+	cancel   func()                // Stops the work; nil if it cannot be stopped
+	done     chan struct{}
+
+	mu        sync.Mutex
+	record    storage.Operation
+	cancelled bool
+}
+
+// finishedOperation wraps a stored record of an operation this process is
+// not running
+func finishedOperation(record *storage.Operation) *operation {
+	op := &operation{record: *record, done: make(chan struct{})}
+	close(op.done)
+	return op
+}
+
+// setProgress records how far along op is
+func (op *operation) setProgress(done, total int64) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.record.ProgressDone, op.record.ProgressTotal = done, total
+}
+
+// snapshot returns op's record with its progress brought up to date
+func (op *operation) snapshot() storage.Operation {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if op.progress != nil && !op.record.Done() {
+		op.record.ProgressDone, op.record.ProgressTotal = op.progress()
+	}
+	return op.record
+}
+
+// wait blocks until op is done, ctx ends or timeout elapses
+func (op *operation) wait(ctx context.Context, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// operationToProto converts a stored operation. The serialized response is
+// left out until the operation succeeded.
+func operationToProto(record storage.Operation) *pb.Operation {
+	info := &pb.Operation{
+		Id:            record.ID,
+		Kind:          record.Kind,
+		Owner:         record.Owner,
+		Done:          record.Done(),
+		Status:        string(record.Status),
+		Error:         record.Error,
+		ProgressDone:  record.ProgressDone,
+		ProgressTotal: record.ProgressTotal,
+		CreatedAt:     record.CreatedAt.Format(time.RFC3339),
+		Result:        record.Result,
+		Response:      record.Response,
+	}
+	if !record.FinishedAt.IsZero() {
+		info.FinishedAt = record.FinishedAt.Format(time.RFC3339)
+	}
+	return info
+}
+
+// operationRegistry tracks the operations this process runs and, with a
+// store, keeps their records in the storage backend so they outlive it.
+// Without one, finished operations are kept in memory until they expire.
+// The zero value is ready to use.
+type operationRegistry struct {
+	store *storage.OperationStore // nil keeps operations in memory only
+
+	mu   sync.Mutex
+	live map[string]*operation
+}
+
+// start registers op as running. Its record names the kind, owner and
+// any result known upfront; its cancel, if not nil, stops the work, which
+// then finishes op as usual.
+func (r *operationRegistry) start(ctx context.Context, op *operation) *operation {
+	op.done = make(chan struct{})
+	op.record.ID = uuid.New().String()
+	op.record.Status = storage.OperationRunning
+	op.record.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	if r.live == nil {
+		r.live = make(map[string]*operation)
+	}
+	r.pruneLive(op.record.CreatedAt)
+	r.live[op.record.ID] = op
+	r.mu.Unlock()
+
+	if r.store != nil {
+		if err := r.store.Put(ctx, &op.record); err != nil {
+			log.Printf("Warning: operation %s is not stored: %v", op.record.ID, err)
+		}
+	}
+	log.Printf("Started operation %s (%s) for %q", op.record.ID, op.record.Kind, op.record.Owner)
+	return op
+}
+
+// finish records the outcome of op and wakes its waiters. response is
+// stored only if err is nil.
+func (r *operationRegistry) finish(op *operation, response proto.Message, err error) {
+	op.mu.Lock()
+	if op.progress != nil {
+		op.record.ProgressDone, op.record.ProgressTotal = op.progress()
+	}
+	op.record.FinishedAt = time.Now()
+	switch {
+	case op.cancelled:
+		op.record.Status = storage.OperationCancelled
+		op.record.Error = "cancelled"
+	case err != nil:
+		op.record.Status = storage.OperationFailed
+		op.record.Error = err.Error()
+	default:
+		op.record.Status = storage.OperationSucceeded
+		op.record.ProgressDone = max(op.record.ProgressDone, op.record.ProgressTotal) // Progress is reported as each unit starts
+		if response != nil {
+			data, marshalErr := proto.Marshal(response)
+			if marshalErr != nil {
+				op.record.Status = storage.OperationFailed
+				op.record.Error = fmt.Sprintf("failed to encode response: %v", marshalErr)
+				break
+			}
+			op.record.Response = data
+		}
+	}
+	record := op.record
+	op.mu.Unlock()
+
+	if r.store != nil {
+		if err := r.store.Put(context.Background(), &record); err != nil {
+			log.Printf("Warning: outcome of operation %s is not stored: %v", record.ID, err)
+		}
+		// Looked up in the store from now on
+		r.mu.Lock()
+		delete(r.live, record.ID)
+		r.mu.Unlock()
+	}
+	close(op.done)
+
+	if record.Status == storage.OperationSucceeded {
+		log.Printf("Operation %s (%s) finished in %s", record.ID, record.Kind, record.FinishedAt.Sub(record.CreatedAt).Round(time.Millisecond))
+	} else {
+		log.Printf("Operation %s (%s) %s: %s", record.ID, record.Kind, record.Status, record.Error)
+	}
+}
+
+// get returns the operation with the given ID. Admin operations are only
+// returned to admin callers.
+func (r *operationRegistry) get(ctx context.Context, id string, admin bool) (*operation, error) {
+	r.mu.Lock()
+	r.pruneLive(time.Now())
+	op, ok := r.live[id]
+	r.mu.Unlock()
+
+	if !ok && r.store != nil {
+		record, err := r.store.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil && time.Since(record.FinishedAt) <= operationRetention {
+			op, ok = finishedOperation(record), true
+		}
+	}
+	if !ok || (op.record.Admin && !admin) {
+		return nil, errOperationNotFound
+	}
+	return op, nil
+}
+
+// list returns the operations visible to the caller, newest first
+func (r *operationRegistry) list(ctx context.Context, admin bool) ([]storage.Operation, error) {
+	seen := make(map[string]bool)
+	var records []storage.Operation
+
+	r.mu.Lock()
+	live := make([]*operation, 0, len(r.live))
+	for _, op := range r.live {
+		live = append(live, op)
+	}
+	r.mu.Unlock()
+	for _, op := range live {
+		record := op.snapshot()
+		seen[record.ID] = true
+		records = append(records, record)
+	}
+
+	if r.store != nil {
+		stored, err := r.store.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range stored {
+			if !seen[record.ID] {
+				records = append(records, *record)
+			}
+		}
+	}
+
+	visible := records[:0]
+	for _, record := range records {
+		if admin || !record.Admin {
+			visible = append(visible, record)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		return visible[i].CreatedAt.After(visible[j].CreatedAt)
+	})
+	return visible, nil
+}
+
+// cancelOperation stops op. Operations already done, or whose work cannot
+// be stopped, are refused.
+func (r *operationRegistry) cancelOperation(op *operation) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if op.record.Done() {
+		return fmt.Errorf("operation %s is already %s", op.record.ID, op.record.Status)
+	}
+	if op.cancel == nil {
+		return fmt.Errorf("operation %s (%s) cannot be cancelled", op.record.ID, op.record.Kind)
+	}
+	if !op.cancelled {
+		op.cancelled = true
+		log.Printf("Cancelling operation %s (%s)", op.record.ID, op.record.Kind)
+		go op.cancel() // Cancel functions may take locks op.mu is held under elsewhere
+	}
+	return nil
+}
+
+// recover marks stored operations that were running when the server last
+// stopped as failed, since nothing will finish them, and deletes the ones
+// that expired
+func (r *operationRegistry) recover(ctx context.Context) error {
+	if r.store == nil {
+		return nil
+	}
+	stored, err := r.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, record := range stored {
+		if record.Done() {
+			continue
+		}
+		record.Status = storage.OperationFailed
+		record.Error = "interrupted by a server restart"
+		record.FinishedAt = time.Now()
+		if err := r.store.Put(ctx, record); err != nil {
+			return err
+		}
+		log.Printf("Operation %s (%s) was interrupted by a restart", record.ID, record.Kind)
+	}
+	if _, err := r.store.Prune(ctx, time.Now().Add(-operationRetention)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// pruneLive forgets operations kept in memory that finished more than
+// operationRetention before now. Callers must hold r.mu.
+func (r *operationRegistry) pruneLive(now time.Time) {
+	for id, op := range r.live {
+		op.mu.Lock()
+		expired := op.record.Done() && now.Sub(op.record.FinishedAt) > operationRetention
+		op.mu.Unlock()
+		if expired {
+			delete(r.live, id)
+		}
+	}
+}
+
+// runOperation starts fn in the background as an operation of kind and
+// returns its ID at once. fn's context is cancelled by CancelOperation and
+// carries a progress reporter for the storage calls that take one.
+func (s *server) runOperation(ctx context.Context, kind string, admin bool, fn func(ctx context.Context) (proto.Message, error)) string {
+	runCtx, cancel := context.WithCancel(context.Background())
+	op := s.operations.start(ctx, &operation{
+		cancel: cancel,
+		record: storage.Operation{Kind: kind, Owner: userFromContext(ctx), Admin: admin},
+	})
+
+	go func() {
+		defer cancel()
+		response, err := fn(storage.WithProgress(runCtx, op.setProgress))
+		s.operations.finish(op, response, err)
+	}()
+	return op.record.ID
+}
+
+// visibleOperation returns the operation with the given ID if the caller
+// may see it: admin operations only through the admin API, and with
+// authentication, other users' operations not at all
+func (s *server) visibleOperation(ctx context.Context, id string, admin bool) (*operation, error) {
+	op, err := s.operations.get(ctx, id, admin)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && s.auth.Enabled() && op.snapshot().Owner != userFromContext(ctx) {
+		return nil, errOperationNotFound
+	}
+	return op, nil
+}
+
+// operationRPC carries out GetOperation and WaitOperation for either API
+func (s *server) operationRPC(ctx context.Context, id string, wait time.Duration, admin bool) (*pb.Operation, error) {
+	op, err := s.visibleOperation(ctx, id, admin)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		op.wait(ctx, wait)
+	}
+	record := op.snapshot()
+	if record.Kind == operationDownloadPath && record.Status == storage.OperationSucceeded {
+		if err := s.operationArchive(ctx, &record); err != nil {
+			return nil, err
+		}
+	}
+	return operationToProto(record), nil
+}
+
+// operationWait turns the timeout of a WaitOperation call into a duration
+func operationWait(seconds int32) time.Duration {
+	timeout := time.Duration(seconds) * time.Second
+	if timeout <= 0 || timeout > maxOperationWait {
+		timeout = maxOperationWait
+	}
+	return timeout
+}
+
+// operationMessage phrases a failed lookup for a response message
+func operationMessage(id string, err error) string {
+	if errors.Is(err, errOperationNotFound) {
+		return fmt.Sprintf("Operation %s not found", id)
+	}
+	return fmt.Sprintf("Failed to get operation %s: %v", id, err)
+}
+
+func (s *server) GetOperation(ctx context.Context, req *pb.GetOperationRequest) (*pb.GetOperationResponse, error) {
+	log.Printf("GetOperation request for %s", req.OperationId)
+
+	op, err := s.operationRPC(ctx, req.OperationId, 0, false)
+	if err != nil {
+		return &pb.GetOperationResponse{
+			Success: false,
+			Message: operationMessage(req.OperationId, err),
+		}, nil
+	}
+	return &pb.GetOperationResponse{
+		Success:   true,
+		Message:   "Operation retrieved successfully",
+		Operation: op,
+	}, nil
+}
+
+func (s *server) WaitOperation(ctx context.Context, req *pb.WaitOperationRequest) (*pb.WaitOperationResponse, error) {
+	log.Printf("WaitOperation request for %s (timeout %ds)", req.OperationId, req.TimeoutSeconds)
+
+	op, err := s.operationRPC(ctx, req.OperationId, operationWait(req.TimeoutSeconds), false)
+	if err != nil {
+		return &pb.WaitOperationResponse{
+			Success: false,
+			Message: operationMessage(req.OperationId, err),
+		}, nil
+	}
+	return &pb.WaitOperationResponse{
+		Success:   true,
+		Message:   "Operation retrieved successfully",
+		Operation: op,
+	}, nil
+}
+
+func (s *server) CancelOperation(ctx context.Context, req *pb.CancelOperationRequest) (*pb.CancelOperationResponse, error) {
+	log.Printf("CancelOperation request for %s", req.OperationId)
+
+	op, err := s.visibleOperation(ctx, req.OperationId, false)
+	if err != nil {
+		return &pb.CancelOperationResponse{
+			Success: false,
+			Message: operationMessage(req.OperationId, err),
+		}, nil
+	}
+	if err := s.operations.cancelOperation(op); err != nil {
+		return &pb.CancelOperationResponse{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+	return &pb.CancelOperationResponse{
+		Success: true,
+		Message: fmt.Sprintf("Cancelling operation %s", req.OperationId),
+	}, nil
+}
+
+func (s *server) ListOperations(ctx context.Context, req *pb.ListOperationsRequest) (*pb.ListOperationsResponse, error) {
+	log.Printf("ListOperations request (running only: %t)", req.RunningOnly)
+
+	records, err := s.operations.list(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations: %w", err)
+	}
+	user := userFromContext(ctx)
+	resp := &pb.ListOperationsResponse{}
+	for _, record := range records {
+		if (req.RunningOnly && record.Done()) || (s.auth.Enabled() && record.Owner != user) {
+			continue
+		}
+		info := operationToProto(record)
+		info.Response = nil // Fetched with GetOperation
+		resp.Operations = append(resp.Operations, info)
+	}
+	return resp, nil
+}
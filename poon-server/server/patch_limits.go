@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nic/poon/poon-server/merge"
+	"google.golang.org/grpc/codes"
+)
+
+// PatchLimits bound the size and complexity of patches accepted by
+// MergePatch. Zero means unlimited.
+type PatchLimits struct {
+	MaxPatchBytes int64 // Size of the raw patch
+	MaxFiles      int   // Files touched by the patch
+	MaxHunks      int   // Hunks across all files
+	MaxFileBytes  int64 // Size of a patched file after the patch is applied
+}
+
+// DefaultPatchLimits are used for any limit not set in the environment
+var DefaultPatchLimits = PatchLimits{
+	MaxPatchBytes: 16 << 20,
+	MaxFiles:      1000,
+	MaxHunks:      10000,
+	MaxFileBytes:  64 << 20,
+}
+
+// defaultMaxMessageBytes leaves room for the largest default patch plus the
+// rest of the request
+const defaultMaxMessageBytes = 32 << 20
+
+// LoadPatchLimits reads MAX_PATCH_BYTES, MAX_PATCH_FILES, MAX_PATCH_HUNKS and
+// MAX_PATCHED_FILE_BYTES, falling back to DefaultPatchLimits
+func LoadPatchLimits() (PatchLimits, error) {
+	limits := DefaultPatchLimits
+
+	var err error
+	if limits.MaxPatchBytes, err = envInt64("MAX_PATCH_BYTES", limits.MaxPatchBytes); err != nil {
+		return limits, err
+	}
+	files, err := envInt64("MAX_PATCH_FILES", int64(limits.MaxFiles))
+	if err != nil {
+		return limits, err
+	}
+	hunks, err := envInt64("MAX_PATCH_HUNKS", int64(limits.MaxHunks))
+	if err != nil {
+		return limits, err
+	}
+	limits.MaxFiles, limits.MaxHunks = int(files), int(hunks)
+	if limits.MaxFileBytes, err = envInt64("MAX_PATCHED_FILE_BYTES", limits.MaxFileBytes); err != nil {
+		return limits, err
+	}
+
+	return limits, nil
+}
+
+// envInt64 reads a non-negative integer from the environment
+func envInt64(name string, fallback int64) (int64, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, value)
+	}
+	return n, nil
+}
+
+// CheckPatch enforces the limits that do not need the parsed patch. It runs
+// before parsing so an oversized patch costs no more than one scan.
+func (l PatchLimits) CheckPatch(patchData []byte) error {
+	if l.MaxPatchBytes > 0 && int64(len(patchData)) > l.MaxPatchBytes {
+		return patchTooLarge("max_patch_bytes", int64(len(patchData)), l.MaxPatchBytes,
+			fmt.Sprintf("patch is %d bytes, limit is %d bytes", len(patchData), l.MaxPatchBytes))
+	}
+
+	if l.MaxFiles == 0 && l.MaxHunks == 0 && l.MaxFileBytes == 0 {
+		return nil
+	}
+
+	counts := merge.CountPatch(patchData)
+	if l.MaxFiles > 0 && counts.Files > l.MaxFiles {
+		return patchTooLarge("max_files", int64(counts.Files), int64(l.MaxFiles),
+			fmt.Sprintf("patch touches %d files, limit is %d", counts.Files, l.MaxFiles))
+	}
+	if l.MaxHunks > 0 && counts.Hunks > l.MaxHunks {
+		return patchTooLarge("max_hunks", int64(counts.Hunks), int64(l.MaxHunks),
+			fmt.Sprintf("patch has %d hunks, limit is %d", counts.Hunks, l.MaxHunks))
+	}
+	// Binary blocks are inflated while parsing, so their announced size is
+	// checked up front
+	if l.MaxFileBytes > 0 && counts.BinaryBytes > l.MaxFileBytes {
+		return patchTooLarge("max_file_bytes", counts.BinaryBytes, l.MaxFileBytes,
+			fmt.Sprintf("binary patch data is %d bytes, limit is %d bytes", counts.BinaryBytes, l.MaxFileBytes))
+	}
+
+	return nil
+}
+
+// CheckFileSize enforces MaxFileBytes on the file a parsed patch produces
+func (l PatchLimits) CheckFileSize(change *Change) error {
+	if l.MaxFileBytes <= 0 {
+		return nil
+	}
+
+	if size := change.NewSize(); size > l.MaxFileBytes {
+		return patchTooLarge("max_file_bytes", size, l.MaxFileBytes,
+			fmt.Sprintf("%s would be %d bytes, limit is %d bytes", change.TargetFile, size, l.MaxFileBytes))
+	}
+	return nil
+}
+
+// patchTooLarge is the RESOURCE_EXHAUSTED error for a patch over one limit
+func patchTooLarge(limit string, actual, max int64, message string) error {
+	return detailedError(codes.ResourceExhausted, message, ReasonPatchTooLarge, map[string]string{
+		"limit":  limit,
+		"actual": strconv.FormatInt(actual, 10),
+		"max":    strconv.FormatInt(max, 10),
+	})
+}
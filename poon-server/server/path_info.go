@@ -0,0 +1,228 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+const (
+	// maxReadmeBytes caps the README content returned by GetPathInfo
+	maxReadmeBytes = 64 * 1024
+
+	ownersFileName = "OWNERS"
+)
+
+// readmeNames lists the README files GetPathInfo and GetRenderedDoc look for, in order of
+// preference. Names are matched case-insensitively.
+var readmeNames = []string{"README.md", "README.markdown", "README.rst", "README.txt", "README"}
+
+func (s *server) GetPathInfo(ctx context.Context, req *pb.GetPathInfoRequest) (*pb.GetPathInfoResponse, error) {
+	log.Printf("Getting path info: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+
+	currentVersion, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	if currentVersion == 0 {
+		return nil, fmt.Errorf("no repository versions exist - create an initial commit first")
+	}
+
+	path := cleanRepoPath(req.Path)
+	entry, err := s.repository.GetEntry(ctx, currentVersion, path)
+	if err != nil {
+		return nil, readError("path not found", path, currentVersion, err)
+	}
+
+	resp := &pb.GetPathInfoResponse{
+		Path:    path,
+		IsDir:   entry.Type == storage.ObjectTypeTree,
+		Version: currentVersion,
+	}
+
+	if resp.IsDir {
+		entries, err := s.repository.ReadDirectory(ctx, currentVersion, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+
+		for _, child := range entries {
+			if child.Type == storage.ObjectTypeTree {
+				resp.Directories++
+			} else {
+				resp.Files++
+			}
+		}
+
+		if readme := findReadme(entries); readme != nil {
+			readmePath := repoJoin(path, readme.Name)
+			content, err := s.repository.ReadFile(ctx, currentVersion, readmePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", readmePath, err)
+			}
+			resp.ReadmePath = readmePath
+			if len(content) > maxReadmeBytes {
+				content = content[:maxReadmeBytes]
+				resp.ReadmeTruncated = true
+			}
+			resp.ReadmeContent = content
+		}
+	}
+
+	resp.TotalFiles, resp.TotalSize, err = s.pathStats(ctx, currentVersion, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size path: %w", err)
+	}
+
+	resp.LastChange, resp.LastChangeVersion, err = s.lastChange(ctx, currentVersion, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read path history: %w", err)
+	}
+
+	ownersDir := path
+	if !resp.IsDir {
+		ownersDir = repoDir(path)
+	}
+	resp.Owners, resp.OwnersPath, err = s.nearestOwners(ctx, currentVersion, ownersDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read owners: %w", err)
+	}
+
+	if !resp.IsDir {
+		rules, err := s.repository.Attributes(ctx, currentVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attributes: %w", err)
+		}
+		resp.Attributes = rules.Lookup(path)
+	}
+
+	return resp, nil
+}
+
+// findReadme returns the README among a directory's entries, or nil
+func findReadme(entries []*storage.TreeEntry) *storage.TreeEntry {
+	files := make(map[string]*storage.TreeEntry)
+	for _, entry := range entries {
+		if entry.Type != storage.ObjectTypeTree {
+			files[strings.ToLower(entry.Name)] = entry
+		}
+	}
+	for _, name := range readmeNames {
+		if entry, ok := files[strings.ToLower(name)]; ok {
+			return entry
+		}
+	}
+	return nil
+}
+
+// cleanRepoPath normalizes a repository path, returning "" for the root
+func cleanRepoPath(p string) string {
+	return strings.Trim(path.Clean("/"+filepath.ToSlash(storage.NormalizePath(p))), "/")
+}
+
+// Repository paths are separated by forward slashes on every platform, so
+// they are taken apart with package path rather than filepath
+
+// repoJoin joins repository path elements
+func repoJoin(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// repoDir returns all but the last element of a repository path, "." for a
+// top-level entry
+func repoDir(p string) string {
+	return path.Dir(p)
+}
+
+// repoBase returns the last element of a repository path
+func repoBase(p string) string {
+	return path.Base(p)
+}
+
+// lastChange returns the newest commit that changed path, as of version
+func (s *server) lastChange(ctx context.Context, version int64, path string) (*pb.Commit, int64, error) {
+	if path == "" {
+		info, err := s.repository.GetVersionInfo(ctx, version)
+		if err != nil {
+			return nil, 0, err
+		}
+		commit, err := s.repository.GetCommit(ctx, info.CommitHash)
+		if err != nil {
+			return nil, 0, err
+		}
+		return &pb.Commit{
+			Hash:      string(info.CommitHash),
+			Author:    commit.Author,
+			Message:   commit.Message,
+			Timestamp: commit.Timestamp.Unix(),
+			Path:      path,
+		}, version, nil
+	}
+
+	changes, err := s.repository.LastChanges(ctx, version, repoDir(path))
+	if err != nil {
+		return nil, 0, err
+	}
+	change, ok := changes[repoBase(path)]
+	if !ok {
+		return nil, 0, nil
+	}
+	return &pb.Commit{
+		Hash:      string(change.CommitHash),
+		Author:    change.Author,
+		Message:   change.Message,
+		Timestamp: change.Timestamp.Unix(),
+		Path:      path,
+	}, change.Version, nil
+}
+
+// nearestOwners reads the OWNERS file closest to dir, looking in dir first
+// and then in each parent up to the repository root
+func (s *server) nearestOwners(ctx context.Context, version int64, dir string) ([]string, string, error) {
+	for {
+		ownersPath := repoJoin(dir, ownersFileName)
+		entry, err := s.repository.GetEntry(ctx, version, ownersPath)
+		if err == nil && entry.Type == storage.ObjectTypeBlob {
+			content, err := s.repository.ReadFile(ctx, version, ownersPath)
+			if err != nil {
+				return nil, "", err
+			}
+			return parseOwners(content), ownersPath, nil
+		}
+
+		if dir == "" || dir == "." {
+			return nil, "", nil
+		}
+		dir = repoDir(dir)
+	}
+}
+
+// parseOwners returns the owners listed in an OWNERS file, one per line, with
+// blank lines and # comments skipped
+func parseOwners(content []byte) []string {
+	var owners []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			owners = append(owners, line)
+		}
+	}
+	return owners
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// PreviewPatch runs a patch through the same checks as MergePatch and applies
+// it in memory, returning the resulting file without creating a version.
+// Content mismatches and locks held by others are reported as conflicts.
+func (s *server) PreviewPatch(ctx context.Context, req *pb.PreviewPatchRequest) (*pb.PreviewPatchResponse, error) {
+	log.Printf("Previewing patch for path: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return &pb.PreviewPatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid path: %v", err),
+			Failure: &pb.FailureInfo{Reason: ReasonInvalidPath, Metadata: map[string]string{"path": req.Path}},
+		}, nil
+	}
+
+	if len(req.Patch) == 0 {
+		return &pb.PreviewPatchResponse{
+			Success: false,
+			Message: "Patch data is empty",
+			Failure: &pb.FailureInfo{Reason: ReasonEmptyPatch},
+		}, nil
+	}
+
+	if err := s.patchLimits.CheckPatch(req.Patch); err != nil {
+		log.Printf("Rejected oversized patch preview for path %s: %v", req.Path, err)
+		return nil, err
+	}
+
+	// The message is optional for a preview; when given it must pass the
+	// policy MergePatch would apply
+	if req.Message != "" {
+		if violations := s.commitPolicy.Check(req.Message); len(violations) > 0 {
+			return nil, commitMessageError(violations)
+		}
+	}
+
+	// Locks held by others are always reported, whether or not the eventual
+	// merge would enforce them
+	mergeReq := &pb.MergePatchRequest{
+		Path:         req.Path,
+		Patch:        req.Patch,
+		Message:      req.Message,
+		Author:       req.Author,
+		Branch:       req.Branch,
+		FailIfLocked: s.locks != nil,
+	}
+
+	var conflicts []string
+	var failure *pb.FailureInfo
+	lock, err := s.checkPatchLocks(ctx, mergeReq)
+	if err != nil {
+		return &pb.PreviewPatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to check locks: %v", err),
+		}, nil
+	}
+	if lock != nil {
+		conflicts = append(conflicts, fmt.Sprintf("Path %s is locked by %s until %s", lock.Path, lock.Owner, lock.ExpiresAt.Format(time.RFC3339)))
+		failure = lockFailure(lock)
+	}
+
+	if change := s.newChange(ctx, mergeReq); change != nil {
+		if err := s.patchLimits.CheckFileSize(change); err != nil {
+			log.Printf("Rejected oversized patch preview for path %s: %v", req.Path, err)
+			return nil, err
+		}
+
+		if violations := s.validatePatch(ctx, change); len(violations) > 0 {
+			return &pb.PreviewPatchResponse{
+				Success:    false,
+				Message:    fmt.Sprintf("Patch rejected by validation: %s", formatViolations(violations)),
+				Violations: violations,
+				Failure:    policyFailure("validation"),
+			}, nil
+		}
+	}
+
+	preview, err := s.repository.PreviewPatch(ctx, req.Patch, merge.ApplyOptions{
+		IgnoreWhitespace:        req.IgnoreWhitespace,
+		NormalizeLineEndings:    req.NormalizeLineEndings,
+		PreserveTrailingNewline: req.PreserveTrailingNewline,
+	})
+	if errors.Is(err, storage.ErrPatchConflict) || errors.Is(err, storage.ErrCaseCollision) {
+		conflicts = append(conflicts, err.Error())
+		return &pb.PreviewPatchResponse{
+			Success:   false,
+			Message:   "Patch does not apply to the current version",
+			Conflicts: conflicts,
+			Failure:   s.patchFailure(ctx, req.Patch, err),
+		}, nil
+	}
+	if err != nil {
+		return &pb.PreviewPatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to preview patch: %v", err),
+		}, nil
+	}
+
+	resp := &pb.PreviewPatchResponse{
+		Success:         len(conflicts) == 0,
+		Message:         fmt.Sprintf("Patch applies cleanly to version %d", preview.BaseVersion),
+		FilePath:        preview.Path,
+		BaseVersion:     preview.BaseVersion,
+		NewFile:         !preview.Exists,
+		DeletedFile:     preview.Deletes,
+		OriginalContent: preview.Original,
+		Content:         preview.Content,
+		Conflicts:       conflicts,
+		Failure:         failure,
+	}
+	if len(conflicts) > 0 {
+		resp.Message = fmt.Sprintf("Patch applies to version %d but would be rejected", preview.BaseVersion)
+	}
+	return resp, nil
+}
@@ -0,0 +1,376 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+)
+
+const (
+	defaultBranch = "main"
+
+	// protectionFile holds branch protection rules kept in the repository
+	// itself, so rule changes are reviewed like any other change
+	protectionFile = ".poon/protection.json"
+
+	// signaturePrefix starts every signed payload, see signedPatchPayload
+	signaturePrefix = "poon-patch-signature-v1"
+)
+
+// ProtectionRule guards paths on a branch. Every requirement that is set
+// must be met by a patch touching a matching path.
+type ProtectionRule struct {
+	Branch string   `json:"branch"` // Branch name or glob (default: main)
+	Paths  []string `json:"paths"`  // Patterns as for forbiddenPaths; a trailing "/" matches a whole directory
+
+	BlockDirectMerge     bool     `json:"blockDirectMerge"`     // Patches must land through the merge queue
+	RequiredApprovals    int      `json:"requiredApprovals"`    // Approvals from users other than the author (ApprovePatch)
+	RequireSignedCommits bool     `json:"requireSignedCommits"` // Patches must be signed with one of the author's signingKeys
+	BypassUsers          []string `json:"bypassUsers"`          // Users the rule does not apply to
+}
+
+// ProtectionConfig is loaded from the JSON file named by
+// BRANCH_PROTECTION_CONFIG. The repository's .poon/protection.json may add
+// rules, but signing keys are only taken from the server's file.
+type ProtectionConfig struct {
+	Rules       []ProtectionRule    `json:"rules"`
+	SigningKeys map[string][]string `json:"signingKeys"` // User -> PEM encoded Ed25519 public keys
+}
+
+// BranchProtection evaluates protection rules and keeps patch approvals.
+// A nil BranchProtection has no server rules or keys and records nothing,
+// but rules from the repository still apply.
+type BranchProtection struct {
+	rules []ProtectionRule
+	keys  map[string][]ed25519.PublicKey
+
+	mu        sync.Mutex
+	approvals map[string]map[string]time.Time // Patch digest -> approver -> time
+}
+
+// NewBranchProtection validates config and parses its signing keys
+func NewBranchProtection(config ProtectionConfig) (*BranchProtection, error) {
+	if err := validateProtectionRules(config.Rules); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]ed25519.PublicKey)
+	for user, pemKeys := range config.SigningKeys {
+		for _, pemKey := range pemKeys {
+			key, err := parseSigningKey(pemKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid signing key for %s: %w", user, err)
+			}
+			keys[user] = append(keys[user], key)
+		}
+	}
+
+	return &BranchProtection{
+		rules:     config.Rules,
+		keys:      keys,
+		approvals: make(map[string]map[string]time.Time),
+	}, nil
+}
+
+// LoadBranchProtection reads a branch protection config file
+func LoadBranchProtection(configPath string) (*BranchProtection, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch protection config: %w", err)
+	}
+
+	var config ProtectionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse branch protection config: %w", err)
+	}
+
+	return NewBranchProtection(config)
+}
+
+func validateProtectionRules(rules []ProtectionRule) error {
+	for i, rule := range rules {
+		if len(rule.Paths) == 0 {
+			return fmt.Errorf("rule %d has no paths", i+1)
+		}
+		if _, err := path.Match(rule.Branch, ""); err != nil {
+			return fmt.Errorf("rule %d has an invalid branch pattern %q: %w", i+1, rule.Branch, err)
+		}
+		for _, pattern := range rule.Paths {
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+				return fmt.Errorf("rule %d has an invalid path pattern %q: %w", i+1, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseSigningKey(pemKey string) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 key")
+	}
+	return edKey, nil
+}
+
+// approve records user's approval of the patch with the given digest and
+// returns everyone who approved it
+func (b *BranchProtection) approve(digest, user string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.approvals[digest] == nil {
+		b.approvals[digest] = make(map[string]time.Time)
+	}
+	b.approvals[digest][user] = time.Now()
+	return b.approversLocked(digest)
+}
+
+// approvers lists the users who approved the patch with the given digest
+func (b *BranchProtection) approvers(digest string) []string {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.approversLocked(digest)
+}
+
+func (b *BranchProtection) approversLocked(digest string) []string {
+	users := make([]string, 0, len(b.approvals[digest]))
+	for user := range b.approvals[digest] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// signedBy reports whether signature is a valid signature by user over the
+// message and patch
+func (b *BranchProtection) signedBy(user string, signature []byte, message string, patch []byte) bool {
+	if b == nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	payload := signedPatchPayload(message, patch)
+	for _, key := range b.keys[user] {
+		if ed25519.Verify(key, payload, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// signedPatchPayload is the byte string a patch signature covers. The CLI
+// builds the same payload in signPatch.
+func signedPatchPayload(message string, patch []byte) []byte {
+	payload := make([]byte, 0, len(signaturePrefix)+len(message)+len(patch)+2)
+	payload = append(payload, signaturePrefix...)
+	payload = append(payload, 0)
+	payload = append(payload, message...)
+	payload = append(payload, 0)
+	return append(payload, patch...)
+}
+
+func patchDigest(patch []byte) string {
+	sum := sha256.Sum256(patch)
+	return hex.EncodeToString(sum[:])
+}
+
+// patchTargets returns the files a patch touches, or the request path when
+// the patch cannot be parsed
+func patchTargets(req *pb.MergePatchRequest) []string {
+	parsed, err := merge.ParsePatch(req.Patch)
+	if err != nil {
+		return []string{req.Path}
+	}
+
+	var targets []string
+	for _, file := range []string{parsed.Header.OldFile, parsed.Header.NewFile} {
+		if file != "" && file != merge.DevNull && (len(targets) == 0 || targets[0] != file) {
+			targets = append(targets, file)
+		}
+	}
+	if len(targets) == 0 {
+		return []string{req.Path}
+	}
+	return targets
+}
+
+// protectionRules returns the server's rules followed by those in the
+// repository. A repository file that cannot be used is reported as an error,
+// which rejects every patch except one fixing the file.
+func (s *server) protectionRules(ctx context.Context) ([]ProtectionRule, error) {
+	var rules []ProtectionRule
+	if s.protection != nil {
+		rules = append(rules, s.protection.rules...)
+	}
+
+	version, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil || version == 0 {
+		return rules, nil
+	}
+	data, err := s.repository.ReadFile(ctx, version, protectionFile)
+	if err != nil {
+		return rules, nil // No rules in the repository
+	}
+
+	var repoConfig ProtectionConfig
+	if err := json.Unmarshal(data, &repoConfig); err != nil {
+		return rules, fmt.Errorf("failed to parse %s: %w", protectionFile, err)
+	}
+	if err := validateProtectionRules(repoConfig.Rules); err != nil {
+		return rules, fmt.Errorf("invalid %s: %w", protectionFile, err)
+	}
+	return append(rules, repoConfig.Rules...), nil
+}
+
+// checkProtection evaluates the branch protection rules covering the files
+// a patch touches and returns one violation per unmet requirement
+func (s *server) checkProtection(ctx context.Context, req *pb.MergePatchRequest) []*pb.PolicyViolation {
+	return s.protectionViolations(ctx, patchTargets(req), req)
+}
+
+// protectionViolations evaluates the rules covering targets for a change
+// described by req, whose patch and signature are checked against approvals
+// and signing keys
+func (s *server) protectionViolations(ctx context.Context, targets []string, req *pb.MergePatchRequest) []*pb.PolicyViolation {
+	rules, err := s.protectionRules(ctx)
+	if err != nil {
+		log.Printf("Branch protection config error: %v", err)
+		if len(targets) != 1 || targets[0] != protectionFile {
+			return []*pb.PolicyViolation{{
+				Rule:        "branch_protection",
+				Path:        protectionFile,
+				Description: fmt.Sprintf("%v; only a patch fixing the file is accepted", err),
+			}}
+		}
+	}
+
+	// A patch to another branch reaches main only through MergeBranches,
+	// which checks the rules for main against everything the merge changes
+	branch := req.Branch
+	if branch == "" {
+		branch = defaultBranch
+	}
+	user := lockOwner(ctx, req.Author)
+
+	var violations []*pb.PolicyViolation
+	seen := make(map[string]bool)
+	add := func(target, description string) {
+		if !seen[description] {
+			seen[description] = true
+			violations = append(violations, &pb.PolicyViolation{Rule: "branch_protection", Path: target, Description: description})
+		}
+	}
+
+	for _, rule := range rules {
+		ruleBranch := rule.Branch
+		if ruleBranch == "" {
+			ruleBranch = defaultBranch
+		}
+		if matched, _ := path.Match(ruleBranch, branch); !matched || containsString(rule.BypassUsers, user) {
+			continue
+		}
+
+		for _, target := range targets {
+			if !matchesAnyPattern(rule.Paths, target) {
+				continue
+			}
+
+			if rule.BlockDirectMerge && s.mergeQueue == nil {
+				add(target, fmt.Sprintf("changes to %s on %s must go through the merge queue, which this server does not run", target, branch))
+			}
+
+			if rule.RequiredApprovals > 0 {
+				approvals := 0
+				for _, approver := range s.protection.approvers(patchDigest(req.Patch)) {
+					if approver != user {
+						approvals++
+					}
+				}
+				if approvals < rule.RequiredApprovals {
+					add(target, fmt.Sprintf("changes to %s on %s need %d approvals from someone other than the author, found %d (see 'poon approve')",
+						target, branch, rule.RequiredApprovals, approvals))
+				}
+			}
+
+			if rule.RequireSignedCommits && !s.protection.signedBy(user, req.Signature, req.Message, req.Patch) {
+				add(target, fmt.Sprintf("changes to %s on %s must be signed with a key registered for %s (see 'poon apply --sign-key')", target, branch, user))
+			}
+		}
+	}
+	return violations
+}
+
+func matchesAnyPattern(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if matchPathPattern(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) ApprovePatch(ctx context.Context, req *pb.ApprovePatchRequest) (*pb.ApprovePatchResponse, error) {
+	approver := lockOwner(ctx, req.Approver)
+	digest := patchDigest(req.Patch)
+	log.Printf("Approving patch %s by %s", digest, approver)
+
+	if len(req.Patch) == 0 {
+		return &pb.ApprovePatchResponse{
+			Success: false,
+			Message: "Patch data is empty",
+		}, nil
+	}
+	if approver == "" {
+		return &pb.ApprovePatchResponse{
+			Success: false,
+			Message: "Approver is required",
+		}, nil
+	}
+	if s.protection == nil {
+		return &pb.ApprovePatchResponse{
+			Success: false,
+			Message: "Patch approvals are not enabled on this server",
+		}, nil
+	}
+
+	approvers := s.protection.approve(digest, approver)
+	return &pb.ApprovePatchResponse{
+		Success:     true,
+		Message:     fmt.Sprintf("Patch %s approved by %s (%d approvals)", digest[:12], approver, len(approvers)),
+		PatchDigest: digest,
+		Approvers:   approvers,
+	}, nil
+}
@@ -0,0 +1,471 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc"
+)
+
+const (
+	anonymousUser = "anonymous"
+
+	// Workspace metadata key overriding the workspace byte limit. Keys with
+	// the quota. prefix are managed by the server and cannot be set by clients.
+	quotaMetadataPrefix      = "quota."
+	quotaMaxBytesMetadataKey = "quota.max_bytes"
+)
+
+// QuotaLimits are the limits applied to a user and their workspaces.
+// Zero means unlimited.
+type QuotaLimits struct {
+	MaxWorkspaceBytes int64 `json:"maxWorkspaceBytes"` // Bytes checked out into a single workspace
+	MaxUserBytes      int64 `json:"maxUserBytes"`      // Bytes across all of a user's workspaces
+	MaxUserWorkspaces int64 `json:"maxUserWorkspaces"` // Number of workspaces a user may own
+
+	// Disk a single workspace repository may use, history included. A
+	// workspace over it has its history compacted to one commit, and paths
+	// are only added while the rest still fits.
+	MaxWorkspaceDiskBytes int64 `json:"maxWorkspaceDiskBytes"`
+
+	// Size policies for each tracked path, so tracking the repository root
+	// cannot fill WORKSPACE_ROOT by accident
+	MaxFileBytes        int64 `json:"maxFileBytes"`        // Largest file a tracked path may contain
+	MaxTrackedPathBytes int64 `json:"maxTrackedPathBytes"` // Bytes under a single tracked path
+	AllowSizeOverride   bool  `json:"allowSizeOverride"`   // May skip the size policies with override_size_limits
+}
+
+// QuotaConfig holds default limits and per-user overrides. It is loaded from
+// the JSON file named by QUOTA_CONFIG.
+type QuotaConfig struct {
+	Defaults QuotaLimits            `json:"defaults"`
+	Users    map[string]QuotaLimits `json:"users"`
+}
+
+// requestWindow is how long request counts accumulate before they start
+// again from zero
+const requestWindow = 24 * time.Hour
+
+// requestCounter counts the requests made since start
+type requestCounter struct {
+	start time.Time
+	count int64
+}
+
+// QuotaManager resolves limits and counts requests per user and workspace
+// over the last requestWindow. A nil QuotaManager applies no limits and
+// counts nothing.
+type QuotaManager struct {
+	config QuotaConfig
+	now    func() time.Time
+
+	mu                sync.Mutex // Guards config and the request counters
+	userRequests      map[string]*requestCounter
+	workspaceRequests map[string]*requestCounter
+	pruned            time.Time // When counters whose window ended were last removed
+}
+
+// NewQuotaManager creates a quota manager from config
+func NewQuotaManager(config QuotaConfig) *QuotaManager {
+	return &QuotaManager{
+		config:            config,
+		now:               time.Now,
+		userRequests:      make(map[string]*requestCounter),
+		workspaceRequests: make(map[string]*requestCounter),
+	}
+}
+
+// LoadQuotaManager reads a quota config file
+func LoadQuotaManager(path string) (*QuotaManager, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quota config: %w", err)
+	}
+
+	var config QuotaConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse quota config: %w", err)
+	}
+
+	return NewQuotaManager(config), nil
+}
+
+// LimitsFor returns the limits for user
+func (q *QuotaManager) LimitsFor(user string) QuotaLimits {
+	if q == nil {
+		return QuotaLimits{}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limits, exists := q.config.Users[user]; exists {
+		return limits
+	}
+	return q.config.Defaults
+}
+
+// SetUserLimits replaces the limits for user
+func (q *QuotaManager) SetUserLimits(user string, limits QuotaLimits) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.config.Users == nil {
+		q.config.Users = make(map[string]QuotaLimits)
+	}
+	q.config.Users[user] = limits
+}
+
+// workspaceByteLimit returns the byte limit for a workspace, honouring the
+// quota.max_bytes metadata override
+func (q *QuotaManager) workspaceByteLimit(workspace *Workspace) int64 {
+	if q == nil {
+		return 0
+	}
+	if value, exists := workspace.Metadata[quotaMaxBytesMetadataKey]; exists {
+		if limit, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return limit
+		}
+	}
+	return q.LimitsFor(workspace.Owner).MaxWorkspaceBytes
+}
+
+// recordRequest counts a request by user and, unless workspaceID is "", one
+// against the workspace. A user of "" counts only the workspace.
+func (q *QuotaManager) recordRequest(user, workspaceID string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.prune(now)
+	if user != "" {
+		countRequest(q.userRequests, user, now)
+	}
+	if workspaceID != "" {
+		countRequest(q.workspaceRequests, workspaceID, now)
+	}
+}
+
+// countRequest adds a request to key's counter, starting a new window when
+// the last one has ended
+func countRequest(counters map[string]*requestCounter, key string, now time.Time) {
+	counter := counters[key]
+	if counter == nil || now.Sub(counter.start) >= requestWindow {
+		counter = &requestCounter{start: now}
+		counters[key] = counter
+	}
+	counter.count++
+}
+
+// prune removes the counters whose window has ended, so that users and
+// workspaces no longer seen are forgotten. It sweeps at most once a window.
+// Callers must hold q.mu.
+func (q *QuotaManager) prune(now time.Time) {
+	if now.Sub(q.pruned) < requestWindow {
+		return
+	}
+	q.pruned = now
+	for _, counters := range []map[string]*requestCounter{q.userRequests, q.workspaceRequests} {
+		for key, counter := range counters {
+			if now.Sub(counter.start) >= requestWindow {
+				delete(counters, key)
+			}
+		}
+	}
+}
+
+func (q *QuotaManager) requestCounts(user, workspaceID string) (int64, int64) {
+	if q == nil {
+		return 0, 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	current := func(counter *requestCounter) int64 {
+		if counter == nil || now.Sub(counter.start) >= requestWindow {
+			return 0
+		}
+		return counter.count
+	}
+	return current(q.userRequests[user]), current(q.workspaceRequests[workspaceID])
+}
+
+// UnaryInterceptor counts requests per user and, for requests that name a
+// workspace, per workspace. It must run after authentication.
+func (q *QuotaManager) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		q.recordRequest(quotaUser(ctx), requestWorkspace(req))
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor counts streaming requests per user when the stream
+// opens and, once the request arrives, per workspace for those that name
+// one, such as StreamWorkspaceArchive
+func (q *QuotaManager) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		q.recordRequest(quotaUser(ss.Context()), "")
+		return handler(srv, &quotaStream{ServerStream: ss, quotas: q})
+	}
+}
+
+// quotaStream charges the workspace the first request on a stream names
+type quotaStream struct {
+	grpc.ServerStream
+	quotas  *QuotaManager
+	counted bool
+}
+
+func (s *quotaStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && !s.counted {
+		s.counted = true
+		if workspaceID := requestWorkspace(m); workspaceID != "" {
+			s.quotas.recordRequest("", workspaceID)
+		}
+	}
+	return err
+}
+
+// requestWorkspace returns the workspace a request names, or ""
+func requestWorkspace(req interface{}) string {
+	if r, ok := req.(interface{ GetWorkspaceId() string }); ok {
+		return r.GetWorkspaceId()
+	}
+	return ""
+}
+
+// quotaUser returns the user quotas are charged to
+func quotaUser(ctx context.Context) string {
+	if user := userFromContext(ctx); user != "" {
+		return user
+	}
+	return anonymousUser
+}
+
+// stripQuotaMetadata removes server-managed keys from client metadata
+func stripQuotaMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	clean := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, quotaMetadataPrefix) {
+			clean[key] = value
+		}
+	}
+	return clean
+}
+
+// pathSize returns the total size of the files at or below path
+func (s *server) pathSize(ctx context.Context, version int64, path string) (int64, error) {
+	_, size, err := s.pathStats(ctx, version, path)
+	return size, err
+}
+
+// pathStats counts the files under path and their total size; a file counts
+// as itself
+func (s *server) pathStats(ctx context.Context, version int64, path string) (files, size int64, err error) {
+	entry, err := s.repository.GetEntry(ctx, version, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if entry.Type == storage.ObjectTypeBlob {
+		return 1, entry.Size, nil
+	}
+
+	entries, err := s.repository.ReadDirectory(ctx, version, path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, child := range entries {
+		if child.Type == storage.ObjectTypeTree {
+			childFiles, childSize, err := s.pathStats(ctx, version, repoJoin(path, child.Name))
+			if err != nil {
+				return 0, 0, err
+			}
+			files += childFiles
+			size += childSize
+		} else {
+			files++
+			size += child.Size
+		}
+	}
+	return files, size, nil
+}
+
+// userUsage sums storage and workspace counts for user. Callers must hold s.mu.
+func (s *server) userUsage(user string) (bytes int64, workspaces int64) {
+	for _, workspace := range s.workspaces {
+		if workspace.Owner == user {
+			bytes += workspace.BytesStored
+			workspaces++
+		}
+	}
+	return bytes, workspaces
+}
+
+// checkCreateQuota reports why user may not create a workspace of the given
+// size, or "" if the request fits. Callers must hold s.mu.
+func (s *server) checkCreateQuota(user string, size int64) string {
+	limits := s.quotas.LimitsFor(user)
+	bytesUsed, workspaces := s.userUsage(user)
+
+	if limits.MaxUserWorkspaces > 0 && workspaces+1 > limits.MaxUserWorkspaces {
+		return fmt.Sprintf("workspace limit reached (%d of %d)", workspaces, limits.MaxUserWorkspaces)
+	}
+	if limits.MaxWorkspaceBytes > 0 && size > limits.MaxWorkspaceBytes {
+		return fmt.Sprintf("workspace would use %d bytes, limit is %d", size, limits.MaxWorkspaceBytes)
+	}
+	if limits.MaxUserBytes > 0 && bytesUsed+size > limits.MaxUserBytes {
+		return fmt.Sprintf("user storage would reach %d bytes, limit is %d", bytesUsed+size, limits.MaxUserBytes)
+	}
+	return ""
+}
+
+// checkAddPathQuota reports why size more bytes may not be added to
+// workspace, or "" if they fit. Callers must hold s.mu.
+func (s *server) checkAddPathQuota(workspace *Workspace, size int64) string {
+	if limit := s.quotas.workspaceByteLimit(workspace); limit > 0 && workspace.BytesStored+size > limit {
+		return fmt.Sprintf("workspace would use %d bytes, limit is %d", workspace.BytesStored+size, limit)
+	}
+
+	limits := s.quotas.LimitsFor(workspace.Owner)
+	if limits.MaxWorkspaceDiskBytes > 0 && workspace.DiskBytes+size > limits.MaxWorkspaceDiskBytes {
+		return fmt.Sprintf("workspace repository would use %d bytes on disk, limit is %d", workspace.DiskBytes+size, limits.MaxWorkspaceDiskBytes)
+	}
+	bytesUsed, _ := s.userUsage(workspace.Owner)
+	if limits.MaxUserBytes > 0 && bytesUsed+size > limits.MaxUserBytes {
+		return fmt.Sprintf("user storage would reach %d bytes, limit is %d", bytesUsed+size, limits.MaxUserBytes)
+	}
+	return ""
+}
+
+// checkSizePolicy reports why path, holding size bytes, may not be tracked
+// under limits, or "" if it may
+func (s *server) checkSizePolicy(ctx context.Context, limits QuotaLimits, version int64, path string, size int64) (string, error) {
+	if limits.MaxTrackedPathBytes > 0 && size > limits.MaxTrackedPathBytes {
+		return fmt.Sprintf("%s holds %d bytes, limit per tracked path is %d", displayPath(path), size, limits.MaxTrackedPathBytes), nil
+	}
+	if limits.MaxFileBytes > 0 {
+		file, fileSize, err := s.largestFile(ctx, version, path)
+		if err != nil {
+			return "", err
+		}
+		if fileSize > limits.MaxFileBytes {
+			return fmt.Sprintf("%s is %d bytes, limit per file is %d", file, fileSize, limits.MaxFileBytes), nil
+		}
+	}
+	return "", nil
+}
+
+// checkSizeOverride reports why user may not skip the size policies, or ""
+// if they did not ask to or may
+func (s *server) checkSizeOverride(user string, override bool) string {
+	if override && !s.quotas.LimitsFor(user).AllowSizeOverride {
+		return fmt.Sprintf("user %s may not override size limits", user)
+	}
+	return ""
+}
+
+// largestFile returns the largest file at or below path and its size
+func (s *server) largestFile(ctx context.Context, version int64, path string) (string, int64, error) {
+	entry, err := s.repository.GetEntry(ctx, version, path)
+	if err != nil {
+		return "", 0, err
+	}
+	if entry.Type == storage.ObjectTypeBlob {
+		return path, entry.Size, nil
+	}
+
+	entries, err := s.repository.ReadDirectory(ctx, version, path)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var largest string
+	var largestSize int64
+	for _, child := range entries {
+		childPath, childSize := repoJoin(path, child.Name), child.Size
+		if child.Type == storage.ObjectTypeTree {
+			if childPath, childSize, err = s.largestFile(ctx, version, childPath); err != nil {
+				return "", 0, err
+			}
+		}
+		if childSize > largestSize {
+			largest, largestSize = childPath, childSize
+		}
+	}
+	return largest, largestSize, nil
+}
+
+// displayPath names path in messages, where the root would otherwise be empty
+func displayPath(path string) string {
+	if cleanRepoPath(path) == "" {
+		return "the repository root"
+	}
+	return path
+}
+
+func (s *server) GetQuota(ctx context.Context, req *pb.GetQuotaRequest) (*pb.GetQuotaResponse, error) {
+	user := quotaUser(ctx)
+	log.Printf("Getting quota for user %s", user)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limits := s.quotas.LimitsFor(user)
+	bytesUsed, workspaces := s.userUsage(user)
+	userRequests, _ := s.quotas.requestCounts(user, "")
+
+	resp := &pb.GetQuotaResponse{
+		Success: true,
+		Message: "Quota retrieved successfully",
+		User:    user,
+		UserUsage: &pb.QuotaUsage{
+			BytesUsed:       bytesUsed,
+			BytesLimit:      limits.MaxUserBytes,
+			WorkspacesUsed:  workspaces,
+			WorkspacesLimit: limits.MaxUserWorkspaces,
+			RequestCount:    userRequests,
+		},
+	}
+
+	if req.WorkspaceId != "" {
+		workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+		if !exists {
+			return &pb.GetQuotaResponse{
+				Success: false,
+				Message: "Workspace not found",
+			}, nil
+		}
+		// Requests are counted under whichever of its ID or name they gave
+		_, workspaceRequests := s.quotas.requestCounts(user, workspace.ID)
+		if workspace.named() {
+			_, byName := s.quotas.requestCounts(user, workspace.Name)
+			workspaceRequests += byName
+		}
+		resp.WorkspaceUsage = &pb.QuotaUsage{
+			BytesUsed:      workspace.BytesStored,
+			BytesLimit:     s.quotas.workspaceByteLimit(workspace),
+			RequestCount:   workspaceRequests,
+			DiskBytesUsed:  workspace.DiskBytes,
+			DiskBytesLimit: s.quotas.LimitsFor(workspace.Owner).MaxWorkspaceDiskBytes,
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxReadFilesPaths bounds the paths in one ReadFiles request
+	maxReadFilesPaths = 1000
+
+	// defaultReadFilesMaxBytes is the content ReadFiles returns per call
+	// unless READ_FILES_MAX_BYTES says otherwise
+	defaultReadFilesMaxBytes = 16 << 20
+)
+
+// ReadFiles reads each requested path at one version. Failures are reported
+// per path; once the content would pass the size cap, the remaining files
+// are marked omitted so the client can ask for them in another call. Files
+// still at their if_not_hash are not_modified and cost nothing against the
+// cap.
+func (s *server) ReadFiles(ctx context.Context, req *pb.ReadFilesRequest) (*pb.ReadFilesResponse, error) {
+	log.Printf("Reading %d files at version %d", len(req.Paths), req.Version)
+
+	if len(req.Paths) > maxReadFilesPaths {
+		return nil, tooManyPathsError(len(req.Paths), maxReadFilesPaths)
+	}
+
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ReadFilesResponse{Version: version}
+	resp.Files, resp.Truncated = s.readBatch(ctx, version, req.Paths, req.IfNotHash, s.batchCap(req.MaxTotalBytes))
+	return resp, nil
+}
+
+// batchCap returns the content a batch may return: the server's cap, or the
+// client's when it asks for less
+func (s *server) batchCap(requested int64) int64 {
+	maxBytes := s.readFilesCap
+	if maxBytes == 0 {
+		maxBytes = defaultReadFilesMaxBytes
+	}
+	if requested > 0 && requested < maxBytes {
+		maxBytes = requested
+	}
+	return maxBytes
+}
+
+// readBatch reads paths at version, one result per path. A file whose hash
+// is its path's entry in ifNotHash is not_modified and sent without content.
+// Once the content would pass maxBytes, the remaining files are omitted and
+// it reports that the batch was truncated.
+func (s *server) readBatch(ctx context.Context, version int64, paths []string, ifNotHash map[string]string, maxBytes int64) ([]*pb.FileResult, bool) {
+	var results []*pb.FileResult
+	var total int64
+	truncated := false
+	for _, path := range paths {
+		result := &pb.FileResult{Path: path}
+		results = append(results, result)
+		if truncated {
+			result.Omitted = true
+			continue
+		}
+
+		entry, err := s.batchEntry(ctx, version, path, ifNotHash[path], maxBytes)
+		if err != nil {
+			result.Error = status.Convert(err).Message()
+			result.Failure = failureInfo(err)
+			continue
+		}
+		if cached := ifNotHash[path]; cached != "" && cached == string(entry.Hash) {
+			result.Hash = cached
+			result.Size = entry.Size
+			result.NotModified = true
+			continue
+		}
+		if total+entry.Size > maxBytes {
+			truncated = true
+			result.Omitted = true
+			continue
+		}
+
+		content, err := s.repository.ReadFile(ctx, version, path)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to read file: %v", err)
+			continue
+		}
+		result.Content = content
+		result.Hash = string(entry.Hash)
+		result.Size = int64(len(content))
+		total += result.Size
+	}
+	return results, truncated
+}
+
+// batchEntry looks up a file for ReadFiles, rejecting paths that are not
+// files or that could never fit in one response. A file still at ifNotHash
+// is never too large, since its content is not sent.
+func (s *server) batchEntry(ctx context.Context, version int64, path, ifNotHash string, maxBytes int64) (*storage.TreeEntry, error) {
+	if err := validatePath(path); err != nil {
+		return nil, invalidPathError(path, err)
+	}
+
+	entry, err := s.repository.GetEntry(ctx, version, path)
+	if err != nil {
+		return nil, readError("failed to read file entry", path, version, err)
+	}
+	if entry.Type != storage.ObjectTypeBlob {
+		return nil, detailedError(codes.InvalidArgument, fmt.Sprintf("%s is a directory", path), ReasonNotAFile,
+			map[string]string{"path": path})
+	}
+	if entry.Size > maxBytes && string(entry.Hash) != ifNotHash {
+		return nil, detailedError(codes.ResourceExhausted, fmt.Sprintf("%s is %d bytes, more than the %d bytes a batch can return", path, entry.Size, maxBytes), ReasonFileTooLarge,
+			map[string]string{"path": path, "size": strconv.FormatInt(entry.Size, 10), "max": strconv.FormatInt(maxBytes, 10)})
+	}
+	return entry, nil
+}
@@ -0,0 +1,550 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-git/diagnostics"
+	"github.com/nic/poon/poon-git/gitserver"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Instance is a server started with Start: the gRPC service and whichever
+// of the admin API, CAS API, gRPC-Web API, git server and ops port its
+// Config enables
+type Instance struct {
+	grpcServer  *grpc.Server
+	grpcLis     net.Listener
+	adminServer *grpc.Server
+	httpServers []*http.Server
+	gitServer   *gitserver.Instance
+	opsLis      net.Listener
+	webLis      net.Listener
+	replication *storage.ReplicatedBackend // Stopped last; nil without replicas
+	cancel      context.CancelFunc         // Stops the merge queue
+	events      *EventBus
+	serving     bool
+	done        chan error
+}
+
+// Serve runs a server until it fails
+func Serve(cfg Config) error {
+	inst, err := Start(cfg)
+	if err != nil {
+		return err
+	}
+	return inst.Wait()
+}
+
+// Start opens the repository, imports cfg.RepoRoot into it if it is empty,
+// and serves in the background until Stop
+func Start(cfg Config) (*Instance, error) {
+	workspaceRoot := cfg.WorkspaceRoot
+	if workspaceRoot == "" {
+		// Use a temporary directory for workspaces
+		var err error
+		workspaceRoot, err = os.MkdirTemp("", "poon-workspaces-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary workspace directory: %w", err)
+		}
+		log.Printf("Using temporary workspace directory: %s", workspaceRoot)
+	} else {
+		// Ensure workspace root directory exists if explicitly set
+		if err := os.MkdirAll(workspaceRoot, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create workspace root directory: %w", err)
+		}
+	}
+
+	// Alternates name the shared object directory by absolute path
+	var sharedObjects string
+	if cfg.WorkspaceSharedObjects {
+		root, err := filepath.Abs(workspaceRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve workspace root: %w", err)
+		}
+		sharedObjects = filepath.Join(root, sharedObjectsDir)
+	}
+
+	// Initialize storage backend (in-memory unless StorageBackend names a
+	// directory or s3:// location)
+	var backend storage.StorageBackend = storage.NewMemoryBackend()
+	if cfg.StorageBackend != "" && cfg.StorageBackend != "memory" {
+		var err error
+		backend, err = storage.OpenBackend(cfg.StorageBackend)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open storage backend: %w", err)
+		}
+		backend = storage.NewResilientBackend(backend, cfg.StorageResilience)
+	}
+	var replication *storage.ReplicatedBackend
+	if len(cfg.ReplicaBackends) > 0 {
+		var replicas []storage.StorageBackend
+		for _, location := range cfg.ReplicaBackends {
+			replica, err := storage.OpenBackend(location)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open replica %s: %w", location, err)
+			}
+			replicas = append(replicas, storage.NewResilientBackend(replica, cfg.StorageResilience))
+		}
+		replication = storage.NewReplicatedBackend(backend, replicas, append([]string{cfg.StorageBackend}, cfg.ReplicaBackends...)...)
+		backend = replication
+		log.Printf("Mirroring writes to %s", strings.Join(cfg.ReplicaBackends, ", "))
+	}
+	repository, err := storage.NewRepositoryWithHash(context.Background(), backend, cfg.HashAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if cfg.RepairSource != "" {
+		source, err := storage.OpenBackend(cfg.RepairSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open repair source: %w", err)
+		}
+		repository.SetRepairSource(storage.NewResilientBackend(source, cfg.StorageResilience))
+		log.Printf("Repairing corrupt objects from %s", cfg.RepairSource)
+	}
+	if algorithm := repository.HashAlgorithm(); cfg.HashAlgorithm != "" && algorithm != cfg.HashAlgorithm {
+		log.Printf("Repository objects are hashed with %s; HASH_ALGORITHM=%s applies only after 'poon-server rehash --algorithm %s'", algorithm, cfg.HashAlgorithm, cfg.HashAlgorithm)
+	}
+	if format := repository.HashFormat(); format != storage.CurrentHashFormat {
+		log.Printf("Repository trees and commits are hashed in the %s format; run 'poon-server rehash' to move them to %s", format, storage.CurrentHashFormat)
+	}
+
+	// Create initial repository version from filesystem if it exists and is empty
+	currentVersion, err := repository.GetCurrentVersion(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	if currentVersion == 0 {
+		// Create initial commit from filesystem
+		log.Printf("Creating initial repository version from filesystem: %s", cfg.RepoRoot)
+		_, err := repository.CreateCommitFromFileSystem(context.Background(), cfg.RepoRoot, "poon-server@example.com", "Initial repository commit")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create initial repository version: %w", err)
+		}
+		log.Printf("✓ Initial repository version created successfully")
+	}
+
+	var auth *Authenticator
+	if cfg.AuthTokensFile != "" {
+		auth, err = LoadAuthenticator(cfg.AuthTokensFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load authentication tokens: %w", err)
+		}
+		log.Printf("Authentication enabled (%s)", cfg.AuthTokensFile)
+	}
+
+	var validators []Validator
+	var commitPolicy *CommitMessagePolicy
+	if cfg.ValidationConfig != "" {
+		validationConfig, err := LoadValidationConfig(cfg.ValidationConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load validation config: %w", err)
+		}
+		validators, err = NewValidators(validationConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure patch validation: %w", err)
+		}
+		commitPolicy, err = NewCommitMessagePolicy(validationConfig.CommitMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure commit message policy: %w", err)
+		}
+		log.Printf("Patch validation enabled (%d validators, commit message policy: %t)", len(validators), commitPolicy != nil)
+	}
+
+	quotas := NewQuotaManager(QuotaConfig{})
+	if cfg.QuotaConfig != "" {
+		quotas, err = LoadQuotaManager(cfg.QuotaConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load quota config: %w", err)
+		}
+		log.Printf("Quotas enabled (%s)", cfg.QuotaConfig)
+	}
+
+	patchLimits := cfg.PatchLimits
+	maxMessageBytes := cfg.MaxMessageBytes
+	if maxMessageBytes == 0 || maxMessageBytes > math.MaxInt32 {
+		maxMessageBytes = math.MaxInt32
+	}
+	log.Printf("Patch limits: %d bytes, %d files, %d hunks, %d bytes per patched file (gRPC messages up to %d bytes)",
+		patchLimits.MaxPatchBytes, patchLimits.MaxFiles, patchLimits.MaxHunks, patchLimits.MaxFileBytes, maxMessageBytes)
+
+	// Leave the other half of a message for the per-file fields of large batches
+	readFilesCap := cfg.ReadFilesMaxBytes
+	if readFilesCap <= 0 || readFilesCap > maxMessageBytes/2 {
+		readFilesCap = maxMessageBytes / 2
+	}
+
+	protection, err := NewBranchProtection(ProtectionConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure branch protection: %w", err)
+	}
+	if cfg.BranchProtectionConfig != "" {
+		protection, err = LoadBranchProtection(cfg.BranchProtectionConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load branch protection config: %w", err)
+		}
+		log.Printf("Branch protection enabled (%s)", cfg.BranchProtectionConfig)
+	}
+
+	events := NewEventBus()
+	if cfg.NotifyConfig != "" {
+		notifications, err := LoadNotifications(cfg.NotifyConfig, repository)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load notification config: %w", err)
+		}
+		events.Subscribe(notifications.Handle)
+		log.Printf("Merge notifications enabled (%s)", cfg.NotifyConfig)
+	}
+
+	inst := &Instance{done: make(chan error, 1), events: events, replication: replication}
+	ctx, cancel := context.WithCancel(context.Background())
+	inst.cancel = cancel
+
+	var mergeQueue *MergeQueue
+	if cfg.MergeQueueConfig != "" {
+		mergeQueue, err = LoadMergeQueue(cfg.MergeQueueConfig, repository)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load merge queue config: %w", err)
+		}
+		mergeQueue.events = events
+		mergeQueue.store = storage.NewQueueStore(backend)
+		if err := mergeQueue.Recover(ctx); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to recover merge queue: %w", err)
+		}
+		go mergeQueue.Run(ctx)
+		log.Printf("Merge queue enabled (%s)", cfg.MergeQueueConfig)
+	}
+
+	var symbolIndex *SymbolIndex
+	if cfg.SymbolIndex {
+		symbolIndex = NewSymbolIndex(repository)
+		events.Subscribe(func(event Event) {
+			if event.Type == EventVersionCreated {
+				symbolIndex.Warm(event.Version)
+			}
+		})
+		if current, err := repository.GetCurrentVersion(ctx); err == nil && current > 0 {
+			symbolIndex.Warm(current)
+		}
+		log.Printf("Symbol index enabled")
+	}
+
+	activity := storage.NewActivityStore(backend)
+	events.Subscribe(recordActivity(activity, repository))
+	go func() {
+		if count, err := activity.Backfill(ctx, repository); err != nil {
+			log.Printf("Warning: failed to backfill activity metrics: %v", err)
+		} else if count > 0 {
+			log.Printf("Recorded activity metrics of %d earlier versions", count)
+		}
+	}()
+
+	tags := storage.NewTagManager(backend)
+	if cfg.SnapshotConfig != "" {
+		snapshots, err := LoadSnapshotScheduler(cfg.SnapshotConfig, repository, tags)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load snapshot config: %w", err)
+		}
+		go snapshots.Run(ctx)
+		log.Printf("Scheduled snapshots enabled (%s)", cfg.SnapshotConfig)
+	}
+
+	var archiveCache *storage.ArchiveCache
+	if cfg.ArchiveCacheBytes > 0 {
+		archiveCache = storage.NewArchiveCache(backend, cfg.ArchiveCacheBytes)
+		log.Printf("Archive cache enabled (%d bytes)", cfg.ArchiveCacheBytes)
+	}
+	var docs *docCache
+	if cfg.DocCacheBytes > 0 {
+		docs = newDocCache(cfg.DocCacheBytes)
+	}
+
+	deadlines := NewDeadlinePolicy()
+	if cfg.RPCTimeoutConfig != "" {
+		deadlines, err = LoadDeadlinePolicy(cfg.RPCTimeoutConfig)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load RPC timeout config: %w", err)
+		}
+		log.Printf("RPC time limits loaded (%s)", cfg.RPCTimeoutConfig)
+	}
+
+	var templates map[string]*WorkspaceTemplate
+	if cfg.TemplatesConfig != "" {
+		templates, err = LoadWorkspaceTemplates(cfg.TemplatesConfig)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load workspace templates: %w", err)
+		}
+		log.Printf("Loaded %d workspace templates (%s)", len(templates), cfg.TemplatesConfig)
+	}
+
+	srv := &server{
+		repoRoot:              cfg.RepoRoot,
+		workspaceRoot:         workspaceRoot,
+		workspaces:            make(map[string]*Workspace),
+		repository:            repository,
+		auth:                  auth,
+		validators:            validators,
+		commitPolicy:          commitPolicy,
+		locks:                 storage.NewLockManager(backend),
+		tags:                  tags,
+		activity:              activity,
+		replication:           replication,
+		quotas:                quotas,
+		patchLimits:           patchLimits,
+		mergeQueue:            mergeQueue,
+		protection:            protection,
+		archiveCache:          archiveCache,
+		docCache:              docs,
+		symbols:               symbolIndex,
+		gitServerPort:         cfg.GitServerPort,
+		minClientVersion:      cfg.MinClientVersion,
+		webURL:                cfg.WebURL,
+		readFilesCap:          readFilesCap,
+		sharedObjects:         sharedObjects,
+		workspaceGCGrace:      cfg.WorkspaceGCGrace,
+		workspaceHistoryDepth: int(cfg.WorkspaceHistoryDepth),
+		templates:             templates,
+		events:                events,
+		operations:            operationRegistry{store: storage.NewOperationStore(backend)},
+	}
+	if err := srv.operations.recover(ctx); err != nil {
+		log.Printf("Warning: failed to recover operations: %v", err)
+	}
+	if srv.workspaceGCGrace <= 0 {
+		srv.workspaceGCGrace = defaultWorkspaceGCGrace
+	}
+	if cfg.WorkspaceGCInterval > 0 {
+		go srv.runWorkspaceGC(ctx, cfg.WorkspaceGCInterval, srv.workspaceGCGrace)
+		log.Printf("Collecting orphaned workspace directories every %s (grace %s)", cfg.WorkspaceGCInterval, srv.workspaceGCGrace)
+		if cfg.WorkspaceHistoryDepth > 0 {
+			log.Printf("Compacting workspace histories to %d commits every %s", cfg.WorkspaceHistoryDepth, cfg.WorkspaceGCInterval)
+		}
+	}
+
+	// Listeners are opened in turn; any failure closes the ones before it
+	fail := func(err error) (*Instance, error) {
+		inst.Stop()
+		return nil, err
+	}
+
+	inst.grpcLis, err = net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fail(fmt.Errorf("failed to listen: %w", err))
+	}
+
+	inst.grpcServer = grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unavailableUnaryInterceptor(), auth.UnaryInterceptor(), deadlines.UnaryInterceptor(), quotas.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(unavailableStreamInterceptor(), auth.StreamInterceptor(), deadlines.StreamInterceptor(), quotas.StreamInterceptor()),
+		grpc.MaxRecvMsgSize(int(maxMessageBytes)),
+		grpc.MaxSendMsgSize(int(maxMessageBytes)),
+	}, keepaliveServerOptions(cfg.KeepaliveTime, cfg.KeepaliveTimeout, cfg.KeepaliveMinTime)...)...)
+	pb.RegisterMonorepoServiceServer(inst.grpcServer, srv)
+	if cfg.Reflection {
+		reflection.Register(inst.grpcServer)
+		log.Printf("gRPC reflection enabled")
+	}
+
+	if cfg.AdminAddr != "" {
+		if cfg.AdminTokensFile == "" {
+			return fail(fmt.Errorf("ADMIN_TOKENS_FILE is required when ADMIN_ADDR is set"))
+		}
+		adminAuth, err := LoadAuthenticator(cfg.AdminTokensFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load admin tokens: %w", err))
+		}
+		if !adminAuth.Enabled() {
+			return fail(fmt.Errorf("admin tokens file %s defines no tokens", cfg.AdminTokensFile))
+		}
+
+		adminLis, err := net.Listen("tcp", cfg.AdminAddr)
+		if err != nil {
+			return fail(fmt.Errorf("failed to listen on admin address: %w", err))
+		}
+
+		inst.adminServer = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(unavailableUnaryInterceptor(), adminAuth.UnaryInterceptor()),
+			grpc.ChainStreamInterceptor(unavailableStreamInterceptor(), adminAuth.StreamInterceptor()),
+		)
+		pb.RegisterMonorepoAdminServiceServer(inst.adminServer, &adminServer{srv: srv})
+		if cfg.Reflection {
+			reflection.Register(inst.adminServer)
+		}
+		go func() {
+			if err := inst.adminServer.Serve(adminLis); err != nil {
+				log.Printf("Admin API stopped: %v", err)
+			}
+		}()
+		log.Printf("Admin API listening on %s", adminLis.Addr())
+	}
+
+	cors := newCORSPolicy(cfg.CORSAllowedOrigins)
+	if cfg.CASAddr != "" {
+		casLis, err := net.Listen("tcp", cfg.CASAddr)
+		if err != nil {
+			return fail(fmt.Errorf("failed to listen on CAS address: %w", err))
+		}
+
+		casServer := &http.Server{
+			Handler:           cors.wrap(newCASHandler(srv, cfg.CASBaseURL)),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		inst.httpServers = append(inst.httpServers, casServer)
+		go func() {
+			if err := casServer.Serve(casLis); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("CAS API stopped: %v", err)
+			}
+		}()
+		log.Printf("Content-addressed fetch API listening on %s", casLis.Addr())
+	}
+
+	if cfg.GRPCWebAddr != "" {
+		inst.webLis, err = net.Listen("tcp", cfg.GRPCWebAddr)
+		if err != nil {
+			return fail(fmt.Errorf("failed to listen on gRPC-Web address: %w", err))
+		}
+
+		webServer := &http.Server{
+			Handler:           newGRPCWebHandler(inst.grpcServer, cors),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		inst.httpServers = append(inst.httpServers, webServer)
+		go func() {
+			if err := webServer.Serve(inst.webLis); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("gRPC-Web API stopped: %v", err)
+			}
+		}()
+		log.Printf("gRPC-Web API listening on %s", inst.webLis.Addr())
+	}
+
+	if cfg.OpsAddr != "" {
+		inst.opsLis, err = net.Listen("tcp", cfg.OpsAddr)
+		if err != nil {
+			return fail(fmt.Errorf("failed to listen on ops address: %w", err))
+		}
+
+		opsServer := &http.Server{
+			Handler:           diagnostics.NewHandler(srv.diagnosticVars(backend)),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		inst.httpServers = append(inst.httpServers, opsServer)
+		go func() {
+			if err := opsServer.Serve(inst.opsLis); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Ops port stopped: %v", err)
+			}
+		}()
+		log.Printf("pprof and expvar listening on %s", inst.opsLis.Addr())
+	}
+
+	if cfg.GitAddr != "" {
+		inst.gitServer, err = gitserver.Start(gitserver.Config{
+			Addr:           cfg.GitAddr,
+			WorkspaceRoot:  workspaceRoot,
+			Workspaces:     srv,
+			Limits:         cfg.GitLimits,
+			SlowRequest:    cfg.GitSlowRequest,
+			PackCacheBytes: cfg.GitPackCacheBytes,
+		})
+		if err != nil {
+			return fail(fmt.Errorf("failed to start git server: %w", err))
+		}
+		if srv.gitServerPort == "" {
+			srv.gitServerPort = strconv.Itoa(inst.gitServer.Addr().(*net.TCPAddr).Port)
+		}
+		log.Printf("Git server listening on %s", inst.gitServer.Addr())
+	}
+
+	log.Printf("gRPC server listening on %s", inst.grpcLis.Addr())
+	log.Printf("Repository root: %s", cfg.RepoRoot)
+	log.Printf("Workspace root: %s", workspaceRoot)
+	if cfg.StorageBackend != "" && cfg.StorageBackend != "memory" {
+		log.Printf("Using content-addressable storage at %s", cfg.StorageBackend)
+	} else {
+		log.Printf("Using in-memory content-addressable storage")
+	}
+
+	inst.serving = true
+	go func() {
+		inst.done <- inst.grpcServer.Serve(inst.grpcLis)
+	}()
+	return inst, nil
+}
+
+// Addr returns the address the gRPC service listens on
+func (inst *Instance) Addr() net.Addr {
+	return inst.grpcLis.Addr()
+}
+
+// GitAddr returns the address of the embedded git server, nil without one
+func (inst *Instance) GitAddr() net.Addr {
+	if inst.gitServer == nil {
+		return nil
+	}
+	return inst.gitServer.Addr()
+}
+
+// OpsAddr returns the address of the pprof and expvar port, nil without one
+func (inst *Instance) OpsAddr() net.Addr {
+	if inst.opsLis == nil {
+		return nil
+	}
+	return inst.opsLis.Addr()
+}
+
+// GRPCWebAddr returns the address of the gRPC-Web API, nil without one
+func (inst *Instance) GRPCWebAddr() net.Addr {
+	if inst.webLis == nil {
+		return nil
+	}
+	return inst.webLis.Addr()
+}
+
+// Wait blocks until the gRPC service stops and returns why, nil after Stop
+func (inst *Instance) Wait() error {
+	err := <-inst.done
+	inst.done <- err
+	return err
+}
+
+// Stop closes every listener and cuts off calls in progress
+func (inst *Instance) Stop() {
+	inst.cancel()
+	if inst.gitServer != nil {
+		inst.gitServer.Close()
+	}
+	for _, httpServer := range inst.httpServers {
+		httpServer.Close()
+	}
+	if inst.adminServer != nil {
+		inst.adminServer.Stop()
+	}
+	if inst.serving {
+		inst.grpcServer.Stop()
+		inst.Wait()
+	} else if inst.grpcLis != nil {
+		inst.grpcLis.Close()
+	}
+	// After the RPCs that publish to it
+	inst.events.Close()
+	if inst.replication != nil {
+		inst.replication.Close()
+	}
+}
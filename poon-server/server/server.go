@@ -0,0 +1,1147 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+type server struct {
+	pb.UnimplementedMonorepoServiceServer
+	repoRoot              string
+	workspaceRoot         string
+	workspaces            map[string]*Workspace
+	mu                    sync.RWMutex
+	repository            storage.Repository
+	auth                  *Authenticator
+	validators            []Validator
+	commitPolicy          *CommitMessagePolicy
+	locks                 *storage.LockManager
+	tags                  *storage.TagManager
+	activity              *storage.ActivityStore
+	replication           *storage.ReplicatedBackend // nil without replicas
+	quotas                *QuotaManager
+	patchLimits           PatchLimits
+	mergeQueue            *MergeQueue // Lands patches in order after validation; nil lands them directly
+	protection            *BranchProtection
+	archiveCache          *storage.ArchiveCache         // Generated archives by tree hash; nil builds each one
+	docCache              *docCache                     // Rendered READMEs and documents by hash; nil renders each one
+	symbols               *SymbolIndex                  // Symbols of recent versions; nil without SYMBOL_INDEX
+	gitServerPort         string                        // Port of the git server in remote URLs; "" for the default
+	minClientVersion      string                        // Reported by GetServerInfo; "" for DefaultMinClientVersion
+	webURL                string                        // Web UI base URL reported by GetServerInfo
+	readFilesCap          int64                         // Content per ReadFiles call; 0 for defaultReadFilesMaxBytes
+	workspaceGCGrace      time.Duration                 // Age before an orphaned workspace directory is removed
+	sharedObjects         string                        // Object directory workspace repositories share; "" gives each its own
+	workspaceHistoryDepth int                           // Commits workspace compaction keeps; 0 keeps all
+	templates             map[string]*WorkspaceTemplate // By name, from WORKSPACE_TEMPLATES_CONFIG
+	events                *EventBus                     // Told about new versions; nil tells no one
+	operations            operationRegistry             // Long-running work followed with GetOperation
+}
+
+type Workspace struct {
+	ID           string
+	Name         string
+	TrackedPaths []string
+	// Glob patterns from CreateWorkspace or AddTrackedPath; their matches are
+	// in TrackedPaths and RefreshTrackedPaths adds new ones
+	TrackedPatterns []string
+	CreatedAt       time.Time
+	LastSync        time.Time
+	SyncedVersion   int64 // Version the repository's files were last copied from
+	Status          pb.WorkspaceStatus
+	Metadata        map[string]string
+	GitRepoPath     string
+	Owner           string // User the workspace counts against for quotas
+	BytesStored     int64  // Size of the tracked paths checked out into the workspace
+	DiskBytes       int64  // Disk used by the workspace repository, history included, when last measured
+	Commits         int    // Commits in the workspace repository's history when last measured
+
+	// Client-side state from the last ReportWorkspaceStatus call
+	ClientVersion string
+	HeadCommit    string
+	DirtyFiles    int32
+	LastReport    time.Time
+	Diverged      bool
+
+	materializing *materialization // Until a lazy workspace's repository is filled
+
+	// repoMu is held while the workspace repository is committed to or
+	// compacted, so compaction can run without holding the server lock.
+	// Holders of s.mu may take it; holders of it must not take s.mu.
+	repoMu sync.Mutex
+}
+
+func validatePath(path string) error {
+	if strings.Contains(path, "..") {
+		return fmt.Errorf("path traversal not allowed: path contains '..'")
+	}
+
+	// Compared with forward slashes, so \etc and C:\ are rejected on Windows
+	cleanPath := filepath.ToSlash(filepath.Clean(path))
+	if strings.HasPrefix(cleanPath, "..") || strings.HasPrefix(cleanPath, "/") || filepath.VolumeName(path) != "" {
+		return fmt.Errorf("invalid path: path must be relative and within repository")
+	}
+
+	return nil
+}
+
+// createWorkspaceGitRepo initializes an empty workspace repository
+func (s *server) createWorkspaceGitRepo(ctx context.Context, gitRepoPath string) error {
+	// Create git repository directory
+	if err := os.MkdirAll(gitRepoPath, 0755); err != nil {
+		return fmt.Errorf("failed to create git repo directory: %w", err)
+	}
+
+	// Initialize git repository
+	cmd := gitCommand(ctx, gitRepoPath, "init")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+
+	// Configure git user (required for commits)
+	cmd = gitCommand(ctx, gitRepoPath, "config", "user.email", "poon-server@example.com")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to configure git user email: %w", err)
+	}
+
+	cmd = gitCommand(ctx, gitRepoPath, "config", "user.name", "Poon Server")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to configure git user name: %w", err)
+	}
+
+	if s.sharedObjects != "" {
+		if err := shareObjectsWith(gitRepoPath, s.sharedObjects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// populateWorkspaceGitRepo copies the tracked paths at currentVersion into
+// an empty workspace repository and makes its initial commit
+func (s *server) populateWorkspaceGitRepo(ctx context.Context, gitRepoPath string, currentVersion int64, trackedPaths, patterns []string) error {
+	// Copy tracked paths from repository to git repo
+	for _, path := range trackedPaths {
+		if err := s.copyPathToGitRepo(ctx, currentVersion, path, gitRepoPath); err != nil {
+			return fmt.Errorf("failed to copy path %s: %w", path, err)
+		}
+	}
+
+	// Create .poon-workspace metadata file
+	metadataContent := workspaceMetadata(trackedPaths, patterns, time.Now())
+
+	metadataPath := filepath.Join(gitRepoPath, ".poon-workspace")
+	if err := os.WriteFile(metadataPath, []byte(metadataContent), 0644); err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+
+	// Create .gitignore
+	gitignoreContent := `# Poon workspace files
+.poon/
+*.tmp
+.DS_Store
+`
+	gitignorePath := filepath.Join(gitRepoPath, ".gitignore")
+	if err := os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644); err != nil {
+		return fmt.Errorf("failed to create .gitignore: %w", err)
+	}
+
+	if err := s.writeGitAttributes(ctx, currentVersion, gitRepoPath); err != nil {
+		return err
+	}
+
+	// Add all files to git
+	cmd := gitCommand(ctx, gitRepoPath, "add", ".")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to add files to git: %w", err)
+	}
+
+	// Create initial commit
+	commitMsg := fmt.Sprintf("Initial workspace commit\n\nTracked paths:\n%s", formatTrackedPaths(trackedPaths))
+	if len(patterns) > 0 {
+		commitMsg += fmt.Sprintf("\n\nTracked patterns:\n%s", formatTrackedPaths(patterns))
+	}
+	cmd = gitCommand(ctx, gitRepoPath, "commit", "-m", commitMsg)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create initial commit: %w", err)
+	}
+	s.shareWorkspaceObjects(gitRepoPath)
+
+	log.Printf("Successfully initialized git repository at %s with %d tracked paths", gitRepoPath, len(trackedPaths))
+	return nil
+}
+
+func (s *server) copyPathToGitRepo(ctx context.Context, version int64, srcPath string, gitRepoPath string) error {
+	// Check if path is a directory or file
+	_, err := s.repository.ReadDirectory(ctx, version, srcPath)
+	if err != nil {
+		// Try as a file
+		content, _, err := s.repository.OpenFile(ctx, version, srcPath)
+		if err != nil {
+			return fmt.Errorf("path %s not found as file or directory", srcPath)
+		}
+		defer content.Close()
+
+		// Create target directory if needed
+		targetPath, err := storage.ResolveInRoot(gitRepoPath, srcPath)
+		if err != nil {
+			return err
+		}
+		targetDir := filepath.Dir(targetPath)
+		if err := os.MkdirAll(targetDir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", targetDir, err)
+		}
+
+		// Write file content
+		if err := writeFileFrom(targetPath, content); err != nil {
+			return err
+		}
+		countCopiedFile(ctx)
+
+		log.Printf("Copied file: %s", srcPath)
+		return nil
+	}
+
+	// It's a directory, copy recursively
+	return s.copyDirectoryToGitRepo(ctx, version, srcPath, gitRepoPath)
+}
+
+func (s *server) copyDirectoryToGitRepo(ctx context.Context, version int64, srcPath string, gitRepoPath string) error {
+	entries, err := s.repository.ReadDirectory(ctx, version, srcPath)
+	if err != nil {
+		return err
+	}
+
+	// Create target directory
+	targetDir, err := storage.ResolveInRoot(gitRepoPath, srcPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", targetDir, err)
+	}
+
+	// Copy each entry
+	for _, entry := range entries {
+		entryPath := repoJoin(srcPath, entry.Name)
+
+		if entry.Type == storage.ObjectTypeTree {
+			// Recursively copy subdirectory
+			if err := s.copyDirectoryToGitRepo(ctx, version, entryPath, gitRepoPath); err != nil {
+				return err
+			}
+		} else if entry.Type == storage.ObjectTypeBlob {
+			// Copy file
+			if err := s.copyFileToGitRepo(ctx, version, entryPath, gitRepoPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("Copied directory: %s (%d entries)", srcPath, len(entries))
+	return nil
+}
+
+func (s *server) copyFileToGitRepo(ctx context.Context, version int64, srcPath string, gitRepoPath string) error {
+	content, _, err := s.repository.OpenFile(ctx, version, srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", srcPath, err)
+	}
+	defer content.Close()
+
+	targetPath, err := storage.ResolveInRoot(gitRepoPath, srcPath)
+	if err != nil {
+		return err
+	}
+	if err := writeFileFrom(targetPath, content); err != nil {
+		return err
+	}
+	countCopiedFile(ctx)
+	return nil
+}
+
+// writeFileFrom writes everything read from r to path, so large files are
+// copied without being held in memory
+func writeFileFrom(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	return nil
+}
+
+func formatTrackedPaths(paths []string) string {
+	result := ""
+	for _, path := range paths {
+		result += fmt.Sprintf("  - %s\n", path)
+	}
+	return strings.TrimSuffix(result, "\n")
+}
+
+func (s *server) MergePatch(ctx context.Context, req *pb.MergePatchRequest) (*pb.MergePatchResponse, error) {
+	log.Printf("Merging patch for path: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid path: %v", err),
+			Failure: &pb.FailureInfo{Reason: ReasonInvalidPath, Metadata: map[string]string{"path": req.Path}},
+		}, nil
+	}
+
+	if req.Amend && (req.Branch == "" || req.Branch == storage.MainBranch) {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Only commits on branches other than %s can be amended", storage.MainBranch),
+		}, nil
+	}
+
+	if req.ExpectedVersion != 0 {
+		if req.Branch != "" && req.Branch != storage.MainBranch {
+			return &pb.MergePatchResponse{
+				Success: false,
+				Message: fmt.Sprintf("Expected versions are versions of %s; patches to other branches cannot name one", storage.MainBranch),
+			}, nil
+		}
+		failure, err := s.changedSinceExpected(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if failure != nil {
+			log.Printf("Rejected patch for path %s: %s changed since version %d", req.Path, failure.Metadata["path"], req.ExpectedVersion)
+			return &pb.MergePatchResponse{
+				Success: false,
+				Message: fmt.Sprintf("%s changed after version %d, which the patch was made against (current version is %s)", failure.Metadata["path"], req.ExpectedVersion, failure.Metadata["current_version"]),
+				Failure: failure,
+			}, nil
+		}
+	}
+
+	if len(req.Patch) == 0 && !(req.Amend && req.Message != "") {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: "Patch data is empty",
+			Failure: &pb.FailureInfo{Reason: ReasonEmptyPatch},
+		}, nil
+	}
+
+	if err := s.patchLimits.CheckPatch(req.Patch); err != nil {
+		log.Printf("Rejected oversized patch for path %s: %v", req.Path, err)
+		return nil, err
+	}
+
+	if violations := s.commitPolicy.Check(req.Message); len(violations) > 0 {
+		log.Printf("Rejected commit message for path %s", req.Path)
+		return nil, commitMessageError(violations)
+	}
+
+	lock, err := s.checkPatchLocks(ctx, req)
+	if err != nil {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to check locks: %v", err),
+		}, nil
+	}
+	if lock != nil {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Path %s is locked by %s until %s", lock.Path, lock.Owner, lock.ExpiresAt.Format(time.RFC3339)),
+			Failure: lockFailure(lock),
+		}, nil
+	}
+
+	if violations := s.checkProtection(ctx, req); len(violations) > 0 {
+		log.Printf("Rejected patch for protected path %s: %s", req.Path, formatViolations(violations))
+		return &pb.MergePatchResponse{
+			Success:    false,
+			Message:    fmt.Sprintf("Patch rejected by branch protection: %s", formatViolations(violations)),
+			Violations: violations,
+			Failure:    policyFailure("branch_protection"),
+		}, nil
+	}
+
+	// Validation and the merge queue guard main; a branch's commits reach
+	// it only through MergeBranches
+	if req.Branch != "" && req.Branch != storage.MainBranch {
+		return s.mergePatchToBranch(ctx, req)
+	}
+
+	if change := s.newChange(ctx, req); change != nil {
+		if err := s.patchLimits.CheckFileSize(change); err != nil {
+			log.Printf("Rejected oversized patch for path %s: %v", req.Path, err)
+			return nil, err
+		}
+
+		if violations := s.validatePatch(ctx, change); len(violations) > 0 {
+			log.Printf("Rejected patch for path %s: %s", req.Path, formatViolations(violations))
+			return &pb.MergePatchResponse{
+				Success:    false,
+				Message:    fmt.Sprintf("Patch rejected by validation: %s", formatViolations(violations)),
+				Violations: violations,
+				Failure:    policyFailure("validation"),
+			}, nil
+		}
+	}
+
+	opts := merge.ApplyOptions{
+		IgnoreWhitespace:        req.IgnoreWhitespace,
+		NormalizeLineEndings:    req.NormalizeLineEndings,
+		PreserveTrailingNewline: req.PreserveTrailingNewline,
+	}
+
+	if s.mergeQueue != nil {
+		// Patches that do not apply now are refused rather than queued
+		if _, err := s.repository.PreviewPatch(ctx, req.Patch, opts); err != nil {
+			return &pb.MergePatchResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to apply patch: %v", err),
+				Failure: s.patchFailure(ctx, req.Patch, err),
+			}, nil
+		}
+
+		entry, err := s.mergeQueue.Submit(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue patch: %w", err)
+		}
+		log.Printf("Queued patch for path %s as merge queue entry %s at position %d", req.Path, entry.Id, entry.Position)
+		return &pb.MergePatchResponse{
+			Success:      true,
+			Message:      fmt.Sprintf("Patch queued at position %d; follow it with 'poon queue status %s'", entry.Position, entry.Id),
+			Queued:       true,
+			QueueEntryId: entry.Id,
+		}, nil
+	}
+
+	// Apply patch using content-addressable storage directly
+	versionInfo, err := s.repository.ApplyPatchAgainst(ctx, req.Patch, req.Author, req.Message, opts, req.ExpectedVersion)
+	if err != nil {
+		return &pb.MergePatchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to apply patch: %v", err),
+			Failure: s.patchFailure(ctx, req.Patch, err),
+		}, nil
+	}
+
+	log.Printf("Successfully applied patch, created version %d with commit %s", versionInfo.Version, versionInfo.CommitHash)
+	event := versionCreated(versionInfo, req.Author)
+	s.events.Publish(event)
+
+	return &pb.MergePatchResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Patch applied successfully, created version %d", versionInfo.Version),
+		CommitHash: string(versionInfo.CommitHash),
+		Stats:      s.changeStats(ctx, event),
+	}, nil
+}
+
+func (s *server) ReadDirectory(ctx context.Context, req *pb.ReadDirectoryRequest) (*pb.ReadDirectoryResponse, error) {
+	log.Printf("Reading directory: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+
+	// Read at the requested version, or the current one
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := s.repository.GetEntry(ctx, version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read directory", req.Path, version, err)
+	}
+	if req.IfNotHash != "" && !req.WithHistory && req.IfNotHash == string(dir.Hash) && dir.Type == storage.ObjectTypeTree {
+		return &pb.ReadDirectoryResponse{
+			Hash:        string(dir.Hash),
+			NotModified: true,
+		}, nil
+	}
+
+	// Read from content-addressable storage
+	entries, err := s.repository.ReadDirectory(ctx, version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read directory", req.Path, version, err)
+	}
+
+	var lastChanges map[string]storage.FileHistoryEntry
+	if req.WithHistory {
+		lastChanges, err = s.repository.LastChanges(ctx, version, req.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory history: %w", err)
+		}
+	}
+
+	var items []*pb.DirectoryItem
+	for _, entry := range entries {
+		item := &pb.DirectoryItem{
+			Name:    entry.Name,
+			IsDir:   entry.Type == storage.ObjectTypeTree,
+			Size:    entry.Size,
+			ModTime: entry.ModTime,
+			Hash:    string(entry.Hash),
+			Mode:    entry.Mode,
+		}
+		if change, ok := lastChanges[entry.Name]; ok {
+			item.LastVersion = change.Version
+			item.LastCommit = string(change.CommitHash)
+			item.LastAuthor = change.Author
+			item.LastMessage = change.Message
+			item.LastTimestamp = change.Timestamp.Unix()
+		}
+		items = append(items, item)
+	}
+
+	return &pb.ReadDirectoryResponse{
+		Items: items,
+		Hash:  string(dir.Hash),
+	}, nil
+}
+
+func (s *server) ReadFile(ctx context.Context, req *pb.ReadFileRequest) (*pb.ReadFileResponse, error) {
+	log.Printf("Reading file: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+
+	// Read at the requested version, or the current one
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.repository.GetEntry(ctx, version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read file entry", req.Path, version, err)
+	}
+	if req.IfNotHash != "" && req.IfNotHash == string(entry.Hash) && entry.Type == storage.ObjectTypeBlob {
+		return &pb.ReadFileResponse{
+			Hash:        string(entry.Hash),
+			Size:        entry.Size,
+			NotModified: true,
+		}, nil
+	}
+
+	if req.Offset != 0 || req.Length != 0 {
+		return s.readFileRange(ctx, req, version, entry.Hash)
+	}
+
+	// Read from content-addressable storage
+	content, err := s.repository.ReadFile(ctx, version, req.Path)
+	if err != nil {
+		return nil, readError("failed to read file", req.Path, version, err)
+	}
+
+	return &pb.ReadFileResponse{
+		Content: content,
+		Hash:    string(entry.Hash),
+		Size:    int64(len(content)),
+	}, nil
+}
+
+// readFileRange answers a ReadFile for part of a file, reading only that part
+func (s *server) readFileRange(ctx context.Context, req *pb.ReadFileRequest, version int64, hash storage.Hash) (*pb.ReadFileResponse, error) {
+	if req.Offset < 0 {
+		return nil, invalidArgumentError("offset", "must not be negative")
+	}
+	if req.Length < 0 {
+		return nil, invalidArgumentError("length", "must not be negative")
+	}
+
+	content, size, err := s.repository.OpenFileRange(ctx, version, req.Path, req.Offset, req.Length)
+	if err != nil {
+		return nil, readError("failed to read file", req.Path, version, err)
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return &pb.ReadFileResponse{
+		Content: data,
+		Hash:    string(hash),
+		Size:    size,
+		Offset:  min(req.Offset, size),
+	}, nil
+}
+
+func (s *server) GetFileHistory(ctx context.Context, req *pb.FileHistoryRequest) (*pb.FileHistoryResponse, error) {
+	log.Printf("Getting file history for: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+
+	history, err := s.repository.FileHistory(ctx, req.Path, int(req.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file history: %w", err)
+	}
+
+	commits := make([]*pb.Commit, 0, len(history))
+	for _, entry := range history {
+		changedFiles := []string{entry.Change.Path}
+		if entry.Change.OldPath != "" && entry.Change.Type == storage.ChangeRenamed {
+			changedFiles = append(changedFiles, entry.Change.OldPath)
+		}
+
+		commits = append(commits, &pb.Commit{
+			Hash:         string(entry.CommitHash),
+			Author:       entry.Author,
+			Message:      entry.Message,
+			Timestamp:    entry.Timestamp.Unix(),
+			ChangedFiles: changedFiles,
+			Path:         entry.Change.Path,
+			OldPath:      entry.Change.OldPath,
+		})
+	}
+
+	return &pb.FileHistoryResponse{
+		Commits: commits,
+	}, nil
+}
+
+// CreateWorkspace registers a workspace and copies its tracked paths into
+// its repository in an operation, without holding s.mu. With lazy the
+// response comes before the copy is done; without it, after, as older
+// clients expect. Either way it names the operation.
+func (s *server) CreateWorkspace(ctx context.Context, req *pb.CreateWorkspaceRequest) (*pb.CreateWorkspaceResponse, error) {
+	log.Printf("Creating workspace with tracked paths: %v (template: %q)", req.TrackedPaths, req.Template)
+
+	resp, op := s.startWorkspace(ctx, req)
+	if op == nil || req.Lazy {
+		return resp, nil
+	}
+
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+		return resp, nil // The operation goes on without the caller
+	}
+	if record := op.snapshot(); record.Status != storage.OperationSucceeded {
+		s.DeleteWorkspace(context.Background(), &pb.DeleteWorkspaceRequest{WorkspaceId: resp.WorkspaceId})
+		return &pb.CreateWorkspaceResponse{
+			Success:     false,
+			Message:     fmt.Sprintf("Failed to initialize git repository: %s", record.Error),
+			OperationId: resp.OperationId,
+		}, nil
+	}
+	return resp, nil
+}
+
+// startWorkspace checks a CreateWorkspace request and registers the
+// workspace with an empty repository, SYNCING while the operation it starts
+// fills it. It returns the operation when it started one.
+func (s *server) startWorkspace(ctx context.Context, req *pb.CreateWorkspaceRequest) (*pb.CreateWorkspaceResponse, *operation) {
+	requestedPaths, metadata := req.TrackedPaths, req.Metadata
+	if req.Template != "" {
+		var err error
+		requestedPaths, metadata, err = s.applyTemplate(req.Template, req.TrackedPaths, req.Metadata)
+		if err != nil {
+			return &pb.CreateWorkspaceResponse{
+				Success: false,
+				Message: fmt.Sprintf("Cannot create workspace: %v", err),
+			}, nil
+		}
+	}
+
+	// Generate UUID for workspace
+	workspaceID := uuid.New().String()
+
+	name := workspaceID
+	if req.Name != "" {
+		if err := validateWorkspaceName(req.Name); err != nil {
+			return &pb.CreateWorkspaceResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid workspace name: %v", err),
+			}, nil
+		}
+		name = req.Name
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, taken := s.lookupWorkspace(name); taken {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Workspace name %q is already taken", name),
+		}, nil
+	}
+
+	// Enforce quotas before doing any work
+	owner := quotaUser(ctx)
+	if reason := s.checkSizeOverride(owner, req.OverrideSizeLimits); reason != "" {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Permission denied: %s", reason),
+		}, nil
+	}
+	currentVersion, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get current version: %v", err),
+		}, nil
+	}
+	trackedPaths, patterns, err := s.expandTrackedPaths(ctx, currentVersion, nil, requestedPaths)
+	if err != nil {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid tracked path: %v", err),
+		}, nil
+	}
+
+	limits := s.quotas.LimitsFor(owner)
+	var files, size int64
+	if currentVersion > 0 {
+		for _, path := range trackedPaths {
+			pathFiles, pathSize, err := s.pathStats(ctx, currentVersion, path)
+			if err != nil {
+				continue
+			}
+			files += pathFiles
+			size += pathSize
+
+			if req.OverrideSizeLimits {
+				continue
+			}
+			reason, err := s.checkSizePolicy(ctx, limits, currentVersion, path, pathSize)
+			if err != nil {
+				return &pb.CreateWorkspaceResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to check size of %s: %v", path, err),
+				}, nil
+			}
+			if reason != "" {
+				return &pb.CreateWorkspaceResponse{
+					Success: false,
+					Message: fmt.Sprintf("Size limit exceeded: %s", reason),
+				}, nil
+			}
+		}
+	}
+	if reason := s.checkCreateQuota(owner, size); reason != "" {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Quota exceeded: %s", reason),
+		}, nil
+	}
+
+	if currentVersion > 0 {
+		if err := s.checkCaseCollisions(ctx, currentVersion, trackedPaths); err != nil {
+			return &pb.CreateWorkspaceResponse{
+				Success: false,
+				Message: fmt.Sprintf("Cannot create workspace: %v", err),
+			}, nil
+		}
+	}
+
+	if currentVersion == 0 {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: "Failed to initialize git repository: no repository versions exist - cannot create workspace",
+		}, nil
+	}
+
+	// Create workspace directory
+	workspaceDir := filepath.Join(s.workspaceRoot, workspaceID)
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create workspace directory: %v", err),
+		}, nil
+	}
+
+	// The repository starts empty and is filled by the operation
+	gitRepoPath := filepath.Join(workspaceDir, "repo")
+	if err := s.createWorkspaceGitRepo(ctx, gitRepoPath); err != nil {
+		// Clean up on failure
+		os.RemoveAll(workspaceDir)
+		return &pb.CreateWorkspaceResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to initialize git repository: %v", err),
+		}, nil
+	}
+
+	// Create workspace metadata
+	workspace := &Workspace{
+		ID:              workspaceID,
+		Name:            name, // The ID unless a name was given
+		TrackedPaths:    trackedPaths,
+		TrackedPatterns: patterns,
+		CreatedAt:       time.Now(),
+		LastSync:        time.Now(),
+		SyncedVersion:   currentVersion,
+		Status:          pb.WorkspaceStatus_ACTIVE,
+		Metadata:        stripQuotaMetadata(metadata),
+		GitRepoPath:     gitRepoPath,
+		Owner:           owner,
+		BytesStored:     size,
+	}
+
+	workspace.Status = pb.WorkspaceStatus_SYNCING
+	m := s.materialize(workspace, currentVersion, files)
+	// Cancelling the creation deletes the workspace, which stops the copy
+	op := s.operations.start(ctx, &operation{
+		progress: m.progress,
+		cancel: func() {
+			s.DeleteWorkspace(context.Background(), &pb.DeleteWorkspaceRequest{WorkspaceId: workspaceID})
+		},
+		record: storage.Operation{
+			Kind:   operationCreateWorkspace,
+			Owner:  owner,
+			Result: map[string]string{"workspace_id": workspaceID},
+		},
+	})
+	go func() { s.operations.finish(op, nil, m.wait(context.Background())) }()
+
+	s.workspaces[workspaceID] = workspace
+	s.linkWorkspaceName(workspace)
+
+	// Generate remote URL for poon-git server
+	gitServerPort := s.gitServerPort
+	if gitServerPort == "" {
+		gitServerPort = DefaultGitServerPort
+	}
+	remoteURL := fmt.Sprintf("http://localhost:%s/%s.git", gitServerPort, workspaceID)
+
+	log.Printf("Successfully created workspace %s with git repo at %s", workspaceID, gitRepoPath)
+
+	message := fmt.Sprintf("Workspace created successfully with %d tracked paths", len(trackedPaths))
+	if req.Lazy {
+		message = fmt.Sprintf("Workspace created with %d tracked paths; their %d files are being copied", len(trackedPaths), files)
+	}
+	return &pb.CreateWorkspaceResponse{
+		Success:     true,
+		Message:     message,
+		WorkspaceId: workspaceID,
+		RemoteUrl:   remoteURL,
+		OperationId: op.record.ID,
+	}, op
+}
+
+func (s *server) GetWorkspace(ctx context.Context, req *pb.GetWorkspaceRequest) (*pb.GetWorkspaceResponse, error) {
+	log.Printf("Getting workspace: %s", req.WorkspaceId)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.GetWorkspaceResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	return &pb.GetWorkspaceResponse{
+		Success:   true,
+		Message:   "Workspace retrieved successfully",
+		Workspace: workspaceToProto(workspace),
+	}, nil
+}
+
+func (s *server) UpdateWorkspace(ctx context.Context, req *pb.UpdateWorkspaceRequest) (*pb.UpdateWorkspaceResponse, error) {
+	log.Printf("Updating workspace: %s", req.WorkspaceId)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.UpdateWorkspaceResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	if len(req.TrackedPaths) > 0 {
+		workspace.TrackedPaths = req.TrackedPaths
+	}
+	if req.Metadata != nil {
+		// Preserve server-managed quota overrides
+		metadata := stripQuotaMetadata(req.Metadata)
+		for key, value := range workspace.Metadata {
+			if strings.HasPrefix(key, quotaMetadataPrefix) {
+				metadata[key] = value
+			}
+		}
+		workspace.Metadata = metadata
+	}
+	workspace.LastSync = time.Now()
+
+	return &pb.UpdateWorkspaceResponse{
+		Success:   true,
+		Message:   "Workspace updated successfully",
+		Workspace: workspaceToProto(workspace),
+	}, nil
+}
+
+// ReportWorkspaceStatus records what the client last saw in its checkout.
+// A sync or push counts as a sync for staleness; the workspace is diverged
+// when it has uncommitted files or its HEAD differs from the server's copy.
+func (s *server) ReportWorkspaceStatus(ctx context.Context, req *pb.ReportWorkspaceStatusRequest) (*pb.ReportWorkspaceStatusResponse, error) {
+	log.Printf("Workspace %s reported status after %s", req.WorkspaceId, req.Operation)
+
+	if req.DirtyFiles < 0 {
+		return &pb.ReportWorkspaceStatusResponse{
+			Success: false,
+			Message: "Dirty file count must not be negative",
+		}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.ReportWorkspaceStatusResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	now := time.Now()
+	workspace.ClientVersion = req.ClientVersion
+	workspace.HeadCommit = req.HeadCommit
+	workspace.DirtyFiles = req.DirtyFiles
+	workspace.LastReport = now
+	if req.Operation == "sync" || req.Operation == "push" {
+		workspace.LastSync = now
+	}
+
+	workspace.Diverged = req.DirtyFiles > 0
+	if req.HeadCommit != "" && workspace.GitRepoPath != "" {
+		if head, err := gitHead(ctx, workspace.GitRepoPath); err == nil && head != req.HeadCommit {
+			workspace.Diverged = true
+		}
+	}
+
+	return &pb.ReportWorkspaceStatusResponse{
+		Success: true,
+		Message: "Workspace status recorded",
+	}, nil
+}
+
+// workspaceToProto converts workspace metadata to its wire form
+func workspaceToProto(workspace *Workspace) *pb.WorkspaceInfo {
+	info := &pb.WorkspaceInfo{
+		Id:              workspace.ID,
+		Name:            workspace.Name,
+		TrackedPaths:    workspace.TrackedPaths,
+		TrackedPatterns: workspace.TrackedPatterns,
+		CreatedAt:       workspace.CreatedAt.Format(time.RFC3339),
+		LastSync:        workspace.LastSync.Format(time.RFC3339),
+		SyncedVersion:   workspace.SyncedVersion,
+		Status:          workspace.Status,
+		Metadata:        workspace.Metadata,
+		ClientVersion:   workspace.ClientVersion,
+		HeadCommit:      workspace.HeadCommit,
+		DirtyFiles:      workspace.DirtyFiles,
+		Diverged:        workspace.Diverged,
+		Owner:           workspace.Owner,
+		DiskBytes:       workspace.DiskBytes,
+		Commits:         int64(workspace.Commits),
+	}
+	if m := workspace.materializing; m != nil {
+		info.FilesCopied, info.FilesTotal = m.progress()
+		info.StatusMessage = workspace.unmaterialized()
+	}
+	if !workspace.LastReport.IsZero() {
+		info.LastReport = workspace.LastReport.Format(time.RFC3339)
+	}
+	return info
+}
+
+// gitHead returns the commit HEAD points to in a git repository
+func gitHead(ctx context.Context, repoPath string) (string, error) {
+	cmd := gitCommand(ctx, repoPath, "rev-parse", "HEAD")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitBranch returns the branch HEAD is on in a git repository
+func gitBranch(ctx context.Context, repoPath string) (string, error) {
+	cmd := gitCommand(ctx, repoPath, "symbolic-ref", "--short", "HEAD")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *server) DeleteWorkspace(ctx context.Context, req *pb.DeleteWorkspaceRequest) (*pb.DeleteWorkspaceResponse, error) {
+	log.Printf("Deleting workspace: %s", req.WorkspaceId)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.DeleteWorkspaceResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	workspace.stopMaterializing()
+	delete(s.workspaces, workspace.ID)
+	s.unlinkWorkspaceName(workspace)
+
+	return &pb.DeleteWorkspaceResponse{
+		Success: true,
+		Message: "Workspace deleted successfully",
+	}, nil
+}
+
+func (s *server) ConfigureSparseCheckout(ctx context.Context, req *pb.SparseCheckoutRequest) (*pb.SparseCheckoutResponse, error) {
+	log.Printf("Configuring sparse checkout for %d paths", len(req.Paths))
+
+	// TODO: Implement actual sparse checkout configuration
+	// This would involve:
+	// 1. Creating a sparse-checkout file
+	// 2. Configuring git to use sparse checkout
+	// 3. Updating the working directory
+
+	return &pb.SparseCheckoutResponse{
+		Success:         true,
+		Message:         fmt.Sprintf("Sparse checkout configured for %d paths", len(req.Paths)),
+		ConfiguredPaths: req.Paths,
+	}, nil
+}
+
+func (s *server) AddTrackedPath(ctx context.Context, req *pb.AddTrackedPathRequest) (*pb.AddTrackedPathResponse, error) {
+	log.Printf("Adding tracked path %s to workspace %s", req.Path, req.WorkspaceId)
+
+	if err := validatePath(req.Path); err != nil {
+		return &pb.AddTrackedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid path: %v", err),
+		}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.AddTrackedPathResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	// Check if path already exists in tracked paths or patterns
+	for _, tracked := range [][]string{workspace.TrackedPaths, workspace.TrackedPatterns} {
+		for _, trackedPath := range tracked {
+			if trackedPath == req.Path {
+				return &pb.AddTrackedPathResponse{
+					Success: false,
+					Message: fmt.Sprintf("Path %s is already tracked", req.Path),
+				}, nil
+			}
+		}
+	}
+
+	currentVersion, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return &pb.AddTrackedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get current version: %v", err),
+		}, nil
+	}
+
+	var paths, patterns []string
+	if isTrackedPattern(req.Path) {
+		// Only the matches not already in the workspace are added; the
+		// pattern or view is kept so later matches are picked up on sync
+		paths, patterns, err = s.expandTrackedPaths(ctx, currentVersion, workspace, []string{req.Path})
+		if err != nil {
+			return &pb.AddTrackedPathResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid pattern %s: %v", req.Path, err),
+			}, nil
+		}
+	} else {
+		// Check if path exists in monorepo
+		_, err = s.repository.ReadDirectory(ctx, currentVersion, req.Path)
+		if err != nil {
+			// Try as file
+			_, err = s.repository.ReadFile(ctx, currentVersion, req.Path)
+			if err != nil {
+				return &pb.AddTrackedPathResponse{
+					Success: false,
+					Message: fmt.Sprintf("Path %s not found in monorepo: %v", req.Path, err),
+				}, nil
+			}
+		}
+		paths = []string{req.Path}
+	}
+
+	if reason := s.checkSizeOverride(quotaUser(ctx), req.OverrideSizeLimits); reason != "" {
+		return &pb.AddTrackedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Permission denied: %s", reason),
+		}, nil
+	}
+
+	commitMsg := fmt.Sprintf("Add %s to tracked paths", req.Path)
+	if len(patterns) > 0 && len(paths) > 0 {
+		commitMsg += "\n\n" + formatTrackedPaths(paths)
+	}
+	commitHash, err := s.addTrackedPaths(ctx, workspace, currentVersion, paths, patterns, req.OverrideSizeLimits, commitMsg)
+	if err != nil {
+		return &pb.AddTrackedPathResponse{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+	if commitHash == "" {
+		// Still return success, path was already tracked
+		return &pb.AddTrackedPathResponse{
+			Success:    true,
+			Message:    fmt.Sprintf("Path %s was already in workspace", req.Path),
+			NewVersion: currentVersion,
+			AddedPaths: paths,
+		}, nil
+	}
+
+	log.Printf("Successfully added tracked path %s to workspace %s", req.Path, req.WorkspaceId)
+
+	message := fmt.Sprintf("Successfully added %s to workspace", req.Path)
+	if len(patterns) > 0 {
+		message = fmt.Sprintf("Successfully added %s to workspace (%d matching path(s))", req.Path, len(paths))
+	}
+	return &pb.AddTrackedPathResponse{
+		Success:    true,
+		Message:    message,
+		CommitHash: commitHash,
+		NewVersion: currentVersion,
+		AddedPaths: paths,
+	}, nil
+}
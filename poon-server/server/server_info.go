@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// Version is the poon-server release, set at build time with
+// -ldflags "-X github.com/nic/poon/poon-server/server.Version=..."
+var Version = "dev"
+
+// APIVersion is the version of the proto package the server implements
+const APIVersion = "v1"
+
+// DefaultMinClientVersion is the oldest poon-cli release the server supports
+const DefaultMinClientVersion = "1.0.0"
+
+// Optional features advertised by GetServerInfo. Names are never reused for
+// something else; a client checks for the ones it can take advantage of.
+const (
+	FeatureStreamingReads     = "streaming-reads"     // StreamDirectory and StreamFile
+	FeatureConditionalReads   = "conditional-reads"   // if_not_hash on ReadDirectory and ReadFile
+	FeaturePatchPreview       = "patch-preview"       // PreviewPatch
+	FeatureZstdCompression    = "zstd-compression"    // Requests and responses compressed with zstd
+	FeatureMergeQueue         = "merge-queue"         // MergePatch queues patches; only when configured
+	FeatureBatchReads         = "batch-reads"         // ReadFiles
+	FeatureTreeHashes         = "tree-hashes"         // GetTreeHash
+	FeatureWorkspaceArchive   = "workspace-archive"   // StreamWorkspaceArchive
+	FeatureTemplates          = "workspace-templates" // ListTemplates and CreateWorkspace templates
+	FeatureViews              = "path-views"          // ListViews and "view:<name>" tracked paths
+	FeatureComposition        = "composed-workspaces" // "workspace:<id-or-name>" tracked paths
+	FeatureAttributes         = "path-attributes"     // .poonattributes for patches, workspaces and archives
+	FeatureRangeReads         = "range-reads"         // offset and length on ReadFile
+	FeatureFilePreview        = "file-preview"        // PreviewFile
+	FeatureTags               = "tags"                // ListTags
+	FeatureActivity           = "activity"            // GetActivity
+	FeatureOperations         = "operations"          // GetOperation, WaitOperation and operation_id from lazy CreateWorkspace
+	FeatureBranches           = "branches"            // CreateBranch, MergeBranches, CherryPick and branch on MergePatch
+	FeatureRenderedDocs       = "rendered-docs"       // GetRenderedDoc
+	FeatureSymbols            = "symbols"             // SearchSymbols and GoToDefinition; only when enabled
+	FeatureEditor             = "workspace-editor"    // OpenWorkspaceFile, ListWorkspaceSiblings and FetchWorkspaceDependencies
+	FeatureStreamingDownloads = "streaming-downloads" // StreamDownloadPath
+)
+
+// Authentication modes advertised by GetServerInfo
+const (
+	AuthModeNone   = "none"   // Calls without credentials are accepted
+	AuthModeBearer = "bearer" // Calls carry a personal access token
+)
+
+func (s *server) GetServerInfo(ctx context.Context, req *pb.GetServerInfoRequest) (*pb.GetServerInfoResponse, error) {
+	log.Printf("GetServerInfo request from client version %q", req.ClientVersion)
+
+	features := []string{FeatureStreamingReads, FeatureConditionalReads, FeaturePatchPreview, FeatureZstdCompression, FeatureBatchReads, FeatureTreeHashes, FeatureWorkspaceArchive, FeatureTemplates, FeatureViews, FeatureComposition, FeatureAttributes, FeatureRangeReads, FeatureFilePreview, FeatureTags, FeatureActivity, FeatureOperations, FeatureBranches, FeatureRenderedDocs, FeatureEditor, FeatureStreamingDownloads}
+	if s.mergeQueue != nil {
+		features = append(features, FeatureMergeQueue)
+	}
+	if s.symbols != nil {
+		features = append(features, FeatureSymbols)
+	}
+
+	authModes := []string{AuthModeNone}
+	if s.auth.Enabled() {
+		authModes = []string{AuthModeBearer}
+	}
+
+	minClientVersion := s.minClientVersion
+	if minClientVersion == "" {
+		minClientVersion = DefaultMinClientVersion
+	}
+
+	resp := &pb.GetServerInfoResponse{
+		ServerVersion:    Version,
+		ApiVersion:       APIVersion,
+		MinClientVersion: minClientVersion,
+		Features:         features,
+		AuthModes:        authModes,
+		WebUrl:           s.webURL,
+	}
+	if s.repository != nil {
+		resp.HashAlgorithm = string(s.repository.HashAlgorithm())
+		resp.HashFormat = s.repository.HashFormat().String()
+	}
+	return resp, nil
+}
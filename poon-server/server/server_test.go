@@ -0,0 +1,6436 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nic/poon/poon-git/gitserver"
+	"github.com/nic/poon/poon-git/subprocess"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+	"github.com/nic/poon/poon-server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServerImplementation(t *testing.T) {
+	// Test the server implementation directly without gRPC serialization
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+
+	t.Run("Server Initialization", func(t *testing.T) {
+		assert.Equal(t, repoRoot, srv.repoRoot)
+	})
+
+	t.Run("Repository Structure", func(t *testing.T) {
+		// Verify test repository was created correctly
+		entries, err := os.ReadDir(repoRoot)
+		require.NoError(t, err)
+
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+
+		assert.Contains(t, names, "src")
+		assert.Contains(t, names, "docs")
+		assert.Contains(t, names, "config")
+	})
+
+	t.Run("File Access", func(t *testing.T) {
+		// Test file system access that the server would use
+		readmePath := filepath.Join(repoRoot, "docs/README.md")
+		content, err := os.ReadFile(readmePath)
+		require.NoError(t, err)
+
+		contentStr := string(content)
+		assert.Contains(t, contentStr, "Poon Monorepo Documentation")
+		assert.Contains(t, contentStr, "Structure")
+	})
+
+	t.Run("Directory Listing", func(t *testing.T) {
+		// Test directory listing that server would perform
+		srcPath := filepath.Join(repoRoot, "src")
+		entries, err := os.ReadDir(srcPath)
+		require.NoError(t, err)
+
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+
+		assert.Contains(t, names, "frontend")
+		assert.Contains(t, names, "backend")
+	})
+
+	t.Run("Nonexistent Path Handling", func(t *testing.T) {
+		// Test error handling for nonexistent paths
+		nonexistentPath := filepath.Join(repoRoot, "nonexistent/path")
+		_, err := os.ReadDir(nonexistentPath)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no such file or directory")
+	})
+}
+
+func TestServerResilience(t *testing.T) {
+	repoRoot := createTestRepo(t)
+
+	t.Run("Multiple Server Instances", func(t *testing.T) {
+		// Test that we can create multiple server instances
+		for i := 0; i < 3; i++ {
+			t.Logf("Creating server instance %d", i+1)
+
+			backend := storage.NewMemoryBackend()
+			repository := storage.NewRepository(backend)
+			srv := &server{
+				repoRoot:   repoRoot,
+				repository: repository,
+			}
+
+			// Verify server can access repository
+			entries, err := os.ReadDir(srv.repoRoot)
+			require.NoError(t, err)
+			assert.Greater(t, len(entries), 0)
+		}
+	})
+
+	t.Run("Concurrent File System Access", func(t *testing.T) {
+		backend := storage.NewMemoryBackend()
+		repository := storage.NewRepository(backend)
+		srv := &server{
+			repoRoot:   repoRoot,
+			repository: repository,
+		}
+
+		// Launch multiple concurrent file system operations
+		done := make(chan bool, 5)
+		for i := 0; i < 5; i++ {
+			go func(id int) {
+				defer func() { done <- true }()
+
+				// Perform file system operations that the server would do
+				_, err := os.ReadDir(srv.repoRoot)
+				if err != nil {
+					t.Logf("Concurrent operation %d failed: %v", id, err)
+				}
+
+				// Try to read a file
+				readmePath := filepath.Join(srv.repoRoot, "docs/README.md")
+				_, err = os.ReadFile(readmePath)
+				if err != nil {
+					t.Logf("Concurrent file read %d failed: %v", id, err)
+				}
+			}(i)
+		}
+
+		// Wait for all operations to complete
+		for i := 0; i < 5; i++ {
+			select {
+			case <-done:
+				// Operation completed
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timeout waiting for concurrent operations")
+			}
+		}
+	})
+
+	t.Run("Backend Unavailable", func(t *testing.T) {
+		interceptor := unavailableUnaryInterceptor()
+		call := func(err error) error {
+			_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/monorepo.MonorepoService/ReadFile"},
+				func(ctx context.Context, req interface{}) (interface{}, error) { return nil, err })
+			return err
+		}
+
+		// Handlers wrap storage errors, which are classified by their chain
+		backendErr := fmt.Errorf("%w: get objects/ab timed out after 10s", storage.ErrBackendUnavailable)
+		err := call(fmt.Errorf("failed to read file: %w", backendErr))
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "timed out")
+		require.NotNil(t, failureInfo(err))
+		assert.Equal(t, ReasonBackendUnavailable, failureInfo(err).Reason)
+		assert.Equal(t, codes.Unavailable, status.Code(call(backendErr)))
+		assert.Equal(t, codes.Unavailable, status.Code(call(internalError(backendErr, "failed to read file: %v", backendErr))))
+		assert.Equal(t, codes.Unavailable, status.Code(call(readError("failed to read file", "a.txt", 1, backendErr))))
+
+		// Other errors pass through untouched, whatever their message says
+		other := internalError(errors.New("corrupt object"), "failed to read file: corrupt object")
+		assert.Equal(t, codes.Internal, status.Code(other))
+		assert.Equal(t, other, call(other))
+		mention := status.Error(codes.Internal, "failed to read file: "+storage.ErrBackendUnavailable.Error())
+		assert.Equal(t, mention, call(mention))
+		assert.NoError(t, call(nil))
+	})
+}
+
+func TestReadFileEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+
+	// Create initial repository version from filesystem
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	t.Run("Read Existing File", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "docs/README.md",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Content)
+
+		content := string(resp.Content)
+		assert.Contains(t, content, "Poon Monorepo Documentation")
+		assert.Contains(t, content, "Structure")
+	})
+
+	t.Run("Read Range", func(t *testing.T) {
+		whole, err := srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/README.md"})
+		require.NoError(t, err)
+
+		resp, err := srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/README.md", Offset: 2, Length: 4})
+		require.NoError(t, err)
+		assert.Equal(t, whole.Content[2:6], resp.Content)
+		assert.Equal(t, whole.Size, resp.Size)
+		assert.Equal(t, int64(2), resp.Offset)
+		assert.Equal(t, whole.Hash, resp.Hash)
+
+		resp, err = srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/README.md", Offset: whole.Size - 3})
+		require.NoError(t, err)
+		assert.Equal(t, whole.Content[whole.Size-3:], resp.Content)
+
+		resp, err = srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/README.md", Offset: whole.Size + 10})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Content)
+		assert.Equal(t, whole.Size, resp.Offset)
+
+		for field, req := range map[string]*pb.ReadFileRequest{
+			"offset": {Path: "docs/README.md", Offset: -1, Length: 4},
+			"length": {Path: "docs/README.md", Length: -1},
+		} {
+			_, err = srv.ReadFile(context.Background(), req)
+			require.Error(t, err)
+			st := status.Convert(err)
+			assert.Equal(t, codes.InvalidArgument, st.Code())
+			require.Len(t, st.Details(), 2)
+			assert.Equal(t, ReasonInvalidArgument, st.Details()[0].(*errdetails.ErrorInfo).Reason)
+			assert.Equal(t, field, st.Details()[1].(*errdetails.BadRequest).FieldViolations[0].Field)
+		}
+	})
+
+	t.Run("Read Frontend JavaScript File", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "src/frontend/app.js",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Content)
+
+		content := string(resp.Content)
+		assert.Contains(t, content, "Sample frontend application")
+		assert.Contains(t, content, "console.log")
+	})
+
+	t.Run("Read Backend Go File", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "src/backend/server.go",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Content)
+
+		content := string(resp.Content)
+		assert.Contains(t, content, "package main")
+		assert.Contains(t, content, "Hello from backend")
+	})
+
+	t.Run("Read Config YAML File", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "config/app.yaml",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Content)
+
+		content := string(resp.Content)
+		assert.Contains(t, content, "environment: test")
+		assert.Contains(t, content, "services:")
+	})
+
+	t.Run("Read Nonexistent File", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "nonexistent/file.txt",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Contains(t, err.Error(), "failed to read file")
+	})
+
+	t.Run("Read File with Empty Path", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("Read File with Invalid Path", func(t *testing.T) {
+		req := &pb.ReadFileRequest{
+			Path: "../../../etc/passwd",
+		}
+
+		resp, err := srv.ReadFile(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Contains(t, err.Error(), "invalid path")
+	})
+
+	t.Run("Conditional Read", func(t *testing.T) {
+		ctx := context.Background()
+		first, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml"})
+		require.NoError(t, err)
+		assert.False(t, first.NotModified)
+
+		resp, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml", IfNotHash: first.Hash})
+		require.NoError(t, err)
+		assert.True(t, resp.NotModified)
+		assert.Equal(t, first.Hash, resp.Hash)
+		assert.Equal(t, first.Size, resp.Size)
+		assert.Empty(t, resp.Content)
+
+		// A stale hash gets the current content
+		resp, err = srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "docs/README.md", IfNotHash: first.Hash})
+		require.NoError(t, err)
+		assert.False(t, resp.NotModified)
+		assert.Contains(t, string(resp.Content), "Poon Monorepo Documentation")
+	})
+
+	t.Run("Read At Version", func(t *testing.T) {
+		ctx := context.Background()
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "config", "app.yaml"), []byte("environment: staging\n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, "src", "mobile"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src", "mobile", "app.kt"), []byte("fun main() {}\n"), 0644))
+		_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Update config")
+		require.NoError(t, err)
+
+		resp, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml", Version: 1})
+		require.NoError(t, err)
+		assert.Contains(t, string(resp.Content), "environment: test")
+
+		resp, err = srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, "environment: staging\n", string(resp.Content))
+
+		dir, err := srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src", Version: 1})
+		require.NoError(t, err)
+		assert.Len(t, dir.Items, 2)
+
+		_, err = srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml", Version: 99})
+		assert.Error(t, err)
+	})
+}
+
+func TestReadDirectoryEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+
+	// Create initial repository version from filesystem
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	t.Run("Read Root Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Items)
+
+		itemNames := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			itemNames[i] = item.Name
+		}
+
+		assert.Contains(t, itemNames, "src")
+		assert.Contains(t, itemNames, "docs")
+		assert.Contains(t, itemNames, "config")
+
+		for _, item := range resp.Items {
+			if item.Name == "src" || item.Name == "docs" || item.Name == "config" {
+				assert.True(t, item.IsDir, "Directory %s should be marked as directory", item.Name)
+			}
+		}
+	})
+
+	t.Run("Read Src Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "src",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Items)
+
+		itemNames := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			itemNames[i] = item.Name
+		}
+
+		assert.Contains(t, itemNames, "frontend")
+		assert.Contains(t, itemNames, "backend")
+
+		for _, item := range resp.Items {
+			assert.True(t, item.IsDir, "Item %s should be marked as directory", item.Name)
+		}
+	})
+
+	t.Run("Read Frontend Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "src/frontend",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Items)
+
+		itemNames := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			itemNames[i] = item.Name
+		}
+
+		assert.Contains(t, itemNames, "app.js")
+
+		for _, item := range resp.Items {
+			if item.Name == "app.js" {
+				assert.False(t, item.IsDir, "File %s should not be marked as directory", item.Name)
+				assert.Greater(t, item.Size, int64(0), "File size should be greater than 0")
+				assert.Greater(t, item.ModTime, int64(0), "ModTime should be set")
+			}
+		}
+	})
+
+	t.Run("Read Backend Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "src/backend",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Items)
+
+		itemNames := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			itemNames[i] = item.Name
+		}
+
+		assert.Contains(t, itemNames, "server.go")
+
+		for _, item := range resp.Items {
+			if item.Name == "server.go" {
+				assert.False(t, item.IsDir, "File %s should not be marked as directory", item.Name)
+				assert.Greater(t, item.Size, int64(0), "File size should be greater than 0")
+				assert.Greater(t, item.ModTime, int64(0), "ModTime should be set")
+			}
+		}
+	})
+
+	t.Run("Read Docs Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "docs",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Items)
+
+		itemNames := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			itemNames[i] = item.Name
+		}
+
+		assert.Contains(t, itemNames, "README.md")
+
+		for _, item := range resp.Items {
+			if item.Name == "README.md" {
+				assert.False(t, item.IsDir, "File %s should not be marked as directory", item.Name)
+				assert.Greater(t, item.Size, int64(0), "File size should be greater than 0")
+				assert.Greater(t, item.ModTime, int64(0), "ModTime should be set")
+			}
+		}
+	})
+
+	t.Run("Read Config Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "config",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Items)
+
+		itemNames := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			itemNames[i] = item.Name
+		}
+
+		assert.Contains(t, itemNames, "app.yaml")
+
+		for _, item := range resp.Items {
+			if item.Name == "app.yaml" {
+				assert.False(t, item.IsDir, "File %s should not be marked as directory", item.Name)
+				assert.Greater(t, item.Size, int64(0), "File size should be greater than 0")
+				assert.Greater(t, item.ModTime, int64(0), "ModTime should be set")
+			}
+		}
+	})
+
+	t.Run("Read Nonexistent Directory", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "nonexistent/directory",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Contains(t, err.Error(), "failed to read directory")
+	})
+
+	t.Run("Read Directory with Invalid Path", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "../../../etc",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Contains(t, err.Error(), "invalid path")
+	})
+
+	t.Run("Verify File Metadata Accuracy", func(t *testing.T) {
+		req := &pb.ReadDirectoryRequest{
+			Path: "docs",
+		}
+
+		resp, err := srv.ReadDirectory(context.Background(), req)
+		require.NoError(t, err)
+
+		for _, item := range resp.Items {
+			if item.Name == "README.md" {
+				fullPath := filepath.Join(repoRoot, "docs", "README.md")
+				info, err := os.Stat(fullPath)
+				require.NoError(t, err)
+
+				assert.Equal(t, info.Size(), item.Size)
+				assert.Equal(t, info.ModTime().Unix(), item.ModTime)
+				assert.False(t, item.IsDir)
+			}
+		}
+	})
+
+	t.Run("With History", func(t *testing.T) {
+		patch := []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Poon Monorepo Documentation\n+# Poon Docs\n")
+		_, err := repository.ApplyPatch(context.Background(), patch, "alice", "Rename docs title")
+		require.NoError(t, err)
+
+		resp, err := srv.ReadDirectory(context.Background(), &pb.ReadDirectoryRequest{Path: "", WithHistory: true})
+		require.NoError(t, err)
+
+		items := make(map[string]*pb.DirectoryItem)
+		for _, item := range resp.Items {
+			items[item.Name] = item
+		}
+		require.Contains(t, items, "docs")
+		assert.Equal(t, int64(2), items["docs"].LastVersion)
+		assert.Equal(t, "alice", items["docs"].LastAuthor)
+		assert.Equal(t, "Rename docs title", items["docs"].LastMessage)
+		assert.NotEmpty(t, items["docs"].LastCommit)
+		require.Contains(t, items, "src")
+		assert.Equal(t, int64(1), items["src"].LastVersion)
+		assert.Equal(t, "test@example.com", items["src"].LastAuthor)
+
+		// History is only computed on request
+		resp, err = srv.ReadDirectory(context.Background(), &pb.ReadDirectoryRequest{Path: ""})
+		require.NoError(t, err)
+		for _, item := range resp.Items {
+			assert.Zero(t, item.LastVersion)
+		}
+	})
+
+	t.Run("Conditional Read", func(t *testing.T) {
+		ctx := context.Background()
+		first, err := srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src"})
+		require.NoError(t, err)
+		require.NotEmpty(t, first.Hash)
+		assert.False(t, first.NotModified)
+
+		resp, err := srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src", IfNotHash: first.Hash})
+		require.NoError(t, err)
+		assert.True(t, resp.NotModified)
+		assert.Equal(t, first.Hash, resp.Hash)
+		assert.Empty(t, resp.Items)
+
+		// Changing a file below the directory changes its hash
+		patch := []byte("--- a/src/backend/server.go\n+++ b/src/backend/server.go\n@@ -1,1 +1,1 @@\n-package main\n+package server\n")
+		_, err = repository.ApplyPatch(ctx, patch, "alice", "Rename package")
+		require.NoError(t, err)
+
+		resp, err = srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src", IfNotHash: first.Hash})
+		require.NoError(t, err)
+		assert.False(t, resp.NotModified)
+		assert.NotEqual(t, first.Hash, resp.Hash)
+		assert.Len(t, resp.Items, len(first.Items))
+
+		// History can change while the tree does not, so it is always sent
+		current, err := srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src"})
+		require.NoError(t, err)
+		resp, err = srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src", IfNotHash: current.Hash, WithHistory: true})
+		require.NoError(t, err)
+		assert.False(t, resp.NotModified)
+		assert.NotEmpty(t, resp.Items)
+
+		// A file's hash never matches a directory read
+		file, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "src/frontend/app.js"})
+		require.NoError(t, err)
+		_, err = srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "src/frontend/app.js", IfNotHash: file.Hash})
+		assert.Error(t, err)
+	})
+}
+
+func TestFileHistoryEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	_, err = srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+		Path:    "src/frontend/app.js",
+		Patch:   []byte("--- a/src/frontend/app.js\n+++ b/src/frontend/app.js\n@@ -1,2 +1,2 @@\n-// Sample frontend application\n+// Frontend\n console.log(\"Hello from frontend\");\n"),
+		Message: "Shorten comment",
+		Author:  "dev@example.com",
+	})
+	require.NoError(t, err)
+
+	t.Run("Changed File", func(t *testing.T) {
+		resp, err := srv.GetFileHistory(context.Background(), &pb.FileHistoryRequest{Path: "src/frontend/app.js"})
+		require.NoError(t, err)
+		require.Len(t, resp.Commits, 2)
+
+		assert.Equal(t, "Shorten comment", resp.Commits[0].Message)
+		assert.Equal(t, "dev@example.com", resp.Commits[0].Author)
+		assert.Equal(t, "src/frontend/app.js", resp.Commits[0].Path)
+		assert.NotEmpty(t, resp.Commits[0].Hash)
+		assert.Equal(t, "Initial commit", resp.Commits[1].Message)
+	})
+
+	t.Run("Unchanged File", func(t *testing.T) {
+		resp, err := srv.GetFileHistory(context.Background(), &pb.FileHistoryRequest{Path: "docs/README.md", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, resp.Commits, 1)
+		assert.Equal(t, "Initial commit", resp.Commits[0].Message)
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := srv.GetFileHistory(context.Background(), &pb.FileHistoryRequest{Path: "does/not/exist.txt"})
+		assert.Error(t, err)
+	})
+}
+
+func TestPathValidation(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+
+	// Create initial repository version from filesystem for valid path tests
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	t.Run("Path with Double Dots - ReadFile", func(t *testing.T) {
+		testCases := []string{
+			"../etc/passwd",
+			"../../etc/passwd",
+			"src/../../../etc/passwd",
+			"docs/../config/../../../etc/passwd",
+			"config/app.yaml/../../../etc/passwd",
+		}
+
+		for _, path := range testCases {
+			t.Run(fmt.Sprintf("Path: %s", path), func(t *testing.T) {
+				req := &pb.ReadFileRequest{Path: path}
+				resp, err := srv.ReadFile(context.Background(), req)
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Contains(t, err.Error(), "path traversal not allowed")
+			})
+		}
+	})
+
+	t.Run("Path with Double Dots - ReadDirectory", func(t *testing.T) {
+		testCases := []string{
+			"../etc",
+			"../../etc",
+			"src/../../../etc",
+			"docs/../config/../../../etc",
+		}
+
+		for _, path := range testCases {
+			t.Run(fmt.Sprintf("Path: %s", path), func(t *testing.T) {
+				req := &pb.ReadDirectoryRequest{Path: path}
+				resp, err := srv.ReadDirectory(context.Background(), req)
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Contains(t, err.Error(), "path traversal not allowed")
+			})
+		}
+	})
+
+	t.Run("Absolute Paths - ReadFile", func(t *testing.T) {
+		testCases := []string{
+			"/etc/passwd",
+			"/usr/bin/sh",
+			"/home/user/.ssh/id_rsa",
+		}
+
+		for _, path := range testCases {
+			t.Run(fmt.Sprintf("Path: %s", path), func(t *testing.T) {
+				req := &pb.ReadFileRequest{Path: path}
+				resp, err := srv.ReadFile(context.Background(), req)
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Contains(t, err.Error(), "invalid path")
+			})
+		}
+	})
+
+	t.Run("Absolute Paths - ReadDirectory", func(t *testing.T) {
+		testCases := []string{
+			"/etc",
+			"/usr/bin",
+			"/home/user",
+		}
+
+		for _, path := range testCases {
+			t.Run(fmt.Sprintf("Path: %s", path), func(t *testing.T) {
+				req := &pb.ReadDirectoryRequest{Path: path}
+				resp, err := srv.ReadDirectory(context.Background(), req)
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Contains(t, err.Error(), "invalid path")
+			})
+		}
+	})
+
+	t.Run("Valid Relative Paths - ReadFile", func(t *testing.T) {
+		testCases := []string{
+			"docs/README.md",
+			"src/frontend/app.js",
+			"config/app.yaml",
+		}
+
+		for _, path := range testCases {
+			t.Run(fmt.Sprintf("Path: %s", path), func(t *testing.T) {
+				req := &pb.ReadFileRequest{Path: path}
+				resp, err := srv.ReadFile(context.Background(), req)
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+				assert.NotEmpty(t, resp.Content)
+			})
+		}
+	})
+
+	t.Run("Valid Relative Paths - ReadDirectory", func(t *testing.T) {
+		testCases := []string{
+			"",
+			"src",
+			"docs",
+			"config",
+			"src/frontend",
+		}
+
+		for _, path := range testCases {
+			t.Run(fmt.Sprintf("Path: %s", path), func(t *testing.T) {
+				req := &pb.ReadDirectoryRequest{Path: path}
+				resp, err := srv.ReadDirectory(context.Background(), req)
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+				assert.NotEmpty(t, resp.Items)
+			})
+		}
+	})
+
+	t.Run("Edge Cases - Clean Path Resolution", func(t *testing.T) {
+		testCases := []struct {
+			path        string
+			shouldError bool
+			description string
+		}{
+			{"./docs/README.md", false, "Current directory prefix"},
+			{"docs/./README.md", false, "Current directory in middle"},
+			{"docs/../docs/README.md", true, "Parent directory reference"},
+			{"docs/README.md", false, "Valid file path"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.description, func(t *testing.T) {
+				req := &pb.ReadFileRequest{Path: tc.path}
+				resp, err := srv.ReadFile(context.Background(), req)
+
+				if tc.shouldError {
+					assert.Error(t, err)
+					assert.Nil(t, resp)
+				} else {
+					assert.NoError(t, err)
+					assert.NotNil(t, resp)
+				}
+			})
+		}
+	})
+}
+
+func TestMergePatchEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+
+	// Create initial repository version from filesystem for patch tests
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	t.Run("Empty Patch Data", func(t *testing.T) {
+		req := &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Patch:   []byte{},
+			Message: "Test patch",
+			Author:  "test@example.com",
+		}
+
+		resp, err := srv.MergePatch(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Patch data is empty")
+	})
+
+	t.Run("Invalid Path", func(t *testing.T) {
+		req := &pb.MergePatchRequest{
+			Path:    "../../../etc/passwd",
+			Patch:   []byte("--- a/test.txt\n+++ b/test.txt\n@@ -1,1 +1,1 @@\n-old\n+new\n"),
+			Message: "Test patch",
+			Author:  "test@example.com",
+		}
+
+		resp, err := srv.MergePatch(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Invalid path")
+	})
+
+	t.Run("Invalid Patch Format", func(t *testing.T) {
+		req := &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Patch:   []byte("not a valid patch"),
+			Message: "Test patch",
+			Author:  "test@example.com",
+		}
+
+		resp, err := srv.MergePatch(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Failed to apply patch")
+	})
+
+	t.Run("Apply Simple Patch to Existing File", func(t *testing.T) {
+		patch := `--- a/docs/README.md
++++ b/docs/README.md
+@@ -1,4 +1,5 @@
+ # Poon Monorepo Documentation
+ 
++This line was added by patch.
+ This is a sample monorepo for testing.
+ 
+`
+
+		req := &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Patch:   []byte(patch),
+			Message: "Add line to README",
+			Author:  "test@example.com",
+		}
+
+		resp, err := srv.MergePatch(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+		assert.Contains(t, resp.Message, "successfully")
+		assert.NotEmpty(t, resp.CommitHash)
+
+		require.NotNil(t, resp.Stats)
+		assert.Equal(t, int32(1), resp.Stats.FilesChanged)
+		// The fixture has no final newline, which the applied patch adds, so
+		// the last line counts as changed too
+		assert.Equal(t, int32(2), resp.Stats.Insertions)
+		assert.Equal(t, int32(1), resp.Stats.Deletions)
+		require.Len(t, resp.Stats.Files, 1)
+		assert.Equal(t, "docs/README.md", resp.Stats.Files[0].Path)
+		assert.Equal(t, "modified", resp.Stats.Files[0].Status)
+
+		// Read the patched content from the repository (not filesystem)
+		fileResp, err := srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/README.md"})
+		require.NoError(t, err)
+		assert.Contains(t, string(fileResp.Content), "This line was added by patch.")
+	})
+
+	t.Run("Apply Patch to Create New File", func(t *testing.T) {
+		patch := `--- /dev/null
++++ b/docs/NEW_FILE.md
+@@ -0,0 +1,3 @@
++# New File
++
++This file was created by a patch.
+`
+
+		req := &pb.MergePatchRequest{
+			Path:    "docs/NEW_FILE.md",
+			Patch:   []byte(patch),
+			Message: "Create new file",
+			Author:  "test@example.com",
+		}
+
+		resp, err := srv.MergePatch(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+		assert.Contains(t, resp.Message, "successfully")
+
+		// Read the new file from the repository (not filesystem)
+		fileResp, err := srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/NEW_FILE.md"})
+		require.NoError(t, err)
+		assert.Contains(t, string(fileResp.Content), "This file was created by a patch.")
+	})
+
+	t.Run("Invalid Target File in Patch", func(t *testing.T) {
+		patch := `--- a/docs/README.md
++++ b/../../../etc/passwd
+@@ -1,1 +1,1 @@
+-old
++new
+`
+
+		req := &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Patch:   []byte(patch),
+			Message: "Malicious patch",
+			Author:  "test@example.com",
+		}
+
+		resp, err := srv.MergePatch(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "path traversal not allowed")
+	})
+
+	t.Run("Apply Options", func(t *testing.T) {
+		mergePatch := func(patch string, req *pb.MergePatchRequest) *pb.MergePatchResponse {
+			req.Path, req.Patch, req.Message, req.Author = "docs/crlf.txt", []byte(patch), "CRLF file", "test@example.com"
+			resp, err := srv.MergePatch(context.Background(), req)
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp := mergePatch("--- /dev/null\n+++ b/docs/crlf.txt\n@@ -0,0 +1,2 @@\n+one\r\n+two\r\n", &pb.MergePatchRequest{})
+		require.True(t, resp.Success, resp.Message)
+
+		// A patch made with LF line endings only applies when normalizing
+		lfPatch := "--- a/docs/crlf.txt\n+++ b/docs/crlf.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+three\n\\ No newline at end of file\n"
+		resp = mergePatch(lfPatch, &pb.MergePatchRequest{})
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "does not match file content")
+
+		resp = mergePatch(lfPatch, &pb.MergePatchRequest{NormalizeLineEndings: true, PreserveTrailingNewline: true})
+		require.True(t, resp.Success, resp.Message)
+
+		fileResp, err := srv.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "docs/crlf.txt"})
+		require.NoError(t, err)
+		assert.Equal(t, "one\r\nthree", string(fileResp.Content))
+	})
+
+	t.Run("Expected Version", func(t *testing.T) {
+		ctx := context.Background()
+		current, err := repository.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		require.Greater(t, current, int64(1))
+
+		// The README changed after version 1; the config did not
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:            "docs/README.md",
+			Patch:           []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Poon Monorepo Documentation\n+# Documentation\n"),
+			Message:         "Stale patch",
+			ExpectedVersion: 1,
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, ReasonPathChanged, resp.Failure.GetReason())
+		assert.Equal(t, "docs/README.md", resp.Failure.Metadata["path"])
+
+		resp, err = srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:            "config/app.yaml",
+			Patch:           []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1,1 +1,1 @@\n-environment: test\n+environment: staging\n"),
+			Message:         "Patch against an old version",
+			ExpectedVersion: 1,
+		})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+
+		_, err = srv.MergePatch(ctx, &pb.MergePatchRequest{Path: "config/app.yaml", Patch: []byte("x"), ExpectedVersion: current + 100})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		resp, err = srv.MergePatch(ctx, &pb.MergePatchRequest{Path: "config/app.yaml", Patch: []byte("x"), Branch: "feature", ExpectedVersion: 1})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	auth := NewAuthenticator(map[string]string{"secret-token": "alice"})
+	interceptor := auth.UnaryInterceptor()
+	srv := &server{auth: auth}
+	info := &grpc.UnaryServerInfo{FullMethod: "/monorepo.MonorepoService/WhoAmI"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.WhoAmI(ctx, req.(*pb.WhoAmIRequest))
+	}
+
+	t.Run("Missing Credentials", func(t *testing.T) {
+		_, err := interceptor(context.Background(), &pb.WhoAmIRequest{}, info, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer wrong"))
+		_, err := interceptor(ctx, &pb.WhoAmIRequest{}, info, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Valid Token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret-token"))
+		resp, err := interceptor(ctx, &pb.WhoAmIRequest{}, info, handler)
+		require.NoError(t, err)
+		whoami := resp.(*pb.WhoAmIResponse)
+		assert.Equal(t, "alice", whoami.User)
+		assert.True(t, whoami.Authenticated)
+		assert.True(t, whoami.AuthRequired)
+	})
+
+	t.Run("Auth Disabled", func(t *testing.T) {
+		var disabled *Authenticator
+		srv := &server{}
+		resp, err := disabled.UnaryInterceptor()(context.Background(), &pb.WhoAmIRequest{}, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.WhoAmI(ctx, req.(*pb.WhoAmIRequest))
+			})
+		require.NoError(t, err)
+		whoami := resp.(*pb.WhoAmIResponse)
+		assert.False(t, whoami.Authenticated)
+		assert.False(t, whoami.AuthRequired)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		streamInterceptor := auth.StreamInterceptor()
+		streamInfo := &grpc.StreamServerInfo{FullMethod: "/monorepo.MonorepoService/StreamFile"}
+		var user string
+		streamHandler := func(srv interface{}, ss grpc.ServerStream) error {
+			user = userFromContext(ss.Context())
+			return nil
+		}
+
+		err := streamInterceptor(nil, &streamRecorder[pb.FileChunk]{ctx: context.Background()}, streamInfo, streamHandler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret-token"))
+		err = streamInterceptor(nil, &streamRecorder[pb.FileChunk]{ctx: ctx}, streamInfo, streamHandler)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user)
+	})
+}
+
+func TestGetServerInfo(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		srv := &server{}
+		resp, err := srv.GetServerInfo(context.Background(), &pb.GetServerInfoRequest{ClientVersion: "1.0.0"})
+		require.NoError(t, err)
+		assert.Equal(t, Version, resp.ServerVersion)
+		assert.Equal(t, "v1", resp.ApiVersion)
+		assert.Equal(t, DefaultMinClientVersion, resp.MinClientVersion)
+		assert.Contains(t, resp.Features, FeatureStreamingReads)
+		assert.NotContains(t, resp.Features, FeatureMergeQueue)
+		assert.Equal(t, []string{AuthModeNone}, resp.AuthModes)
+	})
+
+	t.Run("Configured Server", func(t *testing.T) {
+		srv := &server{
+			auth:             NewAuthenticator(map[string]string{"secret-token": "alice"}),
+			mergeQueue:       &MergeQueue{},
+			minClientVersion: "2.1.0",
+			webURL:           "https://poon.example.com",
+		}
+		resp, err := srv.GetServerInfo(context.Background(), &pb.GetServerInfoRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "2.1.0", resp.MinClientVersion)
+		assert.Equal(t, "https://poon.example.com", resp.WebUrl)
+		assert.Contains(t, resp.Features, FeatureMergeQueue)
+		assert.Equal(t, []string{AuthModeBearer}, resp.AuthModes)
+	})
+
+	t.Run("No Credentials Needed", func(t *testing.T) {
+		srv := &server{auth: NewAuthenticator(map[string]string{"secret-token": "alice"})}
+		info := &grpc.UnaryServerInfo{FullMethod: pb.MonorepoService_GetServerInfo_FullMethodName}
+		resp, err := srv.auth.UnaryInterceptor()(context.Background(), &pb.GetServerInfoRequest{}, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.GetServerInfo(ctx, req.(*pb.GetServerInfoRequest))
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []string{AuthModeBearer}, resp.(*pb.GetServerInfoResponse).AuthModes)
+	})
+}
+
+func TestErrorDetails(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:    repoRoot,
+		repository:  repository,
+		patchLimits: PatchLimits{MaxHunks: 1},
+	}
+	ctx := context.Background()
+
+	// errorInfo returns the ErrorInfo of err and its other details
+	errorInfo := func(t *testing.T, err error, code codes.Code) (*errdetails.ErrorInfo, []any) {
+		t.Helper()
+		require.Error(t, err)
+		st, ok := status.FromError(err)
+		require.True(t, ok, "not a status error: %v", err)
+		assert.Equal(t, code, st.Code())
+		details := st.Details()
+		require.NotEmpty(t, details)
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok, "first detail is %T", details[0])
+		assert.Equal(t, ErrorDomain, info.Domain)
+		return info, details[1:]
+	}
+
+	t.Run("Path Not Found", func(t *testing.T) {
+		_, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "docs/missing.md"})
+		info, details := errorInfo(t, err, codes.NotFound)
+		assert.Equal(t, ReasonPathNotFound, info.Reason)
+		assert.Equal(t, map[string]string{"path": "docs/missing.md", "version": "1"}, info.Metadata)
+		require.Len(t, details, 1)
+		assert.Equal(t, "docs/missing.md", details[0].(*errdetails.ResourceInfo).ResourceName)
+		assert.Contains(t, err.Error(), "failed to read file entry")
+
+		_, err = srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "missing"})
+		info, _ = errorInfo(t, err, codes.NotFound)
+		assert.Equal(t, ReasonPathNotFound, info.Reason)
+	})
+
+	t.Run("Invalid Path", func(t *testing.T) {
+		_, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "../etc/passwd"})
+		info, details := errorInfo(t, err, codes.InvalidArgument)
+		assert.Equal(t, ReasonInvalidPath, info.Reason)
+		require.Len(t, details, 1)
+		assert.Equal(t, "path", details[0].(*errdetails.BadRequest).FieldViolations[0].Field)
+
+		_, err = srv.ListCaseCollisions(ctx, &pb.ListCaseCollisionsRequest{Path: "../etc"})
+		info, _ = errorInfo(t, err, codes.InvalidArgument)
+		assert.Equal(t, ReasonInvalidPath, info.Reason)
+		assert.Equal(t, "../etc", info.Metadata["path"])
+
+		_, err = srv.ListDeletedPaths(ctx, &pb.ListDeletedPathsRequest{Path: "/etc"})
+		info, _ = errorInfo(t, err, codes.InvalidArgument)
+		assert.Equal(t, ReasonInvalidPath, info.Reason)
+		assert.Equal(t, "/etc", info.Metadata["path"])
+	})
+
+	t.Run("Version Not Found", func(t *testing.T) {
+		err := srv.StreamFile(&pb.StreamFileRequest{Path: "docs/README.md", Version: 5}, &streamRecorder[pb.FileChunk]{ctx: ctx})
+		info, details := errorInfo(t, err, codes.NotFound)
+		assert.Equal(t, ReasonVersionNotFound, info.Reason)
+		assert.Equal(t, map[string]string{"version": "5", "current_version": "1"}, info.Metadata)
+		require.Len(t, details, 1)
+		assert.Equal(t, "version", details[0].(*errdetails.BadRequest).FieldViolations[0].Field)
+	})
+
+	t.Run("Patch Too Large", func(t *testing.T) {
+		_, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:    "docs",
+			Patch:   []byte("--- a/docs/new.md\n+++ b/docs/new.md\n@@ -0,0 +1 @@\n+a\n@@ -1,0 +2 @@\n+b\n"),
+			Message: "Test patch",
+		})
+		info, _ := errorInfo(t, err, codes.ResourceExhausted)
+		assert.Equal(t, ReasonPatchTooLarge, info.Reason)
+		assert.Equal(t, "2", info.Metadata["actual"])
+		assert.Equal(t, "1", info.Metadata["max"])
+	})
+
+	t.Run("Patch Conflict", func(t *testing.T) {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Patch:   []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Other Title\n+# New Title\n"),
+			Message: "Test patch",
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		require.NotNil(t, resp.Failure)
+		assert.Equal(t, ReasonPatchConflict, resp.Failure.Reason)
+		assert.Equal(t, "docs/README.md", resp.Failure.Metadata["path"])
+		assert.Equal(t, "1", resp.Failure.Metadata["base_version"])
+		assert.Equal(t, "1", resp.Failure.Metadata["file_line"])
+		assert.Equal(t, "# Other Title", resp.Failure.Metadata["expected"])
+		assert.Equal(t, "# Poon Monorepo Documentation", resp.Failure.Metadata["actual"])
+
+		preview, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{
+			Path:  "docs/README.md",
+			Patch: []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Other Title\n+# New Title\n"),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, preview.Failure)
+		assert.Equal(t, ReasonPatchConflict, preview.Failure.Reason)
+	})
+
+	t.Run("Empty Patch", func(t *testing.T) {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{Path: "docs/README.md"})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Failure)
+		assert.Equal(t, ReasonEmptyPatch, resp.Failure.Reason)
+	})
+}
+
+func TestPatchValidation(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	validators, err := NewValidators(&ValidationConfig{
+		MaxFileSize:    96,
+		ForbiddenPaths: []string{"config/", "*.pem"},
+		LintCommand:    []string{"sh", "-c", "if grep -q TODO; then echo 'TODO not allowed'; exit 1; fi"},
+	})
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+		validators: validators,
+	}
+
+	mergePatch := func(target, body string) *pb.MergePatchResponse {
+		patch := "--- a/" + target + "\n+++ b/" + target + "\n" + body
+		resp, err := srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Path:    target,
+			Patch:   []byte(patch),
+			Message: "Test patch",
+			Author:  "test@example.com",
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("Allowed Patch", func(t *testing.T) {
+		resp := mergePatch("src/frontend/app.js", "@@ -1,2 +1,2 @@\n-// Sample frontend application\n+// Frontend\n console.log(\"Hello from frontend\");\n")
+		assert.True(t, resp.Success, resp.Message)
+		assert.Empty(t, resp.Violations)
+	})
+
+	t.Run("Max File Size", func(t *testing.T) {
+		resp := mergePatch("src/frontend/big.js", "@@ -0,0 +1,1 @@\n+"+strings.Repeat("x", 100)+"\n")
+		assert.False(t, resp.Success)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, "max_file_size", resp.Violations[0].Rule)
+		assert.Equal(t, "src/frontend/big.js", resp.Violations[0].Path)
+	})
+
+	t.Run("Forbidden Directory", func(t *testing.T) {
+		resp := mergePatch("config/app.yaml", "@@ -1,1 +1,1 @@\n-environment: test\n+environment: prod\n")
+		assert.False(t, resp.Success)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, "forbidden_path", resp.Violations[0].Rule)
+	})
+
+	t.Run("Forbidden File Pattern", func(t *testing.T) {
+		resp := mergePatch("src/backend/key.pem", "@@ -0,0 +1,1 @@\n+secret\n")
+		assert.False(t, resp.Success)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, "forbidden_path", resp.Violations[0].Rule)
+	})
+
+	t.Run("Lint Command", func(t *testing.T) {
+		resp := mergePatch("docs/notes.md", "@@ -0,0 +1,1 @@\n+TODO write docs\n")
+		assert.False(t, resp.Success)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, "lint_command", resp.Violations[0].Rule)
+		assert.Contains(t, resp.Violations[0].Description, "TODO not allowed")
+		assert.Contains(t, resp.Message, "Patch rejected by validation")
+	})
+
+	t.Run("Invalid Pattern", func(t *testing.T) {
+		_, err := NewValidators(&ValidationConfig{ForbiddenPaths: []string{"[bad"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCommitMessagePolicy(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	policy, err := NewCommitMessagePolicy(&CommitMessageRules{
+		Pattern:          `^(feat|fix|docs): `,
+		TicketPattern:    `[A-Z]+-[0-9]+`,
+		MaxSubjectLength: 40,
+	})
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:     repoRoot,
+		repository:   repository,
+		commitPolicy: policy,
+	}
+
+	patch := []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Poon Monorepo Documentation\n+# Poon Docs\n")
+	mergePatch := func(message string) (*pb.MergePatchResponse, error) {
+		return srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Patch:   patch,
+			Message: message,
+			Author:  "test@example.com",
+		})
+	}
+
+	violationsOf := func(t *testing.T, err error) []string {
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+
+		var descriptions []string
+		for _, detail := range st.Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				for _, v := range badRequest.FieldViolations {
+					assert.Equal(t, "message", v.Field)
+					descriptions = append(descriptions, v.Description)
+				}
+			}
+		}
+		return descriptions
+	}
+
+	t.Run("Empty Message", func(t *testing.T) {
+		_, err := mergePatch("  ")
+		violations := violationsOf(t, err)
+		require.Len(t, violations, 1)
+		assert.Contains(t, violations[0], "must not be empty")
+	})
+
+	t.Run("Missing Ticket", func(t *testing.T) {
+		_, err := mergePatch("docs: shorten title")
+		violations := violationsOf(t, err)
+		require.Len(t, violations, 1)
+		assert.Contains(t, violations[0], "ticket")
+	})
+
+	t.Run("Multiple Violations", func(t *testing.T) {
+		_, err := mergePatch("shorten the documentation title to something smaller")
+		violations := violationsOf(t, err)
+		assert.Len(t, violations, 3)
+		assert.Contains(t, err.Error(), "subject line is")
+	})
+
+	t.Run("Only Subject Length Counts", func(t *testing.T) {
+		resp, err := mergePatch("docs: shorten title (DOC-12)\n\nThe body may be as long as it needs to be, only the subject is limited.")
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("Invalid Rules", func(t *testing.T) {
+		_, err := NewCommitMessagePolicy(&CommitMessageRules{TicketPattern: "("})
+		assert.Error(t, err)
+	})
+
+	t.Run("No Policy", func(t *testing.T) {
+		var noPolicy *CommitMessagePolicy
+		assert.Empty(t, noPolicy.Check(""))
+	})
+}
+
+func TestPreviewPatchEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+		locks:      storage.NewLockManager(backend),
+	}
+	ctx := context.Background()
+
+	patch := []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Poon Monorepo Documentation\n+# Poon Docs\n")
+
+	t.Run("Clean Patch", func(t *testing.T) {
+		resp, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "docs/README.md", Patch: patch, Author: "alice"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, "docs/README.md", resp.FilePath)
+		assert.Equal(t, int64(1), resp.BaseVersion)
+		assert.False(t, resp.NewFile)
+		assert.True(t, strings.HasPrefix(string(resp.OriginalContent), "# Poon Monorepo Documentation\n"))
+		assert.True(t, strings.HasPrefix(string(resp.Content), "# Poon Docs\n"))
+		assert.Empty(t, resp.Conflicts)
+
+		// Nothing is stored
+		version, err := repository.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), version)
+		fileResp, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "docs/README.md"})
+		require.NoError(t, err)
+		assert.Equal(t, resp.OriginalContent, fileResp.Content)
+	})
+
+	t.Run("New File", func(t *testing.T) {
+		resp, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{
+			Path:  "docs/NEW.md",
+			Patch: []byte("--- /dev/null\n+++ b/docs/NEW.md\n@@ -0,0 +1,1 @@\n+new\n"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.True(t, resp.NewFile)
+		assert.Equal(t, "new\n", string(resp.Content))
+	})
+
+	t.Run("Stale Patch", func(t *testing.T) {
+		stale := []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Old Title\n+# Poon Docs\n")
+		resp, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "docs/README.md", Patch: stale})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		require.Len(t, resp.Conflicts, 1)
+		assert.Contains(t, resp.Conflicts[0], "does not match file content")
+	})
+
+	t.Run("Locked Path", func(t *testing.T) {
+		lockResp, err := srv.LockPath(ctx, &pb.LockPathRequest{Path: "docs", Owner: "bob"})
+		require.NoError(t, err)
+		require.True(t, lockResp.Success, lockResp.Message)
+
+		resp, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "docs/README.md", Patch: patch, Author: "alice"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.NotEmpty(t, resp.Content)
+		require.Len(t, resp.Conflicts, 1)
+		assert.Contains(t, resp.Conflicts[0], "locked by bob")
+
+		// The lock holder sees no conflict
+		resp, err = srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "docs/README.md", Patch: patch, Author: "bob"})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("Invalid Requests", func(t *testing.T) {
+		resp, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "docs/README.md"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Patch data is empty")
+
+		resp, err = srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "docs/README.md", Patch: []byte("not a valid patch")})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Failed to preview patch")
+		assert.Empty(t, resp.Conflicts)
+	})
+}
+
+func TestMergeBranchesEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := &server{repoRoot: repoRoot, repository: repository}
+
+	created, err := srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "feature/docs"})
+	require.NoError(t, err)
+	require.True(t, created.Success, created.Message)
+	head, err := repository.BranchHead(ctx, storage.MainBranch)
+	require.NoError(t, err)
+	assert.Equal(t, string(head), created.CommitHash)
+
+	branches, err := srv.GetBranches(ctx, &pb.BranchesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main", "feature/docs"}, branches.Branches)
+
+	mergePatch := func(branch, target, body string) *pb.MergePatchResponse {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:    target,
+			Branch:  branch,
+			Patch:   []byte("--- a/" + target + "\n+++ b/" + target + "\n" + body),
+			Message: "Test patch",
+			Author:  "test@example.com",
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := mergePatch("feature/docs", "docs/README.md", "@@ -1 +1 @@\n-# Poon Monorepo Documentation\n+# Poon Documentation\n")
+	require.True(t, resp.Success, resp.Message)
+	resp = mergePatch("main", "config/app.yaml", "@@ -1 +1 @@\n-environment: test\n+environment: staging\n")
+	require.True(t, resp.Success, resp.Message)
+	resp = mergePatch("missing", "config/app.yaml", "@@ -1 +1 @@\n-environment: test\n+environment: prod\n")
+	assert.False(t, resp.Success)
+	require.NotNil(t, resp.Failure)
+	assert.Equal(t, ReasonBranchNotFound, resp.Failure.Reason)
+
+	merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "feature/docs", Author: "test@example.com"})
+	require.NoError(t, err)
+	require.True(t, merged.Success, merged.Message)
+	assert.Equal(t, int64(3), merged.Version)
+	assert.Equal(t, string(head), merged.MergeBase)
+
+	content, err := repository.ReadFile(ctx, 3, "docs/README.md")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "# Poon Documentation\n"))
+	content, err = repository.ReadFile(ctx, 3, "config/app.yaml")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "environment: staging\n"))
+
+	t.Run("Conflict", func(t *testing.T) {
+		created, err := srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "config"})
+		require.NoError(t, err)
+		require.True(t, created.Success, created.Message)
+		resp := mergePatch("config", "config/app.yaml", "@@ -1 +1 @@\n-environment: staging\n+environment: prod\n")
+		require.True(t, resp.Success, resp.Message)
+		resp = mergePatch("main", "config/app.yaml", "@@ -1 +1 @@\n-environment: staging\n+environment: dev\n")
+		require.True(t, resp.Success, resp.Message)
+
+		merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "config"})
+		require.NoError(t, err)
+		assert.False(t, merged.Success)
+		assert.Equal(t, []string{"config/app.yaml"}, merged.Conflicts)
+		require.NotNil(t, merged.Failure)
+		assert.Equal(t, ReasonMergeConflict, merged.Failure.Reason)
+		assert.Equal(t, "main", merged.Failure.Metadata["target"])
+	})
+
+	t.Run("Main Line Checks", func(t *testing.T) {
+		created, err := srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "secrets"})
+		require.NoError(t, err)
+		require.True(t, created.Success, created.Message)
+		resp := mergePatch("secrets", "secrets/key.txt", "@@ -0,0 +1 @@\n+hunter2\n")
+		require.True(t, resp.Success, resp.Message)
+		defer func() { srv.validators, srv.patchLimits = nil, PatchLimits{} }()
+
+		srv.validators = []Validator{&forbiddenPathValidator{patterns: []string{"secrets/"}}}
+		merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "secrets"})
+		require.NoError(t, err)
+		assert.False(t, merged.Success)
+		require.Len(t, merged.Violations, 1)
+		assert.Equal(t, "secrets/key.txt", merged.Violations[0].Path)
+		require.NotNil(t, merged.Failure)
+		assert.Equal(t, "validation", merged.Failure.Metadata["policy"])
+
+		srv.validators = nil
+		srv.patchLimits = PatchLimits{MaxFileBytes: 4}
+		_, err = srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "secrets"})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		// Merges between other branches are left to branch protection
+		created, err = srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "staging"})
+		require.NoError(t, err)
+		require.True(t, created.Success, created.Message)
+		merged, err = srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "secrets", TargetBranch: "staging"})
+		require.NoError(t, err)
+		assert.True(t, merged.Success, merged.Message)
+	})
+
+	t.Run("Queued", func(t *testing.T) {
+		srv.mergeQueue = NewMergeQueue(MergeQueueConfig{}, repository)
+		defer func() { srv.mergeQueue = nil }()
+
+		merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "secrets", Squash: true})
+		require.NoError(t, err)
+		require.True(t, merged.Success, merged.Message)
+		require.True(t, merged.Queued)
+		assert.Zero(t, merged.Version)
+
+		queueCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go srv.mergeQueue.Run(queueCtx)
+		var entry *pb.QueueEntry
+		require.Eventually(t, func() bool {
+			resp, err := srv.GetMergeQueue(ctx, &pb.GetMergeQueueRequest{EntryId: merged.QueueEntryId})
+			require.NoError(t, err)
+			entry = resp.Entries[0]
+			return entry.State == pb.QueueEntryState_QUEUE_LANDED
+		}, 5*time.Second, 10*time.Millisecond)
+
+		content, err := repository.ReadFile(ctx, entry.LandedVersion, "secrets/key.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2\n", string(content))
+	})
+
+	t.Run("Missing Branch", func(t *testing.T) {
+		merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "missing"})
+		require.NoError(t, err)
+		assert.False(t, merged.Success)
+		require.NotNil(t, merged.Failure)
+		assert.Equal(t, ReasonBranchNotFound, merged.Failure.Reason)
+		assert.Equal(t, "missing", merged.Failure.Metadata["branch"])
+	})
+}
+
+func TestCherryPickEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := &server{repoRoot: repoRoot, repository: repository}
+
+	created, err := srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "release/1.0"})
+	require.NoError(t, err)
+	require.True(t, created.Success, created.Message)
+
+	_, err = repository.ApplyPatch(ctx, []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1 +1 @@\n-environment: test\n+environment: staging\n"), "bob", "Use staging")
+	require.NoError(t, err)
+
+	picked, err := srv.CherryPick(ctx, &pb.CherryPickRequest{Version: 2, TargetBranch: "release/1.0", Author: "alice"})
+	require.NoError(t, err)
+	require.True(t, picked.Success, picked.Message)
+	assert.False(t, picked.Empty)
+	assert.Zero(t, picked.Version)
+
+	head, err := repository.BranchHead(ctx, "release/1.0")
+	require.NoError(t, err)
+	assert.Equal(t, string(head), picked.CommitHash)
+
+	picked, err = srv.CherryPick(ctx, &pb.CherryPickRequest{Version: 2, TargetBranch: "release/1.0", Author: "alice"})
+	require.NoError(t, err)
+	require.True(t, picked.Success, picked.Message)
+	assert.True(t, picked.Empty)
+
+	t.Run("Conflict", func(t *testing.T) {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:    "config/app.yaml",
+			Branch:  "release/1.0",
+			Patch:   []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1 +1 @@\n-environment: staging\n+environment: prod\n"),
+			Message: "Use prod",
+			Author:  "alice",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		_, err = repository.ApplyPatch(ctx, []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1 +1 @@\n-environment: staging\n+environment: dev\n"), "bob", "Use dev")
+		require.NoError(t, err)
+
+		picked, err := srv.CherryPick(ctx, &pb.CherryPickRequest{Version: 3, TargetBranch: "release/1.0"})
+		require.NoError(t, err)
+		assert.False(t, picked.Success)
+		assert.Equal(t, []string{"config/app.yaml"}, picked.Conflicts)
+		require.NotNil(t, picked.Failure)
+		assert.Equal(t, ReasonMergeConflict, picked.Failure.Reason)
+	})
+
+	t.Run("Main Line Checks", func(t *testing.T) {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:    "docs/README.md",
+			Branch:  "release/1.0",
+			Patch:   []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1 +1 @@\n-# Poon Monorepo Documentation\n+# Poon 1.0\n"),
+			Message: "WIP",
+			Author:  "alice",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		defer func() { srv.validators, srv.commitPolicy = nil, nil }()
+
+		srv.commitPolicy, err = NewCommitMessagePolicy(&CommitMessageRules{TicketPattern: "[A-Z]+-[0-9]+"})
+		require.NoError(t, err)
+		_, err = srv.CherryPick(ctx, &pb.CherryPickRequest{CommitHash: resp.CommitHash})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		srv.commitPolicy = nil
+		srv.validators = []Validator{&forbiddenPathValidator{patterns: []string{"docs/"}}}
+		picked, err := srv.CherryPick(ctx, &pb.CherryPickRequest{CommitHash: resp.CommitHash})
+		require.NoError(t, err)
+		assert.False(t, picked.Success)
+		require.Len(t, picked.Violations, 1)
+		assert.Equal(t, "docs/README.md", picked.Violations[0].Path)
+
+		srv.validators = nil
+		srv.mergeQueue = NewMergeQueue(MergeQueueConfig{}, repository)
+		defer func() { srv.mergeQueue = nil }()
+		picked, err = srv.CherryPick(ctx, &pb.CherryPickRequest{CommitHash: resp.CommitHash})
+		require.NoError(t, err)
+		require.True(t, picked.Success, picked.Message)
+		assert.True(t, picked.Queued)
+		assert.Zero(t, picked.Version)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		picked, err := srv.CherryPick(ctx, &pb.CherryPickRequest{Version: 2, TargetBranch: "release/2.0"})
+		require.NoError(t, err)
+		require.NotNil(t, picked.Failure)
+		assert.Equal(t, ReasonBranchNotFound, picked.Failure.Reason)
+
+		picked, err = srv.CherryPick(ctx, &pb.CherryPickRequest{Version: 9})
+		require.NoError(t, err)
+		require.NotNil(t, picked.Failure)
+		assert.Equal(t, ReasonVersionNotFound, picked.Failure.Reason)
+	})
+}
+
+func TestAmendAndSquashEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := &server{repoRoot: repoRoot, repository: repository}
+
+	created, err := srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "change"})
+	require.NoError(t, err)
+	require.True(t, created.Success, created.Message)
+
+	update := func(req *pb.MergePatchRequest) *pb.MergePatchResponse {
+		req.Path, req.Author = "config/app.yaml", "alice"
+		resp, err := srv.MergePatch(ctx, req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := update(&pb.MergePatchRequest{
+		Branch:  "change",
+		Patch:   []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1 +1 @@\n-environment: test\n+environment: staging\n"),
+		Message: "Use staging",
+	})
+	require.True(t, resp.Success, resp.Message)
+	resp = update(&pb.MergePatchRequest{
+		Branch:  "change",
+		Patch:   []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1 +1 @@\n-environment: staging\n+environment: prod\n"),
+		Message: "Use prod",
+	})
+	require.True(t, resp.Success, resp.Message)
+
+	// A message-only amend needs no patch
+	resp = update(&pb.MergePatchRequest{Branch: "change", Message: "Use prod everywhere", Amend: true})
+	require.True(t, resp.Success, resp.Message)
+	commit, err := repository.GetCommit(ctx, storage.Hash(resp.CommitHash))
+	require.NoError(t, err)
+	assert.Equal(t, "Use prod everywhere", commit.Message)
+
+	resp = update(&pb.MergePatchRequest{Message: "Reword main", Amend: true})
+	assert.False(t, resp.Success)
+
+	merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "change", Squash: true, Author: "alice"})
+	require.NoError(t, err)
+	require.True(t, merged.Success, merged.Message)
+	assert.Equal(t, int64(2), merged.Version)
+
+	squashed, err := repository.GetCommit(ctx, storage.Hash(merged.CommitHash))
+	require.NoError(t, err)
+	assert.Empty(t, squashed.MergeParents)
+	assert.Equal(t, "Squash branch 'change' into main\n\n* Use staging\n* Use prod everywhere", squashed.Message)
+	content, err := repository.ReadFile(ctx, 2, "config/app.yaml")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "environment: prod\n"))
+}
+
+func TestPatchLimits(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+		patchLimits: PatchLimits{
+			MaxPatchBytes: 512,
+			MaxFiles:      1,
+			MaxHunks:      2,
+			MaxFileBytes:  128,
+		},
+	}
+
+	mergePatch := func(patch string) (*pb.MergePatchResponse, error) {
+		return srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Path:    "src/frontend",
+			Patch:   []byte(patch),
+			Message: "Test patch",
+			Author:  "test@example.com",
+		})
+	}
+	header := "--- a/src/frontend/new.js\n+++ b/src/frontend/new.js\n"
+
+	t.Run("Within Limits", func(t *testing.T) {
+		resp, err := mergePatch(header + "@@ -0,0 +1,1 @@\n+ok\n")
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	cases := []struct {
+		name    string
+		patch   string
+		message string
+	}{
+		{"Patch Bytes", header + "@@ -1,1 +1,2 @@\n ok\n+" + strings.Repeat("x", 512) + "\n", "limit is 512 bytes"},
+		{"Files", header + "@@ -1,1 +1,1 @@\n-ok\n+a\n--- a/other.js\n+++ b/other.js\n@@ -0,0 +1 @@\n+b\n", "touches 2 files"},
+		{"Hunks", header + "@@ -1,0 +2 @@\n+a\n@@ -1,0 +2 @@\n+b\n@@ -1,0 +2 @@\n+c\n", "has 3 hunks"},
+		{"Binary Data", "diff --git a/a.bin b/a.bin\nGIT binary patch\nliteral 4096\nRcmZQzWJ=1+ODwAV4*(1}1Bd_s\n\n", "binary patch data is 4096 bytes"},
+		{"Resulting File Size", header + "@@ -1,1 +1,2 @@\n ok\n+" + strings.Repeat("x", 200) + "\n", "would be 204 bytes"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := mergePatch(tc.patch)
+			require.Error(t, err)
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+			assert.Contains(t, err.Error(), tc.message)
+		})
+	}
+
+	t.Run("Header Lookalikes In Hunks", func(t *testing.T) {
+		// Removed and added lines that look like a file header are content
+		resp, err := mergePatch(header + "@@ -1,0 +2,1 @@\n+-- a/y\n")
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		resp, err = mergePatch(header + "@@ -2,1 +2,1 @@\n--- a/y\n+++ b/z\n")
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+}
+
+func TestPathLocking(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+		locks:      storage.NewLockManager(backend),
+	}
+	ctx := context.Background()
+
+	patch := []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Poon Monorepo Documentation\n+# Poon Docs\n")
+
+	t.Run("Lock Path", func(t *testing.T) {
+		resp, err := srv.LockPath(ctx, &pb.LockPathRequest{Path: "docs", TtlSeconds: 60, Owner: "alice"})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+		assert.Equal(t, "alice", resp.Lock.Owner)
+		assert.InDelta(t, time.Now().Add(time.Minute).Unix(), resp.Lock.ExpiresAt, 2)
+
+		resp, err = srv.LockPath(ctx, &pb.LockPathRequest{Path: "docs", Owner: "bob"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "alice", resp.Lock.Owner)
+	})
+
+	t.Run("Invalid Requests", func(t *testing.T) {
+		resp, err := srv.LockPath(ctx, &pb.LockPathRequest{Path: "../etc", Owner: "alice"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		resp, err = srv.LockPath(ctx, &pb.LockPathRequest{Path: "src"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "owner is required")
+	})
+
+	t.Run("Authenticated User Owns Lock", func(t *testing.T) {
+		authCtx := context.WithValue(ctx, userContextKey, "carol")
+		resp, err := srv.LockPath(authCtx, &pb.LockPathRequest{Path: "config", Owner: "mallory"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, "carol", resp.Lock.Owner)
+	})
+
+	t.Run("List Locks", func(t *testing.T) {
+		resp, err := srv.ListLocks(ctx, &pb.ListLocksRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Locks, 2)
+		assert.Equal(t, "config", resp.Locks[0].Path)
+		assert.Equal(t, "docs", resp.Locks[1].Path)
+
+		resp, err = srv.ListLocks(ctx, &pb.ListLocksRequest{PathPrefix: "docs"})
+		require.NoError(t, err)
+		assert.Len(t, resp.Locks, 1)
+	})
+
+	t.Run("MergePatch Respects Locks", func(t *testing.T) {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:         "docs/README.md",
+			Patch:        patch,
+			Message:      "Update title",
+			Author:       "bob",
+			FailIfLocked: true,
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "locked by alice")
+
+		resp, err = srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Path:         "docs/README.md",
+			Patch:        patch,
+			Message:      "Update title",
+			Author:       "alice",
+			FailIfLocked: true,
+		})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("Unlock Path", func(t *testing.T) {
+		resp, err := srv.UnlockPath(ctx, &pb.UnlockPathRequest{Path: "docs", Owner: "bob"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		resp, err = srv.UnlockPath(ctx, &pb.UnlockPathRequest{Path: "docs", Owner: "alice"})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+
+		list, err := srv.ListLocks(ctx, &pb.ListLocksRequest{PathPrefix: "docs"})
+		require.NoError(t, err)
+		assert.Empty(t, list.Locks)
+	})
+}
+
+func TestQuotas(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	docsSize := int64(len(`# Poon Monorepo Documentation
+
+This is a sample monorepo for testing.
+
+## Structure
+
+- src/frontend/ - Frontend application
+- src/backend/ - Backend service  
+- docs/ - Documentation
+- config/ - Configuration files`))
+
+	quotas := NewQuotaManager(QuotaConfig{
+		Defaults: QuotaLimits{MaxUserWorkspaces: 1, MaxWorkspaceBytes: docsSize + 10},
+		Users: map[string]QuotaLimits{
+			"admin": {},
+		},
+	})
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        quotas,
+	}
+
+	interceptor := quotas.UnaryInterceptor()
+	call := func(ctx context.Context, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{}, handler)
+	}
+
+	aliceCtx := context.WithValue(context.Background(), userContextKey, "alice")
+	var workspaceID string
+
+	t.Run("Create Within Quota", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(aliceCtx, &pb.CreateWorkspaceRequest{
+			TrackedPaths: []string{"docs"},
+			Metadata:     map[string]string{"created_by": "test", "quota.max_bytes": "999999999"},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		workspaceID = resp.WorkspaceId
+
+		workspace := srv.workspaces[workspaceID]
+		assert.Equal(t, "alice", workspace.Owner)
+		assert.Equal(t, docsSize, workspace.BytesStored)
+		assert.NotContains(t, workspace.Metadata, "quota.max_bytes", "clients must not set quota overrides")
+		assert.Equal(t, "test", workspace.Metadata["created_by"])
+	})
+
+	t.Run("Workspace Count Limit", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(aliceCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "workspace limit reached")
+	})
+
+	t.Run("Workspace Byte Limit", func(t *testing.T) {
+		resp, err := srv.AddTrackedPath(aliceCtx, &pb.AddTrackedPathRequest{WorkspaceId: workspaceID, Path: "config"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Quota exceeded")
+		assert.Equal(t, []string{"docs"}, srv.workspaces[workspaceID].TrackedPaths)
+	})
+
+	t.Run("Metadata Override", func(t *testing.T) {
+		srv.workspaces[workspaceID].Metadata[quotaMaxBytesMetadataKey] = "1000000"
+
+		// Overrides survive client metadata updates
+		_, err := srv.UpdateWorkspace(aliceCtx, &pb.UpdateWorkspaceRequest{
+			WorkspaceId: workspaceID,
+			Metadata:    map[string]string{"quota.max_bytes": "1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1000000", srv.workspaces[workspaceID].Metadata[quotaMaxBytesMetadataKey])
+
+		resp, err := srv.AddTrackedPath(aliceCtx, &pb.AddTrackedPathRequest{WorkspaceId: workspaceID, Path: "config"})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("User Override", func(t *testing.T) {
+		adminCtx := context.WithValue(context.Background(), userContextKey, "admin")
+		for i := 0; i < 2; i++ {
+			resp, err := srv.CreateWorkspace(adminCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"src"}})
+			require.NoError(t, err)
+			assert.True(t, resp.Success, resp.Message)
+		}
+	})
+
+	t.Run("Get Quota", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := call(aliceCtx, &pb.GetWorkspaceRequest{WorkspaceId: workspaceID}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.GetWorkspace(ctx, req.(*pb.GetWorkspaceRequest))
+			})
+			require.NoError(t, err)
+		}
+
+		result, err := call(aliceCtx, &pb.GetQuotaRequest{WorkspaceId: workspaceID}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.GetQuota(ctx, req.(*pb.GetQuotaRequest))
+		})
+		require.NoError(t, err)
+		resp := result.(*pb.GetQuotaResponse)
+		require.True(t, resp.Success, resp.Message)
+
+		assert.Equal(t, "alice", resp.User)
+		assert.Equal(t, int64(1), resp.UserUsage.WorkspacesUsed)
+		assert.Equal(t, int64(1), resp.UserUsage.WorkspacesLimit)
+		assert.Equal(t, int64(4), resp.UserUsage.RequestCount)
+		assert.Equal(t, srv.workspaces[workspaceID].BytesStored, resp.WorkspaceUsage.BytesUsed)
+		assert.Equal(t, int64(1000000), resp.WorkspaceUsage.BytesLimit)
+		assert.Equal(t, int64(4), resp.WorkspaceUsage.RequestCount)
+	})
+
+	t.Run("Unknown Workspace", func(t *testing.T) {
+		resp, err := srv.GetQuota(aliceCtx, &pb.GetQuotaRequest{WorkspaceId: "missing"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+}
+
+// requestStream receives req as the stream's request
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	req *pb.StreamWorkspaceArchiveRequest
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *requestStream) RecvMsg(m interface{}) error {
+	m.(*pb.StreamWorkspaceArchiveRequest).WorkspaceId = s.req.WorkspaceId
+	return nil
+}
+
+func TestQuotaRequestCounts(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	quotas := NewQuotaManager(QuotaConfig{})
+	quotas.now = func() time.Time { return now }
+	aliceCtx := context.WithValue(context.Background(), userContextKey, "alice")
+
+	_, err := quotas.UnaryInterceptor()(aliceCtx, &pb.GetWorkspaceRequest{WorkspaceId: "ws-1"}, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	require.NoError(t, err)
+
+	// Streams are charged to the workspace their request names
+	stream := &requestStream{ctx: aliceCtx, req: &pb.StreamWorkspaceArchiveRequest{WorkspaceId: "ws-1"}}
+	err = quotas.StreamInterceptor()(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		return ss.RecvMsg(&pb.StreamWorkspaceArchiveRequest{})
+	})
+	require.NoError(t, err)
+
+	user, workspace := quotas.requestCounts("alice", "ws-1")
+	assert.Equal(t, int64(2), user)
+	assert.Equal(t, int64(2), workspace)
+
+	// Counts start again once their window ends, and counters nobody has
+	// used since are removed
+	now = now.Add(requestWindow)
+	user, workspace = quotas.requestCounts("alice", "ws-1")
+	assert.Zero(t, user)
+	assert.Zero(t, workspace)
+
+	quotas.recordRequest("bob", "")
+	assert.Len(t, quotas.userRequests, 1)
+	assert.Empty(t, quotas.workspaceRequests)
+	user, _ = quotas.requestCounts("bob", "")
+	assert.Equal(t, int64(1), user)
+}
+
+func TestSizePolicies(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	// docs/README.md is the only file over 100 bytes, and only the root holds
+	// more than 250
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas: NewQuotaManager(QuotaConfig{
+			Defaults: QuotaLimits{MaxFileBytes: 100, MaxTrackedPathBytes: 250},
+			Users: map[string]QuotaLimits{
+				"admin": {MaxFileBytes: 100, MaxTrackedPathBytes: 250, AllowSizeOverride: true},
+			},
+		}),
+	}
+
+	aliceCtx := context.WithValue(context.Background(), userContextKey, "alice")
+	adminCtx := context.WithValue(context.Background(), userContextKey, "admin")
+	var workspaceID string
+
+	t.Run("Within Limits", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(aliceCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"src"}})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		workspaceID = resp.WorkspaceId
+	})
+
+	t.Run("File Too Large", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(aliceCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "docs/README.md is")
+		assert.Contains(t, resp.Message, "limit per file is 100")
+
+		addResp, err := srv.AddTrackedPath(aliceCtx, &pb.AddTrackedPathRequest{WorkspaceId: workspaceID, Path: "docs/README.md"})
+		require.NoError(t, err)
+		assert.False(t, addResp.Success)
+		assert.Contains(t, addResp.Message, "Size limit exceeded")
+	})
+
+	t.Run("Tracked Path Too Large", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(aliceCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{""}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "the repository root holds")
+
+		addResp, err := srv.AddTrackedPath(aliceCtx, &pb.AddTrackedPathRequest{WorkspaceId: workspaceID, Path: "."})
+		require.NoError(t, err)
+		assert.False(t, addResp.Success)
+		assert.Contains(t, addResp.Message, "limit per tracked path is 250")
+		assert.Equal(t, []string{"src"}, srv.workspaces[workspaceID].TrackedPaths)
+	})
+
+	t.Run("Override Without Permission", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(aliceCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}, OverrideSizeLimits: true})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "may not override size limits")
+
+		addResp, err := srv.AddTrackedPath(aliceCtx, &pb.AddTrackedPathRequest{WorkspaceId: workspaceID, Path: "docs", OverrideSizeLimits: true})
+		require.NoError(t, err)
+		assert.False(t, addResp.Success)
+		assert.Contains(t, addResp.Message, "may not override size limits")
+	})
+
+	t.Run("Override With Permission", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(adminCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success, "limits apply unless an override is requested")
+
+		resp, err = srv.CreateWorkspace(adminCtx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}, OverrideSizeLimits: true})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		addResp, err := srv.AddTrackedPath(adminCtx, &pb.AddTrackedPathRequest{WorkspaceId: resp.WorkspaceId, Path: "config", OverrideSizeLimits: true})
+		require.NoError(t, err)
+		assert.True(t, addResp.Success, addResp.Message)
+	})
+}
+
+func TestReportWorkspaceStatus(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	id := createResp.WorkspaceId
+
+	serverHead, err := gitHead(ctx, srv.workspaces[id].GitRepoPath)
+	require.NoError(t, err)
+
+	t.Run("Clean Workspace", func(t *testing.T) {
+		srv.workspaces[id].LastSync = time.Now().Add(-time.Hour)
+
+		resp, err := srv.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{
+			WorkspaceId:   id,
+			ClientVersion: "1.0.0",
+			HeadCommit:    serverHead,
+			Operation:     "sync",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		getResp, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: id})
+		require.NoError(t, err)
+		info := getResp.Workspace
+		assert.Equal(t, "1.0.0", info.ClientVersion)
+		assert.Equal(t, serverHead, info.HeadCommit)
+		assert.False(t, info.Diverged)
+		assert.NotEmpty(t, info.LastReport)
+		assert.WithinDuration(t, time.Now(), srv.workspaces[id].LastSync, time.Minute)
+	})
+
+	t.Run("Diverged Workspace", func(t *testing.T) {
+		resp, err := srv.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{WorkspaceId: id, HeadCommit: serverHead, DirtyFiles: 2})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.True(t, srv.workspaces[id].Diverged)
+		assert.Equal(t, int32(2), srv.workspaces[id].DirtyFiles)
+
+		resp, err = srv.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{WorkspaceId: id, HeadCommit: "0123456789abcdef"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.True(t, srv.workspaces[id].Diverged)
+	})
+
+	t.Run("Status Only Report Keeps Last Sync", func(t *testing.T) {
+		lastSync := time.Now().Add(-time.Hour)
+		srv.workspaces[id].LastSync = lastSync
+
+		resp, err := srv.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{WorkspaceId: id, HeadCommit: serverHead})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, lastSync, srv.workspaces[id].LastSync)
+	})
+
+	t.Run("Invalid Requests", func(t *testing.T) {
+		resp, err := srv.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{WorkspaceId: "missing"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		resp, err = srv.ReportWorkspaceStatus(ctx, &pb.ReportWorkspaceStatusRequest{WorkspaceId: id, DirtyFiles: -1})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+}
+
+func TestPreviewFile(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	var long strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&long, "line %d\n", i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs/long.txt"), []byte(long.String()), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs/logo.png"), []byte("\x89PNG\x00\x01"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src/backend/doc.go"), []byte("/* Package main\n<serves> requests */\npackage main\n"), 0644))
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := &server{repoRoot: repoRoot, repository: repository}
+	ctx := context.Background()
+
+	texts := func(resp *pb.PreviewFileResponse) []string {
+		var texts []string
+		for _, line := range resp.Lines {
+			texts = append(texts, fmt.Sprintf("%d:%s", line.Number, line.Text))
+		}
+		return texts
+	}
+
+	t.Run("Range", func(t *testing.T) {
+		resp, err := srv.PreviewFile(ctx, &pb.PreviewFileRequest{Path: "docs/long.txt", FromLine: 10, ToLine: 12})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10:line 10", "11:line 11", "12:line 12"}, texts(resp))
+		assert.Equal(t, int32(30), resp.TotalLines)
+		assert.False(t, resp.Truncated)
+		assert.Empty(t, resp.Language)
+
+		resp, err = srv.PreviewFile(ctx, &pb.PreviewFileRequest{Path: "docs/long.txt", FromLine: 28, ToLine: 40})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"28:line 28", "29:line 29", "30:line 30"}, texts(resp))
+	})
+
+	t.Run("Capped", func(t *testing.T) {
+		resp, err := srv.PreviewFile(ctx, &pb.PreviewFileRequest{Path: "docs/long.txt", MaxLines: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1:line 1", "2:line 2"}, texts(resp))
+		assert.True(t, resp.Truncated)
+	})
+
+	t.Run("Highlighted", func(t *testing.T) {
+		// The comment opened on line 1 is still a comment on line 2
+		resp, err := srv.PreviewFile(ctx, &pb.PreviewFileRequest{Path: "src/backend/doc.go", FromLine: 2, Highlight: "html"})
+		require.NoError(t, err)
+		assert.Equal(t, "go", resp.Language)
+		assert.Equal(t, []string{
+			`2:<span class="tok-comment">&lt;serves&gt; requests */</span>`,
+			`3:<span class="tok-keyword">package</span> main`,
+		}, texts(resp))
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		resp, err := srv.PreviewFile(ctx, &pb.PreviewFileRequest{Path: "docs/logo.png"})
+		require.NoError(t, err)
+		assert.True(t, resp.Binary)
+		assert.Empty(t, resp.Lines)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, req := range []*pb.PreviewFileRequest{
+			{Path: "docs/long.txt", FromLine: 5, ToLine: 4},
+			{Path: "docs/long.txt", Highlight: "latex"},
+			{Path: "docs/long.txt", Language: "cobol"},
+			{Path: "docs"},
+			{Path: "missing.txt"},
+			{Path: "../etc/passwd"},
+		} {
+			_, err := srv.PreviewFile(ctx, req)
+			assert.Error(t, err, req.String())
+		}
+	})
+}
+
+func TestGetPathInfoEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "OWNERS"), []byte("# repository owners\nalice@example.com\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src/backend/OWNERS"), []byte("bob@example.com\n\ncarol@example.com # lead\n"), 0644))
+
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		repository: repository,
+	}
+	ctx := context.Background()
+
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	patch := `--- a/docs/guide.md
++++ b/docs/guide.md
+@@ -0,0 +1 @@
++# Guide
+`
+	_, err = repository.ApplyPatch(ctx, []byte(patch), "writer@example.com", "Add guide")
+	require.NoError(t, err)
+
+	t.Run("Directory", func(t *testing.T) {
+		resp, err := srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: "docs/"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "docs", resp.Path)
+		assert.True(t, resp.IsDir)
+		assert.Equal(t, int64(2), resp.Version)
+		assert.Equal(t, int32(2), resp.Files)
+		assert.Equal(t, int32(0), resp.Directories)
+		assert.Equal(t, int64(2), resp.TotalFiles)
+		assert.Equal(t, "docs/README.md", resp.ReadmePath)
+		assert.Contains(t, string(resp.ReadmeContent), "Poon Monorepo Documentation")
+		assert.False(t, resp.ReadmeTruncated)
+
+		require.NotNil(t, resp.LastChange)
+		assert.Equal(t, int64(2), resp.LastChangeVersion)
+		assert.Equal(t, "writer@example.com", resp.LastChange.Author)
+
+		assert.Equal(t, "OWNERS", resp.OwnersPath)
+		assert.Equal(t, []string{"alice@example.com"}, resp.Owners)
+	})
+
+	t.Run("Root", func(t *testing.T) {
+		resp, err := srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: ""})
+		require.NoError(t, err)
+
+		assert.True(t, resp.IsDir)
+		assert.Equal(t, int32(3), resp.Directories)
+		assert.Equal(t, int32(1), resp.Files)
+		assert.Equal(t, int64(7), resp.TotalFiles)
+		assert.Empty(t, resp.ReadmePath)
+		assert.Equal(t, int64(2), resp.LastChangeVersion)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		resp, err := srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: "src/backend/server.go"})
+		require.NoError(t, err)
+
+		assert.False(t, resp.IsDir)
+		assert.Equal(t, int64(1), resp.TotalFiles)
+		assert.Equal(t, int64(len(`package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello from backend")
+}`)), resp.TotalSize)
+		assert.Equal(t, int64(1), resp.LastChangeVersion)
+		assert.Equal(t, "Initial commit", resp.LastChange.Message)
+
+		assert.Equal(t, "src/backend/OWNERS", resp.OwnersPath)
+		assert.Equal(t, []string{"bob@example.com", "carol@example.com"}, resp.Owners)
+	})
+
+	t.Run("Missing Path", func(t *testing.T) {
+		_, err := srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: "missing"})
+		assert.Error(t, err)
+
+		_, err = srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: "../etc"})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetRenderedDoc(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs/guide.md"), []byte("# Guide\n\n<script>alert(1)</script> [x](javascript:alert(1))\n"), 0644))
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := &server{repoRoot: repoRoot, repository: repository, docCache: newDocCache(1 << 20)}
+
+	t.Run("README", func(t *testing.T) {
+		resp, err := srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "docs"})
+		require.NoError(t, err)
+		assert.Equal(t, "docs/README.md", resp.Path)
+		assert.Equal(t, "Poon Monorepo Documentation", resp.Title)
+		assert.Contains(t, resp.Html, `<h1 id="poon-monorepo-documentation">Poon Monorepo Documentation</h1>`)
+		assert.Contains(t, resp.Html, "<li>src/frontend/ - Frontend application</li>")
+		assert.False(t, resp.Cached)
+
+		// The same tree is served from the cache, under any path
+		cached, err := srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "docs/"})
+		require.NoError(t, err)
+		assert.True(t, cached.Cached)
+		assert.Equal(t, resp.Html, cached.Html)
+		assert.Equal(t, resp.Hash, cached.Hash)
+	})
+
+	t.Run("Sanitized", func(t *testing.T) {
+		resp, err := srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "docs/guide.md"})
+		require.NoError(t, err)
+		assert.Equal(t, "docs/guide.md", resp.Path)
+		assert.Equal(t, "<h1 id=\"guide\">Guide</h1>\n<p>&lt;script&gt;alert(1)&lt;/script&gt; x</p>\n", resp.Html)
+	})
+
+	t.Run("Changed README", func(t *testing.T) {
+		patch := `--- a/docs/README.md
++++ b/docs/README.md
+@@ -1 +1 @@
+-# Poon Monorepo Documentation
++# Poon Docs
+`
+		_, err := repository.ApplyPatch(ctx, []byte(patch), "writer@example.com", "Rename docs")
+		require.NoError(t, err)
+
+		resp, err := srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "docs"})
+		require.NoError(t, err)
+		assert.False(t, resp.Cached)
+		assert.Equal(t, "Poon Docs", resp.Title)
+
+		// Earlier versions still render as they were
+		resp, err = srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "docs", Version: 1})
+		require.NoError(t, err)
+		assert.True(t, resp.Cached)
+		assert.Equal(t, "Poon Monorepo Documentation", resp.Title)
+	})
+
+	t.Run("No README", func(t *testing.T) {
+		resp, err := srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: ""})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Path)
+		assert.Empty(t, resp.Html)
+	})
+
+	t.Run("Missing Path", func(t *testing.T) {
+		_, err := srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = srv.GetRenderedDoc(ctx, &pb.GetRenderedDocRequest{Path: "../etc"})
+		assert.Error(t, err)
+	})
+}
+
+func TestSymbolIndex(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	files := map[string]string{
+		"src/backend/handler.go": "package main\n\ntype Handler struct{}\n\nfunc (h *Handler) Serve() {}\n\nfunc NewHandler() *Handler { return &Handler{} }\n",
+		"src/backend/routes.go":  "package main\n\nfunc routes() {\n\th := NewHandler()\n\th.Serve()\n}\n",
+		"tools/handler.py":       "class Handler:\n    def serve(self):\n        pass\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoRoot, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, name), []byte(content), 0644))
+	}
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := &server{repoRoot: repoRoot, repository: repository, symbols: NewSymbolIndex(repository)}
+
+	names := func(symbols []*pb.Symbol) []string {
+		var names []string
+		for _, symbol := range symbols {
+			names = append(names, fmt.Sprintf("%s %s %s:%d:%d", symbol.Kind, symbol.Name, symbol.Path, symbol.Line, symbol.Column))
+		}
+		return names
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		disabled := &server{repoRoot: repoRoot, repository: repository}
+		_, err := disabled.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: "handler"})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		resp, err := srv.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: "handler"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"struct Handler src/backend/handler.go:3:6",
+			"class Handler tools/handler.py:1:7",
+			"function NewHandler src/backend/handler.go:7:6",
+		}, names(resp.Symbols))
+		assert.Equal(t, "go", resp.Symbols[0].Language)
+		assert.False(t, resp.Truncated)
+
+		resp, err = srv.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: "Handler", Exact: true, Path: "src"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"struct Handler src/backend/handler.go:3:6"}, names(resp.Symbols))
+
+		resp, err = srv.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: "serve", Kinds: []string{"method"}, Limit: 1})
+		require.NoError(t, err)
+		assert.Len(t, resp.Symbols, 1)
+		assert.True(t, resp.Truncated)
+
+		_, err = srv.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: " "})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Go To Definition", func(t *testing.T) {
+		resp, err := srv.GoToDefinition(ctx, &pb.GoToDefinitionRequest{Path: "src/backend/routes.go", Line: 4, Column: 9})
+		require.NoError(t, err)
+		assert.Equal(t, "NewHandler", resp.Identifier)
+		assert.Equal(t, []string{"function NewHandler src/backend/handler.go:7:6"}, names(resp.Definitions))
+
+		// Just after the name counts, and Python's serve is another name
+		resp, err = srv.GoToDefinition(ctx, &pb.GoToDefinitionRequest{Path: "src/backend/routes.go", Line: 5, Column: 9})
+		require.NoError(t, err)
+		assert.Equal(t, "Serve", resp.Identifier)
+		assert.Equal(t, []string{"method Serve src/backend/handler.go:5:19"}, names(resp.Definitions))
+
+		resp, err = srv.GoToDefinition(ctx, &pb.GoToDefinitionRequest{Path: "src/backend/routes.go", Line: 4, Column: 1})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Identifier)
+		assert.Empty(t, resp.Definitions)
+
+		_, err = srv.GoToDefinition(ctx, &pb.GoToDefinitionRequest{Path: "src/backend/routes.go", Line: 40, Column: 1})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("New Version", func(t *testing.T) {
+		patch := `--- a/src/backend/routes.go
++++ b/src/backend/routes.go
+@@ -4,3 +4,5 @@
+ 	h := NewHandler()
+ 	h.Serve()
+ }
++
++func health() {}
+`
+		_, err := repository.ApplyPatch(ctx, []byte(patch), "writer@example.com", "Add health")
+		require.NoError(t, err)
+
+		resp, err := srv.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: "health"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"function health src/backend/routes.go:8:6"}, names(resp.Symbols))
+		assert.Equal(t, int64(2), resp.Version)
+
+		resp, err = srv.SearchSymbols(ctx, &pb.SearchSymbolsRequest{Query: "health", Version: 1})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Symbols)
+	})
+
+	t.Run("Old Versions", func(t *testing.T) {
+		_, err := repository.ApplyPatch(ctx, []byte("--- a/tools/handler.py\n+++ b/tools/handler.py\n@@ -1,3 +1,5 @@\n class Handler:\n     def serve(self):\n         pass\n+\n+def main(): pass\n"), "writer@example.com", "Add main")
+		require.NoError(t, err)
+		for _, version := range []int64{3, 2} {
+			_, err := srv.symbols.Version(ctx, version)
+			require.NoError(t, err)
+		}
+
+		// Version 1 is older than both kept, so it is served but not kept
+		index, err := srv.symbols.Version(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), index.version)
+		var kept []int64
+		for _, index := range srv.symbols.versions {
+			kept = append(kept, index.version)
+		}
+		assert.Equal(t, []int64{2, 3}, kept)
+	})
+
+	t.Run("Abandoned", func(t *testing.T) {
+		blocking := &blockingEntryRepository{Repository: repository, started: make(chan struct{}), stopped: make(chan struct{})}
+		index := NewSymbolIndex(blocking)
+		waitCtx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := index.Version(waitCtx, 1)
+			errs <- err
+		}()
+
+		// The build is canceled once its only waiter gives up
+		<-blocking.started
+		cancel()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		select {
+		case <-blocking.stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("build kept running with nobody waiting")
+		}
+	})
+}
+
+// blockingEntryRepository blocks GetEntry until its context is done
+type blockingEntryRepository struct {
+	storage.Repository
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func (r *blockingEntryRepository) GetEntry(ctx context.Context, version int64, path string) (*storage.TreeEntry, error) {
+	close(r.started)
+	<-ctx.Done()
+	close(r.stopped)
+	return nil, ctx.Err()
+}
+
+func TestWorkspaceSymlinkTraversal(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"src/frontend"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	id := createResp.WorkspaceId
+	gitRepoPath := srv.workspaces[id].GitRepoPath
+
+	t.Run("Tracked Path Through Symlink", func(t *testing.T) {
+		outside := t.TempDir()
+		require.NoError(t, os.Symlink(outside, filepath.Join(gitRepoPath, "config")))
+
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: id, Path: "config"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "escapes")
+
+		entries, err := os.ReadDir(outside)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "nothing may be written outside the workspace")
+	})
+
+	t.Run("Metadata Through Symlink", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "metadata")
+		metadataPath := filepath.Join(gitRepoPath, ".poon-workspace")
+		require.NoError(t, os.Remove(metadataPath))
+		require.NoError(t, os.Symlink(outside, metadataPath))
+
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: id, Path: "docs"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		_, err = os.Stat(outside)
+		assert.True(t, os.IsNotExist(err), "metadata must not be written outside the workspace")
+	})
+}
+
+func TestSpecialCharacterPaths(t *testing.T) {
+	names := []string{
+		"docs/my notes.txt",
+		"docs/caf\u00e9.txt",
+		"docs/cafe\u0301 menu.txt", // Decomposed, as written on macOS
+		"docs/-rf",
+		"docs/say \"hi\".txt",
+		"docs/文档/说明.md",
+	}
+
+	repoRoot := createTestRepo(t)
+	for _, name := range names {
+		path := filepath.Join(repoRoot, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("one\n"), 0644))
+	}
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	// Patches come from git itself, which quotes and tab-terminates names
+	git := func(args ...string) []byte {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoRoot
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return out
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-q", "-m", "Initial commit")
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			nfc := storage.NormalizePath(name)
+
+			require.NoError(t, os.WriteFile(filepath.Join(repoRoot, filepath.FromSlash(name)), []byte("two\n"), 0644))
+			patch := git("diff", "--", name)
+
+			resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+				Path:    name,
+				Patch:   patch,
+				Message: "Update " + name,
+				Author:  "test@example.com",
+			})
+			require.NoError(t, err)
+			require.True(t, resp.Success, resp.Message)
+
+			for _, path := range []string{name, nfc} {
+				readResp, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: path})
+				require.NoError(t, err)
+				assert.Equal(t, "two\n", string(readResp.Content))
+			}
+
+			info, err := srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: name})
+			require.NoError(t, err)
+			assert.Equal(t, nfc, info.Path)
+		})
+	}
+
+	t.Run("Workspace", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		gitRepoPath := srv.workspaces[resp.WorkspaceId].GitRepoPath
+		for _, name := range names {
+			content, err := os.ReadFile(filepath.Join(gitRepoPath, filepath.FromSlash(storage.NormalizePath(name))))
+			require.NoError(t, err, name)
+			assert.Equal(t, "two\n", string(content))
+		}
+	})
+}
+
+func TestCaseCollisionsEndpoint(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs", "readme.md"), []byte("# lower\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, "Config"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "Config", "other.yaml"), []byte("x: 1\n"), 0644))
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	t.Run("List", func(t *testing.T) {
+		resp, err := srv.ListCaseCollisions(ctx, &pb.ListCaseCollisionsRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Collisions, 2)
+		assert.Equal(t, []string{"Config", "config"}, resp.Collisions[0].Paths)
+		assert.Equal(t, []string{"docs/README.md", "docs/readme.md"}, resp.Collisions[1].Paths)
+
+		resp, err = srv.ListCaseCollisions(ctx, &pb.ListCaseCollisionsRequest{Path: "src"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Collisions)
+
+		_, err = srv.ListCaseCollisions(ctx, &pb.ListCaseCollisionsRequest{Path: "../etc"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Create Workspace", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "docs/README.md, docs/readme.md")
+
+		resp, err = srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"config", "Config"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "case-insensitive")
+		assert.Empty(t, srv.workspaces)
+	})
+
+	t.Run("Add Tracked Path", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"config"}})
+		require.NoError(t, err)
+		require.True(t, createResp.Success, createResp.Message)
+
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "Config"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "config, Config")
+		assert.Equal(t, []string{"config"}, srv.workspaces[createResp.WorkspaceId].TrackedPaths)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		patch := "--- /dev/null\n+++ b/SRC/new.go\n@@ -0,0 +1 @@\n+package src\n"
+
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{Path: "SRC/new.go", Patch: []byte(patch), Message: "Add new.go", Author: "test@example.com"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "differs only in case")
+
+		previewResp, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "SRC/new.go", Patch: []byte(patch)})
+		require.NoError(t, err)
+		assert.False(t, previewResp.Success)
+		require.Len(t, previewResp.Conflicts, 1)
+		assert.Contains(t, previewResp.Conflicts[0], "collides with src")
+	})
+}
+
+func TestTrackedPatterns(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	for _, path := range []string{"src/frontend/api/app.proto", "src/backend/api/server.proto"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, path), []byte("syntax = \"proto3\";\n"), 0644))
+	}
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"src/*/api"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	workspace := srv.workspaces[createResp.WorkspaceId]
+
+	t.Run("Create Workspace", func(t *testing.T) {
+		assert.Equal(t, []string{"src/backend/api", "src/frontend/api"}, workspace.TrackedPaths)
+		assert.Equal(t, []string{"src/*/api"}, workspace.TrackedPatterns)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "src", "frontend", "api", "app.proto"))
+		assert.NoFileExists(t, filepath.Join(workspace.GitRepoPath, "src", "frontend", "app.js"))
+
+		metadata, err := os.ReadFile(filepath.Join(workspace.GitRepoPath, ".poon-workspace"))
+		require.NoError(t, err)
+		assert.Contains(t, string(metadata), "tracked_patterns:\n  - src/*/api\n")
+
+		getResp, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"src/*/api"}, getResp.Workspace.TrackedPatterns)
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		resp, err := srv.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Empty(t, resp.AddedPaths)
+		assert.Empty(t, resp.CommitHash)
+
+		newPath := filepath.Join(repoRoot, "src", "mobile", "api", "client.proto")
+		require.NoError(t, os.MkdirAll(filepath.Dir(newPath), 0755))
+		require.NoError(t, os.WriteFile(newPath, []byte("syntax = \"proto3\";\n"), 0644))
+		_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Add mobile api")
+		require.NoError(t, err)
+
+		resp, err = srv.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, []string{"src/mobile/api"}, resp.AddedPaths)
+		assert.NotEmpty(t, resp.CommitHash)
+		assert.Equal(t, []string{"src/backend/api", "src/frontend/api", "src/mobile/api"}, workspace.TrackedPaths)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "src", "mobile", "api", "client.proto"))
+
+		head, err := gitHead(ctx, workspace.GitRepoPath)
+		require.NoError(t, err)
+		assert.Equal(t, resp.CommitHash, head)
+
+		resp, err = srv.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{WorkspaceId: "missing"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+
+	t.Run("Add Tracked Path", func(t *testing.T) {
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "**/*.yaml"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, []string{"config/app.yaml"}, resp.AddedPaths)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "config", "app.yaml"))
+		assert.Equal(t, []string{"src/*/api", "**/*.yaml"}, workspace.TrackedPatterns)
+
+		// Every match is already tracked, but the pattern is still recorded
+		resp, err = srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "src/**/*.proto"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Empty(t, resp.AddedPaths)
+		assert.Contains(t, workspace.TrackedPatterns, "src/**/*.proto")
+
+		resp, err = srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "**/*.yaml"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "already tracked")
+
+		resp, err = srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "src/["})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "Invalid pattern")
+	})
+}
+
+func TestPathViews(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	writeViews := func(content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, ".poon"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, viewsFile), []byte(content), 0644))
+	}
+	writeViews(`{"views": [
+		{"name": "backend", "description": "Backend service and its docs", "paths": ["src/backend", "docs", "proto/backend"]},
+		{"name": "sources", "paths": ["src/*"]}
+	]}`)
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	t.Run("Invalid Views", func(t *testing.T) {
+		for _, content := range []string{
+			`{"views": [{"paths": ["docs"]}]}`,
+			`{"views": [{"name": "a:b", "paths": ["docs"]}]}`,
+			`{"views": [{"name": "a", "paths": ["docs"]}, {"name": "a", "paths": ["config"]}]}`,
+			`{"views": [{"name": "empty"}]}`,
+			`{"views": [{"name": "escape", "paths": ["../secrets"]}]}`,
+			`{"views": [{"name": "nested", "paths": ["view:backend"]}]}`,
+			`not json`,
+		} {
+			_, err := parseViews([]byte(content))
+			assert.Error(t, err, content)
+		}
+	})
+
+	t.Run("List Views", func(t *testing.T) {
+		resp, err := srv.ListViews(ctx, &pb.ListViewsRequest{})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		require.Len(t, resp.Views, 2)
+		assert.Equal(t, "backend", resp.Views[0].Name)
+		assert.Equal(t, "Backend service and its docs", resp.Views[0].Description)
+		assert.Equal(t, []string{"src/backend", "docs", "proto/backend"}, resp.Views[0].Paths)
+		assert.Equal(t, "sources", resp.Views[1].Name)
+	})
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"view:backend"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	workspace := srv.workspaces[createResp.WorkspaceId]
+
+	t.Run("Create Workspace", func(t *testing.T) {
+		// proto/backend does not exist yet and is left out
+		assert.Equal(t, []string{"src/backend", "docs"}, workspace.TrackedPaths)
+		assert.Equal(t, []string{"view:backend"}, workspace.TrackedPatterns)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "src", "backend", "server.go"))
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "docs", "README.md"))
+		assert.NoFileExists(t, filepath.Join(workspace.GitRepoPath, "src", "frontend", "app.js"))
+	})
+
+	t.Run("Refresh Picks Up View Changes", func(t *testing.T) {
+		newPath := filepath.Join(repoRoot, "proto", "backend", "service.proto")
+		require.NoError(t, os.MkdirAll(filepath.Dir(newPath), 0755))
+		require.NoError(t, os.WriteFile(newPath, []byte("syntax = \"proto3\";\n"), 0644))
+		writeViews(`{"views": [{"name": "backend", "paths": ["src/backend", "docs", "proto/backend", "config"]}]}`)
+		_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Grow backend view")
+		require.NoError(t, err)
+
+		resp, err := srv.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, []string{"proto/backend", "config"}, resp.AddedPaths)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "proto", "backend", "service.proto"))
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "config", "app.yaml"))
+	})
+
+	t.Run("Removed View Stops Growing", func(t *testing.T) {
+		writeViews(`{"views": []}`)
+		_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Remove backend view")
+		require.NoError(t, err)
+
+		resp, err := srv.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Empty(t, resp.AddedPaths)
+		assert.Contains(t, workspace.TrackedPaths, "config")
+	})
+
+	t.Run("Unknown View", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"view:frontend"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, `unknown view "frontend"`)
+
+		addResp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "view:frontend"})
+		require.NoError(t, err)
+		assert.False(t, addResp.Success)
+		assert.Contains(t, addResp.Message, `unknown view "frontend"`)
+	})
+
+	t.Run("Unreadable Views", func(t *testing.T) {
+		version, err := repository.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		failing := &server{repository: &failingReadRepository{Repository: repository, path: viewsFile}}
+		_, err = failing.loadViews(ctx, version)
+		assert.ErrorContains(t, err, "connection reset")
+
+		// Only a missing file means no views
+		_, err = srv.repository.CreateCommitFromFileSystem(ctx, createTestRepo(t), "test@example.com", "Remove views file")
+		require.NoError(t, err)
+		views, err := srv.loadViews(ctx, version+1)
+		require.NoError(t, err)
+		assert.Empty(t, views)
+	})
+}
+
+// failingReadRepository fails reads of path as an unreachable backend would
+type failingReadRepository struct {
+	storage.Repository
+	path string
+}
+
+func (r *failingReadRepository) ReadFile(ctx context.Context, version int64, path string) ([]byte, error) {
+	if path == r.path {
+		return nil, fmt.Errorf("failed to read blob: connection reset")
+	}
+	return r.Repository.ReadFile(ctx, version, path)
+}
+
+func TestWorkspaceLineEndings(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, storage.AttributesFile), []byte("* text=auto\n*.bat eol=crlf\n*.png binary\n"), 0644))
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"src/backend"}})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+	workspace := srv.workspaces[resp.WorkspaceId]
+
+	// Workspaces get the line ending rules, committed, so git checks files
+	// out natively and normalizes them on commit
+	content, err := os.ReadFile(filepath.Join(workspace.GitRepoPath, ".gitattributes"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "* text=auto\n*.bat eol=crlf\n*.png -text -diff -merge\n")
+
+	committed, err := gitCommand(ctx, workspace.GitRepoPath, "show", "HEAD:.gitattributes").Output()
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(committed))
+
+	attr, err := gitCommand(ctx, workspace.GitRepoPath, "check-attr", "eol", "--", "scripts/build.bat").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "scripts/build.bat: eol: crlf\n", string(attr))
+}
+
+func TestPathAttributes(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, storage.AttributesFile), []byte("*.md text eol=lf\n/src/frontend/** export-ignore\n"), 0644))
+
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:     repoRoot,
+		repository:   repository,
+		archiveCache: storage.NewArchiveCache(backend, 1<<20),
+	}
+	ctx := context.Background()
+
+	info, err := srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: "docs/README.md"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"text": storage.AttributeSet, "eol": "lf"}, info.Attributes)
+
+	info, err = srv.GetPathInfo(ctx, &pb.GetPathInfoRequest{Path: "docs"})
+	require.NoError(t, err)
+	assert.Empty(t, info.Attributes)
+
+	// Downloads leave out export-ignore files, and are not cached as the
+	// tree's archive
+	resp, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "src", Format: "tar"})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(resp.Content))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeReg {
+			names = append(names, header.Name)
+		}
+	}
+	assert.Equal(t, []string{"backend/server.go"}, names)
+	assert.Zero(t, srv.archiveCache.Stats().Entries)
+}
+
+func TestWorkspaceComposition(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	create := func(name string, paths ...string) *Workspace {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{Name: name, TrackedPaths: paths})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		return srv.workspaces[resp.WorkspaceId]
+	}
+	refresh := func(workspace *Workspace) *pb.RefreshTrackedPathsResponse {
+		resp, err := srv.RefreshTrackedPaths(ctx, &pb.RefreshTrackedPathsRequest{WorkspaceId: workspace.ID})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		return resp
+	}
+
+	base := create("platform", "docs", "src/back*")
+	product := create("product", "workspace:platform", "config")
+
+	t.Run("Extends Base", func(t *testing.T) {
+		assert.Equal(t, []string{"config", "docs", "src/backend"}, product.TrackedPaths)
+		assert.Equal(t, []string{"workspace:platform"}, product.TrackedPatterns)
+		assert.FileExists(t, filepath.Join(product.GitRepoPath, "src", "backend", "server.go"))
+	})
+
+	t.Run("Sync Cascades Through Base", func(t *testing.T) {
+		addResp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: base.ID, Path: "src/frontend"})
+		require.NoError(t, err)
+		require.True(t, addResp.Success, addResp.Message)
+
+		// A directory matching the base's pattern reaches the product
+		// without the base being refreshed first
+		newPath := filepath.Join(repoRoot, "src", "backend-jobs", "jobs.go")
+		require.NoError(t, os.MkdirAll(filepath.Dir(newPath), 0755))
+		require.NoError(t, os.WriteFile(newPath, []byte("package jobs\n"), 0644))
+		_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Add backend jobs")
+		require.NoError(t, err)
+
+		resp := refresh(product)
+		assert.ElementsMatch(t, []string{"src/frontend", "src/backend-jobs"}, resp.AddedPaths)
+		assert.FileExists(t, filepath.Join(product.GitRepoPath, "src", "backend-jobs", "jobs.go"))
+		assert.Empty(t, refresh(product).AddedPaths)
+	})
+
+	t.Run("Nested Composition", func(t *testing.T) {
+		app := create("app", "workspace:product")
+		assert.ElementsMatch(t, []string{"config", "docs", "src/backend", "src/frontend", "src/backend-jobs"}, app.TrackedPaths)
+	})
+
+	t.Run("Cycles Are Rejected", func(t *testing.T) {
+		for _, path := range []string{"workspace:app", "workspace:platform"} {
+			resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: base.ID, Path: path})
+			require.NoError(t, err)
+			assert.False(t, resp.Success, path)
+			assert.Contains(t, resp.Message, "workspace composition cycle", path)
+		}
+
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: base.ID, Path: "workspace:product"})
+		require.NoError(t, err)
+		assert.Contains(t, resp.Message, "platform -> product -> platform")
+		assert.Equal(t, []string{"src/back*"}, base.TrackedPatterns)
+	})
+
+	t.Run("Unknown Workspace", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"workspace:missing"}})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, `unknown workspace "missing"`)
+	})
+
+	t.Run("Deleted Base Stops Growing", func(t *testing.T) {
+		deleteResp, err := srv.DeleteWorkspace(ctx, &pb.DeleteWorkspaceRequest{WorkspaceId: base.ID})
+		require.NoError(t, err)
+		require.True(t, deleteResp.Success, deleteResp.Message)
+
+		assert.Empty(t, refresh(product).AddedPaths)
+		assert.Contains(t, product.TrackedPaths, "src/frontend")
+	})
+}
+
+func TestStreamingReads(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	large := strings.Repeat("0123456789abcdef", 10000) // 160000 bytes, three chunks
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs", "large.txt"), []byte(large), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, "many"), 0755))
+	for i := 0; i < streamBatchSize+10; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "many", fmt.Sprintf("f%03d", i)), []byte("x"), 0644))
+	}
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	// A second version changes docs, so reads pinned to version 1 must not see it
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs", "README.md"), []byte("# Changed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs", "new.md"), []byte("new\n"), 0644))
+	_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Second commit")
+	require.NoError(t, err)
+
+	listNames := func(t *testing.T, path string, version int64) ([]string, int64) {
+		stream := &streamRecorder[pb.StreamDirectoryResponse]{ctx: ctx}
+		require.NoError(t, srv.StreamDirectory(&pb.StreamDirectoryRequest{Path: path, Version: version}, stream))
+		require.NotEmpty(t, stream.sent)
+		var names []string
+		for _, resp := range stream.sent {
+			assert.Equal(t, stream.sent[0].Version, resp.Version)
+			for _, item := range resp.Items {
+				names = append(names, item.Name)
+			}
+		}
+		return names, stream.sent[0].Version
+	}
+
+	t.Run("Directory", func(t *testing.T) {
+		names, version := listNames(t, "docs", 0)
+		assert.Equal(t, int64(2), version)
+		assert.Equal(t, []string{"README.md", "large.txt", "new.md"}, names)
+
+		names, version = listNames(t, "docs", 1)
+		assert.Equal(t, int64(1), version)
+		assert.Equal(t, []string{"README.md", "large.txt"}, names)
+
+		stream := &streamRecorder[pb.StreamDirectoryResponse]{ctx: ctx}
+		require.NoError(t, srv.StreamDirectory(&pb.StreamDirectoryRequest{Path: "many"}, stream))
+		require.Len(t, stream.sent, 2)
+		assert.Len(t, stream.sent[0].Items, streamBatchSize)
+		assert.Len(t, stream.sent[1].Items, 10)
+
+		assert.Error(t, srv.StreamDirectory(&pb.StreamDirectoryRequest{Path: "docs", Version: 3}, &streamRecorder[pb.StreamDirectoryResponse]{ctx: ctx}))
+		assert.Error(t, srv.StreamDirectory(&pb.StreamDirectoryRequest{Path: "missing"}, &streamRecorder[pb.StreamDirectoryResponse]{ctx: ctx}))
+		assert.Error(t, srv.StreamDirectory(&pb.StreamDirectoryRequest{Path: "../etc"}, &streamRecorder[pb.StreamDirectoryResponse]{ctx: ctx}))
+	})
+
+	readFile := func(t *testing.T, req *pb.StreamFileRequest) ([]*pb.FileChunk, string) {
+		stream := &streamRecorder[pb.FileChunk]{ctx: ctx}
+		require.NoError(t, srv.StreamFile(req, stream))
+		var data strings.Builder
+		for _, chunk := range stream.sent {
+			assert.Equal(t, req.Offset+int64(data.Len()), chunk.Offset)
+			data.Write(chunk.Data)
+		}
+		return stream.sent, data.String()
+	}
+
+	t.Run("File", func(t *testing.T) {
+		chunks, data := readFile(t, &pb.StreamFileRequest{Path: "docs/large.txt"})
+		assert.Len(t, chunks, 3)
+		assert.Equal(t, large, data)
+		assert.Equal(t, int64(len(large)), chunks[0].Size)
+
+		_, data = readFile(t, &pb.StreamFileRequest{Path: "docs/large.txt", Offset: 100000, Length: 10})
+		assert.Equal(t, large[100000:100010], data)
+
+		_, data = readFile(t, &pb.StreamFileRequest{Path: "docs/large.txt", Offset: 159990, Length: 4096})
+		assert.Equal(t, large[159990:], data)
+
+		stream := &streamRecorder[pb.FileChunk]{ctx: ctx}
+		require.NoError(t, srv.StreamFile(&pb.StreamFileRequest{Path: "docs/large.txt", Offset: 200000}, stream))
+		require.Len(t, stream.sent, 1)
+		assert.Empty(t, stream.sent[0].Data)
+		assert.Equal(t, int64(len(large)), stream.sent[0].Size)
+
+		_, data = readFile(t, &pb.StreamFileRequest{Path: "docs/README.md", Version: 1})
+		assert.Contains(t, data, "Poon Monorepo Documentation")
+		chunks, data = readFile(t, &pb.StreamFileRequest{Path: "docs/README.md"})
+		assert.Equal(t, "# Changed\n", data)
+		assert.Equal(t, int64(2), chunks[0].Version)
+
+		err := srv.StreamFile(&pb.StreamFileRequest{Path: "docs/large.txt", Offset: -1}, &streamRecorder[pb.FileChunk]{ctx: ctx})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Error(t, srv.StreamFile(&pb.StreamFileRequest{Path: "docs/new.md", Version: 1}, &streamRecorder[pb.FileChunk]{ctx: ctx}))
+		assert.Error(t, srv.StreamFile(&pb.StreamFileRequest{Path: "docs"}, &streamRecorder[pb.FileChunk]{ctx: ctx}))
+	})
+}
+
+// streamRecorder collects the messages a server-streaming handler sends
+type streamRecorder[T any] struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*T
+}
+
+func (s *streamRecorder[T]) Send(msg *T) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *streamRecorder[T]) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamWorkspaceArchive(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	id := createResp.WorkspaceId
+
+	stream := &streamRecorder[pb.WorkspaceArchiveChunk]{ctx: ctx}
+	require.NoError(t, srv.StreamWorkspaceArchive(&pb.StreamWorkspaceArchiveRequest{WorkspaceId: id}, stream))
+	require.Greater(t, len(stream.sent), 1)
+
+	// The first chunk names the commit and carries no data
+	head, err := gitHead(ctx, srv.workspaces[id].GitRepoPath)
+	require.NoError(t, err)
+	assert.Equal(t, head, stream.sent[0].Commit)
+	assert.NotEmpty(t, stream.sent[0].Branch)
+	assert.Empty(t, stream.sent[0].Data)
+
+	var archive bytes.Buffer
+	for _, chunk := range stream.sent[1:] {
+		assert.LessOrEqual(t, len(chunk.Data), streamChunkSize)
+		archive.Write(chunk.Data)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(&archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeReg {
+			content, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			files[header.Name] = string(content)
+		}
+	}
+	assert.Contains(t, files["docs/README.md"], "Poon Monorepo Documentation")
+	assert.Contains(t, files, ".poon-workspace")
+	assert.Contains(t, files, ".gitignore")
+
+	err = srv.StreamWorkspaceArchive(&pb.StreamWorkspaceArchiveRequest{WorkspaceId: "missing"}, &streamRecorder[pb.WorkspaceArchiveChunk]{ctx: ctx})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestWorkspaceEditor(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{Name: "editor", TrackedPaths: []string{"src/frontend"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	id := createResp.WorkspaceId
+	assert.Equal(t, int64(1), srv.workspaces[id].SyncedVersion)
+
+	// The backend changes after the workspace was synced
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src/backend/server.go"), []byte("package main\n\nfunc changed() {}\n"), 0644))
+	_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Change backend")
+	require.NoError(t, err)
+
+	t.Run("opens untracked files at the synced version", func(t *testing.T) {
+		resp, err := srv.OpenWorkspaceFile(ctx, &pb.OpenWorkspaceFileRequest{WorkspaceId: "editor", Path: "src/backend/server.go"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Version)
+		assert.Contains(t, string(resp.Content), "Hello from backend")
+		assert.False(t, resp.Tracked)
+
+		resp, err = srv.OpenWorkspaceFile(ctx, &pb.OpenWorkspaceFileRequest{WorkspaceId: id, Path: "src/backend/server.go", Version: 2})
+		require.NoError(t, err)
+		assert.Contains(t, string(resp.Content), "changed")
+
+		resp, err = srv.OpenWorkspaceFile(ctx, &pb.OpenWorkspaceFileRequest{WorkspaceId: id, Path: "src/frontend/app.js"})
+		require.NoError(t, err)
+		assert.True(t, resp.Tracked)
+
+		_, err = srv.OpenWorkspaceFile(ctx, &pb.OpenWorkspaceFileRequest{WorkspaceId: id, Path: "src"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = srv.OpenWorkspaceFile(ctx, &pb.OpenWorkspaceFileRequest{WorkspaceId: "missing", Path: "docs/README.md"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("lists siblings with what the workspace tracks", func(t *testing.T) {
+		resp, err := srv.ListWorkspaceSiblings(ctx, &pb.ListWorkspaceSiblingsRequest{WorkspaceId: id, Path: "src/backend/new.go"})
+		require.NoError(t, err)
+		assert.Equal(t, "src/backend", resp.Directory)
+		require.Len(t, resp.Entries, 1)
+		assert.Equal(t, "src/backend/server.go", resp.Entries[0].Path)
+		assert.False(t, resp.Entries[0].Tracked)
+
+		resp, err = srv.ListWorkspaceSiblings(ctx, &pb.ListWorkspaceSiblingsRequest{WorkspaceId: id, Path: "src"})
+		require.NoError(t, err)
+		assert.Equal(t, "", resp.Directory)
+		tracked := make(map[string]bool)
+		for _, entry := range resp.Entries {
+			tracked[entry.Name] = entry.Tracked
+		}
+		assert.Equal(t, map[string]bool{"src": true, "docs": false, "config": false}, tracked)
+
+		_, err = srv.ListWorkspaceSiblings(ctx, &pb.ListWorkspaceSiblingsRequest{WorkspaceId: id, Path: "../etc/passwd"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("fetches dependencies with directories expanded", func(t *testing.T) {
+		resp, err := srv.FetchWorkspaceDependencies(ctx, &pb.FetchWorkspaceDependenciesRequest{
+			WorkspaceId: id,
+			Paths:       []string{"src/backend", "config/app.yaml", "src/frontend/app.js", "missing.go"},
+			SkipTracked: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Version)
+		require.Len(t, resp.Files, 3)
+		assert.Equal(t, "src/backend/server.go", resp.Files[0].Path)
+		assert.Contains(t, string(resp.Files[0].Content), "Hello from backend")
+		assert.Equal(t, "config/app.yaml", resp.Files[1].Path)
+		assert.NotEmpty(t, resp.Files[1].Content)
+		assert.Equal(t, "missing.go", resp.Files[2].Path)
+		assert.NotEmpty(t, resp.Files[2].Error)
+		assert.False(t, resp.Truncated)
+
+		resp, err = srv.FetchWorkspaceDependencies(ctx, &pb.FetchWorkspaceDependenciesRequest{WorkspaceId: id, Paths: []string{"src/backend", "config/app.yaml"}, MaxTotalBytes: 80})
+		require.NoError(t, err)
+		assert.True(t, resp.Truncated)
+		assert.True(t, resp.Files[1].Omitted)
+	})
+}
+
+func TestReadFiles(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:     repoRoot,
+		repository:   repository,
+		readFilesCap: 300,
+	}
+	ctx := context.Background()
+
+	// A second version changes the README, so reads pinned to version 1 must not see it
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs", "README.md"), []byte("# Changed\n"), 0644))
+	_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Second commit")
+	require.NoError(t, err)
+
+	t.Run("Partial Results", func(t *testing.T) {
+		resp, err := srv.ReadFiles(ctx, &pb.ReadFilesRequest{
+			Paths: []string{"docs/README.md", "docs/missing.md", "src", "../etc/passwd", "src/frontend/app.js"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Version)
+		assert.False(t, resp.Truncated)
+		require.Len(t, resp.Files, 5)
+
+		assert.Equal(t, "# Changed\n", string(resp.Files[0].Content))
+		assert.NotEmpty(t, resp.Files[0].Hash)
+		assert.Empty(t, resp.Files[0].Error)
+
+		assert.Equal(t, ReasonPathNotFound, resp.Files[1].Failure.GetReason())
+		assert.Contains(t, resp.Files[1].Error, "not found")
+		assert.Equal(t, ReasonNotAFile, resp.Files[2].Failure.GetReason())
+		assert.Equal(t, ReasonInvalidPath, resp.Files[3].Failure.GetReason())
+
+		assert.Contains(t, string(resp.Files[4].Content), "Hello from frontend")
+	})
+
+	t.Run("Pinned Version", func(t *testing.T) {
+		resp, err := srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: []string{"docs/README.md"}, Version: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Version)
+		assert.Contains(t, string(resp.Files[0].Content), "Poon Monorepo Documentation")
+
+		_, err = srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: []string{"docs/README.md"}, Version: 3})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Size Cap", func(t *testing.T) {
+		paths := []string{"src/frontend/app.js", "src/backend/server.go", "src/frontend/app.js", "docs/README.md"}
+		resp, err := srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: paths, MaxTotalBytes: 120})
+		require.NoError(t, err)
+		assert.True(t, resp.Truncated)
+		require.Len(t, resp.Files, 4)
+		assert.NotEmpty(t, resp.Files[0].Content)
+		assert.True(t, resp.Files[1].Omitted)
+		assert.Empty(t, resp.Files[1].Content)
+		// Files after the cap are omitted even when they would fit
+		assert.True(t, resp.Files[3].Omitted)
+
+		// A file larger than the whole cap fails instead of being omitted forever
+		resp, err = srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: paths[:2], MaxTotalBytes: 60})
+		require.NoError(t, err)
+		assert.Equal(t, ReasonFileTooLarge, resp.Files[1].Failure.GetReason())
+		assert.False(t, resp.Truncated)
+
+		// Requests cannot raise the server's cap
+		resp, err = srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: []string{"docs/README.md", "src/backend/server.go", "src/backend/server.go"}, Version: 1, MaxTotalBytes: 1 << 20})
+		require.NoError(t, err)
+		assert.True(t, resp.Truncated)
+	})
+
+	t.Run("Not Modified", func(t *testing.T) {
+		v1, err := srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: []string{"docs/README.md", "src/frontend/app.js"}, Version: 1})
+		require.NoError(t, err)
+		cached := map[string]string{
+			"docs/README.md":        v1.Files[0].Hash,
+			"src/frontend/app.js":   v1.Files[1].Hash,
+			"src/backend/server.go": "stale",
+		}
+
+		resp, err := srv.ReadFiles(ctx, &pb.ReadFilesRequest{
+			Paths:     []string{"docs/README.md", "src/frontend/app.js", "src/backend/server.go"},
+			IfNotHash: cached,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Files, 3)
+		// The README changed in version 2, so it is sent again
+		assert.False(t, resp.Files[0].NotModified)
+		assert.Equal(t, "# Changed\n", string(resp.Files[0].Content))
+		assert.True(t, resp.Files[1].NotModified)
+		assert.Empty(t, resp.Files[1].Content)
+		assert.Equal(t, v1.Files[1].Hash, resp.Files[1].Hash)
+		assert.Equal(t, v1.Files[1].Size, resp.Files[1].Size)
+		assert.False(t, resp.Files[2].NotModified)
+		assert.NotEmpty(t, resp.Files[2].Content)
+
+		// An unchanged file is not sent, so it neither fails nor fills a small cap
+		resp, err = srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: []string{"src/frontend/app.js"}, IfNotHash: cached, MaxTotalBytes: 1})
+		require.NoError(t, err)
+		assert.True(t, resp.Files[0].NotModified)
+		assert.Empty(t, resp.Files[0].Error)
+		assert.False(t, resp.Truncated)
+	})
+
+	t.Run("Too Many Paths", func(t *testing.T) {
+		_, err := srv.ReadFiles(ctx, &pb.ReadFilesRequest{Paths: make([]string, maxReadFilesPaths+1)})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGetTreeHash(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{repoRoot: repoRoot, repository: repository}
+	ctx := context.Background()
+
+	// The second version only changes docs
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "docs", "README.md"), []byte("# Changed\n"), 0644))
+	_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Second commit")
+	require.NoError(t, err)
+
+	hashes := func(t *testing.T, version int64, paths ...string) *pb.GetTreeHashResponse {
+		resp, err := srv.GetTreeHash(ctx, &pb.GetTreeHashRequest{Paths: paths, Version: version})
+		require.NoError(t, err)
+		require.Len(t, resp.Hashes, len(paths))
+		return resp
+	}
+
+	v1 := hashes(t, 1, "src", "docs", "", "docs/README.md", "missing")
+	v2 := hashes(t, 0, "src", "docs", "", "docs/README.md", "missing")
+	assert.Equal(t, int64(2), v2.Version)
+
+	assert.True(t, v2.Hashes[0].IsDir)
+	assert.Equal(t, v1.Hashes[0].Hash, v2.Hashes[0].Hash, "src did not change")
+	assert.NotEqual(t, v1.Hashes[1].Hash, v2.Hashes[1].Hash, "docs changed")
+	assert.NotEqual(t, v1.Hashes[2].Hash, v2.Hashes[2].Hash, "the root changed")
+
+	assert.False(t, v2.Hashes[3].IsDir)
+	assert.True(t, v2.Hashes[3].Exists)
+	file, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "docs/README.md"})
+	require.NoError(t, err)
+	assert.Equal(t, file.Hash, v2.Hashes[3].Hash)
+
+	assert.False(t, v2.Hashes[4].Exists)
+	assert.Empty(t, v2.Hashes[4].Hash)
+
+	_, err = srv.GetTreeHash(ctx, &pb.GetTreeHashRequest{Paths: []string{"../etc"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = srv.GetTreeHash(ctx, &pb.GetTreeHashRequest{Paths: []string{"src"}, Version: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCASFetch(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, "src", "tools"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src", "tools", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		auth:          NewAuthenticator(map[string]string{"secret-token": "alice"}),
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	handler := newCASHandler(srv, "https://cas.example.com/")
+
+	get := func(target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, strings.TrimPrefix(target, "https://cas.example.com"), nil)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	resolve := func(t *testing.T, query string) casResolution {
+		rec := get("/cas/resolve?"+query, nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res casResolution
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+
+	t.Run("RequiresToken", func(t *testing.T) {
+		rec := get("/cas/resolve?path=docs/README.md", map[string]string{"Authorization": "Bearer wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("Blob", func(t *testing.T) {
+		res := resolve(t, "path=docs/README.md")
+		assert.Equal(t, "file", res.Type)
+		assert.Equal(t, int64(1), res.Version)
+		assert.Equal(t, "https://cas.example.com/cas/blobs/"+res.Hash, res.URL)
+
+		rec := get(res.URL, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		content, err := os.ReadFile(filepath.Join(repoRoot, "docs", "README.md"))
+		require.NoError(t, err)
+		assert.Equal(t, content, rec.Body.Bytes())
+		sum := sha256.Sum256(rec.Body.Bytes())
+		assert.Equal(t, hex.EncodeToString(sum[:]), res.SHA256)
+		assert.Equal(t, int64(len(content)), res.Size)
+		assert.Contains(t, rec.Header().Get("Cache-Control"), "immutable")
+
+		rec = get(res.URL, map[string]string{"If-None-Match": rec.Header().Get("ETag")})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.Bytes())
+	})
+
+	t.Run("TreeArchive", func(t *testing.T) {
+		res := resolve(t, "path=src&version=1")
+		assert.Equal(t, "directory", res.Type)
+		assert.Equal(t, "https://cas.example.com/cas/trees/"+res.Hash+".tar.gz", res.URL)
+
+		rec := get(res.URL, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		archive := rec.Body.Bytes()
+		sum := sha256.Sum256(archive)
+		assert.Equal(t, hex.EncodeToString(sum[:]), res.SHA256)
+		assert.Equal(t, int64(len(archive)), res.Size)
+		assert.Equal(t, archive, get(res.URL, nil).Body.Bytes(), "archives must be reproducible")
+
+		gz, err := gzip.NewReader(bytes.NewReader(archive))
+		require.NoError(t, err)
+		tr := tar.NewReader(gz)
+		var names []string
+		modes := make(map[string]int64)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, hdr.Name)
+			modes[hdr.Name] = hdr.Mode
+			assert.Equal(t, int64(0), hdr.ModTime.Unix())
+		}
+		assert.Equal(t, []string{"backend/", "backend/server.go", "frontend/", "frontend/app.js", "tools/", "tools/run.sh"}, names)
+		assert.Equal(t, int64(0644), modes["backend/server.go"])
+		assert.Equal(t, int64(0755), modes["tools/run.sh"])
+
+		rec = get("/cas/trees/"+res.Hash+".tar", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-tar", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("TreeListing", func(t *testing.T) {
+		res := resolve(t, "path=")
+		rec := get("/cas/trees/"+res.Hash, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var listing struct {
+			Entries []casEntry `json:"entries"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+		var names []string
+		for _, entry := range listing.Entries {
+			names = append(names, entry.Name+":"+entry.Type)
+		}
+		assert.ElementsMatch(t, []string{"src:directory", "docs:directory", "config:directory"}, names)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		blob := resolve(t, "path=docs/README.md")
+		missing := strings.Repeat("0", 64)
+
+		assert.Equal(t, http.StatusBadRequest, get("/cas/blobs/not-a-hash", nil).Code)
+		assert.Equal(t, http.StatusNotFound, get("/cas/blobs/"+missing, nil).Code)
+		assert.Equal(t, http.StatusNotFound, get("/cas/trees/"+blob.Hash+".tar.gz", nil).Code)
+		assert.Equal(t, http.StatusNotFound, get("/cas/trees/"+blob.Hash+".zip", nil).Code)
+		assert.Equal(t, http.StatusNotFound, get("/cas/resolve?path=docs/missing.md", nil).Code)
+		assert.Equal(t, http.StatusNotFound, get("/cas/resolve?path=docs&version=7", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, get("/cas/resolve?path=docs&version=latest", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, get("/cas/resolve?path=../etc", nil).Code)
+	})
+}
+
+func TestAffectedPaths(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	ctx := context.Background()
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+
+	// Version 2 edits the frontend and moves the README
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src", "frontend", "app.js"), []byte("changed\n"), 0644))
+	require.NoError(t, os.Rename(filepath.Join(repoRoot, "docs", "README.md"), filepath.Join(repoRoot, "README.md")))
+	_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Second commit")
+	require.NoError(t, err)
+
+	// Version 3 edits the backend
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "src", "backend", "server.go"), []byte("package main\n"), 0644))
+	_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Third commit")
+	require.NoError(t, err)
+
+	affected := func(t *testing.T, req *pb.GetAffectedPathsRequest) map[string]int32 {
+		resp, err := srv.GetAffectedPaths(ctx, req)
+		require.NoError(t, err)
+		paths := make(map[string]int32)
+		var total int32
+		for _, p := range resp.Paths {
+			paths[p.Path] = p.FilesChanged
+			total += p.FilesChanged
+		}
+		assert.Equal(t, resp.FilesChanged, total)
+		return paths
+	}
+
+	t.Run("TopLevel", func(t *testing.T) {
+		resp, err := srv.GetAffectedPaths(ctx, &pb.GetAffectedPathsRequest{FromVersion: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.ToVersion)
+		var paths []string
+		for _, p := range resp.Paths {
+			paths = append(paths, p.Path)
+		}
+		assert.Equal(t, []string{"README.md", "docs", "src"}, paths)
+		assert.Equal(t, int32(2), resp.Paths[2].FilesChanged)
+	})
+
+	t.Run("Depth", func(t *testing.T) {
+		assert.Equal(t, map[string]int32{"README.md": 1, "docs/README.md": 1, "src/backend": 1, "src/frontend": 1},
+			affected(t, &pb.GetAffectedPathsRequest{FromVersion: 1, Depth: 2}))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		assert.Equal(t, map[string]int32{"src/backend": 1}, affected(t, &pb.GetAffectedPathsRequest{FromVersion: 2, ToVersion: 3, Depth: 2}))
+		assert.Empty(t, affected(t, &pb.GetAffectedPathsRequest{FromVersion: 3, ToVersion: 3}))
+		assert.Equal(t, map[string]int32{"README.md": 1, "config": 1, "src": 2}, affected(t, &pb.GetAffectedPathsRequest{ToVersion: 2}))
+	})
+
+	t.Run("InvalidRequests", func(t *testing.T) {
+		for _, tc := range []struct {
+			req   *pb.GetAffectedPathsRequest
+			field string
+		}{
+			{&pb.GetAffectedPathsRequest{FromVersion: -1}, "from_version"},
+			{&pb.GetAffectedPathsRequest{FromVersion: 1, ToVersion: -1}, "to_version"},
+			{&pb.GetAffectedPathsRequest{FromVersion: 1, Depth: -1}, "depth"},
+			{&pb.GetAffectedPathsRequest{FromVersion: 3, ToVersion: 2}, "from_version"},
+		} {
+			_, err := srv.GetAffectedPaths(ctx, tc.req)
+			st := status.Convert(err)
+			require.Equal(t, codes.InvalidArgument, st.Code(), "request %v: %v", tc.req, err)
+			require.Len(t, st.Details(), 2)
+			assert.Equal(t, ReasonInvalidArgument, st.Details()[0].(*errdetails.ErrorInfo).Reason)
+			assert.Equal(t, tc.field, st.Details()[1].(*errdetails.BadRequest).FieldViolations[0].Field, "request %v", tc.req)
+		}
+
+		_, err := srv.GetAffectedPaths(ctx, &pb.GetAffectedPathsRequest{FromVersion: 1, ToVersion: 9})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestMergeQueue(t *testing.T) {
+	newServer := func(t *testing.T, config MergeQueueConfig) (*server, func()) {
+		repoRoot := createTestRepo(t)
+		repository := storage.NewRepository(storage.NewMemoryBackend())
+		_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+		require.NoError(t, err)
+
+		srv := &server{
+			repoRoot:      repoRoot,
+			workspaceRoot: t.TempDir(),
+			workspaces:    make(map[string]*Workspace),
+			repository:    repository,
+			quotas:        NewQuotaManager(QuotaConfig{}),
+			mergeQueue:    NewMergeQueue(config, repository),
+		}
+		start := func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			go srv.mergeQueue.Run(ctx)
+		}
+		return srv, start
+	}
+
+	submitPatch := func(t *testing.T, srv *server, path, from, to string) string {
+		patch := fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -1,1 +1,1 @@\n-%s\n+%s\n", path, path, from, to)
+		resp, err := srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Path:    path,
+			Patch:   []byte(patch),
+			Message: "Set " + to,
+			Author:  "test@example.com",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.True(t, resp.Queued)
+		return resp.QueueEntryId
+	}
+	submit := func(t *testing.T, srv *server, from, to string) string {
+		return submitPatch(t, srv, "config/app.yaml", from, to)
+	}
+
+	waitFor := func(t *testing.T, srv *server, id string, state pb.QueueEntryState) *pb.QueueEntry {
+		var entry *pb.QueueEntry
+		require.Eventually(t, func() bool {
+			resp, err := srv.GetMergeQueue(context.Background(), &pb.GetMergeQueueRequest{EntryId: id})
+			require.NoError(t, err)
+			require.Len(t, resp.Entries, 1)
+			entry = resp.Entries[0]
+			return entry.State == state
+		}, 5*time.Second, 10*time.Millisecond)
+		return entry
+	}
+
+	t.Run("LandsInOrder", func(t *testing.T) {
+		srv, start := newServer(t, MergeQueueConfig{})
+		first := submit(t, srv, "environment: test", "environment: staging")
+		second := submitPatch(t, srv, "docs/README.md", "# Poon Monorepo Documentation", "# Poon")
+
+		resp, err := srv.GetMergeQueue(context.Background(), &pb.GetMergeQueueRequest{})
+		require.NoError(t, err)
+		assert.True(t, resp.Enabled)
+		require.Len(t, resp.Entries, 2)
+		assert.Equal(t, first, resp.Entries[0].Id)
+		assert.Equal(t, int32(2), resp.Entries[1].Position)
+
+		start()
+		assert.Equal(t, int64(2), waitFor(t, srv, first, pb.QueueEntryState_QUEUE_LANDED).LandedVersion)
+		landed := waitFor(t, srv, second, pb.QueueEntryState_QUEUE_LANDED)
+		assert.Equal(t, int64(3), landed.LandedVersion)
+		assert.Equal(t, int32(0), landed.Position)
+
+		content, err := srv.repository.ReadFile(context.Background(), 2, "config/app.yaml")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "environment: staging\n"))
+	})
+
+	t.Run("SurvivesRestart", func(t *testing.T) {
+		ctx := context.Background()
+		srv, start := newServer(t, MergeQueueConfig{})
+		store := storage.NewQueueStore(storage.NewMemoryBackend())
+		srv.mergeQueue.store = store
+		id := submit(t, srv, "environment: test", "environment: staging")
+
+		// The queue stopped before landing the patch; the next one lands it
+		restart := func() *MergeQueue {
+			queue := NewMergeQueue(MergeQueueConfig{}, srv.repository)
+			queue.store = store
+			require.NoError(t, queue.Recover(ctx))
+			return queue
+		}
+		srv.mergeQueue = restart()
+		entries, err := srv.mergeQueue.Entries(id)
+		require.NoError(t, err)
+		assert.Equal(t, pb.QueueEntryState_QUEUE_PENDING, entries[0].State)
+		start()
+		landed := waitFor(t, srv, id, pb.QueueEntryState_QUEUE_LANDED)
+
+		// An entry stopped after its version was created, but before it was
+		// marked landed, is not applied again
+		records, err := store.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		record := records[0]
+		record.State, record.Landing, record.LandedVersion = pb.QueueEntryState_QUEUE_VALIDATING.String(), true, 0
+		require.NoError(t, store.Put(ctx, record))
+		queue := restart()
+		entries, err = queue.Entries(id)
+		require.NoError(t, err)
+		assert.Equal(t, pb.QueueEntryState_QUEUE_LANDED, entries[0].State)
+		assert.Equal(t, landed.LandedVersion, entries[0].LandedVersion)
+		assert.Zero(t, queue.Len())
+	})
+
+	t.Run("RejectsPatchesThatNoLongerApply", func(t *testing.T) {
+		srv, start := newServer(t, MergeQueueConfig{})
+		first := submit(t, srv, "environment: test", "environment: staging")
+		second := submit(t, srv, "environment: test", "environment: prod")
+
+		start()
+		waitFor(t, srv, first, pb.QueueEntryState_QUEUE_LANDED)
+		failed := waitFor(t, srv, second, pb.QueueEntryState_QUEUE_FAILED)
+		assert.Contains(t, failed.StateMessage, "no longer applies")
+
+		// Patches that already conflict are refused up front
+		resp, err := srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Path:   "config/app.yaml",
+			Patch:  []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1,1 +1,1 @@\n-environment: test\n+environment: dev\n"),
+			Author: "test@example.com",
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.False(t, resp.Queued)
+	})
+
+	t.Run("Webhook", func(t *testing.T) {
+		webhooks := make(chan queueWebhook, 4)
+		validator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			mac := hmac.New(sha256.New, []byte("shh"))
+			mac.Write(body)
+			assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Poon-Signature"))
+
+			var hook queueWebhook
+			require.NoError(t, json.Unmarshal(body, &hook))
+			webhooks <- hook
+		}))
+		defer validator.Close()
+
+		srv, start := newServer(t, MergeQueueConfig{WebhookURL: validator.URL, WebhookSecret: "shh"})
+		start()
+		ctx := context.Background()
+
+		rejected := submit(t, srv, "environment: test", "environment: broken")
+		hook := <-webhooks
+		assert.Equal(t, rejected, hook.EntryID)
+		assert.Equal(t, int64(1), hook.BaseVersion)
+		assert.Contains(t, hook.Patch, "+environment: broken")
+		assert.Equal(t, pb.QueueEntryState_QUEUE_VALIDATING, waitFor(t, srv, rejected, pb.QueueEntryState_QUEUE_VALIDATING).State)
+
+		report, err := srv.ReportQueueValidation(ctx, &pb.ReportQueueValidationRequest{EntryId: rejected, Token: "guess", Passed: true})
+		require.NoError(t, err)
+		assert.False(t, report.Success)
+
+		report, err = srv.ReportQueueValidation(ctx, &pb.ReportQueueValidationRequest{
+			EntryId:    rejected,
+			Token:      hook.CallbackToken,
+			Message:    "unit tests failed",
+			DetailsUrl: "https://ci.example.com/runs/1",
+		})
+		require.NoError(t, err)
+		require.True(t, report.Success, report.Message)
+		failed := waitFor(t, srv, rejected, pb.QueueEntryState_QUEUE_FAILED)
+		assert.Contains(t, failed.StateMessage, "unit tests failed")
+		assert.Equal(t, "https://ci.example.com/runs/1", failed.DetailsUrl)
+
+		// A token is only good once
+		report, err = srv.ReportQueueValidation(ctx, &pb.ReportQueueValidationRequest{EntryId: rejected, Token: hook.CallbackToken, Passed: true})
+		require.NoError(t, err)
+		assert.False(t, report.Success)
+
+		accepted := submit(t, srv, "environment: test", "environment: staging")
+		hook = <-webhooks
+		report, err = srv.ReportQueueValidation(ctx, &pb.ReportQueueValidationRequest{EntryId: accepted, Token: hook.CallbackToken, Passed: true})
+		require.NoError(t, err)
+		require.True(t, report.Success, report.Message)
+		assert.Equal(t, int64(2), waitFor(t, srv, accepted, pb.QueueEntryState_QUEUE_LANDED).LandedVersion)
+
+		resp, err := srv.GetMergeQueue(ctx, &pb.GetMergeQueueRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Entries, 2)
+		assert.Equal(t, accepted, resp.Entries[0].Id, "finished entries are listed newest first")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv := &server{repository: storage.NewRepository(storage.NewMemoryBackend())}
+		resp, err := srv.GetMergeQueue(context.Background(), &pb.GetMergeQueueRequest{})
+		require.NoError(t, err)
+		assert.False(t, resp.Enabled)
+
+		report, err := srv.ReportQueueValidation(context.Background(), &pb.ReportQueueValidationRequest{EntryId: "x"})
+		require.NoError(t, err)
+		assert.False(t, report.Success)
+	})
+}
+
+func TestBranchProtection(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	alicePEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	newServer := func(t *testing.T, config ProtectionConfig, repoFiles map[string]string) *server {
+		repoRoot := createTestRepo(t)
+		for path, content := range repoFiles {
+			fullPath := filepath.Join(repoRoot, path)
+			require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+			require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+		}
+		repository := storage.NewRepository(storage.NewMemoryBackend())
+		_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+		require.NoError(t, err)
+
+		protection, err := NewBranchProtection(config)
+		require.NoError(t, err)
+		return &server{
+			repoRoot:      repoRoot,
+			workspaceRoot: t.TempDir(),
+			workspaces:    make(map[string]*Workspace),
+			repository:    repository,
+			quotas:        NewQuotaManager(QuotaConfig{}),
+			protection:    protection,
+		}
+	}
+
+	configPatch := []byte("--- a/config/app.yaml\n+++ b/config/app.yaml\n@@ -1,1 +1,1 @@\n-environment: test\n+environment: staging\n")
+	docsPatch := []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,1 +1,1 @@\n-# Poon Monorepo Documentation\n+# Poon\n")
+	submit := func(srv *server, author string, patch, signature []byte) *pb.MergePatchResponse {
+		resp, err := srv.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Patch:     patch,
+			Message:   "Update",
+			Author:    author,
+			Signature: signature,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("BlocksDirectMerge", func(t *testing.T) {
+		srv := newServer(t, ProtectionConfig{Rules: []ProtectionRule{
+			{Paths: []string{"config/"}, BlockDirectMerge: true, BypassUsers: []string{"admin"}},
+		}}, nil)
+
+		resp := submit(srv, "alice", configPatch, nil)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "branch protection")
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, "branch_protection", resp.Violations[0].Rule)
+		assert.Equal(t, "config/app.yaml", resp.Violations[0].Path)
+
+		// Paths outside the rule and bypass users are unaffected
+		assert.True(t, submit(srv, "alice", docsPatch, nil).Success)
+		assert.True(t, submit(srv, "admin", configPatch, nil).Success)
+	})
+
+	t.Run("BranchesDoNotEscapeRules", func(t *testing.T) {
+		srv := newServer(t, ProtectionConfig{Rules: []ProtectionRule{
+			{Paths: []string{"config/"}, BlockDirectMerge: true},
+		}}, nil)
+		ctx := context.Background()
+		created, err := srv.CreateBranch(ctx, &pb.CreateBranchRequest{Name: "feature"})
+		require.NoError(t, err)
+		require.True(t, created.Success, created.Message)
+
+		// The rules for main do not cover the branch, but they cover the
+		// merge that would bring its change to main
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+			Patch:   configPatch,
+			Message: "Update",
+			Author:  "alice",
+			Branch:  "feature",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		merged, err := srv.MergeBranches(ctx, &pb.MergeBranchesRequest{SourceBranch: "feature", Author: "alice"})
+		require.NoError(t, err)
+		assert.False(t, merged.Success)
+		require.Len(t, merged.Violations, 1)
+		assert.Equal(t, "config/app.yaml", merged.Violations[0].Path)
+		require.NotNil(t, merged.Failure)
+		assert.Equal(t, ReasonPolicyViolation, merged.Failure.Reason)
+	})
+
+	t.Run("RequiresApprovals", func(t *testing.T) {
+		srv := newServer(t, ProtectionConfig{Rules: []ProtectionRule{
+			{Paths: []string{"config/*.yaml"}, RequiredApprovals: 1},
+		}}, nil)
+
+		assert.False(t, submit(srv, "alice", configPatch, nil).Success)
+
+		// The author's own approval does not count
+		approval, err := srv.ApprovePatch(context.Background(), &pb.ApprovePatchRequest{Patch: configPatch, Approver: "alice"})
+		require.NoError(t, err)
+		require.True(t, approval.Success, approval.Message)
+		assert.False(t, submit(srv, "alice", configPatch, nil).Success)
+
+		approval, err = srv.ApprovePatch(context.Background(), &pb.ApprovePatchRequest{Patch: configPatch, Approver: "bob"})
+		require.NoError(t, err)
+		require.True(t, approval.Success, approval.Message)
+		assert.Equal(t, []string{"alice", "bob"}, approval.Approvers)
+		assert.Equal(t, patchDigest(configPatch), approval.PatchDigest)
+
+		resp := submit(srv, "alice", configPatch, nil)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("RequiresSignedCommits", func(t *testing.T) {
+		srv := newServer(t, ProtectionConfig{
+			Rules:       []ProtectionRule{{Paths: []string{"docs/"}, RequireSignedCommits: true}},
+			SigningKeys: map[string][]string{"alice": {alicePEM}},
+		}, nil)
+
+		signature := ed25519.Sign(privateKey, signedPatchPayload("Update", docsPatch))
+		assert.False(t, submit(srv, "alice", docsPatch, nil).Success)
+		assert.False(t, submit(srv, "alice", docsPatch, ed25519.Sign(privateKey, signedPatchPayload("Other", docsPatch))).Success)
+		assert.False(t, submit(srv, "bob", docsPatch, signature).Success, "signature must be by the author")
+
+		resp := submit(srv, "alice", docsPatch, signature)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("RulesInRepository", func(t *testing.T) {
+		srv := newServer(t, ProtectionConfig{}, map[string]string{
+			protectionFile: `{"rules": [{"paths": ["src/frontend/"], "blockDirectMerge": true}]}`,
+		})
+
+		frontendPatch := []byte("--- a/src/frontend/app.js\n+++ b/src/frontend/app.js\n@@ -1,1 +1,1 @@\n-// Sample frontend application\n+// Frontend application\n")
+		assert.False(t, submit(srv, "alice", frontendPatch, nil).Success)
+		assert.True(t, submit(srv, "alice", configPatch, nil).Success)
+	})
+
+	t.Run("InvalidRepositoryRulesOnlyAcceptFix", func(t *testing.T) {
+		srv := newServer(t, ProtectionConfig{}, map[string]string{
+			protectionFile: "{\"rules\": [\n",
+		})
+
+		resp := submit(srv, "alice", configPatch, nil)
+		assert.False(t, resp.Success)
+		require.Len(t, resp.Violations, 1)
+		assert.Equal(t, protectionFile, resp.Violations[0].Path)
+
+		fix := []byte("--- a/.poon/protection.json\n+++ b/.poon/protection.json\n@@ -1,1 +1,1 @@\n-{\"rules\": [\n+{\"rules\": []}\n")
+		resp = submit(srv, "alice", fix, nil)
+		require.True(t, resp.Success, resp.Message)
+		assert.True(t, submit(srv, "alice", configPatch, nil).Success)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		_, err := NewBranchProtection(ProtectionConfig{Rules: []ProtectionRule{{BlockDirectMerge: true}}})
+		assert.Error(t, err)
+		_, err = NewBranchProtection(ProtectionConfig{SigningKeys: map[string][]string{"alice": {"not a key"}}})
+		assert.Error(t, err)
+	})
+}
+
+// chanNotifier passes notifications to a channel
+type chanNotifier chan *Notification
+
+func (c chanNotifier) Notify(ctx context.Context, n *Notification) error {
+	c <- n
+	return nil
+}
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	slackMessages := make(chan map[string]string, 4)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		slackMessages <- message
+	}))
+	defer slack.Close()
+
+	notifications, err := NewNotifications(NotifyConfig{
+		DiffURL: "https://poon.example.com/diff/{from}..{to}",
+		Notifiers: []NotifierConfig{
+			{Name: "docs", Paths: []string{"docs/"}, Slack: &SlackConfig{WebhookURL: slack.URL, Channel: "#docs"}},
+			{Name: "backend", Paths: []string{"src/backend/"}, Email: &EmailConfig{
+				Addr: "smtp.example.com:25", From: "poon@example.com", To: []string{"backend@example.com"},
+			}},
+		},
+	}, repository)
+	require.NoError(t, err)
+
+	mails := make(chan []byte, 4)
+	notifications.routes[1].notifier.(*EmailNotifier).send = func(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:25", addr)
+		assert.Equal(t, []string{"backend@example.com"}, to)
+		mails <- msg
+		return nil
+	}
+
+	everything := make(chanNotifier, 4)
+	require.NoError(t, notifications.Add("everything", nil, everything))
+
+	events := NewEventBus()
+	events.Subscribe(notifications.Handle)
+	srv := &server{repoRoot: repoRoot, repository: repository, events: events}
+
+	resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+		Path:    "docs/README.md",
+		Patch:   []byte("--- a/docs/README.md\n+++ b/docs/README.md\n@@ -1,3 +1,4 @@\n # Poon Monorepo Documentation\n \n+Read this first.\n This is a sample monorepo for testing.\n"),
+		Message: "Explain the docs\n\nMore detail.",
+		Author:  "alice@example.com",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+
+	resp, err = srv.MergePatch(ctx, &pb.MergePatchRequest{
+		Path:    "src/backend/handler.go",
+		Patch:   []byte("--- /dev/null\n+++ b/src/backend/handler.go\n@@ -0,0 +1,2 @@\n+package main\n+// Handler\n"),
+		Message: "Add handler",
+		Author:  "bob@example.com",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+
+	// Closing waits for the notifications already published
+	events.Close()
+
+	require.Len(t, slackMessages, 1)
+	message := <-slackMessages
+	assert.Equal(t, "#docs", message["channel"])
+	assert.Contains(t, message["text"], "*[poon] Version 2: Explain the docs*")
+	assert.Contains(t, message["text"], "Version 2 by alice@example.com")
+	assert.Contains(t, message["text"], "modified docs/README.md (+2 -1)")
+	assert.Contains(t, message["text"], "https://poon.example.com/diff/1..2")
+
+	require.Len(t, mails, 1)
+	mail := string(<-mails)
+	assert.Contains(t, mail, "Subject: [poon] Version 3: Add handler\r\n")
+	assert.Contains(t, mail, "To: backend@example.com\r\n")
+	assert.Contains(t, mail, "added    src/backend/handler.go (+2 -0)")
+	assert.Contains(t, mail, "https://poon.example.com/diff/2..3")
+
+	// A notifier without paths hears about every version, in order
+	require.Len(t, everything, 2)
+	first, second := <-everything, <-everything
+	assert.Equal(t, int64(2), first.Version)
+	assert.Equal(t, "bob@example.com", second.Author)
+	assert.Equal(t, "src/backend/handler.go", second.Files[0].Path)
+
+	// Each notifier needs exactly one channel
+	_, err = NewNotifications(NotifyConfig{Notifiers: []NotifierConfig{{Name: "none"}}}, repository)
+	assert.Error(t, err)
+	_, err = NewNotifications(NotifyConfig{Notifiers: []NotifierConfig{{
+		Slack: &SlackConfig{WebhookURL: slack.URL},
+		Email: &EmailConfig{Addr: "smtp.example.com:25", From: "a@example.com", To: []string{"b@example.com"}},
+	}}}, repository)
+	assert.Error(t, err)
+}
+
+func TestEmailNotifierTimeout(t *testing.T) {
+	// An SMTP server that accepts connections but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	notifier := NewEmailNotifier(EmailConfig{
+		Addr: listener.Addr().String(), From: "poon@example.com", To: []string{"backend@example.com"},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = notifier.Notify(ctx, &Notification{Event: Event{Version: 2}})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestParseCron(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04 Mon", value)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	tests := []struct {
+		expr, from, want string
+	}{
+		{"@daily", "2024-06-01 12:30 Sat", "2024-06-02 00:00 Sun"},
+		{"0 2 * * *", "2024-06-01 02:00 Sat", "2024-06-02 02:00 Sun"},
+		{"*/15 * * * *", "2024-06-01 12:31 Sat", "2024-06-01 12:45 Sat"},
+		{"30 9 * * 1-5", "2024-06-01 12:00 Sat", "2024-06-03 09:30 Mon"},
+		{"0 0 * * 7", "2024-06-01 12:00 Sat", "2024-06-02 00:00 Sun"},
+		{"@weekly", "2024-06-02 00:00 Sun", "2024-06-09 00:00 Sun"},
+		{"0 0 1 * *", "2024-12-15 08:00 Sun", "2025-01-01 00:00 Wed"},
+		// Restricted day of month and day of week: either one matches
+		{"0 0 13 * 5", "2024-06-01 00:00 Sat", "2024-06-07 00:00 Fri"},
+	}
+	for _, test := range tests {
+		schedule, err := parseCron(test.expr)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, at(test.want), schedule.next(at(test.from)), test.expr)
+	}
+
+	never, err := parseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.next(at("2024-06-01 00:00 Sat")).IsZero())
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@often"} {
+		_, err := parseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestSnapshotScheduler(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(ctx, createTestRepo(t), "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	tags := storage.NewTagManager(backend)
+	require.NoError(t, tags.Create(ctx, &storage.Tag{Name: "nightly-manual", Version: 1, CreatedBy: "alice"}))
+
+	scheduler, err := NewSnapshotScheduler(SnapshotConfig{Schedules: []SnapshotSchedule{
+		{Name: "nightly", Schedule: "0 2 * * *", Keep: 2},
+		{Name: "weekly", Schedule: "@weekly", Tag: "weekly/{date}-v{version}", MaxAge: "336h"},
+	}}, repository, tags)
+	require.NoError(t, err)
+	nightly, weekly := scheduler.schedules[0], scheduler.schedules[1]
+
+	day := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		tag, err := scheduler.snapshot(ctx, nightly, day.AddDate(0, 0, i))
+		require.NoError(t, err)
+		require.NotNil(t, tag)
+		assert.Equal(t, int64(1), tag.Version)
+		assert.Equal(t, "schedule:nightly", tag.CreatedBy)
+	}
+
+	// A snapshot that already exists, as after a restart, is skipped
+	tag, err := scheduler.snapshot(ctx, nightly, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Nil(t, tag)
+
+	// Only the newest two nightly snapshots are kept; the manual tag with
+	// the same prefix is not the schedule's to delete
+	names := func(prefix string) []string {
+		list, err := tags.List(ctx, prefix)
+		require.NoError(t, err)
+		var result []string
+		for _, tag := range list {
+			result = append(result, tag.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"nightly-2024-06-02", "nightly-2024-06-03", "nightly-manual"}, names("nightly-"))
+
+	// Weekly snapshots expire after two weeks
+	for i := 0; i < 4; i++ {
+		_, err := scheduler.snapshot(ctx, weekly, day.AddDate(0, 0, 7*i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"weekly/2024-06-08-v1", "weekly/2024-06-15-v1", "weekly/2024-06-22-v1"}, names("weekly/"))
+
+	resp, err := (&server{repository: repository, tags: tags}).ListTags(ctx, &pb.ListTagsRequest{Prefix: "weekly/"})
+	require.NoError(t, err)
+	require.Len(t, resp.Tags, 3)
+	assert.Equal(t, int64(1), resp.Tags[0].Version)
+	assert.Equal(t, "schedule:weekly", resp.Tags[0].CreatedBy)
+
+	// Templates must give valid tag names
+	_, err = NewSnapshotScheduler(SnapshotConfig{Schedules: []SnapshotSchedule{
+		{Name: "bad", Schedule: "@daily", Tag: "{name} {date}"},
+	}}, repository, tags)
+	assert.Error(t, err)
+}
+
+func TestGetActivity(t *testing.T) {
+	ctx := context.Background()
+	activity := storage.NewActivityStore(storage.NewMemoryBackend())
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC) // A Monday
+	record := func(version int64, at time.Time, author string, dirs map[string]storage.ChangeCounts) {
+		m := &storage.VersionMetrics{Version: version, Time: at, Author: author, Directories: dirs}
+		for _, counts := range dirs {
+			m.Files += counts.Files
+			m.Insertions += counts.Insertions
+			m.Deletions += counts.Deletions
+			m.BytesAdded += counts.BytesAdded
+		}
+		require.NoError(t, activity.Record(ctx, m))
+	}
+	record(1, day.Add(9*time.Hour), "alice", map[string]storage.ChangeCounts{"": {Files: 1, Insertions: 1}})
+	record(2, day.Add(10*time.Hour), "bob", map[string]storage.ChangeCounts{"src/app": {Files: 2, Insertions: 5, BytesAdded: 100}, "src/lib": {Files: 1, Deletions: 2}})
+	record(3, day.AddDate(0, 0, 2).Add(23*time.Hour), "alice", map[string]storage.ChangeCounts{"docs": {Files: 1, Insertions: 3}})
+	record(4, day.AddDate(0, 0, 9), "carol", map[string]storage.ChangeCounts{"src": {Files: 1, Insertions: 1}})
+	srv := &server{activity: activity}
+
+	query := func(req *pb.GetActivityRequest) *pb.GetActivityResponse {
+		t.Helper()
+		req.Start, req.End = day.Unix(), day.AddDate(0, 0, 7).Unix()
+		resp, err := srv.GetActivity(ctx, req)
+		require.NoError(t, err)
+		return resp
+	}
+	keys := func(resp *pb.GetActivityResponse) (keys []string, commits []int64) {
+		for _, group := range resp.Groups {
+			keys = append(keys, group.Key)
+			commits = append(commits, group.Commits)
+		}
+		return keys, commits
+	}
+
+	// Days include empty ones; the total covers the range only
+	resp := query(&pb.GetActivityRequest{})
+	require.Len(t, resp.Groups, 7)
+	assert.Equal(t, "2024-06-03", resp.Groups[0].Key)
+	assert.Equal(t, &pb.ActivityGroup{Key: "2024-06-03", Start: day.Unix(), Commits: 2, Authors: 2, FilesChanged: 4, Insertions: 6, Deletions: 2, BytesAdded: 100}, resp.Groups[0])
+	assert.Zero(t, resp.Groups[1].Commits)
+	assert.Equal(t, int64(1), resp.Groups[2].Commits)
+	assert.Equal(t, int64(3), resp.Total.Commits)
+	assert.Equal(t, int64(2), resp.Total.Authors)
+
+	// Days start in the requested time zone
+	resp = query(&pb.GetActivityRequest{TimeZone: "Asia/Tokyo"})
+	_, commits := keys(resp)
+	assert.Equal(t, []int64{2, 0, 0, 1, 0, 0, 0, 0}, commits) // Version 3 falls on June 6th
+
+	resp = query(&pb.GetActivityRequest{GroupBy: "week"})
+	group, commits := keys(resp)
+	assert.Equal(t, []string{"2024-06-03"}, group)
+	assert.Equal(t, []int64{3}, commits)
+
+	resp = query(&pb.GetActivityRequest{GroupBy: "author"})
+	group, commits = keys(resp)
+	assert.Equal(t, []string{"alice", "bob"}, group)
+	assert.Equal(t, []int64{2, 1}, commits)
+
+	// A version touching two subdirectories counts once for their parent
+	resp = query(&pb.GetActivityRequest{GroupBy: "directory"})
+	group, commits = keys(resp)
+	assert.Equal(t, []string{"src", "/", "docs"}, group)
+	assert.Equal(t, []int64{1, 1, 1}, commits)
+	assert.Equal(t, int64(3), resp.Groups[0].FilesChanged)
+
+	resp = query(&pb.GetActivityRequest{GroupBy: "directory", DirectoryDepth: 2, Limit: 1})
+	group, _ = keys(resp)
+	assert.Equal(t, []string{"src/app"}, group)
+
+	for _, req := range []*pb.GetActivityRequest{
+		{GroupBy: "year"},
+		{TimeZone: "Nowhere/City"},
+		{Limit: -1},
+		{Start: 100, End: 100},
+		{Start: 1, End: day.Unix()},
+	} {
+		_, err := srv.GetActivity(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "%v", req)
+	}
+}
+
+func TestRestoreDeletedPath(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		locks:         storage.NewLockManager(backend),
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	original, err := os.ReadFile(filepath.Join(repoRoot, "config/app.yaml"))
+	require.NoError(t, err)
+	lines := strings.Split(string(original), "\n")
+	patch := fmt.Sprintf("diff --git a/config/app.yaml b/config/app.yaml\ndeleted file mode 100644\n--- a/config/app.yaml\n+++ /dev/null\n@@ -1,%d +0,0 @@\n-%s\n\\ No newline at end of file\n",
+		len(lines), strings.Join(lines, "\n-"))
+
+	preview, err := srv.PreviewPatch(ctx, &pb.PreviewPatchRequest{Path: "config", Patch: []byte(patch)})
+	require.NoError(t, err)
+	require.True(t, preview.Success, preview.Message)
+	assert.True(t, preview.DeletedFile)
+	assert.Equal(t, "config/app.yaml", preview.FilePath)
+
+	deleteFile := func(t *testing.T) {
+		resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{Path: "config", Patch: []byte(patch), Message: "Remove app config", Author: "alice"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+	}
+	deleteFile(t)
+
+	_, err = srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml"})
+	assert.Error(t, err)
+
+	list, err := srv.ListDeletedPaths(ctx, &pb.ListDeletedPathsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Paths, 1)
+	assert.Equal(t, "config/app.yaml", list.Paths[0].Path)
+	assert.Equal(t, int64(2), list.Paths[0].DeletedVersion)
+	assert.Equal(t, "alice", list.Paths[0].DeletedBy)
+	assert.Equal(t, int64(len(original)), list.Paths[0].Size)
+
+	resp, err := srv.RestoreDeletedPath(ctx, &pb.RestoreDeletedPathRequest{Path: "config/", Author: "bob"})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+	assert.Equal(t, int64(3), resp.Version)
+	require.Len(t, resp.Restored, 1)
+
+	content, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "config/app.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, original, content.Content)
+
+	history, err := srv.repository.GetVersionInfo(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "Restore config", history.Message)
+
+	resp, err = srv.RestoreDeletedPath(ctx, &pb.RestoreDeletedPathRequest{Path: "config/app.yaml", Author: "bob"})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	assert.Contains(t, resp.Message, "No deleted files")
+
+	t.Run("RespectsLocks", func(t *testing.T) {
+		deleteFile(t)
+		_, err := srv.locks.Acquire(ctx, "config", "carol", time.Hour)
+		require.NoError(t, err)
+
+		resp, err := srv.RestoreDeletedPath(ctx, &pb.RestoreDeletedPathRequest{Path: "config/app.yaml", Author: "bob"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "locked by carol")
+
+		resp, err = srv.RestoreDeletedPath(ctx, &pb.RestoreDeletedPathRequest{Path: "config/app.yaml", Author: "carol"})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+	})
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		resp, err := srv.RestoreDeletedPath(ctx, &pb.RestoreDeletedPathRequest{Path: "../etc"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		_, err = srv.ListDeletedPaths(ctx, &pb.ListDeletedPathsRequest{Path: "../etc"})
+		assert.Error(t, err)
+	})
+}
+
+func TestDownloadPath(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+		archiveCache:  storage.NewArchiveCache(backend, 1<<20),
+	}
+	ctx := context.Background()
+
+	archiveFiles := func(t *testing.T, content []byte, compressed bool) map[string]string {
+		var r io.Reader = bytes.NewReader(content)
+		if compressed {
+			gz, err := gzip.NewReader(r)
+			require.NoError(t, err)
+			r = gz
+		}
+		files := make(map[string]string)
+		tr := tar.NewReader(r)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return files
+			}
+			require.NoError(t, err)
+			if header.Typeflag == tar.TypeReg {
+				data, err := io.ReadAll(tr)
+				require.NoError(t, err)
+				files[header.Name] = string(data)
+			}
+		}
+	}
+
+	resp, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "src"})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+	assert.Equal(t, "src.tar.gz", resp.Filename)
+	assert.Equal(t, int64(1), resp.Version)
+	files := archiveFiles(t, resp.Content, true)
+	assert.Len(t, files, 2)
+	assert.Contains(t, files["frontend/app.js"], "Hello from frontend")
+
+	// The second download is served from the cache, byte for byte
+	again, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "src/", Format: "tar.gz"})
+	require.NoError(t, err)
+	assert.Equal(t, resp.Content, again.Content)
+	assert.Equal(t, int64(1), srv.archiveCache.Stats().Hits)
+
+	// So is a CAS fetch of the same tree
+	rec := httptest.NewRecorder()
+	newCASHandler(srv, "").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cas/trees/"+resp.TreeHash+".tar.gz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, resp.Content, rec.Body.Bytes())
+	assert.Equal(t, int64(2), srv.archiveCache.Stats().Hits)
+
+	t.Run("Tar", func(t *testing.T) {
+		resp, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "", Format: "tar"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, "monorepo.tar", resp.Filename)
+		assert.Len(t, archiveFiles(t, resp.Content, false), 4)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		stream := &streamRecorder[pb.DownloadChunk]{ctx: ctx}
+		require.NoError(t, srv.StreamDownloadPath(&pb.DownloadPathRequest{Path: "src"}, stream))
+		require.NotEmpty(t, stream.sent)
+		first := stream.sent[0]
+		assert.Equal(t, "src.tar.gz", first.Filename)
+		assert.Equal(t, int64(1), first.Version)
+		assert.Equal(t, resp.TreeHash, first.TreeHash)
+		assert.Empty(t, first.Data)
+		var content []byte
+		for _, chunk := range stream.sent[1:] {
+			content = append(content, chunk.Data...)
+		}
+		assert.Equal(t, resp.Content, content)
+
+		err := srv.StreamDownloadPath(&pb.DownloadPathRequest{Path: "missing"}, &streamRecorder[pb.DownloadChunk]{ctx: ctx})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		err = srv.StreamDownloadPath(&pb.DownloadPathRequest{Path: "src", Format: "zip"}, &streamRecorder[pb.DownloadChunk]{ctx: ctx})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Branch", func(t *testing.T) {
+		head, err := repository.BranchHead(ctx, storage.MainBranch)
+		require.NoError(t, err)
+		_, err = repository.CreateBranch(ctx, "feature", head, "alice")
+		require.NoError(t, err)
+		_, err = repository.ApplyPatchToBranch(ctx, "feature", []byte("--- /dev/null\n+++ b/src/notes.txt\n@@ -0,0 +1 @@\n+notes\n"), "alice", "Add notes", merge.ApplyOptions{})
+		require.NoError(t, err)
+		feature, err := repository.BranchHead(ctx, "feature")
+		require.NoError(t, err)
+
+		resp, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "src", Branch: "feature", Format: "tar"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, string(feature), resp.CommitHash)
+		assert.Zero(t, resp.Version)
+		files := archiveFiles(t, resp.Content, false)
+		assert.Equal(t, "notes\n", files["notes.txt"])
+
+		// main is unchanged
+		resp, err = srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "src", Branch: storage.MainBranch, Format: "tar"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, string(head), resp.CommitHash)
+		assert.NotContains(t, archiveFiles(t, resp.Content, false), "notes.txt")
+
+		resp, err = srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "src", Branch: "missing"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "Branch missing not found", resp.Message)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, req := range []*pb.DownloadPathRequest{
+			{Path: "src", Format: "zip"},
+			{Path: "docs/README.md"},
+			{Path: "missing"},
+			{Path: "../etc"},
+		} {
+			resp, err := srv.DownloadPath(ctx, req)
+			require.NoError(t, err)
+			assert.False(t, resp.Success, req.Path)
+		}
+	})
+}
+
+func TestDeadlinePolicy(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "timeouts.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"default": "50ms",
+		"slowRequest": "10ms",
+		"methods": {"MergePatch": "0", "StreamFile": "50ms"}
+	}`), 0644))
+	policy, err := LoadDeadlinePolicy(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, 50*time.Millisecond, policy.Timeout("/monorepo.MonorepoService/ReadFile"))
+	assert.Zero(t, policy.Timeout("/monorepo.MonorepoService/MergePatch"))
+	assert.Equal(t, 10*time.Minute, policy.Timeout("/monorepo.MonorepoService/CreateWorkspace"))
+
+	interceptor := policy.UnaryInterceptor()
+	info := func(method string) *grpc.UnaryServerInfo {
+		return &grpc.UnaryServerInfo{FullMethod: "/monorepo.MonorepoService/" + method}
+	}
+
+	t.Run("HandlerSeesDeadline", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, info("ReadFile"), func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, fmt.Errorf("failed to read file: %v", ctx.Err())
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Contains(t, err.Error(), "ReadFile exceeded the server's 50ms time limit")
+	})
+
+	t.Run("LandedAfterLimit", func(t *testing.T) {
+		// A handler past the point of no return is waited for, and its
+		// result reported, rather than timed out while it still commits
+		resp, err := interceptor(context.Background(), "request", info("ReadFile"), func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return req, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "request", resp)
+	})
+
+	t.Run("GitSubprocessKilled", func(t *testing.T) {
+		start := time.Now()
+		_, err := interceptor(context.Background(), nil, info("ReadFile"), func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, exec.CommandContext(ctx, "sleep", "10").Run()
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("ClientDeadlineFirst", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := interceptor(ctx, nil, info("ReadFile"), func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.NotContains(t, err.Error(), "time limit")
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), "request", info("MergePatch"), func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, hasDeadline := ctx.Deadline()
+			assert.False(t, hasDeadline)
+			time.Sleep(60 * time.Millisecond)
+			return req, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "request", resp)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		streamInfo := &grpc.StreamServerInfo{FullMethod: "/monorepo.MonorepoService/StreamFile"}
+		err := policy.StreamInterceptor()(nil, &streamRecorder[pb.FileChunk]{ctx: context.Background()}, streamInfo, func(srv interface{}, ss grpc.ServerStream) error {
+			<-ss.Context().Done()
+			return ss.SendMsg(&pb.FileChunk{})
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, config := range []string{`{"default": "soon"}`, `{"methods": {"ReadFile": "-1s"}}`, `not json`} {
+			require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+			_, err := LoadDeadlinePolicy(configPath)
+			assert.Error(t, err, config)
+		}
+	})
+}
+
+func TestGitCommandIsolation(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	ctx := context.Background()
+	require.NoError(t, gitCommand(ctx, repo, "init").Run())
+	require.NoError(t, gitCommand(ctx, repo, "config", "user.email", "test@example.com").Run())
+	require.NoError(t, gitCommand(ctx, repo, "config", "user.name", "Test").Run())
+
+	// A hook in the repository and a global config that would break commits
+	hook := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[commit]\n\tgpgSign = true\n"), 0644))
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_DIR", "/nonexistent")
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a\n"), 0644))
+	require.NoError(t, gitCommand(ctx, repo, "add", ".").Run())
+	output, err := gitCommand(ctx, repo, "commit", "-m", "first").CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	// Messages stay in English for the checks that read them
+	output, _ = gitCommand(ctx, repo, "commit", "-m", "empty").CombinedOutput()
+	assert.Contains(t, string(output), "nothing to commit")
+
+	for _, variable := range subprocess.GitEnv() {
+		assert.NotContains(t, variable, "GIT_DIR=")
+		assert.NotContains(t, variable, "HOME=")
+	}
+}
+
+func TestEmbeddedGitServer(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+
+	gitServer := httptest.NewServer(gitserver.New(srv.workspaceRoot, srv).Handler())
+	defer gitServer.Close()
+
+	// Workspaces are cloned straight from the registry
+	clone := filepath.Join(t.TempDir(), "clone")
+	output, err := exec.Command("git", "clone", gitServer.URL+"/"+createResp.WorkspaceId+".git", clone).CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.FileExists(t, filepath.Join(clone, "docs", "README.md"))
+
+	// A workspace the registry dropped is gone even if its files remain
+	repoPath, ok := srv.WorkspaceRepoPath(createResp.WorkspaceId)
+	require.True(t, ok)
+	srv.mu.Lock()
+	delete(srv.workspaces, createResp.WorkspaceId)
+	srv.mu.Unlock()
+	assert.DirExists(t, repoPath)
+
+	resp, err := http.Get(gitServer.URL + "/" + createResp.WorkspaceId + ".git/info/refs?service=git-upload-pack")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestLazyWorkspaces(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+	gitServer := httptest.NewServer(gitserver.New(srv.workspaceRoot, srv).Handler())
+	defer gitServer.Close()
+
+	t.Run("First Fetch Waits For Content", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs", "src"}, Lazy: true})
+		require.NoError(t, err)
+		require.True(t, createResp.Success, createResp.Message)
+		assert.Contains(t, createResp.Message, "3 files are being copied")
+
+		clone := filepath.Join(t.TempDir(), "clone")
+		output, err := exec.Command("git", "clone", gitServer.URL+"/"+createResp.WorkspaceId+".git", clone).CombinedOutput()
+		require.NoError(t, err, string(output))
+		assert.FileExists(t, filepath.Join(clone, "docs", "README.md"))
+		assert.FileExists(t, filepath.Join(clone, "src", "backend", "server.go"))
+
+		resp, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Equal(t, pb.WorkspaceStatus_ACTIVE, resp.Workspace.Status)
+		assert.Empty(t, resp.Workspace.StatusMessage)
+		assert.Equal(t, int64(1), resp.Workspace.Commits)
+	})
+
+	t.Run("Changes Wait Until Materialized", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		require.True(t, createResp.Success, createResp.Message)
+
+		// Still copying, then failed
+		m := &materialization{filesTotal: 10, cancel: func() {}, done: make(chan struct{})}
+		m.filesDone.Store(4)
+		srv.mu.Lock()
+		workspace := srv.workspaces[createResp.WorkspaceId]
+		workspace.materializing, workspace.Status = m, pb.WorkspaceStatus_SYNCING
+		srv.mu.Unlock()
+
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: createResp.WorkspaceId, Path: "config"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "4 of 10 files copied")
+
+		get, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Equal(t, pb.WorkspaceStatus_SYNCING, get.Workspace.Status)
+		assert.Equal(t, int64(4), get.Workspace.FilesCopied)
+		assert.Equal(t, int64(10), get.Workspace.FilesTotal)
+
+		m.err = fmt.Errorf("disk full")
+		close(m.done)
+		output, err := exec.Command("git", "ls-remote", gitServer.URL+"/"+createResp.WorkspaceId+".git").CombinedOutput()
+		assert.Error(t, err, string(output))
+		get, err = srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Contains(t, get.Workspace.StatusMessage, "disk full")
+	})
+}
+
+func TestWorkspaceOperations(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	t.Run("Lazy Create Returns An Operation", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs", "src"}, Lazy: true})
+		require.NoError(t, err)
+		require.True(t, createResp.Success, createResp.Message)
+		require.NotEmpty(t, createResp.OperationId)
+
+		getResp, err := srv.GetOperation(ctx, &pb.GetOperationRequest{OperationId: createResp.OperationId})
+		require.NoError(t, err)
+		require.True(t, getResp.Success, getResp.Message)
+		assert.Equal(t, operationCreateWorkspace, getResp.Operation.Kind)
+		assert.Equal(t, createResp.WorkspaceId, getResp.Operation.Result["workspace_id"])
+		assert.Equal(t, int64(3), getResp.Operation.ProgressTotal)
+
+		waitResp, err := srv.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: createResp.OperationId, TimeoutSeconds: 30})
+		require.NoError(t, err)
+		require.True(t, waitResp.Success, waitResp.Message)
+		assert.True(t, waitResp.Operation.Done)
+		assert.Empty(t, waitResp.Operation.Error)
+		assert.Equal(t, int64(3), waitResp.Operation.ProgressDone)
+		assert.NotEmpty(t, waitResp.Operation.FinishedAt)
+
+		ws, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Equal(t, pb.WorkspaceStatus_ACTIVE, ws.Workspace.Status)
+	})
+
+	t.Run("Immediate Create Names Its Finished Operation", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		require.True(t, createResp.Success, createResp.Message)
+		require.NotEmpty(t, createResp.OperationId)
+
+		// Without lazy the copy is done when the response comes
+		getResp, err := srv.GetOperation(ctx, &pb.GetOperationRequest{OperationId: createResp.OperationId})
+		require.NoError(t, err)
+		require.True(t, getResp.Success, getResp.Message)
+		assert.True(t, getResp.Operation.Done)
+		assert.Empty(t, getResp.Operation.Error)
+		assert.Equal(t, getResp.Operation.ProgressTotal, getResp.Operation.ProgressDone)
+
+		ws, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: createResp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Equal(t, pb.WorkspaceStatus_ACTIVE, ws.Workspace.Status)
+	})
+
+	t.Run("Deleted Workspace Fails Its Operation", func(t *testing.T) {
+		m := &materialization{filesTotal: 10, cancel: func() {}, done: make(chan struct{})}
+		op := srv.operations.start(ctx, &operation{
+			progress: m.progress,
+			record:   storage.Operation{Kind: operationCreateWorkspace, Owner: "alice"},
+		})
+
+		waitResp, err := srv.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: op.record.ID, TimeoutSeconds: 1})
+		require.NoError(t, err)
+		assert.False(t, waitResp.Operation.Done)
+		assert.Equal(t, "running", waitResp.Operation.Status)
+		assert.Equal(t, int64(10), waitResp.Operation.ProgressTotal)
+
+		srv.operations.finish(op, nil, fmt.Errorf("the workspace was deleted"))
+		getResp, err := srv.GetOperation(ctx, &pb.GetOperationRequest{OperationId: op.record.ID})
+		require.NoError(t, err)
+		assert.True(t, getResp.Operation.Done)
+		assert.Equal(t, "failed", getResp.Operation.Status)
+		assert.Equal(t, "the workspace was deleted", getResp.Operation.Error)
+	})
+
+	t.Run("Unknown Operation", func(t *testing.T) {
+		resp, err := srv.GetOperation(ctx, &pb.GetOperationRequest{OperationId: "missing"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+
+		waitResp, err := srv.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: "missing"})
+		require.NoError(t, err)
+		assert.False(t, waitResp.Success)
+	})
+
+	t.Run("Finished Operations Expire", func(t *testing.T) {
+		op := srv.operations.start(ctx, &operation{record: storage.Operation{Kind: operationCreateWorkspace}})
+		srv.operations.finish(op, nil, nil)
+		op.mu.Lock()
+		op.record.FinishedAt = time.Now().Add(-operationRetention - time.Minute)
+		op.mu.Unlock()
+
+		_, err := srv.operations.get(ctx, op.record.ID, false)
+		assert.ErrorIs(t, err, errOperationNotFound)
+	})
+}
+
+func TestLongRunningOperations(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	store := storage.NewOperationStore(backend)
+	srv := &server{
+		repoRoot:   repoRoot,
+		workspaces: make(map[string]*Workspace),
+		repository: repository,
+		quotas:     NewQuotaManager(QuotaConfig{}),
+		operations: operationRegistry{store: store},
+	}
+	admin := &adminServer{srv: srv}
+	ctx := context.Background()
+
+	t.Run("Async Garbage Collection", func(t *testing.T) {
+		resp, err := admin.RunGarbageCollection(ctx, &pb.GarbageCollectionRequest{DryRun: true, Async: true})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.OperationId)
+
+		wait, err := admin.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: resp.OperationId, TimeoutSeconds: 30})
+		require.NoError(t, err)
+		require.True(t, wait.Operation.Done)
+		assert.Equal(t, operationGC, wait.Operation.Kind)
+		assert.Equal(t, "succeeded", wait.Operation.Status)
+
+		var result pb.GarbageCollectionResponse
+		require.NoError(t, proto.Unmarshal(wait.Operation.Response, &result))
+		assert.Positive(t, result.Scanned)
+		assert.Equal(t, result.Scanned, result.Reachable)
+		assert.Equal(t, result.Scanned, wait.Operation.ProgressDone)
+		assert.Equal(t, result.Scanned, wait.Operation.ProgressTotal)
+
+		// Admin operations are not visible through the user API
+		get, err := srv.GetOperation(ctx, &pb.GetOperationRequest{OperationId: resp.OperationId})
+		require.NoError(t, err)
+		assert.False(t, get.Success)
+
+		// The record outlives the process that ran it
+		restarted := &operationRegistry{store: store}
+		op, err := restarted.get(ctx, resp.OperationId, true)
+		require.NoError(t, err)
+		assert.Equal(t, wait.Operation.Response, op.snapshot().Response)
+	})
+
+	t.Run("Async Fsck", func(t *testing.T) {
+		resp, err := admin.Fsck(ctx, &pb.FsckRequest{Async: true})
+		require.NoError(t, err)
+		wait, err := admin.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: resp.OperationId, TimeoutSeconds: 30})
+		require.NoError(t, err)
+		require.Equal(t, "succeeded", wait.Operation.Status, wait.Operation.Error)
+
+		var result pb.FsckResponse
+		require.NoError(t, proto.Unmarshal(wait.Operation.Response, &result))
+		assert.Positive(t, result.ObjectsChecked)
+		assert.Empty(t, result.Problems)
+	})
+
+	t.Run("Async Download", func(t *testing.T) {
+		resp, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "docs", Format: "tar", Async: true})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Empty(t, resp.Content)
+
+		wait, err := srv.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: resp.OperationId, TimeoutSeconds: 30})
+		require.NoError(t, err)
+		require.Equal(t, "succeeded", wait.Operation.Status, wait.Operation.Error)
+		var result pb.DownloadPathResponse
+		require.NoError(t, proto.Unmarshal(wait.Operation.Response, &result))
+		assert.Equal(t, "docs.tar", result.Filename)
+		assert.NotEmpty(t, result.Content)
+
+		// The stored operation names the archive rather than holding it
+		stored, err := store.Get(ctx, resp.OperationId)
+		require.NoError(t, err)
+		var reference pb.DownloadPathResponse
+		require.NoError(t, proto.Unmarshal(stored.Response, &reference))
+		assert.Empty(t, reference.Content)
+		assert.Equal(t, "docs", reference.Path)
+		assert.Equal(t, result.Version, reference.Version)
+
+		list, err := srv.ListOperations(ctx, &pb.ListOperationsRequest{})
+		require.NoError(t, err)
+		require.NotEmpty(t, list.Operations)
+		assert.Equal(t, resp.OperationId, list.Operations[0].Id)
+		assert.Empty(t, list.Operations[0].Response)
+		for _, op := range list.Operations {
+			assert.NotEqual(t, operationGC, op.Kind)
+		}
+
+		missing, err := srv.DownloadPath(ctx, &pb.DownloadPathRequest{Path: "missing", Async: true})
+		require.NoError(t, err)
+		wait, err = srv.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: missing.OperationId, TimeoutSeconds: 30})
+		require.NoError(t, err)
+		assert.Equal(t, "failed", wait.Operation.Status)
+		assert.Contains(t, wait.Operation.Error, "not found")
+		assert.Empty(t, wait.Operation.Response)
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		id := srv.runOperation(ctx, operationMigrateBackend, false, func(ctx context.Context) (proto.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		resp, err := srv.CancelOperation(ctx, &pb.CancelOperationRequest{OperationId: id})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		wait, err := srv.WaitOperation(ctx, &pb.WaitOperationRequest{OperationId: id, TimeoutSeconds: 30})
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", wait.Operation.Status)
+
+		resp, err = srv.CancelOperation(ctx, &pb.CancelOperationRequest{OperationId: id})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "already cancelled")
+	})
+
+	t.Run("Only The Owner Sees An Operation", func(t *testing.T) {
+		srv.auth = NewAuthenticator(map[string]string{"alice-token": "alice", "bob-token": "bob"})
+		defer func() { srv.auth = nil }()
+		aliceCtx := context.WithValue(ctx, userContextKey, "alice")
+		bobCtx := context.WithValue(ctx, userContextKey, "bob")
+
+		id := srv.runOperation(aliceCtx, operationMigrateBackend, false, func(ctx context.Context) (proto.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		get, err := srv.GetOperation(bobCtx, &pb.GetOperationRequest{OperationId: id})
+		require.NoError(t, err)
+		assert.False(t, get.Success)
+		assert.Contains(t, get.Message, "not found")
+		wait, err := srv.WaitOperation(bobCtx, &pb.WaitOperationRequest{OperationId: id, TimeoutSeconds: 1})
+		require.NoError(t, err)
+		assert.False(t, wait.Success)
+		cancel, err := srv.CancelOperation(bobCtx, &pb.CancelOperationRequest{OperationId: id})
+		require.NoError(t, err)
+		assert.False(t, cancel.Success)
+
+		get, err = srv.GetOperation(aliceCtx, &pb.GetOperationRequest{OperationId: id})
+		require.NoError(t, err)
+		assert.True(t, get.Success, get.Message)
+		cancel, err = srv.CancelOperation(aliceCtx, &pb.CancelOperationRequest{OperationId: id})
+		require.NoError(t, err)
+		assert.True(t, cancel.Success, cancel.Message)
+	})
+
+	t.Run("Interrupted By A Restart", func(t *testing.T) {
+		record := &storage.Operation{ID: "interrupted", Kind: operationFsck, Admin: true, Status: storage.OperationRunning, CreatedAt: time.Now()}
+		require.NoError(t, store.Put(ctx, record))
+
+		restarted := &operationRegistry{store: store}
+		require.NoError(t, restarted.recover(ctx))
+		op, err := restarted.get(ctx, "interrupted", true)
+		require.NoError(t, err)
+		assert.Equal(t, storage.OperationFailed, op.snapshot().Status)
+		assert.Equal(t, "interrupted by a server restart", op.snapshot().Error)
+	})
+}
+
+func TestWorkspaceNames(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{Name: "team-docs", TrackedPaths: []string{"docs"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+
+	t.Run("Name Resolves To ID", func(t *testing.T) {
+		resp, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: "team-docs"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Equal(t, createResp.WorkspaceId, resp.Workspace.Id)
+		assert.Equal(t, "team-docs", resp.Workspace.Name)
+
+		repoPath, ok := srv.WorkspaceRepoPath("team-docs")
+		require.True(t, ok)
+		assert.Equal(t, filepath.Join(srv.workspaceRoot, createResp.WorkspaceId, "repo"), repoPath)
+	})
+
+	t.Run("Unnamed Workspaces Keep Their ID", func(t *testing.T) {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		defer srv.DeleteWorkspace(ctx, &pb.DeleteWorkspaceRequest{WorkspaceId: resp.WorkspaceId})
+
+		get, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: resp.WorkspaceId})
+		require.NoError(t, err)
+		assert.Equal(t, resp.WorkspaceId, get.Workspace.Name)
+	})
+
+	t.Run("Rejected Names", func(t *testing.T) {
+		for _, name := range []string{"team-docs", createResp.WorkspaceId, "Team", "-docs", "docs.git", "a/b", uuid.New().String(), strings.Repeat("a", 64)} {
+			resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{Name: name, TrackedPaths: []string{"docs"}})
+			require.NoError(t, err)
+			assert.False(t, resp.Success, "name %q should be rejected", name)
+		}
+		assert.Len(t, srv.workspaces, 1)
+	})
+
+	t.Run("Clone By Name", func(t *testing.T) {
+		// The standalone git server finds the workspace through its name link
+		gitServer := httptest.NewServer(gitserver.New(srv.workspaceRoot, nil).Handler())
+		defer gitServer.Close()
+
+		clone := filepath.Join(t.TempDir(), "clone")
+		output, err := exec.Command("git", "clone", gitServer.URL+"/team-docs.git", clone).CombinedOutput()
+		require.NoError(t, err, string(output))
+		assert.FileExists(t, filepath.Join(clone, "docs", "README.md"))
+	})
+
+	t.Run("Delete By Name", func(t *testing.T) {
+		resp, err := srv.DeleteWorkspace(ctx, &pb.DeleteWorkspaceRequest{WorkspaceId: "team-docs"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		assert.Empty(t, srv.workspaces)
+		assert.NoFileExists(t, filepath.Join(srv.workspaceRoot, "team-docs"))
+
+		// The name is free again
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{Name: "team-docs", TrackedPaths: []string{"docs"}})
+		require.NoError(t, err)
+		assert.True(t, createResp.Success, createResp.Message)
+	})
+}
+
+func TestWorkspaceTemplates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	writeConfig := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "templates.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("Invalid Configs", func(t *testing.T) {
+		for _, content := range []string{
+			`{"templates": [{"trackedPaths": ["docs"]}]}`,
+			`{"templates": [{"name": "a", "trackedPaths": ["docs"]}, {"name": "a", "trackedPaths": ["config"]}]}`,
+			`{"templates": [{"name": "empty"}]}`,
+			`{"templates": [{"name": "escape", "trackedPaths": ["../secrets"]}]}`,
+			`{"templates": [{"name": "reserved", "trackedPaths": ["docs"], "metadata": {"template": "other"}}]}`,
+			`not json`,
+		} {
+			_, err := LoadWorkspaceTemplates(writeConfig(t, content))
+			assert.Error(t, err, content)
+		}
+	})
+
+	templates, err := LoadWorkspaceTemplates(writeConfig(t, `{"templates": [
+		{"name": "backend-dev", "description": "Backend service and its config", "trackedPaths": ["src/backend", "config"], "metadata": {"team": "backend", "tier": "1"}},
+		{"name": "docs", "trackedPaths": ["docs"]}
+	]}`))
+	require.NoError(t, err)
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err = repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+		templates:     templates,
+	}
+	ctx := context.Background()
+
+	t.Run("List Templates", func(t *testing.T) {
+		resp, err := srv.ListTemplates(ctx, &pb.ListTemplatesRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Templates, 2)
+		assert.Equal(t, "backend-dev", resp.Templates[0].Name)
+		assert.Equal(t, "Backend service and its config", resp.Templates[0].Description)
+		assert.Equal(t, []string{"src/backend", "config"}, resp.Templates[0].TrackedPaths)
+		assert.Equal(t, "docs", resp.Templates[1].Name)
+	})
+
+	t.Run("Create From Template", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{
+			Template:     "backend-dev",
+			TrackedPaths: []string{"docs", "config"},
+			Metadata:     map[string]string{"tier": "2"},
+		})
+		require.NoError(t, err)
+		require.True(t, createResp.Success, createResp.Message)
+
+		workspace := srv.workspaces[createResp.WorkspaceId]
+		assert.Equal(t, []string{"src/backend", "config", "docs"}, workspace.TrackedPaths)
+		assert.Equal(t, map[string]string{"team": "backend", "tier": "2", "template": "backend-dev"}, workspace.Metadata)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "src", "backend", "server.go"))
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, "docs", "README.md"))
+
+		// The template itself is unchanged
+		assert.Equal(t, []string{"src/backend", "config"}, templates["backend-dev"].TrackedPaths)
+	})
+
+	t.Run("Unknown Template", func(t *testing.T) {
+		createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{Template: "frontend-dev"})
+		require.NoError(t, err)
+		assert.False(t, createResp.Success)
+		assert.Contains(t, createResp.Message, `unknown template "frontend-dev"`)
+	})
+}
+
+func TestWorkspaceHistoryCompaction(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	admin := &adminServer{srv: srv}
+	ctx := context.Background()
+
+	createResp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	id := createResp.WorkspaceId
+	for _, path := range []string{"config", "src/frontend"} {
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: id, Path: path})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+	}
+	workspace := srv.workspaces[id]
+	assert.Equal(t, 3, workspace.Commits)
+	assert.Positive(t, workspace.DiskBytes)
+
+	// A client holding the full history
+	clone := filepath.Join(t.TempDir(), "clone")
+	require.NoError(t, gitCommand(ctx, "", "clone", "-q", workspace.GitRepoPath, clone).Run())
+
+	t.Run("Keeps The Last Commits", func(t *testing.T) {
+		resp, err := admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{WorkspaceId: id, Depth: 1})
+		require.NoError(t, err)
+		require.Len(t, resp.Workspaces, 1)
+		compaction := resp.Workspaces[0]
+		assert.Equal(t, int64(1), compaction.Depth)
+		assert.Equal(t, int64(3), compaction.CommitsBefore)
+		assert.Equal(t, int64(1), compaction.CommitsAfter)
+		assert.Equal(t, 1, workspace.Commits)
+		assert.FileExists(t, filepath.Join(workspace.GitRepoPath, ".git", "shallow"))
+
+		out, err := gitCommand(ctx, workspace.GitRepoPath, "fsck", "--no-progress").CombinedOutput()
+		assert.NoError(t, err, string(out))
+		head, err := gitHead(ctx, clone)
+		require.NoError(t, err)
+		serverHead, err := gitHead(ctx, workspace.GitRepoPath)
+		require.NoError(t, err)
+		assert.Equal(t, head, serverHead, "compaction should not change commit hashes")
+	})
+
+	t.Run("Clients Keep Pulling", func(t *testing.T) {
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: id, Path: "src/backend"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+
+		out, err := gitCommand(ctx, clone, "pull", "-q", "--ff-only").CombinedOutput()
+		require.NoError(t, err, string(out))
+		count, err := countCommits(ctx, clone)
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("Nothing To Compact", func(t *testing.T) {
+		resp, err := admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Workspaces, 1)
+		assert.Zero(t, resp.Workspaces[0].Depth, "without WORKSPACE_HISTORY_DEPTH only usage is measured")
+		assert.Equal(t, int64(2), resp.Workspaces[0].CommitsAfter)
+
+		_, err = admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{WorkspaceId: "missing"})
+		assert.Error(t, err)
+		_, err = admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{Depth: -1})
+		assert.Error(t, err)
+	})
+
+	t.Run("Keeps What Clients Build On", func(t *testing.T) {
+		base, err := gitHead(ctx, workspace.GitRepoPath)
+		require.NoError(t, err)
+		for _, name := range []string{"TODO.md", "CHANGES.md"} {
+			require.NoError(t, os.WriteFile(filepath.Join(repoRoot, name), []byte(name+"\n"), 0644))
+			_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Add "+name)
+			require.NoError(t, err)
+			resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: id, Path: name})
+			require.NoError(t, err)
+			require.True(t, resp.Success, resp.Message)
+		}
+		workspace.HeadCommit = base
+		defer func() { workspace.HeadCommit = "" }()
+
+		resp, err := admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{WorkspaceId: id, Depth: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.Workspaces[0].Depth, "the client's commit and the two after it should be kept")
+		assert.Equal(t, int64(3), resp.Workspaces[0].CommitsAfter)
+
+		// A client on commits the server never saw may build on any of them
+		workspace.HeadCommit = strings.Repeat("0", 40)
+		resp, err = admin.CompactWorkspaces(ctx, &pb.CompactWorkspacesRequest{WorkspaceId: id, Depth: 1})
+		require.NoError(t, err)
+		assert.Zero(t, resp.Workspaces[0].Depth)
+		assert.Equal(t, int64(3), resp.Workspaces[0].CommitsAfter)
+	})
+
+	t.Run("Disk Limit", func(t *testing.T) {
+		srv.quotas.SetUserLimits(anonymousUser, QuotaLimits{MaxWorkspaceDiskBytes: 1})
+		defer srv.quotas.SetUserLimits(anonymousUser, QuotaLimits{})
+
+		quota, err := srv.GetQuota(ctx, &pb.GetQuotaRequest{WorkspaceId: id})
+		require.NoError(t, err)
+		assert.Equal(t, workspace.DiskBytes, quota.WorkspaceUsage.DiskBytesUsed)
+		assert.Equal(t, int64(1), quota.WorkspaceUsage.DiskBytesLimit)
+
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "NOTES.md"), []byte("notes\n"), 0644))
+		_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Add notes")
+		require.NoError(t, err)
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: id, Path: "NOTES.md"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "on disk")
+		assert.Equal(t, 1, workspace.Commits, "a workspace over its disk limit keeps one commit")
+	})
+}
+
+func TestWorkspaceSharedObjects(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoRoot := createTestRepo(t)
+	repository := storage.NewRepository(storage.NewMemoryBackend())
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	workspaceRoot := t.TempDir()
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: workspaceRoot,
+		sharedObjects: filepath.Join(workspaceRoot, sharedObjectsDir),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	ctx := context.Background()
+
+	create := func() *Workspace {
+		resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs", "config"}})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		return srv.workspaces[resp.WorkspaceId]
+	}
+	looseObjects := func(dir string) int {
+		count := 0
+		filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && isLooseObject(filepath.Base(filepath.Dir(path)), entry.Name()) {
+				count++
+			}
+			return nil
+		})
+		return count
+	}
+	fsck := func(workspace *Workspace) {
+		out, err := gitCommand(ctx, workspace.GitRepoPath, "fsck", "--no-progress").CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	remove := func(workspace *Workspace) {
+		delete(srv.workspaces, workspace.ID)
+		require.NoError(t, os.RemoveAll(filepath.Dir(workspace.GitRepoPath)))
+	}
+
+	first, second := create(), create()
+
+	t.Run("Identical Content Is Stored Once", func(t *testing.T) {
+		blob, err := gitCommand(ctx, first.GitRepoPath, "rev-parse", "HEAD:docs/README.md").Output()
+		require.NoError(t, err)
+		hash := strings.TrimSpace(string(blob))
+		assert.FileExists(t, filepath.Join(srv.sharedObjects, hash[:2], hash[2:]))
+
+		for _, workspace := range []*Workspace{first, second} {
+			assert.Zero(t, looseObjects(filepath.Join(workspace.GitRepoPath, ".git", "objects")))
+			fsck(workspace)
+		}
+	})
+
+	t.Run("Collection Keeps Used Objects", func(t *testing.T) {
+		shared := looseObjects(srv.sharedObjects)
+		result, err := srv.collectSharedObjects(ctx, 0, false)
+		require.NoError(t, err)
+		assert.Zero(t, result.Objects)
+
+		// The other workspace still uses every blob and tree
+		remove(second)
+		result, err = srv.collectSharedObjects(ctx, 0, false)
+		require.NoError(t, err)
+		assert.Less(t, result.Objects, 2, "only the second workspace's own commit should go")
+		assert.Equal(t, shared-result.Objects, looseObjects(srv.sharedObjects))
+		fsck(first)
+	})
+
+	t.Run("Compaction Frees Shared History", func(t *testing.T) {
+		resp, err := srv.AddTrackedPath(ctx, &pb.AddTrackedPathRequest{WorkspaceId: first.ID, Path: "src"})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		first.repoMu.Lock()
+		_, err = srv.compactWorkspace(ctx, first, 1)
+		first.repoMu.Unlock()
+		require.NoError(t, err)
+		fsck(first)
+
+		dryRun, err := srv.collectSharedObjects(ctx, 0, true)
+		require.NoError(t, err)
+		assert.Positive(t, dryRun.Objects, "the first commit and its root tree should be unused")
+		result, err := srv.collectSharedObjects(ctx, 0, false)
+		require.NoError(t, err)
+		assert.Equal(t, dryRun, result)
+		fsck(first)
+	})
+
+	t.Run("Unused Objects Are Removed", func(t *testing.T) {
+		remove(first)
+		result, err := srv.collectSharedObjects(ctx, time.Hour, false)
+		require.NoError(t, err)
+		assert.Zero(t, result.Objects, "recent objects should be spared")
+
+		result, err = srv.collectSharedObjects(ctx, 0, false)
+		require.NoError(t, err)
+		assert.Positive(t, result.Objects)
+		assert.Zero(t, looseObjects(srv.sharedObjects))
+	})
+}
+
+func TestStart(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Addr = "localhost:0"
+	cfg.GitAddr = "localhost:0"
+	cfg.RepoRoot = createTestRepo(t)
+	cfg.WorkspaceRoot = t.TempDir()
+	cfg.Reflection = true
+	cfg.OpsAddr = "localhost:0"
+
+	inst, err := Start(cfg)
+	require.NoError(t, err)
+	defer inst.Stop()
+
+	conn, err := grpc.NewClient(inst.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewMonorepoServiceClient(conn)
+
+	ctx := context.Background()
+	readResp, err := client.ReadFile(ctx, &pb.ReadFileRequest{Path: "docs/README.md"})
+	require.NoError(t, err)
+	assert.Contains(t, string(readResp.Content), "Documentation")
+
+	// Reflection lists the API for tools such as grpcurl
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	reflectResp, err := stream.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, service := range reflectResp.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	assert.Contains(t, services, "monorepo.MonorepoService")
+	require.NoError(t, stream.CloseSend())
+
+	// Remote URLs name the embedded git server's port
+	createResp, err := client.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+	require.NoError(t, err)
+	require.True(t, createResp.Success, createResp.Message)
+	gitPort := inst.GitAddr().(*net.TCPAddr).Port
+	assert.Equal(t, fmt.Sprintf("http://localhost:%d/%s.git", gitPort, createResp.WorkspaceId), createResp.RemoteUrl)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/health", inst.GitAddr()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The ops port reports runtime and repository diagnostics
+	resp, err = http.Get(fmt.Sprintf("http://%s/debug/vars", inst.OpsAddr()))
+	require.NoError(t, err)
+	var vars struct {
+		Memstats     map[string]interface{} `json:"memstats"`
+		Goroutines   int                    `json:"goroutines"`
+		Subprocesses int                    `json:"subprocesses"`
+		Workspaces   int                    `json:"workspaces"`
+		Backend      struct{ Keys, Bytes int64 }
+		Queues       map[string]int
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&vars))
+	resp.Body.Close()
+	assert.NotEmpty(t, vars.Memstats)
+	assert.Greater(t, vars.Goroutines, 0)
+	assert.GreaterOrEqual(t, vars.Subprocesses, 0)
+	assert.Equal(t, 1, vars.Workspaces)
+	assert.Greater(t, vars.Backend.Bytes, int64(0))
+	assert.Contains(t, vars.Queues, "events")
+
+	resp, err = http.Get(fmt.Sprintf("http://%s/debug/pprof/goroutine?debug=1", inst.OpsAddr()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Stopping frees the ports and ends Wait
+	inst.Stop()
+	assert.NoError(t, inst.Wait())
+	lis, err := net.Listen("tcp", inst.Addr().String())
+	require.NoError(t, err)
+	lis.Close()
+
+	// A port in use fails Start without leaving the others open
+	busy, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	cfg.GitAddr = busy.Addr().String()
+	_, err = Start(cfg)
+	assert.Error(t, err)
+}
+
+func TestGRPCWeb(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Addr = "localhost:0"
+	cfg.GRPCWebAddr = "localhost:0"
+	cfg.CORSAllowedOrigins = []string{"https://poon.example.com"}
+	cfg.RepoRoot = createTestRepo(t)
+	cfg.WorkspaceRoot = t.TempDir()
+
+	inst, err := Start(cfg)
+	require.NoError(t, err)
+	defer inst.Stop()
+	url := fmt.Sprintf("http://%s/monorepo.MonorepoService/ReadFile", inst.GRPCWebAddr())
+
+	// call sends one message the way grpc-web clients do and returns the
+	// messages and trailers of the response
+	call := func(req proto.Message, text bool) ([][]byte, string, *http.Response) {
+		data, err := proto.Marshal(req)
+		require.NoError(t, err)
+		frame := append([]byte{0, 0, 0, 0, byte(len(data))}, data...)
+		contentType := "application/grpc-web+proto"
+		var body io.Reader = bytes.NewReader(frame)
+		if text {
+			contentType = "application/grpc-web-text"
+			body = strings.NewReader(base64.StdEncoding.EncodeToString(frame))
+		}
+		httpReq, err := http.NewRequest("POST", url, body)
+		require.NoError(t, err)
+		httpReq.Header.Set("Content-Type", contentType)
+		httpReq.Header.Set("Origin", "https://poon.example.com")
+		resp, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, contentType, resp.Header.Get("Content-Type"))
+
+		raw, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		if text {
+			// Chunks are padded separately, so decode each quantum alone
+			var decoded []byte
+			for ; len(raw) >= 4; raw = raw[4:] {
+				part, err := base64.StdEncoding.DecodeString(string(raw[:4]))
+				require.NoError(t, err)
+				decoded = append(decoded, part...)
+			}
+			raw = decoded
+		}
+
+		var messages [][]byte
+		var trailers string
+		for len(raw) >= 5 {
+			n := int(raw[1])<<24 | int(raw[2])<<16 | int(raw[3])<<8 | int(raw[4])
+			if raw[0]&0x80 != 0 {
+				trailers = string(raw[5 : 5+n])
+			} else {
+				messages = append(messages, raw[5:5+n])
+			}
+			raw = raw[5+n:]
+		}
+		return messages, trailers, resp
+	}
+
+	for _, text := range []bool{false, true} {
+		messages, trailers, resp := call(&pb.ReadFileRequest{Path: "docs/README.md"}, text)
+		require.Len(t, messages, 1)
+		var readResp pb.ReadFileResponse
+		require.NoError(t, proto.Unmarshal(messages[0], &readResp))
+		assert.Contains(t, string(readResp.Content), "Documentation")
+		assert.Contains(t, trailers, "grpc-status: 0\r\n")
+		assert.Equal(t, "https://poon.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, resp.Header.Get("Access-Control-Expose-Headers"), "Grpc-Status")
+	}
+
+	// Errors arrive in the trailers
+	messages, trailers, _ := call(&pb.ReadFileRequest{Path: "no/such/file"}, false)
+	assert.Empty(t, messages)
+	assert.Contains(t, trailers, fmt.Sprintf("grpc-status: %d\r\n", codes.NotFound))
+
+	// Preflight requests from allowed origins are answered, others are not
+	// given CORS headers
+	for origin, allowed := range map[string]bool{"https://poon.example.com": true, "https://evil.example.com": false} {
+		req, err := http.NewRequest("OPTIONS", url, nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,authorization")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		if allowed {
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			assert.Equal(t, origin, resp.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "content-type,x-grpc-web,authorization", resp.Header.Get("Access-Control-Allow-Headers"))
+		} else {
+			assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+		}
+	}
+
+	// Plain HTTP is refused
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func TestTransportSettings(t *testing.T) {
+	t.Run("Compressors", func(t *testing.T) {
+		message := bytes.Repeat([]byte("package main\n\nfunc main() {}\n"), 1000)
+		for _, name := range []string{"gzip", "zstd"} {
+			compressor := encoding.GetCompressor(name)
+			require.NotNil(t, compressor, name)
+
+			// Twice, so the second round reuses pooled encoders and decoders
+			for i := 0; i < 2; i++ {
+				var compressed bytes.Buffer
+				w, err := compressor.Compress(&compressed)
+				require.NoError(t, err)
+				_, err = w.Write(message)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+				assert.Less(t, compressed.Len(), len(message)/10, name)
+
+				r, err := compressor.Decompress(&compressed)
+				require.NoError(t, err)
+				decompressed, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, message, decompressed, name)
+			}
+		}
+	})
+
+	t.Run("Keepalive", func(t *testing.T) {
+		t.Setenv("GRPC_KEEPALIVE_MIN_TIME", "15s")
+		cfg, err := ConfigFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Second, cfg.KeepaliveMinTime)
+		assert.Equal(t, defaultKeepaliveTime, cfg.KeepaliveTime)
+		assert.Len(t, keepaliveServerOptions(cfg.KeepaliveTime, cfg.KeepaliveTimeout, cfg.KeepaliveMinTime), 2)
+
+		d, err := envDuration("GRPC_KEEPALIVE_MIN_TIME", defaultKeepaliveMinTime)
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Second, d)
+
+		for _, value := range []string{"15", "-1s", "soon"} {
+			t.Setenv("GRPC_KEEPALIVE_TIME", value)
+			_, err := ConfigFromEnv()
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestAdminService(t *testing.T) {
+	repoRoot := createTestRepo(t)
+	backend := storage.NewMemoryBackend()
+	repository := storage.NewRepository(backend)
+	_, err := repository.CreateCommitFromFileSystem(context.Background(), repoRoot, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	srv := &server{
+		repoRoot:      repoRoot,
+		workspaceRoot: t.TempDir(),
+		workspaces:    make(map[string]*Workspace),
+		repository:    repository,
+		locks:         storage.NewLockManager(backend),
+		quotas:        NewQuotaManager(QuotaConfig{}),
+	}
+	admin := &adminServer{srv: srv}
+	ctx := context.Background()
+
+	t.Run("Backend Stats", func(t *testing.T) {
+		resp, err := admin.GetBackendStats(ctx, &pb.BackendStatsRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.CurrentVersion)
+		assert.Equal(t, int64(1), resp.Commits)
+		assert.Equal(t, resp.Objects, resp.Blobs+resp.Trees+resp.Commits)
+		assert.Greater(t, resp.TotalBytes, resp.ObjectBytes)
+	})
+
+	t.Run("Fsck And Garbage Collection", func(t *testing.T) {
+		fsck, err := admin.Fsck(ctx, &pb.FsckRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, fsck.Problems)
+
+		gc, err := admin.RunGarbageCollection(ctx, &pb.GarbageCollectionRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), gc.Removed)
+		assert.Equal(t, gc.Scanned, gc.Reachable)
+	})
+
+	t.Run("Force Unlock", func(t *testing.T) {
+		_, err := srv.locks.Acquire(ctx, "docs", "alice", time.Hour)
+		require.NoError(t, err)
+
+		resp, err := admin.ForceUnlockPath(ctx, &pb.ForceUnlockPathRequest{Path: "docs"})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+		assert.Equal(t, "alice", resp.Lock.Owner)
+
+		resp, err = admin.ForceUnlockPath(ctx, &pb.ForceUnlockPathRequest{Path: "docs"})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+	})
+
+	t.Run("Quota Management", func(t *testing.T) {
+		resp, err := admin.SetUserQuota(ctx, &pb.SetUserQuotaRequest{User: "alice", MaxUserWorkspaces: 3})
+		require.NoError(t, err)
+		assert.True(t, resp.Success, resp.Message)
+		assert.Equal(t, int64(3), srv.quotas.LimitsFor("alice").MaxUserWorkspaces)
+
+		srv.workspaces["ws-1"] = &Workspace{ID: "ws-1", Owner: "alice"}
+		wsResp, err := admin.SetWorkspaceQuota(ctx, &pb.SetWorkspaceQuotaRequest{WorkspaceId: "ws-1", MaxBytes: 100})
+		require.NoError(t, err)
+		assert.True(t, wsResp.Success, wsResp.Message)
+		assert.Equal(t, int64(100), srv.quotas.workspaceByteLimit(srv.workspaces["ws-1"]))
+
+		wsResp, err = admin.SetWorkspaceQuota(ctx, &pb.SetWorkspaceQuotaRequest{WorkspaceId: "missing", MaxBytes: 100})
+		require.NoError(t, err)
+		assert.False(t, wsResp.Success)
+	})
+
+	t.Run("Reap Idle Workspaces", func(t *testing.T) {
+		staleDir := filepath.Join(srv.workspaceRoot, "stale")
+		require.NoError(t, os.MkdirAll(staleDir, 0755))
+		srv.workspaces["stale"] = &Workspace{ID: "stale", LastSync: time.Now().Add(-2 * time.Hour), GitRepoPath: staleDir}
+		srv.workspaces["fresh"] = &Workspace{ID: "fresh", LastSync: time.Now()}
+
+		resp, err := admin.ReapWorkspaces(ctx, &pb.ReapWorkspacesRequest{MaxIdleSeconds: 3600, DryRun: true})
+		require.NoError(t, err)
+		assert.Contains(t, resp.WorkspaceIds, "stale")
+		assert.Contains(t, srv.workspaces, "stale")
+
+		resp, err = admin.ReapWorkspaces(ctx, &pb.ReapWorkspacesRequest{MaxIdleSeconds: 3600})
+		require.NoError(t, err)
+		assert.Contains(t, resp.WorkspaceIds, "stale")
+		assert.NotContains(t, resp.WorkspaceIds, "fresh")
+		assert.NotContains(t, srv.workspaces, "stale")
+		assert.NoDirExists(t, staleDir)
+	})
+
+	t.Run("Collect Workspace Directories", func(t *testing.T) {
+		root := srv.workspaceRoot
+		old := time.Now().Add(-48 * time.Hour)
+		writeDir := func(name string, modified time.Time) {
+			path := filepath.Join(root, name, "repo")
+			require.NoError(t, os.MkdirAll(path, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(path, "file.txt"), []byte("content\n"), 0644))
+			for _, p := range []string{filepath.Join(path, "file.txt"), path, filepath.Join(root, name)} {
+				require.NoError(t, os.Chtimes(p, modified, modified))
+			}
+		}
+		writeDir("owned", old)
+		writeDir("orphan", old)
+		writeDir("recent", time.Now())
+		require.NoError(t, os.MkdirAll(filepath.Join(root, collectingPrefix+"leftover"), 0755))
+		srv.workspaces["owned"] = &Workspace{ID: "owned", Name: "owned-name", GitRepoPath: filepath.Join(root, "owned", "repo")}
+		defer delete(srv.workspaces, "owned")
+		require.NoError(t, os.Symlink("owned", filepath.Join(root, "owned-name")))
+		require.NoError(t, os.Symlink("orphan", filepath.Join(root, "stale-name")))
+
+		resp, err := admin.CollectWorkspaceDirectories(ctx, &pb.CollectWorkspaceDirectoriesRequest{DryRun: true, GraceSeconds: 3600})
+		require.NoError(t, err)
+		require.Len(t, resp.Directories, 1)
+		assert.Equal(t, "orphan", resp.Directories[0].Name)
+		assert.Equal(t, int64(len("content\n")), resp.BytesFreed)
+		assert.DirExists(t, filepath.Join(root, "orphan"))
+
+		resp, err = admin.CollectWorkspaceDirectories(ctx, &pb.CollectWorkspaceDirectoriesRequest{GraceSeconds: 3600})
+		require.NoError(t, err)
+		require.Len(t, resp.Directories, 1)
+		assert.NoDirExists(t, filepath.Join(root, "orphan"))
+		assert.NoDirExists(t, filepath.Join(root, collectingPrefix+"leftover"))
+		assert.DirExists(t, filepath.Join(root, "owned"))
+		assert.DirExists(t, filepath.Join(root, "recent"))
+		_, err = os.Lstat(filepath.Join(root, "owned-name"))
+		assert.NoError(t, err, "the name link of a workspace should be kept")
+		_, err = os.Lstat(filepath.Join(root, "stale-name"))
+		assert.True(t, os.IsNotExist(err), "stale name links should be removed")
+
+		_, err = admin.CollectWorkspaceDirectories(ctx, &pb.CollectWorkspaceDirectoriesRequest{GraceSeconds: -1})
+		assert.Error(t, err)
+	})
+
+	t.Run("List Workspaces", func(t *testing.T) {
+		srv.workspaces["idle"] = &Workspace{ID: "idle", Owner: "alice", LastSync: time.Now().Add(-2 * time.Hour)}
+		srv.workspaces["dirty"] = &Workspace{ID: "dirty", Owner: "bob", LastSync: time.Now(), DirtyFiles: 3, Diverged: true, LastReport: time.Now()}
+
+		resp, err := admin.ListWorkspaces(ctx, &pb.ListWorkspacesRequest{})
+		require.NoError(t, err)
+		var ids []string
+		for _, workspace := range resp.Workspaces {
+			ids = append(ids, workspace.Id)
+		}
+		assert.Subset(t, ids, []string{"dirty", "idle"})
+
+		resp, err = admin.ListWorkspaces(ctx, &pb.ListWorkspacesRequest{StaleSeconds: 3600})
+		require.NoError(t, err)
+		require.Len(t, resp.Workspaces, 1)
+		assert.Equal(t, "idle", resp.Workspaces[0].Id)
+		assert.Empty(t, resp.Workspaces[0].LastReport)
+
+		resp, err = admin.ListWorkspaces(ctx, &pb.ListWorkspacesRequest{DivergedOnly: true, Owner: "bob"})
+		require.NoError(t, err)
+		require.Len(t, resp.Workspaces, 1)
+		assert.Equal(t, "dirty", resp.Workspaces[0].Id)
+		assert.Equal(t, int32(3), resp.Workspaces[0].DirtyFiles)
+
+		_, err = admin.ListWorkspaces(ctx, &pb.ListWorkspacesRequest{StaleSeconds: -1})
+		assert.Error(t, err)
+
+		delete(srv.workspaces, "idle")
+		delete(srv.workspaces, "dirty")
+	})
+
+	t.Run("Backup And Restore", func(t *testing.T) {
+		srv.workspaces["kept"] = &Workspace{ID: "kept", Name: "kept", Owner: "alice", TrackedPaths: []string{"docs"}}
+		backupDir := t.TempDir()
+
+		backup, err := admin.Backup(ctx, &pb.BackupRequest{Destination: backupDir})
+		require.NoError(t, err)
+		assert.Equal(t, backup.Objects, backup.ObjectsWritten)
+
+		again, err := admin.Backup(ctx, &pb.BackupRequest{Destination: backupDir})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), again.ObjectsWritten)
+
+		srv.workspaces = make(map[string]*Workspace)
+		restore, err := admin.Restore(ctx, &pb.RestoreRequest{Source: backupDir, Snapshot: backup.Snapshot})
+		require.NoError(t, err)
+		assert.Equal(t, backup.Snapshot, restore.Snapshot)
+		assert.Equal(t, int64(1), restore.CurrentVersion)
+		require.Contains(t, srv.workspaces, "kept")
+		assert.Equal(t, []string{"docs"}, srv.workspaces["kept"].TrackedPaths)
+
+		_, err = admin.Restore(ctx, &pb.RestoreRequest{Source: t.TempDir()})
+		assert.Error(t, err)
+	})
+
+	t.Run("Migrate Backend", func(t *testing.T) {
+		dir := t.TempDir()
+		resp, err := admin.MigrateBackend(ctx, &pb.MigrateBackendRequest{Destination: dir})
+		require.NoError(t, err)
+		assert.Equal(t, resp.Objects, resp.ObjectsCopied)
+		assert.Greater(t, resp.Metadata, int64(0))
+
+		dst, err := storage.OpenBackend(dir)
+		require.NoError(t, err)
+		content, err := storage.NewRepository(dst).ReadFile(ctx, 1, "docs/README.md")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Poon Monorepo Documentation")
+	})
+
+	t.Run("Rehash Objects", func(t *testing.T) {
+		_, err := admin.RehashObjects(ctx, &pb.RehashObjectsRequest{Algorithm: "md5"})
+		assert.Error(t, err)
+
+		resp, err := admin.RehashObjects(ctx, &pb.RehashObjectsRequest{Algorithm: "blake3"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Versions)
+		assert.Greater(t, resp.Objects, int64(0))
+
+		fsck, err := admin.Fsck(ctx, &pb.FsckRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, fsck.Problems)
+		assert.Equal(t, "blake3", fsck.HashAlgorithm)
+		assert.Equal(t, resp.Objects, fsck.ObjectsByAlgorithm["blake3"])
+
+		info, err := srv.GetServerInfo(ctx, &pb.GetServerInfoRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "blake3", info.HashAlgorithm)
+
+		content, err := srv.repository.ReadFile(ctx, 1, "docs/README.md")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Poon Monorepo Documentation")
+	})
+	t.Run("Prune History", func(t *testing.T) {
+		srv.tags = storage.NewTagManager(backend)
+		defer func() { srv.tags = nil }()
+		for i := 0; i < 2; i++ {
+			_, err := repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Another commit")
+			require.NoError(t, err)
+		}
+		info, err := repository.GetVersionInfo(ctx, 2)
+		require.NoError(t, err)
+		require.NoError(t, srv.tags.Create(ctx, &storage.Tag{Name: "release", Version: 2, CommitHash: info.CommitHash}))
+
+		_, err = admin.PruneHistory(ctx, &pb.PruneHistoryRequest{KeepDays: -1})
+		assert.Error(t, err)
+
+		dryRun, err := admin.PruneHistory(ctx, &pb.PruneHistoryRequest{KeepTagged: true, DryRun: true})
+		require.NoError(t, err)
+		resp, err := admin.PruneHistory(ctx, &pb.PruneHistoryRequest{KeepTagged: true})
+		require.NoError(t, err)
+		assert.Equal(t, dryRun.Pruned, resp.Pruned)
+		assert.Equal(t, int64(2), resp.Kept)
+		assert.Equal(t, int64(1), resp.Pruned)
+		assert.Equal(t, int64(2), resp.OldestKept)
+
+		// The tag follows its version's rewritten commit
+		info, err = repository.GetVersionInfo(ctx, 2)
+		require.NoError(t, err)
+		tag, err := srv.tags.Get(ctx, "release")
+		require.NoError(t, err)
+		assert.Equal(t, info.CommitHash, tag.CommitHash)
+		_, err = repository.GetVersionInfo(ctx, 1)
+		assert.Error(t, err)
+
+		// A workspace's synced version is kept while the workspace reads it
+		_, err = repository.CreateCommitFromFileSystem(ctx, repoRoot, "test@example.com", "Another commit")
+		require.NoError(t, err)
+		srv.workspaces["pinned"] = &Workspace{ID: "pinned", SyncedVersion: 3}
+		defer delete(srv.workspaces, "pinned")
+		resp, err = admin.PruneHistory(ctx, &pb.PruneHistoryRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Kept)
+		assert.Equal(t, int64(3), resp.OldestKept)
+		_, err = repository.ReadFile(ctx, 3, "docs/README.md")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Corrupt Objects", func(t *testing.T) {
+		resp, err := admin.ListCorruptObjects(ctx, &pb.ListCorruptObjectsRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Objects)
+
+		hash, err := repository.StoreBlob(ctx, []byte("data\n"))
+		require.NoError(t, err)
+		good, err := backend.Get(ctx, "objects/"+string(hash))
+		require.NoError(t, err)
+		require.NoError(t, backend.Put(ctx, "objects/"+string(hash), []byte("damaged")))
+		_, err = repository.GetBlob(ctx, hash)
+		assert.ErrorIs(t, err, storage.ErrCorruptObject)
+
+		resp, err = admin.ListCorruptObjects(ctx, &pb.ListCorruptObjectsRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Objects, 1)
+		assert.Equal(t, string(hash), resp.Objects[0].Hash)
+		assert.Equal(t, int32(1), resp.Objects[0].Detections)
+		assert.Zero(t, resp.Objects[0].RepairedAt)
+
+		source := storage.NewMemoryBackend()
+		require.NoError(t, source.Put(ctx, "objects/"+string(hash), good))
+		repository.SetRepairSource(source)
+		defer repository.SetRepairSource(nil)
+		_, err = repository.GetBlob(ctx, hash)
+		require.NoError(t, err)
+
+		resp, err = admin.ListCorruptObjects(ctx, &pb.ListCorruptObjectsRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Objects)
+		resp, err = admin.ListCorruptObjects(ctx, &pb.ListCorruptObjectsRequest{IncludeRepaired: true})
+		require.NoError(t, err)
+		require.Len(t, resp.Objects, 1)
+		assert.NotZero(t, resp.Objects[0].RepairedAt)
+	})
+
+	t.Run("Replication", func(t *testing.T) {
+		status, err := admin.GetReplicationStatus(ctx, &pb.GetReplicationStatusRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, status.Replicas)
+		_, err = admin.FailoverBackend(ctx, &pb.FailoverBackendRequest{Name: "replica"})
+		assert.Error(t, err)
+
+		replica := storage.NewMemoryBackend()
+		srv.replication = storage.NewReplicatedBackend(storage.NewMemoryBackend(), []storage.StorageBackend{replica}, "primary", "replica")
+		defer func() {
+			srv.replication.Close()
+			srv.replication = nil
+		}()
+		// A new replica is first compared with the primary in full
+		require.Eventually(t, func() bool { return !srv.replication.Status()[1].Resync }, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, srv.replication.Put(ctx, "key", []byte("value")))
+		require.Eventually(t, func() bool {
+			status, err := admin.GetReplicationStatus(ctx, &pb.GetReplicationStatusRequest{})
+			return err == nil && len(status.Replicas) == 2 && status.Replicas[1].Replicated == 1 && status.Replicas[1].Pending == 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, err = admin.FailoverBackend(ctx, &pb.FailoverBackendRequest{Name: "elsewhere"})
+		assert.Error(t, err)
+		resp, err := admin.FailoverBackend(ctx, &pb.FailoverBackendRequest{Name: "replica"})
+		require.NoError(t, err)
+		require.Len(t, resp.Replicas, 2)
+		assert.False(t, resp.Replicas[0].Active)
+		assert.True(t, resp.Replicas[1].Active)
+
+		_, err = admin.ResyncReplica(ctx, &pb.ResyncReplicaRequest{Name: "replica"})
+		assert.Error(t, err, "the active backend cannot be resynced")
+		resynced, err := admin.ResyncReplica(ctx, &pb.ResyncReplicaRequest{Name: "primary"})
+		require.NoError(t, err)
+		require.Len(t, resynced.Replicas, 2)
+		require.Eventually(t, func() bool {
+			status, err := admin.GetReplicationStatus(ctx, &pb.GetReplicationStatusRequest{})
+			return err == nil && !status.Replicas[0].Resync
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+// Test helpers
+
+func createTestRepo(t *testing.T) string {
+	repoRoot := t.TempDir()
+
+	// Create directory structure
+	dirs := []string{
+		"src/frontend",
+		"src/backend",
+		"docs",
+		"config",
+	}
+
+	for _, dir := range dirs {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoRoot, dir), 0755))
+	}
+
+	// Create sample files
+	files := map[string]string{
+		"src/frontend/app.js": `// Sample frontend application
+console.log("Hello from frontend");`,
+
+		"src/backend/server.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello from backend")
+}`,
+
+		"docs/README.md": `# Poon Monorepo Documentation
+
+This is a sample monorepo for testing.
+
+## Structure
+
+- src/frontend/ - Frontend application
+- src/backend/ - Backend service  
+- docs/ - Documentation
+- config/ - Configuration files`,
+
+		"config/app.yaml": `environment: test
+services:
+  frontend:
+    port: 3000
+  backend:
+    port: 8080`,
+	}
+
+	for path, content := range files {
+		fullPath := filepath.Join(repoRoot, path)
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	return repoRoot
+}
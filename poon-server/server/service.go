@@ -0,0 +1,31 @@
+package server
+
+import (
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// NewService returns the MonorepoService of a server over repository, whose
+// objects are in backend, for registering on a gRPC server of the caller's
+// own, as poonfake does. Workspace repositories are created in
+// workspaceRoot. Optional features keep their defaults: no authentication,
+// validation, quotas, merge queue, branch protection, templates or git
+// server, and DefaultPatchLimits.
+func NewService(backend storage.StorageBackend, repository storage.Repository, workspaceRoot string) pb.MonorepoServiceServer {
+	// An empty config has no rules to reject
+	protection, _ := NewBranchProtection(ProtectionConfig{})
+
+	return &server{
+		workspaceRoot:    workspaceRoot,
+		workspaces:       make(map[string]*Workspace),
+		repository:       repository,
+		locks:            storage.NewLockManager(backend),
+		tags:             storage.NewTagManager(backend),
+		activity:         storage.NewActivityStore(backend),
+		quotas:           NewQuotaManager(QuotaConfig{}),
+		patchLimits:      DefaultPatchLimits,
+		protection:       protection,
+		workspaceGCGrace: defaultWorkspaceGCGrace,
+		operations:       operationRegistry{store: storage.NewOperationStore(backend)},
+	}
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// defaultSnapshotTag names scheduled snapshots when a schedule has no tag
+// template, such as nightly-2024-06-01
+const defaultSnapshotTag = "{name}-{date}"
+
+// SnapshotSchedule tags the current version on a cron schedule
+type SnapshotSchedule struct {
+	Name     string `json:"name"`     // Such as "nightly"; tags record it as created by "schedule:NAME"
+	Schedule string `json:"schedule"` // Cron expression ("0 2 * * *") or @hourly, @daily, @weekly, @monthly
+	Tag      string `json:"tag"`      // Name template: {name}, {date}, {time}, {version}; default "{name}-{date}"
+	TimeZone string `json:"timeZone"` // IANA zone the schedule and dates are in; default UTC
+	Keep     int    `json:"keep"`     // Newest snapshots of this schedule to keep; 0 keeps any number
+	MaxAge   string `json:"maxAge"`   // Go duration after which snapshots are deleted; "" keeps them
+}
+
+// SnapshotConfig is loaded from the JSON file named by SNAPSHOT_CONFIG
+type SnapshotConfig struct {
+	Schedules []SnapshotSchedule `json:"schedules"`
+}
+
+// SnapshotScheduler pins the current version with a tag whenever one of its
+// schedules fires, then deletes the schedule's snapshots that fall outside
+// its retention rules. Only tags the schedule created are ever deleted.
+type SnapshotScheduler struct {
+	repository storage.Repository
+	tags       *storage.TagManager
+	schedules  []*snapshotSchedule
+}
+
+type snapshotSchedule struct {
+	SnapshotSchedule
+	cron     *cronSchedule
+	location *time.Location
+	maxAge   time.Duration
+}
+
+// NewSnapshotScheduler validates config; Run must be called to take snapshots
+func NewSnapshotScheduler(config SnapshotConfig, repository storage.Repository, tags *storage.TagManager) (*SnapshotScheduler, error) {
+	scheduler := &SnapshotScheduler{repository: repository, tags: tags}
+	names := make(map[string]bool)
+	for _, sc := range config.Schedules {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("snapshot schedule name is required")
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("duplicate snapshot schedule %q", sc.Name)
+		}
+		names[sc.Name] = true
+
+		schedule := &snapshotSchedule{SnapshotSchedule: sc, location: time.UTC}
+		if schedule.Tag == "" {
+			schedule.Tag = defaultSnapshotTag
+		}
+		var err error
+		if schedule.cron, err = parseCron(sc.Schedule); err != nil {
+			return nil, fmt.Errorf("snapshot schedule %s: %w", sc.Name, err)
+		}
+		if sc.TimeZone != "" {
+			if schedule.location, err = time.LoadLocation(sc.TimeZone); err != nil {
+				return nil, fmt.Errorf("snapshot schedule %s: %w", sc.Name, err)
+			}
+		}
+		if sc.MaxAge != "" {
+			if schedule.maxAge, err = time.ParseDuration(sc.MaxAge); err != nil || schedule.maxAge <= 0 {
+				return nil, fmt.Errorf("snapshot schedule %s: invalid maxAge %q", sc.Name, sc.MaxAge)
+			}
+		}
+		if sc.Keep < 0 {
+			return nil, fmt.Errorf("snapshot schedule %s: keep must not be negative", sc.Name)
+		}
+		// The template must give a valid name for any date
+		if err := storage.ValidateTagName(schedule.tagName(time.Now(), 1)); err != nil {
+			return nil, fmt.Errorf("snapshot schedule %s: %w", sc.Name, err)
+		}
+
+		scheduler.schedules = append(scheduler.schedules, schedule)
+	}
+	return scheduler, nil
+}
+
+// LoadSnapshotScheduler reads a snapshot schedule config file
+func LoadSnapshotScheduler(configPath string, repository storage.Repository, tags *storage.TagManager) (*SnapshotScheduler, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot config: %w", err)
+	}
+
+	var config SnapshotConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot config: %w", err)
+	}
+
+	return NewSnapshotScheduler(config, repository, tags)
+}
+
+// tagName fills in the schedule's tag template for a snapshot taken at a
+// time of a version
+func (s *snapshotSchedule) tagName(at time.Time, version int64) string {
+	at = at.In(s.location)
+	return strings.NewReplacer(
+		"{name}", s.Name,
+		"{date}", at.Format("2006-01-02"),
+		"{time}", at.Format("1504"),
+		"{version}", strconv.FormatInt(version, 10),
+	).Replace(s.Tag)
+}
+
+func (s *snapshotSchedule) createdBy() string {
+	return "schedule:" + s.Name
+}
+
+// Run takes snapshots as their schedules come due until ctx is done.
+// Snapshots missed while the server was down are not taken afterwards.
+func (s *SnapshotScheduler) Run(ctx context.Context) {
+	if len(s.schedules) == 0 {
+		return
+	}
+
+	due := make([]time.Time, len(s.schedules))
+	for i, schedule := range s.schedules {
+		due[i] = schedule.cron.next(time.Now().In(schedule.location))
+	}
+
+	for {
+		next := -1
+		for i, at := range due {
+			if !at.IsZero() && (next < 0 || at.Before(due[next])) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return // No schedule will ever fire again
+		}
+
+		timer := time.NewTimer(time.Until(due[next]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		schedule := s.schedules[next]
+		if tag, err := s.snapshot(ctx, schedule, due[next]); err != nil {
+			log.Printf("Warning: snapshot schedule %s failed: %v", schedule.Name, err)
+		} else if tag != nil {
+			log.Printf("Snapshot schedule %s tagged version %d as %s", schedule.Name, tag.Version, tag.Name)
+		}
+		due[next] = schedule.cron.next(due[next])
+	}
+}
+
+// snapshot tags the current version for a schedule that fired at a time and
+// applies the schedule's retention. A snapshot whose tag already exists,
+// such as after a restart, is skipped and returns nil.
+func (s *SnapshotScheduler) snapshot(ctx context.Context, schedule *snapshotSchedule, at time.Time) (*storage.Tag, error) {
+	info, err := s.repository.GetLatestVersionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	tag := &storage.Tag{
+		Name:       schedule.tagName(at, info.Version),
+		Version:    info.Version,
+		CommitHash: info.CommitHash,
+		CreatedAt:  at,
+		CreatedBy:  schedule.createdBy(),
+	}
+	if err := s.tags.Create(ctx, tag); err != nil {
+		if !errors.Is(err, storage.ErrTagExists) {
+			return nil, err
+		}
+		tag = nil
+	}
+
+	if err := s.prune(ctx, schedule, at); err != nil {
+		return tag, err
+	}
+	return tag, nil
+}
+
+// prune deletes the schedule's snapshots beyond its newest Keep and those
+// older than MaxAge at a time
+func (s *SnapshotScheduler) prune(ctx context.Context, schedule *snapshotSchedule, now time.Time) error {
+	if schedule.Keep == 0 && schedule.maxAge == 0 {
+		return nil
+	}
+
+	tags, err := s.tags.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	var owned []*storage.Tag
+	for _, tag := range tags {
+		if tag.CreatedBy == schedule.createdBy() {
+			owned = append(owned, tag)
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].CreatedAt.After(owned[j].CreatedAt) })
+
+	for i, tag := range owned {
+		expired := schedule.maxAge > 0 && now.Sub(tag.CreatedAt) > schedule.maxAge
+		if (schedule.Keep > 0 && i >= schedule.Keep) || expired {
+			if err := s.tags.Delete(ctx, tag.Name); err != nil {
+				return err
+			}
+			log.Printf("Snapshot schedule %s deleted %s (version %d)", schedule.Name, tag.Name, tag.Version)
+		}
+	}
+	return nil
+}
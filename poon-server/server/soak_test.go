@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"github.com/nic/poon/poon-server/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSoak runs concurrent reads, patches and workspace operations against
+// a server whose storage is slow and fails at random, then checks with the
+// failures turned off that the history stayed linear and complete. It runs
+// only when POON_SOAK_DURATION is set, as make soak does, and injects the
+// same failures each run unless POON_SOAK_SEED picks another seed.
+func TestSoak(t *testing.T) {
+	value := os.Getenv("POON_SOAK_DURATION")
+	if value == "" {
+		t.Skip("set POON_SOAK_DURATION, or run make soak, to soak the server")
+	}
+	duration, err := time.ParseDuration(value)
+	require.NoError(t, err, "invalid POON_SOAK_DURATION")
+	seed := int64(1)
+	if value := os.Getenv("POON_SOAK_SEED"); value != "" {
+		_, err := fmt.Sscan(value, &seed)
+		require.NoError(t, err, "invalid POON_SOAK_SEED")
+	}
+	t.Logf("Soaking for %s with POON_SOAK_SEED=%d", duration, seed)
+
+	ctx := context.Background()
+	backend := storagetest.NewChaosBackend(storage.NewMemoryBackend(), storagetest.ChaosConfig{
+		Latency:     100 * time.Microsecond,
+		Jitter:      time.Millisecond,
+		ErrorRate:   0.02,
+		PartialRate: 0.02,
+		Seed:        seed,
+	})
+	backend.SetEnabled(false)
+	repository := storage.NewRepository(backend)
+	rootDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootDir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "docs", "README.md"), []byte("# Docs\n"), 0644))
+	_, err = repository.CreateCommitFromFileSystem(ctx, rootDir, "soak@example.com", "Initial commit")
+	require.NoError(t, err)
+	srv := NewService(backend, repository, t.TempDir())
+	backend.SetEnabled(true)
+
+	var (
+		mu       sync.Mutex
+		merged   = make(map[string]string) // Content of each file a patch reported merging, by path
+		problems []string
+	)
+	problem := func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	deadline := time.Now().Add(duration)
+	var wg sync.WaitGroup
+	worker := func(run func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				run(i)
+			}
+		}()
+	}
+
+	// Writers add a file of their own per patch, so patches never conflict
+	for w := 0; w < 4; w++ {
+		w := w
+		worker(func(i int) {
+			path := fmt.Sprintf("soak/w%d/%d.txt", w, i)
+			content := fmt.Sprintf("writer %d patch %d\n", w, i)
+			resp, err := srv.MergePatch(ctx, &pb.MergePatchRequest{
+				Patch:   []byte(fmt.Sprintf("--- /dev/null\n+++ b/%s\n@@ -0,0 +1 @@\n+%s", path, content)),
+				Author:  "soak@example.com",
+				Message: fmt.Sprintf("Add %s", path),
+			})
+			if err != nil {
+				problem("MergePatch returned an error instead of a failed response: %v", err)
+				return
+			}
+			if resp.Success {
+				mu.Lock()
+				merged[path] = content
+				mu.Unlock()
+			}
+		})
+	}
+
+	// Readers never see the current version go backwards, and always see
+	// the first version as it was
+	for r := 0; r < 4; r++ {
+		worker(func(i int) {
+			var last int64
+			for j := 0; j < 10; j++ {
+				version, err := repository.GetCurrentVersion(ctx)
+				if err != nil {
+					continue
+				}
+				if version < last {
+					problem("current version went back from %d to %d", last, version)
+				}
+				last = version
+
+				if resp, err := srv.ReadFile(ctx, &pb.ReadFileRequest{Path: "docs/README.md", Version: 1}); err == nil && string(resp.Content) != "# Docs\n" {
+					problem("docs/README.md at version 1 reads %q", resp.Content)
+				}
+				srv.ReadDirectory(ctx, &pb.ReadDirectoryRequest{Path: "soak", Version: version})
+			}
+		})
+	}
+
+	// Workspaces come and go
+	for w := 0; w < 2; w++ {
+		worker(func(i int) {
+			resp, err := srv.CreateWorkspace(ctx, &pb.CreateWorkspaceRequest{TrackedPaths: []string{"docs"}})
+			if err != nil || !resp.Success {
+				return
+			}
+			if got, err := srv.GetWorkspace(ctx, &pb.GetWorkspaceRequest{WorkspaceId: resp.WorkspaceId}); err == nil && got.Success && got.Workspace.Id != resp.WorkspaceId {
+				problem("GetWorkspace(%s) returned workspace %s", resp.WorkspaceId, got.Workspace.Id)
+			}
+			srv.DeleteWorkspace(ctx, &pb.DeleteWorkspaceRequest{WorkspaceId: resp.WorkspaceId})
+		})
+	}
+
+	wg.Wait()
+	backend.SetEnabled(false)
+	stats := backend.Stats()
+	t.Logf("%d storage operations: %d failed, %d partially failed; %d patches merged", stats.Operations, stats.Errors, stats.Partial, len(merged))
+	assert.Empty(t, problems)
+	assert.NotZero(t, stats.Errors+stats.Partial, "no failures were injected")
+
+	// Versions are numbered 1..n, each the child of the one before
+	versions, err := repository.ListVersions(ctx, 0)
+	require.NoError(t, err)
+	current, err := repository.GetCurrentVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int(current), len(versions), "versions listed")
+	byNumber := make(map[int64]*storage.VersionInfo)
+	for _, info := range versions {
+		byNumber[info.Version] = info
+	}
+	for version := int64(2); version <= current; version++ {
+		info, parent := byNumber[version], byNumber[version-1]
+		require.NotNil(t, info, "version %d is missing", version)
+		require.NotNil(t, parent, "version %d is missing", version-1)
+		commit, err := repository.GetCommit(ctx, info.CommitHash)
+		require.NoError(t, err, "commit of version %d", version)
+		if assert.NotNil(t, commit.Parent, "version %d has no parent", version) {
+			assert.Equal(t, parent.CommitHash, *commit.Parent, "parent of version %d", version)
+		}
+	}
+
+	// Every patch reported merged is in the current version; patches whose
+	// success was lost to a failure may be too
+	for path, content := range merged {
+		data, err := repository.ReadFile(ctx, current, path)
+		if assert.NoError(t, err, "%s was merged", path) {
+			assert.Equal(t, content, string(data), path)
+		}
+	}
+
+	// And every object a version references is stored intact
+	fsck, err := repository.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+const (
+	// streamBatchSize is the number of directory entries per StreamDirectory message
+	streamBatchSize = 256
+
+	// streamChunkSize is the largest piece of a file per StreamFile message
+	streamChunkSize = 64 * 1024
+)
+
+func (s *server) StreamDirectory(req *pb.StreamDirectoryRequest, stream pb.MonorepoService_StreamDirectoryServer) error {
+	log.Printf("Streaming directory %s at version %d", req.Path, req.Version)
+
+	if err := validatePath(req.Path); err != nil {
+		return invalidPathError(req.Path, err)
+	}
+
+	ctx := stream.Context()
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.repository.ReadDirectory(ctx, version, req.Path)
+	if err != nil {
+		return readError("failed to read directory", req.Path, version, err)
+	}
+
+	// An empty directory still gets one message so the client learns the version
+	resp := &pb.StreamDirectoryResponse{Version: version}
+	for _, entry := range entries {
+		resp.Items = append(resp.Items, &pb.DirectoryItem{
+			Name:    entry.Name,
+			IsDir:   entry.Type == storage.ObjectTypeTree,
+			Size:    entry.Size,
+			ModTime: entry.ModTime,
+			Hash:    string(entry.Hash),
+			Mode:    entry.Mode,
+		})
+		if len(resp.Items) == streamBatchSize {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			resp = &pb.StreamDirectoryResponse{Version: version}
+		}
+	}
+	if len(resp.Items) > 0 || len(entries) == 0 {
+		return stream.Send(resp)
+	}
+	return nil
+}
+
+func (s *server) StreamFile(req *pb.StreamFileRequest, stream pb.MonorepoService_StreamFileServer) error {
+	log.Printf("Streaming file %s at version %d (offset %d, length %d)", req.Path, req.Version, req.Offset, req.Length)
+
+	if err := validatePath(req.Path); err != nil {
+		return invalidPathError(req.Path, err)
+	}
+	if req.Offset < 0 {
+		return invalidArgumentError("offset", "must not be negative")
+	}
+	if req.Length < 0 {
+		return invalidArgumentError("length", "must not be negative")
+	}
+
+	ctx := stream.Context()
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return err
+	}
+
+	content, size, err := s.repository.OpenFileRange(ctx, version, req.Path, req.Offset, req.Length)
+	if err != nil {
+		return readError("failed to read file", req.Path, version, err)
+	}
+	defer content.Close()
+
+	start, end := storage.BlobRange(size, req.Offset, req.Length)
+
+	// Reads at or past the end still get one empty chunk carrying the size
+	buf := make([]byte, min(streamChunkSize, end-start))
+	for offset := start; ; {
+		n := min(int64(len(buf)), end-offset)
+		if _, err := io.ReadFull(content, buf[:n]); err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		if err := stream.Send(&pb.FileChunk{
+			Version: version,
+			Offset:  offset,
+			Data:    buf[:n],
+			Size:    size,
+		}); err != nil {
+			return err
+		}
+		offset += n
+		if offset == end {
+			return nil
+		}
+	}
+}
+
+// resolveVersion returns the version a request asked for, where 0 means the
+// current one
+func (s *server) resolveVersion(ctx context.Context, version int64) (int64, error) {
+	currentVersion, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	if currentVersion == 0 {
+		return 0, fmt.Errorf("no repository versions exist - create an initial commit first")
+	}
+
+	if version < 0 || version > currentVersion {
+		return 0, versionNotFoundError(version, currentVersion)
+	}
+	if version == 0 {
+		return currentVersion, nil
+	}
+	return version, nil
+}
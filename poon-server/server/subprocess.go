@@ -0,0 +1,19 @@
+package server
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/nic/poon/poon-git/subprocess"
+)
+
+// gitCommand runs git in dir with subprocess.GitSettings and GitEnv, the
+// isolation poon-git's upload-pack runs with too, so the server's
+// environment and the host's system and user gitconfig cannot change what
+// git does
+func gitCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := subprocess.CommandContext(ctx, "git", subprocess.GitArgs(args...)...)
+	cmd.Dir = dir
+	cmd.Env = subprocess.GitEnv()
+	return cmd
+}
@@ -0,0 +1,454 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+	"github.com/nic/poon/poon-server/symbols"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxSymbolFileBytes leaves larger files, which are mostly generated,
+	// out of the symbol index
+	maxSymbolFileBytes = 1 << 20
+
+	// indexedVersions is how many versions' symbol indexes are kept: the
+	// newest, and the one before for requests still reading it
+	indexedVersions = 2
+
+	// defaultSymbolLimit and maxSymbolLimit bound the symbols SearchSymbols
+	// returns
+	defaultSymbolLimit = 100
+	maxSymbolLimit     = 1000
+
+	// maxDefinitions caps the definitions GoToDefinition returns
+	maxDefinitions = 50
+
+	// maxSymbolBuilds bounds the versions indexed at once; more wait
+	maxSymbolBuilds = 2
+)
+
+// SymbolIndex indexes the symbols defined in versions of the repository,
+// each the first time it is asked for, or when it is created if Warm is
+// subscribed to new versions. Files are indexed by content hash, so a new
+// version only extracts the files it changed since the last one indexed.
+// Versions older than those kept are indexed for the requests asking but
+// not kept, so a scan of old versions does not evict the newest.
+type SymbolIndex struct {
+	repository storage.Repository
+	slots      chan struct{} // One per build running
+
+	mu       sync.Mutex
+	versions []*versionSymbols // Oldest first
+	building map[int64]*symbolBuild
+	files    map[storage.Hash][]symbols.Symbol // Of the files in versions
+}
+
+type symbolBuild struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int // Callers waiting; the build is canceled when none are left
+	index   *versionSymbols
+	err     error
+}
+
+// versionSymbols is the symbol index of one version
+type versionSymbols struct {
+	version int64
+	files   map[string]*indexedFile // By path
+	names   map[string][]symbolRef  // By lower-case name
+}
+
+type indexedFile struct {
+	hash     storage.Hash
+	language string
+	symbols  []symbols.Symbol
+}
+
+type symbolRef struct {
+	path  string
+	index int // In the file's symbols
+}
+
+// NewSymbolIndex creates an empty symbol index of a repository
+func NewSymbolIndex(repository storage.Repository) *SymbolIndex {
+	return &SymbolIndex{
+		repository: repository,
+		slots:      make(chan struct{}, maxSymbolBuilds),
+		building:   make(map[int64]*symbolBuild),
+		files:      make(map[storage.Hash][]symbols.Symbol),
+	}
+}
+
+// Warm indexes a version in the background
+func (x *SymbolIndex) Warm(version int64) {
+	go func() {
+		if _, err := x.Version(context.Background(), version); err != nil {
+			log.Printf("Warning: failed to index symbols of version %d: %v", version, err)
+		}
+	}()
+}
+
+// Version returns the symbol index of a version, building it if need be.
+// Callers asking for a version being built wait for that build, which is
+// canceled once every one of them has given up.
+func (x *SymbolIndex) Version(ctx context.Context, version int64) (*versionSymbols, error) {
+	x.mu.Lock()
+	for _, index := range x.versions {
+		if index.version == version {
+			x.mu.Unlock()
+			return index, nil
+		}
+	}
+	build, ok := x.building[version]
+	if !ok {
+		buildCtx, cancel := context.WithCancel(context.Background())
+		build = &symbolBuild{done: make(chan struct{}), cancel: cancel}
+		x.building[version] = build
+		go func() {
+			build.index, build.err = x.build(buildCtx, version)
+			x.finish(version, build)
+		}()
+	}
+	build.waiters++
+	x.mu.Unlock()
+
+	select {
+	case <-build.done:
+		return build.index, build.err
+	case <-ctx.Done():
+		x.leave(version, build)
+		return nil, ctx.Err()
+	}
+}
+
+// leave gives up waiting for a build, canceling it if nobody else waits
+func (x *SymbolIndex) leave(version int64, build *symbolBuild) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	build.waiters--
+	if build.waiters > 0 {
+		return
+	}
+	if x.building[version] == build {
+		delete(x.building, version)
+	}
+	build.cancel()
+}
+
+// finish keeps a built index, dropping the oldest and the files only it had
+func (x *SymbolIndex) finish(version int64, build *symbolBuild) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.building[version] == build {
+		delete(x.building, version)
+	}
+	build.cancel()
+	defer close(build.done)
+	if build.err != nil {
+		return
+	}
+	if len(x.versions) >= indexedVersions && build.index.version < x.versions[0].version {
+		return
+	}
+
+	x.versions = append(x.versions, build.index)
+	sort.Slice(x.versions, func(i, j int) bool { return x.versions[i].version < x.versions[j].version })
+	if len(x.versions) > indexedVersions {
+		x.versions = x.versions[len(x.versions)-indexedVersions:]
+	}
+	x.files = make(map[storage.Hash][]symbols.Symbol)
+	for _, index := range x.versions {
+		for _, file := range index.files {
+			x.files[file.hash] = file.symbols
+		}
+	}
+}
+
+// build indexes the files of a version in languages with symbol support
+func (x *SymbolIndex) build(ctx context.Context, version int64) (*versionSymbols, error) {
+	select {
+	case x.slots <- struct{}{}:
+		defer func() { <-x.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	root, err := x.repository.GetEntry(ctx, version, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version %d: %w", version, err)
+	}
+	index := &versionSymbols{
+		version: version,
+		files:   make(map[string]*indexedFile),
+		names:   make(map[string][]symbolRef),
+	}
+	if err := x.indexTree(ctx, index, root.Hash, ""); err != nil {
+		return nil, err
+	}
+	for filePath, file := range index.files {
+		for i, symbol := range file.symbols {
+			name := strings.ToLower(symbol.Name)
+			index.names[name] = append(index.names[name], symbolRef{path: filePath, index: i})
+		}
+	}
+	return index, nil
+}
+
+func (x *SymbolIndex) indexTree(ctx context.Context, index *versionSymbols, hash storage.Hash, prefix string) error {
+	tree, err := x.repository.GetTree(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to read tree %s: %w", prefix, err)
+	}
+	for _, entry := range tree.Entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entryPath := repoJoin(prefix, entry.Name)
+		if entry.Type == storage.ObjectTypeTree {
+			if err := x.indexTree(ctx, index, entry.Hash, entryPath); err != nil {
+				return err
+			}
+			continue
+		}
+		language := symbols.Language(entryPath)
+		if language == "" || entry.Size > maxSymbolFileBytes {
+			continue
+		}
+
+		x.mu.Lock()
+		found, ok := x.files[entry.Hash]
+		x.mu.Unlock()
+		if !ok {
+			blob, err := x.repository.GetBlob(ctx, entry.Hash)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", entryPath, err)
+			}
+			found = symbols.Extract(entryPath, blob.Content)
+		}
+		if len(found) > 0 {
+			index.files[entryPath] = &indexedFile{hash: entry.Hash, language: language, symbols: found}
+		}
+	}
+	return nil
+}
+
+func (v *versionSymbols) symbol(ref symbolRef) *pb.Symbol {
+	file := v.files[ref.path]
+	symbol := file.symbols[ref.index]
+	return &pb.Symbol{
+		Name:      symbol.Name,
+		Kind:      string(symbol.Kind),
+		Path:      ref.path,
+		Line:      int32(symbol.Line),
+		Column:    int32(symbol.Column),
+		Container: symbol.Container,
+		Language:  file.language,
+	}
+}
+
+// symbolIndex returns the symbol index of a version for an RPC
+func (s *server) symbolIndex(ctx context.Context, version int64) (*versionSymbols, error) {
+	if s.symbols == nil {
+		return nil, status.Error(codes.Unimplemented, "symbol indexing is not enabled on this server")
+	}
+	index, err := s.symbols.Version(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index symbols: %w", err)
+	}
+	return index, nil
+}
+
+func (s *server) SearchSymbols(ctx context.Context, req *pb.SearchSymbolsRequest) (*pb.SearchSymbolsResponse, error) {
+	log.Printf("Searching symbols: %q", req.Query)
+
+	query := strings.ToLower(strings.TrimSpace(req.Query))
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultSymbolLimit
+	}
+	limit = min(limit, maxSymbolLimit)
+
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	index, err := s.symbolIndex(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	under := cleanRepoPath(req.Path)
+	kinds := make(map[string]bool)
+	for _, kind := range req.Kinds {
+		kinds[strings.ToLower(kind)] = true
+	}
+
+	type match struct {
+		rank   int // 0 for the whole name, 1 for a prefix, 2 for the rest
+		symbol *pb.Symbol
+	}
+	var matches []match
+	for name, refs := range index.names {
+		rank := 0
+		switch {
+		case name == query:
+		case req.Exact:
+			continue
+		case strings.HasPrefix(name, query):
+			rank = 1
+		case strings.Contains(name, query):
+			rank = 2
+		default:
+			continue
+		}
+		for _, ref := range refs {
+			if under != "" && ref.path != under && !strings.HasPrefix(ref.path, under+"/") {
+				continue
+			}
+			symbol := index.symbol(ref)
+			if len(kinds) > 0 && !kinds[symbol.Kind] {
+				continue
+			}
+			matches = append(matches, match{rank: rank, symbol: symbol})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if len(a.symbol.Name) != len(b.symbol.Name) {
+			return len(a.symbol.Name) < len(b.symbol.Name)
+		}
+		if a.symbol.Name != b.symbol.Name {
+			return a.symbol.Name < b.symbol.Name
+		}
+		if a.symbol.Path != b.symbol.Path {
+			return a.symbol.Path < b.symbol.Path
+		}
+		return a.symbol.Line < b.symbol.Line
+	})
+
+	resp := &pb.SearchSymbolsResponse{Version: version, Truncated: len(matches) > limit}
+	for _, m := range matches[:min(limit, len(matches))] {
+		resp.Symbols = append(resp.Symbols, m.symbol)
+	}
+	return resp, nil
+}
+
+func (s *server) GoToDefinition(ctx context.Context, req *pb.GoToDefinitionRequest) (*pb.GoToDefinitionResponse, error) {
+	log.Printf("Finding definition at %s:%d:%d", req.Path, req.Line, req.Column)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+	if req.Line < 1 || req.Column < 1 {
+		return nil, status.Error(codes.InvalidArgument, "line and column start at 1")
+	}
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	filePath := cleanRepoPath(req.Path)
+	content, err := s.repository.ReadFile(ctx, version, filePath)
+	if err != nil {
+		return nil, readError("failed to read file", filePath, version, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if int(req.Line) > len(lines) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s has %d lines", filePath, len(lines))
+	}
+	resp := &pb.GoToDefinitionResponse{
+		Identifier: identifierAt(lines[req.Line-1], int(req.Column)-1),
+		Version:    version,
+	}
+	if resp.Identifier == "" {
+		return resp, nil
+	}
+
+	index, err := s.symbolIndex(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Definitions nearer the reference are likelier to be the one it means
+	language := symbols.Language(filePath)
+	distance := func(symbol *pb.Symbol) int {
+		switch {
+		case symbol.Path == filePath:
+			return 0
+		case path.Dir(symbol.Path) == path.Dir(filePath):
+			return 1
+		case symbol.Language == language:
+			return 2
+		}
+		return 3
+	}
+	for _, ref := range index.names[strings.ToLower(resp.Identifier)] {
+		// Names differing only in case are different names
+		if symbol := index.symbol(ref); symbol.Name == resp.Identifier {
+			resp.Definitions = append(resp.Definitions, symbol)
+		}
+	}
+	sort.Slice(resp.Definitions, func(i, j int) bool {
+		a, b := resp.Definitions[i], resp.Definitions[j]
+		if da, db := distance(a), distance(b); da != db {
+			return da < db
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	if len(resp.Definitions) > maxDefinitions {
+		resp.Definitions = resp.Definitions[:maxDefinitions]
+	}
+	return resp, nil
+}
+
+// identifierAt returns the identifier that covers the byte at column, or
+// touches it from the left, as when the cursor is just after a name
+func identifierAt(line string, column int) string {
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '$' || c >= 0x80 || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	if column > len(line) {
+		return ""
+	}
+	if column == len(line) || !isIdent(line[column]) {
+		if column == 0 || !isIdent(line[column-1]) {
+			return ""
+		}
+		column--
+	}
+	start, end := column, column
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdent(line[end]) {
+		end++
+	}
+	// Numbers are not names
+	if c := line[start]; c >= '0' && c <= '9' {
+		return ""
+	}
+	return line[start:end]
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+func tagToProto(tag *storage.Tag) *pb.Tag {
+	return &pb.Tag{
+		Name:       tag.Name,
+		Version:    tag.Version,
+		CommitHash: string(tag.CommitHash),
+		CreatedAt:  tag.CreatedAt.Unix(),
+		CreatedBy:  tag.CreatedBy,
+	}
+}
+
+// ListTags lists the tags whose name starts with the requested prefix
+func (s *server) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	log.Printf("Listing tags with prefix: %s", req.Prefix)
+
+	if s.tags == nil {
+		return &pb.ListTagsResponse{}, nil
+	}
+	tags, err := s.tags.List(ctx, req.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	resp := &pb.ListTagsResponse{}
+	for _, tag := range tags {
+		resp.Tags = append(resp.Tags, tagToProto(tag))
+	}
+	return resp, nil
+}
+
+// refreshTagCommits points tags at their version's current commit hash after
+// a rewrite changed commit hashes. Tags of versions that no longer exist are
+// left alone.
+func (s *server) refreshTagCommits(ctx context.Context) error {
+	if s.tags == nil {
+		return nil
+	}
+	tags, err := s.tags.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		info, err := s.repository.GetVersionInfo(ctx, tag.Version)
+		if err != nil || info.CommitHash == tag.CommitHash {
+			continue
+		}
+		if err := s.tags.SetCommitHash(ctx, tag.Name, info.CommitHash); err != nil {
+			return fmt.Errorf("failed to update tag %s: %w", tag.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// WorkspaceTemplate is a predefined workspace: the tracked paths and
+// metadata a team's workspaces start from
+type WorkspaceTemplate struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	TrackedPaths []string          `json:"trackedPaths"` // Paths or glob patterns
+	Metadata     map[string]string `json:"metadata"`
+}
+
+// TemplateConfig is loaded from the JSON file named by
+// WORKSPACE_TEMPLATES_CONFIG
+type TemplateConfig struct {
+	Templates []WorkspaceTemplate `json:"templates"`
+}
+
+// LoadWorkspaceTemplates reads and validates a template config file and
+// returns its templates by name
+func LoadWorkspaceTemplates(configPath string) (map[string]*WorkspaceTemplate, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template config: %w", err)
+	}
+
+	var config TemplateConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse template config: %w", err)
+	}
+
+	templates := make(map[string]*WorkspaceTemplate, len(config.Templates))
+	for i := range config.Templates {
+		template := &config.Templates[i]
+		if template.Name == "" {
+			return nil, fmt.Errorf("template %d has no name", i+1)
+		}
+		if _, exists := templates[template.Name]; exists {
+			return nil, fmt.Errorf("template %q is defined twice", template.Name)
+		}
+		if len(template.TrackedPaths) == 0 {
+			return nil, fmt.Errorf("template %q has no tracked paths", template.Name)
+		}
+		for _, path := range template.TrackedPaths {
+			if err := validatePath(path); err != nil {
+				return nil, fmt.Errorf("template %q: %w", template.Name, err)
+			}
+		}
+		if _, reserved := template.Metadata[templateMetadataKey]; reserved {
+			return nil, fmt.Errorf("template %q sets reserved metadata key %q", template.Name, templateMetadataKey)
+		}
+		templates[template.Name] = template
+	}
+	return templates, nil
+}
+
+// templateMetadataKey records which template a workspace was created from
+const templateMetadataKey = "template"
+
+// applyTemplate returns the tracked paths and metadata of a workspace created
+// from the named template: the template's paths followed by any others
+// requested, and its metadata overridden by the request's
+func (s *server) applyTemplate(name string, paths []string, metadata map[string]string) ([]string, map[string]string, error) {
+	template, ok := s.templates[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown template %q", name)
+	}
+
+	merged := append([]string(nil), template.TrackedPaths...)
+	seen := make(map[string]bool, len(merged))
+	for _, path := range merged {
+		seen[path] = true
+	}
+	for _, path := range paths {
+		if !seen[path] {
+			merged = append(merged, path)
+			seen[path] = true
+		}
+	}
+
+	combined := make(map[string]string, len(template.Metadata)+len(metadata)+1)
+	for key, value := range template.Metadata {
+		combined[key] = value
+	}
+	for key, value := range metadata {
+		combined[key] = value
+	}
+	combined[templateMetadataKey] = name
+	return merged, combined, nil
+}
+
+func (s *server) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
+	log.Printf("Listing %d workspace templates", len(s.templates))
+
+	templates := make([]*pb.WorkspaceTemplate, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, &pb.WorkspaceTemplate{
+			Name:         template.Name,
+			Description:  template.Description,
+			TrackedPaths: template.TrackedPaths,
+			Metadata:     template.Metadata,
+		})
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return &pb.ListTemplatesResponse{Templates: templates}, nil
+}
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// RefreshTrackedPaths re-expands the workspace's tracked patterns against the
+// current version and adds the paths that started matching since they were
+// last expanded. Paths that stopped matching stay tracked.
+func (s *server) RefreshTrackedPaths(ctx context.Context, req *pb.RefreshTrackedPathsRequest) (*pb.RefreshTrackedPathsResponse, error) {
+	log.Printf("Refreshing tracked patterns for workspace %s", req.WorkspaceId)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workspace, exists := s.lookupWorkspace(req.WorkspaceId)
+	if !exists {
+		return &pb.RefreshTrackedPathsResponse{
+			Success: false,
+			Message: "Workspace not found",
+		}, nil
+	}
+
+	currentVersion, err := s.repository.GetCurrentVersion(ctx)
+	if err != nil {
+		return &pb.RefreshTrackedPathsResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get current version: %v", err),
+		}, nil
+	}
+
+	if len(workspace.TrackedPatterns) == 0 {
+		return &pb.RefreshTrackedPathsResponse{
+			Success:    true,
+			Message:    "Workspace has no tracked patterns",
+			NewVersion: currentVersion,
+		}, nil
+	}
+
+	expansion := &patternExpansion{s: s, version: currentVersion}
+	patterns, err := expansion.defined(ctx, workspace.TrackedPatterns)
+	if err != nil {
+		return &pb.RefreshTrackedPathsResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to expand tracked patterns: %v", err),
+		}, nil
+	}
+	added, _, err := s.expandTrackedPaths(ctx, currentVersion, workspace, patterns)
+	if err != nil {
+		return &pb.RefreshTrackedPathsResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to expand tracked patterns: %v", err),
+		}, nil
+	}
+
+	if len(added) == 0 {
+		return &pb.RefreshTrackedPathsResponse{
+			Success:    true,
+			Message:    "No new paths match the tracked patterns",
+			NewVersion: currentVersion,
+		}, nil
+	}
+
+	commitMsg := fmt.Sprintf("Track paths newly matching %s\n\n%s",
+		strings.Join(workspace.TrackedPatterns, ", "), formatTrackedPaths(added))
+	commitHash, err := s.addTrackedPaths(ctx, workspace, currentVersion, added, nil, false, commitMsg)
+	if err != nil {
+		return &pb.RefreshTrackedPathsResponse{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	log.Printf("Added %d newly matching path(s) to workspace %s", len(added), req.WorkspaceId)
+
+	return &pb.RefreshTrackedPathsResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Added %d path(s) matching tracked patterns", len(added)),
+		AddedPaths: added,
+		CommitHash: commitHash,
+		NewVersion: currentVersion,
+	}, nil
+}
+
+// expandTrackedPaths splits requested into literal paths and patterns
+// (globs, views and other workspaces), and returns the literal paths followed
+// by the pattern matches. Matches already covered by a path the workspace
+// tracks, or by another path in the result, are left out so a directory is
+// never copied twice. workspace is nil for one being created.
+func (s *server) expandTrackedPaths(ctx context.Context, version int64, workspace *Workspace, requested []string) ([]string, []string, error) {
+	var tracked, stack []string
+	if workspace != nil {
+		tracked = workspace.TrackedPaths
+		stack = []string{workspace.ID}
+	}
+
+	expansion := &patternExpansion{s: s, version: version}
+	var paths, patterns, matches []string
+	for _, path := range requested {
+		if !isTrackedPattern(path) {
+			paths = append(paths, path)
+			continue
+		}
+		if version == 0 {
+			return nil, nil, fmt.Errorf("no repository versions exist to expand %s against", path)
+		}
+
+		found, err := expansion.expand(ctx, path, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		patterns = append(patterns, path)
+		matches = append(matches, found...)
+	}
+
+	covering := append(append([]string{}, tracked...), paths...)
+	for _, match := range matches {
+		if coveredBy(match, covering) || coveredBy(match, otherPaths(matches, match)) {
+			continue
+		}
+		paths = append(paths, match)
+		covering = append(covering, match)
+	}
+	return paths, patterns, nil
+}
+
+// isTrackedPattern reports whether path is expanded by the server, as a glob
+// pattern, a view or another workspace, and is kept to be re-expanded on sync
+func isTrackedPattern(path string) bool {
+	return storage.IsGlobPattern(path) || isViewReference(path) || isWorkspaceReference(path)
+}
+
+// patternExpansion expands tracked patterns against one version. The
+// repository's views are loaded once, when first needed. The caller holds
+// s.mu so referenced workspaces can be looked up.
+type patternExpansion struct {
+	s       *server
+	version int64
+	views   map[string]*PathView
+}
+
+// expand returns the paths pattern covers. stack holds the IDs of the
+// workspaces whose tracked sets are being expanded, outermost first.
+func (e *patternExpansion) expand(ctx context.Context, pattern string, stack []string) ([]string, error) {
+	switch {
+	case isViewReference(pattern):
+		if err := e.loadViews(ctx); err != nil {
+			return nil, err
+		}
+		return e.s.expandView(ctx, e.version, e.views, pattern)
+	case isWorkspaceReference(pattern):
+		return e.expandWorkspace(ctx, pattern, stack)
+	default:
+		return e.s.repository.Glob(ctx, e.version, pattern)
+	}
+}
+
+func (e *patternExpansion) loadViews(ctx context.Context) error {
+	if e.views != nil {
+		return nil
+	}
+	views, err := e.s.loadViews(ctx, e.version)
+	if err != nil {
+		return err
+	}
+	e.views = views
+	return nil
+}
+
+// defined returns patterns without the views and workspaces that no longer
+// exist. A removed view or workspace leaves its paths tracked but stops
+// adding new ones.
+func (e *patternExpansion) defined(ctx context.Context, patterns []string) ([]string, error) {
+	var defined []string
+	for _, pattern := range patterns {
+		switch {
+		case isViewReference(pattern):
+			if err := e.loadViews(ctx); err != nil {
+				return nil, err
+			}
+			if _, ok := e.views[strings.TrimPrefix(pattern, viewPrefix)]; !ok {
+				log.Printf("Skipping %s: view is no longer defined in %s", pattern, viewsFile)
+				continue
+			}
+		case isWorkspaceReference(pattern):
+			if _, ok := e.s.lookupWorkspace(strings.TrimPrefix(pattern, workspacePrefix)); !ok {
+				log.Printf("Skipping %s: workspace no longer exists", pattern)
+				continue
+			}
+		}
+		defined = append(defined, pattern)
+	}
+	return defined, nil
+}
+
+// coveredBy reports whether path is one of dirs or lies below one of them
+func coveredBy(path string, dirs []string) bool {
+	path = cleanRepoPath(path)
+	for _, dir := range dirs {
+		dir = cleanRepoPath(dir)
+		if dir == "" || path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// otherPaths returns paths without path
+func otherPaths(paths []string, path string) []string {
+	var others []string
+	for _, other := range paths {
+		if other != path {
+			others = append(others, other)
+		}
+	}
+	return others
+}
+
+// addTrackedPaths checks paths against the owner's size policies and quota,
+// copies them into the workspace repository and commits them together with
+// the updated .poon-workspace file. patterns are recorded as tracked too. It
+// returns the new commit, or "" if there was nothing to commit; errors are
+// worded for the client.
+func (s *server) addTrackedPaths(ctx context.Context, workspace *Workspace, version int64, paths, patterns []string, overrideSizeLimits bool, commitMsg string) (string, error) {
+	if reason := workspace.unmaterialized(); reason != "" {
+		return "", fmt.Errorf("Cannot change tracked paths: %s", reason)
+	}
+	workspace.repoMu.Lock()
+	defer workspace.repoMu.Unlock()
+
+	trackedPaths := append(append([]string{}, workspace.TrackedPaths...), paths...)
+	if err := s.checkCaseCollisions(ctx, version, trackedPaths); err != nil {
+		return "", fmt.Errorf("Cannot track %s: %w", strings.Join(paths, ", "), err)
+	}
+
+	var size int64
+	for _, path := range paths {
+		pathSize, err := s.pathSize(ctx, version, path)
+		if err != nil {
+			return "", fmt.Errorf("Failed to compute size of %s: %w", path, err)
+		}
+		size += pathSize
+
+		if overrideSizeLimits {
+			continue
+		}
+		reason, err := s.checkSizePolicy(ctx, s.quotas.LimitsFor(workspace.Owner), version, path, pathSize)
+		if err != nil {
+			return "", fmt.Errorf("Failed to check size of %s: %w", path, err)
+		}
+		if reason != "" {
+			return "", fmt.Errorf("Size limit exceeded: %s", reason)
+		}
+	}
+	if limit := s.quotas.LimitsFor(workspace.Owner).MaxWorkspaceDiskBytes; limit > 0 && workspace.DiskBytes+size > limit {
+		// Old commits may be what takes the space
+		if _, err := s.compactWorkspace(ctx, workspace, 1); err != nil {
+			log.Printf("Warning: failed to compact workspace %s: %v", workspace.ID, err)
+		}
+	}
+	if reason := s.checkAddPathQuota(workspace, size); reason != "" {
+		return "", fmt.Errorf("Quota exceeded: %s", reason)
+	}
+
+	for _, path := range paths {
+		if err := s.copyPathToGitRepo(ctx, version, path, workspace.GitRepoPath); err != nil {
+			return "", fmt.Errorf("Failed to copy path to git repo: %w", err)
+		}
+	}
+
+	workspace.TrackedPaths = trackedPaths
+	workspace.TrackedPatterns = append(workspace.TrackedPatterns, patterns...)
+	workspace.BytesStored += size
+	workspace.LastSync = time.Now()
+	workspace.SyncedVersion = version
+
+	// Update .poon-workspace metadata file
+	metadataPath, err := storage.ResolveInRoot(workspace.GitRepoPath, ".poon-workspace")
+	if err != nil {
+		return "", fmt.Errorf("Failed to update metadata file: %w", err)
+	}
+	metadata := workspaceMetadata(workspace.TrackedPaths, workspace.TrackedPatterns, workspace.CreatedAt)
+	if err := os.WriteFile(metadataPath, []byte(metadata), 0644); err != nil {
+		return "", fmt.Errorf("Failed to update metadata file: %w", err)
+	}
+
+	if err := s.writeGitAttributes(ctx, version, workspace.GitRepoPath); err != nil {
+		return "", fmt.Errorf("Failed to update line ending attributes: %w", err)
+	}
+
+	// Commit the changes
+	cmd := gitCommand(ctx, workspace.GitRepoPath, "add", ".")
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Failed to add files to git: %w", err)
+	}
+
+	cmd = gitCommand(ctx, workspace.GitRepoPath, "commit", "-m", commitMsg)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "nothing to commit") {
+			return "", nil
+		}
+		return "", fmt.Errorf("Failed to commit changes: %v - %s", err, string(output))
+	}
+
+	s.shareWorkspaceObjects(workspace.GitRepoPath)
+	if err := s.measureWorkspace(ctx, workspace); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	commitHash, err := gitHead(ctx, workspace.GitRepoPath)
+	if err != nil {
+		commitHash = "unknown"
+	}
+	return commitHash, nil
+}
+
+// workspaceMetadata renders the .poon-workspace file kept at the root of
+// every workspace repository
+func workspaceMetadata(trackedPaths, patterns []string, createdAt time.Time) string {
+	var patternSection string
+	if len(patterns) > 0 {
+		patternSection = fmt.Sprintf("tracked_patterns:\n%s\n", formatTrackedPaths(patterns))
+	}
+	return fmt.Sprintf(`# Poon Workspace Metadata
+# This file is managed by poon-server
+workspace_version: 1
+tracked_paths:
+%s
+%screated_at: %s
+`, formatTrackedPaths(trackedPaths), patternSection, createdAt.Format(time.RFC3339))
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	_ "github.com/nic/poon/poon-go/zstdcodec" // Registers the zstd compressor
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // Registers the gzip compressor
+	"google.golang.org/grpc/keepalive"
+)
+
+// Keepalive defaults. gRPC's own minimum ping interval for clients is 5
+// minutes, which would disconnect the CLI; it pings every 30 seconds.
+const (
+	defaultKeepaliveTime    = 2 * time.Hour
+	defaultKeepaliveTimeout = 20 * time.Second
+	defaultKeepaliveMinTime = 10 * time.Second
+)
+
+// keepaliveServerOptions returns the gRPC options for the keepalive
+// settings: keepaliveTime and keepaliveTimeout control the server's pings on
+// idle connections, minTime how often clients may ping before they are
+// disconnected. Clients may ping without active streams, as the CLI does
+// between calls.
+func keepaliveServerOptions(keepaliveTime, keepaliveTimeout, minTime time.Duration) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: keepaliveTime, Timeout: keepaliveTimeout}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: minTime, PermitWithoutStream: true}),
+	}
+}
+
+// envDuration reads a positive duration such as "30s" from the environment
+func envDuration(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 30s", name, value)
+	}
+	return d, nil
+}
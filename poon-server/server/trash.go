@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+func trashEntryToProto(entry *storage.TrashEntry) *pb.DeletedPath {
+	return &pb.DeletedPath{
+		Path:           entry.Path,
+		Hash:           string(entry.Hash),
+		Mode:           entry.Mode,
+		Size:           entry.Size,
+		DeletedVersion: entry.DeletedVersion,
+		DeletedBy:      entry.DeletedBy,
+		DeletedAt:      entry.DeletedAt.Unix(),
+	}
+}
+
+func (s *server) ListDeletedPaths(ctx context.Context, req *pb.ListDeletedPathsRequest) (*pb.ListDeletedPathsResponse, error) {
+	log.Printf("Listing deleted paths under: %s", req.Path)
+
+	if err := validatePath(req.Path); err != nil {
+		return nil, invalidPathError(req.Path, err)
+	}
+
+	entries, err := s.repository.ListTrash(ctx, req.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted paths: %w", err)
+	}
+
+	resp := &pb.ListDeletedPathsResponse{}
+	for _, entry := range entries {
+		resp.Paths = append(resp.Paths, trashEntryToProto(entry))
+	}
+	return resp, nil
+}
+
+// RestoreDeletedPath lands directly, even with a merge queue: the restored
+// content was already in the repository and needs no rebasing. Locks held by
+// others and branch protection apply as they do to patches.
+func (s *server) RestoreDeletedPath(ctx context.Context, req *pb.RestoreDeletedPathRequest) (*pb.RestoreDeletedPathResponse, error) {
+	log.Printf("Restoring deleted path: %s", req.Path)
+
+	path := strings.Trim(req.Path, "/")
+	if err := validatePath(path); err != nil {
+		return &pb.RestoreDeletedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid path: %v", err),
+		}, nil
+	}
+	if path == "" {
+		return &pb.RestoreDeletedPathResponse{
+			Success: false,
+			Message: "Path is required",
+		}, nil
+	}
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("Restore %s", path)
+	}
+	if violations := s.commitPolicy.Check(message); len(violations) > 0 {
+		return nil, commitMessageError(violations)
+	}
+
+	entries, err := s.repository.ListTrash(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted paths: %w", err)
+	}
+	if len(entries) == 0 {
+		return &pb.RestoreDeletedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("No deleted files at %s", path),
+		}, nil
+	}
+
+	author := lockOwner(ctx, req.Author)
+	targets := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		targets = append(targets, entry.Path)
+
+		if s.locks == nil {
+			continue
+		}
+		lock, err := s.locks.FindConflict(ctx, entry.Path, author)
+		if err != nil {
+			return &pb.RestoreDeletedPathResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to check locks: %v", err),
+			}, nil
+		}
+		if lock != nil {
+			return &pb.RestoreDeletedPathResponse{
+				Success: false,
+				Message: fmt.Sprintf("Path %s is locked by %s until %s", lock.Path, lock.Owner, lock.ExpiresAt.Format(time.RFC3339)),
+			}, nil
+		}
+	}
+
+	if violations := s.protectionViolations(ctx, targets, &pb.MergePatchRequest{Author: req.Author, Message: message}); len(violations) > 0 {
+		log.Printf("Rejected restore of protected path %s: %s", path, formatViolations(violations))
+		return &pb.RestoreDeletedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Restore rejected by branch protection: %s", formatViolations(violations)),
+		}, nil
+	}
+
+	versionInfo, restored, err := s.repository.RestoreDeletedPath(ctx, path, author, message)
+	if err != nil && versionInfo == nil {
+		if errors.Is(err, storage.ErrPathExists) {
+			return &pb.RestoreDeletedPathResponse{
+				Success: false,
+				Message: fmt.Sprintf("Cannot restore: %v; delete or rename it first", err),
+			}, nil
+		}
+		return &pb.RestoreDeletedPathResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to restore %s: %v", path, err),
+		}, nil
+	}
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	log.Printf("Restored %d deleted files under %s in version %d", len(restored), path, versionInfo.Version)
+	s.events.Publish(versionCreated(versionInfo, author))
+
+	resp := &pb.RestoreDeletedPathResponse{
+		Success: true,
+		Message: fmt.Sprintf("Restored %d file(s), created version %d", len(restored), versionInfo.Version),
+		Version: versionInfo.Version,
+	}
+	for _, entry := range restored {
+		resp.Restored = append(resp.Restored, trashEntryToProto(entry))
+	}
+	return resp, nil
+}
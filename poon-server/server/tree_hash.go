@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// maxTreeHashPaths bounds the paths in one GetTreeHash request
+const maxTreeHashPaths = 1000
+
+// GetTreeHash returns the hash of each requested path at one version. Trees
+// are content-addressed, so equal hashes mean nothing below the path changed.
+func (s *server) GetTreeHash(ctx context.Context, req *pb.GetTreeHashRequest) (*pb.GetTreeHashResponse, error) {
+	log.Printf("Getting tree hashes of %d paths at version %d", len(req.Paths), req.Version)
+
+	if len(req.Paths) > maxTreeHashPaths {
+		return nil, tooManyPathsError(len(req.Paths), maxTreeHashPaths)
+	}
+	for _, path := range req.Paths {
+		if err := validatePath(path); err != nil {
+			return nil, invalidPathError(path, err)
+		}
+	}
+
+	version, err := s.resolveVersion(ctx, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetTreeHashResponse{Version: version}
+	for _, path := range req.Paths {
+		hash := &pb.TreeHash{Path: path}
+		resp.Hashes = append(resp.Hashes, hash)
+
+		entry, err := s.repository.GetEntry(ctx, version, path)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, readError("failed to read tree", path, version, err)
+		}
+		hash.Hash = string(entry.Hash)
+		hash.IsDir = entry.Type == storage.ObjectTypeTree
+		hash.Exists = true
+	}
+
+	return resp, nil
+}
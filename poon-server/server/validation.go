@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-git/subprocess"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/merge"
+)
+
+// Change is an incoming patch as seen by validators
+type Change struct {
+	Path        string             // Path from the request
+	TargetFile  string             // File modified by the patch
+	Author      string             // Author from the request
+	Message     string             // Commit message from the request
+	Patch       []byte             // Raw unified diff
+	Parsed      *merge.ParsedPatch // Parsed form of Patch
+	CurrentSize int64              // Size of TargetFile before the patch, or -1 if it does not exist
+}
+
+// NewSize estimates the size of the target file after the patch is applied
+func (c *Change) NewSize() int64 {
+	if c.Parsed.Binary != nil {
+		return c.Parsed.Binary.NewSize()
+	}
+
+	size := c.CurrentSize
+	if size < 0 {
+		size = 0
+	}
+	for _, hunk := range c.Parsed.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case "+":
+				size += int64(len(line.Content)) + 1
+			case "-":
+				size -= int64(len(line.Content)) + 1
+			}
+		}
+	}
+	if size < 0 {
+		size = 0
+	}
+	return size
+}
+
+// Validator is a server-side check run against every patch before it is
+// applied. Validators return one violation per problem found.
+type Validator interface {
+	Name() string
+	Validate(ctx context.Context, change *Change) []*pb.PolicyViolation
+}
+
+// ValidationConfig configures the built-in validators. It is loaded from the
+// JSON file named by VALIDATION_CONFIG.
+type ValidationConfig struct {
+	MaxFileSize    int64    `json:"maxFileSize"`    // Largest allowed file in bytes (0 disables)
+	ForbiddenPaths []string `json:"forbiddenPaths"` // Glob patterns; a trailing "/" matches a whole directory
+	LintCommand    []string `json:"lintCommand"`    // Command run with the patch on stdin; non-zero exit rejects
+	LintTimeout    string   `json:"lintTimeout"`    // Duration string, defaults to 30s
+
+	CommitMessage *CommitMessageRules `json:"commitMessage"` // Commit message policy (optional)
+}
+
+// LoadValidationConfig reads a validation config file
+func LoadValidationConfig(configPath string) (*ValidationConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validation config: %w", err)
+	}
+
+	var config ValidationConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse validation config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// NewValidators builds the validators enabled by config
+func NewValidators(config *ValidationConfig) ([]Validator, error) {
+	var validators []Validator
+
+	if config.MaxFileSize > 0 {
+		validators = append(validators, &maxFileSizeValidator{limit: config.MaxFileSize})
+	}
+
+	if len(config.ForbiddenPaths) > 0 {
+		for _, pattern := range config.ForbiddenPaths {
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+				return nil, fmt.Errorf("invalid forbidden path pattern %q: %w", pattern, err)
+			}
+		}
+		validators = append(validators, &forbiddenPathValidator{patterns: config.ForbiddenPaths})
+	}
+
+	if len(config.LintCommand) > 0 {
+		timeout := 30 * time.Second
+		if config.LintTimeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(config.LintTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lint timeout: %w", err)
+			}
+		}
+		validators = append(validators, &lintCommandValidator{command: config.LintCommand, timeout: timeout})
+	}
+
+	return validators, nil
+}
+
+// maxFileSizeValidator rejects patches that grow a file beyond a limit
+type maxFileSizeValidator struct {
+	limit int64
+}
+
+func (v *maxFileSizeValidator) Name() string { return "max_file_size" }
+
+func (v *maxFileSizeValidator) Validate(ctx context.Context, change *Change) []*pb.PolicyViolation {
+	size := change.NewSize()
+	if size <= v.limit {
+		return nil
+	}
+
+	return []*pb.PolicyViolation{{
+		Rule:        v.Name(),
+		Path:        change.TargetFile,
+		Description: fmt.Sprintf("file would be %d bytes, limit is %d bytes", size, v.limit),
+	}}
+}
+
+// forbiddenPathValidator rejects patches touching paths matching any pattern
+type forbiddenPathValidator struct {
+	patterns []string
+}
+
+func (v *forbiddenPathValidator) Name() string { return "forbidden_path" }
+
+func (v *forbiddenPathValidator) Validate(ctx context.Context, change *Change) []*pb.PolicyViolation {
+	for _, pattern := range v.patterns {
+		if matchPathPattern(pattern, change.TargetFile) {
+			return []*pb.PolicyViolation{{
+				Rule:        v.Name(),
+				Path:        change.TargetFile,
+				Description: fmt.Sprintf("path matches forbidden pattern %q", pattern),
+			}}
+		}
+	}
+	return nil
+}
+
+// matchPathPattern matches a slash-separated path against a glob pattern.
+// Patterns ending in "/" match everything below that directory, and patterns
+// without a "/" are also matched against the file name alone.
+func matchPathPattern(pattern, filePath string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		dir := strings.TrimSuffix(pattern, "/")
+		for p := path.Dir(filePath); p != "." && p != "/"; p = path.Dir(p) {
+			if matched, _ := path.Match(dir, p); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	if matched, _ := path.Match(pattern, filePath); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(filePath))
+		return matched
+	}
+	return false
+}
+
+// lintCommandValidator runs an external command with the patch on stdin.
+// The target file, author and message are passed in POON_* environment
+// variables; a non-zero exit rejects the patch with the command's output.
+type lintCommandValidator struct {
+	command []string
+	timeout time.Duration
+}
+
+func (v *lintCommandValidator) Name() string { return "lint_command" }
+
+func (v *lintCommandValidator) Validate(ctx context.Context, change *Change) []*pb.PolicyViolation {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	cmd := subprocess.CommandContext(ctx, v.command[0], v.command[1:]...)
+	cmd.Stdin = bytes.NewReader(change.Patch)
+	cmd.Env = append(os.Environ(),
+		"POON_TARGET_FILE="+change.TargetFile,
+		"POON_AUTHOR="+change.Author,
+		"POON_MESSAGE="+change.Message,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	description := strings.TrimSpace(string(output))
+	if ctx.Err() == context.DeadlineExceeded {
+		description = fmt.Sprintf("lint command timed out after %s", v.timeout)
+	} else if description == "" {
+		description = fmt.Sprintf("lint command failed: %v", err)
+	}
+
+	return []*pb.PolicyViolation{{
+		Rule:        v.Name(),
+		Path:        change.TargetFile,
+		Description: description,
+	}}
+}
+
+// newChange parses a MergePatch request into the form seen by validators
+// and limits. Patches that cannot be parsed return nil and are left for
+// ApplyPatch to report.
+func (s *server) newChange(ctx context.Context, req *pb.MergePatchRequest) *Change {
+	parsed, err := merge.ParsePatch(req.Patch)
+	if err != nil {
+		return nil
+	}
+
+	targetFile := parsed.Target()
+
+	change := &Change{
+		Path:        req.Path,
+		TargetFile:  targetFile,
+		Author:      req.Author,
+		Message:     req.Message,
+		Patch:       req.Patch,
+		Parsed:      parsed,
+		CurrentSize: -1,
+	}
+
+	if version, err := s.repository.GetCurrentVersion(ctx); err == nil && version > 0 {
+		if entry, err := s.repository.GetEntry(ctx, version, targetFile); err == nil {
+			change.CurrentSize = entry.Size
+		}
+	}
+
+	return change
+}
+
+// validatePatch runs all configured validators against a change
+func (s *server) validatePatch(ctx context.Context, change *Change) []*pb.PolicyViolation {
+	var violations []*pb.PolicyViolation
+	for _, validator := range s.validators {
+		violations = append(violations, validator.Validate(ctx, change)...)
+	}
+	return violations
+}
+
+// formatViolations renders violations as a single human readable message
+func formatViolations(violations []*pb.PolicyViolation) string {
+	parts := make([]string, 0, len(violations))
+	for _, v := range violations {
+		if v.Path != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s: %s", v.Rule, v.Path, v.Description))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s: %s", v.Rule, v.Description))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// A view names a set of paths, such as everything a team owns, so it can be
+// tracked without listing its directories. Views are kept in the repository
+// itself and changed like any other file. A workspace tracks a view as the
+// pattern "view:<name>": it is expanded against the current version like a
+// glob, so paths added to the view are picked up on the next sync.
+
+const (
+	// viewsFile holds the repository's view definitions
+	viewsFile = ".poon/views.json"
+
+	// viewPrefix marks a tracked path as a view
+	viewPrefix = "view:"
+)
+
+// PathView is one named set of paths or glob patterns
+type PathView struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Paths       []string `json:"paths"`
+}
+
+// ViewConfig is the format of .poon/views.json
+type ViewConfig struct {
+	Views []PathView `json:"views"`
+}
+
+// isViewReference reports whether path names a view rather than a path
+func isViewReference(path string) bool {
+	return strings.HasPrefix(path, viewPrefix)
+}
+
+// parseViews reads and validates view definitions
+func parseViews(data []byte) (map[string]*PathView, error) {
+	var config ViewConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", viewsFile, err)
+	}
+
+	views := make(map[string]*PathView, len(config.Views))
+	for i := range config.Views {
+		view := &config.Views[i]
+		if view.Name == "" {
+			return nil, fmt.Errorf("view %d in %s has no name", i+1, viewsFile)
+		}
+		if strings.ContainsAny(view.Name, ":/ ") {
+			return nil, fmt.Errorf("view name %q must not contain ':', '/' or spaces", view.Name)
+		}
+		if _, exists := views[view.Name]; exists {
+			return nil, fmt.Errorf("view %q is defined twice", view.Name)
+		}
+		if len(view.Paths) == 0 {
+			return nil, fmt.Errorf("view %q has no paths", view.Name)
+		}
+		for _, path := range view.Paths {
+			if isViewReference(path) || isWorkspaceReference(path) {
+				return nil, fmt.Errorf("view %q includes %s; views list paths, not other views or workspaces", view.Name, path)
+			}
+			if err := validatePath(path); err != nil {
+				return nil, fmt.Errorf("view %q: %w", view.Name, err)
+			}
+		}
+		views[view.Name] = view
+	}
+	return views, nil
+}
+
+// loadViews returns the views defined at version. A repository without a
+// views file has no views.
+func (s *server) loadViews(ctx context.Context, version int64) (map[string]*PathView, error) {
+	if version == 0 {
+		return map[string]*PathView{}, nil
+	}
+	data, err := s.repository.ReadFile(ctx, version, viewsFile)
+	if errors.Is(err, storage.ErrNotFound) {
+		return map[string]*PathView{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", viewsFile, err)
+	}
+	return parseViews(data)
+}
+
+// expandView returns the paths a view reference covers at version. Glob
+// patterns are expanded and literal paths that do not exist (yet) are left
+// out, so a view may name a directory before it is created.
+func (s *server) expandView(ctx context.Context, version int64, views map[string]*PathView, reference string) ([]string, error) {
+	name := strings.TrimPrefix(reference, viewPrefix)
+	view, ok := views[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown view %q (see %s)", name, viewsFile)
+	}
+
+	var found []string
+	for _, path := range view.Paths {
+		if storage.IsGlobPattern(path) {
+			matches, err := s.repository.Glob(ctx, version, path)
+			if err != nil {
+				return nil, fmt.Errorf("view %q: %w", name, err)
+			}
+			found = append(found, matches...)
+			continue
+		}
+		if _, err := s.repository.GetEntry(ctx, version, path); err != nil {
+			continue
+		}
+		found = append(found, cleanRepoPath(path))
+	}
+	return found, nil
+}
+
+func (s *server) ListViews(ctx context.Context, req *pb.ListViewsRequest) (*pb.ListViewsResponse, error) {
+	log.Printf("Listing path views at version %d", req.Version)
+
+	version := req.Version
+	if version == 0 {
+		current, err := s.repository.GetCurrentVersion(ctx)
+		if err != nil {
+			return &pb.ListViewsResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to get current version: %v", err),
+			}, nil
+		}
+		version = current
+	}
+
+	views, err := s.loadViews(ctx, version)
+	if err != nil {
+		return &pb.ListViewsResponse{
+			Success: false,
+			Message: err.Error(),
+			Version: version,
+		}, nil
+	}
+
+	result := make([]*pb.PathView, 0, len(views))
+	for _, view := range views {
+		result = append(result, &pb.PathView{
+			Name:        view.Name,
+			Description: view.Description,
+			Paths:       view.Paths,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return &pb.ListViewsResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d view(s)", len(result)),
+		Views:   result,
+		Version: version,
+	}, nil
+}
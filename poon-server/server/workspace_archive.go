@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamWorkspaceArchive streams the workspace repository's checked-out
+// commit as a tar. git archive reads the objects as they are, without the
+// delta search and compression a clone's pack needs, and the output goes to
+// the client as it is produced.
+func (s *server) StreamWorkspaceArchive(req *pb.StreamWorkspaceArchiveRequest, stream pb.MonorepoService_StreamWorkspaceArchiveServer) error {
+	log.Printf("Streaming archive of workspace %s", req.WorkspaceId)
+
+	repoPath, ok := s.WorkspaceRepoPath(req.WorkspaceId)
+	if !ok {
+		return status.Errorf(codes.NotFound, "workspace %s not found", req.WorkspaceId)
+	}
+
+	ctx := stream.Context()
+	if err := s.AwaitWorkspace(ctx, req.WorkspaceId); err != nil {
+		return status.Errorf(codes.Unavailable, "workspace %s is not ready: %v", req.WorkspaceId, err)
+	}
+	commit, err := gitHead(ctx, repoPath)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "workspace %s has no commit: %v", req.WorkspaceId, err)
+	}
+	branch, err := gitBranch(ctx, repoPath)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "workspace %s is not on a branch: %v", req.WorkspaceId, err)
+	}
+
+	if err := stream.Send(&pb.WorkspaceArchiveChunk{Commit: commit, Branch: branch}); err != nil {
+		return err
+	}
+
+	out := &archiveChunkWriter{send: func(data []byte) error {
+		return stream.Send(&pb.WorkspaceArchiveChunk{Data: data})
+	}}
+	var stderr bytes.Buffer
+	cmd := gitCommand(ctx, repoPath, "archive", "--format=tar", commit)
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to archive workspace %s: %v: %s", req.WorkspaceId, err, strings.TrimSpace(stderr.String()))
+	}
+	return out.flush()
+}
+
+// archiveChunkWriter sends what is written to it as streamChunkSize chunks
+type archiveChunkWriter struct {
+	send func(data []byte) error
+	buf  []byte
+}
+
+func (w *archiveChunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		take := min(len(p), streamChunkSize-len(w.buf))
+		w.buf = append(w.buf, p[:take]...)
+		p = p[take:]
+		if len(w.buf) == streamChunkSize {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *archiveChunkWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	// Send may hold on to the message, so each chunk gets its own buffer
+	err := w.send(w.buf)
+	w.buf = nil
+	return err
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Workspace directory collection runs this often and spares directories
+// changed this recently unless WORKSPACE_GC_INTERVAL and WORKSPACE_GC_GRACE
+// say otherwise
+const (
+	defaultWorkspaceGCInterval = time.Hour
+	defaultWorkspaceGCGrace    = 24 * time.Hour
+)
+
+// collectingPrefix marks a directory being removed. Orphans are renamed
+// while the workspace lock is held and removed after it is released, and a
+// removal cut short is finished by the next collection.
+const collectingPrefix = ".collecting-"
+
+// orphanedDirectory is a directory in the workspace root without a workspace
+type orphanedDirectory struct {
+	Name       string
+	ModifiedAt time.Time // Latest change to anything inside it
+	Bytes      int64
+}
+
+// collectWorkspaceDirectories finds directories in the workspace root that
+// no workspace owns and that have not changed for grace, and removes them
+// unless dryRun. Workspaces deleted through the API leave such directories,
+// as do workspaces from before a restart, since records live in memory.
+func (s *server) collectWorkspaceDirectories(grace time.Duration, dryRun bool) ([]orphanedDirectory, error) {
+	entries, err := os.ReadDir(s.workspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace root: %w", err)
+	}
+	cutoff := time.Now().Add(-grace)
+
+	// Directories are walked without the lock, so that workspace requests
+	// are not held up by a large orphan; whether they are still orphaned is
+	// checked again under the lock before anything is removed
+	s.mu.RLock()
+	owned := s.ownedWorkspaceDirectories()
+	s.mu.RUnlock()
+	var links, remove []string
+	var candidates []orphanedDirectory
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(s.workspaceRoot, name)
+		if owned[name] {
+			continue
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// Name links of workspaces from before a restart
+			links = append(links, name)
+			continue
+		}
+		if !entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, collectingPrefix) {
+			if !dryRun {
+				remove = append(remove, path)
+			}
+			continue
+		}
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		orphan, err := inspectDirectory(path)
+		if err != nil {
+			log.Printf("Warning: failed to inspect workspace directory %s: %v", path, err)
+			continue
+		}
+		if orphan.ModifiedAt.After(cutoff) {
+			continue
+		}
+		candidates = append(candidates, orphan)
+	}
+	if dryRun {
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+		return candidates, nil
+	}
+
+	// The lock keeps CreateWorkspace from claiming a directory between the
+	// check and the rename
+	var orphans []orphanedDirectory
+	s.mu.Lock()
+	owned = s.ownedWorkspaceDirectories()
+	for _, name := range links {
+		if !owned[name] {
+			os.Remove(filepath.Join(s.workspaceRoot, name))
+		}
+	}
+	for _, orphan := range candidates {
+		path := filepath.Join(s.workspaceRoot, orphan.Name)
+		if owned[orphan.Name] {
+			continue
+		}
+		// Anything added since the walk shows in the directory's own time
+		if info, err := os.Stat(path); err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		collecting := filepath.Join(s.workspaceRoot, collectingPrefix+orphan.Name)
+		if err := os.Rename(path, collecting); err != nil {
+			log.Printf("Warning: failed to remove workspace directory %s: %v", path, err)
+			continue
+		}
+		remove = append(remove, collecting)
+		orphans = append(orphans, orphan)
+	}
+	s.mu.Unlock()
+
+	for _, path := range remove {
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("Warning: failed to remove workspace directory %s: %v", path, err)
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Name < orphans[j].Name })
+	return orphans, nil
+}
+
+// ownedWorkspaceDirectories returns the names in the workspace root that
+// belong to a workspace. The caller must hold s.mu.
+func (s *server) ownedWorkspaceDirectories() map[string]bool {
+	owned := make(map[string]bool, len(s.workspaces))
+	for id, workspace := range s.workspaces {
+		owned[id] = true
+		owned[workspace.Name] = true
+		if rel, err := filepath.Rel(s.workspaceRoot, workspace.GitRepoPath); err == nil && filepath.IsLocal(rel) {
+			owned[strings.Split(filepath.ToSlash(rel), "/")[0]] = true
+		}
+	}
+	return owned
+}
+
+// inspectDirectory adds up a directory's size and finds its latest change
+func inspectDirectory(path string) (orphanedDirectory, error) {
+	orphan := orphanedDirectory{Name: filepath.Base(path)}
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(orphan.ModifiedAt) {
+			orphan.ModifiedAt = info.ModTime()
+		}
+		if info.Mode().IsRegular() {
+			orphan.Bytes += info.Size()
+		}
+		return nil
+	})
+	return orphan, err
+}
+
+// runWorkspaceGC collects orphaned workspace directories, compacts workspace
+// histories and collects unused shared objects now and then every interval
+// until ctx ends
+func (s *server) runWorkspaceGC(ctx context.Context, interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		orphans, err := s.collectWorkspaceDirectories(grace, false)
+		if err != nil {
+			log.Printf("Workspace directory collection failed: %v", err)
+		} else if len(orphans) > 0 {
+			var bytes int64
+			for _, orphan := range orphans {
+				bytes += orphan.Bytes
+			}
+			log.Printf("Removed %d orphaned workspace directories (%d bytes)", len(orphans), bytes)
+		}
+
+		compactions, err := s.compactWorkspaces(ctx, "", 0)
+		if err != nil {
+			log.Printf("Workspace compaction failed: %v", err)
+		}
+		var compacted int
+		var freed int64
+		for _, compaction := range compactions {
+			if compaction.Depth > 0 {
+				compacted++
+				freed += compaction.BytesBefore - compaction.BytesAfter
+			}
+		}
+		if compacted > 0 {
+			log.Printf("Compacted the history of %d workspaces (%d bytes freed)", compacted, freed)
+		}
+
+		shared, err := s.collectSharedObjects(ctx, grace, false)
+		if err != nil {
+			log.Printf("Shared object collection failed: %v", err)
+		} else if shared.Objects > 0 {
+			log.Printf("Removed %d shared objects no workspace uses (%d bytes)", shared.Objects, shared.Bytes)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// workspacePruneGrace spares unreachable loose objects this recent when a
+// workspace repository is compacted, since a push may still be writing them
+const workspacePruneGrace = time.Hour
+
+// workspaceCompaction describes a workspace repository before and after
+// its history was compacted
+type workspaceCompaction struct {
+	ID            string
+	Depth         int // Commits kept; 0 when the history was left alone
+	CommitsBefore int
+	CommitsAfter  int
+	BytesBefore   int64
+	BytesAfter    int64
+}
+
+// measureWorkspace records the disk used by workspace's repository and the
+// commits in its history. Callers must hold s.mu.
+func (s *server) measureWorkspace(ctx context.Context, workspace *Workspace) error {
+	bytes, commits, err := measureRepository(ctx, workspace.GitRepoPath)
+	if err != nil {
+		return err
+	}
+	workspace.DiskBytes = bytes
+	workspace.Commits = commits
+	return nil
+}
+
+// measureRepository returns the disk used by a workspace repository and
+// the commits in its history
+func measureRepository(ctx context.Context, repoPath string) (int64, int, error) {
+	dir, err := inspectDirectory(repoPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to measure workspace repository: %w", err)
+	}
+	commits, err := countCommits(ctx, repoPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	return dir.Bytes, commits, nil
+}
+
+// countCommits counts the commits reachable from HEAD, down to the shallow
+// boundary of a compacted repository
+func countCommits(ctx context.Context, repoPath string) (int, error) {
+	out, err := gitCommand(ctx, repoPath, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// workspaceHistory is what compacting a workspace needs to know about it,
+// read under s.mu so the compaction itself can run without it
+type workspaceHistory struct {
+	id         string
+	repoPath   string
+	depth      int   // Commits to keep, given or WORKSPACE_HISTORY_DEPTH; 0 for all
+	diskLimit  int64 // Over it only one commit is kept; 0 for no limit
+	clientHead string
+}
+
+// workspaceHistory returns the compaction settings of workspace, keeping
+// depth commits if given. Callers must hold s.mu.
+func (s *server) workspaceHistory(workspace *Workspace, depth int) workspaceHistory {
+	if depth <= 0 {
+		depth = s.workspaceHistoryDepth
+	}
+	return workspaceHistory{
+		id:         workspace.ID,
+		repoPath:   workspace.GitRepoPath,
+		depth:      depth,
+		diskLimit:  s.quotas.LimitsFor(workspace.Owner).MaxWorkspaceDiskBytes,
+		clientHead: workspace.HeadCommit,
+	}
+}
+
+// compactWorkspace measures workspace and cuts its history down as
+// compactHistory does. Callers must hold s.mu and workspace.repoMu.
+func (s *server) compactWorkspace(ctx context.Context, workspace *Workspace, depth int) (workspaceCompaction, error) {
+	if workspace.materializing != nil {
+		return workspaceCompaction{ID: workspace.ID}, nil // Measured once filled
+	}
+	result, err := s.compactHistory(ctx, s.workspaceHistory(workspace, depth))
+	if err != nil {
+		return result, err
+	}
+	workspace.Commits, workspace.DiskBytes = result.CommitsAfter, result.BytesAfter
+	return result, nil
+}
+
+// compactHistory measures a workspace repository and cuts its history down
+// to history.depth commits, or one when the repository takes more than its
+// disk limit, but never past the commit its client last reported building
+// on. Callers must hold the workspace's repoMu.
+func (s *server) compactHistory(ctx context.Context, history workspaceHistory) (workspaceCompaction, error) {
+	result := workspaceCompaction{ID: history.id}
+	s.shareWorkspaceObjects(history.repoPath) // Such as those of pushes
+	bytes, commits, err := measureRepository(ctx, history.repoPath)
+	if err != nil {
+		return result, err
+	}
+	result.CommitsBefore, result.CommitsAfter = commits, commits
+	result.BytesBefore, result.BytesAfter = bytes, bytes
+
+	depth := history.depth
+	if history.diskLimit > 0 && bytes > history.diskLimit {
+		depth = 1
+	}
+	if depth <= 0 || commits <= depth {
+		return result, nil
+	}
+	if history.clientHead != "" {
+		behind, ok := commitsBehind(ctx, history.repoPath, history.clientHead)
+		if !ok {
+			// The client builds on commits the server has not seen, so
+			// which of its commits they start from is unknown
+			return result, nil
+		}
+		depth = max(depth, behind+1)
+		if commits <= depth {
+			return result, nil
+		}
+	}
+
+	if err := shallowHistory(ctx, history.repoPath, depth); err != nil {
+		return result, err
+	}
+	if bytes, commits, err = measureRepository(ctx, history.repoPath); err != nil {
+		return result, err
+	}
+	result.Depth = depth
+	result.CommitsAfter, result.BytesAfter = commits, bytes
+	return result, nil
+}
+
+// commitsBehind returns how many commits HEAD of repoPath is ahead of
+// commit, and false if commit is not in HEAD's history
+func commitsBehind(ctx context.Context, repoPath, commit string) (int, bool) {
+	if err := gitCommand(ctx, repoPath, "merge-base", "--is-ancestor", commit, "HEAD").Run(); err != nil {
+		return 0, false
+	}
+	out, err := gitCommand(ctx, repoPath, "rev-list", "--count", commit+"..HEAD").Output()
+	if err != nil {
+		return 0, false
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	return count, err == nil
+}
+
+// compactWorkspaces compacts the workspace named by idOrName, or every
+// workspace when it is empty, one at a time. Each is compacted under its
+// own repoMu, so requests for other workspaces are not held up.
+func (s *server) compactWorkspaces(ctx context.Context, idOrName string, depth int) ([]workspaceCompaction, error) {
+	s.mu.RLock()
+	var ids []string
+	if idOrName != "" {
+		workspace, exists := s.lookupWorkspace(idOrName)
+		if !exists {
+			s.mu.RUnlock()
+			return nil, fmt.Errorf("workspace %s not found", idOrName)
+		}
+		ids = append(ids, workspace.ID)
+	} else {
+		for id := range s.workspaces {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Strings(ids)
+
+	var results []workspaceCompaction
+	for _, id := range ids {
+		result, err := s.compactWorkspaceByID(ctx, id, depth)
+		if err != nil {
+			if idOrName != "" {
+				return nil, err
+			}
+			log.Printf("Warning: failed to compact workspace %s: %v", id, err)
+			continue
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results, nil
+}
+
+// compactWorkspaceByID compacts a workspace holding s.mu only to read its
+// settings and record the result, or returns nil if it has been deleted
+func (s *server) compactWorkspaceByID(ctx context.Context, id string, depth int) (*workspaceCompaction, error) {
+	s.mu.RLock()
+	workspace, exists := s.workspaces[id]
+	if !exists {
+		s.mu.RUnlock()
+		return nil, nil // Deleted meanwhile
+	}
+	if workspace.materializing != nil {
+		s.mu.RUnlock()
+		return &workspaceCompaction{ID: id}, nil // Measured once filled
+	}
+	history := s.workspaceHistory(workspace, depth)
+	s.mu.RUnlock()
+
+	workspace.repoMu.Lock()
+	result, err := s.compactHistory(ctx, history)
+	workspace.repoMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.workspaces[id] == workspace {
+		workspace.Commits, workspace.DiskBytes = result.CommitsAfter, result.BytesAfter
+	}
+	s.mu.Unlock()
+	return &result, nil
+}
+
+// shallowHistory turns repoPath into a shallow repository holding the last
+// depth commits of HEAD and deletes the objects only older commits used.
+// Shared objects are left to collectSharedObjects.
+// Commit hashes do not change, so clients holding the full history keep
+// fetching and pushing as before.
+func shallowHistory(ctx context.Context, repoPath string, depth int) error {
+	out, err := gitCommand(ctx, repoPath, "rev-list", "--parents", fmt.Sprintf("--max-count=%d", depth), "HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	// Kept commits with a parent that is not kept become the boundary
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	kept := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		kept[strings.Fields(line)[0]] = true
+	}
+	var boundary []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		for _, parent := range fields[1:] {
+			if !kept[parent] {
+				boundary = append(boundary, fields[0])
+				break
+			}
+		}
+	}
+	if len(boundary) == 0 {
+		return nil
+	}
+
+	gitDir := filepath.Join(repoPath, ".git")
+	if err := os.WriteFile(filepath.Join(gitDir, "shallow"), []byte(strings.Join(boundary, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write shallow boundary: %w", err)
+	}
+	// Reflogs and the commit graph still reach the old commits
+	if output, err := gitCommand(ctx, repoPath, "reflog", "expire", "--expire=now", "--all").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to expire reflogs: %v - %s", err, output)
+	}
+	os.Remove(filepath.Join(gitDir, "objects", "info", "commit-graph"))
+	os.RemoveAll(filepath.Join(gitDir, "objects", "info", "commit-graphs"))
+
+	if output, err := gitCommand(ctx, repoPath, "repack", "-a", "-d", "-l", "-q").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to repack: %v - %s", err, output)
+	}
+	expire := fmt.Sprintf("--expire=%d.seconds.ago", int(workspacePruneGrace.Seconds()))
+	if output, err := gitCommand(ctx, repoPath, "prune", expire).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to prune: %v - %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+)
+
+// materialization fills the repository of a new workspace in the
+// background, without holding s.mu. Until it is done the workspace is
+// SYNCING; fetches wait for it, and changes to the workspace are refused.
+type materialization struct {
+	version    int64
+	filesTotal int64
+	filesDone  atomic.Int64
+	cancel     context.CancelFunc
+	done       chan struct{}
+	err        error // Set before done is closed
+}
+
+// progress returns the files copied so far and the files to copy
+func (m *materialization) progress() (int64, int64) {
+	return m.filesDone.Load(), m.filesTotal
+}
+
+// wait blocks until the repository is filled or ctx ends
+func (m *materialization) wait(ctx context.Context) error {
+	select {
+	case <-m.done:
+		return m.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type copiedFilesKey struct{}
+
+// countCopiedFile adds a file copied into a workspace repository to the
+// counter ctx carries, if any
+func countCopiedFile(ctx context.Context) {
+	if counter, ok := ctx.Value(copiedFilesKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+}
+
+// materialize copies workspace's tracked paths at version, which hold files
+// files, into its empty repository in the background. Callers must hold
+// s.mu.
+func (s *server) materialize(workspace *Workspace, version, files int64) *materialization {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &materialization{
+		version:    version,
+		filesTotal: files,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	workspace.materializing = m
+	trackedPaths, patterns := workspace.TrackedPaths, workspace.TrackedPatterns
+
+	go func() {
+		defer cancel()
+		start := time.Now()
+		err := s.populateWorkspaceGitRepo(context.WithValue(ctx, copiedFilesKey{}, &m.filesDone), workspace.GitRepoPath, version, trackedPaths, patterns)
+
+		s.mu.Lock()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			m.err = fmt.Errorf("the workspace was deleted")
+			log.Printf("Stopped materializing workspace %s", workspace.ID)
+		case err != nil:
+			m.err = fmt.Errorf("failed to copy tracked paths: %w", err)
+			workspace.Status = pb.WorkspaceStatus_ERROR
+			log.Printf("Warning: workspace %s was not materialized: %v", workspace.ID, err)
+		default:
+			workspace.materializing = nil
+			workspace.Status = pb.WorkspaceStatus_ACTIVE
+			workspace.LastSync = time.Now()
+			s.shareWorkspaceObjects(workspace.GitRepoPath)
+			if err := s.measureWorkspace(ctx, workspace); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			log.Printf("Materialized workspace %s (%d files) in %s", workspace.ID, m.filesDone.Load(), time.Since(start).Round(time.Millisecond))
+		}
+		s.mu.Unlock()
+		close(m.done)
+	}()
+	return m
+}
+
+// stopMaterializing cancels the filling of workspace's repository, if it
+// is still going. Callers must hold s.mu.
+func (w *Workspace) stopMaterializing() {
+	if w.materializing != nil {
+		w.materializing.cancel()
+	}
+}
+
+// unmaterialized reports why workspace's repository cannot be changed yet,
+// or "" if it can. Callers must hold s.mu.
+func (w *Workspace) unmaterialized() string {
+	m := w.materializing
+	if m == nil {
+		return ""
+	}
+	select {
+	case <-m.done:
+		return fmt.Sprintf("workspace %s could not be materialized: %v", w.ID, m.err)
+	default:
+		done, total := m.progress()
+		return fmt.Sprintf("workspace %s is still being materialized (%d of %d files copied)", w.ID, done, total)
+	}
+}
+
+// AwaitWorkspace waits until the repository of the workspace with the given
+// ID or name is filled, so the embedded git server does not serve it empty
+func (s *server) AwaitWorkspace(ctx context.Context, workspaceID string) error {
+	s.mu.RLock()
+	var m *materialization
+	if workspace, ok := s.lookupWorkspace(workspaceID); ok {
+		m = workspace.materializing
+	}
+	s.mu.RUnlock()
+
+	if m == nil {
+		return nil
+	}
+	return m.wait(ctx)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// A workspace may be given a name when it is created. The name is an alias
+// accepted wherever the workspace's ID is: the registry stays keyed by ID and
+// lookups fall back to names. A symlink from the name to the ID's directory
+// in the workspace root lets a standalone poon-git serve <name>.git too.
+
+const maxWorkspaceNameLength = 63
+
+var workspaceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// validateWorkspaceName checks that name can be used as a workspace alias:
+// lowercase letters, digits, '.', '_' and '-', starting with a letter or
+// digit, that cannot be mistaken for an ID or a repository URL suffix
+func validateWorkspaceName(name string) error {
+	if len(name) > maxWorkspaceNameLength {
+		return fmt.Errorf("workspace name is longer than %d characters", maxWorkspaceNameLength)
+	}
+	if !workspaceNamePattern.MatchString(name) {
+		return fmt.Errorf("workspace name %q must be lowercase letters, digits, '.', '_' or '-', starting with a letter or digit", name)
+	}
+	if strings.HasSuffix(name, ".git") {
+		return fmt.Errorf("workspace name %q must not end in .git", name)
+	}
+	if _, err := uuid.Parse(name); err == nil {
+		return fmt.Errorf("workspace name %q looks like a workspace ID", name)
+	}
+	return nil
+}
+
+// lookupWorkspace finds a workspace by ID or by name. The caller holds s.mu.
+func (s *server) lookupWorkspace(idOrName string) (*Workspace, bool) {
+	if workspace, ok := s.workspaces[idOrName]; ok {
+		return workspace, true
+	}
+	if idOrName == "" {
+		return nil, false
+	}
+	for _, workspace := range s.workspaces {
+		if workspace.Name == idOrName {
+			return workspace, true
+		}
+	}
+	return nil, false
+}
+
+// named reports whether a workspace was given a name; unnamed workspaces
+// carry their ID as their name
+func (w *Workspace) named() bool {
+	return w.Name != "" && w.Name != w.ID
+}
+
+// linkWorkspaceName points name at the workspace's directory so poon-git can
+// find the repository by name without the registry. A link left by a
+// workspace from before a restart is replaced.
+func (s *server) linkWorkspaceName(workspace *Workspace) {
+	if !workspace.named() || s.workspaceRoot == "" {
+		return
+	}
+	link := filepath.Join(s.workspaceRoot, workspace.Name)
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		os.Remove(link)
+	}
+	if err := os.Symlink(workspace.ID, link); err != nil {
+		log.Printf("Warning: failed to link workspace name %s to %s: %v", workspace.Name, workspace.ID, err)
+	}
+}
+
+// unlinkWorkspaceName removes the symlink made by linkWorkspaceName, leaving
+// anything else at that path alone
+func (s *server) unlinkWorkspaceName(workspace *Workspace) {
+	if !workspace.named() || s.workspaceRoot == "" {
+		return
+	}
+	link := filepath.Join(s.workspaceRoot, workspace.Name)
+	if target, err := os.Readlink(link); err != nil || target != workspace.ID {
+		return
+	}
+	if err := os.Remove(link); err != nil {
+		log.Printf("Warning: failed to unlink workspace name %s: %v", workspace.Name, err)
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sharedObjectsDir is the git object directory in the workspace root that
+// workspace repositories borrow from through alternates, so a file tracked
+// by many workspaces is stored once. It starts with a dot, so workspace
+// directory collection leaves it alone.
+const sharedObjectsDir = ".objects"
+
+// shareObjectsWith makes the repository at repoPath look up objects in the
+// shared object directory too. Objects git finds there are not written
+// again, so only files no workspace had are stored by the next commit.
+func shareObjectsWith(repoPath, shared string) error {
+	if err := os.MkdirAll(filepath.Join(shared, "info"), 0755); err != nil {
+		return fmt.Errorf("failed to create shared object directory: %w", err)
+	}
+	alternates := filepath.Join(repoPath, ".git", "objects", "info", "alternates")
+	if err := os.MkdirAll(filepath.Dir(alternates), 0755); err != nil {
+		return fmt.Errorf("failed to set up shared objects: %w", err)
+	}
+	if err := os.WriteFile(alternates, []byte(shared+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to set up shared objects: %w", err)
+	}
+	return nil
+}
+
+// isLooseObject reports whether dir/name is the path of a loose object,
+// as opposed to git's temporary files beside them
+func isLooseObject(dir, name string) bool {
+	if len(dir) != 2 || (len(name) != 38 && len(name) != 62) {
+		return false
+	}
+	_, err := hex.DecodeString(dir + name)
+	return err == nil
+}
+
+// moveObjectsToShared moves the loose objects of the repository at repoPath
+// into the shared object directory, where the repository still finds them
+// through its alternates. Objects already shared are dropped. Packed
+// objects, such as those of large pushes, stay where they are.
+func moveObjectsToShared(repoPath, shared string) (int, error) {
+	objects := filepath.Join(repoPath, ".git", "objects")
+	dirs, err := os.ReadDir(objects)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read objects: %w", err)
+	}
+
+	moved := 0
+	now := time.Now()
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(objects, dir.Name()))
+		if err != nil {
+			return moved, fmt.Errorf("failed to read objects: %w", err)
+		}
+		for _, file := range files {
+			if !isLooseObject(dir.Name(), file.Name()) {
+				continue
+			}
+			src := filepath.Join(objects, dir.Name(), file.Name())
+			dst := filepath.Join(shared, dir.Name(), file.Name())
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return moved, fmt.Errorf("failed to share object: %w", err)
+			}
+			// Linked before the local copy goes, so the object is always
+			// somewhere git looks
+			if err := os.Link(src, dst); os.IsExist(err) {
+				// Shared already; freshened so collection spares it
+				os.Chtimes(dst, now, now)
+			} else if err != nil {
+				return moved, fmt.Errorf("failed to share object: %w", err)
+			}
+			if err := os.Remove(src); err != nil {
+				return moved, fmt.Errorf("failed to share object: %w", err)
+			}
+			moved++
+		}
+		os.Remove(filepath.Join(objects, dir.Name())) // Only if empty
+	}
+	return moved, nil
+}
+
+// shareWorkspaceObjects moves a workspace repository's new objects into the
+// shared object directory. Failing to is not an error for the caller: the
+// objects stay in the repository and are moved next time. Callers must keep
+// anything else from committing to the repository meanwhile, as holding its
+// workspace's repoMu does.
+func (s *server) shareWorkspaceObjects(repoPath string) {
+	if s.sharedObjects == "" {
+		return
+	}
+	if _, err := moveObjectsToShared(repoPath, s.sharedObjects); err != nil {
+		log.Printf("Warning: failed to share objects of %s: %v", repoPath, err)
+	}
+}
+
+// sharedObjectCollection describes the shared objects no workspace
+// repository uses any more
+type sharedObjectCollection struct {
+	Objects int
+	Bytes   int64
+}
+
+// collectSharedObjects removes shared objects that no repository in the
+// workspace root reaches and that have not been written or reused for
+// grace, unless dryRun. Repositories of orphaned directories count too,
+// since they borrow from the shared directory until they are removed.
+//
+// No lock is held: workspaces may commit while reachability is worked out.
+// Git freshens an object it would write that exists already, and moving
+// objects into the shared directory freshens those shared before, so any
+// object committed since the listing started is newer than the cutoff,
+// which is never later than that start, and is spared.
+func (s *server) collectSharedObjects(ctx context.Context, grace time.Duration, dryRun bool) (sharedObjectCollection, error) {
+	var result sharedObjectCollection
+	if s.sharedObjects == "" {
+		return result, nil
+	}
+	cutoff := time.Now().Add(-grace)
+
+	entries, err := os.ReadDir(s.workspaceRoot)
+	if err != nil {
+		return result, fmt.Errorf("failed to read workspace root: %w", err)
+	}
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		repoPath := filepath.Join(s.workspaceRoot, entry.Name(), "repo")
+		if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
+			continue
+		}
+		// Any failure leaves everything in place rather than risk removing
+		// objects a repository needs
+		out, err := gitCommand(ctx, repoPath, "rev-list", "--objects", "--all", "--reflog", "--indexed-objects").Output()
+		if err != nil {
+			return result, fmt.Errorf("failed to list objects of %s: %w", repoPath, err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if hash, _, _ := strings.Cut(line, " "); hash != "" {
+				used[hash] = true
+			}
+		}
+	}
+
+	err = filepath.WalkDir(s.sharedObjects, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		dir := filepath.Base(filepath.Dir(path))
+		if entry.IsDir() || !isLooseObject(dir, entry.Name()) || used[dir+entry.Name()] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+		if !dryRun {
+			// Checked again just before, in case a commit reused it
+			if info, err = os.Stat(path); err != nil || info.ModTime().After(cutoff) {
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+		result.Objects++
+		result.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to collect shared objects: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChangeCounts adds up the files a version changed
+type ChangeCounts struct {
+	Files      int   `json:"files"`
+	Insertions int   `json:"insertions"`
+	Deletions  int   `json:"deletions"`
+	BytesAdded int64 `json:"bytesAdded"` // Size of the files whose content was added or changed
+}
+
+func (c *ChangeCounts) add(stat FileStat, size int64) {
+	c.Files++
+	c.Insertions += stat.Insertions
+	c.Deletions += stat.Deletions
+	c.BytesAdded += size
+}
+
+// VersionMetrics are the statistics recorded for one version
+type VersionMetrics struct {
+	Version int64     `json:"version"`
+	Time    time.Time `json:"time"`
+	Author  string    `json:"author"`
+	ChangeCounts
+	Directories map[string]ChangeCounts `json:"directories"` // By the changed files' parent directory, "" for the root
+}
+
+// ComputeVersionMetrics diffs a version against an earlier one, normally
+// the version before it, or 0 for the first version
+func ComputeVersionMetrics(ctx context.Context, repo Repository, from, version int64) (*VersionMetrics, error) {
+	stats, err := repo.ChangeStats(ctx, from, version)
+	if err != nil {
+		return nil, err
+	}
+	return NewVersionMetrics(ctx, repo, version, stats)
+}
+
+// NewVersionMetrics builds a version's metrics from the files it changed,
+// for callers that have already diffed it
+func NewVersionMetrics(ctx context.Context, repo Repository, version int64, stats []FileStat) (*VersionMetrics, error) {
+	info, err := repo.GetVersionInfo(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.GetCommit(ctx, info.CommitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit: %w", err)
+	}
+
+	metrics := &VersionMetrics{
+		Version:     version,
+		Time:        info.Timestamp,
+		Author:      commit.Author,
+		Directories: make(map[string]ChangeCounts),
+	}
+	for _, stat := range stats {
+		var size int64
+		if stat.Type != ChangeDeleted && stat.NewHash != stat.OldHash {
+			entry, err := repo.GetEntry(ctx, version, stat.Path)
+			if err != nil {
+				return nil, err
+			}
+			size = entry.Size
+		}
+		metrics.add(stat, size)
+
+		dir := path.Dir(stat.Path)
+		if dir == "." {
+			dir = ""
+		}
+		counts := metrics.Directories[dir]
+		counts.add(stat, size)
+		metrics.Directories[dir] = counts
+	}
+	return metrics, nil
+}
+
+// metricsPrefix is where an ActivityStore keeps its records
+const metricsPrefix = "metrics/"
+
+// metricsRef locates one version's record. The version and time are part of
+// the key, so the store can be indexed by listing keys without reading the
+// records.
+type metricsRef struct {
+	version int64
+	time    time.Time
+	key     string
+}
+
+// ActivityStore keeps VersionMetrics under metrics/ in a storage backend so
+// that activity can be queried without diffing versions again. Metrics
+// outlive their versions: history pruning does not remove them. Only an
+// index of the records is held in memory; Query reads the records in range.
+type ActivityStore struct {
+	backend StorageBackend
+	mu      sync.RWMutex
+	index   []metricsRef     // By time, then version, once loaded
+	keys    map[int64]string // Key of each recorded version
+	loaded  bool
+}
+
+// NewActivityStore creates a new activity store
+func NewActivityStore(backend StorageBackend) *ActivityStore {
+	return &ActivityStore{
+		backend: backend,
+	}
+}
+
+func metricsKey(version int64, t time.Time) string {
+	return fmt.Sprintf("%s%020d-%d", metricsPrefix, version, t.UnixNano())
+}
+
+// parseMetricsKey returns the version and time a key names. Keys written
+// before times were part of them hold only the version, and report ok false.
+func parseMetricsKey(key string) (ref metricsRef, ok bool, err error) {
+	name := strings.TrimPrefix(key, metricsPrefix)
+	versionPart, timePart, ok := strings.Cut(name, "-")
+	ref.key = key
+	if ref.version, err = strconv.ParseInt(versionPart, 10, 64); err != nil {
+		return ref, false, fmt.Errorf("malformed metrics key %s", key)
+	}
+	if !ok {
+		return ref, false, nil
+	}
+	nanos, err := strconv.ParseInt(timePart, 10, 64)
+	if err != nil {
+		return ref, false, fmt.Errorf("malformed metrics key %s", key)
+	}
+	ref.time = time.Unix(0, nanos)
+	return ref, true, nil
+}
+
+func (a *ActivityStore) read(ctx context.Context, key string) (*VersionMetrics, error) {
+	data, err := a.backend.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	var m VersionMetrics
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metrics %s: %w", strings.TrimPrefix(key, metricsPrefix), err)
+	}
+	return &m, nil
+}
+
+// load indexes the recorded versions from the backend's keys the first time
+// they are needed, moving any records stored under the older keys to keys
+// that carry their time. Callers must hold a.mu.
+func (a *ActivityStore) load(ctx context.Context) error {
+	if a.loaded {
+		return nil
+	}
+
+	keys, err := a.backend.List(ctx, metricsPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list metrics: %w", err)
+	}
+
+	index := make([]metricsRef, 0, len(keys))
+	byVersion := make(map[int64]string, len(keys))
+	for _, key := range keys {
+		ref, ok, err := parseMetricsKey(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			m, err := a.read(ctx, key)
+			if err != nil {
+				return err
+			}
+			if ref, err = a.put(ctx, m); err != nil {
+				return err
+			}
+			if err := a.backend.Delete(ctx, key); err != nil {
+				return fmt.Errorf("failed to delete metrics %s: %w", strings.TrimPrefix(key, metricsPrefix), err)
+			}
+		}
+		if _, dup := byVersion[ref.version]; dup {
+			continue // Left by a Record that did not get to delete the old key
+		}
+		index = append(index, ref)
+		byVersion[ref.version] = ref.key
+	}
+	sort.Slice(index, func(i, j int) bool { return index[i].before(index[j]) })
+
+	a.index = index
+	a.keys = byVersion
+	a.loaded = true
+	return nil
+}
+
+func (r metricsRef) before(other metricsRef) bool {
+	if !r.time.Equal(other.time) {
+		return r.time.Before(other.time)
+	}
+	return r.version < other.version
+}
+
+func (a *ActivityStore) put(ctx context.Context, m *VersionMetrics) (metricsRef, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return metricsRef{}, fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+	ref := metricsRef{version: m.Version, time: time.Unix(0, m.Time.UnixNano()), key: metricsKey(m.Version, m.Time)}
+	if err := a.backend.Put(ctx, ref.key, data); err != nil {
+		return metricsRef{}, fmt.Errorf("failed to store metrics: %w", err)
+	}
+	return ref, nil
+}
+
+// Record stores a version's metrics, replacing any recorded before
+func (a *ActivityStore) Record(ctx context.Context, m *VersionMetrics) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.load(ctx); err != nil {
+		return err
+	}
+
+	ref, err := a.put(ctx, m)
+	if err != nil {
+		return err
+	}
+	if old, ok := a.keys[m.Version]; ok {
+		a.index = slices.DeleteFunc(a.index, func(r metricsRef) bool { return r.version == m.Version })
+		if old != ref.key {
+			if err := a.backend.Delete(ctx, old); err != nil {
+				log.Printf("Warning: failed to delete replaced metrics of version %d: %v", m.Version, err)
+			}
+		}
+	}
+	i := sort.Search(len(a.index), func(i int) bool { return !a.index[i].before(ref) })
+	a.index = slices.Insert(a.index, i, ref)
+	a.keys[m.Version] = ref.key
+	return nil
+}
+
+// Query returns the metrics of the versions created in [since, until),
+// oldest first. Only the records in range are read.
+func (a *ActivityStore) Query(ctx context.Context, since, until time.Time) ([]*VersionMetrics, error) {
+	a.mu.Lock()
+	err := a.load(ctx)
+	var keys []string
+	if err == nil {
+		lo := sort.Search(len(a.index), func(i int) bool { return !a.index[i].time.Before(since) })
+		hi := sort.Search(len(a.index), func(i int) bool { return !a.index[i].time.Before(until) })
+		for _, ref := range a.index[lo:max(lo, hi)] {
+			keys = append(keys, ref.key)
+		}
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*VersionMetrics, 0, len(keys))
+	for _, key := range keys {
+		m, err := a.read(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue // Replaced since the index was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// Backfill records the metrics of every version that has none, such as
+// those created before metrics were recorded or while nothing recorded
+// them. Each version is compared with the newest version before it that
+// still exists. It returns how many versions it recorded. When every version
+// up to the current one is recorded, it returns without listing versions.
+func (a *ActivityStore) Backfill(ctx context.Context, repo Repository) (int, error) {
+	current, err := repo.GetCurrentVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	a.mu.Lock()
+	err = a.load(ctx)
+	recorded := make(map[int64]bool, len(a.keys))
+	for version := range a.keys {
+		recorded[version] = true
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	// Metrics are only recorded for versions that existed, so as many
+	// records as versions means none is missing
+	missing := int(current)
+	for version := range recorded {
+		if version <= current {
+			missing--
+		}
+	}
+	if missing == 0 {
+		return 0, nil
+	}
+
+	versions, err := repo.ListVersions(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	var previous int64
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i].Version
+		if !recorded[version] {
+			if err := ctx.Err(); err != nil {
+				return count, err
+			}
+			m, err := ComputeVersionMetrics(ctx, repo, previous, version)
+			if err != nil {
+				return count, fmt.Errorf("version %d: %w", version, err)
+			}
+			if err := a.Record(ctx, m); err != nil {
+				return count, err
+			}
+			count++
+		}
+		previous = version
+	}
+	return count, nil
+}
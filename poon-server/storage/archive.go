@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// archiveModTime is the modification time of every archive entry, so that an
+// archive depends only on the names, modes and contents in its tree
+var archiveModTime = time.Unix(0, 0)
+
+// WriteTreeArchive writes the tree with the given hash to w as a tar archive.
+// The archive is reproducible: entries are sorted by path, owned by root,
+// dated at the Unix epoch, and files are 0644 or 0755 depending on whether
+// any execute bit is set. The same tree therefore always produces the same
+// bytes, which lets build systems pin the archive by checksum.
+func (r *RepositoryImpl) WriteTreeArchive(ctx context.Context, hash Hash, w io.Writer) error {
+	if _, err := r.GetTree(ctx, hash); err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := r.writeTreeArchive(ctx, tw, hash, "", nil); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// WriteExportArchive writes the directory at dirPath in version to w as a
+// tar archive like WriteTreeArchive's, leaving out the files marked
+// "export-ignore" in the version's attributes
+func (r *RepositoryImpl) WriteExportArchive(ctx context.Context, version int64, dirPath string, w io.Writer) error {
+	rootTree, err := r.rootTreeHash(ctx, version)
+	if err != nil {
+		return err
+	}
+	return r.WriteExportArchiveInTree(ctx, rootTree, dirPath, w)
+}
+
+// WriteExportArchiveInTree is WriteExportArchive for the directory at
+// dirPath below a root tree, such as a branch commit's
+func (r *RepositoryImpl) WriteExportArchiveInTree(ctx context.Context, rootTree Hash, dirPath string, w io.Writer) error {
+	rules, err := r.attributesInTree(ctx, rootTree)
+	if err != nil {
+		return err
+	}
+	entry, err := r.entryInTree(ctx, rootTree, dirPath)
+	if err != nil {
+		return err
+	}
+	if entry.Type != ObjectTypeTree {
+		return fmt.Errorf("%s is not a directory", dirPath)
+	}
+
+	ignored := func(name string) bool {
+		return rules.Lookup(joinTreePath(dirPath, name)).ExportIgnore()
+	}
+	tw := tar.NewWriter(w)
+	if err := r.writeTreeArchive(ctx, tw, entry.Hash, "", ignored); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// writeTreeArchive writes a tree's entries below dir, skipping the files
+// ignored reports, if it is set
+func (r *RepositoryImpl) writeTreeArchive(ctx context.Context, tw *tar.Writer, hash Hash, dir string, ignored func(name string) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tree, err := r.getTree(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to read tree %s: %w", dir, err)
+	}
+
+	entries := append([]TreeEntry(nil), tree.Entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	for _, entry := range entries {
+		name := joinTreePath(dir, entry.Name)
+
+		if entry.Type == ObjectTypeTree {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name + "/",
+				Mode:     0755,
+				ModTime:  archiveModTime,
+				Format:   tar.FormatPAX,
+			}); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+			if err := r.writeTreeArchive(ctx, tw, entry.Hash, name, ignored); err != nil {
+				return err
+			}
+			continue
+		}
+		if ignored != nil && ignored(name) {
+			continue
+		}
+
+		if err := r.writeArchiveFile(ctx, tw, entry, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeArchiveFile streams one file's blob into the archive
+func (r *RepositoryImpl) writeArchiveFile(ctx context.Context, tw *tar.Writer, entry TreeEntry, name string) error {
+	content, size, err := r.OpenBlob(ctx, entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer content.Close()
+
+	mode := int64(0644)
+	if entry.Mode&0111 != 0 {
+		mode = 0755
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     mode,
+		Size:     size,
+		ModTime:  archiveModTime,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+package storage
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// archiveCachePrefix holds generated tree archives. They can be rebuilt
+// from the objects at any time, so backups and migrations leave them out.
+const archiveCachePrefix = "archive-cache/"
+
+// isCacheKey reports whether a backend key holds derived data or the
+// backend's own bookkeeping, which are not part of the repository
+func isCacheKey(key string) bool {
+	return strings.HasPrefix(key, archiveCachePrefix) || strings.HasPrefix(key, commitGraphPrefix) || key == replicationStateKey
+}
+
+// ArchiveCacheStats reports the contents and effectiveness of an ArchiveCache
+type ArchiveCacheStats struct {
+	Entries  int   `json:"entries"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"maxBytes"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+// ArchiveCache keeps generated archives of trees in a storage backend, keyed
+// by tree hash and format, and evicts the least recently used ones once they
+// take more than maxBytes. A tree never changes, so entries never go stale.
+//
+// Keys are archive-cache/<format>/<hash>-<size>, which lets the cache rebuild
+// its index from a listing after a restart without reading any archive.
+// Recency is kept in memory only, so after a restart every entry starts out
+// equally old.
+//
+// The lock only guards the index: archives are read, written and deleted
+// without it, so a slow backend holds up no other request. An archive
+// removed behind the index's back is noticed, and forgotten, when it is read.
+type ArchiveCache struct {
+	backend  StorageBackend
+	maxBytes int64
+
+	mu       sync.Mutex
+	loaded   bool
+	lru      *list.List               // Of *archiveCacheEntry, most recently used first
+	entries  map[string]*list.Element // By cacheID
+	building map[string]*archiveBuild // By cacheID, archives WriteArchive is building
+	bytes    int64
+	hits     int64
+	misses   int64
+}
+
+type archiveCacheEntry struct {
+	id   string // <format>/<hash>
+	size int64
+}
+
+func (e *archiveCacheEntry) key() string {
+	return archiveCachePrefix + e.id + "-" + strconv.FormatInt(e.size, 10)
+}
+
+// archiveBuild is an archive being built by WriteArchive. Done is closed
+// once it is built; cached is then set if it is in the cache.
+type archiveBuild struct {
+	done   chan struct{}
+	cached bool
+}
+
+// NewArchiveCache creates a cache of at most maxBytes in backend
+func NewArchiveCache(backend StorageBackend, maxBytes int64) *ArchiveCache {
+	return &ArchiveCache{
+		backend:  backend,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		building: make(map[string]*archiveBuild),
+	}
+}
+
+func cacheID(hash Hash, format string) string {
+	return format + "/" + string(hash)
+}
+
+// loadLocked indexes the archives already in the backend, returning those
+// that no longer fit for the caller to delete. Keys it cannot parse are
+// left alone. Callers must hold c.mu.
+func (c *ArchiveCache) loadLocked(ctx context.Context) ([]*archiveCacheEntry, error) {
+	if c.loaded {
+		return nil, nil
+	}
+
+	keys, err := c.backend.List(ctx, archiveCachePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive cache: %w", err)
+	}
+	for _, key := range keys {
+		rest := strings.TrimPrefix(key, archiveCachePrefix)
+		i := strings.LastIndexByte(rest, '-')
+		if i < 0 {
+			continue
+		}
+		size, err := strconv.ParseInt(rest[i+1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		entry := &archiveCacheEntry{id: rest[:i], size: size}
+		c.entries[entry.id] = c.lru.PushBack(entry)
+		c.bytes += size
+	}
+
+	c.loaded = true
+	return c.evictLocked(), nil
+}
+
+// lookup returns the index entry of an archive, or nil if it is not cached
+func (c *ArchiveCache) lookup(ctx context.Context, id string) *archiveCacheEntry {
+	c.mu.Lock()
+	victims, err := c.loadLocked(ctx)
+	var entry *archiveCacheEntry
+	if elem, ok := c.entries[id]; ok && err == nil {
+		entry = elem.Value.(*archiveCacheEntry)
+	}
+	c.mu.Unlock()
+
+	c.deleteArchives(ctx, victims)
+	return entry
+}
+
+// Get returns the cached archive of a tree in format, if there is one
+func (c *ArchiveCache) Get(ctx context.Context, hash Hash, format string) ([]byte, bool) {
+	entry := c.lookup(ctx, cacheID(hash, format))
+	if entry == nil {
+		c.count(false)
+		return nil, false
+	}
+
+	data, err := c.backend.Get(ctx, entry.key())
+	if err != nil || int64(len(data)) != entry.size {
+		// Removed behind our back, by a restore for example
+		c.forget(ctx, entry)
+		c.count(false)
+		return nil, false
+	}
+	c.touch(entry)
+	c.count(true)
+	return data, true
+}
+
+// WriteArchive writes the archive of a tree in format to w. A cached
+// archive is streamed from the backend; a missing one is written by build
+// as it is built, and cached. Concurrent requests for the same archive
+// wait for one build rather than each building it.
+func (c *ArchiveCache) WriteArchive(ctx context.Context, hash Hash, format string, w io.Writer, build func(io.Writer) error) error {
+	id := cacheID(hash, format)
+	for {
+		if entry := c.lookup(ctx, id); entry != nil {
+			if done, err := c.copyArchive(ctx, entry, w); done {
+				c.count(err == nil)
+				return err
+			}
+		}
+
+		c.mu.Lock()
+		if _, ok := c.entries[id]; ok {
+			// Cached since the lookup
+			c.mu.Unlock()
+			continue
+		}
+		if b, ok := c.building[id]; ok {
+			c.mu.Unlock()
+			select {
+			case <-b.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if b.cached {
+				continue
+			}
+			// Too large to cache, or the build failed: build it here
+			c.count(false)
+			_, err := c.buildArchive(ctx, hash, format, w, build)
+			return err
+		}
+		b := &archiveBuild{done: make(chan struct{})}
+		c.building[id] = b
+		c.misses++
+		c.mu.Unlock()
+
+		cached, err := c.buildArchive(ctx, hash, format, w, build)
+
+		c.mu.Lock()
+		delete(c.building, id)
+		b.cached = cached
+		c.mu.Unlock()
+		close(b.done)
+		return err
+	}
+}
+
+// copyArchive streams a cached archive to w. It reports done once anything
+// has been written, since the archive cannot then be built instead; an
+// archive that cannot be read is forgotten.
+func (c *ArchiveCache) copyArchive(ctx context.Context, entry *archiveCacheEntry, w io.Writer) (bool, error) {
+	r, err := c.backend.Stream(ctx, entry.key())
+	if err != nil {
+		c.forget(ctx, entry)
+		return false, nil
+	}
+	defer r.Close()
+
+	out := &archiveWriter{w: w}
+	_, err = io.Copy(out, r)
+	if out.err != nil {
+		return true, out.err
+	}
+	if err == nil && out.n != entry.size {
+		err = fmt.Errorf("cached archive %s is %d bytes, not %d", entry.id, out.n, entry.size)
+	}
+	if err != nil {
+		c.forget(ctx, entry)
+		if out.n == 0 {
+			return false, nil
+		}
+		return true, fmt.Errorf("failed to read cached archive %s: %w", entry.id, err)
+	}
+	c.touch(entry)
+	return true, nil
+}
+
+// buildArchive writes an archive to w as build produces it, keeping a copy
+// to cache unless it grows larger than the whole cache. It reports whether
+// the archive was cached.
+func (c *ArchiveCache) buildArchive(ctx context.Context, hash Hash, format string, w io.Writer, build func(io.Writer) error) (bool, error) {
+	recorder := &archiveRecorder{max: c.maxBytes}
+	if err := build(io.MultiWriter(w, recorder)); err != nil {
+		return false, err
+	}
+	if recorder.over {
+		return false, nil
+	}
+	if err := c.Put(ctx, hash, format, recorder.buf.Bytes()); err != nil {
+		log.Printf("Warning: %v", err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Put caches the archive of a tree in format, evicting the least recently
+// used archives to stay within the size limit. Archives larger than the
+// whole cache are not stored.
+func (c *ArchiveCache) Put(ctx context.Context, hash Hash, format string, data []byte) error {
+	if int64(len(data)) > c.maxBytes {
+		return nil
+	}
+
+	id := cacheID(hash, format)
+	c.mu.Lock()
+	victims, err := c.loadLocked(ctx)
+	elem, cached := c.entries[id]
+	if cached {
+		c.lru.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := c.deleteArchives(ctx, victims); err != nil || cached {
+		return err
+	}
+
+	entry := &archiveCacheEntry{id: id, size: int64(len(data))}
+	if err := c.backend.Put(ctx, entry.key(), data); err != nil {
+		return fmt.Errorf("failed to cache archive of %s: %w", hash, err)
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[id]; ok {
+		// Put by another request meanwhile
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return nil
+	}
+	c.entries[id] = c.lru.PushFront(entry)
+	c.bytes += entry.size
+	victims = c.evictLocked()
+	c.mu.Unlock()
+
+	return c.deleteArchives(ctx, victims)
+}
+
+// evictLocked removes the least recently used entries from the index until
+// the rest fit, returning them for the caller to delete. Callers must hold
+// c.mu.
+func (c *ArchiveCache) evictLocked() []*archiveCacheEntry {
+	var victims []*archiveCacheEntry
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			break
+		}
+		victims = append(victims, c.removeLocked(oldest))
+	}
+	return victims
+}
+
+// removeLocked removes an entry from the index. Callers must hold c.mu.
+func (c *ArchiveCache) removeLocked(elem *list.Element) *archiveCacheEntry {
+	entry := elem.Value.(*archiveCacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.id)
+	c.bytes -= entry.size
+	return entry
+}
+
+// forget removes an archive that could not be read from the index, and
+// whatever is left of it from the backend
+func (c *ArchiveCache) forget(ctx context.Context, entry *archiveCacheEntry) {
+	c.mu.Lock()
+	elem, ok := c.entries[entry.id]
+	if ok && elem.Value.(*archiveCacheEntry) == entry {
+		c.removeLocked(elem)
+	}
+	c.mu.Unlock()
+
+	if err := c.deleteArchives(ctx, []*archiveCacheEntry{entry}); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// deleteArchives deletes archives removed from the index
+func (c *ArchiveCache) deleteArchives(ctx context.Context, entries []*archiveCacheEntry) error {
+	for _, entry := range entries {
+		if exists, err := c.backend.Exists(ctx, entry.key()); err != nil || !exists {
+			continue
+		}
+		if err := c.backend.Delete(ctx, entry.key()); err != nil {
+			return fmt.Errorf("failed to evict cached archive %s: %w", entry.id, err)
+		}
+	}
+	return nil
+}
+
+// touch marks an archive as just used
+func (c *ArchiveCache) touch(entry *archiveCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.id]; ok {
+		c.lru.MoveToFront(elem)
+	}
+}
+
+// count records a hit or a miss
+func (c *ArchiveCache) count(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// Stats reports the cache's size and hit rate
+func (c *ArchiveCache) Stats() ArchiveCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ArchiveCacheStats{
+		Entries:  c.lru.Len(),
+		Bytes:    c.bytes,
+		MaxBytes: c.maxBytes,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+// archiveWriter counts the bytes written to w and keeps its error, so a
+// failed copy can tell the reader's errors from the writer's
+type archiveWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (a *archiveWriter) Write(p []byte) (int, error) {
+	n, err := a.w.Write(p)
+	a.n += int64(n)
+	a.err = err
+	return n, err
+}
+
+// archiveRecorder keeps a copy of an archive being built, giving up once it
+// grows past max
+type archiveRecorder struct {
+	buf  bytes.Buffer
+	max  int64
+	over bool
+}
+
+func (a *archiveRecorder) Write(p []byte) (int, error) {
+	if a.over {
+		return len(p), nil
+	}
+	if int64(a.buf.Len()+len(p)) > a.max {
+		a.over = true
+		a.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+	return a.buf.Write(p)
+}
@@ -0,0 +1,428 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/nic/poon/poon-server/merge"
+)
+
+// A .poonattributes file in the root of the repository sets attributes on
+// paths, in the syntax of .gitattributes: each line is a pattern followed by
+// attributes, "name" to set one, "-name" to unset it, "name=value" to give it
+// a value and "!name" to leave it unspecified. Later lines override earlier
+// ones. A pattern without a "/" matches the file name at any depth; any
+// other pattern is matched against the whole path from the root, with "**"
+// matching any number of directories.
+//
+// Line endings are controlled like git's: "text" stores a file with LF line
+// endings, "text=auto" does so only if it does not look binary, "-text" (or
+// "binary") leaves it alone, and "eol=lf" or "eol=crlf" sets the line ending
+// of checkouts, which is otherwise the platform's own.
+//
+// The other attributes honored are:
+//   - "diff" and "-diff" mark a file as text or binary, overriding the check
+//     for NUL bytes; text patches to files marked "-diff" are refused
+//   - "merge=union" applies the added lines of hunks that no longer match,
+//     keeping the file's lines, and "-merge" (or "merge=binary") applies
+//     patches exactly, without ignoring whitespace
+//   - "store=raw" stores a file as a raw object, which is streamed rather
+//     than held in memory, whatever its size. It is kept in the same
+//     backend as every other object: poon has no separate large-file
+//     storage, and "filter=lfs" means nothing to it.
+//   - "export-ignore" leaves a file out of archives made with DownloadPath
+
+// AttributesFile holds the repository's path attributes
+const AttributesFile = ".poonattributes"
+
+// Attribute states. Any other value is the value given with "name=value".
+const (
+	AttributeSet   = "set"
+	AttributeUnset = "unset"
+)
+
+// attributeMacros expand to several attributes, as in git
+var attributeMacros = map[string][]string{
+	"binary": {"-diff", "-merge", "-text"},
+}
+
+type attributeRule struct {
+	pattern  []string // Components of an anchored pattern, or one name
+	anchored bool
+	values   map[string]string // "" leaves an attribute unspecified
+}
+
+// AttributeRules are the parsed lines of an attributes file
+type AttributeRules struct {
+	rules []attributeRule
+}
+
+// ParseAttributes parses an attributes file
+func ParseAttributes(data []byte) (*AttributeRules, error) {
+	rules := &AttributeRules{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseAttributeLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", AttributesFile, lineNumber, err)
+		}
+		rules.rules = append(rules.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AttributesFile, err)
+	}
+	return rules, nil
+}
+
+func parseAttributeLine(line string) (attributeRule, error) {
+	fields := strings.Fields(line)
+	pattern := fields[0]
+	if strings.HasSuffix(pattern, "/") {
+		return attributeRule{}, fmt.Errorf("pattern %q names a directory; use %s** for the files below it", pattern, pattern)
+	}
+
+	rule := attributeRule{values: make(map[string]string)}
+	if strings.Contains(pattern, "/") {
+		rule.anchored = true
+		rule.pattern = splitPath(pattern)
+	} else {
+		rule.pattern = []string{pattern}
+	}
+	if len(rule.pattern) == 0 {
+		return attributeRule{}, fmt.Errorf("empty pattern %q", pattern)
+	}
+	for _, part := range rule.pattern {
+		if _, err := path.Match(part, ""); err != nil {
+			return attributeRule{}, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+
+	if len(fields) == 1 {
+		return attributeRule{}, fmt.Errorf("pattern %q sets no attributes", pattern)
+	}
+	for _, field := range fields[1:] {
+		if expansion, ok := attributeMacros[field]; ok {
+			for _, attribute := range expansion {
+				setAttribute(rule.values, attribute)
+			}
+			continue
+		}
+		if err := setAttribute(rule.values, field); err != nil {
+			return attributeRule{}, err
+		}
+	}
+	return rule, nil
+}
+
+func setAttribute(values map[string]string, field string) error {
+	name, value := field, AttributeSet
+	switch {
+	case strings.HasPrefix(field, "-"):
+		name, value = field[1:], AttributeUnset
+	case strings.HasPrefix(field, "!"):
+		name, value = field[1:], ""
+	case strings.Contains(field, "="):
+		name, value, _ = strings.Cut(field, "=")
+		if value == "" {
+			return fmt.Errorf("attribute %q has an empty value", field)
+		}
+	}
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, "=!") {
+		return fmt.Errorf("invalid attribute %q", field)
+	}
+	values[name] = value
+	return nil
+}
+
+// matches reports whether the rule applies to a slash-separated path
+func (rule attributeRule) matches(filePath string) bool {
+	parts := splitPath(filePath)
+	if len(parts) == 0 {
+		return false
+	}
+	if !rule.anchored {
+		matched, _ := path.Match(rule.pattern[0], parts[len(parts)-1])
+		return matched
+	}
+	return matchComponents(rule.pattern, parts)
+}
+
+// matchComponents matches path components against pattern components, where
+// "**" matches any number of components, including none
+func matchComponents(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchComponents(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+		return false
+	}
+	return matchComponents(pattern[1:], parts[1:])
+}
+
+// Lookup returns the attributes of a file
+func (a *AttributeRules) Lookup(filePath string) Attributes {
+	attributes := Attributes{}
+	if a == nil {
+		return attributes
+	}
+	for _, rule := range a.rules {
+		if !rule.matches(filePath) {
+			continue
+		}
+		for name, value := range rule.values {
+			if value == "" {
+				delete(attributes, name)
+				continue
+			}
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
+
+// Attributes are the attributes of one file, by name; unspecified ones are
+// absent
+type Attributes map[string]string
+
+// Text reports whether content is stored with LF line endings: files with
+// "text" or an "eol" always are, and "text=auto" files are if they do not
+// look binary
+func (a Attributes) Text(content []byte) bool {
+	switch a["text"] {
+	case AttributeSet:
+		return true
+	case AttributeUnset:
+		return false
+	case "auto":
+		return !LooksBinary(content)
+	}
+	_, hasEOL := a["eol"]
+	return hasEOL
+}
+
+// CheckoutEOL returns the line ending a text file is checked out with: "lf",
+// "crlf", or "" for the platform's own
+func (a Attributes) CheckoutEOL() string {
+	switch eol := a["eol"]; eol {
+	case "lf", "crlf":
+		return eol
+	}
+	return ""
+}
+
+// Binary reports whether a file is binary: "-diff" (or "binary") says it is
+// and "diff" says it is not; otherwise its content decides
+func (a Attributes) Binary(content []byte) bool {
+	switch a["diff"] {
+	case AttributeUnset:
+		return true
+	case AttributeSet:
+		return false
+	}
+	return LooksBinary(content)
+}
+
+// Merge strategies, from the "merge" attribute
+const (
+	MergeText   = "text"   // Hunks must match the file
+	MergeUnion  = "union"  // Hunks that do not match still add their lines
+	MergeBinary = "binary" // Hunks must match exactly, whitespace included
+)
+
+// MergeStrategy returns how patches are applied to the file
+func (a Attributes) MergeStrategy() string {
+	switch a["merge"] {
+	case AttributeUnset, MergeBinary:
+		return MergeBinary
+	case MergeUnion:
+		return MergeUnion
+	}
+	return MergeText
+}
+
+// StoreRaw reports whether the file is stored as a raw object whatever its
+// size ("store=raw")
+func (a Attributes) StoreRaw() bool {
+	return a["store"] == "raw"
+}
+
+// ExportIgnore reports whether archives leave the file out
+func (a Attributes) ExportIgnore() bool {
+	return a["export-ignore"] == AttributeSet
+}
+
+// Uses reports whether any rule mentions the attribute
+func (a *AttributeRules) Uses(name string) bool {
+	if a == nil {
+		return false
+	}
+	for _, rule := range a.rules {
+		if _, ok := rule.values[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LooksBinary reports whether content looks like a binary file rather than
+// text: like git, whether its first 8000 bytes contain a NUL
+func LooksBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// NormalizeEOL returns content with CRLF line endings replaced by LF
+func NormalizeEOL(content []byte) []byte {
+	if !bytes.Contains(content, []byte("\r\n")) {
+		return content
+	}
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// ErrInvalidAttributes is returned when a change would leave an attributes
+// file that does not parse
+var ErrInvalidAttributes = errors.New("invalid attributes file")
+
+// ErrBinaryFile is returned when a text patch targets a file marked binary
+var ErrBinaryFile = errors.New("text patch for a binary file")
+
+// attributesInTree reads the attributes file of a root tree. A tree without
+// one has no attributes.
+func (r *RepositoryImpl) attributesInTree(ctx context.Context, rootTreeHash Hash) (*AttributeRules, error) {
+	content, err := r.readFileFromTree(ctx, rootTreeHash, AttributesFile)
+	if errors.Is(err, ErrNotFound) {
+		return &AttributeRules{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AttributesFile, err)
+	}
+	rules, err := ParseAttributes(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAttributes, err)
+	}
+	return rules, nil
+}
+
+// patchAttributes returns the attributes a patch to targetPath is applied
+// with. The attributes file itself has none, so a broken one can be fixed.
+func (r *RepositoryImpl) patchAttributes(ctx context.Context, rootTreeHash Hash, targetPath string) (Attributes, error) {
+	if isAttributesFile(targetPath) {
+		return nil, nil
+	}
+	rules, err := r.attributesInTree(ctx, rootTreeHash)
+	if err != nil {
+		return nil, err
+	}
+	return rules.Lookup(targetPath), nil
+}
+
+func isAttributesFile(targetPath string) bool {
+	return strings.Join(splitPath(targetPath), "/") == AttributesFile
+}
+
+// patchOptions adjusts the options a text patch is applied with to the
+// file's attributes. Text files are stored with LF line endings, so patches
+// made against a CRLF checkout of them still apply.
+func (a Attributes) patchOptions(targetPath string, original []byte, exists bool, patch *merge.ParsedPatch, opts merge.ApplyOptions) (merge.ApplyOptions, error) {
+	if patch.Binary != nil {
+		return opts, nil
+	}
+	if exists && a["diff"] == AttributeUnset {
+		// Only files marked binary: a NUL byte alone does not stop a text
+		// patch that matches the file
+		return opts, fmt.Errorf("%w: %s (send a git binary patch)", ErrBinaryFile, targetPath)
+	}
+	if a.Text(original) {
+		opts.NormalizeLineEndings = true
+	}
+	switch a.MergeStrategy() {
+	case MergeUnion:
+		opts.Union = true
+	case MergeBinary:
+		opts.IgnoreWhitespace = false
+	}
+	return opts, nil
+}
+
+// finishPatch normalizes the line endings of patched content and checks that
+// a patched attributes file still parses
+func (a Attributes) finishPatch(targetPath string, patch *merge.ParsedPatch, content []byte) ([]byte, error) {
+	if a.Text(content) {
+		content = NormalizeEOL(content)
+	}
+	if isAttributesFile(targetPath) && !patch.Deletes() {
+		if _, err := ParseAttributes(content); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAttributes, err)
+		}
+	}
+	return content, nil
+}
+
+// Attributes returns the attribute rules at version
+func (r *RepositoryImpl) Attributes(ctx context.Context, version int64) (*AttributeRules, error) {
+	rootTree, err := r.rootTreeHash(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	return r.attributesInTree(ctx, rootTree)
+}
+
+// AttributesInTree returns the attribute rules of a root tree, such as a
+// branch commit's
+func (r *RepositoryImpl) AttributesInTree(ctx context.Context, rootTree Hash) (*AttributeRules, error) {
+	return r.attributesInTree(ctx, rootTree)
+}
+
+// GitAttributes returns the rules as .gitattributes lines, for workspace
+// repositories, so git stores and checks out files as the monorepo does.
+// Only the attributes git understands the same way are written.
+func (a *AttributeRules) GitAttributes(names ...string) string {
+	var b strings.Builder
+	for _, rule := range a.rules {
+		var fields []string
+		for _, name := range names {
+			value, ok := rule.values[name]
+			if !ok {
+				continue
+			}
+			switch value {
+			case AttributeSet:
+				fields = append(fields, name)
+			case AttributeUnset:
+				fields = append(fields, "-"+name)
+			case "":
+				fields = append(fields, "!"+name)
+			default:
+				fields = append(fields, name+"="+value)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		pattern := rule.pattern[0]
+		if rule.anchored {
+			pattern = "/" + strings.Join(rule.pattern, "/")
+		}
+		fmt.Fprintf(&b, "%s %s\n", pattern, strings.Join(fields, " "))
+	}
+	return b.String()
+}
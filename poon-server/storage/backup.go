@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotPrefix    = "snapshots/"
+	latestSnapshotKey = "snapshots/LATEST"
+)
+
+// BackupManifest describes one snapshot. Objects are stored next to it under
+// objects/<hash> and shared between snapshots, so only the manifest and
+// objects the target does not already hold are written by each backup.
+type BackupManifest struct {
+	ID             string            `json:"id"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	CurrentVersion int64             `json:"currentVersion"`
+	Objects        []Hash            `json:"objects"`
+	Metadata       map[string][]byte `json:"metadata"`             // Non-object keys: version index, locks
+	Workspaces     json.RawMessage   `json:"workspaces,omitempty"` // Server workspace state, opaque to storage
+}
+
+// BackupResult summarizes a backup run
+type BackupResult struct {
+	Snapshot       string `json:"snapshot"`
+	Objects        int    `json:"objects"`
+	ObjectsWritten int    `json:"objectsWritten"` // Objects the target did not have yet
+	BytesWritten   int64  `json:"bytesWritten"`
+}
+
+// OpenBackend opens a backend from a location: s3://bucket/prefix for S3,
+// otherwise a local directory (optionally prefixed with file://)
+func OpenBackend(location string) (StorageBackend, error) {
+	if rest, ok := strings.CutPrefix(location, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		return NewS3Backend(&S3Config{Bucket: bucket, Prefix: prefix})
+	}
+	return NewFilesystemBackend(strings.TrimPrefix(location, "file://"))
+}
+
+func snapshotKey(id string) string {
+	return snapshotPrefix + id + ".json"
+}
+
+// Backup writes a consistent snapshot of the repository to target. Writes
+// are blocked while the snapshot is taken. workspaces is stored verbatim in
+// the manifest so the server can restore its workspace metadata.
+func (r *RepositoryImpl) Backup(ctx context.Context, target StorageBackend, workspaces []byte) (*BackupResult, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	backend := r.ContentStore.backend
+	keys, err := backend.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	now := time.Now().UTC()
+	manifest := &BackupManifest{
+		ID:         now.Format("20060102T150405.000000000Z"),
+		CreatedAt:  now,
+		Metadata:   make(map[string][]byte),
+		Workspaces: workspaces,
+	}
+	result := &BackupResult{Snapshot: manifest.ID}
+
+	for _, key := range keys {
+		// Quarantine records describe damage to this backend, not to the
+		// repository a restore recreates
+		if isCacheKey(key) || strings.HasPrefix(key, quarantinePrefix) {
+			continue
+		}
+
+		if !strings.HasPrefix(key, "objects/") {
+			data, err := backend.Get(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", key, err)
+			}
+			manifest.Metadata[key] = data
+			continue
+		}
+
+		manifest.Objects = append(manifest.Objects, Hash(strings.TrimPrefix(key, "objects/")))
+		result.Objects++
+
+		// Objects are immutable, so one already in the target never changes
+		exists, err := target.Exists(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s in backup: %w", key, err)
+		}
+		if exists {
+			continue
+		}
+		size, err := copyKey(ctx, backend, target, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", key, err)
+		}
+		result.ObjectsWritten++
+		result.BytesWritten += size
+	}
+
+	if manifest.CurrentVersion, err = r.GetCurrentVersion(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := target.Put(ctx, snapshotKey(manifest.ID), data); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	result.BytesWritten += int64(len(data))
+
+	// The manifest is written last so a partial backup is never the latest
+	if err := target.Put(ctx, latestSnapshotKey, []byte(manifest.ID)); err != nil {
+		return nil, fmt.Errorf("failed to update latest snapshot: %w", err)
+	}
+
+	return result, nil
+}
+
+// LoadBackupManifest reads a snapshot manifest from source. An empty id
+// selects the latest snapshot.
+func LoadBackupManifest(ctx context.Context, source StorageBackend, id string) (*BackupManifest, error) {
+	if id == "" {
+		latest, err := source.Get(ctx, latestSnapshotKey)
+		if err != nil {
+			return nil, fmt.Errorf("no snapshots found: %w", err)
+		}
+		id = strings.TrimSpace(string(latest))
+	}
+
+	data, err := source.Get(ctx, snapshotKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s not found: %w", id, err)
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", id, err)
+	}
+	return &manifest, nil
+}
+
+// Restore replaces the repository contents with a snapshot from source.
+// Every object is verified against its hash before anything is changed,
+// so a damaged backup leaves the repository untouched.
+func (r *RepositoryImpl) Restore(ctx context.Context, source StorageBackend, id string) (*BackupManifest, error) {
+	manifest, err := LoadBackupManifest(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	defer r.Invalidate()
+	defer r.graph.forget()
+
+	snapshot := NewContentStore(source)
+	for _, hash := range manifest.Objects {
+		if _, err := snapshot.verify(ctx, hash); err != nil {
+			return nil, fmt.Errorf("snapshot %s is damaged: object %s: %w", manifest.ID, hash, err)
+		}
+	}
+
+	backend := r.ContentStore.backend
+	wanted := make(map[string]bool, len(manifest.Objects)+len(manifest.Metadata))
+	for _, hash := range manifest.Objects {
+		key := "objects/" + string(hash)
+		wanted[key] = true
+
+		if exists, err := backend.Exists(ctx, key); err == nil && exists {
+			continue
+		}
+		if _, err := copyKey(ctx, source, backend, key); err != nil {
+			return nil, fmt.Errorf("failed to restore %s from snapshot: %w", key, err)
+		}
+		r.ContentStore.written(hash)
+	}
+
+	for key, data := range manifest.Metadata {
+		wanted[key] = true
+		if err := backend.Put(ctx, key, data); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", key, err)
+		}
+	}
+
+	// Drop anything the snapshot does not contain, including objects
+	// written after it was taken. Cached archives are kept: they depend only
+	// on a tree hash, which means the same tree in any snapshot.
+	keys, err := backend.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	for _, key := range keys {
+		if !wanted[key] && !isCacheKey(key) {
+			if err := backend.Delete(ctx, key); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", key, err)
+			}
+		}
+	}
+
+	// The snapshot may have been taken before a rehash, or after one
+	if err := r.useRecordedHashAlgorithm(ctx); err != nil {
+		return nil, err
+	}
+	r.resetRootTree()
+
+	return manifest, nil
+}
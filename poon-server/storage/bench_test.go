@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// benchFileCount is the size of the synthetic tree used by
+// BenchmarkCreateCommitFromFileSystem. POON_BENCH_FILES overrides it for
+// quicker local runs.
+func benchFileCount(b *testing.B) int {
+	if value := os.Getenv("POON_BENCH_FILES"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count <= 0 {
+			b.Fatalf("invalid POON_BENCH_FILES: %q", value)
+		}
+		return count
+	}
+	return 100000
+}
+
+// writeSyntheticTree creates small source files in directories of 100,
+// grouped two levels deep (d<n>/d<m>/f<i>.go)
+func writeSyntheticTree(b *testing.B, root string, files int) {
+	for i := 0; i < files; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("d%d", i/10000), fmt.Sprintf("d%d", i/100%100))
+		if i%100 == 0 {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				b.Fatal(err)
+			}
+		}
+		content := fmt.Sprintf("package p%d\n\n// file %d\n", i/100, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)), []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStoreBlob(b *testing.B) {
+	for _, size := range []int{1 << 10, 1 << 20} {
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			store := NewContentStore(NewMemoryBackend())
+			ctx := context.Background()
+			content := make([]byte, size)
+
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Vary the content so each iteration stores a new object
+				content[0], content[1] = byte(i), byte(i>>8)
+				if _, err := store.StoreBlob(ctx, content); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkObjectHash(b *testing.B) {
+	for _, algorithm := range []HashAlgorithm{HashSHA256, HashBLAKE3} {
+		hasher := NewHasherWith(algorithm)
+		content := make([]byte, 1<<20)
+		b.Run(string(algorithm), func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			for i := 0; i < b.N; i++ {
+				hasher.ComputeBlobHash(content)
+			}
+		})
+	}
+}
+
+func BenchmarkGetBlob(b *testing.B) {
+	for _, size := range []int{1 << 10, 1 << 20} {
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			store := NewContentStore(NewMemoryBackend())
+			ctx := context.Background()
+			hash, err := store.StoreBlob(ctx, make([]byte, size))
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.GetBlob(ctx, hash); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDeepTreeTraversal(b *testing.B) {
+	const depth = 32
+
+	root := b.TempDir()
+	parts := make([]string, depth)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("level%d", i)
+	}
+	dir := filepath.Join(append([]string{root}, parts...)...)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "leaf.txt"), []byte("leaf\n"), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	repo := NewRepository(NewMemoryBackend())
+	ctx := context.Background()
+	info, err := repo.CreateCommitFromFileSystem(ctx, root, "bench@example.com", "Deep tree")
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := strings.Join(append(parts, "leaf.txt"), "/")
+
+	b.Run("ReadFile", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ReadFile(ctx, info.Version, path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ReadDirectory", func(b *testing.B) {
+		dirPath := strings.Join(parts, "/")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ReadDirectory(ctx, info.Version, dirPath); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkApplyPatchLargeFile(b *testing.B) {
+	const lines = 50000
+
+	root := b.TempDir()
+	var content strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(root, "large.txt"), []byte(content.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	repo := NewRepository(NewMemoryBackend())
+	ctx := context.Background()
+	if _, err := repo.CreateCommitFromFileSystem(ctx, root, "bench@example.com", "Large file"); err != nil {
+		b.Fatal(err)
+	}
+
+	// Alternate between two patches so every iteration applies cleanly
+	middle := lines / 2
+	forward := []byte(fmt.Sprintf("--- a/large.txt\n+++ b/large.txt\n@@ -%d,1 +%d,1 @@\n-line %d\n+changed %d\n", middle, middle, middle, middle))
+	backward := []byte(fmt.Sprintf("--- a/large.txt\n+++ b/large.txt\n@@ -%d,1 +%d,1 @@\n-changed %d\n+line %d\n", middle, middle, middle, middle))
+
+	b.SetBytes(int64(content.Len()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		patch := forward
+		if i%2 == 1 {
+			patch = backward
+		}
+		if _, err := repo.ApplyPatch(ctx, patch, "bench@example.com", "Toggle line"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateCommitFromFileSystem(b *testing.B) {
+	files := benchFileCount(b)
+	root := b.TempDir()
+	writeSyntheticTree(b, root, files)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		repo := NewRepository(NewMemoryBackend())
+		if _, err := repo.CreateCommitFromFileSystem(ctx, root, "bench@example.com", "Synthetic tree"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(files), "files/op")
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"strings"
+)
+
+// A changed-path filter is a Bloom filter of the paths a commit changed
+// relative to its parent, and of every directory above them, so history
+// walks can skip the commits that certainly left a path alone without
+// reading their trees. Like git's commit-graph filters, it has about ten
+// bits and seven hash functions per path, for roughly one false positive in
+// a hundred lookups, and commits changing more than changedPathsMaxPaths
+// paths get none.
+//
+// The first byte is the filter's version, so the hashing can change without
+// misreading the filters of older commits; the rest are the bits.
+const (
+	changedPathsVersion     = 1
+	changedPathsBitsPerPath = 10
+	changedPathsHashes      = 7
+	changedPathsMinBytes    = 8
+	changedPathsMaxPaths    = 512
+)
+
+// newChangedPathFilter builds a filter of paths and their directories, or
+// returns nil when there are too many to be worth filtering
+func newChangedPathFilter(paths []string) []byte {
+	set := make(map[string]bool)
+	for _, path := range paths {
+		path = strings.Trim(path, "/")
+		for path != "" && !set[path] {
+			set[path] = true
+			i := strings.LastIndex(path, "/")
+			if i < 0 {
+				break
+			}
+			path = path[:i]
+		}
+	}
+	if len(set) > changedPathsMaxPaths {
+		return nil
+	}
+
+	size := (len(set)*changedPathsBitsPerPath + 7) / 8
+	if size < changedPathsMinBytes {
+		size = changedPathsMinBytes
+	}
+	filter := make([]byte, 1+size)
+	filter[0] = changedPathsVersion
+	for path := range set {
+		for _, bit := range changedPathBits(path, size*8) {
+			filter[1+bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return filter
+}
+
+// changedPathBits returns the bits of a filter of bits bits that path sets,
+// by double hashing one 64-bit FNV-1a hash
+func changedPathBits(path string, bits int) []int {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)|1
+
+	result := make([]int, changedPathsHashes)
+	for i := range result {
+		result[i] = int((h1 + uint32(i)*h2) % uint32(bits))
+	}
+	return result
+}
+
+// mayHaveChanged reports whether a commit with filter may have changed path
+// or something below it. It is only ever wrong by saying yes: without a
+// filter, or with one of a version this code does not know, the answer is
+// always yes, as it is for the root.
+func mayHaveChanged(filter []byte, path string) bool {
+	path = strings.Join(splitPath(path), "/")
+	if len(filter) < 1+changedPathsMinBytes || filter[0] != changedPathsVersion || path == "" {
+		return true
+	}
+	bits := filter[1:]
+	for _, bit := range changedPathBits(path, len(bits)*8) {
+		if bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// changedPaths returns the filter of the paths that differ between a
+// commit's parent root tree, empty for a root commit, and its own. A commit
+// without a filter is only slower to walk past, so one that cannot be built
+// is left out rather than failing the commit.
+func (r *RepositoryImpl) changedPaths(ctx context.Context, parentRoot, root Hash) []byte {
+	var changes []FileChange
+	if err := r.diffTrees(ctx, parentRoot, root, "", &changes); err != nil {
+		log.Printf("Warning: failed to build changed-path filter: %v", err)
+		return nil
+	}
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	return newChangedPathFilter(paths)
+}
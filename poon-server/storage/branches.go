@@ -0,0 +1,689 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nic/poon/poon-server/merge"
+)
+
+// MainBranch is the branch whose commits are the repository's versions. It
+// has no stored ref: its head is the current version's commit.
+const MainBranch = "main"
+
+// ErrBranchExists is returned when creating a branch whose name is taken
+var ErrBranchExists = errors.New("branch already exists")
+
+// ErrBranchNotFound is returned for a branch that does not exist
+var ErrBranchNotFound = errors.New("branch not found")
+
+// Branch is a named line of commits besides main. Its commits are not
+// versions; they reach main only through MergeBranches.
+type Branch struct {
+	Name       string    `json:"name"`
+	CommitHash Hash      `json:"commitHash"` // Newest commit on the branch
+	CreatedAt  time.Time `json:"createdAt"`
+	CreatedBy  string    `json:"createdBy"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// ValidateBranchName checks a branch name the way ValidateTagName checks a
+// tag name
+func ValidateBranchName(name string) error {
+	return validateRefName("branch", name)
+}
+
+// branchKey escapes the slashes in name so that branches "a" and "a/b"
+// never collide in file-based backends
+func branchKey(name string) string {
+	return "branch/" + url.PathEscape(name)
+}
+
+// storeBranch writes a branch's ref
+func (r *RepositoryImpl) storeBranch(ctx context.Context, branch *Branch) error {
+	data, err := json.Marshal(branch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal branch: %w", err)
+	}
+	if err := r.ContentStore.backend.Put(ctx, branchKey(branch.Name), data); err != nil {
+		return fmt.Errorf("failed to store branch %s: %w", branch.Name, err)
+	}
+	return nil
+}
+
+// GetBranch returns a branch other than main, or an error wrapping
+// ErrBranchNotFound
+func (r *RepositoryImpl) GetBranch(ctx context.Context, name string) (*Branch, error) {
+	data, err := r.ContentStore.backend.Get(ctx, branchKey(name))
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrBranchNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch %s: %w", name, err)
+	}
+
+	var branch Branch
+	if err := json.Unmarshal(data, &branch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal branch %s: %w", name, err)
+	}
+	return &branch, nil
+}
+
+// ListBranches returns the branches other than main, sorted by name
+func (r *RepositoryImpl) ListBranches(ctx context.Context) ([]*Branch, error) {
+	keys, err := r.ContentStore.backend.List(ctx, "branch/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	var branches []*Branch
+	for _, key := range keys {
+		name, err := url.PathUnescape(strings.TrimPrefix(key, "branch/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid branch key %s: %w", key, err)
+		}
+		branch, err := r.GetBranch(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+	}
+
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches, nil
+}
+
+// CreateBranch starts a branch at the commit from, which may be any
+// version's commit or another branch's
+func (r *RepositoryImpl) CreateBranch(ctx context.Context, name string, from Hash, createdBy string) (*Branch, error) {
+	if err := ValidateBranchName(name); err != nil {
+		return nil, err
+	}
+	if name == MainBranch {
+		return nil, fmt.Errorf("%w: %s", ErrBranchExists, name)
+	}
+
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	if _, err := r.GetCommit(ctx, from); err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", from, err)
+	}
+	if _, err := r.GetBranch(ctx, name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBranchExists, name)
+	} else if !errors.Is(err, ErrBranchNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	branch := &Branch{
+		Name:       name,
+		CommitHash: from,
+		CreatedAt:  now,
+		CreatedBy:  createdBy,
+		UpdatedAt:  now,
+	}
+	if err := r.storeBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+// DeleteBranch removes a branch. Its commits stay while a merge into
+// another branch or main refers to them, and are otherwise left to garbage
+// collection.
+func (r *RepositoryImpl) DeleteBranch(ctx context.Context, name string) error {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	if _, err := r.GetBranch(ctx, name); err != nil {
+		return err
+	}
+	if err := r.ContentStore.backend.Delete(ctx, branchKey(name)); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// BranchHead returns the newest commit of a branch; for main, the current
+// version's
+func (r *RepositoryImpl) BranchHead(ctx context.Context, name string) (Hash, error) {
+	if name == MainBranch {
+		currentVersion, err := r.GetCurrentVersion(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get current version: %w", err)
+		}
+		if currentVersion == 0 {
+			return "", fmt.Errorf("%w: %s has no versions yet", ErrBranchNotFound, name)
+		}
+		info, err := r.GetVersionInfo(ctx, currentVersion)
+		if err != nil {
+			return "", fmt.Errorf("failed to get current version info: %w", err)
+		}
+		return info.CommitHash, nil
+	}
+
+	branch, err := r.GetBranch(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return branch.CommitHash, nil
+}
+
+// ApplyPatchToBranch applies a patch on top of a branch other than main as
+// a new commit. Unlike a patch to main it creates no version, and a file it
+// deletes is not put in the trash: the branch's earlier commits still hold
+// it.
+func (r *RepositoryImpl) ApplyPatchToBranch(ctx context.Context, name string, patchData []byte, author, message string, opts merge.ApplyOptions) (*Branch, error) {
+	if name == MainBranch {
+		return nil, fmt.Errorf("patches to %s create versions; use ApplyPatchWithOptions", MainBranch)
+	}
+
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	parsed, err := merge.ParsePatch(patchData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+
+	branch, err := r.GetBranch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.GetCommit(ctx, branch.CommitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch head: %w", err)
+	}
+
+	root, err := r.applyPatchToTree(ctx, head.RootTree, parsed, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	commitHash, err := r.StoreCommit(ctx, &CommitObject{
+		RootTree:     root,
+		Parent:       &branch.CommitHash,
+		Author:       author,
+		Message:      message,
+		Timestamp:    time.Now(),
+		ChangedPaths: r.changedPaths(ctx, head.RootTree, root),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store commit: %w", err)
+	}
+
+	branch.CommitHash = commitHash
+	branch.UpdatedAt = time.Now()
+	if err := r.storeBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+// ErrAlreadyMerged is returned when amending a commit main already has
+var ErrAlreadyMerged = errors.New("commit is already merged into main")
+
+// AmendBranch replaces the newest commit of a branch other than main with
+// one that also has the changes of patch, which may be empty, and message,
+// if it is not empty. The replacement keeps the commit's parents and its
+// author unless author is given. Only a commit made on the branch and not
+// yet merged into main can be amended, so no version's history changes;
+// otherwise it fails with ErrAlreadyMerged.
+func (r *RepositoryImpl) AmendBranch(ctx context.Context, name string, patchData []byte, author, message string, opts merge.ApplyOptions) (*Branch, error) {
+	if name == MainBranch {
+		return nil, fmt.Errorf("versions on %s cannot be amended", MainBranch)
+	}
+
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	branch, err := r.GetBranch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.GetCommit(ctx, branch.CommitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch head: %w", err)
+	}
+	if head.Version > 0 {
+		return nil, fmt.Errorf("%w: %s is at version %d", ErrAlreadyMerged, name, head.Version)
+	}
+	if mainHead, err := r.BranchHead(ctx, MainBranch); err == nil {
+		base, err := r.commitMergeBase(ctx, mainHead, branch.CommitHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", MainBranch, err)
+		}
+		if base == branch.CommitHash {
+			return nil, fmt.Errorf("%w: %s", ErrAlreadyMerged, branch.CommitHash)
+		}
+	}
+
+	amended := *head
+	amended.Timestamp = time.Now()
+	if author != "" {
+		amended.Author = author
+	}
+	if message != "" {
+		amended.Message = message
+	}
+	if len(patchData) > 0 {
+		parsed, err := merge.ParsePatch(patchData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse patch: %w", err)
+		}
+		if amended.RootTree, err = r.applyPatchToTree(ctx, head.RootTree, parsed, opts); err != nil {
+			return nil, fmt.Errorf("failed to apply patch: %w", err)
+		}
+
+		var parentRoot Hash
+		if head.Parent != nil {
+			parent, err := r.GetCommit(ctx, *head.Parent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parent commit: %w", err)
+			}
+			parentRoot = parent.RootTree
+		}
+		amended.ChangedPaths = r.changedPaths(ctx, parentRoot, amended.RootTree)
+	}
+
+	hash, err := r.StoreCommit(ctx, &amended)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store commit: %w", err)
+	}
+	branch.CommitHash = hash
+	branch.UpdatedAt = time.Now()
+	if err := r.storeBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+// MergeResult describes a merge of one branch into another
+type MergeResult struct {
+	Commit   Hash         `json:"commit"`            // The merge commit, or the target's head when up to date
+	Base     Hash         `json:"base,omitempty"`    // Newest common ancestor; empty when the histories never meet
+	Version  *VersionInfo `json:"version,omitempty"` // The version created, for a merge into main
+	UpToDate bool         `json:"upToDate"`          // The target already had every commit of the source
+}
+
+// MergeConflictError lists the paths both sides of a merge or cherry-pick
+// changed differently
+type MergeConflictError struct {
+	Source, Target string // Branches merged, or the commit picked and its target
+	Paths          []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s in %s", e.Source, e.Target, strings.Join(e.Paths, ", "))
+}
+
+// MergePreview is what merging one branch into another, or cherry-picking
+// a commit onto one, would change
+type MergePreview struct {
+	Base     Hash         // Newest common ancestor, or the picked commit's parent; empty when there is none
+	UpToDate bool         // The target already has every commit of the source
+	Changes  []FileChange // Files the merge changes in the target, sorted by path
+}
+
+// PreviewMerge merges source into target without committing, so the changes
+// can be checked first. A conflicting merge fails with a
+// *MergeConflictError, as MergeBranches would.
+func (r *RepositoryImpl) PreviewMerge(ctx context.Context, source, target string) (*MergePreview, error) {
+	prepared, err := r.prepareMerge(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.previewPrepared(ctx, prepared)
+}
+
+// previewPrepared lists what a prepared merge changes in its target
+func (r *RepositoryImpl) previewPrepared(ctx context.Context, prepared *preparedMerge) (*MergePreview, error) {
+	preview := &MergePreview{Base: prepared.base, UpToDate: prepared.upToDate()}
+	if err := r.diffTrees(ctx, prepared.targetRoot, prepared.root, "", &preview.Changes); err != nil {
+		return nil, fmt.Errorf("failed to diff merge: %w", err)
+	}
+	sort.Slice(preview.Changes, func(i, j int) bool { return preview.Changes[i].Path < preview.Changes[j].Path })
+	return preview, nil
+}
+
+// MergeOptions changes how MergeBranches records a merge
+type MergeOptions struct {
+	// Squash records the merge as one ordinary commit on the target, with
+	// no merge parent, so the branch's separate updates stay out of the
+	// target's history. The branch is left as it was; merging it again
+	// later sees its changes as the target's and its base as before.
+	Squash bool
+}
+
+// MergeBranches merges source into target with a merge commit whose first
+// parent is target's head and whose merge parent is source's. A merge into
+// main creates a version; one into another branch moves that branch.
+//
+// The merge is by tree, like a patch: a path either side changed since the
+// merge base takes that side's content, and a path both changed differently
+// fails the merge with a *MergeConflictError, leaving both branches as they
+// were. A merge commit is recorded even when the target has not moved since
+// the branch was made, so the merge shows in the target's history; with
+// opts.Squash it is an ordinary commit instead, whose message defaults to
+// the subjects of the branch's commits.
+func (r *RepositoryImpl) MergeBranches(ctx context.Context, source, target, author, message string, opts MergeOptions) (*MergeResult, error) {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	prepared, err := r.prepareMerge(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+	result := &MergeResult{Base: prepared.base}
+	if prepared.upToDate() {
+		result.Commit, result.UpToDate = prepared.targetHead, true
+		return result, nil
+	}
+
+	commit := &CommitObject{
+		RootTree:     prepared.root,
+		Parent:       &prepared.targetHead,
+		MergeParents: []Hash{prepared.sourceHead},
+		Author:       author,
+		Message:      message,
+		Timestamp:    time.Now(),
+		ChangedPaths: r.changedPaths(ctx, prepared.targetRoot, prepared.root),
+	}
+	if opts.Squash {
+		commit.MergeParents = nil
+		if commit.Message == "" {
+			if commit.Message, err = r.squashMessage(ctx, source, target, prepared); err != nil {
+				return nil, err
+			}
+		}
+	} else if commit.Message == "" {
+		commit.Message = fmt.Sprintf("Merge branch '%s' into %s", source, target)
+	}
+
+	result.Commit, result.Version, err = r.commitToBranch(ctx, target, commit)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// squashMessage lists the messages of the commits a squash merge folds
+// together, oldest first, under a line naming the merge. They are the
+// source's first-parent commits back to the merge base.
+func (r *RepositoryImpl) squashMessage(ctx context.Context, source, target string, prepared *preparedMerge) (string, error) {
+	var messages []string
+	for hash := prepared.sourceHead; hash != "" && hash != prepared.base; {
+		commit, err := r.GetCommit(ctx, hash)
+		if err != nil {
+			return "", fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		messages = append(messages, "* "+subject)
+		hash = ""
+		if commit.Parent != nil {
+			hash = *commit.Parent
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Squash branch '%s' into %s\n", source, target)
+	if len(messages) > 0 {
+		b.WriteString("\n")
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		b.WriteString(messages[i] + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// commitToBranch stores commit as the new head of a branch: as the next
+// version for main, or by moving the branch's ref. Files a commit to main
+// deletes go to the trash first, as a patch's would. Callers hold commitMu.
+func (r *RepositoryImpl) commitToBranch(ctx context.Context, name string, commit *CommitObject) (Hash, *VersionInfo, error) {
+	if name == MainBranch {
+		currentVersion, err := r.GetCurrentVersion(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get current version: %w", err)
+		}
+		commit.Version = currentVersion + 1
+
+		if commit.Parent != nil {
+			parent, err := r.GetCommit(ctx, *commit.Parent)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to get commit %s: %w", *commit.Parent, err)
+			}
+			if err := r.recordDeletions(ctx, parent.RootTree, commit.RootTree, commit.Version, commit.Author); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	hash, err := r.StoreCommit(ctx, commit)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store commit: %w", err)
+	}
+
+	if name == MainBranch {
+		info, err := r.CreateVersion(ctx, hash, commit.Message)
+		if err != nil {
+			return "", nil, err
+		}
+		return hash, info, nil
+	}
+
+	branch, err := r.GetBranch(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+	branch.CommitHash = hash
+	branch.UpdatedAt = time.Now()
+	if err := r.storeBranch(ctx, branch); err != nil {
+		return "", nil, err
+	}
+	return hash, nil, nil
+}
+
+// preparedMerge is a merge worked out but not committed
+type preparedMerge struct {
+	sourceHead, targetHead Hash
+	base                   Hash
+	targetRoot, root       Hash // The target's tree, and the merged one
+}
+
+func (m *preparedMerge) upToDate() bool {
+	return m.base == m.sourceHead
+}
+
+// prepareMerge finds the merge base of two branches and merges their trees
+func (r *RepositoryImpl) prepareMerge(ctx context.Context, source, target string) (*preparedMerge, error) {
+	if source == target {
+		return nil, fmt.Errorf("cannot merge %s into itself", source)
+	}
+
+	m := &preparedMerge{}
+	var err error
+	if m.sourceHead, err = r.BranchHead(ctx, source); err != nil {
+		return nil, err
+	}
+	if m.targetHead, err = r.BranchHead(ctx, target); err != nil {
+		return nil, err
+	}
+	if m.base, err = r.commitMergeBase(ctx, m.targetHead, m.sourceHead); err != nil {
+		return nil, fmt.Errorf("failed to find merge base: %w", err)
+	}
+
+	targetCommit, err := r.GetCommit(ctx, m.targetHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s head: %w", target, err)
+	}
+	m.targetRoot, m.root = targetCommit.RootTree, targetCommit.RootTree
+	if m.upToDate() {
+		return m, nil
+	}
+
+	var baseRoot Hash
+	if m.base != "" {
+		baseCommit, err := r.GetCommit(ctx, m.base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge base: %w", err)
+		}
+		baseRoot = baseCommit.RootTree
+	}
+	sourceCommit, err := r.GetCommit(ctx, m.sourceHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s head: %w", source, err)
+	}
+
+	var conflicts []string
+	m.root, err = r.mergeTrees(ctx, baseRoot, m.targetRoot, sourceCommit.RootTree, "", &conflicts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge trees: %w", err)
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, &MergeConflictError{Source: source, Target: target, Paths: conflicts}
+	}
+	return m, nil
+}
+
+// commitMergeBase returns the newest common ancestor of two commits, or ""
+// when their histories never meet. Branch commits are not in the commit
+// graph, so unlike MergeBase this reads commits, newest first by timestamp,
+// following every parent.
+func (r *RepositoryImpl) commitMergeBase(ctx context.Context, a, b Hash) (Hash, error) {
+	type pendingCommit struct {
+		hash   Hash
+		commit *CommitObject
+	}
+
+	const fromA, fromB = 1, 2
+	reached := make(map[Hash]int)
+	var pending []pendingCommit
+	push := func(hash Hash, side int) error {
+		if reached[hash]&side != 0 {
+			return nil
+		}
+		commit, err := r.GetCommit(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+		reached[hash] |= side
+		pending = append(pending, pendingCommit{hash, commit})
+		return nil
+	}
+	if err := push(a, fromA); err != nil {
+		return "", err
+	}
+	if err := push(b, fromB); err != nil {
+		return "", err
+	}
+
+	for len(pending) > 0 {
+		sort.Slice(pending, func(i, j int) bool {
+			return pending[i].commit.Timestamp.After(pending[j].commit.Timestamp)
+		})
+		next := pending[0]
+		pending = pending[1:]
+		side := reached[next.hash]
+		if side == fromA|fromB {
+			return next.hash, nil
+		}
+		for _, parent := range next.commit.Parents() {
+			if err := push(parent, side); err != nil {
+				return "", err
+			}
+		}
+	}
+	return "", nil
+}
+
+// mergeTrees merges the changes from base to theirs into ours, returning
+// the merged tree, or "" for a directory the merge empties. Paths both
+// sides changed differently are added to conflicts.
+func (r *RepositoryImpl) mergeTrees(ctx context.Context, base, ours, theirs Hash, prefix string, conflicts *[]string) (Hash, error) {
+	switch {
+	case ours == theirs, base == theirs:
+		return ours, nil
+	case base == ours:
+		return theirs, nil
+	}
+
+	baseEntries, err := r.treeEntries(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	ourEntries, err := r.treeEntries(ctx, ours)
+	if err != nil {
+		return "", err
+	}
+	theirEntries, err := r.treeEntries(ctx, theirs)
+	if err != nil {
+		return "", err
+	}
+
+	names := make(map[string]bool)
+	for _, entries := range []map[string]TreeEntry{baseEntries, ourEntries, theirEntries} {
+		for name := range entries {
+			names[name] = true
+		}
+	}
+
+	var merged []TreeEntry
+	for name := range names {
+		baseEntry, inBase := baseEntries[name]
+		ourEntry, inOurs := ourEntries[name]
+		theirEntry, inTheirs := theirEntries[name]
+		sameAs := func(a TreeEntry, inA bool, b TreeEntry, inB bool) bool {
+			return inA == inB && (!inA || a.Hash == b.Hash && a.Type == b.Type && a.Mode == b.Mode)
+		}
+
+		switch {
+		case sameAs(ourEntry, inOurs, theirEntry, inTheirs), sameAs(baseEntry, inBase, theirEntry, inTheirs):
+			if inOurs {
+				merged = append(merged, ourEntry)
+			}
+		case sameAs(baseEntry, inBase, ourEntry, inOurs):
+			if inTheirs {
+				merged = append(merged, theirEntry)
+			}
+		case inOurs && inTheirs && ourEntry.Type == ObjectTypeTree && theirEntry.Type == ObjectTypeTree:
+			var baseTree Hash
+			if inBase && baseEntry.Type == ObjectTypeTree {
+				baseTree = baseEntry.Hash
+			}
+			hash, err := r.mergeTrees(ctx, baseTree, ourEntry.Hash, theirEntry.Hash, prefix+name+"/", conflicts)
+			if err != nil {
+				return "", err
+			}
+			if hash != "" {
+				ourEntry.Hash = hash
+				merged = append(merged, ourEntry)
+			}
+		default:
+			*conflicts = append(*conflicts, prefix+name)
+		}
+	}
+
+	if len(merged) == 0 && prefix != "" {
+		return "", nil
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	return r.StoreTree(ctx, &TreeObject{Entries: merged})
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HashFormat is how trees and commits are encoded for hashing, separately
+// from the JSON they are stored as. Blobs are hashed as their content in
+// every format.
+type HashFormat int
+
+const (
+	// HashFormatJSON hashes the stored JSON itself, so adding a field or a
+	// change in how Go marshals one changes every hash. Objects that record
+	// no format, and repositories that record none, use it.
+	HashFormatJSON HashFormat = 0
+
+	// HashFormatCanonical1 hashes the canonical encoding below, which does
+	// not depend on field order, entry order or the JSON encoder
+	HashFormatCanonical1 HashFormat = 1
+)
+
+// CurrentHashFormat is the format new repositories hash with, and the one
+// Rehash rewrites objects to
+const CurrentHashFormat = HashFormatCanonical1
+
+// ParseHashFormat reads a format version as recorded in config/hash-format
+func ParseHashFormat(value string) (HashFormat, error) {
+	switch value {
+	case "", "0":
+		return HashFormatJSON, nil
+	case "1":
+		return HashFormatCanonical1, nil
+	}
+	return 0, fmt.Errorf("unknown hash format %q", value)
+}
+
+func (f HashFormat) String() string {
+	if f == HashFormatJSON {
+		return "json"
+	}
+	return "canonical/" + strconv.Itoa(int(f))
+}
+
+// The canonical encoding, version 1, of a tree or commit is
+//
+//	poon-canonical 1 <type>\n
+//
+// followed by its fields as records, "<name> <length>:<value>\n", in order
+// of name. Fields holding their zero value are left out, so a field added
+// later changes no hash of an object without it; only a change to how an
+// existing field is encoded needs a new format version. Numbers are
+// decimal, times RFC 3339 in UTC with nanoseconds, and hashes hex. A tree's
+// entries are "entry" records in order of name, each holding the entry's
+// own fields as records; a merge commit's merge parents are "merge_parent"
+// records in their order.
+//
+// Every field that is stored must be encoded: a field the encoding left out
+// could differ between two objects with the same hash.
+
+type canonicalWriter struct {
+	buf bytes.Buffer
+}
+
+func newCanonicalWriter(objType ObjectType) *canonicalWriter {
+	w := &canonicalWriter{}
+	fmt.Fprintf(&w.buf, "poon-canonical %d %s\n", HashFormatCanonical1, objType)
+	return w
+}
+
+func (w *canonicalWriter) field(name string, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+	fmt.Fprintf(&w.buf, "%s %d:", name, len(value))
+	w.buf.Write(value)
+	w.buf.WriteByte('\n')
+}
+
+func (w *canonicalWriter) string(name, value string) {
+	w.field(name, []byte(value))
+}
+
+func (w *canonicalWriter) int(name string, value int64) {
+	if value != 0 {
+		w.string(name, strconv.FormatInt(value, 10))
+	}
+}
+
+func (w *canonicalWriter) time(name string, value time.Time) {
+	if !value.IsZero() {
+		w.string(name, value.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+// canonicalEntry encodes the fields of a tree entry, in order of name
+func canonicalEntry(entry *TreeEntry) []byte {
+	w := &canonicalWriter{}
+	w.string("hash", string(entry.Hash))
+	w.int("mode", int64(entry.Mode))
+	w.int("modtime", entry.ModTime)
+	w.string("name", entry.Name)
+	w.int("size", entry.Size)
+	w.string("type", string(entry.Type))
+	return w.buf.Bytes()
+}
+
+// canonicalTree returns the canonical encoding of tree
+func canonicalTree(tree *TreeObject) []byte {
+	entries := sortedEntries(tree.Entries)
+	w := newCanonicalWriter(ObjectTypeTree)
+	for i := range entries {
+		w.field("entry", canonicalEntry(&entries[i]))
+	}
+	return w.buf.Bytes()
+}
+
+// canonicalCommit returns the canonical encoding of commit
+func canonicalCommit(commit *CommitObject) []byte {
+	w := newCanonicalWriter(ObjectTypeCommit)
+	w.string("author", commit.Author)
+	w.field("changed_paths", commit.ChangedPaths)
+	for _, parent := range commit.MergeParents {
+		w.string("merge_parent", string(parent))
+	}
+	w.string("message", commit.Message)
+	if commit.Parent != nil {
+		w.string("parent", string(*commit.Parent))
+	}
+	w.string("root_tree", string(commit.RootTree))
+	w.time("timestamp", commit.Timestamp)
+	w.int("version", commit.Version)
+	return w.buf.Bytes()
+}
+
+// sortedEntries returns a copy of entries in order of name
+func sortedEntries(entries []TreeEntry) []TreeEntry {
+	sorted := append([]TreeEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// hashedContent returns what an object of objType whose stored content is
+// content is hashed over in format
+func hashedContent(format HashFormat, objType ObjectType, content []byte) ([]byte, error) {
+	if format == HashFormatJSON || objType == ObjectTypeBlob {
+		return content, nil
+	}
+	if format != HashFormatCanonical1 {
+		return nil, fmt.Errorf("unknown hash format %d", format)
+	}
+
+	switch objType {
+	case ObjectTypeTree:
+		var tree TreeObject
+		if err := json.Unmarshal(content, &tree); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tree: %w", err)
+		}
+		return canonicalTree(&tree), nil
+	case ObjectTypeCommit:
+		var commit CommitObject
+		if err := json.Unmarshal(content, &commit); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal commit: %w", err)
+		}
+		return canonicalCommit(&commit), nil
+	}
+	return nil, fmt.Errorf("unknown object type %q", objType)
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CherryPickResult describes a commit's changes applied to a branch
+type CherryPickResult struct {
+	Commit  Hash         `json:"commit"`            // The new commit, or the target's head when empty
+	Version *VersionInfo `json:"version,omitempty"` // The version created, for a pick onto main
+	Empty   bool         `json:"empty"`             // The target already had the commit's changes
+}
+
+// cherryPickTrailer ends the message of a picked commit, naming the commit
+// it came from
+const cherryPickTrailer = "(cherry picked from commit %s)"
+
+// CherryPickMessage returns the message CherryPick gives the commit picked
+// from one with the given hash and message
+func CherryPickMessage(message string, commit Hash) string {
+	return message + "\n\n" + fmt.Sprintf(cherryPickTrailer, commit)
+}
+
+// PreviewCherryPick applies a commit's changes to a branch without
+// committing, so they can be checked first. A pick that conflicts fails
+// with a *MergeConflictError, as CherryPick would.
+func (r *RepositoryImpl) PreviewCherryPick(ctx context.Context, commit Hash, target string) (*MergePreview, error) {
+	prepared, err := r.prepareCherryPick(ctx, commit, target)
+	if err != nil {
+		return nil, err
+	}
+	return r.previewPrepared(ctx, prepared)
+}
+
+// CherryPick applies the changes a commit made to its first parent on top
+// of target, a version's commit or any branch's, as a new commit by author
+// with the commit's message. A pick onto main creates a version. It is a
+// three-way tree merge with the parent as the base: a path the target also
+// changed differently fails the pick with a *MergeConflictError, and a pick
+// whose changes the target already has commits nothing.
+func (r *RepositoryImpl) CherryPick(ctx context.Context, commit Hash, target, author string) (*CherryPickResult, error) {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	prepared, err := r.prepareCherryPick(ctx, commit, target)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := r.previewPrepared(ctx, prepared)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.Changes) == 0 {
+		return &CherryPickResult{Commit: prepared.targetHead, Empty: true}, nil
+	}
+
+	picked, err := r.GetCommit(ctx, commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commit, err)
+	}
+
+	result := &CherryPickResult{}
+	result.Commit, result.Version, err = r.commitToBranch(ctx, target, &CommitObject{
+		RootTree:     prepared.root,
+		Parent:       &prepared.targetHead,
+		Author:       author,
+		Message:      CherryPickMessage(picked.Message, commit),
+		Timestamp:    time.Now(),
+		ChangedPaths: r.changedPaths(ctx, prepared.targetRoot, prepared.root),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// prepareCherryPick merges the changes from a commit's first parent, or
+// from nothing for a root commit, to the commit into target's tree
+func (r *RepositoryImpl) prepareCherryPick(ctx context.Context, hash Hash, target string) (*preparedMerge, error) {
+	commit, err := r.GetCommit(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+
+	m := &preparedMerge{sourceHead: hash}
+	var baseRoot Hash
+	if commit.Parent != nil {
+		parent, err := r.GetCommit(ctx, *commit.Parent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent of %s: %w", hash, err)
+		}
+		m.base, baseRoot = *commit.Parent, parent.RootTree
+	}
+
+	if m.targetHead, err = r.BranchHead(ctx, target); err != nil {
+		return nil, err
+	}
+	targetCommit, err := r.GetCommit(ctx, m.targetHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s head: %w", target, err)
+	}
+	m.targetRoot = targetCommit.RootTree
+
+	var conflicts []string
+	m.root, err = r.mergeTrees(ctx, baseRoot, m.targetRoot, commit.RootTree, "", &conflicts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge trees: %w", err)
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, &MergeConflictError{Source: string(hash), Target: target, Paths: conflicts}
+	}
+	return m, nil
+}
@@ -0,0 +1,421 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// commitGraphPrefix holds the commit graph. It is derived from the version
+// metadata and commits, so backups and migrations leave it out and it is
+// rebuilt on first use.
+const commitGraphPrefix = "commit-graph/"
+
+// commitGraphChunkSize is how many versions one chunk of the graph covers.
+// A walk over n versions reads about n/commitGraphChunkSize keys instead of
+// n commits.
+const commitGraphChunkSize = 256
+
+// commitGraphHeadKey records the newest version the graph covers
+const commitGraphHeadKey = commitGraphPrefix + "head"
+
+// CommitGraphEntry is what the commit graph knows of one version's commit:
+// enough to walk history and compare trees without reading the commit
+type CommitGraphEntry struct {
+	Version    int64 `json:"version"`
+	Commit     Hash  `json:"commit"`
+	RootTree   Hash  `json:"rootTree"`
+	Generation int64 `json:"generation"` // 1 for a root, else one more than the highest parent's
+
+	// Parents holds the version of the commit's first parent. A merge
+	// commit's merge parents are branch commits, which are not versions, so
+	// history walks through the graph follow main and see a merged branch's
+	// changes as made by the merge.
+	Parents []int64 `json:"parents,omitempty"`
+
+	// ChangedPaths is the commit's changed-path filter, if it has one
+	ChangedPaths []byte `json:"changedPaths,omitempty"`
+}
+
+// commitGraphChunk holds the entries of the versions in one chunk, in order
+// of version. Versions that history pruning removed have no entry.
+type commitGraphChunk struct {
+	Entries []CommitGraphEntry `json:"entries"`
+}
+
+func (c *commitGraphChunk) find(version int64) *CommitGraphEntry {
+	i := sort.Search(len(c.Entries), func(i int) bool { return c.Entries[i].Version >= version })
+	if i < len(c.Entries) && c.Entries[i].Version == version {
+		return &c.Entries[i]
+	}
+	return nil
+}
+
+// add appends the entry of a version, replacing any entries at or after it
+// that a stored chunk holds beyond the graph's head
+func (c *commitGraphChunk) add(entry CommitGraphEntry) {
+	for len(c.Entries) > 0 && c.Entries[len(c.Entries)-1].Version >= entry.Version {
+		c.Entries = c.Entries[:len(c.Entries)-1]
+	}
+	c.Entries = append(c.Entries, entry)
+}
+
+func commitGraphChunkKey(chunk int64) string {
+	return commitGraphPrefix + "chunk/" + strconv.FormatInt(chunk, 10)
+}
+
+func commitGraphChunkOf(version int64) int64 {
+	return (version - 1) / commitGraphChunkSize
+}
+
+// commitGraph indexes the parents and generation numbers of every version's
+// commit in chunks of the backend, so ancestry queries and history walks
+// read a key per chunk rather than a commit per version. It is brought up
+// to date when it is read; rewrites of history reset it. Like the version
+// cache, it assumes this repository is the only writer of its backend.
+type commitGraph struct {
+	backend StorageBackend
+
+	mu     sync.Mutex
+	head   int64 // Newest version covered, -1 when not loaded
+	chunks map[int64]*commitGraphChunk
+}
+
+func newCommitGraph(backend StorageBackend) *commitGraph {
+	return &commitGraph{backend: backend, head: -1, chunks: make(map[int64]*commitGraphChunk)}
+}
+
+// chunk returns a chunk of the graph, empty if none is stored. Callers
+// hold g.mu.
+func (g *commitGraph) chunk(ctx context.Context, index int64) (*commitGraphChunk, error) {
+	if chunk, ok := g.chunks[index]; ok {
+		return chunk, nil
+	}
+
+	chunk := &commitGraphChunk{}
+	data, err := g.backend.Get(ctx, commitGraphChunkKey(index))
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read commit graph: %w", err)
+	default:
+		if err := json.Unmarshal(data, chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal commit graph: %w", err)
+		}
+	}
+	g.chunks[index] = chunk
+	return chunk, nil
+}
+
+// loadHead reads the newest version the stored graph covers. Callers hold
+// g.mu.
+func (g *commitGraph) loadHead(ctx context.Context) (int64, error) {
+	if g.head >= 0 {
+		return g.head, nil
+	}
+	data, err := g.backend.Get(ctx, commitGraphHeadKey)
+	if errors.Is(err, ErrKeyNotFound) {
+		g.head = 0
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read commit graph: %w", err)
+	}
+	head, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse commit graph head: %w", err)
+	}
+	g.head = head
+	return head, nil
+}
+
+// forget drops the cached graph after history was rewritten. The stored
+// graph is checked against the versions when it is next loaded.
+func (g *commitGraph) forget() {
+	g.mu.Lock()
+	g.head, g.chunks = -1, make(map[int64]*commitGraphChunk)
+	g.mu.Unlock()
+}
+
+// reset removes the stored graph. Callers hold g.mu.
+func (g *commitGraph) reset(ctx context.Context) error {
+	g.head, g.chunks = 0, make(map[int64]*commitGraphChunk)
+	keys, err := g.backend.List(ctx, commitGraphPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list commit graph: %w", err)
+	}
+	// The head goes first, so a reset that stops partway leaves no graph
+	// claiming to cover the chunks that remain
+	sort.Slice(keys, func(i, j int) bool { return keys[i] == commitGraphHeadKey })
+	for _, key := range keys {
+		if err := g.backend.Delete(ctx, key); err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// commitGraph returns the graph, brought up to date with the current
+// version
+func (r *RepositoryImpl) commitGraph(ctx context.Context) (*commitGraph, error) {
+	g := r.graph
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	loaded := g.head >= 0
+	head, err := g.loadHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := r.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !loaded && head > 0 {
+		// A rehash, pruning or restore rewrites the newest commit, or
+		// takes the repository back to before the graph's head
+		if valid, err := r.commitGraphValid(ctx, g, head, current); err != nil {
+			return nil, err
+		} else if !valid {
+			if err := g.reset(ctx); err != nil {
+				return nil, err
+			}
+			head = 0
+		}
+	}
+	if head >= current {
+		return g, nil
+	}
+
+	// Index the new versions, then store the chunks they went into and
+	// finally the head that makes them visible. If that fails partway the
+	// cached chunks are dropped, as they may hold entries the head does not
+	// cover yet.
+	stored := false
+	defer func() {
+		if !stored {
+			g.head, g.chunks = -1, make(map[int64]*commitGraphChunk)
+		}
+	}()
+	dirty := make(map[int64]bool)
+	for version := head + 1; version <= current; version++ {
+		entry, err := r.indexVersion(ctx, g, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to index version %d: %w", version, err)
+		}
+		if entry == nil {
+			continue // Removed by history pruning
+		}
+		index := commitGraphChunkOf(version)
+		chunk, err := g.chunk(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		chunk.add(*entry)
+		dirty[index] = true
+	}
+
+	for index := range dirty {
+		data, err := json.Marshal(g.chunks[index])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal commit graph: %w", err)
+		}
+		if err := g.backend.Put(ctx, commitGraphChunkKey(index), data); err != nil {
+			return nil, fmt.Errorf("failed to store commit graph: %w", err)
+		}
+	}
+	if err := g.backend.Put(ctx, commitGraphHeadKey, []byte(strconv.FormatInt(current, 10))); err != nil {
+		return nil, fmt.Errorf("failed to store commit graph: %w", err)
+	}
+	g.head, stored = current, true
+	return g, nil
+}
+
+// commitGraphValid reports whether a stored graph covering versions up to
+// head still describes the repository's history. Callers hold g.mu.
+func (r *RepositoryImpl) commitGraphValid(ctx context.Context, g *commitGraph, head, current int64) (bool, error) {
+	if head > current {
+		return false, nil
+	}
+	chunk, err := g.chunk(ctx, commitGraphChunkOf(head))
+	if err != nil {
+		return false, err
+	}
+	info, err := r.GetVersionInfo(ctx, head)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	entry := chunk.find(head)
+	return entry != nil && entry.Commit == info.CommitHash, nil
+}
+
+// indexVersion builds the graph entry of a version from its commit, or
+// returns nil if the version no longer exists. Its parents are already in
+// the graph. Callers hold g.mu.
+func (r *RepositoryImpl) indexVersion(ctx context.Context, g *commitGraph, version int64) (*CommitGraphEntry, error) {
+	info, err := r.GetVersionInfo(ctx, version)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.GetCommit(ctx, info.CommitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	entry := &CommitGraphEntry{
+		Version:      version,
+		Commit:       info.CommitHash,
+		RootTree:     commit.RootTree,
+		Generation:   1,
+		ChangedPaths: commit.ChangedPaths,
+	}
+	if commit.Parent == nil {
+		return entry, nil
+	}
+
+	parentVersion, err := r.GetVersionByCommit(ctx, *commit.Parent)
+	if err != nil {
+		return nil, fmt.Errorf("parent %s: %w", *commit.Parent, err)
+	}
+	chunk, err := g.chunk(ctx, commitGraphChunkOf(parentVersion))
+	if err != nil {
+		return nil, err
+	}
+	parent := chunk.find(parentVersion)
+	if parent == nil {
+		return nil, fmt.Errorf("parent version %d is not in the commit graph", parentVersion)
+	}
+	entry.Parents = []int64{parentVersion}
+	entry.Generation = parent.Generation + 1
+	return entry, nil
+}
+
+// entry returns the graph entry of a version
+func (g *commitGraph) entry(ctx context.Context, version int64) (*CommitGraphEntry, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if version < 1 || version > g.head {
+		return nil, fmt.Errorf("version %d not found", version)
+	}
+	chunk, err := g.chunk(ctx, commitGraphChunkOf(version))
+	if err != nil {
+		return nil, err
+	}
+	entry := chunk.find(version)
+	if entry == nil {
+		return nil, fmt.Errorf("version %d not found", version)
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// CommitGraphEntry returns the commit graph entry of a version
+func (r *RepositoryImpl) CommitGraphEntry(ctx context.Context, version int64) (*CommitGraphEntry, error) {
+	g, err := r.commitGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return g.entry(ctx, version)
+}
+
+// IsAncestor reports whether version ancestor is reachable from version
+// descendant through parents; a version is its own ancestor. The walk stops
+// at generations below the ancestor's, which no path to it can pass.
+func (r *RepositoryImpl) IsAncestor(ctx context.Context, ancestor, descendant int64) (bool, error) {
+	g, err := r.commitGraph(ctx)
+	if err != nil {
+		return false, err
+	}
+	target, err := g.entry(ctx, ancestor)
+	if err != nil {
+		return false, err
+	}
+
+	seen := map[int64]bool{descendant: true}
+	queue := []int64{descendant}
+	for len(queue) > 0 {
+		entry, err := g.entry(ctx, queue[0])
+		if err != nil {
+			return false, err
+		}
+		queue = queue[1:]
+		if entry.Version == ancestor {
+			return true, nil
+		}
+		if entry.Generation <= target.Generation {
+			continue
+		}
+		for _, parent := range entry.Parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return false, nil
+}
+
+// MergeBase returns the newest common ancestor of two versions, by
+// generation, or 0 when their histories never meet
+func (r *RepositoryImpl) MergeBase(ctx context.Context, a, b int64) (int64, error) {
+	g, err := r.commitGraph(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Walk both sides highest generation first, marking which side reached
+	// each version; the first version both reach is the merge base
+	const fromA, fromB = 1, 2
+	reached := make(map[int64]int)
+	var pending []*CommitGraphEntry
+	push := func(version int64, side int) error {
+		if reached[version]&side != 0 {
+			return nil
+		}
+		entry, err := g.entry(ctx, version)
+		if err != nil {
+			return err
+		}
+		reached[version] |= side
+		pending = append(pending, entry)
+		return nil
+	}
+	if err := push(a, fromA); err != nil {
+		return 0, err
+	}
+	if err := push(b, fromB); err != nil {
+		return 0, err
+	}
+
+	for len(pending) > 0 {
+		sort.Slice(pending, func(i, j int) bool {
+			if pending[i].Generation != pending[j].Generation {
+				return pending[i].Generation > pending[j].Generation
+			}
+			return pending[i].Version > pending[j].Version
+		})
+		entry := pending[0]
+		pending = pending[1:]
+		side := reached[entry.Version]
+		if side == fromA|fromB {
+			return entry.Version, nil
+		}
+		for _, parent := range entry.Parents {
+			if err := push(parent, side); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return 0, nil
+}
@@ -1,34 +1,91 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 )
 
 // ContentStore implements ContentAddressable interface
 type ContentStore struct {
 	backend StorageBackend
-	hasher  *Hasher
+
+	// hasher creates new objects. It is swapped when the repository is
+	// rehashed; objects already stored verify with their own algorithm.
+	hasher atomic.Pointer[Hasher]
+
+	// Objects that fail verification are recorded under quarantine/ and
+	// fetched again from repairSource when one is set
+	quarantineMu   sync.Mutex
+	repairSource   atomic.Pointer[ContentStore]
+	isRepairSource bool
+
+	// While journal is set, the hashes of objects written are recorded in
+	// it, so a migration can copy what changed after it listed the objects
+	journalMu sync.Mutex
+	journal   map[Hash]bool
 }
 
-// NewContentStore creates a new content-addressable store
-func NewContentStore(backend StorageBackend) *ContentStore {
-	return &ContentStore{
-		backend: backend,
-		hasher:  NewHasher(),
+// startJournal starts recording the objects written
+func (cs *ContentStore) startJournal() {
+	cs.journalMu.Lock()
+	defer cs.journalMu.Unlock()
+	cs.journal = make(map[Hash]bool)
+}
+
+// stopJournal stops recording and returns the objects written since
+// startJournal
+func (cs *ContentStore) stopJournal() []Hash {
+	cs.journalMu.Lock()
+	defer cs.journalMu.Unlock()
+	hashes := make([]Hash, 0, len(cs.journal))
+	for hash := range cs.journal {
+		hashes = append(hashes, hash)
 	}
+	cs.journal = nil
+	return hashes
+}
+
+// written records an object write in the journal, if one is running
+func (cs *ContentStore) written(hash Hash) {
+	cs.journalMu.Lock()
+	defer cs.journalMu.Unlock()
+	if cs.journal != nil {
+		cs.journal[hash] = true
+	}
+}
+
+// NewContentStore creates a new content-addressable store that hashes new
+// objects with SHA-256
+func NewContentStore(backend StorageBackend) *ContentStore {
+	cs := &ContentStore{backend: backend}
+	cs.hasher.Store(NewHasher())
+	return cs
+}
+
+// HashAlgorithm returns the algorithm new objects are hashed with
+func (cs *ContentStore) HashAlgorithm() HashAlgorithm {
+	return cs.hasher.Load().Algorithm()
+}
+
+// HashFormat returns the format new trees and commits are hashed in
+func (cs *ContentStore) HashFormat() HashFormat {
+	return cs.hasher.Load().Format()
 }
 
 // ComputeHash computes the hash for given content
 func (cs *ContentStore) ComputeHash(content []byte) Hash {
-	return cs.hasher.ComputeHash(content)
+	return cs.hasher.Load().ComputeHash(content)
 }
 
 // Store stores an object and returns its hash
 func (cs *ContentStore) Store(ctx context.Context, obj *Object) (Hash, error) {
 	// Verify object integrity
-	if err := cs.hasher.VerifyObject(obj); err != nil {
+	if err := cs.hasher.Load().VerifyObject(obj); err != nil {
 		return "", fmt.Errorf("object verification failed: %w", err)
 	}
 
@@ -43,13 +100,24 @@ func (cs *ContentStore) Store(ctx context.Context, obj *Object) (Hash, error) {
 	if err := cs.backend.Put(ctx, key, data); err != nil {
 		return "", fmt.Errorf("failed to store object: %w", err)
 	}
+	cs.written(obj.Hash)
 
 	return obj.Hash, nil
 }
 
-// Get retrieves an object by its hash
+// Get retrieves an object by its hash. Large blobs are better read with
+// OpenBlob, which does not hold them in memory. An object that fails
+// verification is quarantined, and returned if it could be repaired.
 func (cs *ContentStore) Get(ctx context.Context, hash Hash) (*Object, error) {
-	if err := cs.hasher.ValidateHash(hash); err != nil {
+	obj, err := cs.get(ctx, hash)
+	if errors.Is(err, ErrCorruptObject) && cs.quarantine(ctx, hash, err) {
+		return cs.get(ctx, hash)
+	}
+	return obj, err
+}
+
+func (cs *ContentStore) get(ctx context.Context, hash Hash) (*Object, error) {
+	if err := cs.hasher.Load().ValidateHash(hash); err != nil {
 		return nil, fmt.Errorf("invalid hash: %w", err)
 	}
 
@@ -59,22 +127,26 @@ func (cs *ContentStore) Get(ctx context.Context, hash Hash) (*Object, error) {
 		return nil, fmt.Errorf("object not found: %w", err)
 	}
 
-	var obj Object
-	if err := json.Unmarshal(data, &obj); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal object: %w", err)
+	obj := &Object{}
+	if bytes.HasPrefix(data, []byte(rawObjectPrefix)) {
+		if obj, err = decodeRawObject(hash, data); err != nil {
+			return nil, corrupt(fmt.Errorf("stored object verification failed: %w", err))
+		}
+	} else if err := json.Unmarshal(data, obj); err != nil {
+		return nil, corrupt(fmt.Errorf("failed to unmarshal object: %w", err))
 	}
 
 	// Verify object integrity
-	if err := cs.hasher.VerifyObject(&obj); err != nil {
-		return nil, fmt.Errorf("stored object verification failed: %w", err)
+	if err := cs.hasher.Load().VerifyObject(obj); err != nil {
+		return nil, corrupt(fmt.Errorf("stored object verification failed: %w", err))
 	}
 
-	return &obj, nil
+	return obj, nil
 }
 
 // Exists checks if an object exists
 func (cs *ContentStore) Exists(ctx context.Context, hash Hash) (bool, error) {
-	if err := cs.hasher.ValidateHash(hash); err != nil {
+	if err := cs.hasher.Load().ValidateHash(hash); err != nil {
 		return false, fmt.Errorf("invalid hash: %w", err)
 	}
 
@@ -84,7 +156,7 @@ func (cs *ContentStore) Exists(ctx context.Context, hash Hash) (bool, error) {
 
 // Delete removes an object
 func (cs *ContentStore) Delete(ctx context.Context, hash Hash) error {
-	if err := cs.hasher.ValidateHash(hash); err != nil {
+	if err := cs.hasher.Load().ValidateHash(hash); err != nil {
 		return fmt.Errorf("invalid hash: %w", err)
 	}
 
@@ -113,13 +185,13 @@ func (cs *ContentStore) List(ctx context.Context) ([]Hash, error) {
 
 // StoreBlob stores file content and returns its hash
 func (cs *ContentStore) StoreBlob(ctx context.Context, content []byte) (Hash, error) {
-	obj := cs.hasher.CreateBlobObject(content)
+	obj := cs.hasher.Load().CreateBlobObject(content)
 	return cs.Store(ctx, obj)
 }
 
 // StoreTree stores directory structure and returns its hash
 func (cs *ContentStore) StoreTree(ctx context.Context, tree *TreeObject) (Hash, error) {
-	obj, err := cs.hasher.CreateTreeObject(tree)
+	obj, err := cs.hasher.Load().CreateTreeObject(tree)
 	if err != nil {
 		return "", fmt.Errorf("failed to create tree object: %w", err)
 	}
@@ -128,7 +200,7 @@ func (cs *ContentStore) StoreTree(ctx context.Context, tree *TreeObject) (Hash,
 
 // StoreCommit stores commit object and returns its hash
 func (cs *ContentStore) StoreCommit(ctx context.Context, commit *CommitObject) (Hash, error) {
-	obj, err := cs.hasher.CreateCommitObject(commit)
+	obj, err := cs.hasher.Load().CreateCommitObject(commit)
 	if err != nil {
 		return "", fmt.Errorf("failed to create commit object: %w", err)
 	}
@@ -0,0 +1,594 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ChangeType classifies a FileChange
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeDeleted  ChangeType = "deleted"
+	ChangeModified ChangeType = "modified"
+	ChangeRenamed  ChangeType = "renamed"
+	ChangeCopied   ChangeType = "copied"
+)
+
+// DefaultRenameThreshold is the similarity, in percent, two files need to
+// be reported as a rename or copy when DiffOptions does not set one
+const DefaultRenameThreshold = 50
+
+// maxRenameCandidates bounds inexact rename and copy detection, which
+// compares every added file with every candidate source. Larger diffs only
+// get exact (same content) renames, like git's diff.renameLimit.
+const maxRenameCandidates = 1000
+
+// DiffOptions controls how DiffVersions pairs added files with their sources
+type DiffOptions struct {
+	DetectRenames bool // Report a deleted and an added file as a rename when similar enough
+	DetectCopies  bool // Report an added file as a copy of a modified or deleted file
+	Threshold     int  // Minimum similarity in percent (DefaultRenameThreshold when 0)
+}
+
+// FileChange describes how one file differs between two versions
+type FileChange struct {
+	Type       ChangeType `json:"type"`
+	Path       string     `json:"path"`              // Path in the new version, or the deleted path
+	OldPath    string     `json:"oldPath,omitempty"` // Source of a rename or copy
+	OldHash    Hash       `json:"oldHash,omitempty"`
+	NewHash    Hash       `json:"newHash,omitempty"`
+	Similarity int        `json:"similarity,omitempty"` // Percent, for renames and copies
+}
+
+// FileHistoryEntry is a commit that changed a file
+type FileHistoryEntry struct {
+	Version    int64      `json:"version"`
+	CommitHash Hash       `json:"commitHash"`
+	Author     string     `json:"author"`
+	Message    string     `json:"message"`
+	Timestamp  time.Time  `json:"timestamp"`
+	Change     FileChange `json:"change"`
+}
+
+// DiffVersions lists the files that differ between two versions, sorted by
+// path. Subtrees with the same hash are skipped without being read.
+func (r *RepositoryImpl) DiffVersions(ctx context.Context, from, to int64, opts DiffOptions) ([]FileChange, error) {
+	var oldRoot Hash
+	if from > 0 {
+		var err error
+		if oldRoot, err = r.rootTreeHash(ctx, from); err != nil {
+			return nil, err
+		}
+	}
+	newRoot, err := r.rootTreeHash(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.diffRoots(ctx, oldRoot, newRoot, opts)
+}
+
+func (r *RepositoryImpl) diffRoots(ctx context.Context, oldRoot, newRoot Hash, opts DiffOptions) ([]FileChange, error) {
+	var changes []FileChange
+	if err := r.diffTrees(ctx, oldRoot, newRoot, "", &changes); err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	if opts.DetectRenames || opts.DetectCopies {
+		threshold := opts.Threshold
+		if threshold <= 0 {
+			threshold = DefaultRenameThreshold
+		}
+		detector := &renameDetector{repo: r, threshold: threshold, contents: make(map[Hash][]byte)}
+		var err error
+		if changes, err = detector.detect(ctx, changes, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// diffTrees appends the blob changes between two trees; an empty hash
+// stands for a tree that does not exist
+func (r *RepositoryImpl) diffTrees(ctx context.Context, oldHash, newHash Hash, prefix string, changes *[]FileChange) error {
+	if oldHash == newHash {
+		return nil
+	}
+
+	oldEntries, err := r.treeEntries(ctx, oldHash)
+	if err != nil {
+		return err
+	}
+	newEntries, err := r.treeEntries(ctx, newHash)
+	if err != nil {
+		return err
+	}
+
+	for name, oldEntry := range oldEntries {
+		path := prefix + name
+		newEntry, exists := newEntries[name]
+
+		switch {
+		case !exists:
+			if err := r.diffEntry(ctx, oldEntry, TreeEntry{}, path, changes); err != nil {
+				return err
+			}
+		case oldEntry.Hash != newEntry.Hash || oldEntry.Type != newEntry.Type:
+			if err := r.diffEntry(ctx, oldEntry, newEntry, path, changes); err != nil {
+				return err
+			}
+		}
+	}
+	for name, newEntry := range newEntries {
+		if _, exists := oldEntries[name]; !exists {
+			if err := r.diffEntry(ctx, TreeEntry{}, newEntry, prefix+name, changes); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffEntry compares one name in two trees. A zero TreeEntry means the name
+// is absent on that side; a file replaced by a directory is a deletion plus
+// additions.
+func (r *RepositoryImpl) diffEntry(ctx context.Context, oldEntry, newEntry TreeEntry, path string, changes *[]FileChange) error {
+	var oldTree, newTree Hash
+	if oldEntry.Type == ObjectTypeTree {
+		oldTree = oldEntry.Hash
+	}
+	if newEntry.Type == ObjectTypeTree {
+		newTree = newEntry.Hash
+	}
+	if oldTree != "" || newTree != "" {
+		if err := r.diffTrees(ctx, oldTree, newTree, path+"/", changes); err != nil {
+			return err
+		}
+	}
+
+	oldBlob := oldEntry.Type == ObjectTypeBlob
+	newBlob := newEntry.Type == ObjectTypeBlob
+	switch {
+	case oldBlob && newBlob:
+		*changes = append(*changes, FileChange{Type: ChangeModified, Path: path, OldHash: oldEntry.Hash, NewHash: newEntry.Hash})
+	case oldBlob:
+		*changes = append(*changes, FileChange{Type: ChangeDeleted, Path: path, OldHash: oldEntry.Hash})
+	case newBlob:
+		*changes = append(*changes, FileChange{Type: ChangeAdded, Path: path, NewHash: newEntry.Hash})
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) treeEntries(ctx context.Context, hash Hash) (map[string]TreeEntry, error) {
+	entries := make(map[string]TreeEntry)
+	if hash == "" {
+		return entries, nil
+	}
+
+	tree, err := r.getTree(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree %s: %w", hash, err)
+	}
+	for _, entry := range tree.Entries {
+		entries[entry.Name] = entry
+	}
+	return entries, nil
+}
+
+// renameDetector pairs added files with deleted (renames) or modified and
+// deleted (copies) files by content similarity
+type renameDetector struct {
+	repo      *RepositoryImpl
+	threshold int
+	contents  map[Hash][]byte
+}
+
+type renameCandidate struct {
+	added, source int
+	score         int
+}
+
+func (d *renameDetector) detect(ctx context.Context, changes []FileChange, opts DiffOptions) ([]FileChange, error) {
+	var added, deleted, modified []int
+	for i, change := range changes {
+		switch change.Type {
+		case ChangeAdded:
+			added = append(added, i)
+		case ChangeDeleted:
+			deleted = append(deleted, i)
+		case ChangeModified:
+			modified = append(modified, i)
+		}
+	}
+	if len(added) == 0 {
+		return changes, nil
+	}
+
+	paired := make(map[int]bool) // Added and deleted changes folded into a rename
+	if opts.DetectRenames && len(deleted) > 0 {
+		// Exact renames first: they are cheap and always win
+		byHash := make(map[Hash][]int)
+		for _, i := range deleted {
+			byHash[changes[i].OldHash] = append(byHash[changes[i].OldHash], i)
+		}
+		for _, a := range added {
+			sources := byHash[changes[a].NewHash]
+			for len(sources) > 0 && paired[sources[0]] {
+				sources = sources[1:]
+			}
+			if len(sources) > 0 {
+				d.pair(changes, a, sources[0], ChangeRenamed, 100)
+				paired[a], paired[sources[0]] = true, true
+			}
+		}
+
+		if err := d.pairSimilar(ctx, changes, unpaired(added, paired), unpaired(deleted, paired), ChangeRenamed, paired); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DetectCopies {
+		// Any deleted or modified file can be a copy source, including ones
+		// already renamed, so sources are not marked as paired
+		sources := append(append([]int{}, deleted...), modified...)
+		if err := d.pairSimilar(ctx, changes, unpaired(added, paired), sources, ChangeCopied, make(map[int]bool)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Deleted files that became renames are dropped; the rename replaces them
+	result := changes[:0:0]
+	for i, change := range changes {
+		if change.Type == ChangeDeleted && paired[i] {
+			continue
+		}
+		result = append(result, change)
+	}
+	return result, nil
+}
+
+// pairSimilar scores every added file against every source and pairs them
+// best score first. Each added file is paired at most once; renamed sources
+// are used at most once, copy sources any number of times.
+func (d *renameDetector) pairSimilar(ctx context.Context, changes []FileChange, added, sources []int, kind ChangeType, paired map[int]bool) error {
+	if len(added) == 0 || len(sources) == 0 || len(added) > maxRenameCandidates || len(sources) > maxRenameCandidates {
+		return nil
+	}
+
+	var candidates []renameCandidate
+	for _, a := range added {
+		for _, s := range sources {
+			score, err := d.similarity(ctx, changes[s].OldHash, changes[a].NewHash)
+			if err != nil {
+				return err
+			}
+			if score >= d.threshold {
+				candidates = append(candidates, renameCandidate{added: a, source: s, score: score})
+			}
+		}
+	}
+
+	// Highest score first; ties go to the closest path so that a file moved
+	// between two similar directories keeps its name
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return commonPrefix(changes[candidates[i].added].Path, changes[candidates[i].source].Path) >
+			commonPrefix(changes[candidates[j].added].Path, changes[candidates[j].source].Path)
+	})
+
+	for _, c := range candidates {
+		if paired[c.added] || (kind == ChangeRenamed && paired[c.source]) {
+			continue
+		}
+		d.pair(changes, c.added, c.source, kind, c.score)
+		paired[c.added] = true
+		if kind == ChangeRenamed {
+			paired[c.source] = true
+		}
+	}
+	return nil
+}
+
+func (d *renameDetector) pair(changes []FileChange, added, source int, kind ChangeType, score int) {
+	changes[added].Type = kind
+	changes[added].OldPath = changes[source].Path
+	changes[added].OldHash = changes[source].OldHash
+	changes[added].Similarity = score
+}
+
+// similarity estimates how much of two files is shared, in percent of the
+// larger one. Lines are compared as a multiset, so moved blocks still count.
+func (d *renameDetector) similarity(ctx context.Context, a, b Hash) (int, error) {
+	if a == b {
+		return 100, nil
+	}
+
+	contentA, err := d.content(ctx, a)
+	if err != nil {
+		return 0, err
+	}
+	contentB, err := d.content(ctx, b)
+	if err != nil {
+		return 0, err
+	}
+
+	larger := max(len(contentA), len(contentB))
+	smaller := min(len(contentA), len(contentB))
+	if larger == 0 || smaller*100 < d.threshold*larger {
+		return 0, nil // Too different in size to reach the threshold
+	}
+
+	lines := make(map[uint64]int)
+	for _, line := range bytes.SplitAfter(contentA, []byte("\n")) {
+		lines[lineHash(line)] += len(line)
+	}
+	common := 0
+	for _, line := range bytes.SplitAfter(contentB, []byte("\n")) {
+		h := lineHash(line)
+		if n := min(lines[h], len(line)); n > 0 {
+			common += n
+			lines[h] -= n
+		}
+	}
+
+	return common * 100 / larger, nil
+}
+
+func (d *renameDetector) content(ctx context.Context, hash Hash) ([]byte, error) {
+	if content, cached := d.contents[hash]; cached {
+		return content, nil
+	}
+	blob, err := d.repo.GetBlob(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	d.contents[hash] = blob.Content
+	return blob.Content, nil
+}
+
+func lineHash(line []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(line)
+	return h.Sum64()
+}
+
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func unpaired(indexes []int, paired map[int]bool) []int {
+	var result []int
+	for _, i := range indexes {
+		if !paired[i] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// FileHistory lists the commits that changed path, newest first, following
+// the file back through renames. A limit of zero returns all of them. The
+// walk follows the commit graph, so only the commits it returns are read.
+func (r *RepositoryImpl) FileHistory(ctx context.Context, path string, limit int) ([]FileHistoryEntry, error) {
+	path = NormalizePath(path)
+	current, err := r.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	graph, err := r.commitGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := graph.entry(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.findFileInTree(ctx, entry.RootTree, path); err != nil {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	var history []FileHistoryEntry
+	for entry != nil && (limit <= 0 || len(history) < limit) {
+		var parent *CommitGraphEntry
+		if len(entry.Parents) > 0 {
+			if parent, err = graph.entry(ctx, entry.Parents[0]); err != nil {
+				return nil, err
+			}
+			// A commit its filter rules out left the file as its parent had
+			// it, so neither tree needs reading
+			if !mayHaveChanged(entry.ChangedPaths, path) {
+				entry = parent
+				continue
+			}
+		}
+
+		newHash, err := r.findFileInTree(ctx, entry.RootTree, path)
+		if err != nil {
+			break // The file did not exist before a rename we could not follow
+		}
+		var parentRoot Hash
+		if parent != nil {
+			parentRoot = parent.RootTree
+		}
+
+		change := FileChange{Type: ChangeModified, Path: path, NewHash: newHash}
+		var oldHash Hash
+		if parentRoot != "" {
+			oldHash, _ = r.findFileInTree(ctx, parentRoot, path)
+		}
+
+		switch {
+		case oldHash == newHash:
+			change.Type = "" // Unchanged in this commit
+		case oldHash != "":
+			change.OldHash = oldHash
+		default:
+			// Added here, unless it was renamed or copied from another path
+			change.Type = ChangeAdded
+			if parentRoot != "" {
+				changes, err := r.diffRoots(ctx, parentRoot, entry.RootTree, DiffOptions{DetectRenames: true, DetectCopies: true})
+				if err != nil {
+					return nil, err
+				}
+				for _, c := range changes {
+					if c.Path == path {
+						change = c
+					}
+				}
+			}
+		}
+
+		if change.Type != "" {
+			commit, err := r.GetCommit(ctx, entry.Commit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get commit: %w", err)
+			}
+			history = append(history, FileHistoryEntry{
+				Version:    commit.Version,
+				CommitHash: entry.Commit,
+				Author:     commit.Author,
+				Message:    commit.Message,
+				Timestamp:  commit.Timestamp,
+				Change:     change,
+			})
+		}
+
+		switch change.Type {
+		case ChangeAdded:
+			return history, nil
+		case ChangeRenamed, ChangeCopied:
+			path = change.OldPath
+		}
+		entry = parent
+	}
+
+	return history, nil
+}
+
+// LastChanges returns, for each entry of the directory at path in a version,
+// the newest commit up to that version that changed it. History is walked
+// back only until every entry is accounted for, and commits that left the
+// directory tree untouched are skipped without reading it or their commit.
+func (r *RepositoryImpl) LastChanges(ctx context.Context, version int64, path string) (map[string]FileHistoryEntry, error) {
+	if _, err := r.GetVersionInfo(ctx, version); err != nil {
+		return nil, err
+	}
+	graph, err := r.commitGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := graph.entry(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	dirHash, err := r.findDirectoryInTree(ctx, entry.RootTree, path)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := r.treeEntries(ctx, dirHash)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	if parts := splitPath(path); len(parts) > 0 {
+		prefix = strings.Join(parts, "/") + "/"
+	}
+
+	changes := make(map[string]FileHistoryEntry, len(pending))
+	for len(pending) > 0 {
+		var parent *CommitGraphEntry
+		var parentDirHash Hash
+		if len(entry.Parents) > 0 {
+			if parent, err = graph.entry(ctx, entry.Parents[0]); err != nil {
+				return nil, err
+			}
+			// A commit its filter rules out changed nothing in the
+			// directory. Its parent's tree may still differ in modification
+			// times, which the comparisons below see through.
+			if !mayHaveChanged(entry.ChangedPaths, path) {
+				entry = parent
+				continue
+			}
+			// A directory missing from the parent leaves parentDirHash empty
+			parentDirHash, _ = r.findDirectoryInTree(ctx, parent.RootTree, path)
+		}
+
+		var commit *CommitObject
+
+		if parentDirHash != dirHash {
+			parentEntries, err := r.treeEntries(ctx, parentDirHash)
+			if err != nil {
+				return nil, err
+			}
+
+			for name, treeEntry := range pending {
+				old, existed := parentEntries[name]
+				if existed && old.Hash == treeEntry.Hash {
+					continue
+				}
+				// Subtree hashes also cover modification times, so a
+				// directory only changed if some file in it did
+				if existed && old.Type == ObjectTypeTree && treeEntry.Type == ObjectTypeTree {
+					var diff []FileChange
+					if err := r.diffTrees(ctx, old.Hash, treeEntry.Hash, "", &diff); err != nil {
+						return nil, err
+					}
+					if len(diff) == 0 {
+						pending[name] = old
+						continue
+					}
+				}
+
+				change := FileChange{Type: ChangeModified, Path: prefix + name, NewHash: treeEntry.Hash}
+				if existed {
+					change.OldHash = old.Hash
+				} else {
+					change.Type = ChangeAdded
+				}
+				if commit == nil {
+					if commit, err = r.GetCommit(ctx, entry.Commit); err != nil {
+						return nil, fmt.Errorf("failed to get commit: %w", err)
+					}
+				}
+				changes[name] = FileHistoryEntry{
+					Version:    commit.Version,
+					CommitHash: entry.Commit,
+					Author:     commit.Author,
+					Message:    commit.Message,
+					Timestamp:  commit.Timestamp,
+					Change:     change,
+				}
+				delete(pending, name)
+			}
+		}
+
+		if parent == nil {
+			break
+		}
+		entry, dirHash = parent, parentDirHash
+	}
+
+	return changes, nil
+}
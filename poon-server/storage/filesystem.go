@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemConfig holds configuration for the filesystem backend
+type FilesystemConfig struct {
+	Root string `json:"root"` // Directory keys are stored under
+}
+
+// FilesystemBackend implements StorageBackend with one file per key below a
+// root directory. Key path separators map to directories. Reads, writes and
+// listings fail with the context's error once it is cancelled, so a request
+// past its deadline stops walking the repository.
+type FilesystemBackend struct {
+	root string
+}
+
+// NewFilesystemBackend creates a filesystem backend rooted at root,
+// creating the directory if needed
+func NewFilesystemBackend(root string) (*FilesystemBackend, error) {
+	if root == "" {
+		return nil, fmt.Errorf("root directory is required")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create root directory: %w", err)
+	}
+	return &FilesystemBackend{root: root}, nil
+}
+
+// keyPath maps a key to a file below the root, rejecting keys that would
+// escape it
+func (f *FilesystemBackend) keyPath(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key: %s", key)
+	}
+	return filepath.Join(f.root, clean), nil
+}
+
+// Put stores data at the given key. The file is written to a temporary
+// name and renamed so readers never see a partial value.
+func (f *FilesystemBackend) Put(ctx context.Context, key string, data []byte) error {
+	return f.PutStream(ctx, key, bytes.NewReader(data))
+}
+
+// PutStream stores everything read from r at the given key, copying it to
+// disk as it is read
+func (f *FilesystemBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := f.keyPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+// contextReader stops a long copy once its context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Get retrieves data for the given key
+func (f *FilesystemBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := f.keyPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Exists checks if a key exists
+func (f *FilesystemBackend) Exists(ctx context.Context, key string) (bool, error) {
+	path, err := f.keyPath(key)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.Mode().IsRegular(), nil
+}
+
+// Delete removes data for the given key
+func (f *FilesystemBackend) Delete(ctx context.Context, key string) error {
+	path, err := f.keyPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns all keys with the given prefix
+func (f *FilesystemBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(f.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(f.root, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Stream returns a reader for the file stored at key
+func (f *FilesystemBackend) Stream(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := f.keyPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return file, err
+}
+
+// Close closes the backend (no-op for filesystem backend)
+func (f *FilesystemBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// IsGlobPattern reports whether p contains glob metacharacters and should be
+// expanded with Glob rather than used as a literal path
+func IsGlobPattern(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// Glob returns the files and directories in the given version that match
+// pattern, sorted. Each component of the pattern is matched with path.Match
+// against one path component, except "**", which matches any number of
+// directories, including none.
+func (r *RepositoryImpl) Glob(ctx context.Context, version int64, pattern string) ([]string, error) {
+	parts := splitPath(pattern)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	for _, part := range parts {
+		if _, err := path.Match(part, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	rootTree, err := r.rootTreeHash(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	if err := r.glob(ctx, rootTree, "", parts, found); err != nil {
+		return nil, err
+	}
+
+	matches := make([]string, 0, len(found))
+	for match := range found {
+		matches = append(matches, match)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (r *RepositoryImpl) glob(ctx context.Context, treeHash Hash, dir string, parts []string, found map[string]bool) error {
+	if len(parts) == 0 {
+		if dir != "" {
+			found[dir] = true
+		}
+		return nil
+	}
+
+	tree, err := r.getTree(ctx, treeHash)
+	if err != nil {
+		return fmt.Errorf("failed to read tree: %w", err)
+	}
+
+	if parts[0] == "**" {
+		// Match no directories, then one more level with ** still in place
+		if err := r.glob(ctx, treeHash, dir, parts[1:], found); err != nil {
+			return err
+		}
+		for _, entry := range tree.Entries {
+			if entry.Type != ObjectTypeTree {
+				if len(parts) == 1 {
+					// A trailing ** matches files too
+					found[joinTreePath(dir, entry.Name)] = true
+				}
+				continue
+			}
+			if err := r.glob(ctx, entry.Hash, joinTreePath(dir, entry.Name), parts, found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range tree.Entries {
+		if ok, _ := path.Match(parts[0], entry.Name); !ok {
+			continue
+		}
+		entryPath := joinTreePath(dir, entry.Name)
+		if len(parts) == 1 {
+			found[entryPath] = true
+			continue
+		}
+		if entry.Type == ObjectTypeTree {
+			if err := r.glob(ctx, entry.Hash, entryPath, parts[1:], found); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinTreePath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
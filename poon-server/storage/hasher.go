@@ -5,28 +5,114 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
+
+	"lukechampine.com/blake3"
+)
+
+// HashAlgorithm names the function objects are hashed with. Both produce
+// 32-byte digests, so hashes look the same (64 hex characters) either way.
+type HashAlgorithm string
+
+const (
+	HashSHA256 HashAlgorithm = "sha256" // The default, and what objects without an algorithm use
+	HashBLAKE3 HashAlgorithm = "blake3" // Roughly twice as fast on large ingestions
 )
 
+// ParseHashAlgorithm checks an algorithm name; empty means SHA-256
+func ParseHashAlgorithm(name string) (HashAlgorithm, error) {
+	switch HashAlgorithm(name) {
+	case "", HashSHA256:
+		return HashSHA256, nil
+	case HashBLAKE3:
+		return HashBLAKE3, nil
+	}
+	return "", fmt.Errorf("unknown hash algorithm %q (want %s or %s)", name, HashSHA256, HashBLAKE3)
+}
+
 // Hasher provides content-addressable hashing functionality
-type Hasher struct{}
+type Hasher struct {
+	algorithm HashAlgorithm
+	format    HashFormat
+}
 
-// NewHasher creates a new hasher instance
+// NewHasher creates a new hasher instance using SHA-256 and the JSON hash
+// format
 func NewHasher() *Hasher {
-	return &Hasher{}
+	return &Hasher{algorithm: HashSHA256}
+}
+
+// NewHasherWith creates a hasher for the given algorithm and the JSON hash
+// format
+func NewHasherWith(algorithm HashAlgorithm) *Hasher {
+	return &Hasher{algorithm: algorithm}
+}
+
+// NewHasherFor creates a hasher for the given algorithm and hash format
+func NewHasherFor(algorithm HashAlgorithm, format HashFormat) *Hasher {
+	return &Hasher{algorithm: algorithm, format: format}
 }
 
-// ComputeHash computes SHA-256 hash for raw content
+// Algorithm returns the algorithm new objects are hashed with
+func (h *Hasher) Algorithm() HashAlgorithm {
+	return h.algorithm
+}
+
+// Format returns the format new trees and commits are hashed in
+func (h *Hasher) Format() HashFormat {
+	return h.format
+}
+
+// ComputeHash computes the hash for raw content
 func (h *Hasher) ComputeHash(content []byte) Hash {
+	return hashWith(h.algorithm, content)
+}
+
+func hashWith(algorithm HashAlgorithm, content []byte) Hash {
+	if algorithm == HashBLAKE3 {
+		hash := blake3.Sum256(content)
+		return Hash(hex.EncodeToString(hash[:]))
+	}
 	hash := sha256.Sum256(content)
 	return Hash(hex.EncodeToString(hash[:]))
 }
 
 // ComputeObjectHash computes hash for a typed object
 func (h *Hasher) ComputeObjectHash(objType ObjectType, content []byte) Hash {
+	return objectHash(h.algorithm, objType, content)
+}
+
+func objectHash(algorithm HashAlgorithm, objType ObjectType, content []byte) Hash {
 	// Create a canonical representation: type + size + content
 	header := fmt.Sprintf("%s %d\x00", objType, len(content))
 	fullContent := append([]byte(header), content...)
-	return h.ComputeHash(fullContent)
+	return hashWith(algorithm, fullContent)
+}
+
+// ComputeObjectHashFrom computes the hash of a typed object whose size
+// bytes of content are read from r, without holding them in memory
+func (h *Hasher) ComputeObjectHashFrom(objType ObjectType, size int64, r io.Reader) (Hash, error) {
+	digest := newObjectDigest(h.algorithm, objType, size)
+	n, err := io.Copy(digest, r)
+	if err != nil {
+		return "", err
+	}
+	if n != size {
+		return "", fmt.Errorf("object content is %d bytes, expected %d", n, size)
+	}
+	return Hash(hex.EncodeToString(digest.Sum(nil))), nil
+}
+
+// newObjectDigest returns a running hash of an object with the header
+// already written; the content follows
+func newObjectDigest(algorithm HashAlgorithm, objType ObjectType, size int64) hash.Hash {
+	var digest hash.Hash = sha256.New()
+	if algorithm == HashBLAKE3 {
+		digest = blake3.New(32, nil)
+	}
+	fmt.Fprintf(digest, "%s %d\x00", objType, size)
+	return digest
 }
 
 // ComputeBlobHash computes hash for blob content
@@ -36,25 +122,23 @@ func (h *Hasher) ComputeBlobHash(content []byte) Hash {
 
 // ComputeTreeHash computes hash for tree object
 func (h *Hasher) ComputeTreeHash(tree *TreeObject) (Hash, error) {
-	// Serialize tree to canonical JSON format
-	data, err := json.Marshal(tree)
+	obj, err := h.CreateTreeObject(tree)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal tree: %w", err)
+		return "", err
 	}
-	return h.ComputeObjectHash(ObjectTypeTree, data), nil
+	return obj.Hash, nil
 }
 
 // ComputeCommitHash computes hash for commit object
 func (h *Hasher) ComputeCommitHash(commit *CommitObject) (Hash, error) {
-	// Serialize commit to canonical JSON format
-	data, err := json.Marshal(commit)
+	obj, err := h.CreateCommitObject(commit)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal commit: %w", err)
+		return "", err
 	}
-	return h.ComputeObjectHash(ObjectTypeCommit, data), nil
+	return obj.Hash, nil
 }
 
-// ValidateHash checks if a hash string is valid SHA-256
+// ValidateHash checks if a hash string is a valid 32-byte hex digest
 func (h *Hasher) ValidateHash(hash Hash) error {
 	if len(hash) != 64 {
 		return fmt.Errorf("invalid hash length: expected 64 characters, got %d", len(hash))
@@ -68,13 +152,23 @@ func (h *Hasher) ValidateHash(hash Hash) error {
 	return nil
 }
 
-// VerifyObject verifies that an object's content matches its hash
+// VerifyObject verifies that an object's content matches its hash, using
+// the algorithm and hash format the object records rather than the
+// hasher's own
 func (h *Hasher) VerifyObject(obj *Object) error {
 	if err := h.ValidateHash(obj.Hash); err != nil {
 		return fmt.Errorf("invalid object hash: %w", err)
 	}
 
-	expectedHash := h.ComputeObjectHash(obj.Type, obj.Content)
+	algorithm, err := ParseHashAlgorithm(string(obj.Algorithm))
+	if err != nil {
+		return fmt.Errorf("object %s: %w", obj.Hash, err)
+	}
+	hashed, err := hashedContent(obj.Format, obj.Type, obj.Content)
+	if err != nil {
+		return fmt.Errorf("object %s: %w", obj.Hash, err)
+	}
+	expectedHash := objectHash(algorithm, obj.Type, hashed)
 	if expectedHash != obj.Hash {
 		return fmt.Errorf("object hash mismatch: expected %s, got %s", expectedHash, obj.Hash)
 	}
@@ -82,36 +176,69 @@ func (h *Hasher) VerifyObject(obj *Object) error {
 	return nil
 }
 
-// CreateObject creates an object with computed hash
-func (h *Hasher) CreateObject(objType ObjectType, content []byte) *Object {
-	hash := h.ComputeObjectHash(objType, content)
-	return &Object{
-		Hash:    hash,
+// CreateObject creates an object with computed hash. Trees and commits are
+// hashed in the hasher's format, so their content must be their JSON.
+func (h *Hasher) CreateObject(objType ObjectType, content []byte) (*Object, error) {
+	hashed, err := hashedContent(h.format, objType, content)
+	if err != nil {
+		return nil, err
+	}
+	obj := &Object{
+		Hash:    objectHash(h.algorithm, objType, hashed),
 		Type:    objType,
 		Size:    int64(len(content)),
 		Content: content,
 	}
+	// SHA-256 objects are stored as they were before algorithms were
+	// recorded
+	if h.algorithm != HashSHA256 {
+		obj.Algorithm = h.algorithm
+	}
+	if objType != ObjectTypeBlob {
+		obj.Format = h.format
+	}
+	return obj, nil
 }
 
 // CreateBlobObject creates a blob object from content
 func (h *Hasher) CreateBlobObject(content []byte) *Object {
-	return h.CreateObject(ObjectTypeBlob, content)
+	obj := &Object{
+		Hash:    h.ComputeBlobHash(content),
+		Type:    ObjectTypeBlob,
+		Size:    int64(len(content)),
+		Content: content,
+	}
+	if h.algorithm != HashSHA256 {
+		obj.Algorithm = h.algorithm
+	}
+	return obj
 }
 
-// CreateTreeObject creates a tree object from tree structure
+// CreateTreeObject creates a tree object from tree structure. In the
+// canonical formats entries are stored in order of name, the order they
+// are hashed in.
 func (h *Hasher) CreateTreeObject(tree *TreeObject) (*Object, error) {
+	if h.format != HashFormatJSON {
+		tree = &TreeObject{Entries: sortedEntries(tree.Entries)}
+	}
 	data, err := json.Marshal(tree)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal tree: %w", err)
 	}
-	return h.CreateObject(ObjectTypeTree, data), nil
+	return h.CreateObject(ObjectTypeTree, data)
 }
 
-// CreateCommitObject creates a commit object from commit structure
+// CreateCommitObject creates a commit object from commit structure. In the
+// canonical formats the timestamp is stored in UTC, as it is hashed.
 func (h *Hasher) CreateCommitObject(commit *CommitObject) (*Object, error) {
+	if h.format != HashFormatJSON {
+		utc := *commit
+		utc.Timestamp = commit.Timestamp.UTC()
+		commit = &utc
+	}
 	data, err := json.Marshal(commit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal commit: %w", err)
 	}
-	return h.CreateObject(ObjectTypeCommit, data), nil
+	return h.CreateObject(ObjectTypeCommit, data)
 }
@@ -2,7 +2,10 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+
+	"github.com/nic/poon/poon-server/merge"
 )
 
 // ObjectStore defines the interface for storing and retrieving objects
@@ -49,6 +52,10 @@ type ContentAddressable interface {
 	// StoreBlob stores file content and returns its hash
 	StoreBlob(ctx context.Context, content []byte) (Hash, error)
 
+	// StoreBlobFrom stores content read from r without holding large
+	// content in memory and returns its hash and size
+	StoreBlobFrom(ctx context.Context, r io.Reader) (Hash, int64, error)
+
 	// StoreTree stores directory structure and returns its hash
 	StoreTree(ctx context.Context, tree *TreeObject) (Hash, error)
 
@@ -58,6 +65,14 @@ type ContentAddressable interface {
 	// GetBlob retrieves blob content
 	GetBlob(ctx context.Context, hash Hash) (*BlobObject, error)
 
+	// OpenBlob returns a reader for blob content and its size; the read
+	// that reaches the end fails if the content does not match the hash
+	OpenBlob(ctx context.Context, hash Hash) (io.ReadCloser, int64, error)
+
+	// OpenBlobRange returns a reader for part of a blob and the blob's size;
+	// only a read of the whole blob is checked against the hash
+	OpenBlobRange(ctx context.Context, hash Hash, offset, length int64) (io.ReadCloser, int64, error)
+
 	// GetTree retrieves tree structure
 	GetTree(ctx context.Context, hash Hash) (*TreeObject, error)
 
@@ -74,25 +89,189 @@ type Repository interface {
 	// ReadFile reads file content at a specific path in a version
 	ReadFile(ctx context.Context, version int64, path string) ([]byte, error)
 
+	// OpenFile returns a reader for a file's content at a version and its size
+	OpenFile(ctx context.Context, version int64, path string) (io.ReadCloser, int64, error)
+
+	// OpenFileRange returns a reader for part of a file at a version and the
+	// file's size
+	OpenFileRange(ctx context.Context, version int64, path string, offset, length int64) (io.ReadCloser, int64, error)
+
 	// ReadDirectory lists directory contents at a specific path in a version
 	ReadDirectory(ctx context.Context, version int64, path string) ([]*TreeEntry, error)
 
+	// GetEntry returns the tree entry for a file or directory at a specific path in a version
+	GetEntry(ctx context.Context, version int64, path string) (*TreeEntry, error)
+
+	// GetEntryInTree returns the tree entry at a path below a root tree
+	GetEntryInTree(ctx context.Context, rootTree Hash, path string) (*TreeEntry, error)
+
 	// CreateCommitFromFileSystem creates a commit from current file system state
 	CreateCommitFromFileSystem(ctx context.Context, rootPath string, author, message string) (*VersionInfo, error)
 
 	// ApplyPatch applies a patch and creates a new version
 	ApplyPatch(ctx context.Context, patch []byte, author, message string) (*VersionInfo, error)
 
+	// ApplyPatchWithOptions applies a patch with whitespace and line ending
+	// options and creates a new version
+	ApplyPatchWithOptions(ctx context.Context, patch []byte, author, message string, opts merge.ApplyOptions) (*VersionInfo, error)
+
+	// ApplyPatchAgainst is ApplyPatchWithOptions for a patch made against
+	// expectedVersion: it fails with a *PathChangedError, under the same
+	// lock as the commit, when a file the patch touches has changed since.
+	// An expectedVersion of 0 skips the check.
+	ApplyPatchAgainst(ctx context.Context, patch []byte, author, message string, opts merge.ApplyOptions, expectedVersion int64) (*VersionInfo, error)
+
+	// CheckPatchBase returns a *PathChangedError when a file the patch
+	// touches differs between expectedVersion and the current version
+	CheckPatchBase(ctx context.Context, patch []byte, expectedVersion int64) error
+
+	// PreviewPatch applies a patch to the current version in memory without
+	// creating a new version
+	PreviewPatch(ctx context.Context, patch []byte, opts merge.ApplyOptions) (*PatchPreview, error)
+
+	// DiffVersions lists the files that differ between two versions
+	DiffVersions(ctx context.Context, from, to int64, opts DiffOptions) ([]FileChange, error)
+
+	// ChangeStats lists the files that differ between two versions with the
+	// lines each inserted and deleted
+	ChangeStats(ctx context.Context, from, to int64) ([]FileStat, error)
+
+	// FileHistory lists the commits that changed a file, following renames
+	FileHistory(ctx context.Context, path string, limit int) ([]FileHistoryEntry, error)
+
+	// LastChanges returns the newest commit that changed each entry of a directory
+	LastChanges(ctx context.Context, version int64, path string) (map[string]FileHistoryEntry, error)
+
+	// CommitGraphEntry returns the commit graph entry of a version
+	CommitGraphEntry(ctx context.Context, version int64) (*CommitGraphEntry, error)
+
+	// IsAncestor reports whether one version is reachable from another
+	IsAncestor(ctx context.Context, ancestor, descendant int64) (bool, error)
+
+	// MergeBase returns the newest common ancestor of two versions
+	MergeBase(ctx context.Context, a, b int64) (int64, error)
+
+	// CreateBranch starts a branch at a commit
+	CreateBranch(ctx context.Context, name string, from Hash, createdBy string) (*Branch, error)
+
+	// GetBranch returns a branch other than main
+	GetBranch(ctx context.Context, name string) (*Branch, error)
+
+	// ListBranches returns the branches other than main, sorted by name
+	ListBranches(ctx context.Context) ([]*Branch, error)
+
+	// DeleteBranch removes a branch, leaving its commits to garbage collection
+	DeleteBranch(ctx context.Context, name string) error
+
+	// BranchHead returns the newest commit of a branch, or of main
+	BranchHead(ctx context.Context, name string) (Hash, error)
+
+	// ApplyPatchToBranch applies a patch as a new commit on a branch
+	ApplyPatchToBranch(ctx context.Context, name string, patch []byte, author, message string, opts merge.ApplyOptions) (*Branch, error)
+
+	// AmendBranch folds a patch, a new message or both into a branch's
+	// newest commit while it is unmerged
+	AmendBranch(ctx context.Context, name string, patch []byte, author, message string, opts merge.ApplyOptions) (*Branch, error)
+
+	// PreviewMerge returns what merging one branch into another would change
+	PreviewMerge(ctx context.Context, source, target string) (*MergePreview, error)
+
+	// MergeBranches merges one branch into another with a merge commit, or
+	// with one squashed commit
+	MergeBranches(ctx context.Context, source, target, author, message string, opts MergeOptions) (*MergeResult, error)
+
+	// PreviewCherryPick returns what applying a commit to a branch would change
+	PreviewCherryPick(ctx context.Context, commit Hash, target string) (*MergePreview, error)
+
+	// CherryPick applies the changes a commit made to a branch as a new commit
+	CherryPick(ctx context.Context, commit Hash, target, author string) (*CherryPickResult, error)
+
+	// CaseCollisions lists groups of paths that differ only in case
+	CaseCollisions(ctx context.Context, version int64, path string) ([][]string, error)
+
+	// Glob lists the paths matching a glob pattern
+	Glob(ctx context.Context, version int64, pattern string) ([]string, error)
+
+	// Attributes returns the path attributes set by .poonattributes
+	Attributes(ctx context.Context, version int64) (*AttributeRules, error)
+
+	// AttributesInTree returns the path attributes of a root tree
+	AttributesInTree(ctx context.Context, rootTree Hash) (*AttributeRules, error)
+
+	// ListTrash returns the files deleted by patches at or below a path
+	ListTrash(ctx context.Context, path string) ([]*TrashEntry, error)
+
+	// RestoreDeletedPath puts deleted files back in a new version
+	RestoreDeletedPath(ctx context.Context, path, author, message string) (*VersionInfo, []*TrashEntry, error)
+
+	// WriteTreeArchive writes a tree as a reproducible tar archive
+	WriteTreeArchive(ctx context.Context, hash Hash, w io.Writer) error
+
+	// WriteExportArchive writes a directory as a tar archive without its
+	// export-ignore files
+	WriteExportArchive(ctx context.Context, version int64, path string, w io.Writer) error
+
+	// WriteExportArchiveInTree writes a directory below a root tree as a tar
+	// archive without its export-ignore files
+	WriteExportArchiveInTree(ctx context.Context, rootTree Hash, path string, w io.Writer) error
+
+	// GarbageCollect deletes objects not reachable from any version
+	GarbageCollect(ctx context.Context, dryRun bool) (*GCResult, error)
+
+	// Fsck verifies stored objects and the objects referenced by versions
+	Fsck(ctx context.Context) (*FsckResult, error)
+
+	// CorruptObjects returns the objects reads found corrupt, most recently
+	// seen first, leaving out repaired ones unless includeRepaired is set
+	CorruptObjects(ctx context.Context, includeRepaired bool) ([]*CorruptObject, error)
+
+	// SetRepairSource sets where corrupt objects are fetched again from
+	SetRepairSource(source StorageBackend)
+
+	// Stats reports object counts and storage usage
+	Stats(ctx context.Context) (*RepositoryStats, error)
+
+	// Backup writes a snapshot of the repository to target
+	Backup(ctx context.Context, target StorageBackend, workspaces []byte) (*BackupResult, error)
+
+	// Restore replaces the repository contents with a snapshot from source
+	Restore(ctx context.Context, source StorageBackend, id string) (*BackupManifest, error)
+
+	// MigrateTo copies all objects and version metadata to another backend
+	MigrateTo(ctx context.Context, dst StorageBackend) (*MigrationResult, error)
+
+	// HashAlgorithm returns the algorithm new objects are hashed with
+	HashAlgorithm() HashAlgorithm
+
+	// HashFormat returns the format new trees and commits are hashed in
+	HashFormat() HashFormat
+
+	// Rehash rewrites all reachable objects with another hash algorithm and
+	// the current hash format
+	Rehash(ctx context.Context, algorithm HashAlgorithm) (*RehashResult, error)
+
+	// PruneHistory removes versions a retention policy does not keep and
+	// squashes the gaps into the retained versions' commits
+	PruneHistory(ctx context.Context, policy RetentionPolicy, dryRun bool) (*PruneResult, error)
+
 	// Close closes the repository and any underlying resources
 	Close() error
 }
 
+// ErrKeyNotFound is wrapped by the errors backends return for keys they do
+// not hold
+var ErrKeyNotFound = errors.New("key not found")
+
 // StorageBackend defines the low-level storage interface that can be implemented
 // by different backends (in-memory, S3, filesystem, etc.)
 type StorageBackend interface {
 	// Put stores data at the given key
 	Put(ctx context.Context, key string, data []byte) error
 
+	// PutStream stores everything read from r at the given key; backends
+	// that can avoid holding the whole value in memory do
+	PutStream(ctx context.Context, key string, r io.Reader) error
+
 	// Get retrieves data for the given key
 	Get(ctx context.Context, key string) ([]byte, error)
 
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLockHeld is returned when a path is locked by another owner
+var ErrLockHeld = errors.New("path is locked")
+
+// PathLock is an advisory lock on a file or directory in the monorepo
+type PathLock struct {
+	Path      string    `json:"path"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Expired reports whether the lock has lapsed
+func (l *PathLock) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// Covers reports whether the lock applies to path, either directly or
+// because the lock is on one of its parent directories
+func (l *PathLock) Covers(path string) bool {
+	path = strings.Trim(NormalizePath(path), "/")
+	return path == l.Path || l.Path == "" || strings.HasPrefix(path, l.Path+"/")
+}
+
+// LockManager stores advisory path locks in a storage backend under lock/
+type LockManager struct {
+	backend StorageBackend
+	mu      sync.Mutex
+}
+
+// NewLockManager creates a new lock manager
+func NewLockManager(backend StorageBackend) *LockManager {
+	return &LockManager{
+		backend: backend,
+	}
+}
+
+func lockKey(path string) string {
+	return "lock/" + strings.Trim(NormalizePath(path), "/")
+}
+
+func (lm *LockManager) get(ctx context.Context, path string) (*PathLock, error) {
+	exists, err := lm.backend.Exists(ctx, lockKey(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check lock: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := lm.backend.Get(ctx, lockKey(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock: %w", err)
+	}
+
+	var lock PathLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lock: %w", err)
+	}
+
+	return &lock, nil
+}
+
+// Acquire locks path for owner until ttl elapses. Re-locking a path already
+// held by the same owner extends the lock.
+func (lm *LockManager) Acquire(ctx context.Context, path, owner string, ttl time.Duration) (*PathLock, error) {
+	if owner == "" {
+		return nil, fmt.Errorf("lock owner is required")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lock ttl must be positive")
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	now := time.Now()
+	existing, err := lm.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &PathLock{
+		Path:      strings.Trim(NormalizePath(path), "/"),
+		Owner:     owner,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	// A lock on a directory covers everything below it, so overlapping locks
+	// held by someone else conflict in either direction
+	others, err := lm.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if other.Owner != owner && (other.Covers(lock.Path) || lock.Covers(other.Path)) {
+			return other, fmt.Errorf("%w by %s until %s", ErrLockHeld, other.Owner, other.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+
+	if existing != nil && !existing.Expired(now) && existing.Owner == owner {
+		lock.CreatedAt = existing.CreatedAt
+	}
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock: %w", err)
+	}
+
+	if err := lm.backend.Put(ctx, lockKey(path), data); err != nil {
+		return nil, fmt.Errorf("failed to store lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+// Release removes the lock on path. Only the owner may release a live lock
+// unless force is set; releasing an unlocked path is not an error.
+func (lm *LockManager) Release(ctx context.Context, path, owner string, force bool) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	existing, err := lm.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+
+	if !force && !existing.Expired(time.Now()) && existing.Owner != owner {
+		return fmt.Errorf("%w by %s", ErrLockHeld, existing.Owner)
+	}
+
+	if err := lm.backend.Delete(ctx, lockKey(path)); err != nil {
+		return fmt.Errorf("failed to delete lock: %w", err)
+	}
+
+	return nil
+}
+
+// List returns live locks whose path starts with prefix, sorted by path.
+// Expired locks are skipped.
+func (lm *LockManager) List(ctx context.Context, prefix string) ([]*PathLock, error) {
+	keys, err := lm.backend.List(ctx, "lock/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locks: %w", err)
+	}
+
+	prefix = strings.Trim(NormalizePath(prefix), "/")
+	now := time.Now()
+	var locks []*PathLock
+	for _, key := range keys {
+		lock, err := lm.get(ctx, strings.TrimPrefix(key, "lock/"))
+		if err != nil || lock == nil {
+			continue
+		}
+		if lock.Expired(now) || !strings.HasPrefix(lock.Path, prefix) {
+			continue
+		}
+		locks = append(locks, lock)
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Path < locks[j].Path
+	})
+
+	return locks, nil
+}
+
+// FindConflict returns a live lock held by someone other than owner that
+// covers path, or nil if path is free for owner to modify
+func (lm *LockManager) FindConflict(ctx context.Context, path, owner string) (*PathLock, error) {
+	locks, err := lm.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lock := range locks {
+		if lock.Owner != owner && lock.Covers(path) {
+			return lock, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GCResult summarizes a garbage collection run
+type GCResult struct {
+	Scanned    int   `json:"scanned"`    // Objects examined
+	Reachable  int   `json:"reachable"`  // Objects referenced by some version or branch
+	Removed    int   `json:"removed"`    // Unreachable objects deleted (or that would be, in a dry run)
+	BytesFreed int64 `json:"bytesFreed"` // Stored bytes of the removed objects
+}
+
+// FsckResult summarizes a repository consistency check
+type FsckResult struct {
+	ObjectsChecked  int      `json:"objectsChecked"`
+	VersionsChecked int      `json:"versionsChecked"`
+	Problems        []string `json:"problems"`
+
+	// HashAlgorithm is what new objects use; ObjectsByAlgorithm counts the
+	// stored objects per algorithm, so objects a rehash has not reached yet
+	// show up as a second entry
+	HashAlgorithm      HashAlgorithm         `json:"hashAlgorithm"`
+	ObjectsByAlgorithm map[HashAlgorithm]int `json:"objectsByAlgorithm"`
+
+	// HashFormat is what new trees and commits are hashed in;
+	// ObjectsByFormat counts the stored trees and commits per format
+	HashFormat      HashFormat         `json:"hashFormat"`
+	ObjectsByFormat map[HashFormat]int `json:"objectsByFormat"`
+}
+
+// RepositoryStats describes what the backend currently holds
+type RepositoryStats struct {
+	Objects        int   `json:"objects"`
+	Blobs          int   `json:"blobs"`
+	Trees          int   `json:"trees"`
+	Commits        int   `json:"commits"`
+	ObjectBytes    int64 `json:"objectBytes"` // Stored size of all objects
+	Versions       int   `json:"versions"`
+	CurrentVersion int64 `json:"currentVersion"`
+	Keys           int   `json:"keys"`       // All backend keys, including metadata
+	TotalBytes     int64 `json:"totalBytes"` // Stored size of all backend keys
+}
+
+// reachableObjects walks every version and branch and returns the set of
+// objects they reference. Missing or unreadable objects are reported through problem.
+func (r *RepositoryImpl) reachableObjects(ctx context.Context, problem func(string)) (map[Hash]bool, int, error) {
+	versions, err := r.ListVersions(ctx, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reachable := make(map[Hash]bool)
+
+	var walkTree func(hash Hash, path string)
+	walkTree = func(hash Hash, path string) {
+		if reachable[hash] {
+			return
+		}
+		reachable[hash] = true
+
+		tree, err := r.GetTree(ctx, hash)
+		if err != nil {
+			problem(fmt.Sprintf("tree %s (%s): %v", hash, path, err))
+			return
+		}
+
+		for _, entry := range tree.Entries {
+			entryPath := strings.TrimPrefix(path+"/"+entry.Name, "/")
+			if entry.Type == ObjectTypeTree {
+				walkTree(entry.Hash, entryPath)
+				continue
+			}
+			if reachable[entry.Hash] {
+				continue
+			}
+			reachable[entry.Hash] = true
+			if exists, err := r.ContentStore.Exists(ctx, entry.Hash); err != nil || !exists {
+				problem(fmt.Sprintf("blob %s (%s) is missing", entry.Hash, entryPath))
+			}
+		}
+	}
+
+	// walkCommits follows every parent, so the branch commits a merge
+	// brought in stay reachable with it
+	walkCommits := func(head Hash, from string) {
+		pending := []Hash{head}
+		for len(pending) > 0 {
+			hash := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if reachable[hash] {
+				continue
+			}
+			reachable[hash] = true
+
+			commit, err := r.GetCommit(ctx, hash)
+			if err != nil {
+				problem(fmt.Sprintf("commit %s (%s): %v", hash, from, err))
+				continue
+			}
+
+			walkTree(commit.RootTree, "")
+			pending = append(pending, commit.Parents()...)
+		}
+	}
+
+	for _, info := range versions {
+		walkCommits(info.CommitHash, fmt.Sprintf("version %d", info.Version))
+	}
+
+	branches, err := r.ListBranches(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, branch := range branches {
+		walkCommits(branch.CommitHash, "branch "+branch.Name)
+	}
+
+	return reachable, len(versions), nil
+}
+
+// GarbageCollect deletes objects that are not reachable from any version or
+// branch. With dryRun set it only reports what would be removed. Writes are
+// blocked while the collection runs so new objects are never collected
+// before the version or branch that references them exists.
+func (r *RepositoryImpl) GarbageCollect(ctx context.Context, dryRun bool) (*GCResult, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	reachable, _, err := r.reachableObjects(ctx, func(string) {})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk versions: %w", err)
+	}
+
+	hashes, err := r.ContentStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GCResult{Scanned: len(hashes)}
+	for i, hash := range hashes {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		reportProgress(ctx, i, len(hashes))
+		if reachable[hash] {
+			result.Reachable++
+			continue
+		}
+
+		if size, err := keySize(ctx, r.ContentStore.backend, "objects/"+string(hash)); err == nil {
+			result.BytesFreed += size
+		}
+		result.Removed++
+
+		if !dryRun {
+			if err := r.ContentStore.Delete(ctx, hash); err != nil {
+				return result, fmt.Errorf("failed to delete object %s: %w", hash, err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Fsck verifies every stored object against its hash and checks that all
+// objects referenced by versions exist
+func (r *RepositoryImpl) Fsck(ctx context.Context) (*FsckResult, error) {
+	result := &FsckResult{
+		Problems:           []string{},
+		HashAlgorithm:      r.HashAlgorithm(),
+		ObjectsByAlgorithm: make(map[HashAlgorithm]int),
+		HashFormat:         r.HashFormat(),
+		ObjectsByFormat:    make(map[HashFormat]int),
+	}
+	problem := func(p string) {
+		result.Problems = append(result.Problems, p)
+	}
+
+	hashes, err := r.ContentStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, hash := range hashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		reportProgress(ctx, i, len(hashes))
+		result.ObjectsChecked++
+		// Large objects are streamed through their hash
+		obj, err := r.ContentStore.verify(ctx, hash)
+		if err != nil {
+			problem(fmt.Sprintf("object %s: %v", hash, err))
+			continue
+		}
+		result.ObjectsByAlgorithm[objectAlgorithm(obj)]++
+		if obj.Type != ObjectTypeBlob {
+			result.ObjectsByFormat[obj.Format]++
+		}
+	}
+
+	_, versions, err := r.reachableObjects(ctx, problem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk versions: %w", err)
+	}
+	result.VersionsChecked = versions
+
+	return result, nil
+}
+
+// Stats reports object counts and storage usage
+func (r *RepositoryImpl) Stats(ctx context.Context) (*RepositoryStats, error) {
+	backend := r.ContentStore.backend
+	keys, err := backend.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	stats := &RepositoryStats{Keys: len(keys)}
+	for _, key := range keys {
+		size, err := keySize(ctx, backend, key)
+		if err != nil {
+			continue
+		}
+		stats.TotalBytes += size
+
+		switch {
+		case strings.HasPrefix(key, "objects/"):
+			stats.Objects++
+			stats.ObjectBytes += size
+			obj, content, err := r.ContentStore.openObject(ctx, Hash(strings.TrimPrefix(key, "objects/")))
+			if err != nil {
+				continue
+			}
+			content.Close()
+			switch obj.Type {
+			case ObjectTypeBlob:
+				stats.Blobs++
+			case ObjectTypeTree:
+				stats.Trees++
+			case ObjectTypeCommit:
+				stats.Commits++
+			}
+		case strings.HasPrefix(key, "version/info/"):
+			stats.Versions++
+		}
+	}
+
+	stats.CurrentVersion, err = r.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
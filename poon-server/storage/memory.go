@@ -21,6 +21,18 @@ func NewMemoryBackend() *MemoryBackend {
 	}
 }
 
+// Usage returns how many keys the backend holds and the bytes of their
+// data, which is the memory the repository's content takes
+func (m *MemoryBackend) Usage() (keys int, bytes int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, data := range m.data {
+		bytes += int64(len(data))
+	}
+	return len(m.data), bytes
+}
+
 // Put stores data at the given key
 func (m *MemoryBackend) Put(ctx context.Context, key string, data []byte) error {
 	m.mu.Lock()
@@ -34,6 +46,15 @@ func (m *MemoryBackend) Put(ctx context.Context, key string, data []byte) error
 	return nil
 }
 
+// PutStream stores everything read from r at the given key
+func (m *MemoryBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return m.Put(ctx, key, data)
+}
+
 // Get retrieves data for the given key
 func (m *MemoryBackend) Get(ctx context.Context, key string) ([]byte, error) {
 	m.mu.RLock()
@@ -41,7 +62,7 @@ func (m *MemoryBackend) Get(ctx context.Context, key string) ([]byte, error) {
 
 	data, exists := m.data[key]
 	if !exists {
-		return nil, fmt.Errorf("key not found: %s", key)
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	}
 
 	// Return a copy to avoid external modifications
@@ -65,7 +86,7 @@ func (m *MemoryBackend) Delete(ctx context.Context, key string) error {
 	defer m.mu.Unlock()
 
 	if _, exists := m.data[key]; !exists {
-		return fmt.Errorf("key not found: %s", key)
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	}
 
 	delete(m.data, key)
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// MigrationResult summarizes a copy from one backend to another
+type MigrationResult struct {
+	Objects         int   `json:"objects"`         // Objects in the source
+	ObjectsCopied   int   `json:"objectsCopied"`   // Objects the destination did not have yet
+	Metadata        int   `json:"metadata"`        // Non-object keys copied
+	MetadataRemoved int   `json:"metadataRemoved"` // Stale non-object keys removed from the destination
+	BytesCopied     int64 `json:"bytesCopied"`
+}
+
+// copyObjects copies objects missing from dst and verifies each copy against
+// its hash. Objects already present are checked but not rewritten, which is
+// what lets an interrupted migration resume where it stopped.
+func copyObjects(ctx context.Context, src, dst StorageBackend, result *MigrationResult) error {
+	keys, err := src.List(ctx, "objects/")
+	if err != nil {
+		return fmt.Errorf("failed to list source objects: %w", err)
+	}
+
+	verifier := NewContentStore(dst)
+	for i, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		reportProgress(ctx, i, len(keys))
+		hash := Hash(strings.TrimPrefix(key, "objects/"))
+
+		exists, err := dst.Exists(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", key, err)
+		}
+		if exists {
+			if _, err := verifier.verify(ctx, hash); err == nil {
+				continue
+			}
+			// A damaged or partial copy is rewritten below
+		}
+
+		size, err := copyKey(ctx, src, dst, key)
+		if err != nil {
+			return err
+		}
+		if _, err := verifier.verify(ctx, hash); err != nil {
+			return fmt.Errorf("verification failed for %s: %w", key, err)
+		}
+
+		result.ObjectsCopied++
+		result.BytesCopied += size
+	}
+
+	result.Objects = len(keys)
+	return nil
+}
+
+// MigrateTo copies every object and all version metadata to dst. Objects are
+// copied and verified in a first pass while the repository keeps serving
+// writes, with the objects written meanwhile journaled. Writes are then
+// blocked only while the journaled objects and the metadata are copied;
+// they are verified once writes resume, since objects never change and the
+// metadata is compared with what was copied. dst is an exact, verified copy
+// when MigrateTo returns. Running it again after a failure only copies what
+// is still missing. Cached archives are not copied; dst rebuilds its own.
+func (r *RepositoryImpl) MigrateTo(ctx context.Context, dst StorageBackend) (*MigrationResult, error) {
+	src := r.ContentStore.backend
+	result := &MigrationResult{}
+
+	r.ContentStore.startJournal()
+	if err := copyObjects(ctx, src, dst, result); err != nil {
+		r.ContentStore.stopJournal()
+		return result, err
+	}
+
+	written, digests, err := r.copyChanges(ctx, dst, result)
+	if err != nil {
+		return result, err
+	}
+
+	verifier := NewContentStore(dst)
+	for _, hash := range written {
+		if _, err := verifier.verify(ctx, hash); err != nil {
+			return result, fmt.Errorf("verification failed for objects/%s: %w", hash, err)
+		}
+	}
+	for key, digest := range digests {
+		copied, err := dst.Get(ctx, key)
+		if err != nil || sha256.Sum256(copied) != digest {
+			return result, fmt.Errorf("verification failed for %s", key)
+		}
+	}
+	return result, nil
+}
+
+// copyChanges copies, with writes blocked, the objects written since the
+// journal started and all metadata, and removes stale metadata from dst. It
+// returns the objects copied and a digest of each metadata value for
+// verification.
+func (r *RepositoryImpl) copyChanges(ctx context.Context, dst StorageBackend, result *MigrationResult) ([]Hash, map[string][sha256.Size]byte, error) {
+	src := r.ContentStore.backend
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	written := r.ContentStore.stopJournal()
+	for _, hash := range written {
+		// Objects the first pass listed are already copied and verified
+		key := "objects/" + string(hash)
+		if exists, err := dst.Exists(ctx, key); err == nil && exists {
+			continue
+		}
+		size, err := copyKey(ctx, src, dst, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Objects++
+		result.ObjectsCopied++
+		result.BytesCopied += size
+	}
+
+	keys, err := src.List(ctx, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list source keys: %w", err)
+	}
+
+	digests := make(map[string][sha256.Size]byte)
+	for _, key := range keys {
+		if strings.HasPrefix(key, "objects/") || isCacheKey(key) {
+			continue
+		}
+
+		data, err := src.Get(ctx, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if err := dst.Put(ctx, key, data); err != nil {
+			return nil, nil, fmt.Errorf("failed to write %s: %w", key, err)
+		}
+		digests[key] = sha256.Sum256(data)
+
+		result.Metadata++
+		result.BytesCopied += int64(len(data))
+	}
+
+	// Metadata left over from an earlier run (a released lock, say) must not
+	// reappear after the switch
+	existing, err := dst.List(ctx, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list destination keys: %w", err)
+	}
+	for _, key := range existing {
+		if _, ok := digests[key]; ok || strings.HasPrefix(key, "objects/") {
+			continue
+		}
+		if err := dst.Delete(ctx, key); err != nil {
+			return nil, nil, fmt.Errorf("failed to remove stale %s: %w", key, err)
+		}
+		result.MetadataRemoved++
+	}
+
+	return written, digests, nil
+}
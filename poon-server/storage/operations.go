@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OperationStatus is where a long-running operation stands
+type OperationStatus string
+
+const (
+	OperationRunning   OperationStatus = "running"
+	OperationSucceeded OperationStatus = "succeeded"
+	OperationFailed    OperationStatus = "failed"
+	OperationCancelled OperationStatus = "cancelled"
+)
+
+// Operation records work a call started and returned from before it was
+// done, such as a garbage collection, so clients can follow it and read its
+// outcome later, from another connection or after a restart
+type Operation struct {
+	ID            string            `json:"id"`
+	Kind          string            `json:"kind"`
+	Owner         string            `json:"owner,omitempty"` // User who started it
+	Admin         bool              `json:"admin,omitempty"` // Started through the admin API, and only visible there
+	Status        OperationStatus   `json:"status"`
+	ProgressDone  int64             `json:"progressDone,omitempty"`
+	ProgressTotal int64             `json:"progressTotal,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Result        map[string]string `json:"result,omitempty"`   // Such as the ID of the workspace created
+	Response      []byte            `json:"response,omitempty"` // Serialized response message of the call, once it succeeded
+	CreatedAt     time.Time         `json:"createdAt"`
+	FinishedAt    time.Time         `json:"finishedAt,omitempty"`
+}
+
+// Done reports whether the operation has stopped, whatever its outcome
+func (op *Operation) Done() bool {
+	return op.Status != OperationRunning
+}
+
+// OperationStore stores operations in a storage backend under operation/
+type OperationStore struct {
+	backend StorageBackend
+}
+
+// NewOperationStore creates a new operation store
+func NewOperationStore(backend StorageBackend) *OperationStore {
+	return &OperationStore{
+		backend: backend,
+	}
+}
+
+func operationKey(id string) string {
+	return "operation/" + id
+}
+
+// Put stores op, replacing any earlier record of it
+func (o *OperationStore) Put(ctx context.Context, op *Operation) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to marshal operation: %w", err)
+	}
+	if err := o.backend.Put(ctx, operationKey(op.ID), data); err != nil {
+		return fmt.Errorf("failed to store operation: %w", err)
+	}
+	return nil
+}
+
+// Get returns the operation with the given ID, or nil if there is none
+func (o *OperationStore) Get(ctx context.Context, id string) (*Operation, error) {
+	if id == "" || strings.Contains(id, "/") {
+		return nil, nil
+	}
+	exists, err := o.backend.Exists(ctx, operationKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check operation: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := o.backend.Get(ctx, operationKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operation: %w", err)
+	}
+	var op Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal operation: %w", err)
+	}
+	return &op, nil
+}
+
+// List returns every stored operation, newest first. Unreadable records
+// are skipped.
+func (o *OperationStore) List(ctx context.Context) ([]*Operation, error) {
+	keys, err := o.backend.List(ctx, "operation/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations: %w", err)
+	}
+
+	var ops []*Operation
+	for _, key := range keys {
+		op, err := o.Get(ctx, strings.TrimPrefix(key, "operation/"))
+		if err != nil || op == nil {
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].CreatedAt.After(ops[j].CreatedAt)
+	})
+	return ops, nil
+}
+
+// Prune deletes the operations that finished before cutoff and returns how
+// many it deleted
+func (o *OperationStore) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	ops, err := o.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, op := range ops {
+		if !op.Done() || !op.FinishedAt.Before(cutoff) {
+			continue
+		}
+		if err := o.backend.Delete(ctx, operationKey(op.ID)); err != nil {
+			return pruned, fmt.Errorf("failed to delete operation %s: %w", op.ID, err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes long-running repository calls,
+// such as GarbageCollect, Fsck and MigrateTo, report how far they got to
+// report
+func WithProgress(ctx context.Context, report func(done, total int64)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// reportProgress passes done and total to the reporter ctx carries, if any
+func reportProgress(ctx context.Context, done, total int) {
+	if report, ok := ctx.Value(progressKey{}).(func(done, total int64)); ok {
+		report(int64(done), int64(total))
+	}
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrPathEscapesRoot is returned when a path, or a symlink along it, leads
+// outside the directory it must stay within
+var ErrPathEscapesRoot = errors.New("path escapes root")
+
+// NormalizePath returns path in Unicode NFC, the form names are stored in.
+// macOS file systems produce decomposed (NFD) names, which would otherwise not
+// match the same name committed from elsewhere.
+func NormalizePath(path string) string {
+	return norm.NFC.String(path)
+}
+
+// ErrCaseCollision is returned when a new path differs only in case from an
+// existing one. Case-insensitive file systems, the default on macOS and
+// Windows, cannot hold both.
+var ErrCaseCollision = errors.New("path differs only in case from an existing path")
+
+// FoldPath returns path case-folded, so paths that a case-insensitive file
+// system treats as the same name compare equal
+func FoldPath(path string) string {
+	return cases.Fold().String(NormalizePath(path))
+}
+
+// ResolveInRoot joins rel onto root and resolves every symlink along the way,
+// failing with ErrPathEscapesRoot if the result lies outside root. Components
+// that do not exist yet are allowed, so the result can be used to create files.
+func ResolveInRoot(root, rel string) (string, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root %s: %w", root, err)
+	}
+
+	clean := filepath.Clean(rel)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrPathEscapesRoot, rel)
+	}
+	if clean == "." {
+		return realRoot, nil
+	}
+
+	current := realRoot
+	parts := strings.Split(clean, string(filepath.Separator))
+	for i, part := range parts {
+		next := filepath.Join(current, part)
+		info, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			// Nothing below a missing directory can be a symlink
+			return filepath.Join(append([]string{next}, parts[i+1:]...)...), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to stat %s: %w", next, err)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := filepath.EvalSymlinks(next)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve symlink %s: %w", next, err)
+			}
+			if !isWithin(realRoot, target) {
+				return "", fmt.Errorf("%w: %s links to %s", ErrPathEscapesRoot, rel, target)
+			}
+			next = target
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// isWithin reports whether path is root or lies below it. Both must already
+// be free of symlinks.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// CaseCollisions returns the groups of paths at or below path, in the given
+// version, that differ only in case. Each group and the list of groups are
+// sorted.
+func (r *RepositoryImpl) CaseCollisions(ctx context.Context, version int64, path string) ([][]string, error) {
+	entry, err := r.GetEntry(ctx, version, path)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != ObjectTypeTree {
+		return nil, nil
+	}
+
+	var groups [][]string
+	if err := r.caseCollisions(ctx, entry.Hash, strings.Join(splitPath(path), "/"), &groups); err != nil {
+		return nil, err
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups, nil
+}
+
+func (r *RepositoryImpl) caseCollisions(ctx context.Context, treeHash Hash, dir string, groups *[][]string) error {
+	tree, err := r.getTree(ctx, treeHash)
+	if err != nil {
+		return fmt.Errorf("failed to read tree: %w", err)
+	}
+
+	byFold := make(map[string][]string, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		path := entry.Name
+		if dir != "" {
+			path = dir + "/" + entry.Name
+		}
+		folded := FoldPath(entry.Name)
+		byFold[folded] = append(byFold[folded], path)
+
+		if entry.Type == ObjectTypeTree {
+			if err := r.caseCollisions(ctx, entry.Hash, path, groups); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, paths := range byFold {
+		if len(paths) > 1 {
+			sort.Strings(paths)
+			*groups = append(*groups, paths)
+		}
+	}
+	return nil
+}
+
+// caseCollision returns the existing path that path would collide with on a
+// case-insensitive file system, or "" if there is none. Only the components
+// up to the first one that differs in case are returned.
+func (r *RepositoryImpl) caseCollision(ctx context.Context, rootTreeHash Hash, path string) (string, error) {
+	treeHash := rootTreeHash
+	var existing []string
+	for _, part := range splitPath(path) {
+		tree, err := r.getTree(ctx, treeHash)
+		if err != nil {
+			return "", fmt.Errorf("failed to read tree: %w", err)
+		}
+
+		var exact, folded *TreeEntry
+		for i := range tree.Entries {
+			entry := &tree.Entries[i]
+			if entry.Name == part {
+				exact = entry
+				break
+			}
+			if folded == nil && FoldPath(entry.Name) == FoldPath(part) {
+				folded = entry
+			}
+		}
+
+		switch {
+		case exact != nil && exact.Type == ObjectTypeTree:
+			existing = append(existing, exact.Name)
+			treeHash = exact.Hash
+		case exact != nil:
+			return "", nil
+		case folded != nil:
+			return strings.Join(append(existing, folded.Name), "/"), nil
+		default:
+			return "", nil // Everything below a new directory is new too
+		}
+	}
+	return "", nil
+}
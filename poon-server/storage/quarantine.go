@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrCorruptObject is wrapped by the errors of reads that found a stored
+// object not matching its hash
+var ErrCorruptObject = errors.New("corrupt object")
+
+// quarantinePrefix holds a CorruptObject record for each object that has
+// failed verification
+const quarantinePrefix = "quarantine/"
+
+// corruptError marks a verification failure of a stored object; its
+// message is the failure's own
+type corruptError struct {
+	err error
+}
+
+func (e *corruptError) Error() string        { return e.err.Error() }
+func (e *corruptError) Unwrap() error        { return e.err }
+func (e *corruptError) Is(target error) bool { return target == ErrCorruptObject }
+
+func corrupt(err error) error {
+	return &corruptError{err: err}
+}
+
+// CorruptObject records an object that failed verification when it was
+// read, and whether it was repaired
+type CorruptObject struct {
+	Hash        Hash       `json:"hash"`
+	DetectedAt  time.Time  `json:"detectedAt"`
+	LastSeenAt  time.Time  `json:"lastSeenAt"`
+	Detections  int        `json:"detections"`
+	Error       string     `json:"error"`                 // The latest failure
+	RepairedAt  *time.Time `json:"repairedAt,omitempty"`  // When a good copy replaced it, unless it failed again since
+	RepairError string     `json:"repairError,omitempty"` // Why the latest repair failed
+}
+
+// SetRepairSource makes reads that find a corrupt object fetch it from
+// source, such as a backup destination or a replica, which holds objects
+// under the same keys. A copy that verifies replaces the damaged object.
+// Nil turns repair off.
+func (cs *ContentStore) SetRepairSource(source StorageBackend) {
+	if source == nil {
+		cs.repairSource.Store(nil)
+		return
+	}
+	// The source's store neither records nor repairs its own corruption
+	store := &ContentStore{backend: source, isRepairSource: true}
+	store.hasher.Store(cs.hasher.Load())
+	cs.repairSource.Store(store)
+}
+
+// quarantine records that the object at hash failed verification with
+// cause and tries to repair it. It reports whether the object was
+// repaired, so that the read can be retried.
+func (cs *ContentStore) quarantine(ctx context.Context, hash Hash, cause error) bool {
+	if cs.isRepairSource {
+		return false
+	}
+	cs.quarantineMu.Lock()
+	defer cs.quarantineMu.Unlock()
+	log.Printf("Warning: object %s failed verification: %v", hash, cause)
+
+	now := time.Now().UTC()
+	record := &CorruptObject{Hash: hash, DetectedAt: now}
+	if data, err := cs.backend.Get(ctx, quarantinePrefix+string(hash)); err == nil {
+		if err := json.Unmarshal(data, record); err != nil {
+			log.Printf("Warning: replacing unreadable quarantine record of %s: %v", hash, err)
+			record = &CorruptObject{Hash: hash, DetectedAt: now}
+		}
+	}
+	record.LastSeenAt = now
+	record.Detections++
+	record.Error = cause.Error()
+	record.RepairedAt, record.RepairError = nil, ""
+
+	err := cs.repair(ctx, hash)
+	if err == nil {
+		record.RepairedAt = &now
+		log.Printf("Repaired object %s from the repair source", hash)
+	} else {
+		record.RepairError = err.Error()
+		log.Printf("Warning: object %s was not repaired: %v", hash, err)
+	}
+
+	if data, merr := json.Marshal(record); merr != nil {
+		log.Printf("Warning: failed to marshal quarantine record of %s: %v", hash, merr)
+	} else if perr := cs.backend.Put(ctx, quarantinePrefix+string(hash), data); perr != nil {
+		log.Printf("Warning: failed to record corrupt object %s: %v", hash, perr)
+	}
+	return err == nil
+}
+
+// repair replaces the object at hash with the repair source's copy once
+// that copy verifies
+func (cs *ContentStore) repair(ctx context.Context, hash Hash) error {
+	source := cs.repairSource.Load()
+	if source == nil {
+		return fmt.Errorf("no repair source is configured")
+	}
+	if _, err := source.verify(ctx, hash); err != nil {
+		return fmt.Errorf("repair source: %w", err)
+	}
+	if _, err := copyKey(ctx, source.backend, cs.backend, "objects/"+string(hash)); err != nil {
+		return err
+	}
+	cs.written(hash)
+	return nil
+}
+
+// CorruptObjects returns the objects that have failed verification, most
+// recently seen first. Repaired objects are left out unless
+// includeRepaired is set.
+func (cs *ContentStore) CorruptObjects(ctx context.Context, includeRepaired bool) ([]*CorruptObject, error) {
+	keys, err := cs.backend.List(ctx, quarantinePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined objects: %w", err)
+	}
+
+	records := make([]*CorruptObject, 0, len(keys))
+	for _, key := range keys {
+		data, err := cs.backend.Get(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read quarantine record: %w", err)
+		}
+		record := &CorruptObject{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal quarantine record %s: %w", strings.TrimPrefix(key, quarantinePrefix), err)
+		}
+		if record.RepairedAt != nil && !includeRepaired {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].LastSeenAt.After(records[j].LastSeenAt)
+	})
+	return records, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// QueueRecord is a merge queue entry as stored, so the queue survives a
+// restart. State is the name of the entry's pb.QueueEntryState.
+type QueueRecord struct {
+	ID      string `json:"id"`
+	Author  string `json:"author"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	Patch   []byte `json:"patch,omitempty"`
+
+	IgnoreWhitespace        bool  `json:"ignoreWhitespace,omitempty"`
+	NormalizeLineEndings    bool  `json:"normalizeLineEndings,omitempty"`
+	PreserveTrailingNewline bool  `json:"preserveTrailingNewline,omitempty"`
+	ExpectedVersion         int64 `json:"expectedVersion,omitempty"`
+
+	Source string `json:"source,omitempty"` // Branch merged into main
+	Squash bool   `json:"squash,omitempty"`
+	Pick   Hash   `json:"pick,omitempty"` // Commit cherry-picked onto main
+
+	State         string    `json:"state"`
+	StateMessage  string    `json:"stateMessage"`
+	BaseVersion   int64     `json:"baseVersion,omitempty"`
+	LandedVersion int64     `json:"landedVersion,omitempty"`
+	CommitHash    string    `json:"commitHash,omitempty"`
+	DetailsURL    string    `json:"detailsURL,omitempty"`
+	SubmittedAt   time.Time `json:"submittedAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// Landing is set just before the entry is committed to main, so after
+	// a crash the queue can tell whether the version was created
+	Landing bool `json:"landing,omitempty"`
+}
+
+// QueueStore stores merge queue entries in a storage backend under queue/
+type QueueStore struct {
+	backend StorageBackend
+}
+
+// NewQueueStore creates a new queue store
+func NewQueueStore(backend StorageBackend) *QueueStore {
+	return &QueueStore{
+		backend: backend,
+	}
+}
+
+func queueKey(id string) string {
+	return "queue/" + id
+}
+
+// Put stores record, replacing any earlier record of the entry
+func (q *QueueStore) Put(ctx context.Context, record *QueueRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue entry: %w", err)
+	}
+	if err := q.backend.Put(ctx, queueKey(record.ID), data); err != nil {
+		return fmt.Errorf("failed to store queue entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an entry's record
+func (q *QueueStore) Delete(ctx context.Context, id string) error {
+	if err := q.backend.Delete(ctx, queueKey(id)); err != nil {
+		return fmt.Errorf("failed to delete queue entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// List returns every stored entry in submission order
+func (q *QueueStore) List(ctx context.Context) ([]*QueueRecord, error) {
+	keys, err := q.backend.List(ctx, "queue/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue entries: %w", err)
+	}
+
+	var records []*QueueRecord
+	for _, key := range keys {
+		data, err := q.backend.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		var record QueueRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+		}
+		if record.ID != strings.TrimPrefix(key, "queue/") {
+			return nil, fmt.Errorf("%s holds entry %s", key, record.ID)
+		}
+		records = append(records, &record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].SubmittedAt.Equal(records[j].SubmittedAt) {
+			return records[i].SubmittedAt.Before(records[j].SubmittedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
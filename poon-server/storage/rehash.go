@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// hashAlgorithmKey records the algorithm a repository hashes new objects
+// with. Repositories created before algorithms were recorded have no key and
+// use SHA-256.
+const hashAlgorithmKey = "config/hash-algorithm"
+
+// hashFormatKey records the format a repository hashes new trees and
+// commits in. Repositories created before formats were recorded have no key
+// and use HashFormatJSON.
+const hashFormatKey = "config/hash-format"
+
+// RehashResult summarizes a rewrite of the repository to another algorithm
+// or hash format
+type RehashResult struct {
+	Algorithm    HashAlgorithm `json:"algorithm"`
+	Format       HashFormat    `json:"format"`
+	Objects      int           `json:"objects"`      // Objects written with the new algorithm or format
+	Unchanged    int           `json:"unchanged"`    // Objects that already used both
+	Versions     int           `json:"versions"`     // Versions pointed at rehashed commits
+	TrashEntries int           `json:"trashEntries"` // Trash entries pointed at rehashed blobs
+	Branches     int           `json:"branches"`     // Branches pointed at rehashed commits
+}
+
+// NewRepositoryWithHash opens a repository that hashes new objects with
+// algorithm. An empty repository records algorithm and CurrentHashFormat;
+// one that already records an algorithm, or that predates the record, keeps
+// its own algorithm and format until it is rewritten with Rehash.
+// HashAlgorithm and HashFormat report which are in use.
+func NewRepositoryWithHash(ctx context.Context, backend StorageBackend, algorithm HashAlgorithm) (Repository, error) {
+	r := NewRepository(backend).(*RepositoryImpl)
+
+	recorded, err := recordedHashAlgorithm(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+	if recorded != "" {
+		return r, nil
+	}
+
+	current, err := r.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+	format := CurrentHashFormat
+	if current > 0 {
+		algorithm, format = HashSHA256, HashFormatJSON
+	}
+	// The format is recorded first: a repository whose algorithm is recorded
+	// is taken to record its format too, or to predate formats
+	if err := recordHashFormat(ctx, backend, format); err != nil {
+		return nil, err
+	}
+	if err := backend.Put(ctx, hashAlgorithmKey, []byte(algorithm)); err != nil {
+		return nil, fmt.Errorf("failed to record hash algorithm: %w", err)
+	}
+	r.ContentStore.hasher.Store(NewHasherFor(algorithm, format))
+	return r, nil
+}
+
+// recordedHashAlgorithm returns the algorithm a backend records, or "" when
+// it records none
+func recordedHashAlgorithm(ctx context.Context, backend StorageBackend) (HashAlgorithm, error) {
+	exists, err := backend.Exists(ctx, hashAlgorithmKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to check hash algorithm: %w", err)
+	}
+	if !exists {
+		return "", nil
+	}
+
+	data, err := backend.Get(ctx, hashAlgorithmKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read hash algorithm: %w", err)
+	}
+	return ParseHashAlgorithm(strings.TrimSpace(string(data)))
+}
+
+// recordedHashFormat returns the format a backend records, HashFormatJSON
+// when it records none
+func recordedHashFormat(ctx context.Context, backend StorageBackend) (HashFormat, error) {
+	exists, err := backend.Exists(ctx, hashFormatKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check hash format: %w", err)
+	}
+	if !exists {
+		return HashFormatJSON, nil
+	}
+
+	data, err := backend.Get(ctx, hashFormatKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read hash format: %w", err)
+	}
+	return ParseHashFormat(strings.TrimSpace(string(data)))
+}
+
+// recordHashFormat records the format new trees and commits are hashed in
+func recordHashFormat(ctx context.Context, backend StorageBackend, format HashFormat) error {
+	if err := backend.Put(ctx, hashFormatKey, []byte(strconv.Itoa(int(format)))); err != nil {
+		return fmt.Errorf("failed to record hash format: %w", err)
+	}
+	return nil
+}
+
+// useRecordedHashAlgorithm makes new objects use the algorithm and format
+// the backend records, SHA-256 and HashFormatJSON if it records none
+func (r *RepositoryImpl) useRecordedHashAlgorithm(ctx context.Context) error {
+	algorithm, err := recordedHashAlgorithm(ctx, r.ContentStore.backend)
+	if err != nil {
+		return err
+	}
+	if algorithm == "" {
+		algorithm = HashSHA256
+	}
+	format, err := recordedHashFormat(ctx, r.ContentStore.backend)
+	if err != nil {
+		return err
+	}
+	r.ContentStore.hasher.Store(NewHasherFor(algorithm, format))
+	return nil
+}
+
+// Rehash rewrites every object reachable from a version, a branch or the
+// trash with algorithm, and every tree and commit in CurrentHashFormat,
+// points versions, branches and trash entries at the new hashes and records
+// both for new objects. An empty algorithm keeps the repository's, migrating
+// only the format.
+// Version numbers and content are unchanged. The old objects stay in place
+// until the next garbage collection, and objects already written with
+// algorithm and format are reused, so an interrupted rewrite picks up where
+// it stopped. Writes are blocked while it runs.
+func (r *RepositoryImpl) Rehash(ctx context.Context, algorithm HashAlgorithm) (*RehashResult, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	defer r.resetRootTree()
+	defer r.graph.forget()
+
+	if algorithm == "" {
+		algorithm = r.HashAlgorithm()
+	}
+	rh := &rehasher{
+		store:  r.ContentStore,
+		hasher: NewHasherFor(algorithm, CurrentHashFormat),
+		done:   make(map[Hash]Hash),
+		result: &RehashResult{Algorithm: algorithm, Format: CurrentHashFormat},
+	}
+
+	versions, err := r.ListVersions(ctx, 0)
+	if err != nil {
+		return rh.result, err
+	}
+	// Oldest first, so each commit's parent is already rewritten
+	for i := len(versions) - 1; i >= 0; i-- {
+		info := versions[i]
+		hash, err := rh.rehash(ctx, info.CommitHash)
+		if err != nil {
+			return rh.result, fmt.Errorf("version %d: %w", info.Version, err)
+		}
+		if hash == info.CommitHash {
+			continue
+		}
+		if err := r.replaceCommit(ctx, info, hash); err != nil {
+			return rh.result, fmt.Errorf("version %d: %w", info.Version, err)
+		}
+		rh.result.Versions++
+	}
+
+	trash, err := r.ListTrash(ctx, "")
+	if err != nil {
+		return rh.result, err
+	}
+	for _, entry := range trash {
+		hash, err := rh.rehash(ctx, entry.Hash)
+		if err != nil {
+			return rh.result, fmt.Errorf("trash entry %s: %w", entry.Path, err)
+		}
+		if hash == entry.Hash {
+			continue
+		}
+		entry.Hash = hash
+		if err := r.recordDeletion(ctx, entry); err != nil {
+			return rh.result, err
+		}
+		rh.result.TrashEntries++
+	}
+
+	branches, err := r.ListBranches(ctx)
+	if err != nil {
+		return rh.result, err
+	}
+	for _, branch := range branches {
+		hash, err := rh.rehash(ctx, branch.CommitHash)
+		if err != nil {
+			return rh.result, fmt.Errorf("branch %s: %w", branch.Name, err)
+		}
+		if hash == branch.CommitHash {
+			continue
+		}
+		branch.CommitHash = hash
+		if err := r.storeBranch(ctx, branch); err != nil {
+			return rh.result, err
+		}
+		rh.result.Branches++
+	}
+
+	if err := recordHashFormat(ctx, r.ContentStore.backend, CurrentHashFormat); err != nil {
+		return rh.result, err
+	}
+	if err := r.ContentStore.backend.Put(ctx, hashAlgorithmKey, []byte(algorithm)); err != nil {
+		return rh.result, fmt.Errorf("failed to record hash algorithm: %w", err)
+	}
+	r.ContentStore.hasher.Store(rh.hasher)
+
+	return rh.result, nil
+}
+
+// objectAlgorithm returns the algorithm an object was hashed with
+func objectAlgorithm(obj *Object) HashAlgorithm {
+	if obj.Algorithm == "" {
+		return HashSHA256
+	}
+	return obj.Algorithm
+}
+
+// resetRootTree drops the cached root tree after its hash may have changed
+func (r *RepositoryImpl) resetRootTree() {
+	r.rootMu.Lock()
+	r.rootCommit, r.rootHash, r.rootTree = "", "", nil
+	r.rootMu.Unlock()
+}
+
+// rehasher rewrites objects with another algorithm or format, children
+// before the trees and commits that refer to them
+type rehasher struct {
+	store  *ContentStore
+	hasher *Hasher
+	done   map[Hash]Hash // New hash by old hash
+	result *RehashResult
+}
+
+func (rh *rehasher) rehash(ctx context.Context, hash Hash) (Hash, error) {
+	if rewritten, ok := rh.done[hash]; ok {
+		return rewritten, nil
+	}
+
+	obj, stream, err := rh.store.openObject(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	defer stream.Close()
+
+	// Blobs may be too large to hold, so they are rehashed as streams
+	if obj.Type == ObjectTypeBlob {
+		return rh.rehashBlob(ctx, obj, stream)
+	}
+
+	value, changed, err := rh.rewriteReferences(ctx, obj)
+	if err != nil {
+		return "", err
+	}
+
+	if !changed && objectAlgorithm(obj) == rh.hasher.Algorithm() && obj.Format == rh.hasher.Format() {
+		rh.done[hash] = hash
+		rh.result.Unchanged++
+		return hash, nil
+	}
+
+	// Trees and commits are built again rather than rehashed as they are
+	// stored, so they are normalized the way the format hashes them
+	var rewritten *Object
+	switch value := value.(type) {
+	case *TreeObject:
+		rewritten, err = rh.hasher.CreateTreeObject(value)
+	case *CommitObject:
+		rewritten, err = rh.hasher.CreateCommitObject(value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to rehash object %s: %w", hash, err)
+	}
+	if _, err := rh.store.Store(ctx, rewritten); err != nil {
+		return "", fmt.Errorf("failed to store rehashed object %s: %w", hash, err)
+	}
+	rh.done[hash] = rewritten.Hash
+	rh.result.Objects++
+	return rewritten.Hash, nil
+}
+
+func (rh *rehasher) rehashBlob(ctx context.Context, obj *Object, content io.Reader) (Hash, error) {
+	if objectAlgorithm(obj) == rh.hasher.Algorithm() {
+		rh.done[obj.Hash] = obj.Hash
+		rh.result.Unchanged++
+		return obj.Hash, nil
+	}
+
+	rewritten, _, err := rh.store.storeBlobFrom(ctx, rh.hasher, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to store rehashed object %s: %w", obj.Hash, err)
+	}
+	rh.done[obj.Hash] = rewritten
+	rh.result.Objects++
+	return rewritten, nil
+}
+
+// rewriteReferences decodes a tree or commit and rehashes the objects it
+// refers to, reporting whether any of their hashes changed
+func (rh *rehasher) rewriteReferences(ctx context.Context, obj *Object) (any, bool, error) {
+	changed := false
+	rewrite := func(hash *Hash) error {
+		rewritten, err := rh.rehash(ctx, *hash)
+		if err != nil {
+			return err
+		}
+		if rewritten != *hash {
+			*hash, changed = rewritten, true
+		}
+		return nil
+	}
+
+	var value any
+	switch obj.Type {
+	case ObjectTypeTree:
+		var tree TreeObject
+		if err := json.Unmarshal(obj.Content, &tree); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal tree %s: %w", obj.Hash, err)
+		}
+		for i := range tree.Entries {
+			if err := rewrite(&tree.Entries[i].Hash); err != nil {
+				return nil, false, err
+			}
+		}
+		value = &tree
+	case ObjectTypeCommit:
+		var commit CommitObject
+		if err := json.Unmarshal(obj.Content, &commit); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal commit %s: %w", obj.Hash, err)
+		}
+		if err := rewrite(&commit.RootTree); err != nil {
+			return nil, false, err
+		}
+		if commit.Parent != nil {
+			if err := rewrite(commit.Parent); err != nil {
+				return nil, false, err
+			}
+		}
+		for i := range commit.MergeParents {
+			if err := rewrite(&commit.MergeParents[i]); err != nil {
+				return nil, false, err
+			}
+		}
+		value = &commit
+	default:
+		return nil, false, fmt.Errorf("object %s has no references: %s", obj.Hash, obj.Type)
+	}
+	return value, changed, nil
+}
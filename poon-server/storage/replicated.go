@@ -0,0 +1,723 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Bounds of the delay before a replica retries a write it failed to mirror
+const (
+	replicationMinBackoff = time.Second
+	replicationMaxBackoff = 30 * time.Second
+)
+
+const (
+	// replicationStateKey holds the replicationState in every backend. It
+	// is written to each backend directly and never mirrored.
+	replicationStateKey = "replication/state"
+
+	// replicationStateInterval is how often changed state is saved
+	replicationStateInterval = time.Second
+
+	// defaultReplicationMaxPending bounds the writes a replica may have
+	// waiting; past it they are dropped for a full resync
+	defaultReplicationMaxPending = 100000
+
+	// replicationPersistLimit is the most pending keys saved for a replica;
+	// a replica with more is saved as needing a full resync instead
+	replicationPersistLimit = 10000
+)
+
+// replicationState is what a ReplicatedBackend keeps across restarts. Each
+// backend gets a copy; the one with the highest epoch wins, so a failover
+// survives the loss of the old primary.
+type replicationState struct {
+	Active  string              `json:"active"`
+	Epoch   int64               `json:"epoch"`             // Raised by every failover
+	Clean   bool                `json:"clean"`             // Saved by Close; after a crash writes since the last save are unknown
+	Pending map[string][]string `json:"pending,omitempty"` // Keys each replica has yet to mirror, oldest first
+	Resync  []string            `json:"resync,omitempty"`  // Replicas due a full resync
+}
+
+// ReplicaStatus reports how far a backend of a ReplicatedBackend is behind
+// the active one
+type ReplicaStatus struct {
+	Name             string        `json:"name"`
+	Active           bool          `json:"active"`
+	Pending          int           `json:"pending"`          // Keys written to the active backend but not yet mirrored here
+	Lag              time.Duration `json:"lag"`              // Age of the oldest pending write
+	Replicated       int64         `json:"replicated"`       // Keys mirrored since startup
+	Failures         int64         `json:"failures"`         // Failed attempts since startup
+	LastError        string        `json:"lastError"`        // Of the latest failed attempt, cleared by a success
+	LastReplicatedAt time.Time     `json:"lastReplicatedAt"` // Zero before the first
+	Resync           bool          `json:"resync"`           // A full comparison with the active backend is due or running
+}
+
+// pendingWrite is a key waiting to be mirrored to a replica
+type pendingWrite struct {
+	seq   uint64    // Matches the key's latest entry in the queue
+	since time.Time // When the oldest unmirrored write to the key happened
+}
+
+type queuedKey struct {
+	key string
+	seq uint64
+}
+
+// replica is one backend of a ReplicatedBackend and the writes it has yet
+// to mirror
+type replica struct {
+	name    string
+	backend StorageBackend
+
+	mu         sync.Mutex
+	queue      []queuedKey
+	pending    map[string]pendingWrite
+	seq        uint64
+	resync     bool // Pending writes are unknown; compare every key instead
+	resyncing  bool // A resync is running
+	replicated int64
+	failures   int64
+	lastError  string
+	lastAt     time.Time
+	wake       chan struct{}
+}
+
+// enqueue records a write to key. Past maxPending writes the queue is
+// dropped and the replica resynced instead, so an unreachable replica does
+// not hold every write in memory.
+func (r *replica) enqueue(key string, maxPending int) {
+	r.mu.Lock()
+	if r.resync {
+		r.mu.Unlock()
+		return
+	}
+	if _, ok := r.pending[key]; !ok && maxPending > 0 && len(r.pending) >= maxPending {
+		log.Printf("Warning: %s has %d writes waiting; dropping them for a full resync", r.name, len(r.pending))
+		r.queue, r.pending, r.resync = nil, make(map[string]pendingWrite), true
+		r.mu.Unlock()
+		r.signal()
+		return
+	}
+	r.seq++
+	since := time.Now()
+	if p, ok := r.pending[key]; ok {
+		since = p.since
+	}
+	// A key written again moves to the back, so keys are mirrored in the
+	// order of their latest writes: version/current after its objects
+	r.pending[key] = pendingWrite{seq: r.seq, since: since}
+	r.queue = append(r.queue, queuedKey{key: key, seq: r.seq})
+	r.mu.Unlock()
+	r.signal()
+}
+
+func (r *replica) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// markResync drops the pending writes for a full resync
+func (r *replica) markResync() {
+	r.mu.Lock()
+	r.queue, r.pending, r.resync = nil, make(map[string]pendingWrite), true
+	r.mu.Unlock()
+	r.signal()
+}
+
+// startResync reports whether a resync is due, clearing the flag so writes
+// made from now on are queued again; those made before are found by the
+// resync's listing
+func (r *replica) startResync() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	due := r.resync
+	r.resync, r.resyncing = false, due
+	return due
+}
+
+// finishResync records the end of a resync, which is due again if it failed
+func (r *replica) finishResync(err error) {
+	r.mu.Lock()
+	r.resyncing = false
+	r.mu.Unlock()
+	if err != nil {
+		r.markResync()
+	}
+}
+
+// saved returns what the replica's state saves: its pending keys in queue
+// order, or nil and true when only a full resync can catch it up
+func (r *replica) saved() ([]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resync || r.resyncing || len(r.pending) > replicationPersistLimit {
+		return nil, true
+	}
+	keys := make([]string, 0, len(r.pending))
+	for _, q := range r.queue {
+		if p, ok := r.pending[q.key]; ok && p.seq == q.seq {
+			keys = append(keys, q.key)
+		}
+	}
+	return keys, false
+}
+
+// next returns the oldest pending key, or "" when there is none
+func (r *replica) next() (string, uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.queue) > 0 {
+		q := r.queue[0]
+		if p, ok := r.pending[q.key]; ok && p.seq == q.seq {
+			return q.key, q.seq
+		}
+		r.queue = r.queue[1:] // Superseded by a later write
+	}
+	return "", 0
+}
+
+// done records the outcome of mirroring key as of write seq
+func (r *replica) done(key string, seq uint64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failures++
+		r.lastError = err.Error()
+		return
+	}
+	r.replicated++
+	r.lastError = ""
+	r.lastAt = time.Now()
+	if p, ok := r.pending[key]; ok && p.seq == seq {
+		delete(r.pending, key)
+	}
+}
+
+// reset drops the pending writes
+func (r *replica) reset() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dropped := len(r.pending)
+	r.queue, r.pending, r.resync = nil, make(map[string]pendingWrite), false
+	return dropped
+}
+
+func (r *replica) status(active bool) ReplicaStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := ReplicaStatus{
+		Name:             r.name,
+		Active:           active,
+		Pending:          len(r.pending),
+		Replicated:       r.replicated,
+		Failures:         r.failures,
+		LastError:        r.lastError,
+		LastReplicatedAt: r.lastAt,
+		Resync:           r.resync || r.resyncing,
+	}
+	for _, p := range r.pending {
+		if lag := time.Since(p.since); lag > status.Lag {
+			status.Lag = lag
+		}
+	}
+	return status
+}
+
+// ReplicatedBackend writes to an active backend, the primary at first, and
+// mirrors every write to the other backends in the background, such as
+// buckets in other regions. Reads go to the active backend only.
+//
+// Mirroring copies a key's value as it is in the active backend when its
+// turn comes, so a key written many times is copied once. Replicas lag
+// behind by the pending writes. These are saved, with the active backend,
+// in every backend under replicationStateKey each second and on Close, and
+// picked up again on restart. A replica whose pending writes are not known
+// (after a crash, when too many piled up, or when it is new) is resynced in
+// full: every key is compared with the active backend.
+//
+// Failover makes a replica the active backend, for when the primary is
+// lost, and lasts across restarts. The old primary then becomes a replica
+// and receives the writes made while it was not active once it is
+// reachable again.
+type ReplicatedBackend struct {
+	replicas   []*replica
+	active     atomic.Int32
+	maxPending atomic.Int64
+
+	stateMu sync.Mutex // Serializes state saves and failovers
+	epoch   int64
+	dirty   atomic.Bool // Pending writes changed since the last save
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReplicatedBackend returns a backend that writes to primary and
+// mirrors to secondaries. Names identify the backends in statuses, Failover
+// and the saved state, primary first; they default to "primary" and
+// "replica-N". The state saved by an earlier run is loaded first, so a
+// failover made then is kept and unmirrored writes are picked up.
+func NewReplicatedBackend(primary StorageBackend, secondaries []StorageBackend, names ...string) *ReplicatedBackend {
+	b := &ReplicatedBackend{stop: make(chan struct{})}
+	b.maxPending.Store(defaultReplicationMaxPending)
+	for i, backend := range append([]StorageBackend{primary}, secondaries...) {
+		name := fmt.Sprintf("replica-%d", i)
+		if i == 0 {
+			name = "primary"
+		}
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		b.replicas = append(b.replicas, &replica{
+			name:    name,
+			backend: backend,
+			pending: make(map[string]pendingWrite),
+			wake:    make(chan struct{}, 1),
+		})
+	}
+	b.loadState()
+	if err := b.saveState(false); err != nil {
+		log.Printf("Warning: failed to save replication state: %v", err)
+	}
+
+	for _, r := range b.replicas {
+		b.wg.Add(1)
+		go b.mirror(r)
+	}
+	b.wg.Add(1)
+	go b.saveLoop()
+	return b
+}
+
+// SetMaxPending bounds the writes a replica may have waiting before they
+// are dropped for a full resync; 0 removes the bound
+func (b *ReplicatedBackend) SetMaxPending(n int) {
+	b.maxPending.Store(int64(n))
+}
+
+// loadState restores the newest state saved in any backend. Replicas
+// without a saved state, and all of them after a crash, are resynced.
+func (b *ReplicatedBackend) loadState() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var state *replicationState
+	hasState := make([]bool, len(b.replicas))
+	for i, r := range b.replicas {
+		data, err := r.backend.Get(ctx, replicationStateKey)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: failed to read replication state from %s: %v", r.name, err)
+			continue
+		}
+		var saved replicationState
+		if err := json.Unmarshal(data, &saved); err != nil {
+			log.Printf("Warning: ignoring replication state in %s: %v", r.name, err)
+			continue
+		}
+		hasState[i] = true
+		if state == nil || saved.Epoch > state.Epoch {
+			state = &saved
+		}
+	}
+
+	if state != nil {
+		b.epoch = state.Epoch
+		found := false
+		for i, r := range b.replicas {
+			if r.name == state.Active {
+				b.active.Store(int32(i))
+				found = true
+				log.Printf("Storage reads and writes %s, as saved in the replication state", r.name)
+			}
+		}
+		if !found {
+			log.Printf("Warning: the saved active backend %s is not configured; using %s", state.Active, b.replicas[0].name)
+		}
+	}
+
+	active := int(b.active.Load())
+	for i, r := range b.replicas {
+		if i == active {
+			continue
+		}
+		switch {
+		case state == nil || !hasState[i] || !state.Clean || slices.Contains(state.Resync, r.name):
+			r.resync = true
+		default:
+			for _, key := range state.Pending[r.name] {
+				r.enqueue(key, 0)
+			}
+		}
+		if r.resync {
+			log.Printf("Resyncing %s with %s in full", r.name, b.replicas[active].name)
+		}
+	}
+}
+
+// saveState writes the state to every backend, the active one first; only
+// a failure to save it there is returned. clean marks a save by Close.
+// Callers other than saveLoop and Close hold stateMu.
+func (b *ReplicatedBackend) saveState(clean bool) error {
+	active := int(b.active.Load())
+	state := replicationState{
+		Active:  b.replicas[active].name,
+		Epoch:   b.epoch,
+		Clean:   clean,
+		Pending: make(map[string][]string),
+	}
+	for i, r := range b.replicas {
+		if i == active {
+			continue
+		}
+		keys, resync := r.saved()
+		switch {
+		case resync:
+			state.Resync = append(state.Resync, r.name)
+		case len(keys) > 0:
+			state.Pending[r.name] = keys
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replication state: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := b.replicas[active].backend.Put(ctx, replicationStateKey, data); err != nil {
+		return fmt.Errorf("failed to save replication state in %s: %w", b.replicas[active].name, err)
+	}
+	// A replica that is down keeps an older copy, which the epoch outranks
+	for i, r := range b.replicas {
+		if i != active {
+			r.backend.Put(ctx, replicationStateKey, data)
+		}
+	}
+	return nil
+}
+
+// saveLoop saves the state each second while pending writes change
+func (b *ReplicatedBackend) saveLoop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(replicationStateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+		if !b.dirty.Swap(false) {
+			continue
+		}
+		b.stateMu.Lock()
+		err := b.saveState(false)
+		b.stateMu.Unlock()
+		if err != nil {
+			b.dirty.Store(true)
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// Active returns the backend reads and writes go to
+func (b *ReplicatedBackend) Active() StorageBackend {
+	return b.replicas[b.active.Load()].backend
+}
+
+// Status reports each backend, primary first
+func (b *ReplicatedBackend) Status() []ReplicaStatus {
+	active := int(b.active.Load())
+	statuses := make([]ReplicaStatus, len(b.replicas))
+	for i, r := range b.replicas {
+		statuses[i] = r.status(i == active)
+	}
+	return statuses
+}
+
+// Failover makes the backend called name the active one and saves that
+// in the state, so it lasts across restarts. A replica with pending writes
+// or a resync due lacks data the active backend has, so it is refused
+// unless force is set, which gives up on those writes; the old active
+// backend, which may then have writes the new one lacks, is resynced.
+func (b *ReplicatedBackend) Failover(name string, force bool) error {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	for i, r := range b.replicas {
+		if r.name != name {
+			continue
+		}
+		old := int(b.active.Load())
+		if old == i {
+			return nil
+		}
+		status := r.status(false)
+		if status.Pending > 0 || status.Resync {
+			if !force {
+				if status.Resync {
+					return fmt.Errorf("%s is due a full resync; wait for it to catch up or force the failover", name)
+				}
+				return fmt.Errorf("%s has %d writes not yet mirrored; wait for it to catch up or force the failover", name, status.Pending)
+			}
+			log.Printf("Warning: failing over to %s without %d writes it had not mirrored", name, r.reset())
+			b.replicas[old].markResync()
+		}
+
+		b.active.Store(int32(i))
+		b.epoch++
+		if err := b.saveState(false); err != nil {
+			b.epoch--
+			b.active.Store(int32(old))
+			return fmt.Errorf("failed to fail over to %s: %w", name, err)
+		}
+		log.Printf("Storage failed over to %s", name)
+		return nil
+	}
+	return fmt.Errorf("no backend named %q", name)
+}
+
+// Resync compares every key of the replica called name with the active
+// backend and copies those that differ, in the background, instead of
+// relying on its pending writes
+func (b *ReplicatedBackend) Resync(name string) error {
+	for i, r := range b.replicas {
+		if r.name != name {
+			continue
+		}
+		if int(b.active.Load()) == i {
+			return fmt.Errorf("%s is the active backend", name)
+		}
+		r.markResync()
+		b.dirty.Store(true)
+		log.Printf("Resyncing %s in full", name)
+		return nil
+	}
+	return fmt.Errorf("no backend named %q", name)
+}
+
+// written queues key for every backend but the active one
+func (b *ReplicatedBackend) written(key string) {
+	active := int(b.active.Load())
+	maxPending := int(b.maxPending.Load())
+	for i, r := range b.replicas {
+		if i != active {
+			r.enqueue(key, maxPending)
+		}
+	}
+	b.dirty.Store(true)
+}
+
+// mirror copies r's pending keys from the active backend until Close
+func (b *ReplicatedBackend) mirror(r *replica) {
+	defer b.wg.Done()
+	backoff := replicationMinBackoff
+	for {
+		if b.replicas[b.active.Load()] != r && r.startResync() {
+			err := b.resync(r)
+			r.finishResync(err)
+			if err == nil {
+				log.Printf("Resynced %s with %s", r.name, b.replicas[b.active.Load()].name)
+				b.dirty.Store(true)
+				backoff = replicationMinBackoff
+				continue
+			}
+			log.Printf("Warning: failed to resync %s (retrying in %s): %v", r.name, backoff, err)
+			select {
+			case <-b.stop:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, replicationMaxBackoff)
+			continue
+		}
+
+		key, seq := r.next()
+		if key == "" || b.replicas[b.active.Load()] == r {
+			select {
+			case <-b.stop:
+				return
+			case <-r.wake:
+				continue
+			case <-time.After(time.Second): // Notices failovers away from r
+				continue
+			}
+		}
+
+		err := b.copy(r, key)
+		r.done(key, seq, err)
+		if err == nil {
+			b.dirty.Store(true)
+			backoff = replicationMinBackoff
+			continue
+		}
+		log.Printf("Warning: failed to mirror %s to %s (retrying in %s): %v", key, r.name, backoff, err)
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, replicationMaxBackoff)
+	}
+}
+
+// resync makes r hold the keys the active backend does. Objects are
+// content-addressed, so one r already has is taken to match; every other
+// key is copied, and keys the active backend lacks are deleted.
+func (b *ReplicatedBackend) resync(r *replica) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-b.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	activeKeys, err := b.Active().List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list the active backend: %w", err)
+	}
+	replicaKeys, err := r.backend.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", r.name, err)
+	}
+	has := make(map[string]bool, len(replicaKeys))
+	for _, key := range replicaKeys {
+		has[key] = true
+	}
+
+	var keys []string
+	for _, key := range activeKeys {
+		if !(has[key] && strings.HasPrefix(key, "objects/")) {
+			keys = append(keys, key)
+		}
+		delete(has, key)
+	}
+	for key := range has {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if key == replicationStateKey {
+			continue
+		}
+		err := b.copy(r, key)
+		r.done(key, 0, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copy makes key in r what it is in the active backend
+func (b *ReplicatedBackend) copy(r *replica, key string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-b.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := b.Active().Stream(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		if err := r.backend.Delete(ctx, key); err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer stream.Close()
+	return r.backend.PutStream(ctx, key, stream)
+}
+
+// Put stores data at the given key
+func (b *ReplicatedBackend) Put(ctx context.Context, key string, data []byte) error {
+	if err := b.Active().Put(ctx, key, data); err != nil {
+		return err
+	}
+	b.written(key)
+	return nil
+}
+
+// PutStream stores everything read from r at the given key
+func (b *ReplicatedBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	if err := b.Active().PutStream(ctx, key, r); err != nil {
+		return err
+	}
+	b.written(key)
+	return nil
+}
+
+// Get retrieves data for the given key
+func (b *ReplicatedBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return b.Active().Get(ctx, key)
+}
+
+// Exists checks if a key exists
+func (b *ReplicatedBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return b.Active().Exists(ctx, key)
+}
+
+// Delete removes data for the given key
+func (b *ReplicatedBackend) Delete(ctx context.Context, key string) error {
+	if err := b.Active().Delete(ctx, key); err != nil {
+		return err
+	}
+	b.written(key)
+	return nil
+}
+
+// List returns all keys with the given prefix
+func (b *ReplicatedBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	return b.Active().List(ctx, prefix)
+}
+
+// Stream returns a reader for the given key
+func (b *ReplicatedBackend) Stream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.Active().Stream(ctx, key)
+}
+
+// Close stops mirroring, saves the writes not yet mirrored for the next
+// run and closes every backend
+func (b *ReplicatedBackend) Close() error {
+	close(b.stop)
+	b.wg.Wait()
+
+	var errs []error
+	b.stateMu.Lock()
+	if err := b.saveState(true); err != nil {
+		errs = append(errs, err)
+	}
+	b.stateMu.Unlock()
+	for _, r := range b.replicas {
+		if pending := r.status(false).Pending; pending > 0 {
+			log.Printf("%d writes are left to mirror to %s after a restart", pending, r.name)
+		}
+		if err := r.backend.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -2,11 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nic/poon/poon-server/merge"
@@ -16,37 +19,96 @@ import (
 type RepositoryImpl struct {
 	*ContentStore
 	*VersionManager
-	hasher *Hasher
+
+	// writeMu keeps commits from running during garbage collection and
+	// other maintenance that rewrites the repository
+	writeMu sync.RWMutex
+
+	// commitMu is held by a commit from reading the current version until
+	// it has created the next one, so each version's parent is the version
+	// before it and no commit is built on a version another one replaced
+	commitMu sync.Mutex
+
+	// The root tree of the most recently read commit. Objects are immutable,
+	// so this never goes stale; it is replaced when a newer commit is read.
+	rootMu     sync.RWMutex
+	rootCommit Hash
+	rootHash   Hash
+	rootTree   *TreeObject
+
+	// graph indexes the parents of every version's commit
+	graph *commitGraph
 }
 
-// NewRepository creates a new repository with the given backend
+// NewRepository creates a new repository with the given backend. New
+// objects use the hash algorithm the backend records, or SHA-256.
 func NewRepository(backend StorageBackend) Repository {
 	contentStore := NewContentStore(backend)
 	versionManager := NewVersionManager(backend)
 
-	return &RepositoryImpl{
+	r := &RepositoryImpl{
 		ContentStore:   contentStore,
 		VersionManager: versionManager,
-		hasher:         NewHasher(),
+		graph:          newCommitGraph(backend),
 	}
+	// Stored objects name their own algorithm, so falling back to SHA-256
+	// when the record cannot be read still leaves a consistent repository
+	_ = r.useRecordedHashAlgorithm(context.Background())
+	return r
 }
 
-// ReadFile reads file content at a specific path in a version
-func (r *RepositoryImpl) ReadFile(ctx context.Context, version int64, path string) ([]byte, error) {
-	// Get version info
+// rootTreeHash returns the root tree of a version's commit
+func (r *RepositoryImpl) rootTreeHash(ctx context.Context, version int64) (Hash, error) {
 	versionInfo, err := r.GetVersionInfo(ctx, version)
 	if err != nil {
-		return nil, fmt.Errorf("version %d not found: %w", version, err)
+		return "", fmt.Errorf("version %d not found: %w", version, err)
+	}
+
+	r.rootMu.RLock()
+	commitHash, rootHash := r.rootCommit, r.rootHash
+	r.rootMu.RUnlock()
+	if commitHash == versionInfo.CommitHash {
+		return rootHash, nil
 	}
 
-	// Get commit object
 	commit, err := r.GetCommit(ctx, versionInfo.CommitHash)
 	if err != nil {
-		return nil, fmt.Errorf("commit not found: %w", err)
+		return "", fmt.Errorf("commit not found: %w", err)
+	}
+
+	tree, err := r.GetTree(ctx, commit.RootTree)
+	if err != nil {
+		return "", fmt.Errorf("failed to read tree: %w", err)
+	}
+
+	r.rootMu.Lock()
+	r.rootCommit, r.rootHash, r.rootTree = versionInfo.CommitHash, commit.RootTree, tree
+	r.rootMu.Unlock()
+
+	return commit.RootTree, nil
+}
+
+// getTree is GetTree with the cached root tree short-circuited
+func (r *RepositoryImpl) getTree(ctx context.Context, hash Hash) (*TreeObject, error) {
+	r.rootMu.RLock()
+	tree := r.rootTree
+	cached := r.rootHash == hash
+	r.rootMu.RUnlock()
+	if cached && tree != nil {
+		return tree, nil
+	}
+	return r.GetTree(ctx, hash)
+}
+
+// ReadFile reads file content at a specific path in a version
+func (r *RepositoryImpl) ReadFile(ctx context.Context, version int64, path string) ([]byte, error) {
+	rootTree, err := r.rootTreeHash(ctx, version)
+	if err != nil {
+		return nil, err
 	}
 
 	// Navigate to file through tree structure
-	blobHash, err := r.findFileInTree(ctx, commit.RootTree, path)
+	blobHash, err := r.findFileInTree(ctx, rootTree, path)
 	if err != nil {
 		return nil, fmt.Errorf("file not found: %w", err)
 	}
@@ -60,42 +122,125 @@ func (r *RepositoryImpl) ReadFile(ctx context.Context, version int64, path strin
 	return blob.Content, nil
 }
 
-// ReadDirectory lists directory contents at a specific path in a version
-func (r *RepositoryImpl) ReadDirectory(ctx context.Context, version int64, path string) ([]*TreeEntry, error) {
-	// Get version info
-	versionInfo, err := r.GetVersionInfo(ctx, version)
+// OpenFile returns a reader for a file's content at a version and its size,
+// streaming large files instead of reading them into memory
+func (r *RepositoryImpl) OpenFile(ctx context.Context, version int64, path string) (io.ReadCloser, int64, error) {
+	rootTree, err := r.rootTreeHash(ctx, version)
 	if err != nil {
-		return nil, fmt.Errorf("version %d not found: %w", version, err)
+		return nil, 0, err
 	}
 
-	// Get commit object
-	commit, err := r.GetCommit(ctx, versionInfo.CommitHash)
+	blobHash, err := r.findFileInTree(ctx, rootTree, path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("file not found: %w", err)
+	}
+
+	content, size, err := r.OpenBlob(ctx, blobHash)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return content, size, nil
+}
+
+// OpenFileRange returns a reader for length bytes of a file from offset, or
+// for the rest of it if length is 0, and the file's size
+func (r *RepositoryImpl) OpenFileRange(ctx context.Context, version int64, path string, offset, length int64) (io.ReadCloser, int64, error) {
+	rootTree, err := r.rootTreeHash(ctx, version)
 	if err != nil {
-		return nil, fmt.Errorf("commit not found: %w", err)
+		return nil, 0, err
+	}
+
+	blobHash, err := r.findFileInTree(ctx, rootTree, path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("file not found: %w", err)
+	}
+
+	content, size, err := r.OpenBlobRange(ctx, blobHash, offset, length)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return content, size, nil
+}
+
+// ReadDirectory lists directory contents at a specific path in a version
+func (r *RepositoryImpl) ReadDirectory(ctx context.Context, version int64, path string) ([]*TreeEntry, error) {
+	rootTree, err := r.rootTreeHash(ctx, version)
+	if err != nil {
+		return nil, err
 	}
 
 	// Navigate to directory through tree structure
-	treeHash, err := r.findDirectoryInTree(ctx, commit.RootTree, path)
+	treeHash, err := r.findDirectoryInTree(ctx, rootTree, path)
 	if err != nil {
 		return nil, fmt.Errorf("directory not found: %w", err)
 	}
 
 	// Get tree object
-	tree, err := r.GetTree(ctx, treeHash)
+	tree, err := r.getTree(ctx, treeHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read tree: %w", err)
 	}
 
-	// Convert []TreeEntry to []*TreeEntry
+	// Copy the entries; the tree may be the shared cached root
 	result := make([]*TreeEntry, len(tree.Entries))
 	for i := range tree.Entries {
-		result[i] = &tree.Entries[i]
+		entry := tree.Entries[i]
+		result[i] = &entry
 	}
 	return result, nil
 }
 
+// GetEntry returns the tree entry for a file or directory at a specific path in a version.
+// The repository root resolves to a tree entry with an empty name.
+func (r *RepositoryImpl) GetEntry(ctx context.Context, version int64, path string) (*TreeEntry, error) {
+	rootTree, err := r.rootTreeHash(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	return r.entryInTree(ctx, rootTree, path)
+}
+
+// GetEntryInTree returns the tree entry at path below a root tree, such as
+// a branch commit's
+func (r *RepositoryImpl) GetEntryInTree(ctx context.Context, rootTree Hash, path string) (*TreeEntry, error) {
+	return r.entryInTree(ctx, rootTree, path)
+}
+
+// entryInTree returns the tree entry at path below a root tree
+func (r *RepositoryImpl) entryInTree(ctx context.Context, rootTree Hash, path string) (*TreeEntry, error) {
+	parts := splitPath(path)
+	if len(parts) == 0 {
+		return &TreeEntry{Hash: rootTree, Type: ObjectTypeTree, Mode: 0755}, nil
+	}
+
+	parentHash, err := r.findDirectoryInTree(ctx, rootTree, strings.Join(parts[:len(parts)-1], "/"))
+	if err != nil {
+		return nil, fmt.Errorf("path not found: %w", err)
+	}
+
+	tree, err := r.getTree(ctx, parentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree: %w", err)
+	}
+
+	name := parts[len(parts)-1]
+	for _, entry := range tree.Entries {
+		if entry.Name == name {
+			result := entry
+			return &result, nil
+		}
+	}
+
+	return nil, fmt.Errorf("path '%s' %w", path, ErrNotFound)
+}
+
 // CreateCommitFromFileSystem creates a commit from current file system state
 func (r *RepositoryImpl) CreateCommitFromFileSystem(ctx context.Context, rootPath string, author, message string) (*VersionInfo, error) {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
 	// Get current version for parent reference
 	currentVersion, err := r.GetCurrentVersion(ctx)
 	if err != nil {
@@ -103,27 +248,40 @@ func (r *RepositoryImpl) CreateCommitFromFileSystem(ctx context.Context, rootPat
 	}
 
 	var parentHash *Hash
+	var parentRoot Hash
 	if currentVersion > 0 {
 		parentInfo, err := r.GetVersionInfo(ctx, currentVersion)
-		if err == nil {
-			parentHash = &parentInfo.CommitHash
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current version info: %w", err)
 		}
+		parentCommit, err := r.GetCommit(ctx, parentInfo.CommitHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current commit: %w", err)
+		}
+		parentHash, parentRoot = &parentInfo.CommitHash, parentCommit.RootTree
+	}
+
+	// Symlinks are checked against the real root, so resolve the root itself
+	realRoot, err := filepath.EvalSymlinks(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root: %w", err)
 	}
 
 	// Create tree from file system
-	rootTreeHash, err := r.createTreeFromFileSystem(ctx, rootPath)
+	rootTreeHash, err := r.createTreeFromFileSystem(ctx, realRoot, realRoot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tree from filesystem: %w", err)
 	}
 
 	// Create commit object
 	commit := &CommitObject{
-		RootTree:  rootTreeHash,
-		Parent:    parentHash,
-		Author:    author,
-		Message:   message,
-		Timestamp: time.Now(),
-		Version:   currentVersion + 1,
+		RootTree:     rootTreeHash,
+		Parent:       parentHash,
+		Author:       author,
+		Message:      message,
+		Timestamp:    time.Now(),
+		Version:      currentVersion + 1,
+		ChangedPaths: r.changedPaths(ctx, parentRoot, rootTreeHash),
 	}
 
 	// Store commit
@@ -138,6 +296,24 @@ func (r *RepositoryImpl) CreateCommitFromFileSystem(ctx context.Context, rootPat
 
 // ApplyPatch applies a patch and creates a new version
 func (r *RepositoryImpl) ApplyPatch(ctx context.Context, patchData []byte, author, message string) (*VersionInfo, error) {
+	return r.ApplyPatchWithOptions(ctx, patchData, author, message, merge.ApplyOptions{})
+}
+
+// ApplyPatchWithOptions applies a patch with relaxed matching and creates a
+// new version
+func (r *RepositoryImpl) ApplyPatchWithOptions(ctx context.Context, patchData []byte, author, message string, opts merge.ApplyOptions) (*VersionInfo, error) {
+	return r.ApplyPatchAgainst(ctx, patchData, author, message, opts, 0)
+}
+
+// ApplyPatchAgainst applies a patch made against expectedVersion, refusing
+// it if a file it touches has changed since. The check and the commit hold
+// commitMu together, so no version can slip in between them.
+func (r *RepositoryImpl) ApplyPatchAgainst(ctx context.Context, patchData []byte, author, message string, opts merge.ApplyOptions, expectedVersion int64) (*VersionInfo, error) {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
 	// Parse patch
 	parsed, err := merge.ParsePatch(patchData)
 	if err != nil {
@@ -165,20 +341,47 @@ func (r *RepositoryImpl) ApplyPatch(ctx context.Context, patchData []byte, autho
 		return nil, fmt.Errorf("failed to get current commit: %w", err)
 	}
 
+	if expectedVersion != 0 {
+		if err := r.changedSince(ctx, parsed, expectedVersion, currentVersion, currentCommit.RootTree); err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply patch to tree structure
-	newRootHash, err := r.applyPatchToTree(ctx, currentCommit.RootTree, parsed)
+	newRootHash, err := r.applyPatchToTree(ctx, currentCommit.RootTree, parsed, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply patch: %w", err)
 	}
 
+	// Deleted files go to the trash before the version exists, so a
+	// deletion is never visible without a way back
+	if parsed.Deletes() {
+		deleted, err := r.entryInTree(ctx, currentCommit.RootTree, parsed.Target())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deleted file: %w", err)
+		}
+		if err := r.recordDeletion(ctx, &TrashEntry{
+			Path:           strings.Trim(NormalizePath(parsed.Target()), "/"),
+			Hash:           deleted.Hash,
+			Mode:           deleted.Mode,
+			Size:           deleted.Size,
+			DeletedVersion: currentVersion + 1,
+			DeletedBy:      author,
+			DeletedAt:      time.Now(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create new commit
 	newCommit := &CommitObject{
-		RootTree:  newRootHash,
-		Parent:    &currentInfo.CommitHash,
-		Author:    author,
-		Message:   message,
-		Timestamp: time.Now(),
-		Version:   currentVersion + 1,
+		RootTree:     newRootHash,
+		Parent:       &currentInfo.CommitHash,
+		Author:       author,
+		Message:      message,
+		Timestamp:    time.Now(),
+		Version:      currentVersion + 1,
+		ChangedPaths: r.changedPaths(ctx, currentCommit.RootTree, newRootHash),
 	}
 
 	// Store new commit
@@ -191,6 +394,186 @@ func (r *RepositoryImpl) ApplyPatch(ctx context.Context, patchData []byte, autho
 	return r.CreateVersion(ctx, commitHash, message)
 }
 
+// PathChangedError is returned for a patch made against an earlier version
+// when a file it touches is different in the current one. A file that did
+// not exist then and does now counts as changed.
+type PathChangedError struct {
+	Path            string
+	ExpectedVersion int64
+	CurrentVersion  int64
+}
+
+func (e *PathChangedError) Error() string {
+	return fmt.Sprintf("%s changed after version %d, which the patch was made against (current version is %d)", e.Path, e.ExpectedVersion, e.CurrentVersion)
+}
+
+// CheckPatchBase checks a patch made against expectedVersion against the
+// current version without applying it
+func (r *RepositoryImpl) CheckPatchBase(ctx context.Context, patchData []byte, expectedVersion int64) error {
+	parsed, err := merge.ParsePatch(patchData)
+	if err != nil {
+		return fmt.Errorf("failed to parse patch: %w", err)
+	}
+	currentVersion, err := r.GetCurrentVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current version: %w", err)
+	}
+	currentInfo, err := r.GetVersionInfo(ctx, currentVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get current version info: %w", err)
+	}
+	currentCommit, err := r.GetCommit(ctx, currentInfo.CommitHash)
+	if err != nil {
+		return fmt.Errorf("failed to get current commit: %w", err)
+	}
+	return r.changedSince(ctx, parsed, expectedVersion, currentVersion, currentCommit.RootTree)
+}
+
+// changedSince returns a *PathChangedError for the first file parsed
+// touches whose entry differs between expectedVersion and currentRoot
+func (r *RepositoryImpl) changedSince(ctx context.Context, parsed *merge.ParsedPatch, expectedVersion, currentVersion int64, currentRoot Hash) error {
+	if expectedVersion == currentVersion {
+		return nil
+	}
+	info, err := r.GetVersionInfo(ctx, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get version %d: %w", expectedVersion, err)
+	}
+	commit, err := r.GetCommit(ctx, info.CommitHash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit of version %d: %w", expectedVersion, err)
+	}
+
+	entryHash := func(root Hash, path string) (Hash, error) {
+		entry, err := r.entryInTree(ctx, root, path)
+		if errors.Is(err, ErrNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return entry.Hash, nil
+	}
+	for _, path := range []string{parsed.Header.OldFile, parsed.Header.NewFile} {
+		if path == "" || path == merge.DevNull {
+			continue
+		}
+		then, err := entryHash(commit.RootTree, path)
+		if err != nil {
+			return err
+		}
+		now, err := entryHash(currentRoot, path)
+		if err != nil {
+			return err
+		}
+		if then != now {
+			return &PathChangedError{Path: path, ExpectedVersion: expectedVersion, CurrentVersion: currentVersion}
+		}
+	}
+	return nil
+}
+
+// ErrNotFound is wrapped by errors for paths missing from the version read
+var ErrNotFound = errors.New("not found")
+
+// ErrPatchConflict is returned by PreviewPatch when the patch does not apply
+// to the current content of its file
+var ErrPatchConflict = errors.New("patch does not apply")
+
+// HunkMismatchError reports a context or removed line of a hunk that differs
+// from the file the patch is applied to
+type HunkMismatchError struct {
+	HunkLine int    // First line of the hunk in the file the patch was made against
+	FileLine int    // Line of the file that differs
+	Expected string // Line as the patch has it
+	Actual   string // Line as the file has it; empty past the end of the file
+}
+
+func (e *HunkMismatchError) Error() string {
+	return fmt.Sprintf("hunk at line %d does not match file content at line %d", e.HunkLine, e.FileLine)
+}
+
+// PatchPreview is the outcome of applying a patch without storing it
+type PatchPreview struct {
+	Path        string // File the patch applies to
+	BaseVersion int64  // Version the patch was applied to
+	Exists      bool   // Whether the file exists in the base version
+	Deletes     bool   // Whether the patch deletes the file
+	Original    []byte // File content in the base version
+	Content     []byte // File content after the patch
+}
+
+// PreviewPatch applies a patch to the current version in memory and returns
+// the resulting file content. Nothing is stored.
+func (r *RepositoryImpl) PreviewPatch(ctx context.Context, patchData []byte, opts merge.ApplyOptions) (*PatchPreview, error) {
+	parsed, err := merge.ParsePatch(patchData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+
+	targetPath, err := patchTarget(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	currentVersion, err := r.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	if currentVersion == 0 {
+		return nil, fmt.Errorf("cannot apply patch to empty repository")
+	}
+
+	currentInfo, err := r.GetVersionInfo(ctx, currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version info: %w", err)
+	}
+
+	currentCommit, err := r.GetCommit(ctx, currentInfo.CommitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current commit: %w", err)
+	}
+
+	if existing, err := r.caseCollision(ctx, currentCommit.RootTree, targetPath); err != nil {
+		return nil, err
+	} else if existing != "" {
+		return nil, fmt.Errorf("%w: %s collides with %s", ErrCaseCollision, targetPath, existing)
+	}
+
+	preview := &PatchPreview{Path: targetPath, BaseVersion: currentVersion, Exists: true}
+	preview.Original, err = r.readFileFromTree(ctx, currentCommit.RootTree, targetPath)
+	if err != nil {
+		preview.Original = []byte{}
+		preview.Exists = false
+	}
+
+	attributes, err := r.patchAttributes(ctx, currentCommit.RootTree, targetPath)
+	if err != nil {
+		return nil, err
+	}
+	opts, err = attributes.patchOptions(targetPath, preview.Original, preview.Exists, parsed, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Content, err = r.applyPatchToContent(preview.Original, parsed, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPatchConflict, err)
+	}
+	if preview.Content, err = attributes.finishPatch(targetPath, parsed, preview.Content); err != nil {
+		return nil, err
+	}
+	if parsed.Deletes() {
+		if err := checkDeletion(targetPath, preview.Exists, preview.Content); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPatchConflict, err)
+		}
+		preview.Deletes = true
+	}
+
+	return preview, nil
+}
+
 // Close closes the repository and any underlying resources
 func (r *RepositoryImpl) Close() error {
 	return r.ContentStore.backend.Close()
@@ -198,13 +581,24 @@ func (r *RepositoryImpl) Close() error {
 
 // Helper methods
 
+// splitPath splits a repository path into its non-empty components
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(NormalizePath(path))), "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
 func (r *RepositoryImpl) findFileInTree(ctx context.Context, treeHash Hash, path string) (Hash, error) {
 	if path == "" {
 		return "", fmt.Errorf("empty path")
 	}
 
 	// Clean the path to handle "." and ".." properly
-	cleanPath := filepath.Clean(path)
+	cleanPath := filepath.ToSlash(filepath.Clean(NormalizePath(path)))
 	if cleanPath == "." {
 		return "", fmt.Errorf("cannot read directory as file")
 	}
@@ -227,7 +621,7 @@ func (r *RepositoryImpl) findFileInTree(ctx context.Context, treeHash Hash, path
 
 	// Navigate through directories
 	for i, part := range parts[:len(parts)-1] {
-		tree, err := r.GetTree(ctx, currentTreeHash)
+		tree, err := r.getTree(ctx, currentTreeHash)
 		if err != nil {
 			return "", fmt.Errorf("failed to get tree at level %d: %w", i, err)
 		}
@@ -242,12 +636,12 @@ func (r *RepositoryImpl) findFileInTree(ctx context.Context, treeHash Hash, path
 		}
 
 		if !found {
-			return "", fmt.Errorf("directory '%s' not found", part)
+			return "", fmt.Errorf("directory '%s' %w", part, ErrNotFound)
 		}
 	}
 
 	// Find file in final directory
-	tree, err := r.GetTree(ctx, currentTreeHash)
+	tree, err := r.getTree(ctx, currentTreeHash)
 	if err != nil {
 		return "", fmt.Errorf("failed to get final tree: %w", err)
 	}
@@ -259,7 +653,7 @@ func (r *RepositoryImpl) findFileInTree(ctx context.Context, treeHash Hash, path
 		}
 	}
 
-	return "", fmt.Errorf("file '%s' not found", fileName)
+	return "", fmt.Errorf("file '%s' %w", fileName, ErrNotFound)
 }
 
 func (r *RepositoryImpl) findDirectoryInTree(ctx context.Context, treeHash Hash, path string) (Hash, error) {
@@ -268,7 +662,7 @@ func (r *RepositoryImpl) findDirectoryInTree(ctx context.Context, treeHash Hash,
 	}
 
 	// Clean the path to handle "." and ".." properly
-	cleanPath := filepath.Clean(path)
+	cleanPath := filepath.ToSlash(filepath.Clean(NormalizePath(path)))
 	if cleanPath == "." {
 		return treeHash, nil // Current directory is root
 	}
@@ -291,7 +685,7 @@ func (r *RepositoryImpl) findDirectoryInTree(ctx context.Context, treeHash Hash,
 
 	// Navigate through all directories
 	for i, part := range parts {
-		tree, err := r.GetTree(ctx, currentTreeHash)
+		tree, err := r.getTree(ctx, currentTreeHash)
 		if err != nil {
 			return "", fmt.Errorf("failed to get tree at level %d: %w", i, err)
 		}
@@ -306,27 +700,37 @@ func (r *RepositoryImpl) findDirectoryInTree(ctx context.Context, treeHash Hash,
 		}
 
 		if !found {
-			return "", fmt.Errorf("directory '%s' not found", part)
+			return "", fmt.Errorf("directory '%s' %w", part, ErrNotFound)
 		}
 	}
 
 	return currentTreeHash, nil
 }
 
-func (r *RepositoryImpl) createTreeFromFileSystem(ctx context.Context, dirPath string) (Hash, error) {
+// createTreeFromFileSystem stores dirPath, which lies within root, as a tree.
+// Names are stored in NFC. Symlinks to files within root are stored as the
+// file they point to; any other symlink is rejected.
+func (r *RepositoryImpl) createTreeFromFileSystem(ctx context.Context, root, dirPath string) (Hash, error) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read directory: %w", err)
 	}
 
 	var treeEntries []TreeEntry
+	seen := make(map[string]string, len(entries))
 
 	for _, entry := range entries {
 		fullPath := filepath.Join(dirPath, entry.Name())
 
+		name := NormalizePath(entry.Name())
+		if other, ok := seen[name]; ok {
+			return "", fmt.Errorf("%s and %s are the same name in different Unicode forms", other, fullPath)
+		}
+		seen[name] = fullPath
+
 		if entry.IsDir() {
 			// Recursively create tree for subdirectory
-			subTreeHash, err := r.createTreeFromFileSystem(ctx, fullPath)
+			subTreeHash, err := r.createTreeFromFileSystem(ctx, root, fullPath)
 			if err != nil {
 				return "", fmt.Errorf("failed to create subtree for %s: %w", entry.Name(), err)
 			}
@@ -337,34 +741,48 @@ func (r *RepositoryImpl) createTreeFromFileSystem(ctx context.Context, dirPath s
 			}
 
 			treeEntries = append(treeEntries, TreeEntry{
-				Name:    entry.Name(),
+				Name:    name,
 				Hash:    subTreeHash,
 				Type:    ObjectTypeTree,
 				Mode:    int32(entry.Type() & fs.ModePerm),
 				ModTime: info.ModTime().Unix(),
 			})
 		} else {
-			// Read file content and create blob
-			content, err := os.ReadFile(fullPath)
-			if err != nil {
-				return "", fmt.Errorf("failed to read file %s: %w", entry.Name(), err)
+			filePath := fullPath
+			if entry.Type()&fs.ModeSymlink != 0 {
+				rel, err := filepath.Rel(root, fullPath)
+				if err != nil {
+					return "", fmt.Errorf("failed to resolve symlink %s: %w", entry.Name(), err)
+				}
+				if filePath, err = ResolveInRoot(root, rel); err != nil {
+					return "", err
+				}
 			}
 
-			blobHash, err := r.StoreBlob(ctx, content)
+			info, err := os.Stat(filePath)
 			if err != nil {
-				return "", fmt.Errorf("failed to store blob for %s: %w", entry.Name(), err)
+				return "", fmt.Errorf("failed to get file info for %s: %w", entry.Name(), err)
+			}
+			if !info.Mode().IsRegular() {
+				return "", fmt.Errorf("%s is not a regular file", fullPath)
 			}
 
-			info, err := entry.Info()
+			// Stream file content into a blob
+			file, err := os.Open(filePath)
 			if err != nil {
-				return "", fmt.Errorf("failed to get file info for %s: %w", entry.Name(), err)
+				return "", fmt.Errorf("failed to read file %s: %w", entry.Name(), err)
+			}
+			blobHash, _, err := r.StoreBlobFrom(ctx, file)
+			file.Close()
+			if err != nil {
+				return "", fmt.Errorf("failed to store blob for %s: %w", entry.Name(), err)
 			}
 
 			treeEntries = append(treeEntries, TreeEntry{
-				Name:    entry.Name(),
+				Name:    name,
 				Hash:    blobHash,
 				Type:    ObjectTypeBlob,
-				Mode:    int32(info.Mode()),
+				Mode:    fileMode(info),
 				Size:    info.Size(),
 				ModTime: info.ModTime().Unix(),
 			})
@@ -376,12 +794,19 @@ func (r *RepositoryImpl) createTreeFromFileSystem(ctx context.Context, dirPath s
 	return r.StoreTree(ctx, tree)
 }
 
-func (r *RepositoryImpl) applyPatchToTree(ctx context.Context, rootTreeHash Hash, patch *merge.ParsedPatch) (Hash, error) {
-	// Get the target file path from the patch
-	targetPath := patch.Header.NewFile
-	if targetPath == "" {
-		targetPath = patch.Header.OldFile
+// fileMode returns the mode a file is stored with: 0755 if it is executable
+// and 0644 otherwise, like git, so the same files give the same tree on every
+// platform
+func fileMode(info fs.FileInfo) int32 {
+	if info.Mode()&0111 != 0 {
+		return 0755
 	}
+	return 0644
+}
+
+// patchTarget returns the file a patch applies to
+func patchTarget(patch *merge.ParsedPatch) (string, error) {
+	targetPath := patch.Target()
 
 	if targetPath == "" {
 		return "", fmt.Errorf("patch does not specify a target file")
@@ -392,30 +817,66 @@ func (r *RepositoryImpl) applyPatchToTree(ctx context.Context, rootTreeHash Hash
 		return "", fmt.Errorf("path traversal not allowed in patch target: path contains '..'")
 	}
 
-	cleanPath := filepath.Clean(targetPath)
-	if strings.HasPrefix(cleanPath, "..") || strings.HasPrefix(cleanPath, "/") {
+	cleanPath := filepath.ToSlash(filepath.Clean(targetPath))
+	if strings.HasPrefix(cleanPath, "..") || strings.HasPrefix(cleanPath, "/") || filepath.VolumeName(targetPath) != "" {
 		return "", fmt.Errorf("invalid patch target path: path must be relative and within repository")
 	}
 
-	// Get the current file content
-	var originalContent []byte
-	var err error
+	return targetPath, nil
+}
+
+func (r *RepositoryImpl) applyPatchToTree(ctx context.Context, rootTreeHash Hash, patch *merge.ParsedPatch, opts merge.ApplyOptions) (Hash, error) {
+	targetPath, err := patchTarget(patch)
+	if err != nil {
+		return "", err
+	}
+
+	if existing, err := r.caseCollision(ctx, rootTreeHash, targetPath); err != nil {
+		return "", err
+	} else if existing != "" {
+		return "", fmt.Errorf("%w: %s collides with %s", ErrCaseCollision, targetPath, existing)
+	}
 
 	// Try to read the existing file content
-	originalContent, err = r.readFileFromTree(ctx, rootTreeHash, targetPath)
+	exists := true
+	originalContent, err := r.readFileFromTree(ctx, rootTreeHash, targetPath)
 	if err != nil {
 		// File might not exist (new file), start with empty content
 		originalContent = []byte{}
+		exists = false
+	}
+
+	attributes, err := r.patchAttributes(ctx, rootTreeHash, targetPath)
+	if err != nil {
+		return "", err
+	}
+	opts, err = attributes.patchOptions(targetPath, originalContent, exists, patch, opts)
+	if err != nil {
+		return "", err
 	}
 
 	// Apply the patch to the content
-	patchedContent, err := r.applyPatchToContent(originalContent, patch)
+	patchedContent, err := r.applyPatchToContent(originalContent, patch, opts)
 	if err != nil {
 		return "", fmt.Errorf("failed to apply patch to content: %w", err)
 	}
+	if patchedContent, err = attributes.finishPatch(targetPath, patch, patchedContent); err != nil {
+		return "", err
+	}
+
+	if patch.Deletes() {
+		if err := checkDeletion(targetPath, exists, patchedContent); err != nil {
+			return "", err
+		}
+		return r.removeTreeEntry(ctx, rootTreeHash, splitPath(targetPath))
+	}
 
 	// Store the new blob
-	newBlobHash, err := r.StoreBlob(ctx, patchedContent)
+	store := r.StoreBlob
+	if attributes.StoreRaw() {
+		store = r.storeRawBlob
+	}
+	newBlobHash, err := store(ctx, patchedContent)
 	if err != nil {
 		return "", fmt.Errorf("failed to store patched blob: %w", err)
 	}
@@ -429,6 +890,18 @@ func (r *RepositoryImpl) applyPatchToTree(ctx context.Context, rootTreeHash Hash
 	return newRootTreeHash, nil
 }
 
+// checkDeletion verifies that a deleting patch removes a file that exists
+// and leaves none of its content behind
+func checkDeletion(path string, exists bool, remaining []byte) error {
+	if !exists {
+		return fmt.Errorf("patch deletes %s, which does not exist", path)
+	}
+	if len(remaining) > 0 {
+		return fmt.Errorf("patch deletes %s but does not remove all of its content", path)
+	}
+	return nil
+}
+
 // Helper function to read file content from tree structure
 func (r *RepositoryImpl) readFileFromTree(ctx context.Context, rootTreeHash Hash, path string) ([]byte, error) {
 	blobHash, err := r.findFileInTree(ctx, rootTreeHash, path)
@@ -445,69 +918,162 @@ func (r *RepositoryImpl) readFileFromTree(ctx context.Context, rootTreeHash Hash
 }
 
 // Helper function to apply patch to content without filesystem
-func (r *RepositoryImpl) applyPatchToContent(originalContent []byte, patch *merge.ParsedPatch) ([]byte, error) {
+func (r *RepositoryImpl) applyPatchToContent(originalContent []byte, patch *merge.ParsedPatch, opts merge.ApplyOptions) ([]byte, error) {
+	if patch.Binary != nil {
+		return merge.ApplyBinaryPatch(originalContent, patch.Binary)
+	}
+
 	var originalLines []string
+	finalNewline := true
 
 	if len(originalContent) > 0 {
-		originalContentStr := string(originalContent)
-		if originalContentStr != "" {
-			originalLines = strings.Split(originalContentStr, "\n")
-			// Remove empty last line if present
-			if len(originalLines) > 0 && originalLines[len(originalLines)-1] == "" {
-				originalLines = originalLines[:len(originalLines)-1]
-			}
+		originalLines = strings.Split(string(originalContent), "\n")
+		// Remove empty last line if present
+		if originalLines[len(originalLines)-1] == "" {
+			originalLines = originalLines[:len(originalLines)-1]
+		} else {
+			finalNewline = false
 		}
 	}
 
+	// Added lines take the file's line ending when line endings are normalized
+	addCR := opts.NormalizeLineEndings && usesCRLF(originalLines)
+
 	result := make([]string, 0, len(originalLines)+100)
+	resultNewline := true // Whether the last line of result ends with a newline
 	originalIndex := 0
+	copyOriginal := func() {
+		result = append(result, originalLines[originalIndex])
+		resultNewline = originalIndex < len(originalLines)-1 || finalNewline
+		originalIndex++
+	}
+	addLine := func(patchLine merge.PatchLine) {
+		content := patchLine.Content
+		if opts.NormalizeLineEndings {
+			content = strings.TrimSuffix(content, "\r")
+			if addCR && !patchLine.NoNewline {
+				content += "\r"
+			}
+		}
+		result = append(result, content)
+		resultNewline = !patchLine.NoNewline
+	}
 
 	for _, hunk := range patch.Hunks {
+		// A hunk for an empty range (OldCount 0) names the line after which
+		// it inserts rather than the first line it touches
+		start := hunk.OldStart - 1
+		if hunk.OldCount == 0 {
+			start = hunk.OldStart
+		}
+		if opts.Union {
+			// Hunks of a union merge land wherever is closest
+			start = min(max(start, originalIndex), len(originalLines))
+		}
+		if start < originalIndex || start > len(originalLines) {
+			return nil, fmt.Errorf("hunk at line %d does not fit a %d-line file", hunk.OldStart, len(originalLines))
+		}
+
 		// Copy context lines before hunk
-		for originalIndex < hunk.OldStart-1 && originalIndex < len(originalLines) {
-			result = append(result, originalLines[originalIndex])
-			originalIndex++
+		for originalIndex < start {
+			copyOriginal()
+		}
+
+		// With a union merge, a hunk that no longer matches only adds its
+		// lines, in front of the ones it expected
+		if opts.Union && !hunkMatches(originalLines[originalIndex:], hunk, opts) {
+			for _, patchLine := range hunk.Lines {
+				if patchLine.Type == "+" {
+					addLine(patchLine)
+				}
+			}
+			continue
 		}
 
-		// Apply hunk changes
+		// Apply hunk changes. Context and deleted lines must match the file,
+		// otherwise the patch was made against different content.
 		for _, patchLine := range hunk.Lines {
 			switch patchLine.Type {
-			case " ": // Context line
-				if originalIndex < len(originalLines) {
-					result = append(result, originalLines[originalIndex])
-					originalIndex++
+			case " ", "-":
+				if originalIndex >= len(originalLines) || !linesMatch(originalLines[originalIndex], patchLine.Content, opts) {
+					mismatch := &HunkMismatchError{HunkLine: hunk.OldStart, FileLine: originalIndex + 1, Expected: patchLine.Content}
+					if originalIndex < len(originalLines) {
+						mismatch.Actual = originalLines[originalIndex]
+					}
+					return nil, mismatch
 				}
-			case "-": // Deletion
-				if originalIndex < len(originalLines) {
+				if patchLine.Type == " " {
+					copyOriginal()
+				} else {
 					originalIndex++
 				}
 			case "+": // Addition
-				result = append(result, patchLine.Content)
+				addLine(patchLine)
 			}
 		}
 	}
 
 	// Copy remaining lines
 	for originalIndex < len(originalLines) {
-		result = append(result, originalLines[originalIndex])
-		originalIndex++
+		copyOriginal()
 	}
 
 	newContent := strings.Join(result, "\n")
-	if len(result) > 0 {
+	if len(result) > 0 && (resultNewline || !opts.PreserveTrailingNewline) {
 		newContent += "\n"
 	}
 
 	return []byte(newContent), nil
 }
 
+// hunkMatches reports whether a hunk's context and removed lines match the
+// start of lines
+func hunkMatches(lines []string, hunk merge.PatchHunk, opts merge.ApplyOptions) bool {
+	i := 0
+	for _, patchLine := range hunk.Lines {
+		if patchLine.Type == "+" {
+			continue
+		}
+		if i >= len(lines) || !linesMatch(lines[i], patchLine.Content, opts) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// linesMatch compares a file line with a context or removed patch line
+func linesMatch(fileLine, patchLine string, opts merge.ApplyOptions) bool {
+	if fileLine == patchLine {
+		return true
+	}
+	if opts.IgnoreWhitespace {
+		return strings.Join(strings.Fields(fileLine), " ") == strings.Join(strings.Fields(patchLine), " ")
+	}
+	if opts.NormalizeLineEndings {
+		return strings.TrimSuffix(fileLine, "\r") == strings.TrimSuffix(patchLine, "\r")
+	}
+	return false
+}
+
+// usesCRLF reports whether most lines end with a carriage return
+func usesCRLF(lines []string) bool {
+	crlf := 0
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\r") {
+			crlf++
+		}
+	}
+	return crlf > 0 && crlf*2 >= len(lines)
+}
+
 // Helper function to update tree structure with new blob
 func (r *RepositoryImpl) updateTreeWithBlob(ctx context.Context, rootTreeHash Hash, path string, blobHash Hash, size int64) (Hash, error) {
 	if path == "" {
 		return "", fmt.Errorf("empty path")
 	}
 
-	parts := strings.Split(strings.Trim(path, "/"), "/")
+	parts := strings.Split(strings.Trim(NormalizePath(path), "/"), "/")
 
 	// If it's a single file in root directory
 	if len(parts) == 1 {
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBackendUnavailable is wrapped by the errors of operations a
+// ResilientBackend refused because its circuit breaker is open, or gave up
+// on because they timed out
+var ErrBackendUnavailable = errors.New("storage backend unavailable")
+
+// ResilienceConfig bounds the operations of a ResilientBackend
+type ResilienceConfig struct {
+	Timeout          time.Duration // Per attempt of Put, Exists, Delete and Gets of keys other than objects; 0 for none
+	MaxAttempts      int           // Attempts per operation, including the first; PutStream is never retried
+	InitialBackoff   time.Duration // Delay before the first retry, doubled after each
+	MaxBackoff       time.Duration
+	BreakerThreshold int           // Consecutive failed operations that open the breaker; 0 disables it
+	BreakerCooldown  time.Duration // How long the breaker fails fast before letting an operation probe
+}
+
+// DefaultResilienceConfig returns the settings poon-server wraps its
+// storage backend with
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		Timeout:          10 * time.Second,
+		MaxAttempts:      3,
+		InitialBackoff:   100 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// Breaker states reported by ResilientBackend.State
+const (
+	BreakerClosed   = "closed"    // Operations run
+	BreakerOpen     = "open"      // Operations fail fast with ErrBackendUnavailable
+	BreakerHalfOpen = "half-open" // The cooldown is over; the next outcome closes or reopens it
+)
+
+// ResilientBackend wraps a StorageBackend so that an outage fails requests
+// quickly instead of hanging them: attempts whose duration does not depend
+// on the size of the data are bounded by a timeout, failed operations are
+// retried with jittered backoff, and after enough consecutive failures a
+// circuit breaker refuses operations outright until a cooldown has passed.
+// Missing keys and the caller's own cancellation are answers, not failures:
+// they are neither retried nor counted.
+//
+// Listing and reading objects take as long as the repository and the
+// object are large, so List, Get of objects/ keys and streams have no
+// timeout of their own; the caller's deadline bounds them, and GC, fsck and
+// migrations of large repositories are not cut off part way.
+type ResilientBackend struct {
+	backend StorageBackend
+	config  ResilienceConfig
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	halfOpen  bool
+}
+
+// NewResilientBackend wraps backend with config
+func NewResilientBackend(backend StorageBackend, config ResilienceConfig) *ResilientBackend {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	return &ResilientBackend{backend: backend, config: config}
+}
+
+// Unwrap returns the wrapped backend
+func (b *ResilientBackend) Unwrap() StorageBackend {
+	return b.backend
+}
+
+// State returns BreakerClosed, BreakerOpen or BreakerHalfOpen
+func (b *ResilientBackend) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case time.Now().Before(b.openUntil):
+		return BreakerOpen
+	case b.halfOpen:
+		return BreakerHalfOpen
+	default:
+		return BreakerClosed
+	}
+}
+
+func (b *ResilientBackend) allow(op, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := b.openUntil; time.Now().Before(until) {
+		return fmt.Errorf("%w: %s %s refused for %s after repeated failures", ErrBackendUnavailable, op, key, time.Until(until).Round(time.Second))
+	}
+	return nil
+}
+
+// record counts the outcome of an operation toward the breaker
+func (b *ResilientBackend) record(failed bool) {
+	if b.config.BreakerThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		b.halfOpen = false
+		return
+	}
+	b.failures++
+	if b.halfOpen || b.failures >= b.config.BreakerThreshold {
+		b.openUntil = time.Now().Add(b.config.BreakerCooldown)
+		b.halfOpen = true // Once the cooldown ends
+		b.failures = 0
+	}
+}
+
+// isFailure reports whether err says the backend is failing rather than
+// answering
+func isFailure(ctx context.Context, err error) bool {
+	return err != nil && !errors.Is(err, ErrKeyNotFound) && ctx.Err() == nil
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based)
+func (b *ResilientBackend) backoff(attempt int) time.Duration {
+	delay := b.config.InitialBackoff << (attempt - 1)
+	if delay > b.config.MaxBackoff || delay <= 0 {
+		delay = b.config.MaxBackoff
+	}
+	// Full jitter: anywhere from none to all of the computed delay, so
+	// clients that failed together do not retry together
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// do runs op with the retries and breaker, and each attempt with timeout
+// unless it is 0
+func (b *ResilientBackend) do(ctx context.Context, name, key string, timeout time.Duration, op func(ctx context.Context) error) error {
+	if err := b.allow(name, key); err != nil {
+		return err
+	}
+
+	var err error
+	for attempt := 1; attempt <= b.config.MaxAttempts; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		err = op(attemptCtx)
+		timedOut := err != nil && attemptCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if timedOut {
+			err = fmt.Errorf("%w: %s %s timed out after %s", ErrBackendUnavailable, name, key, timeout)
+		}
+
+		if !isFailure(ctx, err) {
+			b.record(false)
+			return err
+		}
+		if attempt == b.config.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(b.backoff(attempt)):
+		}
+	}
+	b.record(true)
+	return err
+}
+
+// Put stores data at the given key
+func (b *ResilientBackend) Put(ctx context.Context, key string, data []byte) error {
+	return b.do(ctx, "put", key, b.config.Timeout, func(ctx context.Context) error {
+		return b.backend.Put(ctx, key, data)
+	})
+}
+
+// PutStream stores everything read from r at the given key. It cannot
+// rewind r, so it is neither retried nor bounded by the timeout; the
+// request's deadline bounds it.
+func (b *ResilientBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	if err := b.allow("put", key); err != nil {
+		return err
+	}
+	err := b.backend.PutStream(ctx, key, r)
+	b.record(isFailure(ctx, err))
+	return err
+}
+
+// Get retrieves data for the given key. Objects may be of any size, so
+// their attempts have no timeout.
+func (b *ResilientBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	timeout := b.config.Timeout
+	if strings.HasPrefix(key, "objects/") {
+		timeout = 0
+	}
+	var data []byte
+	err := b.do(ctx, "get", key, timeout, func(ctx context.Context) error {
+		var err error
+		data, err = b.backend.Get(ctx, key)
+		return err
+	})
+	return data, err
+}
+
+// Exists checks if a key exists
+func (b *ResilientBackend) Exists(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := b.do(ctx, "exists", key, b.config.Timeout, func(ctx context.Context) error {
+		var err error
+		exists, err = b.backend.Exists(ctx, key)
+		return err
+	})
+	return exists, err
+}
+
+// Delete removes data for the given key. A retry finding the key gone
+// succeeds, since the attempt that timed out may have deleted it.
+func (b *ResilientBackend) Delete(ctx context.Context, key string) error {
+	attempts := 0
+	return b.do(ctx, "delete", key, b.config.Timeout, func(ctx context.Context) error {
+		attempts++
+		err := b.backend.Delete(ctx, key)
+		if attempts > 1 && errors.Is(err, ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// List returns all keys with the given prefix. A listing grows with the
+// repository, so its attempts have no timeout.
+func (b *ResilientBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := b.do(ctx, "list", prefix+"*", 0, func(ctx context.Context) error {
+		var err error
+		keys, err = b.backend.List(ctx, prefix)
+		return err
+	})
+	return keys, err
+}
+
+// Stream returns a reader for the given key. Opening it is retried, but the
+// reads that follow are bounded only by the request's deadline.
+func (b *ResilientBackend) Stream(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := b.allow("stream", key); err != nil {
+		return nil, err
+	}
+	var rc io.ReadCloser
+	var err error
+	for attempt := 1; attempt <= b.config.MaxAttempts; attempt++ {
+		rc, err = b.backend.Stream(ctx, key)
+		if !isFailure(ctx, err) {
+			b.record(false)
+			return rc, err
+		}
+		if attempt == b.config.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(b.backoff(attempt)):
+		}
+	}
+	b.record(true)
+	return nil, err
+}
+
+// Close closes the wrapped backend
+func (b *ResilientBackend) Close() error {
+	return b.backend.Close()
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetentionPolicy says which versions PruneHistory keeps. The current
+// version is always kept, and so is the version before each deletion in the
+// trash, which is where the deleted file's content is found.
+type RetentionPolicy struct {
+	KeepSince    time.Time      // Versions created at or after this are kept; zero keeps none for their age
+	KeepVersions map[int64]bool // Kept whatever their age, such as tagged versions
+}
+
+// PruneResult summarizes a history pruning run
+type PruneResult struct {
+	Kept       int   `json:"kept"`       // Versions retained
+	Pruned     int   `json:"pruned"`     // Versions removed (or that would be, in a dry run)
+	Rewritten  int   `json:"rewritten"`  // Retained versions whose commit got a new parent
+	OldestKept int64 `json:"oldestKept"` // The checkpoint the retained history now starts at
+}
+
+// PruneHistory removes the versions policy does not retain. Each retained
+// version's commit is rewritten to follow the previous retained one, so the
+// oldest retained version becomes a root checkpoint and every gap is
+// squashed into the version after it. Retained versions keep their numbers
+// and content; pruned numbers are no longer found. Commits are rewritten,
+// oldest first, before any version is removed, so an interrupted run leaves
+// a consistent history and can be repeated. The objects only pruned
+// versions used stay until the next garbage collection. Writes are blocked
+// while it runs.
+func (r *RepositoryImpl) PruneHistory(ctx context.Context, policy RetentionPolicy, dryRun bool) (*PruneResult, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	defer r.resetRootTree()
+	defer r.graph.forget()
+
+	versions, err := r.ListVersions(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	result := &PruneResult{}
+	if len(versions) == 0 {
+		return result, nil
+	}
+
+	trash, err := r.ListTrash(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[int64]bool, len(trash))
+	for _, entry := range trash {
+		deleted[entry.DeletedVersion-1] = true
+	}
+
+	current := versions[0].Version
+	var kept, pruned []*VersionInfo
+	// Oldest first, so each commit's new parent is already written
+	for i := len(versions) - 1; i >= 0; i-- {
+		info := versions[i]
+		keep := info.Version == current || policy.KeepVersions[info.Version] || deleted[info.Version] ||
+			(!policy.KeepSince.IsZero() && !info.Timestamp.Before(policy.KeepSince))
+		if keep {
+			kept = append(kept, info)
+		} else {
+			pruned = append(pruned, info)
+		}
+	}
+	result.Kept, result.Pruned = len(kept), len(pruned)
+	result.OldestKept = kept[0].Version
+
+	var parent *Hash
+	var parentRoot Hash
+	rewriting := false // Once one commit changes, every later one's parent does
+	for _, info := range kept {
+		commit, err := r.GetCommit(ctx, info.CommitHash)
+		if err != nil {
+			return result, fmt.Errorf("version %d: failed to read commit: %w", info.Version, err)
+		}
+
+		hash := info.CommitHash
+		if rewriting || !sameParent(commit.Parent, parent) {
+			rewriting = true
+			result.Rewritten++
+			commit.Parent = parent
+			if !dryRun {
+				// The commit now also carries the changes of the versions
+				// squashed into it
+				commit.ChangedPaths = r.changedPaths(ctx, parentRoot, commit.RootTree)
+				if hash, err = r.StoreCommit(ctx, commit); err != nil {
+					return result, fmt.Errorf("version %d: failed to store commit: %w", info.Version, err)
+				}
+				if err := r.replaceCommit(ctx, info, hash); err != nil {
+					return result, fmt.Errorf("version %d: %w", info.Version, err)
+				}
+			}
+		}
+		parent, parentRoot = &hash, commit.RootTree
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	for _, info := range pruned {
+		if err := r.DeleteVersion(ctx, info.Version); err != nil {
+			return result, fmt.Errorf("failed to remove version %d: %w", info.Version, err)
+		}
+	}
+	return result, nil
+}
+
+func sameParent(a, b *Hash) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
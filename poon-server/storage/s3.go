@@ -61,6 +61,23 @@ func (s3b *S3Backend) Put(ctx context.Context, key string, data []byte) error {
 	return fmt.Errorf("S3 backend not yet implemented")
 }
 
+// PutStream stores everything read from r at the given key in S3
+func (s3b *S3Backend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	_ = s3b.buildKey(key) // fullKey would be used in actual implementation
+
+	// TODO: Implement with the S3 upload manager, which sends r in parts
+	// without reading it into memory
+	// _, err := s3b.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+	//     Bucket: aws.String(s3b.config.Bucket),
+	//     Key:    aws.String(fullKey),
+	//     Body:   r,
+	//     ServerSideEncryption: aws.String("AES256"),
+	// })
+	// return err
+
+	return fmt.Errorf("S3 backend not yet implemented")
+}
+
 // Get retrieves data for the given key from S3
 func (s3b *S3Backend) Get(ctx context.Context, key string) ([]byte, error) {
 	_ = s3b.buildKey(key) // fullKey would be used in actual implementation
@@ -182,14 +199,16 @@ func (s3b *S3Backend) stripPrefix(key string) string {
 type BackendType string
 
 const (
-	BackendTypeMemory BackendType = "memory"
-	BackendTypeS3     BackendType = "s3"
+	BackendTypeMemory     BackendType = "memory"
+	BackendTypeS3         BackendType = "s3"
+	BackendTypeFilesystem BackendType = "filesystem"
 )
 
 // BackendConfig holds configuration for different backend types
 type BackendConfig struct {
-	Type BackendType `json:"type"`
-	S3   *S3Config   `json:"s3,omitempty"`
+	Type       BackendType       `json:"type"`
+	S3         *S3Config         `json:"s3,omitempty"`
+	Filesystem *FilesystemConfig `json:"filesystem,omitempty"`
 }
 
 // NewStorageBackend creates a storage backend based on configuration
@@ -202,6 +221,11 @@ func NewStorageBackend(config *BackendConfig) (StorageBackend, error) {
 			return nil, fmt.Errorf("S3 configuration is required for S3 backend")
 		}
 		return NewS3Backend(config.S3)
+	case BackendTypeFilesystem:
+		if config.Filesystem == nil {
+			return nil, fmt.Errorf("filesystem configuration is required for filesystem backend")
+		}
+		return NewFilesystemBackend(config.Filesystem.Root)
 	default:
 		return nil, fmt.Errorf("unsupported backend type: %s", config.Type)
 	}
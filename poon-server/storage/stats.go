@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// maxStatEdits bounds the line diff behind ChangeStats. Files that differ
+// by more lines than this count every remaining line as changed, which
+// over-counts but keeps a huge rewrite from taking quadratic time.
+const maxStatEdits = 4096
+
+// FileStat is a FileChange with the lines it inserted and deleted
+type FileStat struct {
+	FileChange
+	Insertions int  `json:"insertions"`
+	Deletions  int  `json:"deletions"`
+	Binary     bool `json:"binary,omitempty"` // Lines are not counted for binary files
+}
+
+// ChangeStats lists the files that differ between two versions with their
+// line counts, like git diff --stat. Renames are detected; a file is binary
+// when the attributes of the new version or either side's content say so.
+func (r *RepositoryImpl) ChangeStats(ctx context.Context, from, to int64) ([]FileStat, error) {
+	changes, err := r.DiffVersions(ctx, from, to, DiffOptions{DetectRenames: true})
+	if err != nil {
+		return nil, err
+	}
+	rules, err := r.Attributes(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]FileStat, 0, len(changes))
+	for _, change := range changes {
+		stat := FileStat{FileChange: change}
+		attrs := rules.Lookup(change.Path)
+
+		oldContent, err := r.statContent(ctx, change.OldHash)
+		if err != nil {
+			return nil, err
+		}
+		newContent, err := r.statContent(ctx, change.NewHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if attrs.StoreRaw() || attrs.Binary(oldContent) || attrs.Binary(newContent) {
+			stat.Binary = true
+		} else {
+			stat.Insertions, stat.Deletions = countLineChanges(oldContent, newContent)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func (r *RepositoryImpl) statContent(ctx context.Context, hash Hash) ([]byte, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	blob, err := r.GetBlob(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	return blob.Content, nil
+}
+
+// countLineChanges returns how many lines a minimal line diff from before to
+// after inserts and deletes
+func countLineChanges(before, after []byte) (insertions, deletions int) {
+	a, b := splitLines(before), splitLines(after)
+
+	// Lines shared at the start and end are not part of any edit
+	for len(a) > 0 && len(b) > 0 && bytes.Equal(a[0], b[0]) {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && bytes.Equal(a[len(a)-1], b[len(b)-1]) {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	common, ok := commonLines(a, b, maxStatEdits)
+	if !ok {
+		return len(b), len(a)
+	}
+	return len(b) - common, len(a) - common
+}
+
+// splitLines splits content after each newline; a missing final newline
+// still ends a line, so "a" and "a\n" differ
+func splitLines(content []byte) [][]byte {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// commonLines returns the length of the longest common subsequence of a and
+// b using Myers' O(ND) algorithm, or false when more than limit lines would
+// have to be inserted or deleted
+func commonLines(a, b [][]byte, limit int) (int, bool) {
+	n, m := len(a), len(b)
+	bound := min(n+m, limit)
+	offset := bound + 1
+	v := make([]int, 2*bound+3)
+
+	for d := 0; d <= bound; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Insertion
+			} else {
+				x = v[offset+k-1] + 1 // Deletion
+			}
+			y := x - k
+			for x < n && y < m && bytes.Equal(a[x], b[y]) {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				// d edits split into n-common deletions and m-common insertions
+				return (n + m - d) / 2, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -1,10 +1,26 @@
 package storage
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/nic/poon/poon-server/merge"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -82,6 +98,34 @@ func TestMemoryBackend(t *testing.T) {
 	})
 }
 
+func TestFilesystemBackend(t *testing.T) {
+	backend, err := NewFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, backend.Put(ctx, "objects/abc", []byte("one")))
+	require.NoError(t, backend.Put(ctx, "version/current", []byte("two")))
+
+	data, err := backend.Get(ctx, "objects/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+
+	exists, err := backend.Exists(ctx, "objects/missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	keys, err := backend.List(ctx, "objects/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"objects/abc"}, keys)
+
+	require.NoError(t, backend.Delete(ctx, "objects/abc"))
+	_, err = backend.Get(ctx, "objects/abc")
+	assert.Error(t, err)
+	assert.Error(t, backend.Delete(ctx, "objects/abc"))
+
+	assert.Error(t, backend.Put(ctx, "../escape", []byte("x")))
+}
+
 func TestHasher(t *testing.T) {
 	hasher := NewHasher()
 
@@ -267,193 +311,3421 @@ func TestVersionManager(t *testing.T) {
 	})
 }
 
-func TestRepository(t *testing.T) {
+func TestLockManager(t *testing.T) {
 	backend := NewMemoryBackend()
 	defer backend.Close()
 
-	repo := NewRepository(backend)
-	defer repo.Close()
-
+	lm := NewLockManager(backend)
 	ctx := context.Background()
 
-	t.Run("InitialState", func(t *testing.T) {
-		version, err := repo.GetCurrentVersion(ctx)
+	t.Run("AcquireAndConflict", func(t *testing.T) {
+		lock, err := lm.Acquire(ctx, "assets/logo.psd", "alice", time.Hour)
 		require.NoError(t, err)
-		assert.Equal(t, int64(0), version)
+		assert.Equal(t, "assets/logo.psd", lock.Path)
+		assert.Equal(t, "alice", lock.Owner)
+
+		held, err := lm.Acquire(ctx, "assets/logo.psd", "bob", time.Hour)
+		assert.ErrorIs(t, err, ErrLockHeld)
+		assert.Equal(t, "alice", held.Owner)
+
+		// Re-locking by the owner extends the lock
+		extended, err := lm.Acquire(ctx, "assets/logo.psd", "alice", 2*time.Hour)
+		require.NoError(t, err)
+		assert.True(t, extended.ExpiresAt.After(lock.ExpiresAt))
+		assert.Equal(t, lock.CreatedAt.Unix(), extended.CreatedAt.Unix())
 	})
 
-	t.Run("ContentOperations", func(t *testing.T) {
-		// Store a blob
-		content := []byte("repository test content")
-		hash, err := repo.StoreBlob(ctx, content)
+	t.Run("FindConflict", func(t *testing.T) {
+		_, err := lm.Acquire(ctx, "protos", "carol", time.Hour)
 		require.NoError(t, err)
 
-		// Retrieve the blob
-		blob, err := repo.GetBlob(ctx, hash)
+		conflict, err := lm.FindConflict(ctx, "protos/api/v1.proto", "alice")
 		require.NoError(t, err)
-		assert.Equal(t, content, blob.Content)
+		require.NotNil(t, conflict)
+		assert.Equal(t, "carol", conflict.Owner)
 
-		// Create a tree
-		tree := &TreeObject{
-			Entries: []TreeEntry{
-				{
-					Name: "test.txt",
-					Hash: hash,
-					Type: ObjectTypeBlob,
-					Mode: 0644,
-					Size: int64(len(content)),
-				},
-			},
-		}
+		conflict, err = lm.FindConflict(ctx, "protos/api/v1.proto", "carol")
+		require.NoError(t, err)
+		assert.Nil(t, conflict)
 
-		treeHash, err := repo.StoreTree(ctx, tree)
+		conflict, err = lm.FindConflict(ctx, "protos-old/v1.proto", "alice")
 		require.NoError(t, err)
+		assert.Nil(t, conflict)
 
-		// Create a commit
-		commit := &CommitObject{
-			RootTree:  treeHash,
-			Author:    "test@example.com",
-			Message:   "Test commit",
-			Timestamp: time.Now(),
-			Version:   1,
-		}
+		// Overlapping locks conflict in both directions
+		_, err = lm.Acquire(ctx, "protos/api", "alice", time.Hour)
+		assert.ErrorIs(t, err, ErrLockHeld)
+		_, err = lm.Acquire(ctx, "assets", "bob", time.Hour)
+		assert.ErrorIs(t, err, ErrLockHeld)
+	})
 
-		commitHash, err := repo.StoreCommit(ctx, commit)
+	t.Run("List", func(t *testing.T) {
+		locks, err := lm.List(ctx, "")
 		require.NoError(t, err)
+		require.Len(t, locks, 2)
+		assert.Equal(t, "assets/logo.psd", locks[0].Path)
+		assert.Equal(t, "protos", locks[1].Path)
 
-		// Create a version
-		versionInfo, err := repo.CreateVersion(ctx, commitHash, "Test commit")
+		locks, err = lm.List(ctx, "assets")
 		require.NoError(t, err)
-		assert.Equal(t, int64(1), versionInfo.Version)
-		assert.Equal(t, commitHash, versionInfo.CommitHash)
+		assert.Len(t, locks, 1)
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		err := lm.Release(ctx, "protos", "alice", false)
+		assert.ErrorIs(t, err, ErrLockHeld)
+
+		require.NoError(t, lm.Release(ctx, "protos", "carol", false))
+		require.NoError(t, lm.Release(ctx, "assets/logo.psd", "bob", true))
+		require.NoError(t, lm.Release(ctx, "not/locked", "bob", false))
+
+		locks, err := lm.List(ctx, "")
+		require.NoError(t, err)
+		assert.Empty(t, locks)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		_, err := lm.Acquire(ctx, "docs", "alice", time.Millisecond)
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+
+		locks, err := lm.List(ctx, "")
+		require.NoError(t, err)
+		assert.Empty(t, locks)
+
+		_, err = lm.Acquire(ctx, "docs", "bob", time.Hour)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := lm.Acquire(ctx, "src", "", time.Hour)
+		assert.Error(t, err)
+		_, err = lm.Acquire(ctx, "src", "alice", 0)
+		assert.Error(t, err)
 	})
 }
 
-func TestRepositoryPatchApplication(t *testing.T) {
+func TestOperationStore(t *testing.T) {
 	backend := NewMemoryBackend()
 	defer backend.Close()
 
-	repo := NewRepository(backend)
-	defer repo.Close()
-
+	store := NewOperationStore(backend)
 	ctx := context.Background()
+	now := time.Now()
 
-	// Create initial repository state with a file
-	originalContent := []byte("line 1\nline 2\nline 3\n")
-	blobHash, err := repo.StoreBlob(ctx, originalContent)
-	require.NoError(t, err)
-
-	tree := &TreeObject{
-		Entries: []TreeEntry{
-			{
-				Name: "test.txt",
-				Hash: blobHash,
-				Type: ObjectTypeBlob,
-				Mode: 0644,
-				Size: int64(len(originalContent)),
-			},
-		},
+	old := &Operation{ID: "old", Kind: "gc", Status: OperationSucceeded, CreatedAt: now.Add(-48 * time.Hour), FinishedAt: now.Add(-47 * time.Hour)}
+	running := &Operation{ID: "running", Kind: "fsck", Status: OperationRunning, CreatedAt: now.Add(-48 * time.Hour)}
+	recent := &Operation{ID: "recent", Kind: "gc", Status: OperationFailed, Error: "boom", CreatedAt: now, FinishedAt: now}
+	for _, op := range []*Operation{old, running, recent} {
+		require.NoError(t, store.Put(ctx, op))
 	}
 
-	treeHash, err := repo.StoreTree(ctx, tree)
-	require.NoError(t, err)
+	t.Run("GetAndList", func(t *testing.T) {
+		op, err := store.Get(ctx, "recent")
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		assert.Equal(t, "boom", op.Error)
+		assert.True(t, op.Done())
 
-	commit := &CommitObject{
-		RootTree:  treeHash,
-		Author:    "test@example.com",
-		Message:   "Initial commit",
-		Timestamp: time.Now(),
-		Version:   1,
-	}
+		missing, err := store.Get(ctx, "missing")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
 
-	commitHash, err := repo.StoreCommit(ctx, commit)
+		ops, err := store.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, ops, 3)
+		assert.Equal(t, "recent", ops[0].ID)
+	})
+
+	t.Run("PruneKeepsRunning", func(t *testing.T) {
+		pruned, err := store.Prune(ctx, now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, pruned)
+
+		op, err := store.Get(ctx, "old")
+		require.NoError(t, err)
+		assert.Nil(t, op)
+		op, err = store.Get(ctx, "running")
+		require.NoError(t, err)
+		assert.NotNil(t, op)
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		repo := NewRepository(NewMemoryBackend())
+		rootDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+		_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
+		require.NoError(t, err)
+
+		var done, total int64
+		_, err = repo.Fsck(WithProgress(ctx, func(d, t int64) { done, total = d, t }))
+		require.NoError(t, err)
+		assert.Positive(t, total)
+		assert.Equal(t, total-1, done)
+	})
+}
+
+func TestRepositoryMaintenance(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+
+	repo := NewRepository(backend)
+	ctx := context.Background()
+
+	rootDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootDir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "src", "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+
+	_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
 	require.NoError(t, err)
 
-	// Create initial version
-	_, err = repo.CreateVersion(ctx, commitHash, "Initial commit")
+	orphan, err := repo.StoreBlob(ctx, []byte("nobody references this"))
 	require.NoError(t, err)
 
-	t.Run("ApplyPatch", func(t *testing.T) {
-		// Create a patch that modifies line 2
-		patchData := []byte(`--- test.txt
-+++ test.txt
-@@ -1,3 +1,3 @@
- line 1
--line 2
-+line 2 modified
- line 3
-`)
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := repo.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.Blobs) // main.go, README.md, orphan
+		assert.Equal(t, 2, stats.Trees) // root, src
+		assert.Equal(t, 1, stats.Commits)
+		assert.Equal(t, 6, stats.Objects)
+		assert.Equal(t, 1, stats.Versions)
+		assert.Equal(t, int64(1), stats.CurrentVersion)
+		assert.Greater(t, stats.TotalBytes, stats.ObjectBytes)
+	})
 
-		// Apply patch
-		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Apply patch")
+	t.Run("FsckClean", func(t *testing.T) {
+		result, err := repo.Fsck(ctx)
 		require.NoError(t, err)
-		assert.Equal(t, int64(2), versionInfo.Version)
+		assert.Equal(t, 6, result.ObjectsChecked)
+		assert.Equal(t, 1, result.VersionsChecked)
+		assert.Empty(t, result.Problems)
+	})
 
-		// Verify the file content was updated
-		newContent, err := repo.ReadFile(ctx, versionInfo.Version, "test.txt")
+	t.Run("GarbageCollectDryRun", func(t *testing.T) {
+		result, err := repo.GarbageCollect(ctx, true)
 		require.NoError(t, err)
-		expectedContent := []byte("line 1\nline 2 modified\nline 3\n")
-		assert.Equal(t, expectedContent, newContent)
+		assert.Equal(t, 6, result.Scanned)
+		assert.Equal(t, 5, result.Reachable)
+		assert.Equal(t, 1, result.Removed)
+		assert.Greater(t, result.BytesFreed, int64(0))
 
-		// Verify original version is unchanged
-		originalContentRead, err := repo.ReadFile(ctx, 1, "test.txt")
+		exists, err := repo.Exists(ctx, orphan)
 		require.NoError(t, err)
-		assert.Equal(t, originalContent, originalContentRead)
+		assert.True(t, exists)
 	})
 
-	t.Run("ApplyPatchNewFile", func(t *testing.T) {
-		// Create a patch that adds a new file
-		patchData := []byte(`--- /dev/null
-+++ newfile.txt
-@@ -0,0 +1,2 @@
-+new line 1
-+new line 2
-`)
+	t.Run("GarbageCollect", func(t *testing.T) {
+		result, err := repo.GarbageCollect(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Removed)
 
-		// Apply patch
-		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Add new file")
+		exists, err := repo.Exists(ctx, orphan)
 		require.NoError(t, err)
-		assert.Equal(t, int64(3), versionInfo.Version)
+		assert.False(t, exists)
 
-		// Verify the new file exists
-		newFileContent, err := repo.ReadFile(ctx, versionInfo.Version, "newfile.txt")
+		// Everything referenced by the version survives
+		content, err := repo.ReadFile(ctx, 1, "src/main.go")
 		require.NoError(t, err)
-		expectedContent := []byte("new line 1\nnew line 2\n")
-		assert.Equal(t, expectedContent, newFileContent)
+		assert.Equal(t, "package main\n", string(content))
+	})
 
-		// Verify original file is still accessible
-		originalFileContent, err := repo.ReadFile(ctx, versionInfo.Version, "test.txt")
+	t.Run("FsckDetectsCorruption", func(t *testing.T) {
+		entry, err := repo.GetEntry(ctx, 1, "README.md")
 		require.NoError(t, err)
-		expectedOriginalContent := []byte("line 1\nline 2 modified\nline 3\n")
-		assert.Equal(t, expectedOriginalContent, originalFileContent)
+
+		// Overwrite the stored blob with content that no longer matches its hash
+		corrupt := []byte(`{"hash":"` + string(entry.Hash) + `","type":"blob","size":3,"content":"YmFk"}`)
+		require.NoError(t, backend.Put(ctx, "objects/"+string(entry.Hash), corrupt))
+
+		result, err := repo.Fsck(ctx)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Problems)
+		assert.Contains(t, result.Problems[0], string(entry.Hash))
 	})
+}
 
-	t.Run("ApplyPatchNestedDirectory", func(t *testing.T) {
-		// Create a patch that adds a file in a new directory
-		patchData := []byte(`--- /dev/null
-+++ src/main.go
-@@ -0,0 +1,3 @@
-+package main
-+
-+func main() {}
-`)
+func TestBackupRestore(t *testing.T) {
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	ctx := context.Background()
 
-		// Apply patch
-		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Add main.go")
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+	_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	target, err := OpenBackend(t.TempDir())
+	require.NoError(t, err)
+
+	first, err := repo.Backup(ctx, target, []byte(`{"ws":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, first.Objects, first.ObjectsWritten)
+
+	// A second backup only writes objects created since the first
+	_, err = repo.ApplyPatch(ctx, []byte("--- a/README.md\n+++ b/README.md\n@@ -1,1 +1,1 @@\n-# Test\n+# Changed\n"), "test@example.com", "Change README")
+	require.NoError(t, err)
+	second, err := repo.Backup(ctx, target, nil)
+	require.NoError(t, err)
+	assert.Greater(t, second.Objects, first.Objects)
+	assert.Less(t, second.ObjectsWritten, second.Objects)
+
+	t.Run("RestoreLatest", func(t *testing.T) {
+		restored := NewRepository(NewMemoryBackend())
+		manifest, err := restored.Restore(ctx, target, "")
 		require.NoError(t, err)
-		assert.Equal(t, int64(4), versionInfo.Version)
+		assert.Equal(t, second.Snapshot, manifest.ID)
 
-		// Verify the nested file exists
-		nestedFileContent, err := repo.ReadFile(ctx, versionInfo.Version, "src/main.go")
+		version, err := restored.GetCurrentVersion(ctx)
 		require.NoError(t, err)
-		expectedContent := []byte("package main\n\nfunc main() {}\n")
-		assert.Equal(t, expectedContent, nestedFileContent)
+		assert.Equal(t, int64(2), version)
 
-		// Verify directory listing
-		dirEntries, err := repo.ReadDirectory(ctx, versionInfo.Version, "src")
+		content, err := restored.ReadFile(ctx, 2, "README.md")
 		require.NoError(t, err)
-		assert.Len(t, dirEntries, 1)
-		assert.Equal(t, "main.go", dirEntries[0].Name)
-		assert.Equal(t, ObjectTypeBlob, dirEntries[0].Type)
+		assert.Equal(t, "# Changed\n", string(content))
+	})
+
+	t.Run("RestoreOlderSnapshot", func(t *testing.T) {
+		manifest, err := repo.Restore(ctx, target, first.Snapshot)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ws":1}`, string(manifest.Workspaces))
+
+		version, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), version)
+
+		stats, err := repo.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, first.Objects, stats.Objects)
+	})
+
+	t.Run("DamagedSnapshotIsRejected", func(t *testing.T) {
+		manifest, err := LoadBackupManifest(ctx, target, "")
+		require.NoError(t, err)
+		require.NoError(t, target.Put(ctx, "objects/"+string(manifest.Objects[0]), []byte(`{}`)))
+
+		_, err = repo.Restore(ctx, target, "")
+		assert.Error(t, err)
+
+		// The repository still holds the previously restored snapshot
+		version, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), version)
 	})
 }
+
+func TestBackendMigration(t *testing.T) {
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	ctx := context.Background()
+
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+	_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+	require.NoError(t, backend.Put(ctx, "lock/docs", []byte(`{"path":"docs"}`)))
+
+	dst, err := NewFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+
+	// Simulate an interrupted earlier run: one object copied, one stale lock
+	objects, err := backend.List(ctx, "objects/")
+	require.NoError(t, err)
+	data, err := backend.Get(ctx, objects[0])
+	require.NoError(t, err)
+	require.NoError(t, dst.Put(ctx, objects[0], data))
+	require.NoError(t, dst.Put(ctx, "lock/released", []byte(`{}`)))
+
+	result, err := repo.MigrateTo(ctx, dst)
+	require.NoError(t, err)
+	assert.Equal(t, len(objects), result.Objects)
+	assert.Equal(t, len(objects)-1, result.ObjectsCopied)
+	assert.Equal(t, 1, result.MetadataRemoved)
+
+	migrated := NewRepository(dst)
+	content, err := migrated.ReadFile(ctx, 1, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\n", string(content))
+
+	exists, err := dst.Exists(ctx, "lock/docs")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	fsck, err := migrated.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+
+	// A second run has nothing left to copy
+	result, err = repo.MigrateTo(ctx, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ObjectsCopied)
+}
+
+// commitDuringListBackend commits to a repository right after the first
+// object listing, as a write landing during a migration's first pass would
+type commitDuringListBackend struct {
+	*MemoryBackend
+	onList func()
+}
+
+func (b *commitDuringListBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := b.MemoryBackend.List(ctx, prefix)
+	if prefix == "objects/" && b.onList != nil {
+		onList := b.onList
+		b.onList = nil
+		onList()
+	}
+	return keys, err
+}
+
+func TestMigrationCopiesWritesDuringFirstPass(t *testing.T) {
+	ctx := context.Background()
+	backend := &commitDuringListBackend{MemoryBackend: NewMemoryBackend()}
+	repo := NewRepository(backend)
+	commitFiles(t, repo, t.TempDir(), map[string]string{"README.md": "# Test\n"}, "Initial commit")
+
+	backend.onList = func() {
+		commitFiles(t, repo, t.TempDir(), map[string]string{"README.md": "# Test\n", "new.txt": "written mid-migration\n"}, "Concurrent commit")
+	}
+
+	dst := NewMemoryBackend()
+	_, err := repo.MigrateTo(ctx, dst)
+	require.NoError(t, err)
+
+	migrated := NewRepository(dst)
+	content, err := migrated.ReadFile(ctx, 2, "new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "written mid-migration\n", string(content))
+
+	fsck, err := migrated.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+}
+
+func TestRehash(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	v1 := commitFiles(t, repo, t.TempDir(), map[string]string{
+		"README.md":   "# Test\n",
+		"src/main.go": "package main\n",
+	}, "Initial commit")
+	_, err := repo.ApplyPatch(ctx, []byte("diff --git a/README.md b/README.md\ndeleted file mode 100644\n--- a/README.md\n+++ /dev/null\n@@ -1 +0,0 @@\n-# Test\n"), "alice", "Remove README")
+	require.NoError(t, err)
+	assert.Equal(t, HashSHA256, repo.HashAlgorithm())
+
+	before, err := repo.GetVersionInfo(ctx, v1)
+	require.NoError(t, err)
+
+	result, err := repo.Rehash(ctx, HashBLAKE3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Versions)
+	assert.Equal(t, 1, result.TrashEntries)
+	assert.Greater(t, result.Objects, 0)
+	assert.Equal(t, HashBLAKE3, repo.HashAlgorithm())
+
+	// Versions keep their numbers and content under new commit hashes
+	after, err := repo.GetVersionInfo(ctx, v1)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.CommitHash, after.CommitHash)
+	version, err := repo.(*RepositoryImpl).GetVersionByCommit(ctx, after.CommitHash)
+	require.NoError(t, err)
+	assert.Equal(t, v1, version)
+	content, err := repo.ReadFile(ctx, v1, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\n", string(content))
+
+	entry, err := repo.GetEntry(ctx, v1, "README.md")
+	require.NoError(t, err)
+	trash, err := repo.ListTrash(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, entry.Hash, trash[0].Hash)
+
+	// New objects use the recorded algorithm, also after a reopen
+	hash, err := repo.StoreBlob(ctx, []byte("new\n"))
+	require.NoError(t, err)
+	assert.Equal(t, NewHasherWith(HashBLAKE3).ComputeBlobHash([]byte("new\n")), hash)
+	assert.Equal(t, HashBLAKE3, NewRepository(backend).HashAlgorithm())
+
+	fsck, err := repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+	assert.Equal(t, HashBLAKE3, fsck.HashAlgorithm)
+	assert.Greater(t, fsck.ObjectsByAlgorithm[HashSHA256], 0)
+
+	// Garbage collection drops the SHA-256 objects; running again is a no-op
+	_, err = repo.GarbageCollect(ctx, false)
+	require.NoError(t, err)
+	fsck, err = repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+	assert.Zero(t, fsck.ObjectsByAlgorithm[HashSHA256])
+
+	result, err = repo.Rehash(ctx, HashBLAKE3)
+	require.NoError(t, err)
+	assert.Zero(t, result.Objects)
+	assert.Zero(t, result.Versions)
+}
+
+func TestNewRepositoryWithHash(t *testing.T) {
+	ctx := context.Background()
+
+	// An empty repository takes the requested algorithm
+	backend := NewMemoryBackend()
+	repo, err := NewRepositoryWithHash(ctx, backend, HashBLAKE3)
+	require.NoError(t, err)
+	assert.Equal(t, HashBLAKE3, repo.HashAlgorithm())
+	assert.Equal(t, CurrentHashFormat, repo.HashFormat())
+	assert.Equal(t, CurrentHashFormat, NewRepository(backend).HashFormat())
+
+	// Once recorded, it is kept whatever is requested
+	repo, err = NewRepositoryWithHash(ctx, backend, HashSHA256)
+	require.NoError(t, err)
+	assert.Equal(t, HashBLAKE3, repo.HashAlgorithm())
+
+	// A repository with versions but no record predates algorithms
+	backend = NewMemoryBackend()
+	commitFiles(t, NewRepository(backend), t.TempDir(), map[string]string{"README.md": "# Test\n"}, "Initial commit")
+	repo, err = NewRepositoryWithHash(ctx, backend, HashBLAKE3)
+	require.NoError(t, err)
+	assert.Equal(t, HashSHA256, repo.HashAlgorithm())
+	assert.Equal(t, HashFormatJSON, repo.HashFormat())
+
+	_, err = ParseHashAlgorithm("md5")
+	assert.Error(t, err)
+	_, err = ParseHashFormat("2")
+	assert.Error(t, err)
+}
+
+func TestCanonicalHashFormat(t *testing.T) {
+	hasher := NewHasherFor(HashSHA256, HashFormatCanonical1)
+	a := TreeEntry{Name: "a.txt", Type: ObjectTypeBlob, Hash: NewHasher().ComputeBlobHash([]byte("a")), Size: 1, Mode: 0644}
+	b := TreeEntry{Name: "b", Type: ObjectTypeTree, Hash: NewHasher().ComputeBlobHash([]byte("b")), Mode: 0755}
+
+	// Entry order does not change a tree's hash, and entries are stored
+	// in the order they are hashed
+	forward, err := hasher.CreateTreeObject(&TreeObject{Entries: []TreeEntry{a, b}})
+	require.NoError(t, err)
+	backward, err := hasher.CreateTreeObject(&TreeObject{Entries: []TreeEntry{b, a}})
+	require.NoError(t, err)
+	assert.Equal(t, forward.Hash, backward.Hash)
+	assert.Equal(t, forward.Content, backward.Content)
+	assert.Equal(t, HashFormatCanonical1, forward.Format)
+	require.NoError(t, hasher.VerifyObject(forward))
+
+	// Nor does the layout of the stored JSON
+	reordered := *forward
+	reordered.Content = []byte(`{"entries":[{"type":"blob","name":"a.txt","size":1,"mode":420,"hash":"` + string(a.Hash) + `"},` +
+		`{"mode":493,"hash":"` + string(b.Hash) + `","name":"b","type":"tree","size":0}]}`)
+	assert.NoError(t, hasher.VerifyObject(&reordered))
+
+	// But every field does
+	changed := a
+	changed.Mode = 0755
+	other, err := hasher.CreateTreeObject(&TreeObject{Entries: []TreeEntry{changed, b}})
+	require.NoError(t, err)
+	assert.NotEqual(t, forward.Hash, other.Hash)
+
+	// A commit hashes the same in any time zone
+	parent := forward.Hash
+	commit := &CommitObject{RootTree: forward.Hash, Parent: &parent, Author: "alice", Message: "Change", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 5, time.UTC), Version: 2}
+	local := *commit
+	local.Timestamp = commit.Timestamp.In(time.FixedZone("CEST", 2*60*60))
+	utcHash, err := hasher.ComputeCommitHash(commit)
+	require.NoError(t, err)
+	localHash, err := hasher.ComputeCommitHash(&local)
+	require.NoError(t, err)
+	assert.Equal(t, utcHash, localHash)
+
+	// The JSON format hashes the stored bytes, so its hashes differ
+	jsonHash, err := NewHasher().ComputeCommitHash(commit)
+	require.NoError(t, err)
+	assert.NotEqual(t, utcHash, jsonHash)
+}
+
+// TestCanonicalEncodingCoversFields guards against adding a stored field to
+// a tree entry or commit without adding it to the canonical encoding, which
+// would let two objects that differ in it share a hash
+func TestCanonicalEncodingCoversFields(t *testing.T) {
+	for _, value := range []any{&TreeEntry{}, &CommitObject{}} {
+		typ := reflect.TypeOf(value).Elem()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			// Set just this field and check the encoding changes
+			changed := reflect.New(typ)
+			fieldValue := changed.Elem().Field(i)
+			switch fieldValue.Kind() {
+			case reflect.String:
+				fieldValue.SetString("x")
+			case reflect.Int32, reflect.Int64:
+				fieldValue.SetInt(1)
+			case reflect.Slice:
+				// A []byte or a []Hash of one element
+				fieldValue.Set(reflect.MakeSlice(field.Type, 1, 1))
+				if field.Type.Elem().Kind() == reflect.String {
+					fieldValue.Index(0).SetString("x")
+				} else {
+					fieldValue.Index(0).SetUint('x')
+				}
+			case reflect.Pointer:
+				fieldValue.Set(reflect.New(field.Type.Elem()))
+				fieldValue.Elem().SetString("x")
+			case reflect.Struct:
+				fieldValue.Set(reflect.ValueOf(time.Unix(1, 0)))
+			default:
+				t.Fatalf("%s.%s has a kind the test cannot set: %s", typ.Name(), field.Name, fieldValue.Kind())
+			}
+
+			var empty, encoded []byte
+			switch value.(type) {
+			case *TreeEntry:
+				empty, encoded = canonicalEntry(&TreeEntry{}), canonicalEntry(changed.Interface().(*TreeEntry))
+			case *CommitObject:
+				empty, encoded = canonicalCommit(&CommitObject{}), canonicalCommit(changed.Interface().(*CommitObject))
+			}
+			assert.NotEqual(t, empty, encoded, "%s.%s is stored but not in the canonical encoding", typ.Name(), field.Name)
+		}
+	}
+}
+
+func TestRehashHashFormat(t *testing.T) {
+	ctx := context.Background()
+
+	// A repository from before hash formats keeps hashing its JSON
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	v1 := commitFiles(t, repo, t.TempDir(), map[string]string{
+		"README.md":   "# Test\n",
+		"src/main.go": "package main\n",
+	}, "Initial commit")
+	v2 := commitFiles(t, repo, t.TempDir(), map[string]string{"README.md": "# Changed\n"}, "Change README")
+	assert.Equal(t, HashFormatJSON, repo.HashFormat())
+	before, err := repo.GetVersionInfo(ctx, v1)
+	require.NoError(t, err)
+
+	// Rehashing without an algorithm moves it to the current format only
+	result, err := repo.Rehash(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, HashSHA256, result.Algorithm)
+	assert.Equal(t, CurrentHashFormat, result.Format)
+	assert.Equal(t, 2, result.Versions)
+	assert.Equal(t, HashSHA256, repo.HashAlgorithm())
+	assert.Equal(t, CurrentHashFormat, repo.HashFormat())
+	assert.Equal(t, CurrentHashFormat, NewRepository(backend).HashFormat())
+
+	after, err := repo.GetVersionInfo(ctx, v1)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.CommitHash, after.CommitHash)
+	content, err := repo.ReadFile(ctx, v1, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\n", string(content))
+	content, err = repo.ReadFile(ctx, v2, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Changed\n", string(content))
+
+	// Objects of both formats verify until garbage collection drops the old
+	fsck, err := repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+	assert.Equal(t, CurrentHashFormat, fsck.HashFormat)
+	assert.Greater(t, fsck.ObjectsByFormat[HashFormatJSON], 0)
+	assert.Greater(t, fsck.ObjectsByFormat[CurrentHashFormat], 0)
+
+	_, err = repo.GarbageCollect(ctx, false)
+	require.NoError(t, err)
+	fsck, err = repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+	assert.Zero(t, fsck.ObjectsByFormat[HashFormatJSON])
+
+	// New commits use the format, and a second rehash has nothing to do
+	v3 := commitFiles(t, repo, t.TempDir(), map[string]string{"README.md": "# Again\n"}, "Change README again")
+	info, err := repo.GetVersionInfo(ctx, v3)
+	require.NoError(t, err)
+	commit, err := repo.Get(ctx, info.CommitHash)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentHashFormat, commit.Format)
+
+	result, err = repo.Rehash(ctx, "")
+	require.NoError(t, err)
+	assert.Zero(t, result.Objects)
+	assert.Zero(t, result.Versions)
+}
+
+func TestLargeBlobs(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+	repo := NewRepository(backend)
+
+	large := strings.Repeat("0123456789abcdef", largeBlobBytes/16+1)
+	version := commitFiles(t, repo, t.TempDir(), map[string]string{
+		"data/large.bin": large,
+		"README.md":      "# Test\n",
+	}, "Initial commit")
+
+	// Large blobs are stored raw under the hash the JSON form would have
+	entry, err := repo.GetEntry(ctx, version, "data/large.bin")
+	require.NoError(t, err)
+	assert.Equal(t, NewHasher().ComputeBlobHash([]byte(large)), entry.Hash)
+	assert.Equal(t, int64(len(large)), entry.Size)
+	stored, err := backend.Get(ctx, "objects/"+string(entry.Hash))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(stored), rawObjectPrefix))
+
+	content, size, err := repo.OpenFile(ctx, version, "data/large.bin")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, int64(len(large)), size)
+	assert.Equal(t, large, string(data))
+
+	data, err = repo.ReadFile(ctx, version, "data/large.bin")
+	require.NoError(t, err)
+	assert.Equal(t, large, string(data))
+
+	// Ranges of raw blobs seek past the bytes before them
+	for _, r := range []struct{ offset, length int64 }{{5, 10}, {int64(len(large)) - 3, 100}, {int64(len(large)) + 1, 0}} {
+		content, size, err := repo.OpenFileRange(ctx, version, "data/large.bin", r.offset, r.length)
+		require.NoError(t, err)
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		require.NoError(t, content.Close())
+		assert.Equal(t, int64(len(large)), size)
+		start, end := BlobRange(size, r.offset, r.length)
+		assert.Equal(t, large[start:end], string(data))
+	}
+
+	// Storing the same content again reuses the object
+	hash, size, err := repo.StoreBlobFrom(ctx, strings.NewReader(large))
+	require.NoError(t, err)
+	assert.Equal(t, entry.Hash, hash)
+	assert.Equal(t, int64(len(large)), size)
+
+	fsck, err := repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+
+	// Migration copies raw objects as they are
+	dst := NewMemoryBackend()
+	_, err = repo.MigrateTo(ctx, dst)
+	require.NoError(t, err)
+	data, err = NewRepository(dst).ReadFile(ctx, version, "data/large.bin")
+	require.NoError(t, err)
+	assert.Equal(t, large, string(data))
+
+	t.Run("Rehash", func(t *testing.T) {
+		repo := NewRepository(dst)
+		_, err := repo.Rehash(ctx, HashBLAKE3)
+		require.NoError(t, err)
+
+		entry, err := repo.GetEntry(ctx, version, "data/large.bin")
+		require.NoError(t, err)
+		assert.Equal(t, NewHasherWith(HashBLAKE3).ComputeBlobHash([]byte(large)), entry.Hash)
+		data, err := repo.ReadFile(ctx, version, "data/large.bin")
+		require.NoError(t, err)
+		assert.Equal(t, large, string(data))
+	})
+
+	t.Run("Corruption", func(t *testing.T) {
+		damaged := []byte(string(stored))
+		damaged[len(damaged)-1] ^= 0xff
+		require.NoError(t, backend.Put(ctx, "objects/"+string(entry.Hash), damaged))
+
+		content, _, err := repo.OpenBlob(ctx, entry.Hash)
+		require.NoError(t, err)
+		_, err = io.ReadAll(content)
+		assert.ErrorContains(t, err, "hash mismatch")
+		content.Close()
+
+		require.NoError(t, backend.Put(ctx, "objects/"+string(entry.Hash), stored[:len(stored)-1]))
+		_, err = repo.ReadFile(ctx, version, "data/large.bin")
+		assert.Error(t, err)
+
+		fsck, err := repo.Fsck(ctx)
+		require.NoError(t, err)
+		assert.Len(t, fsck.Problems, 1)
+	})
+}
+
+// countingBackend counts Get calls to measure backend round trips
+type countingBackend struct {
+	*MemoryBackend
+	gets int
+	read []string // Keys in the order they were read
+}
+
+func (c *countingBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	c.gets++
+	c.read = append(c.read, key)
+	return c.MemoryBackend.Get(ctx, key)
+}
+
+// reads returns how many reads were of keys under prefix
+func (c *countingBackend) reads(prefix string) int {
+	n := 0
+	for _, key := range c.read {
+		if strings.HasPrefix(key, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestVersionCache(t *testing.T) {
+	backend := &countingBackend{MemoryBackend: NewMemoryBackend()}
+	repo := NewRepository(backend)
+	ctx := context.Background()
+
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+	_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	readLatest := func() string {
+		version, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		content, err := repo.ReadFile(ctx, version, "README.md")
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	assert.Equal(t, "# Test\n", readLatest())
+
+	// Once warm, reading a top-level file only fetches its blob
+	backend.gets = 0
+	assert.Equal(t, "# Test\n", readLatest())
+	assert.Equal(t, 1, backend.gets)
+
+	// A new version replaces the cached entries
+	info, err := repo.ApplyPatch(ctx, []byte("--- a/README.md\n+++ b/README.md\n@@ -1,1 +1,1 @@\n-# Test\n+# Changed\n"), "test@example.com", "Change README")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Version)
+	assert.Equal(t, "# Changed\n", readLatest())
+
+	// Older versions are still read from the backend
+	content, err := repo.ReadFile(ctx, 1, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\n", string(content))
+
+	// Writes that bypass the version manager require an explicit invalidation
+	require.NoError(t, backend.Put(ctx, "version/current", []byte("1")))
+	repo.(*RepositoryImpl).Invalidate()
+	assert.Equal(t, "# Test\n", readLatest())
+}
+
+// flakyBackend fails its next failures Gets, or hangs them until the
+// context ends. Gets and Lists take delay, like a large transfer.
+type flakyBackend struct {
+	*MemoryBackend
+	failures int
+	hang     bool
+	delay    time.Duration
+	gets     int
+}
+
+func (f *flakyBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	time.Sleep(f.delay)
+	return f.MemoryBackend.List(ctx, prefix)
+}
+
+func (f *flakyBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	f.gets++
+	time.Sleep(f.delay)
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.failures > 0 {
+		f.failures--
+		return nil, fmt.Errorf("connection reset")
+	}
+	return f.MemoryBackend.Get(ctx, key)
+}
+
+func TestResilientBackend(t *testing.T) {
+	ctx := context.Background()
+	flaky := &flakyBackend{MemoryBackend: NewMemoryBackend()}
+	require.NoError(t, flaky.Put(ctx, "key", []byte("value")))
+	backend := NewResilientBackend(flaky, ResilienceConfig{
+		Timeout:          50 * time.Millisecond,
+		MaxAttempts:      3,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  100 * time.Millisecond,
+	})
+
+	// Transient failures are retried
+	flaky.failures = 2
+	data, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(data))
+	assert.Equal(t, 3, flaky.gets)
+
+	// Missing keys are answers: not retried, not counted
+	flaky.gets = 0
+	for i := 0; i < 3; i++ {
+		_, err = backend.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	}
+	assert.Equal(t, 3, flaky.gets)
+	assert.Equal(t, BreakerClosed, backend.State())
+
+	// A hanging backend times out instead of blocking the caller
+	flaky.hang = true
+	start := time.Now()
+	_, err = backend.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, BreakerClosed, backend.State())
+
+	// A second failed operation opens the breaker, which fails fast
+	_, err = backend.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+	assert.Equal(t, BreakerOpen, backend.State())
+	flaky.gets = 0
+	_, err = backend.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+	assert.Equal(t, 0, flaky.gets)
+
+	// After the cooldown one failure reopens it
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, backend.State())
+	flaky.hang, flaky.failures = false, 3
+	_, err = backend.Get(ctx, "key")
+	assert.Error(t, err)
+	assert.Equal(t, BreakerOpen, backend.State())
+
+	// And one success closes it
+	time.Sleep(150 * time.Millisecond)
+	data, err = backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(data))
+	assert.Equal(t, BreakerClosed, backend.State())
+
+	// The caller's own cancellation is not the backend's failure
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	flaky.hang = true
+	for i := 0; i < 3; i++ {
+		_, err = backend.Get(cancelled, "key")
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	assert.Equal(t, BreakerClosed, backend.State())
+
+	// Objects and listings take as long as they are large, past the timeout
+	flaky.hang = false
+	flaky.delay = 100 * time.Millisecond
+	require.NoError(t, flaky.MemoryBackend.Put(ctx, "objects/big", []byte("big")))
+	data, err = backend.Get(ctx, "objects/big")
+	require.NoError(t, err)
+	assert.Equal(t, "big", string(data))
+	keys, err := backend.List(ctx, "objects/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"objects/big"}, keys)
+
+	// Backoff is full jitter, from none to the whole doubled delay
+	jittered := NewResilientBackend(flaky, ResilienceConfig{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second})
+	var shortest, longest time.Duration = time.Hour, 0
+	for i := 0; i < 200; i++ {
+		delay := jittered.backoff(2)
+		require.LessOrEqual(t, delay, 2*time.Second)
+		shortest, longest = min(shortest, delay), max(longest, delay)
+	}
+	assert.Less(t, shortest, time.Second/2)
+	assert.Greater(t, longest, 3*time.Second/2)
+	assert.LessOrEqual(t, jittered.backoff(5), 3*time.Second)
+}
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	primary, backup := NewMemoryBackend(), NewMemoryBackend()
+	cs := NewContentStore(primary)
+
+	hash, err := cs.StoreBlob(ctx, []byte("hello\n"))
+	require.NoError(t, err)
+	rawHash, err := cs.storeRawBlob(ctx, []byte("large content\n"))
+	require.NoError(t, err)
+	for _, h := range []Hash{hash, rawHash} {
+		_, err := copyKey(ctx, primary, backup, "objects/"+string(h))
+		require.NoError(t, err)
+	}
+
+	// Damage both objects in place
+	data, err := primary.Get(ctx, "objects/"+string(hash))
+	require.NoError(t, err)
+	require.NoError(t, primary.Put(ctx, "objects/"+string(hash), bytes.Replace(data, []byte(`"content":"`), []byte(`"content":"AAAA`), 1)))
+	require.NoError(t, primary.Put(ctx, "objects/"+string(rawHash), []byte(rawObjectHeader(ObjectTypeBlob, 14, HashSHA256)+"LARGE content\n")))
+
+	// Without a repair source reads fail and the objects are recorded
+	_, err = cs.Get(ctx, hash)
+	assert.ErrorIs(t, err, ErrCorruptObject)
+	assert.Contains(t, err.Error(), "stored object verification failed")
+	blob, _, err := cs.OpenBlob(ctx, rawHash)
+	require.NoError(t, err)
+	_, err = io.ReadAll(blob)
+	blob.Close()
+	assert.ErrorIs(t, err, ErrCorruptObject)
+
+	records, err := cs.CorruptObjects(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, rawHash, records[0].Hash)
+	assert.Equal(t, hash, records[1].Hash)
+	assert.Equal(t, 1, records[1].Detections)
+	assert.Nil(t, records[1].RepairedAt)
+	assert.Contains(t, records[1].RepairError, "no repair source")
+
+	// With one, a read replaces the object with the verified copy
+	cs.SetRepairSource(backup)
+	obj, err := cs.Get(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(obj.Content))
+
+	// A streamed read has already returned the damaged content, so only
+	// the next read gets the repaired object
+	blob, _, err = cs.OpenBlob(ctx, rawHash)
+	require.NoError(t, err)
+	_, err = io.ReadAll(blob)
+	blob.Close()
+	assert.ErrorIs(t, err, ErrCorruptObject)
+	blob, _, err = cs.OpenBlob(ctx, rawHash)
+	require.NoError(t, err)
+	content, err := io.ReadAll(blob)
+	blob.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "large content\n", string(content))
+
+	records, err = cs.CorruptObjects(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	records, err = cs.CorruptObjects(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, 2, record.Detections)
+		assert.NotNil(t, record.RepairedAt)
+		assert.Empty(t, record.RepairError)
+	}
+
+	// A damaged copy in the repair source is not used
+	require.NoError(t, primary.Put(ctx, "objects/"+string(hash), []byte("garbage")))
+	require.NoError(t, backup.Put(ctx, "objects/"+string(hash), []byte("garbage")))
+	_, err = cs.Get(ctx, hash)
+	assert.ErrorIs(t, err, ErrCorruptObject)
+	records, err = cs.CorruptObjects(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0].RepairError, "repair source")
+
+	// Quarantine records are not backed up
+	target := NewMemoryBackend()
+	result, err := NewRepository(primary).Backup(ctx, target, nil)
+	require.NoError(t, err)
+	data, err = target.Get(ctx, snapshotKey(result.Snapshot))
+	require.NoError(t, err)
+	var manifest BackupManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	for key := range manifest.Metadata {
+		assert.False(t, strings.HasPrefix(key, quarantinePrefix), key)
+	}
+}
+
+// downBackend fails every write while down is set
+type downBackend struct {
+	*MemoryBackend
+	down atomic.Bool
+}
+
+func (d *downBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	if d.down.Load() {
+		return fmt.Errorf("region unreachable")
+	}
+	return d.MemoryBackend.PutStream(ctx, key, r)
+}
+
+func TestReplicatedBackend(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemoryBackend()
+	east := &downBackend{MemoryBackend: NewMemoryBackend()}
+	west := NewMemoryBackend()
+	backend := NewReplicatedBackend(primary, []StorageBackend{east, west}, "primary", "east", "west")
+	defer backend.Close()
+
+	mirrored := func(b StorageBackend, key, want string) func() bool {
+		return func() bool {
+			data, err := b.Get(ctx, key)
+			if want == "" {
+				return errors.Is(err, ErrKeyNotFound)
+			}
+			return err == nil && string(data) == want
+		}
+	}
+
+	// Writes land in the primary at once and in the replicas soon after
+	require.NoError(t, backend.Put(ctx, "a", []byte("1")))
+	require.NoError(t, backend.PutStream(ctx, "b", strings.NewReader("2")))
+	data, err := primary.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(data))
+	for _, replica := range []StorageBackend{east, west} {
+		require.Eventually(t, mirrored(replica, "a", "1"), 5*time.Second, 10*time.Millisecond)
+		require.Eventually(t, mirrored(replica, "b", "2"), 5*time.Second, 10*time.Millisecond)
+	}
+	require.NoError(t, backend.Delete(ctx, "a"))
+	require.Eventually(t, mirrored(west, "a", ""), 5*time.Second, 10*time.Millisecond)
+
+	// An unreachable replica falls behind without holding writes up
+	east.down.Store(true)
+	require.NoError(t, backend.Put(ctx, "c", []byte("3")))
+	require.NoError(t, backend.Put(ctx, "c", []byte("4")))
+	require.Eventually(t, mirrored(west, "c", "4"), 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return backend.Status()[1].Failures > 0 }, 5*time.Second, 10*time.Millisecond)
+	status := backend.Status()
+	require.Len(t, status, 3)
+	assert.True(t, status[0].Active)
+	assert.Equal(t, "east", status[1].Name)
+	assert.Equal(t, 1, status[1].Pending)
+	assert.Greater(t, status[1].Lag, time.Duration(0))
+	assert.Contains(t, status[1].LastError, "region unreachable")
+	assert.Equal(t, 0, status[2].Pending)
+
+	// Failing over to a replica that lacks writes takes force
+	assert.Error(t, backend.Failover("east", false))
+	assert.Error(t, backend.Failover("north", false))
+	require.NoError(t, backend.Failover("west", false))
+	assert.True(t, backend.Status()[2].Active)
+	data, err = backend.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, "4", string(data))
+
+	// The old primary now mirrors the new active backend
+	require.NoError(t, backend.Put(ctx, "d", []byte("5")))
+	data, err = west.Get(ctx, "d")
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(data))
+	require.Eventually(t, mirrored(primary, "d", "5"), 5*time.Second, 10*time.Millisecond)
+
+	// And the replica that was down catches up once it is back
+	east.down.Store(false)
+	require.Eventually(t, mirrored(east, "c", "4"), 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, mirrored(east, "d", "5"), 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return backend.Status()[1].Pending == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, backend.Status()[1].LastError)
+}
+
+// reopenedBackend outlives Close, as a bucket outlives the server
+type reopenedBackend struct {
+	*downBackend
+}
+
+func (reopenedBackend) Close() error { return nil }
+
+func TestReplicatedBackendRestart(t *testing.T) {
+	ctx := context.Background()
+	primary := reopenedBackend{&downBackend{MemoryBackend: NewMemoryBackend()}}
+	east := reopenedBackend{&downBackend{MemoryBackend: NewMemoryBackend()}}
+	mirrored := func(b StorageBackend, key, want string) func() bool {
+		return func() bool {
+			data, err := b.Get(ctx, key)
+			if want == "" {
+				return errors.Is(err, ErrKeyNotFound)
+			}
+			return err == nil && string(data) == want
+		}
+	}
+
+	// Writes east has not mirrored when the server stops are mirrored
+	// after it starts again
+	backend := NewReplicatedBackend(primary, []StorageBackend{east}, "primary", "east")
+	require.Eventually(t, func() bool { return !backend.Status()[1].Resync }, 5*time.Second, 10*time.Millisecond)
+	east.down.Store(true)
+	require.NoError(t, backend.Put(ctx, "a", []byte("1")))
+	require.NoError(t, backend.Close())
+	east.down.Store(false)
+
+	backend = NewReplicatedBackend(primary, []StorageBackend{east}, "primary", "east")
+	require.Eventually(t, mirrored(east, "a", "1"), 5*time.Second, 10*time.Millisecond)
+
+	// A failover is kept across restarts
+	require.Eventually(t, func() bool { return backend.Status()[1].Pending == 0 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, backend.Failover("east", false))
+	require.NoError(t, backend.Close())
+	backend = NewReplicatedBackend(primary, []StorageBackend{east}, "primary", "east")
+	defer backend.Close()
+	assert.True(t, backend.Status()[1].Active)
+
+	// Too many waiting writes are dropped for a full resync, which also
+	// removes keys the active backend lacks
+	require.NoError(t, primary.MemoryBackend.Put(ctx, "stale", []byte("x")))
+	backend.SetMaxPending(2)
+	primary.down.Store(true)
+	for _, key := range []string{"b", "c", "d"} {
+		require.NoError(t, backend.Put(ctx, key, []byte(key)))
+	}
+	status := backend.Status()[0]
+	assert.True(t, status.Resync)
+	assert.Zero(t, status.Pending)
+
+	primary.down.Store(false)
+	for _, key := range []string{"b", "c", "d"} {
+		require.Eventually(t, mirrored(primary, key, key), 5*time.Second, 10*time.Millisecond)
+	}
+	require.Eventually(t, mirrored(primary, "stale", ""), 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return !backend.Status()[0].Resync }, 5*time.Second, 10*time.Millisecond)
+}
+
+// commitFiles replaces the contents of dir with files and commits it
+func commitFiles(t *testing.T, repo Repository, dir string, files map[string]string, message string) int64 {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, entry.Name())))
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	info, err := repo.CreateCommitFromFileSystem(context.Background(), dir, "test@example.com", message)
+	require.NoError(t, err)
+	return info.Version
+}
+
+func TestDiffVersions(t *testing.T) {
+	repo := NewRepository(NewMemoryBackend())
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	body := strings.Repeat("shared line of content\n", 20)
+	v1 := commitFiles(t, repo, dir, map[string]string{
+		"README.md":       "# Test\n",
+		"src/main.go":     "package main\n" + body,
+		"src/util.go":     "package util\n" + body,
+		"docs/guide.md":   "guide\n",
+		"config/app.yaml": "name: app\n",
+	}, "Initial commit")
+	v2 := commitFiles(t, repo, dir, map[string]string{
+		"README.md":       "# Changed\n",
+		"cmd/main.go":     "package main\n" + body,           // Exact rename of src/main.go
+		"lib/util.go":     "package util\n" + body + "end\n", // Rename with an edit
+		"docs/guide.md":   "guide\n",
+		"docs/copy.md":    "package main\n" + body + "copy\n", // Copy of src/main.go with an addition
+		"config/app.json": "{}\n",
+	}, "Reorganize")
+
+	byPath := func(changes []FileChange) map[string]FileChange {
+		result := make(map[string]FileChange)
+		for _, change := range changes {
+			result[change.Path] = change
+		}
+		return result
+	}
+
+	t.Run("Without Detection", func(t *testing.T) {
+		changes, err := repo.DiffVersions(ctx, v1, v2, DiffOptions{})
+		require.NoError(t, err)
+
+		paths := make([]string, 0, len(changes))
+		for _, change := range changes {
+			paths = append(paths, string(change.Type)+" "+change.Path)
+		}
+		assert.Equal(t, []string{
+			"modified README.md",
+			"added cmd/main.go",
+			"added config/app.json",
+			"deleted config/app.yaml",
+			"added docs/copy.md",
+			"added lib/util.go",
+			"deleted src/main.go",
+			"deleted src/util.go",
+		}, paths)
+	})
+
+	t.Run("Renames", func(t *testing.T) {
+		changes, err := repo.DiffVersions(ctx, v1, v2, DiffOptions{DetectRenames: true})
+		require.NoError(t, err)
+		changed := byPath(changes)
+
+		assert.Equal(t, ChangeRenamed, changed["cmd/main.go"].Type)
+		assert.Equal(t, "src/main.go", changed["cmd/main.go"].OldPath)
+		assert.Equal(t, 100, changed["cmd/main.go"].Similarity)
+
+		assert.Equal(t, ChangeRenamed, changed["lib/util.go"].Type)
+		assert.Equal(t, "src/util.go", changed["lib/util.go"].OldPath)
+		assert.Less(t, changed["lib/util.go"].Similarity, 100)
+		assert.GreaterOrEqual(t, changed["lib/util.go"].Similarity, DefaultRenameThreshold)
+
+		// Dissimilar files stay a deletion and an addition
+		assert.Equal(t, ChangeAdded, changed["config/app.json"].Type)
+		assert.Equal(t, ChangeDeleted, changed["config/app.yaml"].Type)
+
+		// Renamed sources are no longer reported as deleted
+		assert.NotContains(t, changed, "src/main.go")
+		assert.NotContains(t, changed, "src/util.go")
+		assert.Equal(t, ChangeAdded, changed["docs/copy.md"].Type)
+	})
+
+	t.Run("Copies", func(t *testing.T) {
+		changes, err := repo.DiffVersions(ctx, v1, v2, DiffOptions{DetectRenames: true, DetectCopies: true})
+		require.NoError(t, err)
+		changed := byPath(changes)
+
+		assert.Equal(t, ChangeCopied, changed["docs/copy.md"].Type)
+		assert.Equal(t, "src/main.go", changed["docs/copy.md"].OldPath)
+		assert.Equal(t, ChangeRenamed, changed["cmd/main.go"].Type)
+	})
+
+	t.Run("Threshold", func(t *testing.T) {
+		changes, err := repo.DiffVersions(ctx, v1, v2, DiffOptions{DetectRenames: true, Threshold: 100})
+		require.NoError(t, err)
+		changed := byPath(changes)
+
+		assert.Equal(t, ChangeRenamed, changed["cmd/main.go"].Type)
+		assert.Equal(t, ChangeAdded, changed["lib/util.go"].Type)
+		assert.Equal(t, ChangeDeleted, changed["src/util.go"].Type)
+	})
+
+	t.Run("From Empty", func(t *testing.T) {
+		changes, err := repo.DiffVersions(ctx, 0, v1, DiffOptions{DetectRenames: true})
+		require.NoError(t, err)
+		require.Len(t, changes, 5)
+		for _, change := range changes {
+			assert.Equal(t, ChangeAdded, change.Type)
+		}
+	})
+}
+
+func TestChangeStats(t *testing.T) {
+	repo := NewRepository(NewMemoryBackend())
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	body := strings.Repeat("shared line of content\n", 20)
+	v1 := commitFiles(t, repo, dir, map[string]string{
+		"README.md":   "# Test\nline one\nline two\nline three\n",
+		"src/main.go": "package main\n" + body,
+		"old.txt":     "gone\nsoon\n",
+		"logo.png":    "\x89PNG\x00\x01",
+	}, "Initial commit")
+	v2 := commitFiles(t, repo, dir, map[string]string{
+		"README.md":   "# Changed\nline one\nline three\nline four\nline five\n",
+		"cmd/main.go": "package main\n" + body + "end\n",
+		"logo.png":    "\x89PNG\x00\x02",
+		"new.txt":     "fresh\n",
+	}, "Change files")
+
+	stats, err := repo.ChangeStats(ctx, v1, v2)
+	require.NoError(t, err)
+
+	summary := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		name := stat.Path
+		if stat.OldPath != "" {
+			name = stat.OldPath + " => " + stat.Path
+		}
+		summary = append(summary, fmt.Sprintf("%s %s +%d -%d binary=%v", stat.Type, name, stat.Insertions, stat.Deletions, stat.Binary))
+	}
+	assert.Equal(t, []string{
+		"modified README.md +3 -2 binary=false",
+		"renamed src/main.go => cmd/main.go +1 -0 binary=false",
+		"modified logo.png +0 -0 binary=true",
+		"added new.txt +1 -0 binary=false",
+		"deleted old.txt +0 -2 binary=false",
+	}, summary)
+
+	// The first version counts every line as inserted
+	stats, err = repo.ChangeStats(ctx, 0, v1)
+	require.NoError(t, err)
+	require.Len(t, stats, 4)
+	assert.Equal(t, "README.md", stats[0].Path)
+	assert.Equal(t, 4, stats[0].Insertions)
+}
+
+func TestCountLineChanges(t *testing.T) {
+	tests := []struct {
+		before, after         string
+		insertions, deletions int
+	}{
+		{"", "", 0, 0},
+		{"a\nb\n", "a\nb\n", 0, 0},
+		{"", "a\nb\n", 2, 0},
+		{"a\nb\n", "", 0, 2},
+		{"a\nb\nc\n", "a\nx\nc\n", 1, 1},
+		{"a\nb\nc\nd\n", "b\nc\nd\ne\n", 1, 1},
+		{"a", "a\n", 1, 1}, // Adding the final newline changes the line
+		{"a\nb\nc\n", "c\nb\na\n", 2, 2},
+	}
+	for _, test := range tests {
+		insertions, deletions := countLineChanges([]byte(test.before), []byte(test.after))
+		assert.Equal(t, test.insertions, insertions, "insertions from %q to %q", test.before, test.after)
+		assert.Equal(t, test.deletions, deletions, "deletions from %q to %q", test.before, test.after)
+	}
+
+	// Rewrites beyond the edit limit count every differing line
+	var before, after strings.Builder
+	for i := 0; i < maxStatEdits; i++ {
+		fmt.Fprintf(&before, "old %d\n", i)
+		fmt.Fprintf(&after, "new %d\n", i)
+	}
+	insertions, deletions := countLineChanges([]byte(before.String()), []byte(after.String()))
+	assert.Equal(t, maxStatEdits, insertions)
+	assert.Equal(t, maxStatEdits, deletions)
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	tags := NewTagManager(NewMemoryBackend())
+	now := time.Now()
+
+	require.NoError(t, tags.Create(ctx, &Tag{Name: "nightly-2024-06-01", Version: 3, CreatedAt: now}))
+	require.NoError(t, tags.Create(ctx, &Tag{Name: "release/1.0", Version: 5, CreatedAt: now}))
+
+	// Tags are pins and never move
+	err := tags.Create(ctx, &Tag{Name: "release/1.0", Version: 6})
+	assert.ErrorIs(t, err, ErrTagExists)
+	tag, err := tags.Get(ctx, "release/1.0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), tag.Version)
+
+	for _, name := range []string{"", "a b", "../x", "a//b", "x/", strings.Repeat("a", 129)} {
+		assert.Error(t, tags.Create(ctx, &Tag{Name: name, Version: 1}), name)
+	}
+
+	list, err := tags.List(ctx, "nightly-")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, int64(3), list[0].Version)
+
+	require.NoError(t, tags.Delete(ctx, "nightly-2024-06-01"))
+	require.NoError(t, tags.Delete(ctx, "nightly-2024-06-01"))
+	missing, err := tags.Get(ctx, "nightly-2024-06-01")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestActivityStore(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	dir := t.TempDir()
+	v1 := commitFiles(t, repo, dir, map[string]string{
+		"README.md":       "# Test\n",
+		"src/app/main.go": "package main\n",
+	}, "Initial commit")
+	v2 := commitFiles(t, repo, dir, map[string]string{
+		"README.md":       "# Test\n",
+		"src/app/main.go": "package main\n\nfunc main() {}\n",
+		"src/lib/util.go": "package lib\n",
+	}, "Add main")
+
+	metrics, err := ComputeVersionMetrics(ctx, repo, v1, v2)
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", metrics.Author)
+	assert.Equal(t, ChangeCounts{Files: 2, Insertions: 3, BytesAdded: 41}, metrics.ChangeCounts)
+	assert.Equal(t, map[string]ChangeCounts{
+		"src/app": {Files: 1, Insertions: 2, BytesAdded: 29},
+		"src/lib": {Files: 1, Insertions: 1, BytesAdded: 12},
+	}, metrics.Directories)
+
+	// Backfill records every version once
+	activity := NewActivityStore(backend)
+	count, err := activity.Backfill(ctx, repo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = activity.Backfill(ctx, repo)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Metrics are read back from the backend in version order
+	all, err := NewActivityStore(backend).Query(ctx, time.Time{}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, v1, all[0].Version)
+	assert.Equal(t, map[string]ChangeCounts{
+		"":        {Files: 1, Insertions: 1, BytesAdded: 7},
+		"src/app": {Files: 1, Insertions: 1, BytesAdded: 13},
+	}, all[0].Directories)
+	assert.True(t, metrics.Time.Equal(all[1].Time))
+	assert.Equal(t, metrics.ChangeCounts, all[1].ChangeCounts)
+	assert.Equal(t, metrics.Directories, all[1].Directories)
+
+	none, err := activity.Query(ctx, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	// A fresh store indexes the records from their keys: a backfill with
+	// nothing missing reads none, and a query reads only those in range
+	counting := &countingBackend{MemoryBackend: backend}
+	indexed := NewActivityStore(counting)
+	count, err = indexed.Backfill(ctx, repo)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Zero(t, counting.reads("metrics/"))
+	latest, err := indexed.Query(ctx, all[1].Time, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.Equal(t, v2, latest[0].Version)
+	assert.Equal(t, 1, counting.reads("metrics/"))
+
+	// Records stored under keys without their time are moved once
+	legacy := NewMemoryBackend()
+	data, err := json.Marshal(metrics)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Put(ctx, fmt.Sprintf("metrics/%020d", v2), data))
+	moved, err := NewActivityStore(legacy).Query(ctx, time.Time{}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, moved, 1)
+	assert.Equal(t, v2, moved[0].Version)
+	keys, err := legacy.List(ctx, "metrics/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{metricsKey(v2, metrics.Time)}, keys)
+}
+
+func TestPruneHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	dir := t.TempDir()
+	var versions []int64
+	for i := 1; i <= 5; i++ {
+		versions = append(versions, commitFiles(t, repo, dir, map[string]string{
+			"README.md":                     "# Test\n",
+			fmt.Sprintf("notes/v%d.txt", i): fmt.Sprintf("version %d\n", i),
+		}, fmt.Sprintf("Version %d", i)))
+	}
+	policy := RetentionPolicy{KeepVersions: map[int64]bool{versions[1]: true, versions[3]: true}}
+
+	// A dry run reports without changing anything
+	before, err := repo.GetVersionInfo(ctx, versions[4])
+	require.NoError(t, err)
+	result, err := repo.PruneHistory(ctx, policy, true)
+	require.NoError(t, err)
+	assert.Equal(t, &PruneResult{Kept: 3, Pruned: 2, Rewritten: 3, OldestKept: versions[1]}, result)
+	after, err := repo.GetVersionInfo(ctx, versions[4])
+	require.NoError(t, err)
+	assert.Equal(t, before.CommitHash, after.CommitHash)
+	_, err = repo.GetVersionInfo(ctx, versions[0])
+	require.NoError(t, err)
+
+	result, err = repo.PruneHistory(ctx, policy, false)
+	require.NoError(t, err)
+	assert.Equal(t, &PruneResult{Kept: 3, Pruned: 2, Rewritten: 3, OldestKept: versions[1]}, result)
+
+	for _, version := range []int64{versions[0], versions[2]} {
+		_, err := repo.GetVersionInfo(ctx, version)
+		assert.Error(t, err, "version %d", version)
+	}
+
+	// Kept versions keep their content and chain to the previous kept one
+	var parent *Hash
+	for i, version := range []int64{versions[1], versions[3], versions[4]} {
+		info, err := repo.GetVersionInfo(ctx, version)
+		require.NoError(t, err)
+		commit, err := repo.GetCommit(ctx, info.CommitHash)
+		require.NoError(t, err)
+		if i == 0 {
+			assert.Nil(t, commit.Parent)
+		} else {
+			require.NotNil(t, commit.Parent)
+			assert.Equal(t, *parent, *commit.Parent)
+		}
+		hash := info.CommitHash
+		parent = &hash
+
+		content, err := repo.ReadFile(ctx, version, fmt.Sprintf("notes/v%d.txt", version))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("version %d\n", version), string(content))
+	}
+
+	// The pruned versions' objects go with the next garbage collection
+	gc, err := repo.GarbageCollect(ctx, false)
+	require.NoError(t, err)
+	assert.Greater(t, gc.Removed, 0)
+	fsck, err := repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+
+	// Running again with the same policy has nothing to do
+	result, err = repo.PruneHistory(ctx, policy, false)
+	require.NoError(t, err)
+	assert.Equal(t, &PruneResult{Kept: 3, Rewritten: 0, OldestKept: versions[1]}, result)
+
+	// Versions new enough are kept by age
+	result, err = repo.PruneHistory(ctx, RetentionPolicy{KeepSince: time.Now().Add(-time.Hour)}, true)
+	require.NoError(t, err)
+	assert.Zero(t, result.Pruned)
+
+	// The version a trashed file was deleted after is kept, so the file can
+	// still be restored once the pruned objects are collected
+	deleted, err := repo.ApplyPatch(ctx, []byte("diff --git a/notes/v5.txt b/notes/v5.txt\ndeleted file mode 100644\n--- a/notes/v5.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-version 5\n"), "alice", "Remove v5")
+	require.NoError(t, err)
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Test\n", "notes/v7.txt": "version 7\n"}, "Version 7")
+	result, err = repo.PruneHistory(ctx, RetentionPolicy{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, versions[4], result.OldestKept)
+	assert.Equal(t, 2, result.Kept)
+	_, err = repo.GetVersionInfo(ctx, deleted.Version-1)
+	require.NoError(t, err)
+	_, err = repo.GarbageCollect(ctx, false)
+	require.NoError(t, err)
+	_, restored, err := repo.RestoreDeletedPath(ctx, "notes/v5.txt", "alice", "")
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+}
+
+func TestFileHistory(t *testing.T) {
+	repo := NewRepository(NewMemoryBackend())
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	body := strings.Repeat("func helper() {}\n", 10)
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Test\n", "src/util.go": "package src\n" + body}, "Add util")
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Changed\n", "src/util.go": "package src\n" + body}, "Unrelated change")
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Changed\n", "src/util.go": "package src\n" + body + "// more\n"}, "Edit util")
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Changed\n", "lib/util.go": "package lib\n" + body + "// more\n"}, "Move util to lib")
+
+	history, err := repo.FileHistory(ctx, "lib/util.go", 0)
+	require.NoError(t, err)
+
+	messages := make([]string, 0, len(history))
+	for _, entry := range history {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"Move util to lib", "Edit util", "Add util"}, messages)
+
+	assert.Equal(t, ChangeRenamed, history[0].Change.Type)
+	assert.Equal(t, "lib/util.go", history[0].Change.Path)
+	assert.Equal(t, "src/util.go", history[0].Change.OldPath)
+	assert.Equal(t, "src/util.go", history[1].Change.Path)
+	assert.Equal(t, ChangeAdded, history[2].Change.Type)
+	assert.Equal(t, int64(1), history[2].Version)
+
+	t.Run("Limit", func(t *testing.T) {
+		history, err := repo.FileHistory(ctx, "lib/util.go", 2)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := repo.FileHistory(ctx, "src/util.go", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestCommitGraph(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	dir := t.TempDir()
+	for i := 1; i <= 5; i++ {
+		commitFiles(t, repo, dir, map[string]string{
+			"README.md":                     "# Test\n",
+			fmt.Sprintf("notes/v%d.txt", i): fmt.Sprintf("version %d\n", i),
+		}, fmt.Sprintf("Version %d", i))
+	}
+
+	// Each version follows the one before it
+	for version := int64(1); version <= 5; version++ {
+		entry, err := repo.CommitGraphEntry(ctx, version)
+		require.NoError(t, err)
+		info, err := repo.GetVersionInfo(ctx, version)
+		require.NoError(t, err)
+		assert.Equal(t, info.CommitHash, entry.Commit)
+		assert.Equal(t, version, entry.Generation)
+		if version == 1 {
+			assert.Empty(t, entry.Parents)
+		} else {
+			assert.Equal(t, []int64{version - 1}, entry.Parents)
+		}
+	}
+
+	ancestor, err := repo.IsAncestor(ctx, 2, 5)
+	require.NoError(t, err)
+	assert.True(t, ancestor)
+	ancestor, err = repo.IsAncestor(ctx, 5, 2)
+	require.NoError(t, err)
+	assert.False(t, ancestor)
+	ancestor, err = repo.IsAncestor(ctx, 3, 3)
+	require.NoError(t, err)
+	assert.True(t, ancestor)
+	base, err := repo.MergeBase(ctx, 5, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), base)
+
+	// Backups leave the graph out; it is rebuilt from the versions
+	target := NewMemoryBackend()
+	_, err = repo.Backup(ctx, target, nil)
+	require.NoError(t, err)
+	keys, err := target.List(ctx, commitGraphPrefix)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// The graph is stored, and history walks read only the commits they
+	// return: README.md was added in version 1 and never changed
+	for version := int64(2); version <= 5; version++ {
+		info, err := repo.GetVersionInfo(ctx, version)
+		require.NoError(t, err)
+		require.NoError(t, backend.Delete(ctx, "objects/"+string(info.CommitHash)))
+	}
+	history, err := NewRepository(backend).FileHistory(ctx, "README.md", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, int64(1), history[0].Version)
+}
+
+func TestCommitGraphAfterRewrites(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	dir := t.TempDir()
+	for i := 1; i <= 5; i++ {
+		commitFiles(t, repo, dir, map[string]string{fmt.Sprintf("notes/v%d.txt", i): fmt.Sprintf("version %d\n", i)}, fmt.Sprintf("Version %d", i))
+	}
+	_, err := repo.CommitGraphEntry(ctx, 5)
+	require.NoError(t, err)
+
+	// A rehash gives every version a new commit; the graph follows, also
+	// when another repository opens the stored one
+	_, err = repo.Rehash(ctx, HashBLAKE3)
+	require.NoError(t, err)
+	for _, r := range []Repository{repo, NewRepository(backend)} {
+		info, err := r.GetVersionInfo(ctx, 5)
+		require.NoError(t, err)
+		entry, err := r.CommitGraphEntry(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, info.CommitHash, entry.Commit)
+	}
+
+	// Pruned versions leave the graph and the kept ones chain up
+	_, err = repo.PruneHistory(ctx, RetentionPolicy{KeepVersions: map[int64]bool{2: true, 4: true}}, false)
+	require.NoError(t, err)
+	_, err = repo.CommitGraphEntry(ctx, 3)
+	assert.Error(t, err)
+	entry, err := repo.CommitGraphEntry(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, entry.Parents)
+	assert.Equal(t, int64(1), entry.Generation)
+	entry, err = repo.CommitGraphEntry(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4}, entry.Parents)
+	assert.Equal(t, int64(3), entry.Generation)
+
+	base, err := repo.MergeBase(ctx, 4, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), base)
+	ancestor, err := repo.IsAncestor(ctx, 2, 5)
+	require.NoError(t, err)
+	assert.True(t, ancestor)
+}
+
+func TestChangedPathFilter(t *testing.T) {
+	filter := newChangedPathFilter([]string{"src/backend/main.go", "README.md"})
+	for _, path := range []string{"src", "src/backend", "src/backend/main.go", "/src/backend/", "README.md"} {
+		assert.True(t, mayHaveChanged(filter, path), path)
+	}
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if mayHaveChanged(filter, fmt.Sprintf("other/file%d.txt", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 50)
+
+	// Without a usable filter every path may have changed
+	assert.True(t, mayHaveChanged(nil, "anything"))
+	assert.True(t, mayHaveChanged(filter, ""))
+	unknown := append([]byte{changedPathsVersion + 1}, filter[1:]...)
+	assert.True(t, mayHaveChanged(unknown, "other/file.txt"))
+	many := make([]string, changedPathsMaxPaths+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("file%d.txt", i)
+	}
+	assert.Nil(t, newChangedPathFilter(many))
+
+	// An empty change still gets a filter, which rules everything out
+	assert.False(t, mayHaveChanged(newChangedPathFilter(nil), "README.md"))
+}
+
+func TestChangedPathFilterWalks(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	repo := NewRepository(backend)
+	dir := t.TempDir()
+	for i := 1; i <= 5; i++ {
+		changelog := "v1\n"
+		if i >= 3 {
+			changelog = "v3\n"
+		}
+		commitFiles(t, repo, dir, map[string]string{
+			"README.md":                     "# Test\n",
+			"CHANGELOG.md":                  changelog,
+			"docs/guide.md":                 "# Guide\n",
+			fmt.Sprintf("notes/v%d.txt", i): fmt.Sprintf("version %d\n", i),
+		}, fmt.Sprintf("Version %d", i))
+	}
+
+	// Each commit's filter holds what it changed
+	info, err := repo.GetVersionInfo(ctx, 3)
+	require.NoError(t, err)
+	commit, err := repo.GetCommit(ctx, info.CommitHash)
+	require.NoError(t, err)
+	require.NotNil(t, commit.ChangedPaths)
+	assert.True(t, mayHaveChanged(commit.ChangedPaths, "notes/v3.txt"))
+	assert.True(t, mayHaveChanged(commit.ChangedPaths, "notes/v2.txt"))
+	assert.False(t, mayHaveChanged(commit.ChangedPaths, "README.md"))
+
+	// History walks skip the trees of commits their filters rule out
+	roots := make(map[int64]Hash)
+	for version := int64(1); version <= 5; version++ {
+		entry, err := repo.CommitGraphEntry(ctx, version)
+		require.NoError(t, err)
+		roots[version] = entry.RootTree
+	}
+	stored := make(map[int64][]byte)
+	for version := int64(2); version <= 4; version++ {
+		stored[version], err = backend.Get(ctx, "objects/"+string(roots[version]))
+		require.NoError(t, err)
+		require.NoError(t, backend.Delete(ctx, "objects/"+string(roots[version])))
+	}
+	reopened := NewRepository(backend)
+	history, err := reopened.FileHistory(ctx, "README.md", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, int64(1), history[0].Version)
+	changes, err := reopened.LastChanges(ctx, 5, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), changes["guide.md"].Version)
+	for version := int64(2); version <= 4; version++ {
+		require.NoError(t, backend.Put(ctx, "objects/"+string(roots[version]), stored[version]))
+	}
+
+	// A commit that absorbs pruned versions absorbs their changes too
+	entry, err := repo.CommitGraphEntry(ctx, 5)
+	require.NoError(t, err)
+	assert.False(t, mayHaveChanged(entry.ChangedPaths, "CHANGELOG.md"))
+	_, err = repo.PruneHistory(ctx, RetentionPolicy{KeepVersions: map[int64]bool{2: true}}, false)
+	require.NoError(t, err)
+	entry, err = repo.CommitGraphEntry(ctx, 5)
+	require.NoError(t, err)
+	assert.True(t, mayHaveChanged(entry.ChangedPaths, "CHANGELOG.md"))
+	history, err = repo.FileHistory(ctx, "CHANGELOG.md", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(5), history[0].Version)
+	assert.Equal(t, int64(2), history[1].Version)
+}
+
+func TestLastChanges(t *testing.T) {
+	repo := NewRepository(NewMemoryBackend())
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Test\n", "src/main.go": "package main\n", "src/util.go": "package util\n"}, "Initial commit")
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Changed\n", "src/main.go": "package main\n", "src/util.go": "package util\n"}, "Edit README")
+	commitFiles(t, repo, dir, map[string]string{"README.md": "# Changed\n", "src/main.go": "package main\n", "src/util.go": "package util\n// more\n", "docs/guide.md": "guide\n"}, "Edit util, add guide")
+	v4 := commitFiles(t, repo, dir, map[string]string{"README.md": "# Changed\n", "src/main.go": "package main\n", "src/util.go": "package util\n// more\n", "docs/guide.md": "guide\n"}, "Touch nothing")
+
+	versions := func(changes map[string]FileHistoryEntry) map[string]int64 {
+		result := make(map[string]int64, len(changes))
+		for name, change := range changes {
+			result[name] = change.Version
+		}
+		return result
+	}
+
+	t.Run("Root", func(t *testing.T) {
+		changes, err := repo.LastChanges(ctx, v4, "")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"README.md": 2, "src": 3, "docs": 3}, versions(changes))
+		assert.Equal(t, "Edit README", changes["README.md"].Message)
+		assert.Equal(t, ChangeModified, changes["README.md"].Change.Type)
+		assert.Equal(t, ChangeAdded, changes["docs"].Change.Type)
+	})
+
+	t.Run("Subdirectory", func(t *testing.T) {
+		changes, err := repo.LastChanges(ctx, v4, "src")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"main.go": 1, "util.go": 3}, versions(changes))
+		assert.Equal(t, "src/util.go", changes["util.go"].Change.Path)
+	})
+
+	t.Run("Older Version", func(t *testing.T) {
+		changes, err := repo.LastChanges(ctx, 2, "src")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"main.go": 1, "util.go": 1}, versions(changes))
+	})
+
+	t.Run("Missing Directory", func(t *testing.T) {
+		_, err := repo.LastChanges(ctx, v4, "missing")
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyPatchAgainst(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	dir := t.TempDir()
+	v1 := commitFiles(t, repo, dir, map[string]string{"a.txt": "a\n", "b.txt": "b\n"}, "Initial commit")
+	commitFiles(t, repo, dir, map[string]string{"a.txt": "a\n", "b.txt": "b2\n"}, "Change b")
+
+	// b.txt changed after v1, so a patch made then is refused even though
+	// it applies to the current content
+	patchB := []byte("--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b2\n+b3\n")
+	var changed *PathChangedError
+	require.ErrorAs(t, repo.CheckPatchBase(ctx, patchB, v1), &changed)
+	_, err := repo.ApplyPatchAgainst(ctx, patchB, "alice", "Stale", merge.ApplyOptions{}, v1)
+	require.ErrorAs(t, err, &changed)
+	assert.Equal(t, &PathChangedError{Path: "b.txt", ExpectedVersion: v1, CurrentVersion: v1 + 1}, changed)
+
+	// A new file that exists now but did not then counts as changed
+	commitFiles(t, repo, dir, map[string]string{"a.txt": "a\n", "b.txt": "b2\n", "c.txt": "c\n"}, "Add c")
+	require.ErrorAs(t, repo.CheckPatchBase(ctx, []byte("--- /dev/null\n+++ b/c.txt\n@@ -0,0 +1 @@\n+c\n"), v1), &changed)
+
+	info, err := repo.ApplyPatchAgainst(ctx, []byte("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+a2\n"), "alice", "Untouched since", merge.ApplyOptions{}, v1)
+	require.NoError(t, err)
+	assert.Equal(t, v1+3, info.Version)
+	_, err = repo.ApplyPatchAgainst(ctx, patchB, "alice", "Current", merge.ApplyOptions{}, info.Version)
+	assert.NoError(t, err)
+}
+
+func TestApplyPatchOptions(t *testing.T) {
+	repo := &RepositoryImpl{}
+	header := "--- a/f\n+++ b/f\n"
+
+	cases := []struct {
+		name     string
+		original string
+		patch    string
+		opts     merge.ApplyOptions
+		want     string // Empty means the patch must not apply
+	}{
+		{"CRLF Base With Matching Patch", "a\r\nb\r\n", "@@ -1,2 +1,2 @@\n a\r\n-b\r\n+c\r\n", merge.ApplyOptions{}, "a\r\nc\r\n"},
+		{"CRLF Base With LF Patch", "a\r\nb\r\n", "@@ -1,2 +1,2 @@\n a\n-b\n+c\n", merge.ApplyOptions{}, ""},
+		{"CRLF Base Normalized", "a\r\nb\r\n", "@@ -1,2 +1,2 @@\n a\n-b\n+c\n", merge.ApplyOptions{NormalizeLineEndings: true}, "a\r\nc\r\n"},
+		{"LF Base With CRLF Patch Normalized", "a\nb\n", "@@ -1,2 +1,2 @@\r\n a\r\n-b\r\n+c\r\n", merge.ApplyOptions{NormalizeLineEndings: true}, "a\nc\n"},
+		{"Whitespace Mismatch", "if  x {\n\treturn\n}\n", "@@ -1,3 +1,3 @@\n if x {\n-    return\n+    return nil\n }\n", merge.ApplyOptions{}, ""},
+		{"Whitespace Ignored", "if  x {\n\treturn\n}\n", "@@ -1,3 +1,3 @@\n if x {\n-    return\n+    return nil\n }\n", merge.ApplyOptions{IgnoreWhitespace: true}, "if  x {\n    return nil\n}\n"},
+		{"Missing Final Newline Added By Default", "a\nb", "@@ -1 +1 @@\n-a\n+z\n", merge.ApplyOptions{}, "z\nb\n"},
+		{"Missing Final Newline Preserved", "a\nb", "@@ -1 +1 @@\n-a\n+z\n", merge.ApplyOptions{PreserveTrailingNewline: true}, "z\nb"},
+		{"No Newline Marker On Added Line", "a\n", "@@ -1 +1 @@\n-a\n+b\n\\ No newline at end of file\n", merge.ApplyOptions{PreserveTrailingNewline: true}, "b"},
+		{"Final Newline Added By Patch", "a", "@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n", merge.ApplyOptions{PreserveTrailingNewline: true}, "a\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := merge.ParsePatch([]byte(header + tc.patch))
+			require.NoError(t, err)
+
+			result, err := repo.applyPatchToContent([]byte(tc.original), patch, tc.opts)
+			if tc.want == "" {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(result))
+		})
+	}
+}
+
+func FuzzApplyPatchToContent(f *testing.F) {
+	f.Add([]byte("line 1\nline 2\nline 3\n"), []byte("--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n line 1\n-line 2\n+changed\n line 3\n"), uint8(0))
+	f.Add([]byte(""), []byte("--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n"), uint8(0))
+	f.Add([]byte("a\nb\n"), []byte("--- a/f\n+++ b/f\n@@ -2,0 +3 @@\n+c\n"), uint8(0))
+	f.Add([]byte("a\n"), []byte("--- a/f\n+++ b/f\n@@ -7,1 +7,1 @@\n-a\n+b\n"), uint8(0))
+	f.Add([]byte("a\r\nb\r\n"), []byte("--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"), uint8(2))
+	f.Add([]byte("a  b\nc"), []byte("--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a b\n-c\n\\ No newline at end of file\n+d\n"), uint8(7))
+
+	repo := &RepositoryImpl{}
+	f.Fuzz(func(t *testing.T, original, patchData []byte, flags uint8) {
+		patch, err := merge.ParsePatch(patchData)
+		if err != nil || patch.Binary != nil {
+			return
+		}
+		opts := merge.ApplyOptions{
+			IgnoreWhitespace:        flags&1 != 0,
+			NormalizeLineEndings:    flags&2 != 0,
+			PreserveTrailingNewline: flags&4 != 0,
+		}
+
+		result, err := repo.applyPatchToContent(original, patch, opts)
+		if err != nil {
+			return
+		}
+
+		// Every original line is either kept or deleted, and every added
+		// line appears exactly once
+		added, deleted := 0, 0
+		for _, hunk := range patch.Hunks {
+			for _, line := range hunk.Lines {
+				switch line.Type {
+				case "+":
+					added++
+				case "-":
+					deleted++
+				}
+			}
+		}
+		countLines := func(data []byte) int {
+			trimmed := strings.TrimSuffix(string(data), "\n")
+			if len(data) == 0 {
+				return 0
+			}
+			return strings.Count(trimmed, "\n") + 1
+		}
+		if got, want := countLines(result), countLines(original)-deleted+added; got != want {
+			t.Fatalf("result has %d lines, want %d", got, want)
+		}
+	})
+}
+
+func TestResolveInRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(root, "src"), filepath.Join(root, "inside")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink("../../", filepath.Join(root, "src", "up")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "dangling")))
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+
+	t.Run("Plain Paths", func(t *testing.T) {
+		path, err := ResolveInRoot(root, "src/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(realRoot, "src", "main.go"), path)
+
+		path, err = ResolveInRoot(root, ".")
+		require.NoError(t, err)
+		assert.Equal(t, realRoot, path)
+	})
+
+	t.Run("Missing Components", func(t *testing.T) {
+		path, err := ResolveInRoot(root, "new/dir/file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(realRoot, "new", "dir", "file.txt"), path)
+	})
+
+	t.Run("Symlink Within Root", func(t *testing.T) {
+		path, err := ResolveInRoot(root, "inside/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(realRoot, "src", "main.go"), path)
+	})
+
+	t.Run("Symlink Escapes", func(t *testing.T) {
+		for _, rel := range []string{"escape", "escape/passwd", "src/up/etc", "../outside", "/etc/passwd"} {
+			_, err := ResolveInRoot(root, rel)
+			assert.ErrorIs(t, err, ErrPathEscapesRoot, rel)
+		}
+	})
+
+	t.Run("Dangling Symlink", func(t *testing.T) {
+		_, err := ResolveInRoot(root, "dangling")
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateCommitFromFileSystemSymlinks(t *testing.T) {
+	ctx := context.Background()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret\n"), 0600))
+
+	newRoot := func(t *testing.T) string {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "README.md"), []byte("# Docs\n"), 0644))
+		return root
+	}
+
+	t.Run("Link To File Within Root", func(t *testing.T) {
+		root := newRoot(t)
+		require.NoError(t, os.Symlink("docs/README.md", filepath.Join(root, "README.md")))
+
+		repo := NewRepository(NewMemoryBackend())
+		info, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+		require.NoError(t, err)
+
+		content, err := repo.ReadFile(ctx, info.Version, "README.md")
+		require.NoError(t, err)
+		assert.Equal(t, "# Docs\n", string(content))
+	})
+
+	t.Run("Root Behind Symlink", func(t *testing.T) {
+		root := newRoot(t)
+		link := filepath.Join(t.TempDir(), "repo")
+		require.NoError(t, os.Symlink(root, link))
+		require.NoError(t, os.Symlink("docs/README.md", filepath.Join(root, "README.md")))
+
+		repo := NewRepository(NewMemoryBackend())
+		info, err := repo.CreateCommitFromFileSystem(ctx, link, "test@example.com", "Initial commit")
+		require.NoError(t, err)
+
+		content, err := repo.ReadFile(ctx, info.Version, "README.md")
+		require.NoError(t, err)
+		assert.Equal(t, "# Docs\n", string(content))
+	})
+
+	t.Run("Link Outside Root", func(t *testing.T) {
+		for name, target := range map[string]string{
+			"absolute": filepath.Join(outside, "secret"),
+			"relative": "../../../../../../../../" + filepath.Join(outside, "secret"),
+			"dir":      outside,
+		} {
+			root := newRoot(t)
+			require.NoError(t, os.Symlink(target, filepath.Join(root, "docs", "leak")))
+
+			repo := NewRepository(NewMemoryBackend())
+			_, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+			assert.ErrorIs(t, err, ErrPathEscapesRoot, name)
+		}
+	})
+
+	t.Run("Link To Directory Within Root", func(t *testing.T) {
+		root := newRoot(t)
+		require.NoError(t, os.Symlink("docs", filepath.Join(root, "manual")))
+
+		repo := NewRepository(NewMemoryBackend())
+		_, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateCommitFromFileSystemModes(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes.txt"), []byte("notes\n"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(root, "notes.txt"), 0664))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "build.sh"), []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(root, "build.sh"), 0775))
+
+	repo := NewRepository(NewMemoryBackend())
+	info, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	entry, err := repo.GetEntry(ctx, info.Version, "notes.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int32(0644), entry.Mode)
+
+	// Windows has no executable bit to read
+	if runtime.GOOS != "windows" {
+		entry, err = repo.GetEntry(ctx, info.Version, "build.sh")
+		require.NoError(t, err)
+		assert.Equal(t, int32(0755), entry.Mode)
+	}
+}
+
+func TestUnicodePaths(t *testing.T) {
+	ctx := context.Background()
+	const (
+		nfc = "caf\u00e9"  // é as one code point
+		nfd = "cafe\u0301" // e followed by a combining accent, as macOS writes it
+	)
+
+	t.Run("Stored And Looked Up In NFC", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, nfd+" menu"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, nfd+" menu", "-price list.txt"), []byte("3\n"), 0644))
+
+		repo := NewRepository(NewMemoryBackend())
+		info, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+		require.NoError(t, err)
+
+		entries, err := repo.ReadDirectory(ctx, info.Version, "")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, nfc+" menu", entries[0].Name)
+
+		for _, dir := range []string{nfc, nfd} {
+			content, err := repo.ReadFile(ctx, info.Version, dir+" menu/-price list.txt")
+			require.NoError(t, err, dir)
+			assert.Equal(t, "3\n", string(content))
+		}
+
+		patch := "--- a/" + nfd + " menu/-price list.txt\t\n+++ b/" + nfd + " menu/-price list.txt\t\n@@ -1 +1 @@\n-3\n+4\n"
+		info, err = repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Raise prices")
+		require.NoError(t, err)
+
+		content, err := repo.ReadFile(ctx, info.Version, nfc+" menu/-price list.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "4\n", string(content))
+
+		history, err := repo.FileHistory(ctx, nfd+" menu/-price list.txt", 0)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+
+	t.Run("Names Equal After Normalization", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, nfc), []byte("a\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(root, nfd), []byte("b\n"), 0644))
+
+		repo := NewRepository(NewMemoryBackend())
+		_, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+		assert.Error(t, err)
+	})
+
+	t.Run("Locks", func(t *testing.T) {
+		lm := NewLockManager(NewMemoryBackend())
+		_, err := lm.Acquire(ctx, nfd+"/menu", "alice", time.Hour)
+		require.NoError(t, err)
+
+		_, err = lm.Acquire(ctx, nfc+"/menu", "bob", time.Hour)
+		assert.ErrorIs(t, err, ErrLockHeld)
+
+		locks, err := lm.List(ctx, nfc)
+		require.NoError(t, err)
+		require.Len(t, locks, 1)
+		assert.Equal(t, nfc+"/menu", locks[0].Path)
+	})
+}
+
+func TestCaseCollisions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := NewRepository(NewMemoryBackend())
+	version := commitFiles(t, repo, dir, map[string]string{
+		"README.md":      "# Project\n",
+		"readme.md":      "# project\n",
+		"docs/guide.md":  "guide\n",
+		"Docs/notes.md":  "notes\n",
+		"src/main.go":    "package main\n",
+		"src/util/a.go":  "package util\n",
+		"src/Util/b.go":  "package util\n",
+		"src/util/c.go":  "package util\n",
+		"src/util/C.GO":  "package util\n",
+		"config/app.yml": "port: 80\n",
+	}, "Initial commit")
+
+	t.Run("List", func(t *testing.T) {
+		groups, err := repo.CaseCollisions(ctx, version, "")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"Docs", "docs"},
+			{"README.md", "readme.md"},
+			{"src/Util", "src/util"},
+			{"src/util/C.GO", "src/util/c.go"},
+		}, groups)
+
+		groups, err = repo.CaseCollisions(ctx, version, "src/util")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"src/util/C.GO", "src/util/c.go"}}, groups)
+
+		groups, err = repo.CaseCollisions(ctx, version, "config")
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+	})
+
+	t.Run("Patch Adding Colliding Path", func(t *testing.T) {
+		for target, existing := range map[string]string{
+			"config/APP.yml":   "config/app.yml",
+			"CONFIG/other.yml": "config",
+			"src/Main.go":      "src/main.go",
+		} {
+			patch := "--- /dev/null\n+++ b/" + target + "\n@@ -0,0 +1 @@\n+new\n"
+
+			_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Add "+target)
+			require.ErrorIs(t, err, ErrCaseCollision, target)
+			assert.Contains(t, err.Error(), existing)
+
+			_, err = repo.PreviewPatch(ctx, []byte(patch), merge.ApplyOptions{})
+			assert.ErrorIs(t, err, ErrCaseCollision, target)
+		}
+
+		current, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, version, current, "rejected patches must not create versions")
+	})
+
+	t.Run("Patch Without Collision", func(t *testing.T) {
+		patch := "--- a/src/util/c.go\n+++ b/src/util/c.go\n@@ -1 +1,2 @@\n package util\n+// c\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Edit c.go")
+		require.NoError(t, err)
+
+		patch = "--- /dev/null\n+++ b/config/new.yml\n@@ -0,0 +1 @@\n+new\n"
+		_, err = repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Add new.yml")
+		require.NoError(t, err)
+	})
+}
+
+func TestGlob(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := NewRepository(NewMemoryBackend())
+	version := commitFiles(t, repo, dir, map[string]string{
+		"api.proto":                  "syntax = \"proto3\";\n",
+		"src/web/api/handlers.go":    "package api\n",
+		"src/web/main.go":            "package main\n",
+		"src/mobile/api/client.go":   "package api\n",
+		"src/mobile/proto/app.proto": "syntax = \"proto3\";\n",
+		"src/tools/gen/api/types.go": "package api\n",
+		"docs/a.md":                  "a\n",
+		"docs/b.md":                  "b\n",
+		"docs/c.txt":                 "c\n",
+	}, "Initial commit")
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"src/*/api", []string{"src/mobile/api", "src/web/api"}},
+		{"**/*.proto", []string{"api.proto", "src/mobile/proto/app.proto"}},
+		{"src/**/api", []string{"src/mobile/api", "src/tools/gen/api", "src/web/api"}},
+		{"docs/?.md", []string{"docs/a.md", "docs/b.md"}},
+		{"docs/[bc].*", []string{"docs/b.md", "docs/c.txt"}},
+		{"src/*/main.go", []string{"src/web/main.go"}},
+		{"src/*/missing", []string{}},
+		{"src/web/**", []string{"src/web", "src/web/api", "src/web/api/handlers.go", "src/web/main.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			matches, err := repo.Glob(ctx, version, tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, matches)
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := repo.Glob(ctx, version, "src/[")
+		assert.Error(t, err)
+
+		_, err = repo.Glob(ctx, version, "")
+		assert.Error(t, err)
+	})
+
+	assert.True(t, IsGlobPattern("src/*/api"))
+	assert.True(t, IsGlobPattern("docs/[ab].md"))
+	assert.False(t, IsGlobPattern("src/web/api"))
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+	repo := NewRepository(backend)
+	v1 := commitFiles(t, repo, t.TempDir(), map[string]string{
+		"docs/a.md":   "a\n",
+		"docs/b.md":   "b\n",
+		"src/main.go": "package main\n",
+	}, "Initial commit")
+
+	deletePatch := func(path, content string) []byte {
+		return []byte(fmt.Sprintf("diff --git a/%s b/%s\ndeleted file mode 100644\n--- a/%s\n+++ /dev/null\n@@ -1 +0,0 @@\n-%s\n", path, path, path, content))
+	}
+
+	original, err := repo.GetEntry(ctx, v1, "docs/a.md")
+	require.NoError(t, err)
+
+	info, err := repo.ApplyPatch(ctx, deletePatch("docs/a.md", "a"), "alice", "Remove a")
+	require.NoError(t, err)
+	_, err = repo.ReadFile(ctx, info.Version, "docs/a.md")
+	assert.Error(t, err)
+	content, err := repo.ReadFile(ctx, info.Version, "docs/b.md")
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", string(content))
+
+	trash, err := repo.ListTrash(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, "docs/a.md", trash[0].Path)
+	assert.Equal(t, original.Hash, trash[0].Hash)
+	assert.Equal(t, original.Mode, trash[0].Mode)
+	assert.Equal(t, info.Version, trash[0].DeletedVersion)
+	assert.Equal(t, "alice", trash[0].DeletedBy)
+
+	t.Run("DeletionMustRemoveAllContent", func(t *testing.T) {
+		patch := []byte("--- a/src/main.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-package other\n")
+		_, err := repo.ApplyPatch(ctx, patch, "alice", "Remove main")
+		assert.Error(t, err)
+
+		_, err = repo.ApplyPatch(ctx, deletePatch("src/missing.go", "package main"), "alice", "Remove missing")
+		assert.Error(t, err)
+	})
+
+	// Removing the last file removes its directory
+	info, err = repo.ApplyPatch(ctx, deletePatch("docs/b.md", "b"), "bob", "Remove b")
+	require.NoError(t, err)
+	_, err = repo.GetEntry(ctx, info.Version, "docs")
+	assert.Error(t, err)
+
+	preview, err := repo.PreviewPatch(ctx, deletePatch("src/main.go", "package main"), merge.ApplyOptions{})
+	require.NoError(t, err)
+	assert.True(t, preview.Deletes)
+	assert.Empty(t, preview.Content)
+
+	trash, err = repo.ListTrash(ctx, "docs")
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	trash, err = repo.ListTrash(ctx, "doc")
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	info, restored, err := repo.RestoreDeletedPath(ctx, "docs", "carol", "")
+	require.NoError(t, err)
+	assert.Len(t, restored, 2)
+	assert.Equal(t, "Restore docs", info.Message)
+	for path, want := range map[string]string{"docs/a.md": "a\n", "docs/b.md": "b\n"} {
+		content, err := repo.ReadFile(ctx, info.Version, path)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+	entry, err := repo.GetEntry(ctx, info.Version, "docs/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, original.Mode, entry.Mode)
+
+	trash, err = repo.ListTrash(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+	_, _, err = repo.RestoreDeletedPath(ctx, "docs/a.md", "carol", "")
+	assert.ErrorIs(t, err, ErrNotInTrash)
+
+	t.Run("DoesNotOverwrite", func(t *testing.T) {
+		_, err := repo.ApplyPatch(ctx, deletePatch("docs/a.md", "a"), "alice", "Remove a")
+		require.NoError(t, err)
+		_, err = repo.ApplyPatch(ctx, []byte("--- /dev/null\n+++ b/docs/a.md\n@@ -0,0 +1 @@\n+new\n"), "alice", "Add a")
+		require.NoError(t, err)
+
+		_, _, err = repo.RestoreDeletedPath(ctx, "docs/a.md", "carol", "")
+		assert.ErrorIs(t, err, ErrPathExists)
+	})
+}
+
+func TestArchiveCache(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	cache := NewArchiveCache(backend, 100)
+	archive := func(b byte) []byte { return []byte(strings.Repeat(string(b), 40)) }
+
+	require.NoError(t, cache.Put(ctx, "aaaa", "tar.gz", archive('a')))
+	require.NoError(t, cache.Put(ctx, "bbbb", "tar.gz", archive('b')))
+	_, ok := cache.Get(ctx, "aaaa", "tar")
+	assert.False(t, ok, "formats are cached separately")
+
+	// Reading a makes b the least recently used, so c evicts b
+	data, ok := cache.Get(ctx, "aaaa", "tar.gz")
+	require.True(t, ok)
+	assert.Equal(t, archive('a'), data)
+	require.NoError(t, cache.Put(ctx, "cccc", "tar.gz", archive('c')))
+
+	_, ok = cache.Get(ctx, "bbbb", "tar.gz")
+	assert.False(t, ok)
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(80), stats.Bytes)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	keys, err := backend.List(ctx, archiveCachePrefix)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	// Archives larger than the cache are not stored
+	require.NoError(t, cache.Put(ctx, "dddd", "tar", []byte(strings.Repeat("d", 101))))
+	_, ok = cache.Get(ctx, "dddd", "tar")
+	assert.False(t, ok)
+
+	t.Run("SurvivesRestart", func(t *testing.T) {
+		reopened := NewArchiveCache(backend, 100)
+		data, ok := reopened.Get(ctx, "cccc", "tar.gz")
+		require.True(t, ok)
+		assert.Equal(t, archive('c'), data)
+		assert.Equal(t, 2, reopened.Stats().Entries)
+
+		// A smaller limit evicts on load
+		smaller := NewArchiveCache(backend, 50)
+		smaller.Get(ctx, "cccc", "tar.gz")
+		assert.Equal(t, 1, smaller.Stats().Entries)
+	})
+
+	t.Run("WriteArchive", func(t *testing.T) {
+		cache := NewArchiveCache(NewMemoryBackend(), 100)
+		var builds atomic.Int32
+		release := make(chan struct{})
+		build := func(w io.Writer) error {
+			builds.Add(1)
+			<-release
+			_, err := w.Write(archive('f'))
+			return err
+		}
+
+		// Concurrent requests for one archive wait for a single build
+		outputs := make([]bytes.Buffer, 4)
+		var wg sync.WaitGroup
+		for i := range outputs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, cache.WriteArchive(ctx, "ffff", "tar", &outputs[i], build))
+			}()
+		}
+		require.Eventually(t, func() bool { return builds.Load() == 1 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(1), builds.Load())
+		for _, out := range outputs {
+			assert.Equal(t, archive('f'), out.Bytes())
+		}
+
+		// Later requests stream the cached archive
+		var out bytes.Buffer
+		require.NoError(t, cache.WriteArchive(ctx, "ffff", "tar", &out, build))
+		assert.Equal(t, archive('f'), out.Bytes())
+		assert.Equal(t, int32(1), builds.Load())
+		stats := cache.Stats()
+		assert.Equal(t, int64(1), stats.Misses)
+		assert.Equal(t, int64(4), stats.Hits)
+
+		// Archives larger than the cache are written but not kept
+		large := func(w io.Writer) error {
+			builds.Add(1)
+			_, err := w.Write([]byte(strings.Repeat("g", 101)))
+			return err
+		}
+		for range 2 {
+			out.Reset()
+			require.NoError(t, cache.WriteArchive(ctx, "gggg", "tar", &out, large))
+			assert.Equal(t, 101, out.Len())
+		}
+		assert.Equal(t, int32(3), builds.Load())
+		assert.Equal(t, 1, cache.Stats().Entries)
+	})
+
+	t.Run("MissingArchive", func(t *testing.T) {
+		cache := NewArchiveCache(NewMemoryBackend(), 100)
+		require.NoError(t, cache.Put(ctx, "eeee", "tar", archive('e')))
+		keys, err := cache.backend.List(ctx, archiveCachePrefix)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.NoError(t, cache.backend.Delete(ctx, keys[0]))
+
+		_, ok := cache.Get(ctx, "eeee", "tar")
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Stats().Entries)
+	})
+
+	t.Run("NotBackedUp", func(t *testing.T) {
+		repo := NewRepository(backend)
+		commitFiles(t, repo, t.TempDir(), map[string]string{"README.md": "# Test\n"}, "Initial commit")
+
+		target := NewMemoryBackend()
+		result, err := repo.Backup(ctx, target, nil)
+		require.NoError(t, err)
+		manifest, err := LoadBackupManifest(ctx, target, result.Snapshot)
+		require.NoError(t, err)
+		for key := range manifest.Metadata {
+			assert.False(t, isCacheKey(key), key)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+
+	repo := NewRepository(backend)
+	defer repo.Close()
+
+	ctx := context.Background()
+
+	t.Run("InitialState", func(t *testing.T) {
+		version, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), version)
+	})
+
+	t.Run("ContentOperations", func(t *testing.T) {
+		// Store a blob
+		content := []byte("repository test content")
+		hash, err := repo.StoreBlob(ctx, content)
+		require.NoError(t, err)
+
+		// Retrieve the blob
+		blob, err := repo.GetBlob(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, content, blob.Content)
+
+		// Create a tree
+		tree := &TreeObject{
+			Entries: []TreeEntry{
+				{
+					Name: "test.txt",
+					Hash: hash,
+					Type: ObjectTypeBlob,
+					Mode: 0644,
+					Size: int64(len(content)),
+				},
+			},
+		}
+
+		treeHash, err := repo.StoreTree(ctx, tree)
+		require.NoError(t, err)
+
+		// Create a commit
+		commit := &CommitObject{
+			RootTree:  treeHash,
+			Author:    "test@example.com",
+			Message:   "Test commit",
+			Timestamp: time.Now(),
+			Version:   1,
+		}
+
+		commitHash, err := repo.StoreCommit(ctx, commit)
+		require.NoError(t, err)
+
+		// Create a version
+		versionInfo, err := repo.CreateVersion(ctx, commitHash, "Test commit")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), versionInfo.Version)
+		assert.Equal(t, commitHash, versionInfo.CommitHash)
+	})
+}
+
+func TestRepositoryPatchApplication(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+
+	repo := NewRepository(backend)
+	defer repo.Close()
+
+	ctx := context.Background()
+
+	// Create initial repository state with a file
+	originalContent := []byte("line 1\nline 2\nline 3\n")
+	blobHash, err := repo.StoreBlob(ctx, originalContent)
+	require.NoError(t, err)
+
+	tree := &TreeObject{
+		Entries: []TreeEntry{
+			{
+				Name: "test.txt",
+				Hash: blobHash,
+				Type: ObjectTypeBlob,
+				Mode: 0644,
+				Size: int64(len(originalContent)),
+			},
+		},
+	}
+
+	treeHash, err := repo.StoreTree(ctx, tree)
+	require.NoError(t, err)
+
+	commit := &CommitObject{
+		RootTree:  treeHash,
+		Author:    "test@example.com",
+		Message:   "Initial commit",
+		Timestamp: time.Now(),
+		Version:   1,
+	}
+
+	commitHash, err := repo.StoreCommit(ctx, commit)
+	require.NoError(t, err)
+
+	// Create initial version
+	_, err = repo.CreateVersion(ctx, commitHash, "Initial commit")
+	require.NoError(t, err)
+
+	t.Run("ApplyPatch", func(t *testing.T) {
+		// Create a patch that modifies line 2
+		patchData := []byte(`--- test.txt
++++ test.txt
+@@ -1,3 +1,3 @@
+ line 1
+-line 2
++line 2 modified
+ line 3
+`)
+
+		// Apply patch
+		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Apply patch")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), versionInfo.Version)
+
+		// Verify the file content was updated
+		newContent, err := repo.ReadFile(ctx, versionInfo.Version, "test.txt")
+		require.NoError(t, err)
+		expectedContent := []byte("line 1\nline 2 modified\nline 3\n")
+		assert.Equal(t, expectedContent, newContent)
+
+		// Verify original version is unchanged
+		originalContentRead, err := repo.ReadFile(ctx, 1, "test.txt")
+		require.NoError(t, err)
+		assert.Equal(t, originalContent, originalContentRead)
+	})
+
+	t.Run("ApplyPatchNewFile", func(t *testing.T) {
+		// Create a patch that adds a new file
+		patchData := []byte(`--- /dev/null
++++ newfile.txt
+@@ -0,0 +1,2 @@
++new line 1
++new line 2
+`)
+
+		// Apply patch
+		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Add new file")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), versionInfo.Version)
+
+		// Verify the new file exists
+		newFileContent, err := repo.ReadFile(ctx, versionInfo.Version, "newfile.txt")
+		require.NoError(t, err)
+		expectedContent := []byte("new line 1\nnew line 2\n")
+		assert.Equal(t, expectedContent, newFileContent)
+
+		// Verify original file is still accessible
+		originalFileContent, err := repo.ReadFile(ctx, versionInfo.Version, "test.txt")
+		require.NoError(t, err)
+		expectedOriginalContent := []byte("line 1\nline 2 modified\nline 3\n")
+		assert.Equal(t, expectedOriginalContent, originalFileContent)
+	})
+
+	t.Run("ApplyPatchNestedDirectory", func(t *testing.T) {
+		// Create a patch that adds a file in a new directory
+		patchData := []byte(`--- /dev/null
++++ src/main.go
+@@ -0,0 +1,3 @@
++package main
++
++func main() {}
+`)
+
+		// Apply patch
+		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Add main.go")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), versionInfo.Version)
+
+		// Verify the nested file exists
+		nestedFileContent, err := repo.ReadFile(ctx, versionInfo.Version, "src/main.go")
+		require.NoError(t, err)
+		expectedContent := []byte("package main\n\nfunc main() {}\n")
+		assert.Equal(t, expectedContent, nestedFileContent)
+
+		// Verify directory listing
+		dirEntries, err := repo.ReadDirectory(ctx, versionInfo.Version, "src")
+		require.NoError(t, err)
+		assert.Len(t, dirEntries, 1)
+		assert.Equal(t, "main.go", dirEntries[0].Name)
+		assert.Equal(t, ObjectTypeBlob, dirEntries[0].Type)
+	})
+
+	t.Run("ApplyPatchStaleContext", func(t *testing.T) {
+		// The context line does not match the current file content
+		patchData := []byte(`--- a/src/main.go
++++ b/src/main.go
+@@ -1,1 +1,1 @@
+-package other
++package renamed
+`)
+
+		_, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Stale patch")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match file content")
+
+		current, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), current)
+	})
+
+	t.Run("PreviewPatch", func(t *testing.T) {
+		original, err := repo.ReadFile(ctx, 4, "src/main.go")
+		require.NoError(t, err)
+		firstLine, _, _ := strings.Cut(string(original), "\n")
+
+		patchData := []byte("--- a/src/main.go\n+++ b/src/main.go\n@@ -1,1 +1,1 @@\n-" + firstLine + "\n+package previewed\n")
+		preview, err := repo.PreviewPatch(ctx, patchData, merge.ApplyOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "src/main.go", preview.Path)
+		assert.Equal(t, int64(4), preview.BaseVersion)
+		assert.True(t, preview.Exists)
+		assert.Equal(t, original, preview.Original)
+		assert.True(t, strings.HasPrefix(string(preview.Content), "package previewed\n"))
+
+		current, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), current)
+
+		_, err = repo.PreviewPatch(ctx, []byte("--- a/src/main.go\n+++ b/src/main.go\n@@ -1,1 +1,1 @@\n-package other\n+package renamed\n"), merge.ApplyOptions{})
+		assert.ErrorIs(t, err, ErrPatchConflict)
+
+		var mismatch *HunkMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, 1, mismatch.FileLine)
+		assert.Equal(t, "package other", mismatch.Expected)
+		assert.Equal(t, firstLine, mismatch.Actual)
+	})
+
+	t.Run("ApplyPatchBinary", func(t *testing.T) {
+		// Created by git diff --binary for a new file
+		patchData := []byte("diff --git a/assets/new.bin b/assets/new.bin\n" +
+			"new file mode 100644\n" +
+			"index 0000000000000000000000000000000000000000..b43761b27df02a0c6c305120d37445368d1ac5e1\n" +
+			"GIT binary patch\n" +
+			"literal 10\n" +
+			"RcmZQzWJ=1+ODwAV4*(1}1Bd_s\n" +
+			"\n" +
+			"literal 0\n" +
+			"HcmV?d00001\n" +
+			"\n")
+
+		versionInfo, err := repo.ApplyPatch(ctx, patchData, "test@example.com", "Add binary file")
+		require.NoError(t, err)
+
+		content, err := repo.ReadFile(ctx, versionInfo.Version, "assets/new.bin")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x00\x01\x02binary\xff"), content)
+	})
+
+	t.Run("GetEntry", func(t *testing.T) {
+		fileEntry, err := repo.GetEntry(ctx, 4, "src/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, ObjectTypeBlob, fileEntry.Type)
+		assert.Equal(t, NewHasher().ComputeBlobHash([]byte("package main\n\nfunc main() {}\n")), fileEntry.Hash)
+
+		dirEntry, err := repo.GetEntry(ctx, 4, "src")
+		require.NoError(t, err)
+		assert.Equal(t, ObjectTypeTree, dirEntry.Type)
+
+		rootEntry, err := repo.GetEntry(ctx, 4, "")
+		require.NoError(t, err)
+		assert.Equal(t, ObjectTypeTree, rootEntry.Type)
+
+		_, err = repo.GetEntry(ctx, 4, "src/missing.go")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = repo.ReadFile(ctx, 4, "missing/main.go")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestAttributes(t *testing.T) {
+	rules, err := ParseAttributes([]byte(`# Line endings
+*             text=auto
+*.bat         eol=crlf
+*.png         binary
+/legacy/**    -text
+docs/**/*.md  text eol=lf
+vendor/**     !text
+`))
+	require.NoError(t, err)
+
+	cases := map[string]Attributes{
+		"main.go":              {"text": "auto"},
+		"src/run.bat":          {"text": "auto", "eol": "crlf"},
+		"img/logo.png":         {"text": AttributeUnset, "diff": AttributeUnset, "merge": AttributeUnset},
+		"legacy/old.c":         {"text": AttributeUnset},
+		"src/legacy/new.c":     {"text": "auto"},
+		"docs/guide.md":        {"text": AttributeSet, "eol": "lf"},
+		"docs/api/v1/index.md": {"text": AttributeSet, "eol": "lf"},
+		"vendor/lib.go":        {},
+	}
+	for path, want := range cases {
+		assert.Equal(t, want, rules.Lookup(path), path)
+	}
+
+	assert.True(t, rules.Lookup("main.go").Text([]byte("a\r\n")))
+	assert.False(t, rules.Lookup("main.go").Text([]byte("a\x00b")))
+	assert.False(t, rules.Lookup("legacy/old.c").Text([]byte("a\r\n")))
+	assert.True(t, Attributes{"eol": "crlf"}.Text(nil))
+	assert.False(t, Attributes{}.Text(nil))
+	assert.Equal(t, "crlf", rules.Lookup("run.bat").CheckoutEOL())
+	assert.Equal(t, "", rules.Lookup("main.go").CheckoutEOL())
+
+	assert.Equal(t, "* text=auto\n*.bat eol=crlf\n*.png -text\n/legacy/** -text\n/docs/**/*.md text eol=lf\n/vendor/** !text\n",
+		rules.GitAttributes("text", "eol"))
+
+	for _, invalid := range []string{"src/ text", "*.go", "*.go =x", "*.go eol=", "[ text"} {
+		_, err := ParseAttributes([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestApplyPatchLineEndingAttributes(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	files := map[string]string{
+		AttributesFile: "* text=auto\n/legacy/** -text\n",
+		"src/main.go":  "a\nb\n",
+		"legacy/old.c": "a\r\nb\r\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+	repo := NewRepository(NewMemoryBackend())
+	_, err := repo.CreateCommitFromFileSystem(ctx, root, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	read := func(path string) string {
+		version, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		content, err := repo.ReadFile(ctx, version, path)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("CRLF Patch To Text File", func(t *testing.T) {
+		// Made against a Windows checkout: every line ends in CRLF
+		patch := "--- a/src/main.go\r\n+++ b/src/main.go\r\n@@ -1,2 +1,2 @@\r\n a\r\n-b\r\n+c\r\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Edit on Windows")
+		require.NoError(t, err)
+		assert.Equal(t, "a\nc\n", read("src/main.go"))
+	})
+
+	t.Run("New CRLF File", func(t *testing.T) {
+		patch := "--- /dev/null\n+++ b/src/new.go\n@@ -0,0 +1,2 @@\n+x\r\n+y\r\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Add file")
+		require.NoError(t, err)
+		assert.Equal(t, "x\ny\n", read("src/new.go"))
+	})
+
+	t.Run("Unset Text Keeps CRLF", func(t *testing.T) {
+		patch := "--- a/legacy/old.c\n+++ b/legacy/old.c\n@@ -1,2 +1,2 @@\n a\r\n-b\r\n+c\r\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Edit legacy file")
+		require.NoError(t, err)
+		assert.Equal(t, "a\r\nc\r\n", read("legacy/old.c"))
+	})
+
+	t.Run("Invalid Attributes Rejected", func(t *testing.T) {
+		patch := "--- a/.poonattributes\n+++ b/.poonattributes\n@@ -2 +2 @@\n-/legacy/** -text\n+/legacy/ -text\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Break attributes")
+		assert.ErrorIs(t, err, ErrInvalidAttributes)
+	})
+}
+
+func TestApplyPatchMergeAttributes(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+	repo := NewRepository(backend)
+	commitFiles(t, repo, t.TempDir(), map[string]string{
+		AttributesFile:    "CHANGELOG merge=union\n*.dat binary\n*.bin store=raw\nstrict.txt -merge\n",
+		"CHANGELOG":       "v2\nv1\n",
+		"data/table.dat":  "id,name\n",
+		"data/fields.txt": "id\x00name\n",
+		"assets/logo.bin": "logo\n",
+		"strict.txt":      "a\nb\n",
+		"src/loose.txt":   "a\nb\n",
+	}, "Initial commit")
+
+	read := func(path string) string {
+		version, err := repo.GetCurrentVersion(ctx)
+		require.NoError(t, err)
+		content, err := repo.ReadFile(ctx, version, path)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("Union Keeps Both Sides", func(t *testing.T) {
+		// Made before v2 was added: the context no longer matches
+		patch := "--- a/CHANGELOG\n+++ b/CHANGELOG\n@@ -1 +1,2 @@\n+v3\n v1\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Add v3")
+		require.NoError(t, err)
+		assert.Equal(t, "v3\nv2\nv1\n", read("CHANGELOG"))
+
+		preview, err := repo.PreviewPatch(ctx, []byte("--- a/CHANGELOG\n+++ b/CHANGELOG\n@@ -1 +1,2 @@\n+v4\n v0\n"), merge.ApplyOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "v4\nv3\nv2\nv1\n", string(preview.Content))
+	})
+
+	t.Run("Text Patch To Binary File", func(t *testing.T) {
+		patch := "--- a/data/table.dat\n+++ b/data/table.dat\n@@ -1 +1 @@\n-id,name\n+id,title\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Rename column")
+		assert.ErrorIs(t, err, ErrBinaryFile)
+		_, err = repo.PreviewPatch(ctx, []byte(patch), merge.ApplyOptions{})
+		assert.ErrorIs(t, err, ErrBinaryFile)
+	})
+
+	t.Run("Text Patch To Unmarked File With NUL", func(t *testing.T) {
+		patch := "--- a/data/fields.txt\n+++ b/data/fields.txt\n@@ -1 +1 @@\n-id\x00name\n+id\x00title\n"
+		_, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "Rename field")
+		require.NoError(t, err)
+		assert.Equal(t, "id\x00title\n", read("data/fields.txt"))
+	})
+
+	t.Run("Unset Merge Matches Whitespace", func(t *testing.T) {
+		opts := merge.ApplyOptions{IgnoreWhitespace: true}
+		_, err := repo.ApplyPatchWithOptions(ctx, []byte("--- a/src/loose.txt\n+++ b/src/loose.txt\n@@ -1,2 +1,2 @@\n a \n-b\n+c\n"), "test@example.com", "Edit", opts)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nc\n", read("src/loose.txt"))
+
+		_, err = repo.ApplyPatchWithOptions(ctx, []byte("--- a/strict.txt\n+++ b/strict.txt\n@@ -1,2 +1,2 @@\n a \n-b\n+c\n"), "test@example.com", "Edit", opts)
+		assert.Error(t, err)
+	})
+
+	t.Run("Store Raw", func(t *testing.T) {
+		patch := "--- a/assets/logo.bin\n+++ b/assets/logo.bin\n@@ -1 +1 @@\n-logo\n+new logo\n"
+		info, err := repo.ApplyPatch(ctx, []byte(patch), "test@example.com", "New logo")
+		require.NoError(t, err)
+		entry, err := repo.GetEntry(ctx, info.Version, "assets/logo.bin")
+		require.NoError(t, err)
+		assert.Equal(t, NewHasher().ComputeBlobHash([]byte("new logo\n")), entry.Hash)
+		stored, err := backend.Get(ctx, "objects/"+string(entry.Hash))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(stored), rawObjectPrefix))
+		assert.Equal(t, "new logo\n", read("assets/logo.bin"))
+	})
+}
+
+// unreadableBackend fails every Get of key
+type unreadableBackend struct {
+	*MemoryBackend
+	key string
+}
+
+func (u *unreadableBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == u.key {
+		return nil, fmt.Errorf("connection reset")
+	}
+	return u.MemoryBackend.Get(ctx, key)
+}
+
+func TestUnreadableAttributes(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryBackend()
+	version := commitFiles(t, NewRepository(memory), t.TempDir(), map[string]string{
+		AttributesFile: "*.dat binary\n",
+		"data/a.dat":   "a\n",
+	}, "Initial commit")
+
+	// A tree without the file has no attributes, but a file that cannot be
+	// read is not taken for a missing one, which would drop its rules
+	empty := NewRepository(NewMemoryBackend())
+	emptyVersion := commitFiles(t, empty, t.TempDir(), map[string]string{"README.md": "# Test\n"}, "Initial commit")
+	rules, err := empty.Attributes(ctx, emptyVersion)
+	require.NoError(t, err)
+	assert.Empty(t, rules.Lookup("README.md"))
+
+	hash := NewHasher().ComputeBlobHash([]byte("*.dat binary\n"))
+	repo := NewRepository(&unreadableBackend{MemoryBackend: memory, key: "objects/" + string(hash)})
+	_, err = repo.Attributes(ctx, version)
+	assert.ErrorContains(t, err, "connection reset")
+	_, err = repo.ApplyPatch(ctx, []byte("--- a/data/a.dat\n+++ b/data/a.dat\n@@ -1 +1 @@\n-a\n+b\n"), "test@example.com", "Edit")
+	assert.ErrorContains(t, err, "connection reset")
+}
+
+func TestWriteExportArchive(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	version := commitFiles(t, repo, t.TempDir(), map[string]string{
+		AttributesFile:           "/src/testdata/** export-ignore\n*.secret export-ignore\n",
+		"src/main.go":            "package main\n",
+		"src/app.secret":         "key\n",
+		"src/testdata/input.txt": "input\n",
+		"README.md":              "# Test\n",
+	}, "Initial commit")
+
+	names := func(dir string) []string {
+		var archive bytes.Buffer
+		require.NoError(t, repo.WriteExportArchive(ctx, version, dir, &archive))
+		var names []string
+		tr := tar.NewReader(&archive)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return names
+			}
+			require.NoError(t, err)
+			if header.Typeflag == tar.TypeReg {
+				names = append(names, header.Name)
+			}
+		}
+	}
+
+	assert.Equal(t, []string{AttributesFile, "README.md", "src/main.go"}, names(""))
+	assert.Equal(t, []string{"main.go"}, names("src"))
+
+	err := repo.WriteExportArchive(ctx, version, "README.md", io.Discard)
+	assert.Error(t, err)
+}
+
+func TestCommitAfterFailedVersionRead(t *testing.T) {
+	ctx := context.Background()
+	backend := &flakyBackend{MemoryBackend: NewMemoryBackend()}
+	repo := NewRepository(backend)
+
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+	_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	// A version number that cannot be read is not taken for an empty
+	// repository, whose next commit would replace version 1
+	repo.(*RepositoryImpl).Invalidate()
+	backend.failures = math.MaxInt
+	_, err = repo.ApplyPatch(ctx, []byte("--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+new\n"), "test@example.com", "Add new.txt")
+	assert.ErrorContains(t, err, "connection reset")
+
+	backend.failures = 0
+	info, err := repo.GetVersionInfo(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Initial commit", info.Message)
+}
+
+// slowBackend delays every Get, widening the window between reading the
+// current version and writing the next
+type slowBackend struct {
+	*MemoryBackend
+	delay time.Duration
+}
+
+func (s *slowBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	time.Sleep(s.delay)
+	return s.MemoryBackend.Get(ctx, key)
+}
+
+func TestConcurrentCommits(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(&slowBackend{MemoryBackend: NewMemoryBackend(), delay: 100 * time.Microsecond})
+
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "README.md"), []byte("# Test\n"), 0644))
+	_, err := repo.CreateCommitFromFileSystem(ctx, rootDir, "test@example.com", "Initial commit")
+	require.NoError(t, err)
+
+	// Patches merged at the same time all land, each on the one before
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			_, err := repo.ApplyPatch(ctx, []byte(fmt.Sprintf("--- /dev/null\n+++ b/%d.txt\n@@ -0,0 +1 @@\n+%d\n", i, i)), "test@example.com", "Add a file")
+			errs <- err
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for i := 0; i < 8; i++ {
+		data, err := repo.ReadFile(ctx, 9, fmt.Sprintf("%d.txt", i))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%d\n", i), string(data))
+	}
+	for version := int64(2); version <= 9; version++ {
+		info, err := repo.GetVersionInfo(ctx, version)
+		require.NoError(t, err)
+		parent, err := repo.GetVersionInfo(ctx, version-1)
+		require.NoError(t, err)
+		commit, err := repo.GetCommit(ctx, info.CommitHash)
+		require.NoError(t, err)
+		assert.Equal(t, parent.CommitHash, *commit.Parent, "parent of version %d", version)
+	}
+}
+
+func TestMergeBranches(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	dir := t.TempDir()
+	commitFiles(t, repo, dir, map[string]string{
+		"README.md": "# Test\n",
+		"src/a.txt": "a\n",
+		"src/b.txt": "b\n",
+	}, "Initial")
+
+	head, err := repo.BranchHead(ctx, MainBranch)
+	require.NoError(t, err)
+	_, err = repo.CreateBranch(ctx, "feature", head, "alice")
+	require.NoError(t, err)
+	_, err = repo.CreateBranch(ctx, "feature", head, "alice")
+	assert.ErrorIs(t, err, ErrBranchExists)
+	_, err = repo.CreateBranch(ctx, MainBranch, head, "alice")
+	assert.ErrorIs(t, err, ErrBranchExists)
+
+	// A patch to the branch leaves main alone
+	feature, err := repo.ApplyPatchToBranch(ctx, "feature", []byte("--- a/src/a.txt\n+++ b/src/a.txt\n@@ -1 +1 @@\n-a\n+A\n"), "alice", "Change a", merge.ApplyOptions{})
+	require.NoError(t, err)
+	current, err := repo.GetCurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), current)
+
+	main, err := repo.ApplyPatch(ctx, []byte("--- a/src/b.txt\n+++ b/src/b.txt\n@@ -1 +1 @@\n-b\n+B\n"), "bob", "Change b")
+	require.NoError(t, err)
+
+	result, err := repo.MergeBranches(ctx, "feature", MainBranch, "alice", "", MergeOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, result.Version)
+	assert.Equal(t, int64(3), result.Version.Version)
+	assert.Equal(t, head, result.Base)
+	for path, want := range map[string]string{"src/a.txt": "A\n", "src/b.txt": "B\n", "README.md": "# Test\n"} {
+		content, err := repo.ReadFile(ctx, 3, path)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), path)
+	}
+
+	commit, err := repo.GetCommit(ctx, result.Commit)
+	require.NoError(t, err)
+	assert.Equal(t, []Hash{main.CommitHash, feature.CommitHash}, commit.Parents())
+	assert.Equal(t, "Merge branch 'feature' into main", commit.Message)
+
+	// History follows main, so the branch's change is the merge's
+	entry, err := repo.CommitGraphEntry(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, entry.Parents)
+	history, err := repo.FileHistory(ctx, "src/a.txt", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(3), history[0].Version)
+
+	// Merging again has nothing to do
+	result, err = repo.MergeBranches(ctx, "feature", MainBranch, "alice", "", MergeOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.UpToDate)
+	assert.Nil(t, result.Version)
+
+	// Both sides changing a file differently conflict and change nothing
+	merged, err := repo.BranchHead(ctx, MainBranch)
+	require.NoError(t, err)
+	_, err = repo.CreateBranch(ctx, "docs", merged, "alice")
+	require.NoError(t, err)
+	_, err = repo.ApplyPatchToBranch(ctx, "docs", []byte("--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-# Test\n+# Docs\n"), "alice", "Docs title", merge.ApplyOptions{})
+	require.NoError(t, err)
+	_, err = repo.ApplyPatch(ctx, []byte("--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-# Test\n+# Main\n"), "bob", "Main title")
+	require.NoError(t, err)
+
+	_, err = repo.MergeBranches(ctx, "docs", MainBranch, "alice", "", MergeOptions{})
+	var conflict *MergeConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{"README.md"}, conflict.Paths)
+	current, err = repo.GetCurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), current)
+
+	// Main merged into a branch moves the branch without a version
+	_, err = repo.MergeBranches(ctx, MainBranch, "feature", "alice", "", MergeOptions{})
+	require.NoError(t, err)
+	current, err = repo.GetCurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), current)
+	feature, err = repo.GetBranch(ctx, "feature")
+	require.NoError(t, err)
+	featureCommit, err := repo.GetCommit(ctx, feature.CommitHash)
+	require.NoError(t, err)
+	assert.Len(t, featureCommit.Parents(), 2)
+	assert.Zero(t, featureCommit.Version)
+
+	// A deleted branch's commits stay while a merge refers to them
+	require.NoError(t, repo.DeleteBranch(ctx, "docs"))
+	assert.ErrorIs(t, repo.DeleteBranch(ctx, "docs"), ErrBranchNotFound)
+	branches, err := repo.ListBranches(ctx)
+	require.NoError(t, err)
+	require.Len(t, branches, 1)
+	assert.Equal(t, "feature", branches[0].Name)
+
+	_, err = repo.GarbageCollect(ctx, false)
+	require.NoError(t, err)
+	fsck, err := repo.Fsck(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fsck.Problems)
+	_, err = repo.GetCommit(ctx, commit.MergeParents[0])
+	assert.NoError(t, err)
+
+	// Rehashing moves the branch along with its commits
+	rehash, err := repo.Rehash(ctx, HashBLAKE3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rehash.Branches)
+	feature, err = repo.GetBranch(ctx, "feature")
+	require.NoError(t, err)
+	featureCommit, err = repo.GetCommit(ctx, feature.CommitHash)
+	require.NoError(t, err)
+	for _, parent := range featureCommit.Parents() {
+		_, err := repo.GetCommit(ctx, parent)
+		assert.NoError(t, err)
+	}
+}
+
+func TestMergeDeletionsGoToTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	dir := t.TempDir()
+	commitFiles(t, repo, dir, map[string]string{
+		"src/a.txt": "a\n",
+		"src/b.txt": "b\n",
+	}, "Initial")
+
+	head, err := repo.BranchHead(ctx, MainBranch)
+	require.NoError(t, err)
+	_, err = repo.CreateBranch(ctx, "cleanup", head, "alice")
+	require.NoError(t, err)
+	_, err = repo.ApplyPatchToBranch(ctx, "cleanup", []byte("--- a/src/b.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-b\n"), "alice", "Remove b", merge.ApplyOptions{})
+	require.NoError(t, err)
+
+	// Deleting on a branch puts nothing in the trash until main loses the file
+	trash, err := repo.ListTrash(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	result, err := repo.MergeBranches(ctx, "cleanup", MainBranch, "bob", "", MergeOptions{Squash: true})
+	require.NoError(t, err)
+	require.NotNil(t, result.Version)
+
+	trash, err = repo.ListTrash(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, "src/b.txt", trash[0].Path)
+	assert.Equal(t, result.Version.Version, trash[0].DeletedVersion)
+	assert.Equal(t, "bob", trash[0].DeletedBy)
+
+	restored, _, err := repo.RestoreDeletedPath(ctx, "src/b.txt", "bob", "Bring b back")
+	require.NoError(t, err)
+	content, err := repo.ReadFile(ctx, restored.Version, "src/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", string(content))
+}
+
+func TestCherryPick(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	dir := t.TempDir()
+	commitFiles(t, repo, dir, map[string]string{
+		"a.txt": "a\n",
+		"b.txt": "b\n",
+		"c.txt": "c\n",
+	}, "Initial")
+
+	v1, err := repo.BranchHead(ctx, MainBranch)
+	require.NoError(t, err)
+	_, err = repo.CreateBranch(ctx, "release/1.0", v1, "alice")
+	require.NoError(t, err)
+
+	_, err = repo.ApplyPatch(ctx, []byte("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n"), "bob", "Feature")
+	require.NoError(t, err)
+	hotfix, err := repo.ApplyPatch(ctx, []byte("--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b\n+fixed\n"), "bob", "Hotfix")
+	require.NoError(t, err)
+
+	// Only the hotfix's own change moves to the release branch
+	result, err := repo.CherryPick(ctx, hotfix.CommitHash, "release/1.0", "alice")
+	require.NoError(t, err)
+	assert.False(t, result.Empty)
+	assert.Nil(t, result.Version)
+
+	release, err := repo.GetBranch(ctx, "release/1.0")
+	require.NoError(t, err)
+	assert.Equal(t, result.Commit, release.CommitHash)
+	commit, err := repo.GetCommit(ctx, result.Commit)
+	require.NoError(t, err)
+	assert.Equal(t, []Hash{v1}, commit.Parents())
+	assert.Equal(t, "alice", commit.Author)
+	assert.Equal(t, "Hotfix\n\n(cherry picked from commit "+string(hotfix.CommitHash)+")", commit.Message)
+
+	tree, err := repo.GetTree(ctx, commit.RootTree)
+	require.NoError(t, err)
+	contents := make(map[string]string)
+	for _, entry := range tree.Entries {
+		blob, err := repo.GetBlob(ctx, entry.Hash)
+		require.NoError(t, err)
+		contents[entry.Name] = string(blob.Content)
+	}
+	assert.Equal(t, map[string]string{"a.txt": "a\n", "b.txt": "fixed\n", "c.txt": "c\n"}, contents)
+
+	// Picking it again has nothing to apply
+	result, err = repo.CherryPick(ctx, hotfix.CommitHash, "release/1.0", "alice")
+	require.NoError(t, err)
+	assert.True(t, result.Empty)
+	assert.Equal(t, release.CommitHash, result.Commit)
+
+	// A fix made on the release branch can come back to main as a version
+	release, err = repo.ApplyPatchToBranch(ctx, "release/1.0", []byte("--- a/c.txt\n+++ b/c.txt\n@@ -1 +1 @@\n-c\n+C\n"), "alice", "Release fix", merge.ApplyOptions{})
+	require.NoError(t, err)
+	preview, err := repo.PreviewCherryPick(ctx, release.CommitHash, MainBranch)
+	require.NoError(t, err)
+	require.Len(t, preview.Changes, 1)
+	assert.Equal(t, "c.txt", preview.Changes[0].Path)
+
+	result, err = repo.CherryPick(ctx, release.CommitHash, MainBranch, "alice")
+	require.NoError(t, err)
+	require.NotNil(t, result.Version)
+	assert.Equal(t, int64(4), result.Version.Version)
+	content, err := repo.ReadFile(ctx, 4, "c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "C\n", string(content))
+	content, err = repo.ReadFile(ctx, 4, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "A\n", string(content))
+
+	// A change the target made differently conflicts
+	_, err = repo.ApplyPatchToBranch(ctx, "release/1.0", []byte("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+x\n"), "alice", "Release change", merge.ApplyOptions{})
+	require.NoError(t, err)
+	feature, err := repo.GetVersionInfo(ctx, 2)
+	require.NoError(t, err)
+	_, err = repo.CherryPick(ctx, feature.CommitHash, "release/1.0", "alice")
+	var conflict *MergeConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{"a.txt"}, conflict.Paths)
+	assert.Equal(t, "release/1.0", conflict.Target)
+}
+
+func TestSquashAndAmend(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(NewMemoryBackend())
+	dir := t.TempDir()
+	commitFiles(t, repo, dir, map[string]string{
+		"a.txt": "a\n",
+		"b.txt": "b\n",
+	}, "Initial")
+
+	head, err := repo.BranchHead(ctx, MainBranch)
+	require.NoError(t, err)
+	_, err = repo.CreateBranch(ctx, "change", head, "alice")
+	require.NoError(t, err)
+
+	// Nothing made on the branch yet can be amended
+	_, err = repo.AmendBranch(ctx, "change", nil, "", "Reword", merge.ApplyOptions{})
+	assert.ErrorIs(t, err, ErrAlreadyMerged)
+
+	first, err := repo.ApplyPatchToBranch(ctx, "change", []byte("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n"), "alice", "Change a", merge.ApplyOptions{})
+	require.NoError(t, err)
+	second, err := repo.ApplyPatchToBranch(ctx, "change", []byte("--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b\n+B\n"), "alice", "Change b\n\nWith details", merge.ApplyOptions{})
+	require.NoError(t, err)
+
+	// Amending folds a fixup and a new message into the newest commit
+	amended, err := repo.AmendBranch(ctx, "change", []byte("--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-B\n+B!\n"), "", "Change b properly", merge.ApplyOptions{})
+	require.NoError(t, err)
+	assert.NotEqual(t, second.CommitHash, amended.CommitHash)
+	commit, err := repo.GetCommit(ctx, amended.CommitHash)
+	require.NoError(t, err)
+	assert.Equal(t, []Hash{first.CommitHash}, commit.Parents())
+	assert.Equal(t, "alice", commit.Author)
+	assert.Equal(t, "Change b properly", commit.Message)
+	assert.True(t, mayHaveChanged(commit.ChangedPaths, "b.txt"))
+
+	// A message alone keeps the content
+	reworded, err := repo.AmendBranch(ctx, "change", nil, "bob", "Change b", merge.ApplyOptions{})
+	require.NoError(t, err)
+	rewordedCommit, err := repo.GetCommit(ctx, reworded.CommitHash)
+	require.NoError(t, err)
+	assert.Equal(t, commit.RootTree, rewordedCommit.RootTree)
+	assert.Equal(t, "bob", rewordedCommit.Author)
+
+	// Squashing lands the branch as one ordinary commit
+	result, err := repo.MergeBranches(ctx, "change", MainBranch, "alice", "", MergeOptions{Squash: true})
+	require.NoError(t, err)
+	require.NotNil(t, result.Version)
+	squashed, err := repo.GetCommit(ctx, result.Commit)
+	require.NoError(t, err)
+	assert.Equal(t, []Hash{head}, squashed.Parents())
+	assert.Equal(t, "Squash branch 'change' into main\n\n* Change a\n* Change b", squashed.Message)
+	content, err := repo.ReadFile(ctx, result.Version.Version, "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "B!\n", string(content))
+
+	// Once merged, the branch's commits are main's and stay as they are
+	_, err = repo.CreateBranch(ctx, "merged", head, "alice")
+	require.NoError(t, err)
+	merged, err := repo.ApplyPatchToBranch(ctx, "merged", []byte("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n"), "alice", "Change a", merge.ApplyOptions{})
+	require.NoError(t, err)
+	_, err = repo.MergeBranches(ctx, "merged", MainBranch, "alice", "", MergeOptions{})
+	require.NoError(t, err)
+	_, err = repo.AmendBranch(ctx, "merged", nil, "", "Reword", merge.ApplyOptions{})
+	assert.ErrorIs(t, err, ErrAlreadyMerged)
+	branch, err := repo.GetBranch(ctx, "merged")
+	require.NoError(t, err)
+	assert.Equal(t, merged.CommitHash, branch.CommitHash)
+}
@@ -0,0 +1,212 @@
+// Package storagetest holds storage backends for tests. It is not imported
+// by poon-server itself.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nic/poon/poon-server/storage"
+)
+
+// ErrChaos is wrapped by the errors a ChaosBackend injects
+var ErrChaos = errors.New("injected storage failure")
+
+// ChaosConfig describes the failures a ChaosBackend injects
+type ChaosConfig struct {
+	Latency     time.Duration // Added to every operation
+	Jitter      time.Duration // Up to this much more, at random
+	ErrorRate   float64       // Fraction of operations that fail without reaching the backend
+	PartialRate float64       // Fraction of writes and deletes that land but report failure, and of streams that break part way
+	Seed        int64         // Seeds the random choices
+}
+
+// ChaosStats counts what a ChaosBackend did
+type ChaosStats struct {
+	Operations int64 `json:"operations"`
+	Errors     int64 `json:"errors"`  // Operations failed before reaching the backend
+	Partial    int64 `json:"partial"` // Operations that reached the backend but reported failure
+}
+
+// ChaosBackend wraps a storage backend for soak and chaos tests, slowing
+// its operations down and failing some of them at random. Partial failures
+// are the ones retries and callers find hardest: a write that landed but
+// whose acknowledgement was lost, or a stream that ends in an error after
+// some of the data.
+type ChaosBackend struct {
+	backend storage.StorageBackend
+	config  ChaosConfig
+	enabled atomic.Bool
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	operations atomic.Int64
+	errors     atomic.Int64
+	partial    atomic.Int64
+}
+
+// NewChaosBackend wraps backend with config, with failures enabled
+func NewChaosBackend(backend storage.StorageBackend, config ChaosConfig) *ChaosBackend {
+	c := &ChaosBackend{backend: backend, config: config, rng: rand.New(rand.NewSource(config.Seed))}
+	c.enabled.Store(true)
+	return c
+}
+
+// Unwrap returns the wrapped backend
+func (c *ChaosBackend) Unwrap() storage.StorageBackend {
+	return c.backend
+}
+
+// SetEnabled turns the injected latency and failures on or off, so a test
+// can set up and check its invariants against the plain backend
+func (c *ChaosBackend) SetEnabled(enabled bool) {
+	c.enabled.Store(enabled)
+}
+
+// Stats returns what the backend has done so far
+func (c *ChaosBackend) Stats() ChaosStats {
+	return ChaosStats{
+		Operations: c.operations.Load(),
+		Errors:     c.errors.Load(),
+		Partial:    c.partial.Load(),
+	}
+}
+
+// chance reports true with probability rate
+func (c *ChaosBackend) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < rate
+}
+
+// before delays an operation and decides whether it fails outright
+func (c *ChaosBackend) before(ctx context.Context, op, key string) error {
+	c.operations.Add(1)
+	if !c.enabled.Load() {
+		return nil
+	}
+
+	delay := c.config.Latency
+	if c.config.Jitter > 0 {
+		c.mu.Lock()
+		delay += time.Duration(c.rng.Int63n(int64(c.config.Jitter)))
+		c.mu.Unlock()
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if c.chance(c.config.ErrorRate) {
+		c.errors.Add(1)
+		return fmt.Errorf("%w: %s %s", ErrChaos, op, key)
+	}
+	return nil
+}
+
+// after turns the success of a write that landed into a failure, now and
+// then
+func (c *ChaosBackend) after(op, key string, err error) error {
+	if err != nil || !c.enabled.Load() || !c.chance(c.config.PartialRate) {
+		return err
+	}
+	c.partial.Add(1)
+	return fmt.Errorf("%w: %s %s landed but its acknowledgement was lost", ErrChaos, op, key)
+}
+
+func (c *ChaosBackend) Put(ctx context.Context, key string, data []byte) error {
+	if err := c.before(ctx, "put", key); err != nil {
+		return err
+	}
+	return c.after("put", key, c.backend.Put(ctx, key, data))
+}
+
+func (c *ChaosBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	if err := c.before(ctx, "put", key); err != nil {
+		return err
+	}
+	return c.after("put", key, c.backend.PutStream(ctx, key, r))
+}
+
+func (c *ChaosBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := c.before(ctx, "get", key); err != nil {
+		return nil, err
+	}
+	return c.backend.Get(ctx, key)
+}
+
+func (c *ChaosBackend) Exists(ctx context.Context, key string) (bool, error) {
+	if err := c.before(ctx, "exists", key); err != nil {
+		return false, err
+	}
+	return c.backend.Exists(ctx, key)
+}
+
+func (c *ChaosBackend) Delete(ctx context.Context, key string) error {
+	if err := c.before(ctx, "delete", key); err != nil {
+		return err
+	}
+	return c.after("delete", key, c.backend.Delete(ctx, key))
+}
+
+func (c *ChaosBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := c.before(ctx, "list", prefix); err != nil {
+		return nil, err
+	}
+	return c.backend.List(ctx, prefix)
+}
+
+func (c *ChaosBackend) Stream(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := c.before(ctx, "stream", key); err != nil {
+		return nil, err
+	}
+	r, err := c.backend.Stream(ctx, key)
+	if err != nil || !c.enabled.Load() || !c.chance(c.config.PartialRate) {
+		return r, err
+	}
+	c.partial.Add(1)
+	return &brokenStream{ReadCloser: r, key: key}, nil
+}
+
+func (c *ChaosBackend) Close() error {
+	return c.backend.Close()
+}
+
+// brokenStream fails after its first read, like a connection dropped part
+// way through a large object
+type brokenStream struct {
+	io.ReadCloser
+	key  string
+	read bool
+}
+
+func (b *brokenStream) Read(p []byte) (int, error) {
+	if b.read {
+		return 0, fmt.Errorf("%w: stream %s broke part way", ErrChaos, b.key)
+	}
+	b.read = true
+	if len(p) > 1 {
+		p = p[:len(p)/2]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		// Even a short object loses its end
+		return n, fmt.Errorf("%w: stream %s broke part way", ErrChaos, b.key)
+	}
+	return n, err
+}
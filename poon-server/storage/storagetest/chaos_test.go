@@ -0,0 +1,45 @@
+package storagetest
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/nic/poon/poon-server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosBackend(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemoryBackend()
+	require.NoError(t, memory.Put(ctx, "key", []byte("value")))
+
+	// Every operation fails before reaching the backend
+	failing := NewChaosBackend(memory, ChaosConfig{ErrorRate: 1})
+	_, err := failing.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrChaos)
+	assert.ErrorIs(t, failing.Put(ctx, "other", []byte("x")), ErrChaos)
+	exists, err := memory.Exists(ctx, "other")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Writes land but report failure, and streams break part way
+	partial := NewChaosBackend(memory, ChaosConfig{PartialRate: 1})
+	assert.ErrorIs(t, partial.Put(ctx, "other", []byte("x")), ErrChaos)
+	data, err := memory.Get(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(data))
+	stream, err := partial.Stream(ctx, "key")
+	require.NoError(t, err)
+	_, err = io.ReadAll(stream)
+	assert.ErrorIs(t, err, ErrChaos)
+	assert.Equal(t, ChaosStats{Operations: 2, Partial: 2}, partial.Stats())
+
+	// Disabled, it is the plain backend
+	failing.SetEnabled(false)
+	data, err = failing.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(data))
+	assert.Equal(t, ChaosStats{Operations: 3, Errors: 2}, failing.Stats())
+}
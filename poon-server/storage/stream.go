@@ -0,0 +1,429 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Blobs larger than largeBlobBytes are stored raw: a one-line header
+// followed by the content, so they can be written and read as streams
+// without holding them in memory. Everything else is stored as JSON.
+const (
+	largeBlobBytes  = 8 << 20
+	rawObjectPrefix = "poon-raw "
+)
+
+// rawObjectHeader is the line before a raw object's content
+func rawObjectHeader(objType ObjectType, size int64, algorithm HashAlgorithm) string {
+	return fmt.Sprintf("%s%s %d %s\n", rawObjectPrefix, objType, size, algorithm)
+}
+
+// parseRawObjectHeader reads a raw object's header line into an object
+// without content
+func parseRawObjectHeader(hash Hash, line string) (*Object, error) {
+	fields := strings.Fields(strings.TrimPrefix(line, rawObjectPrefix))
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("malformed object header %q", strings.TrimSpace(line))
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("malformed object size %q", fields[1])
+	}
+	algorithm, err := ParseHashAlgorithm(fields[2])
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &Object{Hash: hash, Type: ObjectType(fields[0]), Size: size}
+	if algorithm != HashSHA256 {
+		obj.Algorithm = algorithm
+	}
+	return obj, nil
+}
+
+// decodeRawObject parses a raw object read whole
+func decodeRawObject(hash Hash, data []byte) (*Object, error) {
+	line, content, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil, fmt.Errorf("malformed object header")
+	}
+	obj, err := parseRawObjectHeader(hash, string(line))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) != obj.Size {
+		return nil, fmt.Errorf("object content is %d bytes, header says %d", len(content), obj.Size)
+	}
+	obj.Content = content
+	return obj, nil
+}
+
+// StoreBlobFrom stores everything read from r as a blob and returns its hash
+// and size. Content over largeBlobBytes is spooled to a temporary file to
+// learn its size, which the hash covers, and then streamed to the backend.
+func (cs *ContentStore) StoreBlobFrom(ctx context.Context, r io.Reader) (Hash, int64, error) {
+	return cs.storeBlobFrom(ctx, cs.hasher.Load(), r)
+}
+
+func (cs *ContentStore) storeBlobFrom(ctx context.Context, hasher *Hasher, r io.Reader) (Hash, int64, error) {
+	head, err := io.ReadAll(io.LimitReader(r, largeBlobBytes+1))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read blob: %w", err)
+	}
+	if len(head) <= largeBlobBytes {
+		hash, err := cs.Store(ctx, hasher.CreateBlobObject(head))
+		return hash, int64(len(head)), err
+	}
+
+	spool, err := os.CreateTemp("", "poon-blob-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, io.MultiReader(bytes.NewReader(head), contextReader{ctx: ctx, r: r}))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %w", err)
+	}
+	head = nil
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %w", err)
+	}
+	hash, err := hasher.ComputeObjectHashFrom(ObjectTypeBlob, size, spool)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash blob: %w", err)
+	}
+
+	// Objects are immutable, so one already stored is never rewritten
+	key := "objects/" + string(hash)
+	if exists, err := cs.backend.Exists(ctx, key); err == nil && exists {
+		return hash, size, nil
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %w", err)
+	}
+	header := rawObjectHeader(ObjectTypeBlob, size, hasher.Algorithm())
+	if err := cs.backend.PutStream(ctx, key, io.MultiReader(strings.NewReader(header), spool)); err != nil {
+		return "", 0, fmt.Errorf("failed to store object: %w", err)
+	}
+	cs.written(hash)
+	return hash, size, nil
+}
+
+// storeRawBlob stores content as a raw object whatever its size, as files
+// with "store=raw" are kept
+func (cs *ContentStore) storeRawBlob(ctx context.Context, content []byte) (Hash, error) {
+	hasher := cs.hasher.Load()
+	hash, err := hasher.ComputeObjectHashFrom(ObjectTypeBlob, int64(len(content)), bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash blob: %w", err)
+	}
+
+	key := "objects/" + string(hash)
+	if exists, err := cs.backend.Exists(ctx, key); err == nil && exists {
+		return hash, nil
+	}
+	header := rawObjectHeader(ObjectTypeBlob, int64(len(content)), hasher.Algorithm())
+	if err := cs.backend.PutStream(ctx, key, io.MultiReader(strings.NewReader(header), bytes.NewReader(content))); err != nil {
+		return "", fmt.Errorf("failed to store object: %w", err)
+	}
+	cs.written(hash)
+	return hash, nil
+}
+
+// OpenBlob returns a reader for a blob's content and its size. Raw blobs
+// are streamed from the backend and checked against their hash as they are
+// read: the read that reaches the end fails if the content does not match.
+func (cs *ContentStore) OpenBlob(ctx context.Context, hash Hash) (io.ReadCloser, int64, error) {
+	obj, content, err := cs.openObject(ctx, hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	if obj.Type != ObjectTypeBlob {
+		content.Close()
+		return nil, 0, fmt.Errorf("object is not a blob: %s", obj.Type)
+	}
+	return content, obj.Size, nil
+}
+
+// OpenBlobRange returns a reader for length bytes of a blob from offset, or
+// for the rest of it if length is 0, and the blob's size. A range past the
+// end is empty. Raw blobs on backends whose streams can seek, like the
+// filesystem's, skip straight to offset. Only a read of the whole blob is
+// checked against its hash, as OpenBlob's are.
+func (cs *ContentStore) OpenBlobRange(ctx context.Context, hash Hash, offset, length int64) (io.ReadCloser, int64, error) {
+	if offset < 0 || length < 0 {
+		return nil, 0, fmt.Errorf("offset and length must not be negative")
+	}
+	if offset == 0 && length == 0 {
+		return cs.OpenBlob(ctx, hash)
+	}
+	if err := cs.hasher.Load().ValidateHash(hash); err != nil {
+		return nil, 0, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	stream, err := cs.backend.Stream(ctx, "objects/"+string(hash))
+	if err != nil {
+		return nil, 0, fmt.Errorf("object not found: %w", err)
+	}
+
+	buffered := bufio.NewReader(stream)
+	if prefix, err := buffered.Peek(len(rawObjectPrefix)); err != nil || string(prefix) != rawObjectPrefix {
+		stream.Close()
+		blob, err := cs.GetBlob(ctx, hash)
+		if err != nil {
+			return nil, 0, err
+		}
+		size := int64(len(blob.Content))
+		start, end := BlobRange(size, offset, length)
+		return io.NopCloser(bytes.NewReader(blob.Content[start:end])), size, nil
+	}
+
+	line, err := buffered.ReadString('\n')
+	if err != nil {
+		stream.Close()
+		return nil, 0, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	obj, err := parseRawObjectHeader(hash, line)
+	if err != nil {
+		stream.Close()
+		err = corrupt(fmt.Errorf("stored object verification failed: %w", err))
+		if cs.quarantine(ctx, hash, err) {
+			return cs.OpenBlobRange(ctx, hash, offset, length)
+		}
+		return nil, 0, err
+	}
+	if obj.Type != ObjectTypeBlob {
+		stream.Close()
+		return nil, 0, fmt.Errorf("object is not a blob: %s", obj.Type)
+	}
+
+	start, end := BlobRange(obj.Size, offset, length)
+	var content io.Reader = buffered
+	if seeker, ok := stream.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(len(line))+start, io.SeekStart); err != nil {
+			stream.Close()
+			return nil, 0, fmt.Errorf("failed to read object %s: %w", hash, err)
+		}
+		content = stream
+	} else if _, err := io.CopyN(io.Discard, buffered, start); err != nil {
+		stream.Close()
+		return nil, 0, fmt.Errorf("stored object %s is truncated", hash)
+	}
+
+	return &rangeReader{r: content, closer: stream, hash: hash, remaining: end - start}, obj.Size, nil
+}
+
+// BlobRange returns the bounds of the range of a blob of size bytes that a
+// read of length bytes from offset covers
+func BlobRange(size, offset, length int64) (int64, int64) {
+	start := min(offset, size)
+	end := size
+	if length > 0 && length < size-start {
+		end = start + length
+	}
+	return start, end
+}
+
+// rangeReader reads a range of a raw object, failing if the object ends
+// before the range does
+type rangeReader struct {
+	r         io.Reader
+	closer    io.Closer
+	hash      Hash
+	remaining int64
+}
+
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	if rr.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > rr.remaining {
+		p = p[:rr.remaining]
+	}
+	n, err := rr.r.Read(p)
+	rr.remaining -= int64(n)
+	if err == io.EOF && rr.remaining > 0 {
+		return n, fmt.Errorf("stored object %s is truncated", rr.hash)
+	}
+	if rr.remaining == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (rr *rangeReader) Close() error {
+	return rr.closer.Close()
+}
+
+// openObject returns an object's metadata and a reader for its content.
+// JSON objects are read whole and verified by Get, and keep their Content;
+// raw objects have none and verify as the reader reaches the end.
+func (cs *ContentStore) openObject(ctx context.Context, hash Hash) (*Object, io.ReadCloser, error) {
+	if err := cs.hasher.Load().ValidateHash(hash); err != nil {
+		return nil, nil, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	stream, err := cs.backend.Stream(ctx, "objects/"+string(hash))
+	if err != nil {
+		return nil, nil, fmt.Errorf("object not found: %w", err)
+	}
+
+	buffered := bufio.NewReader(stream)
+	if prefix, err := buffered.Peek(len(rawObjectPrefix)); err != nil || string(prefix) != rawObjectPrefix {
+		stream.Close()
+		obj, err := cs.Get(ctx, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		return obj, io.NopCloser(bytes.NewReader(obj.Content)), nil
+	}
+
+	line, err := buffered.ReadString('\n')
+	if err != nil {
+		stream.Close()
+		return nil, nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	obj, err := parseRawObjectHeader(hash, line)
+	if err != nil {
+		stream.Close()
+		err = corrupt(fmt.Errorf("stored object verification failed: %w", err))
+		if cs.quarantine(ctx, hash, err) {
+			return cs.openObject(ctx, hash)
+		}
+		return nil, nil, err
+	}
+
+	return obj, &verifyingReader{
+		r:         buffered,
+		closer:    stream,
+		digest:    newObjectDigest(objectAlgorithm(obj), obj.Type, obj.Size),
+		hash:      hash,
+		remaining: obj.Size,
+		// Only later reads can get the repaired object; this one has
+		// already returned content
+		corrupt: func(err error) {
+			cs.quarantine(context.WithoutCancel(ctx), hash, err)
+		},
+	}, nil
+}
+
+// verify checks a stored object against its hash, streaming raw objects,
+// and returns its metadata
+func (cs *ContentStore) verify(ctx context.Context, hash Hash) (*Object, error) {
+	obj, content, err := cs.openObject(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	if _, err := io.Copy(io.Discard, content); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// verifyingReader hashes a raw object's content as it is read and fails
+// the read that reaches the end if the content is short, long or does not
+// match the object's hash. The failure is reported to corrupt once.
+type verifyingReader struct {
+	r         io.Reader
+	closer    io.Closer
+	digest    hash.Hash
+	hash      Hash
+	remaining int64
+	corrupt   func(error)
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if v.remaining <= 0 {
+		return 0, v.finish()
+	}
+	if int64(len(p)) > v.remaining {
+		p = p[:v.remaining]
+	}
+
+	n, err := v.r.Read(p)
+	v.digest.Write(p[:n])
+	v.remaining -= int64(n)
+	if err == io.EOF && v.remaining > 0 {
+		return n, v.fail(fmt.Errorf("stored object %s is truncated", v.hash))
+	}
+	if v.remaining == 0 {
+		if err := v.finish(); err != io.EOF {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (v *verifyingReader) finish() error {
+	// Anything after the content means the object was damaged
+	if n, _ := v.r.Read(make([]byte, 1)); n > 0 {
+		return v.fail(fmt.Errorf("stored object %s is longer than its header says", v.hash))
+	}
+	if actual := Hash(hex.EncodeToString(v.digest.Sum(nil))); actual != v.hash {
+		return v.fail(fmt.Errorf("stored object verification failed: object hash mismatch: expected %s, got %s", v.hash, actual))
+	}
+	return io.EOF
+}
+
+func (v *verifyingReader) fail(err error) error {
+	err = corrupt(err)
+	if v.corrupt != nil {
+		v.corrupt(err)
+		v.corrupt = nil
+	}
+	return err
+}
+
+func (v *verifyingReader) Close() error {
+	return v.closer.Close()
+}
+
+// copyKey streams the value at key from src to dst and returns its size
+func copyKey(ctx context.Context, src, dst StorageBackend, key string) (int64, error) {
+	stream, err := src.Stream(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer stream.Close()
+
+	counted := &countingReader{r: stream}
+	if err := dst.PutStream(ctx, key, counted); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return counted.n, nil
+}
+
+// keySize returns the size of the value at key, reading it as a stream
+func keySize(ctx context.Context, backend StorageBackend, key string) (int64, error) {
+	stream, err := backend.Stream(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+	return io.Copy(io.Discard, stream)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
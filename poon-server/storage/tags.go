@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTagExists is returned when a tag name is already taken. Tags are pins,
+// so they are never moved to another version.
+var ErrTagExists = errors.New("tag already exists")
+
+// maxTagNameLength bounds tag and branch names, which become backend keys
+const maxTagNameLength = 128
+
+// Tag names a version so that it can be found again
+type Tag struct {
+	Name       string    `json:"name"`
+	Version    int64     `json:"version"`
+	CommitHash Hash      `json:"commitHash"`
+	CreatedAt  time.Time `json:"createdAt"`
+	CreatedBy  string    `json:"createdBy"` // User, or "schedule:NAME" for scheduled snapshots
+}
+
+// ValidateTagName checks that a tag name is made of letters, digits, ".",
+// "_", "-" and "/" separating non-empty parts that are not "." or ".."
+func ValidateTagName(name string) error {
+	return validateRefName("tag", name)
+}
+
+// validateRefName checks the name of a tag or branch, kind
+func validateRefName(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s name is required", kind)
+	}
+	if len(name) > maxTagNameLength {
+		return fmt.Errorf("%s name is longer than %d characters", kind, maxTagNameLength)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid %s name %q", kind, name)
+		}
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("._-/", c)) {
+			return fmt.Errorf("invalid character %q in %s name %q", c, kind, name)
+		}
+	}
+	return nil
+}
+
+// TagManager stores tags in a storage backend under tag/
+type TagManager struct {
+	backend StorageBackend
+	mu      sync.Mutex
+}
+
+// NewTagManager creates a new tag manager
+func NewTagManager(backend StorageBackend) *TagManager {
+	return &TagManager{
+		backend: backend,
+	}
+}
+
+func tagKey(name string) string {
+	return "tag/" + name
+}
+
+// Get returns the tag with a name, or nil when there is none
+func (tm *TagManager) Get(ctx context.Context, name string) (*Tag, error) {
+	exists, err := tm.backend.Exists(ctx, tagKey(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check tag: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := tm.backend.Get(ctx, tagKey(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag: %w", err)
+	}
+
+	var tag Tag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tag: %w", err)
+	}
+	return &tag, nil
+}
+
+// Create stores a new tag; it fails with ErrTagExists if the name is taken
+func (tm *TagManager) Create(ctx context.Context, tag *Tag) error {
+	if err := ValidateTagName(tag.Name); err != nil {
+		return err
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	existing, err := tm.Get(ctx, tag.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s is version %d", ErrTagExists, existing.Name, existing.Version)
+	}
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tag: %w", err)
+	}
+	if err := tm.backend.Put(ctx, tagKey(tag.Name), data); err != nil {
+		return fmt.Errorf("failed to store tag: %w", err)
+	}
+	return nil
+}
+
+// SetCommitHash records a tag's version under a new commit hash, after a
+// rewrite such as a rehash or history pruning changed the version's commit.
+// The tag keeps pinning the same version.
+func (tm *TagManager) SetCommitHash(ctx context.Context, name string, hash Hash) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tag, err := tm.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if tag == nil {
+		return fmt.Errorf("tag not found: %s", name)
+	}
+	tag.CommitHash = hash
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tag: %w", err)
+	}
+	if err := tm.backend.Put(ctx, tagKey(name), data); err != nil {
+		return fmt.Errorf("failed to store tag: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a tag; deleting a missing tag is not an error
+func (tm *TagManager) Delete(ctx context.Context, name string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	exists, err := tm.backend.Exists(ctx, tagKey(name))
+	if err != nil {
+		return fmt.Errorf("failed to check tag: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	if err := tm.backend.Delete(ctx, tagKey(name)); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// List returns the tags whose name starts with prefix, sorted by name
+func (tm *TagManager) List(ctx context.Context, prefix string) ([]*Tag, error) {
+	keys, err := tm.backend.List(ctx, tagKey(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var tags []*Tag
+	for _, key := range keys {
+		tag, err := tm.Get(ctx, strings.TrimPrefix(key, "tag/"))
+		if err != nil || tag == nil {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotInTrash is returned when restoring a path no patch has deleted
+var ErrNotInTrash = errors.New("path is not in the trash")
+
+// ErrPathExists is returned when restoring a path that exists again
+var ErrPathExists = errors.New("path already exists")
+
+// TrashEntry records a file deleted by a patch. Its content stays in the
+// versions before the deletion; the entry says where to find it.
+type TrashEntry struct {
+	Path           string    `json:"path"`
+	Hash           Hash      `json:"hash"` // Blob holding the file's last content
+	Mode           int32     `json:"mode"`
+	Size           int64     `json:"size"`
+	DeletedVersion int64     `json:"deletedVersion"` // Version without the file; the one before still has it
+	DeletedBy      string    `json:"deletedBy"`
+	DeletedAt      time.Time `json:"deletedAt"`
+}
+
+// trashKey escapes the slashes in path so a deleted file and a deleted
+// directory of the same name never collide in file-based backends
+func trashKey(path string) string {
+	return "trash/" + url.PathEscape(strings.Trim(NormalizePath(path), "/"))
+}
+
+// recordDeletion adds a deleted file to the trash, replacing any earlier
+// deletion of the same path
+func (r *RepositoryImpl) recordDeletion(ctx context.Context, entry *TrashEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trash entry: %w", err)
+	}
+	if err := r.ContentStore.backend.Put(ctx, trashKey(entry.Path), data); err != nil {
+		return fmt.Errorf("failed to record deletion of %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// recordDeletions adds the files in oldRoot that newRoot no longer has to
+// the trash, as deleted by version. Merges and cherry-picks onto main use it;
+// a patch deletes at most its one target.
+func (r *RepositoryImpl) recordDeletions(ctx context.Context, oldRoot, newRoot Hash, version int64, author string) error {
+	var changes []FileChange
+	if err := r.diffTrees(ctx, oldRoot, newRoot, "", &changes); err != nil {
+		return fmt.Errorf("failed to diff deleted files: %w", err)
+	}
+
+	now := time.Now()
+	for _, change := range changes {
+		if change.Type != ChangeDeleted {
+			continue
+		}
+		deleted, err := r.entryInTree(ctx, oldRoot, change.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read deleted file: %w", err)
+		}
+		if err := r.recordDeletion(ctx, &TrashEntry{
+			Path:           strings.Trim(NormalizePath(change.Path), "/"),
+			Hash:           deleted.Hash,
+			Mode:           deleted.Mode,
+			Size:           deleted.Size,
+			DeletedVersion: version,
+			DeletedBy:      author,
+			DeletedAt:      now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTrash returns the deleted files at or below path, sorted by path. An
+// empty path lists the whole trash.
+func (r *RepositoryImpl) ListTrash(ctx context.Context, path string) ([]*TrashEntry, error) {
+	path = strings.Trim(NormalizePath(path), "/")
+	backend := r.ContentStore.backend
+
+	keys, err := backend.List(ctx, "trash/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	var entries []*TrashEntry
+	for _, key := range keys {
+		data, err := backend.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+
+		var entry TrashEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+		}
+		if path == "" || entry.Path == path || strings.HasPrefix(entry.Path, path+"/") {
+			entries = append(entries, &entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// RestoreDeletedPath puts the deleted files at or below path back with
+// their last content and mode, in one new version, and removes them from
+// the trash. Files that exist again are not overwritten.
+func (r *RepositoryImpl) RestoreDeletedPath(ctx context.Context, path, author, message string) (*VersionInfo, []*TrashEntry, error) {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+
+	entries, err := r.ListTrash(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotInTrash, path)
+	}
+
+	currentVersion, err := r.GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+	currentInfo, err := r.GetVersionInfo(ctx, currentVersion)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current version info: %w", err)
+	}
+	currentCommit, err := r.GetCommit(ctx, currentInfo.CommitHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current commit: %w", err)
+	}
+
+	rootHash := currentCommit.RootTree
+	for _, entry := range entries {
+		if _, err := r.entryInTree(ctx, rootHash, entry.Path); err == nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrPathExists, entry.Path)
+		}
+		if existing, err := r.caseCollision(ctx, rootHash, entry.Path); err != nil {
+			return nil, nil, err
+		} else if existing != "" {
+			return nil, nil, fmt.Errorf("%w: %s collides with %s", ErrCaseCollision, entry.Path, existing)
+		}
+		if _, err := r.GetBlob(ctx, entry.Hash); err != nil {
+			return nil, nil, fmt.Errorf("content of %s is no longer stored: %w", entry.Path, err)
+		}
+
+		rootHash, err = r.setTreeEntry(ctx, rootHash, splitPath(entry.Path), TreeEntry{
+			Hash:    entry.Hash,
+			Type:    ObjectTypeBlob,
+			Mode:    entry.Mode,
+			Size:    entry.Size,
+			ModTime: time.Now().Unix(),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("Restore %s", strings.Trim(NormalizePath(path), "/"))
+	}
+	commitHash, err := r.StoreCommit(ctx, &CommitObject{
+		RootTree:     rootHash,
+		Parent:       &currentInfo.CommitHash,
+		Author:       author,
+		Message:      message,
+		Timestamp:    time.Now(),
+		Version:      currentVersion + 1,
+		ChangedPaths: r.changedPaths(ctx, currentCommit.RootTree, rootHash),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store commit: %w", err)
+	}
+
+	info, err := r.CreateVersion(ctx, commitHash, message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The files are back, so a failure here leaves only a stale entry, which
+	// a later restore reports as ErrPathExists
+	for _, entry := range entries {
+		if err := r.ContentStore.backend.Delete(ctx, trashKey(entry.Path)); err != nil {
+			return info, entries, fmt.Errorf("restored %s but failed to remove it from the trash: %w", entry.Path, err)
+		}
+	}
+	return info, entries, nil
+}
+
+// setTreeEntry stores a copy of the tree with entry placed at the path
+// given by parts, creating missing directories, and returns its hash
+func (r *RepositoryImpl) setTreeEntry(ctx context.Context, treeHash Hash, parts []string, entry TreeEntry) (Hash, error) {
+	var entries []TreeEntry
+	if treeHash != "" {
+		tree, err := r.getTree(ctx, treeHash)
+		if err != nil {
+			return "", fmt.Errorf("failed to get tree: %w", err)
+		}
+		entries = append(entries, tree.Entries...)
+	}
+
+	name := parts[0]
+	index := -1
+	for i, existing := range entries {
+		if existing.Name == name {
+			index = i
+			break
+		}
+	}
+
+	if len(parts) == 1 {
+		entry.Name = name
+		if index >= 0 {
+			entries[index] = entry
+		} else {
+			entries = append(entries, entry)
+		}
+		return r.StoreTree(ctx, &TreeObject{Entries: entries})
+	}
+
+	dir := TreeEntry{Name: name, Type: ObjectTypeTree, Mode: 0755, ModTime: time.Now().Unix()}
+	if index >= 0 {
+		if entries[index].Type != ObjectTypeTree {
+			return "", fmt.Errorf("%s is a file", name)
+		}
+		dir = entries[index]
+	}
+
+	subtree, err := r.setTreeEntry(ctx, dir.Hash, parts[1:], entry)
+	if err != nil {
+		return "", err
+	}
+	dir.Hash = subtree
+	if index >= 0 {
+		entries[index] = dir
+	} else {
+		entries = append(entries, dir)
+	}
+	return r.StoreTree(ctx, &TreeObject{Entries: entries})
+}
+
+// removeTreeEntry stores a copy of the tree without the entry at the path
+// given by parts and returns its hash. Directories left empty are removed
+// too, as git does; the root tree is kept even when empty.
+func (r *RepositoryImpl) removeTreeEntry(ctx context.Context, treeHash Hash, parts []string) (Hash, error) {
+	tree, err := r.getTree(ctx, treeHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	entries := make([]TreeEntry, 0, len(tree.Entries))
+	found := false
+	for _, entry := range tree.Entries {
+		if entry.Name != parts[0] {
+			entries = append(entries, entry)
+			continue
+		}
+		found = true
+
+		if len(parts) == 1 {
+			continue
+		}
+		if entry.Type != ObjectTypeTree {
+			return "", fmt.Errorf("'%s' is not a directory", parts[0])
+		}
+
+		subtree, err := r.removeTreeEntry(ctx, entry.Hash, parts[1:])
+		if err != nil {
+			return "", err
+		}
+		if sub, err := r.getTree(ctx, subtree); err != nil {
+			return "", fmt.Errorf("failed to get tree: %w", err)
+		} else if len(sub.Entries) > 0 {
+			entry.Hash = subtree
+			entries = append(entries, entry)
+		}
+	}
+
+	if !found {
+		return "", fmt.Errorf("'%s' %w", parts[0], ErrNotFound)
+	}
+	return r.StoreTree(ctx, &TreeObject{Entries: entries})
+}
@@ -22,6 +22,12 @@ type Object struct {
 	Type    ObjectType `json:"type"`
 	Size    int64      `json:"size"`
 	Content []byte     `json:"content"`
+
+	// Algorithm the hash was computed with; empty for SHA-256
+	Algorithm HashAlgorithm `json:"algorithm,omitempty"`
+
+	// Format a tree or commit was hashed in; zero for HashFormatJSON
+	Format HashFormat `json:"format,omitempty"`
 }
 
 // BlobObject represents file content
@@ -47,11 +53,29 @@ type TreeObject struct {
 // CommitObject represents a version snapshot
 type CommitObject struct {
 	RootTree  Hash      `json:"root_tree"`
-	Parent    *Hash     `json:"parent,omitempty"`
+	Parent    *Hash     `json:"parent,omitempty"` // First parent; for a merge, the branch merged into
 	Author    string    `json:"author"`
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
 	Version   int64     `json:"version"`
+
+	// ChangedPaths is a Bloom filter of the paths changed from the parent
+	// (see bloom.go); nil when the commit predates filters or changed too
+	// many paths
+	ChangedPaths []byte `json:"changed_paths,omitempty"`
+
+	// MergeParents are the heads of the branches a merge commit merged in,
+	// in order; empty for any other commit
+	MergeParents []Hash `json:"merge_parents,omitempty"`
+}
+
+// Parents returns every parent of the commit, the first parent first
+func (c *CommitObject) Parents() []Hash {
+	var parents []Hash
+	if c.Parent != nil {
+		parents = append(parents, *c.Parent)
+	}
+	return append(parents, c.MergeParents...)
 }
 
 // VersionInfo maps version numbers to commit hashes
@@ -3,31 +3,75 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
-// VersionManager implements VersionStore interface
+// VersionManager implements VersionStore interface. It caches the current
+// version number and the latest VersionInfo, which nearly every read needs.
+// The cache assumes this VersionManager is the only writer of version
+// metadata in its backend; Invalidate must be called after writing version
+// keys any other way.
 type VersionManager struct {
 	backend StorageBackend
+
+	mu      sync.RWMutex
+	current int64        // Cached current version, -1 when unknown
+	latest  *VersionInfo // Cached info for the current version, nil when unknown
 }
 
 // NewVersionManager creates a new version manager
 func NewVersionManager(backend StorageBackend) *VersionManager {
 	return &VersionManager{
 		backend: backend,
+		current: -1,
 	}
 }
 
+// Invalidate drops cached version metadata
+func (vm *VersionManager) Invalidate() {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	vm.current = -1
+	vm.latest = nil
+}
+
 // GetCurrentVersion returns the current version number
 func (vm *VersionManager) GetCurrentVersion(ctx context.Context) (int64, error) {
-	data, err := vm.backend.Get(ctx, "version/current")
+	vm.mu.RLock()
+	current := vm.current
+	vm.mu.RUnlock()
+	if current >= 0 {
+		return current, nil
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	current, err := vm.loadCurrentVersion(ctx)
 	if err != nil {
+		return 0, err
+	}
+	vm.current = current
+	return current, nil
+}
+
+// loadCurrentVersion reads the current version from the backend
+func (vm *VersionManager) loadCurrentVersion(ctx context.Context) (int64, error) {
+	data, err := vm.backend.Get(ctx, "version/current")
+	if errors.Is(err, ErrKeyNotFound) {
 		// No versions exist yet, start at 0
 		return 0, nil
 	}
+	if err != nil {
+		// Counting from 0 here would overwrite version 1
+		return 0, fmt.Errorf("failed to read current version: %w", err)
+	}
 
 	version, err := strconv.ParseInt(string(data), 10, 64)
 	if err != nil {
@@ -39,6 +83,14 @@ func (vm *VersionManager) GetCurrentVersion(ctx context.Context) (int64, error)
 
 // GetVersionInfo returns version information for a specific version
 func (vm *VersionManager) GetVersionInfo(ctx context.Context, version int64) (*VersionInfo, error) {
+	vm.mu.RLock()
+	latest := vm.latest
+	vm.mu.RUnlock()
+	if latest != nil && latest.Version == version {
+		info := *latest
+		return &info, nil
+	}
+
 	key := fmt.Sprintf("version/info/%d", version)
 	data, err := vm.backend.Get(ctx, key)
 	if err != nil {
@@ -50,6 +102,13 @@ func (vm *VersionManager) GetVersionInfo(ctx context.Context, version int64) (*V
 		return nil, fmt.Errorf("failed to unmarshal version info: %w", err)
 	}
 
+	vm.mu.Lock()
+	if vm.current == version && vm.latest == nil {
+		cached := info
+		vm.latest = &cached
+	}
+	vm.mu.Unlock()
+
 	return &info, nil
 }
 
@@ -69,8 +128,13 @@ func (vm *VersionManager) GetLatestVersionInfo(ctx context.Context) (*VersionInf
 
 // CreateVersion creates a new version pointing to a commit
 func (vm *VersionManager) CreateVersion(ctx context.Context, commitHash Hash, message string) (*VersionInfo, error) {
+	// Holding the lock throughout also keeps concurrent commits from
+	// claiming the same version number
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
 	// Get next version number
-	currentVersion, err := vm.GetCurrentVersion(ctx)
+	currentVersion, err := vm.loadCurrentVersion(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current version: %w", err)
 	}
@@ -100,9 +164,13 @@ func (vm *VersionManager) CreateVersion(ctx context.Context, commitHash Hash, me
 	// Update current version
 	currentData := []byte(strconv.FormatInt(newVersion, 10))
 	if err := vm.backend.Put(ctx, "version/current", currentData); err != nil {
+		vm.current, vm.latest = -1, nil
 		return nil, fmt.Errorf("failed to update current version: %w", err)
 	}
 
+	cached := *info
+	vm.current, vm.latest = newVersion, &cached
+
 	// Store commit hash mapping for quick lookup
 	hashKey := fmt.Sprintf("version/hash/%s", commitHash)
 	versionData := []byte(strconv.FormatInt(newVersion, 10))
@@ -173,8 +241,37 @@ func (vm *VersionManager) GetVersionByCommit(ctx context.Context, commitHash Has
 	return version, nil
 }
 
+// replaceCommit points an existing version at another commit, for rewrites
+// that keep the version's content but change its commit hash
+func (vm *VersionManager) replaceCommit(ctx context.Context, info *VersionInfo, commitHash Hash) error {
+	defer vm.Invalidate()
+
+	updated := *info
+	updated.CommitHash = commitHash
+	data, err := json.Marshal(&updated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal version info: %w", err)
+	}
+
+	// The new mapping goes first so the version can be found by either hash
+	// if the rewrite stops partway
+	versionData := []byte(strconv.FormatInt(info.Version, 10))
+	if err := vm.backend.Put(ctx, fmt.Sprintf("version/hash/%s", commitHash), versionData); err != nil {
+		return fmt.Errorf("failed to store commit hash mapping: %w", err)
+	}
+	if err := vm.backend.Put(ctx, fmt.Sprintf("version/info/%d", info.Version), data); err != nil {
+		return fmt.Errorf("failed to store version info: %w", err)
+	}
+	if err := vm.backend.Delete(ctx, fmt.Sprintf("version/hash/%s", info.CommitHash)); err != nil {
+		return fmt.Errorf("failed to delete commit hash mapping: %w", err)
+	}
+	return nil
+}
+
 // DeleteVersion removes a version (for cleanup or rollback)
 func (vm *VersionManager) DeleteVersion(ctx context.Context, version int64) error {
+	defer vm.Invalidate()
+
 	// Get version info first to get commit hash
 	info, err := vm.GetVersionInfo(ctx, version)
 	if err != nil {
@@ -0,0 +1,130 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pattern matches the lines that define one sort of symbol. The name group
+// is the symbol's name; a kind group, when there is one, is the keyword
+// that says its kind, looked up in keywordKinds.
+type pattern struct {
+	re   *regexp.Regexp
+	kind Kind
+}
+
+var keywordKinds = map[string]Kind{
+	"class":     Class,
+	"interface": Interface,
+	"enum":      Enum,
+	"record":    Class,
+	"struct":    Struct,
+	"union":     Struct,
+	"trait":     Interface,
+	"type":      Type,
+	"mod":       Module,
+	"const":     Const,
+	"static":    Var,
+	"message":   Message,
+	"service":   Service,
+	"rpc":       Method,
+}
+
+// cFunctionExcluded are words a C function pattern would take for names
+var cFunctionExcluded = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true, "else": true,
+}
+
+var pythonDefRe = regexp.MustCompile(`^([ \t]*)(?:async[ \t]+)?(def|class)[ \t]+([A-Za-z_]\w*)`)
+
+func pat(re string, kind Kind) pattern {
+	return pattern{re: regexp.MustCompile(re), kind: kind}
+}
+
+var jsPatterns = []pattern{
+	pat(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>[A-Za-z_$][\w$]*)`, Function),
+	pat(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>[A-Za-z_$][\w$]*)`, Class),
+	pat(`^\s*(?:export\s+)?(?:const|let|var)\s+(?P<name>[A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s*)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`, Function),
+}
+
+// patterns are the symbol patterns of each language found by pattern, by
+// the language's name in package highlight, in the order they are tried
+var patterns = map[string][]pattern{
+	"javascript": jsPatterns,
+	"typescript": append([]pattern{
+		pat(`^\s*(?:export\s+)?(?:declare\s+)?interface\s+(?P<name>[A-Za-z_$][\w$]*)`, Interface),
+		pat(`^\s*(?:export\s+)?(?:declare\s+)?type\s+(?P<name>[A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`, Type),
+		pat(`^\s*(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(?P<name>[A-Za-z_$][\w$]*)`, Enum),
+	}, jsPatterns...),
+	"java": {
+		pat(`^\s*(?:(?:public|protected|private|static|final|abstract|sealed|non-sealed|strictfp)\s+)*(?P<kind>class|interface|enum|record)\s+(?P<name>\w+)`, ""),
+		pat(`^\s+(?:(?:public|protected|private|static|final|abstract|synchronized|native|default)\s+)+(?:<[^>]+>\s+)?[\w<>\[\],.?]+\s+(?P<name>\w+)\s*\(`, Method),
+	},
+	"rust": {
+		pat(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+(?P<name>\w+)`, Function),
+		pat(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?P<kind>struct|enum|trait|type|mod|union)\s+(?P<name>\w+)`, ""),
+		pat(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?P<kind>const|static)\s+(?:mut\s+)?(?P<name>[A-Z_][A-Z0-9_]*)\s*:`, ""),
+		pat(`^\s*macro_rules!\s+(?P<name>\w+)`, Macro),
+	},
+	"c": {
+		pat(`^\s*#\s*define\s+(?P<name>[A-Za-z_]\w*)`, Macro),
+		pat(`^\s*(?:typedef\s+)?(?P<kind>struct|union|enum)\s+(?P<name>[A-Za-z_]\w*)\s*\{`, ""),
+		pat(`^[A-Za-z_][\w \t\*]*?[\s\*](?P<name>[A-Za-z_]\w*)\s*\([^;]*$`, Function),
+	},
+	"cpp": {
+		pat(`^\s*#\s*define\s+(?P<name>[A-Za-z_]\w*)`, Macro),
+		pat(`^\s*(?:typedef\s+)?(?:template\s*<[^>]*>\s*)?(?P<kind>class|struct|union|enum)(?:\s+class)?\s+(?P<name>[A-Za-z_]\w*)\s*(?:final\s*)?[:{]`, ""),
+		pat(`^\s*namespace\s+(?P<name>[A-Za-z_]\w*)`, Module),
+		pat(`^[A-Za-z_][\w \t\*&:<>,]*?[\s\*&](?P<name>[A-Za-z_~]\w*(?:::[A-Za-z_~]\w*)*)\s*\([^;]*$`, Function),
+	},
+	"proto": {
+		pat(`^\s*(?P<kind>message|enum|service)\s+(?P<name>\w+)`, ""),
+		pat(`^\s*(?P<kind>rpc)\s+(?P<name>\w+)\s*\(`, ""),
+	},
+	"shell": {
+		pat(`^\s*function\s+(?P<name>[\w.:-]+)`, Function),
+		pat(`^\s*(?P<name>[A-Za-z_][\w.:-]*)\s*\(\)`, Function),
+	},
+}
+
+// extractPatterns finds symbols line by line. A line is taken by the
+// first pattern it matches, so a symbol is never found twice.
+func extractPatterns(list []pattern, content []byte) []Symbol {
+	var symbols []Symbol
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		for _, p := range list {
+			m := p.re.FindStringSubmatchIndex(line)
+			if m == nil {
+				continue
+			}
+			symbol := Symbol{Kind: p.kind, Line: i + 1}
+			for group, name := range p.re.SubexpNames() {
+				if m[2*group] < 0 {
+					continue
+				}
+				switch name {
+				case "name":
+					symbol.Name = line[m[2*group]:m[2*group+1]]
+					symbol.Column = m[2*group] + 1
+				case "kind":
+					symbol.Kind = keywordKinds[line[m[2*group]:m[2*group+1]]]
+				}
+			}
+			// Qualified C++ definitions such as Server::Start belong to
+			// the class they name
+			if k := strings.LastIndex(symbol.Name, "::"); k >= 0 {
+				symbol.Container = symbol.Name[:k]
+				symbol.Column += k + 2
+				symbol.Name = symbol.Name[k+2:]
+				symbol.Kind = Method
+			}
+			if symbol.Kind == Function && cFunctionExcluded[symbol.Name] {
+				continue
+			}
+			symbols = append(symbols, symbol)
+			break
+		}
+	}
+	return symbols
+}
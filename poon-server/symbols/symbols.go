@@ -0,0 +1,194 @@
+// Package symbols finds the definitions in source files: functions, types,
+// classes, constants and the like, with where each one is. Go is parsed with
+// go/parser; the other languages are matched line by line with patterns, as
+// ctags does, which finds the definitions written the usual way without
+// parsing the language.
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/nic/poon/poon-server/highlight"
+)
+
+// Kind is what sort of definition a symbol is
+type Kind string
+
+const (
+	Function  Kind = "function"
+	Method    Kind = "method"
+	Type      Kind = "type"
+	Class     Kind = "class"
+	Interface Kind = "interface"
+	Struct    Kind = "struct"
+	Enum      Kind = "enum"
+	Const     Kind = "const"
+	Var       Kind = "var"
+	Field     Kind = "field"
+	Module    Kind = "module"
+	Macro     Kind = "macro"
+	Message   Kind = "message"
+	Service   Kind = "service"
+)
+
+// Symbol is a definition in a file
+type Symbol struct {
+	Name      string
+	Kind      Kind
+	Line      int    // From 1
+	Column    int    // From 1, in bytes
+	Container string // Type or class the symbol belongs to, such as a method's receiver
+}
+
+// Language returns the name of the language symbols are found in for a
+// file, or "" when they are not
+func Language(path string) string {
+	lang := highlight.Detect(path)
+	if lang == nil {
+		return ""
+	}
+	if _, ok := patterns[lang.Name]; !ok && lang.Name != "go" && lang.Name != "python" {
+		return ""
+	}
+	return lang.Name
+}
+
+// Extract returns the symbols defined in a file, in the order they appear.
+// Files in languages without symbol support have none.
+func Extract(path string, content []byte) []Symbol {
+	switch language := Language(path); language {
+	case "":
+		return nil
+	case "go":
+		return extractGo(content)
+	case "python":
+		return extractPython(content)
+	default:
+		return extractPatterns(patterns[language], content)
+	}
+}
+
+// extractGo finds the package-level declarations of a Go file, and the
+// fields and methods of its structs and interfaces. A file with syntax
+// errors still yields what was parsed before them.
+func extractGo(content []byte) []Symbol {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+
+	var symbols []Symbol
+	add := func(ident *ast.Ident, kind Kind, container string) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+		pos := fset.Position(ident.Pos())
+		symbols = append(symbols, Symbol{Name: ident.Name, Kind: kind, Line: pos.Line, Column: pos.Column, Container: container})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				add(decl.Name, Method, receiverName(decl.Recv.List[0].Type))
+			} else {
+				add(decl.Name, Function, "")
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					switch typ := spec.Type.(type) {
+					case *ast.StructType:
+						add(spec.Name, Struct, "")
+						for _, field := range typ.Fields.List {
+							for _, name := range field.Names {
+								add(name, Field, spec.Name.Name)
+							}
+						}
+					case *ast.InterfaceType:
+						add(spec.Name, Interface, "")
+						for _, method := range typ.Methods.List {
+							for _, name := range method.Names {
+								add(name, Method, spec.Name.Name)
+							}
+						}
+					default:
+						add(spec.Name, Type, "")
+					}
+				case *ast.ValueSpec:
+					kind := Var
+					if decl.Tok == token.CONST {
+						kind = Const
+					}
+					for _, name := range spec.Names {
+						add(name, kind, "")
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverName returns the type name of a method receiver such as *T or
+// T[K, V]
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// extractPython finds classes, functions and methods by their def and class
+// lines, following indentation to tell methods, defined directly in a
+// class, from functions
+func extractPython(content []byte) []Symbol {
+	type scope struct {
+		name   string
+		indent int
+		class  bool
+	}
+	var symbols []Symbol
+	var scopes []scope
+
+	for i, line := range strings.Split(string(content), "\n") {
+		m := pythonDefRe.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		indent := m[3] - m[2]
+		for len(scopes) > 0 && scopes[len(scopes)-1].indent >= indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+		name := line[m[6]:m[7]]
+		symbol := Symbol{Name: name, Kind: Function, Line: i + 1, Column: m[6] + 1}
+		if len(scopes) > 0 && scopes[len(scopes)-1].class {
+			symbol.Container = scopes[len(scopes)-1].name
+		}
+		isClass := line[m[4]:m[5]] == "class"
+		if isClass {
+			symbol.Kind = Class
+		} else if symbol.Container != "" {
+			symbol.Kind = Method
+		}
+		scopes = append(scopes, scope{name: name, indent: indent, class: isClass})
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
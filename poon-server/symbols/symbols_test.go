@@ -0,0 +1,136 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractGo(t *testing.T) {
+	source := `package server
+
+const defaultPort = 8080
+
+var (
+	started bool
+	_       = started
+)
+
+type Server struct {
+	Addr, Name string
+}
+
+type Handler interface {
+	Handle(req string) error
+}
+
+type ID = string
+
+func New(addr string) *Server { return &Server{Addr: addr} }
+
+func (s *Server) Start() error { return nil }
+
+func (m *Map[K, V]) Get(key K) V {
+`
+
+	assert.Equal(t, []Symbol{
+		{Name: "defaultPort", Kind: Const, Line: 3, Column: 7},
+		{Name: "started", Kind: Var, Line: 6, Column: 2},
+		{Name: "Server", Kind: Struct, Line: 10, Column: 6},
+		{Name: "Addr", Kind: Field, Line: 11, Column: 2, Container: "Server"},
+		{Name: "Name", Kind: Field, Line: 11, Column: 8, Container: "Server"},
+		{Name: "Handler", Kind: Interface, Line: 14, Column: 6},
+		{Name: "Handle", Kind: Method, Line: 15, Column: 2, Container: "Handler"},
+		{Name: "ID", Kind: Type, Line: 18, Column: 6},
+		{Name: "New", Kind: Function, Line: 20, Column: 6},
+		{Name: "Start", Kind: Method, Line: 22, Column: 18, Container: "Server"},
+		{Name: "Get", Kind: Method, Line: 24, Column: 21, Container: "Map"},
+	}, Extract("server/server.go", []byte(source)))
+}
+
+func TestExtractPython(t *testing.T) {
+	source := `import os
+
+class Client:
+    def __init__(self):
+        pass
+
+    async def fetch(self, path):
+        def helper():
+            pass
+
+def main():
+    pass
+`
+	assert.Equal(t, []Symbol{
+		{Name: "Client", Kind: Class, Line: 3, Column: 7},
+		{Name: "__init__", Kind: Method, Line: 4, Column: 9, Container: "Client"},
+		{Name: "fetch", Kind: Method, Line: 7, Column: 15, Container: "Client"},
+		{Name: "helper", Kind: Function, Line: 8, Column: 13},
+		{Name: "main", Kind: Function, Line: 11, Column: 5},
+	}, Extract("tools/client.py", []byte(source)))
+}
+
+func TestExtractPatterns(t *testing.T) {
+	names := func(path, source string) []string {
+		var names []string
+		for _, symbol := range Extract(path, []byte(source)) {
+			names = append(names, string(symbol.Kind)+" "+symbol.Container+"."+symbol.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"function .render", "class .View", "function .handler", "interface .Props", "type .Mode"}, names("web/view.ts", `
+export default function render() {}
+export class View extends Base {}
+export const handler = async (req: Request): Promise<void> => {}
+const value = compute()
+export interface Props { name: string }
+type Mode = "a" | "b"
+`))
+
+	assert.Equal(t, []string{"class .Server", "method .start", "enum .State"}, names("src/Server.java", `
+public final class Server {
+    public static void start(String[] args) {
+        if (ready) { start(args); }
+    }
+    private enum State { ON, OFF }
+}
+`))
+
+	assert.Equal(t, []string{"struct .Config", "function .load", "interface .Store", "const .MAX_SIZE"}, names("src/lib.rs", `
+pub struct Config {}
+pub(crate) async fn load() -> Config {}
+trait Store {}
+const MAX_SIZE: usize = 10;
+`))
+
+	assert.Equal(t, []string{"macro .BUFSIZE", "struct .buffer", "function .buffer_init"}, names("src/buffer.c", `
+#define BUFSIZE 4096
+struct buffer {
+static int buffer_init(struct buffer *b)
+{
+    if (b == NULL)
+        return -1;
+    memset(b, 0, sizeof(*b));
+}
+int declared(void);
+`))
+
+	assert.Equal(t, []string{"class .Server", "method Server.Start"}, names("src/server.cc", `
+class Server : public Base {
+bool Server::Start(int port) {
+`))
+
+	assert.Equal(t, []string{"service .Monorepo", "method .ReadFile", "message .ReadFileRequest"}, names("api.proto", `
+service Monorepo {
+  rpc ReadFile(ReadFileRequest) returns (ReadFileResponse);
+}
+message ReadFileRequest {}
+`))
+
+	assert.Equal(t, []string{"function .build", "function .deploy"}, names("scripts/run.sh", "build() {\n}\nfunction deploy {\n}\n"))
+	assert.Nil(t, Extract("README.md", []byte("# func main")))
+	assert.Empty(t, Language("config.yaml"))
+	assert.Equal(t, "go", Language("main.go"))
+}
@@ -1,6 +1,7 @@
 package poon_tests
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	pb "github.com/nic/poon/poon-proto/gen/go"
 	"github.com/nic/poon/poon-tests/testutil"
 )
 
@@ -19,6 +21,15 @@ func TestCLIWorkflow(t *testing.T) {
 
 	// Set up test server
 	server := testutil.NewTestServer(t)
+	server.TemplatesConfig = filepath.Join(workDir, "templates.json")
+	require.NoError(t, os.WriteFile(server.TemplatesConfig, []byte(`{"templates": [
+		{"name": "backend-dev", "description": "Backend service and docs", "trackedPaths": ["src/backend", "docs"], "metadata": {"team": "backend"}}
+	]}`), 0644))
+	server.WebURL = "http://poon-web.test"
+	require.NoError(t, os.MkdirAll(filepath.Join(server.RepoRoot, ".poon"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(server.RepoRoot, ".poon", "views.json"), []byte(`{"views": [
+		{"name": "backend", "description": "Backend service and config", "paths": ["src/backend", "config", "proto/backend"]}
+	]}`), 0644))
 	defer server.Stop()
 	server.Start(t)
 
@@ -37,6 +48,39 @@ func TestCLIWorkflow(t *testing.T) {
 			AssertContains(t, "status")
 	})
 
+	t.Run("Version", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "version")
+		result.AssertSuccess(t).
+			AssertContains(t, "poon 1.0.0").
+			AssertContains(t, "poon-server").
+			AssertContains(t, "streaming-reads").
+			AssertContains(t, "✓ Supports poon")
+	})
+
+	t.Run("Cat Several Files", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "cat", "src/frontend/app.js", "src/no-such-file.txt", "src/backend/server.go")
+		result.AssertError(t).
+			AssertContains(t, "Welcome to Poon Monorepo").
+			AssertContains(t, "type Response struct").
+			AssertContains(t, "✗ src/no-such-file.txt").
+			AssertContains(t, "failed to read 1 of 3 files")
+	})
+
+	t.Run("Cat Lines", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "cat", "--lines", "3:4", "src/backend/server.go")
+		result.AssertSuccess(t).
+			AssertContains(t, "3  import (").
+			AssertContains(t, "4  \t\"encoding/json\"")
+		assert.NotContains(t, result.Output, "package main")
+	})
+
+	t.Run("Missing Path Hint", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "cat", "src/no-such-file.txt")
+		result.AssertError(t).
+			AssertContains(t, "code = NotFound").
+			AssertContains(t, "hint: src/no-such-file.txt does not exist at version")
+	})
+
 	t.Run("Initialize Workspace", func(t *testing.T) {
 		result := cli.RunCommandWithServer(t, server, "start", "src")
 		result.AssertSuccess(t).
@@ -62,6 +106,118 @@ func TestCLIWorkflow(t *testing.T) {
 		assert.Equal(t, "src", trackedPaths[0], "Should track 'src' path")
 	})
 
+	t.Run("Initialize Workspace From Archive", func(t *testing.T) {
+		archiveDir := t.TempDir()
+		archiveCli := testutil.NewCLIRunner(t, archiveDir)
+		archiveWorkspace := testutil.NewWorkspaceHelper(archiveDir)
+
+		result := archiveCli.RunCommandWithServer(t, server, "start", "--archive", "src")
+		result.AssertSuccess(t).
+			AssertContains(t, "Extracted").
+			AssertContains(t, "Successfully bootstrapped workspace repository")
+		assert.True(t, archiveWorkspace.HasGitDirectory(t), "Should create .git directory")
+
+		// The extracted files match the commit, whose blobs were not fetched
+		status := archiveWorkspace.RunGitCommand(t, "status", "--porcelain", "src").AssertSuccess(t)
+		assert.Empty(t, strings.TrimSpace(status.Output), "Tracked files should be unchanged")
+		archiveWorkspace.RunGitCommand(t, "config", "remote.origin.promisor").
+			AssertSuccess(t).
+			AssertContains(t, "true")
+	})
+
+	t.Run("Initialize Workspace From Template", func(t *testing.T) {
+		cli.RunCommandWithServer(t, server, "workspace", "templates").
+			AssertSuccess(t).
+			AssertContains(t, "backend-dev").
+			AssertContains(t, "Tracks: src/backend, docs")
+
+		templateDir := t.TempDir()
+		templateCli := testutil.NewCLIRunner(t, templateDir)
+		templateWorkspace := testutil.NewWorkspaceHelper(templateDir)
+
+		templateCli.RunCommandWithServer(t, server, "start", "--template", "backend-dev", "config").
+			AssertSuccess(t).
+			AssertContains(t, "Template: backend-dev").
+			AssertContains(t, "Tracking: src/backend, docs, config")
+		assert.Equal(t, []interface{}{"src/backend", "docs", "config"}, templateWorkspace.GetConfig(t)["trackedPaths"])
+		assert.FileExists(t, filepath.Join(templateDir, "src", "backend", "server.go"))
+
+		templateCli.RunCommandWithServer(t, server, "start", "--template", "no-such-template").AssertError(t)
+	})
+
+	t.Run("Initialize Workspace From View", func(t *testing.T) {
+		cli.RunCommandWithServer(t, server, "views").
+			AssertSuccess(t).
+			AssertContains(t, "view:backend").
+			AssertContains(t, "Paths: src/backend, config, proto/backend")
+
+		viewDir := t.TempDir()
+		viewCli := testutil.NewCLIRunner(t, viewDir)
+		viewWorkspace := testutil.NewWorkspaceHelper(viewDir)
+
+		viewCli.RunCommandWithServer(t, server, "start", "view:backend").
+			AssertSuccess(t).
+			AssertContains(t, "Tracking: view:backend (src/backend, config)")
+		config := viewWorkspace.GetConfig(t)
+		assert.Equal(t, []interface{}{"view:backend"}, config["trackedPatterns"])
+		assert.Equal(t, []interface{}{"src/backend", "config"}, config["trackedPaths"])
+		assert.FileExists(t, filepath.Join(viewDir, "config", "app.yaml"))
+
+		viewCli.RunCommandWithServer(t, server, "start", "view:no-such-view").AssertError(t)
+	})
+
+	t.Run("Compose Workspaces", func(t *testing.T) {
+		baseDir := t.TempDir()
+		baseCli := testutil.NewCLIRunner(t, baseDir)
+		baseCli.RunCommandWithServer(t, server, "start", "--name", "compose-base", "src/backend").AssertSuccess(t)
+
+		productDir := t.TempDir()
+		productCli := testutil.NewCLIRunner(t, productDir)
+		product := testutil.NewWorkspaceHelper(productDir)
+		productCli.RunCommandWithServer(t, server, "start", "workspace:compose-base").
+			AssertSuccess(t).
+			AssertContains(t, "Tracking: workspace:compose-base (src/backend)")
+		assert.Equal(t, []interface{}{"workspace:compose-base"}, product.GetConfig(t)["trackedPatterns"])
+		assert.FileExists(t, filepath.Join(productDir, "src", "backend", "server.go"))
+
+		// The base cannot build on a workspace that builds on it
+		productID := product.GetConfig(t)["workspaceName"].(string)
+		baseCli.RunCommandWithServer(t, server, "track", "workspace:"+productID).
+			AssertError(t).
+			AssertContains(t, "workspace composition cycle")
+
+		// The test server ingests its repo root, workspace root included, on
+		// restart, and the name link is not a regular file
+		_, err := server.GetGrpcClient(t).DeleteWorkspace(context.Background(), &pb.DeleteWorkspaceRequest{WorkspaceId: "compose-base"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Named Workspace", func(t *testing.T) {
+		namedDir := t.TempDir()
+		namedCli := testutil.NewCLIRunner(t, namedDir)
+		named := testutil.NewWorkspaceHelper(namedDir)
+
+		namedCli.RunCommandWithServer(t, server, "start", "--name", "named-docs", "src").
+			AssertSuccess(t).
+			AssertContains(t, "Name: named-docs")
+
+		// The name works wherever the ID does, including the git URL
+		id := named.GetConfig(t)["workspaceName"].(string)
+		namedCli.RunCommandWithServer(t, server, "workspace", "get", "named-docs").
+			AssertSuccess(t).
+			AssertContains(t, "ID: "+id)
+		clone := filepath.Join(t.TempDir(), "clone")
+		named.RunGitCommand(t, "clone", server.GetHttpURL()+"/named-docs.git", clone).AssertSuccess(t)
+
+		namedCli.RunCommandWithServer(t, server, "workspace", "create", "named-docs").
+			AssertContains(t, "already taken")
+
+		// The test server ingests its repo root, workspace root included, on
+		// restart, and the name link is not a regular file
+		_, err := server.GetGrpcClient(t).DeleteWorkspace(context.Background(), &pb.DeleteWorkspaceRequest{WorkspaceId: "named-docs"})
+		require.NoError(t, err)
+	})
+
 	t.Run("Workspace Status", func(t *testing.T) {
 		result := cli.RunCommand(t, "status")
 		result.AssertSuccess(t).
@@ -132,6 +288,43 @@ func TestCLIWorkflow(t *testing.T) {
 			AssertContains(t, "Add test file")
 	})
 
+	t.Run("Export And Import Workspace", func(t *testing.T) {
+		// The server has restarted since the workflow's workspace was made,
+		// and workspace records do not survive restarts
+		exportDir := t.TempDir()
+		exportCli := testutil.NewCLIRunner(t, exportDir)
+		exported := testutil.NewWorkspaceHelper(exportDir)
+		exportCli.RunCommandWithServer(t, server, "start", "src").AssertSuccess(t)
+		exported.CreateTestFile(t, "src/exported.txt", "not pushed yet")
+		exported.RunGitCommand(t, "add", "src/exported.txt").AssertSuccess(t)
+		exported.RunGitCommand(t, "commit", "-m", "Unpushed commit").AssertSuccess(t)
+
+		bundle := filepath.Join(t.TempDir(), "workspace.tar.gz")
+		result := exportCli.RunCommand(t, "workspace", "export", bundle)
+		result.AssertSuccess(t).
+			AssertContains(t, "Exported workspace").
+			AssertContains(t, "1 local commit(s)")
+
+		importDir := t.TempDir()
+		importCli := testutil.NewCLIRunner(t, importDir)
+		imported := testutil.NewWorkspaceHelper(importDir)
+
+		result = importCli.RunCommandWithServer(t, server, "workspace", "import", bundle)
+		result.AssertSuccess(t).
+			AssertContains(t, "Imported workspace")
+
+		// The import is the same workspace with the unpushed commit on top
+		assert.Equal(t, exported.GetConfig(t)["workspaceName"], imported.GetConfig(t)["workspaceName"])
+		imported.RunGitCommand(t, "log", "--oneline", "-1").
+			AssertSuccess(t).
+			AssertContains(t, "Unpushed commit")
+		imported.RunGitCommand(t, "status", "-sb", "src").
+			AssertSuccess(t).
+			AssertContains(t, "ahead 1")
+
+		importCli.RunCommandWithServer(t, server, "workspace", "import", bundle).AssertError(t)
+	})
+
 	t.Run("Push and Sync Commands", func(t *testing.T) {
 		// Test push command (should complete even if not fully implemented)
 		result := cli.RunCommandWithServer(t, server, "push")
@@ -149,6 +342,28 @@ func TestCLIWorkflow(t *testing.T) {
 			t.Logf("Sync command failed (expected): %v", result.Error)
 		}
 	})
+
+	t.Run("Status After Sync", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "sync")
+		result.AssertSuccess(t)
+
+		result = cli.RunCommandWithServer(t, server, "status")
+		result.AssertSuccess(t).
+			AssertContains(t, "src  ✓ up to date")
+	})
+
+	t.Run("Open In Web UI", func(t *testing.T) {
+		// Links point at the version the workspace was synced to
+		cli.RunCommandWithServer(t, server, "open", "--no-browser", "src/frontend/app.js").
+			AssertSuccess(t).
+			AssertContains(t, "http://poon-web.test/?file=%2Fsrc%2Ffrontend%2Fapp.js&version=")
+		cli.RunCommandWithServer(t, server, "open", "-n").
+			AssertSuccess(t).
+			AssertContains(t, "http://poon-web.test/?path=%2F")
+		cli.RunCommandWithServer(t, server, "open", "-n", "..").
+			AssertError(t).
+			AssertContains(t, "outside the workspace")
+	})
 }
 
 func TestCLIErrorHandling(t *testing.T) {
@@ -179,6 +394,15 @@ func TestCLIErrorHandling(t *testing.T) {
 			AssertContains(t, "no poon workspace found")
 	})
 
+	t.Run("Stash Without Workspace", func(t *testing.T) {
+		newWorkDir := t.TempDir()
+		newCli := testutil.NewCLIRunner(t, newWorkDir)
+
+		result := newCli.RunCommand(t, "stash")
+		result.AssertError(t).
+			AssertContains(t, "no poon workspace found")
+	})
+
 	t.Run("Start In Existing Workspace", func(t *testing.T) {
 		// Create a new temporary directory for this test
 		newWorkDir := t.TempDir()
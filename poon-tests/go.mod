@@ -5,24 +5,37 @@ go 1.23.0
 toolchain go1.23.3
 
 require (
+	github.com/nic/poon/poon-git v0.0.0-00010101000000-000000000000
 	github.com/nic/poon/poon-proto/gen/go v0.0.0-00010101000000-000000000000
+	github.com/nic/poon/poon-server v0.0.0-00010101000000-000000000000
 	github.com/stretchr/testify v1.8.4
 	google.golang.org/grpc v1.74.2
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 replace github.com/nic/poon => ../
 
 replace github.com/nic/poon/poon-cli => ../poon-cli
 
+replace github.com/nic/poon/poon-git => ../poon-git
+
+replace github.com/nic/poon/poon-server => ../poon-server
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/klauspost/cpuid/v2 v2.2.10 // indirect
+	github.com/nic/poon/poon-go v0.0.0-00010101000000-000000000000 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250528174236-200df99c418a // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	lukechampine.com/blake3 v1.4.1 // indirect
 )
 
 replace github.com/nic/poon/poon-proto/gen/go => ../poon-proto/gen/go
+
+replace github.com/nic/poon/poon-go => ../poon-go
@@ -0,0 +1,14 @@
+package poon_tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nic/poon/poon-tests/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.RemoveCLIBuild()
+	os.Exit(code)
+}
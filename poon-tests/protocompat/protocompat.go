@@ -0,0 +1,28 @@
+// Package protocompat records the released API that buf checks later
+// versions of monorepo.proto against. The check itself is `buf breaking`
+// with the WIRE_JSON rules of poon-proto/buf.yaml, run by this package's
+// test.
+package protocompat
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// WriteBaseline records file as the API later versions are checked against,
+// as a buf image: a FileDescriptorSet holding file, which has no imports
+func WriteBaseline(path string, file protoreflect.FileDescriptor) error {
+	image := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(file)},
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(image)
+	if err != nil {
+		return fmt.Errorf("failed to marshal proto baseline: %v", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,59 @@
+package protocompat
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// protoDir holds monorepo.proto and the buf.yaml naming the rules
+	protoDir = "../../poon-proto"
+
+	// baselinePath is the API released clients were built against, relative
+	// to protoDir
+	baselinePath = "compat/monorepo.binpb"
+)
+
+var updateBaseline = flag.Bool("update-proto-baseline", false, "record the current monorepo.proto as the compatibility baseline")
+
+func TestMonorepoProtoIsBackwardCompatible(t *testing.T) {
+	if *updateBaseline {
+		require.NoError(t, WriteBaseline(protoDir+"/"+baselinePath, pb.File_monorepo_proto))
+		t.Logf("Wrote %s", baselinePath)
+	}
+
+	buf, err := exec.LookPath("buf")
+	if err != nil {
+		t.Skip("buf is not installed; install it (https://buf.build/docs/installation) to check monorepo.proto against the released API")
+	}
+
+	cmd := exec.Command(buf, "breaking", "--against", baselinePath)
+	cmd.Dir = protoDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("monorepo.proto breaks released clients (buf breaking: %v):\n%s\n"+
+			"Reserve removed field numbers and names instead of reusing them, and put "+
+			"incompatible changes in a new package (see poon-proto/README.md)", err, out)
+	}
+}
+
+// The baseline must stay an image buf can read even where buf is not
+// installed to read it
+func TestBaselineIsImage(t *testing.T) {
+	data, err := os.ReadFile(protoDir + "/" + baselinePath)
+	require.NoError(t, err)
+
+	var image descriptorpb.FileDescriptorSet
+	require.NoError(t, proto.Unmarshal(data, &image))
+	require.Len(t, image.File, 1)
+	assert.Equal(t, "monorepo.proto", image.File[0].GetName())
+	assert.Equal(t, "monorepo", image.File[0].GetPackage())
+}
@@ -0,0 +1,91 @@
+package poon_tests
+
+import (
+	"testing"
+
+	"github.com/nic/poon/poon-tests/testutil"
+)
+
+func TestFixtureServer(t *testing.T) {
+	fixture := testutil.LoadFixture(t, "services")
+	server := testutil.NewFixtureServer(t, fixture)
+	server.Start(t)
+	defer server.Stop()
+
+	// Every version of the fixture is a version of the repository
+	server.AssertFixture(t)
+	server.AssertFile(t, 2, "services/api/handlers.go", *fixture.Versions[1].Files["services/api/handlers.go"])
+	server.AssertNoFile(t, 3, "services/api/handlers.go")
+
+	// Changes merged by hand land one file per version
+	version := server.MergeChanges(t, "carol@example.com", "Document the web service", map[string]*string{
+		"services/web/README.md":  testutil.Content("# Web\n"),
+		"services/web/index.html": nil,
+	})
+	if version != 5 {
+		t.Fatalf("Merging two files made version %d, want 5", version)
+	}
+	server.AssertTree(t, 0, "services/web", map[string]string{
+		"services/web/README.md":   "# Web\n",
+		"services/web/config.json": "{\"port\": 3000}\n",
+	})
+}
+
+func TestParseFixture(t *testing.T) {
+	for name, yaml := range map[string]string{
+		"no versions":          "description: Empty\n",
+		"empty version":        "versions:\n  - message: Nothing\n",
+		"deletes at version 1": "versions:\n  - message: Import\n    files:\n      a.txt: null\n",
+		"malformed":            "versions: [",
+	} {
+		if _, err := testutil.ParseFixture([]byte(yaml)); err == nil {
+			t.Errorf("ParseFixture accepted a fixture with %s", name)
+		}
+	}
+
+	fixture, err := testutil.ParseFixture([]byte("versions:\n  - message: Import\n    files:\n      a.txt: one\n      b.txt: two\n  - message: Drop a\n    files:\n      a.txt: null\n"))
+	if err != nil {
+		t.Fatalf("ParseFixture failed: %v", err)
+	}
+	if tree := fixture.Tree(2); len(tree) != 1 || tree["b.txt"] != "two" {
+		t.Errorf("Tree(2) = %v, want only b.txt", tree)
+	}
+}
+
+func TestScenarioStartCheckout(t *testing.T) {
+	testutil.RunScenario(t, testutil.Scenario{
+		Fixture: "services",
+		Steps: []testutil.Step{
+			testutil.Poon("start", "services/api"),
+			testutil.ExpectFixtureFiles("services/api"),
+			testutil.ExpectWorkspaceFiles(map[string]*string{
+				"services/api/handlers.go": nil, // Deleted at version 3
+				"services/web/index.html":  nil, // Not tracked
+			}),
+		},
+	})
+}
+
+func TestScenarioPushAndSync(t *testing.T) {
+	testutil.RunScenario(t, testutil.Scenario{
+		Fixture: "services",
+		Steps: []testutil.Step{
+			testutil.Poon("start", "services/api"),
+			testutil.Edit(map[string]*string{
+				"services/api/metrics.go": testutil.Content("package main\n"),
+			}),
+			testutil.Commit("Add metrics"),
+			testutil.Poon("push"),
+			testutil.Upstream("alice@example.com", "Configure the web port", map[string]*string{
+				"services/web/config.json": testutil.Content("{\"port\": 8080}\n"),
+			}),
+			testutil.ExpectServerFiles(map[string]*string{
+				"services/web/config.json": testutil.Content("{\"port\": 8080}\n"),
+			}),
+			testutil.Poon("sync"),
+			testutil.ExpectWorkspaceFiles(map[string]*string{
+				"services/api/metrics.go": testutil.Content("package main\n"),
+			}),
+		},
+	})
+}
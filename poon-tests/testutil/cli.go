@@ -2,10 +2,12 @@ package testutil
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -15,7 +17,16 @@ type CLIRunner struct {
 	BinPath string
 }
 
-// NewCLIRunner creates a new CLI runner
+// cliBuild is the CLI binary shared by every runner in the test process
+var cliBuild struct {
+	once sync.Once
+	dir  string
+	path string
+	err  error
+}
+
+// NewCLIRunner creates a new CLI runner. The CLI is built once per test
+// process and linked into workDir.
 func NewCLIRunner(t *testing.T, workDir string) *CLIRunner {
 	// Check if CLI binary already exists
 	binPath := filepath.Join(workDir, "poon-test")
@@ -26,20 +37,41 @@ func NewCLIRunner(t *testing.T, workDir string) *CLIRunner {
 			BinPath: binPath,
 		}
 	}
-	
-	// Build CLI binary for testing from its directory
-	buildCmd := exec.Command("go", "build", "-o", binPath)
-	buildCmd.Dir = "../poon-cli"
-	if err := buildCmd.Run(); err != nil {
-		t.Fatalf("Failed to build CLI: %v", err)
+
+	cliBuild.once.Do(func() {
+		if cliBuild.dir, cliBuild.err = os.MkdirTemp("", "poon-tests-cli-*"); cliBuild.err != nil {
+			return
+		}
+		cliBuild.path = filepath.Join(cliBuild.dir, "poon")
+
+		// Build CLI binary for testing from its directory
+		buildCmd := exec.Command("go", "build", "-o", cliBuild.path)
+		buildCmd.Dir = "../poon-cli"
+		if output, err := buildCmd.CombinedOutput(); err != nil {
+			cliBuild.err = fmt.Errorf("%v\n%s", err, output)
+		}
+	})
+	if cliBuild.err != nil {
+		t.Fatalf("Failed to build CLI: %v", cliBuild.err)
 	}
-	
+	if err := os.Link(cliBuild.path, binPath); err != nil {
+		t.Fatalf("Failed to link CLI into %s: %v", workDir, err)
+	}
+
 	return &CLIRunner{
 		WorkDir: workDir,
 		BinPath: binPath,
 	}
 }
 
+// RemoveCLIBuild deletes the shared CLI binary; call it from TestMain once
+// the tests are done
+func RemoveCLIBuild() {
+	if cliBuild.dir != "" {
+		os.RemoveAll(cliBuild.dir)
+	}
+}
+
 // RunCommand executes a CLI command and returns output
 func (c *CLIRunner) RunCommand(t *testing.T, args ...string) *CommandResult {
 	cmd := exec.Command(c.BinPath, args...)
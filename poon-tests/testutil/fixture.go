@@ -0,0 +1,167 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/nic/poon/poon-server/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a repository described in YAML, one entry per version:
+//
+//	description: Two services sharing a library
+//	versions:
+//	  - message: Initial import
+//	    files:
+//	      services/api/main.go: |
+//	        package main
+//	  - message: Drop the old handler
+//	    author: alice@example.com
+//	    files:
+//	      services/api/handler.go: null # Deleted
+//	      services/api/routes.go: |
+//	        package main
+//
+// The first version is the whole tree; later versions list only the files
+// they add, change or (with null) delete. Fixtures live in
+// testdata/fixtures/<name>.yaml.
+type Fixture struct {
+	Description string           `yaml:"description"`
+	Versions    []FixtureVersion `yaml:"versions"`
+}
+
+// FixtureVersion is one version of a Fixture
+type FixtureVersion struct {
+	Message string             `yaml:"message"`
+	Author  string             `yaml:"author"`
+	Files   map[string]*string `yaml:"files"` // nil deletes the file
+}
+
+// ParseFixture reads a fixture from YAML
+func ParseFixture(data []byte) (*Fixture, error) {
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, err
+	}
+	if len(fixture.Versions) == 0 {
+		return nil, fmt.Errorf("fixture has no versions")
+	}
+	for path, content := range fixture.Versions[0].Files {
+		if content == nil {
+			return nil, fmt.Errorf("first version deletes %s, which does not exist yet", path)
+		}
+	}
+	for i, version := range fixture.Versions {
+		if len(version.Files) == 0 {
+			return nil, fmt.Errorf("version %d changes no files", i+1)
+		}
+	}
+	return &fixture, nil
+}
+
+// LoadFixture reads testdata/fixtures/<name>.yaml
+func LoadFixture(t *testing.T, name string) *Fixture {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", name+".yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	fixture, err := ParseFixture(data)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture %s: %v", name, err)
+	}
+	return fixture
+}
+
+// Tree returns every file of the fixture's version n, counting from 1
+func (f *Fixture) Tree(n int) map[string]string {
+	tree := make(map[string]string)
+	for _, version := range f.Versions[:n] {
+		applyChanges(tree, version.Files)
+	}
+	return tree
+}
+
+// applyChanges updates tree with the files of a version
+func applyChanges(tree map[string]string, files map[string]*string) {
+	for path, content := range files {
+		if content == nil {
+			delete(tree, path)
+		} else {
+			tree[path] = *content
+		}
+	}
+}
+
+// writeTree writes the files of tree below dir
+func writeTree(t *testing.T, dir string, tree map[string]string) {
+	t.Helper()
+	for path, content := range tree {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", path, err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
+
+// seedFixture writes every version of fixture to a filesystem storage
+// backend at root, for a server to open
+func seedFixture(t *testing.T, root string, fixture *Fixture) {
+	t.Helper()
+	backend, err := storage.NewFilesystemBackend(root)
+	if err != nil {
+		t.Fatalf("Failed to create fixture storage: %v", err)
+	}
+	repository := storage.NewRepository(backend)
+	for n, version := range fixture.Versions {
+		dir := t.TempDir()
+		writeTree(t, dir, fixture.Tree(n+1))
+		author := version.Author
+		if author == "" {
+			author = defaultAuthor
+		}
+		if _, err := repository.CreateCommitFromFileSystem(context.Background(), dir, author, version.Message); err != nil {
+			t.Fatalf("Failed to commit fixture version %d: %v", n+1, err)
+		}
+	}
+}
+
+// filePatch returns the git patch that changes path from old to new, as
+// poon push would send it. A nil old creates the file and a nil new
+// deletes it.
+func filePatch(t *testing.T, path string, old, new *string) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) []byte {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=poon-tests", "-c", "user.email=" + defaultAuthor}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+		return output
+	}
+
+	git("init", "-q")
+	if old != nil {
+		writeTree(t, dir, map[string]string{path: *old})
+	}
+	git("add", "-A")
+	git("commit", "-q", "--allow-empty", "-m", "base")
+
+	if new != nil {
+		writeTree(t, dir, map[string]string{path: *new})
+	} else if err := os.Remove(filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+		t.Fatalf("Cannot delete %s, which does not exist: %v", path, err)
+	}
+	git("add", "-A")
+	return git("diff", "--cached", "--binary", "--full-index")
+}
@@ -0,0 +1,137 @@
+package testutil
+
+import (
+	"context"
+	"path"
+	"sort"
+	"testing"
+
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultAuthor is the author of versions merged for fixtures and
+// scenarios that name none
+const defaultAuthor = "poon-tests@example.com"
+
+// MergeChanges lands a change on the server as another developer would,
+// without a workspace, and returns the new version number. Files set to nil
+// are deleted. The server applies one file per patch, so each file becomes
+// a version of its own, in path order.
+func (ts *TestServer) MergeChanges(t *testing.T, author, message string, files map[string]*string) int64 {
+	t.Helper()
+	if author == "" {
+		author = defaultAuthor
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	client := ts.GetGrpcClient(t)
+	for _, path := range paths {
+		var old *string
+		resp, err := client.ReadFile(context.Background(), &pb.ReadFileRequest{Path: path})
+		if err == nil {
+			content := string(resp.Content)
+			old = &content
+		} else if status.Code(err) != codes.NotFound {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+
+		merge, err := client.MergePatch(context.Background(), &pb.MergePatchRequest{
+			Patch:                   filePatch(t, path, old, files[path]),
+			Message:                 message,
+			Author:                  author,
+			PreserveTrailingNewline: true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to merge %s: %v", path, err)
+		}
+		if !merge.Success {
+			t.Fatalf("Failed to merge %s: %s", path, merge.Message)
+		}
+	}
+	return ts.CurrentVersion(t)
+}
+
+// CurrentVersion returns the repository's current version
+func (ts *TestServer) CurrentVersion(t *testing.T) int64 {
+	t.Helper()
+	stream, err := ts.GetGrpcClient(t).StreamDirectory(context.Background(), &pb.StreamDirectoryRequest{})
+	if err != nil {
+		t.Fatalf("Failed to read the repository root: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to read the repository root: %v", err)
+	}
+	return resp.Version
+}
+
+// RepositoryTree returns the content of every file below dir ("" for the
+// whole repository) at version (0 for the current one), by path from the
+// repository root
+func (ts *TestServer) RepositoryTree(t *testing.T, version int64, dir string) map[string]string {
+	t.Helper()
+	client := ts.GetGrpcClient(t)
+	tree := make(map[string]string)
+	var walk func(dir string)
+	walk = func(dir string) {
+		resp, err := client.ReadDirectory(context.Background(), &pb.ReadDirectoryRequest{Path: dir, Version: version})
+		if err != nil {
+			t.Fatalf("Failed to read directory %q at version %d: %v", dir, version, err)
+		}
+		for _, item := range resp.Items {
+			name := path.Join(dir, item.Name)
+			if item.IsDir {
+				walk(name)
+				continue
+			}
+			file, err := client.ReadFile(context.Background(), &pb.ReadFileRequest{Path: name, Version: version})
+			if err != nil {
+				t.Fatalf("Failed to read %s at version %d: %v", name, version, err)
+			}
+			tree[name] = string(file.Content)
+		}
+	}
+	walk(dir)
+	return tree
+}
+
+// AssertFile asserts that path holds want at version (0 for the current one)
+func (ts *TestServer) AssertFile(t *testing.T, version int64, path, want string) {
+	t.Helper()
+	resp, err := ts.GetGrpcClient(t).ReadFile(context.Background(), &pb.ReadFileRequest{Path: path, Version: version})
+	if err != nil {
+		t.Fatalf("Failed to read %s at version %d: %v", path, version, err)
+	}
+	assert.Equal(t, want, string(resp.Content), "%s at version %d", path, version)
+}
+
+// AssertNoFile asserts that path does not exist at version (0 for the
+// current one)
+func (ts *TestServer) AssertNoFile(t *testing.T, version int64, path string) {
+	t.Helper()
+	_, err := ts.GetGrpcClient(t).ReadFile(context.Background(), &pb.ReadFileRequest{Path: path, Version: version})
+	assert.Equal(t, codes.NotFound, status.Code(err), "%s should not exist at version %d", path, version)
+}
+
+// AssertTree asserts that the files below dir at version (0 for the current
+// one) are exactly want, by path from the repository root
+func (ts *TestServer) AssertTree(t *testing.T, version int64, dir string, want map[string]string) {
+	t.Helper()
+	assert.Equal(t, want, ts.RepositoryTree(t, version, dir), "files below %q at version %d", dir, version)
+}
+
+// AssertFixture asserts that every version of the repository up to the
+// fixture's last holds the files of the fixture's version
+func (ts *TestServer) AssertFixture(t *testing.T) {
+	t.Helper()
+	for n := range ts.Fixture.Versions {
+		ts.AssertTree(t, int64(n+1), "", ts.Fixture.Tree(n+1))
+	}
+}
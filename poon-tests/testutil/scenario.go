@@ -0,0 +1,177 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Scenario is an end-to-end test against a fixture repository: a server
+// seeded with the fixture, a workspace directory and the CLI, driven
+// through steps that run in order
+type Scenario struct {
+	Fixture string // Name of the fixture in testdata/fixtures
+	Steps   []Step
+}
+
+// Step is one named action or check of a Scenario
+type Step struct {
+	Name string
+	Run  func(t *testing.T, env *Env)
+}
+
+// Env is what the steps of a running Scenario act on
+type Env struct {
+	Server    *TestServer
+	Fixture   *Fixture
+	Workspace *WorkspaceHelper
+	CLI       *CLIRunner
+}
+
+// RunScenario runs the steps of s as subtests, stopping at the first that
+// fails, as later steps build on the state the earlier ones left
+func RunScenario(t *testing.T, s Scenario) {
+	t.Helper()
+	fixture := LoadFixture(t, s.Fixture)
+	server := NewFixtureServer(t, fixture)
+	server.Start(t)
+	defer server.Stop()
+
+	workDir := t.TempDir()
+	env := &Env{
+		Server:    server,
+		Fixture:   fixture,
+		Workspace: NewWorkspaceHelper(workDir),
+		CLI:       NewCLIRunner(t, workDir),
+	}
+	for _, step := range s.Steps {
+		if !t.Run(step.Name, func(t *testing.T) { step.Run(t, env) }) {
+			t.Fatalf("Step %q failed; skipping the rest of the scenario", step.Name)
+		}
+	}
+}
+
+// Poon runs the CLI against the scenario's server, failing the step if it
+// fails
+func (env *Env) Poon(t *testing.T, args ...string) *CommandResult {
+	t.Helper()
+	return env.CLI.RunCommandWithServer(t, env.Server, args...).AssertSuccess(t)
+}
+
+// Commit stages every change in the workspace and commits it
+func (env *Env) Commit(t *testing.T, message string) {
+	t.Helper()
+	env.Workspace.RunGitCommand(t, "add", "-A").AssertSuccess(t)
+	env.Workspace.RunGitCommand(t, "-c", "user.name=poon-tests", "-c", "user.email="+defaultAuthor, "commit", "-q", "-m", message).AssertSuccess(t)
+}
+
+// Poon is a step running the CLI with args
+func Poon(args ...string) Step {
+	return Step{
+		Name: "poon " + strings.Join(args, " "),
+		Run: func(t *testing.T, env *Env) {
+			env.Poon(t, args...)
+		},
+	}
+}
+
+// Edit is a step writing files into the workspace; files set to nil are
+// removed
+func Edit(files map[string]*string) Step {
+	return Step{
+		Name: "edit",
+		Run: func(t *testing.T, env *Env) {
+			for path, content := range files {
+				if content != nil {
+					env.Workspace.CreateTestFile(t, path, *content)
+				} else if err := os.Remove(filepath.Join(env.Workspace.Path, filepath.FromSlash(path))); err != nil {
+					t.Fatalf("Failed to remove %s: %v", path, err)
+				}
+			}
+		},
+	}
+}
+
+// Commit is a step committing every change in the workspace
+func Commit(message string) Step {
+	return Step{
+		Name: "commit",
+		Run: func(t *testing.T, env *Env) {
+			env.Commit(t, message)
+		},
+	}
+}
+
+// Upstream is a step landing a change on the server from elsewhere, as
+// another developer would
+func Upstream(author, message string, files map[string]*string) Step {
+	return Step{
+		Name: "upstream " + message,
+		Run: func(t *testing.T, env *Env) {
+			env.Server.MergeChanges(t, author, message, files)
+		},
+	}
+}
+
+// ExpectServerFiles is a step asserting the current content of files on
+// the server; files set to nil must not exist
+func ExpectServerFiles(files map[string]*string) Step {
+	return Step{
+		Name: "expect server files",
+		Run: func(t *testing.T, env *Env) {
+			for path, content := range files {
+				if content != nil {
+					env.Server.AssertFile(t, 0, path, *content)
+				} else {
+					env.Server.AssertNoFile(t, 0, path)
+				}
+			}
+		},
+	}
+}
+
+// ExpectWorkspaceFiles is a step asserting the content of files in the
+// workspace; files set to nil must not exist
+func ExpectWorkspaceFiles(files map[string]*string) Step {
+	return Step{
+		Name: "expect workspace files",
+		Run: func(t *testing.T, env *Env) {
+			for path, content := range files {
+				data, err := os.ReadFile(filepath.Join(env.Workspace.Path, filepath.FromSlash(path)))
+				if content == nil {
+					assert.True(t, os.IsNotExist(err), "%s should not exist in the workspace", path)
+					continue
+				}
+				if assert.NoError(t, err, "%s should exist in the workspace", path) {
+					assert.Equal(t, *content, string(data), "%s in the workspace", path)
+				}
+			}
+		},
+	}
+}
+
+// ExpectFixtureFiles is a step asserting that the workspace holds the files
+// below dir of the fixture's last version
+func ExpectFixtureFiles(dir string) Step {
+	return Step{
+		Name: "expect fixture files below " + dir,
+		Run: func(t *testing.T, env *Env) {
+			files := make(map[string]*string)
+			for path, content := range env.Fixture.Tree(len(env.Fixture.Versions)) {
+				if rel, err := filepath.Rel(dir, path); err == nil && filepath.IsLocal(rel) {
+					files[path] = &content
+				}
+			}
+			ExpectWorkspaceFiles(files).Run(t, env)
+		},
+	}
+}
+
+// Content returns a pointer to content, for the file maps of steps and
+// fixtures
+func Content(content string) *string {
+	return &content
+}
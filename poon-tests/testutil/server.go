@@ -1,44 +1,51 @@
 package testutil
 
 import (
-	"context"
 	"fmt"
 	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"testing"
-	"time"
 
+	"github.com/nic/poon/poon-git/gitserver"
+	pb "github.com/nic/poon/poon-proto/gen/go"
+	"github.com/nic/poon/poon-server/server"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	pb "github.com/nic/poon/poon-proto/gen/go"
 )
 
-// TestServer manages test server instances for workflow integration testing
+// TestServer runs poon-server and poon-git in the test process, on free
+// ports with the in-memory storage backend. Stop and Start again gives a
+// fresh server on the same ports, as restarting the binaries would.
 type TestServer struct {
-	GrpcPort    int
-	HttpPort    int
-	RepoRoot    string
-	grpcCmd     *exec.Cmd
-	httpCmd     *exec.Cmd
-	grpcClient  pb.MonorepoServiceClient
-	grpcConn    *grpc.ClientConn
-	mu          sync.Mutex
-	running     bool
+	GrpcPort int // Set by the first Start
+	HttpPort int
+	RepoRoot string
+
+	TemplatesConfig string   // The server's WORKSPACE_TEMPLATES_CONFIG; set before Start
+	WebURL          string   // The server's WEB_URL; set before Start
+	WorkspaceRoot   string   // Where workspace repositories go; RepoRoot/workspaces when empty
+	StorageBackend  string   // The server's STORAGE_BACKEND; in memory when empty
+	Fixture         *Fixture // The fixture StorageBackend was seeded with, if any
+
+	grpcServer *server.Instance
+	gitServer  *gitserver.Instance
+	grpcClient pb.MonorepoServiceClient
+	grpcConn   *grpc.ClientConn
+	mu         sync.Mutex
+	running    bool
 }
 
 // NewTestServer creates a new test server instance
 func NewTestServer(t *testing.T) *TestServer {
 	tempDir := t.TempDir()
-	
+
 	// Create sample monorepo structure
 	setupSampleRepo(t, tempDir)
-	
+
 	return &TestServer{
-		GrpcPort: GetFreePort(t),
-		HttpPort: GetFreePort(t),
 		RepoRoot: tempDir,
 	}
 }
@@ -47,46 +54,87 @@ func NewTestServer(t *testing.T) *TestServer {
 func (ts *TestServer) Start(t *testing.T) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
-	
+
 	if ts.running {
 		return
 	}
-	
-	// Start gRPC server
-	ts.startGrpcServer(t)
-	
-	// Start HTTP server
-	ts.startHttpServer(t)
-	
-	// Wait for servers to be ready
-	ts.waitForReady(t)
-	
+
+	workspaceRoot := ts.WorkspaceRoot
+	if workspaceRoot == "" {
+		workspaceRoot = filepath.Join(ts.RepoRoot, "workspaces")
+	}
+
+	// The git server starts first so workspace remote URLs can name its port
+	gitServer, err := gitserver.Start(gitserver.Config{
+		Addr:          fmt.Sprintf("localhost:%d", ts.HttpPort),
+		WorkspaceRoot: workspaceRoot,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start HTTP server: %v", err)
+	}
+	ts.gitServer = gitServer
+	ts.HttpPort = gitServer.Addr().(*net.TCPAddr).Port
+
+	cfg := server.DefaultConfig()
+	cfg.Addr = fmt.Sprintf("localhost:%d", ts.GrpcPort)
+	cfg.RepoRoot = ts.RepoRoot
+	cfg.WorkspaceRoot = workspaceRoot
+	cfg.GitServerPort = strconv.Itoa(ts.HttpPort)
+	cfg.TemplatesConfig = ts.TemplatesConfig
+	cfg.WebURL = ts.WebURL
+	if ts.StorageBackend != "" {
+		cfg.StorageBackend = ts.StorageBackend
+	}
+	grpcServer, err := server.Start(cfg)
+	if err != nil {
+		gitServer.Close()
+		t.Fatalf("Failed to start gRPC server: %v", err)
+	}
+	ts.grpcServer = grpcServer
+	ts.GrpcPort = grpcServer.Addr().(*net.TCPAddr).Port
+
 	ts.running = true
 }
 
+// NewFixtureServer creates a test server whose repository holds the
+// versions of fixture, with their authors and messages, so version n of the
+// repository is version n of the fixture. The versions are written to the
+// server's storage before it starts.
+func NewFixtureServer(t *testing.T, fixture *Fixture) *TestServer {
+	tempDir := t.TempDir()
+	repoRoot := filepath.Join(tempDir, "repo")
+	if err := os.MkdirAll(repoRoot, 0755); err != nil {
+		t.Fatalf("Failed to create repository root: %v", err)
+	}
+	storageRoot := filepath.Join(tempDir, "storage")
+	seedFixture(t, storageRoot, fixture)
+
+	return &TestServer{
+		RepoRoot:       repoRoot,
+		WorkspaceRoot:  filepath.Join(tempDir, "workspaces"),
+		StorageBackend: storageRoot,
+		Fixture:        fixture,
+	}
+}
+
 // Stop stops both servers
 func (ts *TestServer) Stop() {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
-	
+
 	if !ts.running {
 		return
 	}
-	
+
 	if ts.grpcConn != nil {
 		ts.grpcConn.Close()
+		ts.grpcConn = nil
+		ts.grpcClient = nil
 	}
-	
-	if ts.grpcCmd != nil && ts.grpcCmd.Process != nil {
-		ts.grpcCmd.Process.Kill()
-		ts.grpcCmd.Wait()
-	}
-	
-	if ts.httpCmd != nil && ts.httpCmd.Process != nil {
-		ts.httpCmd.Process.Kill()
-		ts.httpCmd.Wait()
-	}
-	
+
+	ts.grpcServer.Stop()
+	ts.gitServer.Close()
+
 	ts.running = false
 }
 
@@ -95,7 +143,7 @@ func (ts *TestServer) GetGrpcClient(t *testing.T) pb.MonorepoServiceClient {
 	if ts.grpcClient != nil {
 		return ts.grpcClient
 	}
-	
+
 	conn, err := grpc.Dial(
 		fmt.Sprintf("localhost:%d", ts.GrpcPort),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -103,7 +151,7 @@ func (ts *TestServer) GetGrpcClient(t *testing.T) pb.MonorepoServiceClient {
 	if err != nil {
 		t.Fatalf("Failed to connect to gRPC server: %v", err)
 	}
-	
+
 	ts.grpcConn = conn
 	ts.grpcClient = pb.NewMonorepoServiceClient(conn)
 	return ts.grpcClient
@@ -119,65 +167,6 @@ func (ts *TestServer) GetGrpcAddr() string {
 	return fmt.Sprintf("localhost:%d", ts.GrpcPort)
 }
 
-func (ts *TestServer) startGrpcServer(t *testing.T) {
-	serverPath := filepath.Join("..", "poon-server")
-	workspaceRoot := filepath.Join(ts.RepoRoot, "workspaces")
-	
-	ts.grpcCmd = exec.Command("go", "run", ".")
-	ts.grpcCmd.Dir = serverPath
-	ts.grpcCmd.Env = append(os.Environ(),
-		fmt.Sprintf("PORT=%d", ts.GrpcPort),
-		fmt.Sprintf("REPO_ROOT=%s", ts.RepoRoot),
-		fmt.Sprintf("WORKSPACE_ROOT=%s", workspaceRoot),
-		fmt.Sprintf("GIT_SERVER_PORT=%d", ts.HttpPort),
-	)
-	
-	if err := ts.grpcCmd.Start(); err != nil {
-		t.Fatalf("Failed to start gRPC server: %v", err)
-	}
-}
-
-func (ts *TestServer) startHttpServer(t *testing.T) {
-	serverPath := filepath.Join("..", "poon-git")
-	workspaceRoot := filepath.Join(ts.RepoRoot, "workspaces")
-	
-	ts.httpCmd = exec.Command("go", "run", ".")
-	ts.httpCmd.Dir = serverPath
-	ts.httpCmd.Env = append(os.Environ(),
-		fmt.Sprintf("PORT=%d", ts.HttpPort),
-		fmt.Sprintf("GRPC_SERVER=localhost:%d", ts.GrpcPort),
-		fmt.Sprintf("WORKSPACE_ROOT=%s", workspaceRoot),
-	)
-	
-	if err := ts.httpCmd.Start(); err != nil {
-		t.Fatalf("Failed to start HTTP server: %v", err)
-	}
-}
-
-func (ts *TestServer) waitForReady(t *testing.T) {
-	// Wait for gRPC server
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	
-	for {
-		select {
-		case <-ctx.Done():
-			t.Fatal("Timeout waiting for servers to start")
-		default:
-			conn, err := grpc.Dial(
-				fmt.Sprintf("localhost:%d", ts.GrpcPort),
-				grpc.WithTransportCredentials(insecure.NewCredentials()),
-			)
-			if err == nil {
-				conn.Close()
-				time.Sleep(100 * time.Millisecond) // Give HTTP server time too
-				return
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
-	}
-}
-
 // GetFreePort finds an available port for testing
 func GetFreePort(t *testing.T) int {
 	listener, err := net.Listen("tcp", "localhost:0")
@@ -274,6 +274,70 @@ func TestMultipleWorkspaceCreation(t *testing.T) {
 	})
 }
 
+func TestMultipleWorkspacesInOneCheckout(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Stop()
+	server.Start(t)
+
+	time.Sleep(2 * time.Second)
+
+	workDir := t.TempDir()
+	cli := testutil.NewCLIRunner(t, workDir)
+	workspace := testutil.NewWorkspaceHelper(workDir)
+
+	cli.RunCommandWithServer(t, server, "start", "src").AssertSuccess(t)
+
+	t.Run("DiscoverFromSubdirectory", func(t *testing.T) {
+		subDir := filepath.Join(workDir, "src", "nested")
+		require.NoError(t, os.MkdirAll(subDir, 0755))
+		subCli := &testutil.CLIRunner{WorkDir: subDir, BinPath: cli.BinPath}
+
+		result := subCli.RunCommandWithServer(t, server, "status")
+		result.AssertSuccess(t)
+		assert.Contains(t, result.Output, "(default)")
+		assert.Contains(t, result.Output, "  src")
+	})
+
+	t.Run("AddRemote", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "remote", "add", "docs", "docs")
+		result.AssertSuccess(t)
+		assert.Contains(t, result.Output, "Added workspace docs")
+
+		config := workspace.GetConfig(t)
+		remotes, ok := config["remotes"].(map[string]interface{})
+		require.True(t, ok, "remotes should be an object")
+		require.Contains(t, remotes, "docs")
+
+		result = workspace.RunGitCommand(t, "remote")
+		result.AssertSuccess(t)
+		assert.Contains(t, result.Output, "docs")
+
+		result = cli.RunCommandWithServer(t, server, "remote")
+		result.AssertSuccess(t)
+		assert.Contains(t, result.Output, "* default")
+		assert.Contains(t, result.Output, "  docs")
+	})
+
+	t.Run("SelectRemote", func(t *testing.T) {
+		result := cli.RunCommandWithServer(t, server, "--workspace", "docs", "status")
+		result.AssertSuccess(t)
+		assert.Contains(t, result.Output, "(docs)")
+		assert.Contains(t, result.Output, "Tracked Paths (1):\n  docs")
+
+		result = cli.RunCommandWithServer(t, server, "--workspace", "missing", "status")
+		result.AssertError(t)
+		assert.Contains(t, result.Output, "unknown workspace")
+	})
+
+	t.Run("RemoveRemote", func(t *testing.T) {
+		cli.RunCommandWithServer(t, server, "remote", "remove", "docs").AssertSuccess(t)
+
+		config := workspace.GetConfig(t)
+		assert.NotContains(t, config, "remotes")
+		assert.Equal(t, []interface{}{"src"}, config["trackedPaths"])
+	})
+}
+
 func TestWorkspaceCreationErrorHandling(t *testing.T) {
 	t.Run("ServerNotRunning", func(t *testing.T) {
 		workDir := t.TempDir()
@@ -332,12 +332,25 @@ export async function GET(request: NextRequest) {
       );
     }
 
+    // offset and length select part of the file, as ReadFile's range does
+    const offset = Number(searchParams.get('offset') ?? 0);
+    const length = Number(searchParams.get('length') ?? 0);
+    if (!Number.isInteger(offset) || !Number.isInteger(length) || offset < 0 || length < 0) {
+      return NextResponse.json(
+        { error: 'offset and length must be non-negative integers' },
+        { status: 400 }
+      );
+    }
+    const start = Math.min(offset, content.length);
+    const end = length > 0 ? Math.min(start + length, content.length) : content.length;
+
     // Return file content as plain text
-    return new NextResponse(content, {
+    return new NextResponse(content.slice(start, end), {
       headers: {
         'Content-Type': 'text/plain; charset=utf-8',
         'X-File-Hash': 'mock-hash-' + Math.random().toString(36).substr(2, 9),
         'X-File-Size': content.length.toString(),
+        'X-File-Offset': start.toString(),
       },
     });
   } catch (error) {
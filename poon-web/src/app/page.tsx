@@ -1,6 +1,15 @@
 import { FileBrowser } from '@/components/FileBrowser';
 
-export default function Home() {
+// Links from 'poon open' name a directory (?path=) or a file (?file=), and the
+// version the workspace was synced to (?version=)
+interface HomeProps {
+  searchParams: Promise<{ path?: string; file?: string; version?: string }>;
+}
+
+export default async function Home({ searchParams }: HomeProps) {
+  const { path, file, version } = await searchParams;
+  const parsedVersion = version ? Number.parseInt(version, 10) : undefined;
+
   return (
     <div className="min-h-screen flex flex-col bg-gray-50">
       {/* Header */}
@@ -20,7 +29,11 @@ export default function Home() {
       
       {/* Main Content */}
       <main className="flex-1 max-w-6xl mx-auto w-full p-6">
-        <FileBrowser initialPath="/" />
+        <FileBrowser
+          initialPath={path || '/'}
+          initialFile={file}
+          version={Number.isNaN(parsedVersion) ? undefined : parsedVersion}
+        />
       </main>
       
       {/* Footer */}
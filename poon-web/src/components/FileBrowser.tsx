@@ -11,12 +11,20 @@ import { ErrorMessage } from './ErrorMessage';
 
 interface FileBrowserProps {
   initialPath?: string;
+  initialFile?: string; // Opened instead of initialPath, within its directory
+  version?: number;     // Version to browse; the current one when absent
 }
 
-export const FileBrowser: React.FC<FileBrowserProps> = ({ initialPath = '/' }) => {
-  const [currentPath, setCurrentPath] = useState<string>(initialPath);
+// parentPath returns the directory holding a file path
+const parentPath = (path: string): string => {
+  const parent = path.substring(0, path.lastIndexOf('/'));
+  return parent === '' ? '/' : parent;
+};
+
+export const FileBrowser: React.FC<FileBrowserProps> = ({ initialPath = '/', initialFile, version }) => {
+  const [currentPath, setCurrentPath] = useState<string>(initialFile ? parentPath(initialFile) : initialPath);
   const [items, setItems] = useState<DirectoryItem[]>([]);
-  const [selectedFile, setSelectedFile] = useState<string | null>(null);
+  const [selectedFile, setSelectedFile] = useState<string | null>(initialFile ?? null);
   const [loading, setLoading] = useState<boolean>(false);
   const [error, setError] = useState<string | null>(null);
 
@@ -26,7 +34,7 @@ export const FileBrowser: React.FC<FileBrowserProps> = ({ initialPath = '/' }) =
     setSelectedFile(null);
     
     try {
-      const response = await defaultService.readDirectory({ path });
+      const response = await defaultService.readDirectory({ path, version });
       setItems(response.items);
       setCurrentPath(path);
     } catch (err) {
@@ -58,8 +66,15 @@ export const FileBrowser: React.FC<FileBrowserProps> = ({ initialPath = '/' }) =
   };
 
   useEffect(() => {
+    if (initialFile) {
+      // Keep the file open; its directory is loaded for the way back
+      defaultService.readDirectory({ path: parentPath(initialFile), version })
+        .then((response) => setItems(response.items))
+        .catch(() => setItems([]));
+      return;
+    }
     loadDirectory(initialPath);
-  }, [initialPath]);
+  }, [initialPath, initialFile, version]);
 
   if (selectedFile) {
     return (
@@ -71,6 +86,7 @@ export const FileBrowser: React.FC<FileBrowserProps> = ({ initialPath = '/' }) =
         />
         <FileView 
           filePath={selectedFile} 
+          version={version}
           onBack={handleBackToDirectory}
         />
       </div>
@@ -4,40 +4,40 @@ import React, { useState, useEffect, useCallback } from 'react';
 import { defaultService } from '@/services/monorepoService';
 import { LoadingSpinner } from './LoadingSpinner';
 import { ErrorMessage } from './ErrorMessage';
+import { PreviewLine } from '@/proto/monorepo_pb';
 
 interface FileViewProps {
   filePath: string;
+  version?: number;
   onBack: () => void;
 }
 
-export const FileView: React.FC<FileViewProps> = ({ filePath, onBack }) => {
-  const [content, setContent] = useState<string>('');
+export const FileView: React.FC<FileViewProps> = ({ filePath, version, onBack }) => {
+  const [lines, setLines] = useState<PreviewLine[]>([]);
+  const [truncated, setTruncated] = useState<boolean>(false);
   const [loading, setLoading] = useState<boolean>(false);
   const [error, setError] = useState<string | null>(null);
   const [fileSize, setFileSize] = useState<number>(0);
   const [isText, setIsText] = useState<boolean>(true);
 
+  // The server numbers, highlights and caps the lines; the whole file is
+  // only read to download it
   const loadFile = useCallback(async () => {
     setLoading(true);
     setError(null);
     
     try {
-      const response = await defaultService.readFile({ path: filePath });
-      const decoder = new TextDecoder('utf-8');
-      const textContent = decoder.decode(response.content);
-      
-      // Simple check for binary content (presence of null bytes)
-      const isBinary = textContent.includes('\0');
-      
-      setContent(textContent);
+      const response = await defaultService.previewFile({ path: filePath, version, highlight: 'html' });
+      setLines(response.lines);
+      setTruncated(response.truncated);
       setFileSize(response.size);
-      setIsText(!isBinary);
+      setIsText(!response.binary);
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to load file');
     } finally {
       setLoading(false);
     }
-  }, [filePath]);
+  }, [filePath, version]);
 
   const getLanguageFromExtension = (filename: string): string => {
     const ext = filename.split('.').pop()?.toLowerCase();
@@ -73,8 +73,9 @@ export const FileView: React.FC<FileViewProps> = ({ filePath, onBack }) => {
     return `${(bytes / Math.pow(1024, i)).toFixed(1)} ${sizes[i]}`;
   };
 
-  const downloadFile = () => {
-    const blob = new Blob([content], { type: 'application/octet-stream' });
+  const downloadFile = async () => {
+    const response = await defaultService.readFile({ path: filePath, version });
+    const blob = new Blob([response.content], { type: 'application/octet-stream' });
     const url = URL.createObjectURL(blob);
     const a = document.createElement('a');
     a.href = url;
@@ -127,9 +128,21 @@ export const FileView: React.FC<FileViewProps> = ({ filePath, onBack }) => {
         <>
           {isText ? (
             <div className="bg-gray-50 border border-gray-200 rounded-lg overflow-hidden">
-              <pre className="p-6 overflow-x-auto text-sm font-mono leading-relaxed bg-white text-gray-900 whitespace-pre-wrap break-words">
-                <code>{content}</code>
+              <pre className="p-6 overflow-x-auto text-sm font-mono leading-relaxed bg-white text-gray-900">
+                <code>
+                  {lines.map((line) => (
+                    <div key={line.number} className="flex">
+                      <span className="w-12 pr-4 text-right text-gray-400 select-none">{line.number}</span>
+                      <span dangerouslySetInnerHTML={{ __html: line.text + (line.truncated ? ' …' : '') }} />
+                    </div>
+                  ))}
+                </code>
               </pre>
+              {truncated && (
+                <div className="px-6 py-3 text-sm text-gray-600 border-t border-gray-200">
+                  Showing the first {lines.length} lines. Download the file to see the rest.
+                </div>
+              )}
             </div>
           ) : (
             <div className="text-center py-16 bg-gray-50 border border-gray-200 rounded-lg">
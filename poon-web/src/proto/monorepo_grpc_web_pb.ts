@@ -6,6 +6,8 @@ import {
   ReadDirectoryResponse,
   ReadFileRequest,
   ReadFileResponse,
+  PreviewFileRequest,
+  PreviewFileResponse,
   FileHistoryRequest,
   FileHistoryResponse,
   BranchesRequest,
@@ -20,6 +22,7 @@ export interface MonorepoServiceClient {
   mergePatch(request: MergePatchRequest): Promise<MergePatchResponse>;
   readDirectory(request: ReadDirectoryRequest): Promise<ReadDirectoryResponse>;
   readFile(request: ReadFileRequest): Promise<ReadFileResponse>;
+  previewFile(request: PreviewFileRequest): Promise<PreviewFileResponse>;
   getFileHistory(request: FileHistoryRequest): Promise<FileHistoryResponse>;
   getBranches(request: BranchesRequest): Promise<BranchesResponse>;
   createBranch(request: CreateBranchRequest): Promise<CreateBranchResponse>;
@@ -64,11 +67,14 @@ export class MonorepoServiceClientImpl implements MonorepoServiceClient {
   async readFile(request: ReadFileRequest): Promise<ReadFileResponse> {
     const content = this.getMockFileContent(request.path);
     const contentBytes = new TextEncoder().encode(content);
+    const start = Math.min(request.offset ?? 0, contentBytes.length);
+    const end = request.length ? Math.min(start + request.length, contentBytes.length) : contentBytes.length;
     
     const mockResponse: ReadFileResponse = {
-      content: contentBytes,
+      content: contentBytes.slice(start, end),
       hash: 'mock-hash-' + Math.random().toString(36).substr(2, 9),
-      size: contentBytes.length
+      size: contentBytes.length,
+      offset: start
     };
     
     return new Promise((resolve) => {
@@ -78,6 +84,40 @@ export class MonorepoServiceClientImpl implements MonorepoServiceClient {
     });
   }
 
+  async previewFile(request: PreviewFileRequest): Promise<PreviewFileResponse> {
+    const content = this.getMockFileContent(request.path);
+    const allLines = content.replace(/\n$/, '').split('\n');
+    const from = Math.max(request.fromLine ?? 1, 1);
+    const limit = request.maxLines || 2000;
+    const to = Math.min(request.toLine || from + limit - 1, from + limit - 1);
+    const escape = (text: string) =>
+      request.highlight === 'html'
+        ? text.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&#34;')
+        : text;
+
+    const mockResponse: PreviewFileResponse = {
+      path: request.path,
+      version: request.version ?? 1,
+      hash: 'mock-hash-' + Math.random().toString(36).substr(2, 9),
+      size: new TextEncoder().encode(content).length,
+      totalLines: allLines.length,
+      lines: allLines.slice(from - 1, to).map((text, i) => ({
+        number: from + i,
+        text: escape(text),
+        truncated: false,
+      })),
+      truncated: (!request.toLine || request.toLine > to) && allLines.length > to,
+      binary: false,
+      language: '',
+    };
+
+    return new Promise((resolve) => {
+      setTimeout(() => {
+        resolve(mockResponse);
+      }, 50 + Math.random() * 150);
+    });
+  }
+
   async getFileHistory(request: FileHistoryRequest): Promise<FileHistoryResponse> {
     const mockResponse: FileHistoryResponse = {
       commits: [
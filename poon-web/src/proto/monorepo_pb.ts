@@ -19,6 +19,7 @@ export interface ReadDirectoryRequest {
   path: string;
   branch?: string;
   recursive?: boolean;
+  version?: number; // 0 or absent for the current version
 }
 
 export interface ReadDirectoryResponse {
@@ -37,12 +38,44 @@ export interface ReadFileRequest {
   path: string;
   branch?: string;
   revision?: string;
+  version?: number; // 0 or absent for the current version
+  offset?: number; // First byte to read
+  length?: number; // Bytes to read, 0 or absent for the rest of the file
 }
 
 export interface ReadFileResponse {
   content: Uint8Array;
   hash: string;
+  size: number; // Size of the whole file
+  offset?: number; // Offset of content within the file
+}
+
+export interface PreviewFileRequest {
+  path: string;
+  version?: number; // 0 or absent for the current version
+  fromLine?: number; // First line to return, from 1
+  toLine?: number; // Last line to return; 0 or absent for as many as maxLines allows
+  maxLines?: number;
+  highlight?: 'none' | 'html' | 'ansi';
+  language?: string; // Absent to detect from the file name
+}
+
+export interface PreviewLine {
+  number: number;
+  text: string; // Rendered as asked; HTML is escaped
+  truncated: boolean;
+}
+
+export interface PreviewFileResponse {
+  path: string;
+  version: number;
+  hash: string;
   size: number;
+  totalLines: number;
+  lines: PreviewLine[];
+  truncated: boolean; // Lines in the requested range were left out at the cap
+  binary: boolean;
+  language: string;
 }
 
 export interface FileHistoryRequest {